@@ -0,0 +1,150 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/audit"
+	"github.com/gojue/moling/pkg/server"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Search and export the audit trail of the tool calls",
+	Long: `Search and export the record of the tool calls kept in data/audit in the base path when the audit section of
+the configuration is enabled. The trail of a running server can be read at any time.
+    moling audit search --tool 'browser_*' --since 24h      Print the browser calls of the last day
+    moling audit search --status error --json              Print the failed calls as JSON
+    moling audit export --format csv --since 2026-10-01 -o october.csv
+    moling audit export --format jsonl --principal ci      Print the calls of a client as JSON Lines
+`,
+}
+
+var (
+	auditQuery  audit.Query
+	auditStatus string
+	auditSince  string
+	auditUntil  string
+	auditJSON   bool
+	auditFormat string
+	auditOutput string
+)
+
+// auditEntries returns the entries selected by the flags, the newest first.
+func auditEntries(limit int) ([]audit.Entry, error) {
+	q := auditQuery
+	q.Status = audit.Status(auditStatus)
+	q.Limit = limit
+	now := time.Now()
+	var err error
+	if auditSince != "" {
+		if q.Since, err = audit.ParseTime(auditSince, now); err != nil {
+			return nil, err
+		}
+	}
+	if auditUntil != "" {
+		if q.Until, err = audit.ParseTime(auditUntil, now); err != nil {
+			return nil, err
+		}
+	}
+	return audit.Search(filepath.Join(mlConfig.BasePath, server.AuditDir), q)
+}
+
+// AuditSearchCommandFunc executes the "audit search" command.
+func AuditSearchCommandFunc(command *cobra.Command, args []string) error {
+	entries, err := auditEntries(auditQuery.Limit)
+	if err != nil {
+		return err
+	}
+	if auditJSON {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		return printJSON(command.OutOrStdout(), entries)
+	}
+	tw := tabwriter.NewWriter(command.OutOrStdout(), 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TIME\tSERVICE\tTOOL\tPRINCIPAL\tSESSION\tSTATUS\tDURATION\tERROR")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%dms\t%s\n", e.Time.Local().Format(time.DateTime), e.Service, e.Tool,
+			e.Principal, e.Session, e.Status, e.Duration, shortDescription(e.Error, toolsDescriptionWidth))
+	}
+	return tw.Flush()
+}
+
+// AuditExportCommandFunc executes the "audit export" command.
+func AuditExportCommandFunc(command *cobra.Command, args []string) error {
+	if auditFormat != "csv" && auditFormat != "jsonl" {
+		return fmt.Errorf("unknown format %s, use csv or jsonl", auditFormat)
+	}
+	entries, err := auditEntries(0)
+	if err != nil {
+		return err
+	}
+	// Exports read like the trail, the oldest call first
+	slices.Reverse(entries)
+	var w io.Writer = command.OutOrStdout()
+	if auditOutput != "" && auditOutput != "-" {
+		f, err := os.OpenFile(auditOutput, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if auditFormat == "csv" {
+		err = audit.WriteCSV(w, entries)
+	} else {
+		err = audit.WriteJSONL(w, entries)
+	}
+	if err != nil {
+		return err
+	}
+	if auditOutput != "" && auditOutput != "-" {
+		_, _ = fmt.Fprintf(command.ErrOrStderr(), "exported %d calls to %s\n", len(entries), auditOutput)
+	}
+	return nil
+}
+
+func init() {
+	searchCmd := &cobra.Command{Use: "search", Short: "Print the recorded tool calls, the newest first", Args: cobra.NoArgs, RunE: AuditSearchCommandFunc}
+	exportCmd := &cobra.Command{Use: "export", Short: "Export the recorded tool calls as CSV or JSON Lines, the oldest first", Args: cobra.NoArgs, RunE: AuditExportCommandFunc}
+	for _, c := range []*cobra.Command{searchCmd, exportCmd} {
+		c.Flags().StringVar(&auditQuery.Service, "service", "", "Only calls of this service, e.g. FileSystem")
+		c.Flags().StringVar(&auditQuery.Tool, "tool", "", "Only calls of this tool, or of the tools matching a pattern such as browser_*")
+		c.Flags().StringVar(&auditQuery.Session, "session", "", "Only calls of this client session")
+		c.Flags().StringVar(&auditQuery.Principal, "principal", "", "Only calls of this authenticated client")
+		c.Flags().StringVar(&auditQuery.Text, "text", "", "Only calls whose arguments or error contain this text")
+		c.Flags().StringVar(&auditStatus, "status", "", "Only calls with this outcome: ok or error")
+		c.Flags().StringVar(&auditSince, "since", "", "Only calls since this time: RFC 3339, a day such as 2026-10-16, or a duration such as 24h")
+		c.Flags().StringVar(&auditUntil, "until", "", "Only calls until this time, in the same formats as --since")
+	}
+	searchCmd.Flags().IntVarP(&auditQuery.Limit, "limit", "n", 50, "Maximum number of calls, 0 for all")
+	searchCmd.Flags().BoolVar(&auditJSON, "json", false, "Print the calls as JSON")
+	exportCmd.Flags().StringVar(&auditFormat, "format", "csv", "Format of the export: csv or jsonl")
+	exportCmd.Flags().StringVarP(&auditOutput, "output", "o", "", "File to write the export to, default the standard output")
+	auditCmd.AddCommand(searchCmd, exportCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services"
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call <service.tool>",
+	Short: "Call a tool from the command line and print its result",
+	Long: `Start the service of a tool locally, call the tool the way an MCP client does and print its result, then exit.
+The exit code is not 0 if the tool reports an error, so that scripts can check the outcome.
+    moling call FileSystem.list_directory --args '{"path":"."}'
+    moling call Weather.weather_forecast --args '{"city":"Paris"}' --json
+    echo '{"url":"https://example.com"}' | moling call Browser.browser_navigate --args -
+    moling call read_file -m FileSystem --args '{"path":"notes.txt"}'
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: CallCommandFunc,
+}
+
+var (
+	callArgs string
+	callJSON bool
+)
+
+// splitToolName splits service.tool into the service, as registered, and the tool. The service is empty if the
+// name has none.
+func splitToolName(name string) (comm.MoLingServerType, string, error) {
+	service, tool, ok := strings.Cut(name, ".")
+	if !ok {
+		return "", name, nil
+	}
+	for srvName := range services.ServiceList() {
+		if strings.EqualFold(string(srvName), service) {
+			return srvName, tool, nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown service %s", service)
+}
+
+// parseCallArgs parses the arguments of the tool call, read from in if they are -.
+func parseCallArgs(raw string, in io.Reader) (map[string]any, error) {
+	if raw == "-" {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		raw = string(data)
+	}
+	args := make(map[string]any)
+	if strings.TrimSpace(raw) == "" {
+		return args, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("--args must be a JSON object: %w", err)
+	}
+	return args, nil
+}
+
+// printCallResult prints the content of a tool result, the text as is and a summary of the other content.
+func printCallResult(w io.Writer, result *mcp.CallToolResult) {
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			_, _ = fmt.Fprintln(w, c.Text)
+		case mcp.ImageContent:
+			_, _ = fmt.Fprintf(w, "[image %s, %d bytes of base64]\n", c.MIMEType, len(c.Data))
+		case mcp.AudioContent:
+			_, _ = fmt.Fprintf(w, "[audio %s, %d bytes of base64]\n", c.MIMEType, len(c.Data))
+		case mcp.EmbeddedResource:
+			if text, ok := c.Resource.(mcp.TextResourceContents); ok {
+				_, _ = fmt.Fprintln(w, text.Text)
+				continue
+			}
+			data, _ := json.Marshal(c.Resource)
+			_, _ = fmt.Fprintf(w, "[resource %s]\n", data)
+		default:
+			data, _ := json.Marshal(c)
+			_, _ = fmt.Fprintln(w, string(data))
+		}
+	}
+}
+
+// startLocalServer loads the configuration and the services of mlConfig.Module like the server does, and returns a
+// server that serves no transport, for commands that call it directly. Stop it with stopLocalServer.
+func startLocalServer(ctx context.Context) (*server.MoLingServer, zerolog.Logger, error) {
+	logger := initLogger(mlConfig.BasePath)
+	mlConfig.SetLogger(logger)
+	configFile, err := loadConfigFile()
+	if err != nil {
+		return nil, logger, fmt.Errorf("error loading config file: %w", err)
+	}
+	configFile, err = applyProfile(configFile)
+	if err != nil {
+		return nil, logger, err
+	}
+	loader := newConfigLoader(configFile, logger)
+	if err = loadGlobalConfig(loader); err != nil {
+		return nil, logger, err
+	}
+	logger = configureLogger()
+	mlConfig.SetLogger(logger)
+	registerPlugins(logger)
+
+	ctx = context.WithValue(ctx, comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
+	srvs, _, failed := loadServices(ctx, loader, logger)
+	if err = loader.err(); err != nil {
+		for _, srv := range srvs {
+			_ = srv.Close()
+		}
+		return nil, logger, err
+	}
+	srv, err := server.NewMoLingServer(ctx, srvs, *mlConfig)
+	if err != nil {
+		return nil, logger, err
+	}
+	srv.SetFailedServices(failed)
+	return srv, logger, nil
+}
+
+// stopLocalServer shuts down a server started by startLocalServer.
+func stopLocalServer(srv *server.MoLingServer, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(mlConfig.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("failed to shut down cleanly")
+	}
+	_ = logWriter.Sync()
+}
+
+// CallCommandFunc executes the "call" command.
+func CallCommandFunc(command *cobra.Command, args []string) error {
+	service, tool, err := splitToolName(args[0])
+	if err != nil {
+		return err
+	}
+	if service != "" {
+		mlConfig.Module = string(service)
+	}
+	toolArgs, err := parseCallArgs(callArgs, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	// The first signal cancels the tool call
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	srv, logger, err := startLocalServer(ctx)
+	if err != nil {
+		return err
+	}
+	defer stopLocalServer(srv, logger)
+
+	logger.Info().Str("tool", tool).Str("module", mlConfig.Module).Msg("calling tool from the command line")
+	result, err := srv.CallTool(ctx, tool, toolArgs)
+	if err != nil {
+		return err
+	}
+	if callJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(command.OutOrStdout(), string(data))
+	} else {
+		printCallResult(command.OutOrStdout(), result)
+	}
+	if result.IsError {
+		return fmt.Errorf("the tool %s reported an error", tool)
+	}
+	return nil
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callArgs, "args", "{}", "Arguments of the tool call as a JSON object, - to read them from the standard input")
+	callCmd.Flags().BoolVar(&callJSON, "json", false, "Print the result of the tool call as JSON")
+	rootCmd.AddCommand(callCmd)
+}
@@ -21,7 +21,10 @@
 package cmd
 
 import (
+	"bufio"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -34,9 +37,12 @@ var clientCmd = &cobra.Command{
 	Use:   "client",
 	Short: "Provides automated access to MoLing MCP Server for local MCP clients, Cline, Roo Code, and Claude, etc.",
 	Long: `Automatically checks the MCP clients installed on the current computer, displays them, and automatically adds the MoLing MCP Server configuration to enable one-click activation, reducing the hassle of manual configuration.
-Currently supports the following clients: Cline, Roo Code, Claude
+Currently supports the following clients: Claude, Cursor, Windsurf, Cline, Roo Code, Trae
     moling client -l --list   List the current installed MCP clients
     moling client -i --install Add MoLing MCP Server configuration to the currently installed MCP clients on this computer
+    moling client list                 List the MCP clients and whether MoLing is configured in them
+    moling client install Claude       Show the change to the configuration of Claude, then write it after a backup
+    moling client uninstall --yes      Remove MoLing from all MCP clients without asking
 `,
 	RunE: ClientCommandFunc,
 }
@@ -44,6 +50,9 @@ Currently supports the following clients: Cline, Roo Code, Claude
 var (
 	list    bool
 	install bool
+
+	clientYes    bool
+	clientDryRun bool
 )
 
 // ClientCommandFunc executes the "config" command.
@@ -73,8 +82,112 @@ func ClientCommandFunc(command *cobra.Command, args []string) error {
 	return nil
 }
 
+// newClientManager returns the client manager with the configuration of this MoLing executable. The services
+// given with --module are passed on to the clients.
+func newClientManager() *client.Manager {
+	logger := initLogger(mlConfig.BasePath)
+	mlConfig.SetLogger(logger)
+	mcpConfig := client.NewMCPServerConfig(CliDescription, CliName, MCPServerName)
+	if exePath, err := os.Executable(); err == nil {
+		mcpConfig.Command = exePath
+	}
+	mcpConfig.Args = nil
+	if mlConfig.Module != "all" {
+		mcpConfig.Args = []string{"-m", mlConfig.Module}
+	}
+	return client.NewManager(logger, mcpConfig)
+}
+
+// ClientListCommandFunc executes the "client list" command.
+func ClientListCommandFunc(command *cobra.Command, args []string) error {
+	for _, s := range newClientManager().Status() {
+		status := "not found"
+		switch {
+		case s.Installed:
+			status = "installed"
+		case s.Detected:
+			status = "not installed"
+		}
+		fmt.Printf("%-16s %-14s %s\n", s.Name, status, s.Path)
+	}
+	return nil
+}
+
+// ClientChangeCommandFunc returns the function of the "client install" and "client uninstall" commands. Each change
+// is shown as a diff and written after confirmation, with a backup of the file.
+func ClientChangeCommandFunc(installing bool) func(command *cobra.Command, args []string) error {
+	return func(command *cobra.Command, args []string) error {
+		cm := newClientManager()
+		var names []string
+		for _, arg := range args {
+			name, ok := cm.Find(arg)
+			if !ok {
+				return fmt.Errorf("unknown MCP client %s, run moling client list to see them", arg)
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			// Without names, install into the detected clients, and uninstall from the ones that have MoLing.
+			for _, s := range cm.Status() {
+				if (installing && s.Detected) || (!installing && s.Installed) {
+					names = append(names, s.Name)
+				}
+			}
+			if len(names) == 0 {
+				fmt.Println("No MCP client to change.")
+				return nil
+			}
+		}
+		in := bufio.NewReader(os.Stdin)
+		for _, name := range names {
+			plan := cm.PlanUninstall
+			if installing {
+				plan = cm.PlanInstall
+			}
+			ch, err := plan(name)
+			if err != nil {
+				return err
+			}
+			if ch.Empty() {
+				fmt.Printf("%s: %s is up to date\n", name, ch.Path)
+				continue
+			}
+			fmt.Printf("%s:\n%s", name, ch.Diff())
+			if clientDryRun {
+				continue
+			}
+			if !clientYes {
+				fmt.Printf("Write %s? (y/N): ", ch.Path)
+				answer, _ := in.ReadString('\n')
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					fmt.Println("Skipped.")
+					continue
+				}
+			}
+			backup, err := cm.Apply(ch)
+			if err != nil {
+				return err
+			}
+			if backup != "" {
+				fmt.Printf("Written, the previous file is saved as %s\n", backup)
+			} else {
+				fmt.Printf("Created %s\n", ch.Path)
+			}
+		}
+		return nil
+	}
+}
+
 func init() {
 	clientCmd.PersistentFlags().BoolVar(&list, "list", false, "List the current installed MCP clients")
 	clientCmd.PersistentFlags().BoolVarP(&install, "install", "i", false, "Add MoLing MCP Server configuration to the currently installed MCP clients on this computer. default is all")
+	listCmd := &cobra.Command{Use: "list", Short: "List the MCP clients and whether MoLing is configured in them", Args: cobra.NoArgs, RunE: ClientListCommandFunc}
+	installCmd := &cobra.Command{Use: "install [client...]", Short: "Add MoLing to the configuration of MCP clients, all detected ones by default", RunE: ClientChangeCommandFunc(true)}
+	uninstallCmd := &cobra.Command{Use: "uninstall [client...]", Short: "Remove MoLing from the configuration of MCP clients", RunE: ClientChangeCommandFunc(false)}
+	for _, c := range []*cobra.Command{installCmd, uninstallCmd} {
+		c.Flags().BoolVarP(&clientYes, "yes", "y", false, "Write the changes without asking")
+		c.Flags().BoolVar(&clientDryRun, "dry-run", false, "Only show the changes")
+	}
+	clientCmd.AddCommand(listCmd, installCmd, uninstallCmd)
 	rootCmd.AddCommand(clientCmd)
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
 	"github.com/gojue/moling/pkg/services"
 )
 
@@ -36,6 +37,11 @@ var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Show the configuration of the current service list",
 	Long: `Show the configuration of the current service list. You can refer to the configuration file to modify the configuration.
+    moling config show                        Print the effective configuration
+    moling config get Log.max_entries         Print the effective value of a key
+    moling config set Log.max_entries 100     Set a key in the configuration file
+    moling config validate                    Check the configuration file and the MOLING_* variables
+    moling config diff                        List the keys that differ from the defaults
 `,
 	RunE: ConfigCommandFunc,
 }
@@ -48,7 +54,7 @@ var (
 func ConfigCommandFunc(command *cobra.Command, args []string) error {
 	var err error
 	logger := initLogger(mlConfig.BasePath)
-	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	consoleWriter := zerolog.ConsoleWriter{Out: config.NewSecretMaskWriter(os.Stdout), TimeFormat: time.RFC3339}
 	multi := zerolog.MultiLevelWriter(consoleWriter, logger)
 	logger = zerolog.New(multi).With().Timestamp().Logger()
 	mlConfig.SetLogger(logger)
@@ -57,19 +63,14 @@ func ConfigCommandFunc(command *cobra.Command, args []string) error {
 	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
 
 	// 当前配置文件检测
-	hasConfig := false
-	var nowConfig []byte
-	nowConfigJSON := make(map[string]any)
-	configFilePath := filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)
-	if nowConfig, err = os.ReadFile(configFilePath); err == nil {
-		hasConfig = true
-	}
-	if hasConfig {
-		err = json.Unmarshal(nowConfig, &nowConfigJSON)
-		if err != nil {
-			return fmt.Errorf("error unmarshaling JSON: %w, payload:%s", err, string(nowConfig))
-		}
+	configFile, err := loadConfigFile()
+	if err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
 	}
+	configFilePath := configFile.Path
+	_, err = os.Stat(configFilePath)
+	hasConfig := err == nil
+	loader := newConfigLoader(configFile, logger)
 
 	bf := bytes.Buffer{}
 	bf.WriteString("\n{\n")
@@ -80,18 +81,18 @@ func ConfigCommandFunc(command *cobra.Command, args []string) error {
 		return fmt.Errorf("error marshaling GlobalConfig: %w", err)
 	}
 	bf.WriteString("\t\"MoLingConfig\":\n")
-	bf.WriteString(fmt.Sprintf("\t%s,\n", mlConfigJSON))
+	bf.WriteString(fmt.Sprintf("\t%s,\n", config.MaskSecrets(string(mlConfigJSON))))
 	first := true
+	registerPlugins(logger)
 	for srvName, nsv := range services.ServiceList() {
-		// 获取服务对应的配置
-		cfg, ok := nowConfigJSON[string(srvName)].(map[string]any)
-
 		srv, err := nsv(ctx)
 		if err != nil {
 			return err
 		}
+		// 获取服务对应的配置
+		cfg := loader.section([]string{string(srvName)}, srv.Config())
 		// srv Loadconfig
-		if ok {
+		if cfg != nil {
 			err = srv.LoadConfig(cfg)
 			if err != nil {
 				return fmt.Errorf("error loading config for service %s: %w", srv.Name(), err)
@@ -108,10 +109,14 @@ func ConfigCommandFunc(command *cobra.Command, args []string) error {
 			bf.WriteString(",\n")
 		}
 		bf.WriteString(fmt.Sprintf("\t\"%s\":\n", srv.Name()))
-		bf.WriteString(fmt.Sprintf("\t%s\n", srv.Config()))
+		bf.WriteString(fmt.Sprintf("\t%s\n", config.MaskSecrets(srv.Config())))
 		first = false
 	}
 	bf.WriteString("}\n")
+	err = loader.err()
+	if err != nil {
+		return err
+	}
 	// 解析原始 JSON 字符串
 	var data any
 	err = json.Unmarshal(bf.Bytes(), &data)
@@ -0,0 +1,415 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+)
+
+var (
+	configFormat string
+)
+
+// configContext returns the context services are created with, and a logger that also prints warnings to stderr,
+// so that the output of the config commands stays clean for scripts.
+func configContext() (context.Context, zerolog.Logger) {
+	logger := initLogger(mlConfig.BasePath)
+	console := &zerolog.FilteredLevelWriter{
+		Writer: zerolog.LevelWriterAdapter{Writer: zerolog.ConsoleWriter{Out: config.NewSecretMaskWriter(os.Stderr), TimeFormat: time.RFC3339}},
+		Level:  zerolog.WarnLevel,
+	}
+	logger = zerolog.New(zerolog.MultiLevelWriter(logger, console)).With().Timestamp().Logger()
+	mlConfig.SetLogger(logger)
+	registerPlugins(logger)
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
+	return ctx, logger
+}
+
+// serviceNames returns the names of the registered services in order.
+func serviceNames() []comm.MoLingServerType {
+	names := make([]comm.MoLingServerType, 0, len(services.ServiceList()))
+	for name := range services.ServiceList() {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// jsonValue converts a config struct or the JSON of Service.Config to the types of encoding/json, with the resolved
+// secrets masked.
+func jsonValue(v any) (any, error) {
+	data, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		data = string(b)
+	}
+	var value any
+	err := json.Unmarshal([]byte(config.MaskSecrets(data)), &value)
+	return value, err
+}
+
+// configDefaults returns the default configuration of every section. It must be called before the configuration
+// file is loaded into mlConfig.
+func configDefaults(ctx context.Context) (map[string]any, error) {
+	global := make(map[string]any)
+	for _, s := range globalSections() {
+		value, err := jsonValue(s.target)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling %s config: %w", s.name, err)
+		}
+		global[s.name] = value
+	}
+	defaults := map[string]any{mlConfigSection: global}
+	for _, name := range serviceNames() {
+		srv, err := services.ServiceList()[name](ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating service %s: %w", name, err)
+		}
+		if defaults[string(name)], err = jsonValue(srv.Config()); err != nil {
+			return nil, fmt.Errorf("error reading the config of service %s: %w", name, err)
+		}
+	}
+	return defaults, nil
+}
+
+// loadEffectiveConfig returns the configuration the server would run with: the defaults of every section with the
+// configuration file and, if env is set, the environment overrides merged in. Services are not initialized. All
+// invalid values are reported together.
+func loadEffectiveConfig(ctx context.Context, file *config.File, logger zerolog.Logger, env bool) (map[string]any, error) {
+	loader := newConfigLoader(file, logger)
+	if !env {
+		loader.overrides = nil
+	}
+	var errs []error
+	if err := loadGlobalConfig(loader); err != nil {
+		errs = append(errs, err)
+	}
+	global := make(map[string]any)
+	for _, s := range globalSections() {
+		value, err := jsonValue(s.target)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling %s config: %w", s.name, err)
+		}
+		global[s.name] = value
+	}
+	values := map[string]any{mlConfigSection: global}
+	for _, name := range serviceNames() {
+		srv, err := services.ServiceList()[name](ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating service %s: %w", name, err)
+		}
+		cfg := loader.section([]string{string(name)}, srv.Config())
+		if cfg != nil {
+			if err = srv.LoadConfig(cfg); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		if values[string(name)], err = jsonValue(srv.Config()); err != nil {
+			return nil, fmt.Errorf("error reading the config of service %s: %w", name, err)
+		}
+	}
+	if err := loader.err(); err != nil {
+		errs = append([]error{err}, errs...)
+	}
+	return values, errors.Join(errs...)
+}
+
+// resolveKey returns the path of a dotted key with the section name as it is registered, such as
+// [Log max_entries] for log.max_entries.
+func resolveKey(key string, sections map[string]any) ([]string, error) {
+	path := config.SplitKey(key)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty key")
+	}
+	for name := range sections {
+		if strings.EqualFold(name, path[0]) {
+			path[0] = name
+			return path, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown section %s, run moling config show to list them", path[0])
+}
+
+// printValue prints scalars as they are, and objects and arrays in the format of the configuration file.
+func printValue(value any, format string) error {
+	switch v := value.(type) {
+	case string:
+		fmt.Println(v)
+		return nil
+	case map[string]any:
+		data, err := config.Marshal(format, v)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// outputFormat returns the format of --format, or of the configuration file if it is not given.
+func outputFormat(file *config.File) (string, error) {
+	if configFormat == "" {
+		return file.Format, nil
+	}
+	format := strings.ToLower(configFormat)
+	if format == "yml" {
+		format = config.FormatYAML
+	}
+	if _, err := config.Marshal(format, nil); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// ConfigShowCommandFunc executes the "config show" command.
+func ConfigShowCommandFunc(command *cobra.Command, args []string) error {
+	return withEffectiveConfig(args, func(file *config.File, values any) error {
+		format, err := outputFormat(file)
+		if err != nil {
+			return err
+		}
+		return printValue(values, format)
+	})
+}
+
+// ConfigGetCommandFunc executes the "config get" command.
+func ConfigGetCommandFunc(command *cobra.Command, args []string) error {
+	return withEffectiveConfig(args, func(file *config.File, value any) error {
+		format, err := outputFormat(file)
+		if err != nil {
+			return err
+		}
+		return printValue(value, format)
+	})
+}
+
+// loadProfileFile loads the configuration file with the profile of --profile merged in.
+func loadProfileFile() (*config.File, error) {
+	file, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return applyProfile(file)
+}
+
+// withEffectiveConfig loads the effective configuration and calls fn with the value of the key in args, or with
+// the whole configuration if there is none.
+func withEffectiveConfig(args []string, fn func(file *config.File, value any) error) error {
+	ctx, logger := configContext()
+	file, err := loadProfileFile()
+	if err != nil {
+		return err
+	}
+	values, err := loadEffectiveConfig(ctx, file, logger, true)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fn(file, values)
+	}
+	path, err := resolveKey(args[0], values)
+	if err != nil {
+		return err
+	}
+	value, ok := config.Lookup(values, path)
+	if !ok {
+		return fmt.Errorf("unknown key %s", args[0])
+	}
+	return fn(file, value)
+}
+
+// ConfigSetCommandFunc executes the "config set" command. The value is parsed as the type of the key, and the
+// configuration file is only written if the whole configuration is still valid.
+func ConfigSetCommandFunc(command *cobra.Command, args []string) error {
+	ctx, logger := configContext()
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	defaults, err := configDefaults(ctx)
+	if err != nil {
+		return err
+	}
+	path, err := resolveKey(args[0], defaults)
+	if err != nil {
+		return err
+	}
+	if len(path) < 2 {
+		return fmt.Errorf("%s is a section, set one of its keys such as %s.<key>", path[0], path[0])
+	}
+	schema, err := config.InferSchema(defaults[path[0]])
+	if err != nil {
+		return err
+	}
+	// With --profile the key is set in the profile, not in the sections shared by all profiles.
+	parent, prefix := file.Data, ""
+	if mlConfig.Profile != "" {
+		profiles, err := file.Profiles()
+		if err != nil {
+			return err
+		}
+		p, err := findProfile(profiles, mlConfig.Profile)
+		if err != nil {
+			return err
+		}
+		parent = file.Data[config.ProfilesKey].(map[string]any)[p.Name].(map[string]any)
+		prefix = config.ProfilesKey + "." + p.Name + "."
+	}
+	section, _ := parent[path[0]].(map[string]any)
+	if section == nil {
+		section = make(map[string]any)
+		parent[path[0]] = section
+	}
+	if err = schema.Set(section, path[1:], args[1]); err != nil {
+		return fmt.Errorf("%s: %w", path[0], err)
+	}
+	// The environment is left out, the file must be valid on its own.
+	if err = validateFile(ctx, file, logger, false); err != nil {
+		return fmt.Errorf("%s was not changed: %w", file.Path, err)
+	}
+	if err = file.Save(); err != nil {
+		return fmt.Errorf("error writing %s: %w", file.Path, err)
+	}
+	fmt.Printf("%s%s set in %s\n", prefix, config.PathString(path), file.Path)
+	return nil
+}
+
+// ConfigValidateCommandFunc executes the "config validate" command.
+func ConfigValidateCommandFunc(command *cobra.Command, args []string) error {
+	ctx, logger := configContext()
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	if err = validateFile(ctx, file, logger, true); err != nil {
+		return err
+	}
+	fmt.Printf("%s is valid\n", file.Path)
+	return nil
+}
+
+// validateFile checks the configuration file on its own and with each of its profiles merged in.
+func validateFile(ctx context.Context, file *config.File, logger zerolog.Logger, env bool) error {
+	if _, err := loadEffectiveConfig(ctx, file, logger, env); err != nil {
+		return err
+	}
+	profiles, err := file.Profiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range config.ProfileNames(profiles) {
+		if _, err = loadEffectiveConfig(ctx, file.WithProfile(profiles[name]), logger, env); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ConfigDiffCommandFunc executes the "config diff" command, which lists the keys whose effective value differs
+// from the default.
+func ConfigDiffCommandFunc(command *cobra.Command, args []string) error {
+	ctx, logger := configContext()
+	file, err := loadProfileFile()
+	if err != nil {
+		return err
+	}
+	defaults, err := configDefaults(ctx)
+	if err != nil {
+		return err
+	}
+	values, err := loadEffectiveConfig(ctx, file, logger, true)
+	if err != nil {
+		return err
+	}
+	changes := config.Diff(nil, defaults, values)
+	if len(changes) == 0 {
+		fmt.Println("The configuration does not differ from the defaults.")
+		return nil
+	}
+	for _, c := range changes {
+		from, _ := json.Marshal(c.Default)
+		to, _ := json.Marshal(c.Value)
+		fmt.Printf("%s\n  - %s\n  + %s\n", c.Path, from, to)
+	}
+	return nil
+}
+
+func init() {
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration: the defaults with the configuration file and the MOLING_* environment variables merged in",
+		Args:  cobra.NoArgs,
+		RunE:  ConfigShowCommandFunc,
+	}
+	getCmd := &cobra.Command{
+		Use:     "get <key>",
+		Short:   "Print the effective value of a key, e.g. Log.max_entries or MoLingConfig.limits",
+		Args:    cobra.ExactArgs(1),
+		RunE:    ConfigGetCommandFunc,
+		Example: "  moling config get Command.allowed_commands",
+	}
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key in the configuration file, checking the value against the type of the key",
+		Long: `Set a key in the configuration file. The value is parsed as the type of the key: arrays are JSON or comma-separated, objects are JSON.
+The file is not written if the configuration would be invalid. Comments of YAML and TOML files are not kept.
+`,
+		Args:    cobra.ExactArgs(2),
+		RunE:    ConfigSetCommandFunc,
+		Example: "  moling config set Log.max_entries 100\n  moling config set Command.allowed_commands ls,cat,git",
+	}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration file and the MOLING_* environment variables",
+		Args:  cobra.NoArgs,
+		RunE:  ConfigValidateCommandFunc,
+	}
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "List the keys whose effective value differs from the default",
+		Args:  cobra.NoArgs,
+		RunE:  ConfigDiffCommandFunc,
+	}
+	for _, c := range []*cobra.Command{showCmd, getCmd} {
+		c.Flags().StringVar(&configFormat, "format", "", "Output format of objects: json, yaml or toml, default: the format of the configuration file")
+	}
+	configCmd.AddCommand(showCmd, getCmd, setCmd, validateCmd, diffCmd)
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// mlConfigSection is the top-level key of the global configuration in the configuration file.
+const mlConfigSection = "MoLingConfig"
+
+// loadConfigFile finds and reads the configuration file, which may be JSON, YAML or TOML: config.yaml is used if
+// config.json does not exist. A missing file is an empty configuration.
+func loadConfigFile() (*config.File, error) {
+	path := config.FindFile(filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile))
+	if rel, err := filepath.Rel(mlConfig.BasePath, path); err == nil {
+		mlConfig.ConfigFile = rel
+	}
+	f, err := config.LoadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		format, _ := config.FileFormat(path)
+		return &config.File{Path: path, Format: format, Data: make(map[string]any)}, nil
+	}
+	return f, err
+}
+
+// configLoader checks the sections of the configuration file against the schema of their defaults, applies
+// the MOLING_* environment overrides to them and resolves their secret references, such as ${env:TOKEN}.
+type configLoader struct {
+	file      *config.File
+	overrides []config.EnvOverride
+	used      map[string]bool
+	errs      []error
+	logger    zerolog.Logger
+}
+
+func newConfigLoader(file *config.File, logger zerolog.Logger) *configLoader {
+	return &configLoader{
+		file:      file,
+		overrides: config.EnvOverrides(os.Environ()),
+		used:      make(map[string]bool),
+		logger:    logger,
+	}
+}
+
+// section returns the section at path, such as [Log] or [MoLingConfig limits], with the environment overrides
+// applied. It returns nil if the file has no such section and no variable overrides it, or if the section is
+// invalid, which err reports. The keys of the global
+// sections can be overridden without MOLINGCONFIG__, as in MOLING_LIMITS__QPS.
+func (cl *configLoader) section(path []string, defaults any) map[string]any {
+	var value any = cl.file.Data
+	for _, key := range path {
+		if m, ok := value.(map[string]any); ok {
+			value = m[key]
+		} else {
+			value = nil
+		}
+	}
+	schema, err := config.InferSchema(defaults)
+	if err != nil {
+		cl.errs = append(cl.errs, fmt.Errorf("%s: %w", config.PathString(path), err))
+		return nil
+	}
+	invalid := false
+	for _, e := range schema.Validate(cl.file, path, value) {
+		if e.Unknown {
+			cl.logger.Warn().Str("file", cl.file.Path).Msg(e.Error())
+			continue
+		}
+		cl.errs = append(cl.errs, e)
+		invalid = true
+	}
+	section, _ := value.(map[string]any)
+
+	prefixes := [][]string{path}
+	if len(path) > 1 && path[0] == mlConfigSection {
+		prefixes = append(prefixes, path[1:])
+	}
+	for _, o := range cl.overrides {
+		for _, prefix := range prefixes {
+			if !hasKeyPrefix(o.Path, prefix) {
+				continue
+			}
+			if section == nil {
+				section = make(map[string]any)
+			}
+			cl.used[o.Name] = true
+			if err := schema.Set(section, o.Path[len(prefix):], o.Value); err != nil {
+				cl.errs = append(cl.errs, fmt.Errorf("%s: %w", o.Name, err))
+				invalid = true
+				break
+			}
+			cl.logger.Info().Str("variable", o.Name).Msg("configuration overridden by the environment")
+			break
+		}
+	}
+	if invalid {
+		// The errors are reported by err, the section is not loaded half-way.
+		return nil
+	}
+	if section == nil {
+		return nil
+	}
+	resolved, err := config.ResolveSecrets(path, section)
+	if err != nil {
+		cl.errs = append(cl.errs, err)
+		return nil
+	}
+	return resolved
+}
+
+// hasKeyPrefix reports whether the keys of path start with the keys of prefix, ignoring case.
+func hasKeyPrefix(path, prefix []string) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+	for i, key := range prefix {
+		if !strings.EqualFold(path[i], key) {
+			return false
+		}
+	}
+	return true
+}
+
+// err reports the invalid values of all sections, and warns about environment variables and sections that
+// match nothing.
+func (cl *configLoader) err() error {
+	for _, o := range cl.overrides {
+		if !cl.used[o.Name] {
+			cl.logger.Warn().Str("variable", o.Name).Msg("the environment variable matches no loaded configuration section")
+		}
+	}
+	var unknown []string
+	for name := range cl.file.Data {
+		_, known := services.ServiceList()[comm.MoLingServerType(name)]
+		if !known && name != mlConfigSection && name != config.ProfilesKey {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		cl.logger.Warn().Str("file", cl.file.Path).Str("section", name).Msg("the configuration file has a section no service uses")
+	}
+	if len(cl.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(cl.errs...))
+}
+
+// applyEnvFlags sets the flags that are not given on the command line from MOLING_<FLAG> environment variables,
+// such as MOLING_LISTEN_ADDR for --listen_addr.
+func applyEnvFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		name := config.EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok || f.Changed || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q of %s: %w", value, name, setErr)
+		}
+	})
+	return err
+}
+
+// globalSection is a section of MoLingConfig that the configuration file sets.
+type globalSection struct {
+	name   string
+	target config.Config
+}
+
+func globalSections() []globalSection {
+	return []globalSection{
+		{"auth", &mlConfig.Auth},
+		{"sessions", &mlConfig.Sessions},
+		{"limits", &mlConfig.Limits},
+		{"elicitation", &mlConfig.Elicitation},
+		{"sampling", &mlConfig.Sampling},
+		{"logging", &mlConfig.Logging},
+		{"cache", &mlConfig.Cache},
+		{"jobs", &mlConfig.Jobs},
+		{"policy", &mlConfig.Policy},
+		{"audit", &mlConfig.Audit},
+		{"i18n", &mlConfig.I18n},
+		{"tool_names", &mlConfig.ToolNames},
+		{"budgets", &mlConfig.Budgets},
+		{"responses", &mlConfig.Responses},
+		{"stats", &mlConfig.Stats},
+	}
+}
+
+// loadGlobalConfig merges the sections of MoLingConfig in the configuration file into mlConfig and checks them.
+func loadGlobalConfig(loader *configLoader) error {
+	for _, s := range globalSections() {
+		section := loader.section([]string{mlConfigSection, s.name}, s.target)
+		if section == nil {
+			continue
+		}
+		err := utils.MergeJSONToStruct(s.target, section)
+		if err != nil {
+			return fmt.Errorf("error loading %s config: %w", s.name, err)
+		}
+	}
+	for _, s := range globalSections() {
+		err := s.target.Check()
+		if err != nil {
+			return fmt.Errorf("invalid %s config: %w", s.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/daemon"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// DaemonListenAddr is the address the service listens on if --listen_addr is not given.
+const DaemonListenAddr = "127.0.0.1:6789"
+
+// DaemonLogName is the log file of the standard output and error of the service.
+const DaemonLogName = "daemon.log"
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run MoLing in the background as a service of the operating system, over SSE",
+	Long: `Install and control MoLing as a systemd user unit on Linux, a launchd agent on macOS or a Windows service.
+The service runs with the --listen_addr, --base_path, --module and --profile given to install, on 127.0.0.1:6789 by default.
+    moling daemon install -l 127.0.0.1:6789   Install the service, started at login or boot
+    moling daemon start                       Start the service now
+    moling daemon status                      Show the state of the service and its PID file
+    moling daemon stop                        Stop the service
+    moling daemon uninstall                   Stop and remove the service
+Use --system for a systemd unit or launchd daemon of the system, which needs root. User units of systemd stop when
+the user logs out unless lingering is enabled with loginctl enable-linger.
+`,
+}
+
+var (
+	daemonSystem bool
+	daemonForce  bool
+)
+
+func daemonManager() (daemon.Manager, error) {
+	return daemon.New(CliName, daemonSystem)
+}
+
+// DaemonInstallCommandFunc executes the "daemon install" command.
+func DaemonInstallCommandFunc(command *cobra.Command, args []string) error {
+	m, err := daemonManager()
+	if err != nil {
+		return err
+	}
+	st, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if st.Installed {
+		if !daemonForce {
+			return fmt.Errorf("the service is already installed: %s, use --force to replace it", st.Path)
+		}
+		if err = m.Uninstall(); err != nil {
+			return fmt.Errorf("failed to remove the installed service: %w", err)
+		}
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	basePath, err := filepath.Abs(mlConfig.BasePath)
+	if err != nil {
+		return err
+	}
+	listenAddr := mlConfig.ListenAddr
+	if listenAddr == "" {
+		listenAddr = DaemonListenAddr
+	}
+	serviceArgs := []string{"--listen_addr", listenAddr, "--base_path", basePath}
+	if mlConfig.Module != "all" {
+		serviceArgs = append(serviceArgs, "--module", mlConfig.Module)
+	}
+	if mlConfig.Profile != "" {
+		serviceArgs = append(serviceArgs, "--profile", mlConfig.Profile)
+	}
+	if mlConfig.Debug {
+		serviceArgs = append(serviceArgs, "--debug")
+	}
+	path, err := m.Install(daemon.Spec{
+		Name:        CliName,
+		DisplayName: MCPServerName,
+		Description: CliDescription,
+		Executable:  exePath,
+		Args:        serviceArgs,
+		LogFile:     filepath.Join(basePath, "logs", DaemonLogName),
+		System:      daemonSystem,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s, listening on %s once started.\nStart it with: moling daemon start\n", path, listenAddr)
+	return nil
+}
+
+// DaemonUninstallCommandFunc executes the "daemon uninstall" command.
+func DaemonUninstallCommandFunc(command *cobra.Command, args []string) error {
+	m, err := daemonManager()
+	if err != nil {
+		return err
+	}
+	if err = m.Uninstall(); err != nil {
+		return err
+	}
+	fmt.Println("The service is stopped and removed.")
+	return nil
+}
+
+// DaemonStartCommandFunc executes the "daemon start" command, and waits until the server holds its PID file.
+func DaemonStartCommandFunc(command *cobra.Command, args []string) error {
+	m, err := daemonManager()
+	if err != nil {
+		return err
+	}
+	if err = m.Start(); err != nil {
+		return err
+	}
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	if !waitPIDFile(pidFilePath, true, 10*time.Second) {
+		return fmt.Errorf("the service did not start within 10s, see %s", filepath.Join(mlConfig.BasePath, "logs"))
+	}
+	pid, _, _ := utils.ReadPIDFile(pidFilePath)
+	fmt.Printf("The service is running, pid %d.\n", pid)
+	return nil
+}
+
+// DaemonStopCommandFunc executes the "daemon stop" command, and waits until the server released its PID file.
+func DaemonStopCommandFunc(command *cobra.Command, args []string) error {
+	m, err := daemonManager()
+	if err != nil {
+		return err
+	}
+	if err = m.Stop(); err != nil {
+		return err
+	}
+	if !waitPIDFile(filepath.Join(mlConfig.BasePath, MLPidName), false, 10*time.Second) {
+		return fmt.Errorf("the service was stopped, but MoLing still runs after 10s")
+	}
+	fmt.Println("The service is stopped.")
+	return nil
+}
+
+// DaemonStatusCommandFunc executes the "daemon status" command.
+func DaemonStatusCommandFunc(command *cobra.Command, args []string) error {
+	m, err := daemonManager()
+	if err != nil {
+		return err
+	}
+	st, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if st.Installed {
+		fmt.Printf("service:  installed, %s\n", st.Path)
+		fmt.Printf("state:    %s\n", st.Detail)
+	} else {
+		fmt.Printf("service:  not installed\n")
+	}
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	pid, running, err := utils.ReadPIDFile(pidFilePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Printf("pid file: none, %s\n", pidFilePath)
+	case err != nil:
+		return err
+	case running:
+		fmt.Printf("pid file: %s, pid %d running\n", pidFilePath, pid)
+		if owner, err := utils.ReadInstanceOwner(pidFilePath); err == nil {
+			fmt.Printf("owner:    %s\n", owner)
+		}
+	default:
+		// The server did not remove it, it crashed or was killed. The next start replaces it.
+		fmt.Printf("pid file: %s, stale, pid %d is not running\n", pidFilePath, pid)
+	}
+	return nil
+}
+
+// waitPIDFile waits until the PID file is held by a running MoLing, or no longer is if running is false.
+func waitPIDFile(pidFilePath string, running bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, locked, _ := utils.ReadPIDFile(pidFilePath)
+		if locked == running {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func init() {
+	daemonCmd.PersistentFlags().BoolVar(&daemonSystem, "system", false, "Manage a service of the system instead of the current user (systemd and launchd), needs root")
+	installCmd := &cobra.Command{Use: "install", Short: "Install MoLing as a service started at login or boot", Args: cobra.NoArgs, RunE: DaemonInstallCommandFunc}
+	installCmd.Flags().BoolVar(&daemonForce, "force", false, "Replace the installed service")
+	uninstallCmd := &cobra.Command{Use: "uninstall", Short: "Stop and remove the service", Args: cobra.NoArgs, RunE: DaemonUninstallCommandFunc}
+	startCmd := &cobra.Command{Use: "start", Short: "Start the service", Args: cobra.NoArgs, RunE: DaemonStartCommandFunc}
+	stopCmd := &cobra.Command{Use: "stop", Short: "Stop the service", Args: cobra.NoArgs, RunE: DaemonStopCommandFunc}
+	statusCmd := &cobra.Command{Use: "status", Short: "Show the state of the service and of the PID file", Args: cobra.NoArgs, RunE: DaemonStatusCommandFunc}
+	daemonCmd.AddCommand(installCmd, uninstallCmd, startCmd, stopCmd, statusCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
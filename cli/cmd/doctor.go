@@ -0,0 +1,282 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/docconvert"
+	"github.com/gojue/moling/pkg/services/media"
+	"github.com/gojue/moling/pkg/services/sandbox"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment MoLing runs in and tell how to fix problems",
+	Long: `Check the configuration, the permissions of the data directory, Chrome for the Browser service, the address
+of --listen_addr for the SSE transport, and the optional programs some tools run: ffmpeg, yt-dlp, python, node, pandoc,
+soffice and git.
+Every problem comes with a fix. The exit code is not zero if a check failed; warnings only concern optional features.
+    moling doctor
+    moling doctor -l 127.0.0.1:6789 --profile work
+    moling doctor --json
+`,
+	Args: cobra.NoArgs,
+	RunE: DoctorCommandFunc,
+}
+
+var doctorJSON bool
+
+// Statuses of the doctor checks.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorCheck is the outcome of one check of moling doctor.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // Status is ok, warn or fail.
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"` // Fix tells how to solve the problem, for warnings and failures.
+}
+
+// DoctorCommandFunc executes the "doctor" command.
+func DoctorCommandFunc(command *cobra.Command, args []string) error {
+	ctx, logger := configContext()
+	var checks []doctorCheck
+	add := func(name, status, detail, fix string) {
+		checks = append(checks, doctorCheck{Name: name, Status: status, Detail: detail, Fix: fix})
+	}
+
+	// The configuration, and the configuration of the services for the checks below
+	var values map[string]any
+	file, err := loadProfileFile()
+	switch {
+	case err != nil:
+		add("config", doctorFail, err.Error(), "fix the syntax of the configuration file, or move it away and run moling config --init")
+	default:
+		if _, serr := os.Stat(file.Path); errors.Is(serr, os.ErrNotExist) {
+			add("config", doctorWarn, fmt.Sprintf("%s does not exist, the defaults are used", file.Path), "run moling init to choose the services and directories, or moling config --init")
+		} else if verr := validateFile(ctx, file, logger, true); verr != nil {
+			add("config", doctorFail, verr.Error(), "fix the values with moling config set <key> <value>, moling config validate checks the file again")
+		} else {
+			add("config", doctorOK, fmt.Sprintf("%s is valid", file.Path), "")
+		}
+		values, _ = loadEffectiveConfig(ctx, file, logger, true)
+	}
+
+	checks = append(checks, doctorDataDirs(mlConfig.BasePath)...)
+	var chrome string
+	if b, ok := values[string(browser.BrowserServerName)].(map[string]any); ok {
+		chrome, _ = b["exec_path"].(string)
+	}
+	checks = append(checks, doctorChrome(command.Context(), chrome, moduleEnabled(string(browser.BrowserServerName))))
+	checks = append(checks, doctorListen(mlConfig.ListenAddr))
+
+	sandboxConfig, docConfig := sandbox.NewSandboxConfig(), docconvert.NewDocConvertConfig()
+	program := func(service, key, def string) string {
+		if m, ok := values[service].(map[string]any); ok {
+			if p, ok := m[key].(string); ok && p != "" {
+				return p
+			}
+		}
+		return def
+	}
+	sandboxName, docName, mediaName := string(sandbox.SandboxServerName), string(docconvert.DocConvertServerName), string(media.MediaServerName)
+	checks = append(checks,
+		doctorProgram("ffmpeg", program(mediaName, "ffmpeg_path", "ffmpeg"), "converting and merging audio and video of the Media service", "Media.ffmpeg_path"),
+		doctorProgram("yt-dlp", program(mediaName, "ytdlp_path", "yt-dlp"), "downloading videos with media_download", "Media.ytdlp_path"),
+		doctorProgram("python", program(sandboxName, "python_path", sandboxConfig.PythonPath), "running Python code in the Sandbox service", "Sandbox.python_path"),
+		doctorProgram("node", program(sandboxName, "node_path", sandboxConfig.NodePath), "running JavaScript code in the Sandbox service", "Sandbox.node_path"),
+		doctorProgram("pandoc", program(docName, "pandoc_path", docConfig.PandocPath), "converting documents with pandoc in the DocConvert service", "DocConvert.pandoc_path"),
+		doctorProgram("soffice", program(docName, "libreoffice_path", "soffice"), "converting office documents and PDFs in the DocConvert service", "DocConvert.libreoffice_path"),
+		doctorProgram("git", "git", "the checkpoints and git status of the FileSystem service", ""),
+	)
+
+	failed := 0
+	for _, c := range checks {
+		if c.Status == doctorFail {
+			failed++
+		}
+	}
+	if doctorJSON {
+		if err = printJSON(command.OutOrStdout(), checks); err != nil {
+			return err
+		}
+	} else {
+		out := command.OutOrStdout()
+		for _, c := range checks {
+			fmt.Fprintf(out, "[%-4s] %-16s %s\n", c.Status, c.Name, c.Detail)
+			if c.Fix != "" {
+				fmt.Fprintf(out, "       %-16s fix: %s\n", "", c.Fix)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// moduleEnabled reports whether the service is loaded with --module and the profile.
+func moduleEnabled(name string) bool {
+	return mlConfig.Module == "all" || utils.StringInSlice(name, strings.Split(mlConfig.Module, ","))
+}
+
+// doctorDataDirs checks that the base path and the directories MoLing writes to are writable.
+func doctorDataDirs(base string) []doctorCheck {
+	var checks []doctorCheck
+	for _, dir := range []string{base, filepath.Join(base, "config"), filepath.Join(base, "logs"), filepath.Join(base, "data")} {
+		name := "data_dir"
+		if dir != base {
+			name = "data_dir:" + filepath.Base(dir)
+		}
+		info, err := os.Stat(dir)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && dir != base:
+			// Created when it is first needed
+			continue
+		case err != nil:
+			checks = append(checks, doctorCheck{Name: name, Status: doctorFail, Detail: err.Error(), Fix: fmt.Sprintf("create %s and make it writable for %s", dir, currentUser())})
+			continue
+		case !info.IsDir():
+			checks = append(checks, doctorCheck{Name: name, Status: doctorFail, Detail: fmt.Sprintf("%s is not a directory", dir), Fix: fmt.Sprintf("move %s away, MoLing creates the directory", dir)})
+			continue
+		}
+		f, err := os.CreateTemp(dir, ".doctor-*")
+		if err != nil {
+			fix := fmt.Sprintf("make %s writable for %s, e.g. chmod u+rwx %s", dir, currentUser(), dir)
+			if runtime.GOOS == "windows" {
+				fix = fmt.Sprintf("give %s full control of %s in the Security tab of its properties", currentUser(), dir)
+			}
+			checks = append(checks, doctorCheck{Name: name, Status: doctorFail, Detail: err.Error(), Fix: fix})
+			continue
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		checks = append(checks, doctorCheck{Name: name, Status: doctorOK, Detail: fmt.Sprintf("%s is writable", dir)})
+	}
+	return checks
+}
+
+func currentUser() string {
+	if mlConfig.Username != "" {
+		return mlConfig.Username
+	}
+	return "the user running MoLing"
+}
+
+// doctorChrome checks that the configured browser, or else Chrome, Chromium or Edge, is installed and prints its
+// version. A missing browser is a failure only if the Browser service is enabled.
+func doctorChrome(ctx context.Context, path string, enabled bool) doctorCheck {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return doctorCheck{Name: "chrome", Status: doctorFail, Detail: fmt.Sprintf("Browser.exec_path %s is not usable: %s", path, err), Fix: "set the path of chrome.exe or msedge.exe with moling config set Browser.exec_path <path>"}
+		}
+	} else {
+		path = browser.FindExecPath()
+	}
+	if path == "" {
+		status := doctorWarn
+		if enabled {
+			status = doctorFail
+		}
+		fix := "install Google Chrome or Chromium, e.g. sudo apt install chromium, or disable the Browser service with --module"
+		switch runtime.GOOS {
+		case "darwin":
+			fix = "install Google Chrome from https://www.google.com/chrome/ or brew install --cask chromium, or disable the Browser service with --module"
+		case "windows":
+			fix = "install Google Chrome or Microsoft Edge, or set its path with moling config set Browser.exec_path <path>, or disable the Browser service with --module"
+		}
+		return doctorCheck{Name: "chrome", Status: status, Detail: "Chrome, Chromium or Edge was not found, the Browser service needs it", Fix: fix}
+	}
+	detail := path
+	// chrome.exe does not print its version on Windows
+	if runtime.GOOS != "windows" {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if out, err := exec.CommandContext(ctx, path, "--version").Output(); err == nil {
+			detail = fmt.Sprintf("%s (%s)", strings.TrimSpace(string(out)), path)
+		}
+	}
+	return doctorCheck{Name: "chrome", Status: doctorOK, Detail: detail}
+}
+
+// doctorListen checks that the address of the SSE transport is free.
+func doctorListen(addr string) doctorCheck {
+	if addr == "" {
+		return doctorCheck{Name: "listen_addr", Status: doctorOK, Detail: "STDIO mode, no port is needed; use -l to check the address of the SSE mode"}
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return doctorCheck{Name: "listen_addr", Status: doctorFail, Detail: err.Error(),
+			Fix: fmt.Sprintf("stop the program listening on %s, such as a running MoLing (moling daemon status), or choose another address with --listen_addr", addr)}
+	}
+	_ = l.Close()
+	return doctorCheck{Name: "listen_addr", Status: doctorOK, Detail: fmt.Sprintf("%s is free", addr)}
+}
+
+// doctorPackages are the packages of the programs that apt and brew name differently, as apt and brew package.
+var doctorPackages = map[string][2]string{
+	"python":  {"python3", "python"},
+	"node":    {"nodejs", "node"},
+	"soffice": {"libreoffice", "libreoffice"},
+}
+
+// doctorProgram checks that an optional program is installed. A missing program is a warning, since only some
+// tools need it. key is the configuration key of its path, empty if it is looked up in the PATH only.
+func doctorProgram(name, path, purpose, key string) doctorCheck {
+	found, err := exec.LookPath(path)
+	if err != nil {
+		apt, brew := name, name
+		if pkgs, ok := doctorPackages[name]; ok {
+			apt, brew = pkgs[0], pkgs[1]
+		}
+		fix := fmt.Sprintf("install it, e.g. sudo apt install %s", apt)
+		switch runtime.GOOS {
+		case "darwin":
+			fix = fmt.Sprintf("install it with brew install %s", brew)
+		case "windows":
+			fix = fmt.Sprintf("install it, e.g. with scoop install %s, and add it to the PATH", name)
+		}
+		if key != "" {
+			fix += fmt.Sprintf(", or set its path with moling config set %s <path>", key)
+		}
+		return doctorCheck{Name: name, Status: doctorWarn, Detail: fmt.Sprintf("%s was not found, it is needed for %s", path, purpose), Fix: fix}
+	}
+	return doctorCheck{Name: name, Status: doctorOK, Detail: found}
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Print the checks as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// takeover makes the server stop the instance that holds the PID file of the base path and start in its place.
+var takeover bool
+
+// lockInstance takes the PID file of the base path, so that two instances never share its data. With --takeover, the
+// instance that holds it is stopped first, as by SIGTERM, and killed if it does not stop within its shutdown timeout.
+func lockInstance(pidFilePath string, loger zerolog.Logger) error {
+	owner := utils.InstanceOwner{Version: GitVersion, ListenAddr: mlConfig.ListenAddr, Module: mlConfig.Module, Profile: mlConfig.Profile}
+	err := utils.CreatePIDFile(pidFilePath, owner)
+	var running *utils.InstanceRunningError
+	if !errors.As(err, &running) {
+		return err
+	}
+	if !takeover {
+		return fmt.Errorf("%w\nMoLing instances must not share a base path. Stop the running one, start with --takeover to replace it, or give this one a base path of its own with --base_path", err)
+	}
+	if running.Owner == nil || running.Owner.PID <= 0 {
+		return fmt.Errorf("%w\ncannot take over: the running instance did not record its PID, stop it yourself", err)
+	}
+	if host, _ := os.Hostname(); running.Owner.Host != host {
+		return fmt.Errorf("%w\ncannot take over an instance of another host, stop it on %s", err, running.Owner.Host)
+	}
+	p, err := os.FindProcess(running.Owner.PID)
+	if err != nil {
+		return fmt.Errorf("cannot take over pid %d: %w", running.Owner.PID, err)
+	}
+	loger.Warn().Str("owner", running.Owner.String()).Msg("taking over the base path, stopping the running instance")
+	if err = p.Signal(syscall.SIGTERM); err != nil {
+		// Windows processes cannot be asked to stop.
+		err = p.Kill()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot stop pid %d: %w", running.Owner.PID, err)
+	}
+	if !waitPIDFile(pidFilePath, false, time.Duration(mlConfig.ShutdownTimeout)*time.Second+5*time.Second) {
+		loger.Warn().Int("pid", running.Owner.PID).Msg("the running instance did not stop, killing it")
+		_ = p.Kill()
+		if !waitPIDFile(pidFilePath, false, 5*time.Second) {
+			return fmt.Errorf("cannot take over: pid %d still holds %s", running.Owner.PID, pidFilePath)
+		}
+	}
+	return utils.CreatePIDFile(pidFilePath, owner)
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// logsFollowInterval is how often the log file is checked for new lines with --follow.
+const logsFollowInterval = 500 * time.Millisecond
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the log file of MoLing",
+	Long: `Show the log file of MoLing in the logs directory of the base path.
+    moling logs tail                         Print the last 50 lines
+    moling logs tail -n 200 -f               Print the last 200 lines and the new ones as they are written
+    moling logs tail --service Browser       Print the lines of the Browser service only
+    moling logs tail --level warn --json     Print warnings and errors as written, without formatting
+`,
+}
+
+var (
+	logsLines   int
+	logsFollow  bool
+	logsService string
+	logsLevel   string
+	logsJSON    bool
+)
+
+// logFilter selects and formats the lines of the log file.
+type logFilter struct {
+	service string
+	level   zerolog.Level
+	raw     bool
+	console zerolog.ConsoleWriter
+}
+
+// match reports whether a line is of the service and at least of the level of the filter. Lines of the console
+// format are matched by their Service field and level abbreviation.
+func (f *logFilter) match(line string) bool {
+	var entry struct {
+		Level   string `json:"level"`
+		Service string `json:"Service"`
+	}
+	if json.Unmarshal([]byte(line), &entry) != nil {
+		if f.service != "" && !strings.Contains(line, "Service="+f.service) {
+			return false
+		}
+		fields := strings.Fields(line)
+		for level, abbr := range zerolog.FormattedLevels {
+			if len(fields) > 1 && fields[1] == abbr {
+				return level >= f.level
+			}
+		}
+		return true
+	}
+	if f.service != "" && entry.Service != f.service {
+		return false
+	}
+	level, err := zerolog.ParseLevel(entry.Level)
+	return err != nil || level >= f.level
+}
+
+// unwrap returns the entry logged as the message of a line without level, as the SSE server does with the lines
+// it also prints to the console, else the line.
+func (f *logFilter) unwrap(line string) string {
+	var entry struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(line), &entry) != nil || entry.Level != "" {
+		return line
+	}
+	inner := strings.TrimSpace(entry.Message)
+	if strings.HasPrefix(inner, "{") && json.Valid([]byte(inner)) {
+		return inner
+	}
+	return line
+}
+
+// print writes a line, formatted for the terminal unless it is printed raw or is not JSON.
+func (f *logFilter) print(w io.Writer, line string) {
+	if !f.raw && json.Valid([]byte(line)) {
+		if _, err := f.console.Write([]byte(line)); err == nil {
+			return
+		}
+	}
+	_, _ = fmt.Fprintln(w, line)
+}
+
+// LogsTailCommandFunc executes the "logs tail" command.
+func LogsTailCommandFunc(command *cobra.Command, args []string) error {
+	level, err := zerolog.ParseLevel(logsLevel)
+	if err != nil {
+		return err
+	}
+	if logsLines < 0 {
+		return fmt.Errorf("--lines must not be negative")
+	}
+	filter := &logFilter{
+		service: logsService,
+		level:   level,
+		raw:     logsJSON,
+		console: zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339},
+	}
+	logFile := filepath.Join(mlConfig.BasePath, "logs", LogFileName)
+	f, err := os.Open(logFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	lines, offset, err := tailLines(f, logsLines, func(line string) bool { return filter.match(filter.unwrap(line)) })
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		filter.print(os.Stdout, filter.unwrap(line))
+	}
+	if !logsFollow {
+		return nil
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return followLog(ctx, logFile, f, offset, filter)
+}
+
+// tailLines returns the last n lines of f that match, read backwards from its end, and the size of f.
+func tailLines(f *os.File, n int, match func(string) bool) ([]string, int64, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := st.Size()
+	const chunkSize = 64 * 1024
+	var lines []string
+	var rest []byte
+	for pos := size; pos > 0 && len(lines) < n; {
+		readSize := min(int64(chunkSize), pos)
+		pos -= readSize
+		buf := make([]byte, readSize, int(readSize)+len(rest))
+		if _, err = f.ReadAt(buf, pos); err != nil {
+			return nil, 0, err
+		}
+		buf = append(buf, rest...)
+		parts := bytes.Split(buf, []byte("\n"))
+		// The first part may continue in the previous chunk, unless this is the start of the file.
+		rest = parts[0]
+		if pos == 0 {
+			rest = nil
+		} else {
+			parts = parts[1:]
+		}
+		for i := len(parts) - 1; i >= 0 && len(lines) < n; i-- {
+			line := string(bytes.TrimRight(parts[i], "\r"))
+			if line != "" && match(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines, size, nil
+}
+
+// followLog prints the lines written to the log file after offset until ctx is done. When the file is rotated, the
+// new file is followed from its start.
+func followLog(ctx context.Context, path string, f *os.File, offset int64, filter *logFilter) error {
+	var partial []byte
+	ticker := time.NewTicker(logsFollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if st, err := os.Stat(path); err == nil && (!os.SameFile(current, st) || st.Size() < offset) {
+			// Rotated: print what is left of the old file, then switch to the new one.
+			if err = readNewLines(f, &offset, &partial, filter); err != nil {
+				return err
+			}
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f, offset, partial = next, 0, nil
+		}
+		if err = readNewLines(f, &offset, &partial, filter); err != nil {
+			return err
+		}
+	}
+}
+
+// readNewLines prints the complete lines of f after offset that match the filter, and keeps the incomplete last one.
+func readNewLines(f *os.File, offset *int64, partial *[]byte, filter *logFilter) error {
+	_, err := f.Seek(*offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	*offset += int64(len(data))
+	scanner := bufio.NewScanner(bytes.NewReader(append(*partial, data...)))
+	scanner.Buffer(nil, 16*1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	*partial = nil
+	if data[len(data)-1] != '\n' && len(lines) > 0 {
+		*partial = []byte(lines[len(lines)-1])
+		lines = lines[:len(lines)-1]
+	}
+	for _, line := range lines {
+		line = filter.unwrap(strings.TrimRight(line, "\r"))
+		if line != "" && filter.match(line) {
+			filter.print(os.Stdout, line)
+		}
+	}
+	return nil
+}
+
+func init() {
+	tailCmd := &cobra.Command{Use: "tail", Short: "Print the last lines of the log file", Args: cobra.NoArgs, RunE: LogsTailCommandFunc}
+	tailCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to print")
+	tailCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Print new lines as they are written, until interrupted")
+	tailCmd.Flags().StringVar(&logsService, "service", "", "Print only the lines of the service, e.g. Browser")
+	tailCmd.Flags().StringVar(&logsLevel, "level", "trace", "Print only the lines of this level or higher: trace, debug, info, warn or error")
+	tailCmd.Flags().BoolVar(&logsJSON, "json", false, "Print the lines as written to the file, without formatting")
+	logsCmd.AddCommand(tailCmd)
+	rootCmd.AddCommand(logsCmd)
+}
@@ -26,7 +26,11 @@ import (
 
 // mlsCommandPreFunc is a pre-run function for the MoLing command.
 func mlsCommandPreFunc(cmd *cobra.Command, args []string) error {
-	err := utils.CreateDirectory(mlConfig.BasePath)
+	err := applyEnvFlags(cmd)
+	if err != nil {
+		return err
+	}
+	err = utils.CreateDirectory(mlConfig.BasePath)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,52 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/plugin"
+)
+
+// PluginsDir is the directory of the plugin manifests, relative to the base path.
+const PluginsDir = "plugins"
+
+// registerPlugins registers the executable plugins of the plugins directory as services. Plugins cannot replace
+// built-in services.
+func registerPlugins(logger zerolog.Logger) {
+	manifests, err := plugin.Discover(filepath.Join(mlConfig.BasePath, PluginsDir))
+	if err != nil {
+		logger.Warn().Err(err).Msg("some plugins were skipped")
+	}
+	for _, m := range manifests {
+		taken := false
+		for name := range services.ServiceList() {
+			taken = taken || strings.EqualFold(string(name), m.Name)
+		}
+		if taken {
+			logger.Warn().Str("plugin", m.Name).Msg("a service with the name of the plugin exists, the plugin was skipped")
+			continue
+		}
+		services.RegisterServ(comm.MoLingServerType(m.Name), plugin.NewFactory(m))
+		logger.Info().Str("plugin", m.Name).Str("command", m.Command).Msg("plugin registered")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/policy"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check the policy rules of the configuration",
+	Long: `Evaluate the rules of the policy section of the configuration without starting a server, to check what they
+decide before clients make the calls.
+    moling policy test FileSystem.write_file --args '{"path":"/etc/hosts"}'
+    moling policy test Command.execute_command --role operator --principal ci
+    moling policy test Browser.browser_navigate --time 2026-01-01T23:00:00+01:00 --json
+`,
+}
+
+var (
+	policyArgs      string
+	policyPrincipal string
+	policyRoles     []string
+	policyProfile   string
+	policySession   string
+	policyTime      string
+	policyJSON      bool
+)
+
+// PolicyTestCommandFunc executes the "policy test" command.
+func PolicyTestCommandFunc(command *cobra.Command, args []string) error {
+	service, tool, err := splitToolName(args[0])
+	if err != nil {
+		return err
+	}
+	callArgs, err := parseCallArgs(policyArgs, command.InOrStdin())
+	if err != nil {
+		return err
+	}
+	in := policy.Input{
+		Tool:      tool,
+		Service:   string(service),
+		Args:      callArgs,
+		Principal: policyPrincipal,
+		Roles:     policyRoles,
+		Profile:   policyProfile,
+		Session:   policySession,
+		Time:      time.Now(),
+	}
+	if policyTime != "" {
+		if in.Time, err = time.Parse(time.RFC3339, policyTime); err != nil {
+			return fmt.Errorf("invalid --time, use RFC 3339 such as 2026-01-01T23:00:00+01:00: %w", err)
+		}
+	}
+
+	logger := initLogger(mlConfig.BasePath)
+	mlConfig.SetLogger(logger)
+	configFile, err := loadConfigFile()
+	if err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
+	}
+	if configFile, err = applyProfile(configFile); err != nil {
+		return err
+	}
+	if err = loadGlobalConfig(newConfigLoader(configFile, logger)); err != nil {
+		return err
+	}
+	engine, err := mlConfig.Policy.Engine()
+	if err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	d := engine.Evaluate(in)
+	if policyJSON {
+		return printJSON(command.OutOrStdout(), d)
+	}
+	_, err = fmt.Fprintf(command.OutOrStdout(), "%s (%s)\n", d.Effect, d.Reason())
+	return err
+}
+
+func init() {
+	testCmd := &cobra.Command{Use: "test <[service.]tool>", Short: "Print what the policy decides for a tool call", Args: cobra.ExactArgs(1), RunE: PolicyTestCommandFunc}
+	testCmd.Flags().StringVar(&policyArgs, "args", "{}", "Arguments of the call as a JSON object, - to read them from the standard input")
+	testCmd.Flags().StringVar(&policyPrincipal, "principal", "", "Name of the authenticated client, empty for clients that may use all tools")
+	testCmd.Flags().StringSliceVar(&policyRoles, "role", nil, "Roles of the client, may be repeated")
+	testCmd.Flags().StringVar(&policyProfile, "client_profile", "", "Profile of the configuration the client uses")
+	testCmd.Flags().StringVar(&policySession, "session", "", "ID of the session of the client")
+	testCmd.Flags().StringVar(&policyTime, "time", "", "Time of the call in RFC 3339, default now")
+	testCmd.Flags().BoolVar(&policyJSON, "json", false, "Print the decision as JSON")
+	policyCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(policyCmd)
+}
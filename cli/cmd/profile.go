@@ -0,0 +1,138 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// findProfile returns the profile with the given name, or an error listing the profiles there are.
+func findProfile(profiles map[string]*config.Profile, name string) (*config.Profile, error) {
+	if p, ok := profiles[name]; ok {
+		return p, nil
+	}
+	names := "none"
+	if len(profiles) > 0 {
+		names = strings.Join(config.ProfileNames(profiles), ", ")
+	}
+	return nil, fmt.Errorf("unknown profile %q, the configuration file has %s", name, names)
+}
+
+// applyProfile returns the configuration file with the profile of --profile merged in, and applies the enabled
+// services and the base path of the profile to mlConfig. --module given on the command line wins.
+func applyProfile(file *config.File) (*config.File, error) {
+	if mlConfig.Profile == "" {
+		return file, nil
+	}
+	profiles, err := file.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	p, err := findProfile(profiles, mlConfig.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if p.Module != "" && mlConfig.Module == "all" {
+		mlConfig.Module = p.Module
+	}
+	if p.BasePath != "" {
+		basePath, err := filepath.Abs(p.BasePath)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: invalid base_path: %w", p.Name, err)
+		}
+		mlConfig.BasePath = basePath
+		for _, dir := range append([]string{""}, mlDirectories...) {
+			if err = utils.CreateDirectory(filepath.Join(basePath, dir)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return file.WithProfile(p), nil
+}
+
+// loadKeyProfiles loads the profiles that API keys and bearer tokens use for their SSE sessions. The services the
+// profile configures get instances of their own for these sessions, all services if it has a base path of its own.
+func loadKeyProfiles(ctx context.Context, file *config.File, srv *server.MoLingServer, loaded []abstract.Service, logger zerolog.Logger) error {
+	names := mlConfig.Auth.Profiles()
+	if len(names) == 0 {
+		return nil
+	}
+	if mlConfig.ListenAddr == "" {
+		logger.Warn().Strs("profiles", names).Msg("the profiles of credentials only apply to SSE clients")
+		return nil
+	}
+	profiles, err := file.Profiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		p, err := findProfile(profiles, name)
+		if err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+		var enabled []comm.MoLingServerType
+		if p.Module != "" {
+			for _, m := range strings.Split(p.Module, ",") {
+				enabled = append(enabled, comm.MoLingServerType(strings.TrimSpace(m)))
+			}
+		}
+		profileConfig := mlConfig
+		if p.BasePath != "" {
+			copied := *mlConfig
+			copied.BasePath = p.BasePath
+			profileConfig = &copied
+		}
+		loader := newConfigLoader(file.WithProfile(p), logger)
+		factories := make(map[comm.MoLingServerType]server.SessionFactory)
+		for _, s := range loaded {
+			if _, ok := p.Sections[string(s.Name())]; !ok && p.BasePath == "" {
+				continue
+			}
+			cfg := loader.section([]string{string(s.Name())}, s.Config())
+			nsv := services.ServiceList()[s.Name()]
+			// The instances are created when a client first calls a tool, check the configuration now.
+			check, err := nsv(context.WithValue(ctx, comm.MoLingConfigKey, profileConfig))
+			if err == nil && cfg != nil {
+				err = check.LoadConfig(cfg)
+			}
+			if err != nil {
+				return fmt.Errorf("profile %s: %s: %w", name, s.Name(), err)
+			}
+			factory := sessionFactory(s.Name(), nsv, cfg)
+			factories[s.Name()] = func(ctx context.Context) (abstract.Service, error) {
+				return factory(context.WithValue(ctx, comm.MoLingConfigKey, profileConfig))
+			}
+		}
+		if err = loader.err(); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+		srv.SetProfile(name, enabled, factories)
+	}
+	return nil
+}
@@ -18,14 +18,14 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"os/user"
 	"path/filepath"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -35,6 +35,7 @@ import (
 	"github.com/gojue/moling/cli/cobrautl"
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/daemon"
 	"github.com/gojue/moling/pkg/server"
 	"github.com/gojue/moling/pkg/services"
 	"github.com/gojue/moling/pkg/services/abstract"
@@ -85,11 +86,28 @@ const (
 var (
 	GitVersion = "unknown_arm64_v0.0.0_2025-03-22 20:08"
 	mlConfig   = &config.MoLingConfig{
-		Version:    GitVersion,
-		ConfigFile: filepath.Join("config", MLConfigName),
-		BasePath:   filepath.Join(os.TempDir(), MLRootPath), // will set in mlsCommandPreFunc
+		Version:     GitVersion,
+		ConfigFile:  filepath.Join("config", MLConfigName),
+		BasePath:    filepath.Join(os.TempDir(), MLRootPath), // will set in mlsCommandPreFunc
+		Sessions:    config.NewSessionConfig(),
+		Limits:      config.NewLimitConfig(),
+		Elicitation: config.NewElicitationConfig(),
+		Sampling:    config.NewSamplingConfig(),
+		Logging:     config.NewLoggingConfig(),
+		Cache:       config.NewCacheConfig(),
+		Jobs:        config.NewJobsConfig(),
+		Policy:      config.NewPolicyConfig(),
+		Audit:       config.NewAuditConfig(),
+		I18n:        config.NewI18nConfig(),
+		ToolNames:   config.NewToolNamesConfig(),
+		Budgets:     config.NewBudgetsConfig(),
+		Responses:   config.NewResponseConfig(),
+		Stats:       config.NewStatsConfig(),
 	}
 
+	// logWriter is the log file of the running command.
+	logWriter *utils.RotateWriter
+
 	// mlDirectories is a list of directories to be created in the base path
 	mlDirectories = []string{
 		"logs",    // log file
@@ -97,6 +115,8 @@ var (
 		"browser", // browser cache
 		"data",    // data
 		"cache",
+		PluginsDir,        // executable plugins
+		server.PromptsDir, // prompt library
 	}
 )
 
@@ -142,10 +162,14 @@ func init() {
 	cobra.EnablePrefixMatching = true
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	rootCmd.PersistentFlags().StringVar(&mlConfig.BasePath, "base_path", mlConfig.BasePath, "MoLing Base Data Path, automatically set by the system, cannot be changed, display only.")
+	rootCmd.PersistentFlags().StringVar(&mlConfig.BasePath, "base_path", mlConfig.BasePath, "MoLing Base Data Path, default ~/.moling. Instances with different base paths run side by side, two instances cannot share one.")
 	rootCmd.PersistentFlags().BoolVarP(&mlConfig.Debug, "debug", "d", false, "Debug mode, default is false.")
 	rootCmd.PersistentFlags().StringVarP(&mlConfig.ListenAddr, "listen_addr", "l", "", "listen address for SSE mode. default:'', not listen, used STDIO mode.")
 	rootCmd.PersistentFlags().StringVarP(&mlConfig.Module, "module", "m", "all", "module to load, default: all; others: Browser,FileSystem,Command, etc. Multiple modules are separated by commas")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.ShutdownTimeout, "shutdown_timeout", 20, "seconds that running tool calls may take to finish when the server stops, before they are cancelled")
+	rootCmd.PersistentFlags().BoolVar(&mlConfig.DryRun, "dry_run", false, "dry-run mode: tools that change something only describe what they would do, default is false.")
+	rootCmd.Flags().BoolVar(&takeover, "takeover", false, "stop the MoLing instance running with the same base path and start in its place, default is false.")
+	rootCmd.PersistentFlags().StringVar(&mlConfig.Profile, "profile", "", "profile of the configuration file to use, e.g. work. default: '', the configuration without profile")
 	rootCmd.SilenceUsage = true
 }
 
@@ -164,75 +188,93 @@ func initLogger(mlDataPath string) zerolog.Logger {
 	if err != nil {
 		panic(fmt.Sprintf("failed to open log file %s: %s", logFile, err.Error()))
 	}
-	logger = zerolog.New(rw).With().Timestamp().Logger()
-	logger.Info().Uint32("MaxLogSize", MaxLogSize).Msgf("Log files are automatically rotated when they exceed the size threshold, and saved to %s.1 and %s.2 respectively", LogFileName, LogFileName)
+	logWriter = rw
+	logger = zerolog.New(config.NewSecretMaskWriter(rw)).With().Timestamp().Logger()
+	logger.Info().Uint32("MaxLogSize", MaxLogSize).Msgf("Log files are automatically rotated when they exceed the size threshold, and saved to %s.1", LogFileName)
+	return logger
+}
+
+// configureLogger applies the logging configuration to the log file opened by initLogger and returns the logger of the server.
+func configureLogger() zerolog.Logger {
+	lc := &mlConfig.Logging
+	if mlConfig.Debug {
+		lc.Level = zerolog.LevelDebugValue
+	}
+	zerolog.SetGlobalLevel(lc.MinLevel())
+	logWriter.SetRotation(int64(lc.MaxSize)*1024*1024, time.Duration(lc.MaxAge)*time.Hour, lc.MaxBackups)
+	var w io.Writer = config.NewSecretMaskWriter(logWriter)
+	if lc.Format == config.LogFormatConsole {
+		w = zerolog.ConsoleWriter{Out: w, NoColor: true, TimeFormat: time.RFC3339}
+	}
+	mlConfig.SetLogWriter(w)
+	logger := zerolog.New(w).Level(lc.DefaultLevel()).With().Timestamp().Logger()
+	logger.Info().Str("level", lc.DefaultLevel().String()).Any("services", lc.Services).Str("format", lc.Format).
+		Int("maxSize", lc.MaxSize).Int("maxAge", lc.MaxAge).Int("maxBackups", lc.MaxBackups).Msg("logging configured")
 	return logger
 }
 
+// serviceContext returns ctx with the logger of the service, at the level of the service in the logging configuration.
+func serviceContext(ctx context.Context, name comm.MoLingServerType) context.Context {
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, comm.MoLingLoggerKey, logger.Level(mlConfig.Logging.ServiceLevel(string(name))))
+}
+
 func mlsCommandFunc(command *cobra.Command, args []string) error {
 	loger := initLogger(mlConfig.BasePath)
 	mlConfig.SetLogger(loger)
 	var err error
-	var nowConfig []byte
-	var nowConfigJSON map[string]any
 
-	// 增加实例重复运行检测
+	// 当前配置文件检测
+	loger.Info().Str("ServerName", MCPServerName).Str("version", GitVersion).Msg("start")
+	configFile, err := loadConfigFile()
+	if err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
+	}
+	loger.Info().Str("config_file", configFile.Path).Msg("load config file")
+	baseFile := configFile
+	configFile, err = applyProfile(configFile)
+	if err != nil {
+		return err
+	}
+	if mlConfig.Profile != "" {
+		loger.Info().Str("profile", mlConfig.Profile).Str("module", mlConfig.Module).Str("base_path", mlConfig.BasePath).Msg("profile applied")
+	}
+
+	// 增加实例重复运行检测. The lock is of the base path of the profile, which holds the data of the services.
 	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
 	loger.Info().Str("pid", pidFilePath).Msg("Starting MoLing MCP Server...")
-	err = utils.CreatePIDFile(pidFilePath)
+	err = lockInstance(pidFilePath, loger)
 	if err != nil {
 		return err
 	}
-
-	// 当前配置文件检测
-	loger.Info().Str("ServerName", MCPServerName).Str("version", GitVersion).Msg("start")
-	configFilePath := filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)
-	if nowConfig, err = os.ReadFile(configFilePath); err == nil {
-		err = json.Unmarshal(nowConfig, &nowConfigJSON)
-		if err != nil {
-			return fmt.Errorf("error unmarshaling JSON: %w, config file:%s", err, configFilePath)
-		}
+	loader := newConfigLoader(configFile, loger)
+	err = loadGlobalConfig(loader)
+	if err != nil {
+		return err
 	}
-	loger.Info().Str("config_file", configFilePath).Msg("load config file")
+	loger = configureLogger()
+	mlConfig.SetLogger(loger)
+	registerPlugins(loger)
 	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
 	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, loger)
 	ctxNew, cancelFunc := context.WithCancel(ctx)
 
-	var modules []string
-	if mlConfig.Module != "all" {
-		modules = strings.Split(mlConfig.Module, ",")
-	}
-	var srvs []abstract.Service
+	srvs, sessionFactories, failed := loadServices(ctxNew, loader, loger)
 	var closers = make(map[string]func() error)
-	for srvName, nsv := range services.ServiceList() {
-		if len(modules) > 0 {
-			if !utils.StringInSlice(string(srvName), modules) {
-				loger.Debug().Str("moduleName", string(srvName)).Msgf("module %s not in %v, skip", string(srvName), modules)
-				continue
-			}
-			loger.Debug().Str("moduleName", string(srvName)).Msgf("starting %s service", srvName)
-		}
-		cfg, ok := nowConfigJSON[string(srvName)].(map[string]any)
-		srv, err := nsv(ctxNew)
-		if err != nil {
-			loger.Error().Err(err).Msgf("failed to create service %s", srv.Name())
-			break
-		}
-		if ok {
-			err = srv.LoadConfig(cfg)
-			if err != nil {
-				loger.Error().Err(err).Msgf("failed to load config for service %s", srv.Name())
-				break
-			}
-		}
-		err = srv.Init()
-		if err != nil {
-			loger.Error().Err(err).Msgf("failed to init service %s", srv.Name())
-			break
-		}
-		srvs = append(srvs, srv)
+	for _, srv := range srvs {
 		closers[string(srv.Name())] = srv.Close
 	}
+	err = loader.err()
+	if err != nil {
+		for _, closeFn := range closers {
+			_ = closeFn()
+		}
+		cancelFunc()
+		return err
+	}
 	// MCPServer
 	srv, err := server.NewMoLingServer(ctxNew, srvs, *mlConfig)
 	if err != nil {
@@ -240,6 +282,18 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 		cancelFunc()
 		return err
 	}
+	for srvName, factory := range sessionFactories {
+		srv.SetSessionFactory(srvName, factory)
+	}
+	srv.SetFailedServices(failed)
+	err = loadKeyProfiles(ctxNew, baseFile, srv, srvs, loger)
+	if err != nil {
+		for _, closeFn := range closers {
+			_ = closeFn()
+		}
+		cancelFunc()
+		return err
+	}
 
 	go func() {
 		err = srv.Serve()
@@ -253,12 +307,22 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 	// 创建一个信号通道
 	sigChan := make(chan os.Signal, 2)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	isService, err := daemon.RunAsService(CliName, func() { sigChan <- syscall.SIGTERM })
+	if err != nil {
+		loger.Warn().Err(err).Msg("failed to detect the Windows service control manager")
+	} else if isService {
+		loger.Info().Msg("running as a Windows service")
+	}
 
 	// 创建一个 goroutine 来判断父进程是否退出
 	// Claude Desktop 0.9.2 退出时，没有向MCP Server发送 SIGTERM信号，导致MCP 不能正常退出。
 	// fix https://github.com/gojue/moling/issues/32
 	go func() {
 		ppid := os.Getppid()
+		if ppid == 1 {
+			// Started by a service manager such as systemd or launchd, see moling daemon.
+			return
+		}
 		for {
 			time.Sleep(1 * time.Second)
 			newPpid := os.Getppid()
@@ -275,38 +339,23 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 	_ = <-sigChan
 	loger.Info().Msg("Received signal, shutting down...")
 
-	// close all services
-	// close all services
-	var wg sync.WaitGroup
-	done := make(chan struct{})
-
-	// 在goroutine中等待所有服务关闭
+	// Running tool calls get shutdown_timeout seconds to finish before they are cancelled, a second signal
+	// cancels them at once. The services are closed after them.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(mlConfig.ShutdownTimeout)*time.Second)
 	go func() {
-		for srvName, closer := range closers {
-			wg.Add(1)
-			go func(name string, closeFn func() error) {
-				defer wg.Done()
-				err := closeFn()
-				if err != nil {
-					loger.Error().Err(err).Msgf("failed to close service %s", name)
-				} else {
-					loger.Info().Msgf("service %s closed", name)
-				}
-			}(srvName, closer)
+		select {
+		case <-sigChan:
+			loger.Warn().Msg("Received another signal, cancelling the running tool calls")
+			cancelShutdown()
+		case <-shutdownCtx.Done():
 		}
-
-		// 等待所有服务关闭
-		wg.Wait()
-		close(done)
 	}()
-
-	// 使用select等待完成或超时
-	select {
-	case <-time.After(5 * time.Second):
-		cancelFunc()
-		loger.Info().Msg("timeout, all services closed forcefully")
-	case <-done:
-		cancelFunc()
+	err = srv.Shutdown(shutdownCtx)
+	cancelShutdown()
+	cancelFunc()
+	if err != nil {
+		loger.Error().Err(err).Msg("failed to shut down cleanly")
+	} else {
 		loger.Info().Msg("all services closed")
 	}
 	err = utils.RemovePIDFile(pidFilePath)
@@ -316,5 +365,89 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 	}
 	loger.Info().Msgf("removed pid file %s", pidFilePath)
 	loger.Info().Msg(" Bye!")
+	_ = logWriter.Sync()
 	return nil
 }
+
+// loadServices creates and configures the services of mlConfig.Module and initializes them concurrently, every
+// service after the services it depends on. It returns the services that started, the factories of their
+// per-session instances and the errors of the services that failed, which do not stop the others.
+func loadServices(ctx context.Context, loader *configLoader, loger zerolog.Logger) ([]abstract.Service, map[comm.MoLingServerType]server.SessionFactory, map[comm.MoLingServerType]error) {
+	var modules []string
+	if mlConfig.Module != "all" {
+		modules = strings.Split(mlConfig.Module, ",")
+	}
+	var created []abstract.Service
+	var configs = make(map[comm.MoLingServerType]map[string]any)
+	var failed = make(map[comm.MoLingServerType]error)
+	for srvName, nsv := range services.ServiceList() {
+		if len(modules) > 0 {
+			if !utils.StringInSlice(string(srvName), modules) {
+				loger.Debug().Str("moduleName", string(srvName)).Msgf("module %s not in %v, skip", string(srvName), modules)
+				continue
+			}
+			loger.Debug().Str("moduleName", string(srvName)).Msgf("starting %s service", srvName)
+		}
+		srv, err := nsv(serviceContext(ctx, srvName))
+		if err != nil {
+			loger.Error().Err(err).Msgf("failed to create service %s", srvName)
+			failed[srvName] = fmt.Errorf("failed to create the service: %w", err)
+			continue
+		}
+		cfg := loader.section([]string{string(srvName)}, srv.Config())
+		if cfg != nil {
+			err = srv.LoadConfig(cfg)
+			if err != nil {
+				loger.Error().Err(err).Msgf("failed to load config for service %s", srv.Name())
+				failed[srvName] = fmt.Errorf("invalid configuration: %w", err)
+				continue
+			}
+		}
+		configs[srvName] = cfg
+		created = append(created, srv)
+	}
+
+	var srvs []abstract.Service
+	var sessionFactories = make(map[comm.MoLingServerType]server.SessionFactory)
+	for _, s := range abstract.StartServices(ctx, created) {
+		var pe *abstract.PreflightError
+		if errors.As(s.Err, &pe) {
+			loger.Warn().Str("serviceName", string(s.Name)).Str("reason", pe.Error()).Msgf("service %s disabled, MoLing runs without it", s.Name)
+			failed[s.Name] = fmt.Errorf("service disabled: %w", s.Err)
+			continue
+		}
+		if s.Err != nil {
+			loger.Error().Err(s.Err).Str("serviceName", string(s.Name)).Msgf("failed to init service %s, MoLing runs without it", s.Name)
+			failed[s.Name] = fmt.Errorf("failed to initialize the service: %w", s.Err)
+			continue
+		}
+		if len(s.Missing) > 0 {
+			loger.Warn().Str("serviceName", string(s.Name)).Interface("missing", s.Missing).Msg("service started without some of the services it depends on")
+		}
+		loger.Debug().Str("serviceName", string(s.Name)).Dur("duration", s.Duration).Msg("service initialized")
+		srvs = append(srvs, s.Service)
+		sessionFactories[s.Name] = sessionFactory(s.Name, services.ServiceList()[s.Name], configs[s.Name])
+	}
+	return srvs, sessionFactories, failed
+}
+
+// sessionFactory creates per-session instances of a service with the same configuration as the shared one.
+func sessionFactory(name comm.MoLingServerType, nsv abstract.ServiceFactory, cfg map[string]any) server.SessionFactory {
+	return func(ctx context.Context) (abstract.Service, error) {
+		srv, err := nsv(serviceContext(ctx, name))
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			err = srv.LoadConfig(cfg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = srv.Init()
+		if err != nil {
+			return nil, err
+		}
+		return srv, nil
+	}
+}
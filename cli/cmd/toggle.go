@@ -0,0 +1,116 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services"
+)
+
+var toggleCmd = &cobra.Command{
+	Use:   "toggle",
+	Short: "Disable or enable services and tools of running MoLing servers",
+	Long: `Disable or enable whole services or single tools at runtime. Running MoLing servers pick up the change within seconds and notify their clients, no restart is needed.
+    moling toggle list                                       List the disabled services and tools
+    moling toggle disable --service Command --reason "..."   Disable a service
+    moling toggle enable --tool execute_command              Enable a tool again
+`,
+}
+
+var (
+	toggleService string
+	toggleTool    string
+	toggleReason  string
+)
+
+// ToggleCommandFunc returns the function of the "toggle disable" and "toggle enable" commands.
+func ToggleCommandFunc(enable bool) func(command *cobra.Command, args []string) error {
+	return func(command *cobra.Command, args []string) error {
+		if (toggleService == "") == (toggleTool == "") {
+			return fmt.Errorf("either --service or --tool must be given")
+		}
+		service := toggleService
+		if service != "" {
+			found := false
+			for name := range services.ServiceList() {
+				if strings.EqualFold(string(name), service) {
+					service, found = string(name), true
+				}
+			}
+			if !found {
+				return fmt.Errorf("unknown service %s", toggleService)
+			}
+		}
+		path := filepath.Join(mlConfig.BasePath, server.ToggleFile)
+		t, err := server.LoadToggles(path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case enable && service != "":
+			delete(t.Services, service)
+		case enable:
+			delete(t.Tools, toggleTool)
+		case service != "":
+			t.Services[service] = toggleReason
+		default:
+			t.Tools[toggleTool] = toggleReason
+		}
+		if err = server.SaveToggles(path, t); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		return printToggles(t)
+	}
+}
+
+// ToggleListCommandFunc executes the "toggle list" command.
+func ToggleListCommandFunc(command *cobra.Command, args []string) error {
+	t, err := server.LoadToggles(filepath.Join(mlConfig.BasePath, server.ToggleFile))
+	if err != nil {
+		return err
+	}
+	return printToggles(t)
+}
+
+func printToggles(t server.Toggles) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func init() {
+	disableCmd := &cobra.Command{Use: "disable", Short: "Disable a service or tool", RunE: ToggleCommandFunc(false)}
+	enableCmd := &cobra.Command{Use: "enable", Short: "Enable a disabled service or tool", RunE: ToggleCommandFunc(true)}
+	listCmd := &cobra.Command{Use: "list", Short: "List the disabled services and tools", RunE: ToggleListCommandFunc}
+	for _, c := range []*cobra.Command{disableCmd, enableCmd} {
+		c.Flags().StringVar(&toggleService, "service", "", "Name of the service, e.g. Command")
+		c.Flags().StringVar(&toggleTool, "tool", "", "Name of the tool, e.g. execute_command")
+	}
+	disableCmd.Flags().StringVar(&toggleReason, "reason", "", "Why it is disabled, shown to clients that call it")
+	toggleCmd.AddCommand(disableCmd, enableCmd, listCmd)
+	rootCmd.AddCommand(toggleCmd)
+}
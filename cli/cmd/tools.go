@@ -0,0 +1,228 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// toolsDescriptionWidth is the length at which descriptions are cut in the table of moling tools list.
+const toolsDescriptionWidth = 80
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "List the tools MoLing offers to MCP clients",
+	Long: `Start the configured services and print the tools they register, so that you can check what an agent may do
+before connecting a client. Disabled tools are not listed.
+    moling tools list                        Print the tools with their service and parameters
+    moling tools list -m Browser,FileSystem  Print the tools of these services
+    moling tools list --service Command      Print the tools of a service, including MoLing and Jobs
+    moling tools describe execute_command    Print a tool and all of its parameters
+`,
+}
+
+var (
+	toolsService string
+	toolsJSON    bool
+)
+
+// toolParameter is a parameter of a tool, as declared in its input schema.
+type toolParameter struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+}
+
+// toolInfo describes a tool and the service that provides it.
+type toolInfo struct {
+	Name        string          `json:"name"`
+	Service     string          `json:"service"`
+	Description string          `json:"description"`
+	Parameters  []toolParameter `json:"parameters"`
+}
+
+// describeTool returns the service and the parameters of a tool, the required parameters first.
+func describeTool(tool mcp.Tool, service string) toolInfo {
+	schema := tool.InputSchema
+	if len(tool.RawInputSchema) > 0 {
+		_ = json.Unmarshal(tool.RawInputSchema, &schema)
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	info := toolInfo{Name: tool.Name, Service: service, Description: tool.Description, Parameters: make([]toolParameter, 0, len(schema.Properties))}
+	for name, property := range schema.Properties {
+		p := toolParameter{Name: name, Required: required[name]}
+		if prop, ok := property.(map[string]any); ok {
+			p.Type, _ = prop["type"].(string)
+			p.Description, _ = prop["description"].(string)
+			p.Enum, _ = prop["enum"].([]any)
+		}
+		info.Parameters = append(info.Parameters, p)
+	}
+	sort.Slice(info.Parameters, func(i, j int) bool {
+		a, b := info.Parameters[i], info.Parameters[j]
+		if a.Required != b.Required {
+			return a.Required
+		}
+		return a.Name < b.Name
+	})
+	return info
+}
+
+// signature returns the parameters of a tool for the table, the optional ones in brackets.
+func (ti toolInfo) signature() string {
+	params := make([]string, 0, len(ti.Parameters))
+	for _, p := range ti.Parameters {
+		if p.Required {
+			params = append(params, p.Name)
+		} else {
+			params = append(params, "["+p.Name+"]")
+		}
+	}
+	return strings.Join(params, " ")
+}
+
+// shortDescription returns the first line of a description, cut to width.
+func shortDescription(description string, width int) string {
+	description, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+	if r := []rune(description); len(r) > width {
+		return string(r[:width-3]) + "..."
+	}
+	return description
+}
+
+// loadTools starts the configured services and returns their tools.
+func loadTools() ([]toolInfo, error) {
+	srv, logger, err := startLocalServer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer stopLocalServer(srv, logger)
+	tools, err := srv.ListTools(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]toolInfo, 0, len(tools))
+	for _, tool := range tools {
+		infos = append(infos, describeTool(tool, string(srv.ToolService(tool.Name))))
+	}
+	return infos, nil
+}
+
+// printJSON writes v as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// ToolsListCommandFunc executes the "tools list" command.
+func ToolsListCommandFunc(command *cobra.Command, args []string) error {
+	infos, err := loadTools()
+	if err != nil {
+		return err
+	}
+	if toolsService != "" {
+		filtered := infos[:0]
+		for _, info := range infos {
+			if strings.EqualFold(info.Service, toolsService) {
+				filtered = append(filtered, info)
+			}
+		}
+		infos = filtered
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Service < infos[j].Service })
+	if toolsJSON {
+		return printJSON(command.OutOrStdout(), infos)
+	}
+	tw := tabwriter.NewWriter(command.OutOrStdout(), 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SERVICE\tTOOL\tPARAMETERS\tDESCRIPTION")
+	for _, info := range infos {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Service, info.Name, info.signature(), shortDescription(info.Description, toolsDescriptionWidth))
+	}
+	return tw.Flush()
+}
+
+// ToolsDescribeCommandFunc executes the "tools describe" command.
+func ToolsDescribeCommandFunc(command *cobra.Command, args []string) error {
+	service, name, err := splitToolName(args[0])
+	if err != nil {
+		return err
+	}
+	if service != "" {
+		mlConfig.Module = string(service)
+	}
+	infos, err := loadTools()
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if info.Name != name {
+			continue
+		}
+		if toolsJSON {
+			return printJSON(command.OutOrStdout(), info)
+		}
+		w := command.OutOrStdout()
+		_, _ = fmt.Fprintf(w, "Tool:     %s\nService:  %s\n\n%s\n", info.Name, info.Service, strings.TrimSpace(info.Description))
+		if len(info.Parameters) == 0 {
+			_, _ = fmt.Fprintln(w, "\nNo parameters.")
+			return nil
+		}
+		_, _ = fmt.Fprintln(w, "\nParameters:")
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, p := range info.Parameters {
+			required := "optional"
+			if p.Required {
+				required = "required"
+			}
+			description := p.Description
+			if len(p.Enum) > 0 {
+				description = strings.TrimSpace(fmt.Sprintf("%s (one of %v)", description, p.Enum))
+			}
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", p.Name, p.Type, required, description)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("tool %s not found, see moling tools list", name)
+}
+
+func init() {
+	listCmd := &cobra.Command{Use: "list", Short: "Print the tools of the configured services", Args: cobra.NoArgs, RunE: ToolsListCommandFunc}
+	listCmd.Flags().StringVar(&toolsService, "service", "", "Print only the tools of this service, e.g. FileSystem")
+	listCmd.Flags().BoolVar(&toolsJSON, "json", false, "Print the tools as JSON")
+	describeCmd := &cobra.Command{Use: "describe <tool>", Short: "Print a tool and its parameters", Args: cobra.ExactArgs(1), RunE: ToolsDescribeCommandFunc}
+	describeCmd.Flags().BoolVar(&toolsJSON, "json", false, "Print the tool as JSON")
+	toolsCmd.AddCommand(listCmd, describeCmd)
+	rootCmd.AddCommand(toolsCmd)
+}
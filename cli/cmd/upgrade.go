@@ -0,0 +1,132 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/upgrade"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade MoLing to the latest release",
+	Long: `Download the latest release of MoLing from GitHub, verify its checksum and signature, and replace the running
+binary. The previous binary is kept next to it, with the .old suffix, until the next upgrade.
+    moling upgrade --check         Only tell whether a newer release is available
+    moling upgrade                 Upgrade to the latest release after confirmation
+    moling upgrade --version v0.2.0 --yes
+    moling upgrade --rollback      Go back to the previous binary
+Running MoLing servers, such as the daemon, keep the previous binary until they are restarted.
+Set GITHUB_TOKEN if the GitHub API limits the requests.
+`,
+	Args: cobra.NoArgs,
+	RunE: UpgradeCommandFunc,
+}
+
+var (
+	upgradeCheck    bool
+	upgradeVersion  string
+	upgradeRollback bool
+	upgradeForce    bool
+	upgradeYes      bool
+)
+
+// UpgradeCommandFunc executes the "upgrade" command.
+func UpgradeCommandFunc(command *cobra.Command, args []string) error {
+	out := command.OutOrStdout()
+	target, err := upgrade.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the MoLing binary: %w", err)
+	}
+	if upgradeRollback {
+		if err = upgrade.Rollback(target); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "Rolled back %s, the replaced binary is kept as %s\n", target, upgrade.BackupPath(target))
+		return err
+	}
+
+	u, err := upgrade.New(upgrade.PublicKey)
+	if err != nil {
+		return err
+	}
+	var rel *upgrade.Release
+	if upgradeVersion != "" {
+		rel, err = u.Release(command.Context(), upgradeVersion)
+	} else {
+		rel, err = u.Latest(command.Context())
+	}
+	if err != nil {
+		return err
+	}
+	current, _ := upgrade.Version(GitVersion)
+	if current == "" {
+		current = GitVersion
+	}
+	if !upgradeForce && upgradeVersion == "" && !upgrade.Newer(rel.Tag, current) {
+		_, err = fmt.Fprintf(out, "MoLing %s is up to date, the latest release is %s\n", current, rel.Tag)
+		return err
+	}
+	if upgradeCheck {
+		_, err = fmt.Fprintf(out, "MoLing %s is available, this is %s: %s\n", rel.Tag, current, rel.URL)
+		return err
+	}
+	if !upgradeYes {
+		fmt.Fprintf(out, "Replace %s (%s) with MoLing %s? (y/N): ", target, current, rel.Tag)
+		answer, _ := bufio.NewReader(command.InOrStdin()).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			_, err = fmt.Fprintln(out, "Skipped.")
+			return err
+		}
+	}
+	if u.PublicKey == nil {
+		fmt.Fprintln(command.ErrOrStderr(), "warning: this build has no public key of the releases, only the checksum is verified")
+	}
+	fmt.Fprintf(out, "Downloading %s...\n", upgrade.ArchiveName(rel.Tag, runtime.GOOS, runtime.GOARCH))
+	binary, err := u.Download(command.Context(), rel, filepath.Dir(target))
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("%w, run the upgrade as the owner of %s", err, target)
+		}
+		return err
+	}
+	backup, err := upgrade.Install(binary, target)
+	if err != nil {
+		_ = os.Remove(binary)
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Upgraded %s to MoLing %s, the previous binary is kept as %s, 'moling upgrade --rollback' restores it\n", target, rel.Tag, backup)
+	return err
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only tell whether a newer release is available")
+	upgradeCmd.Flags().StringVar(&upgradeVersion, "version", "", "Install the release with this tag, such as v0.2.0, instead of the latest")
+	upgradeCmd.Flags().BoolVar(&upgradeRollback, "rollback", false, "Replace the binary with the one kept by the last upgrade")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Reinstall the latest release even if it is not newer")
+	upgradeCmd.Flags().BoolVarP(&upgradeYes, "yes", "y", false, "Upgrade without asking")
+	rootCmd.AddCommand(upgradeCmd)
+}
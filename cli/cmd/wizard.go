@@ -0,0 +1,324 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/client"
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/command"
+	"github.com/gojue/moling/pkg/services/filesystem"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up MoLing interactively: choose the services, the allowed directories and commands, and get the MCP client configuration",
+	Long: `Asks which services to enable, checks that Chrome is installed for the Browser service, proposes directories the FileSystem service may access, writes the configuration file and prints the configuration of Claude Desktop, Cline and Cherry Studio.
+    moling init           Answer the questions
+    moling init --yes     Accept all proposals
+`,
+	Args: cobra.NoArgs,
+	RunE: InitCommandFunc,
+}
+
+var (
+	initYes   bool
+	initForce bool
+)
+
+// wizard asks questions on the terminal. With yes set, it takes the proposed answers without asking.
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	yes bool
+}
+
+// ask prints the question with the proposed answer and returns the answer, or the proposal if the answer is empty.
+func (w *wizard) ask(question, proposal string) (string, error) {
+	if proposal != "" {
+		question = fmt.Sprintf("%s [%s]", question, proposal)
+	}
+	if w.yes {
+		_, _ = fmt.Fprintf(w.out, "%s: %s\n", question, proposal)
+		return proposal, nil
+	}
+	_, _ = fmt.Fprintf(w.out, "%s: ", question)
+	line, err := w.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("no answer: %w", err)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line, nil
+	}
+	return proposal, nil
+}
+
+// confirm asks a yes or no question.
+func (w *wizard) confirm(question string, proposal bool) (bool, error) {
+	p := "y/N"
+	if proposal {
+		p = "Y/n"
+	}
+	for {
+		answer, err := w.ask(question+" ("+p+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return proposal, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// selectServices asks for a comma-separated list of services and returns their registered names.
+func (w *wizard) selectServices(proposal []string) ([]string, error) {
+	names := serviceNames()
+	all := make([]string, 0, len(names))
+	for _, name := range names {
+		all = append(all, string(name))
+	}
+	_, _ = fmt.Fprintf(w.out, "Available services: %s\n", strings.Join(all, ", "))
+	for {
+		answer, err := w.ask("Services to enable, comma-separated, or all", strings.Join(proposal, ","))
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(answer, "all") {
+			return all, nil
+		}
+		var selected, unknown []string
+		for _, s := range strings.Split(answer, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			path, err := resolveKey(s, stringSet(all))
+			if err != nil {
+				unknown = append(unknown, s)
+				continue
+			}
+			selected = append(selected, path[0])
+		}
+		if len(unknown) == 0 && len(selected) > 0 {
+			return selected, nil
+		}
+		if w.yes {
+			return nil, fmt.Errorf("unknown services %s", strings.Join(unknown, ", "))
+		}
+		_, _ = fmt.Fprintf(w.out, "Unknown services: %s\n", strings.Join(unknown, ", "))
+	}
+}
+
+func stringSet(values []string) map[string]any {
+	set := make(map[string]any, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// proposedDirs returns the usual document folders of the user that exist, and the data directory of MoLing.
+// The home directory itself is not proposed, it holds keys and credentials.
+func proposedDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range []string{"Documents", "Downloads", "Desktop"} {
+			dir := filepath.Join(home, name)
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return append(dirs, filepath.Join(mlConfig.BasePath, "data"))
+}
+
+// selectDirs asks for the directories the FileSystem service may access, and warns about the root and home
+// directories.
+func (w *wizard) selectDirs() ([]string, error) {
+	home, _ := os.UserHomeDir()
+	for {
+		answer, err := w.ask("Directories the FileSystem service may access, comma-separated", strings.Join(proposedDirs(), ","))
+		if err != nil {
+			return nil, err
+		}
+		var dirs []string
+		var problems []string
+		for _, dir := range strings.Split(answer, ",") {
+			if dir = strings.TrimSpace(dir); dir == "" {
+				continue
+			}
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+				problems = append(problems, fmt.Sprintf("%s is not a directory", abs))
+				continue
+			}
+			if abs == filepath.VolumeName(abs)+string(filepath.Separator) || abs == home {
+				ok, err := w.confirm(fmt.Sprintf("%s gives access to all files below it, including keys and credentials. Allow it anyway?", abs), false)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					problems = append(problems, fmt.Sprintf("%s was not allowed", abs))
+					continue
+				}
+			}
+			dirs = append(dirs, abs)
+		}
+		if len(problems) == 0 && len(dirs) > 0 {
+			return dirs, nil
+		}
+		if w.yes {
+			return nil, errors.New(strings.Join(problems, "; "))
+		}
+		_, _ = fmt.Fprintln(w.out, strings.Join(problems, "\n"))
+	}
+}
+
+// InitCommandFunc executes the "init" command.
+func InitCommandFunc(cmd *cobra.Command, args []string) error {
+	ctx, logger := configContext()
+	w := &wizard{in: bufio.NewReader(os.Stdin), out: os.Stdout, yes: initYes}
+	file, err := loadConfigFile()
+	if err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
+	}
+	if _, err = os.Stat(file.Path); err == nil && !initForce {
+		if w.yes {
+			return fmt.Errorf("%s exists, use --force to replace it", file.Path)
+		}
+		replace, err := w.confirm(fmt.Sprintf("%s exists. Replace it?", file.Path), false)
+		if err != nil {
+			return err
+		}
+		if !replace {
+			_, _ = fmt.Fprintln(w.out, "Nothing was changed. Use moling config set to change single keys.")
+			return nil
+		}
+	}
+
+	chrome := browser.FindExecPath()
+	proposal := []string{string(filesystem.FilesystemServerName), string(command.CommandServerName)}
+	if chrome != "" {
+		_, _ = fmt.Fprintf(w.out, "Chrome found at %s\n", chrome)
+		proposal = append(proposal, string(browser.BrowserServerName))
+	} else {
+		_, _ = fmt.Fprintln(w.out, "Chrome was not found. The Browser service needs Chrome or Chromium, on Windows in the PATH.")
+	}
+	selected, err := w.selectServices(proposal)
+	if err != nil {
+		return err
+	}
+	if _, ok := stringSet(selected)[string(browser.BrowserServerName)]; ok && chrome == "" {
+		keep, err := w.confirm("Enable the Browser service without Chrome?", false)
+		if err != nil {
+			return err
+		}
+		if !keep {
+			selected = without(selected, string(browser.BrowserServerName))
+		}
+	}
+	if len(selected) == 0 {
+		return errors.New("no service was selected")
+	}
+
+	defaults, err := configDefaults(ctx)
+	if err != nil {
+		return err
+	}
+	file.Data = make(map[string]any)
+	for _, name := range selected {
+		file.Data[name] = defaults[name]
+	}
+	if section := file.Section(string(filesystem.FilesystemServerName)); section != nil {
+		dirs, err := w.selectDirs()
+		if err != nil {
+			return err
+		}
+		section["allowed_dir"] = strings.Join(dirs, ",")
+	}
+	if section := file.Section(string(command.CommandServerName)); section != nil {
+		commands, err := w.ask("Commands the Command service may run, comma-separated", fmt.Sprint(section["allowed_command"]))
+		if err != nil {
+			return err
+		}
+		section["allowed_command"] = commands
+	}
+	if _, err = loadEffectiveConfig(ctx, file, logger, false); err != nil {
+		return err
+	}
+	if err = file.Save(); err != nil {
+		return fmt.Errorf("error writing %s: %w", file.Path, err)
+	}
+	_, _ = fmt.Fprintf(w.out, "\nConfiguration written to %s\n", file.Path)
+
+	mcpConfig := client.NewMCPServerConfig(CliDescription, CliName, MCPServerName)
+	if exePath, err := os.Executable(); err == nil {
+		mcpConfig.Command = exePath
+	}
+	mcpConfig.Args = nil
+	if len(selected) < len(serviceNames()) {
+		mcpConfig.Args = []string{"-m", strings.Join(selected, ",")}
+	}
+	for _, name := range client.SnippetClients {
+		snippet, err := client.Snippet(name, mcpConfig)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w.out, "\n%s, add to mcpServers:\n%s\n", name, snippet)
+	}
+	setup, err := w.confirm("\nAdd MoLing to the MCP clients installed on this computer now?", false)
+	if err != nil {
+		return err
+	}
+	if setup {
+		client.NewManager(logger, mcpConfig).SetupConfig()
+	}
+	return nil
+}
+
+func without(values []string, value string) []string {
+	out := values[:0:0]
+	for _, v := range values {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept all proposals without asking")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Replace an existing configuration file")
+	rootCmd.AddCommand(initCmd)
+}
@@ -21,8 +21,6 @@
 package client
 
 import (
-	"encoding/json"
-	"errors"
 	"os"
 
 	"github.com/rs/zerolog"
@@ -91,56 +89,23 @@ func (c *Manager) ListClient() {
 	return
 }
 
-// SetupConfig sets up the configuration for the clients.
+// SetupConfig adds the MCP Server to the configuration files of the clients that exist, backing up each file first.
 func (c *Manager) SetupConfig() {
 	for name, path := range c.clients {
 		c.logger.Debug().Msgf("Client %s: %s", name, path)
 		if !c.checkExist(path) {
 			continue
 		}
-		// read config file
-		file, err := os.ReadFile(path)
-		if err != nil {
-			c.logger.Error().Str("Client Name", name).Msgf("Failed to open config file %s: %s", path, err)
-			continue
-		}
-		c.logger.Debug().Str("Client Name", name).Str("config", string(file)).Send()
-		b, err := c.appendConfig(c.mcpConfig.ServerName, file)
+		ch, err := c.PlanInstall(name)
 		if err != nil {
 			c.logger.Error().Str("Client Name", name).Msgf("Failed to append config file %s: %s", path, err)
 			continue
 		}
-		c.logger.Debug().Str("Client Name", name).Str("newConfig", string(b)).Send()
-		// write config file
-		err = os.WriteFile(path, b, 0644)
-		if err != nil {
+		c.logger.Debug().Str("Client Name", name).Str("newConfig", string(ch.New)).Send()
+		if _, err = c.Apply(ch); err != nil {
 			c.logger.Error().Str("Client Name", name).Msgf("Failed to write config file %s: %s", path, err)
-			continue
 		}
-		c.logger.Info().Str("Client Name", name).Msgf("Successfully added config to %s", path)
-	}
-}
-
-// appendConfig appends the mlMCPConfig to the client config.
-func (c *Manager) appendConfig(name string, payload []byte) ([]byte, error) {
-	var err error
-	var jsonMap map[string]any
-	var jsonBytes []byte
-	err = json.Unmarshal(payload, &jsonMap)
-	if err != nil {
-		return nil, err
-	}
-	jsonMcpServer, ok := jsonMap[MCPServersKey].(map[string]any)
-	if !ok {
-		return nil, errors.New("MCPServersKey not found in JSON")
-	}
-	jsonMcpServer[name] = c.mcpConfig
-	jsonMap[MCPServersKey] = jsonMcpServer
-	jsonBytes, err = json.MarshalIndent(jsonMap, "", "  ")
-	if err != nil {
-		return nil, err
 	}
-	return jsonBytes, nil
 }
 
 // checkExist checks if the file at the given path exists.
@@ -1,5 +1,3 @@
-//go:build !windows
-
 /*
  *
  *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
@@ -28,14 +26,28 @@ import (
 )
 
 func init() {
-	clientLists["VSCode Cline"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")
-	clientLists["Trae CN Cline"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae CN", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")
-	clientLists["Trae Cline"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")
-	clientLists["Trae"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae", "User", "mcp.json")
-	clientLists["Trae CN"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae CN", "User", "mcp.json")
-	clientLists["VSCode Roo"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "mcp_settings.json")
-	clientLists["Trae CN Roo"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae CN", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "mcp_settings.json")
-	clientLists["Trae Roo"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Trae", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "mcp_settings.json")
-	clientLists["Claude"] = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "Claude", "claude_desktop_config.json")
-	clientLists["Cursor"] = filepath.Join(os.Getenv("HOME"), ".cursor", "mcp.json")
+	// The user configuration directory is ~/Library/Application Support on macOS, %APPDATA% on Windows and
+	// ~/.config on Linux.
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	for _, editor := range []struct{ name, dir string }{
+		{"VSCode", "Code"},
+		{"Trae", "Trae"},
+		{"Trae CN", "Trae CN"},
+	} {
+		storage := filepath.Join(configDir, editor.dir, "User", "globalStorage")
+		clientLists[editor.name+" Cline"] = filepath.Join(storage, "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")
+		clientLists[editor.name+" Roo"] = filepath.Join(storage, "rooveterinaryinc.roo-cline", "settings", "mcp_settings.json")
+	}
+	clientLists["Trae"] = filepath.Join(configDir, "Trae", "User", "mcp.json")
+	clientLists["Trae CN"] = filepath.Join(configDir, "Trae CN", "User", "mcp.json")
+	clientLists["Claude"] = filepath.Join(configDir, "Claude", "claude_desktop_config.json")
+	clientLists["Cursor"] = filepath.Join(home, ".cursor", "mcp.json")
+	clientLists["Windsurf"] = filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")
 }
@@ -21,7 +21,10 @@
 package client
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/rs/zerolog"
@@ -118,3 +121,102 @@ func TestClientManager_checkExist(t *testing.T) {
 		t.Errorf("Expected file to exist")
 	}
 }
+
+func TestSnippet(t *testing.T) {
+	mcpConfig := NewMCPServerConfig("MoLing UnitTest Description", "moling_test", "MoLing MCP Server")
+	for _, name := range SnippetClients {
+		data, err := Snippet(name, mcpConfig)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var snippet map[string]map[string]map[string]any
+		if err = json.Unmarshal(data, &snippet); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		server := snippet[MCPServersKey]["MoLing MCP Server"]
+		if server["command"] != "moling_test" || len(server["args"].([]any)) != 2 {
+			t.Errorf("%s: unexpected snippet %s", name, data)
+		}
+	}
+	if _, err := Snippet("Unknown", mcpConfig); err == nil {
+		t.Error("expected an error for an unknown client")
+	}
+}
+
+func TestClientManager_InstallUninstall(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.json")
+	original := "{\n  \"mcpServers\": {\n    \"other\": {\"command\": \"x\"}\n  },\n  \"theme\": \"dark\"\n}\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mcpConfig := NewMCPServerConfig("MoLing UnitTest Description", "moling_test", "MoLing MCP Server")
+	cm := NewManager(zerolog.Nop(), mcpConfig)
+	cm.clients = map[string]string{
+		"Cursor":       path,
+		"VSCode Cline": filepath.Join(dir, "cline", "settings.json"),
+		"Windsurf":     filepath.Join(dir, "missing", "mcp_config.json"),
+	}
+	if err := os.Mkdir(filepath.Join(dir, "cline"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := cm.PlanInstall("Cursor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ch.Diff(), "+    \"MoLing MCP Server\": {") {
+		t.Errorf("unexpected diff:\n%s", ch.Diff())
+	}
+	backup, err := cm.Apply(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(backup); string(data) != original {
+		t.Errorf("unexpected backup %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("the mode of the file changed to %v", info.Mode())
+	}
+	status := cm.Status()
+	if len(status) != 3 || !status[0].Installed || !status[1].Detected || status[1].Installed || status[2].Detected {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// Cline entries carry its extra keys, and its file is created
+	ch, err = cm.PlanInstall("VSCode Cline")
+	if err != nil || ch.Old != nil || !strings.Contains(string(ch.New), "autoApprove") {
+		t.Fatalf("unexpected change %+v, %v", ch, err)
+	}
+	if _, err = cm.PlanInstall("Windsurf"); err == nil {
+		t.Error("expected an error for a client that is not installed")
+	}
+
+	ch, err = cm.PlanUninstall("Cursor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cm.Apply(ch); err != nil {
+		t.Fatal(err)
+	}
+	ch, err = cm.PlanUninstall("Cursor")
+	if err != nil || !ch.Empty() {
+		t.Errorf("uninstalling twice should change nothing: %+v, %v", ch, err)
+	}
+}
+
+func TestChangeDiff(t *testing.T) {
+	ch := &Change{Path: "a", Old: []byte("1\n2\n3\n"), New: []byte("1\nx\n3\n")}
+	expected := "--- a\n+++ a\n@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n"
+	if got := ch.Diff(); got != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", got, expected)
+	}
+	ch = &Change{Path: "a", New: []byte("x\n")}
+	if got := ch.Diff(); got != "--- a\n+++ a\n@@ -0,0 +1 @@\n+x\n" {
+		t.Errorf("unexpected diff against nothing:\n%s", got)
+	}
+	ch = &Change{Path: "a", Old: []byte("x\n"), New: []byte("x\n")}
+	if got := ch.Diff(); got != "" {
+		t.Errorf("expected no diff, got:\n%s", got)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gojue/moling/pkg/utils/diff"
+)
+
+const (
+	diffContext  = 3    // diffContext is the number of unchanged lines shown around the changes.
+	diffMaxEdits = 2000 // diffMaxEdits bounds the diff of a file that was rewritten as a whole.
+)
+
+// ClientStatus describes an MCP client and whether the MCP Server is in its configuration.
+type ClientStatus struct {
+	Name      string
+	Path      string
+	Detected  bool // Detected is true if the configuration file or its directory exists.
+	Installed bool // Installed is true if the configuration file has an entry of the MCP Server.
+}
+
+// Change is a planned edit of the configuration file of an MCP client.
+type Change struct {
+	Client string
+	Path   string
+	Old    []byte // Old is nil if the file does not exist yet.
+	New    []byte
+}
+
+// Empty reports whether the change leaves the file as it is.
+func (ch *Change) Empty() bool {
+	return bytes.Equal(ch.Old, ch.New)
+}
+
+// Diff renders the change as a unified diff.
+func (ch *Change) Diff() string {
+	edits := diff.Lines(diff.SplitLines(string(ch.Old)), diff.SplitLines(string(ch.New)), diffMaxEdits)
+	return diff.Unified(ch.Path, ch.Path, edits, diffContext)
+}
+
+// Status returns the known MCP clients sorted by name.
+func (c *Manager) Status() []ClientStatus {
+	statuses := make([]ClientStatus, 0, len(c.clients))
+	for name, path := range c.clients {
+		s := ClientStatus{Name: name, Path: path}
+		if servers, err := c.readServers(path); err == nil {
+			s.Detected = true
+			_, s.Installed = servers[c.mcpConfig.ServerName]
+		} else if _, err = os.Stat(filepath.Dir(path)); err == nil {
+			s.Detected = true
+		}
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Find returns the name of the client that matches name case-insensitively.
+func (c *Manager) Find(name string) (string, bool) {
+	for n := range c.clients {
+		if strings.EqualFold(n, name) {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// readServers returns the mcpServers of a configuration file.
+func (c *Manager) readServers(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]any
+	if err = json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	servers, _ := config[MCPServersKey].(map[string]any)
+	return servers, nil
+}
+
+// PlanInstall returns the change that adds the MCP Server to the configuration file of the client. The file is
+// created if the client is installed but has no configuration file yet.
+func (c *Manager) PlanInstall(name string) (*Change, error) {
+	entry, err := serverEntry(entryFormat(name), c.mcpConfig)
+	if err != nil {
+		return nil, err
+	}
+	return c.plan(name, func(servers map[string]any) bool {
+		servers[c.mcpConfig.ServerName] = entry
+		return true
+	})
+}
+
+// PlanUninstall returns the change that removes the MCP Server from the configuration file of the client.
+func (c *Manager) PlanUninstall(name string) (*Change, error) {
+	return c.plan(name, func(servers map[string]any) bool {
+		_, ok := servers[c.mcpConfig.ServerName]
+		delete(servers, c.mcpConfig.ServerName)
+		return ok
+	})
+}
+
+// plan reads the configuration file of the client and applies edit to its mcpServers. The file is left as it is,
+// including its formatting, if edit reports no change.
+func (c *Manager) plan(name string, edit func(servers map[string]any) bool) (*Change, error) {
+	path, ok := c.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown MCP client %s", name)
+	}
+	ch := &Change{Client: name, Path: path}
+	config := make(map[string]any)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		ch.Old = data
+		if len(bytes.TrimSpace(data)) > 0 {
+			if err = json.Unmarshal(data, &config); err != nil {
+				return nil, fmt.Errorf("%s is not valid JSON, fix it first: %w", path, err)
+			}
+		}
+	case os.IsNotExist(err):
+		if _, err = os.Stat(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("%s is not installed, %s does not exist", name, filepath.Dir(path))
+		}
+	default:
+		return nil, err
+	}
+	servers, ok := config[MCPServersKey].(map[string]any)
+	if !ok {
+		servers = make(map[string]any)
+	}
+	if !edit(servers) {
+		ch.New = ch.Old
+		return ch, nil
+	}
+	config[MCPServersKey] = servers
+	if ch.New, err = json.MarshalIndent(config, "", "  "); err != nil {
+		return nil, err
+	}
+	ch.New = append(ch.New, '\n')
+	if ch.Old != nil && !bytes.HasSuffix(ch.Old, []byte("\n")) {
+		ch.New = ch.New[:len(ch.New)-1]
+	}
+	return ch, nil
+}
+
+// Apply writes the change, after copying the current file to a backup next to it. It returns the path of the
+// backup, or "" if there was no file.
+func (c *Manager) Apply(ch *Change) (string, error) {
+	mode := os.FileMode(0o644)
+	backup := ""
+	if ch.Old != nil {
+		if info, err := os.Stat(ch.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		backup = fmt.Sprintf("%s.%s.bak", ch.Path, time.Now().Format("20060102150405"))
+		if err := os.WriteFile(backup, ch.Old, mode); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", ch.Path, err)
+		}
+	}
+	if err := os.WriteFile(ch.Path, ch.New, mode); err != nil {
+		return backup, fmt.Errorf("failed to write %s: %w", ch.Path, err)
+	}
+	c.logger.Info().Str("Client Name", ch.Client).Str("backup", backup).Msgf("Successfully updated %s", ch.Path)
+	return backup, nil
+}
+
+// entryFormat returns the snippet format of a client: Cline and Roo Code keep extra keys, the others use the
+// format of Claude Desktop.
+func entryFormat(name string) string {
+	if strings.Contains(name, "Cline") || strings.Contains(name, "Roo") {
+		return SnippetCline
+	}
+	return SnippetClaude
+}
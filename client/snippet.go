@@ -0,0 +1,72 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling
+ *
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The MCP clients whose configuration format Snippet knows.
+const (
+	SnippetClaude       = "Claude Desktop"
+	SnippetCline        = "Cline"
+	SnippetCherryStudio = "Cherry Studio"
+)
+
+// SnippetClients lists the clients of Snippet in the order they are shown.
+var SnippetClients = []string{SnippetClaude, SnippetCline, SnippetCherryStudio}
+
+// Snippet returns the JSON that adds the MCP Server to the configuration file of the given client, to be merged
+// into its mcpServers by hand.
+func Snippet(clientName string, cfg MCPServerConfig) ([]byte, error) {
+	entry, err := serverEntry(clientName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(map[string]any{MCPServersKey: map[string]any{cfg.ServerName: entry}}, "", "  ")
+}
+
+// serverEntry returns the entry of the MCP Server in mcpServers in the format of the given client.
+func serverEntry(clientName string, cfg MCPServerConfig) (map[string]any, error) {
+	entry := map[string]any{
+		"command": cfg.Command,
+		"args":    cfg.Args,
+	}
+	if cfg.Args == nil {
+		entry["args"] = []string{}
+	}
+	switch clientName {
+	case SnippetClaude:
+	case SnippetCline:
+		entry["disabled"] = false
+		entry["autoApprove"] = []string{}
+		entry["timeout"] = cfg.TimeOut
+	case SnippetCherryStudio:
+		entry["name"] = cfg.ServerName
+		entry["description"] = cfg.Description
+		entry["isActive"] = cfg.IsActive
+		entry["type"] = "stdio"
+	default:
+		return nil, fmt.Errorf("unknown MCP client %s", clientName)
+	}
+	return entry, nil
+}
@@ -0,0 +1,333 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package audit keeps a trail of the tool calls. Entries are recorded to a SQLite database in a directory, so that
+// they can be searched, exported and removed after the retention period.
+package audit
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/utils/sqlitedb"
+)
+
+// Status is the outcome of a tool call.
+type Status string
+
+const (
+	StatusOK    Status = "ok"
+	StatusError Status = "error"
+)
+
+const (
+	// DBFile is the name of the database in the audit directory.
+	DBFile    = "audit.db"
+	dayLayout = "2006-01-02"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	time        INTEGER NOT NULL,
+	service     TEXT NOT NULL DEFAULT '',
+	tool        TEXT NOT NULL,
+	session     TEXT NOT NULL DEFAULT '',
+	principal   TEXT NOT NULL DEFAULT '',
+	args        TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT '',
+	error_code  TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS entries_time ON entries (time);
+`
+
+// ErrClosed is returned when recording to a closed store.
+var ErrClosed = errors.New("the audit store is closed")
+
+// Entry is the record of a tool call.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	Service   string         `json:"service,omitempty"`
+	Tool      string         `json:"tool"`
+	Session   string         `json:"session,omitempty"`
+	Principal string         `json:"principal,omitempty"` // Principal is the authenticated client, empty for unrestricted clients.
+	Args      map[string]any `json:"args,omitempty"`      // Args are the arguments of the call, with credentials redacted.
+	Status    Status         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	ErrorCode string         `json:"error_code,omitempty"` // ErrorCode is the machine-readable kind of the error, such as not_found.
+	Duration  int64          `json:"duration_ms"`
+}
+
+// Query selects entries. Empty fields match all entries.
+type Query struct {
+	Service   string
+	Tool      string // Tool is a tool name or a pattern such as browser_*.
+	Session   string
+	Principal string
+	Status    Status
+	Text      string // Text must appear in the arguments or the error of the entries.
+	Since     time.Time
+	Until     time.Time
+	Limit     int // Limit is the maximum number of entries, 0 for all.
+}
+
+// Match reports whether an entry is selected by the query.
+func (q Query) Match(e Entry) bool {
+	if q.Service != "" && !strings.EqualFold(q.Service, e.Service) {
+		return false
+	}
+	if q.Tool != "" && q.Tool != e.Tool {
+		if ok, _ := path.Match(q.Tool, e.Tool); !ok {
+			return false
+		}
+	}
+	if (q.Session != "" && q.Session != e.Session) || (q.Principal != "" && q.Principal != e.Principal) || (q.Status != "" && q.Status != e.Status) {
+		return false
+	}
+	if (!q.Since.IsZero() && e.Time.Before(q.Since)) || (!q.Until.IsZero() && e.Time.After(q.Until)) {
+		return false
+	}
+	if q.Text != "" {
+		args, _ := json.Marshal(e.Args)
+		if !strings.Contains(string(args), q.Text) && !strings.Contains(e.Error, q.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store records entries to the database of a directory and removes the entries older than the retention period.
+type Store struct {
+	dir       string
+	retention int
+	logger    zerolog.Logger
+	db        *sql.DB
+
+	lock   sync.Mutex
+	day    string
+	closed bool
+}
+
+// Open returns a store that keeps its entries in dir for retention days, forever if 0.
+func Open(dir string, retention int, logger zerolog.Logger) (*Store, error) {
+	db, err := sqlitedb.Open(filepath.Join(dir, DBFile), schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit database: %w", err)
+	}
+	s := &Store{dir: dir, retention: retention, logger: logger, db: db}
+	s.prune(time.Now())
+	return s, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Record adds an entry to the store. The expired entries are removed once a day.
+func (s *Store) Record(e Entry) error {
+	var args []byte
+	if len(e.Args) > 0 {
+		var err error
+		if args, err = json.Marshal(e.Args); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if day := e.Time.UTC().Format(dayLayout); day != s.day {
+		s.day = day
+		s.prune(e.Time)
+	}
+	_, err := s.db.Exec(`INSERT INTO entries (time, service, tool, session, principal, args, status, error, error_code, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Time.UnixNano(), e.Service, e.Tool, e.Session, e.Principal, string(args), e.Status, e.Error, e.ErrorCode, e.Duration)
+	if err != nil {
+		return fmt.Errorf("failed to record the tool call: %w", err)
+	}
+	return nil
+}
+
+// Search returns the entries of the store that match the query, the newest first.
+func (s *Store) Search(q Query) ([]Entry, error) {
+	return search(s.db, q)
+}
+
+// Close closes the database of the store. Entries recorded afterwards are rejected with ErrClosed.
+func (s *Store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.db.Close()
+}
+
+// prune removes the entries of the days before the retention period.
+func (s *Store) prune(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	oldest, _ := time.Parse(dayLayout, now.UTC().AddDate(0, 0, -s.retention).Format(dayLayout))
+	if _, err := s.db.Exec(`DELETE FROM entries WHERE time < ?`, oldest.UnixNano()); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to remove the expired audit entries")
+	}
+}
+
+// Search returns the entries of the audit database in dir that match the query, the newest first. The database is
+// opened on its own, so that the trail of a running server can be searched from another process.
+func Search(dir string, q Query) ([]Entry, error) {
+	file := filepath.Join(dir, DBFile)
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	db, err := sqlitedb.Open(file, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return search(db, q)
+}
+
+// search selects the entries by time, service, session, principal and status in the database, then matches the
+// tool patterns and the text of the query on the entries.
+func search(db *sql.DB, q Query) ([]Entry, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+	if !q.Since.IsZero() {
+		add("time >= ?", q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		add("time <= ?", q.Until.UnixNano())
+	}
+	if q.Service != "" {
+		add("service = ? COLLATE NOCASE", q.Service)
+	}
+	if q.Session != "" {
+		add("session = ?", q.Session)
+	}
+	if q.Principal != "" {
+		add("principal = ?", q.Principal)
+	}
+	if q.Status != "" {
+		add("status = ?", q.Status)
+	}
+	query := `SELECT time, service, tool, session, principal, args, status, error, error_code, duration_ms FROM entries`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := db.Query(query+" ORDER BY time DESC, id DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the audit trail: %w", err)
+	}
+	defer rows.Close()
+	var found []Entry
+	for rows.Next() {
+		var e Entry
+		var t int64
+		var rawArgs string
+		if err = rows.Scan(&t, &e.Service, &e.Tool, &e.Session, &e.Principal, &rawArgs, &e.Status, &e.Error, &e.ErrorCode, &e.Duration); err != nil {
+			return nil, fmt.Errorf("failed to search the audit trail: %w", err)
+		}
+		e.Time = time.Unix(0, t)
+		if rawArgs != "" {
+			if err = json.Unmarshal([]byte(rawArgs), &e.Args); err != nil {
+				return nil, fmt.Errorf("failed to read the arguments of %s: %w", e.Tool, err)
+			}
+		}
+		if !q.Match(e) {
+			continue
+		}
+		found = append(found, e)
+		if q.Limit > 0 && len(found) >= q.Limit {
+			break
+		}
+	}
+	return found, rows.Err()
+}
+
+// WriteJSONL writes entries as JSON Lines.
+func WriteJSONL(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVHeader are the columns written by WriteCSV.
+var CSVHeader = []string{"time", "service", "tool", "session", "principal", "status", "duration_ms", "error", "error_code", "args"}
+
+// WriteCSV writes entries as CSV with a header line, the arguments as JSON.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var args []byte
+		if len(e.Args) > 0 {
+			args, _ = json.Marshal(e.Args)
+		}
+		err := cw.Write([]string{e.Time.Format(time.RFC3339Nano), e.Service, e.Tool, e.Session, e.Principal, string(e.Status),
+			strconv.FormatInt(e.Duration, 10), e.Error, e.ErrorCode, string(args)})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseTime parses the bounds of a query: a time in RFC 3339, a day such as 2026-10-16, or a duration such as 24h
+// meaning that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(dayLayout, s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339, a day such as 2026-10-16 or a duration such as 24h", s)
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	s, err := Open(dir, 30, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	if err = s.Record(Entry{Time: now.AddDate(0, 0, -40), Tool: "expired", Status: StatusOK}); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(dir, 30, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to reopen the store: %v", err)
+	}
+	if got, _ := Search(dir, Query{Tool: "expired"}); len(got) != 0 {
+		t.Fatal("entries older than the retention period must be removed")
+	}
+
+	yesterday := now.AddDate(0, 0, -1)
+	for _, e := range []Entry{
+		{Time: yesterday, Service: "FileSystem", Tool: "read_file", Session: "s1", Args: map[string]any{"path": "a.txt"}, Status: StatusOK},
+		{Time: now.Add(-time.Minute), Service: "FileSystem", Tool: "write_file", Session: "s1", Args: map[string]any{"path": "b.txt"}, Status: StatusOK, Duration: 3},
+		{Time: now, Service: "Browser", Tool: "browser_navigate", Session: "s2", Principal: "ci", Status: StatusError, Error: "timeout"},
+	} {
+		if err = s.Record(e); err != nil {
+			t.Fatalf("failed to record: %v", err)
+		}
+	}
+
+	all, err := s.Search(Query{})
+	if err != nil || len(all) != 3 || all[0].Tool != "browser_navigate" || all[2].Tool != "read_file" || all[1].Args["path"] != "b.txt" || all[1].Duration != 3 {
+		t.Fatalf("expected all entries, the newest first, got %+v, %v", all, err)
+	}
+	for _, c := range []struct {
+		q    Query
+		want int
+	}{
+		{Query{Service: "filesystem"}, 2},
+		{Query{Tool: "browser_*"}, 1},
+		{Query{Session: "s1", Status: StatusOK}, 2},
+		{Query{Principal: "ci"}, 1},
+		{Query{Status: StatusError}, 1},
+		{Query{Text: "b.txt"}, 1},
+		{Query{Text: "timeout"}, 1},
+		{Query{Since: now.Add(-time.Hour)}, 2},
+		{Query{Until: yesterday}, 1},
+		{Query{Limit: 2}, 2},
+	} {
+		got, err := Search(dir, c.q)
+		if err != nil || len(got) != c.want {
+			t.Errorf("%+v: expected %d entries, got %d, %v", c.q, c.want, len(got), err)
+		}
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Record(Entry{Time: now, Tool: "late"}); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if got, err := Search(t.TempDir(), Query{}); err != nil || len(got) != 0 {
+		t.Fatalf("expected no entries without a database, got %v, %v", got, err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	entries := []Entry{
+		{Time: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), Service: "Command", Tool: "execute_command", Args: map[string]any{"command": "echo \"a,b\""}, Status: StatusOK, Duration: 12},
+		{Time: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), Tool: "read_file", Status: StatusError, Error: "not found", ErrorCode: "not_found"},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("expected a header and 2 records, got %v, %v", records, err)
+	}
+	if strings.Join(records[0], ",") != strings.Join(CSVHeader, ",") || records[1][9] != `{"command":"echo \"a,b\""}` || records[2][7] != "not found" || records[2][8] != "not_found" {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	buf.Reset()
+	if err = WriteJSONL(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"status":"error"`) {
+		t.Fatalf("unexpected JSON lines %q", buf.String())
+	}
+}
@@ -32,8 +32,9 @@ type contextKey string
 
 // MoLingConfigKey is a context key for storing the version of MoLing
 const (
-	MoLingConfigKey contextKey = "moling_config"
-	MoLingLoggerKey contextKey = "moling_logger"
+	MoLingConfigKey  contextKey = "moling_config"
+	MoLingLoggerKey  contextKey = "moling_logger"
+	MoLingSessionKey contextKey = "moling_session" // MoLingSessionKey holds the client session id of a per-session service instance.
 )
 
 // InitTestEnv initializes the test environment by creating a temporary log file and setting up the logger.
@@ -0,0 +1,46 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import "fmt"
+
+// AuditConfig configures the audit trail, the record of every tool call kept for compliance.
+type AuditConfig struct {
+	Enabled       bool `json:"enabled"`        // Enabled records the tool calls to data/audit in the base path.
+	RetentionDays int  `json:"retention_days"` // RetentionDays is how long the records are kept, 0 for ever.
+	MaxArgLength  int  `json:"max_arg_length"` // MaxArgLength cuts longer string arguments, such as file contents, 0 for no limit.
+}
+
+// NewAuditConfig creates a new AuditConfig with default values.
+func NewAuditConfig() AuditConfig {
+	return AuditConfig{
+		Enabled:       false,
+		RetentionDays: 90,
+		MaxArgLength:  1024,
+	}
+}
+
+// Check validates the audit configuration.
+func (cfg *AuditConfig) Check() error {
+	if cfg.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	if cfg.MaxArgLength < 0 {
+		return fmt.Errorf("max_arg_length must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AuthConfig holds the authentication settings of the SSE transport. STDIO clients are never authenticated.
+type AuthConfig struct {
+	Enabled      bool         `json:"enabled"`       // Enabled rejects SSE requests without valid credentials.
+	APIKeys      []AuthKey    `json:"api_keys"`      // APIKeys are accepted in the X-API-Key header.
+	BearerTokens []AuthKey    `json:"bearer_tokens"` // BearerTokens are accepted in the Authorization header.
+	OAuth2       OAuth2Config `json:"oauth2"`        // OAuth2 validates the other bearer tokens with the authorization server.
+	Roles        []AuthRole   `json:"roles"`         // Roles are named sets of permissions that credentials are granted.
+	DefaultRole  string       `json:"default_role"`  // DefaultRole applies to clients that do not authenticate: STDIO clients, and SSE clients when auth is disabled.
+}
+
+// AuthKey is a static credential and the services and tools it may use, given by a role or by its own scope.
+type AuthKey struct {
+	Name string `json:"name"` // Name identifies the credential in logs.
+	Key  string `json:"key"`
+	Role string `json:"role"` // Role is the name of the role the credential is granted.
+	// Profile is the profile of the configuration file that the sessions of the credential use, empty for the
+	// configuration of the server.
+	Profile string `json:"profile"`
+	AuthScope
+}
+
+// AuthRole is a named set of permissions, such as read-only, operator or admin.
+type AuthRole struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Tools       map[string][]string `json:"tools"` // Tools maps service names, or * for all services, to the tools the role may use. An empty list allows all tools of the service.
+	Deny        []string            `json:"deny"`  // Deny are the tools the role may not use, even if Tools allows them.
+}
+
+// AuthScope limits a credential to services and tools. Tools may end with *, such as browser_*.
+type AuthScope struct {
+	Services []string `json:"services"` // Services are the services that may be used, empty allows all.
+	Tools    []string `json:"tools"`    // Tools are the tools that may be used, empty allows all tools of the allowed services.
+	Deny     []string `json:"deny"`     // Deny are the tools that may not be used, even if Tools allows them.
+}
+
+// OAuth2Config validates bearer tokens with an OAuth2 token introspection endpoint (RFC 7662).
+type OAuth2Config struct {
+	IntrospectionURL string        `json:"introspection_url"` // IntrospectionURL enables OAuth2 when set.
+	ClientID         string        `json:"client_id"`         // ClientID and ClientSecret authenticate MoLing to the introspection endpoint.
+	ClientSecret     string        `json:"client_secret"`
+	Audience         string        `json:"audience"`  // Audience, if set, must be in the aud claim of the token.
+	Scopes           []OAuth2Scope `json:"scopes"`    // Scopes map token scopes to services and tools. If set, a token needs at least one of them.
+	CacheTTL         int           `json:"cache_ttl"` // CacheTTL is how long a validated token is trusted without asking again. time.Second
+}
+
+// OAuth2Scope grants the holders of a token scope a role, or access to services and tools.
+type OAuth2Scope struct {
+	Scope string `json:"scope"`
+	Role  string `json:"role"`
+	AuthScope
+}
+
+// Scopes returns the permissions of the role as alternative scopes.
+func (r *AuthRole) Scopes() []AuthScope {
+	scopes := make([]AuthScope, 0, len(r.Tools))
+	for service, tools := range r.Tools {
+		scope := AuthScope{Tools: tools, Deny: r.Deny}
+		if service != "*" {
+			scope.Services = []string{service}
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Role returns the role with the given name.
+func (cfg *AuthConfig) Role(name string) (*AuthRole, bool) {
+	for i := range cfg.Roles {
+		if cfg.Roles[i].Name == name {
+			return &cfg.Roles[i], true
+		}
+	}
+	return nil, false
+}
+
+// checkRole reports whether a credential names an existing role and no scope of its own besides.
+func (cfg *AuthConfig) checkRole(name, role string, scope AuthScope) error {
+	if role == "" {
+		return nil
+	}
+	if _, ok := cfg.Role(role); !ok {
+		return fmt.Errorf("%q has the unknown role %q", name, role)
+	}
+	if len(scope.Services) > 0 || len(scope.Tools) > 0 || len(scope.Deny) > 0 {
+		return fmt.Errorf("%q has a role and services or tools, use one of them", name)
+	}
+	return nil
+}
+
+// Profiles returns the names of the profiles that credentials use.
+func (cfg *AuthConfig) Profiles() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, keys := range [][]AuthKey{cfg.APIKeys, cfg.BearerTokens} {
+		for _, k := range keys {
+			if k.Profile != "" && !seen[k.Profile] {
+				seen[k.Profile] = true
+				names = append(names, k.Profile)
+			}
+		}
+	}
+	return names
+}
+
+// Check validates the authentication configuration.
+func (cfg *AuthConfig) Check() error {
+	roles := make(map[string]bool, len(cfg.Roles))
+	for _, r := range cfg.Roles {
+		if r.Name == "" || roles[r.Name] {
+			return fmt.Errorf("role name %q is empty or used twice", r.Name)
+		}
+		roles[r.Name] = true
+		patterns := r.Deny
+		for _, tools := range r.Tools {
+			patterns = append(patterns[:len(patterns):len(patterns)], tools...)
+		}
+		for _, tool := range patterns {
+			if strings.Contains(strings.TrimSuffix(tool, "*"), "*") {
+				return fmt.Errorf("role %s: tool pattern %q may only end with *", r.Name, tool)
+			}
+		}
+	}
+	if cfg.DefaultRole != "" && !roles[cfg.DefaultRole] {
+		return fmt.Errorf("default_role %q is not a role", cfg.DefaultRole)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.APIKeys) == 0 && len(cfg.BearerTokens) == 0 && cfg.OAuth2.IntrospectionURL == "" {
+		return fmt.Errorf("auth is enabled but no api_keys, bearer_tokens or oauth2 introspection_url are configured")
+	}
+	seen := make(map[string]bool)
+	for _, keys := range [][]AuthKey{cfg.APIKeys, cfg.BearerTokens} {
+		for _, k := range keys {
+			if len(k.Key) < 16 {
+				return fmt.Errorf("the key of %q must have at least 16 characters", k.Name)
+			}
+			if seen[k.Key] {
+				return fmt.Errorf("the key of %q is used twice", k.Name)
+			}
+			seen[k.Key] = true
+			if err := cfg.checkRole(k.Name, k.Role, k.AuthScope); err != nil {
+				return err
+			}
+		}
+	}
+	if cfg.OAuth2.IntrospectionURL != "" {
+		u, err := url.Parse(cfg.OAuth2.IntrospectionURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("oauth2 introspection_url must be an http or https URL")
+		}
+		if cfg.OAuth2.CacheTTL < 0 {
+			return fmt.Errorf("oauth2 cache_ttl must not be negative")
+		}
+		for _, s := range cfg.OAuth2.Scopes {
+			if s.Scope == "" {
+				return fmt.Errorf("oauth2 scopes must have a scope name")
+			}
+			if err := cfg.checkRole(s.Scope, s.Role, s.AuthScope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
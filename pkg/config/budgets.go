@@ -0,0 +1,87 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Budget actions, what the server does when the child processes of a service use more memory or CPU than allowed.
+const (
+	BudgetRestart = "restart" // BudgetRestart restarts the child processes, such as Chrome.
+	BudgetRefuse  = "refuse"  // BudgetRefuse refuses the tool calls of the service until it is back within its budget.
+)
+
+// Budget limits the resources of a service. Zero values are not limited.
+type Budget struct {
+	MemoryMB    int     `json:"memory_mb"`    // MemoryMB is the resident memory of the child processes of the service, in MB.
+	CPUPercent  float64 `json:"cpu_percent"`  // CPUPercent is the CPU of the child processes between two checks, in percent of one core.
+	DiskMB      int     `json:"disk_mb"`      // DiskMB is the size of the data directory of the service, in MB.
+	DataDir     string  `json:"data_dir"`     // DataDir is the data directory, relative to the base path, data/<service in lower case> by default.
+	Action      string  `json:"action"`       // Action is restart or refuse, for memory and CPU. Over the disk budget, tool calls are refused.
+	WarnPercent int     `json:"warn_percent"` // WarnPercent warns when a resource reaches this share of its budget, 80 by default.
+}
+
+// BudgetsConfig configures the watchdog that checks the resources of services: the memory and CPU of their child
+// processes, such as Chrome, yt-dlp or sandbox runs, and the disk space of their data directories.
+type BudgetsConfig struct {
+	Interval int               `json:"interval"` // Interval is the time between two checks, in seconds.
+	Services map[string]Budget `json:"services"` // Services are the budgets by service name.
+}
+
+// NewBudgetsConfig creates a new BudgetsConfig with default values, without budgets.
+func NewBudgetsConfig() BudgetsConfig {
+	return BudgetsConfig{
+		Interval: 30,
+		Services: map[string]Budget{},
+	}
+}
+
+// Enabled reports whether any service has a budget.
+func (cfg *BudgetsConfig) Enabled() bool {
+	return len(cfg.Services) > 0
+}
+
+// IntervalDuration returns Interval as a duration, 30 seconds if it is not set.
+func (cfg *BudgetsConfig) IntervalDuration() time.Duration {
+	if cfg.Interval == 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(cfg.Interval) * time.Second
+}
+
+// Check validates the budgets configuration.
+func (cfg *BudgetsConfig) Check() error {
+	if cfg.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	for name, b := range cfg.Services {
+		if b.MemoryMB < 0 || b.CPUPercent < 0 || b.DiskMB < 0 {
+			return fmt.Errorf("the budget of %s must not be negative", name)
+		}
+		if b.WarnPercent < 0 || b.WarnPercent > 100 {
+			return fmt.Errorf("warn_percent of %s must be between 0 and 100", name)
+		}
+		switch b.Action {
+		case "", BudgetRestart, BudgetRefuse:
+		default:
+			return fmt.Errorf("unknown action %q of %s, use %s or %s", b.Action, name, BudgetRestart, BudgetRefuse)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CacheConfig caches the results of tools whose answers change slowly, such as weather forecasts and translations,
+// so that repeated calls of an agent neither wait nor reach external services again.
+type CacheConfig struct {
+	Enabled    bool           `json:"enabled"`     // Enabled turns on the cache of the tools in Tools.
+	MaxEntries int            `json:"max_entries"` // MaxEntries is the number of results kept in memory.
+	Persist    bool           `json:"persist"`     // Persist also keeps the results in the cache directory, so that they survive restarts.
+	Tools      map[string]int `json:"tools"`       // Tools are how long results are reused by tool name, in seconds. Names may end with *, such as weather_*.
+}
+
+// NewCacheConfig creates a new CacheConfig with default values. The cache is disabled by default.
+func NewCacheConfig() CacheConfig {
+	return CacheConfig{
+		Enabled:    false,
+		MaxEntries: 1000,
+		Persist:    true,
+		Tools: map[string]int{
+			"weather_*":          600,
+			"weather_find_place": 86400,
+			"translate_text":     86400,
+			"web_summarize":      3600,
+			"llm_models":         3600,
+			"graphql_schema":     3600,
+			"grpc_list":          600,
+			"grpc_describe":      600,
+		},
+	}
+}
+
+// TTL returns how long the results of a tool are reused: as set for its exact name, else for the longest matching
+// pattern. It returns 0 if the tool is not cached.
+func (cfg *CacheConfig) TTL(tool string) time.Duration {
+	if !cfg.Enabled {
+		return 0
+	}
+	if ttl, ok := cfg.Tools[tool]; ok {
+		return time.Duration(ttl) * time.Second
+	}
+	ttl, longest := 0, -1
+	for pattern, t := range cfg.Tools {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(tool, prefix) && len(prefix) > longest {
+			ttl, longest = t, len(prefix)
+		}
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// Check validates the cache configuration.
+func (cfg *CacheConfig) Check() error {
+	if cfg.Enabled && cfg.MaxEntries < 1 {
+		return fmt.Errorf("max_entries must be at least 1")
+	}
+	for name, ttl := range cfg.Tools {
+		if strings.Contains(strings.TrimSuffix(name, "*"), "*") {
+			return fmt.Errorf("tool pattern %q may only end with *", name)
+		}
+		if ttl < 0 {
+			return fmt.Errorf("%s: the ttl must not be negative", name)
+		}
+	}
+	return nil
+}
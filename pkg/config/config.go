@@ -17,6 +17,8 @@
 package config
 
 import (
+	"io"
+
 	"github.com/rs/zerolog"
 )
 
@@ -31,13 +33,31 @@ type MoLingConfig struct {
 	ConfigFile string `json:"config_file"` // The path to the configuration file.
 	BasePath   string `json:"base_path"`   // The base path for the server, used for storing files. automatically created if not exists. eg: /Users/user1/.moling
 	//AllowDir   []string `json:"allow_dir"`   // The directories that are allowed to be accessed by the server.
-	Version    string `json:"version"`     // The version of the MoLing server.
-	ListenAddr string `json:"listen_addr"` // The address to listen on for SSE mode.
-	Debug      bool   `json:"debug"`       // Debug mode, if true, the server will run in debug mode.
-	Module     string `json:"module"`      // The module to load, default: all
-	Username   string // The username of the user running the server.
-	HomeDir    string // The home directory of the user running the server. macOS: /Users/user1, Linux: /home/user1
-	SystemInfo string // The system information of the user running the server. macOS: Darwin 15.3.3, Linux: Ubuntu 20.04.1 LTS
+	Version         string            `json:"version"`          // The version of the MoLing server.
+	ListenAddr      string            `json:"listen_addr"`      // The address to listen on for SSE mode.
+	Debug           bool              `json:"debug"`            // Debug mode, if true, the server will run in debug mode.
+	Module          string            `json:"module"`           // The module to load, default: all
+	Profile         string            `json:"profile"`          // Profile is the name of the profile of the configuration file in use, empty for none.
+	ShutdownTimeout int               `json:"shutdown_timeout"` // ShutdownTimeout is how long running tool calls are waited for on shutdown, in seconds.
+	DryRun          bool              `json:"dry_run"`          // DryRun makes every tool call only describe what it would do, without changing anything.
+	Auth            AuthConfig        `json:"auth"`             // Authentication of SSE clients.
+	Sessions        SessionConfig     `json:"sessions"`         // Per-session service instances of SSE clients.
+	Limits          LimitConfig       `json:"limits"`           // Rate and concurrency limits of tool calls.
+	Elicitation     ElicitationConfig `json:"elicitation"`      // Questions to the user during tool calls.
+	Sampling        SamplingConfig    `json:"sampling"`         // Requests to the model of the client during tool calls.
+	Logging         LoggingConfig     `json:"logging"`          // The log file, its rotation and the levels of the services.
+	Cache           CacheConfig       `json:"cache"`            // Reuse of the results of tool calls.
+	Jobs            JobsConfig        `json:"jobs"`             // Tool calls run in the background.
+	Policy          PolicyConfig      `json:"policy"`           // Rules that allow, deny or ask the user to approve tool calls.
+	Audit           AuditConfig       `json:"audit"`            // The record of every tool call.
+	I18n            I18nConfig        `json:"i18n"`             // The language of tool descriptions, prompts and messages.
+	ToolNames       ToolNamesConfig   `json:"tool_names"`       // Prefixes and new names of the tools of services.
+	Budgets         BudgetsConfig     `json:"budgets"`          // Limits of the memory, CPU and disk space of services.
+	Responses       ResponseConfig    `json:"responses"`        // The size limit of tool results.
+	Stats           StatsConfig       `json:"stats"`            // Per-tool statistics of the tool calls and the log of slow calls.
+	Username        string            // The username of the user running the server.
+	HomeDir         string            // The home directory of the user running the server. macOS: /Users/user1, Linux: /home/user1
+	SystemInfo      string            // The system information of the user running the server. macOS: Darwin 15.3.3, Linux: Ubuntu 20.04.1 LTS
 
 	// for MCP Server Config
 	Description string // Description of the MCP Server, default: CliDescription
@@ -46,6 +66,7 @@ type MoLingConfig struct {
 	BaseURL     string // BaseURL , SSE mode only.
 	ServerName  string // ServerName MCP ServerName, add to the MCP Client config
 	logger      zerolog.Logger
+	logWriter   io.Writer
 }
 
 func (cfg *MoLingConfig) Check() error {
@@ -59,3 +80,13 @@ func (cfg *MoLingConfig) Logger() zerolog.Logger {
 func (cfg *MoLingConfig) SetLogger(logger zerolog.Logger) {
 	cfg.logger = logger
 }
+
+// LogWriter returns the writer of the log file that the logger writes to, nil if it is not set.
+func (cfg *MoLingConfig) LogWriter() io.Writer {
+	return cfg.logWriter
+}
+
+// SetLogWriter sets the writer of the log file, for loggers that also write elsewhere.
+func (cfg *MoLingConfig) SetLogWriter(w io.Writer) {
+	cfg.logWriter = w
+}
@@ -19,7 +19,12 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
 
 	"github.com/gojue/moling/pkg/utils"
 )
@@ -57,3 +62,391 @@ func TestConfigLoad(t *testing.T) {
 		t.Fatalf("expected BasePath to be '/newpath/.moling', got '%s'", cfg.BasePath)
 	}
 }
+
+// TestAuthRoles tests the scopes granted by roles.
+func TestAuthRoles(t *testing.T) {
+	cfg := &AuthConfig{
+		Enabled:     true,
+		DefaultRole: "read-only",
+		Roles: []AuthRole{
+			{Name: "read-only", Tools: map[string][]string{"FileSystem": {"read_*", "list_*"}}},
+			{Name: "admin", Tools: map[string][]string{"*": nil}, Deny: []string{"execute_command"}},
+		},
+		APIKeys: []AuthKey{{Name: "ops", Key: "0123456789abcdef", Role: "admin"}},
+	}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("roles must be valid: %s", err.Error())
+	}
+	role, ok := cfg.Role("admin")
+	if !ok {
+		t.Fatalf("role admin not found")
+	}
+	scopes := role.Scopes()
+	if len(scopes) != 1 || len(scopes[0].Services) != 0 || len(scopes[0].Deny) != 1 {
+		t.Fatalf("unexpected admin scopes: %+v", scopes)
+	}
+	if _, ok = cfg.Role("operator"); ok {
+		t.Fatalf("role operator must not exist")
+	}
+}
+
+// TestAuthConfigCheck tests the validation of the auth configuration.
+func TestAuthConfigCheck(t *testing.T) {
+	cfg := &AuthConfig{}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("disabled auth must be valid: %s", err.Error())
+	}
+	for _, cfg := range []*AuthConfig{
+		{Enabled: true},
+		{Enabled: true, APIKeys: []AuthKey{{Name: "short", Key: "abc"}}},
+		{Enabled: true, APIKeys: []AuthKey{{Name: "a", Key: "0123456789abcdef"}}, BearerTokens: []AuthKey{{Name: "b", Key: "0123456789abcdef"}}},
+		{Enabled: true, OAuth2: OAuth2Config{IntrospectionURL: "ftp://idp"}},
+		{Enabled: true, OAuth2: OAuth2Config{IntrospectionURL: "https://idp/introspect", Scopes: []OAuth2Scope{{}}}},
+		{Roles: []AuthRole{{Name: "admin"}, {Name: "admin"}}},
+		{Roles: []AuthRole{{Name: "ops", Tools: map[string][]string{"*": {"*_file"}}}}},
+		{DefaultRole: "missing"},
+		{Enabled: true, APIKeys: []AuthKey{{Name: "a", Key: "0123456789abcdef", Role: "missing"}}},
+		{Enabled: true, Roles: []AuthRole{{Name: "ops"}}, APIKeys: []AuthKey{{Name: "a", Key: "0123456789abcdef", Role: "ops", AuthScope: AuthScope{Tools: []string{"x"}}}}},
+	} {
+		if err := cfg.Check(); err == nil {
+			t.Fatalf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+// TestLimitConfig tests the lookup and validation of tool rate limits.
+func TestLimitConfig(t *testing.T) {
+	cfg := NewLimitConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("the default limits must be valid: %s", err.Error())
+	}
+	cfg.Tools["browser_screenshot"] = RateLimit{QPS: 1, Burst: 1}
+	cfg.Tools["browser_nav*"] = RateLimit{QPS: 3, Burst: 3}
+	for tool, qps := range map[string]float64{"browser_screenshot": 1, "browser_navigate": 3, "browser_click": 2, "read_file": 10} {
+		if l := cfg.Limit(tool); l.QPS != qps {
+			t.Fatalf("%s: expected %g qps, got %g", tool, qps, l.QPS)
+		}
+	}
+	cfg.Tools["*_file*"] = RateLimit{QPS: 1, Burst: 1}
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected an error for an inner *")
+	}
+}
+
+func TestElicitationConfig(t *testing.T) {
+	cfg := NewElicitationConfig()
+	if err := cfg.Check(); err != nil || !cfg.Enabled {
+		t.Fatalf("the default elicitation config must be valid and enabled: %v", err)
+	}
+	cfg.Timeout = -1
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected an error for a negative timeout")
+	}
+}
+
+func TestSamplingConfig(t *testing.T) {
+	cfg := NewSamplingConfig()
+	if err := cfg.Check(); err != nil || !cfg.Enabled {
+		t.Fatalf("the default sampling config must be valid and enabled: %v", err)
+	}
+	cfg.MaxTokens = 0
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected an error for max_tokens 0")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	files := map[string]string{
+		FormatJSON: "{\n  \"Log\": {\n    \"max_entries\": 100,\n    \"allowed_dirs\": [\"/var/log\"]\n  }\n}\n",
+		FormatYAML: "Log:\n  max_entries: 100\n  allowed_dirs:\n    - /var/log\n",
+		FormatTOML: "[Log]\nmax_entries = 100\nallowed_dirs = [\"/var/log\"]\n",
+	}
+	for format, content := range files {
+		f, err := ParseFile("config."+format, format, []byte(content))
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		log := f.Section("Log")
+		if log["max_entries"] != float64(100) || log["allowed_dirs"].([]any)[0] != "/var/log" {
+			t.Fatalf("%s: unexpected data %v", format, f.Data)
+		}
+		if format == FormatTOML {
+			continue
+		}
+		if p, ok := f.Position("Log.allowed_dirs[0]"); !ok || p.Line != 4 {
+			t.Errorf("%s: unexpected position %+v", format, p)
+		}
+	}
+
+	_, err := ParseFile("config.json", FormatJSON, []byte("{\n  \"Log\": {\n    \"max_entries\": 100,\n  }\n}"))
+	if err == nil || !strings.HasPrefix(err.Error(), "config.json:4:3:") {
+		t.Errorf("expected the location of the syntax error, got %v", err)
+	}
+	_, err = ParseFile("config.toml", FormatTOML, []byte("[Log]\nmax_entries = = 1\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "config.toml:2:") {
+		t.Errorf("expected the location of the syntax error, got %v", err)
+	}
+	if _, err := FileFormat("config.ini"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(files[FormatYAML]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if found := FindFile(filepath.Join(dir, "config.json")); found != filepath.Join(dir, "config.yaml") {
+		t.Errorf("expected config.yaml to be found, got %s", found)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := InferSchema(struct {
+		MaxEntries  int               `json:"max_entries"`
+		AllowedDirs []string          `json:"allowed_dirs"`
+		Debug       bool              `json:"debug"`
+		Tools       map[string]string `json:"tools"`
+		Roles       []string          `json:"roles"`
+	}{MaxEntries: 10, AllowedDirs: []string{"/tmp"}, Tools: map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseFile("config.yaml", FormatYAML, []byte("Log:\n  max_entries: many\n  allowed_dirs: [/var/log, 3]\n  Debug: true\n  tools:\n    read_file: x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := schema.Validate(f, []string{"Log"}, f.Data["Log"])
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	want := []string{
+		"config.yaml:4:3: Log.Debug: unknown key, did you mean debug?",
+		"config.yaml:3:28: Log.allowed_dirs[1]: expected string, got number",
+		"config.yaml:2:3: Log.max_entries: expected number, got string",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected validation errors:\n%s", strings.Join(messages, "\n"))
+	}
+	if !errs[0].Unknown || errs[1].Unknown {
+		t.Errorf("only the unknown key must be marked unknown")
+	}
+
+	section := map[string]any{}
+	for path, value := range map[string]string{"MAX_ENTRIES": "20", "allowed_dirs": "/a, /b", "debug": "true", "tools.read_file": `{"qps":1}`, "roles": `["admin"]`} {
+		if err := schema.Set(section, strings.Split(path, "."), value); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+	data, _ := json.Marshal(section)
+	if string(data) != `{"allowed_dirs":["/a","/b"],"debug":true,"max_entries":20,"roles":["admin"],"tools":{"read_file":{"qps":1}}}` {
+		t.Errorf("unexpected section %s", data)
+	}
+	if err := schema.Set(section, []string{"max_entries"}, "lots"); err == nil {
+		t.Errorf("expected an error for a value of the wrong type")
+	}
+	if err := schema.Set(section, []string{"missing"}, "1"); err == nil {
+		t.Errorf("expected an error for an unknown key")
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	overrides := EnvOverrides([]string{"MOLING_LOG__MAX_ENTRIES=5", "MOLING_DEBUG=true", "HOME=/root", "MOLING_LIMITS__TOOLS__READ_FILE={\"qps\":1}", "MOLING___X=1"})
+	if len(overrides) != 2 || strings.Join(overrides[0].Path, ".") != "limits.tools.read_file" || overrides[1].Value != "5" {
+		t.Fatalf("unexpected overrides %+v", overrides)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	data := map[string]any{"Log": map[string]any{"max_entries": float64(100), "paths": []any{"a", "b"}, "ratio": 0.5, "none": nil}}
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		out, err := Marshal(format, data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if format == FormatTOML && strings.Contains(string(out), "100.0") {
+			t.Errorf("whole numbers should be integers in TOML:\n%s", out)
+		}
+		f, err := ParseFile("config."+format, format, out)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, out)
+		}
+		if v, ok := Lookup(f.Data, SplitKey("log.MAX_ENTRIES")); !ok || v != float64(100) {
+			t.Errorf("%s: max_entries is %v, %v", format, v, ok)
+		}
+		if v, _ := Lookup(f.Data, []string{"Log", "ratio"}); v != 0.5 {
+			t.Errorf("%s: ratio is %v", format, v)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	defaults := map[string]any{"Log": map[string]any{"max_entries": float64(500), "paths": []any{"a"}}, "Command": map[string]any{"timeout": float64(5)}}
+	values := map[string]any{"Log": map[string]any{"max_entries": float64(100), "paths": []any{"a"}, "extra": "x"}, "Command": map[string]any{"timeout": float64(5)}}
+	changes := Diff(nil, defaults, values)
+	if len(changes) != 2 || changes[0].Path != "Log.extra" || changes[0].Default != nil || changes[1].Path != "Log.max_entries" || changes[1].Value != float64(100) {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if changes := Diff(nil, defaults, defaults); len(changes) != 0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	content := "Log:\n  max_entries: 100\n  allowed_dirs:\n    - /var/log\nprofiles:\n  work:\n    module: Log\n    base_path: /tmp/work\n    Log:\n      max_entries: -1\n"
+	f, err := ParseFile("config.yaml", FormatYAML, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := f.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := profiles["work"]
+	if work == nil || work.Module != "Log" || work.BasePath != "/tmp/work" || len(work.Sections) != 1 {
+		t.Fatalf("unexpected profiles %+v", profiles)
+	}
+
+	merged := f.WithProfile(work)
+	log := merged.Section("Log")
+	if log["max_entries"] != float64(-1) || log["allowed_dirs"].([]any)[0] != "/var/log" || merged.Data[ProfilesKey] != nil {
+		t.Fatalf("unexpected merged data %v", merged.Data)
+	}
+	if p, ok := merged.Position("Log.max_entries"); !ok || p.Line != 10 {
+		t.Errorf("expected the position in the profile, got %+v", p)
+	}
+	if f.Section("Log")["max_entries"] != float64(100) {
+		t.Errorf("the profile changed the sections of the file")
+	}
+
+	f, err = ParseFile("config.yaml", FormatYAML, []byte("profiles:\n  work:\n    module: [Log]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Profiles(); err == nil || !strings.Contains(err.Error(), "profiles.work.module") {
+		t.Errorf("expected an error for the module of the profile, got %v", err)
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("MOLING_TEST_TOKEN", "env-secret-value")
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-secret-value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]any{
+		"token":   "${env:MOLING_TEST_TOKEN}",
+		"headers": []any{"Bearer ${file:" + path + "}"},
+		"retries": float64(3),
+	}
+	resolved, err := ResolveSecrets([]string{"Webhook"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved["token"] != "env-secret-value" || resolved["headers"].([]any)[0] != "Bearer file-secret-value" || resolved["retries"] != float64(3) {
+		t.Fatalf("unexpected resolved data %v", resolved)
+	}
+	if data["token"] != "${env:MOLING_TEST_TOKEN}" {
+		t.Fatalf("the references of the data were replaced")
+	}
+	if masked := MaskSecrets(`{"token":"env-secret-value","header":"Bearer file-secret-value"}`); strings.Contains(masked, "secret-value") {
+		t.Fatalf("secrets were not masked: %s", masked)
+	}
+
+	_, err = ResolveSecrets([]string{"Webhook"}, map[string]any{"token": "${env:MOLING_TEST_UNSET}"})
+	if err == nil || !strings.Contains(err.Error(), "Webhook.token: ${env:MOLING_TEST_UNSET}") {
+		t.Errorf("expected an error naming the reference, got %v", err)
+	}
+	_, err = ResolveSecrets([]string{"Webhook"}, map[string]any{"token": "${vault:x}"})
+	if err == nil || !strings.Contains(err.Error(), "unknown secret reference") {
+		t.Errorf("expected an error for an unknown scheme, got %v", err)
+	}
+}
+
+func TestLoggingConfig(t *testing.T) {
+	cfg := NewLoggingConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("default logging config must be valid: %s", err.Error())
+	}
+	cfg.Level = "warn"
+	cfg.Services = map[string]string{"Browser": "debug", "Command": "error"}
+	if l := cfg.ServiceLevel("Browser"); l != zerolog.DebugLevel {
+		t.Fatalf("Browser level: expected debug, got %s", l)
+	}
+	if l := cfg.ServiceLevel("FileSystem"); l != zerolog.WarnLevel {
+		t.Fatalf("FileSystem level: expected warn, got %s", l)
+	}
+	if l := cfg.MinLevel(); l != zerolog.DebugLevel {
+		t.Fatalf("min level: expected debug, got %s", l)
+	}
+	for _, bad := range []LoggingConfig{
+		{Level: "verbose", Format: LogFormatJSON, MaxBackups: 1},
+		{Format: "xml", MaxBackups: 1},
+		{Format: LogFormatJSON, Services: map[string]string{"Browser": "loud"}, MaxBackups: 1},
+		{Format: LogFormatConsole, MaxSize: -1, MaxBackups: 1},
+		{Format: LogFormatConsole, MaxBackups: -1},
+	} {
+		if err := bad.Check(); err == nil {
+			t.Fatalf("logging config %+v must be invalid", bad)
+		}
+	}
+}
+
+func TestToolNamesConfig(t *testing.T) {
+	names := ToolNamesConfig{Prefixes: map[string]string{"Browser": "web_"}, Rename: map[string]string{"read_file": "cat", "Browser.browser_navigate": "open"}}
+	if names.Name("Browser", "browser_navigate") != "open" || names.Name("Browser", "read_file") != "cat" || names.Name("Browser", "browser_click") != "web_browser_click" {
+		t.Fatal("renames must win over prefixes, and renames by service.tool over renames by tool")
+	}
+	for _, cfg := range []ToolNamesConfig{
+		{Prefixes: map[string]string{"Browser": "web."}},
+		{Rename: map[string]string{"read_file": "read file"}},
+		{Rename: map[string]string{"A.x": "y", "B.x": "y"}},
+	} {
+		if err := cfg.Check(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
+
+func TestBudgetsConfig(t *testing.T) {
+	cfg := NewBudgetsConfig()
+	if cfg.Enabled() || cfg.Check() != nil {
+		t.Fatal("the default budgets must be valid and disabled")
+	}
+	cfg.Services["Browser"] = Budget{MemoryMB: 2048, Action: BudgetRestart}
+	if !cfg.Enabled() || cfg.Check() != nil {
+		t.Fatal("a memory budget must enable the watchdog")
+	}
+	for _, b := range []Budget{{MemoryMB: -1}, {WarnPercent: 120}, {Action: "kill"}} {
+		cfg.Services["Browser"] = b
+		if err := cfg.Check(); err == nil {
+			t.Errorf("expected %+v to be invalid", b)
+		}
+	}
+}
+
+func TestResponseConfig(t *testing.T) {
+	cfg := NewResponseConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []ResponseConfig{{MaxSize: -1}, {MaxSize: 100, PreviewSize: 100}, {MaxKept: -1}} {
+		if err := c.Check(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+}
+
+func TestStatsConfig(t *testing.T) {
+	cfg := NewStatsConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SlowDuration() != 10*time.Second {
+		t.Errorf("unexpected slow threshold %s", cfg.SlowDuration())
+	}
+	for _, c := range []StatsConfig{{Samples: -1}, {DumpInterval: -1}, {SlowThreshold: -1}} {
+		if err := c.Check(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SplitKey splits a dotted key given on the command line, such as Log.max_entries, into its path.
+func SplitKey(key string) []string {
+	var path []string
+	for _, k := range strings.Split(key, ".") {
+		if k != "" {
+			path = append(path, k)
+		}
+	}
+	return path
+}
+
+// Lookup returns the value at path in data. Keys that do not match exactly match case-insensitively.
+func Lookup(data map[string]any, path []string) (any, bool) {
+	var value any = data
+	for _, key := range path {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; ok {
+			continue
+		}
+		found := false
+		for name, v := range m {
+			if strings.EqualFold(name, key) {
+				value, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Change is a key whose value differs from its default.
+type Change struct {
+	Path    string
+	Default any // Default is nil for keys the defaults do not have.
+	Value   any // Value is nil for keys that were removed.
+}
+
+// Diff returns the keys of value that differ from defaults, sorted by path. Both use the types of encoding/json.
+// Objects are compared key by key, other values, including arrays, as a whole.
+func Diff(path []string, defaults, value any) []Change {
+	var changes []Change
+	diff(path, defaults, value, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diff(path []string, defaults, value any, changes *[]Change) {
+	dm, dok := defaults.(map[string]any)
+	vm, vok := value.(map[string]any)
+	if !dok || !vok {
+		if !reflect.DeepEqual(defaults, value) {
+			*changes = append(*changes, Change{Path: PathString(path), Default: defaults, Value: value})
+		}
+		return
+	}
+	for key, d := range dm {
+		diff(append(path[:len(path):len(path)], key), d, vm[key], changes)
+	}
+	for key, v := range vm {
+		if _, ok := dm[key]; !ok {
+			diff(append(path[:len(path):len(path)], key), nil, v, changes)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import "fmt"
+
+// ElicitationConfig controls whether tools may ask the user for confirmations and missing values during a call.
+type ElicitationConfig struct {
+	Enabled bool `json:"enabled"` // Enabled allows elicitation requests, disable it for non-interactive deployments.
+	Timeout int  `json:"timeout"` // Timeout is how long to wait for the user to answer, 0 for no limit. time.Second
+}
+
+// NewElicitationConfig creates a new ElicitationConfig with default values.
+func NewElicitationConfig() ElicitationConfig {
+	return ElicitationConfig{
+		Enabled: true,
+		Timeout: 120,
+	}
+}
+
+// Check validates the elicitation configuration.
+func (cfg *ElicitationConfig) Check() error {
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
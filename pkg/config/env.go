@@ -0,0 +1,55 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// EnvPrefix starts the names of the environment variables that override configuration keys.
+const EnvPrefix = "MOLING_"
+
+// EnvOverride is an environment variable that overrides a configuration key. MOLING_LOG__MAX_ENTRIES=100 sets
+// max_entries in the Log section; __ separates the keys of the path, which match case-insensitively.
+type EnvOverride struct {
+	Name  string   // Name is the name of the variable.
+	Path  []string // Path is the section and the keys, in lower case.
+	Value string
+}
+
+// EnvOverrides returns the overrides in environ, a list of KEY=value strings such as os.Environ, sorted by name.
+// Variables without __, such as MOLING_DEBUG, set command line flags instead and are skipped.
+func EnvOverrides(environ []string) []EnvOverride {
+	var overrides []EnvOverride
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) || !strings.Contains(name, "__") {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		valid := len(path) > 1
+		for _, key := range path {
+			valid = valid && key != ""
+		}
+		if valid {
+			overrides = append(overrides, EnvOverride{Name: name, Path: path, Value: value})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Name < overrides[j].Name })
+	return overrides
+}
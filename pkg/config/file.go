@@ -0,0 +1,266 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats, chosen by the extension of the file.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// formats maps the supported extensions to their format, in the order FindFile tries them.
+var formats = []struct{ ext, format string }{
+	{".json", FormatJSON},
+	{".yaml", FormatYAML},
+	{".yml", FormatYAML},
+	{".toml", FormatTOML},
+}
+
+// Position is a location in a configuration file, 0 if unknown.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// File is a parsed configuration file. Its data uses the types of encoding/json whatever the format, so that
+// the loaders of services see the same values for JSON, YAML and TOML files.
+type File struct {
+	Path      string
+	Format    string
+	Data      map[string]any
+	positions map[string]Position // positions are the locations of the keys by path, for JSON and YAML files.
+}
+
+// FileFormat returns the format of a configuration file by its extension.
+func FileFormat(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range formats {
+		if f.ext == ext {
+			return f.format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported configuration file %s, use .json, .yaml, .yml or .toml", path)
+}
+
+// FindFile returns path if it exists, otherwise the first existing file with the same name and another supported
+// extension, such as config.yaml for config.json. It returns path if none exists.
+func FindFile(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, f := range formats {
+		if _, err := os.Stat(base + f.ext); err == nil {
+			return base + f.ext
+		}
+	}
+	return path
+}
+
+// LoadFile reads and parses a configuration file. Syntax errors report the line and column.
+func LoadFile(path string) (*File, error) {
+	format, err := FileFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFile(path, format, data)
+}
+
+// ParseFile parses the content of a configuration file of the given format.
+func ParseFile(path, format string, data []byte) (*File, error) {
+	f := &File{Path: path, Format: format, positions: make(map[string]Position)}
+	var raw any
+	var err error
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(data, &raw)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := offsetPosition(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %w", path, line, column, err)
+		}
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &raw)
+	case FormatTOML:
+		err = toml.Unmarshal(data, &raw)
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return nil, fmt.Errorf("%s:%d:%d: %w", path, line, column, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported configuration format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if raw == nil {
+		f.Data = make(map[string]any)
+		return f, nil
+	}
+	// A JSON round trip turns the integers, dates and map types of YAML and TOML into the types of encoding/json.
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err = json.Unmarshal(normalized, &f.Data); err != nil {
+		return nil, fmt.Errorf("%s: the configuration must be an object of sections", path)
+	}
+	if format != FormatTOML {
+		// JSON is YAML too, so the YAML parser finds the keys of both; files it cannot parse have no positions.
+		var node yaml.Node
+		if yaml.Unmarshal(data, &node) == nil {
+			f.collectPositions(&node, nil)
+		}
+	}
+	return f, nil
+}
+
+// offsetPosition returns the line and column of a byte offset.
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, int(offset) - bytes.LastIndexByte(before, '\n') - 1
+}
+
+func (f *File) collectPositions(node *yaml.Node, path []string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			f.collectPositions(n, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := append(path[:len(path):len(path)], node.Content[i].Value)
+			f.positions[PathString(key)] = Position{Line: node.Content[i].Line, Column: node.Content[i].Column}
+			f.collectPositions(node.Content[i+1], key)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			key := append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]")
+			f.positions[PathString(key)] = Position{Line: n.Line, Column: n.Column}
+			f.collectPositions(n, key)
+		}
+	}
+}
+
+// Position returns the location of the key at path, such as Log.allowed_dirs[1].
+func (f *File) Position(path string) (Position, bool) {
+	p, ok := f.positions[path]
+	return p, ok
+}
+
+// Section returns the object at the top-level key name, or nil if there is none.
+func (f *File) Section(name string) map[string]any {
+	section, _ := f.Data[name].(map[string]any)
+	return section
+}
+
+// PathString joins the keys of a path with dots, and array indexes such as [0] without.
+func PathString(path []string) string {
+	var b strings.Builder
+	for i, key := range path {
+		if i > 0 && !strings.HasPrefix(key, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(key)
+	}
+	return b.String()
+}
+
+// Marshal encodes configuration data in the given format. JSON is indented with two spaces like the file that
+// moling config --init writes.
+func Marshal(format string, data map[string]any) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	case FormatYAML:
+		var b bytes.Buffer
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), enc.Close()
+	case FormatTOML:
+		// TOML has no null, and writes the numbers of encoding/json as floats, such as 100.0.
+		return toml.Marshal(tomlValue(data))
+	}
+	return nil, fmt.Errorf("unsupported configuration format %q", format)
+}
+
+// tomlValue drops nulls and turns whole numbers into integers.
+func tomlValue(value any) any {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				out = append(out, tomlValue(item))
+			}
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			if item != nil {
+				out[key] = tomlValue(item)
+			}
+		}
+		return out
+	}
+	return value
+}
+
+// Save writes the data of the file to its path in its format. Comments of YAML and TOML files are not kept.
+func (f *File) Save() error {
+	data, err := Marshal(f.Format, f.Data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, data, 0o644)
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// I18nConfig configures the language of the tool descriptions, prompts and messages of the server.
+type I18nConfig struct {
+	Language string `json:"language"` // Language is the language served to clients, such as en or zh-CN, empty for en.
+}
+
+// NewI18nConfig creates a new I18nConfig with default values.
+func NewI18nConfig() I18nConfig {
+	return I18nConfig{
+		Language: "en",
+	}
+}
+
+// Check validates the i18n configuration. Whether there is a catalog for the language is checked when the server starts.
+func (cfg *I18nConfig) Check() error {
+	if strings.ContainsAny(cfg.Language, `/\ `) {
+		return fmt.Errorf("invalid language %q", cfg.Language)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobsConfig configures the background jobs, the tool calls that clients submit to run while they go on working.
+type JobsConfig struct {
+	MaxRunning int `json:"max_running"` // MaxRunning is the number of jobs that run at the same time, the others wait in the queue.
+	MaxKept    int `json:"max_kept"`    // MaxKept is the number of finished jobs kept with their results.
+	Timeout    int `json:"timeout"`     // Timeout cancels the jobs that run longer, in seconds, 0 for no limit.
+}
+
+// NewJobsConfig creates a new JobsConfig with default values.
+func NewJobsConfig() JobsConfig {
+	return JobsConfig{
+		MaxRunning: 4,
+		MaxKept:    200,
+		Timeout:    3600,
+	}
+}
+
+// TimeoutDuration returns Timeout as a duration.
+func (cfg *JobsConfig) TimeoutDuration() time.Duration {
+	return time.Duration(cfg.Timeout) * time.Second
+}
+
+// Check validates the jobs configuration.
+func (cfg *JobsConfig) Check() error {
+	if cfg.MaxRunning < 0 {
+		return fmt.Errorf("max_running must not be negative")
+	}
+	if cfg.MaxKept < 0 {
+		return fmt.Errorf("max_kept must not be negative")
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
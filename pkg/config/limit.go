@@ -0,0 +1,83 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LimitConfig throttles tool calls, protecting the browser and the host from a client stuck in a call loop.
+type LimitConfig struct {
+	MaxInFlight  int                  `json:"max_in_flight"` // MaxInFlight is the maximum number of tool calls running at a time, 0 for no limit.
+	QueueTimeout int                  `json:"queue_timeout"` // QueueTimeout is how long a call waits for one of the others to finish. time.Second
+	Default      RateLimit            `json:"default"`       // Default applies to tools without a limit of their own.
+	Tools        map[string]RateLimit `json:"tools"`         // Tools are the limits by tool name. Names may end with *, such as browser_*.
+}
+
+// RateLimit is a token bucket of calls per client session and tool.
+type RateLimit struct {
+	QPS   float64 `json:"qps"`   // QPS is the sustained number of calls per second, 0 for no limit.
+	Burst int     `json:"burst"` // Burst is the number of calls that may be made at once.
+}
+
+// NewLimitConfig creates a new LimitConfig with default values.
+func NewLimitConfig() LimitConfig {
+	return LimitConfig{
+		MaxInFlight:  16,
+		QueueTimeout: 30,
+		Default:      RateLimit{QPS: 10, Burst: 20},
+		Tools: map[string]RateLimit{
+			"browser_*": {QPS: 2, Burst: 10},
+		},
+	}
+}
+
+// Limit returns the rate limit of a tool: that of its exact name, else of the longest matching pattern, else the default.
+func (cfg *LimitConfig) Limit(tool string) RateLimit {
+	if l, ok := cfg.Tools[tool]; ok {
+		return l
+	}
+	limit, longest := cfg.Default, -1
+	for pattern, l := range cfg.Tools {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(tool, prefix) && len(prefix) > longest {
+			limit, longest = l, len(prefix)
+		}
+	}
+	return limit
+}
+
+// Check validates the limit configuration.
+func (cfg *LimitConfig) Check() error {
+	if cfg.MaxInFlight < 0 || cfg.QueueTimeout < 0 {
+		return fmt.Errorf("max_in_flight and queue_timeout must not be negative")
+	}
+	limits := map[string]RateLimit{"default": cfg.Default}
+	for name, l := range cfg.Tools {
+		if strings.Contains(strings.TrimSuffix(name, "*"), "*") {
+			return fmt.Errorf("tool pattern %q may only end with *", name)
+		}
+		limits[name] = l
+	}
+	for name, l := range limits {
+		if l.QPS < 0 || (l.QPS > 0 && l.Burst < 1) {
+			return fmt.Errorf("%s: qps must not be negative and burst must be at least 1", name)
+		}
+	}
+	return nil
+}
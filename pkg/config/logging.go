@@ -0,0 +1,101 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// Formats of the log file.
+const (
+	LogFormatJSON    = "json"    // LogFormatJSON writes a JSON object per line.
+	LogFormatConsole = "console" // LogFormatConsole writes human-readable lines.
+)
+
+// LoggingConfig controls the log file under BasePath/logs, its rotation and the levels of the services.
+type LoggingConfig struct {
+	Level      string            `json:"level"`       // Level is the minimum level logged: trace, debug, info, warn or error. --debug sets debug.
+	Format     string            `json:"format"`      // Format of the log file, json (the default) or console.
+	Services   map[string]string `json:"services"`    // Services override the level by service name, e.g. {"Browser": "debug"}.
+	MaxSize    int               `json:"max_size"`    // MaxSize rotates the log file when it is larger, in megabytes, 0 for no limit.
+	MaxAge     int               `json:"max_age"`     // MaxAge rotates the log file when it was opened longer ago, in hours, 0 for no limit.
+	MaxBackups int               `json:"max_backups"` // MaxBackups is the number of rotated log files kept, at least one, moling.log.1 being the newest.
+}
+
+// NewLoggingConfig creates a new LoggingConfig with default values.
+func NewLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		Level:      zerolog.LevelInfoValue,
+		Format:     LogFormatJSON,
+		Services:   map[string]string{},
+		MaxSize:    512,
+		MaxAge:     24,
+		MaxBackups: 7,
+	}
+}
+
+// ServiceLevel returns the level of a service: its own if overridden, else the level of the log.
+func (cfg *LoggingConfig) ServiceLevel(name string) zerolog.Level {
+	if l, ok := cfg.Services[name]; ok {
+		if level, err := zerolog.ParseLevel(l); err == nil {
+			return level
+		}
+	}
+	return cfg.DefaultLevel()
+}
+
+// MinLevel returns the lowest of the levels of the log and of the services, the global level of zerolog.
+func (cfg *LoggingConfig) MinLevel() zerolog.Level {
+	minLevel := cfg.DefaultLevel()
+	for name := range cfg.Services {
+		if l := cfg.ServiceLevel(name); l < minLevel {
+			minLevel = l
+		}
+	}
+	return minLevel
+}
+
+// DefaultLevel returns the level of the log, info if it is not set.
+func (cfg *LoggingConfig) DefaultLevel() zerolog.Level {
+	level, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.InfoLevel
+	}
+	return level
+}
+
+// Check validates the logging configuration.
+func (cfg *LoggingConfig) Check() error {
+	levels := map[string]string{"level": cfg.Level}
+	for name, l := range cfg.Services {
+		levels["services."+name] = l
+	}
+	for key, l := range levels {
+		if _, err := zerolog.ParseLevel(l); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if cfg.Format != "" && cfg.Format != LogFormatJSON && cfg.Format != LogFormatConsole {
+		return fmt.Errorf("format must be %s or %s, got %q", LogFormatJSON, LogFormatConsole, cfg.Format)
+	}
+	if cfg.MaxSize < 0 || cfg.MaxAge < 0 || cfg.MaxBackups < 0 {
+		return fmt.Errorf("max_size, max_age and max_backups must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"github.com/gojue/moling/pkg/policy"
+)
+
+// PolicyConfig holds the rules that decide, in one place, which tool calls may run, are denied or need the approval
+// of the user. Rules see the tool, its service and arguments, the principal, its roles, profile and session, and the
+// time of the call.
+type PolicyConfig struct {
+	Default policy.Effect `json:"default"` // Default applies to the calls that no rule matches: allow, deny or approve.
+	Rules   []policy.Rule `json:"rules"`   // Rules are evaluated in order, the first one that matches decides.
+}
+
+// NewPolicyConfig creates a new PolicyConfig with default values.
+func NewPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		Default: policy.Allow,
+		Rules:   []policy.Rule{},
+	}
+}
+
+// Enabled reports whether the policy may decide anything but allow.
+func (cfg *PolicyConfig) Enabled() bool {
+	return len(cfg.Rules) > 0 || (cfg.Default != "" && cfg.Default != policy.Allow)
+}
+
+// Engine compiles the rules of the policy.
+func (cfg *PolicyConfig) Engine() (*policy.Engine, error) {
+	return policy.New(cfg.Default, cfg.Rules)
+}
+
+// Check validates the policy configuration.
+func (cfg *PolicyConfig) Check() error {
+	_, err := cfg.Engine()
+	return err
+}
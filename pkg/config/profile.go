@@ -0,0 +1,143 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfilesKey is the top-level key of the profiles in the configuration file.
+const ProfilesKey = "profiles"
+
+// Profile is a named variant of the configuration, such as a locked-down work profile and a permissive personal
+// one. It is chosen with --profile, or by the API key of an SSE client. Its sections are merged over those of the
+// configuration file:
+//
+//	profiles:
+//	  work:
+//	    module: FileSystem,Command
+//	    FileSystem:
+//	      allowed_dir: /home/me/work
+type Profile struct {
+	Name     string
+	Module   string         // Module are the enabled services separated by commas, like --module. Empty keeps them.
+	BasePath string         // BasePath replaces the base path of the data of the services. Empty keeps it.
+	Sections map[string]any // Sections are the sections of the profile by name, such as FileSystem or MoLingConfig.
+}
+
+// Profiles returns the profiles of the file by name.
+func (f *File) Profiles() (map[string]*Profile, error) {
+	raw, ok := f.Data[ProfilesKey]
+	if !ok {
+		return map[string]*Profile{}, nil
+	}
+	all, ok := raw.(map[string]any)
+	if !ok {
+		return nil, f.profileError([]string{ProfilesKey}, "expected an object of profiles, got %s", jsonType(raw))
+	}
+	profiles := make(map[string]*Profile, len(all))
+	for name, value := range all {
+		path := []string{ProfilesKey, name}
+		data, ok := value.(map[string]any)
+		if !ok {
+			return nil, f.profileError(path, "expected object, got %s", jsonType(value))
+		}
+		p := &Profile{Name: name, Sections: make(map[string]any)}
+		for key, v := range data {
+			switch key {
+			case "module", "base_path":
+				s, ok := v.(string)
+				if !ok {
+					return nil, f.profileError(append(path, key), "expected string, got %s", jsonType(v))
+				}
+				if key == "module" {
+					p.Module = s
+				} else {
+					p.BasePath = s
+				}
+			default:
+				if _, ok := v.(map[string]any); !ok {
+					return nil, f.profileError(append(path, key), "expected a section object, got %s", jsonType(v))
+				}
+				p.Sections[key] = v
+			}
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+func (f *File) profileError(path []string, format string, args ...any) error {
+	e := &ValidationError{File: f.Path, Path: PathString(path), Message: fmt.Sprintf(format, args...)}
+	e.Position, _ = f.Position(e.Path)
+	return e
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func ProfileNames(profiles map[string]*Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile returns a copy of the file with the sections of the profile merged over its own, and without the
+// profiles. Errors in the merged values are reported at their location in the profile.
+func (f *File) WithProfile(p *Profile) *File {
+	merged := &File{Path: f.Path, Format: f.Format, Data: make(map[string]any), positions: make(map[string]Position)}
+	for key, value := range f.Data {
+		if key != ProfilesKey {
+			merged.Data[key] = value
+		}
+	}
+	MergeData(merged.Data, p.Sections)
+	prefix := PathString([]string{ProfilesKey, p.Name}) + "."
+	for key, pos := range f.positions {
+		if !strings.HasPrefix(key, ProfilesKey+".") {
+			merged.positions[key] = pos
+		}
+	}
+	for key, pos := range f.positions {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			merged.positions[rest] = pos
+		}
+	}
+	return merged
+}
+
+// MergeData merges src into dst: objects are merged key by key, other values replace those of dst. The objects of
+// dst are copied before they are changed, so dst may share them with other data.
+func MergeData(dst, src map[string]any) {
+	for key, value := range src {
+		s, sok := value.(map[string]any)
+		d, dok := dst[key].(map[string]any)
+		if !sok || !dok {
+			dst[key] = value
+			continue
+		}
+		copied := make(map[string]any, len(d))
+		for k, v := range d {
+			copied[k] = v
+		}
+		MergeData(copied, s)
+		dst[key] = copied
+	}
+}
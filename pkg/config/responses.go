@@ -0,0 +1,53 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import "fmt"
+
+// ResponseConfig limits the size of tool results. Larger results, such as the HTML of a page or a long command log,
+// are saved to a file and replaced by a preview and a link to the file, so that clients do not choke on them.
+type ResponseConfig struct {
+	MaxSize     int `json:"max_size"`     // MaxSize is the size of a tool result in bytes beyond which it is saved to a file, 0 for no limit.
+	PreviewSize int `json:"preview_size"` // PreviewSize is the length of the text kept in the result of a saved one, in bytes.
+	MaxKept     int `json:"max_kept"`     // MaxKept is the number of saved results kept, the oldest are removed, 0 keeps all.
+}
+
+// NewResponseConfig creates a new ResponseConfig with default values.
+func NewResponseConfig() ResponseConfig {
+	return ResponseConfig{
+		MaxSize:     1 << 20,
+		PreviewSize: 4096,
+		MaxKept:     100,
+	}
+}
+
+// Check validates the response configuration.
+func (cfg *ResponseConfig) Check() error {
+	if cfg.MaxSize < 0 {
+		return fmt.Errorf("max_size must not be negative")
+	}
+	if cfg.PreviewSize < 0 {
+		return fmt.Errorf("preview_size must not be negative")
+	}
+	if cfg.MaxSize > 0 && cfg.PreviewSize >= cfg.MaxSize {
+		return fmt.Errorf("preview_size must be smaller than max_size")
+	}
+	if cfg.MaxKept < 0 {
+		return fmt.Errorf("max_kept must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import "fmt"
+
+// SamplingConfig controls whether services may ask the model of the connected client to generate text during a call.
+type SamplingConfig struct {
+	Enabled   bool `json:"enabled"`    // Enabled allows sampling requests, disable it to keep data from being sent to the client's model.
+	Timeout   int  `json:"timeout"`    // Timeout is how long to wait for the client's answer, 0 for no limit. time.Second
+	MaxTokens int  `json:"max_tokens"` // MaxTokens is the maximum number of tokens a sampling request may ask for.
+}
+
+// NewSamplingConfig creates a new SamplingConfig with default values.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Enabled:   true,
+		Timeout:   300,
+		MaxTokens: 2048,
+	}
+}
+
+// Check validates the sampling configuration.
+func (cfg *SamplingConfig) Check() error {
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if cfg.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be greater than 0")
+	}
+	return nil
+}
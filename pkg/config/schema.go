@@ -0,0 +1,292 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema used to validate configuration sections. It is inferred from the default
+// configuration of a section, so that it cannot drift from the fields the section has.
+type Schema struct {
+	Type       string             `json:"type,omitempty"` // Type is empty for values whose default is null, which accept anything.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	// AdditionalProperties is false for objects with a fixed set of keys. Objects whose default is empty, such as
+	// maps of tool names, accept any key.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// ValidationError is a value of a configuration file that does not match the schema.
+type ValidationError struct {
+	File     string
+	Path     string
+	Position Position
+	Message  string
+	Unknown  bool // Unknown is true for keys the schema does not know, which may be typos or keys of another version.
+}
+
+func (e *ValidationError) Error() string {
+	location := e.File
+	if e.Position.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Position.Line, e.Position.Column)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, e.Path, e.Message)
+}
+
+// InferSchema returns the schema of a default configuration, such as a config struct or the JSON of Service.Config.
+func InferSchema(defaults any) (*Schema, error) {
+	var value any
+	switch d := defaults.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(d), &value); err != nil {
+			return nil, err
+		}
+	default:
+		data, err := json.Marshal(defaults)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	}
+	return inferSchema(value), nil
+}
+
+func inferSchema(value any) *Schema {
+	switch v := value.(type) {
+	case string:
+		return &Schema{Type: "string"}
+	case float64:
+		return &Schema{Type: "number"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case []any:
+		s := &Schema{Type: "array"}
+		if len(v) > 0 {
+			s.Items = inferSchema(v[0])
+		}
+		return s
+	case map[string]any:
+		s := &Schema{Type: "object"}
+		if len(v) == 0 {
+			return s
+		}
+		closed := false
+		s.AdditionalProperties = &closed
+		s.Properties = make(map[string]*Schema, len(v))
+		for key, value := range v {
+			s.Properties[key] = inferSchema(value)
+		}
+		return s
+	}
+	return &Schema{}
+}
+
+// Validate checks value, the section at path of file f, against the schema. Unknown keys are reported with Unknown set.
+func (s *Schema) Validate(f *File, path []string, value any) []*ValidationError {
+	var errs []*ValidationError
+	s.validate(f, path, value, &errs)
+	return errs
+}
+
+func (s *Schema) validate(f *File, path []string, value any, errs *[]*ValidationError) {
+	if s.Type == "" || value == nil {
+		return
+	}
+	report := func(unknown bool, path []string, format string, args ...any) {
+		e := &ValidationError{Path: PathString(path), Message: fmt.Sprintf(format, args...), Unknown: unknown}
+		if f != nil {
+			e.File = f.Path
+			e.Position, _ = f.Position(e.Path)
+		}
+		*errs = append(*errs, e)
+	}
+	if got := jsonType(value); got != s.Type {
+		report(false, path, "expected %s, got %s", s.Type, got)
+		return
+	}
+	switch v := value.(type) {
+	case []any:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range v {
+			s.Items.validate(f, append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]"), item, errs)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := append(path[:len(path):len(path)], key)
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					report(true, keyPath, "unknown key%s", s.suggest(key))
+				}
+				continue
+			}
+			prop.validate(f, keyPath, v[key], errs)
+		}
+	}
+}
+
+// suggest names a known key that differs from key only in case, underscores or dashes.
+func (s *Schema) suggest(key string) string {
+	normalize := func(k string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(k))
+	}
+	for known := range s.Properties {
+		if normalize(known) == normalize(key) {
+			return fmt.Sprintf(", did you mean %s?", known)
+		}
+	}
+	return ""
+}
+
+// jsonType returns the JSON Schema type of a value decoded by encoding/json.
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Property returns the name and schema of the property that matches key case-insensitively.
+func (s *Schema) Property(key string) (string, *Schema, bool) {
+	if prop, ok := s.Properties[key]; ok {
+		return key, prop, true
+	}
+	for name, prop := range s.Properties {
+		if strings.EqualFold(name, key) {
+			return name, prop, true
+		}
+	}
+	return "", nil, false
+}
+
+// Set parses value as the type of the key at path and sets it in section, creating the objects on the way. The keys
+// of the path match case-insensitively, so that they can come from environment variable names.
+func (s *Schema) Set(section map[string]any, path []string, value string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty key")
+	}
+	name, prop, ok := s.Property(path[0])
+	if !ok {
+		if s.AdditionalProperties != nil || (s.Type != "object" && s.Type != "") {
+			return fmt.Errorf("unknown key %s", path[0])
+		}
+		// A free-form object, such as a map of tool names, keeps the key as given.
+		name, prop = path[0], &Schema{}
+	}
+	if len(path) == 1 {
+		parsed, err := prop.parse(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		section[name] = parsed
+		return nil
+	}
+	if prop.Type != "object" && prop.Type != "" {
+		return fmt.Errorf("%s is a %s, not an object", name, prop.Type)
+	}
+	child, ok := section[name].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		section[name] = child
+	}
+	if err := prop.Set(child, path[1:], value); err != nil {
+		return fmt.Errorf("%s.%w", name, err)
+	}
+	return nil
+}
+
+// parse converts a string, such as the value of an environment variable, to the type of the schema. Arrays and
+// objects are JSON; arrays of strings and numbers may also be comma-separated.
+func (s *Schema) parse(value string) (any, error) {
+	switch s.Type {
+	case "string":
+		return value, nil
+	case "number":
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", value)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return b, nil
+	case "array":
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			break
+		}
+		items := []any{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v := any(item)
+			if s.Items != nil && s.Items.Type != "" {
+				var err error
+				if v, err = s.Items.parse(item); err != nil {
+					return nil, err
+				}
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case "":
+		var v any
+		if json.Unmarshal([]byte(value), &v) == nil {
+			return v, nil
+		}
+		return value, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return nil, fmt.Errorf("expected JSON: %w", err)
+	}
+	if got := jsonType(v); got != s.Type {
+		return nil, fmt.Errorf("expected %s, got %s", s.Type, got)
+	}
+	return v, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretMask replaces the values of resolved secret references in the output of the configuration and in logs.
+const SecretMask = "******"
+
+// minMaskedLength is the length below which resolved values are not masked, so that a value like "1" does not
+// mask every digit of the logs.
+const minMaskedLength = 4
+
+// SecretResolver returns the value of a secret reference, such as the value of an environment variable for
+// ${env:TOKEN}.
+type SecretResolver func(ref string) (string, error)
+
+// secretRefPattern matches the secret references in string values, such as ${env:TOKEN} or ${file:/run/token}.
+var secretRefPattern = regexp.MustCompile(`\$\{([a-z]+):([^}]+)\}`)
+
+var (
+	secretsLock     sync.RWMutex
+	secretResolvers = map[string]SecretResolver{
+		"env":  envSecret,
+		"file": fileSecret,
+	}
+	secretValues []string // secretValues are the resolved values to mask, longest first.
+)
+
+// RegisterSecretResolver registers the resolver of the references with the given scheme, such as keychain for
+// ${keychain:name}.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+	secretResolvers[scheme] = r
+}
+
+func envSecret(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+func fileSecret(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// ResolveSecrets returns a copy of data with the secret references in its strings replaced by their values, so the
+// configuration file does not need to hold tokens in plain text. The resolved values are masked by MaskSecrets from
+// then on. path is the location of data in the configuration file, used in errors.
+func ResolveSecrets(path []string, data map[string]any) (map[string]any, error) {
+	resolved, err := resolveSecrets(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]any), nil
+}
+
+func resolveSecrets(path []string, value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return resolveSecretString(path, v)
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := resolveSecrets(append(path[:len(path):len(path)], key), item)
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = r
+		}
+		return copied, nil
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			r, err := resolveSecrets(append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)), item)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = r
+		}
+		return copied, nil
+	}
+	return value, nil
+}
+
+func resolveSecretString(path []string, s string) (string, error) {
+	var err error
+	resolved := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		m := secretRefPattern.FindStringSubmatch(ref)
+		secretsLock.RLock()
+		resolver, ok := secretResolvers[m[1]]
+		secretsLock.RUnlock()
+		if !ok {
+			err = fmt.Errorf("%s: unknown secret reference %s, use env, file or keychain", PathString(path), ref)
+			return ref
+		}
+		value, rerr := resolver(m[2])
+		if rerr != nil {
+			// The error of the resolver names the reference, never the value.
+			err = fmt.Errorf("%s: %s: %w", PathString(path), ref, rerr)
+			return ref
+		}
+		addSecretValue(value)
+		return value
+	})
+	return resolved, err
+}
+
+func addSecretValue(value string) {
+	if len(value) < minMaskedLength {
+		return
+	}
+	secretsLock.Lock()
+	defer secretsLock.Unlock()
+	for _, v := range secretValues {
+		if v == value {
+			return
+		}
+	}
+	secretValues = append(secretValues, value)
+	sort.Slice(secretValues, func(i, j int) bool { return len(secretValues[i]) > len(secretValues[j]) })
+}
+
+// MaskSecrets replaces the resolved values of secret references in s with SecretMask, also where they are
+// escaped as in JSON.
+func MaskSecrets(s string) string {
+	secretsLock.RLock()
+	defer secretsLock.RUnlock()
+	for _, value := range secretValues {
+		s = strings.ReplaceAll(s, value, SecretMask)
+		if escaped, _ := json.Marshal(value); string(escaped[1:len(escaped)-1]) != value {
+			s = strings.ReplaceAll(s, string(escaped[1:len(escaped)-1]), SecretMask)
+		}
+	}
+	return s
+}
+
+// secretMaskWriter masks the resolved secrets in everything written to it.
+type secretMaskWriter struct {
+	w io.Writer
+}
+
+// NewSecretMaskWriter returns a writer that masks the resolved values of secret references before writing to w,
+// for the logs.
+func NewSecretMaskWriter(w io.Writer) io.Writer {
+	return &secretMaskWriter{w: w}
+}
+
+func (sw *secretMaskWriter) Write(p []byte) (int, error) {
+	secretsLock.RLock()
+	none := len(secretValues) == 0
+	secretsLock.RUnlock()
+	if none {
+		return sw.w.Write(p)
+	}
+	if _, err := sw.w.Write([]byte(MaskSecrets(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
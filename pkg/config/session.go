@@ -0,0 +1,44 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import "fmt"
+
+// SessionConfig controls the per-session state of services in SSE mode. In STDIO mode there is only one client
+// and all services are shared.
+type SessionConfig struct {
+	IsolatedServices []string `json:"isolated_services"` // IsolatedServices get a separate instance for every client session, e.g. Browser.
+	IdleTimeout      int      `json:"idle_timeout"`      // IdleTimeout closes the instances of a session after this long without tool calls. time.Second
+	MaxSessions      int      `json:"max_sessions"`      // MaxSessions is the maximum number of sessions with isolated instances at a time.
+}
+
+// NewSessionConfig creates a new SessionConfig with default values.
+func NewSessionConfig() SessionConfig {
+	return SessionConfig{
+		IsolatedServices: []string{"Browser"},
+		IdleTimeout:      1800,
+		MaxSessions:      8,
+	}
+}
+
+// Check validates the session configuration.
+func (cfg *SessionConfig) Check() error {
+	if len(cfg.IsolatedServices) > 0 && (cfg.IdleTimeout <= 0 || cfg.MaxSessions <= 0) {
+		return fmt.Errorf("idle_timeout and max_sessions must be greater than 0")
+	}
+	return nil
+}
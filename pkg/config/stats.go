@@ -0,0 +1,52 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatsConfig configures the per-tool statistics of the tool calls and the log of slow calls, which help to tune
+// timeouts and limits.
+type StatsConfig struct {
+	Samples       int `json:"samples"`        // Samples is the number of the latest durations of a tool the percentiles are computed from, 0 for 1000.
+	DumpInterval  int `json:"dump_interval"`  // DumpInterval is the time between writes of the statistics to data/stats.json, 0 for never. time.Second
+	SlowThreshold int `json:"slow_threshold"` // SlowThreshold logs the calls that take longer with their redacted arguments, 0 for none. time.Millisecond
+}
+
+// NewStatsConfig creates a new StatsConfig with default values.
+func NewStatsConfig() StatsConfig {
+	return StatsConfig{
+		Samples:       1000,
+		DumpInterval:  300,
+		SlowThreshold: 10000,
+	}
+}
+
+// SlowDuration returns SlowThreshold as a duration.
+func (cfg *StatsConfig) SlowDuration() time.Duration {
+	return time.Duration(cfg.SlowThreshold) * time.Millisecond
+}
+
+// Check validates the statistics configuration.
+func (cfg *StatsConfig) Check() error {
+	if cfg.Samples < 0 || cfg.DumpInterval < 0 || cfg.SlowThreshold < 0 {
+		return fmt.Errorf("samples, dump_interval and slow_threshold must not be negative")
+	}
+	return nil
+}
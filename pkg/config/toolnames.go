@@ -0,0 +1,75 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validToolName matches the tool names that MCP clients accept.
+var validToolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolNamesConfig renames the tools of services, so that services with tools of the same name can be loaded together.
+type ToolNamesConfig struct {
+	Prefixes map[string]string `json:"prefixes"` // Prefixes are put before the names of the tools of a service, by service, e.g. {"Browser": "web_"}.
+	Rename   map[string]string `json:"rename"`   // Rename gives single tools a new name, by tool or service.tool, e.g. {"FileSystem.read_file": "fs_read"}.
+}
+
+// NewToolNamesConfig creates a new ToolNamesConfig with default values.
+func NewToolNamesConfig() ToolNamesConfig {
+	return ToolNamesConfig{
+		Prefixes: map[string]string{},
+		Rename:   map[string]string{},
+	}
+}
+
+// Name returns the name a tool of a service is served with. A rename by service.tool wins over a rename by tool,
+// which wins over the prefix of the service.
+func (cfg *ToolNamesConfig) Name(service, tool string) string {
+	if name, ok := cfg.Rename[service+"."+tool]; ok {
+		return name
+	}
+	if name, ok := cfg.Rename[tool]; ok {
+		return name
+	}
+	return cfg.Prefixes[service] + tool
+}
+
+// Check validates the tool names configuration.
+func (cfg *ToolNamesConfig) Check() error {
+	for service, prefix := range cfg.Prefixes {
+		if prefix != "" && !validToolName.MatchString(prefix) {
+			return fmt.Errorf("invalid prefix %q of %s, use letters, digits, _ and -", prefix, service)
+		}
+	}
+	targets := make(map[string]string, len(cfg.Rename))
+	for from, to := range cfg.Rename {
+		if !validToolName.MatchString(to) {
+			return fmt.Errorf("invalid name %q for %s, use up to 64 letters, digits, _ and -", to, from)
+		}
+		if _, tool, ok := strings.Cut(from, "."); ok && tool == "" {
+			return fmt.Errorf("invalid tool %q to rename, use tool or service.tool", from)
+		}
+		if other, ok := targets[to]; ok {
+			return fmt.Errorf("%s and %s are both renamed to %s", other, from, to)
+		}
+		targets[to] = from
+	}
+	return nil
+}
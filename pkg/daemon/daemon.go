@@ -0,0 +1,149 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package daemon installs and controls MoLing as a background service of the service manager of the operating
+// system: a systemd unit on Linux, a launchd agent on macOS and a Windows service.
+package daemon
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNotInstalled is returned by the managers for services that are not installed.
+var ErrNotInstalled = errors.New("the service is not installed")
+
+// Spec describes the service to install.
+type Spec struct {
+	Name        string   // Name is the name of the service, such as moling.
+	DisplayName string   // DisplayName is the name shown by the service manager.
+	Description string   // Description is the description shown by the service manager.
+	Executable  string   // Executable is the absolute path of the MoLing binary.
+	Args        []string // Args are the arguments the service runs with.
+	LogFile     string   // LogFile receives the standard output and error of the service, if the manager supports it.
+	System      bool     // System installs a service of the system, started at boot, instead of one of the current user.
+}
+
+// Status is the state of an installed service.
+type Status struct {
+	Installed bool
+	Running   bool
+	Path      string // Path is the unit file or plist of the service, or the name of the Windows service.
+	Detail    string // Detail is the state as the service manager reports it.
+}
+
+// Manager installs and controls a service.
+type Manager interface {
+	// Install writes the definition of the service and registers it to start with the system or the user session.
+	// It returns the path of the definition.
+	Install(spec Spec) (string, error)
+	// Uninstall stops the service and removes its definition.
+	Uninstall() error
+	Start() error
+	Stop() error
+	Status() (Status, error)
+}
+
+// New returns the manager of the service with the given name on this operating system. system selects the
+// services of the system instead of those of the current user, where the service manager makes the difference.
+func New(name string, system bool) (Manager, error) {
+	return newManager(name, system)
+}
+
+// systemdUnit returns the systemd unit of the service.
+func systemdUnit(spec Spec) string {
+	words := make([]string, 0, len(spec.Args)+1)
+	for _, w := range append([]string{spec.Executable}, spec.Args...) {
+		words = append(words, systemdQuote(w))
+	}
+	wantedBy := "default.target"
+	if spec.System {
+		wantedBy = "multi-user.target"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nAfter=network-online.target\nWants=network-online.target\n\n", spec.Description)
+	fmt.Fprintf(&b, "[Service]\nType=simple\nExecStart=%s\nRestart=on-failure\nRestartSec=5\n", strings.Join(words, " "))
+	if spec.LogFile != "" {
+		fmt.Fprintf(&b, "StandardOutput=append:%s\nStandardError=append:%s\n", spec.LogFile, spec.LogFile)
+	}
+	fmt.Fprintf(&b, "\n[Install]\nWantedBy=%s\n", wantedBy)
+	return b.String()
+}
+
+// systemdQuote quotes a word of ExecStart, where % starts a specifier.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "$", "$$")
+	return `"` + s + `"`
+}
+
+// launchdLabel returns the label of the launchd job of the service.
+func launchdLabel(name string) string {
+	return "cc.gojue." + name
+}
+
+// launchdPlist returns the launchd property list of the service. The job is kept alive unless it exits cleanly.
+func launchdPlist(spec Spec) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	plistString(&b, "Label", launchdLabel(spec.Name))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, w := range append([]string{spec.Executable}, spec.Args...) {
+		b.WriteString("\t\t<string>" + xmlEscape(w) + "</string>\n")
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	if spec.LogFile != "" {
+		plistString(&b, "StandardOutPath", spec.LogFile)
+		plistString(&b, "StandardErrorPath", spec.LogFile)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func plistString(b *strings.Builder, key, value string) {
+	b.WriteString("\t<key>" + xmlEscape(key) + "</key>\n\t<string>" + xmlEscape(value) + "</string>\n")
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// run executes a command of the service manager and returns its combined output, which is part of the error.
+func run(name string, args ...string) (string, error) {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		if out != "" {
+			return out, fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, out)
+		}
+		return out, fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// launchdManager manages a launchd job: an agent of the current user, or a daemon of the system if system is set.
+// The job is loaded at login or boot once it is installed; start and stop load and unload it.
+type launchdManager struct {
+	label  string
+	system bool
+}
+
+func newManager(name string, system bool) (Manager, error) {
+	return &launchdManager{label: launchdLabel(name), system: system}, nil
+}
+
+func (m *launchdManager) path() (string, error) {
+	if m.system {
+		return filepath.Join("/Library/LaunchDaemons", m.label+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", m.label+".plist"), nil
+}
+
+func (m *launchdManager) Install(spec Spec) (string, error) {
+	path, err := m.path()
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(launchdPlist(spec)), 0o644)
+}
+
+func (m *launchdManager) Uninstall() error {
+	path, err := m.path()
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	_, _ = run("launchctl", "unload", path)
+	return os.Remove(path)
+}
+
+func (m *launchdManager) Start() error {
+	path, err := m.path()
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	_, err = run("launchctl", "load", "-w", path)
+	return err
+}
+
+func (m *launchdManager) Stop() error {
+	path, err := m.path()
+	if err != nil {
+		return err
+	}
+	_, err = run("launchctl", "unload", path)
+	return err
+}
+
+func (m *launchdManager) Status() (Status, error) {
+	path, err := m.path()
+	if err != nil {
+		return Status{}, err
+	}
+	st := Status{Path: path}
+	if _, err = os.Stat(path); err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	// launchctl list fails for jobs that are not loaded. The PID key is only there while the job runs.
+	out, err := run("launchctl", "list", m.label)
+	switch {
+	case err != nil:
+		st.Detail = "not loaded"
+	case strings.Contains(out, `"PID" =`):
+		st.Running = true
+		st.Detail = "running"
+	default:
+		st.Detail = "loaded, not running"
+	}
+	return st, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// systemdManager manages a systemd unit, a user unit unless system is set. User units only run while the user is
+// logged in, unless lingering is enabled with loginctl enable-linger.
+type systemdManager struct {
+	name   string
+	system bool
+}
+
+func newManager(name string, system bool) (Manager, error) {
+	return &systemdManager{name: name, system: system}, nil
+}
+
+func (m *systemdManager) unit() string {
+	return m.name + ".service"
+}
+
+func (m *systemdManager) path() (string, error) {
+	if m.system {
+		return filepath.Join("/etc/systemd/system", m.unit()), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", m.unit()), nil
+}
+
+func (m *systemdManager) systemctl(args ...string) (string, error) {
+	if !m.system {
+		args = append([]string{"--user"}, args...)
+	}
+	return run("systemctl", args...)
+}
+
+func (m *systemdManager) Install(spec Spec) (string, error) {
+	path, err := m.path()
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(path, []byte(systemdUnit(spec)), 0o644); err != nil {
+		return "", err
+	}
+	if _, err = m.systemctl("daemon-reload"); err != nil {
+		return path, err
+	}
+	_, err = m.systemctl("enable", m.unit())
+	return path, err
+}
+
+func (m *systemdManager) Uninstall() error {
+	path, err := m.path()
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	_, _ = m.systemctl("disable", "--now", m.unit())
+	if err = os.Remove(path); err != nil {
+		return err
+	}
+	_, err = m.systemctl("daemon-reload")
+	return err
+}
+
+func (m *systemdManager) Start() error {
+	_, err := m.systemctl("start", m.unit())
+	return err
+}
+
+func (m *systemdManager) Stop() error {
+	_, err := m.systemctl("stop", m.unit())
+	return err
+}
+
+func (m *systemdManager) Status() (Status, error) {
+	path, err := m.path()
+	if err != nil {
+		return Status{}, err
+	}
+	st := Status{Path: path}
+	if _, err = os.Stat(path); err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	// is-active exits with an error for units that are not active, its output is the state.
+	out, err := m.systemctl("is-active", m.unit())
+	if err != nil && out == "" {
+		return st, fmt.Errorf("failed to query %s: %w", m.unit(), err)
+	}
+	st.Detail = out
+	st.Running = out == "active"
+	return st, nil
+}
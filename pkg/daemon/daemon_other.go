@@ -0,0 +1,28 @@
+//go:build !linux && !darwin && !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package daemon
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newManager(name string, system bool) (Manager, error) {
+	return nil, fmt.Errorf("running MoLing as a service is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package daemon
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Name:        "moling",
+		Description: "MoLing MCP Server",
+		Executable:  "/opt/mo ling/moling",
+		Args:        []string{"--listen_addr", "127.0.0.1:6789", "--module", "50%"},
+		LogFile:     "/home/me/.moling/logs/daemon.log",
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit(testSpec())
+	for _, want := range []string{
+		`ExecStart="/opt/mo ling/moling" --listen_addr 127.0.0.1:6789 --module 50%%` + "\n",
+		"StandardOutput=append:/home/me/.moling/logs/daemon.log\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("expected %q in the unit:\n%s", want, unit)
+		}
+	}
+	spec := testSpec()
+	spec.System = true
+	if unit = systemdUnit(spec); !strings.Contains(unit, "WantedBy=multi-user.target\n") {
+		t.Errorf("expected a unit of the system:\n%s", unit)
+	}
+	if q := systemdQuote(`a"b$c`); q != `"a\"b$$c"` {
+		t.Errorf("unexpected quoting %s", q)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	spec := testSpec()
+	spec.Args = append(spec.Args, "--profile", "<work>")
+	plist := launchdPlist(spec)
+	var parsed struct{ XMLName xml.Name }
+	if err := xml.Unmarshal([]byte(plist), &parsed); err != nil || parsed.XMLName.Local != "plist" {
+		t.Fatalf("invalid plist: %v\n%s", err, plist)
+	}
+	for _, want := range []string{
+		"<string>cc.gojue.moling</string>",
+		"<string>/opt/mo ling/moling</string>",
+		"<string>&lt;work&gt;</string>",
+		"<key>StandardErrorPath</key>\n\t<string>/home/me/.moling/logs/daemon.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("expected %q in the plist:\n%s", want, plist)
+		}
+	}
+}
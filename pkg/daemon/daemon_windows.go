@@ -0,0 +1,200 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// stopTimeout is how long Stop waits for the service to stop.
+const stopTimeout = 15 * time.Second
+
+// windowsManager manages a Windows service. Windows services belong to the system, installing one needs an
+// administrator; system is ignored. Without standard output, the service only logs to the log file of MoLing.
+type windowsManager struct {
+	name string
+}
+
+func newManager(name string, system bool) (Manager, error) {
+	return &windowsManager{name: name}, nil
+}
+
+// open connects to the service control manager and opens the service.
+func (m *windowsManager) open() (*mgr.Mgr, *mgr.Service, error) {
+	sm, err := mgr.Connect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	s, err := sm.OpenService(m.name)
+	if err != nil {
+		_ = sm.Disconnect()
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return nil, nil, ErrNotInstalled
+		}
+		return nil, nil, err
+	}
+	return sm, s, nil
+}
+
+func (m *windowsManager) Install(spec Spec) (string, error) {
+	sm, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer sm.Disconnect()
+	if s, err := sm.OpenService(m.name); err == nil {
+		_ = s.Close()
+		return "", fmt.Errorf("service %s already exists, uninstall it first", m.name)
+	}
+	s, err := sm.CreateService(m.name, spec.Executable, mgr.Config{
+		DisplayName: spec.DisplayName,
+		Description: spec.Description,
+		StartType:   mgr.StartAutomatic,
+	}, spec.Args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create service %s: %w", m.name, err)
+	}
+	defer s.Close()
+	// Restart the service after failures, like Restart=on-failure of systemd.
+	err = s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+	}, uint32((24 * time.Hour).Seconds()))
+	return m.name, err
+}
+
+func (m *windowsManager) Uninstall() error {
+	sm, s, err := m.open()
+	if err != nil {
+		return err
+	}
+	defer sm.Disconnect()
+	defer s.Close()
+	if st, err := s.Query(); err == nil && st.State != svc.Stopped {
+		_ = m.stop(s)
+	}
+	return s.Delete()
+}
+
+func (m *windowsManager) Start() error {
+	sm, s, err := m.open()
+	if err != nil {
+		return err
+	}
+	defer sm.Disconnect()
+	defer s.Close()
+	return s.Start()
+}
+
+func (m *windowsManager) Stop() error {
+	sm, s, err := m.open()
+	if err != nil {
+		return err
+	}
+	defer sm.Disconnect()
+	defer s.Close()
+	return m.stop(s)
+}
+
+// stop asks the service to stop and waits until it has.
+func (m *windowsManager) stop(s *mgr.Service) error {
+	st, err := s.Control(svc.Stop)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for st.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not stop within %s", m.name, stopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if st, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *windowsManager) Status() (Status, error) {
+	st := Status{Path: m.name}
+	sm, s, err := m.open()
+	if errors.Is(err, ErrNotInstalled) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	defer sm.Disconnect()
+	defer s.Close()
+	st.Installed = true
+	q, err := s.Query()
+	if err != nil {
+		return st, err
+	}
+	st.Running = q.State == svc.Running
+	st.Detail = stateNames[q.State]
+	return st, nil
+}
+
+var stateNames = map[svc.State]string{
+	svc.Stopped:         "stopped",
+	svc.StartPending:    "start pending",
+	svc.StopPending:     "stop pending",
+	svc.Running:         "running",
+	svc.ContinuePending: "continue pending",
+	svc.PausePending:    "pause pending",
+	svc.Paused:          "paused",
+}
+
+// RunAsService reports whether the process was started by the service control manager. If it was, it reports the
+// service as running and calls stop when the service control manager stops the service.
+func RunAsService(name string, stop func()) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, err
+	}
+	go func() {
+		_ = svc.Run(name, serviceHandler{stop: stop})
+	}()
+	return true, nil
+}
+
+// serviceHandler reports the state of MoLing to the service control manager.
+type serviceHandler struct {
+	stop func()
+}
+
+func (h serviceHandler) Execute(args []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+	s <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for c := range r {
+		switch c.Cmd {
+		case svc.Interrogate:
+			s <- c.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			s <- svc.Status{State: svc.StopPending}
+			h.stop()
+			return false, 0
+		}
+	}
+	return false, 0
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package i18n serves the tool descriptions, prompts and messages of MoLing in the configured language. The
+// catalogs of the supported languages are embedded in the binary, and files named after a language in the
+// override directory replace or add entries.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language of the texts in the code, and of messages missing from other catalogs.
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog Catalog
+)
+
+// defaults returns the embedded catalog of DefaultLanguage.
+func defaults() Catalog {
+	defaultOnce.Do(func() {
+		// The embedded catalogs are checked by the tests
+		defaultCatalog, _ = embedded(DefaultLanguage)
+	})
+	return defaultCatalog
+}
+
+// ToolText is the translation of a tool.
+type ToolText struct {
+	Description string            `json:"description"`
+	Parameters  map[string]string `json:"parameters"` // Parameters are the descriptions of the arguments by name.
+}
+
+// PromptText is the translation of a prompt.
+type PromptText struct {
+	Description string `json:"description"`
+	Text        string `json:"text"` // Text replaces the messages of the prompt, empty to keep them.
+}
+
+// Catalog holds the texts of one language. Texts missing from it are left as they are.
+type Catalog struct {
+	Tools    map[string]ToolText   `json:"tools"`    // Tools are the translations of tools by name.
+	Prompts  map[string]PromptText `json:"prompts"`  // Prompts are the translations of prompts by name.
+	Messages map[string]string     `json:"messages"` // Messages are the formats of server messages by ID.
+}
+
+// merge copies the entries of other over the entries of c.
+func (c *Catalog) merge(other Catalog) {
+	if c.Tools == nil {
+		c.Tools = make(map[string]ToolText)
+	}
+	if c.Prompts == nil {
+		c.Prompts = make(map[string]PromptText)
+	}
+	if c.Messages == nil {
+		c.Messages = make(map[string]string)
+	}
+	for name, t := range other.Tools {
+		old := c.Tools[name]
+		if t.Description != "" {
+			old.Description = t.Description
+		}
+		for param, desc := range t.Parameters {
+			if old.Parameters == nil {
+				old.Parameters = make(map[string]string)
+			}
+			old.Parameters[param] = desc
+		}
+		c.Tools[name] = old
+	}
+	for name, p := range other.Prompts {
+		old := c.Prompts[name]
+		if p.Description != "" {
+			old.Description = p.Description
+		}
+		if p.Text != "" {
+			old.Text = p.Text
+		}
+		c.Prompts[name] = old
+	}
+	for id, msg := range other.Messages {
+		c.Messages[id] = msg
+	}
+}
+
+// Languages returns the languages of the embedded catalogs.
+func Languages() []string {
+	entries, _ := catalogs.ReadDir("catalogs")
+	langs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize returns the canonical name of a language, such as zh-CN for zh_cn, or the language of an embedded
+// catalog that matches its first part, such as zh-CN for zh. It returns lang unchanged if no catalog matches.
+func Normalize(lang string) string {
+	lang = strings.ReplaceAll(strings.TrimSpace(lang), "_", "-")
+	if lang == "" {
+		return DefaultLanguage
+	}
+	// Drop the encoding of locales such as zh_CN.UTF-8
+	lang, _, _ = strings.Cut(lang, ".")
+	base, _, _ := strings.Cut(lang, "-")
+	var partial string
+	for _, l := range Languages() {
+		if strings.EqualFold(l, lang) {
+			return l
+		}
+		if lb, _, _ := strings.Cut(l, "-"); partial == "" && strings.EqualFold(lb, base) {
+			partial = l
+		}
+	}
+	if partial != "" {
+		return partial
+	}
+	return lang
+}
+
+// Localizer serves the texts of one language. The nil Localizer serves the texts of DefaultLanguage.
+type Localizer struct {
+	lang    string
+	catalog Catalog
+}
+
+// Load returns the Localizer of the language, with the entries of the file <lang>.json in dir, if any, over the
+// embedded catalog. It fails if there is neither an embedded catalog nor a file for the language.
+func Load(lang, dir string) (*Localizer, error) {
+	lang = Normalize(lang)
+	l := &Localizer{lang: lang}
+	found := false
+	if c, err := embedded(lang); err == nil {
+		l.catalog.merge(c)
+		found = true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if dir != "" {
+		c, err := readCatalog(filepath.Join(dir, lang+".json"))
+		switch {
+		case err == nil:
+			l.catalog.merge(c)
+			found = true
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unsupported language %s, use one of %s or add %s.json to %s", lang, strings.Join(Languages(), ", "), lang, dir)
+	}
+	return l, nil
+}
+
+// embedded returns the embedded catalog of the language.
+func embedded(lang string) (Catalog, error) {
+	var c Catalog
+	data, err := catalogs.ReadFile("catalogs/" + lang + ".json")
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid catalog %s: %w", lang, err)
+	}
+	return c, nil
+}
+
+// readCatalog reads an override file.
+func readCatalog(path string) (Catalog, error) {
+	var c Catalog
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Language returns the language of the Localizer.
+func (l *Localizer) Language() string {
+	if l == nil {
+		return DefaultLanguage
+	}
+	return l.lang
+}
+
+// Tool returns the translation of the tool with the given name, and whether there is one.
+func (l *Localizer) Tool(name string) (ToolText, bool) {
+	if l == nil {
+		return ToolText{}, false
+	}
+	t, ok := l.catalog.Tools[name]
+	return t, ok
+}
+
+// Prompt returns the translation of the prompt with the given name, and whether there is one.
+func (l *Localizer) Prompt(name string) (PromptText, bool) {
+	if l == nil {
+		return PromptText{}, false
+	}
+	p, ok := l.catalog.Prompts[name]
+	return p, ok
+}
+
+// Sprintf formats the message with the given ID in the language of the Localizer, or in DefaultLanguage if its
+// catalog lacks the message. Unknown IDs are used as the format.
+func (l *Localizer) Sprintf(id string, a ...any) string {
+	format, ok := "", false
+	if l != nil {
+		format, ok = l.catalog.Messages[id]
+	}
+	if !ok {
+		format, ok = defaults().Messages[id]
+	}
+	if !ok {
+		format = id
+	}
+	return fmt.Sprintf(format, a...)
+}
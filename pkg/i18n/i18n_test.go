@@ -0,0 +1,109 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"":            "en",
+		"en":          "en",
+		"zh_CN":       "zh-CN",
+		"zh-cn":       "zh-CN",
+		"zh":          "zh-CN",
+		"zh_CN.UTF-8": "zh-CN",
+		"fr":          "fr",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestCatalogs checks that every embedded catalog parses and translates the messages of the English catalog with
+// the same number of arguments.
+func TestCatalogs(t *testing.T) {
+	verbs := regexp.MustCompile(`%(\[\d+\])?[a-zA-Z]`)
+	en := defaults()
+	if len(en.Messages) == 0 {
+		t.Fatal("the English catalog has no messages")
+	}
+	for _, lang := range Languages() {
+		c, err := embedded(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, msg := range c.Messages {
+			source, ok := en.Messages[id]
+			if !ok {
+				t.Errorf("%s: message %s is not in the English catalog", lang, id)
+				continue
+			}
+			if len(verbs.FindAllString(msg, -1)) != len(verbs.FindAllString(source, -1)) {
+				t.Errorf("%s: message %s takes other arguments than %q", lang, id, source)
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "zh-CN.json"), []byte(`{"tools":{"read_file":{"parameters":{"path":"路径"}}},"messages":{"server.tool_not_found":"没有工具 %s"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load("zh", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Language() != "zh-CN" {
+		t.Fatalf("unexpected language %s", l.Language())
+	}
+	tool, ok := l.Tool("read_file")
+	if !ok || tool.Description == "" || tool.Parameters["path"] != "路径" {
+		t.Fatalf("the override file must be merged over the embedded catalog, got %+v", tool)
+	}
+	if got := l.Sprintf("server.tool_not_found", "x"); got != "没有工具 x" {
+		t.Fatalf("unexpected message %q", got)
+	}
+	if got := l.Sprintf("no.such.message"); got != "no.such.message" {
+		t.Fatalf("unknown IDs must be used as the format, got %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"messages":{"server.tool_not_found":"outil %s introuvable"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Load("fr", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Sprintf("server.access_denied", "ci", "x"); got != "access denied - ci may not use the tool x" {
+		t.Fatalf("messages missing from the catalog must be English, got %q", got)
+	}
+	if _, err = Load("de", dir); err == nil {
+		t.Fatal("a language without a catalog must fail")
+	}
+
+	var nilLocalizer *Localizer
+	if got := nilLocalizer.Sprintf("server.tool_not_found", "x"); got != "tool x not found" {
+		t.Fatalf("the nil Localizer must be English, got %q", got)
+	}
+}
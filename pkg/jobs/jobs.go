@@ -0,0 +1,399 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package jobs runs long tool calls in the background. Jobs are queued, run a few at a time and kept with their
+// result in a SQLite database, so that their outcome survives restarts of MoLing.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/utils/sqlitedb"
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+	// StatusInterrupted marks the jobs that were queued or running when MoLing stopped.
+	StatusInterrupted Status = "interrupted"
+)
+
+// DBFile is the name of the database in the jobs directory.
+const DBFile = "jobs.db"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id       TEXT PRIMARY KEY,
+	tool     TEXT NOT NULL,
+	service  TEXT NOT NULL DEFAULT '',
+	owner    TEXT NOT NULL DEFAULT '',
+	session  TEXT NOT NULL DEFAULT '',
+	status   TEXT NOT NULL,
+	created  INTEGER NOT NULL,
+	started  INTEGER,
+	finished INTEGER,
+	error    TEXT NOT NULL DEFAULT '',
+	result   BLOB
+);
+`
+
+// Done reports whether a job in this state has finished.
+func (s Status) Done() bool {
+	return s != StatusQueued && s != StatusRunning
+}
+
+var (
+	// ErrNotFound is returned for unknown jobs.
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that has already finished.
+	ErrFinished = errors.New("the job has already finished")
+	// ErrClosed is returned when submitting jobs to a closed queue.
+	ErrClosed = errors.New("the job queue is closed")
+)
+
+// Job is a background tool call.
+type Job struct {
+	ID       string          `json:"id"`
+	Tool     string          `json:"tool"`
+	Service  string          `json:"service,omitempty"`
+	Owner    string          `json:"owner,omitempty"`   // Owner is the principal that submitted the job, empty for unauthenticated clients.
+	Session  string          `json:"session,omitempty"` // Session is the client session that submitted the job.
+	Status   Status          `json:"status"`
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started,omitempty"`
+	Finished *time.Time      `json:"finished,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"` // Result is the result of the tool call, kept for failed jobs too.
+}
+
+// Func runs a job. It returns the result to keep and an error if the job failed.
+type Func func(ctx context.Context) (json.RawMessage, error)
+
+// Options configures a Queue.
+type Options struct {
+	MaxRunning int           // MaxRunning is the number of jobs that run at the same time.
+	MaxKept    int           // MaxKept is the number of finished jobs kept, the oldest are removed first.
+	Timeout    time.Duration // Timeout cancels the jobs that run longer, 0 for no limit.
+}
+
+// entry is a job and what the queue needs to run and stop it.
+type entry struct {
+	job    Job
+	cancel context.CancelFunc // cancel stops the job while it is queued or running.
+	stop   Status             // stop is the state a job ends in once it was cancelled, empty if it was not.
+}
+
+// Queue runs jobs in the background and keeps them in a database.
+type Queue struct {
+	db     *sql.DB
+	opts   Options
+	logger zerolog.Logger
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	lock   sync.Mutex
+	jobs   map[string]*entry
+	closed bool
+}
+
+// Open loads the jobs kept in the database in dir and returns a queue for new ones. The jobs that were queued or
+// running when the previous queue stopped without Close are marked as interrupted.
+func Open(dir string, opts Options, logger zerolog.Logger) (*Queue, error) {
+	if opts.MaxRunning < 1 {
+		opts.MaxRunning = 1
+	}
+	db, err := sqlitedb.Open(filepath.Join(dir, DBFile), schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the jobs database: %w", err)
+	}
+	q := &Queue{db: db, opts: opts, logger: logger, slots: make(chan struct{}, opts.MaxRunning), jobs: make(map[string]*entry)}
+	kept, err := q.load()
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to load the jobs: %w", err)
+	}
+	for _, job := range kept {
+		if !job.Status.Done() {
+			now := time.Now()
+			job.Status, job.Finished, job.Error = StatusInterrupted, &now, "MoLing stopped before the job finished"
+			q.save(job)
+		}
+		q.jobs[job.ID] = &entry{job: job}
+	}
+	q.lock.Lock()
+	q.prune()
+	q.lock.Unlock()
+	return q, nil
+}
+
+// Submit queues a job and returns it with its ID. The job runs with a context detached from ctx, with its values.
+func (q *Queue) Submit(ctx context.Context, job Job, run Func) (Job, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return Job{}, ErrClosed
+	}
+	job.ID = newID()
+	job.Status = StatusQueued
+	job.Created = time.Now()
+	job.Started, job.Finished, job.Error, job.Result = nil, nil, "", nil
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	e := &entry{job: job, cancel: cancel}
+	q.jobs[job.ID] = e
+	q.save(job)
+	q.wg.Add(1)
+	go q.run(ctx, e, run)
+	return job, nil
+}
+
+// run waits for a free slot, then runs the job and records its outcome.
+func (q *Queue) run(ctx context.Context, e *entry, run Func) {
+	defer q.wg.Done()
+	defer e.cancel()
+	select {
+	case q.slots <- struct{}{}:
+		defer func() { <-q.slots }()
+	case <-ctx.Done():
+		q.finish(e, nil, ctx.Err())
+		return
+	}
+	q.lock.Lock()
+	if e.stop != "" {
+		q.lock.Unlock()
+		q.finish(e, nil, context.Canceled)
+		return
+	}
+	now := time.Now()
+	e.job.Status, e.job.Started = StatusRunning, &now
+	q.save(e.job)
+	q.lock.Unlock()
+
+	if q.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.opts.Timeout)
+		defer cancel()
+	}
+	result, err := run(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	q.finish(e, result, err)
+}
+
+// finish records the outcome of a job and removes the oldest finished jobs beyond MaxKept.
+func (q *Queue) finish(e *entry, result json.RawMessage, err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	now := time.Now()
+	e.job.Finished, e.job.Result = &now, result
+	switch {
+	case e.stop != "":
+		e.job.Status = e.stop
+		if e.stop == StatusInterrupted {
+			e.job.Error = "MoLing stopped before the job finished"
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		e.job.Status, e.job.Error = StatusFailed, fmt.Sprintf("the job did not finish within %s", q.opts.Timeout)
+	case err != nil:
+		e.job.Status, e.job.Error = StatusFailed, err.Error()
+	default:
+		e.job.Status = StatusSucceeded
+	}
+	q.save(e.job)
+	q.logger.Info().Str("job", e.job.ID).Str("tool", e.job.Tool).Str("status", string(e.job.Status)).Msg("job finished")
+	q.prune()
+}
+
+// Get returns the job with the given ID.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.job, true
+}
+
+// List returns the jobs, the newest first, without their results.
+func (q *Queue) List() []Job {
+	q.lock.Lock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, e := range q.jobs {
+		job := e.job
+		job.Result = nil
+		jobs = append(jobs, job)
+	}
+	q.lock.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.After(jobs[j].Created) })
+	return jobs
+}
+
+// Cancel stops a queued or running job. The job is marked as cancelled once its tool call returns.
+func (q *Queue) Cancel(id string) (Job, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if e.job.Status.Done() || e.cancel == nil {
+		return e.job, ErrFinished
+	}
+	if e.stop == "" {
+		e.stop = StatusCancelled
+	}
+	e.cancel()
+	return e.job, nil
+}
+
+// Close stops accepting jobs, cancels the queued and running ones and waits until ctx is done for them to return.
+// They are kept as interrupted. The database is closed afterwards, the jobs that have not returned by then stay
+// running in it and are marked as interrupted by the next Open.
+func (q *Queue) Close(ctx context.Context) error {
+	defer func() {
+		if err := q.db.Close(); err != nil {
+			q.logger.Warn().Err(err).Msg("failed to close the jobs database")
+		}
+	}()
+	q.lock.Lock()
+	q.closed = true
+	running := 0
+	for _, e := range q.jobs {
+		if !e.job.Status.Done() && e.cancel != nil {
+			if e.stop == "" {
+				e.stop = StatusInterrupted
+			}
+			e.cancel()
+			running++
+		}
+	}
+	q.lock.Unlock()
+	if running == 0 {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d jobs did not stop in time", running)
+	}
+}
+
+// load reads the jobs kept in the database.
+func (q *Queue) load() ([]Job, error) {
+	rows, err := q.db.Query(`SELECT id, tool, service, owner, session, status, created, started, finished, error, result FROM jobs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		var created int64
+		var started, finished sql.NullInt64
+		var result []byte
+		err = rows.Scan(&job.ID, &job.Tool, &job.Service, &job.Owner, &job.Session, &job.Status, &created, &started, &finished, &job.Error, &result)
+		if err != nil {
+			return nil, err
+		}
+		job.Created, job.Started, job.Finished, job.Result = time.Unix(0, created), fromNull(started), fromNull(finished), result
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// save writes a job to the database. The caller holds the lock.
+func (q *Queue) save(job Job) {
+	_, err := q.db.Exec(`INSERT INTO jobs (id, tool, service, owner, session, status, created, started, finished, error, result)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET status = excluded.status, started = excluded.started, finished = excluded.finished,
+	error = excluded.error, result = excluded.result`,
+		job.ID, job.Tool, job.Service, job.Owner, job.Session, job.Status, job.Created.UnixNano(), toNull(job.Started),
+		toNull(job.Finished), job.Error, []byte(job.Result))
+	if err != nil {
+		q.logger.Warn().Err(err).Str("job", job.ID).Msg("failed to save the job")
+	}
+}
+
+// prune removes the oldest finished jobs beyond MaxKept. The caller holds the lock.
+func (q *Queue) prune() {
+	if q.opts.MaxKept < 1 {
+		return
+	}
+	finished := make([]Job, 0, len(q.jobs))
+	for _, e := range q.jobs {
+		if e.job.Status.Done() {
+			finished = append(finished, e.job)
+		}
+	}
+	if len(finished) <= q.opts.MaxKept {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].Created.Before(finished[j].Created) })
+	for _, job := range finished[:len(finished)-q.opts.MaxKept] {
+		delete(q.jobs, job.ID)
+		if _, err := q.db.Exec(`DELETE FROM jobs WHERE id = ?`, job.ID); err != nil {
+			q.logger.Warn().Err(err).Str("job", job.ID).Msg("failed to remove the job")
+		}
+	}
+}
+
+func toNull(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+func fromNull(n sql.NullInt64) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	t := time.Unix(0, n.Int64)
+	return &t
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
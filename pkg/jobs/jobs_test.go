@@ -0,0 +1,144 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// wait returns the job once it has finished.
+func wait(t *testing.T, q *Queue, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, ok := q.Get(id); ok && job.Status.Done() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, Options{MaxRunning: 1, MaxKept: 2}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to open the queue: %v", err)
+	}
+
+	ok, _ := q.Submit(context.Background(), Job{Tool: "echo"}, func(ctx context.Context) (json.RawMessage, error) {
+		return json.RawMessage(`"done"`), nil
+	})
+	if job := wait(t, q, ok.ID); job.Status != StatusSucceeded || string(job.Result) != `"done"` || job.Started == nil {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	failed, _ := q.Submit(context.Background(), Job{Tool: "fail"}, func(ctx context.Context) (json.RawMessage, error) {
+		return json.RawMessage(`"partial"`), errors.New("boom")
+	})
+	if job := wait(t, q, failed.ID); job.Status != StatusFailed || job.Error != "boom" || string(job.Result) != `"partial"` {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	// A running job holds the only slot, the next one waits in the queue until it is cancelled
+	started := make(chan struct{})
+	running, _ := q.Submit(context.Background(), Job{Tool: "sleep"}, func(ctx context.Context) (json.RawMessage, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+	queued, _ := q.Submit(context.Background(), Job{Tool: "sleep"}, func(ctx context.Context) (json.RawMessage, error) {
+		t.Error("a cancelled job must not run")
+		return nil, nil
+	})
+	if job, _ := q.Get(queued.ID); job.Status != StatusQueued {
+		t.Fatalf("expected a queued job, got %s", job.Status)
+	}
+	for _, id := range []string{queued.ID, running.ID} {
+		if _, err = q.Cancel(id); err != nil {
+			t.Fatalf("failed to cancel the job: %v", err)
+		}
+		if job := wait(t, q, id); job.Status != StatusCancelled {
+			t.Fatalf("expected a cancelled job, got %s", job.Status)
+		}
+	}
+	if _, err = q.Cancel(running.ID); !errors.Is(err, ErrFinished) {
+		t.Fatalf("expected ErrFinished, got %v", err)
+	}
+	if _, err = q.Cancel("nope"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if n := len(q.List()); n != 2 {
+		t.Fatalf("only max_kept finished jobs must be kept, got %d", n)
+	}
+	if err = q.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close the queue: %v", err)
+	}
+	q, err = Open(dir, Options{MaxRunning: 1, MaxKept: 2}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to reopen the queue: %v", err)
+	}
+	defer q.Close(context.Background())
+	if n := len(q.List()); n != 2 {
+		t.Fatalf("the pruned jobs must be removed from the database, got %d", n)
+	}
+}
+
+func TestQueueRestart(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, Options{MaxRunning: 1, Timeout: 50 * time.Millisecond}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to open the queue: %v", err)
+	}
+	slow := func(ctx context.Context) (json.RawMessage, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	timedOut, _ := q.Submit(context.Background(), Job{Tool: "sleep"}, slow)
+	if job := wait(t, q, timedOut.ID); job.Status != StatusFailed {
+		t.Fatalf("a job running past the timeout must fail, got %s", job.Status)
+	}
+
+	q.opts.Timeout = 0
+	running, _ := q.Submit(context.Background(), Job{Tool: "sleep", Owner: "alice"}, slow)
+	if err = q.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close the queue: %v", err)
+	}
+	if _, err = q.Submit(context.Background(), Job{Tool: "echo"}, slow); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	q, err = Open(dir, Options{MaxRunning: 1}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("failed to reopen the queue: %v", err)
+	}
+	job, ok := q.Get(running.ID)
+	if !ok || job.Status != StatusInterrupted || job.Owner != "alice" {
+		t.Fatalf("the running job must be kept as interrupted, got %+v", job)
+	}
+	if job, _ = q.Get(timedOut.ID); job.Status != StatusFailed || job.Started == nil || job.Error == "" {
+		t.Fatalf("finished jobs must survive restarts, got %+v", job)
+	}
+	_ = q.Close(context.Background())
+}
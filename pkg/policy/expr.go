@@ -0,0 +1,655 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package policy
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled rule expression. Expressions read like CEL: they compare the variables of a tool call, such as
+// tool, service, principal, roles, session, args and time, with literals, combined with &&, || and !. For example:
+//
+//	service == "Command" && !("admin" in roles)
+//	tool == "write_file" && startsWith(args.path, "/etc/")
+//	args.url matches "^https?://intranet\\." || time.hour < 8
+//
+// Comparisons are ==, !=, <, <=, >, >=, in (an item of a list, a key of a map or a substring) and matches (a regular
+// expression). The functions are startsWith, endsWith, contains, glob, lower and size. Missing variables and
+// arguments are null, and comparisons of values of different types are false.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses an expression.
+func Compile(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression with the given variables. The expression must result in a boolean.
+func (e *Expr) Eval(vars map[string]any) (bool, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return truth(v, "the expression")
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+// operators are the operator tokens, the longer ones first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+// comparisons are the binary operators between two values.
+var comparisons = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "in": true, "matches": true}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && rune(src[j]) != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text := src[i+1 : j]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(src[i : j+1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+				}
+				text = unquoted
+			} else {
+				text = strings.ReplaceAll(text, `\'`, `'`)
+			}
+			toks = append(toks, token{kind: tokString, text: text, pos: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			j := i + 1
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: i})
+			i = j
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return fmt.Errorf("expected %q at the end", op)
+		}
+		return fmt.Errorf("expected %q at offset %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if !comparisons[t.text] || (t.kind != tokOp && t.kind != tokIdent) {
+		return left, nil
+	}
+	op := t.text
+	p.next()
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if op != "matches" {
+		return &compareNode{op: op, left: left, right: right}, nil
+	}
+	lit, ok := right.(*literalNode)
+	var pattern string
+	if ok {
+		pattern, ok = lit.value.(string)
+	}
+	if !ok {
+		return nil, fmt.Errorf("matches at offset %d needs a string literal", t.pos)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression at offset %d: %w", t.pos, err)
+	}
+	return &matchNode{value: left, re: re}, nil
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected a field name at offset %d", t.pos)
+			}
+			n = &indexNode{base: n, index: &literalNode{value: t.text}}
+		case p.accept("["):
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{base: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return &literalNode{value: t.text}, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.accept("(") {
+			return &varNode{name: t.text}, nil
+		}
+		fn, ok := functions[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s at offset %d", t.text, t.pos)
+		}
+		args, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != fn.arity {
+			return nil, fmt.Errorf("%s takes %d arguments, got %d at offset %d", t.text, fn.arity, len(args), t.pos)
+		}
+		return &callNode{name: t.text, fn: fn.call, args: args}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of the expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+// parseList parses comma-separated expressions up to the closing operator.
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.accept(closing) {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(closing) {
+			return items, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literalNode struct{ value any }
+
+func (n *literalNode) eval(map[string]any) (any, error) { return n.value, nil }
+
+type varNode struct{ name string }
+
+func (n *varNode) eval(vars map[string]any) (any, error) { return vars[n.name], nil }
+
+type listNode struct{ items []node }
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	list := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+type indexNode struct{ base, index node }
+
+func (n *indexNode) eval(vars map[string]any) (any, error) {
+	base, err := n.base.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch b := base.(type) {
+	case map[string]any:
+		key, _ := index.(string)
+		return b[key], nil
+	case []any:
+		i, ok := number(index)
+		if !ok || i < 0 || int(i) >= len(b) {
+			return nil, nil
+		}
+		return b[int(i)], nil
+	}
+	return nil, nil
+}
+
+type logicalNode struct {
+	or          bool
+	left, right node
+}
+
+func (n *logicalNode) eval(vars map[string]any) (any, error) {
+	op := "&&"
+	if n.or {
+		op = "||"
+	}
+	v, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	left, err := truth(v, "the operand of "+op)
+	if err != nil || left == n.or {
+		return left, err
+	}
+	v, err = n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return truth(v, "the operand of "+op)
+}
+
+type notNode struct{ operand node }
+
+func (n *notNode) eval(vars map[string]any) (any, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truth(v, "the operand of !")
+	return !b, err
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left), nil
+	}
+	var c int
+	if l, ok := number(left); ok {
+		r, ok := number(right)
+		if !ok {
+			return false, nil
+		}
+		c = compareOrdered(l, r)
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return false, nil
+		}
+		c = strings.Compare(l, r)
+	} else {
+		return false, nil
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+type matchNode struct {
+	value node
+	re    *regexp.Regexp
+}
+
+func (n *matchNode) eval(vars map[string]any) (any, error) {
+	v, err := n.value.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := v.(string)
+	return ok && n.re.MatchString(s), nil
+}
+
+type callNode struct {
+	name string
+	fn   func(args []any) any
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return n.fn(args), nil
+}
+
+type function struct {
+	arity int
+	call  func(args []any) any
+}
+
+// stringFunc returns a function of two strings that is false if either argument is not a string.
+func stringFunc(fn func(s, arg string) bool) function {
+	return function{arity: 2, call: func(args []any) any {
+		s, ok := args[0].(string)
+		arg, isString := args[1].(string)
+		return ok && isString && fn(s, arg)
+	}}
+}
+
+var functions = map[string]function{
+	"startsWith": stringFunc(strings.HasPrefix),
+	"endsWith":   stringFunc(strings.HasSuffix),
+	"glob": stringFunc(func(s, pattern string) bool {
+		ok, _ := path.Match(pattern, s)
+		return ok
+	}),
+	"contains": {arity: 2, call: func(args []any) any { return contains(args[0], args[1]) }},
+	"lower": {arity: 1, call: func(args []any) any {
+		if s, ok := args[0].(string); ok {
+			return strings.ToLower(s)
+		}
+		return args[0]
+	}},
+	"size": {arity: 1, call: func(args []any) any {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v)))
+		case []any:
+			return float64(len(v))
+		case map[string]any:
+			return float64(len(v))
+		}
+		return float64(0)
+	}},
+}
+
+// truth returns the value of a boolean operand. Null, such as a missing argument, is false.
+func truth(v any, what string) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s is %v, not a boolean", what, v)
+}
+
+// number returns the value of the numeric types of JSON arguments and variables.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// contains reports whether item is an item of a list, a key of a map or a substring of a string.
+func contains(collection, item any) bool {
+	switch c := collection.(type) {
+	case []any:
+		for _, v := range c {
+			if equal(v, item) {
+				return true
+			}
+		}
+	case []string:
+		for _, v := range c {
+			if v == item {
+				return true
+			}
+		}
+	case map[string]any:
+		key, ok := item.(string)
+		if ok {
+			_, found := c[key]
+			return found
+		}
+	case string:
+		s, ok := item.(string)
+		return ok && strings.Contains(c, s)
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package policy decides whether tool calls may run. Rules are expressions over the tool, its arguments, the
+// identity of the client and the time, evaluated in order: the first rule that matches a call decides it.
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Effect is what a policy decides for a tool call.
+type Effect string
+
+const (
+	Allow   Effect = "allow"   // Allow runs the call.
+	Deny    Effect = "deny"    // Deny rejects the call.
+	Approve Effect = "approve" // Approve runs the call once the user approves it.
+)
+
+// Rule applies an effect to the tool calls its expression matches.
+type Rule struct {
+	Name    string `json:"name"`
+	When    string `json:"when"`    // When is the expression the calls must match, empty for all calls.
+	Effect  Effect `json:"effect"`  // Effect is allow, deny or approve.
+	Message string `json:"message"` // Message tells the client, or the user asked for approval, why.
+}
+
+// Input describes a tool call for the rules.
+type Input struct {
+	Tool      string
+	Service   string
+	Args      map[string]any
+	Principal string   // Principal is the authenticated client, empty for clients that may use all tools.
+	Roles     []string // Roles are the roles of the principal.
+	Profile   string
+	Session   string
+	Time      time.Time // Time is when the call is made, now if zero.
+}
+
+// Decision is the effect of the policy on a tool call, and the rule that decided it.
+type Decision struct {
+	Effect  Effect `json:"effect"`
+	Rule    string `json:"rule,omitempty"` // Rule is the name of the rule that matched, empty for the default effect.
+	Message string `json:"message,omitempty"`
+}
+
+// Reason describes why the decision was made, for clients and logs.
+func (d Decision) Reason() string {
+	if d.Rule == "" {
+		return "no rule matched"
+	}
+	if d.Message == "" {
+		return fmt.Sprintf("rule %s", d.Rule)
+	}
+	return fmt.Sprintf("rule %s: %s", d.Rule, d.Message)
+}
+
+type compiledRule struct {
+	Rule
+	expr *Expr
+}
+
+// Engine evaluates a list of rules.
+type Engine struct {
+	fallback Effect
+	rules    []compiledRule
+}
+
+// New compiles the rules. Calls that no rule matches get the fallback effect, allow if empty.
+func New(fallback Effect, rules []Rule) (*Engine, error) {
+	if fallback == "" {
+		fallback = Allow
+	}
+	if err := checkEffect(fallback); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	e := &Engine{fallback: fallback, rules: make([]compiledRule, 0, len(rules))}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := checkEffect(r.Effect); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		c := compiledRule{Rule: r}
+		if r.When != "" {
+			expr, err := Compile(r.When)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+			}
+			c.expr = expr
+		}
+		e.rules = append(e.rules, c)
+	}
+	return e, nil
+}
+
+func checkEffect(effect Effect) error {
+	switch effect {
+	case Allow, Deny, Approve:
+		return nil
+	}
+	return fmt.Errorf("effect %q must be allow, deny or approve", effect)
+}
+
+// Evaluate returns the decision of the first rule that matches the call. A rule that cannot be evaluated denies the
+// call, so that a mistake in a rule never lets a call through.
+func (e *Engine) Evaluate(in Input) Decision {
+	vars := in.vars()
+	for _, r := range e.rules {
+		if r.expr == nil {
+			return Decision{Effect: r.Effect, Rule: r.Name, Message: r.Message}
+		}
+		match, err := r.expr.Eval(vars)
+		if err != nil {
+			return Decision{Effect: Deny, Rule: r.Name, Message: fmt.Sprintf("the rule failed: %s", err.Error())}
+		}
+		if match {
+			return Decision{Effect: r.Effect, Rule: r.Name, Message: r.Message}
+		}
+	}
+	return Decision{Effect: e.fallback}
+}
+
+// vars returns the variables of the rule expressions.
+func (in Input) vars() map[string]any {
+	args := in.Args
+	if args == nil {
+		args = map[string]any{}
+	}
+	roles := make([]any, 0, len(in.Roles))
+	for _, r := range in.Roles {
+		roles = append(roles, r)
+	}
+	t := in.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return map[string]any{
+		"tool":      in.Tool,
+		"service":   in.Service,
+		"args":      args,
+		"principal": in.Principal,
+		"roles":     roles,
+		"profile":   in.Profile,
+		"session":   in.Session,
+		"time": map[string]any{
+			"hour":    float64(t.Hour()),
+			"minute":  float64(t.Minute()),
+			"weekday": t.Weekday().String(),
+			"date":    t.Format(time.DateOnly),
+		},
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`tool ==`,
+		`tool == "write_file`,
+		`(tool == "a"`,
+		`args.path matches "("`,
+		`args.path matches tool`,
+		`unknown(tool)`,
+		`startsWith(tool)`,
+		`tool = "a"`,
+		`tool == "a" service`,
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%s: expected a compile error", src)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	vars := Input{
+		Tool:      "write_file",
+		Service:   "FileSystem",
+		Args:      map[string]any{"path": "/etc/hosts", "size": float64(2048), "tags": []any{"a", "b"}, "opts": map[string]any{"force": true}},
+		Principal: "ci",
+		Roles:     []string{"operator"},
+		Time:      time.Date(2026, 10, 16, 22, 30, 0, 0, time.Local),
+	}.vars()
+	for src, want := range map[string]bool{
+		`tool == "write_file"`:                                  true,
+		`tool != 'write_file'`:                                  false,
+		`service == "FileSystem" && tool == "read_file"`:        false,
+		`service == "Command" || tool == "write_file"`:          true,
+		`!(principal == "ci")`:                                  false,
+		`"operator" in roles`:                                   true,
+		`"admin" in roles`:                                      false,
+		`tool in ["write_file", "move_file"]`:                   true,
+		`startsWith(args.path, "/etc/")`:                        true,
+		`glob(tool, "write_*")`:                                 true,
+		`args.path matches "^/etc/(hosts|passwd)$"`:             true,
+		`args.size > 1024 && args.size <= 2048`:                 true,
+		`args.missing == null`:                                  true,
+		`args.missing > 1`:                                      false,
+		`args.missing`:                                          false,
+		`args.opts.force`:                                       true,
+		`args["opts"]["force"] && args.tags[1] == "b"`:          true,
+		`contains(args.tags, "a") && size(args.tags) == 2`:      true,
+		`"hosts" in args.path`:                                  true,
+		`lower(service) == "filesystem"`:                        true,
+		`time.hour >= 22 || time.hour < 6`:                      true,
+		`time.weekday == "Friday" && time.date == "2026-10-16"`: true,
+	} {
+		expr, err := Compile(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		got, err := expr.Eval(vars)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %v, got %v", src, want, got)
+		}
+	}
+
+	expr, _ := Compile(`args.path && true`)
+	if _, err := expr.Eval(vars); err == nil {
+		t.Fatal("a string operand of && must be an error")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	if _, err := New("maybe", nil); err == nil {
+		t.Fatal("an unknown default effect must be rejected")
+	}
+	if _, err := New("", []Rule{{Name: "bad", When: `tool ==`, Effect: Deny}}); err == nil || !strings.Contains(err.Error(), "rule bad") {
+		t.Fatalf("an invalid rule must be rejected with its name, got %v", err)
+	}
+	if _, err := New("", []Rule{{When: `true`}}); err == nil || !strings.Contains(err.Error(), "rule #1") {
+		t.Fatalf("a rule without effect must be rejected, got %v", err)
+	}
+
+	e, err := New(Allow, []Rule{
+		{Name: "no-etc", When: `startsWith(args.path, "/etc/")`, Effect: Deny, Message: "system files are off limits"},
+		{Name: "admins", When: `"admin" in roles`, Effect: Allow},
+		{Name: "commands", When: `service == "Command"`, Effect: Approve},
+		{Name: "broken", When: `args.path && true`, Effect: Allow},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		in   Input
+		want Decision
+	}{
+		{Input{Tool: "write_file", Args: map[string]any{"path": "/etc/hosts"}, Roles: []string{"admin"}}, Decision{Effect: Deny, Rule: "no-etc", Message: "system files are off limits"}},
+		{Input{Tool: "execute_command", Service: "Command", Roles: []string{"admin"}}, Decision{Effect: Allow, Rule: "admins"}},
+		{Input{Tool: "execute_command", Service: "Command"}, Decision{Effect: Approve, Rule: "commands"}},
+		{Input{Tool: "read_file"}, Decision{Effect: Allow}},
+	} {
+		if got := e.Evaluate(c.in); got != c.want {
+			t.Errorf("%s: expected %+v, got %+v", c.in.Tool, c.want, got)
+		}
+	}
+	if d := e.Evaluate(Input{Tool: "write_file", Args: map[string]any{"path": "notes.txt"}}); d.Effect != Deny || d.Rule != "broken" {
+		t.Fatalf("a rule that fails must deny the call, got %+v", d)
+	}
+	if d := e.Evaluate(Input{Tool: "write_file", Args: map[string]any{"path": "/etc/x"}}); d.Reason() != "rule no-etc: system files are off limits" {
+		t.Fatalf("unexpected reason %q", d.Reason())
+	}
+}
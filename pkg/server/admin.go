@@ -0,0 +1,266 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// SessionsToolName is the name of the admin tool that lists the connected client sessions.
+	SessionsToolName = "moling_sessions"
+	// RecentCallsToolName is the name of the admin tool that lists the recent tool calls.
+	RecentCallsToolName = "moling_recent_calls"
+	// ConfigToolName is the name of the admin tool that shows the running configuration.
+	ConfigToolName = "moling_config"
+	// recentCallsSize is the number of tool calls kept for moling_recent_calls.
+	recentCallsSize = 200
+)
+
+// RecentCall is a finished tool call.
+type RecentCall struct {
+	Tool      string                `json:"tool"`
+	Service   comm.MoLingServerType `json:"service,omitempty"`
+	Session   string                `json:"session,omitempty"`
+	Principal string                `json:"principal,omitempty"`
+	Started   time.Time             `json:"started"`
+	Duration  string                `json:"duration"`
+	IsError   bool                  `json:"is_error"`
+}
+
+// SessionInfo describes a connected client session.
+type SessionInfo struct {
+	ID           string   `json:"id"`
+	Client       string   `json:"client,omitempty"`       // Client is the name and version the client sent in initialize.
+	Capabilities []string `json:"capabilities,omitempty"` // Capabilities are those the client declared, such as elicitation.
+	LogLevel     string   `json:"log_level,omitempty"`    // LogLevel is the level of the log messages the client gets.
+	Principal    string   `json:"principal,omitempty"`    // Principal is the client as of its last tool call.
+	Calls        int      `json:"calls"`                  // Calls is the number of tool calls of the session.
+	LastCall     string   `json:"last_call,omitempty"`
+	Instances    []string `json:"instances,omitempty"` // Instances are the isolated services running for the session.
+}
+
+// sessionCalls counts the tool calls of a session.
+type sessionCalls struct {
+	calls     int
+	last      time.Time
+	principal string
+}
+
+// callHistory keeps the most recent tool calls and counts the calls of every session. The zero value is ready to use.
+type callHistory struct {
+	lock     sync.Mutex
+	calls    []RecentCall
+	sessions map[string]*sessionCalls
+}
+
+func (h *callHistory) add(call RecentCall) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.calls = append(h.calls, call)
+	if len(h.calls) > recentCallsSize {
+		h.calls = append(h.calls[:0], h.calls[len(h.calls)-recentCallsSize:]...)
+	}
+	if call.Session == "" {
+		return
+	}
+	if h.sessions == nil {
+		h.sessions = make(map[string]*sessionCalls)
+	}
+	s, ok := h.sessions[call.Session]
+	if !ok {
+		s = &sessionCalls{}
+		h.sessions[call.Session] = s
+	}
+	s.calls++
+	s.last = call.Started
+	s.principal = call.Principal
+}
+
+// recent returns up to limit of the most recent calls, the newest first, of the tool or service if given.
+func (h *callHistory) recent(limit int, filter string) []RecentCall {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	calls := make([]RecentCall, 0, min(limit, len(h.calls)))
+	for i := len(h.calls) - 1; i >= 0 && len(calls) < limit; i-- {
+		c := h.calls[i]
+		if filter == "" || c.Tool == filter || strings.EqualFold(string(c.Service), filter) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (h *callHistory) session(id string) (sessionCalls, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	s, ok := h.sessions[id]
+	if !ok {
+		return sessionCalls{}, false
+	}
+	return *s, true
+}
+
+// forget drops the counts of a session that ended.
+func (h *callHistory) forget(id string) {
+	h.lock.Lock()
+	delete(h.sessions, id)
+	h.lock.Unlock()
+}
+
+// Sessions describes the connected client sessions.
+func (m *MoLingServer) Sessions() []SessionInfo {
+	ids, levels := m.clientLog.list()
+	infos := make([]SessionInfo, 0, len(ids))
+	for i, id := range ids {
+		info := SessionInfo{ID: id, LogLevel: string(levels[i])}
+		info.Client, info.Capabilities = m.clients.describe(id)
+		if s, ok := m.calls.session(id); ok {
+			info.Calls, info.Principal = s.calls, s.principal
+			info.LastCall = s.last.Format(time.RFC3339)
+		}
+		if m.sessions != nil {
+			info.Instances = m.sessions.instances(id)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// RedactedConfig returns the configuration of the server and of the loaded services, with the values of secret
+// references, credentials and settings that look like secrets replaced by config.SecretMask.
+func (m *MoLingServer) RedactedConfig() (map[string]any, error) {
+	var server map[string]any
+	if err := unmarshalRedacted(m.mlConfig, &server); err != nil {
+		return nil, err
+	}
+	services := make(map[string]any, len(m.services))
+	for _, srv := range m.services {
+		var cfg any
+		if err := json.Unmarshal([]byte(srv.Config()), &cfg); err != nil {
+			return nil, fmt.Errorf("the configuration of %s is not valid JSON: %w", srv.Name(), err)
+		}
+		services[string(srv.Name())] = redactValue("", cfg)
+	}
+	return map[string]any{"MoLingConfig": server, "services": services}, nil
+}
+
+// unmarshalRedacted converts v to JSON values with the secrets in it redacted.
+func unmarshalRedacted(v any, out *map[string]any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*out, _ = redactValue("", value).(map[string]any)
+	return nil
+}
+
+// redactValue masks the resolved secret references in v and the strings under keys that look like secrets.
+func redactValue(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, value := range v {
+			v[k] = redactValue(k, value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(key, value)
+		}
+		return v
+	case string:
+		if v != "" && secretKey(key) {
+			return config.SecretMask
+		}
+		return config.MaskSecrets(v)
+	default:
+		return v
+	}
+}
+
+// secretKey reports whether a configuration key names a credential, such as key, api_key, token or client_secret.
+func secretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"token", "secret", "password", "passwd", "credential"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return key == "key" || strings.HasSuffix(key, "_key") || strings.HasSuffix(key, "apikey")
+}
+
+// addAdminTools registers the admin tools that show the sessions, the recent tool calls and the configuration.
+// Like moling_status, they belong to the MoLing service, which roles can deny.
+func (m *MoLingServer) addAdminTools() {
+	for _, name := range []string{SessionsToolName, RecentCallsToolName, ConfigToolName} {
+		m.toolServices[name] = AdminServiceName
+	}
+	m.server.AddTool(mcp.NewTool(
+		SessionsToolName,
+		mcp.WithDescription("List the client sessions connected to the MoLing server: the client, its log level, the number of tool calls, the last one and the per-session service instances."),
+	), m.handleSessions)
+	m.server.AddTool(mcp.NewTool(
+		RecentCallsToolName,
+		mcp.WithDescription("List the most recent tool calls to the MoLing server, the newest first, with their session, duration and whether they failed."),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of calls to list, 20 by default"), mcp.Min(1), mcp.Max(recentCallsSize)),
+		mcp.WithString("filter", mcp.Description("Only list the calls of this tool or service")),
+	), m.handleRecentCalls)
+	m.server.AddTool(mcp.NewTool(
+		ConfigToolName,
+		mcp.WithDescription("Show the configuration the MoLing server runs with, of the server and of every loaded service. Credentials and secrets are redacted."),
+	), m.handleConfig)
+}
+
+func (m *MoLingServer) handleSessions(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessions := m.Sessions()
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return abstract.NewJSONResult(map[string]any{"sessions": sessions}), nil
+}
+
+func (m *MoLingServer) handleRecentCalls(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	limit := abstract.GetInt(args, "limit", 20)
+	if limit < 1 || limit > recentCallsSize {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", recentCallsSize)), nil
+	}
+	filter := abstract.GetString(args, "filter", "")
+	return abstract.NewJSONResult(map[string]any{"calls": m.calls.recent(limit, filter)}), nil
+}
+
+func (m *MoLingServer) handleConfig(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cfg, err := m.RedactedConfig()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read the configuration: %s", err.Error())), nil
+	}
+	return abstract.NewJSONResult(cfg), nil
+}
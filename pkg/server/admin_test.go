@@ -0,0 +1,127 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// configService is a service with a configuration.
+type configService struct {
+	abstract.Service
+	name   comm.MoLingServerType
+	config string
+}
+
+func (cs *configService) Name() comm.MoLingServerType { return cs.name }
+func (cs *configService) Config() string              { return cs.config }
+
+func TestCallHistory(t *testing.T) {
+	var h callHistory
+	started := time.Now()
+	for i := 0; i < recentCallsSize+10; i++ {
+		service := comm.MoLingServerType("FileSystem")
+		if i%2 == 1 {
+			service = "Browser"
+		}
+		h.add(RecentCall{Tool: fmt.Sprintf("tool_%d", i), Service: service, Session: "s1", Started: started})
+	}
+	calls := h.recent(recentCallsSize*2, "")
+	if len(calls) != recentCallsSize || calls[0].Tool != fmt.Sprintf("tool_%d", recentCallsSize+9) {
+		t.Fatalf("expected the %d newest calls, newest first, got %d starting with %s", recentCallsSize, len(calls), calls[0].Tool)
+	}
+	calls = h.recent(3, "browser")
+	if len(calls) != 3 || calls[0].Service != "Browser" || calls[2].Tool != fmt.Sprintf("tool_%d", recentCallsSize+5) {
+		t.Fatalf("unexpected calls of the Browser service: %+v", calls)
+	}
+	if s, ok := h.session("s1"); !ok || s.calls != recentCallsSize+10 {
+		t.Fatalf("all the calls of the session must be counted, got %+v", s)
+	}
+	h.forget("s1")
+	if _, ok := h.session("s1"); ok {
+		t.Fatal("the session must be forgotten")
+	}
+}
+
+func TestRedactedConfig(t *testing.T) {
+	cfg := config.MoLingConfig{
+		BasePath: "/tmp/moling",
+		Auth: config.AuthConfig{
+			Enabled: true,
+			APIKeys: []config.AuthKey{{Name: "ops", Key: "0123456789abcdef", Role: "admin"}},
+			OAuth2:  config.OAuth2Config{ClientID: "moling", ClientSecret: "hunter22"},
+		},
+	}
+	m := &MoLingServer{mlConfig: cfg, services: []abstract.Service{
+		&configService{name: "Forge", config: `{"token":"ghp_abcdef","api_url":"https://api.github.com","max_results":20}`},
+	}}
+	redacted, err := m.RedactedConfig()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	data, _ := json.Marshal(redacted)
+	for _, secret := range []string{"0123456789abcdef", "hunter22", "ghp_abcdef"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("%s must be redacted: %s", secret, data)
+		}
+	}
+	for _, kept := range []string{`"client_id":"moling"`, `"api_url":"https://api.github.com"`, `"max_results":20`, `"base_path":"/tmp/moling"`} {
+		if !strings.Contains(string(data), kept) {
+			t.Fatalf("%s must be kept: %s", kept, data)
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	cfg := config.MoLingConfig{
+		ServerName: "MoLing",
+		Version:    "linux_amd64_v0.2.0_2025-05-01 10:00",
+		ListenAddr: "127.0.0.1:6789",
+		Responses:  config.NewResponseConfig(),
+		Limits:     config.NewLimitConfig(),
+		Jobs:       config.NewJobsConfig(),
+	}
+	m := &MoLingServer{mlConfig: cfg, listenAddr: cfg.ListenAddr, started: time.Now(), services: []abstract.Service{
+		&configService{name: "FileSystem", config: `{"allowed_dir":"/tmp","max_read_size":1024,"versions":0}`},
+		&configService{name: "Command", config: `{"timeout":10,"allowed_command":["ls"]}`},
+	}}
+	m.failed = map[comm.MoLingServerType]error{"Browser": fmt.Errorf("chrome not found")}
+	info := m.Info()
+	if info.Release != "v0.2.0" || info.Transport != "sse" || info.Limits.MaxOutputSize != 1<<20 || info.Limits.MaxInFlight != 16 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if strings.Join(info.Services, ",") != "Command,FileSystem" || len(info.Failed) != 1 || info.Failed[0] != "Browser" {
+		t.Fatalf("the loaded and failed services must be listed, got %v and %v", info.Services, info.Failed)
+	}
+	if len(info.Service["FileSystem"]) != 1 || info.Service["FileSystem"]["max_read_size"] != float64(1024) || info.Service["Command"]["timeout"] != float64(10) {
+		t.Fatalf("only the limits of the services must be listed, got %v", info.Service)
+	}
+	if _, err := json.Marshal(info); err != nil {
+		t.Fatal(err)
+	}
+	if commit := buildCommit("linux_amd64_2a3b4c5_2025-05-01 10:00:00"); commit != "2a3b4c5" && !isHex(commit) {
+		t.Fatalf("unexpected commit %q", commit)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/audit"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// AuditDir is the directory of the audit trail in the base path.
+	AuditDir = "data/audit"
+	// AuditSearchToolName is the admin tool that searches the audit trail.
+	AuditSearchToolName = "audit_search"
+	// maxAuditError is the length at which the errors of failed calls are cut in the audit trail.
+	maxAuditError = 1024
+	// maxAuditResults is the maximum number of entries audit_search returns.
+	maxAuditResults = 1000
+)
+
+// openAudit opens the audit trail if it is enabled. Without it, tool calls are not recorded.
+func (m *MoLingServer) openAudit() {
+	if !m.mlConfig.Audit.Enabled {
+		return
+	}
+	s, err := audit.Open(filepath.Join(m.mlConfig.BasePath, AuditDir), m.mlConfig.Audit.RetentionDays, m.logger.With().Str("component", "audit").Logger())
+	if err != nil {
+		m.logger.Error().Err(err).Msg("failed to open the audit trail, tool calls are not recorded")
+		return
+	}
+	m.audit = s
+}
+
+// auditTool records every tool call, with its redacted arguments, outcome and duration, to the audit trail.
+func (m *MoLingServer) auditTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.audit == nil {
+			return next(ctx, request)
+		}
+		started := time.Now()
+		entry := audit.Entry{
+			Time:    started,
+			Service: string(m.toolServices[request.Params.Name]),
+			Tool:    request.Params.Name,
+			Session: sessionID(ctx),
+			Args:    auditArgs(request.GetArguments(), m.mlConfig.Audit.MaxArgLength),
+			Status:  audit.StatusOK,
+		}
+		if p, ok := m.principal(ctx); ok {
+			entry.Principal = p.Name
+		}
+		result, err := next(ctx, request)
+		entry.Duration = time.Since(started).Milliseconds()
+		switch {
+		case err != nil:
+			entry.Status, entry.Error = audit.StatusError, err.Error()
+		case result != nil && result.IsError:
+			entry.Status, entry.Error, entry.ErrorCode = audit.StatusError, resultText(result), string(abstract.ErrorCodeOf(result))
+		}
+		entry.Error = cut(entry.Error, maxAuditError)
+		if rerr := m.audit.Record(entry); rerr != nil {
+			m.logger.Error().Err(rerr).Str("tool", entry.Tool).Msg("failed to record the tool call in the audit trail")
+		}
+		return result, err
+	}
+}
+
+// auditArgs returns a copy of the arguments of a tool call with the credentials redacted and the strings longer
+// than maxLength cut.
+func auditArgs(args map[string]any, maxLength int) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	var copied map[string]any
+	if err := unmarshalRedacted(args, &copied); err != nil {
+		return map[string]any{"error": "the arguments are not JSON"}
+	}
+	if maxLength > 0 {
+		cutStrings(copied, maxLength)
+	}
+	return copied
+}
+
+// cutStrings cuts the strings in v that are longer than maxLength.
+func cutStrings(v any, maxLength int) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, value := range v {
+			if s, ok := value.(string); ok {
+				v[k] = cut(s, maxLength)
+			} else {
+				cutStrings(value, maxLength)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			if s, ok := value.(string); ok {
+				v[i] = cut(s, maxLength)
+			} else {
+				cutStrings(value, maxLength)
+			}
+		}
+	}
+}
+
+// cut returns s cut to maxLength bytes, with the length of s noted.
+func cut(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	return fmt.Sprintf("%s... (%d bytes)", s[:maxLength], len(s))
+}
+
+// addAuditTool registers audit_search if the audit trail is enabled. Like the other admin tools, it belongs to the
+// MoLing service, which roles can deny.
+func (m *MoLingServer) addAuditTool() {
+	if m.audit == nil {
+		return
+	}
+	m.toolServices[AuditSearchToolName] = AdminServiceName
+	m.server.AddTool(mcp.NewTool(
+		AuditSearchToolName,
+		mcp.WithDescription("Search the audit trail of the tool calls, the newest first: the service, tool, session, principal, redacted arguments, status, error and duration of each call."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("service", mcp.Description("Only calls of this service, e.g. FileSystem")),
+		mcp.WithString("tool", mcp.Description("Only calls of this tool, or of the tools matching a pattern such as browser_*")),
+		mcp.WithString("session", mcp.Description("Only calls of this client session")),
+		mcp.WithString("principal", mcp.Description("Only calls of this authenticated client")),
+		mcp.WithString("status", mcp.Description("Only calls with this outcome"), mcp.Enum(string(audit.StatusOK), string(audit.StatusError))),
+		mcp.WithString("text", mcp.Description("Only calls whose arguments or error contain this text")),
+		mcp.WithString("since", mcp.Description("Only calls since this time: RFC 3339, a day such as 2026-10-16, or a duration such as 24h")),
+		mcp.WithString("until", mcp.Description("Only calls until this time, in the same formats as since")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of calls, default 50, at most %d", maxAuditResults))),
+	), m.handleAuditSearch)
+}
+
+func (m *MoLingServer) handleAuditSearch(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	q := audit.Query{
+		Service:   request.GetString("service", ""),
+		Tool:      request.GetString("tool", ""),
+		Session:   request.GetString("session", ""),
+		Principal: request.GetString("principal", ""),
+		Status:    audit.Status(request.GetString("status", "")),
+		Text:      request.GetString("text", ""),
+		Limit:     request.GetInt("limit", 50),
+	}
+	if q.Limit < 1 || q.Limit > maxAuditResults {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxAuditResults)), nil
+	}
+	now := time.Now()
+	var err error
+	if since := request.GetString("since", ""); since != "" {
+		if q.Since, err = audit.ParseTime(since, now); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if until := request.GetString("until", ""); until != "" {
+		if q.Until, err = audit.ParseTime(until, now); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	entries, err := m.audit.Search(q)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to search the audit trail: %s", err.Error())), nil
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	return abstract.NewJSONResult(map[string]any{"calls": entries}), nil
+}
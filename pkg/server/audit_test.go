@@ -0,0 +1,88 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/audit"
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+)
+
+func TestAuditTool(t *testing.T) {
+	cfg := config.NewAuditConfig()
+	cfg.Enabled, cfg.MaxArgLength = true, 8
+	ms := &MoLingServer{logger: zerolog.Nop(), mlConfig: config.MoLingConfig{BasePath: t.TempDir(), Audit: cfg},
+		toolServices: map[string]comm.MoLingServerType{"write_file": "FileSystem"}}
+	ms.openAudit()
+	if ms.audit == nil {
+		t.Fatal("the audit trail must be open")
+	}
+	t.Cleanup(func() { _ = ms.audit.Close() })
+
+	handler := ms.auditTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.GetArguments()["path"] == "/etc/hosts" {
+			return mcp.NewToolResultError("access denied"), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	call := func(args map[string]any) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "write_file"
+		req.Params.Arguments = args
+		if _, err := handler(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	args := map[string]any{"path": "notes.txt", "content": "a long content", "api_key": "s3cr3t"}
+	call(args)
+	call(map[string]any{"path": "/etc/hosts"})
+	if args["api_key"] != "s3cr3t" {
+		t.Fatal("the arguments of the call must not be changed")
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"status": "ok"}
+	res, _ := ms.handleAuditSearch(context.Background(), req)
+	var out struct {
+		Calls []audit.Entry `json:"calls"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &out); err != nil || len(out.Calls) != 1 {
+		t.Fatalf("expected one successful call, got %+v, %v", res, err)
+	}
+	e := out.Calls[0]
+	if e.Service != "FileSystem" || e.Args["api_key"] != config.SecretMask || !strings.HasPrefix(e.Args["content"].(string), "a long c...") {
+		t.Fatalf("the arguments must be redacted and cut, got %+v", e)
+	}
+
+	req.Params.Arguments = map[string]any{"status": "error", "since": "1h"}
+	res, _ = ms.handleAuditSearch(context.Background(), req)
+	if !strings.Contains(res.Content[0].(mcp.TextContent).Text, "access denied") {
+		t.Fatalf("the failed call must be recorded with its error, got %+v", res)
+	}
+	req.Params.Arguments = map[string]any{"since": "yesterday"}
+	if res, _ = ms.handleAuditSearch(context.Background(), req); !res.IsError {
+		t.Fatal("an invalid time must be rejected")
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+)
+
+// maxCachedTokens is the number of validated OAuth2 tokens kept in memory.
+const maxCachedTokens = 1000
+
+type principalKey struct{}
+
+// Principal is an authenticated client and the scopes it was granted.
+type Principal struct {
+	Name    string
+	Scopes  []config.AuthScope // Scopes are alternatives, a tool is allowed if any of them allows it.
+	Roles   []string           // Roles are the names of the roles the client was granted, for the policy rules.
+	Profile string             // Profile is the profile of the configuration the client uses, empty for the configuration of the server.
+}
+
+// PrincipalFromContext returns the authenticated client of a request. It reports false for STDIO clients
+// and when authentication is disabled, whose permissions are those of the default role if one is configured.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Allowed reports whether the principal may use a tool of a service.
+func (p *Principal) Allowed(service comm.MoLingServerType, tool string) bool {
+	for _, s := range p.Scopes {
+		if scopeAllows(s, service, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeAllows(s config.AuthScope, service comm.MoLingServerType, tool string) bool {
+	if len(s.Services) > 0 {
+		found := false
+		for _, name := range s.Services {
+			if strings.EqualFold(name, string(service)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if matchTool(s.Deny, tool) {
+		return false
+	}
+	return len(s.Tools) == 0 || matchTool(s.Tools, tool)
+}
+
+// matchTool reports whether tool matches one of the patterns, which may end with *.
+func matchTool(patterns []string, tool string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(tool, prefix) {
+				return true
+			}
+		} else if pattern == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// grantScopes returns the scopes of a credential: those of its role if it has one, or its own scope.
+func grantScopes(cfg *config.AuthConfig, role string, scope config.AuthScope) []config.AuthScope {
+	if role == "" {
+		return []config.AuthScope{scope}
+	}
+	if r, ok := cfg.Role(role); ok {
+		return r.Scopes()
+	}
+	return nil
+}
+
+// rolePrincipal returns the principal of clients that do not authenticate, or nil if no default role is configured.
+func rolePrincipal(cfg *config.AuthConfig) *Principal {
+	if cfg.DefaultRole == "" {
+		return nil
+	}
+	return &Principal{Name: "default role " + cfg.DefaultRole, Scopes: grantScopes(cfg, cfg.DefaultRole, config.AuthScope{}), Roles: []string{cfg.DefaultRole}}
+}
+
+// Authenticator checks the credentials of HTTP requests against the auth configuration.
+type Authenticator struct {
+	config *config.AuthConfig
+	logger zerolog.Logger
+	client *http.Client
+
+	lock   sync.Mutex
+	tokens map[[sha256.Size]byte]cachedToken // tokens are validated OAuth2 tokens by hash.
+}
+
+type cachedToken struct {
+	principal *Principal
+	expires   time.Time
+}
+
+// NewAuthenticator creates an Authenticator for a checked auth configuration.
+func NewAuthenticator(cfg *config.AuthConfig, logger zerolog.Logger) *Authenticator {
+	return &Authenticator{
+		config: cfg,
+		logger: logger,
+		client: &http.Client{Timeout: 10 * time.Second},
+		tokens: make(map[[sha256.Size]byte]cachedToken),
+	}
+}
+
+// Middleware rejects requests without valid credentials and passes the principal on in the request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, status, err := a.authenticate(r)
+		if err != nil {
+			a.logger.Warn().Str("remote", r.RemoteAddr).Str("path", r.URL.Path).Err(err).Msg("request rejected")
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="MoLing"`)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		a.logger.Debug().Str("remote", r.RemoteAddr).Str("path", r.URL.Path).Str("principal", p.Name).Msg("request authenticated")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// authenticate returns the principal of a request, or the HTTP status and the reason to reject it.
+func (a *Authenticator) authenticate(r *http.Request) (*Principal, int, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		if p := a.matchKey(a.config.APIKeys, key); p != nil {
+			return p, 0, nil
+		}
+		return nil, http.StatusUnauthorized, errors.New("invalid API key")
+	}
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, http.StatusUnauthorized, errors.New("missing credentials, send an X-API-Key header or an Authorization: Bearer header")
+	}
+	if p := a.matchKey(a.config.BearerTokens, token); p != nil {
+		return p, 0, nil
+	}
+	if a.config.OAuth2.IntrospectionURL == "" {
+		return nil, http.StatusUnauthorized, errors.New("invalid bearer token")
+	}
+	return a.introspect(r.Context(), token)
+}
+
+// matchKey returns the principal of the key that equals key, comparing in constant time.
+func (a *Authenticator) matchKey(keys []config.AuthKey, key string) *Principal {
+	var found *Principal
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			found = &Principal{Name: k.Name, Scopes: grantScopes(a.config, k.Role, k.AuthScope), Profile: k.Profile}
+			if k.Role != "" {
+				found.Roles = []string{k.Role}
+			}
+		}
+	}
+	return found
+}
+
+// introspect validates an OAuth2 token with the introspection endpoint and caches the result.
+func (a *Authenticator) introspect(ctx context.Context, token string) (*Principal, int, error) {
+	hash := sha256.Sum256([]byte(token))
+	now := time.Now()
+	a.lock.Lock()
+	cached, ok := a.tokens[hash]
+	a.lock.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.principal, 0, nil
+	}
+
+	cfg := a.config.OAuth2
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.IntrospectionURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, http.StatusBadGateway, fmt.Errorf("token introspection at %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, http.StatusBadGateway, fmt.Errorf("token introspection at %s returned %s", req.URL.Host, resp.Status)
+	}
+	var info struct {
+		Active   bool   `json:"active"`
+		Scope    string `json:"scope"`
+		Aud      any    `json:"aud"`
+		Exp      int64  `json:"exp"`
+		Sub      string `json:"sub"`
+		Username string `json:"username"`
+		ClientID string `json:"client_id"`
+	}
+	if err = json.Unmarshal(data, &info); err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("invalid token introspection response: %w", err)
+	}
+	if !info.Active || (info.Exp > 0 && now.Unix() >= info.Exp) {
+		return nil, http.StatusUnauthorized, errors.New("the bearer token is not active")
+	}
+	if cfg.Audience != "" && !hasAudience(info.Aud, cfg.Audience) {
+		return nil, http.StatusUnauthorized, errors.New("the bearer token is not meant for this server")
+	}
+
+	p := &Principal{Name: firstNonEmpty(info.Username, info.Sub, info.ClientID, "oauth2")}
+	if len(cfg.Scopes) == 0 {
+		p.Scopes = []config.AuthScope{{}}
+	}
+	granted := strings.Fields(info.Scope)
+	for _, s := range cfg.Scopes {
+		for _, g := range granted {
+			if g == s.Scope {
+				p.Scopes = append(p.Scopes, grantScopes(a.config, s.Role, s.AuthScope)...)
+				if s.Role != "" {
+					p.Roles = append(p.Roles, s.Role)
+				}
+				break
+			}
+		}
+	}
+	if len(p.Scopes) == 0 {
+		return nil, http.StatusForbidden, errors.New("the bearer token has none of the configured scopes")
+	}
+
+	expires := now.Add(time.Duration(cfg.CacheTTL) * time.Second)
+	if info.Exp > 0 && time.Unix(info.Exp, 0).Before(expires) {
+		expires = time.Unix(info.Exp, 0)
+	}
+	a.lock.Lock()
+	if len(a.tokens) >= maxCachedTokens {
+		for h, t := range a.tokens {
+			if now.After(t.expires) || len(a.tokens) >= maxCachedTokens {
+				delete(a.tokens, h)
+			}
+		}
+	}
+	a.tokens[hash] = cachedToken{principal: p, expires: expires}
+	a.lock.Unlock()
+	return p, 0, nil
+}
+
+// hasAudience reports whether the aud claim, a string or an array of strings, contains audience.
+func hasAudience(aud any, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []any:
+		for _, item := range v {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/watchdog"
+)
+
+// defaultWarnPercent is the share of a budget at which a warning is sent if the budget does not set one.
+const defaultWarnPercent = 80
+
+// BudgetStatus is the last measured usage of a service with a budget.
+type BudgetStatus struct {
+	Service  string         `json:"service"`
+	Usage    watchdog.Usage `json:"usage"`
+	Refused  string         `json:"refused,omitempty"` // Refused is why the tool calls of the service are refused, empty if they run.
+	Restarts int            `json:"restarts,omitempty"`
+	Checked  time.Time      `json:"checked"`
+}
+
+// budgets keeps the state of the services with a budget.
+type budgets struct {
+	meter  *watchdog.Meter
+	lock   sync.Mutex
+	states map[comm.MoLingServerType]*budgetState
+}
+
+type budgetState struct {
+	status BudgetStatus
+	warned bool // warned is set once a warning was sent, until the usage is below the warning level again.
+}
+
+// openBudgets prepares the watchdog if a service has a budget.
+func (m *MoLingServer) openBudgets() {
+	if !m.mlConfig.Budgets.Enabled() {
+		return
+	}
+	m.budgets = &budgets{meter: watchdog.NewMeter(), states: make(map[comm.MoLingServerType]*budgetState)}
+	if runtime.GOOS == "linux" {
+		return
+	}
+	for name, b := range m.mlConfig.Budgets.Services {
+		if b.MemoryMB > 0 || b.CPUPercent > 0 {
+			m.logger.Warn().Str("serviceName", name).Msgf("memory and CPU budgets are not enforced on %s, only disk budgets are", runtime.GOOS)
+		}
+	}
+}
+
+// watchBudgets checks the budgets of the services at the configured interval, until the server context is done.
+func (m *MoLingServer) watchBudgets() {
+	if m.budgets == nil {
+		return
+	}
+	m.checkBudgets()
+	ticker := time.NewTicker(m.mlConfig.Budgets.IntervalDuration())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.watchCtx.Done():
+			return
+		case <-ticker.C:
+			m.checkBudgets()
+		}
+	}
+}
+
+// checkBudgets measures every service with a budget and enforces it. Over the memory or CPU budget, the child
+// processes of the service are restarted, or its tool calls refused if its action is refuse. Over the disk budget,
+// its tool calls are refused. Calls run again once a later check finds the service within its budget.
+func (m *MoLingServer) checkBudgets() {
+	names := make([]string, 0, len(m.mlConfig.Budgets.Services))
+	for name := range m.mlConfig.Budgets.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.checkBudget(comm.MoLingServerType(name), m.mlConfig.Budgets.Services[name])
+	}
+}
+
+func (m *MoLingServer) checkBudget(name comm.MoLingServerType, b config.Budget) {
+	instances := m.instancesOf(name)
+	var pids []int
+	for _, srv := range instances {
+		if po, ok := srv.(abstract.ProcessOwner); ok {
+			pids = append(pids, po.Processes()...)
+		}
+	}
+	usage, err := m.budgets.meter.Processes(string(name), pids)
+	if err != nil && !errors.Is(err, watchdog.ErrUnsupported) {
+		m.logger.Warn().Err(err).Str("serviceName", string(name)).Msg("failed to measure the processes of the service")
+	}
+	if b.DiskMB > 0 {
+		usage.DiskBytes, err = watchdog.DirSize(m.budgetDir(name, b))
+		if err != nil {
+			m.logger.Warn().Err(err).Str("serviceName", string(name)).Msg("failed to measure the data directory of the service")
+		}
+	}
+
+	warnPercent := b.WarnPercent
+	if warnPercent == 0 {
+		warnPercent = defaultWarnPercent
+	}
+	var overProcesses, overDisk, near []string
+	check := func(over *[]string, what string, used, limit float64, unit string) {
+		switch {
+		case limit <= 0:
+		case used > limit:
+			*over = append(*over, fmt.Sprintf("%s %.0f%s of %.0f%s", what, used, unit, limit, unit))
+		case used >= limit*float64(warnPercent)/100:
+			near = append(near, fmt.Sprintf("%s %.0f%s of %.0f%s", what, used, unit, limit, unit))
+		}
+	}
+	check(&overProcesses, "memory", float64(usage.MemoryBytes)/(1<<20), float64(b.MemoryMB), " MB")
+	check(&overProcesses, "CPU", usage.CPUPercent, b.CPUPercent, "%")
+	check(&overDisk, "disk", float64(usage.DiskBytes)/(1<<20), float64(b.DiskMB), " MB")
+
+	m.budgets.lock.Lock()
+	state, ok := m.budgets.states[name]
+	if !ok {
+		state = &budgetState{status: BudgetStatus{Service: string(name)}}
+		m.budgets.states[name] = state
+	}
+	state.status.Usage = usage
+	state.status.Checked = time.Now()
+	wasRefused := state.status.Refused
+	var refused []string
+	if b.Action == config.BudgetRefuse {
+		refused = append(refused, overProcesses...)
+	}
+	refused = append(refused, overDisk...)
+	state.status.Refused = strings.Join(refused, ", ")
+	restart := len(overProcesses) > 0 && b.Action != config.BudgetRefuse
+	if restart {
+		state.status.Restarts++
+	}
+	warn := len(near) > 0 && !state.warned
+	state.warned = len(near) > 0 || len(overProcesses) > 0 || len(overDisk) > 0
+	nowRefused := state.status.Refused
+	m.budgets.lock.Unlock()
+
+	if restart {
+		reason := strings.Join(overProcesses, ", ")
+		m.logger.Error().Str("serviceName", string(name)).Str("usage", reason).Msg("the service is over its budget, restarting its processes")
+		m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "the service is over its budget, its processes were restarted", "service": name, "usage": reason})
+		for _, srv := range instances {
+			if r, ok := srv.(abstract.Restarter); ok {
+				if err := r.Restart(); err != nil {
+					m.logger.Error().Err(err).Str("serviceName", string(name)).Msg("failed to restart the processes of the service")
+				}
+			}
+		}
+	}
+	switch {
+	case nowRefused != "" && nowRefused != wasRefused:
+		m.logger.Error().Str("serviceName", string(name)).Str("usage", nowRefused).Msg("the service is over its budget, its tool calls are refused")
+		m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "the service is over its budget, its tool calls are refused", "service": name, "usage": nowRefused})
+	case nowRefused == "" && wasRefused != "":
+		m.logger.Info().Str("serviceName", string(name)).Msg("the service is within its budget again")
+		m.logToClients(mcp.LoggingLevelNotice, serverLogName, map[string]any{"message": "the service is within its budget again", "service": name})
+	}
+	if warn {
+		reason := strings.Join(near, ", ")
+		m.logger.Warn().Str("serviceName", string(name)).Str("usage", reason).Msg("the service is close to its budget")
+		m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "the service is close to its budget", "service": name, "usage": reason})
+	}
+}
+
+// budgetDir returns the data directory measured for the disk budget of a service.
+func (m *MoLingServer) budgetDir(name comm.MoLingServerType, b config.Budget) string {
+	dir := b.DataDir
+	if dir == "" {
+		dir = filepath.Join("data", strings.ToLower(string(name)))
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(m.mlConfig.BasePath, dir)
+	}
+	return dir
+}
+
+// instancesOf returns the shared instance of a service and its instances of client sessions.
+func (m *MoLingServer) instancesOf(name comm.MoLingServerType) []abstract.Service {
+	var instances []abstract.Service
+	for _, srv := range m.services {
+		if srv.Name() == name {
+			instances = append(instances, srv)
+		}
+	}
+	if m.sessions != nil {
+		instances = append(instances, m.sessions.servicesOf(name)...)
+	}
+	return instances
+}
+
+// budgetStatus returns the last measured usage of the services with a budget, nil without budgets.
+func (m *MoLingServer) budgetStatus() []BudgetStatus {
+	if m.budgets == nil {
+		return nil
+	}
+	m.budgets.lock.Lock()
+	defer m.budgets.lock.Unlock()
+	status := make([]BudgetStatus, 0, len(m.budgets.states))
+	for _, state := range m.budgets.states {
+		status = append(status, state.status)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Service < status[j].Service })
+	return status
+}
+
+// refusedBy returns why the tool calls of a service are refused, empty if they run.
+func (b *budgets) refusedBy(name comm.MoLingServerType) string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if state, ok := b.states[name]; ok {
+		return state.status.Refused
+	}
+	return ""
+}
+
+// budgetTool refuses the tool calls of services that are over their budget.
+func (m *MoLingServer) budgetTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.budgets == nil {
+			return next(ctx, request)
+		}
+		if reason := m.budgets.refusedBy(m.toolServices[request.Params.Name]); reason != "" {
+			return abstract.NewErrorResult(abstract.CodeUnavailable, m.locale.Sprintf("server.over_budget", m.toolServices[request.Params.Name], reason)), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// processService is a service whose child process is the test itself.
+type processService struct {
+	abstract.Service
+	name     comm.MoLingServerType
+	restarts int
+}
+
+func (ps *processService) Name() comm.MoLingServerType { return ps.name }
+func (ps *processService) Processes() []int            { return []int{os.Getpid()} }
+func (ps *processService) Restart() error {
+	ps.restarts++
+	return nil
+}
+
+func TestBudgets(t *testing.T) {
+	dir := t.TempDir()
+	browser := &processService{name: "Browser"}
+	media := &processService{name: "Media"}
+	ms := &MoLingServer{
+		logger: zerolog.Nop(),
+		mlConfig: config.MoLingConfig{BasePath: dir, Budgets: config.BudgetsConfig{Services: map[string]config.Budget{
+			"Browser": {MemoryMB: 1},
+			"Media":   {MemoryMB: 1, Action: config.BudgetRefuse},
+			"Storage": {DiskMB: 1},
+		}}},
+		services:     []abstract.Service{browser, media, &processService{name: "Storage"}},
+		toolServices: map[string]comm.MoLingServerType{"browser_navigate": "Browser", "media_download": "Media", "storage_put": "Storage"},
+	}
+	ms.openBudgets()
+	call := func(tool string) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		res, _ := ms.budgetTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("done"), nil
+		})(context.Background(), req)
+		return res
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "data", "storage"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	big := filepath.Join(dir, "data", "storage", "big")
+	if err := os.WriteFile(big, make([]byte, 2<<20), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ms.checkBudgets()
+	if res := call("storage_put"); !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "disk 2 MB of 1 MB") {
+		t.Fatalf("the calls of a service over its disk budget must be refused: %+v", res)
+	}
+	if runtime.GOOS == "linux" {
+		if browser.restarts != 1 || call("browser_navigate").IsError {
+			t.Fatalf("a service over its memory budget must be restarted, got %d restarts", browser.restarts)
+		}
+		if media.restarts != 0 || !call("media_download").IsError {
+			t.Fatal("the calls of a service with the refuse action must be refused")
+		}
+	}
+	status := ms.Health(context.Background()).Budgets
+	if len(status) != 3 || status[2].Service != "Storage" || status[2].Usage.DiskBytes != 2<<20 {
+		t.Fatalf("unexpected budget status %+v", status)
+	}
+
+	if err := os.Remove(big); err != nil {
+		t.Fatal(err)
+	}
+	ms.checkBudgets()
+	if res := call("storage_put"); res.IsError {
+		t.Fatalf("the calls must run again once the service is within its budget: %+v", res)
+	}
+}
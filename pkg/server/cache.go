@@ -0,0 +1,211 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// CacheDir is the directory of the cached tool results in the cache directory of the base path.
+	CacheDir = "tools"
+	// CacheMetaKey marks the results served from the cache in their meta, with the time they were cached.
+	CacheMetaKey = "moling/cachedAt"
+)
+
+// cachedResult is a tool result and when it expires, as kept in memory and on disk.
+type cachedResult struct {
+	Tool     string          `json:"tool"`
+	CachedAt time.Time       `json:"cached_at"`
+	Expires  time.Time       `json:"expires"`
+	Result   json.RawMessage `json:"result"`
+}
+
+// toolCache keeps the results of tool calls by tool, arguments and profile, in memory and optionally on disk.
+type toolCache struct {
+	config config.CacheConfig
+	dir    string // dir holds the results on disk, empty if they are not persisted.
+	logger zerolog.Logger
+
+	lock    sync.Mutex
+	entries map[string]cachedResult
+}
+
+// newToolCache creates the cache of the tool results and removes the expired results on disk. It returns nil if
+// the cache is disabled.
+func newToolCache(cfg config.CacheConfig, basePath string, logger zerolog.Logger) *toolCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	c := &toolCache{config: cfg, logger: logger, entries: make(map[string]cachedResult)}
+	if cfg.Persist {
+		c.dir = filepath.Join(basePath, "cache", CacheDir)
+		if err := os.MkdirAll(c.dir, 0o700); err != nil {
+			logger.Warn().Err(err).Str("dir", c.dir).Msg("failed to create the cache directory, tool results are cached in memory only")
+			c.dir = ""
+		} else {
+			go c.prune()
+		}
+	}
+	return c
+}
+
+// cacheKey identifies the result of a call by the tool, its arguments and the profile of the client, whose services
+// may be configured differently.
+func cacheKey(tool string, args any, profile string) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(profile + "\x00" + tool + "\x00" + string(data)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// get returns the cached result of a call, from memory or else from disk.
+func (c *toolCache) get(key string) (*mcp.CallToolResult, bool) {
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	c.lock.Unlock()
+	if !ok && c.dir != "" {
+		data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+		ok = err == nil && json.Unmarshal(data, &entry) == nil
+		if ok && time.Now().Before(entry.Expires) {
+			c.remember(key, entry)
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.Expires) {
+		c.remove(key)
+		return nil, false
+	}
+	result, err := mcp.ParseCallToolResult(&entry.Result)
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+	if result.Meta == nil {
+		result.Meta = make(map[string]any)
+	}
+	result.Meta[CacheMetaKey] = entry.CachedAt.Format(time.RFC3339)
+	return result, true
+}
+
+// put caches the result of a call for ttl.
+func (c *toolCache) put(key, tool string, result *mcp.CallToolResult, ttl time.Duration) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	entry := cachedResult{Tool: tool, CachedAt: now, Expires: now.Add(ttl), Result: data}
+	c.remember(key, entry)
+	if c.dir == "" {
+		return
+	}
+	data, err = json.Marshal(entry)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0o600)
+	}
+	if err != nil {
+		c.logger.Warn().Err(err).Str("tool", tool).Msg("failed to write the tool result to the cache directory")
+	}
+}
+
+// remember keeps an entry in memory, evicting the one that expires first when the cache is full.
+func (c *toolCache) remember(key string, entry cachedResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.config.MaxEntries {
+		var oldest string
+		for k, e := range c.entries {
+			if oldest == "" || e.Expires.Before(c.entries[oldest].Expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = entry
+}
+
+func (c *toolCache) remove(key string) {
+	c.lock.Lock()
+	delete(c.entries, key)
+	c.lock.Unlock()
+	if c.dir != "" {
+		_ = os.Remove(filepath.Join(c.dir, key+".json"))
+	}
+}
+
+// prune removes the expired results on disk.
+func (c *toolCache) prune() {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, file := range files {
+		var entry cachedResult
+		data, err := os.ReadFile(file)
+		if err != nil || json.Unmarshal(data, &entry) != nil || !now.Before(entry.Expires) {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+// cacheTool answers tool calls with a cached result of the same call while it is fresh, and caches the successful
+// results of the tools that have a ttl in the cache configuration. Dry runs are neither cached nor answered from the cache.
+func (m *MoLingServer) cacheTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ttl := m.mlConfig.Cache.TTL(request.Params.Name)
+		if m.cache == nil || ttl <= 0 || abstract.IsDryRun(ctx, request) {
+			return next(ctx, request)
+		}
+		var profile string
+		if p, ok := m.principal(ctx); ok {
+			profile = p.Profile
+		}
+		key, err := cacheKey(request.Params.Name, request.GetArguments(), profile)
+		if err != nil {
+			return next(ctx, request)
+		}
+		if result, ok := m.cache.get(key); ok {
+			m.logger.Debug().Str("tool", request.Params.Name).Msg("tool result served from the cache")
+			return result, nil
+		}
+		result, err := next(ctx, request)
+		if err == nil && result != nil && !result.IsError {
+			m.cache.put(key, request.Params.Name, result, ttl)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+func TestCacheTool(t *testing.T) {
+	cfg := config.NewCacheConfig()
+	cfg.Enabled = true
+	dir := t.TempDir()
+	ms := &MoLingServer{logger: zerolog.Nop(), mlConfig: config.MoLingConfig{BasePath: dir, Cache: cfg}}
+	ms.cache = newToolCache(cfg, dir, ms.logger)
+
+	calls := 0
+	handler := ms.cacheTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		if request.GetArguments()["city"] == "nowhere" {
+			return mcp.NewToolResultError("unknown city"), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("forecast %d", calls)), nil
+	})
+	call := func(tool, city string) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		req.Params.Arguments = map[string]any{"city": city}
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return res
+	}
+
+	first := call("weather_forecast", "Paris")
+	second := call("weather_forecast", "Paris")
+	if calls != 1 || second.Content[0].(mcp.TextContent).Text != first.Content[0].(mcp.TextContent).Text {
+		t.Fatalf("the second call must be served from the cache, the tool ran %d times", calls)
+	}
+	if _, ok := second.Meta[CacheMetaKey]; !ok {
+		t.Fatal("a cached result must be marked in its meta")
+	}
+	call("weather_forecast", "Berlin")
+	call("time_now", "Paris")
+	call("time_now", "Paris")
+	if calls != 4 {
+		t.Fatalf("other arguments and tools without ttl must not be served from the cache, the tool ran %d times", calls)
+	}
+	call("weather_forecast", "nowhere")
+	call("weather_forecast", "nowhere")
+	if calls != 6 {
+		t.Fatalf("errors must not be cached, the tool ran %d times", calls)
+	}
+
+	// A new cache finds the results on disk, until they expire
+	ms.cache = newToolCache(cfg, dir, ms.logger)
+	call("weather_forecast", "Paris")
+	if calls != 6 {
+		t.Fatalf("the result must be read from disk, the tool ran %d times", calls)
+	}
+	key, _ := cacheKey("weather_forecast", map[string]any{"city": "Paris"}, "")
+	ms.cache.put(key, "weather_forecast", mcp.NewToolResultText("stale"), -time.Second)
+	if res := call("weather_forecast", "Paris"); calls != 7 || res.Content[0].(mcp.TextContent).Text != "forecast 7" {
+		t.Fatal("an expired result must not be served")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cfg := config.CacheConfig{Enabled: true, MaxEntries: 1, Tools: map[string]int{"weather_*": 60, "weather_find_place": 3600}}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for tool, want := range map[string]time.Duration{"weather_forecast": time.Minute, "weather_find_place": time.Hour, "time_now": 0} {
+		if ttl := cfg.TTL(tool); ttl != want {
+			t.Fatalf("%s: expected a ttl of %s, got %s", tool, want, ttl)
+		}
+	}
+	cfg.Enabled = false
+	if cfg.TTL("weather_forecast") != 0 {
+		t.Fatal("nothing must be cached while the cache is disabled")
+	}
+
+	c := newToolCache(config.CacheConfig{Enabled: true, MaxEntries: 1}, t.TempDir(), zerolog.Nop())
+	c.put("a", "weather_forecast", mcp.NewToolResultText("a"), time.Minute)
+	c.put("b", "weather_forecast", mcp.NewToolResultText("b"), time.Minute)
+	if len(c.entries) != 1 {
+		t.Fatalf("at most max_entries results must be kept in memory, got %d", len(c.entries))
+	}
+}
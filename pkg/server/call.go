@@ -0,0 +1,92 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// CallTool calls a tool the way a client does, through the MCP server and the middleware chain, without a
+// transport. It is used by moling call to run tools from the command line. Renamed tools may be called by the name
+// their service gave them as well.
+func (m *MoLingServer) CallTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	served, ok := m.resolveTool(name)
+	if !ok {
+		if failed := m.failedServices(); failed != "" {
+			return nil, fmt.Errorf("tool %s not found, these services did not start: %s", name, failed)
+		}
+		return nil, fmt.Errorf("tool %s not found", name)
+	}
+	result, err := m.request(ctx, mcp.MethodToolsCall, map[string]any{"name": served, "arguments": args})
+	if err != nil {
+		return nil, err
+	}
+	return mcp.ParseCallToolResult(&result)
+}
+
+// ListTools returns the tools that a client without restrictions gets from tools/list, sorted by name. Disabled
+// tools are not listed.
+func (m *MoLingServer) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	result, err := m.request(ctx, mcp.MethodToolsList, map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	var list mcp.ListToolsResult
+	if err = json.Unmarshal(result, &list); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Tools, func(i, j int) bool { return list.Tools[i].Name < list.Tools[j].Name })
+	return list.Tools, nil
+}
+
+// ToolService returns the service that provides a tool, empty for unknown tools.
+func (m *MoLingServer) ToolService(name string) comm.MoLingServerType {
+	return m.toolServices[name]
+}
+
+// request handles a JSON-RPC request without a transport and returns its result.
+func (m *MoLingServer) request(ctx context.Context, method mcp.MCPMethod, params map[string]any) (json.RawMessage, error) {
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Request: mcp.Request{Method: string(method)},
+		Params:  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch response := m.server.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		data, err := json.Marshal(response.Result)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	case mcp.JSONRPCError:
+		return nil, errors.New(response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response %T to %s", response, method)
+	}
+}
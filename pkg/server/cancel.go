@@ -0,0 +1,129 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// CancelledNotification is sent by clients to stop a request they no longer need.
+	CancelledNotification = "notifications/cancelled"
+	// requestIDField is the request meta field that carries the JSON-RPC ID of a tool call to its handler.
+	requestIDField = "moling/requestId"
+)
+
+// inflight tracks running tool calls by session and request ID so that clients can cancel them.
+type inflight struct {
+	lock    sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func inflightKey(session string, id any) string {
+	return session + "/" + mcp.NewRequestId(id).String()
+}
+
+// start registers a tool call and returns its cancellable context and a function that unregisters it.
+func (f *inflight) start(ctx context.Context, session string, id any) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := inflightKey(session, id)
+	f.lock.Lock()
+	if f.cancels == nil {
+		f.cancels = make(map[string]context.CancelFunc)
+	}
+	f.cancels[key] = cancel
+	f.lock.Unlock()
+	return ctx, func() {
+		f.lock.Lock()
+		delete(f.cancels, key)
+		f.lock.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels a running tool call. It reports false if the call already finished.
+func (f *inflight) cancel(session string, id any) bool {
+	f.lock.Lock()
+	cancel, ok := f.cancels[inflightKey(session, id)]
+	f.lock.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// cancelAll cancels all running tool calls.
+func (f *inflight) cancelAll() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, cancel := range f.cancels {
+		cancel()
+	}
+}
+
+// stampRequestID keeps the JSON-RPC ID of a tool call in the request meta, because mcp-go does not pass it to tool handlers.
+func stampRequestID(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	request.Params.Meta.AdditionalFields[requestIDField] = id
+}
+
+// trackTool makes a tool call cancellable by its client and passes its progress token to the handler.
+func (m *MoLingServer) trackTool(ctx context.Context, request mcp.CallToolRequest) (context.Context, func()) {
+	meta := request.Params.Meta
+	if meta == nil {
+		return ctx, func() {}
+	}
+	if meta.ProgressToken != nil {
+		ctx = abstract.WithProgressToken(ctx, meta.ProgressToken)
+	}
+	id, ok := meta.AdditionalFields[requestIDField]
+	if !ok {
+		return ctx, func() {}
+	}
+	return m.inflight.start(ctx, sessionID(ctx), id)
+}
+
+// handleCancelled stops the tool call named by a notifications/cancelled from the same session.
+func (m *MoLingServer) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	reason, _ := notification.Params.AdditionalFields["reason"].(string)
+	if m.inflight.cancel(sessionID(ctx), id) {
+		m.logger.Info().Str("session", sessionID(ctx)).Any("requestId", id).Str("reason", reason).Msg("tool call cancelled")
+	}
+}
+
+// sessionID returns the ID of the client session in ctx, empty outside of a session.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
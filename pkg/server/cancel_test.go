@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestCancelTool(t *testing.T) {
+	ms := &MoLingServer{
+		logger:       zerolog.Nop(),
+		toolServices: map[string]comm.MoLingServerType{"slow": "Command"},
+	}
+	ms.chain = ms.builtinMiddlewares()
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(stampRequestID)
+	ms.server = server.NewMCPServer("test", "v1", server.WithToolHandlerMiddleware(ms.handleTool), server.WithHooks(hooks))
+	ms.server.AddNotificationHandler(CancelledNotification, ms.handleCancelled)
+
+	started := make(chan mcp.ProgressToken, 1)
+	ms.server.AddTool(mcp.NewTool("slow"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token, _ := abstract.ProgressTokenFromContext(ctx)
+		started <- token
+		select {
+		case <-ctx.Done():
+			return mcp.NewToolResultError(ctx.Err().Error()), nil
+		case <-time.After(10 * time.Second):
+			return mcp.NewToolResultText("finished"), nil
+		}
+	})
+
+	done := make(chan string, 1)
+	go func() {
+		res := ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"slow","_meta":{"progressToken":"p1"}}}`))
+		data, _ := json.Marshal(res)
+		done <- string(data)
+	}()
+	if token := <-started; token != "p1" {
+		t.Fatalf("expected progress token p1, got %v", token)
+	}
+
+	// cancelling another request has no effect
+	ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":8}}`))
+	ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user aborted"}}`))
+	select {
+	case res := <-done:
+		if !strings.Contains(res, "context canceled") {
+			t.Fatalf("unexpected result %s", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tool call was not cancelled")
+	}
+	if len(ms.inflight.cancels) != 0 {
+		t.Fatalf("finished calls must be unregistered, got %d", len(ms.inflight.cancels))
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// stdioSessionID is the session ID mcp-go gives the single STDIO client.
+const stdioSessionID = "stdio"
+
+// sessionIDPattern finds the session ID in the endpoint event of an SSE stream.
+var sessionIDPattern = regexp.MustCompile(`sessionId=([0-9A-Za-z-]+)`)
+
+// clients sends JSON-RPC requests, such as elicitation/create, to connected clients.
+// mcp-go only sends notifications and drops the responses of clients, so the SSE and STDIO transports are wrapped
+// to write the requests and to route the responses back here.
+type clients struct {
+	lock         sync.Mutex
+	next         int64
+	writers      map[string]func(data []byte) error    // writers write a JSON-RPC message to a session.
+	pending      map[string]chan clientResponse        // pending are the requests waiting for a response, by session and ID.
+	capabilities map[string]map[string]json.RawMessage // capabilities are the capabilities each session declared.
+	info         map[string]mcp.Implementation         // info is the name and version each client sent in initialize.
+	roots        map[string][]mcp.Root                 // roots are the roots each session listed, see ClientRoots.
+}
+
+// clientResponse is the response of a client to a request of the server.
+type clientResponse struct {
+	ID     any             `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newClients() *clients {
+	return &clients{
+		writers:      make(map[string]func(data []byte) error),
+		pending:      make(map[string]chan clientResponse),
+		capabilities: make(map[string]map[string]json.RawMessage),
+		info:         make(map[string]mcp.Implementation),
+		roots:        make(map[string][]mcp.Root),
+	}
+}
+
+// attach registers the writer of a session and returns a function that removes it.
+func (c *clients) attach(session string, write func(data []byte) error) func() {
+	c.lock.Lock()
+	c.writers[session] = write
+	c.lock.Unlock()
+	return func() {
+		c.lock.Lock()
+		delete(c.writers, session)
+		c.lock.Unlock()
+	}
+}
+
+// recordCapabilities is a request hook that keeps the capabilities and the name a client declares in its initialize request.
+func (c *clients) recordCapabilities(ctx context.Context, id any, message any) error {
+	raw, ok := message.(json.RawMessage)
+	if !ok {
+		return nil
+	}
+	var request struct {
+		Method string `json:"method"`
+		Params struct {
+			Capabilities map[string]json.RawMessage `json:"capabilities"`
+			ClientInfo   mcp.Implementation         `json:"clientInfo"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &request); err != nil || request.Method != "initialize" {
+		return nil
+	}
+	c.lock.Lock()
+	c.capabilities[sessionID(ctx)] = request.Params.Capabilities
+	c.info[sessionID(ctx)] = request.Params.ClientInfo
+	c.lock.Unlock()
+	return nil
+}
+
+// forget drops the capabilities and roots of a session that ended.
+func (c *clients) forget(session string) {
+	c.lock.Lock()
+	delete(c.capabilities, session)
+	delete(c.info, session)
+	delete(c.roots, session)
+	c.lock.Unlock()
+}
+
+// describe returns the name and version of the client of a session and the capabilities it declared.
+func (c *clients) describe(session string) (string, []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	client := strings.TrimSpace(c.info[session].Name + " " + c.info[session].Version)
+	capabilities := make([]string, 0, len(c.capabilities[session]))
+	for name := range c.capabilities[session] {
+		capabilities = append(capabilities, name)
+	}
+	sort.Strings(capabilities)
+	return client, capabilities
+}
+
+// ClientSupports reports whether the client of the session in ctx declared a capability and can receive requests.
+func (c *clients) ClientSupports(ctx context.Context, capability string) bool {
+	session := sessionID(ctx)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, declared := c.capabilities[session][capability]
+	_, attached := c.writers[session]
+	return declared && attached
+}
+
+// RequestClient sends a request to the client of the session in ctx and waits for its result.
+func (c *clients) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	session := sessionID(ctx)
+	c.lock.Lock()
+	write, ok := c.writers[session]
+	if !ok {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("the client of session %q cannot receive requests", session)
+	}
+	c.next++
+	id := fmt.Sprintf("moling-%d", c.next)
+	key := session + "/" + id
+	ch := make(chan clientResponse, 1)
+	c.pending[key] = ch
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, key)
+		c.lock.Unlock()
+	}()
+
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return nil, err
+	}
+	if err := write(data); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s failed: %s (code %d)", method, resp.Error.Message, resp.Error.Code)
+		}
+		return resp.Result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no answer to %s: %w", method, ctx.Err())
+	}
+}
+
+// deliver routes the response of a client to the pending request. It reports false for any other message.
+func (c *clients) deliver(session string, data []byte) bool {
+	var resp clientResponse
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Method != "" {
+		return false
+	}
+	id, ok := resp.ID.(string)
+	if !ok {
+		return false
+	}
+	c.lock.Lock()
+	ch, ok := c.pending[session+"/"+id]
+	c.lock.Unlock()
+	if ok {
+		select {
+		case ch <- resp:
+		default: // a duplicate response
+		}
+	}
+	return ok
+}
+
+// wrapSSE lets the SSE transport carry requests to clients and their responses.
+func (c *clients) wrapSSE(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			sw := &sseWriter{ResponseWriter: w, clients: c}
+			defer sw.close()
+			next.ServeHTTP(sw, r)
+		case http.MethodPost:
+			session := r.URL.Query().Get("sessionId")
+			if session != "" && r.Body != nil {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					http.Error(w, "failed to read the request body", http.StatusBadRequest)
+					return
+				}
+				if c.deliver(session, body) {
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			next.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// sseWriter shares an SSE stream between mcp-go and the requests to its client.
+type sseWriter struct {
+	http.ResponseWriter
+	lock    sync.Mutex
+	clients *clients
+	detach  func()
+	closed  bool
+}
+
+func (sw *sseWriter) Write(p []byte) (int, error) {
+	sw.lock.Lock()
+	defer sw.lock.Unlock()
+	if sw.detach == nil {
+		if m := sessionIDPattern.FindSubmatch(p); m != nil {
+			sw.detach = sw.clients.attach(string(m[1]), sw.writeEvent)
+		}
+	}
+	return sw.ResponseWriter.Write(p)
+}
+
+func (sw *sseWriter) Flush() {
+	sw.lock.Lock()
+	defer sw.lock.Unlock()
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeEvent writes a JSON-RPC message as an SSE event.
+func (sw *sseWriter) writeEvent(data []byte) error {
+	sw.lock.Lock()
+	defer sw.lock.Unlock()
+	if sw.closed {
+		return fmt.Errorf("the SSE stream is closed")
+	}
+	if _, err := fmt.Fprintf(sw.ResponseWriter, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (sw *sseWriter) close() {
+	sw.lock.Lock()
+	defer sw.lock.Unlock()
+	sw.closed = true
+	if sw.detach != nil {
+		sw.detach()
+	}
+}
+
+// wrapStdio lets the STDIO transport carry requests to the client and their responses.
+func (c *clients) wrapStdio(stdin io.Reader, stdout io.Writer) (io.Reader, io.Writer) {
+	out := &lockedWriter{w: stdout}
+	c.attach(stdioSessionID, func(data []byte) error {
+		_, err := out.Write(append(data, '\n'))
+		return err
+	})
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(stdin)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && !c.deliver(stdioSessionID, line) {
+				if _, werr := pw.Write(line); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr, out
+}
+
+// lockedWriter serializes writes, so that each message stays on its own line.
+type lockedWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.lock.Lock()
+	defer lw.lock.Unlock()
+	return lw.w.Write(p)
+}
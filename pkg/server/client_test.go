@@ -0,0 +1,168 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestClientRequestsSSE(t *testing.T) {
+	c := newClients()
+	ctx := server.NewMCPServer("test", "v1").WithContext(context.Background(), fakeSession{id: "s1"})
+	_ = c.recordCapabilities(ctx, 1, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"elicitation":{}}}}`))
+	if c.ClientSupports(ctx, abstract.ElicitationCapability) {
+		t.Fatal("a client without a stream cannot receive requests")
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		fmt.Fprintf(w, "event: endpoint\ndata: /message?sessionId=s1\r\n\r\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	ts := httptest.NewServer(c.wrapSSE(next))
+	t.Cleanup(ts.Close)
+	resp, err := http.Get(ts.URL + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	for deadline := time.Now().Add(5 * time.Second); !c.ClientSupports(ctx, abstract.ElicitationCapability); {
+		if time.Now().After(deadline) {
+			t.Fatal("the SSE stream was not attached to its session")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	type answer struct {
+		result *abstract.ElicitResult
+		err    error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		result, err := abstract.ElicitClient(ctx, c, 5, "What is your name?", map[string]any{"type": "object"})
+		answers <- answer{result, err}
+	}()
+	var request struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+	}
+	for request.ID == "" {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: {"); ok {
+			if err := json.Unmarshal([]byte("{"+data), &request); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if request.Method != abstract.MethodElicitationCreate {
+		t.Fatalf("unexpected request %+v", request)
+	}
+
+	post := func(body string) int {
+		resp, err := http.Post(ts.URL+"/message?sessionId=s1", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post(`{"jsonrpc":"2.0","id":1,"method":"ping"}`); code != http.StatusTeapot {
+		t.Fatalf("requests of the client must reach mcp-go, got %d", code)
+	}
+	if code := post(fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":{"action":"accept","content":{"name":"moling"}}}`, request.ID)); code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d for the response", code)
+	}
+	a := <-answers
+	if a.err != nil || !a.result.Accepted() || a.result.Content["name"] != "moling" {
+		t.Fatalf("unexpected answer %+v %v", a.result, a.err)
+	}
+}
+
+func TestClientRequestsStdio(t *testing.T) {
+	c := newClients()
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	stdin, _ := c.wrapStdio(stdinReader, stdoutWriter)
+	ctx := server.NewMCPServer("test", "v1").WithContext(context.Background(), fakeSession{id: stdioSessionID})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.RequestClient(ctx, "roots/list", nil)
+		done <- err
+	}()
+	go func() {
+		line, _ := bufio.NewReader(stdoutReader).ReadString('\n')
+		var request struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal([]byte(line), &request)
+		fmt.Fprintf(stdinWriter, "{\"jsonrpc\":\"2.0\",\"id\":%q,\"result\":{\"roots\":[]}}\n", request.ID)
+		fmt.Fprintln(stdinWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		stdinWriter.Close()
+	}()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	rest, _ := io.ReadAll(stdin)
+	if string(rest) != "{\"jsonrpc\":\"2.0\",\"id\":2,\"method\":\"tools/list\"}\n" {
+		t.Fatalf("only client requests must reach mcp-go, got %q", rest)
+	}
+}
+
+func TestMissingArguments(t *testing.T) {
+	tool := mcp.NewTool("read_file",
+		mcp.WithString("path", mcp.Required(), mcp.Description("path of the file")),
+		mcp.WithNumber("limit"),
+	)
+	schema, missing := missingArguments(tool, map[string]any{})
+	if len(missing) != 1 || missing[0] != "path" {
+		t.Fatalf("unexpected missing arguments %v", missing)
+	}
+	if prop := schema["properties"].(map[string]any)["path"].(map[string]any); prop["description"] != "path of the file" {
+		t.Fatalf("unexpected schema %v", schema)
+	}
+	if _, missing = missingArguments(tool, map[string]any{"path": "a.txt"}); len(missing) != 0 {
+		t.Fatalf("unexpected missing arguments %v", missing)
+	}
+	tool = mcp.NewTool("write", mcp.WithArray("lines", mcp.Required()))
+	if _, missing = missingArguments(tool, nil); len(missing) != 0 {
+		t.Fatal("arrays cannot be asked for")
+	}
+}
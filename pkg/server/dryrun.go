@@ -0,0 +1,60 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// dryRunTool turns tool calls into dry runs if the server runs in dry-run mode or the client set dry_run. Tools
+// declaring the dry_run argument describe what they would do themselves. The tools of the server itself and the tools
+// annotated as read-only run as usual, and the calls of any other tool are answered here without calling it.
+func (m *MoLingServer) dryRunTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !m.mlConfig.DryRun && !abstract.IsDryRun(ctx, request) {
+			return next(ctx, request)
+		}
+		tool, ok := m.tools[request.Params.Name]
+		if !ok {
+			return next(ctx, request)
+		}
+		if _, declared := tool.InputSchema.Properties[abstract.DryRunArgument]; declared {
+			return next(abstract.WithDryRun(ctx), request)
+		}
+		if tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint {
+			return next(ctx, request)
+		}
+		args := make(map[string]any, len(request.GetArguments()))
+		for k, v := range request.GetArguments() {
+			if k != abstract.DryRunArgument {
+				args[k] = v
+			}
+		}
+		data, err := json.Marshal(args)
+		if err != nil {
+			data = []byte("{}")
+		}
+		m.logger.Debug().Str("tool", tool.Name).Msg("tool call answered as a dry run")
+		return abstract.NewDryRunResult("%s cannot describe its effect, it would be called with the arguments %s", tool.Name, data), nil
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestDryRunTool(t *testing.T) {
+	ms := &MoLingServer{logger: zerolog.Nop(), tools: map[string]mcp.Tool{
+		"write_file": mcp.NewTool("write_file", mcp.WithString("path"), abstract.WithDryRunArgument()),
+		"read_file":  mcp.NewTool("read_file", mcp.WithReadOnlyHintAnnotation(true), mcp.WithString("path")),
+		"send_mail":  mcp.NewTool("send_mail", mcp.WithString("to")),
+	}}
+
+	var ran []string
+	handler := ms.dryRunTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ran = append(ran, request.Params.Name)
+		if abstract.IsDryRun(ctx, request) {
+			return abstract.NewDryRunResult("would write %s", request.GetArguments()["path"]), nil
+		}
+		return mcp.NewToolResultText("done"), nil
+	})
+	call := func(tool string, args map[string]any) string {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil || res.IsError {
+			t.Fatalf("%s: unexpected result %+v, error %v", tool, res, err)
+		}
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	if text := call("send_mail", map[string]any{"to": "a@example.com"}); text != "done" {
+		t.Fatalf("calls without dry_run must run, got %q", text)
+	}
+	if text := call("write_file", map[string]any{"path": "a.txt", "dry_run": true}); !strings.Contains(text, "would write a.txt") {
+		t.Fatalf("tools declaring dry_run must describe the call themselves, got %q", text)
+	}
+	ran = nil
+	text := call("send_mail", map[string]any{"to": "a@example.com", "dry_run": true})
+	if len(ran) != 0 || !strings.Contains(text, `{"to":"a@example.com"}`) {
+		t.Fatalf("tools without dry_run must not run in a dry run, ran %v, got %q", ran, text)
+	}
+
+	// In dry-run mode, every call is a dry run, but read-only tools still run
+	ms.mlConfig.DryRun = true
+	ran = nil
+	if text := call("write_file", map[string]any{"path": "b.txt"}); !strings.Contains(text, "would write b.txt") {
+		t.Fatalf("calls must be dry runs in dry-run mode, got %q", text)
+	}
+	if text := call("read_file", map[string]any{"path": "b.txt"}); text != "done" {
+		t.Fatalf("read-only tools must run in dry-run mode, got %q", text)
+	}
+	call("send_mail", map[string]any{"to": "a@example.com"})
+	if strings.Join(ran, ",") != "write_file,read_file" {
+		t.Fatalf("unexpected calls %v", ran)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// elicitTool asks the user for the required arguments a client left out, if the client supports elicitation.
+// Calls the user cannot complete go through unchanged and fail in the tool as before.
+func (m *MoLingServer) elicitTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tool, ok := m.tools[request.Params.Name]
+		if !ok || m.clients == nil || !m.mlConfig.Elicitation.Enabled || !m.clients.ClientSupports(ctx, abstract.ElicitationCapability) {
+			return next(ctx, request)
+		}
+		args := request.GetArguments()
+		schema, missing := missingArguments(tool, args)
+		if len(missing) == 0 {
+			return next(ctx, request)
+		}
+		message := m.locale.Sprintf("server.user_input_question", tool.Name, strings.Join(missing, ", "))
+		result, err := abstract.ElicitClient(ctx, m.clients, m.mlConfig.Elicitation.Timeout, message, schema)
+		if err != nil {
+			m.logger.Debug().Err(err).Str("tool", tool.Name).Msg("failed to ask for missing arguments")
+			return next(ctx, request)
+		}
+		if !result.Accepted() {
+			return abstract.NewErrorResult(abstract.CodeInvalidArgument, m.locale.Sprintf("server.user_input_missing", strings.Join(missing, ", "))), nil
+		}
+		merged := make(map[string]any, len(args)+len(result.Content))
+		for k, v := range args {
+			merged[k] = v
+		}
+		for k, v := range result.Content {
+			merged[k] = v
+		}
+		request.Params.Arguments = merged
+		return next(ctx, request)
+	}
+}
+
+// missingArguments returns the elicitation schema of the required arguments missing from args and their names.
+// It returns none if a missing argument is not a primitive value, since elicitation cannot ask for those.
+func missingArguments(tool mcp.Tool, args map[string]any) (map[string]any, []string) {
+	properties := make(map[string]any)
+	var missing []string
+	for _, name := range tool.InputSchema.Required {
+		if v, ok := args[name]; ok && v != nil {
+			continue
+		}
+		prop, _ := tool.InputSchema.Properties[name].(map[string]any)
+		switch prop["type"] {
+		case "string", "number", "integer", "boolean":
+		default:
+			return nil, nil
+		}
+		field := map[string]any{"type": prop["type"]}
+		for _, key := range []string{"title", "description", "enum", "minimum", "maximum"} {
+			if v, ok := prop[key]; ok {
+				field[key] = v
+			}
+		}
+		properties[name] = field
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	return map[string]any{"type": "object", "properties": properties, "required": missing}, missing
+}
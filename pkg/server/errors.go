@@ -0,0 +1,43 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// errorCodeTool gives every failed tool result an error code. The results of tools that set none get the code their
+// text suggests, and the ToolErrors handlers return are turned into failed results with their code.
+func (m *MoLingServer) errorCodeTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		var te *abstract.ToolError
+		switch {
+		case errors.As(err, &te):
+			return abstract.NewErrorResult(te.Code, te.Error()), nil
+		case err == nil && result != nil && result.IsError && abstract.ErrorCodeOf(result) == "":
+			return abstract.WithErrorCode(result, abstract.ClassifyMessage(resultText(result))), nil
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestErrorCodeTool(t *testing.T) {
+	ms := &MoLingServer{logger: zerolog.Nop()}
+	call := func(result *mcp.CallToolResult, err error) (*mcp.CallToolResult, error) {
+		handler := ms.errorCodeTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return result, err
+		})
+		return handler(context.Background(), mcp.CallToolRequest{})
+	}
+
+	res, err := call(nil, abstract.Errorf(abstract.CodeNotFound, "job %s not found", "j1"))
+	if err != nil || !res.IsError || abstract.ErrorCodeOf(res) != abstract.CodeNotFound {
+		t.Fatalf("a returned ToolError must become a failed result with its code, got %+v, error %v", res, err)
+	}
+	if resultText(res) != "job j1 not found" {
+		t.Fatalf("unexpected text %q", resultText(res))
+	}
+
+	res, _ = call(mcp.NewToolResultError("request timed out after 30s"), nil)
+	if abstract.ErrorCodeOf(res) != abstract.CodeTimeout {
+		t.Fatalf("a failed result without a code must get the code its text suggests, got %+v", res.Meta)
+	}
+	if e := res.Meta[abstract.ErrorMetaKey].(map[string]any); e["retryable"] != true {
+		t.Fatalf("timeouts must be retryable, got %v", e)
+	}
+
+	res, _ = call(abstract.NewErrorResult(abstract.CodePolicyDenied, "request timed out"), nil)
+	if abstract.ErrorCodeOf(res) != abstract.CodePolicyDenied {
+		t.Fatalf("the code a tool sets must be kept, got %+v", res.Meta)
+	}
+
+	res, _ = call(mcp.NewToolResultText("ok"), nil)
+	if res.Meta != nil {
+		t.Fatalf("successful results must not get a code, got %+v", res.Meta)
+	}
+
+	if _, err = call(nil, errors.New("boom")); err == nil || err.Error() != "boom" {
+		t.Fatalf("other errors must be returned unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// AdminServiceName is the service name of the tools that the server provides itself, used by roles and logs.
+	AdminServiceName comm.MoLingServerType = "MoLing"
+	// StatusToolName is the name of the self-diagnostics tool.
+	StatusToolName = "moling_status"
+	// healthCheckTimeout bounds every single health check.
+	healthCheckTimeout = 5 * time.Second
+)
+
+// HealthCheck is the outcome of one health check.
+type HealthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// HealthReport is the health of the server and its services.
+type HealthReport struct {
+	Status  string         `json:"status"` // Status is ok if all checks passed, unhealthy otherwise.
+	Version string         `json:"version"`
+	Uptime  string         `json:"uptime"`
+	Checks  []HealthCheck  `json:"checks"`
+	Budgets []BudgetStatus `json:"budgets,omitempty"` // Budgets is the resource usage of the services with a budget.
+}
+
+// Health checks that the data directory is writable, that the configuration file is valid and that every
+// service started and is healthy.
+func (m *MoLingServer) Health(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Status:  "ok",
+		Version: m.mlConfig.Version,
+		Uptime:  time.Since(m.started).Round(time.Second).String(),
+	}
+	add := func(name string, err error) {
+		check := HealthCheck{Name: name, OK: err == nil}
+		if err != nil {
+			check.Error = err.Error()
+			report.Status = "unhealthy"
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	add("data_dir", checkWritable(m.mlConfig.BasePath))
+	add("config", m.checkConfig())
+	for _, srv := range m.services {
+		var err error
+		if hc, ok := srv.(abstract.HealthChecker); ok {
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			err = hc.Health(checkCtx)
+			cancel()
+		}
+		add("service:"+string(srv.Name()), err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.failed)) {
+		add("service:"+string(name), fmt.Errorf("failed to start: %w", m.failed[name]))
+	}
+	report.Budgets = m.budgetStatus()
+	return report
+}
+
+// SetFailedServices records the services that failed to start, so the health report shows them as unhealthy and the
+// description of the status tool lists them.
+func (m *MoLingServer) SetFailedServices(failed map[comm.MoLingServerType]error) {
+	m.failed = failed
+	if m.server != nil {
+		m.addStatusTool()
+	}
+}
+
+// failedServices lists the services that failed to start with the reasons, "" if all started.
+func (m *MoLingServer) failedServices() string {
+	var failed []string
+	for _, name := range slices.Sorted(maps.Keys(m.failed)) {
+		failed = append(failed, fmt.Sprintf("%s (%s)", name, m.failed[name]))
+	}
+	return strings.Join(failed, "; ")
+}
+
+// checkWritable creates and removes a file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// checkConfig validates the server settings and the configuration file, which may have been edited since the start.
+func (m *MoLingServer) checkConfig() error {
+	for _, err := range []error{m.mlConfig.Auth.Check(), m.mlConfig.Sessions.Check(), m.mlConfig.Limits.Check(), m.mlConfig.Elicitation.Check(), m.mlConfig.Sampling.Check(), m.mlConfig.Logging.Check(), m.mlConfig.Cache.Check(), m.mlConfig.Jobs.Check(), m.mlConfig.Policy.Check(), m.mlConfig.Audit.Check(), m.mlConfig.I18n.Check(), m.mlConfig.ToolNames.Check(), m.mlConfig.Budgets.Check(), m.mlConfig.Responses.Check(), m.mlConfig.Stats.Check()} {
+		if err != nil {
+			return err
+		}
+	}
+	_, err := config.LoadFile(filepath.Join(m.mlConfig.BasePath, m.mlConfig.ConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("the configuration file %s is not valid: %w", m.mlConfig.ConfigFile, err)
+	}
+	return nil
+}
+
+// handleHealthz answers liveness probes. It does not check anything beyond the server answering.
+func (m *MoLingServer) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
+
+// handleReadyz answers readiness probes with 503 if a health check fails or the server shuts down. Errors are left out since probes are not
+// authenticated, the moling_status tool reports them.
+func (m *MoLingServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if m.drain.isDraining() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":"shutting down"}`))
+		return
+	}
+	report := m.Health(r.Context())
+	for i := range report.Checks {
+		report.Checks[i].Error = ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// addStatusTool registers the self-diagnostics tool. Its description names the services that failed to start and
+// why, so that clients see in the tool list why their tools are missing.
+func (m *MoLingServer) addStatusTool() {
+	description := "Report the health of the MoLing server and its services: whether the data directory is writable, the configuration is valid and services such as the browser respond."
+	if failed := m.failedServices(); failed != "" {
+		description += " These services did not start and their tools are not available: " + failed
+	}
+	m.toolServices[StatusToolName] = AdminServiceName
+	m.server.AddTool(mcp.NewTool(
+		StatusToolName,
+		mcp.WithDescription(description),
+	), m.handleStatus)
+}
+
+func (m *MoLingServer) handleStatus(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(m.Health(ctx), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode the health report: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
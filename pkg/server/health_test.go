@@ -0,0 +1,139 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// healthService is a service with a health check.
+type healthService struct {
+	abstract.Service
+	name comm.MoLingServerType
+	err  error
+}
+
+func (hs *healthService) Name() comm.MoLingServerType      { return hs.name }
+func (hs *healthService) Health(ctx context.Context) error { return hs.err }
+
+func TestHealth(t *testing.T) {
+	dir := t.TempDir()
+	dead := &healthService{name: "Browser", err: errors.New("the browser does not respond")}
+	ms := &MoLingServer{
+		logger:   zerolog.Nop(),
+		mlConfig: config.MoLingConfig{BasePath: dir, ConfigFile: "moling_config.json", Version: "v1", Sessions: config.NewSessionConfig(), Limits: config.NewLimitConfig(), Sampling: config.NewSamplingConfig()},
+		services: []abstract.Service{&healthService{name: "FileSystem"}, dead},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "moling_config.json"), []byte(`{"MoLingConfig":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report := ms.Health(context.Background())
+	if report.Status != "unhealthy" || len(report.Checks) != 4 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for _, c := range report.Checks {
+		if c.OK != (c.Name != "service:Browser") {
+			t.Fatalf("unexpected check %+v", c)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ms.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || strings.Contains(rec.Body.String(), "does not respond") {
+		t.Fatalf("unexpected readyz response %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	ms.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected healthz status %d", rec.Code)
+	}
+
+	dead.err = nil
+	if err := os.WriteFile(filepath.Join(dir, "moling_config.json"), []byte(`{"MoLingConfig":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := ms.handleStatus(context.Background(), mcp.CallToolRequest{})
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != "unhealthy" || report.Checks[1].Name != "config" || report.Checks[1].OK {
+		t.Fatalf("the broken configuration file must be reported: %+v", report)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "moling_config.json"), []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	ms.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected readyz status %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHealthFailedServices(t *testing.T) {
+	ms := &MoLingServer{
+		logger:   zerolog.Nop(),
+		mlConfig: config.MoLingConfig{BasePath: t.TempDir(), ConfigFile: "moling_config.json", Sessions: config.NewSessionConfig(), Limits: config.NewLimitConfig(), Sampling: config.NewSamplingConfig()},
+		services: []abstract.Service{&healthService{name: "FileSystem"}},
+	}
+	ms.SetFailedServices(map[comm.MoLingServerType]error{"Browser": errors.New("chrome not found")})
+	report := ms.Health(context.Background())
+	last := report.Checks[len(report.Checks)-1]
+	if report.Status != "unhealthy" || last.Name != "service:Browser" || last.OK || !strings.Contains(last.Error, "failed to start: chrome not found") {
+		t.Fatalf("the failed service must be reported: %+v", report)
+	}
+}
+
+func TestStatusToolListsFailedServices(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewMoLingServer(ctx, []abstract.Service{&namedService{name: "FileSystem"}}, config.MoLingConfig{BasePath: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetFailedServices(map[comm.MoLingServerType]error{"Browser": errors.New("service disabled: Chrome was not found")})
+	tools, err := srv.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools {
+		if tool.Name == StatusToolName {
+			if !strings.Contains(tool.Description, "Browser (service disabled: Chrome was not found)") {
+				t.Fatalf("the status tool must name the failed services, got %q", tool.Description)
+			}
+			return
+		}
+	}
+	t.Fatal("the status tool must be listed")
+}
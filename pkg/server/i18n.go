@@ -0,0 +1,96 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/i18n"
+)
+
+// I18nDir is the directory of the catalogs that replace or add translations, relative to the base path. The file
+// of a language is named after it, such as zh-CN.json.
+const I18nDir = "config/i18n"
+
+// openLocale loads the catalog of the configured language.
+func (m *MoLingServer) openLocale() error {
+	locale, err := i18n.Load(m.mlConfig.I18n.Language, filepath.Join(m.mlConfig.BasePath, I18nDir))
+	if err != nil {
+		return err
+	}
+	m.locale = locale
+	return nil
+}
+
+// localizeTool returns the tool with its description and the descriptions of its arguments in the configured language.
+func (m *MoLingServer) localizeTool(tool mcp.Tool) mcp.Tool {
+	text, ok := m.locale.Tool(tool.Name)
+	if !ok {
+		return tool
+	}
+	if text.Description != "" {
+		tool.Description = text.Description
+	}
+	if len(text.Parameters) == 0 || tool.InputSchema.Properties == nil {
+		return tool
+	}
+	// Copy the properties, the definition of the tool is shared with the service
+	props := make(map[string]any, len(tool.InputSchema.Properties))
+	for name, prop := range tool.InputSchema.Properties {
+		desc, ok := text.Parameters[name]
+		schema, isMap := prop.(map[string]any)
+		if !ok || !isMap {
+			props[name] = prop
+			continue
+		}
+		copied := make(map[string]any, len(schema))
+		for k, v := range schema {
+			copied[k] = v
+		}
+		copied["description"] = desc
+		props[name] = copied
+	}
+	tool.InputSchema.Properties = props
+	return tool
+}
+
+// localizePrompt returns the prompt with its description in the configured language, and a handler that replaces
+// its messages with the text of the catalog, if it has one.
+func (m *MoLingServer) localizePrompt(prompt mcp.Prompt, handler server.PromptHandlerFunc) (mcp.Prompt, server.PromptHandlerFunc) {
+	text, ok := m.locale.Prompt(prompt.Name)
+	if !ok {
+		return prompt, handler
+	}
+	if text.Description != "" {
+		prompt.Description = text.Description
+	}
+	if text.Text == "" {
+		return prompt, handler
+	}
+	return prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		result, err := handler(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		result.Messages = []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.Text))}
+		return result, nil
+	}
+}
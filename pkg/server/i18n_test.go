@@ -0,0 +1,65 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+func TestLocalize(t *testing.T) {
+	ms := &MoLingServer{mlConfig: config.MoLingConfig{BasePath: t.TempDir(), I18n: config.I18nConfig{Language: "zh-CN"}}}
+	if err := ms.openLocale(); err != nil {
+		t.Fatal(err)
+	}
+	tool := mcp.NewTool("read_file",
+		mcp.WithDescription("Read the complete contents of a file from the file system."),
+		mcp.WithString("path", mcp.Description("Relative path to the file to read")),
+	)
+	localized := ms.localizeTool(tool)
+	if localized.Description == tool.Description {
+		t.Fatal("the description must be translated")
+	}
+	if localized.InputSchema.Properties["path"].(map[string]any)["description"] == "Relative path to the file to read" {
+		t.Fatal("the descriptions of the arguments must be translated")
+	}
+	if tool.InputSchema.Properties["path"].(map[string]any)["description"] != "Relative path to the file to read" {
+		t.Fatal("the definition of the service must not change")
+	}
+
+	prompt, handler := ms.localizePrompt(mcp.Prompt{Name: "filesystem_prompt", Description: "Get the prompts."}, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{Messages: []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("You are a powerful assistant."))}}, nil
+	})
+	if prompt.Description == "Get the prompts." {
+		t.Fatal("the description of the prompt must be translated")
+	}
+	result, err := handler(context.Background(), mcp.GetPromptRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Content.(mcp.TextContent).Text == "You are a powerful assistant." {
+		t.Fatalf("the text of the prompt must be translated, got %+v", result.Messages)
+	}
+
+	if got := ms.locale.Sprintf("server.tool_not_found", "x"); got != "未找到工具 x" {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
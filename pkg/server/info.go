@@ -0,0 +1,167 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/upgrade"
+)
+
+// InfoToolName is the name of the admin tool that describes the server to agents and orchestrators.
+const InfoToolName = "moling_info"
+
+// ServerInfo describes the running server, so that clients can adapt to its version, transport and limits.
+type ServerInfo struct {
+	Name      string                    `json:"name"`
+	Version   string                    `json:"version"`           // Version is the build version, such as linux_amd64_v0.2.0_2025-05-01 10:00.
+	Release   string                    `json:"release,omitempty"` // Release is the release version in Version, empty for snapshot builds.
+	Commit    string                    `json:"commit,omitempty"`  // Commit is the git revision the binary was built from, if known.
+	GoVersion string                    `json:"go_version"`
+	OS        string                    `json:"os"`
+	Arch      string                    `json:"arch"`
+	Transport string                    `json:"transport"`         // Transport is sse or stdio.
+	Listen    string                    `json:"listen,omitempty"`  // Listen is the SSE listen address.
+	Profile   string                    `json:"profile,omitempty"` // Profile is the configuration profile in use.
+	DryRun    bool                      `json:"dry_run"`           // DryRun is set if every tool call only describes what it would do.
+	Uptime    string                    `json:"uptime"`
+	Services  []string                  `json:"services"`         // Services are the loaded services.
+	Failed    []string                  `json:"failed,omitempty"` // Failed are the services that did not start.
+	Tools     int                       `json:"tools"`            // Tools is the number of tools of the services.
+	Limits    InfoLimits                `json:"limits"`
+	Service   map[string]map[string]any `json:"service_limits,omitempty"` // Service are the size, count and time limits of every service.
+}
+
+// InfoLimits are the limits of the server that apply to all tool calls. Durations are in seconds, 0 means no limit.
+type InfoLimits struct {
+	MaxOutputSize   int `json:"max_output_size"`  // MaxOutputSize is the size of a tool result in bytes beyond which it is saved to a file.
+	PreviewSize     int `json:"preview_size"`     // PreviewSize is the length of the text kept in the result of a saved one.
+	MaxInFlight     int `json:"max_in_flight"`    // MaxInFlight is the number of tool calls running at a time.
+	QueueTimeout    int `json:"queue_timeout"`    // QueueTimeout is how long a call waits for a free slot.
+	JobTimeout      int `json:"job_timeout"`      // JobTimeout cancels background jobs that run longer.
+	MaxRunningJobs  int `json:"max_running_jobs"` // MaxRunningJobs is the number of background jobs that run at the same time.
+	ShutdownTimeout int `json:"shutdown_timeout"` // ShutdownTimeout is how long running tool calls are waited for on shutdown.
+}
+
+// Info describes the running server: its build, transport, services and limits.
+func (m *MoLingServer) Info() ServerInfo {
+	info := ServerInfo{
+		Name:      m.mlConfig.ServerName,
+		Version:   m.mlConfig.Version,
+		Commit:    buildCommit(m.mlConfig.Version),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Transport: "stdio",
+		Listen:    m.listenAddr,
+		Profile:   m.mlConfig.Profile,
+		DryRun:    m.mlConfig.DryRun,
+		Uptime:    time.Since(m.started).Round(time.Second).String(),
+		Services:  make([]string, 0, len(m.services)),
+		Tools:     len(m.tools),
+		Limits: InfoLimits{
+			MaxOutputSize:   m.mlConfig.Responses.MaxSize,
+			PreviewSize:     m.mlConfig.Responses.PreviewSize,
+			MaxInFlight:     m.mlConfig.Limits.MaxInFlight,
+			QueueTimeout:    m.mlConfig.Limits.QueueTimeout,
+			JobTimeout:      m.mlConfig.Jobs.Timeout,
+			MaxRunningJobs:  m.mlConfig.Jobs.MaxRunning,
+			ShutdownTimeout: m.mlConfig.ShutdownTimeout,
+		},
+	}
+	info.Release, _ = upgrade.Version(m.mlConfig.Version)
+	if m.listenAddr != "" {
+		info.Transport = "sse"
+	}
+	for _, srv := range m.services {
+		info.Services = append(info.Services, string(srv.Name()))
+		if limits := serviceLimits(srv.Config()); len(limits) > 0 {
+			if info.Service == nil {
+				info.Service = make(map[string]map[string]any)
+			}
+			info.Service[string(srv.Name())] = limits
+		}
+	}
+	slices.Sort(info.Services)
+	for _, name := range slices.Sorted(maps.Keys(m.failed)) {
+		info.Failed = append(info.Failed, string(name))
+	}
+	return info
+}
+
+// serviceLimits picks the numeric settings of a service configuration that are limits: timeouts and maximums.
+func serviceLimits(cfg string) map[string]any {
+	var settings map[string]any
+	if json.Unmarshal([]byte(cfg), &settings) != nil {
+		return nil
+	}
+	limits := make(map[string]any)
+	for key, value := range settings {
+		if _, ok := value.(float64); !ok {
+			continue
+		}
+		name := strings.ToLower(key)
+		if strings.Contains(name, "timeout") || strings.HasPrefix(name, "max") {
+			limits[key] = value
+		}
+	}
+	return limits
+}
+
+// buildCommit returns the git revision recorded in the binary, or the one in a version string of a build of a commit,
+// such as linux_amd64_2a3b4c5_2025-05-01 10:00:00, empty if neither is known.
+func buildCommit(version string) string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" && s.Value != "" {
+				return s.Value
+			}
+		}
+	}
+	if fields := strings.Split(version, "_"); len(fields) > 3 && len(fields[2]) >= 7 && isHex(fields[2]) {
+		return fields[2]
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	return strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// addInfoTool registers the tool that describes the server. Like moling_status, it belongs to the MoLing service.
+func (m *MoLingServer) addInfoTool() {
+	m.toolServices[InfoToolName] = AdminServiceName
+	m.server.AddTool(mcp.NewTool(
+		InfoToolName,
+		mcp.WithDescription("Describe the MoLing server: its version and git commit, OS and architecture, transport, the loaded services and the limits of tool calls such as the maximum output size and timeouts. Call it to adapt to the server you are talking to."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), m.handleInfo)
+}
+
+func (m *MoLingServer) handleInfo(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return abstract.NewJSONResult(m.Info()), nil
+}
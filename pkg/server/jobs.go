@@ -0,0 +1,250 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/jobs"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// JobsServiceName is the service name of the jobs_* tools, used by roles and logs.
+	JobsServiceName comm.MoLingServerType = "Jobs"
+	// JobsDir is the directory of the background jobs in the base path.
+	JobsDir = "data/jobs"
+	// BackgroundArgument is the argument that runs a call of a tool declaring it as a background job.
+	BackgroundArgument = "background"
+
+	JobsSubmitToolName = "jobs_submit"
+	JobsListToolName   = "jobs_list"
+	JobsStatusToolName = "jobs_status"
+	JobsResultToolName = "jobs_result"
+	JobsCancelToolName = "jobs_cancel"
+)
+
+type backgroundKey struct{}
+
+// openJobs opens the queue of the background jobs. Without it, tool calls always run in the foreground.
+func (m *MoLingServer) openJobs() {
+	cfg := m.mlConfig.Jobs
+	q, err := jobs.Open(filepath.Join(m.mlConfig.BasePath, JobsDir), jobs.Options{
+		MaxRunning: cfg.MaxRunning,
+		MaxKept:    cfg.MaxKept,
+		Timeout:    cfg.TimeoutDuration(),
+	}, m.logger.With().Str("component", "jobs").Logger())
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("failed to open the job queue, tool calls cannot run in the background")
+		return
+	}
+	m.jobs = q
+}
+
+// runsInBackground reports whether a tool call is to be run as a background job: if it was submitted with
+// jobs_submit, or if the tool declares the background argument and the client set it.
+func (m *MoLingServer) runsInBackground(ctx context.Context, request mcp.CallToolRequest) bool {
+	if background, _ := ctx.Value(backgroundKey{}).(bool); background {
+		return true
+	}
+	if background, _ := request.GetArguments()[BackgroundArgument].(bool); !background {
+		return false
+	}
+	_, declared := m.tools[request.Params.Name].InputSchema.Properties[BackgroundArgument]
+	return declared
+}
+
+// jobsTool runs the tool calls meant for the background as jobs, and answers them with the job at once. Dry runs
+// are quick and always answered in the foreground.
+func (m *MoLingServer) jobsTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.jobs == nil || !m.runsInBackground(ctx, request) || abstract.IsDryRun(ctx, request) {
+			return next(ctx, request)
+		}
+		args := make(map[string]any, len(request.GetArguments()))
+		for k, v := range request.GetArguments() {
+			if k != BackgroundArgument {
+				args[k] = v
+			}
+		}
+		request.Params.Arguments = args
+		job := jobs.Job{Tool: request.Params.Name, Service: string(m.toolServices[request.Params.Name])}
+		if call, ok := ToolCallFromContext(ctx); ok {
+			job.Session = call.Session
+			if call.Principal != nil {
+				job.Owner = call.Principal.Name
+			}
+		}
+		job, err := m.jobs.Submit(ctx, job, func(ctx context.Context) (json.RawMessage, error) {
+			result, err := next(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode the result: %w", err)
+			}
+			if result.IsError {
+				return data, errors.New(resultText(result))
+			}
+			return data, nil
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to start the job: %s", err.Error())), nil
+		}
+		m.logger.Info().Str("job", job.ID).Str("tool", job.Tool).Str("owner", job.Owner).Msg("job submitted")
+		return abstract.NewJSONResult(map[string]any{"job": job, "next": fmt.Sprintf("poll %s or %s with the id of the job", JobsStatusToolName, JobsResultToolName)}), nil
+	}
+}
+
+// resultText returns the text of a tool result, as the error of a failed job.
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return "the tool reported an error"
+}
+
+// ownJob returns a job if the client of ctx may see it: restricted clients only see the jobs they submitted.
+func (m *MoLingServer) ownJob(ctx context.Context, id string) (jobs.Job, bool) {
+	if m.jobs == nil {
+		return jobs.Job{}, false
+	}
+	job, ok := m.jobs.Get(id)
+	if !ok {
+		return jobs.Job{}, false
+	}
+	if p, restricted := m.principal(ctx); restricted && job.Owner != p.Name {
+		return jobs.Job{}, false
+	}
+	return job, true
+}
+
+// addJobTools registers the jobs_* tools, which belong to the Jobs service, which roles can deny.
+func (m *MoLingServer) addJobTools() {
+	for _, name := range []string{JobsSubmitToolName, JobsListToolName, JobsStatusToolName, JobsResultToolName, JobsCancelToolName} {
+		m.toolServices[name] = JobsServiceName
+	}
+	m.server.AddTool(mcp.NewTool(
+		JobsSubmitToolName,
+		mcp.WithDescription("Run a call of any tool as a background job and return the job at once. Use it for calls that take long, such as downloads or long commands, then poll jobs_status and read the outcome with jobs_result."),
+		mcp.WithString("tool", mcp.Description("The name of the tool to call"), mcp.Required()),
+		mcp.WithObject("arguments", mcp.Description("The arguments of the tool call")),
+	), m.handleJobsSubmit)
+	m.server.AddTool(mcp.NewTool(
+		JobsListToolName,
+		mcp.WithDescription("List the background jobs, the newest first, with their tool and status."),
+		mcp.WithString("status", mcp.Description("Only list the jobs in this state"),
+			mcp.Enum(string(jobs.StatusQueued), string(jobs.StatusRunning), string(jobs.StatusSucceeded), string(jobs.StatusFailed), string(jobs.StatusCancelled), string(jobs.StatusInterrupted))),
+	), m.handleJobsList)
+	m.server.AddTool(mcp.NewTool(
+		JobsStatusToolName,
+		mcp.WithDescription("Show the status of a background job: queued, running, succeeded, failed, cancelled, or interrupted if MoLing stopped while it ran."),
+		mcp.WithString("id", mcp.Description("The id of the job"), mcp.Required()),
+	), m.handleJobsStatus)
+	m.server.AddTool(mcp.NewTool(
+		JobsResultToolName,
+		mcp.WithDescription("Return the result of a finished background job, as the tool returned it."),
+		mcp.WithString("id", mcp.Description("The id of the job"), mcp.Required()),
+	), m.handleJobsResult)
+	m.server.AddTool(mcp.NewTool(
+		JobsCancelToolName,
+		mcp.WithDescription("Cancel a queued or running background job."),
+		mcp.WithString("id", mcp.Description("The id of the job"), mcp.Required()),
+	), m.handleJobsCancel)
+}
+
+func (m *MoLingServer) handleJobsSubmit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if m.jobs == nil {
+		return abstract.NewErrorResult(abstract.CodeUnavailable, "background jobs are not available, see the log of the MoLing server"), nil
+	}
+	tool := request.GetString("tool", "")
+	handler, ok := m.handlers[tool]
+	if !ok {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "tool %s not found", tool), nil
+	}
+	args, _ := request.GetArguments()["arguments"].(map[string]any)
+	call := mcp.CallToolRequest{}
+	call.Params.Name = tool
+	call.Params.Arguments = args
+	// The call runs through the middleware chain as its own, so that it is authorized and throttled as such
+	return m.handleTool(handler)(context.WithValue(ctx, backgroundKey{}, true), call)
+}
+
+func (m *MoLingServer) handleJobsList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	list := make([]jobs.Job, 0)
+	if m.jobs != nil {
+		status := jobs.Status(request.GetString("status", ""))
+		p, restricted := m.principal(ctx)
+		for _, job := range m.jobs.List() {
+			if (status == "" || job.Status == status) && (!restricted || job.Owner == p.Name) {
+				list = append(list, job)
+			}
+		}
+	}
+	return abstract.NewJSONResult(map[string]any{"jobs": list}), nil
+}
+
+func (m *MoLingServer) handleJobsStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, ok := m.ownJob(ctx, request.GetString("id", ""))
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeNotFound, jobs.ErrNotFound.Error()), nil
+	}
+	job.Result = nil
+	return abstract.NewJSONResult(job), nil
+}
+
+func (m *MoLingServer) handleJobsResult(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, ok := m.ownJob(ctx, request.GetString("id", ""))
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeNotFound, jobs.ErrNotFound.Error()), nil
+	}
+	if !job.Status.Done() {
+		return abstract.NewErrorResultf(abstract.CodeUnavailable, "the job is %s, try again once it has finished", job.Status), nil
+	}
+	if len(job.Result) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("the job %s without a result: %s", job.Status, job.Error)), nil
+	}
+	result, err := mcp.ParseCallToolResult(&job.Result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read the result of the job: %s", err.Error())), nil
+	}
+	return result, nil
+}
+
+func (m *MoLingServer) handleJobsCancel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, ok := m.ownJob(ctx, request.GetString("id", ""))
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeNotFound, jobs.ErrNotFound.Error()), nil
+	}
+	job, err := m.jobs.Cancel(job.ID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to cancel the job: %s, it is %s", err.Error(), job.Status)), nil
+	}
+	m.logger.Info().Str("job", job.ID).Str("tool", job.Tool).Msg("job cancelled")
+	return mcp.NewToolResultText(fmt.Sprintf("job %s cancelled", job.ID)), nil
+}
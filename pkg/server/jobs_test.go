@@ -0,0 +1,111 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/jobs"
+)
+
+func TestJobsTool(t *testing.T) {
+	ms := &MoLingServer{
+		logger:       zerolog.Nop(),
+		mlConfig:     config.MoLingConfig{BasePath: t.TempDir(), Jobs: config.NewJobsConfig()},
+		toolServices: map[string]comm.MoLingServerType{"media_download": "Media", "read_file": "FileSystem"},
+		tools: map[string]mcp.Tool{
+			"media_download": mcp.NewTool("media_download", mcp.WithString("url"), mcp.WithBoolean(BackgroundArgument)),
+			"read_file":      mcp.NewTool("read_file", mcp.WithString("path")),
+		},
+	}
+	ms.openJobs()
+	if ms.jobs == nil {
+		t.Fatal("the job queue must open in the base path")
+	}
+
+	var got map[string]any
+	handler := ms.jobsTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		got = request.GetArguments()
+		return mcp.NewToolResultText("downloaded"), nil
+	})
+	alice := &Principal{Name: "alice"}
+	ctx := context.WithValue(context.Background(), principalKey{}, alice)
+	ctx = context.WithValue(ctx, toolCallKey{}, &ToolCall{Tool: "media_download", Principal: alice})
+	call := func(tool string, background bool) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		req.Params.Arguments = map[string]any{"url": "https://example.com", BackgroundArgument: background}
+		res, err := handler(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return res
+	}
+
+	// Tools that do not declare the background argument, and calls without it, run in the foreground
+	if res := call("read_file", true); res.Content[0].(mcp.TextContent).Text != "downloaded" {
+		t.Fatal("read_file must run in the foreground")
+	}
+	if res := call("media_download", false); res.Content[0].(mcp.TextContent).Text != "downloaded" {
+		t.Fatal("media_download without background must run in the foreground")
+	}
+
+	var submitted struct {
+		Job jobs.Job `json:"job"`
+	}
+	res := call("media_download", true)
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &submitted); err != nil || submitted.Job.ID == "" {
+		t.Fatalf("expected the submitted job, got %v", res.Content)
+	}
+	if submitted.Job.Owner != "alice" || submitted.Job.Service != "Media" {
+		t.Fatalf("unexpected job %+v", submitted.Job)
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"id": submitted.Job.ID}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, _ = ms.handleJobsResult(ctx, req)
+		if !res.IsError || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.IsError || res.Content[0].(mcp.TextContent).Text != "downloaded" {
+		t.Fatalf("expected the result of the tool, got %v", res.Content)
+	}
+	if _, ok := got[BackgroundArgument]; ok {
+		t.Fatal("the background argument must not reach the tool")
+	}
+
+	// Other clients do not see the job
+	bob := context.WithValue(context.Background(), principalKey{}, &Principal{Name: "bob"})
+	if res, _ = ms.handleJobsStatus(bob, req); !res.IsError {
+		t.Fatal("bob must not see the job of alice")
+	}
+	res, _ = ms.handleJobsList(bob, mcp.CallToolRequest{})
+	if text := res.Content[0].(mcp.TextContent).Text; text != "{\n  \"jobs\": []\n}" {
+		t.Fatalf("bob must not list the job of alice, got %s", text)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// maxBuckets is the number of rate limit buckets above which the full ones are dropped.
+const maxBuckets = 1024
+
+type bucketKey struct {
+	session string
+	tool    string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter enforces the rate limits of tools per client session and the limit of tool calls in flight.
+type limiter struct {
+	config   config.LimitConfig
+	inFlight chan struct{} // inFlight holds a token per running tool call, nil without a limit.
+	now      func() time.Time
+
+	lock    sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+func newLimiter(cfg config.LimitConfig) *limiter {
+	l := &limiter{
+		config:  cfg,
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+	if cfg.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+// allow takes a token from the bucket of a session and tool. Without a token it returns an error that tells when
+// the next one is available.
+func (l *limiter) allow(session, tool string) error {
+	limit := l.config.Limit(tool)
+	if limit.QPS <= 0 {
+		return nil
+	}
+	now := l.now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	key := bucketKey{session: session, tool: tool}
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.dropFull(now)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.QPS)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.QPS * float64(time.Second))
+		return fmt.Errorf("rate limit exceeded - %s may be called %g times per second with bursts of %d, retry in %s",
+			tool, limit.QPS, limit.Burst, wait.Round(time.Millisecond))
+	}
+	b.tokens--
+	return nil
+}
+
+// dropFull removes the buckets that have refilled, since they behave like new ones.
+func (l *limiter) dropFull(now time.Time) {
+	for key, b := range l.buckets {
+		limit := l.config.Limit(key.tool)
+		if b.tokens+now.Sub(b.last).Seconds()*limit.QPS >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// enter waits up to the queue timeout for a free slot among the tool calls in flight. The returned function
+// frees the slot.
+func (l *limiter) enter(ctx context.Context) (func(), error) {
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.inFlight }
+	select {
+	case l.inFlight <- struct{}{}:
+		return release, nil
+	default:
+	}
+	timer := time.NewTimer(time.Duration(l.config.QueueTimeout) * time.Second)
+	defer timer.Stop()
+	select {
+	case l.inFlight <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("server busy - %d tool calls are already running, try again later", l.config.MaxInFlight)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
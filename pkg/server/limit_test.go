@@ -0,0 +1,87 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+func TestLimiter(t *testing.T) {
+	cfg := config.NewLimitConfig()
+	l := newLimiter(cfg)
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		if err := l.allow("s1", "browser_navigate"); err != nil {
+			t.Fatalf("call %d must be allowed: %s", i, err.Error())
+		}
+	}
+	err := l.allow("s1", "browser_navigate")
+	if err == nil || !strings.Contains(err.Error(), "retry in 500ms") {
+		t.Fatalf("expected a throttling error, got %v", err)
+	}
+	if err = l.allow("s2", "browser_navigate"); err != nil {
+		t.Fatalf("other sessions must not be throttled: %s", err.Error())
+	}
+	if err = l.allow("s1", "read_file"); err != nil {
+		t.Fatalf("other tools must not be throttled: %s", err.Error())
+	}
+	now = now.Add(500 * time.Millisecond)
+	if err = l.allow("s1", "browser_navigate"); err != nil {
+		t.Fatalf("the bucket must refill: %s", err.Error())
+	}
+}
+
+func TestLimitTool(t *testing.T) {
+	cfg := config.LimitConfig{MaxInFlight: 1, QueueTimeout: 0}
+	ms := &MoLingServer{logger: zerolog.Nop(), limits: newLimiter(cfg)}
+	started, done := make(chan struct{}), make(chan struct{})
+	handler := ms.limitTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "slow" {
+			close(started)
+			<-done
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "slow"
+	go handler(context.Background(), req)
+	<-started
+
+	req.Params.Name = "fast"
+	res, _ := handler(context.Background(), req)
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "server busy") {
+		t.Fatalf("expected a busy error, got %+v", res.Content)
+	}
+	close(done)
+	for i := 0; i < 100; i++ {
+		if res, _ = handler(context.Background(), req); !res.IsError {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the slot must be freed after the slow call")
+}
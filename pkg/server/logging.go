@@ -0,0 +1,117 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// LoggingMessageNotification is the method of the log messages sent to clients.
+const LoggingMessageNotification = "notifications/message"
+
+// serverLogName is the logger of the log messages of the server itself, the services use their names.
+const serverLogName = "MoLing"
+
+// loggingSeverity orders the levels of log messages, the least severe first.
+var loggingSeverity = map[mcp.LoggingLevel]int{
+	mcp.LoggingLevelDebug:     0,
+	mcp.LoggingLevelInfo:      1,
+	mcp.LoggingLevelNotice:    2,
+	mcp.LoggingLevelWarning:   3,
+	mcp.LoggingLevelError:     4,
+	mcp.LoggingLevelCritical:  5,
+	mcp.LoggingLevelAlert:     6,
+	mcp.LoggingLevelEmergency: 7,
+}
+
+// clientLog keeps the connected sessions, to send them the log messages of the level they set with
+// logging/setLevel or a more severe one. mcp-go answers logging/setLevel but does not filter log messages.
+type clientLog struct {
+	lock     sync.Mutex
+	sessions map[string]server.SessionWithLogging
+}
+
+func newClientLog() *clientLog {
+	return &clientLog{sessions: make(map[string]server.SessionWithLogging)}
+}
+
+// register is a hook that keeps the sessions that can receive log messages.
+func (c *clientLog) register(_ context.Context, session server.ClientSession) {
+	if s, ok := session.(server.SessionWithLogging); ok {
+		c.lock.Lock()
+		c.sessions[session.SessionID()] = s
+		c.lock.Unlock()
+	}
+}
+
+// unregister is a hook that drops the sessions that ended.
+func (c *clientLog) unregister(_ context.Context, session server.ClientSession) {
+	c.lock.Lock()
+	delete(c.sessions, session.SessionID())
+	c.lock.Unlock()
+}
+
+// list returns the IDs of the connected sessions and the levels of their log messages.
+func (c *clientLog) list() ([]string, []mcp.LoggingLevel) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ids := make([]string, 0, len(c.sessions))
+	levels := make([]mcp.LoggingLevel, 0, len(c.sessions))
+	for id, s := range c.sessions {
+		ids = append(ids, id)
+		levels = append(levels, s.GetLogLevel())
+	}
+	return ids, levels
+}
+
+// recipients returns the IDs of the initialized sessions that want log messages of the level.
+func (c *clientLog) recipients(level mcp.LoggingLevel) []string {
+	severity, ok := loggingSeverity[level]
+	if !ok {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var ids []string
+	for id, s := range c.sessions {
+		if s.Initialized() && severity >= loggingSeverity[s.GetLogLevel()] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// logToClients sends a log message to the connected clients that set its level or a less severe one. Clients that
+// did not set a level get errors and more severe messages only.
+func (m *MoLingServer) logToClients(level mcp.LoggingLevel, logger string, data any) {
+	if m.clientLog == nil || m.server == nil {
+		return
+	}
+	params := map[string]any{"level": level, "data": data}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	for _, id := range m.clientLog.recipients(level) {
+		if err := m.server.SendNotificationToSpecificClient(id, LoggingMessageNotification, params); err != nil {
+			m.logger.Debug().Err(err).Str("session", id).Msg("failed to send the log message to the client")
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+)
+
+// fakeLoggingSession is a client session that can set the level of its log messages.
+type fakeLoggingSession struct {
+	fakeSession
+	level         mcp.LoggingLevel
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *fakeLoggingSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *fakeLoggingSession) SetLogLevel(level mcp.LoggingLevel) { s.level = level }
+func (s *fakeLoggingSession) GetLogLevel() mcp.LoggingLevel      { return s.level }
+
+func TestLogToClients(t *testing.T) {
+	m := &MoLingServer{clientLog: newClientLog(), logger: zerolog.Nop()}
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(m.clientLog.register)
+	hooks.AddOnUnregisterSession(m.clientLog.unregister)
+	m.server = server.NewMCPServer("test", "v1", server.WithLogging(), server.WithHooks(hooks))
+
+	verbose := &fakeLoggingSession{fakeSession: fakeSession{id: "verbose"}, level: mcp.LoggingLevelInfo, notifications: make(chan mcp.JSONRPCNotification, 4)}
+	quiet := &fakeLoggingSession{fakeSession: fakeSession{id: "quiet"}, level: mcp.LoggingLevelError, notifications: make(chan mcp.JSONRPCNotification, 4)}
+	for _, s := range []*fakeLoggingSession{verbose, quiet} {
+		if err := m.server.RegisterSession(context.Background(), s); err != nil {
+			t.Fatalf("failed to register session %s: %v", s.id, err)
+		}
+	}
+
+	m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "tool call denied"})
+	select {
+	case n := <-verbose.notifications:
+		if n.Method != LoggingMessageNotification || n.Params.AdditionalFields["level"] != mcp.LoggingLevelWarning ||
+			n.Params.AdditionalFields["logger"] != serverLogName {
+			t.Fatalf("unexpected notification %+v", n)
+		}
+	default:
+		t.Fatal("a session at info level must get warnings")
+	}
+	if len(quiet.notifications) != 0 {
+		t.Fatal("a session at error level must not get warnings")
+	}
+
+	m.logToClients(mcp.LoggingLevelCritical, "Browser", "the browser page crashed")
+	if len(verbose.notifications) != 1 || len(quiet.notifications) != 1 {
+		t.Fatalf("both sessions must get critical messages, got %d and %d", len(verbose.notifications), len(quiet.notifications))
+	}
+
+	m.server.UnregisterSession(context.Background(), "verbose")
+	m.logToClients(mcp.LoggingLevelError, serverLogName, "error")
+	if len(verbose.notifications) != 1 || len(quiet.notifications) != 2 {
+		t.Fatal("only the sessions still connected must get messages")
+	}
+}
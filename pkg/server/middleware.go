@@ -0,0 +1,218 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// ToolMiddleware wraps the handling of tool calls. It may inspect or change the request, answer the call itself
+// without calling next, or post-process the result of next.
+type ToolMiddleware func(next server.ToolHandlerFunc) server.ToolHandlerFunc
+
+// ToolCall describes the tool call being handled, for middleware and tool handlers.
+type ToolCall struct {
+	Service   comm.MoLingServerType // Service provides the tool, empty for unknown tools.
+	Tool      string
+	Session   string     // Session is the ID of the client session.
+	Principal *Principal // Principal is the client, nil if it may use all tools.
+	Started   time.Time
+}
+
+type toolCallKey struct{}
+
+// ToolCallFromContext returns the tool call being handled. It reports false outside of tool calls.
+func ToolCallFromContext(ctx context.Context) (*ToolCall, bool) {
+	call, ok := ctx.Value(toolCallKey{}).(*ToolCall)
+	return call, ok
+}
+
+type namedMiddleware struct {
+	name string
+	wrap ToolMiddleware
+}
+
+// Use adds a middleware to the tool call chain. Middleware runs in the order it is added, after the built-in
+// logging, errors, audit, spill, auth, policy, toggle, validate, dryrun, cache, budget, jobs, ratelimit and elicit
+// middleware, and before the session middleware that dispatches calls to the service instance of the client session.
+// Middleware added while serving applies to the next calls.
+func (m *MoLingServer) Use(name string, mw ToolMiddleware) {
+	m.chainLock.Lock()
+	defer m.chainLock.Unlock()
+	chain := make([]namedMiddleware, 0, len(m.chain)+1)
+	chain = append(chain, m.chain[:len(m.chain)-1]...)
+	chain = append(chain, namedMiddleware{name: name, wrap: mw}, m.chain[len(m.chain)-1])
+	m.chain = chain
+}
+
+// Middlewares returns the names of the middleware in the tool call chain, outermost first.
+func (m *MoLingServer) Middlewares() []string {
+	m.chainLock.RLock()
+	defer m.chainLock.RUnlock()
+	names := make([]string, 0, len(m.chain))
+	for _, mw := range m.chain {
+		names = append(names, mw.name)
+	}
+	return names
+}
+
+// builtinMiddlewares returns the middleware every tool call passes through. The session middleware must stay last.
+func (m *MoLingServer) builtinMiddlewares() []namedMiddleware {
+	return []namedMiddleware{
+		{name: "logging", wrap: m.logTool},
+		{name: "errors", wrap: m.errorCodeTool},
+		{name: "audit", wrap: m.auditTool},
+		{name: "spill", wrap: m.spillTool},
+		{name: "auth", wrap: m.authorizeTool},
+		{name: "policy", wrap: m.policyTool},
+		{name: "toggle", wrap: m.toggleTool},
+		{name: "validate", wrap: m.validateTool},
+		{name: "dryrun", wrap: m.dryRunTool},
+		{name: "cache", wrap: m.cacheTool},
+		{name: "budget", wrap: m.budgetTool},
+		{name: "jobs", wrap: m.jobsTool},
+		{name: "ratelimit", wrap: m.limitTool},
+		{name: "elicit", wrap: m.elicitTool},
+		{name: "session", wrap: m.isolateTool},
+	}
+}
+
+// handleTool runs tool calls through the middleware chain. It is the only middleware registered with the MCP server.
+func (m *MoLingServer) handleTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !m.drain.enter() {
+			return abstract.NewErrorResult(abstract.CodeUnavailable, m.locale.Sprintf("server.shutting_down")), nil
+		}
+		defer m.drain.leave()
+		ctx, done := m.trackTool(ctx, request)
+		defer done()
+		call := &ToolCall{
+			Service: m.toolServices[request.Params.Name],
+			Tool:    request.Params.Name,
+			Session: sessionID(ctx),
+			Started: time.Now(),
+		}
+		call.Principal, _ = m.principal(ctx)
+		m.chainLock.RLock()
+		chain := m.chain
+		m.chainLock.RUnlock()
+		handler := next
+		for i := len(chain) - 1; i >= 0; i-- {
+			handler = chain[i].wrap(handler)
+		}
+		return handler(context.WithValue(ctx, toolCallKey{}, call), request)
+	}
+}
+
+// logTool logs the outcome and duration of tool calls, and adds them to the recent calls and the statistics.
+func (m *MoLingServer) logTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		isError := err != nil || (result != nil && result.IsError)
+		event := m.logger.Info()
+		if call, ok := ToolCallFromContext(ctx); ok {
+			recent := RecentCall{Tool: call.Tool, Service: call.Service, Session: call.Session, Started: call.Started,
+				Duration: time.Since(call.Started).Round(time.Microsecond).String(), IsError: isError}
+			event = event.Str("service", string(call.Service)).Str("session", call.Session).Dur("duration", time.Since(call.Started))
+			if call.Principal != nil {
+				event = event.Str("principal", call.Principal.Name)
+				recent.Principal = call.Principal.Name
+			}
+			m.calls.add(recent)
+			m.recordStats(call, request, isError)
+		}
+		event.Str("tool", request.Params.Name).Bool("isError", isError).Err(err).Msg("tool call")
+		return result, err
+	}
+}
+
+// authorizeTool rejects tool calls that the authenticated client is not allowed to make.
+func (m *MoLingServer) authorizeTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		service := m.toolServices[request.Params.Name]
+		if p, ok := m.principal(ctx); ok && (!p.Allowed(service, request.Params.Name) || !m.profileAllows(p, service)) {
+			m.logger.Warn().Str("principal", p.Name).Str("tool", request.Params.Name).Msg("tool call denied")
+			m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "tool call denied", "principal": p.Name, "tool": request.Params.Name})
+			return abstract.NewErrorResult(abstract.CodePolicyDenied, m.locale.Sprintf("server.access_denied", p.Name, request.Params.Name)), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// limitTool rejects tool calls above the rate limit of the tool and waits for a free slot among the calls in flight.
+func (m *MoLingServer) limitTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.limits == nil {
+			return next(ctx, request)
+		}
+		var id string
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			id = session.SessionID()
+		}
+		if err := m.limits.allow(id, request.Params.Name); err != nil {
+			m.logger.Warn().Str("session", id).Str("tool", request.Params.Name).Msg("tool call throttled")
+			m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "tool call throttled", "tool": request.Params.Name, "error": err.Error()})
+			return abstract.NewErrorResult(abstract.CodeUnavailable, err.Error()), nil
+		}
+		release, err := m.limits.enter(ctx)
+		if err != nil {
+			m.logger.Warn().Str("session", id).Str("tool", request.Params.Name).Err(err).Msg("tool call rejected")
+			m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "tool call rejected", "tool": request.Params.Name, "error": err.Error()})
+			return abstract.NewErrorResult(abstract.CodeUnavailable, err.Error()), nil
+		}
+		defer release()
+		return next(ctx, request)
+	}
+}
+
+// isolateTool runs the tool calls of isolated services on the instance of the calling client session.
+func (m *MoLingServer) isolateTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := server.ClientSessionFromContext(ctx)
+		if m.sessions == nil || session == nil {
+			return next(ctx, request)
+		}
+		var profile string
+		if p, ok := PrincipalFromContext(ctx); ok {
+			profile = p.Profile
+		}
+		srv, release, err := m.sessions.acquire(session.SessionID(), profile, m.toolServices[request.Params.Name])
+		if err != nil {
+			return abstract.NewErrorResult(abstract.CodeUnavailable, err.Error()), nil
+		}
+		if srv == nil {
+			return next(ctx, request)
+		}
+		defer release()
+		name := request.Params.Name
+		request.Params.Name = m.originalName(name)
+		for _, tool := range srv.Tools() {
+			if tool.Tool.Name == request.Params.Name {
+				return tool.Handler(ctx, request)
+			}
+		}
+		request.Params.Name = name
+		return abstract.NewErrorResult(abstract.CodeNotFound, m.locale.Sprintf("server.tool_not_found", request.Params.Name)), nil
+	}
+}
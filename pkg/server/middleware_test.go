@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	ms := &MoLingServer{
+		logger:       zerolog.Nop(),
+		toolServices: map[string]comm.MoLingServerType{"read_file": "FileSystem", "write_file": "FileSystem"},
+	}
+	ms.chain = ms.builtinMiddlewares()
+	var order []string
+	trace := func(name string) ToolMiddleware {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				call, ok := ToolCallFromContext(ctx)
+				if !ok || call.Service != "FileSystem" || call.Tool != request.Params.Name {
+					t.Fatalf("%s: unexpected tool call %+v", name, call)
+				}
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+	ms.Use("first", trace("first"))
+	ms.Use("second", trace("second"))
+	ms.Use("readonly", func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if request.Params.Name == "write_file" {
+				return mcp.NewToolResultError("read-only"), nil
+			}
+			return next(ctx, request)
+		}
+	})
+	want := []string{"logging", "errors", "audit", "spill", "auth", "policy", "toggle", "validate", "dryrun", "cache", "budget", "jobs", "ratelimit", "elicit", "first", "second", "readonly", "session"}
+	if names := ms.Middlewares(); !slices.Equal(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+
+	handler := ms.handleTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		order = append(order, "tool")
+		return mcp.NewToolResultText("ok"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "read_file"
+	res, _ := handler(context.Background(), req)
+	if res.IsError || !slices.Equal(order, []string{"first", "second", "tool"}) {
+		t.Fatalf("unexpected call order %v", order)
+	}
+	order = nil
+	req.Params.Name = "write_file"
+	res, _ = handler(context.Background(), req)
+	if !res.IsError || slices.Contains(order, "tool") {
+		t.Fatalf("write_file must be answered by the readonly middleware, order %v", order)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/policy"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// policyTool applies the policy rules to tool calls: denied calls are answered with the reason, and calls that need
+// approval only run once the user approved them. Dry runs change nothing and need no approval.
+func (m *MoLingServer) policyTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.policy == nil {
+			return next(ctx, request)
+		}
+		in := policy.Input{
+			Tool:    request.Params.Name,
+			Service: string(m.toolServices[request.Params.Name]),
+			Args:    request.GetArguments(),
+			Session: sessionID(ctx),
+			Time:    time.Now(),
+		}
+		if p, ok := m.principal(ctx); ok {
+			in.Principal, in.Roles, in.Profile = p.Name, p.Roles, p.Profile
+		}
+		d := m.policy.Evaluate(in)
+		switch d.Effect {
+		case policy.Deny:
+			m.logger.Warn().Str("tool", in.Tool).Str("principal", in.Principal).Str("rule", d.Rule).Msg("tool call denied by the policy")
+			m.logToClients(mcp.LoggingLevelWarning, serverLogName, map[string]any{"message": "tool call denied by the policy", "tool": in.Tool, "rule": d.Rule})
+			return abstract.NewErrorResult(abstract.CodePolicyDenied, m.locale.Sprintf("server.policy_denied", d.Reason())), nil
+		case policy.Approve:
+			if m.mlConfig.DryRun || abstract.IsDryRun(ctx, request) {
+				break
+			}
+			if err := m.approve(ctx, in, d); err != nil {
+				m.logger.Warn().Str("tool", in.Tool).Str("principal", in.Principal).Str("rule", d.Rule).Err(err).Msg("tool call not approved")
+				return abstract.NewErrorResult(abstract.CodePolicyDenied, err.Error()), nil
+			}
+			m.logger.Info().Str("tool", in.Tool).Str("principal", in.Principal).Str("rule", d.Rule).Msg("tool call approved by the user")
+		}
+		return next(ctx, request)
+	}
+}
+
+// approve asks the user of the client to approve a tool call. It returns an error if the user cannot be asked or
+// does not approve the call.
+func (m *MoLingServer) approve(ctx context.Context, in policy.Input, d policy.Decision) error {
+	if m.clients == nil || !m.mlConfig.Elicitation.Enabled || !m.clients.ClientSupports(ctx, abstract.ElicitationCapability) {
+		return errors.New(m.locale.Sprintf("server.approval_unavailable", d.Reason()))
+	}
+	args, err := json.Marshal(in.Args)
+	if err != nil {
+		args = []byte("{}")
+	}
+	message := m.locale.Sprintf("server.approval_question", in.Tool, args)
+	if d.Message != "" {
+		message = fmt.Sprintf("%s (%s)", message, d.Message)
+	}
+	result, err := abstract.ElicitClient(ctx, m.clients, m.mlConfig.Elicitation.Timeout, message, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"approve": map[string]any{"type": "boolean", "title": "Approve", "description": message},
+		},
+		"required": []string{"approve"},
+	})
+	if err != nil {
+		return errors.New(m.locale.Sprintf("server.approval_failed", err))
+	}
+	if approved, _ := result.Content["approve"].(bool); !result.Accepted() || !approved {
+		return errors.New(m.locale.Sprintf("server.not_approved", d.Reason()))
+	}
+	return nil
+}
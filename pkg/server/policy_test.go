@@ -0,0 +1,93 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/i18n"
+	"github.com/gojue/moling/pkg/policy"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestPolicyTool(t *testing.T) {
+	cfg := config.PolicyConfig{Rules: []policy.Rule{
+		{Name: "no-etc", When: `startsWith(args.path, "/etc/")`, Effect: policy.Deny, Message: "system files are off limits"},
+		{Name: "operators", When: `service == "Command" && "operator" in roles`, Effect: policy.Allow},
+		{Name: "commands", When: `service == "Command"`, Effect: policy.Approve},
+	}}
+	engine, err := cfg.Engine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := &MoLingServer{logger: zerolog.Nop(), clientLog: newClientLog(), policy: engine, toolServices: map[string]comm.MoLingServerType{
+		"write_file":      "FileSystem",
+		"execute_command": "Command",
+	}}
+	ran := 0
+	handler := ms.policyTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ran++
+		return mcp.NewToolResultText("done"), nil
+	})
+	call := func(ctx context.Context, tool string, args map[string]any) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		req.Params.Arguments = args
+		res, err := handler(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return res
+	}
+
+	if res := call(context.Background(), "write_file", map[string]any{"path": "notes.txt"}); res.IsError || ran != 1 {
+		t.Fatal("calls that no rule matches must run")
+	}
+	res := call(context.Background(), "write_file", map[string]any{"path": "/etc/hosts"})
+	if !res.IsError || ran != 1 || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "system files are off limits") {
+		t.Fatalf("the call must be denied with the message of the rule, got %+v", res)
+	}
+	res = call(context.Background(), "execute_command", map[string]any{"command": "ls"})
+	if !res.IsError || ran != 1 || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "approval") {
+		t.Fatalf("a call that needs approval must fail if the user cannot be asked, got %+v", res)
+	}
+	if code := abstract.ErrorCodeOf(res); code != abstract.CodePolicyDenied {
+		t.Fatalf("a call that is not approved must be coded %s, got %s", abstract.CodePolicyDenied, code)
+	}
+	if ms.locale, err = i18n.Load("zh-CN", ""); err != nil {
+		t.Fatal(err)
+	}
+	res = call(context.Background(), "execute_command", map[string]any{"command": "ls"})
+	if code := abstract.ErrorCodeOf(res); !res.IsError || code != abstract.CodePolicyDenied {
+		t.Fatalf("the code must not depend on the language of the message, got %s", code)
+	}
+	ms.locale = nil
+	if res = call(context.Background(), "execute_command", map[string]any{"command": "ls", "dry_run": true}); res.IsError || ran != 2 {
+		t.Fatal("dry runs must not need approval")
+	}
+	operator := context.WithValue(context.Background(), principalKey{}, &Principal{Name: "ci", Roles: []string{"operator"}})
+	if res = call(operator, "execute_command", map[string]any{"command": "ls"}); res.IsError || ran != 3 {
+		t.Fatal("the roles of the principal must be seen by the rules")
+	}
+}
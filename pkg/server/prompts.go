@@ -0,0 +1,282 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PromptsDir is the directory of the user prompt library, relative to the base path. Running servers pick up
+	// added, changed and removed prompts.
+	PromptsDir = "prompts"
+	// promptPollInterval is how often running servers check the prompt library for changes.
+	promptPollInterval = 2 * time.Second
+)
+
+var (
+	// validPromptName matches the names of user prompts.
+	validPromptName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+	// promptPlaceholder matches the {{argument}} placeholders of prompt templates.
+	promptPlaceholder = regexp.MustCompile(`{{\s*([A-Za-z0-9_-]+)\s*}}`)
+)
+
+// PromptDefinition is a prompt of the user prompt library. It is read from a JSON file, or from a Markdown file
+// whose YAML front matter holds everything but the messages and whose body is the single user message.
+type PromptDefinition struct {
+	Name        string           `json:"name" yaml:"name"`               // Name defaults to the file name without extension.
+	Description string           `json:"description" yaml:"description"` // Description tells users what the prompt is for.
+	Arguments   []PromptArgument `json:"arguments" yaml:"arguments"`     // Arguments fill the {{name}} placeholders of the messages.
+	Messages    []PromptMessage  `json:"messages" yaml:"-"`              // Messages are the messages of the prompt.
+	File        string           `json:"-" yaml:"-"`                     // File is the file the prompt was read from.
+}
+
+// PromptArgument is an argument of a user prompt.
+type PromptArgument struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Required    bool   `json:"required" yaml:"required"`
+}
+
+// PromptMessage is a message template of a user prompt.
+type PromptMessage struct {
+	Role    string `json:"role"`    // Role is user or assistant, default: user.
+	Content string `json:"content"` // Content is the text of the message with {{name}} placeholders.
+}
+
+// LoadPrompts reads the *.md and *.json prompt definitions in dir. A missing directory has no prompts. Invalid
+// files are skipped and reported in the error, so that one broken prompt does not hide the others.
+func LoadPrompts(dir string) ([]PromptDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var prompts []PromptDefinition
+	var errs []error
+	names := make(map[string]string)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".md" && ext != ".json") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		p, err := readPrompt(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if other, ok := names[p.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: the prompt name %s is already used by %s", file, p.Name, other))
+			continue
+		}
+		names[p.Name] = file
+		prompts = append(prompts, p)
+	}
+	return prompts, errors.Join(errs...)
+}
+
+func readPrompt(file string) (PromptDefinition, error) {
+	var p PromptDefinition
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return p, err
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = parseMarkdownPrompt(data, &p)
+	}
+	if err != nil {
+		return p, fmt.Errorf("%s: %w", file, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	p.File = file
+	return p, p.check()
+}
+
+// parseMarkdownPrompt reads an optional front matter between --- lines followed by the message.
+func parseMarkdownPrompt(data []byte, p *PromptDefinition) error {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	body := string(data)
+	if rest, ok := strings.CutPrefix(strings.ReplaceAll(body, "\r\n", "\n"), "---\n"); ok {
+		front, message, found := strings.Cut(rest, "\n---")
+		if !found {
+			return fmt.Errorf("the front matter is not closed by ---")
+		}
+		if err := yaml.Unmarshal([]byte(front), p); err != nil {
+			return fmt.Errorf("invalid front matter: %w", err)
+		}
+		body = strings.TrimPrefix(message, "\n")
+	}
+	p.Messages = []PromptMessage{{Role: string(mcp.RoleUser), Content: strings.TrimSpace(body)}}
+	return nil
+}
+
+// check validates a prompt definition.
+func (p *PromptDefinition) check() error {
+	if !validPromptName.MatchString(p.Name) {
+		return fmt.Errorf("%s: the name %q must start with a letter and only contain letters, digits, _, . and -", p.File, p.Name)
+	}
+	if len(p.Messages) == 0 {
+		return fmt.Errorf("%s: the prompt has no messages", p.File)
+	}
+	args := make(map[string]bool)
+	for _, a := range p.Arguments {
+		if a.Name == "" || args[a.Name] {
+			return fmt.Errorf("%s: argument names must be unique and not empty", p.File)
+		}
+		args[a.Name] = true
+	}
+	for i, m := range p.Messages {
+		switch m.Role {
+		case "":
+			p.Messages[i].Role = string(mcp.RoleUser)
+		case string(mcp.RoleUser), string(mcp.RoleAssistant):
+		default:
+			return fmt.Errorf("%s: the role %q must be user or assistant", p.File, m.Role)
+		}
+		for _, match := range promptPlaceholder.FindAllStringSubmatch(m.Content, -1) {
+			if !args[match[1]] {
+				return fmt.Errorf("%s: the placeholder {{%s}} is not an argument", p.File, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Prompt returns the MCP definition of the prompt.
+func (p *PromptDefinition) Prompt() mcp.Prompt {
+	opts := []mcp.PromptOption{mcp.WithPromptDescription(p.Description)}
+	for _, a := range p.Arguments {
+		argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(a.Description)}
+		if a.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(a.Name, argOpts...))
+	}
+	return mcp.NewPrompt(p.Name, opts...)
+}
+
+// Render fills the placeholders of the messages with args. Missing optional arguments are left empty.
+func (p *PromptDefinition) Render(args map[string]string) (*mcp.GetPromptResult, error) {
+	for _, a := range p.Arguments {
+		if a.Required && args[a.Name] == "" {
+			return nil, fmt.Errorf("the argument %s of the prompt %s is required", a.Name, p.Name)
+		}
+	}
+	messages := make([]mcp.PromptMessage, 0, len(p.Messages))
+	for _, m := range p.Messages {
+		text := promptPlaceholder.ReplaceAllStringFunc(m.Content, func(s string) string {
+			return args[promptPlaceholder.FindStringSubmatch(s)[1]]
+		})
+		messages = append(messages, mcp.NewPromptMessage(mcp.Role(m.Role), mcp.NewTextContent(text)))
+	}
+	return mcp.NewGetPromptResult(p.Description, messages), nil
+}
+
+// reloadPrompts replaces the user prompts with the prompt library. User prompts cannot replace the prompts of services.
+func (m *MoLingServer) reloadPrompts() {
+	prompts, err := LoadPrompts(filepath.Join(m.mlConfig.BasePath, PromptsDir))
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("some prompts of the prompt library were skipped")
+	}
+	m.promptsLock.Lock()
+	defer m.promptsLock.Unlock()
+	if len(m.userPrompts) > 0 {
+		m.server.DeletePrompts(m.userPrompts...)
+	}
+	m.userPrompts = m.userPrompts[:0]
+	for _, p := range prompts {
+		if m.servicePrompts[p.Name] {
+			m.logger.Warn().Str("prompt", p.Name).Str("file", p.File).Msg("a service provides a prompt with this name, the user prompt was skipped")
+			continue
+		}
+		m.server.AddPrompt(p.Prompt(), userPromptHandler(p))
+		m.userPrompts = append(m.userPrompts, p.Name)
+	}
+	sort.Strings(m.userPrompts)
+	m.logger.Debug().Strs("prompts", m.userPrompts).Msg("prompt library loaded")
+}
+
+func userPromptHandler(p PromptDefinition) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return p.Render(request.Params.Arguments)
+	}
+}
+
+// promptsFingerprint identifies the state of the prompt library by the names, sizes and modification times of its files.
+func promptsFingerprint(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// loadPrompts loads the prompt library, before the server serves, and returns the fingerprint of its files.
+func (m *MoLingServer) loadPrompts() string {
+	fingerprint := promptsFingerprint(filepath.Join(m.mlConfig.BasePath, PromptsDir))
+	m.reloadPrompts()
+	return fingerprint
+}
+
+// watchPrompts reloads the prompt library when its files change from the fingerprint of the loaded ones, until the
+// server shuts down.
+func (m *MoLingServer) watchPrompts(fingerprint string) {
+	dir := filepath.Join(m.mlConfig.BasePath, PromptsDir)
+	ticker := time.NewTicker(promptPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.watchCtx.Done():
+			return
+		case <-ticker.C:
+			if fp := promptsFingerprint(dir); fp != fingerprint {
+				fingerprint = fp
+				m.reloadPrompts()
+				m.logToClients(mcp.LoggingLevelNotice, serverLogName, map[string]any{"message": "prompt library reloaded", "dir": dir})
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func writePrompt(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrompts(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "review.md", "---\ndescription: Review a change\narguments:\n  - name: file\n    required: true\n  - name: focus\n---\nReview {{file}}. Focus on {{ focus }}.\n")
+	writePrompt(t, dir, "plain.md", "Say hello.")
+	writePrompt(t, dir, "chat.json", `{"name":"chat","arguments":[{"name":"topic"}],"messages":[{"content":"Talk about {{topic}}"},{"role":"assistant","content":"Sure."}]}`)
+	writePrompt(t, dir, "broken.md", "Use {{missing}}.")
+	writePrompt(t, dir, "notes.txt", "ignored")
+
+	prompts, err := LoadPrompts(dir)
+	if err == nil || !strings.Contains(err.Error(), "{{missing}}") {
+		t.Fatalf("expected an error for the broken prompt, got %v", err)
+	}
+	if len(prompts) != 3 || prompts[0].Name != "chat" || prompts[1].Name != "plain" || prompts[2].Name != "review" {
+		t.Fatalf("unexpected prompts %+v", prompts)
+	}
+
+	review := prompts[2]
+	if _, err := review.Render(map[string]string{}); err == nil {
+		t.Fatal("expected an error for a missing required argument")
+	}
+	res, err := review.Render(map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Description != "Review a change" || res.Messages[0].Content.(mcp.TextContent).Text != "Review main.go. Focus on ." {
+		t.Fatalf("unexpected rendering %+v", res)
+	}
+	if p := review.Prompt(); len(p.Arguments) != 2 || !p.Arguments[0].Required {
+		t.Fatalf("unexpected prompt definition %+v", p)
+	}
+	res, _ = prompts[0].Render(map[string]string{"topic": "Go"})
+	if len(res.Messages) != 2 || res.Messages[1].Role != mcp.RoleAssistant {
+		t.Fatalf("unexpected messages %+v", res.Messages)
+	}
+
+	if prompts, err := LoadPrompts(filepath.Join(dir, "missing")); err != nil || prompts != nil {
+		t.Fatalf("a missing directory must have no prompts, got %v %v", prompts, err)
+	}
+}
+
+func TestReloadPrompts(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, PromptsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MoLingServer{
+		logger:         zerolog.Nop(),
+		mlConfig:       config.MoLingConfig{BasePath: base},
+		server:         server.NewMCPServer("test", "v1", server.WithPromptCapabilities(true)),
+		servicePrompts: map[string]bool{"filesystem_prompt": true},
+	}
+	listPrompts := func() []string {
+		resp := ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+		var names []string
+		for _, p := range resp.(mcp.JSONRPCResponse).Result.(mcp.ListPromptsResult).Prompts {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	writePrompt(t, dir, "standup.md", "Summarize yesterday.")
+	writePrompt(t, dir, "filesystem_prompt.md", "Replace a service prompt.")
+	ms.reloadPrompts()
+	if names := listPrompts(); len(names) != 1 || names[0] != "standup" {
+		t.Fatalf("unexpected prompts %v", names)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "standup.md")); err != nil {
+		t.Fatal(err)
+	}
+	writePrompt(t, dir, "retro.md", "Plan the retro.")
+	ms.reloadPrompts()
+	if names := listPrompts(); len(names) != 1 || names[0] != "retro" {
+		t.Fatalf("unexpected prompts after a reload %v", names)
+	}
+}
+
+func TestPromptsLoadedBeforeServing(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := t.TempDir()
+	if err = os.MkdirAll(filepath.Join(base, PromptsDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writePrompt(t, filepath.Join(base, PromptsDir), "standup.md", "Summarize yesterday.")
+	ms, err := NewMoLingServer(ctx, []abstract.Service{&namedService{name: "FileSystem"}}, config.MoLingConfig{BasePath: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No wait: the prompt library is loaded by the constructor, not by the watcher
+	resp := ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+	prompts := resp.(mcp.JSONRPCResponse).Result.(mcp.ListPromptsResult).Prompts
+	if len(prompts) != 1 || prompts[0].Name != "standup" {
+		t.Fatalf("the user prompts must be listed once the server is created, got %+v", prompts)
+	}
+	if err = ms.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ms.watchCtx.Err() == nil {
+		t.Fatal("Shutdown must stop the watchers")
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// ClientRoots returns the roots of the client of the session in ctx. They are requested once and kept until the
+// client announces a change or the session ends.
+func (c *clients) ClientRoots(ctx context.Context) ([]mcp.Root, error) {
+	session := sessionID(ctx)
+	c.lock.Lock()
+	roots, ok := c.roots[session]
+	c.lock.Unlock()
+	if ok {
+		return roots, nil
+	}
+	roots, err := abstract.ListClientRoots(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	c.roots[session] = roots
+	c.lock.Unlock()
+	return roots, nil
+}
+
+// handleRootsChanged drops the roots of a client that announced a change, so they are requested again.
+func (c *clients) handleRootsChanged(ctx context.Context, notification mcp.JSONRPCNotification) {
+	c.lock.Lock()
+	delete(c.roots, sessionID(ctx))
+	c.lock.Unlock()
+}
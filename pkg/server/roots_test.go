@@ -0,0 +1,65 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestClientRoots(t *testing.T) {
+	c := newClients()
+	ctx := server.NewMCPServer("test", "v1").WithContext(context.Background(), fakeSession{id: "s1"})
+	requests := 0
+	c.attach("s1", func(data []byte) error {
+		var request struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(data, &request); err != nil || request.Method != "roots/list" {
+			return fmt.Errorf("unexpected request %s", data)
+		}
+		requests++
+		go c.deliver("s1", []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":{"roots":[{"uri":"file:///work/%d"}]}}`, request.ID, requests)))
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		roots, err := c.ClientRoots(ctx)
+		if err != nil || len(roots) != 1 || roots[0].URI != "file:///work/1" {
+			t.Fatalf("unexpected roots %+v %v", roots, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("the roots must be requested once, got %d requests", requests)
+	}
+
+	c.handleRootsChanged(ctx, mcp.JSONRPCNotification{})
+	roots, err := c.ClientRoots(ctx)
+	if err != nil || roots[0].URI != "file:///work/2" {
+		t.Fatalf("the roots must be requested again after a change, got %+v %v", roots, err)
+	}
+	c.forget("s1")
+	if _, ok := c.roots["s1"]; ok {
+		t.Fatal("the roots of an ended session must be dropped")
+	}
+}
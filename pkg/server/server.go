@@ -17,47 +17,145 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rs/zerolog"
 
+	"github.com/gojue/moling/pkg/audit"
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/i18n"
+	"github.com/gojue/moling/pkg/jobs"
+	"github.com/gojue/moling/pkg/policy"
 	"github.com/gojue/moling/pkg/services/abstract"
 )
 
 type MoLingServer struct {
-	ctx        context.Context
-	server     *server.MCPServer
-	services   []abstract.Service
-	logger     zerolog.Logger
-	mlConfig   config.MoLingConfig
-	listenAddr string // SSE mode listen address, if empty, use STDIO mode.
+	ctx            context.Context
+	server         *server.MCPServer
+	services       []abstract.Service
+	failed         map[comm.MoLingServerType]error   // failed are the errors of the services that did not start.
+	toolServices   map[string]comm.MoLingServerType  // toolServices maps tool names to the services that provide them.
+	tools          map[string]mcp.Tool               // tools are the definitions of the loaded tools by name.
+	handlers       map[string]server.ToolHandlerFunc // handlers are the handlers of the loaded tools by name, for jobs_submit.
+	originalNames  map[string]string                 // originalNames are the names services gave their renamed tools, by the names they are served under.
+	exposedNames   map[string]string                 // exposedNames are the names renamed tools are served under, by the names services gave them.
+	clients        *clients                          // clients sends requests, such as elicitations, to connected clients.
+	clientLog      *clientLog                        // clientLog sends log messages to connected clients.
+	calls          callHistory                       // calls are the recent tool calls, for moling_recent_calls.
+	stats          callStats                         // stats are the latency and failures of every tool, for stats_report.
+	cache          *toolCache                        // cache keeps tool results, nil if caching is disabled.
+	jobs           *jobs.Queue                       // jobs runs tool calls in the background, nil if its directory is not usable.
+	audit          *audit.Store                      // audit records the tool calls, nil if the audit trail is disabled.
+	budgets        *budgets                          // budgets holds the resource usage of services, nil if no service has a budget.
+	spillLock      sync.Mutex                        // spillLock serializes removing the oldest saved tool results.
+	sessions       *sessionManager                   // sessions holds the per-session service instances, nil in STDIO mode.
+	anonymous      *Principal                        // anonymous is the principal of unauthenticated clients, nil if no default role is configured.
+	limits         *limiter
+	policy         *policy.Engine  // policy decides which tool calls may run, nil if it allows all of them.
+	locale         *i18n.Localizer // locale holds the texts of the configured language, nil for English.
+	chainLock      sync.RWMutex
+	chain          []namedMiddleware // chain is the tool call middleware, outermost first.
+	started        time.Time
+	inflight       inflight // inflight holds the running tool calls that clients can cancel.
+	drain          drainer  // drain counts the running tool calls for Shutdown.
+	serveLock      sync.Mutex
+	sseServer      *server.SSEServer  // sseServer is the running SSE server, nil in STDIO mode.
+	stopStdio      context.CancelFunc // stopStdio stops the running STDIO server.
+	watchCtx       context.Context    // watchCtx ends the background watchers of the server, Shutdown cancels it.
+	stopWatchers   context.CancelFunc
+	promptsLock    sync.Mutex
+	promptsPrint   string          // promptsPrint is the fingerprint of the prompt library loaded by init.
+	servicePrompts map[string]bool // servicePrompts are the names of the prompts of services, which user prompts cannot replace.
+	userPrompts    []string        // userPrompts are the names of the loaded prompts of the prompt library.
+	togglesLock    sync.RWMutex
+	toggles        Toggles // toggles are the services and tools disabled at runtime.
+	profilesLock   sync.RWMutex
+	profiles       map[string]map[comm.MoLingServerType]bool // profiles are the services each profile enables, nil for all.
+	logger         zerolog.Logger
+	mlConfig       config.MoLingConfig
+	listenAddr     string // SSE mode listen address, if empty, use STDIO mode.
 }
 
 func NewMoLingServer(ctx context.Context, srvs []abstract.Service, mlConfig config.MoLingConfig) (*MoLingServer, error) {
-	mcpServer := server.NewMCPServer(
-		mlConfig.ServerName,
-		mlConfig.Version,
-		server.WithResourceCapabilities(true, true),
-		server.WithLogging(),
-		server.WithPromptCapabilities(true),
-	)
 	// Set the context for the server
 	ms := &MoLingServer{
-		ctx:        ctx,
-		server:     mcpServer,
-		services:   srvs,
-		listenAddr: mlConfig.ListenAddr,
-		logger:     ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger),
-		mlConfig:   mlConfig,
+		ctx:            ctx,
+		services:       srvs,
+		toolServices:   make(map[string]comm.MoLingServerType),
+		tools:          make(map[string]mcp.Tool),
+		handlers:       make(map[string]server.ToolHandlerFunc),
+		originalNames:  make(map[string]string),
+		exposedNames:   make(map[string]string),
+		servicePrompts: make(map[string]bool),
+		clients:        newClients(),
+		clientLog:      newClientLog(),
+		listenAddr:     mlConfig.ListenAddr,
+		logger:         ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger),
+		mlConfig:       mlConfig,
+		anonymous:      rolePrincipal(&mlConfig.Auth),
+		limits:         newLimiter(mlConfig.Limits),
+		started:        time.Now(),
+		stats:          callStats{samples: mlConfig.Stats.Samples},
+	}
+	ms.watchCtx, ms.stopWatchers = context.WithCancel(ctx)
+	if mlConfig.Policy.Enabled() {
+		engine, err := mlConfig.Policy.Engine()
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+		ms.policy = engine
+	}
+	if err := ms.openLocale(); err != nil {
+		return nil, fmt.Errorf("invalid i18n: %w", err)
+	}
+	ms.chain = ms.builtinMiddlewares()
+	ms.cache = newToolCache(mlConfig.Cache, mlConfig.BasePath, ms.logger)
+	ms.openJobs()
+	ms.openAudit()
+	ms.openBudgets()
+	opts := []server.ServerOption{
+		server.WithResourceCapabilities(false, true),
+		server.WithLogging(),
+		server.WithPromptCapabilities(true),
+		server.WithToolHandlerMiddleware(ms.handleTool),
+		server.WithToolFilter(ms.filterTools),
 	}
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(stampRequestID)
+	hooks.AddOnRequestInitialization(ms.clients.recordCapabilities)
+	hooks.AddOnRegisterSession(ms.clientLog.register)
+	hooks.AddOnUnregisterSession(ms.clientLog.unregister)
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		ms.clients.forget(session.SessionID())
+		ms.calls.forget(session.SessionID())
+	})
+	if ms.listenAddr != "" && (len(mlConfig.Sessions.IsolatedServices) > 0 || len(mlConfig.Auth.Profiles()) > 0) {
+		ms.sessions = newSessionManager(ctx, mlConfig.Sessions, ms.logger, ms.connectService)
+		hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+			ms.sessions.end(session.SessionID(), "disconnected")
+		})
+		go ms.sessions.run()
+	}
+	opts = append(opts, server.WithHooks(hooks))
+	ms.server = server.NewMCPServer(mlConfig.ServerName, mlConfig.Version, opts...)
+	ms.server.AddNotificationHandler(CancelledNotification, ms.handleCancelled)
+	ms.server.AddNotificationHandler(abstract.RootsListChangedNotification, ms.clients.handleRootsChanged)
 	err := ms.init()
+	go ms.watchToggles()
+	go ms.watchPrompts(ms.promptsPrint)
+	go ms.watchBudgets()
+	go ms.watchStats()
 	return ms, err
 }
 
@@ -70,6 +168,16 @@ func (m *MoLingServer) init() error {
 			m.logger.Info().Err(err).Str("serviceName", string(srv.Name())).Msg("Failed to load service")
 		}
 	}
+	m.addStatusTool()
+	m.addInfoTool()
+	m.addStatsTool()
+	m.addAdminTools()
+	m.addToggleTools()
+	m.addJobTools()
+	m.addAuditTool()
+	m.addSpillResources()
+	// User prompts are there from the first request, the watcher only reloads them
+	m.promptsPrint = m.loadPrompts()
 	return err
 }
 
@@ -86,7 +194,21 @@ func (m *MoLingServer) loadService(srv abstract.Service) error {
 	}
 
 	// Add Tools
-	m.server.AddTools(srv.Tools()...)
+	tools := make([]server.ServerTool, 0, len(srv.Tools()))
+	for _, tool := range srv.Tools() {
+		tool.Tool = m.localizeTool(tool.Tool)
+		tool = m.exposeTool(srv.Name(), tool)
+		if other, ok := m.toolServices[tool.Tool.Name]; ok && other != srv.Name() {
+			m.logger.Error().Str("tool", tool.Tool.Name).Str("service", string(srv.Name())).Str("loadedBy", string(other)).
+				Msg("a loaded service has a tool with this name, the tool was skipped, rename it in tool_names")
+			continue
+		}
+		m.toolServices[tool.Tool.Name] = srv.Name()
+		m.tools[tool.Tool.Name] = tool.Tool
+		m.handlers[tool.Tool.Name] = tool.Handler
+		tools = append(tools, tool)
+	}
+	m.server.AddTools(tools...)
 
 	// Add Notification Handlers
 	for n, nhf := range srv.NotificationHandlers() {
@@ -96,22 +218,210 @@ func (m *MoLingServer) loadService(srv abstract.Service) error {
 	// Add Prompts
 	for _, pe := range srv.Prompts() {
 		// Add Prompt
-		m.server.AddPrompt(pe.Prompt(), pe.Handler())
+		m.promptsLock.Lock()
+		m.servicePrompts[pe.Prompt().Name] = true
+		m.promptsLock.Unlock()
+		m.server.AddPrompt(m.localizePrompt(pe.Prompt(), pe.Handler()))
 	}
+
+	m.connectService(srv)
 	return nil
 }
 
+// connectService lets a service push notifications and call into the other loaded services.
+func (m *MoLingServer) connectService(srv abstract.Service) {
+	// Let the service push notifications to connected clients
+	if n, ok := srv.(abstract.Notifier); ok {
+		n.SetNotifyFunc(m.server.SendNotificationToAllClients)
+	}
+
+	// Let the service report important events to connected clients
+	if l, ok := srv.(abstract.ClientLogger); ok {
+		l.SetClientLogFunc(m.logToClients)
+	}
+
+	// Let the service call into the other loaded services
+	if l, ok := srv.(abstract.Linker); ok {
+		l.SetServiceLookup(m.lookupService)
+	}
+
+	// Let the service ask the user of a tool call for input
+	if r, ok := srv.(abstract.Requester); ok {
+		r.SetClientRequester(m.clients)
+	}
+
+	// Let the service add and update resources while the server runs
+	if p, ok := srv.(abstract.Publisher); ok {
+		p.SetResourcePublisher(resourcePublisher{server: m.server})
+	}
+}
+
+// resourcePublisher publishes the resources a service adds at runtime and announces changes to them.
+// mcp-go cannot answer resources/subscribe, so updates go to every connected client.
+type resourcePublisher struct {
+	server *server.MCPServer
+}
+
+func (p resourcePublisher) AddResource(rs mcp.Resource, hr server.ResourceHandlerFunc) {
+	p.server.AddResource(rs, hr)
+}
+
+func (p resourcePublisher) RemoveResource(uri string) {
+	p.server.RemoveResource(uri)
+}
+
+func (p resourcePublisher) ResourceUpdated(uri string) {
+	p.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+}
+
+// SetSessionFactory gives every SSE client session its own instance of a service listed in isolated_services.
+// It has no effect in STDIO mode.
+func (m *MoLingServer) SetSessionFactory(name comm.MoLingServerType, f SessionFactory) {
+	if m.sessions != nil && m.sessions.setFactory(name, f) {
+		m.logger.Info().Str("serviceName", string(name)).Msg("service state is isolated per client session")
+	}
+}
+
+// SetProfile sets the services that the SSE clients of a profile may use, nil for all, and the factories of the
+// services the profile configures differently. The sessions of these clients get their own instances of them.
+// It has no effect in STDIO mode.
+func (m *MoLingServer) SetProfile(profile string, services []comm.MoLingServerType, factories map[comm.MoLingServerType]SessionFactory) {
+	if m.sessions == nil {
+		return
+	}
+	var enabled map[comm.MoLingServerType]bool
+	if services != nil {
+		enabled = make(map[comm.MoLingServerType]bool, len(services))
+		for _, name := range services {
+			enabled[name] = true
+		}
+	}
+	m.profilesLock.Lock()
+	if m.profiles == nil {
+		m.profiles = make(map[string]map[comm.MoLingServerType]bool)
+	}
+	m.profiles[profile] = enabled
+	m.profilesLock.Unlock()
+	for name, f := range factories {
+		m.sessions.setProfileFactory(profile, name, f)
+	}
+	m.logger.Info().Str("profile", profile).Int("services", len(factories)).Msg("profile loaded for SSE clients")
+}
+
+// profileAllows reports whether the profile of a client enables a service.
+func (m *MoLingServer) profileAllows(p *Principal, service comm.MoLingServerType) bool {
+	if p == nil || p.Profile == "" {
+		return true
+	}
+	m.profilesLock.RLock()
+	defer m.profilesLock.RUnlock()
+	enabled, ok := m.profiles[p.Profile]
+	return ok && (enabled == nil || enabled[service])
+}
+
+// CloseSessions closes the per-session service instances.
+func (m *MoLingServer) CloseSessions() error {
+	if m.sessions == nil {
+		return nil
+	}
+	return m.sessions.close()
+}
+
+// lookupService returns the loaded service with the given name.
+func (m *MoLingServer) lookupService(name comm.MoLingServerType) (abstract.Service, bool) {
+	for _, srv := range m.services {
+		if srv.Name() == name {
+			return srv, true
+		}
+	}
+	return nil, false
+}
+
+// principal returns the client of a request, falling back to the default role for unauthenticated clients.
+// It reports false if the client may use all tools.
+func (m *MoLingServer) principal(ctx context.Context) (*Principal, bool) {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p, true
+	}
+	return m.anonymous, m.anonymous != nil
+}
+
+// filterTools hides the tools that are disabled or that the authenticated client is not allowed to use.
+func (m *MoLingServer) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	p, restricted := m.principal(ctx)
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		service := m.toolServices[tool.Name]
+		if _, off := m.disabled(service, tool.Name); off {
+			continue
+		}
+		if !restricted || (p.Allowed(service, tool.Name) && m.profileAllows(p, service)) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
 func (m *MoLingServer) Serve() error {
 	mLogger := log.New(m.logger, m.mlConfig.ServerName, 0)
 	if m.listenAddr != "" {
 		ltnAddr := fmt.Sprintf("http://%s", strings.TrimPrefix(m.listenAddr, "http://"))
-		consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
-		multi := zerolog.MultiLevelWriter(consoleWriter, m.logger)
-		m.logger = zerolog.New(multi).With().Timestamp().Logger()
+		consoleWriter := zerolog.ConsoleWriter{Out: config.NewSecretMaskWriter(os.Stdout), TimeFormat: time.RFC3339}
+		if w := m.mlConfig.LogWriter(); w != nil {
+			m.logger = m.logger.Output(zerolog.MultiLevelWriter(consoleWriter, w))
+		} else {
+			multi := zerolog.MultiLevelWriter(consoleWriter, m.logger)
+			m.logger = zerolog.New(multi).With().Timestamp().Logger()
+		}
 		m.logger.Info().Str("listenAddr", m.listenAddr).Str("BaseURL", ltnAddr).Msg("Starting SSE server")
 		m.logger.Warn().Msgf("The SSE server URL must be: %s. Please do not make mistakes, even if it is another IP or domain name on the same computer, it cannot be mixed.", ltnAddr)
-		return server.NewSSEServer(m.server, server.WithBaseURL(ltnAddr)).Start(m.listenAddr)
+		httpServer := &http.Server{Addr: m.listenAddr}
+		sseServer := server.NewSSEServer(m.server, server.WithBaseURL(ltnAddr), server.WithHTTPServer(httpServer))
+		handler := m.clients.wrapSSE(sseServer)
+		if m.mlConfig.Auth.Enabled {
+			m.logger.Info().Int("apiKeys", len(m.mlConfig.Auth.APIKeys)).Int("bearerTokens", len(m.mlConfig.Auth.BearerTokens)).
+				Bool("oauth2", m.mlConfig.Auth.OAuth2.IntrospectionURL != "").Msg("SSE authentication enabled")
+			handler = NewAuthenticator(&m.mlConfig.Auth, m.logger).Middleware(handler)
+		} else if !isLoopback(m.listenAddr) {
+			m.logger.Warn().Msg("SSE authentication is disabled, anyone who can reach this address can use all tools. Enable auth in the MoLingConfig section of the configuration file.")
+		}
+		// Health probes are not authenticated, so that orchestrators can use them.
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /healthz", m.handleHealthz)
+		mux.HandleFunc("GET /readyz", m.handleReadyz)
+		mux.Handle("/", handler)
+		httpServer.Handler = mux
+		m.serveLock.Lock()
+		m.sseServer = sseServer
+		m.serveLock.Unlock()
+		err := sseServer.Start(m.listenAddr)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
 	}
 	m.logger.Info().Msg("Starting STDIO server")
-	return server.ServeStdio(m.server, server.WithErrorLogger(mLogger))
+	stdioServer := server.NewStdioServer(m.server)
+	stdioServer.SetErrorLogger(mLogger)
+	// The context is not cancelled by signals, so that running tool calls can finish; Shutdown stops the server.
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	m.serveLock.Lock()
+	m.stopStdio = stop
+	m.serveLock.Unlock()
+	stdin, stdout := m.clients.wrapStdio(os.Stdin, os.Stdout)
+	return stdioServer.Listen(ctx, stdin, stdout)
+}
+
+// isLoopback reports whether a listen address only accepts local connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(strings.TrimPrefix(addr, "http://"))
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
@@ -17,10 +17,16 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/config"
 	"github.com/gojue/moling/pkg/services/abstract"
@@ -79,3 +85,68 @@ func TestNewMLServer(t *testing.T) {
 	}
 	t.Logf("Server started successfully: %v", srv)
 }
+
+func TestResourcePublisher(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "v1", server.WithResourceCapabilities(false, true))
+	p := resourcePublisher{server: mcpServer}
+	p.AddResource(mcp.NewResource("test://a", "a"), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: "test://a", Text: "a"}}, nil
+	})
+
+	list := func() string {
+		res := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`))
+		data, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if !strings.Contains(list(), "test://a") {
+		t.Fatalf("the published resource is not listed: %s", list())
+	}
+	p.RemoveResource("test://a")
+	if strings.Contains(list(), "test://a") {
+		t.Fatalf("the removed resource is still listed: %s", list())
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	fs, err := filesystem.NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create filesystem server: %s", err.Error())
+	}
+	if err = fs.Init(); err != nil {
+		t.Fatalf("Failed to initialize filesystem server: %s", err.Error())
+	}
+	srv, err := NewMoLingServer(ctx, []abstract.Service{fs}, config.MoLingConfig{BasePath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create server: %s", err.Error())
+	}
+
+	res, err := srv.CallTool(context.Background(), "list_allowed_directories", map[string]any{})
+	if err != nil || res.IsError || len(res.Content) == 0 {
+		t.Fatalf("unexpected result %v, error %v", res, err)
+	}
+	if calls := srv.calls.recent(10, ""); len(calls) != 1 || calls[0].Service != filesystem.FilesystemServerName {
+		t.Fatalf("the call must pass through the middleware chain, got %v", calls)
+	}
+	if _, err = srv.CallTool(context.Background(), "nope", nil); err == nil {
+		t.Fatal("expected an error for an unknown tool")
+	}
+
+	tools, err := srv.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list the tools: %v", err)
+	}
+	names := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		names[tool.Name] = true
+	}
+	if !names["read_file"] || !names[StatusToolName] || srv.ToolService("read_file") != filesystem.FilesystemServerName {
+		t.Fatalf("expected the tools of the services and of the server, got %v", names)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// SessionFactory creates an initialized instance of a service for one client session. The session id is
+// in the context under comm.MoLingSessionKey.
+type SessionFactory func(ctx context.Context) (abstract.Service, error)
+
+// sessionManager keeps separate instances of the isolated services for every client session, and closes
+// them when the session ends or has been idle for too long.
+type sessionManager struct {
+	ctx     context.Context
+	config  config.SessionConfig
+	logger  zerolog.Logger
+	connect func(srv abstract.Service) // connect wires a new instance up like the shared services.
+
+	lock      sync.Mutex
+	factories map[comm.MoLingServerType]SessionFactory
+	profiles  map[string]map[comm.MoLingServerType]SessionFactory // profiles are the factories of the services a profile configures, by profile.
+	sessions  map[string]*clientSession
+}
+
+type clientSession struct {
+	id       string
+	lastUsed time.Time
+	active   int  // active is the number of running tool calls.
+	closed   bool // closed sessions are closed by the last running tool call.
+
+	lock     sync.Mutex // lock serializes creating and closing the instances.
+	services map[comm.MoLingServerType]abstract.Service
+}
+
+func newSessionManager(ctx context.Context, cfg config.SessionConfig, logger zerolog.Logger, connect func(srv abstract.Service)) *sessionManager {
+	return &sessionManager{
+		ctx:       ctx,
+		config:    cfg,
+		logger:    logger,
+		connect:   connect,
+		factories: make(map[comm.MoLingServerType]SessionFactory),
+		profiles:  make(map[string]map[comm.MoLingServerType]SessionFactory),
+		sessions:  make(map[string]*clientSession),
+	}
+}
+
+// setFactory isolates a service if it is listed in isolated_services.
+func (sm *sessionManager) setFactory(name comm.MoLingServerType, f SessionFactory) bool {
+	for _, isolated := range sm.config.IsolatedServices {
+		if isolated == string(name) {
+			sm.lock.Lock()
+			sm.factories[name] = f
+			sm.lock.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// setProfileFactory gives the sessions of clients with the profile their own instance of a service the profile
+// configures, whether the service is isolated or not.
+func (sm *sessionManager) setProfileFactory(profile string, name comm.MoLingServerType, f SessionFactory) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	if sm.profiles[profile] == nil {
+		sm.profiles[profile] = make(map[comm.MoLingServerType]SessionFactory)
+	}
+	sm.profiles[profile][name] = f
+}
+
+// acquire returns the instance of a service for a session of a client with the given profile, creating it on
+// first use. It returns nil if the service is shared. The caller must call release when the tool call is done.
+func (sm *sessionManager) acquire(id string, profile string, name comm.MoLingServerType) (abstract.Service, func(), error) {
+	sm.lock.Lock()
+	factory, ok := sm.profiles[profile][name]
+	if !ok {
+		factory, ok = sm.factories[name]
+	}
+	if !ok {
+		sm.lock.Unlock()
+		return nil, nil, nil
+	}
+	s, ok := sm.sessions[id]
+	if !ok {
+		if len(sm.sessions) >= sm.config.MaxSessions {
+			sm.lock.Unlock()
+			return nil, nil, fmt.Errorf("too many client sessions, at most %d may use %s at a time, try again later", sm.config.MaxSessions, name)
+		}
+		s = &clientSession{id: id, services: make(map[comm.MoLingServerType]abstract.Service)}
+		sm.sessions[id] = s
+		sm.logger.Info().Str("session", id).Msg("client session started")
+	}
+	s.lastUsed = time.Now()
+	s.active++
+	sm.lock.Unlock()
+	release := func() { sm.release(s) }
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if srv, ok := s.services[name]; ok {
+		return srv, release, nil
+	}
+	srv, err := factory(context.WithValue(sm.ctx, comm.MoLingSessionKey, id))
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to start %s for this session: %w", name, err)
+	}
+	sm.connect(srv)
+	s.services[name] = srv
+	sm.logger.Info().Str("session", id).Str("service", string(name)).Msg("session service started")
+	return srv, release, nil
+}
+
+func (sm *sessionManager) release(s *clientSession) {
+	sm.lock.Lock()
+	s.active--
+	s.lastUsed = time.Now()
+	closeNow := s.closed && s.active == 0
+	sm.lock.Unlock()
+	if closeNow {
+		sm.closeSession(s)
+	}
+}
+
+// instances returns the names of the isolated services running for a session.
+func (sm *sessionManager) instances(id string) []string {
+	sm.lock.Lock()
+	s, ok := sm.sessions[id]
+	sm.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names := make([]string, 0, len(s.services))
+	for name := range s.services {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// servicesOf returns the instances of a service that run for client sessions.
+func (sm *sessionManager) servicesOf(name comm.MoLingServerType) []abstract.Service {
+	sm.lock.Lock()
+	sessions := make([]*clientSession, 0, len(sm.sessions))
+	for _, s := range sm.sessions {
+		sessions = append(sessions, s)
+	}
+	sm.lock.Unlock()
+	var srvs []abstract.Service
+	for _, s := range sessions {
+		s.lock.Lock()
+		if srv, ok := s.services[name]; ok {
+			srvs = append(srvs, srv)
+		}
+		s.lock.Unlock()
+	}
+	return srvs
+}
+
+// end closes the instances of a session, or leaves that to its last running tool call.
+func (sm *sessionManager) end(id string, reason string) {
+	sm.lock.Lock()
+	s, ok := sm.sessions[id]
+	if !ok {
+		sm.lock.Unlock()
+		return
+	}
+	delete(sm.sessions, id)
+	s.closed = true
+	closeNow := s.active == 0
+	sm.lock.Unlock()
+	sm.logger.Info().Str("session", id).Str("reason", reason).Msg("client session ended")
+	if closeNow {
+		sm.closeSession(s)
+	}
+}
+
+func (sm *sessionManager) closeSession(s *clientSession) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, srv := range s.services {
+		err := srv.Close()
+		if err != nil {
+			sm.logger.Error().Err(err).Str("session", s.id).Str("service", string(name)).Msg("failed to close session service")
+		}
+		delete(s.services, name)
+	}
+}
+
+// run ends idle sessions until the context is done.
+func (sm *sessionManager) run() {
+	idle := time.Duration(sm.config.IdleTimeout) * time.Second
+	if idle <= 0 {
+		// Sessions are only kept for profiles, without isolated services there is no idle_timeout.
+		idle = time.Duration(config.NewSessionConfig().IdleTimeout) * time.Second
+	}
+	ticker := time.NewTicker(min(idle/2, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sm.ctx.Done():
+			return
+		case <-ticker.C:
+			sm.endIdle(time.Now().Add(-idle))
+		}
+	}
+}
+
+// endIdle ends the sessions without running tool calls that were last used before deadline.
+func (sm *sessionManager) endIdle(deadline time.Time) {
+	var idle []string
+	sm.lock.Lock()
+	for id, s := range sm.sessions {
+		if s.active == 0 && s.lastUsed.Before(deadline) {
+			idle = append(idle, id)
+		}
+	}
+	sm.lock.Unlock()
+	for _, id := range idle {
+		sm.end(id, "idle")
+	}
+}
+
+// close ends all sessions.
+func (sm *sessionManager) close() error {
+	sm.lock.Lock()
+	ids := make([]string, 0, len(sm.sessions))
+	for id := range sm.sessions {
+		ids = append(ids, id)
+	}
+	sm.lock.Unlock()
+	for _, id := range ids {
+		sm.end(id, "shutdown")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// fakeService is a service whose whoami tool returns the session it was created for.
+type fakeService struct {
+	abstract.Service
+	session string
+	closed  bool
+}
+
+func (fs *fakeService) Tools() []server.ServerTool {
+	return []server.ServerTool{{
+		Tool: mcp.NewTool("whoami"),
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("instance of " + fs.session), nil
+		},
+	}}
+}
+
+func (fs *fakeService) Close() error {
+	fs.closed = true
+	return nil
+}
+
+// fakeSession is an MCP client session.
+type fakeSession struct {
+	id string
+}
+
+func (s fakeSession) Initialize()       {}
+func (s fakeSession) Initialized() bool { return true }
+func (s fakeSession) SessionID() string { return s.id }
+func (s fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+
+func newTestSessionManager(max int) (*sessionManager, map[string]*fakeService) {
+	instances := make(map[string]*fakeService)
+	sm := newSessionManager(context.Background(), config.SessionConfig{IsolatedServices: []string{"Fake"}, IdleTimeout: 60, MaxSessions: max},
+		zerolog.Nop(), func(srv abstract.Service) {})
+	sm.setFactory("Fake", func(ctx context.Context) (abstract.Service, error) {
+		id := ctx.Value(comm.MoLingSessionKey).(string)
+		instances[id] = &fakeService{session: id}
+		return instances[id], nil
+	})
+	return sm, instances
+}
+
+func TestSessionManager(t *testing.T) {
+	sm, instances := newTestSessionManager(2)
+	if sm.setFactory("Shared", nil) {
+		t.Fatalf("services not in isolated_services must stay shared")
+	}
+	srv, _, err := sm.acquire("a", "", "Shared")
+	if srv != nil || err != nil {
+		t.Fatalf("expected no instance for a shared service")
+	}
+
+	a1, releaseA1, err := sm.acquire("a", "", "Fake")
+	if err != nil {
+		t.Fatalf("Failed to acquire: %s", err.Error())
+	}
+	a2, releaseA2, _ := sm.acquire("a", "", "Fake")
+	b, releaseB, _ := sm.acquire("b", "", "Fake")
+	if a1 != a2 || a1 == b || len(instances) != 2 {
+		t.Fatalf("expected one instance per session, got %d", len(instances))
+	}
+	releaseA2()
+	_, _, err = sm.acquire("c", "", "Fake")
+	if err == nil || !strings.Contains(err.Error(), "too many client sessions") {
+		t.Fatalf("expected a session limit error, got %v", err)
+	}
+
+	// A session that ends during a tool call is closed when the call returns.
+	sm.end("a", "disconnected")
+	if instances["a"].closed {
+		t.Fatalf("session a was closed during a tool call")
+	}
+	releaseA1()
+	if !instances["a"].closed {
+		t.Fatalf("session a was not closed after the tool call")
+	}
+
+	releaseB()
+	sm.endIdle(time.Now().Add(-time.Minute))
+	if instances["b"].closed {
+		t.Fatalf("session b was closed before it was idle")
+	}
+	sm.endIdle(time.Now().Add(time.Second))
+	if !instances["b"].closed || len(sm.sessions) != 0 {
+		t.Fatalf("idle session b was not closed")
+	}
+}
+
+func TestIsolateTool(t *testing.T) {
+	sm, _ := newTestSessionManager(4)
+	ms := &MoLingServer{
+		logger:       zerolog.Nop(),
+		sessions:     sm,
+		toolServices: map[string]comm.MoLingServerType{"whoami": "Fake"},
+	}
+	mcpServer := server.NewMCPServer("test", "1.0")
+	handler := ms.isolateTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("shared instance"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "whoami"
+
+	for _, c := range []struct {
+		ctx  context.Context
+		want string
+	}{
+		{context.Background(), "shared instance"},
+		{mcpServer.WithContext(context.Background(), fakeSession{id: "s1"}), "instance of s1"},
+		{mcpServer.WithContext(context.Background(), fakeSession{id: "s2"}), "instance of s2"},
+	} {
+		res, err := handler(c.ctx, req)
+		if err != nil {
+			t.Fatalf("Failed to call tool: %s", err.Error())
+		}
+		if text := res.Content[0].(mcp.TextContent).Text; text != c.want {
+			t.Fatalf("expected %q, got %q", c.want, text)
+		}
+	}
+	if err := ms.CloseSessions(); err != nil || len(sm.sessions) != 0 {
+		t.Fatalf("sessions were not closed: %v", err)
+	}
+}
+
+func TestProfileSessions(t *testing.T) {
+	sm, instances := newTestSessionManager(4)
+	ms := &MoLingServer{logger: zerolog.Nop(), sessions: sm}
+	ms.SetProfile("work", []comm.MoLingServerType{"Fake", "Shared"}, map[comm.MoLingServerType]SessionFactory{
+		"Shared": func(ctx context.Context) (abstract.Service, error) {
+			return &fakeService{session: "work"}, nil
+		},
+	})
+
+	// Services the profile configures get instances of their own, even if they are shared otherwise.
+	srv, release, err := sm.acquire("a", "work", "Shared")
+	if err != nil || srv == nil || srv.(*fakeService).session != "work" {
+		t.Fatalf("expected the instance of the profile, got %v, %v", srv, err)
+	}
+	release()
+	srv, _, _ = sm.acquire("b", "", "Shared")
+	if srv != nil {
+		t.Fatalf("expected the shared instance for clients without a profile")
+	}
+	_, release, _ = sm.acquire("a", "work", "Fake")
+	release()
+	if _, ok := instances["a"]; !ok {
+		t.Fatalf("expected the default factory for services the profile does not configure")
+	}
+
+	for _, c := range []struct {
+		profile string
+		service comm.MoLingServerType
+		want    bool
+	}{
+		{"", "Other", true},
+		{"work", "Fake", true},
+		{"work", "Other", false},
+		{"unknown", "Fake", false},
+	} {
+		if got := ms.profileAllows(&Principal{Profile: c.profile}, c.service); got != c.want {
+			t.Errorf("profile %q, service %s: expected %v, got %v", c.profile, c.service, c.want, got)
+		}
+	}
+	if err = ms.CloseSessions(); err != nil {
+		t.Fatalf("Failed to close sessions: %s", err.Error())
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// cancelGrace is how long Shutdown waits for the tool calls it cancelled at the deadline to return.
+	cancelGrace = 2 * time.Second
+	// closeTimeout bounds the stopping of the transport and the closing of the sessions and services.
+	closeTimeout = 10 * time.Second
+)
+
+// drainer counts the running tool calls, and rejects new ones once the server shuts down.
+type drainer struct {
+	lock     sync.Mutex
+	draining bool
+	running  int
+	idle     chan struct{} // idle is closed when no tool call runs any more during draining.
+}
+
+// enter registers a tool call. It reports false if the server is shutting down.
+func (d *drainer) enter() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.draining {
+		return false
+	}
+	d.running++
+	return true
+}
+
+// leave unregisters a tool call.
+func (d *drainer) leave() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.running--
+	if d.draining && d.running == 0 {
+		close(d.idle)
+	}
+}
+
+// drain rejects new tool calls from now on, and returns the number of running ones and a channel closed once
+// they have all returned.
+func (d *drainer) drain() (int, <-chan struct{}) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.draining {
+		d.draining = true
+		d.idle = make(chan struct{})
+		if d.running == 0 {
+			close(d.idle)
+		}
+	}
+	return d.running, d.idle
+}
+
+func (d *drainer) isDraining() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.draining
+}
+
+// Shutdown stops the server gracefully. It rejects new tool calls and waits for the running ones until ctx is done,
+// then cancels them. Then it stops the transport, interrupts the background jobs, closes the per-session instances
+// and closes the services, those that call into other services first, so that no service is closed in the middle of
+// an operation.
+func (m *MoLingServer) Shutdown(ctx context.Context) error {
+	running, idle := m.drain.drain()
+	m.logger.Info().Int("running", running).Msg("shutting down, waiting for the running tool calls")
+	m.logToClients(mcp.LoggingLevelNotice, serverLogName, map[string]any{"message": "the server is shutting down", "running": running})
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		m.logger.Warn().Msg("running tool calls did not finish in time, cancelling them")
+		m.inflight.cancelAll()
+		select {
+		case <-idle:
+		case <-time.After(cancelGrace):
+			m.logger.Warn().Msg("cancelled tool calls did not return, closing the services anyway")
+		}
+	}
+
+	if m.stopWatchers != nil {
+		m.stopWatchers()
+	}
+	closeCtx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	var errs []error
+	m.serveLock.Lock()
+	sseServer, stopStdio := m.sseServer, m.stopStdio
+	m.serveLock.Unlock()
+	if sseServer != nil {
+		if err := sseServer.Shutdown(closeCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop the SSE server: %w", err))
+		}
+	}
+	if stopStdio != nil {
+		stopStdio()
+	}
+	if m.jobs != nil {
+		if err := m.jobs.Close(closeCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop the background jobs: %w", err))
+		}
+	}
+	if err := m.CloseSessions(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close the client sessions: %w", err))
+	}
+	if err := m.closeServices(closeCtx); err != nil {
+		errs = append(errs, err)
+	}
+	if m.mlConfig.Stats.DumpInterval > 0 {
+		if err := m.dumpStats(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write the tool call statistics: %w", err))
+		}
+	}
+	if m.audit != nil {
+		if err := m.audit.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close the audit trail: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeServices closes the services in closeOrder, one at a time, until ctx is done.
+func (m *MoLingServer) closeServices(ctx context.Context) error {
+	var errs []error
+	for _, srv := range closeOrder(m.services) {
+		done := make(chan error, 1)
+		go func() { done <- srv.Close() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to close service %s: %w", srv.Name(), err))
+				continue
+			}
+			m.logger.Info().Str("serviceName", string(srv.Name())).Msg("service closed")
+		case <-ctx.Done():
+			return errors.Join(append(errs, fmt.Errorf("service %s did not close in time", srv.Name()))...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeOrder returns the services in the order to close them: a service is closed before the services it depends
+// on, and otherwise in the reverse order of loading. Services in a dependency cycle are closed in reverse order.
+func closeOrder(srvs []abstract.Service) []abstract.Service {
+	remaining := make([]abstract.Service, len(srvs))
+	for i, srv := range srvs {
+		remaining[len(srvs)-1-i] = srv
+	}
+	// needed reports whether a remaining service other than srv depends on srv.
+	needed := func(srv abstract.Service) bool {
+		for _, other := range remaining {
+			if other == srv {
+				continue
+			}
+			d, ok := other.(abstract.Dependent)
+			if !ok {
+				continue
+			}
+			// As at startup, only nil means any service, an empty list means none
+			deps := d.Dependencies()
+			if deps == nil {
+				return true
+			}
+			for _, name := range deps {
+				if name == srv.Name() {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	ordered := make([]abstract.Service, 0, len(srvs))
+	for len(remaining) > 0 {
+		next := -1
+		for i, srv := range remaining {
+			if !needed(srv) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next:next], remaining[next+1:]...)
+	}
+	return ordered
+}
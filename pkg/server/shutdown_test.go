@@ -0,0 +1,177 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// orderedService records the order services are closed in.
+type orderedService struct {
+	abstract.Service
+	name   comm.MoLingServerType
+	closed *[]comm.MoLingServerType
+}
+
+func (s *orderedService) Name() comm.MoLingServerType { return s.name }
+
+func (s *orderedService) Close() error {
+	*s.closed = append(*s.closed, s.name)
+	return nil
+}
+
+// dependentService depends on the services in deps, or on all services if deps is nil.
+type dependentService struct {
+	orderedService
+	deps []comm.MoLingServerType
+}
+
+func (s *dependentService) Dependencies() []comm.MoLingServerType { return s.deps }
+
+func TestShutdown(t *testing.T) {
+	var closed []comm.MoLingServerType
+	svc := func(name comm.MoLingServerType) orderedService {
+		return orderedService{name: name, closed: &closed}
+	}
+	browser, llm, fs := svc("Browser"), svc("LLM"), svc("FileSystem")
+	ms := &MoLingServer{
+		logger:       zerolog.Nop(),
+		toolServices: map[string]comm.MoLingServerType{"slow": "Command"},
+		services: []abstract.Service{
+			&browser,
+			&dependentService{orderedService: svc("Pipeline")},
+			&llm,
+			&dependentService{orderedService: svc("Summarize"), deps: []comm.MoLingServerType{"Browser"}},
+			&fs,
+		},
+	}
+	ms.chain = ms.builtinMiddlewares()
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(stampRequestID)
+	ms.server = server.NewMCPServer("test", "v1", server.WithToolHandlerMiddleware(ms.handleTool), server.WithHooks(hooks))
+	started := make(chan struct{}, 2)
+	finish := make(chan struct{})
+	ms.server.AddTool(mcp.NewTool("slow"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return mcp.NewToolResultError(ctx.Err().Error()), nil
+		case <-finish:
+			return mcp.NewToolResultText("finished"), nil
+		}
+	})
+	call := func(id int) <-chan string {
+		done := make(chan string, 1)
+		go func() {
+			res := ms.server.HandleMessage(context.Background(), json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"slow"}}`, id)))
+			data, _ := json.Marshal(res)
+			done <- string(data)
+		}()
+		return done
+	}
+
+	// The running call finishes, new calls are rejected meanwhile.
+	first := call(1)
+	<-started
+	stopped := make(chan error, 1)
+	go func() { stopped <- ms.Shutdown(context.Background()) }()
+	for !ms.drain.isDraining() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res := <-call(2); !strings.Contains(res, "shutting down") {
+		t.Fatalf("expected new calls to be rejected, got %s", res)
+	}
+	if len(closed) != 0 {
+		t.Fatalf("services were closed while a tool call was running: %v", closed)
+	}
+	close(finish)
+	if res := <-first; !strings.Contains(res, "finished") {
+		t.Fatalf("expected the running call to finish, got %s", res)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("Failed to shut down: %s", err.Error())
+	}
+	// Pipeline uses any service, Summarize uses Browser; the others go in the reverse order of loading.
+	want := []comm.MoLingServerType{"Pipeline", "FileSystem", "Summarize", "LLM", "Browser"}
+	if !slices.Equal(closed, want) {
+		t.Fatalf("expected the services to be closed in the order %v, got %v", want, closed)
+	}
+}
+
+func TestCloseOrderEmptyDependencies(t *testing.T) {
+	var closed []comm.MoLingServerType
+	svc := func(name comm.MoLingServerType) orderedService {
+		return orderedService{name: name, closed: &closed}
+	}
+	fs := svc("FileSystem")
+	srvs := []abstract.Service{
+		&dependentService{orderedService: svc("Browser"), deps: []comm.MoLingServerType{}},
+		&fs,
+		&dependentService{orderedService: svc("Pipeline")},
+	}
+	var names []comm.MoLingServerType
+	for _, srv := range closeOrder(srvs) {
+		names = append(names, srv.Name())
+	}
+	// Browser depends on no service, so FileSystem is closed before it in the reverse order of loading.
+	want := []comm.MoLingServerType{"Pipeline", "FileSystem", "Browser"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("expected the services to be closed in the order %v, got %v", want, names)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	ms := &MoLingServer{logger: zerolog.Nop(), toolServices: map[string]comm.MoLingServerType{}}
+	ms.chain = ms.builtinMiddlewares()
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(stampRequestID)
+	ms.server = server.NewMCPServer("test", "v1", server.WithToolHandlerMiddleware(ms.handleTool), server.WithHooks(hooks))
+	started := make(chan struct{})
+	ms.server.AddTool(mcp.NewTool("stuck"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return mcp.NewToolResultError(ctx.Err().Error()), nil
+	})
+	done := make(chan string, 1)
+	go func() {
+		res := ms.server.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"stuck"}}`))
+		data, _ := json.Marshal(res)
+		done <- string(data)
+	}()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := ms.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %s", err.Error())
+	}
+	if res := <-done; !strings.Contains(res, "context canceled") {
+		t.Fatalf("expected the call to be cancelled at the deadline, got %s", res)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// SpillDir is the directory in the base path of the tool results too large to be returned.
+	SpillDir = "data/responses"
+	// SpillURIPrefix is the URI prefix of the saved tool results, which clients read as resources.
+	SpillURIPrefix = "moling://responses/"
+)
+
+// spillTool saves the results larger than max_size to files and returns a preview of their text with the paths and
+// the resource URIs of the files instead, so that giant results do not crash clients.
+func (m *MoLingServer) spillTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || m.mlConfig.Responses.MaxSize <= 0 {
+			return result, err
+		}
+		raw, mErr := json.Marshal(result)
+		if mErr != nil || len(raw) <= m.mlConfig.Responses.MaxSize {
+			return result, err
+		}
+		spilled, sErr := m.spill(request.Params.Name, result, len(raw))
+		if sErr != nil {
+			m.logger.Error().Err(sErr).Str("tool", request.Params.Name).Int("size", len(raw)).Msg("failed to save a large tool result")
+			return abstract.NewErrorResultf(abstract.CodeInternal, "the result of %s is too large to return (%d bytes) and could not be saved: %s", request.Params.Name, len(raw), sErr), nil
+		}
+		m.logger.Info().Str("tool", request.Params.Name).Int("size", len(raw)).Msg("a large tool result was saved to a file")
+		return spilled, nil
+	}
+}
+
+// spill writes the content of a result to files: its text to one file, every image, audio and binary resource to
+// a file of its own. It returns a result with the beginning of the text and the list of the files.
+func (m *MoLingServer) spill(tool string, result *mcp.CallToolResult, size int) (*mcp.CallToolResult, error) {
+	dir := filepath.Join(m.mlConfig.BasePath, SpillDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	base := fmt.Sprintf("%s-%s-%s", safeFileName(tool), time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+
+	var text strings.Builder
+	var files []string
+	write := func(name string, data []byte) error {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			return err
+		}
+		files = append(files, name)
+		return nil
+	}
+	writeBlob := func(data, mimeType string) error {
+		blob, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return fmt.Errorf("invalid base64 content: %w", err)
+		}
+		ext := ".bin"
+		if mimeType == "image/jpeg" {
+			ext = ".jpg"
+		} else if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			ext = exts[0]
+		}
+		return write(fmt.Sprintf("%s-%d%s", base, len(files)+1, ext), blob)
+	}
+	for _, c := range result.Content {
+		var err error
+		switch c := c.(type) {
+		case mcp.TextContent:
+			if text.Len() > 0 {
+				text.WriteString("\n")
+			}
+			text.WriteString(c.Text)
+		case mcp.ImageContent:
+			err = writeBlob(c.Data, c.MIMEType)
+		case mcp.AudioContent:
+			err = writeBlob(c.Data, c.MIMEType)
+		case mcp.EmbeddedResource:
+			switch r := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				if text.Len() > 0 {
+					text.WriteString("\n")
+				}
+				text.WriteString(r.Text)
+			case mcp.BlobResourceContents:
+				err = writeBlob(r.Blob, r.MIMEType)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if text.Len() > 0 {
+		if err := write(base+".txt", []byte(text.String())); err != nil {
+			return nil, err
+		}
+		// The text file comes first, it is the one clients are most likely to read.
+		files = append(files[len(files)-1:], files[:len(files)-1]...)
+	}
+	m.pruneSpilled(dir)
+
+	lines := make([]string, 0, len(files))
+	for _, name := range files {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", filepath.Join(dir, name), SpillURIPrefix+name))
+	}
+	preview := truncateText(text.String(), m.mlConfig.Responses.PreviewSize)
+	if preview != "" {
+		preview += "\n\n"
+	}
+	spilled := mcp.NewToolResultText(preview + m.locale.Sprintf("server.response_spilled", size, strings.Join(lines, "\n")))
+	spilled.IsError = result.IsError
+	for k, v := range result.Meta {
+		if k == abstract.StructuredContentKey {
+			continue
+		}
+		if spilled.Meta == nil {
+			spilled.Meta = make(map[string]any)
+		}
+		spilled.Meta[k] = v
+	}
+	return spilled, nil
+}
+
+// pruneSpilled removes the oldest saved results beyond max_kept, none if it is 0.
+func (m *MoLingServer) pruneSpilled(dir string) {
+	m.spillLock.Lock()
+	defer m.spillLock.Unlock()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type file struct {
+		name string
+		mod  time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			files = append(files, file{name: e.Name(), mod: info.ModTime()})
+		}
+	}
+	if m.mlConfig.Responses.MaxKept == 0 || len(files) <= m.mlConfig.Responses.MaxKept {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+	for _, f := range files[:len(files)-m.mlConfig.Responses.MaxKept] {
+		if err := os.Remove(filepath.Join(dir, f.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			m.logger.Warn().Err(err).Str("file", f.name).Msg("failed to remove a saved tool result")
+		}
+	}
+}
+
+// addSpillResources lets clients read the saved results as resources.
+func (m *MoLingServer) addSpillResources() {
+	if m.mlConfig.Responses.MaxSize <= 0 {
+		return
+	}
+	m.server.AddResourceTemplate(mcp.NewResourceTemplate(SpillURIPrefix+"{name}", "Saved tool results",
+		mcp.WithTemplateDescription("Tool results too large to be returned, saved to files"),
+	), m.handleReadSpilled)
+}
+
+// handleReadSpilled returns the content of a saved result.
+func (m *MoLingServer) handleReadSpilled(_ context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	name := strings.TrimPrefix(uri, SpillURIPrefix)
+	if name == uri || name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid saved result URI: %s", uri)
+	}
+	data, err := os.ReadFile(filepath.Join(m.mlConfig.BasePath, SpillDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the saved result: %w", err)
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(name))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)}}, nil
+	}
+	return []mcp.ResourceContents{mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}}, nil
+}
+
+// truncateText returns at most n bytes of s, cut at a rune boundary.
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// safeFileName replaces the characters of a tool name that are not safe in file names.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
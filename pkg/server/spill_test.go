@@ -0,0 +1,103 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestSpillTool(t *testing.T) {
+	dir := t.TempDir()
+	ms := &MoLingServer{
+		logger:   zerolog.Nop(),
+		mlConfig: config.MoLingConfig{BasePath: dir, Responses: config.ResponseConfig{MaxSize: 1000, PreviewSize: 10, MaxKept: 2}},
+	}
+	var result *mcp.CallToolResult
+	handler := ms.spillTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return result, nil
+	})
+	call := func() *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "browser_get_html"
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	result = mcp.NewToolResultText("small")
+	if res := call(); res != result {
+		t.Fatal("small results must be returned as they are")
+	}
+
+	html := "<html>" + strings.Repeat("é", 1000) + "</html>"
+	result = abstract.WithStructuredContent(mcp.NewToolResultText(html), map[string]string{"html": html})
+	result.Content = append(result.Content, mcp.NewImageContent(base64.StdEncoding.EncodeToString([]byte("png")), "image/png"))
+	res := call()
+	text := res.Content[0].(mcp.TextContent).Text
+	if len(res.Content) != 1 || !strings.HasPrefix(text, "<html>éé\n\n") || res.Meta[abstract.StructuredContentKey] != nil {
+		t.Fatalf("expected a preview cut at a rune boundary, got %+v", res)
+	}
+	if !strings.Contains(text, SpillURIPrefix) || !strings.Contains(text, ".txt") || !strings.Contains(text, ".png") {
+		t.Fatalf("expected the saved files in the result, got %s", text)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, SpillDir))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected the text and the image to be saved, got %v %v", entries, err)
+	}
+
+	for _, e := range entries {
+		req := mcp.ReadResourceRequest{}
+		req.Params.URI = SpillURIPrefix + e.Name()
+		contents, err := ms.handleReadSpilled(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch c := contents[0].(type) {
+		case mcp.TextResourceContents:
+			if !strings.Contains(c.Text, html) {
+				t.Fatalf("the saved text must be complete, got %d bytes", len(c.Text))
+			}
+		case mcp.BlobResourceContents:
+			if c.MIMEType != "image/png" || c.Blob != base64.StdEncoding.EncodeToString([]byte("png")) {
+				t.Fatalf("unexpected saved image %+v", c)
+			}
+		}
+	}
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = SpillURIPrefix + "../config/config.json"
+	if _, err := ms.handleReadSpilled(context.Background(), req); err == nil {
+		t.Fatal("names outside of the directory must be rejected")
+	}
+
+	call()
+	if entries, _ = os.ReadDir(filepath.Join(dir, SpillDir)); len(entries) != 2 {
+		t.Fatalf("only max_kept results must be kept, got %d", len(entries))
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// StatsReportToolName is the name of the admin tool that reports the latency and failures of every tool.
+	StatsReportToolName = "stats_report"
+	// StatsFile is the file in the base path the statistics are written to every dump_interval.
+	StatsFile = "data/stats.json"
+	// defaultStatsSamples is the number of durations kept per tool if the configuration does not set it.
+	defaultStatsSamples = 1000
+)
+
+// ToolStats are the statistics of the calls of a tool since the start of the server. Durations are in milliseconds,
+// the percentiles are of the latest calls.
+type ToolStats struct {
+	Tool     string                `json:"tool"`
+	Service  comm.MoLingServerType `json:"service,omitempty"`
+	Calls    int                   `json:"calls"`
+	Failures int                   `json:"failures"`
+	P50      float64               `json:"p50_ms"`
+	P95      float64               `json:"p95_ms"`
+	Max      float64               `json:"max_ms"`
+	Mean     float64               `json:"mean_ms"`
+	Last     time.Time             `json:"last"`
+}
+
+// StatsReport is the content of the stats file and of stats_report.
+type StatsReport struct {
+	Since time.Time   `json:"since"`
+	Time  time.Time   `json:"time"`
+	Tools []ToolStats `json:"tools"`
+}
+
+// toolStats collects the calls of a tool.
+type toolStats struct {
+	service   comm.MoLingServerType
+	calls     int
+	failures  int
+	total     time.Duration
+	max       time.Duration
+	last      time.Time
+	durations []time.Duration // durations is a ring of the latest durations.
+	next      int
+}
+
+// callStats collects the statistics of every tool. The zero value is ready to use.
+type callStats struct {
+	lock    sync.Mutex
+	samples int
+	tools   map[string]*toolStats
+}
+
+func (s *callStats) add(tool string, service comm.MoLingServerType, d time.Duration, failed bool, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.tools == nil {
+		s.tools = make(map[string]*toolStats)
+	}
+	ts, ok := s.tools[tool]
+	if !ok {
+		ts = &toolStats{service: service}
+		s.tools[tool] = ts
+	}
+	ts.calls++
+	if failed {
+		ts.failures++
+	}
+	ts.total += d
+	ts.max = max(ts.max, d)
+	ts.last = at
+	samples := s.samples
+	if samples <= 0 {
+		samples = defaultStatsSamples
+	}
+	if len(ts.durations) < samples {
+		ts.durations = append(ts.durations, d)
+		return
+	}
+	ts.durations[ts.next] = d
+	ts.next = (ts.next + 1) % samples
+}
+
+// report returns the statistics of the tools, the slowest by p95 first.
+func (s *callStats) report() []ToolStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	report := make([]ToolStats, 0, len(s.tools))
+	for tool, ts := range s.tools {
+		sorted := slices.Clone(ts.durations)
+		slices.Sort(sorted)
+		report = append(report, ToolStats{
+			Tool:     tool,
+			Service:  ts.service,
+			Calls:    ts.calls,
+			Failures: ts.failures,
+			P50:      milliseconds(percentile(sorted, 0.5)),
+			P95:      milliseconds(percentile(sorted, 0.95)),
+			Max:      milliseconds(ts.max),
+			Mean:     milliseconds(ts.total / time.Duration(ts.calls)),
+			Last:     ts.last,
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].P95 != report[j].P95 {
+			return report[i].P95 > report[j].P95
+		}
+		return report[i].Tool < report[j].Tool
+	})
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations, 0 for none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p+0.999999) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// recordStats adds a finished tool call to the statistics, and logs it with its redacted arguments if it was slow.
+func (m *MoLingServer) recordStats(call *ToolCall, request mcp.CallToolRequest, failed bool) {
+	d := time.Since(call.Started)
+	m.stats.add(call.Tool, call.Service, d, failed, call.Started)
+	if slow := m.mlConfig.Stats.SlowDuration(); slow > 0 && d > slow {
+		m.logger.Warn().Str("tool", call.Tool).Str("service", string(call.Service)).Str("session", call.Session).
+			Dur("duration", d).Dur("threshold", slow).Bool("isError", failed).
+			Any("args", auditArgs(request.GetArguments(), m.mlConfig.Audit.MaxArgLength)).Msg("slow tool call")
+	}
+}
+
+// StatsReport returns the statistics of the tool calls since the start of the server.
+func (m *MoLingServer) StatsReport() StatsReport {
+	return StatsReport{Since: m.started, Time: time.Now(), Tools: m.stats.report()}
+}
+
+// dumpStats writes the statistics to the stats file of the base path.
+func (m *MoLingServer) dumpStats() error {
+	data, err := json.MarshalIndent(m.StatsReport(), "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.mlConfig.BasePath, StatsFile)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// watchStats writes the statistics to the stats file every dump_interval until the server stops.
+func (m *MoLingServer) watchStats() {
+	if m.mlConfig.Stats.DumpInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(m.mlConfig.Stats.DumpInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.watchCtx.Done():
+			return
+		case <-ticker.C:
+			if err := m.dumpStats(); err != nil {
+				m.logger.Warn().Err(err).Msg("failed to write the tool call statistics")
+			}
+		}
+	}
+}
+
+// addStatsTool registers stats_report. Like moling_status, it belongs to the MoLing service.
+func (m *MoLingServer) addStatsTool() {
+	m.toolServices[StatsReportToolName] = AdminServiceName
+	m.server.AddTool(mcp.NewTool(
+		StatsReportToolName,
+		mcp.WithDescription("Report the number of calls, failures and the p50, p95, maximum and mean latency in milliseconds of every tool since the server started, the slowest first. Use it to tune timeouts and find failing tools."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("filter", mcp.Description("Only report the tools of this service or the tools matching a pattern such as browser_*")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of tools, all by default"), mcp.Min(1)),
+	), m.handleStatsReport)
+}
+
+func (m *MoLingServer) handleStatsReport(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	filter := abstract.GetString(args, "filter", "")
+	limit := abstract.GetInt(args, "limit", 0)
+	if limit < 0 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "limit must be greater than 0"), nil
+	}
+	report := m.StatsReport()
+	if filter != "" {
+		matched := report.Tools[:0]
+		for _, ts := range report.Tools {
+			if matchTool([]string{filter}, ts.Tool) || strings.EqualFold(string(ts.Service), filter) {
+				matched = append(matched, ts)
+			}
+		}
+		report.Tools = matched
+	}
+	if limit > 0 && len(report.Tools) > limit {
+		report.Tools = report.Tools[:limit]
+	}
+	if len(report.Tools) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No tool calls since %s.", report.Since.Format(time.RFC3339))), nil
+	}
+	return abstract.NewJSONResult(report), nil
+}
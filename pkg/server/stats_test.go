@@ -0,0 +1,96 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+func TestCallStats(t *testing.T) {
+	s := callStats{samples: 10}
+	now := time.Now()
+	for i := 1; i <= 20; i++ {
+		s.add("read_file", "FileSystem", time.Duration(i)*time.Millisecond, i%5 == 0, now)
+	}
+	s.add("browser_navigate", "Browser", 2*time.Second, false, now)
+	report := s.report()
+	if len(report) != 2 || report[0].Tool != "browser_navigate" {
+		t.Fatalf("the slowest tool must come first, got %+v", report)
+	}
+	rf := report[1]
+	// The percentiles are of the 10 latest calls, 11 to 20 ms, the maximum and mean of all of them.
+	if rf.Calls != 20 || rf.Failures != 4 || rf.P50 != 15 || rf.P95 != 20 || rf.Max != 20 || rf.Mean != 10.5 {
+		t.Fatalf("unexpected statistics %+v", rf)
+	}
+	if percentile(nil, 0.5) != 0 {
+		t.Fatal("the percentile of no calls must be 0")
+	}
+}
+
+func TestSlowCallsAndStatsReport(t *testing.T) {
+	var logs bytes.Buffer
+	m := &MoLingServer{
+		logger:  zerolog.New(&logs),
+		started: time.Now(),
+		mlConfig: config.MoLingConfig{
+			BasePath: t.TempDir(),
+			Stats:    config.StatsConfig{SlowThreshold: 50},
+			Audit:    config.NewAuditConfig(),
+		},
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"url": "https://example.com", "token": "ghp_abcdef"}
+	m.recordStats(&ToolCall{Tool: "http_get", Service: "Http", Started: time.Now()}, request, false)
+	if logs.Len() != 0 {
+		t.Fatalf("a fast call must not be logged, got %s", logs.String())
+	}
+	m.recordStats(&ToolCall{Tool: "http_get", Service: "Http", Started: time.Now().Add(-time.Second)}, request, true)
+	if !strings.Contains(logs.String(), "slow tool call") || !strings.Contains(logs.String(), "example.com") || strings.Contains(logs.String(), "ghp_abcdef") {
+		t.Fatalf("a slow call must be logged with its redacted arguments, got %s", logs.String())
+	}
+	m.recordStats(&ToolCall{Tool: "read_file", Service: "FileSystem", Started: time.Now()}, mcp.CallToolRequest{}, false)
+
+	request.Params.Arguments = map[string]any{"filter": "http"}
+	res, _ := m.handleStatsReport(context.Background(), request)
+	var report StatsReport
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tools) != 1 || report.Tools[0].Tool != "http_get" || report.Tools[0].Calls != 2 || report.Tools[0].Failures != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if err := m.dumpStats(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(m.mlConfig.BasePath, StatsFile))
+	if err != nil || json.Unmarshal(data, &report) != nil || len(report.Tools) != 2 {
+		t.Fatalf("the statistics must be written to the stats file, got %s, %v", data, err)
+	}
+}
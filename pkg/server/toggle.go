@@ -0,0 +1,269 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// ToggleFile records the services and tools disabled at runtime, relative to the base path. Running servers
+	// pick up changes to it, so that the CLI and other server instances can disable tools too.
+	ToggleFile = "config/disabled.json"
+	// DisableToolName and EnableToolName are the admin tools that change the toggles.
+	DisableToolName = "moling_disable"
+	EnableToolName  = "moling_enable"
+	// togglePollInterval is how often running servers check the toggle file for changes.
+	togglePollInterval = 2 * time.Second
+)
+
+// Toggles are the services and tools disabled at runtime, mapped to the reason they were disabled for.
+type Toggles struct {
+	Services map[string]string `json:"services"`
+	Tools    map[string]string `json:"tools"`
+}
+
+// LoadToggles reads the toggle file. A missing file disables nothing.
+func LoadToggles(path string) (Toggles, error) {
+	t := Toggles{Services: map[string]string{}, Tools: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	if err = json.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("invalid toggle file %s: %w", path, err)
+	}
+	if t.Services == nil {
+		t.Services = map[string]string{}
+	}
+	if t.Tools == nil {
+		t.Tools = map[string]string{}
+	}
+	return t, nil
+}
+
+// SaveToggles replaces the toggle file, so that readers never see a partial file.
+func SaveToggles(path string, t Toggles) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".disabled-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Disabled reports whether a tool is disabled, by itself or with its service, and why.
+func (t Toggles) Disabled(service comm.MoLingServerType, tool string) (string, bool) {
+	if reason, ok := t.Tools[tool]; ok {
+		return reason, true
+	}
+	for name, reason := range t.Services {
+		if strings.EqualFold(name, string(service)) {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+// disabled reports whether a tool is disabled at runtime and why.
+func (m *MoLingServer) disabled(service comm.MoLingServerType, tool string) (string, bool) {
+	if service == AdminServiceName {
+		return "", false
+	}
+	m.togglesLock.RLock()
+	defer m.togglesLock.RUnlock()
+	return m.toggles.Disabled(service, tool)
+}
+
+// toggleTool rejects calls to disabled tools.
+func (m *MoLingServer) toggleTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if reason, ok := m.disabled(m.toolServices[request.Params.Name], request.Params.Name); ok {
+			msg := m.locale.Sprintf("server.tool_disabled", request.Params.Name)
+			if reason != "" {
+				msg += ": " + reason
+			}
+			return abstract.NewErrorResult(abstract.CodePolicyDenied, msg), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// setToggles replaces the toggles and tells clients to fetch the tool list again.
+func (m *MoLingServer) setToggles(t Toggles) {
+	m.togglesLock.Lock()
+	m.toggles = t
+	m.togglesLock.Unlock()
+	m.logger.Info().Int("services", len(t.Services)).Int("tools", len(t.Tools)).Msg("disabled services and tools changed")
+	if m.server != nil {
+		m.server.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+	}
+}
+
+// watchToggles loads the toggle file when it changes, until the server context is done.
+func (m *MoLingServer) watchToggles() {
+	path := filepath.Join(m.mlConfig.BasePath, ToggleFile)
+	var modTime time.Time
+	load := func() {
+		info, err := os.Stat(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		var mod time.Time
+		if info != nil {
+			mod = info.ModTime()
+		}
+		if mod.Equal(modTime) {
+			return
+		}
+		modTime = mod
+		t, err := LoadToggles(path)
+		if err != nil {
+			m.logger.Warn().Err(err).Msg("failed to load the disabled services and tools")
+			return
+		}
+		m.setToggles(t)
+		m.logToClients(mcp.LoggingLevelNotice, serverLogName, map[string]any{"message": "disabled services and tools reloaded", "file": path})
+	}
+	load()
+	ticker := time.NewTicker(togglePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.watchCtx.Done():
+			return
+		case <-ticker.C:
+			load()
+		}
+	}
+}
+
+// addToggleTools registers the admin tools that disable and enable services and tools.
+func (m *MoLingServer) addToggleTools() {
+	m.toolServices[DisableToolName] = AdminServiceName
+	m.toolServices[EnableToolName] = AdminServiceName
+	m.server.AddTool(mcp.NewTool(
+		DisableToolName,
+		mcp.WithDescription("Disable a whole service or a single tool of the MoLing server until it is enabled again, e.g. to stop command execution during an incident. Disabled tools are hidden from all clients."),
+		mcp.WithString("service", mcp.Description("Name of the service to disable, e.g. Command")),
+		mcp.WithString("tool", mcp.Description("Name of the tool to disable, e.g. execute_command")),
+		mcp.WithString("reason", mcp.Description("Why it is disabled, shown to clients that call it")),
+	), m.handleToggle(false))
+	m.server.AddTool(mcp.NewTool(
+		EnableToolName,
+		mcp.WithDescription("Enable a service or tool of the MoLing server that was disabled."),
+		mcp.WithString("service", mcp.Description("Name of the service to enable")),
+		mcp.WithString("tool", mcp.Description("Name of the tool to enable")),
+	), m.handleToggle(true))
+}
+
+func (m *MoLingServer) handleToggle(enable bool) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		service := abstract.GetString(args, "service", "")
+		tool := abstract.GetString(args, "tool", "")
+		reason := abstract.GetString(args, "reason", "")
+		if (service == "") == (tool == "") {
+			return abstract.NewErrorResult(abstract.CodeInvalidArgument, "either service or tool must be given"), nil
+		}
+		if tool != "" {
+			s, ok := m.toolServices[tool]
+			if !ok {
+				return abstract.NewErrorResultf(abstract.CodeNotFound, "unknown tool %s", tool), nil
+			}
+			if s == AdminServiceName {
+				return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "the admin tool %s cannot be disabled", tool), nil
+			}
+		} else {
+			name, ok := m.serviceName(service)
+			if !ok {
+				return abstract.NewErrorResultf(abstract.CodeNotFound, "unknown service %s", service), nil
+			}
+			service = string(name)
+		}
+
+		path := filepath.Join(m.mlConfig.BasePath, ToggleFile)
+		m.togglesLock.Lock()
+		t, err := LoadToggles(path)
+		if err == nil {
+			switch {
+			case enable && tool != "":
+				delete(t.Tools, tool)
+			case enable:
+				for name := range t.Services {
+					if strings.EqualFold(name, service) {
+						delete(t.Services, name)
+					}
+				}
+			case tool != "":
+				t.Tools[tool] = reason
+			default:
+				t.Services[service] = reason
+			}
+			err = SaveToggles(path, t)
+		}
+		m.togglesLock.Unlock()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to save the disabled services and tools: %s", err.Error())), nil
+		}
+		m.setToggles(t)
+		event := m.logger.Warn().Bool("enable", enable).Str("service", service).Str("tool", tool)
+		if p, ok := m.principal(ctx); ok {
+			event = event.Str("principal", p.Name)
+		}
+		event.Msg("toggle changed")
+		data, _ := json.MarshalIndent(t, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Disabled services and tools:\n%s", data)), nil
+	}
+}
+
+// serviceName returns the name of the loaded service that matches name case-insensitively.
+func (m *MoLingServer) serviceName(name string) (comm.MoLingServerType, bool) {
+	for _, srv := range m.services {
+		if strings.EqualFold(string(srv.Name()), name) {
+			return srv.Name(), true
+		}
+	}
+	return "", false
+}
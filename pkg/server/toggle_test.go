@@ -0,0 +1,91 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestToggles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MoLingServer{
+		logger:   zerolog.Nop(),
+		mlConfig: config.MoLingConfig{BasePath: dir},
+		services: []abstract.Service{&healthService{name: "Command"}, &healthService{name: "FileSystem"}},
+		toolServices: map[string]comm.MoLingServerType{
+			"execute_command": "Command", "read_file": "FileSystem", "write_file": "FileSystem",
+			DisableToolName: AdminServiceName, EnableToolName: AdminServiceName,
+		},
+	}
+	all := []mcp.Tool{{Name: "execute_command"}, {Name: "read_file"}, {Name: "write_file"}, {Name: DisableToolName}}
+	toggle := func(enable bool, args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, _ := ms.handleToggle(enable)(context.Background(), req)
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	if _, isErr := toggle(false, map[string]any{"service": "command", "reason": "incident 42"}); isErr {
+		t.Fatalf("disabling the command service must succeed")
+	}
+	if _, isErr := toggle(false, map[string]any{"tool": "write_file"}); isErr {
+		t.Fatalf("disabling write_file must succeed")
+	}
+	for _, args := range []map[string]any{{"tool": DisableToolName}, {"tool": "missing"}, {"service": "Missing"}, {}} {
+		if _, isErr := toggle(false, args); !isErr {
+			t.Fatalf("expected an error for %v", args)
+		}
+	}
+	tools := ms.filterTools(context.Background(), all)
+	if len(tools) != 2 || tools[0].Name != "read_file" || tools[1].Name != DisableToolName {
+		t.Fatalf("unexpected tools %v", tools)
+	}
+	handler := ms.toggleTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "execute_command"
+	res, _ := handler(context.Background(), req)
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "incident 42") {
+		t.Fatalf("execute_command must be rejected with the reason, got %+v", res.Content)
+	}
+
+	saved, err := LoadToggles(filepath.Join(dir, ToggleFile))
+	if err != nil || saved.Services["Command"] != "incident 42" || len(saved.Tools) != 1 {
+		t.Fatalf("unexpected toggle file %+v, %v", saved, err)
+	}
+	if _, isErr := toggle(true, map[string]any{"service": "Command"}); isErr {
+		t.Fatalf("enabling the command service must succeed")
+	}
+	if len(ms.filterTools(context.Background(), all)) != 3 {
+		t.Fatalf("the command service must be enabled again")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// exposeTool returns the tool of a service under the name that the tool_names configuration gives it, with a
+// handler that calls the service with the name the service gave the tool.
+func (m *MoLingServer) exposeTool(service comm.MoLingServerType, tool server.ServerTool) server.ServerTool {
+	original := tool.Tool.Name
+	name := m.mlConfig.ToolNames.Name(string(service), original)
+	if name == original {
+		return tool
+	}
+	m.originalNames[name] = original
+	if _, ok := m.exposedNames[original]; !ok {
+		m.exposedNames[original] = name
+	}
+	handler := tool.Handler
+	tool.Tool.Name = name
+	tool.Handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request.Params.Name = original
+		return handler(ctx, request)
+	}
+	return tool
+}
+
+// originalName returns the name the service gave the tool served under name.
+func (m *MoLingServer) originalName(name string) string {
+	if original, ok := m.originalNames[name]; ok {
+		return original
+	}
+	return name
+}
+
+// resolveTool returns the name a tool is served under, for the name the service gave it if the tool was renamed.
+func (m *MoLingServer) resolveTool(name string) (string, bool) {
+	if _, ok := m.toolServices[name]; ok {
+		return name, true
+	}
+	exposed, ok := m.exposedNames[name]
+	return exposed, ok
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// namedService is a service whose whoami tool returns its name and the name of the tool it was called with.
+type namedService struct {
+	abstract.Service
+	name comm.MoLingServerType
+}
+
+func (ns *namedService) Name() comm.MoLingServerType { return ns.name }
+func (ns *namedService) Resources() map[mcp.Resource]server.ResourceHandlerFunc {
+	return nil
+}
+func (ns *namedService) ResourceTemplates() map[mcp.ResourceTemplate]server.ResourceTemplateHandlerFunc {
+	return nil
+}
+func (ns *namedService) NotificationHandlers() map[string]server.NotificationHandlerFunc { return nil }
+func (ns *namedService) Prompts() []abstract.PromptEntry                                 { return nil }
+func (ns *namedService) Close() error                                                    { return nil }
+func (ns *namedService) Tools() []server.ServerTool {
+	return []server.ServerTool{{
+		Tool: mcp.NewTool("whoami"),
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(string(ns.name) + " " + request.Params.Name), nil
+		},
+	}}
+}
+
+func TestToolNames(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newServer := func(names config.ToolNamesConfig) *MoLingServer {
+		srv, err := NewMoLingServer(ctx, []abstract.Service{&namedService{name: "A"}, &namedService{name: "B"}}, config.MoLingConfig{BasePath: t.TempDir(), ToolNames: names})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return srv
+	}
+	call := func(srv *MoLingServer, tool string) string {
+		res, err := srv.CallTool(context.Background(), tool, nil)
+		if err != nil {
+			t.Fatalf("failed to call %s: %v", tool, err)
+		}
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	srv := newServer(config.NewToolNamesConfig())
+	if got := call(srv, "whoami"); got != "A whoami" {
+		t.Fatalf("the tool of the first service must be kept on a collision, got %q", got)
+	}
+
+	srv = newServer(config.ToolNamesConfig{Prefixes: map[string]string{"B": "b_"}})
+	if got := call(srv, "whoami"); got != "A whoami" {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if got := call(srv, "b_whoami"); got != "B whoami" {
+		t.Fatalf("the prefixed tool must be called with the name of its service, got %q", got)
+	}
+	if srv.ToolService("b_whoami") != "B" {
+		t.Fatalf("the prefixed tool must belong to its service")
+	}
+
+	srv = newServer(config.ToolNamesConfig{Prefixes: map[string]string{"B": "b_"}, Rename: map[string]string{"A.whoami": "who"}})
+	tools, err := srv.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]bool)
+	for _, tool := range tools {
+		listed[tool.Name] = true
+	}
+	if !listed["who"] || !listed["b_whoami"] || listed["whoami"] {
+		t.Fatalf("tools/list must show the new names, got %v", listed)
+	}
+	if got := call(srv, "whoami"); got != "A whoami" {
+		t.Fatalf("a renamed tool must be found by the name its service gave it, got %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// validateTool rejects tool calls whose arguments do not match the input schema of the tool, before the handler
+// runs, with an error that names every invalid argument.
+func (m *MoLingServer) validateTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tool, ok := m.tools[request.Params.Name]
+		if !ok {
+			return next(ctx, request)
+		}
+		if err := abstract.ValidateArguments(tool, request.GetArguments()); err != nil {
+			m.logger.Debug().Str("tool", tool.Name).Err(err).Msg("tool call rejected")
+			return nil, err
+		}
+		return next(ctx, request)
+	}
+}
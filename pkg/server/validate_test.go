@@ -0,0 +1,64 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestValidateTool(t *testing.T) {
+	ms := &MoLingServer{logger: zerolog.Nop(), tools: map[string]mcp.Tool{
+		"read_file": mcp.NewTool("read_file", mcp.WithString("path", mcp.Required()), mcp.WithNumber("limit")),
+	}}
+	var ran bool
+	handler := ms.errorCodeTool(ms.validateTool(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ran = true
+		return mcp.NewToolResultText("done"), nil
+	}))
+	call := func(tool string, args map[string]any) *mcp.CallToolResult {
+		ran = false
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tool, err)
+		}
+		return res
+	}
+
+	res := call("read_file", map[string]any{"limit": "ten"})
+	if ran || !res.IsError || abstract.ErrorCodeOf(res) != abstract.CodeInvalidArgument {
+		t.Fatalf("invalid calls must be rejected before the handler runs, ran %v, got %+v", ran, res)
+	}
+	if text := resultText(res); !strings.Contains(text, "limit: expected number, got string") || !strings.Contains(text, "path: is required") {
+		t.Fatalf("the error must name every invalid argument, got %q", text)
+	}
+	if res = call("read_file", map[string]any{"path": "a.txt", "limit": float64(10)}); !ran || res.IsError {
+		t.Fatalf("valid calls must run, got %+v", res)
+	}
+	if res = call("unknown", map[string]any{"x": 1}); !ran || res.IsError {
+		t.Fatalf("calls of unknown tools are left to the next middleware, got %+v", res)
+	}
+}
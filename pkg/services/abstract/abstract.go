@@ -18,6 +18,7 @@ package abstract
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -28,6 +29,92 @@ import (
 
 type ServiceFactory func(ctx context.Context) (Service, error)
 
+// NotifyFunc sends a notification with the given method and params to all connected clients.
+type NotifyFunc func(method string, params map[string]any)
+
+// Notifier is implemented by services that push notifications to connected clients.
+type Notifier interface {
+	SetNotifyFunc(fn NotifyFunc)
+}
+
+// ClientLogFunc sends a log message to the connected clients that asked for messages of its level.
+type ClientLogFunc func(level mcp.LoggingLevel, logger string, data any)
+
+// ClientLogger is implemented by services that report important events, such as a crashed browser, to connected clients.
+type ClientLogger interface {
+	SetClientLogFunc(fn ClientLogFunc)
+}
+
+// ServiceLookup returns the loaded service with the given name.
+type ServiceLookup func(name comm.MoLingServerType) (Service, bool)
+
+// Linker is implemented by services that call into other loaded services.
+type Linker interface {
+	SetServiceLookup(fn ServiceLookup)
+}
+
+// ResourcePublisher adds and removes resources and announces changes to them after a service is loaded.
+type ResourcePublisher interface {
+	AddResource(rs mcp.Resource, hr server.ResourceHandlerFunc)
+	RemoveResource(uri string)
+	ResourceUpdated(uri string)
+}
+
+// Publisher is implemented by services whose resources change while the server runs.
+type Publisher interface {
+	SetResourcePublisher(p ResourcePublisher)
+}
+
+// ClientRequester sends requests to the client of the tool call being handled.
+type ClientRequester interface {
+	// ClientSupports reports whether the client declared a capability, such as elicitation or sampling.
+	ClientSupports(ctx context.Context, capability string) bool
+	// RequestClient sends a request to the client and returns the raw result.
+	RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+// RootsProvider is implemented by client requesters that keep the roots of each client, so they are not requested on every call.
+type RootsProvider interface {
+	ClientRoots(ctx context.Context) ([]mcp.Root, error)
+}
+
+// Requester is implemented by services that send requests to clients, such as to ask the user a question.
+type Requester interface {
+	SetClientRequester(r ClientRequester)
+}
+
+// Dependent is implemented by services that call into other loaded services. They are closed before the services
+// they depend on when the server shuts down.
+type Dependent interface {
+	// Dependencies returns the names of the services the service calls, none for any of the loaded services.
+	Dependencies() []comm.MoLingServerType
+}
+
+// Preflighter is implemented by services that can check before Init whether they are able to start, such as
+// whether the programs they run are installed. A service that fails it is disabled and the others keep running.
+type Preflighter interface {
+	// Preflight returns why the service cannot start and what to change, nil if it can.
+	Preflight() error
+}
+
+// HealthChecker is implemented by services that can check their own health, such as whether a browser still responds.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// ProcessOwner is implemented by services that run child processes, such as a browser, so that their memory and CPU
+// count against the budget of the service.
+type ProcessOwner interface {
+	// Processes returns the IDs of the running child processes. The processes they start are counted with them.
+	Processes() []int
+}
+
+// Restarter is implemented by services that can restart their child processes, such as a browser that uses too
+// much memory.
+type Restarter interface {
+	Restart() error
+}
+
 // Service defines the interface for a service with various handlers and tools.
 type Service interface {
 	Ctx() context.Context
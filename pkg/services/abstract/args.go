@@ -0,0 +1,100 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tool arguments arrive decoded from JSON, so numbers are float64 and arrays are []any. The accessors below read
+// them with the type the handler wants and fall back to a default if an argument is missing, null or of another type.
+
+// GetString returns the string argument key, or def.
+func GetString(args map[string]any, key, def string) string {
+	if s, ok := args[key].(string); ok {
+		return s
+	}
+	return def
+}
+
+// GetFloat returns the number argument key, or def. Numbers given as strings are parsed.
+func GetFloat(args map[string]any, key string, def float64) float64 {
+	switch v := args[key].(type) {
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f
+		}
+	default:
+		if f, ok := number(v); ok {
+			return f
+		}
+	}
+	return def
+}
+
+// GetInt returns the number argument key truncated to an int, or def. Numbers given as strings are parsed.
+func GetInt(args map[string]any, key string, def int) int {
+	f := GetFloat(args, key, math.NaN())
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return def
+	}
+	return int(f)
+}
+
+// GetBool returns the boolean argument key, or def. The strings "true" and "false" are accepted too.
+func GetBool(args map[string]any, key string, def bool) bool {
+	switch v := args[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// GetStringSlice returns the string items of the array argument key, or def if it is not an array. Items that are
+// not strings are skipped.
+func GetStringSlice(args map[string]any, key string, def []string) []string {
+	switch v := args[key].(type) {
+	case []string:
+		return v
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return def
+}
+
+// GetMap returns the object argument key, or nil.
+func GetMap(args map[string]any, key string) map[string]any {
+	m, _ := args[key].(map[string]any)
+	return m
+}
+
+// Has reports whether the argument key is set to a value other than null.
+func Has(args map[string]any, key string) bool {
+	return args[key] != nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestArgs(t *testing.T) {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(`{"name":"a","width":1280,"ratio":0.5,"count":"7","on":true,"off":"false","tags":["x",1,"y"],"opts":{"k":1},"nothing":null}`), &args); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := GetString(args, "name", "b"); got != "a" {
+		t.Errorf("GetString = %q", got)
+	}
+	if got := GetString(args, "width", "b"); got != "b" {
+		t.Errorf("GetString of a number must return the default, got %q", got)
+	}
+	if got := GetInt(args, "width", 800); got != 1280 {
+		t.Errorf("GetInt of a JSON number = %d, want 1280", got)
+	}
+	if got := GetInt(args, "count", 0); got != 7 {
+		t.Errorf("GetInt of a numeric string = %d, want 7", got)
+	}
+	if got := GetInt(args, "name", 3); got != 3 {
+		t.Errorf("GetInt of a string = %d, want the default", got)
+	}
+	if got := GetInt(args, "missing", 3); got != 3 {
+		t.Errorf("GetInt of a missing argument = %d, want the default", got)
+	}
+	if got := GetFloat(args, "ratio", 1); got != 0.5 {
+		t.Errorf("GetFloat = %v", got)
+	}
+	if got := GetInt(map[string]any{"n": 42}, "n", 0); got != 42 {
+		t.Errorf("GetInt of an int = %d", got)
+	}
+	if !GetBool(args, "on", false) || GetBool(args, "off", true) || !GetBool(args, "missing", true) {
+		t.Error("unexpected GetBool results")
+	}
+	if got := GetStringSlice(args, "tags", nil); !slices.Equal(got, []string{"x", "y"}) {
+		t.Errorf("GetStringSlice = %v", got)
+	}
+	if got := GetStringSlice(args, "name", []string{"d"}); !slices.Equal(got, []string{"d"}) {
+		t.Errorf("GetStringSlice of a string must return the default, got %v", got)
+	}
+	if got := GetMap(args, "opts"); got["k"] != float64(1) {
+		t.Errorf("GetMap = %v", got)
+	}
+	if Has(args, "nothing") || Has(args, "missing") || !Has(args, "name") {
+		t.Error("Has must report arguments set to a value other than null")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// DryRunArgument is the argument that asks a tool declaring it to describe what it would do, without doing it.
+	DryRunArgument = "dry_run"
+	// DryRunMetaKey marks the results of dry runs in their _meta.
+	DryRunMetaKey = "moling/dryRun"
+)
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context in which tool calls are dry runs, whatever their arguments.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether a tool call must only describe what it would do: if the server runs in dry-run mode,
+// or if the client set the dry_run argument.
+func IsDryRun(ctx context.Context, request mcp.CallToolRequest) bool {
+	if dryRun, _ := ctx.Value(dryRunKey{}).(bool); dryRun {
+		return true
+	}
+	dryRun, _ := request.GetArguments()[DryRunArgument].(bool)
+	return dryRun
+}
+
+// WithDryRunArgument declares the dry_run argument on a tool that changes something, so that clients can check the
+// effect of a call before making it.
+func WithDryRunArgument() mcp.ToolOption {
+	return mcp.WithBoolean(DryRunArgument,
+		mcp.Description("Only describe what the call would do, without changing anything"),
+	)
+}
+
+// NewDryRunResult returns the result of a dry run, describing what the call would have done.
+func NewDryRunResult(format string, a ...any) *mcp.CallToolResult {
+	result := mcp.NewToolResultText("Dry run, nothing was changed: " + fmt.Sprintf(format, a...))
+	result.Meta = map[string]any{DryRunMetaKey: true}
+	return result
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ElicitationCapability is the client capability needed to ask the user questions.
+	ElicitationCapability = "elicitation"
+	// MethodElicitationCreate is the request that asks the user a question.
+	MethodElicitationCreate = "elicitation/create"
+
+	ElicitAccept  = "accept"  // ElicitAccept means the user submitted the requested values.
+	ElicitDecline = "decline" // ElicitDecline means the user explicitly refused.
+	ElicitCancel  = "cancel"  // ElicitCancel means the user dismissed the question.
+)
+
+// ErrElicitationUnavailable means the user cannot be asked: elicitation is disabled, or the client does not support it.
+var ErrElicitationUnavailable = errors.New("the user cannot be asked for input")
+
+// ElicitResult is the answer of the user to an elicitation request.
+type ElicitResult struct {
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// Accepted reports whether the user submitted the requested values.
+func (er *ElicitResult) Accepted() bool {
+	return er.Action == ElicitAccept
+}
+
+// SetClientRequester sets the requester used to ask the client of a tool call for input.
+func (mls *MLService) SetClientRequester(r ClientRequester) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.requester = r
+}
+
+// CanElicit reports whether the user of the tool call handled in ctx can be asked for input.
+func (mls *MLService) CanElicit(ctx context.Context) bool {
+	mls.lock.Lock()
+	requester := mls.requester
+	mls.lock.Unlock()
+	if requester == nil || mls.mlConfig == nil || !mls.mlConfig.Elicitation.Enabled {
+		return false
+	}
+	return requester.ClientSupports(ctx, ElicitationCapability)
+}
+
+// Elicit asks the user of the tool call handled in ctx for the values described by schema, a flat JSON Schema object
+// of primitive properties. It returns ErrElicitationUnavailable if the user cannot be asked.
+func (mls *MLService) Elicit(ctx context.Context, message string, schema map[string]any) (*ElicitResult, error) {
+	if !mls.CanElicit(ctx) {
+		return nil, ErrElicitationUnavailable
+	}
+	mls.lock.Lock()
+	requester := mls.requester
+	mls.lock.Unlock()
+	return ElicitClient(ctx, requester, mls.mlConfig.Elicitation.Timeout, message, schema)
+}
+
+// ElicitClient sends an elicitation request through r and waits up to timeout seconds for the answer, 0 for no limit.
+func ElicitClient(ctx context.Context, r ClientRequester, timeout int, message string, schema map[string]any) (*ElicitResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+	raw, err := r.RequestClient(ctx, MethodElicitationCreate, map[string]any{
+		"message":         message,
+		"requestedSchema": schema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("elicitation failed: %w", err)
+	}
+	var result ElicitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid elicitation result: %w", err)
+	}
+	return &result, nil
+}
+
+// Confirm asks the user to confirm an action. It reports true only if the user accepted and confirmed.
+func (mls *MLService) Confirm(ctx context.Context, message string) (bool, error) {
+	result, err := mls.Elicit(ctx, message, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"confirm": map[string]any{"type": "boolean", "title": "Confirm", "description": message},
+		},
+		"required": []string{"confirm"},
+	})
+	if err != nil {
+		return false, err
+	}
+	confirmed, _ := result.Content["confirm"].(bool)
+	return result.Accepted() && confirmed, nil
+}
+
+// AskString asks the user for a single value, such as a 2FA code. It returns ErrElicitationUnavailable if the user
+// cannot be asked, and an error if the user declined.
+func (mls *MLService) AskString(ctx context.Context, message, name, description string) (string, error) {
+	result, err := mls.Elicit(ctx, message, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			name: map[string]any{"type": "string", "description": description},
+		},
+		"required": []string{name},
+	})
+	if err != nil {
+		return "", err
+	}
+	value, _ := result.Content[name].(string)
+	if !result.Accepted() || value == "" {
+		return "", fmt.Errorf("the user did not provide %s", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ErrorCode is the machine-readable kind of a failed tool call. Clients find it in the meta of the result under
+// ErrorMetaKey, so that agents can decide whether to retry, fix their arguments or give up without parsing the text.
+type ErrorCode string
+
+const (
+	CodePolicyDenied    ErrorCode = "policy_denied"    // CodePolicyDenied is a call that roles, policies, allowed directories or the user do not allow.
+	CodeTimeout         ErrorCode = "timeout"          // CodeTimeout is a call that took too long.
+	CodeNotFound        ErrorCode = "not_found"        // CodeNotFound is a file, tool, job or other thing that does not exist.
+	CodeInvalidArgument ErrorCode = "invalid_argument" // CodeInvalidArgument is a call with missing or invalid arguments.
+	CodeUpstreamFailure ErrorCode = "upstream_failure" // CodeUpstreamFailure is a failure of a program or remote API the tool uses.
+	CodeUnavailable     ErrorCode = "unavailable"      // CodeUnavailable is a call refused because the server is busy, throttled or shutting down.
+	CodeInternal        ErrorCode = "internal"         // CodeInternal is any other failure.
+)
+
+// ErrorMetaKey is the result meta field that carries the code of a failed tool call and whether to retry it.
+const ErrorMetaKey = "error"
+
+// Retryable reports whether a call that failed with the code may succeed if it is retried unchanged.
+func (c ErrorCode) Retryable() bool {
+	return c == CodeTimeout || c == CodeUpstreamFailure || c == CodeUnavailable
+}
+
+// ToolError is an error with a code. Handlers may return it as their error, the server turns it into a failed
+// tool result with the code.
+type ToolError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *ToolError) Error() string { return e.Err.Error() }
+func (e *ToolError) Unwrap() error { return e.Err }
+
+// Errorf formats an error with a code.
+func Errorf(code ErrorCode, format string, a ...any) error {
+	return &ToolError{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// NewErrorResult returns a failed tool result with the text and the code.
+func NewErrorResult(code ErrorCode, text string) *mcp.CallToolResult {
+	return WithErrorCode(mcp.NewToolResultError(text), code)
+}
+
+// NewErrorResultf returns a failed tool result with a formatted text and the code.
+func NewErrorResultf(code ErrorCode, format string, a ...any) *mcp.CallToolResult {
+	return NewErrorResult(code, fmt.Sprintf(format, a...))
+}
+
+// WithErrorCode returns a copy of a failed tool result with the code in its meta.
+func WithErrorCode(result *mcp.CallToolResult, code ErrorCode) *mcp.CallToolResult {
+	copied := *result
+	copied.Meta = maps.Clone(result.Meta)
+	if copied.Meta == nil {
+		copied.Meta = make(map[string]any)
+	}
+	copied.Meta[ErrorMetaKey] = map[string]any{"code": string(code), "retryable": code.Retryable()}
+	return &copied
+}
+
+// ErrorCodeOf returns the code of a failed tool result, empty if it has none.
+func ErrorCodeOf(result *mcp.CallToolResult) ErrorCode {
+	if result == nil || !result.IsError {
+		return ""
+	}
+	if e, ok := result.Meta[ErrorMetaKey].(map[string]any); ok {
+		if code, ok := e["code"].(string); ok {
+			return ErrorCode(code)
+		}
+	}
+	return ""
+}
+
+// ClassifyError returns the code of an error: the code of a ToolError it wraps, else the code of the standard
+// errors it wraps, else the code its message suggests.
+func ClassifyError(err error) ErrorCode {
+	var te *ToolError
+	switch {
+	case errors.As(err, &te):
+		return te.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, fs.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return CodePolicyDenied
+	}
+	return ClassifyMessage(err.Error())
+}
+
+// messageCodes are the phrases that suggest the code of an error message, checked in order.
+var messageCodes = []struct {
+	code    ErrorCode
+	phrases []string
+}{
+	{CodeTimeout, []string{"deadline exceeded", "timed out", "timeout", "did not finish within"}},
+	{CodeUnavailable, []string{"rate limit", "server busy", "shutting down", "try again later", "over its resource budget"}},
+	{CodePolicyDenied, []string{"access denied", "permission denied", "denied by", "not allowed", "forbidden", "outside allowed", "did not approve", "declined", "disabled"}},
+	{CodeNotFound, []string{"not found", "no such file", "does not exist", "unknown tool", "unknown service"}},
+	{CodeInvalidArgument, []string{"invalid", "must be", "must not", "is required", "are required", "missing", "unsupported", "expected"}},
+	{CodeUpstreamFailure, []string{"status code", "http ", "connection refused", "no such host", "api error", "upstream", "failed with", "exit status"}},
+}
+
+// ClassifyMessage returns the code an error message suggests, for the results of tools that do not set one.
+// It is CodeInternal if the message suggests none.
+func ClassifyMessage(msg string) ErrorCode {
+	msg = strings.ToLower(msg)
+	for _, mc := range messageCodes {
+		for _, phrase := range mc.phrases {
+			if strings.Contains(msg, phrase) {
+				return mc.code
+			}
+		}
+	}
+	return CodeInternal
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := map[string]ErrorCode{
+		"context deadline exceeded":                           CodeTimeout,
+		"rate limit exceeded for tool read_file":              CodeUnavailable,
+		"access denied - path outside allowed directories: /": CodePolicyDenied,
+		"Unknown tool: foo":                                   CodeNotFound,
+		"path must be a string":                               CodeInvalidArgument,
+		"GitHub API error: status code 502":                   CodeUpstreamFailure,
+		"something odd happened":                              CodeInternal,
+	}
+	for msg, want := range tests {
+		if got := ClassifyMessage(msg); got != want {
+			t.Errorf("ClassifyMessage(%q) = %q, want %q", msg, got, want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	_, statErr := os.Stat("/no/such/path/for/moling")
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{fmt.Errorf("wrapped: %w", Errorf(CodeUnavailable, "access denied")), CodeUnavailable},
+		{fmt.Errorf("waiting: %w", context.DeadlineExceeded), CodeTimeout},
+		{statErr, CodeNotFound},
+		{fmt.Errorf("open: %w", os.ErrPermission), CodePolicyDenied},
+		{fmt.Errorf("invalid pattern"), CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithErrorCode(t *testing.T) {
+	orig := mcp.NewToolResultError("boom")
+	orig.Meta = map[string]any{"other": 1}
+	res := WithErrorCode(orig, CodeUpstreamFailure)
+	if ErrorCodeOf(res) != CodeUpstreamFailure || res.Meta["other"] != 1 {
+		t.Fatalf("unexpected meta %+v", res.Meta)
+	}
+	if _, ok := orig.Meta[ErrorMetaKey]; ok {
+		t.Fatal("WithErrorCode must not change the original result")
+	}
+	if e := res.Meta[ErrorMetaKey].(map[string]any); e["retryable"] != true {
+		t.Fatalf("upstream failures must be retryable, got %v", e)
+	}
+	if NewErrorResult(CodeInvalidArgument, "bad").Meta[ErrorMetaKey].(map[string]any)["retryable"] != false {
+		t.Fatal("invalid arguments must not be retryable")
+	}
+	if ErrorCodeOf(mcp.NewToolResultText("ok")) != "" {
+		t.Fatal("successful results have no code")
+	}
+}
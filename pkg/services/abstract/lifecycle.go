@@ -0,0 +1,207 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// Startup is the outcome of the initialization of a service.
+type Startup struct {
+	Name     comm.MoLingServerType
+	Service  Service                 // Service is the initialized service, nil if it failed.
+	Err      error                   // Err is why the service failed to initialize.
+	Missing  []comm.MoLingServerType // Missing are the dependencies that are not running, the service runs without them.
+	Duration time.Duration
+}
+
+// StartServices initializes the services concurrently. A service is initialized once the services it depends on
+// are, and services that depend on any service after all the others. A service that fails does not stop the others:
+// the services depending on it are initialized without it and report it as missing. The startups are returned in
+// the order the services may be loaded in, dependencies first.
+func StartServices(ctx context.Context, srvs []Service) []Startup {
+	order, deps := startOrder(srvs)
+	done := make(map[comm.MoLingServerType]chan struct{}, len(order))
+	for _, srv := range order {
+		done[srv.Name()] = make(chan struct{})
+	}
+	startups := make([]Startup, len(order))
+	index := make(map[comm.MoLingServerType]int, len(order))
+	for i, srv := range order {
+		index[srv.Name()] = i
+	}
+	var wg sync.WaitGroup
+	for i, srv := range order {
+		wg.Add(1)
+		go func(i int, srv Service) {
+			defer wg.Done()
+			defer close(done[srv.Name()])
+			s := Startup{Name: srv.Name()}
+			for _, dep := range deps[srv.Name()] {
+				ch, ok := done[dep]
+				if !ok {
+					s.Missing = append(s.Missing, dep)
+					continue
+				}
+				select {
+				case <-ch:
+				case <-ctx.Done():
+					// The dependency may still be starting and writing its startup
+					s.Missing = append(s.Missing, dep)
+					continue
+				}
+				// The startup of the dependency is written before its channel is closed
+				if d := startups[index[dep]]; d.Err != nil || d.Service == nil {
+					s.Missing = append(s.Missing, dep)
+				}
+			}
+			started := time.Now()
+			s.Err = initService(ctx, srv)
+			s.Duration = time.Since(started)
+			if s.Err == nil {
+				s.Service = srv
+			}
+			startups[i] = s
+		}(i, srv)
+	}
+	wg.Wait()
+	return startups
+}
+
+// PreflightError is the error of a service that failed its preflight check and was not initialized.
+type PreflightError struct {
+	Err error
+}
+
+func (e *PreflightError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// initService runs the preflight check of a service and initializes it, turning a panic into an error so that the
+// other services keep starting.
+func initService(ctx context.Context, srv Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if p, ok := srv.(Preflighter); ok {
+		if err = p.Preflight(); err != nil {
+			return &PreflightError{Err: err}
+		}
+	}
+	return srv.Init()
+}
+
+// startOrder sorts the services so that every service comes after the services it depends on, by name otherwise,
+// and returns the dependencies of each service that come before it. Services that depend on any service come after
+// all the others. Dependencies in a cycle are dropped. Dependencies that are not among the services are kept,
+// they are reported as missing.
+func startOrder(srvs []Service) ([]Service, map[comm.MoLingServerType][]comm.MoLingServerType) {
+	byName := make(map[comm.MoLingServerType]Service, len(srvs))
+	names := make([]comm.MoLingServerType, 0, len(srvs))
+	for _, srv := range srvs {
+		byName[srv.Name()] = srv
+		names = append(names, srv.Name())
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	declared := make(map[comm.MoLingServerType][]comm.MoLingServerType, len(srvs))
+	var late []comm.MoLingServerType
+	for _, name := range names {
+		d, ok := byName[name].(Dependent)
+		if !ok {
+			continue
+		}
+		if deps := d.Dependencies(); deps != nil {
+			declared[name] = deps
+		} else {
+			late = append(late, name)
+		}
+	}
+	isLate := make(map[comm.MoLingServerType]bool, len(late))
+	for _, name := range late {
+		isLate[name] = true
+	}
+
+	deps := make(map[comm.MoLingServerType][]comm.MoLingServerType, len(srvs))
+	placed := make(map[comm.MoLingServerType]bool, len(srvs))
+	order := make([]Service, 0, len(srvs))
+	place := func(name comm.MoLingServerType) {
+		for _, dep := range declared[name] {
+			if _, ok := byName[dep]; !ok || placed[dep] {
+				deps[name] = append(deps[name], dep)
+			}
+		}
+		placed[name] = true
+		order = append(order, byName[name])
+	}
+	// Place the services whose dependencies are placed, by name, until none is left. Services in a cycle are
+	// placed by name and lose the dependencies on the services of the cycle placed after them.
+	for pending := len(names) - len(late); pending > 0; {
+		progress := false
+		for _, name := range names {
+			if placed[name] || isLate[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range declared[name] {
+				if _, ok := byName[dep]; ok && !placed[dep] && !isLate[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				place(name)
+				pending--
+				progress = true
+			}
+		}
+		if !progress {
+			for _, name := range names {
+				if !placed[name] && !isLate[name] {
+					place(name)
+					pending--
+					break
+				}
+			}
+		}
+	}
+	for _, name := range late {
+		for _, other := range names {
+			if !isLate[other] {
+				deps[name] = append(deps[name], other)
+			}
+		}
+		placed[name] = true
+		order = append(order, byName[name])
+	}
+	return order, deps
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// startService records when it was initialized.
+type startService struct {
+	Service
+	name  comm.MoLingServerType
+	deps  []comm.MoLingServerType
+	any   bool
+	err   error
+	delay time.Duration
+	log   *startLog
+}
+
+type startLog struct {
+	sync.Mutex
+	inits []comm.MoLingServerType
+}
+
+func (s *startService) Name() comm.MoLingServerType { return s.name }
+func (s *startService) Init() error {
+	time.Sleep(s.delay)
+	s.log.Lock()
+	s.log.inits = append(s.log.inits, s.name)
+	s.log.Unlock()
+	if s.name == "Panics" {
+		panic("boom")
+	}
+	return s.err
+}
+
+// dependentService is a startService that declares its dependencies.
+type dependentService struct {
+	*startService
+}
+
+func (s dependentService) Dependencies() []comm.MoLingServerType {
+	if s.any {
+		return nil
+	}
+	return s.deps
+}
+
+func TestStartServices(t *testing.T) {
+	log := &startLog{}
+	srvs := []Service{
+		dependentService{&startService{name: "Pipeline", any: true, log: log}},
+		dependentService{&startService{name: "Summary", deps: []comm.MoLingServerType{"Browser", "LLM", "Weather"}, log: log}},
+		&startService{name: "Browser", delay: 20 * time.Millisecond, log: log},
+		&startService{name: "LLM", err: errors.New("no API key"), log: log},
+		&startService{name: "Panics", log: log},
+		&startService{name: "FileSystem", log: log},
+	}
+	startups := StartServices(context.Background(), srvs)
+	if len(startups) != len(srvs) {
+		t.Fatalf("expected a startup of every service, got %d", len(startups))
+	}
+	pos := make(map[comm.MoLingServerType]int)
+	for i, name := range log.inits {
+		pos[name] = i
+	}
+	if pos["Summary"] < pos["Browser"] || pos["Summary"] < pos["LLM"] {
+		t.Fatalf("a service must be initialized after its dependencies, got %v", log.inits)
+	}
+	if pos["Pipeline"] != len(srvs)-1 || startups[len(startups)-1].Name != "Pipeline" {
+		t.Fatalf("a service that depends on any service must come last, got %v", log.inits)
+	}
+	byName := make(map[comm.MoLingServerType]Startup)
+	for _, s := range startups {
+		byName[s.Name] = s
+	}
+	if s := byName["LLM"]; s.Err == nil || s.Service != nil {
+		t.Fatalf("the failure of a service must be reported, got %+v", s)
+	}
+	if s := byName["Panics"]; s.Err == nil {
+		t.Fatal("a panic must be reported as a failure")
+	}
+	if s := byName["Summary"]; s.Err != nil || s.Service == nil || len(s.Missing) != 2 {
+		t.Fatalf("a service must start without its failed and disabled dependencies, got %+v", s)
+	}
+	if s := byName["FileSystem"]; s.Err != nil || s.Service == nil {
+		t.Fatalf("the other services must start, got %+v", s)
+	}
+}
+
+func TestStartOrderCycle(t *testing.T) {
+	log := &startLog{}
+	srvs := []Service{
+		dependentService{&startService{name: "A", deps: []comm.MoLingServerType{"B"}, log: log}},
+		dependentService{&startService{name: "B", deps: []comm.MoLingServerType{"A"}, log: log}},
+	}
+	done := make(chan []Startup)
+	go func() { done <- StartServices(context.Background(), srvs) }()
+	select {
+	case startups := <-done:
+		if len(startups) != 2 || startups[0].Err != nil || startups[1].Err != nil {
+			t.Fatalf("services in a cycle must start, got %+v", startups)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("services in a cycle must not wait for each other")
+	}
+}
+
+// preflightService is a startService with a preflight check.
+type preflightService struct {
+	*startService
+	preflight error
+}
+
+func (s preflightService) Preflight() error { return s.preflight }
+
+func TestStartServicesPreflight(t *testing.T) {
+	log := &startLog{}
+	srvs := []Service{
+		preflightService{&startService{name: "Browser", log: log}, errors.New("chrome not found")},
+		preflightService{&startService{name: "Screen", log: log}, nil},
+	}
+	startups := StartServices(context.Background(), srvs)
+	var pe *PreflightError
+	if s := startups[0]; s.Name != "Browser" || !errors.As(s.Err, &pe) || pe.Error() != "chrome not found" || s.Service != nil {
+		t.Fatalf("a failed preflight check must be reported, got %+v", s)
+	}
+	if len(log.inits) != 1 || log.inits[0] != "Screen" {
+		t.Fatalf("only the services that pass their preflight check must be initialized, got %v", log.inits)
+	}
+}
+
+// blockingService is a startService whose Init waits until it is released.
+type blockingService struct {
+	*startService
+	started, release chan struct{}
+}
+
+func (s blockingService) Init() error {
+	close(s.started)
+	<-s.release
+	return s.startService.Init()
+}
+
+func TestStartServicesCanceled(t *testing.T) {
+	log := &startLog{}
+	slow := blockingService{&startService{name: "Browser", log: log}, make(chan struct{}), make(chan struct{})}
+	srvs := []Service{
+		slow,
+		dependentService{&startService{name: "Pipeline", deps: []comm.MoLingServerType{"Browser"}, log: log}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []Startup)
+	go func() { done <- StartServices(ctx, srvs) }()
+	<-slow.started
+	cancel()
+	// Let the dependent give up on the dependency before the dependency finishes
+	time.Sleep(20 * time.Millisecond)
+	close(slow.release)
+	startups := <-done
+	for _, s := range startups {
+		if s.Name == "Pipeline" && (len(s.Missing) != 1 || !errors.Is(s.Err, context.Canceled)) {
+			t.Fatalf("a dependency still starting when the context ends must be missing, got %+v", s)
+		}
+	}
+}
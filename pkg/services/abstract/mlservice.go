@@ -18,12 +18,17 @@ package abstract
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
 	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rs/zerolog"
 
+	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/config"
 	"github.com/gojue/moling/pkg/utils"
 )
@@ -60,7 +65,14 @@ type MLService struct {
 	resourcesTemplates   map[mcp.ResourceTemplate]server.ResourceTemplateHandlerFunc
 	prompts              []PromptEntry
 	tools                []server.ServerTool
+	outputSchemas        map[string]json.RawMessage // outputSchemas holds the declared output schemas by tool name.
 	notificationHandlers map[string]server.NotificationHandlerFunc
+	notify               NotifyFunc
+	clientLog            ClientLogFunc
+	lookup               ServiceLookup
+	publisher            ResourcePublisher
+	requester            ClientRequester
+	processes            map[int]*exec.Cmd    // processes are the running child processes, by process ID.
 	mlConfig             *config.MoLingConfig // The configuration for the service
 }
 
@@ -72,6 +84,8 @@ func (mls *MLService) InitResources() error {
 	mls.prompts = make([]PromptEntry, 0)
 	mls.notificationHandlers = make(map[string]server.NotificationHandlerFunc)
 	mls.tools = []server.ServerTool{}
+	mls.outputSchemas = make(map[string]json.RawMessage)
+	mls.processes = make(map[int]*exec.Cmd)
 	return nil
 }
 
@@ -80,11 +94,41 @@ func (mls *MLService) Ctx() context.Context {
 	return mls.Context
 }
 
-// AddResource adds a resource and its handler function to the service.
+// AddResource adds a resource and its handler function to the service. Once the service is loaded, the resource is also published to connected clients.
 func (mls *MLService) AddResource(rs mcp.Resource, hr server.ResourceHandlerFunc) {
 	mls.lock.Lock()
-	defer mls.lock.Unlock()
 	mls.resources[rs] = hr
+	publisher := mls.publisher
+	mls.lock.Unlock()
+	if publisher != nil {
+		publisher.AddResource(rs, hr)
+	}
+}
+
+// RemoveResource removes the resource with the given URI from the service and from connected clients.
+func (mls *MLService) RemoveResource(uri string) {
+	mls.lock.Lock()
+	for rs := range mls.resources {
+		if rs.URI == uri {
+			delete(mls.resources, rs)
+		}
+	}
+	publisher := mls.publisher
+	mls.lock.Unlock()
+	if publisher != nil {
+		publisher.RemoveResource(uri)
+	}
+}
+
+// NotifyResourceUpdated tells connected clients that the content of the resource with the given URI changed.
+// It is a no-op until the service is loaded by a server.
+func (mls *MLService) NotifyResourceUpdated(uri string) {
+	mls.lock.Lock()
+	publisher := mls.publisher
+	mls.lock.Unlock()
+	if publisher != nil {
+		publisher.ResourceUpdated(uri)
+	}
 }
 
 // AddResourceTemplate adds a resource template and its handler function to the service.
@@ -115,6 +159,114 @@ func (mls *MLService) AddNotificationHandler(name string, handler server.Notific
 	mls.notificationHandlers[name] = handler
 }
 
+// SetNotifyFunc sets the function used to push notifications to connected clients.
+func (mls *MLService) SetNotifyFunc(fn NotifyFunc) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.notify = fn
+}
+
+// SendNotification pushes a notification to all connected clients. It is a no-op until the service is loaded by a server.
+func (mls *MLService) SendNotification(method string, params map[string]any) {
+	mls.lock.Lock()
+	notify := mls.notify
+	mls.lock.Unlock()
+	if notify != nil {
+		notify(method, params)
+	}
+}
+
+// SetClientLogFunc sets the function used to send log messages to connected clients.
+func (mls *MLService) SetClientLogFunc(fn ClientLogFunc) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.clientLog = fn
+}
+
+// LogToClients sends a log message to the connected clients that asked for messages of its level, with the name of
+// the service as logger. It is a no-op until the service is loaded by a server.
+func (mls *MLService) LogToClients(level mcp.LoggingLevel, logger string, data any) {
+	mls.lock.Lock()
+	clientLog := mls.clientLog
+	mls.lock.Unlock()
+	if clientLog != nil {
+		clientLog(level, logger, data)
+	}
+}
+
+// SetResourcePublisher sets the publisher used to change resources after the service is loaded.
+func (mls *MLService) SetResourcePublisher(p ResourcePublisher) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.publisher = p
+}
+
+// SetServiceLookup sets the function used to find other loaded services.
+func (mls *MLService) SetServiceLookup(fn ServiceLookup) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.lookup = fn
+}
+
+// LookupService returns the loaded service with the given name. It reports false until the service is loaded by a server.
+func (mls *MLService) LookupService(name comm.MoLingServerType) (Service, bool) {
+	mls.lock.Lock()
+	lookup := mls.lookup
+	mls.lock.Unlock()
+	if lookup == nil {
+		return nil, false
+	}
+	return lookup(name)
+}
+
+// TrackProcess records a started child process of the service until the returned function is called once the
+// process has exited. Tracked processes count against the budget of the service.
+func (mls *MLService) TrackProcess(cmd *exec.Cmd) func() {
+	pid := cmd.Process.Pid
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	mls.processes[pid] = cmd
+	return func() {
+		mls.lock.Lock()
+		defer mls.lock.Unlock()
+		delete(mls.processes, pid)
+	}
+}
+
+// Processes returns the IDs of the tracked child processes.
+func (mls *MLService) Processes() []int {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	pids := make([]int, 0, len(mls.processes))
+	for pid := range mls.processes {
+		pids = append(pids, pid)
+	}
+	return pids
+}
+
+// Restart kills the tracked child processes with their Cancel function, or with Kill if they have none, so the tool
+// calls that wait for them fail. Services with long-running processes, such as a browser, start them again on the
+// next tool call.
+func (mls *MLService) Restart() error {
+	mls.lock.Lock()
+	cmds := make([]*exec.Cmd, 0, len(mls.processes))
+	for _, cmd := range mls.processes {
+		cmds = append(cmds, cmd)
+	}
+	mls.lock.Unlock()
+	var errs []error
+	for _, cmd := range cmds {
+		kill := cmd.Process.Kill
+		if cmd.Cancel != nil {
+			kill = cmd.Cancel
+		}
+		if err := kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Resources returns the map of resources and their handler functions.
 func (mls *MLService) Resources() map[mcp.Resource]server.ResourceHandlerFunc {
 	mls.lock.Lock()
@@ -18,9 +18,19 @@ package abstract
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/config"
 )
 
 func TestMLService_AddResource(t *testing.T) {
@@ -164,3 +174,296 @@ func TestMLService_AddNotificationHandler(t *testing.T) {
 		t.Errorf("Handler for notification not found")
 	}
 }
+
+func TestMLService_SendNotification(t *testing.T) {
+	service := &MLService{}
+	err := service.InitResources()
+	if err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	// no-op before a notify function is set
+	service.SendNotification("notifications/test", nil)
+
+	var got string
+	service.SetNotifyFunc(func(method string, params map[string]any) {
+		got = method
+	})
+	service.SendNotification("notifications/test", map[string]any{"k": "v"})
+	if got != "notifications/test" {
+		t.Errorf("Expected notification notifications/test, got %q", got)
+	}
+}
+
+func TestMLService_TrackProcess(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+	service := &MLService{}
+	if err := service.InitResources(); err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	cmd := exec.Command(sleep, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	untrack := service.TrackProcess(cmd)
+	if pids := service.Processes(); len(pids) != 1 || pids[0] != cmd.Process.Pid {
+		t.Fatalf("Expected the process %d, got %v", cmd.Process.Pid, pids)
+	}
+	if err := service.Restart(); err != nil {
+		t.Fatalf("Failed to restart: %s", err.Error())
+	}
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("Expected the process to be killed")
+	}
+	untrack()
+	if pids := service.Processes(); len(pids) != 0 {
+		t.Fatalf("Expected no process, got %v", pids)
+	}
+}
+
+type recordingPublisher struct {
+	added   []string
+	removed []string
+	updated []string
+}
+
+func (p *recordingPublisher) AddResource(rs mcp.Resource, hr server.ResourceHandlerFunc) {
+	p.added = append(p.added, rs.URI)
+}
+
+func (p *recordingPublisher) RemoveResource(uri string) {
+	p.removed = append(p.removed, uri)
+}
+
+func (p *recordingPublisher) ResourceUpdated(uri string) {
+	p.updated = append(p.updated, uri)
+}
+
+func TestMLService_ResourcePublisher(t *testing.T) {
+	service := &MLService{}
+	err := service.InitResources()
+	if err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return nil, nil
+	}
+	// before the service is loaded, resources are only collected
+	service.AddResource(mcp.NewResource("test://a", "a"), handler)
+	service.NotifyResourceUpdated("test://a")
+
+	p := &recordingPublisher{}
+	service.SetResourcePublisher(p)
+	service.AddResource(mcp.NewResource("test://b", "b"), handler)
+	service.NotifyResourceUpdated("test://b")
+	service.RemoveResource("test://a")
+
+	if len(p.added) != 1 || p.added[0] != "test://b" {
+		t.Errorf("Expected test://b to be published, got %v", p.added)
+	}
+	if len(p.updated) != 1 || p.updated[0] != "test://b" {
+		t.Errorf("Expected an update for test://b, got %v", p.updated)
+	}
+	if len(p.removed) != 1 || p.removed[0] != "test://a" {
+		t.Errorf("Expected test://a to be removed, got %v", p.removed)
+	}
+	if len(service.Resources()) != 1 {
+		t.Errorf("Expected 1 resource, got %d", len(service.Resources()))
+	}
+}
+
+func TestMLService_RequestContext(t *testing.T) {
+	service := &MLService{Context: context.Background()}
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	runCtx, cancel := service.RequestContext(reqCtx)
+	defer cancel()
+	if runCtx.Err() != nil {
+		t.Fatalf("Expected a live context, got %v", runCtx.Err())
+	}
+	cancelReq()
+	<-runCtx.Done()
+
+	// no progress token, no notification
+	service.ReportProgress(context.Background(), 1, 2, "halfway")
+	if _, ok := ProgressTokenFromContext(WithProgressToken(context.Background(), "p1")); !ok {
+		t.Errorf("Expected the progress token to be found")
+	}
+}
+
+func TestMLService_AddStructuredTool(t *testing.T) {
+	service := &MLService{}
+	err := service.InitResources()
+	if err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	schema := json.RawMessage(`{"type":"object","properties":{"n":{"type":"integer"}}}`)
+	service.AddStructuredTool(mcp.NewTool("count"), schema, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return NewStructuredResult("counted 3", map[string]int{"n": 3}), nil
+	})
+	if got, ok := service.OutputSchema("count"); !ok || string(got) != string(schema) {
+		t.Fatalf("Expected the output schema of count, got %s", got)
+	}
+	for rs, handler := range service.Resources() {
+		if rs.URI != SchemaURIPrefix+"count" {
+			t.Fatalf("Unexpected resource %s", rs.URI)
+		}
+		contents, err := handler(context.Background(), mcp.ReadResourceRequest{})
+		if err != nil || contents[0].(mcp.TextResourceContents).Text != string(schema) {
+			t.Fatalf("Unexpected schema resource %v %v", contents, err)
+		}
+	}
+
+	res, _ := service.Tools()[0].Handler(context.Background(), mcp.CallToolRequest{})
+	if len(res.Content) != 2 || res.Content[0].(mcp.TextContent).Text != "counted 3" {
+		t.Fatalf("Unexpected content %+v", res.Content)
+	}
+	var data map[string]int
+	if err := json.Unmarshal([]byte(res.Content[1].(mcp.TextContent).Text), &data); err != nil || data["n"] != 3 {
+		t.Fatalf("Unexpected structured content %+v %v", data, err)
+	}
+	if _, ok := res.Meta[StructuredContentKey]; !ok {
+		t.Fatalf("Expected %s in the result meta", StructuredContentKey)
+	}
+}
+
+// answeringRequester answers every request with the same result.
+type answeringRequester struct {
+	result string
+	method string
+}
+
+func (r *answeringRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == ElicitationCapability
+}
+
+func (r *answeringRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	r.method = method
+	return json.RawMessage(r.result), nil
+}
+
+func TestMLService_Elicit(t *testing.T) {
+	cfg := &config.MoLingConfig{Elicitation: config.NewElicitationConfig()}
+	service := NewMLService(context.Background(), zerolog.Nop(), cfg)
+	if err := service.InitResources(); err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	if _, err := service.Confirm(context.Background(), "Proceed?"); !errors.Is(err, ErrElicitationUnavailable) {
+		t.Fatalf("Expected ErrElicitationUnavailable without a requester, got %v", err)
+	}
+
+	requester := &answeringRequester{result: `{"action":"accept","content":{"confirm":true}}`}
+	service.SetClientRequester(requester)
+	if ok, err := service.Confirm(context.Background(), "Proceed?"); err != nil || !ok {
+		t.Fatalf("Expected a confirmation, got %v %v", ok, err)
+	}
+	if requester.method != MethodElicitationCreate {
+		t.Errorf("Unexpected method %s", requester.method)
+	}
+	requester.result = `{"action":"decline"}`
+	if _, err := service.AskString(context.Background(), "2FA code?", "code", "the code from your app"); err == nil {
+		t.Errorf("Expected an error when the user declines")
+	}
+
+	cfg.Elicitation.Enabled = false
+	if service.CanElicit(context.Background()) {
+		t.Errorf("Elicitation must be off when disabled in the config")
+	}
+}
+
+// samplingRequester records the sampling request and answers with a fixed text.
+type samplingRequester struct {
+	params SampleParams
+}
+
+func (r *samplingRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == SamplingCapability
+}
+
+func (r *samplingRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if method != MethodSamplingCreateMessage {
+		return nil, errors.New("unexpected method " + method)
+	}
+	r.params = params.(SampleParams)
+	return json.RawMessage(`{"role":"assistant","content":{"type":"text","text":"all good"},"model":"test-model"}`), nil
+}
+
+func TestMLService_Sample(t *testing.T) {
+	cfg := &config.MoLingConfig{Sampling: config.NewSamplingConfig()}
+	service := NewMLService(context.Background(), zerolog.Nop(), cfg)
+	if err := service.InitResources(); err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	if _, err := service.SampleText(context.Background(), "", "Summarize", 0); !errors.Is(err, ErrSamplingUnavailable) {
+		t.Fatalf("Expected ErrSamplingUnavailable without a requester, got %v", err)
+	}
+
+	requester := &samplingRequester{}
+	service.SetClientRequester(requester)
+	text, err := service.SampleText(context.Background(), "Be brief", "Summarize", 1<<20)
+	if err != nil || text != "all good" {
+		t.Fatalf("Unexpected answer %q: %v", text, err)
+	}
+	if requester.params.MaxTokens != cfg.Sampling.MaxTokens || requester.params.SystemPrompt != "Be brief" || len(requester.params.Messages) != 1 {
+		t.Errorf("Unexpected request %+v", requester.params)
+	}
+
+	cfg.Sampling.Enabled = false
+	if service.CanSample(context.Background()) {
+		t.Errorf("Sampling must be off when disabled in the config")
+	}
+}
+
+// rootsRequester lists fixed roots.
+type rootsRequester struct {
+	roots string
+}
+
+func (r *rootsRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == RootsCapability
+}
+
+func (r *rootsRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return json.RawMessage(r.roots), nil
+}
+
+func TestMLService_ClientRoots(t *testing.T) {
+	service := NewMLService(context.Background(), zerolog.Nop(), &config.MoLingConfig{})
+	if err := service.InitResources(); err != nil {
+		t.Fatalf("Failed to initialize MLService: %s", err.Error())
+	}
+	if roots, err := service.ClientRoots(context.Background()); err != nil || roots != nil {
+		t.Fatalf("Expected no roots without a requester, got %v %v", roots, err)
+	}
+	work := filepath.Join(t.TempDir(), "work")
+	uri := "file://" + filepath.ToSlash(work)
+	if !strings.HasPrefix(filepath.ToSlash(work), "/") {
+		uri = "file:///" + filepath.ToSlash(work) // file:///C:/...
+	}
+	service.SetClientRequester(&rootsRequester{roots: fmt.Sprintf(`{"roots":[{"uri":%q},{"uri":"https://example.com"}]}`, uri)})
+	roots, err := service.ClientRoots(context.Background())
+	if err != nil || len(roots) != 1 || roots[0] != work {
+		t.Fatalf("Unexpected roots %v %v", roots, err)
+	}
+}
+
+func TestNarrowToRoots(t *testing.T) {
+	base := t.TempDir()
+	dir := func(elem ...string) string {
+		return filepath.Join(append([]string{base}, elem...)...)
+	}
+	for _, c := range []struct {
+		dirs, roots, want []string
+	}{
+		{[]string{dir("a")}, []string{dir("a", "project")}, []string{dir("a", "project")}},
+		{[]string{dir("a", "project")}, []string{dir("a")}, []string{dir("a", "project")}},
+		{[]string{dir("a"), dir("b")}, []string{dir("b")}, []string{dir("b")}},
+		{[]string{dir("a")}, []string{dir("ab")}, []string{}},
+	} {
+		got := NarrowToRoots(c.dirs, c.roots)
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("NarrowToRoots(%v, %v) = %v, want %v", c.dirs, c.roots, got, c.want)
+		}
+	}
+}
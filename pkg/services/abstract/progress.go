@@ -0,0 +1,74 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type progressTokenKey struct{}
+
+// WithProgressToken returns a context that carries the progress token of the request being handled.
+func WithProgressToken(ctx context.Context, token mcp.ProgressToken) context.Context {
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// ProgressTokenFromContext returns the progress token of the request being handled. It reports false if the client did not ask for progress.
+func ProgressTokenFromContext(ctx context.Context) (mcp.ProgressToken, bool) {
+	token := ctx.Value(progressTokenKey{})
+	return token, token != nil
+}
+
+// ReportProgress sends a progress notification for the request handled in ctx.
+// A total of 0 means the total is unknown. It is a no-op if the client did not ask for progress.
+func (mls *MLService) ReportProgress(ctx context.Context, progress, total float64, message string) {
+	token, ok := ProgressTokenFromContext(ctx)
+	if !ok {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	params := map[string]any{
+		"progressToken": token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	if err := srv.SendNotificationToClient(ctx, "notifications/progress", params); err != nil {
+		mls.Logger.Debug().Err(err).Msg("failed to send progress notification")
+	}
+}
+
+// RequestContext returns a context derived from the service context that is also cancelled when the request
+// handled in ctx is cancelled. Handlers use it for work that must run on the service context, such as browser actions.
+func (mls *MLService) RequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(mls.Context)
+	stop := context.AfterFunc(ctx, cancel)
+	return runCtx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	// RootsCapability is the client capability needed to ask for the workspace roots of the client.
+	RootsCapability = "roots"
+	// MethodRootsList is the request that asks the client for its roots.
+	MethodRootsList = "roots/list"
+	// RootsListChangedNotification is sent by clients when their roots change.
+	RootsListChangedNotification = "notifications/roots/list_changed"
+
+	// rootsTimeout is how long to wait for the client to list its roots.
+	rootsTimeout = 10 * time.Second
+)
+
+// ListClientRoots asks the client of the tool call handled in ctx for its roots through r.
+func ListClientRoots(ctx context.Context, r ClientRequester) ([]mcp.Root, error) {
+	ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+	defer cancel()
+	raw, err := r.RequestClient(ctx, MethodRootsList, map[string]any{})
+	if err != nil {
+		return nil, fmt.Errorf("listing roots failed: %w", err)
+	}
+	var result mcp.ListRootsResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid roots result: %w", err)
+	}
+	return result.Roots, nil
+}
+
+// ClientRoots returns the local directories the client of the tool call handled in ctx declared as its workspace roots.
+// It returns nil if the client does not support roots; roots that are not file:// URIs are skipped.
+func (mls *MLService) ClientRoots(ctx context.Context) ([]string, error) {
+	mls.lock.Lock()
+	requester := mls.requester
+	mls.lock.Unlock()
+	if requester == nil || !requester.ClientSupports(ctx, RootsCapability) {
+		return nil, nil
+	}
+	var roots []mcp.Root
+	var err error
+	if provider, ok := requester.(RootsProvider); ok {
+		roots, err = provider.ClientRoots(ctx)
+	} else {
+		roots, err = ListClientRoots(ctx, requester)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if dir, ok := rootPath(root.URI); ok {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// rootPath converts a file:// URI to a clean absolute local path.
+func rootPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/work has the path /C:/work
+		path = filepath.FromSlash(strings.TrimPrefix(path, "/"))
+	}
+	if !filepath.IsAbs(path) {
+		return "", false
+	}
+	return filepath.Clean(path), true
+}
+
+// NarrowToRoots returns the parts of dirs that are inside roots: a root inside one of dirs replaces it, and a
+// directory inside one of the roots is kept. The result is empty if dirs and roots do not overlap.
+func NarrowToRoots(dirs, roots []string) []string {
+	narrowed := make([]string, 0, len(dirs))
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			narrowed = append(narrowed, dir)
+		}
+	}
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		for _, root := range roots {
+			root = filepath.Clean(root)
+			switch {
+			case utils.Within(root, dir):
+				add(dir)
+			case utils.Within(dir, root):
+				add(root)
+			}
+		}
+	}
+	return narrowed
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// SamplingCapability is the client capability needed to ask the client's model to generate text.
+	SamplingCapability = "sampling"
+	// MethodSamplingCreateMessage is the request that asks the client's model to generate a message.
+	MethodSamplingCreateMessage = "sampling/createMessage"
+)
+
+// ErrSamplingUnavailable means the client's model cannot be asked: sampling is disabled, or the client does not support it.
+var ErrSamplingUnavailable = errors.New("the client's model cannot be asked")
+
+// SampleParams are the parameters of a sampling request.
+type SampleParams struct {
+	Messages         []mcp.SamplingMessage `json:"messages"`
+	ModelPreferences *mcp.ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                `json:"systemPrompt,omitempty"`
+	Temperature      *float64              `json:"temperature,omitempty"`
+	MaxTokens        int                   `json:"maxTokens"`
+	StopSequences    []string              `json:"stopSequences,omitempty"`
+}
+
+// SampleResult is the message generated by the client's model.
+type SampleResult struct {
+	Role    mcp.Role `json:"role"`
+	Content struct {
+		Type     string `json:"type"`
+		Text     string `json:"text,omitempty"`
+		Data     string `json:"data,omitempty"`
+		MIMEType string `json:"mimeType,omitempty"`
+	} `json:"content"`
+	Model      string `json:"model"`
+	StopReason string `json:"stopReason,omitempty"`
+}
+
+// CanSample reports whether the model of the client of the tool call handled in ctx can be asked to generate text.
+func (mls *MLService) CanSample(ctx context.Context) bool {
+	mls.lock.Lock()
+	requester := mls.requester
+	mls.lock.Unlock()
+	if requester == nil || mls.mlConfig == nil || !mls.mlConfig.Sampling.Enabled {
+		return false
+	}
+	return requester.ClientSupports(ctx, SamplingCapability)
+}
+
+// Sample asks the model of the client of the tool call handled in ctx to generate a message. MaxTokens is capped
+// by the sampling config, 0 uses the maximum. It returns ErrSamplingUnavailable if the client's model cannot be asked.
+func (mls *MLService) Sample(ctx context.Context, params SampleParams) (*SampleResult, error) {
+	if !mls.CanSample(ctx) {
+		return nil, ErrSamplingUnavailable
+	}
+	mls.lock.Lock()
+	requester := mls.requester
+	mls.lock.Unlock()
+	cfg := mls.mlConfig.Sampling
+	if params.MaxTokens <= 0 || params.MaxTokens > cfg.MaxTokens {
+		params.MaxTokens = cfg.MaxTokens
+	}
+	return SampleClient(ctx, requester, cfg.Timeout, params)
+}
+
+// SampleClient sends a sampling request through r and waits up to timeout seconds for the answer, 0 for no limit.
+func SampleClient(ctx context.Context, r ClientRequester, timeout int, params SampleParams) (*SampleResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+	raw, err := r.RequestClient(ctx, MethodSamplingCreateMessage, params)
+	if err != nil {
+		return nil, fmt.Errorf("sampling failed: %w", err)
+	}
+	var result SampleResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid sampling result: %w", err)
+	}
+	return &result, nil
+}
+
+// SampleText asks the client's model to answer prompt, following systemPrompt if it is not empty, and returns the text of the answer.
+func (mls *MLService) SampleText(ctx context.Context, systemPrompt, prompt string, maxTokens int) (string, error) {
+	result, err := mls.Sample(ctx, SampleParams{
+		Messages:     []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent(prompt)}},
+		SystemPrompt: systemPrompt,
+		MaxTokens:    maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Content.Type != "text" {
+		return "", fmt.Errorf("the client's model answered with %s content instead of text", result.Content.Type)
+	}
+	return result.Content.Text, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// SchemaURIPrefix is the URI prefix of the tool output schemas published as resources.
+	SchemaURIPrefix = "moling://schemas/"
+	// StructuredContentKey is the result meta field that carries the structured content of a tool result.
+	StructuredContentKey = "structuredContent"
+)
+
+// AddStructuredTool adds a tool whose results carry JSON content matching outputSchema.
+// The schema is published as the resource moling://schemas/<tool name>.
+func (mls *MLService) AddStructuredTool(tool mcp.Tool, outputSchema json.RawMessage, handler server.ToolHandlerFunc) {
+	mls.AddTool(tool, handler)
+	mls.lock.Lock()
+	mls.outputSchemas[tool.Name] = outputSchema
+	mls.lock.Unlock()
+	uri := SchemaURIPrefix + tool.Name
+	mls.AddResource(mcp.NewResource(uri, fmt.Sprintf("%s output schema", tool.Name),
+		mcp.WithResourceDescription(fmt.Sprintf("JSON Schema of the structured content returned by %s", tool.Name)),
+		mcp.WithMIMEType("application/schema+json"),
+	), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: "application/schema+json", Text: string(outputSchema)},
+		}, nil
+	})
+}
+
+// OutputSchema returns the output schema declared for a tool. It reports false for tools that return plain text.
+func (mls *MLService) OutputSchema(tool string) (json.RawMessage, bool) {
+	mls.lock.Lock()
+	defer mls.lock.Unlock()
+	schema, ok := mls.outputSchemas[tool]
+	return schema, ok
+}
+
+// NewStructuredResult returns a tool result with a human-readable text followed by data as JSON.
+func NewStructuredResult(text string, data any) *mcp.CallToolResult {
+	return WithStructuredContent(mcp.NewToolResultText(text), data)
+}
+
+// NewJSONResult returns a tool result with v as indented JSON text, or an error result if v cannot be encoded.
+func NewJSONResult(v any) *mcp.CallToolResult {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode the result: %s", err.Error()))
+	}
+	return mcp.NewToolResultText(string(data))
+}
+
+// WithStructuredContent appends data as a JSON text block to a tool result and keeps it in the result meta under
+// structuredContent, so that clients can parse the result without reading the human-readable text.
+func WithStructuredContent(result *mcp.CallToolResult, data any) *mcp.CallToolResult {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return result
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(string(raw)))
+	if result.Meta == nil {
+		result.Meta = make(map[string]any)
+	}
+	result.Meta[StructuredContentKey] = json.RawMessage(raw)
+	return result
+}
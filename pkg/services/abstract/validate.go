@@ -0,0 +1,314 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ValidateArguments checks the arguments of a tool call against the input schema of the tool: the types, required
+// properties, enums, ranges, lengths and patterns it declares, in nested objects and arrays too. It returns a
+// ToolError with CodeInvalidArgument that lists every violation, or nil if the arguments are valid. Properties the
+// schema does not declare are allowed, and so is null for an optional property.
+func ValidateArguments(tool mcp.Tool, args map[string]any) error {
+	schema, err := inputSchema(tool)
+	if err != nil {
+		return nil
+	}
+	var problems []string
+	validateValue("", schema, args, &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	return Errorf(CodeInvalidArgument, "invalid arguments for %s: %s", tool.Name, strings.Join(problems, "; "))
+}
+
+// inputSchema returns the input schema of a tool as a generic JSON Schema object.
+func inputSchema(tool mcp.Tool) (map[string]any, error) {
+	if len(tool.RawInputSchema) > 0 {
+		var schema map[string]any
+		err := json.Unmarshal(tool.RawInputSchema, &schema)
+		return schema, err
+	}
+	schema := map[string]any{"type": "object", "properties": tool.InputSchema.Properties}
+	required := make([]any, 0, len(tool.InputSchema.Required))
+	for _, name := range tool.InputSchema.Required {
+		required = append(required, name)
+	}
+	schema["required"] = required
+	return schema, nil
+}
+
+// asSchema returns a schema as a map, converting the typed schemas some tools declare through JSON.
+func asSchema(v any) (map[string]any, bool) {
+	if schema, ok := v.(map[string]any); ok {
+		return schema, true
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var schema map[string]any
+	if json.Unmarshal(raw, &schema) != nil || schema == nil {
+		return nil, false
+	}
+	return schema, true
+}
+
+// validateValue appends the violations of the schema by the value at path to problems.
+func validateValue(path string, schema map[string]any, v any, problems *[]string) {
+	at := func(format string, a ...any) {
+		msg := fmt.Sprintf(format, a...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		*problems = append(*problems, msg)
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return isType(v, t) }) {
+		at("expected %s, got %s", strings.Join(types, " or "), jsonType(v))
+		return
+	}
+	if enum, ok := schema["enum"]; ok {
+		values := anySlice(enum)
+		if !slices.ContainsFunc(values, func(e any) bool { return equalJSON(e, v) }) {
+			at("must be one of %s", formatEnum(values))
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if min, ok := number(schema["minLength"]); ok && n < min {
+			at("must be at least %v characters long", min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && n > max {
+			at("must be at most %v characters long", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				at("must match the pattern %s", pattern)
+			}
+		}
+	case []any:
+		n := float64(len(v))
+		if min, ok := number(schema["minItems"]); ok && n < min {
+			at("must have at least %v items", min)
+		}
+		if max, ok := number(schema["maxItems"]); ok && n > max {
+			at("must have at most %v items", max)
+		}
+		if items, ok := asSchema(schema["items"]); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, problems)
+			}
+		}
+	case map[string]any:
+		validateObject(path, schema, v, problems)
+	default:
+		if f, ok := number(v); ok {
+			if min, ok := number(schema["minimum"]); ok && f < min {
+				at("must be at least %v", min)
+			}
+			if max, ok := number(schema["maximum"]); ok && f > max {
+				at("must be at most %v", max)
+			}
+		}
+	}
+}
+
+// validateObject appends the violations of the properties and required properties of an object schema to problems.
+// Nested properties may be marked required with "required": true, as the property options of mcp-go do.
+func validateObject(path string, schema map[string]any, obj map[string]any, problems *[]string) {
+	prefix := path
+	if prefix != "" {
+		prefix += "."
+	}
+	properties, _ := asSchema(schema["properties"])
+	required := make(map[string]bool)
+	for _, name := range anySlice(schema["required"]) {
+		if name, ok := name.(string); ok {
+			required[name] = true
+		}
+	}
+	for name, prop := range properties {
+		if propSchema, ok := prop.(map[string]any); ok && propSchema["required"] == true {
+			required[name] = true
+		}
+	}
+	names := make([]string, 0, len(required)+len(obj))
+	for name := range required {
+		names = append(names, name)
+	}
+	for name := range obj {
+		if !required[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := obj[name]
+		if v == nil {
+			if required[name] {
+				*problems = append(*problems, prefix+name+": is required")
+			}
+			continue
+		}
+		if propSchema, ok := asSchema(properties[name]); ok {
+			validateValue(prefix+name, propSchema, v, problems)
+		}
+	}
+}
+
+// schemaTypes returns the types a schema allows, none if it does not restrict the type.
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// isType reports whether a decoded JSON value has a JSON Schema type.
+func isType(v any, t string) bool {
+	switch t {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		f, ok := number(v)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+// jsonType returns the JSON type of a decoded value, for validation errors.
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if f, ok := number(v); ok {
+		if f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// number returns a numeric value as a float64. It reports false for any other value.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// anySlice returns the items of a slice of any type, such as the []string enums mcp.Enum declares.
+func anySlice(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return nil
+}
+
+// equalJSON reports whether two decoded JSON values are equal, comparing numbers by value.
+func equalJSON(a, b any) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ra) == string(rb)
+}
+
+// formatEnum formats the allowed values of an enum, for validation errors.
+func formatEnum(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		raw, _ := json.Marshal(v)
+		parts[i] = string(raw)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
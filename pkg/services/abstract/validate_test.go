@@ -0,0 +1,99 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package abstract
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestValidateArguments(t *testing.T) {
+	tool := mcp.NewTool("resize",
+		mcp.WithString("path", mcp.Required(), mcp.Pattern(`\.png$`)),
+		mcp.WithNumber("width", mcp.Min(1), mcp.Max(4096)),
+		mcp.WithString("mode", mcp.Enum("fit", "fill")),
+		mcp.WithBoolean("keep"),
+		mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"}), mcp.MaxItems(2)),
+		mcp.WithObject("crop", mcp.Properties(map[string]any{
+			"x": map[string]any{"type": "integer", "minimum": 0, "required": true},
+		})),
+	)
+
+	valid := []map[string]any{
+		{"path": "a.png"},
+		{"path": "a.png", "width": float64(100), "mode": "fit", "keep": true, "tags": []any{"a"}, "crop": map[string]any{"x": float64(3)}},
+		{"path": "a.png", "mode": nil, "unknown": "allowed"},
+	}
+	for _, args := range valid {
+		if err := ValidateArguments(tool, args); err != nil {
+			t.Errorf("ValidateArguments(%v) = %v, want nil", args, err)
+		}
+	}
+
+	tests := []struct {
+		args map[string]any
+		want []string
+	}{
+		{map[string]any{}, []string{"path: is required"}},
+		{map[string]any{"path": nil}, []string{"path: is required"}},
+		{map[string]any{"path": 5}, []string{"path: expected string, got integer"}},
+		{map[string]any{"path": "a.jpg"}, []string{`path: must match the pattern \.png$`}},
+		{map[string]any{"path": "a.png", "width": float64(0)}, []string{"width: must be at least 1"}},
+		{map[string]any{"path": "a.png", "width": "100"}, []string{"width: expected number, got string"}},
+		{map[string]any{"path": "a.png", "mode": "crop"}, []string{`mode: must be one of ["fit", "fill"]`}},
+		{map[string]any{"path": "a.png", "keep": "yes"}, []string{"keep: expected boolean, got string"}},
+		{map[string]any{"path": "a.png", "tags": []any{"a", 1, "c"}}, []string{"tags: must have at most 2 items", "tags[1]: expected string, got integer"}},
+		{map[string]any{"path": "a.png", "crop": map[string]any{"x": 1.5}}, []string{"crop.x: expected integer, got number"}},
+		{map[string]any{"path": "a.png", "crop": map[string]any{}}, []string{"crop.x: is required"}},
+		{map[string]any{"width": float64(5000), "mode": "x"}, []string{"mode: must be one of", "path: is required", "width: must be at most 4096"}},
+	}
+	for _, tt := range tests {
+		err := ValidateArguments(tool, tt.args)
+		var te *ToolError
+		if !errors.As(err, &te) || te.Code != CodeInvalidArgument {
+			t.Errorf("ValidateArguments(%v) = %v, want an invalid_argument error", tt.args, err)
+			continue
+		}
+		msg := err.Error()
+		if !strings.HasPrefix(msg, "invalid arguments for resize: ") {
+			t.Errorf("unexpected message %q", msg)
+		}
+		last := 0
+		for _, want := range tt.want {
+			i := strings.Index(msg, want)
+			if i < last {
+				t.Errorf("ValidateArguments(%v) = %q, want %q in order", tt.args, msg, tt.want)
+				break
+			}
+			last = i
+		}
+	}
+}
+
+func TestValidateArgumentsRawSchema(t *testing.T) {
+	tool := mcp.NewToolWithRawSchema("seek", "", json.RawMessage(`{"type":"object","properties":{"offset":{"type":["integer","null"]}},"required":["offset"]}`))
+	if err := ValidateArguments(tool, map[string]any{"offset": float64(3)}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := ValidateArguments(tool, map[string]any{"offset": "3"}); err == nil || !strings.Contains(err.Error(), "offset: expected integer or null, got string") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
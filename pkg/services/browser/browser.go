@@ -22,11 +22,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/rs/zerolog"
@@ -49,6 +54,45 @@ type BrowserServer struct {
 	name         string // The name of the service
 	cancelAlloc  context.CancelFunc
 	cancelChrome context.CancelFunc
+	chromeOpts   []chromedp.ExecAllocatorOption // chromeOpts are the options Chrome is started with, again after Restart.
+	chromeLock   sync.RWMutex                   // chromeLock guards the Chrome context, which Restart replaces.
+	sessionPath  string                         // sessionPath is the browser profile of a per-session instance, removed on Close.
+	backend      Backend
+	restoreOnce  sync.Once          // restoreOnce restores the saved session before the first tool call runs.
+	stopGuard    context.CancelFunc // stopGuard stops the memory and CPU guard of the tabs, nil if it does not run.
+	farm         *browserFarm       // farm runs the named contexts across several Chrome instances, nil for a single Chrome.
+	snapshots    chan struct{}      // snapshots holds a slot for every running browser_snapshot.
+
+	initScriptsLock sync.Mutex
+	initScripts     []InitScript // initScripts are the scripts added by browser_add_init_script to the current Chrome.
+
+	captureLock sync.Mutex
+	capture     *xhrCapture // capture records the XHR and fetch responses while browser_capture_xhr runs.
+
+	artifactsLock sync.Mutex
+	artifacts     []string // artifacts holds the URIs of the published screenshots, oldest first.
+}
+
+// Backend runs chromedp actions. The tools run them in Chrome, tests may set another backend with SetBackend to run
+// the handlers without a browser, see testkit.FakeBrowser.
+type Backend interface {
+	Run(ctx context.Context, actions ...chromedp.Action) error
+}
+
+// SetBackend replaces Chrome as the backend that runs the actions of the tools, nil to use Chrome again.
+func (bs *BrowserServer) SetBackend(b Backend) {
+	bs.backend = b
+}
+
+// run runs actions with the backend of the service.
+func (bs *BrowserServer) run(ctx context.Context, actions ...chromedp.Action) error {
+	if bs.backend != nil {
+		return bs.backend.Run(ctx, actions...)
+	}
+	if bs.config.RestoreSession && bs.sessionPath == "" {
+		bs.restoreOnce.Do(func() { bs.restoreSession(bs.chrome()) })
+	}
+	return chromedp.Run(ctx, actions...)
 }
 
 // NewBrowserServer creates a new BrowserServer instance with the given context and configuration.
@@ -77,8 +121,27 @@ func NewBrowserServer(ctx context.Context) (abstract.Service, error) {
 	return bs, nil
 }
 
+// Preflight checks that Chrome, Chromium or Edge is installed and that the browser profile can be written.
+func (bs *BrowserServer) Preflight() error {
+	if bs.config.ExecPath == "" && FindExecPath() == "" {
+		return fmt.Errorf("Chrome, Chromium or Microsoft Edge was not found: install one, or set its path with moling config set Browser.exec_path <path>")
+	}
+	for _, dir := range []string{filepath.Dir(bs.config.BrowserDataPath), bs.config.DataPath} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("%s is not writable, change the permissions or set Browser.browser_data_path and Browser.data_path: %w", dir, err)
+		}
+	}
+	return nil
+}
+
 // Init initializes the browser server by creating a new context.
 func (bs *BrowserServer) Init() error {
+	// Per-session instances get a profile of their own, two browsers cannot share one
+	if id, ok := bs.Context.Value(comm.MoLingSessionKey).(string); ok && id != "" {
+		bs.config.BrowserDataPath = filepath.Join(bs.config.BrowserDataPath, "sessions", id)
+		bs.sessionPath = bs.config.BrowserDataPath
+	}
+
 	// Initialize the browser server
 	err := bs.initBrowser(bs.config.BrowserDataPath)
 	if err != nil {
@@ -107,25 +170,38 @@ func (bs *BrowserServer) Init() error {
 		chromedp.Flag("disable-dev-shm-usage", true),
 		chromedp.Flag("autoplay-policy", "user-gesture-required"),
 		chromedp.CombinedOutput(bs.Logger),
-		// (1920, 1080), (1366, 768), (1440, 900), (1280, 800)
-		chromedp.WindowSize(1280, 800),
+		chromedp.WindowSize(bs.config.WindowWidth, bs.config.WindowHeight),
+		chromedp.Flag("force-device-scale-factor", strconv.FormatFloat(bs.config.Zoom, 'f', -1, 64)),
 		chromedp.UserDataDir(bs.config.BrowserDataPath),
 		chromedp.IgnoreCertErrors,
 	)
 
+	execPath := bs.config.ExecPath
+	if execPath == "" {
+		execPath = FindExecPath()
+	}
+	if execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+
 	// headless mode
 	if bs.config.Headless {
 		opts = append(opts, chromedp.Flag("headless", true))
 		opts = append(opts, chromedp.Flag("disable-gpu", true))
 		opts = append(opts, chromedp.Flag("disable-webgl", true))
 	}
+	if proxy := bs.config.proxyFor(0); proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(proxy))
+	}
 
-	bs.Context, bs.cancelAlloc = chromedp.NewExecAllocator(context.Background(), opts...)
-
-	bs.Context, bs.cancelChrome = chromedp.NewContext(bs.Context,
-		chromedp.WithErrorf(bs.Logger.Error().Msgf),
-		chromedp.WithDebugf(bs.Logger.Debug().Msgf),
-	)
+	bs.chromeOpts = opts
+	bs.newChrome()
+	bs.farm = bs.newFarm()
+	if bs.config.guardEnabled() {
+		var guardCtx context.Context
+		guardCtx, bs.stopGuard = context.WithCancel(context.Background())
+		go bs.guard(guardCtx)
+	}
 
 	pe := abstract.PromptEntry{
 		PromptVar: mcp.Prompt{
@@ -136,15 +212,21 @@ func (bs *BrowserServer) Init() error {
 		HandlerFunc: bs.handlePrompt,
 	}
 	bs.AddPrompt(pe)
-	bs.AddTool(mcp.NewTool(
+	bs.AddStructuredTool(mcp.NewTool(
 		"browser_navigate",
 		mcp.WithDescription("Navigate to a URL"),
 		mcp.WithString("url",
 			mcp.Description("URL to navigate to"),
 			mcp.Required(),
 		),
-	), bs.handleNavigate)
-	bs.AddTool(mcp.NewTool(
+		mcp.WithString("language",
+			mcp.Description("Accept-Language of the page and its requests, such as de-DE or fr-FR,fr;q=0.9 (default: the default_language of the config)"),
+		),
+		mcp.WithString("user_agent",
+			mcp.Description("User agent of the page and its requests (default: the user_agent of the config)"),
+		),
+	), navigateSchema, bs.handleNavigate)
+	bs.AddStructuredTool(mcp.NewTool(
 		"browser_screenshot",
 		mcp.WithDescription("Take a screenshot of the current page or a specific element"),
 		mcp.WithString("name",
@@ -155,12 +237,12 @@ func (bs *BrowserServer) Init() error {
 			mcp.Description("CSS selector for element to screenshot"),
 		),
 		mcp.WithNumber("width",
-			mcp.Description("Width in pixels (default: 1700)"),
+			mcp.Description("Width in pixels (default: the window width)"),
 		),
 		mcp.WithNumber("height",
-			mcp.Description("Height in pixels (default: 1100)"),
+			mcp.Description("Height in pixels (default: the window height)"),
 		),
-	), bs.handleScreenshot)
+	), screenshotSchema, bs.handleScreenshot)
 	bs.AddTool(mcp.NewTool(
 		"browser_click",
 		mcp.WithDescription("Click an element on the page"),
@@ -201,14 +283,25 @@ func (bs *BrowserServer) Init() error {
 			mcp.Required(),
 		),
 	), bs.handleHover)
-	bs.AddTool(mcp.NewTool(
+	bs.AddStructuredTool(mcp.NewTool(
 		"browser_evaluate",
 		mcp.WithDescription("Execute JavaScript in the browser console"),
 		mcp.WithString("script",
 			mcp.Description("JavaScript code to execute"),
 			mcp.Required(),
 		),
-	), bs.handleEvaluate)
+	), evaluateSchema, bs.handleEvaluate)
+	bs.addFormTools()
+	bs.addScrapeTools()
+	bs.addCaptureTools()
+	bs.addInitScriptTools()
+	bs.addEmulateTools()
+	bs.addLoginTools()
+	bs.addFarmTools()
+	bs.addSnapshotTools()
+	bs.addResetTools()
+	bs.addDownloadTools()
+	bs.addVisualDiffTools()
 
 	bs.AddTool(mcp.NewTool(
 		"browser_debug_enable",
@@ -264,7 +357,59 @@ func (bs *BrowserServer) Init() error {
 	return nil
 }
 
-// init initializes the browser server by creating the user data directory.
+// newChrome creates the context of a new Chrome, which is started by the first tool call. The caller must hold
+// chromeLock, or own the service as Init does.
+func (bs *BrowserServer) newChrome() {
+	bs.Context, bs.cancelAlloc = chromedp.NewExecAllocator(context.Background(), bs.chromeOpts...)
+	bs.Context, bs.cancelChrome = chromedp.NewContext(bs.Context,
+		chromedp.WithErrorf(bs.Logger.Error().Msgf),
+		chromedp.WithDebugf(bs.Logger.Debug().Msgf),
+	)
+	chromedp.ListenTarget(bs.Context, bs.handleTargetEvent)
+}
+
+// chrome returns the context of the current Chrome.
+func (bs *BrowserServer) chrome() context.Context {
+	bs.chromeLock.RLock()
+	defer bs.chromeLock.RUnlock()
+	return bs.Context
+}
+
+// Processes returns the process IDs of the started Chrome instances, their renderers are counted with them.
+func (bs *BrowserServer) Processes() []int {
+	pids := bs.MLService.Processes()
+	if c := chromedp.FromContext(bs.chrome()); c != nil && c.Browser != nil {
+		if p := c.Browser.Process(); p != nil {
+			pids = append(pids, p.Pid)
+		}
+	}
+	if bs.farm != nil {
+		pids = append(pids, bs.farm.processes()...)
+	}
+	return pids
+}
+
+// Restart stops Chrome, and the other instances in farm mode, which start again with the next tool call. The open
+// pages and their state are lost, the profiles are kept.
+func (bs *BrowserServer) Restart() error {
+	if bs.farm != nil {
+		bs.farm.stop()
+	}
+	bs.chromeLock.Lock()
+	defer bs.chromeLock.Unlock()
+	bs.cancelChrome()
+	bs.cancelAlloc()
+	bs.newChrome()
+	// The init scripts were added to the page of the Chrome that is gone
+	bs.initScriptsLock.Lock()
+	bs.initScripts = nil
+	bs.initScriptsLock.Unlock()
+	bs.Logger.Warn().Msg("the browser was restarted")
+	return nil
+}
+
+// initBrowser prepares the user data directory: it creates it, or removes the lock a browser that did not exit
+// cleanly left in it.
 func (bs *BrowserServer) initBrowser(userDataDir string) error {
 	_, err := os.Stat(userDataDir)
 	if err != nil && !os.IsNotExist(err) {
@@ -273,15 +418,8 @@ func (bs *BrowserServer) initBrowser(userDataDir string) error {
 
 	// Check if the directory exists, if it does, we can reuse it
 	if err == nil {
-		//  判断浏览器运行锁
-		singletonLock := filepath.Join(userDataDir, "SingletonLock")
-		_, err = os.Stat(singletonLock)
-		if err == nil {
-			bs.Logger.Debug().Msg("Browser is already running, removing SingletonLock")
-			err = os.RemoveAll(singletonLock)
-			if err != nil {
-				bs.Logger.Error().Str("Lock", singletonLock).Msgf("Browser can't work due to failed removal of SingletonLock: %s", err.Error())
-			}
+		if err = removeProfileLock(userDataDir); err != nil {
+			bs.Logger.Error().Err(err).Str("path", userDataDir).Msg("Browser can't work due to the lock of its profile")
 		}
 		return nil
 	}
@@ -293,6 +431,21 @@ func (bs *BrowserServer) initBrowser(userDataDir string) error {
 	return nil
 }
 
+// handleTargetEvent reports a crash of the page to the log and to connected clients, the tool calls fail until a new
+// page is loaded.
+func (bs *BrowserServer) handleTargetEvent(ev any) {
+	switch ev := ev.(type) {
+	case *inspector.EventTargetCrashed:
+		bs.Logger.Error().Msg("the browser page crashed")
+		bs.LogToClients(mcp.LoggingLevelError, string(BrowserServerName), map[string]any{"message": "the browser page crashed, navigate to a page again to continue"})
+	case *inspector.EventDetached:
+		bs.Logger.Warn().Str("reason", string(ev.Reason)).Msg("the browser page was detached")
+		bs.LogToClients(mcp.LoggingLevelWarning, string(BrowserServerName), map[string]any{"message": "the browser page was detached", "reason": ev.Reason})
+	case *network.EventRequestWillBeSent, *network.EventResponseReceived, *network.EventLoadingFinished, *network.EventLoadingFailed:
+		bs.handleNetworkEvent(ev)
+	}
+}
+
 func (bs *BrowserServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
 	// 处理浏览器提示
 	return &mcp.GetPromptResult{
@@ -317,11 +470,49 @@ func (bs *BrowserServer) handleNavigate(ctx context.Context, request mcp.CallToo
 		return nil, fmt.Errorf("url must be a string")
 	}
 
-	err := chromedp.Run(bs.Context, chromedp.Navigate(url))
+	override, err := bs.navigateOverride(args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to navigate: %s", err.Error())), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, err.Error()), nil
 	}
-	return mcp.NewToolResultText(fmt.Sprintf("Navigated to %s", url)), nil
+
+	runCtx, cancelFunc := context.WithCancel(bs.page(ctx))
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	bs.ReportProgress(ctx, 0, 1, fmt.Sprintf("navigating to %s", url))
+	output := NavigateOutput{URL: url}
+	output.Language, output.UserAgent = override.AcceptLanguage, override.UserAgent
+	err = bs.run(runCtx, override, chromedp.Navigate(url), chromedp.Location(&output.Location), chromedp.Title(&output.Title),
+		chromedp.Evaluate(navigationStatusScript, &output.Status))
+	if err != nil {
+		if netErr, code, ok := navigationFailure(err); ok {
+			output.Error = netErr
+			return abstract.WithErrorCode(abstract.WithStructuredContent(
+				mcp.NewToolResultError(fmt.Sprintf("failed to navigate to %s: %s", url, netErr)), output), code), nil
+		}
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to navigate: %s", err.Error()), nil
+	}
+	bs.ReportProgress(ctx, 1, 1, "page loaded")
+
+	text := fmt.Sprintf("Navigated to %s", url)
+	if output.Location != "" && output.Location != url {
+		text += fmt.Sprintf(", redirected to %s", output.Location)
+	}
+	switch {
+	case strings.HasPrefix(output.Location, chromeErrorPrefix):
+		// The error page replaced a page that failed to load, its URL is all that is left of the error
+		output.Error = "the page failed to load and the browser shows its error page"
+		return abstract.WithErrorCode(abstract.WithStructuredContent(
+			mcp.NewToolResultError(fmt.Sprintf("failed to navigate to %s: %s", url, output.Error)), output), abstract.CodeUpstreamFailure), nil
+	case output.Status >= 400:
+		output.Error = fmt.Sprintf("HTTP %d %s", output.Status, http.StatusText(output.Status))
+		return abstract.WithErrorCode(abstract.WithStructuredContent(
+			mcp.NewToolResultError(fmt.Sprintf("%s, but the server answered %s: the page shows its error page", text, output.Error)), output),
+			statusErrorCode(output.Status)), nil
+	case output.Status > 0:
+		text += fmt.Sprintf(" (HTTP %d)", output.Status)
+	}
+	return abstract.NewStructuredResult(text, output), nil
 }
 
 // handleScreenshot handles the screenshot action.
@@ -331,23 +522,24 @@ func (bs *BrowserServer) handleScreenshot(ctx context.Context, request mcp.CallT
 	if !ok {
 		return mcp.NewToolResultError("name must be a string"), nil
 	}
-	selector, _ := args["selector"].(string)
-	width, _ := args["width"].(int)
-	height, _ := args["height"].(int)
-	if width == 0 {
-		width = 1280
+	selector := abstract.GetString(args, "selector", "")
+	width := abstract.GetInt(args, "width", 0)
+	height := abstract.GetInt(args, "height", 0)
+	if width <= 0 {
+		width = bs.config.WindowWidth
 	}
-	if height == 0 {
-		height = 800
+	if height <= 0 {
+		height = bs.config.WindowHeight
 	}
 	var buf []byte
 	var err error
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
+	viewport := chromedp.EmulateViewport(int64(width), int64(height))
 	if selector == "" {
-		err = chromedp.Run(runCtx, chromedp.FullScreenshot(&buf, 90))
+		err = bs.run(runCtx, viewport, chromedp.FullScreenshot(&buf, 90))
 	} else {
-		err = chromedp.Run(bs.Context, chromedp.Screenshot(selector, &buf, chromedp.NodeVisible))
+		err = bs.run(bs.page(ctx), viewport, chromedp.Screenshot(selector, &buf, chromedp.NodeVisible))
 	}
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to take screenshot: %s", err.Error())), nil
@@ -358,7 +550,10 @@ func (bs *BrowserServer) handleScreenshot(ctx context.Context, request mcp.CallT
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to save screenshot: %s", err.Error())), nil
 	}
-	return mcp.NewToolResultText(fmt.Sprintf("Screenshot saved to:%s", newName)), nil
+	mimeType := http.DetectContentType(buf)
+	uri := bs.publishScreenshot(newName, mimeType)
+	return abstract.NewStructuredResult(fmt.Sprintf("Screenshot saved to:%s, resource:%s", newName, uri),
+		ScreenshotOutput{Path: newName, URI: uri, MIMEType: mimeType, Size: len(buf)}), nil
 }
 
 // handleClick handles the click action on a specified element.
@@ -368,9 +563,9 @@ func (bs *BrowserServer) handleClick(ctx context.Context, request mcp.CallToolRe
 	if !ok {
 		return mcp.NewToolResultError(fmt.Sprintf("selector must be a string:%v", selector)), nil
 	}
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
-	err := chromedp.Run(runCtx,
+	err := bs.run(runCtx,
 		chromedp.WaitReady("body", chromedp.ByQuery), // 等待页面就绪
 		chromedp.WaitVisible(selector, chromedp.ByQuery),
 		chromedp.Click(selector, chromedp.NodeVisible),
@@ -394,9 +589,9 @@ func (bs *BrowserServer) handleFill(ctx context.Context, request mcp.CallToolReq
 		return mcp.NewToolResultError(fmt.Sprintf("failed to fill input field: %v, selector:%v", args["value"], selector)), nil
 	}
 
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
-	err := chromedp.Run(runCtx, chromedp.SendKeys(selector, value, chromedp.NodeVisible))
+	err := bs.run(runCtx, chromedp.SendKeys(selector, value, chromedp.NodeVisible))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to fill input field: %s", err.Error())), nil
 	}
@@ -413,9 +608,9 @@ func (bs *BrowserServer) handleSelect(ctx context.Context, request mcp.CallToolR
 	if !ok {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to select value:%v", args["value"])), nil
 	}
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
-	err := chromedp.Run(runCtx, chromedp.SetValue(selector, value, chromedp.NodeVisible))
+	err := bs.run(runCtx, chromedp.SetValue(selector, value, chromedp.NodeVisible))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Errorf("failed to select value: %s", err.Error()).Error()), nil
 	}
@@ -430,9 +625,9 @@ func (bs *BrowserServer) handleHover(ctx context.Context, request mcp.CallToolRe
 		return mcp.NewToolResultError(fmt.Sprintf("selector must be a string:%v", selector)), nil
 	}
 	var res bool
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
-	err := chromedp.Run(runCtx, chromedp.Evaluate(`document.querySelector('`+selector+`').dispatchEvent(new Event('mouseover'))`, &res))
+	err := bs.run(runCtx, chromedp.Evaluate(`document.querySelector('`+selector+`').dispatchEvent(new Event('mouseover'))`, &res))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Errorf("failed to hover over element: %s", err.Error()).Error()), nil
 	}
@@ -446,23 +641,63 @@ func (bs *BrowserServer) handleEvaluate(ctx context.Context, request mcp.CallToo
 		return mcp.NewToolResultError("script must be a string"), nil
 	}
 	var result any
-	runCtx, cancelFunc := context.WithTimeout(bs.Context, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
 	defer cancelFunc()
-	err := chromedp.Run(runCtx, chromedp.Evaluate(script, &result))
+	err := bs.run(runCtx, chromedp.Evaluate(script, &result))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Errorf("failed to execute script: %s", err.Error()).Error()), nil
 	}
-	return mcp.NewToolResultText(fmt.Sprintf("Script executed successfully: %v", result)), nil
+	return abstract.NewStructuredResult(fmt.Sprintf("Script executed successfully: %v", result), EvaluateOutput{Result: result}), nil
 }
 
 func (bs *BrowserServer) Close() error {
 	bs.Logger.Debug().Msg("Closing browser server")
-	bs.cancelAlloc()
+	if bs.stopGuard != nil {
+		bs.stopGuard()
+	}
+	if bs.farm != nil {
+		bs.farm.stop()
+	}
+	// Close a started browser gracefully first, so that it writes its profile, then release the allocator.
+	var err error
+	bs.chromeLock.Lock()
+	defer bs.chromeLock.Unlock()
+	if bs.config.RestoreSession && bs.sessionPath == "" {
+		if saveErr := bs.saveSession(); saveErr != nil {
+			bs.Logger.Warn().Err(saveErr).Msg("failed to save the browser session")
+		}
+	}
+	if c := chromedp.FromContext(bs.Context); c != nil && c.Browser != nil {
+		err = chromedp.Cancel(bs.Context)
+	}
 	bs.cancelChrome()
-	// Cancel the context to stop the browser
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	bs.cancelAlloc()
+	if bs.sessionPath != "" {
+		if rmErr := os.RemoveAll(bs.sessionPath); rmErr != nil {
+			bs.Logger.Warn().Err(rmErr).Str("path", bs.sessionPath).Msg("failed to remove the session browser profile")
+		}
+	}
+	return err
+}
+
+// Health reports whether the browser still responds. A browser that has not been started yet is healthy, it starts
+// with the first tool call.
+func (bs *BrowserServer) Health(ctx context.Context) error {
+	if err := bs.chrome().Err(); err != nil {
+		return fmt.Errorf("the browser has been closed: %w", err)
+	}
+	if c := chromedp.FromContext(bs.chrome()); c == nil || c.Browser == nil {
+		return nil
+	}
+	runCtx, cancel := context.WithCancel(bs.chrome())
 	defer cancel()
-	return chromedp.Cancel(ctx)
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	var result int
+	if err := bs.run(runCtx, chromedp.Evaluate("1", &result)); err != nil {
+		return fmt.Errorf("the browser does not respond: %w", err)
+	}
+	return nil
 }
 
 // Config returns the configuration of the service as a string.
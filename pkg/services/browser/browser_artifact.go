@@ -0,0 +1,79 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// ArtifactURIPrefix is the URI prefix of the screenshots published as resources.
+	ArtifactURIPrefix = "moling://browser/screenshots/"
+	maxArtifacts      = 50
+)
+
+// publishScreenshot publishes a saved screenshot as a resource and returns its URI.
+// Only the latest maxArtifacts screenshots stay listed, older files are kept on disk.
+func (bs *BrowserServer) publishScreenshot(path, mimeType string) string {
+	name := filepath.Base(path)
+	uri := ArtifactURIPrefix + name
+	bs.artifactsLock.Lock()
+	bs.artifacts = append(bs.artifacts, uri)
+	var dropped []string
+	if n := len(bs.artifacts) - maxArtifacts; n > 0 {
+		dropped = bs.artifacts[:n]
+		bs.artifacts = append([]string(nil), bs.artifacts[n:]...)
+	}
+	bs.artifactsLock.Unlock()
+
+	bs.AddResource(mcp.NewResource(uri, name,
+		mcp.WithResourceDescription(fmt.Sprintf("Browser screenshot saved to %s", path)),
+		mcp.WithMIMEType(mimeType),
+	), bs.handleReadArtifact)
+	for _, old := range dropped {
+		bs.RemoveResource(old)
+	}
+	return uri
+}
+
+// handleReadArtifact returns the content of a published screenshot.
+func (bs *BrowserServer) handleReadArtifact(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	name := strings.TrimPrefix(uri, ArtifactURIPrefix)
+	if name == uri || name == "" || name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid browser artifact URI: %s", uri)
+	}
+	data, err := os.ReadFile(filepath.Join(bs.config.DataPath, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read browser artifact: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: http.DetectContentType(data),
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	captureMaxResponsesDefault = 50
+	captureMaxResponsesLimit   = 500
+	captureMaxBody             = 1 << 20 // captureMaxBody is the size of the largest body kept, larger bodies are cut.
+)
+
+// CapturedResponse is an XHR or fetch response recorded by browser_capture_xhr.
+type CapturedResponse struct {
+	URL      string `json:"url"`
+	Method   string `json:"method"`
+	Status   int64  `json:"status"`
+	MIMEType string `json:"mimeType"`
+	JSON     any    `json:"json,omitempty"` // JSON is the decoded body, if it is JSON.
+	Text     string `json:"text,omitempty"` // Text is the body if it is not JSON.
+	Error    string `json:"error,omitempty"`
+	done     bool   // done is set once the body is read, or failed to be read.
+}
+
+// xhrCapture records the responses of the requests the page makes while it is active.
+type xhrCapture struct {
+	pattern   *regexp.Regexp
+	max       int
+	methods   map[network.RequestID]string // methods are the methods of the requests seen, until their response.
+	byID      map[network.RequestID]*CapturedResponse
+	responses []*CapturedResponse
+	dropped   int // dropped counts the matching responses beyond max.
+}
+
+// compileURLPattern compiles a URL pattern: a substring of the URL, or with * wildcards, the whole URL.
+func compileURLPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("the URL pattern is empty")
+	}
+	if !strings.Contains(pattern, "*") {
+		return regexp.Compile(regexp.QuoteMeta(pattern))
+	}
+	return regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+}
+
+// decodeBody returns the body as JSON if it is JSON, else as text.
+func decodeBody(body []byte) (any, string) {
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		return v, ""
+	}
+	if len(body) > captureMaxBody {
+		body = body[:captureMaxBody]
+	}
+	return nil, strings.ToValidUTF8(string(body), "")
+}
+
+// handleNetworkEvent records the XHR and fetch responses that match the active capture.
+func (bs *BrowserServer) handleNetworkEvent(ev any) {
+	bs.captureLock.Lock()
+	defer bs.captureLock.Unlock()
+	c := bs.capture
+	if c == nil {
+		return
+	}
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if (ev.Type == network.ResourceTypeXHR || ev.Type == network.ResourceTypeFetch) && c.pattern.MatchString(ev.Request.URL) {
+			c.methods[ev.RequestID] = ev.Request.Method
+		}
+	case *network.EventResponseReceived:
+		method, ok := c.methods[ev.RequestID]
+		if !ok {
+			return
+		}
+		delete(c.methods, ev.RequestID)
+		if len(c.responses) >= c.max {
+			c.dropped++
+			return
+		}
+		r := &CapturedResponse{URL: ev.Response.URL, Method: method, Status: ev.Response.Status, MIMEType: ev.Response.MimeType}
+		c.byID[ev.RequestID] = r
+		c.responses = append(c.responses, r)
+	case *network.EventLoadingFinished:
+		if _, ok := c.byID[ev.RequestID]; ok {
+			// The body cannot be read from the event listener, which would block the events of the target
+			go bs.readCapturedBody(c, ev.RequestID)
+		}
+	case *network.EventLoadingFailed:
+		if r, ok := c.byID[ev.RequestID]; ok {
+			r.Error, r.done = ev.ErrorText, true
+			delete(c.byID, ev.RequestID)
+		}
+	}
+}
+
+// readCapturedBody reads the body of a captured response.
+func (bs *BrowserServer) readCapturedBody(c *xhrCapture, id network.RequestID) {
+	runCtx, cancelFunc := context.WithTimeout(bs.chrome(), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	var body []byte
+	err := bs.run(runCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+
+	bs.captureLock.Lock()
+	defer bs.captureLock.Unlock()
+	r, ok := c.byID[id]
+	if !ok {
+		return
+	}
+	delete(c.byID, id)
+	if err != nil {
+		r.Error = fmt.Sprintf("failed to read the body: %s", err.Error())
+	} else {
+		r.JSON, r.Text = decodeBody(body)
+	}
+	r.done = true
+}
+
+// takeCaptured returns the responses whose bodies have been read, and with forget, forgets them.
+func (bs *BrowserServer) takeCaptured(forget bool) ([]*CapturedResponse, int, bool) {
+	bs.captureLock.Lock()
+	defer bs.captureLock.Unlock()
+	c := bs.capture
+	if c == nil {
+		return nil, 0, false
+	}
+	var ready, pending []*CapturedResponse
+	for _, r := range c.responses {
+		if r.done {
+			ready = append(ready, r)
+		} else {
+			pending = append(pending, r)
+		}
+	}
+	if forget {
+		c.responses = pending
+	}
+	return ready, len(pending), true
+}
+
+// addCaptureTools adds browser_capture_xhr.
+func (bs *BrowserServer) addCaptureTools() {
+	bs.AddTool(mcp.NewTool(
+		"browser_capture_xhr",
+		mcp.WithDescription("Capture the API responses the page loads with XHR or fetch, to get the data behind a page instead of scraping it. Start a capture with a URL pattern, interact with the page, then read the responses with their JSON bodies"),
+		mcp.WithString("action",
+			mcp.Description("start a new capture, read the responses captured so far, or stop the capture and return its responses"),
+			mcp.Enum("start", "read", "stop"),
+			mcp.Required(),
+		),
+		mcp.WithString("url_pattern",
+			mcp.Description("For start: part of the URL of the requests to capture, e.g. \"/api/\", or a whole URL with * wildcards"),
+		),
+		mcp.WithNumber("max_responses",
+			mcp.Description(fmt.Sprintf("For start: maximum number of responses to keep (default: %d, at most %d)", captureMaxResponsesDefault, captureMaxResponsesLimit)),
+		),
+		mcp.WithNumber("wait",
+			mcp.Description("For read and stop: seconds to wait for a first response if none was captured yet (default: 0)"),
+		),
+		mcp.WithBoolean("clear",
+			mcp.Description("For read: forget the returned responses, so the next read returns only new ones (default: true)"),
+		),
+	), bs.handleCaptureXHR)
+}
+
+// handleCaptureXHR starts, reads and stops the capture of XHR and fetch responses.
+func (bs *BrowserServer) handleCaptureXHR(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	action := abstract.GetString(args, "action", "")
+	if action == "start" {
+		pattern, err := compileURLPattern(abstract.GetString(args, "url_pattern", ""))
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "invalid url_pattern: %s", err.Error()), nil
+		}
+		maxResponses := min(max(abstract.GetInt(args, "max_responses", captureMaxResponsesDefault), 1), captureMaxResponsesLimit)
+		bs.captureLock.Lock()
+		bs.capture = &xhrCapture{
+			pattern: pattern,
+			max:     maxResponses,
+			methods: make(map[network.RequestID]string),
+			byID:    make(map[network.RequestID]*CapturedResponse),
+		}
+		bs.captureLock.Unlock()
+		return mcp.NewToolResultText(fmt.Sprintf("Capturing the XHR and fetch responses matching %s, interact with the page, then read them with action read", abstract.GetString(args, "url_pattern", ""))), nil
+	}
+
+	wait := time.Duration(abstract.GetFloat(args, "wait", 0) * float64(time.Second))
+	deadline := time.Now().Add(wait)
+	forget := action == "stop" || abstract.GetBool(args, "clear", true)
+	var responses []*CapturedResponse
+	var pending int
+	for {
+		var ok bool
+		responses, pending, ok = bs.takeCaptured(false)
+		if !ok {
+			return abstract.NewErrorResult(abstract.CodeNotFound, "no capture is running, start one with action start"), nil
+		}
+		if len(responses) > 0 || !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return abstract.NewErrorResult(abstract.CodeTimeout, ctx.Err().Error()), nil
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	responses, pending, _ = bs.takeCaptured(forget)
+
+	bs.captureLock.Lock()
+	dropped := bs.capture.dropped
+	if action == "stop" {
+		bs.capture = nil
+	}
+	bs.captureLock.Unlock()
+
+	if responses == nil {
+		responses = []*CapturedResponse{}
+	}
+	data, err := json.MarshalIndent(responses, "", "  ")
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to encode the responses: %s", err.Error()), nil
+	}
+	text := string(data)
+	if pending > 0 && action != "stop" {
+		text += fmt.Sprintf("\n%d more responses are still loading, read again to get them", pending)
+	}
+	if dropped > 0 {
+		text += fmt.Sprintf("\n%d matching responses were not kept because max_responses was reached", dropped)
+	}
+	return mcp.NewToolResultText(text), nil
+}
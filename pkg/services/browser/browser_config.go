@@ -21,26 +21,36 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const BrowserPromptDefault = `
 You are an AI-powered browser automation assistant capable of performing a wide range of web interactions and debugging tasks. Your capabilities include:
 
-1. **Navigation**: Navigate to any specified URL to load web pages.
+1. **Navigation**: Navigate to any specified URL to load web pages. A navigation fails when the server answers with an HTTP error status or the page cannot be loaded; its result tells the status and the URL after redirects. To read a site in another language, pass its Accept-Language as "language" (for example de-DE), and "user_agent" for another user agent; the next navigation without them goes back to the defaults. If a page is stuck, browser_reset closes the other tabs and the state left by the tools and goes back to about:blank.
+   When the browser tools take a "context" argument, several Chrome instances run side by side: give every task its own context name and pass it to all the calls of the task, they run in one tab of one instance. List or close the contexts with browser_farm.
 
-2. **Screenshot Capture**: Take full-page screenshots or capture specific elements using CSS selectors, with customizable dimensions (default: 1700x1100 pixels).
+2. **Screenshot Capture**: Take full-page screenshots or capture specific elements using CSS selectors, with customizable dimensions (default: the window size). For a quick screenshot, PDF or text of a URL that must not change the current page, use browser_snapshot, which runs in a separate headless browser. To check a page or an element for visual regressions, use browser_visual_diff: the first screenshot of a name is its baseline, later ones are compared with it.
 
 3. **Element Interaction**:
    - Click on elements identified by CSS selectors
    - Hover over specified elements
    - Fill input fields with provided values
    - Select options in dropdown menus
+   - Fill registration and checkout forms with browser_smart_fill from values such as name, email and address, without selectors
+   - Download a file with browser_download_and_read, from a link or button selector or a URL, and get its JSON, CSV rows or text in the same call
 
 4. **JavaScript Execution**:
    - Run arbitrary JavaScript code in the browser context
    - Evaluate scripts and return results
+   - Emulate print media, dark mode or reduced motion with browser_emulate_media to check how the page looks with them
+   - Add scripts that run in every new document, such as polyfills and hooks, with browser_add_init_script, and remove them with browser_remove_init_script
+   - Capture the JSON responses of the API calls the page makes with browser_capture_xhr: start it, interact with the page, then read the responses. Prefer this to scraping when a page loads its data from an API
+   - Scrape lists that span several pages in one call with browser_scrape_list, instead of reading and paging one page at a time
 
-5. **Debugging Tools**:
+5. **Login**: Log in to the sites configured as login profiles with browser_login. The credentials are read from the secrets store and are never shown to you, do not ask the user for them. One-time codes are entered by browser_login, or fill a code field with browser_fill_totp and the name of the secret holding the TOTP key.
+
+6. **Debugging Tools**:
    - Enable/disable JavaScript debugging mode
    - Set breakpoints at specific script locations (URL + line number + optional column/condition)
    - Remove existing breakpoints by ID
@@ -61,15 +71,28 @@ You should confirm actions before execution when dealing with sensitive operatio
 type BrowserConfig struct {
 	PromptFile           string `json:"prompt_file"` // PromptFile is the prompt file for the browser.
 	prompt               string
-	Headless             bool   `json:"headless"`
-	Timeout              int    `json:"timeout"`
-	Proxy                string `json:"proxy"`
-	UserAgent            string `json:"user_agent"`
-	DefaultLanguage      string `json:"default_language"`
-	URLTimeout           int    `json:"url_timeout"`            // URLTimeout is the timeout for loading a URL. time.Second
-	SelectorQueryTimeout int    `json:"selector_query_timeout"` // SelectorQueryTimeout is the timeout for CSS selector queries. time.Second
-	DataPath             string `json:"data_path"`              // DataPath is the path to the data directory.
-	BrowserDataPath      string `json:"browser_data_path"`      // BrowserDataPath is the path to the browser data directory.
+	Headless             bool           `json:"headless"`
+	Timeout              int            `json:"timeout"`
+	Proxy                string         `json:"proxy"`
+	UserAgent            string         `json:"user_agent"`
+	DefaultLanguage      string         `json:"default_language"`
+	URLTimeout           int            `json:"url_timeout"`            // URLTimeout is the timeout for loading a URL. time.Second
+	SelectorQueryTimeout int            `json:"selector_query_timeout"` // SelectorQueryTimeout is the timeout for CSS selector queries. time.Second
+	DataPath             string         `json:"data_path"`              // DataPath is the path to the data directory.
+	BrowserDataPath      string         `json:"browser_data_path"`      // BrowserDataPath is the path to the browser data directory.
+	ExecPath             string         `json:"exec_path"`              // ExecPath is the Chrome, Chromium or Edge executable, found in the usual locations if empty.
+	RestoreSession       bool           `json:"restore_session"`        // RestoreSession saves the open tabs and cookies on shutdown and restores them on the next start.
+	Logins               []LoginProfile `json:"logins"`                 // Logins are the sites browser_login logs in to.
+	WindowWidth          int            `json:"window_width"`           // WindowWidth is the width of the browser window in pixels.
+	WindowHeight         int            `json:"window_height"`          // WindowHeight is the height of the browser window in pixels.
+	Zoom                 float64        `json:"zoom"`                   // Zoom is the scale the pages are shown at, as the device scale factor: 1.25 shows them at 125%.
+	GuardMemoryMB        int            `json:"guard_memory_mb"`        // GuardMemoryMB is the JavaScript heap a tab may use before it is recycled, 0 for no limit.
+	GuardCPUPercent      int            `json:"guard_cpu_percent"`      // GuardCPUPercent is the CPU a tab may use for two checks in a row before it is recycled, 0 for no limit.
+	GuardInterval        int            `json:"guard_interval"`         // GuardInterval is the time between the checks of the tabs. time.Second
+	GuardAction          string         `json:"guard_action"`           // GuardAction is reload or close, what is done to a tab over a limit. The main tab is always reloaded.
+	Instances            int            `json:"instances"`              // Instances is the number of Chrome instances, more than 1 runs the tools of named contexts across them.
+	Proxies              []string       `json:"proxies"`                // Proxies are the proxies of the instances in order, repeated if there are fewer, proxy if empty.
+	MaxSnapshots         int            `json:"max_snapshots"`          // MaxSnapshots is the number of browser_snapshot calls, each with a Chrome of its own, that run at a time.
 }
 
 func (cfg *BrowserConfig) Check() error {
@@ -83,6 +106,54 @@ func (cfg *BrowserConfig) Check() error {
 	if cfg.SelectorQueryTimeout <= 0 {
 		return fmt.Errorf("selector Query timeout must be greater than 0")
 	}
+	cfg.DataPath = cleanPath(cfg.DataPath)
+	cfg.BrowserDataPath = cleanPath(cfg.BrowserDataPath)
+	cfg.ExecPath = cleanPath(cfg.ExecPath)
+	if cfg.ExecPath != "" {
+		if _, err := os.Stat(cfg.ExecPath); err != nil {
+			return fmt.Errorf("exec_path %s is not usable: %w", cfg.ExecPath, err)
+		}
+	}
+	if cfg.WindowWidth <= 0 || cfg.WindowHeight <= 0 {
+		return fmt.Errorf("window_width and window_height must be greater than 0")
+	}
+	if cfg.Zoom <= 0 || cfg.Zoom > 5 {
+		return fmt.Errorf("zoom must be greater than 0 and at most 5, got %v", cfg.Zoom)
+	}
+	if cfg.GuardMemoryMB < 0 || cfg.GuardCPUPercent < 0 {
+		return fmt.Errorf("guard_memory_mb and guard_cpu_percent must not be negative")
+	}
+	if cfg.guardEnabled() && cfg.GuardInterval <= 0 {
+		return fmt.Errorf("guard_interval must be greater than 0")
+	}
+	switch cfg.GuardAction {
+	case "":
+		cfg.GuardAction = GuardActionReload
+	case GuardActionReload, GuardActionClose:
+	default:
+		return fmt.Errorf("guard_action must be %s or %s, got %s", GuardActionReload, GuardActionClose, cfg.GuardAction)
+	}
+	if cfg.Instances < 1 || cfg.Instances > maxInstances {
+		return fmt.Errorf("instances must be between 1 and %d, got %d", maxInstances, cfg.Instances)
+	}
+	if cfg.MaxSnapshots <= 0 {
+		return fmt.Errorf("max_snapshots must be greater than 0")
+	}
+	for _, proxy := range cfg.Proxies {
+		if strings.TrimSpace(proxy) == "" {
+			return fmt.Errorf("proxies must not be empty, leave out the list to use proxy")
+		}
+	}
+	names := make(map[string]bool, len(cfg.Logins))
+	for i := range cfg.Logins {
+		if err := cfg.Logins[i].Check(); err != nil {
+			return err
+		}
+		if names[cfg.Logins[i].Name] {
+			return fmt.Errorf("login profile %s is defined twice", cfg.Logins[i].Name)
+		}
+		names[cfg.Logins[i].Name] = true
+	}
 	if cfg.PromptFile != "" {
 		read, err := os.ReadFile(cfg.PromptFile)
 		if err != nil {
@@ -93,6 +164,14 @@ func (cfg *BrowserConfig) Check() error {
 	return nil
 }
 
+// proxyFor returns the proxy of the Chrome instance with the index, "" for none.
+func (cfg *BrowserConfig) proxyFor(index int) string {
+	if len(cfg.Proxies) == 0 {
+		return cfg.Proxy
+	}
+	return cfg.Proxies[index%len(cfg.Proxies)]
+}
+
 // NewBrowserConfig creates a new BrowserConfig with default values.
 func NewBrowserConfig() *BrowserConfig {
 	return &BrowserConfig{
@@ -100,6 +179,13 @@ func NewBrowserConfig() *BrowserConfig {
 		Timeout:              30,
 		URLTimeout:           10,
 		SelectorQueryTimeout: 10,
+		WindowWidth:          1280,
+		WindowHeight:         800,
+		Zoom:                 1,
+		GuardInterval:        60,
+		GuardAction:          GuardActionReload,
+		Instances:            1,
+		MaxSnapshots:         2,
 		UserAgent:            "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/134.0.0.0 Safari/537.36",
 		DefaultLanguage:      "en-US",
 		DataPath:             filepath.Join(os.TempDir(), ".moling", "data"),
@@ -25,6 +25,8 @@ import (
 	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
 )
 
 // handleDebugEnable handles the enabling and disabling of debugging in the browser.
@@ -36,18 +38,18 @@ func (bs *BrowserServer) handleDebugEnable(ctx context.Context, request mcp.Call
 	}
 
 	var err error
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
 
 	if enabled {
-		err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		err = bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 			t := chromedp.FromContext(ctx).Target
 			// 使用Execute方法执行AttachToTarget命令
 			params := target.AttachToTarget(t.TargetID).WithFlatten(true)
 			return t.Execute(ctx, "Target.attachToTarget", params, nil)
 		}))
 	} else {
-		err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		err = bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 			t := chromedp.FromContext(ctx).Target
 			// 使用Execute方法执行DetachFromTarget命令
 			params := target.DetachFromTarget().WithSessionID(t.SessionID)
@@ -71,23 +73,22 @@ func (bs *BrowserServer) handleSetBreakpoint(ctx context.Context, request mcp.Ca
 		return mcp.NewToolResultError("url must be a string"), nil
 	}
 
-	line, ok := args["line"].(float64)
-	if !ok {
+	if !abstract.Has(args, "line") {
 		return mcp.NewToolResultError("line must be a number"), nil
 	}
-
-	column, _ := args["column"].(float64)
-	condition, _ := args["condition"].(string)
+	line := abstract.GetInt(args, "line", 0)
+	column := abstract.GetInt(args, "column", 0)
+	condition := abstract.GetString(args, "condition", "")
 
 	var breakpointID string
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err := bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		params := map[string]any{
 			"url":       url,
-			"line":      int(line),
-			"column":    int(column),
+			"line":      line,
+			"column":    column,
 			"condition": condition,
 		}
 
@@ -118,9 +119,9 @@ func (bs *BrowserServer) handleRemoveBreakpoint(ctx context.Context, request mcp
 	if !ok {
 		return mcp.NewToolResultError("breakpointId must be a string"), nil
 	}
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err := bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.removeBreakpoint命令
 		return t.Execute(ctx, "Debugger.removeBreakpoint", map[string]any{
@@ -136,9 +137,9 @@ func (bs *BrowserServer) handleRemoveBreakpoint(ctx context.Context, request mcp
 
 // handlePause handles pausing the JavaScript execution in the browser.
 func (bs *BrowserServer) handlePause(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err := bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.pause命令
 		return t.Execute(ctx, "Debugger.pause", nil, nil)
@@ -152,9 +153,9 @@ func (bs *BrowserServer) handlePause(ctx context.Context, request mcp.CallToolRe
 
 // handleResume handles resuming the JavaScript execution in the browser.
 func (bs *BrowserServer) handleResume(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err := bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.resume命令
 		return t.Execute(ctx, "Debugger.resume", nil, nil)
@@ -169,9 +170,9 @@ func (bs *BrowserServer) handleResume(ctx context.Context, request mcp.CallToolR
 // handleStepOver handles stepping over the next line of JavaScript code in the browser.
 func (bs *BrowserServer) handleGetCallstack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var callstack any
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(bs.page(ctx))
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err := bs.run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.getStackTrace命令
 		return t.Execute(ctx, "Debugger.getStackTrace", nil, &callstack)
@@ -0,0 +1,328 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// DownloadsDir is the directory of the data path the downloads of browser_download_and_read are saved to.
+	DownloadsDir = "downloads"
+
+	downloadMaxBytesDefault = 1 << 20
+	downloadMaxBytesLimit   = 10 << 20
+)
+
+// Formats of the content of a download.
+const (
+	DownloadJSON   = "json"
+	DownloadCSV    = "csv"
+	DownloadText   = "text"
+	DownloadBinary = "binary"
+)
+
+// DownloadOutput is the structured result of browser_download_and_read.
+type DownloadOutput struct {
+	URL       string     `json:"url"` // URL is the URL the file was downloaded from.
+	Path      string     `json:"path"`
+	FileName  string     `json:"fileName"`
+	MIMEType  string     `json:"mimeType"`
+	Size      int64      `json:"size"`
+	Format    string     `json:"format"`
+	Truncated bool       `json:"truncated"` // Truncated is set when the file is larger than max_bytes, only its start is read.
+	JSON      any        `json:"json,omitempty"`
+	Rows      [][]string `json:"rows,omitempty"`
+	Text      string     `json:"text,omitempty"`
+}
+
+var downloadSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"url": {"type": "string"},
+		"path": {"type": "string"},
+		"fileName": {"type": "string"},
+		"mimeType": {"type": "string"},
+		"size": {"type": "integer"},
+		"format": {"type": "string", "enum": ["json", "csv", "text", "binary"]},
+		"truncated": {"type": "boolean"},
+		"json": {},
+		"rows": {"type": "array", "items": {"type": "array", "items": {"type": "string"}}},
+		"text": {"type": "string"}
+	},
+	"required": ["url", "path", "fileName", "mimeType", "size", "format", "truncated"]
+}`)
+
+// addDownloadTools adds browser_download_and_read.
+func (bs *BrowserServer) addDownloadTools() {
+	bs.AddStructuredTool(mcp.NewTool(
+		"browser_download_and_read",
+		mcp.WithDescription("Click an element or open a URL that starts a download, wait for the download to finish and return its content: JSON is decoded, CSV split into rows, other text returned as is. The file is kept in the downloads directory, binary files are only saved"),
+		mcp.WithString("selector",
+			mcp.Description("CSS selector of the link or button that starts the download"),
+		),
+		mcp.WithString("url",
+			mcp.Description("URL of the file to download, instead of a selector"),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Seconds to wait for the download to finish (default: the timeout of the config)"),
+		),
+		mcp.WithNumber("max_bytes",
+			mcp.Description(fmt.Sprintf("Bytes of the file to read, the rest is left out (default: %d, at most %d)", downloadMaxBytesDefault, downloadMaxBytesLimit)),
+		),
+	), downloadSchema, bs.handleDownloadAndRead)
+}
+
+// downloadWatch waits for the first download a page starts.
+type downloadWatch struct {
+	lock sync.Mutex
+	guid string
+	url  string
+	name string
+	done chan error // done gets the end of the download, nil once it completed.
+}
+
+// handleEvent follows the events of the first download.
+func (w *downloadWatch) handleEvent(ev any) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	switch ev := ev.(type) {
+	case *browser.EventDownloadWillBegin:
+		if w.guid == "" {
+			w.guid, w.url, w.name = ev.GUID, ev.URL, ev.SuggestedFilename
+		}
+	case *browser.EventDownloadProgress:
+		if ev.GUID != w.guid {
+			return
+		}
+		var err error
+		switch ev.State {
+		case browser.DownloadProgressStateCompleted:
+		case browser.DownloadProgressStateCanceled:
+			err = fmt.Errorf("the download of %s was canceled", w.url)
+		default:
+			return
+		}
+		select {
+		case w.done <- err:
+		default:
+		}
+	}
+}
+
+func (bs *BrowserServer) handleDownloadAndRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	selector := abstract.GetString(args, "selector", "")
+	target := abstract.GetString(args, "url", "")
+	if (selector == "") == (target == "") {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "either selector or url must be set"), nil
+	}
+	if target != "" {
+		if u, err := url.Parse(target); err != nil || u.Scheme == "" {
+			return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "url must be an absolute URL, got %q", target), nil
+		}
+	}
+	timeout := abstract.GetInt(args, "timeout", bs.config.Timeout)
+	maxBytes := min(max(abstract.GetInt(args, "max_bytes", downloadMaxBytesDefault), 1), downloadMaxBytesLimit)
+	dir := filepath.Join(bs.config.DataPath, DownloadsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to create the downloads directory: %s", err.Error()), nil
+	}
+
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(timeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	watch := &downloadWatch{done: make(chan error, 1)}
+	actions := []chromedp.Action{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			chromedp.ListenTarget(runCtx, watch.handleEvent)
+			return nil
+		}),
+		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).WithDownloadPath(dir).WithEventsEnabled(true),
+	}
+	if selector != "" {
+		actions = append(actions, chromedp.Click(selector, chromedp.NodeVisible))
+	} else {
+		// A URL that is downloaded does not load a page, the navigation ends as aborted
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, _, errorText, err := page.Navigate(target).Do(ctx)
+			if err == nil && errorText != "" && errorText != "net::ERR_ABORTED" {
+				err = fmt.Errorf("page load error %s", errorText)
+			}
+			return err
+		}))
+	}
+	bs.ReportProgress(ctx, 0, 1, "starting the download")
+	if err := bs.run(runCtx, actions...); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to start the download: %s", err.Error()), nil
+	}
+	// Downloads of later calls are saved to the default place of the browser again
+	defer func() {
+		resetCtx, cancel := context.WithTimeout(bs.page(context.Background()), 5*time.Second)
+		defer cancel()
+		if err := bs.run(resetCtx, browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorDefault)); err != nil {
+			bs.Logger.Debug().Err(err).Msg("failed to reset the download behavior")
+		}
+	}()
+
+	select {
+	case err := <-watch.done:
+		if err != nil {
+			return abstract.NewErrorResult(abstract.CodeUpstreamFailure, err.Error()), nil
+		}
+	case <-runCtx.Done():
+		watch.lock.Lock()
+		started := watch.guid != ""
+		watch.lock.Unlock()
+		if ctx.Err() != nil {
+			return abstract.NewErrorResult(abstract.CodeTimeout, ctx.Err().Error()), nil
+		}
+		if started {
+			return abstract.NewErrorResultf(abstract.CodeTimeout, "the download did not finish within %d seconds", timeout), nil
+		}
+		return abstract.NewErrorResultf(abstract.CodeTimeout, "no download started within %d seconds", timeout), nil
+	}
+	bs.ReportProgress(ctx, 1, 1, "downloaded")
+
+	watch.lock.Lock()
+	guid, source, name := watch.guid, watch.url, watch.name
+	watch.lock.Unlock()
+	path, err := keepDownload(dir, guid, name)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to save the download: %s", err.Error()), nil
+	}
+	output, err := readDownload(path, maxBytes)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to read the download: %s", err.Error()), nil
+	}
+	output.URL = source
+
+	text := fmt.Sprintf("Downloaded %s (%d bytes) to %s", output.FileName, output.Size, output.Path)
+	switch {
+	case output.Format == DownloadBinary:
+		text += fmt.Sprintf(", it is a %s file and was not read", output.MIMEType)
+	case output.Truncated:
+		text += fmt.Sprintf(", only its first %d bytes were read", maxBytes)
+	}
+	return abstract.NewStructuredResult(text, output), nil
+}
+
+// keepDownload renames a download, saved under its GUID, to its suggested file name. A number is added to the name
+// if a file has it already.
+func keepDownload(dir, guid, name string) (string, error) {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." || name == string(filepath.Separator) {
+		name = guid
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	path := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(dir, base+"_"+strconv.Itoa(i)+ext)
+	}
+	return path, os.Rename(filepath.Join(dir, guid), path)
+}
+
+// readDownload reads up to maxBytes of a downloaded file and decodes them by the type of the file.
+func readDownload(path string, maxBytes int) (DownloadOutput, error) {
+	output := DownloadOutput{Path: path, FileName: filepath.Base(path)}
+	f, err := os.Open(path)
+	if err != nil {
+		return output, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return output, err
+	}
+	output.Size = info.Size()
+	data, err := io.ReadAll(io.LimitReader(f, int64(maxBytes)))
+	if err != nil {
+		return output, err
+	}
+	output.Truncated = output.Size > int64(len(data))
+
+	ext := strings.ToLower(filepath.Ext(path))
+	output.MIMEType = mime.TypeByExtension(ext)
+	if output.MIMEType == "" {
+		output.MIMEType = http.DetectContentType(data)
+	}
+	if output.Truncated {
+		// Leave out the rune cut in half at the end
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		output.Format = DownloadBinary
+		return output, nil
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if !output.Truncated && (ext == ".json" || strings.Contains(output.MIMEType, "json") || (len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['))) {
+		var v any
+		if json.Unmarshal(trimmed, &v) == nil {
+			output.Format, output.JSON = DownloadJSON, v
+			return output, nil
+		}
+	}
+	if ext == ".csv" || ext == ".tsv" || strings.Contains(output.MIMEType, "csv") {
+		if output.Truncated {
+			// The last line is cut off
+			if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+				data = data[:i+1]
+			}
+		}
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord, r.LazyQuotes = -1, true
+		if ext == ".tsv" {
+			r.Comma = '\t'
+		}
+		if rows, err := r.ReadAll(); err == nil {
+			output.Format, output.Rows = DownloadCSV, rows
+			return output, nil
+		}
+	}
+	output.Format, output.Text = DownloadText, string(data)
+	return output, nil
+}
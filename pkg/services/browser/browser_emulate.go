@@ -0,0 +1,111 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// acceptLanguagePattern matches an Accept-Language value, a list of language tags with optional weights.
+var acceptLanguagePattern = regexp.MustCompile(`^[A-Za-z*]{1,8}(-[A-Za-z0-9]{1,8})*(;\s*q=[0-9.]{1,5})?(\s*,\s*[A-Za-z*]{1,8}(-[A-Za-z0-9]{1,8})*(;\s*q=[0-9.]{1,5})?)*$`)
+
+// addEmulateTools adds browser_emulate_media.
+func (bs *BrowserServer) addEmulateTools() {
+	bs.AddTool(mcp.NewTool(
+		"browser_emulate_media",
+		mcp.WithDescription("Emulate a CSS media type and media features for the page, such as dark mode or print, to check how the page looks with them. Omitted values go back to the browser's own"),
+		mcp.WithString("media",
+			mcp.Description("CSS media type to emulate"),
+			mcp.Enum("screen", "print"),
+		),
+		mcp.WithString("color_scheme",
+			mcp.Description("Value of prefers-color-scheme"),
+			mcp.Enum("light", "dark"),
+		),
+		mcp.WithString("reduced_motion",
+			mcp.Description("Value of prefers-reduced-motion"),
+			mcp.Enum("reduce", "no-preference"),
+		),
+	), bs.handleEmulateMedia)
+}
+
+// handleEmulateMedia sets the emulated media type and features of the page.
+func (bs *BrowserServer) handleEmulateMedia(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	media := abstract.GetString(args, "media", "")
+	var features []*emulation.MediaFeature
+	var described []string
+	if media != "" {
+		described = append(described, "media "+media)
+	}
+	for _, f := range []struct{ arg, feature string }{
+		{"color_scheme", "prefers-color-scheme"},
+		{"reduced_motion", "prefers-reduced-motion"},
+	} {
+		if v := abstract.GetString(args, f.arg, ""); v != "" {
+			features = append(features, &emulation.MediaFeature{Name: f.feature, Value: v})
+			described = append(described, fmt.Sprintf("%s: %s", f.feature, v))
+		}
+	}
+
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	// Features left out are reset, the emulation replaces the previous one
+	err := bs.run(runCtx, emulation.SetEmulatedMedia().WithMedia(media).WithFeatures(features))
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to emulate the media: %s", err.Error()), nil
+	}
+	if len(described) == 0 {
+		return mcp.NewToolResultText("Stopped emulating media, the page uses the browser's own media type and features"), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Emulating %s", strings.Join(described, ", "))), nil
+}
+
+// navigateOverride returns the user agent override of a browser_navigate call, made of the language and user_agent
+// arguments and the config for those left out. The override stays on the tab until the next navigation replaces it,
+// so every navigation sets one and a navigation without the arguments goes back to the config. Values left empty by
+// the config clear the override, which restores those of the browser.
+func (bs *BrowserServer) navigateOverride(args map[string]any) (*emulation.SetUserAgentOverrideParams, error) {
+	language := strings.TrimSpace(abstract.GetString(args, "language", ""))
+	userAgent := abstract.GetString(args, "user_agent", "")
+	if language != "" && !acceptLanguagePattern.MatchString(language) {
+		return nil, fmt.Errorf("language %q is not a valid Accept-Language, such as de-DE or fr-FR,fr;q=0.9", language)
+	}
+	if strings.IndexFunc(userAgent, unicode.IsControl) >= 0 {
+		return nil, fmt.Errorf("user_agent must not contain control characters")
+	}
+	userAgent = strings.TrimSpace(userAgent)
+	if language == "" {
+		language = bs.config.DefaultLanguage
+	}
+	if userAgent == "" {
+		userAgent = bs.config.UserAgent
+	}
+	return emulation.SetUserAgentOverride(userAgent).WithAcceptLanguage(language), nil
+}
@@ -0,0 +1,369 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// FarmContextArgument is the argument of the browser tools that names the context a call runs in, in farm mode.
+	FarmContextArgument = "context"
+	// maxInstances is the largest number of Chrome instances of the farm mode.
+	maxInstances = 32
+)
+
+// farmTabKey is the key of the context of the tab a tool call runs in.
+type farmTabKey struct{}
+
+// farmInstance is a Chrome of the farm. The first one is the main Chrome of the service.
+type farmInstance struct {
+	proxy   string
+	profile string             // profile is the user data directory, "" for the main Chrome.
+	ctx     context.Context    // ctx is the context of the started Chrome, nil before the first call.
+	cancel  context.CancelFunc // cancel stops the Chrome.
+}
+
+// farmTab is the tab of a named context, all its tool calls run in it.
+type farmTab struct {
+	instance int
+	ctx      context.Context // ctx is the context of the tab, nil before the first call.
+	cancel   context.CancelFunc
+	lastUsed time.Time
+}
+
+// browserFarm runs the tool calls of named contexts across several Chrome instances. A context sticks to the instance
+// it was first given, the one with the fewest contexts, and gets a tab of its own there.
+type browserFarm struct {
+	lock      sync.Mutex
+	instances []*farmInstance
+	tabs      map[string]*farmTab
+}
+
+// FarmContext describes a named context of the farm.
+type FarmContext struct {
+	Name     string `json:"name"`
+	Instance int    `json:"instance"`
+	LastUsed string `json:"last_used"`
+}
+
+// FarmInstance describes a Chrome of the farm.
+type FarmInstance struct {
+	Index    int      `json:"index"`
+	Proxy    string   `json:"proxy,omitempty"` // Proxy is the proxy of the instance, with its password redacted.
+	Running  bool     `json:"running"`
+	Contexts []string `json:"contexts"`
+}
+
+// newFarm prepares the instances of the farm mode, nil if a single Chrome runs. Chrome is started by the first call of
+// a context given to an instance.
+func (bs *BrowserServer) newFarm() *browserFarm {
+	if bs.config.Instances <= 1 {
+		return nil
+	}
+	f := &browserFarm{tabs: make(map[string]*farmTab)}
+	for i := 0; i < bs.config.Instances; i++ {
+		inst := &farmInstance{proxy: bs.config.proxyFor(i)}
+		if i > 0 {
+			inst.profile = filepath.Join(bs.config.BrowserDataPath, "farm", strconv.Itoa(i))
+		}
+		f.instances = append(f.instances, inst)
+	}
+	return f
+}
+
+// pick returns the instance with the fewest contexts, the first one of them. The main tab counts as a context of
+// the main Chrome.
+func (f *browserFarm) pick() int {
+	load := make([]int, len(f.instances))
+	load[0] = 1
+	for _, tab := range f.tabs {
+		load[tab.instance]++
+	}
+	best := 0
+	for i := range load {
+		if load[i] < load[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// tabOf returns the context of the tab of a named context. The first call opens the tab and starts its Chrome.
+// Calls of the same context therefore always run in the same tab of the same Chrome.
+func (bs *BrowserServer) tabOf(name string) (context.Context, error) {
+	f := bs.farm
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	tab, ok := f.tabs[name]
+	if !ok {
+		tab = &farmTab{instance: f.pick()}
+		f.tabs[name] = tab
+		bs.Logger.Debug().Str("context", name).Int("instance", tab.instance).Msg("browser context assigned")
+	}
+	tab.lastUsed = time.Now()
+	if tab.ctx != nil && tab.ctx.Err() == nil {
+		return tab.ctx, nil
+	}
+	parent, err := bs.instanceContext(f.instances[tab.instance], tab.instance)
+	if err != nil {
+		return nil, err
+	}
+	tab.ctx, tab.cancel = chromedp.NewContext(parent)
+	chromedp.ListenTarget(tab.ctx, bs.handleTargetEvent)
+	return tab.ctx, nil
+}
+
+// instanceContext returns the context of a started Chrome of the farm, and starts it if needed: the tabs of an instance
+// must be opened in a running Chrome, or they start another Chrome on the same profile. The caller holds the farm lock.
+func (bs *BrowserServer) instanceContext(inst *farmInstance, index int) (context.Context, error) {
+	ctx := inst.ctx
+	if index == 0 {
+		ctx = bs.chrome()
+	} else if ctx == nil || ctx.Err() != nil {
+		if err := bs.initBrowser(inst.profile); err != nil {
+			return nil, fmt.Errorf("failed to prepare the profile of instance %d: %w", index, err)
+		}
+		opts := append(slices.Clone(bs.chromeOpts), chromedp.UserDataDir(inst.profile))
+		if inst.proxy != "" {
+			opts = append(opts, chromedp.ProxyServer(inst.proxy))
+		}
+		allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+		var cancelChrome context.CancelFunc
+		ctx, cancelChrome = chromedp.NewContext(allocCtx,
+			chromedp.WithErrorf(bs.Logger.Error().Msgf),
+			chromedp.WithDebugf(bs.Logger.Debug().Msgf),
+		)
+		inst.ctx, inst.cancel = ctx, func() {
+			cancelChrome()
+			cancelAlloc()
+		}
+	}
+	if c := chromedp.FromContext(ctx); c != nil && c.Browser != nil {
+		return ctx, nil
+	}
+	if err := bs.run(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start Chrome instance %d: %w", index, err)
+	}
+	return ctx, nil
+}
+
+// page returns the context the actions of a tool call run in: the tab of its named context in farm mode, the main
+// tab otherwise.
+func (bs *BrowserServer) page(ctx context.Context) context.Context {
+	if tab, ok := ctx.Value(farmTabKey{}).(context.Context); ok {
+		return tab
+	}
+	return bs.chrome()
+}
+
+// AddTool adds a tool of the service. In farm mode, the tool takes the context argument and runs in the tab of the
+// named context.
+func (bs *BrowserServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if bs.farm != nil {
+		tool, handler = bs.farmTool(tool, handler)
+	}
+	bs.MLService.AddTool(tool, handler)
+}
+
+// AddStructuredTool adds a tool of the service with an output schema, see AddTool.
+func (bs *BrowserServer) AddStructuredTool(tool mcp.Tool, outputSchema json.RawMessage, handler server.ToolHandlerFunc) {
+	if bs.farm != nil {
+		tool, handler = bs.farmTool(tool, handler)
+	}
+	bs.MLService.AddStructuredTool(tool, outputSchema, handler)
+}
+
+// farmTool adds the context argument to a tool, and makes its handler run in the tab of the context.
+func (bs *BrowserServer) farmTool(tool mcp.Tool, handler server.ToolHandlerFunc) (mcp.Tool, server.ToolHandlerFunc) {
+	mcp.WithString(FarmContextArgument,
+		mcp.Description("Name of the browser context to run in, such as the task or site. All calls of a context run in the same tab of the same Chrome instance, leave it out for the main tab"),
+	)(&tool)
+	return tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := abstract.GetString(request.GetArguments(), FarmContextArgument, "")
+		if name == "" || bs.backend != nil {
+			return handler(ctx, request)
+		}
+		tab, err := bs.tabOf(name)
+		if err != nil {
+			return abstract.NewErrorResult(abstract.CodeUpstreamFailure, err.Error()), nil
+		}
+		return handler(context.WithValue(ctx, farmTabKey{}, tab), request)
+	}
+}
+
+// closeTab closes the tab of a named context. It reports false for unknown contexts.
+func (f *browserFarm) closeTab(name string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	tab, ok := f.tabs[name]
+	if !ok {
+		return false
+	}
+	if tab.cancel != nil {
+		tab.cancel()
+	}
+	delete(f.tabs, name)
+	return true
+}
+
+// closeTabs closes the tabs of all the contexts, the Chrome instances keep running. It returns the number of contexts.
+func (f *browserFarm) closeTabs() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.closeTabsLocked()
+}
+
+// closeTabsLocked is closeTabs for callers holding the lock.
+func (f *browserFarm) closeTabsLocked() int {
+	n := len(f.tabs)
+	for name, tab := range f.tabs {
+		if tab.cancel != nil {
+			tab.cancel()
+		}
+		delete(f.tabs, name)
+	}
+	return n
+}
+
+// stop closes the tabs of the contexts and stops the Chrome instances other than the main one.
+func (f *browserFarm) stop() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.closeTabsLocked()
+	for _, inst := range f.instances {
+		if inst.cancel != nil {
+			inst.cancel()
+			inst.ctx, inst.cancel = nil, nil
+		}
+	}
+}
+
+// processes returns the process IDs of the started Chrome instances other than the main one.
+func (f *browserFarm) processes() []int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var pids []int
+	for _, inst := range f.instances {
+		if inst.ctx == nil {
+			continue
+		}
+		if c := chromedp.FromContext(inst.ctx); c != nil && c.Browser != nil {
+			if p := c.Browser.Process(); p != nil {
+				pids = append(pids, p.Pid)
+			}
+		}
+	}
+	return pids
+}
+
+// describe lists the instances and the contexts of the farm.
+func (f *browserFarm) describe(mainRunning bool) ([]FarmInstance, []FarmContext) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	instances := make([]FarmInstance, len(f.instances))
+	for i, inst := range f.instances {
+		instances[i] = FarmInstance{Index: i, Proxy: redactProxy(inst.proxy), Running: inst.ctx != nil && inst.ctx.Err() == nil, Contexts: []string{}}
+	}
+	instances[0].Running = mainRunning
+	contexts := make([]FarmContext, 0, len(f.tabs))
+	for name, tab := range f.tabs {
+		instances[tab.instance].Contexts = append(instances[tab.instance].Contexts, name)
+		contexts = append(contexts, FarmContext{Name: name, Instance: tab.instance, LastUsed: tab.lastUsed.Format(time.RFC3339)})
+	}
+	for i := range instances {
+		sort.Strings(instances[i].Contexts)
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return instances, contexts
+}
+
+// redactProxy hides the password of a proxy URL.
+func redactProxy(proxy string) string {
+	if u, err := url.Parse(proxy); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return proxy
+}
+
+// addFarmTools adds browser_farm in farm mode.
+func (bs *BrowserServer) addFarmTools() {
+	if bs.farm == nil {
+		return
+	}
+	bs.MLService.AddTool(mcp.NewTool(
+		"browser_farm",
+		mcp.WithDescription(fmt.Sprintf("List the %d Chrome instances of the browser farm with their proxies and named contexts, or close a named context and its tab. Pass the same context argument to the browser tools for all the calls of one task; new contexts go to the instance with the fewest", len(bs.farm.instances))),
+		mcp.WithString("action",
+			mcp.Description("list (default) or close"),
+			mcp.Enum("list", "close"),
+		),
+		mcp.WithString(FarmContextArgument,
+			mcp.Description("Name of the context to close"),
+		),
+	), bs.handleFarm)
+}
+
+func (bs *BrowserServer) handleFarm(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	switch action := abstract.GetString(args, "action", "list"); action {
+	case "list":
+		c := chromedp.FromContext(bs.chrome())
+		instances, contexts := bs.farm.describe(c != nil && c.Browser != nil)
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d Chrome instances, %d contexts:\n", len(instances), len(contexts))
+		for _, inst := range instances {
+			state := "not started"
+			if inst.Running {
+				state = "running"
+			}
+			proxy := inst.Proxy
+			if proxy == "" {
+				proxy = "no proxy"
+			}
+			fmt.Fprintf(&b, "- %d: %s, %s, contexts: %s\n", inst.Index, state, proxy, strings.Join(inst.Contexts, ", "))
+		}
+		return abstract.NewStructuredResult(b.String(), map[string]any{"instances": instances, "contexts": contexts}), nil
+	case "close":
+		name := abstract.GetString(args, FarmContextArgument, "")
+		if name == "" {
+			return abstract.NewErrorResult(abstract.CodeInvalidArgument, "context is required to close a context"), nil
+		}
+		if !bs.farm.closeTab(name) {
+			return abstract.NewErrorResultf(abstract.CodeNotFound, "there is no context %s", name), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Closed the context %s and its tab.", name)), nil
+	default:
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "unknown action %s, use list or close", action), nil
+	}
+}
@@ -0,0 +1,399 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// FormField is a fillable field of a page form, as described by its markup.
+type FormField struct {
+	Selector     string `json:"selector"` // Selector is a unique selector of the field, or of the first button of a radio group.
+	Tag          string `json:"tag"`      // Tag is input, select or textarea.
+	Type         string `json:"type"`     // Type is the type attribute of inputs, such as email or radio.
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	Autocomplete string `json:"autocomplete"`
+	Label        string `json:"label"` // Label is the text of the label, the aria-label or the legend of a radio group.
+	Placeholder  string `json:"placeholder"`
+}
+
+// formFieldsScript marks the visible fields of the form, or of the whole page without a form selector, with
+// data-moling-field and describes them. Radio buttons with the same name are one field.
+const formFieldsScript = `((formSelector) => {
+	const root = formSelector ? document.querySelector(formSelector) : document;
+	if (!root) {
+		return null;
+	}
+	const text = el => el ? el.innerText.replace(/\s+/g, ' ').trim() : '';
+	const labelOf = el => {
+		if (el.getAttribute('aria-label')) {
+			return el.getAttribute('aria-label');
+		}
+		const by = el.getAttribute('aria-labelledby');
+		if (by) {
+			return by.split(/\s+/).map(id => text(document.getElementById(id))).join(' ').trim();
+		}
+		if (el.id) {
+			const label = document.querySelector('label[for="' + CSS.escape(el.id) + '"]');
+			if (label) {
+				return text(label);
+			}
+		}
+		return text(el.closest('label'));
+	};
+	const fields = [];
+	const radios = {};
+	root.querySelectorAll('input, select, textarea').forEach(el => {
+		const type = (el.getAttribute('type') || '').toLowerCase();
+		if (el.disabled || el.readOnly || ['hidden', 'submit', 'button', 'reset', 'image', 'file'].includes(type) ||
+			el.offsetParent === null) {
+			return;
+		}
+		if (type === 'radio' && el.name) {
+			if (radios[el.name]) {
+				return;
+			}
+			radios[el.name] = true;
+		}
+		const mark = String(fields.length);
+		el.setAttribute('data-moling-field', mark);
+		const fieldset = type === 'radio' ? el.closest('fieldset') : null;
+		fields.push({
+			selector: '[data-moling-field="' + mark + '"]',
+			tag: el.tagName.toLowerCase(),
+			type: type,
+			name: el.name || '',
+			id: el.id || '',
+			autocomplete: el.getAttribute('autocomplete') || '',
+			label: fieldset ? text(fieldset.querySelector('legend')) : labelOf(el),
+			placeholder: el.getAttribute('placeholder') || '',
+		});
+	});
+	return fields;
+})(%s)`
+
+// chooseScript sets a select, checkbox or radio group to a value, matching options and radio buttons by value or
+// label, and reports whether it did.
+const chooseScript = `((selector, value) => {
+	const el = document.querySelector(selector);
+	if (!el) {
+		return false;
+	}
+	const want = value.trim().toLowerCase();
+	const changed = target => {
+		target.dispatchEvent(new Event('input', {bubbles: true}));
+		target.dispatchEvent(new Event('change', {bubbles: true}));
+		return true;
+	};
+	if (el.tagName === 'SELECT') {
+		const option = Array.from(el.options).find(o => o.value.toLowerCase() === want || o.text.trim().toLowerCase() === want);
+		if (!option) {
+			return false;
+		}
+		el.value = option.value;
+		return changed(el);
+	}
+	if (el.type === 'checkbox') {
+		const on = !['', 'false', 'no', 'off', '0'].includes(want);
+		if (el.checked !== on) {
+			el.click();
+		}
+		return true;
+	}
+	const scope = el.form || document;
+	const radio = Array.from(scope.querySelectorAll('input[type=radio]')).filter(r => r.name === el.name).find(r => {
+		const label = r.closest('label') || (r.id && document.querySelector('label[for="' + CSS.escape(r.id) + '"]'));
+		return r.value.toLowerCase() === want || (label && label.innerText.trim().toLowerCase() === want);
+	});
+	if (!radio) {
+		return false;
+	}
+	radio.click();
+	return true;
+})(%s, %s)`
+
+// formHint describes the fields a payload key stands for.
+type formHint struct {
+	keys         []string // keys are the normalized payload keys of the hint.
+	autocomplete []string // autocomplete are the autocomplete tokens of the fields.
+	types        []string // types are the input types of the fields.
+	words        []string // words are the normalized words of their names and labels.
+}
+
+// formHints are the usual personal, address and payment fields of registration and checkout forms.
+var formHints = []formHint{
+	{keys: []string{"name", "full name"}, autocomplete: []string{"name"}, words: []string{"name", "full name", "your name"}},
+	{keys: []string{"first name", "given name", "firstname"}, autocomplete: []string{"given-name"}, words: []string{"first name", "given name", "firstname", "fname", "forename"}},
+	{keys: []string{"last name", "family name", "surname", "lastname"}, autocomplete: []string{"family-name"}, words: []string{"last name", "family name", "surname", "lastname", "lname"}},
+	{keys: []string{"email", "e mail", "email address"}, autocomplete: []string{"email"}, types: []string{"email"}, words: []string{"email", "e mail", "email address", "mail"}},
+	{keys: []string{"phone", "telephone", "tel", "mobile", "phone number"}, autocomplete: []string{"tel", "tel-national"}, types: []string{"tel"}, words: []string{"phone", "telephone", "tel", "mobile", "phone number", "cell"}},
+	{keys: []string{"username", "user name", "login"}, autocomplete: []string{"username"}, words: []string{"username", "user name", "login", "user"}},
+	{keys: []string{"password"}, autocomplete: []string{"new-password", "current-password"}, types: []string{"password"}, words: []string{"password", "passwd", "pass"}},
+	{keys: []string{"company", "organization", "organisation"}, autocomplete: []string{"organization"}, words: []string{"company", "organization", "organisation", "company name"}},
+	{keys: []string{"address", "street", "street address", "address line1", "address1"}, autocomplete: []string{"street-address", "address-line1"}, words: []string{"address", "street", "street address", "address line 1", "address1", "address line1"}},
+	{keys: []string{"address2", "address line2", "apartment", "suite"}, autocomplete: []string{"address-line2"}, words: []string{"address line 2", "address2", "address line2", "apartment", "suite", "apt"}},
+	{keys: []string{"city", "town"}, autocomplete: []string{"address-level2"}, words: []string{"city", "town", "locality"}},
+	{keys: []string{"state", "province", "region"}, autocomplete: []string{"address-level1"}, words: []string{"state", "province", "region", "county"}},
+	{keys: []string{"zip", "postal code", "postcode", "zip code", "zipcode"}, autocomplete: []string{"postal-code"}, words: []string{"zip", "postal code", "postcode", "zip code", "zipcode", "postal"}},
+	{keys: []string{"country"}, autocomplete: []string{"country", "country-name"}, words: []string{"country"}},
+	{keys: []string{"birthday", "birth date", "date of birth", "dob"}, autocomplete: []string{"bday"}, words: []string{"birthday", "birth date", "date of birth", "dob"}},
+	{keys: []string{"card name", "cardholder", "name on card"}, autocomplete: []string{"cc-name"}, words: []string{"name on card", "cardholder", "card name", "cardholder name"}},
+	{keys: []string{"card number", "cc number", "credit card"}, autocomplete: []string{"cc-number"}, words: []string{"card number", "cc number", "credit card", "card no"}},
+	{keys: []string{"expiry", "expiration", "card expiry", "cc exp"}, autocomplete: []string{"cc-exp"}, words: []string{"expiry", "expiration", "expiration date", "exp date", "mm yy"}},
+	{keys: []string{"cvc", "cvv", "security code", "cc csc"}, autocomplete: []string{"cc-csc"}, words: []string{"cvc", "cvv", "security code", "csc"}},
+}
+
+// normalizeFormText lowercases the text and splits it into words, also at camelCase boundaries, joined by spaces.
+func normalizeFormText(s string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			b.WriteRune(' ')
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			r = ' '
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// hintFor returns the hint of a payload key, or a hint that matches the key's own words.
+func hintFor(key string) formHint {
+	norm := normalizeFormText(key)
+	for _, h := range formHints {
+		for _, k := range h.keys {
+			if k == norm {
+				return h
+			}
+		}
+	}
+	return formHint{words: []string{norm}}
+}
+
+// hasPhrase reports whether the words of phrase appear in text as whole words.
+func hasPhrase(text, phrase string) bool {
+	return phrase != "" && strings.Contains(" "+text+" ", " "+phrase+" ")
+}
+
+// score rates how well a field matches the hint, 0 if it does not.
+func (h formHint) score(f FormField) int {
+	best := 0
+	for _, token := range strings.Fields(strings.ToLower(f.Autocomplete)) {
+		for _, a := range h.autocomplete {
+			if token == a {
+				return 100
+			}
+		}
+	}
+	for _, t := range h.types {
+		if f.Type == t {
+			best = 60
+		}
+	}
+	label, placeholder := normalizeFormText(f.Label), normalizeFormText(f.Placeholder)
+	for _, w := range h.words {
+		compact := strings.ReplaceAll(w, " ", "")
+		for _, attr := range []string{f.Name, f.ID} {
+			attr = normalizeFormText(attr)
+			switch {
+			case attr == w || strings.ReplaceAll(attr, " ", "") == compact:
+				best = max(best, 80)
+			case hasPhrase(attr, w):
+				best = max(best, 40)
+			}
+		}
+		for _, text := range []string{label, placeholder} {
+			switch {
+			case text == w:
+				best = max(best, 70)
+			case hasPhrase(text, w):
+				best = max(best, 50)
+			}
+		}
+	}
+	return best
+}
+
+// matchFormFields assigns each payload key to the field that matches it best, each field to one key at most. It
+// returns the field index of the matched keys and the keys that match no field.
+func matchFormFields(fields []FormField, keys []string) (map[string]int, []string) {
+	type candidate struct {
+		key   string
+		field int
+		score int
+	}
+	var candidates []candidate
+	for _, key := range keys {
+		h := hintFor(key)
+		for i, f := range fields {
+			if s := h.score(f); s > 0 {
+				candidates = append(candidates, candidate{key: key, field: i, score: s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	matched := make(map[string]int, len(keys))
+	taken := make(map[int]bool, len(fields))
+	for _, c := range candidates {
+		if _, ok := matched[c.key]; ok || taken[c.field] {
+			continue
+		}
+		matched[c.key] = c.field
+		taken[c.field] = true
+	}
+	var unmatched []string
+	for _, key := range keys {
+		if _, ok := matched[key]; !ok {
+			unmatched = append(unmatched, key)
+		}
+	}
+	return matched, unmatched
+}
+
+// describe names the field for the result of browser_smart_fill.
+func (f FormField) describe() string {
+	for _, s := range []string{f.Label, f.Placeholder, f.Name, f.ID} {
+		if s != "" {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	return f.Selector
+}
+
+// addFormTools adds browser_smart_fill.
+func (bs *BrowserServer) addFormTools() {
+	bs.AddTool(mcp.NewTool(
+		"browser_smart_fill",
+		mcp.WithDescription("Fill a form from key/value pairs such as name, email, address, city, zip and country. The fields are found by their labels, autocomplete attributes, aria labels, names and placeholders, so no selectors are needed. Selects, checkboxes and radio groups are set by value or label"),
+		mcp.WithObject("fields",
+			mcp.Description(`Values to fill by field meaning, e.g. {"first_name": "Ada", "email": "ada@example.com", "country": "UK"}`),
+			mcp.Required(),
+		),
+		mcp.WithString("form_selector",
+			mcp.Description("CSS selector of the form to fill (default: the whole page)"),
+		),
+		mcp.WithBoolean("submit",
+			mcp.Description("Submit the form after filling it by pressing Enter in the last filled text field (default: false)"),
+		),
+	), bs.handleSmartFill)
+}
+
+// handleSmartFill maps the payload keys onto the fields of the page and fills them.
+func (bs *BrowserServer) handleSmartFill(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	payload := abstract.GetMap(args, "fields")
+	if len(payload) == 0 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "fields must have at least one value to fill"), nil
+	}
+	formSelector := abstract.GetString(args, "form_selector", "")
+	keys := make([]string, 0, len(payload))
+	for key := range payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+
+	quoted, _ := json.Marshal(formSelector)
+	var fields []FormField
+	if err := bs.run(runCtx, chromedp.Evaluate(fmt.Sprintf(formFieldsScript, quoted), &fields)); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to find the form fields: %s", err.Error()), nil
+	}
+	if len(fields) == 0 {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "no fillable fields found on the page%s", formScope(formSelector)), nil
+	}
+	matched, unmatched := matchFormFields(fields, keys)
+	if len(matched) == 0 {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "none of %s matches a field%s", strings.Join(keys, ", "), formScope(formSelector)), nil
+	}
+
+	var report []string
+	lastText := ""
+	for _, key := range keys {
+		i, ok := matched[key]
+		if !ok {
+			continue
+		}
+		f := fields[i]
+		value := fmt.Sprint(payload[key])
+		if f.Tag == "select" || f.Type == "checkbox" || f.Type == "radio" {
+			quotedSelector, _ := json.Marshal(f.Selector)
+			quotedValue, _ := json.Marshal(value)
+			var chosen bool
+			err := bs.run(runCtx, chromedp.Evaluate(fmt.Sprintf(chooseScript, quotedSelector, quotedValue), &chosen))
+			if err != nil {
+				return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to set %s: %s", f.describe(), err.Error()), nil
+			}
+			if !chosen {
+				unmatched = append(unmatched, fmt.Sprintf("%s (%s has no option %q)", key, f.describe(), value))
+				continue
+			}
+		} else {
+			if err := bs.run(runCtx, typeInto(f.Selector, value)); err != nil {
+				return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to fill %s: %s", f.describe(), err.Error()), nil
+			}
+			lastText = f.Selector
+		}
+		report = append(report, fmt.Sprintf("%s -> %s", key, f.describe()))
+	}
+
+	if abstract.GetBool(args, "submit", false) {
+		if lastText == "" {
+			return abstract.NewErrorResult(abstract.CodeInvalidArgument, "no text field was filled to submit the form from, click its submit button with browser_click"), nil
+		}
+		if err := bs.run(runCtx, submitAction(lastText, "")); err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to submit the form: %s", err.Error()), nil
+		}
+		report = append(report, "submitted the form")
+	}
+	text := "Filled " + strings.Join(report, ", ")
+	if len(unmatched) > 0 {
+		text += "\nNot filled: " + strings.Join(unmatched, ", ")
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// formScope names the form a search was limited to, for errors.
+func formScope(formSelector string) string {
+	if formSelector == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in %s", formSelector)
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/performance"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	GuardActionReload = "reload"
+	GuardActionClose  = "close"
+
+	// guardCPUChecks is the number of checks in a row a tab must use too much CPU for, short bursts are normal.
+	guardCPUChecks = 2
+)
+
+// guardedTab is a tab the guard measures.
+type guardedTab struct {
+	ctx    context.Context // ctx is attached to the tab, cancelling it closes the tab.
+	cancel context.CancelFunc
+	tasks  float64   // tasks is the TaskDuration metric of the last check, the CPU seconds the tab used so far.
+	at     time.Time // at is the time of the last check, zero before the first.
+	busy   int       // busy counts the checks in a row over the CPU limit.
+}
+
+// usage returns the JavaScript heap in MB and the CPU percentage since the last check from the metrics of the tab.
+func (t *guardedTab) usage(metrics []*performance.Metric, now time.Time) (heapMB, cpu float64) {
+	var tasks float64
+	for _, m := range metrics {
+		switch m.Name {
+		case "JSHeapUsedSize":
+			heapMB = m.Value / (1 << 20)
+		case "TaskDuration":
+			tasks = m.Value
+		}
+	}
+	if !t.at.IsZero() && now.After(t.at) && tasks >= t.tasks {
+		cpu = (tasks - t.tasks) / now.Sub(t.at).Seconds() * 100
+	}
+	t.tasks, t.at = tasks, now
+	return heapMB, cpu
+}
+
+// overLimit returns why the tab must be recycled, or "" if it is within the limits.
+func (cfg *BrowserConfig) overLimit(t *guardedTab, heapMB, cpu float64) string {
+	if cfg.GuardMemoryMB > 0 && heapMB > float64(cfg.GuardMemoryMB) {
+		return fmt.Sprintf("its JavaScript heap is %.0f MB, over the limit of %d MB", heapMB, cfg.GuardMemoryMB)
+	}
+	if cfg.GuardCPUPercent > 0 && cpu > float64(cfg.GuardCPUPercent) {
+		t.busy++
+		if t.busy >= guardCPUChecks {
+			return fmt.Sprintf("it used %.0f%% CPU, over the limit of %d%%", cpu, cfg.GuardCPUPercent)
+		}
+		return ""
+	}
+	t.busy = 0
+	return ""
+}
+
+// guardEnabled reports whether a memory or CPU limit is set.
+func (cfg *BrowserConfig) guardEnabled() bool {
+	return cfg.GuardMemoryMB > 0 || cfg.GuardCPUPercent > 0
+}
+
+// guard checks the memory and CPU use of the tabs every GuardInterval until ctx ends, and reloads or closes the tabs
+// over the limits.
+func (bs *BrowserServer) guard(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(bs.config.GuardInterval) * time.Second)
+	defer ticker.Stop()
+	tabs := make(map[target.ID]*guardedTab)
+	var chrome context.Context
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := bs.chrome()
+		if current != chrome {
+			// A restart replaced Chrome, the tabs of the old one are gone
+			chrome = current
+			tabs = make(map[target.ID]*guardedTab)
+		}
+		if err := bs.guardTabs(ctx, chrome, tabs); err != nil {
+			bs.Logger.Debug().Err(err).Msg("failed to check the tabs")
+		}
+	}
+}
+
+// guardTabs checks the tabs of a started Chrome once.
+func (bs *BrowserServer) guardTabs(ctx context.Context, chrome context.Context, tabs map[target.ID]*guardedTab) error {
+	c := chromedp.FromContext(chrome)
+	if bs.backend != nil || c == nil || c.Browser == nil || chrome.Err() != nil {
+		return nil
+	}
+	listCtx, cancel := context.WithTimeout(chrome, 5*time.Second)
+	infos, err := target.GetTargets().Do(cdp.WithExecutor(listCtx, c.Browser))
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list the tabs: %w", err)
+	}
+	var main target.ID
+	if c.Target != nil {
+		main = c.Target.TargetID
+	}
+	seen := make(map[target.ID]bool, len(infos))
+	for _, info := range infos {
+		if info.Type != "page" {
+			continue
+		}
+		seen[info.TargetID] = true
+		t, ok := tabs[info.TargetID]
+		if !ok {
+			t = &guardedTab{ctx: chrome}
+			if info.TargetID != main {
+				t.ctx, t.cancel = chromedp.NewContext(chrome, chromedp.WithTargetID(info.TargetID))
+			}
+			tabs[info.TargetID] = t
+		}
+		bs.guardTab(ctx, t, info, info.TargetID == main)
+		if t.ctx.Err() != nil {
+			delete(tabs, info.TargetID)
+		}
+	}
+	for id := range tabs {
+		if !seen[id] {
+			// The tab was closed, which the detach of its context notices
+			if tabs[id].cancel != nil {
+				tabs[id].cancel()
+			}
+			delete(tabs, id)
+		}
+	}
+	return nil
+}
+
+// guardTab measures a tab and recycles it if it is over a limit. The main tab is reloaded, closing it would stop the
+// tools.
+func (bs *BrowserServer) guardTab(ctx context.Context, t *guardedTab, info *target.Info, isMain bool) {
+	runCtx, cancel := context.WithTimeout(t.ctx, 5*time.Second)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	var metrics []*performance.Metric
+	err := chromedp.Run(runCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		if t.at.IsZero() {
+			if err := performance.Enable().Do(ctx); err != nil {
+				return err
+			}
+		}
+		var err error
+		metrics, err = performance.GetMetrics().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		bs.Logger.Debug().Err(err).Str("url", info.URL).Msg("failed to measure the tab")
+		return
+	}
+	heapMB, cpu := t.usage(metrics, time.Now())
+	reason := bs.config.overLimit(t, heapMB, cpu)
+	if reason == "" {
+		return
+	}
+
+	action := bs.config.GuardAction
+	if isMain {
+		action = GuardActionReload
+	}
+	if action == GuardActionClose {
+		t.cancel()
+	} else {
+		reloadCtx, cancelReload := context.WithTimeout(t.ctx, time.Duration(bs.config.URLTimeout)*time.Second)
+		err = chromedp.Run(reloadCtx, chromedp.Reload())
+		cancelReload()
+		t.at, t.busy = time.Time{}, 0
+	}
+	message := fmt.Sprintf("the tab %s was %s because %s", info.URL, map[string]string{GuardActionReload: "reloaded", GuardActionClose: "closed"}[action], reason)
+	if err != nil {
+		message = fmt.Sprintf("failed to reload the tab %s, %s: %s", info.URL, reason, err.Error())
+	}
+	bs.Logger.Warn().Str("url", info.URL).Str("action", action).Float64("heap_mb", heapMB).Float64("cpu", cpu).Msg(message)
+	bs.LogToClients(mcp.LoggingLevelWarning, string(BrowserServerName), map[string]any{"message": message, "url": info.URL})
+}
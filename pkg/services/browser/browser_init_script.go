@@ -0,0 +1,143 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// InitScript is a script that runs in every new document of the page before its own scripts.
+type InitScript struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size int    `json:"size"` // Size is the length of the source in bytes.
+}
+
+// addInitScriptTools adds browser_add_init_script and browser_remove_init_script.
+func (bs *BrowserServer) addInitScriptTools() {
+	bs.AddTool(mcp.NewTool(
+		"browser_add_init_script",
+		mcp.WithDescription("Add a JavaScript snippet that runs in every new document before the page's own scripts, such as polyfills, hooks and test shims, so it persists across navigations until it is removed or the browser restarts"),
+		mcp.WithString("script",
+			mcp.Description("JavaScript source to run"),
+			mcp.Required(),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name to tell the script apart in the list of init scripts"),
+		),
+		mcp.WithBoolean("run_now",
+			mcp.Description("Also run the script in the current document (default: false)"),
+		),
+	), bs.handleAddInitScript)
+	bs.AddTool(mcp.NewTool(
+		"browser_remove_init_script",
+		mcp.WithDescription("Remove a script added with browser_add_init_script, it no longer runs in new documents"),
+		mcp.WithString("id",
+			mcp.Description("ID of the script, as returned by browser_add_init_script"),
+			mcp.Required(),
+		),
+	), bs.handleRemoveInitScript)
+}
+
+// handleAddInitScript adds a script to evaluate on every new document.
+func (bs *BrowserServer) handleAddInitScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	source := abstract.GetString(args, "script", "")
+	name := abstract.GetString(args, "name", "")
+	runNow := abstract.GetBool(args, "run_now", false)
+
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	var id page.ScriptIdentifier
+	err := bs.run(runCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = page.AddScriptToEvaluateOnNewDocument(source).WithRunImmediately(runNow).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to add the init script: %s", err.Error()), nil
+	}
+
+	bs.initScriptsLock.Lock()
+	bs.initScripts = append(bs.initScripts, InitScript{ID: string(id), Name: name, Size: len(source)})
+	scripts := describeInitScripts(bs.initScripts)
+	bs.initScriptsLock.Unlock()
+	return mcp.NewToolResultText(fmt.Sprintf("Added init script %s, it runs in every new document. Init scripts:\n%s", id, scripts)), nil
+}
+
+// handleRemoveInitScript removes a script added by browser_add_init_script.
+func (bs *BrowserServer) handleRemoveInitScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := abstract.GetString(request.GetArguments(), "id", "")
+	bs.initScriptsLock.Lock()
+	index := -1
+	for i, s := range bs.initScripts {
+		if s.ID == id {
+			index = i
+		}
+	}
+	bs.initScriptsLock.Unlock()
+	if index < 0 {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "no init script with ID %s, it may have been removed by a browser restart", id), nil
+	}
+
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	err := bs.run(runCtx, page.RemoveScriptToEvaluateOnNewDocument(page.ScriptIdentifier(id)))
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to remove the init script: %s", err.Error()), nil
+	}
+
+	bs.initScriptsLock.Lock()
+	for i, s := range bs.initScripts {
+		if s.ID == id {
+			bs.initScripts = append(bs.initScripts[:i], bs.initScripts[i+1:]...)
+			break
+		}
+	}
+	scripts := describeInitScripts(bs.initScripts)
+	bs.initScriptsLock.Unlock()
+	return mcp.NewToolResultText(fmt.Sprintf("Removed init script %s, it no longer runs in new documents. Init scripts:\n%s", id, scripts)), nil
+}
+
+// describeInitScripts lists the init scripts for the tool results.
+func describeInitScripts(scripts []InitScript) string {
+	if len(scripts) == 0 {
+		return "(none)"
+	}
+	lines := make([]string, 0, len(scripts))
+	for _, s := range scripts {
+		name := s.Name
+		if name == "" {
+			name = "unnamed"
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s, %d bytes", s.ID, name, s.Size))
+	}
+	return strings.Join(lines, "\n")
+}
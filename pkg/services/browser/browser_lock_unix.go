@@ -0,0 +1,39 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// removeProfileLock removes the SingletonLock symbolic link of a browser that did not exit cleanly. Its target does
+// not exist, so it is looked at with Lstat.
+func removeProfileLock(userDataDir string) error {
+	singletonLock := filepath.Join(userDataDir, "SingletonLock")
+	if _, err := os.Lstat(singletonLock); err != nil {
+		return nil
+	}
+	if err := os.Remove(singletonLock); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", singletonLock, err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// removeProfileLock removes the lockfile of a browser that did not exit cleanly. A running browser keeps the file
+// open, so Windows refuses to remove it, and the profile cannot be used by a second browser.
+func removeProfileLock(userDataDir string) error {
+	lockfile := filepath.Join(userDataDir, "lockfile")
+	err := os.Remove(lockfile)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return fmt.Errorf("the profile is in use by another browser, close it or set browser_data_path to another directory: %w", err)
+}
@@ -0,0 +1,353 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/services/secrets"
+)
+
+// LoginProfile describes how to log in to a site with browser_login. The password, and the username if it is
+// secret too, are read from the Secrets service, so they never pass through the client.
+type LoginProfile struct {
+	Name             string `json:"name"`              // Name identifies the site in browser_login.
+	URL              string `json:"url"`               // URL is the login page.
+	Username         string `json:"username"`          // Username is typed as is, if UsernameSecret is empty.
+	UsernameSecret   string `json:"username_secret"`   // UsernameSecret is the name of the secret that holds the username.
+	PasswordSecret   string `json:"password_secret"`   // PasswordSecret is the name of the secret that holds the password.
+	UsernameSelector string `json:"username_selector"` // UsernameSelector is the CSS selector of the username field.
+	PasswordSelector string `json:"password_selector"` // PasswordSelector is the CSS selector of the password field.
+	SubmitSelector   string `json:"submit_selector"`   // SubmitSelector is the button to click, Enter is pressed in the password field if empty.
+	SuccessSelector  string `json:"success_selector"`  // SuccessSelector is an element that is only shown once logged in.
+	SuccessURL       string `json:"success_url"`       // SuccessURL is a part of the address of the page shown once logged in.
+	FailureSelector  string `json:"failure_selector"`  // FailureSelector is an element that shows that the login failed, such as an error message.
+	// CodeSelector is the field of the one-time code some sites ask for after the password. The code is generated
+	// from TOTPSecret if set, else the user is asked for it, such as the code of an SMS.
+	CodeSelector       string `json:"code_selector"`
+	CodeSubmitSelector string `json:"code_submit_selector"` // CodeSubmitSelector is the button that submits the code, Enter is pressed if empty.
+	TOTPSecret         string `json:"totp_secret"`          // TOTPSecret is the name of the secret that holds the TOTP key, in base32 or as an otpauth:// URI.
+}
+
+// Check validates the profile.
+func (lp *LoginProfile) Check() error {
+	switch {
+	case strings.TrimSpace(lp.Name) == "":
+		return fmt.Errorf("login profiles must have a name")
+	case lp.URL == "":
+		return fmt.Errorf("login profile %s: url is required", lp.Name)
+	case lp.UsernameSelector == "" || lp.PasswordSelector == "":
+		return fmt.Errorf("login profile %s: username_selector and password_selector are required", lp.Name)
+	case lp.PasswordSecret == "":
+		return fmt.Errorf("login profile %s: password_secret is required", lp.Name)
+	case lp.Username == "" && lp.UsernameSecret == "":
+		return fmt.Errorf("login profile %s: username or username_secret is required", lp.Name)
+	case lp.SuccessSelector == "" && lp.SuccessURL == "":
+		return fmt.Errorf("login profile %s: success_selector or success_url is required to verify the login", lp.Name)
+	case lp.TOTPSecret != "" && lp.CodeSelector == "":
+		return fmt.Errorf("login profile %s: code_selector is required with totp_secret", lp.Name)
+	}
+	return nil
+}
+
+// loginProfile returns the login profile with the name.
+func (bs *BrowserServer) loginProfile(name string) (*LoginProfile, bool) {
+	for i := range bs.config.Logins {
+		if bs.config.Logins[i].Name == name {
+			return &bs.config.Logins[i], true
+		}
+	}
+	return nil, false
+}
+
+// Dependencies returns the Secrets service if login profiles are configured, browser_login reads the credentials
+// from it.
+func (bs *BrowserServer) Dependencies() []comm.MoLingServerType {
+	if len(bs.config.Logins) == 0 {
+		return []comm.MoLingServerType{}
+	}
+	return []comm.MoLingServerType{secrets.SecretsServerName}
+}
+
+// addLoginTools adds browser_fill_totp, and browser_login if login profiles are configured.
+func (bs *BrowserServer) addLoginTools() {
+	bs.AddTool(mcp.NewTool(
+		"browser_fill_totp",
+		mcp.WithDescription("Fill a one-time code field with the current TOTP code generated from a key kept in the Secrets service. The key and the code are never returned"),
+		mcp.WithString("selector",
+			mcp.Description("CSS selector of the one-time code field"),
+			mcp.Required(),
+		),
+		mcp.WithString("secret",
+			mcp.Description("Name of the secret that holds the TOTP key, as listed by secret_list"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("submit",
+			mcp.Description("Press Enter in the field after filling it (default: false)"),
+		),
+	), bs.handleFillTOTP)
+	if len(bs.config.Logins) == 0 {
+		return
+	}
+	names := make([]string, 0, len(bs.config.Logins))
+	for _, lp := range bs.config.Logins {
+		names = append(names, lp.Name)
+	}
+	bs.AddTool(mcp.NewTool(
+		"browser_login",
+		mcp.WithDescription("Log in to a configured site with the credentials kept in the Secrets service, and verify that the login succeeded. The credentials are never returned"),
+		mcp.WithString("site",
+			mcp.Description("Name of the login profile of the site"),
+			mcp.Required(),
+			mcp.Enum(names...),
+		),
+	), bs.handleLogin)
+}
+
+// handleLogin logs in to the site of a login profile.
+func (bs *BrowserServer) handleLogin(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	site := abstract.GetString(request.GetArguments(), "site", "")
+	lp, ok := bs.loginProfile(site)
+	if !ok {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "unknown login profile %q", site), nil
+	}
+	username := lp.Username
+	if lp.UsernameSecret != "" {
+		var err error
+		if username, err = secrets.Lookup(ctx, BrowserServerName, lp.UsernameSecret); err != nil {
+			return loginSecretError(site, err), nil
+		}
+	}
+	password, err := secrets.Lookup(ctx, BrowserServerName, lp.PasswordSecret)
+	if err != nil {
+		return loginSecretError(site, err), nil
+	}
+
+	bs.ReportProgress(ctx, 0, 3, fmt.Sprintf("logging in to %s", site))
+	runCtx, cancelFunc := bs.loginContext(ctx)
+	err = bs.run(runCtx,
+		chromedp.Navigate(lp.URL),
+		typeInto(lp.UsernameSelector, username),
+		typeInto(lp.PasswordSelector, password),
+		submitAction(lp.PasswordSelector, lp.SubmitSelector),
+	)
+	cancelFunc()
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to fill in the login form of %s: %s", site, err.Error()), nil
+	}
+
+	bs.ReportProgress(ctx, 1, 3, "verifying the login")
+	state, err := bs.waitLogin(ctx, lp, lp.CodeSelector != "")
+	if err == nil && state.Code {
+		bs.ReportProgress(ctx, 2, 3, "entering the one-time code")
+		var code string
+		if code, err = bs.oneTimeCode(ctx, lp); err != nil {
+			return loginCodeError(site, err), nil
+		}
+		runCtx, cancelFunc = bs.loginContext(ctx)
+		err = bs.run(runCtx, typeInto(lp.CodeSelector, code), submitAction(lp.CodeSelector, lp.CodeSubmitSelector))
+		cancelFunc()
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to enter the one-time code of %s: %s", site, err.Error()), nil
+		}
+		state, err = bs.waitLogin(ctx, lp, false)
+	}
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeTimeout, "could not verify the login to %s: %s", site, err.Error()), nil
+	}
+	if state.Failed {
+		bs.Logger.Warn().Str("site", site).Msg("login failed")
+		return abstract.NewErrorResultf(abstract.CodePolicyDenied, "the login to %s failed, the site reported: %s", site, state.Message), nil
+	}
+	bs.Logger.Info().Str("site", site).Msg("logged in")
+	return mcp.NewToolResultText(fmt.Sprintf("Logged in to %s, the page is now %s", site, state.URL)), nil
+}
+
+// handleFillTOTP fills a field with the current TOTP code of a secret key.
+func (bs *BrowserServer) handleFillTOTP(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	selector := abstract.GetString(args, "selector", "")
+	name := abstract.GetString(args, "secret", "")
+	code, err := bs.totpCode(ctx, name)
+	if err != nil {
+		return loginCodeError(name, err), nil
+	}
+	actions := chromedp.Tasks{typeInto(selector, code)}
+	if abstract.GetBool(args, "submit", false) {
+		actions = append(actions, submitAction(selector, ""))
+	}
+	runCtx, cancelFunc := bs.loginContext(ctx)
+	defer cancelFunc()
+	if err = bs.run(runCtx, actions); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to fill the one-time code: %s", err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Filled %s with the current code of %s", selector, name)), nil
+}
+
+// loginContext returns the context of a step of a login, which ends after the URL and selector timeouts or when the
+// tool call is cancelled.
+func (bs *BrowserServer) loginContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.URLTimeout+bs.config.SelectorQueryTimeout)*time.Second)
+	stop := context.AfterFunc(ctx, cancelFunc)
+	return runCtx, func() {
+		stop()
+		cancelFunc()
+	}
+}
+
+// typeInto replaces the value of a field by typing the value into it.
+func typeInto(selector, value string) chromedp.Tasks {
+	return chromedp.Tasks{
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.SetValue(selector, "", chromedp.ByQuery),
+		chromedp.SendKeys(selector, value, chromedp.ByQuery),
+	}
+}
+
+// submitAction clicks the submit button, or presses Enter in the field if there is none.
+func submitAction(field, submitSelector string) chromedp.Action {
+	if submitSelector != "" {
+		return chromedp.Click(submitSelector, chromedp.NodeVisible)
+	}
+	return chromedp.SendKeys(field, kb.Enter, chromedp.ByQuery)
+}
+
+// totpCode returns the current code of the TOTP key kept in a secret.
+func (bs *BrowserServer) totpCode(ctx context.Context, secret string) (string, error) {
+	value, err := secrets.Lookup(ctx, BrowserServerName, secret)
+	if err != nil {
+		return "", err
+	}
+	key, err := parseTOTPKey(value)
+	if err != nil {
+		return "", abstract.Errorf(abstract.CodeInvalidArgument, "secret %s: %w", secret, err)
+	}
+	return key.code(time.Now()), nil
+}
+
+// oneTimeCode returns the code a site asks for after the password: the TOTP code of the profile, or else the code the
+// user enters when asked.
+func (bs *BrowserServer) oneTimeCode(ctx context.Context, lp *LoginProfile) (string, error) {
+	if lp.TOTPSecret != "" {
+		return bs.totpCode(ctx, lp.TOTPSecret)
+	}
+	code, err := bs.AskString(ctx, fmt.Sprintf("%s asks for a one-time code to log in. Enter the code you received by SMS or see in your authenticator app.", lp.Name),
+		"code", "One-time code")
+	if err != nil && !errors.Is(err, abstract.ErrElicitationUnavailable) {
+		return "", abstract.Errorf(abstract.CodePolicyDenied, "%w", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(code), nil
+}
+
+// loginCodeError reports that the one-time code for a site or secret could not be obtained, without the key.
+func loginCodeError(name string, err error) *mcp.CallToolResult {
+	if errors.Is(err, abstract.ErrElicitationUnavailable) {
+		return abstract.NewErrorResultf(abstract.CodeUnavailable, "%s asks for a one-time code, but the user cannot be asked for it: set totp_secret in its login profile, or use a client that supports elicitation", name)
+	}
+	var te *abstract.ToolError
+	if errors.As(err, &te) {
+		return abstract.NewErrorResult(te.Code, err.Error())
+	}
+	return loginSecretError(name, err)
+}
+
+// loginSecretError reports that the credentials of a site could not be read, without the secret values.
+func loginSecretError(site string, err error) *mcp.CallToolResult {
+	code := abstract.CodeUpstreamFailure
+	switch {
+	case errors.Is(err, secrets.ErrSecretsUnavailable):
+		code = abstract.CodeUnavailable
+	case errors.Is(err, secrets.ErrSecretNotAllowed):
+		code = abstract.CodePolicyDenied
+	}
+	return abstract.NewErrorResultf(code, "failed to read the credentials of %s: %s", site, err.Error())
+}
+
+// loginState is the outcome of a login as seen on the page.
+type loginState struct {
+	Done    bool   `json:"done"`    // Done is set once the page shows success or failure.
+	Failed  bool   `json:"failed"`  // Failed is set if the failure element is shown.
+	Message string `json:"message"` // Message is the text of the failure element.
+	Code    bool   `json:"code"`    // Code is set if the page asks for a one-time code.
+	URL     string `json:"url"`
+}
+
+// waitLogin polls the page until it shows that the login succeeded or failed, or with askCode, until it asks for a
+// one-time code.
+func (bs *BrowserServer) waitLogin(ctx context.Context, lp *LoginProfile, askCode bool) (*loginState, error) {
+	ctx, cancelFunc := bs.loginContext(ctx)
+	defer cancelFunc()
+	script := loginCheckScript(lp, askCode)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		state := &loginState{}
+		// The page may be navigating after the submission, so errors are retried until the deadline
+		err := bs.run(ctx, chromedp.Evaluate(script, state))
+		if err == nil && (state.Done || state.Code) {
+			return state, nil
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = fmt.Errorf("neither success nor failure was shown before the timeout")
+			}
+			return nil, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// loginCheckScript returns the script that reports the loginState of the page.
+func loginCheckScript(lp *LoginProfile, askCode bool) string {
+	quote := func(s string) string {
+		raw, _ := json.Marshal(s)
+		return string(raw)
+	}
+	return fmt.Sprintf(`(() => {
+	const visible = sel => {
+		if (!sel) {
+			return null;
+		}
+		const el = document.querySelector(sel);
+		return el && el.offsetParent !== null ? el : null;
+	};
+	const failure = visible(%s);
+	if (failure) {
+		return {done: true, failed: true, message: failure.innerText.trim(), url: location.href};
+	}
+	const url = %s;
+	const ok = (%s === '' || visible(%s) !== null) && (url === '' || location.href.includes(url));
+	const code = !ok && %t && visible(%s) !== null;
+	return {done: ok, failed: false, message: '', code: code, url: location.href};
+})()`, quote(lp.FailureSelector), quote(lp.SuccessURL), quote(lp.SuccessSelector), quote(lp.SuccessSelector),
+		askCode, quote(lp.CodeSelector))
+}
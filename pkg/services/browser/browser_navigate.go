@@ -0,0 +1,68 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// navigationStatusScript returns the HTTP status of the document of the page, 0 if it was not loaded over HTTP.
+const navigationStatusScript = `(() => {
+	const entry = performance.getEntriesByType('navigation')[0];
+	return entry && entry.responseStatus ? entry.responseStatus : 0;
+})()`
+
+// chromeErrorPrefix is the URL of the pages Chrome shows instead of a page it failed to load.
+const chromeErrorPrefix = "chrome-error://"
+
+// netErrorPattern matches the network error of a failed navigation, such as net::ERR_NAME_NOT_RESOLVED.
+var netErrorPattern = regexp.MustCompile(`net::ERR_[A-Z0-9_]+`)
+
+// navigationFailure returns the network error of a failed navigation and its code. It reports false for the
+// failures that are not network errors, such as a timeout of the tool call.
+func navigationFailure(err error) (string, abstract.ErrorCode, bool) {
+	netErr := netErrorPattern.FindString(err.Error())
+	if netErr == "" {
+		return "", "", false
+	}
+	switch {
+	case strings.Contains(netErr, "TIMED_OUT"):
+		return netErr, abstract.CodeTimeout, true
+	case netErr == "net::ERR_BLOCKED_BY_CLIENT", netErr == "net::ERR_BLOCKED_BY_ADMINISTRATOR":
+		return netErr, abstract.CodePolicyDenied, true
+	}
+	return netErr, abstract.CodeUpstreamFailure, true
+}
+
+// statusErrorCode returns the code of a navigation answered with an HTTP error status.
+func statusErrorCode(status int) abstract.ErrorCode {
+	switch status {
+	case http.StatusNotFound, http.StatusGone:
+		return abstract.CodeNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return abstract.CodePolicyDenied
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return abstract.CodeUnavailable
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return abstract.CodeTimeout
+	}
+	return abstract.CodeUpstreamFailure
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Page is the readable content of a web page.
+type Page struct {
+	URL    string   `json:"url"` // URL is the address after redirects.
+	Title  string   `json:"title"`
+	Blocks []string `json:"blocks"` // Blocks are the headings, paragraphs and list items of the main content, in document order.
+}
+
+// readPageScript picks the main content element and collects its text blocks, leaving out navigation, sidebars and footers.
+const readPageScript = `(() => {
+	const root = document.querySelector('article, main, [role=main]') || document.body;
+	if (!root) {
+		return {url: location.href, title: document.title, blocks: []};
+	}
+	let skip = 'nav, aside, footer, script, style, noscript, template, [role=navigation], [aria-hidden=true]';
+	if (root === document.body) {
+		skip += ', header';
+	}
+	const blocks = [];
+	root.querySelectorAll('h1, h2, h3, h4, h5, h6, p, li, pre, blockquote, td, dd').forEach(el => {
+		const parent = el.parentElement;
+		if (el.closest(skip) || (parent && parent.closest('p, li, pre, blockquote, td, dd'))) {
+			return;
+		}
+		const text = el.innerText.replace(/\s+/g, ' ').trim();
+		if (text) {
+			blocks.push(text);
+		}
+	});
+	if (blocks.length === 0) {
+		root.innerText.split(/\n\s*\n/).forEach(t => {
+			t = t.replace(/\s+/g, ' ').trim();
+			if (t) {
+				blocks.push(t);
+			}
+		});
+	}
+	return {url: location.href, title: document.title, blocks: blocks};
+})()`
+
+// ReadPage navigates to the URL and extracts the main content of the page.
+func (bs *BrowserServer) ReadPage(ctx context.Context, url string) (*Page, error) {
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.URLTimeout+bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+
+	page := &Page{}
+	err := bs.run(runCtx, chromedp.Navigate(url), chromedp.Evaluate(readPageScript, page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return page, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// addResetTools adds browser_reset.
+func (bs *BrowserServer) addResetTools() {
+	bs.MLService.AddTool(mcp.NewTool(
+		"browser_reset",
+		mcp.WithDescription("Reset the browser to a fresh about:blank page without restarting it: close the other tabs and the tabs of the named contexts, remove the init scripts, stop the XHR capture and the media and language emulation. Use it to recover from a page that is stuck, such as one that keeps redirecting or no longer responds"),
+		mcp.WithBoolean("clear_cookies",
+			mcp.Description("Also clear the cookies and the cache of the browser, which logs out of the sites (default: false)"),
+		),
+	), bs.handleReset)
+}
+
+// handleReset closes the tabs other than the main one, clears the state the tools left in the browser and loads
+// about:blank in the main tab.
+func (bs *BrowserServer) handleReset(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	clearCookies := abstract.GetBool(request.GetArguments(), "clear_cookies", false)
+	var done []string
+	if bs.farm != nil {
+		if n := bs.farm.closeTabs(); n > 0 {
+			done = append(done, fmt.Sprintf("closed %d named contexts", n))
+		}
+	}
+	bs.captureLock.Lock()
+	if bs.capture != nil {
+		bs.capture = nil
+		done = append(done, "stopped the XHR capture")
+	}
+	bs.captureLock.Unlock()
+	bs.initScriptsLock.Lock()
+	scripts := bs.initScripts
+	bs.initScripts = nil
+	bs.initScriptsLock.Unlock()
+	if len(scripts) > 0 {
+		done = append(done, fmt.Sprintf("removed %d init scripts", len(scripts)))
+	}
+
+	closed := 0
+	actions := []chromedp.Action{chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		closed, err = closeOtherTabs(ctx)
+		return err
+	})}
+	for _, s := range scripts {
+		actions = append(actions, page.RemoveScriptToEvaluateOnNewDocument(page.ScriptIdentifier(s.ID)))
+	}
+	actions = append(actions, emulation.SetEmulatedMedia())
+	if override, _ := bs.navigateOverride(nil); override != nil {
+		actions = append(actions, override)
+	}
+	if clearCookies {
+		actions = append(actions, network.ClearBrowserCookies(), network.ClearBrowserCache())
+	}
+	actions = append(actions, chromedp.Navigate("about:blank"))
+
+	runCtx, cancelFunc := context.WithTimeout(bs.chrome(), time.Duration(bs.config.URLTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	if err := bs.run(runCtx, actions...); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to reset the browser: %s", err.Error()), nil
+	}
+	if closed > 0 {
+		done = append(done, fmt.Sprintf("closed %d tabs", closed))
+	}
+	if clearCookies {
+		done = append(done, "cleared the cookies and the cache")
+	}
+	text := "The browser was reset to about:blank"
+	if len(done) > 0 {
+		text += ": " + strings.Join(done, ", ")
+	}
+	bs.Logger.Info().Strs("done", done).Msg("the browser was reset")
+	return mcp.NewToolResultText(text), nil
+}
+
+// closeOtherTabs closes the tabs of the browser other than the one ctx runs in, and returns how many it closed.
+func closeOtherTabs(ctx context.Context) (int, error) {
+	c := chromedp.FromContext(ctx)
+	browserCtx := cdp.WithExecutor(ctx, c.Browser)
+	infos, err := target.GetTargets().Do(browserCtx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the tabs: %w", err)
+	}
+	closed := 0
+	for _, info := range infos {
+		if info.Type != "page" || info.TargetID == c.Target.TargetID {
+			continue
+		}
+		if err = target.CloseTarget(info.TargetID).Do(browserCtx); err != nil {
+			return closed, fmt.Errorf("failed to close the tab %s: %w", info.URL, err)
+		}
+		closed++
+	}
+	return closed, nil
+}
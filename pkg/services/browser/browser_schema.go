@@ -0,0 +1,104 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import "encoding/json"
+
+// NavigateOutput is the structured result of browser_navigate.
+type NavigateOutput struct {
+	URL       string `json:"url"`      // URL is the requested URL.
+	Location  string `json:"location"` // Location is the URL after redirects.
+	Title     string `json:"title"`
+	Status    int    `json:"status"`              // Status is the HTTP status of the page, 0 if it was not loaded over HTTP.
+	Error     string `json:"error,omitempty"`     // Error tells why the page failed to load, for failed navigations.
+	Language  string `json:"language,omitempty"`  // Language is the Accept-Language the page was loaded with.
+	UserAgent string `json:"userAgent,omitempty"` // UserAgent is the user agent the page was loaded with.
+}
+
+// ScreenshotOutput is the structured result of browser_screenshot.
+type ScreenshotOutput struct {
+	Path     string `json:"path"`
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType"`
+	Size     int    `json:"size"`
+}
+
+// SnapshotOutput is the structured result of browser_snapshot.
+type SnapshotOutput struct {
+	URL      string `json:"url"`
+	Location string `json:"location"` // Location is the URL after redirects.
+	Title    string `json:"title"`
+	Format   string `json:"format"`
+	Path     string `json:"path,omitempty"` // Path is the saved screenshot or PDF.
+	URI      string `json:"uri,omitempty"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"` // Text is the readable text of the page, for the text format.
+	Size     int    `json:"size"`
+}
+
+// EvaluateOutput is the structured result of browser_evaluate.
+type EvaluateOutput struct {
+	Result any `json:"result"`
+}
+
+var (
+	navigateSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"url": {"type": "string"},
+		"location": {"type": "string"},
+		"title": {"type": "string"},
+		"status": {"type": "integer"},
+		"error": {"type": "string"},
+		"language": {"type": "string"},
+		"userAgent": {"type": "string"}
+	},
+	"required": ["url", "location", "title", "status"]
+}`)
+	screenshotSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"mimeType": {"type": "string"},
+		"size": {"type": "integer"}
+	},
+	"required": ["path", "uri", "mimeType", "size"]
+}`)
+	snapshotSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"url": {"type": "string"},
+		"location": {"type": "string"},
+		"title": {"type": "string"},
+		"format": {"type": "string", "enum": ["screenshot", "pdf", "text"]},
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"mimeType": {"type": "string"},
+		"text": {"type": "string"},
+		"size": {"type": "integer"}
+	},
+	"required": ["url", "location", "title", "format", "size"]
+}`)
+	evaluateSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"result": {}
+	},
+	"required": ["result"]
+}`)
+)
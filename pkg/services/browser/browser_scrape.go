@@ -0,0 +1,271 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	scrapeMaxPagesDefault = 5
+	scrapeMaxPagesLimit   = 50
+	scrapeMaxItemsDefault = 500
+	scrapeMaxItemsLimit   = 5000
+)
+
+// ScrapeListOutput is the structured result of browser_scrape_list.
+type ScrapeListOutput struct {
+	Items     []map[string]any `json:"items"`
+	Pages     int              `json:"pages"`     // Pages is the number of pages read.
+	URL       string           `json:"url"`       // URL is the address of the last page read.
+	Truncated bool             `json:"truncated"` // Truncated is set if max_pages or max_items stopped the scraping before the last page.
+}
+
+var scrapeListSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"items": {"type": "array", "items": {"type": "object"}},
+		"pages": {"type": "integer"},
+		"url": {"type": "string"},
+		"truncated": {"type": "boolean"}
+	},
+	"required": ["items", "pages", "url", "truncated"]
+}`)
+
+// scrapedPage is what scrapeListScript reads from a page.
+type scrapedPage struct {
+	Items     []map[string]any `json:"items"`
+	URL       string           `json:"url"`
+	Next      string           `json:"next"`      // Next is the href of the next page link, if it is a link.
+	HasNext   bool             `json:"hasNext"`   // HasNext is set if the next page element is on the page.
+	Signature string           `json:"signature"` // Signature changes when the list shows another page.
+}
+
+// scrapeListScript reads the items of the page. Fields are selectors relative to the item, with @attr to read an
+// attribute instead of the text; an empty selector is the item itself. Links and sources are made absolute.
+const scrapeListScript = `((itemSelector, fields, nextSelector) => {
+	const read = (item, spec) => {
+		let sel = spec, attr = '';
+		const at = spec.lastIndexOf('@');
+		if (at >= 0) {
+			sel = spec.slice(0, at).trim();
+			attr = spec.slice(at + 1).trim();
+		}
+		const el = sel ? item.querySelector(sel) : item;
+		if (!el) {
+			return null;
+		}
+		if (!attr) {
+			return el.innerText.replace(/\s+/g, ' ').trim();
+		}
+		if ((attr === 'href' || attr === 'src') && el[attr]) {
+			return String(el[attr]);
+		}
+		return el.getAttribute(attr);
+	};
+	const items = Array.from(document.querySelectorAll(itemSelector)).map(item => {
+		const names = Object.keys(fields);
+		if (names.length === 0) {
+			return {text: read(item, '')};
+		}
+		const out = {};
+		names.forEach(name => out[name] = read(item, fields[name]));
+		return out;
+	});
+	const next = nextSelector ? document.querySelector(nextSelector) : null;
+	const disabled = next && (next.disabled || next.getAttribute('aria-disabled') === 'true' || next.classList.contains('disabled'));
+	const href = next && !disabled && next.href && !next.getAttribute('href').startsWith('#') &&
+		!next.href.startsWith('javascript:') ? next.href : '';
+	const first = document.querySelector(itemSelector);
+	return {
+		items: items,
+		url: location.href,
+		next: href,
+		hasNext: !!next && !disabled,
+		signature: location.href + '|' + items.length + '|' + (first ? first.innerText.slice(0, 200) : ''),
+	};
+})(%s, %s, %s)`
+
+// pageURL fills the page number into a URL pattern with {page}.
+func pageURL(pattern string, page int) string {
+	return strings.ReplaceAll(pattern, "{page}", strconv.Itoa(page))
+}
+
+// scrapeFields returns the field selectors of browser_scrape_list, which must all be strings.
+func scrapeFields(raw map[string]any) (map[string]string, error) {
+	fields := make(map[string]string, len(raw))
+	for name, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %s: the selector must be a string, got %T", name, v)
+		}
+		fields[name] = s
+	}
+	return fields, nil
+}
+
+// addScrapeTools adds browser_scrape_list.
+func (bs *BrowserServer) addScrapeTools() {
+	bs.AddStructuredTool(mcp.NewTool(
+		"browser_scrape_list",
+		mcp.WithDescription("Scrape a list that spans several pages in one call: read the fields of every item on the page, go to the next page by clicking next_selector or by next_url, and repeat up to max_pages. Returns all items as JSON"),
+		mcp.WithString("item_selector",
+			mcp.Description("CSS selector matching each item of the list, e.g. \"li.result\""),
+			mcp.Required(),
+		),
+		mcp.WithObject("fields",
+			mcp.Description(`Fields to read from each item, as name to CSS selector relative to the item. Append @attr to read an attribute, e.g. {"title": "h2", "link": "a@href", "id": "@data-id"}; "" is the item itself. Default: the text of the item`),
+		),
+		mcp.WithString("url",
+			mcp.Description("URL of the first page (default: the current page)"),
+		),
+		mcp.WithString("next_selector",
+			mcp.Description("CSS selector of the next page link or button"),
+		),
+		mcp.WithString("next_url",
+			mcp.Description("URL pattern of the pages with {page} for the page number, e.g. \"https://example.com/list?page={page}\", used instead of next_selector. The first page is number 1 unless url is set"),
+		),
+		mcp.WithNumber("max_pages",
+			mcp.Description(fmt.Sprintf("Maximum number of pages to read (default: %d, at most %d)", scrapeMaxPagesDefault, scrapeMaxPagesLimit)),
+		),
+		mcp.WithNumber("max_items",
+			mcp.Description(fmt.Sprintf("Maximum number of items to return (default: %d, at most %d)", scrapeMaxItemsDefault, scrapeMaxItemsLimit)),
+		),
+	), scrapeListSchema, bs.handleScrapeList)
+}
+
+// handleScrapeList reads a list page by page.
+func (bs *BrowserServer) handleScrapeList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	itemSelector := abstract.GetString(args, "item_selector", "")
+	startURL := abstract.GetString(args, "url", "")
+	nextSelector := abstract.GetString(args, "next_selector", "")
+	nextURL := abstract.GetString(args, "next_url", "")
+	if nextURL != "" && !strings.Contains(nextURL, "{page}") {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "next_url must contain {page}"), nil
+	}
+	fields, err := scrapeFields(abstract.GetMap(args, "fields"))
+	if err != nil {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, err.Error()), nil
+	}
+	maxPages := min(max(abstract.GetInt(args, "max_pages", scrapeMaxPagesDefault), 1), scrapeMaxPagesLimit)
+	maxItems := min(max(abstract.GetInt(args, "max_items", scrapeMaxItemsDefault), 1), scrapeMaxItemsLimit)
+
+	quotedItem, _ := json.Marshal(itemSelector)
+	quotedFields, _ := json.Marshal(fields)
+	quotedNext, _ := json.Marshal(nextSelector)
+	script := fmt.Sprintf(scrapeListScript, quotedItem, quotedFields, quotedNext)
+
+	if startURL == "" && nextURL != "" {
+		startURL = pageURL(nextURL, 1)
+	}
+	output := ScrapeListOutput{Items: []map[string]any{}}
+	var page *scrapedPage
+	for number := 1; ; number++ {
+		bs.ReportProgress(ctx, float64(number-1), float64(maxPages), fmt.Sprintf("reading page %d", number))
+		var next chromedp.Action
+		switch {
+		case number == 1 && startURL != "":
+			next = chromedp.Navigate(startURL)
+		case number == 1:
+		case nextURL != "":
+			next = chromedp.Navigate(pageURL(nextURL, number))
+		case page.Next != "":
+			next = chromedp.Navigate(page.Next)
+		default:
+			next = chromedp.Click(nextSelector, chromedp.ByQuery)
+		}
+		previous := page
+		page, err = bs.scrapePage(ctx, next, script, previous)
+		if err != nil {
+			if number == 1 {
+				return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to scrape %s: %s", itemSelector, err.Error()), nil
+			}
+			// The pages read so far are returned, the next one may not exist
+			bs.Logger.Warn().Err(err).Int("page", number).Msg("failed to scrape the next page")
+			break
+		}
+		if len(page.Items) == 0 && number > 1 {
+			break
+		}
+		output.Pages = number
+		output.URL = page.URL
+		for _, item := range page.Items {
+			if len(output.Items) == maxItems {
+				output.Truncated = true
+				break
+			}
+			output.Items = append(output.Items, item)
+		}
+		hasNext := nextURL != "" || page.HasNext
+		if output.Truncated || !hasNext {
+			break
+		}
+		if number == maxPages {
+			output.Truncated = true
+			break
+		}
+	}
+	bs.ReportProgress(ctx, float64(maxPages), float64(maxPages), "done")
+	text := fmt.Sprintf("Scraped %d items from %d pages", len(output.Items), output.Pages)
+	if output.Truncated {
+		text += ", more are left: raise max_pages or max_items to get them"
+	}
+	return abstract.NewStructuredResult(text, output), nil
+}
+
+// scrapePage runs the action that shows the page, if any, and reads it. After a click, it waits for the list to
+// change from the previous page.
+func (bs *BrowserServer) scrapePage(ctx context.Context, show chromedp.Action, script string, previous *scrapedPage) (*scrapedPage, error) {
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.URLTimeout+bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+
+	if show != nil {
+		if err := bs.run(runCtx, show); err != nil {
+			return nil, err
+		}
+	}
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		page := &scrapedPage{}
+		err := bs.run(runCtx, chromedp.Evaluate(script, page))
+		if err == nil && (previous == nil || page.Signature != previous.Signature) {
+			return page, nil
+		}
+		select {
+		case <-runCtx.Done():
+			if err == nil {
+				err = fmt.Errorf("the list did not change after going to the next page")
+			}
+			return nil, err
+		case <-ticker.C:
+		}
+	}
+}
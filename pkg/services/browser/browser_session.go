@@ -0,0 +1,215 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// SessionFileName is the file in the browser profile that keeps the open tabs and cookies between restarts.
+const SessionFileName = "moling_session.json"
+
+// SavedTab is an open tab of a saved browser session.
+type SavedTab struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+// SavedCookie is a cookie of a saved browser session.
+type SavedCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires,omitempty"` // Expires is the expiry in seconds since the UNIX epoch, zero for session cookies.
+	HTTPOnly bool    `json:"http_only,omitempty"`
+	Secure   bool    `json:"secure,omitempty"`
+	SameSite string  `json:"same_site,omitempty"`
+}
+
+// SavedSession is the state of the browser saved on shutdown and restored on the next start if restore_session is on.
+type SavedSession struct {
+	SavedAt time.Time     `json:"saved_at"`
+	Tabs    []SavedTab    `json:"tabs"` // Tabs are the open tabs, the one the tools use first.
+	Cookies []SavedCookie `json:"cookies"`
+}
+
+// sessionFile returns the path of the saved session of the browser profile.
+func (bs *BrowserServer) sessionFile() string {
+	return filepath.Join(bs.config.BrowserDataPath, SessionFileName)
+}
+
+// saveSession writes the open tabs and the cookies of a started browser to the session file. The caller must hold
+// chromeLock.
+func (bs *BrowserServer) saveSession() error {
+	c := chromedp.FromContext(bs.Context)
+	if c == nil || c.Browser == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(bs.Context, 5*time.Second)
+	defer cancel()
+	browserCtx := cdp.WithExecutor(ctx, c.Browser)
+	infos, err := target.GetTargets().Do(browserCtx)
+	if err != nil {
+		return fmt.Errorf("failed to list the tabs: %w", err)
+	}
+	cookies, err := storage.GetCookies().Do(browserCtx)
+	if err != nil {
+		return fmt.Errorf("failed to read the cookies: %w", err)
+	}
+	var main string
+	if c.Target != nil {
+		main = string(c.Target.TargetID)
+	}
+	session := SavedSession{SavedAt: time.Now(), Cookies: savedCookies(cookies)}
+	for _, info := range infos {
+		if info.Type != "page" || !restorableURL(info.URL) {
+			continue
+		}
+		tab := SavedTab{URL: info.URL, Title: info.Title}
+		if string(info.TargetID) == main {
+			session.Tabs = append([]SavedTab{tab}, session.Tabs...)
+		} else {
+			session.Tabs = append(session.Tabs, tab)
+		}
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The cookies log the user in to the sites, only the user may read them
+	return os.WriteFile(bs.sessionFile(), data, 0o600)
+}
+
+// loadSession reads the saved session of the browser profile, nil if there is none. Expired cookies are left out.
+func (bs *BrowserServer) loadSession() (*SavedSession, error) {
+	data, err := os.ReadFile(bs.sessionFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session SavedSession
+	if err = json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("invalid session file %s: %w", bs.sessionFile(), err)
+	}
+	now := float64(time.Now().Unix())
+	cookies := session.Cookies[:0]
+	for _, c := range session.Cookies {
+		if c.Expires <= 0 || c.Expires > now {
+			cookies = append(cookies, c)
+		}
+	}
+	session.Cookies = cookies
+	return &session, nil
+}
+
+// restoreSession starts the browser and restores the cookies and tabs of the saved session in it. The first tab is
+// loaded in the tab the tools use, the others are opened next to it without waiting for them to load. ctx must be the
+// context of Chrome, without a deadline, chromedp stops the browser when the context it was started with ends.
+func (bs *BrowserServer) restoreSession(ctx context.Context) {
+	session, err := bs.loadSession()
+	if err != nil {
+		bs.Logger.Warn().Err(err).Msg("failed to load the saved browser session")
+		return
+	}
+	if session == nil || (len(session.Tabs) == 0 && len(session.Cookies) == 0) {
+		return
+	}
+	if err = chromedp.Run(ctx); err != nil {
+		bs.Logger.Warn().Err(err).Msg("failed to start the browser to restore the saved session")
+		return
+	}
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(bs.config.URLTimeout)*time.Second)
+	defer cancel()
+	err = chromedp.Run(runCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		browserCtx := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser)
+		if len(session.Cookies) > 0 {
+			if err := storage.SetCookies(cookieParams(session.Cookies)).Do(browserCtx); err != nil {
+				return fmt.Errorf("failed to restore the cookies: %w", err)
+			}
+		}
+		for i := len(session.Tabs) - 1; i > 0; i-- {
+			if _, err := target.CreateTarget(session.Tabs[i].URL).Do(browserCtx); err != nil {
+				return fmt.Errorf("failed to restore the tab %s: %w", session.Tabs[i].URL, err)
+			}
+		}
+		if len(session.Tabs) > 0 {
+			return chromedp.Navigate(session.Tabs[0].URL).Do(ctx)
+		}
+		return nil
+	}))
+	if err != nil {
+		bs.Logger.Warn().Err(err).Msg("failed to restore the saved browser session")
+		return
+	}
+	bs.Logger.Info().Int("tabs", len(session.Tabs)).Int("cookies", len(session.Cookies)).Time("saved_at", session.SavedAt).Msg("browser session restored")
+}
+
+// savedCookies converts the cookies of the browser to the cookies of a saved session.
+func savedCookies(cookies []*network.Cookie) []SavedCookie {
+	saved := make([]SavedCookie, 0, len(cookies))
+	for _, c := range cookies {
+		sc := SavedCookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path, HTTPOnly: c.HTTPOnly,
+			Secure: c.Secure, SameSite: string(c.SameSite)}
+		if !c.Session && c.Expires > 0 {
+			sc.Expires = c.Expires
+		}
+		saved = append(saved, sc)
+	}
+	return saved
+}
+
+// cookieParams converts the cookies of a saved session to the parameters that set them again.
+func cookieParams(cookies []SavedCookie) []*network.CookieParam {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		p := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			SameSite: network.CookieSameSite(c.SameSite),
+		}
+		if c.Expires > 0 {
+			expires := cdp.TimeSinceEpoch(time.Unix(0, int64(c.Expires*float64(time.Second))))
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// restorableURL reports whether a tab with the URL is worth restoring, internal and blank pages are not.
+func restorableURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "file://")
+}
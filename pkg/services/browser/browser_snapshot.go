@@ -0,0 +1,190 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	SnapshotScreenshot = "screenshot"
+	SnapshotPDF        = "pdf"
+	SnapshotText       = "text"
+)
+
+// addSnapshotTools adds browser_snapshot. It does not take the context argument of the farm mode, it never uses the
+// tabs of the service.
+func (bs *BrowserServer) addSnapshotTools() {
+	bs.snapshots = make(chan struct{}, bs.config.MaxSnapshots)
+	bs.MLService.AddStructuredTool(mcp.NewTool(
+		"browser_snapshot",
+		mcp.WithDescription("Capture a URL as a screenshot, a PDF or its readable text in a separate, short-lived headless Chrome without cookies or history. The interactive tab is not touched, so use it for quick one-off captures while a browser session is in use"),
+		mcp.WithString("url",
+			mcp.Description("URL to capture"),
+			mcp.Required(),
+		),
+		mcp.WithString("format",
+			mcp.Description("screenshot (default), pdf or text"),
+			mcp.Enum(SnapshotScreenshot, SnapshotPDF, SnapshotText),
+		),
+		mcp.WithBoolean("full_page",
+			mcp.Description("Capture the whole page instead of the window (default: true), screenshots only"),
+		),
+		mcp.WithNumber("width",
+			mcp.Description("Window width in pixels (default: the window width)"),
+		),
+		mcp.WithNumber("height",
+			mcp.Description("Window height in pixels (default: the window height)"),
+		),
+		mcp.WithString("wait_selector",
+			mcp.Description("CSS selector of an element to wait for before capturing, for pages that render late"),
+		),
+	), snapshotSchema, bs.handleSnapshot)
+}
+
+// snapshotOptions returns the options of a headless Chrome for a snapshot. Without a user data directory, chromedp
+// gives it a temporary one that is removed when it exits.
+func (bs *BrowserServer) snapshotOptions(width, height int) []chromedp.ExecAllocatorOption {
+	opts := append(
+		chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserAgent(bs.config.UserAgent),
+		chromedp.Flag("lang", bs.config.DefaultLanguage),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.Flag("mute-audio", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-notifications", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.WindowSize(width, height),
+		chromedp.Flag("force-device-scale-factor", strconv.FormatFloat(bs.config.Zoom, 'f', -1, 64)),
+		chromedp.IgnoreCertErrors,
+	)
+	execPath := bs.config.ExecPath
+	if execPath == "" {
+		execPath = FindExecPath()
+	}
+	if execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if bs.config.Proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(bs.config.Proxy))
+	}
+	return opts
+}
+
+// runSnapshot runs actions in a new headless Chrome, which is stopped and removed afterwards.
+func (bs *BrowserServer) runSnapshot(ctx context.Context, width, height int, actions ...chromedp.Action) error {
+	if bs.backend != nil {
+		return bs.backend.Run(ctx, actions...)
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, bs.snapshotOptions(width, height)...)
+	defer cancelAlloc()
+	tabCtx, cancelTab := chromedp.NewContext(allocCtx, chromedp.WithErrorf(bs.Logger.Debug().Msgf))
+	defer cancelTab()
+	return chromedp.Run(tabCtx, actions...)
+}
+
+func (bs *BrowserServer) handleSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	target := abstract.GetString(args, "url", "")
+	if u, err := url.Parse(target); err != nil || u.Scheme == "" {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "url must be an absolute URL, got %q", target), nil
+	}
+	format := abstract.GetString(args, "format", SnapshotScreenshot)
+	if format != SnapshotScreenshot && format != SnapshotPDF && format != SnapshotText {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "format must be %s, %s or %s, got %s", SnapshotScreenshot, SnapshotPDF, SnapshotText, format), nil
+	}
+	width := abstract.GetInt(args, "width", bs.config.WindowWidth)
+	height := abstract.GetInt(args, "height", bs.config.WindowHeight)
+	if width <= 0 || height <= 0 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "width and height must be greater than 0"), nil
+	}
+	waitSelector := abstract.GetString(args, "wait_selector", "")
+
+	// Every snapshot starts a Chrome, only max_snapshots run at a time
+	select {
+	case bs.snapshots <- struct{}{}:
+		defer func() { <-bs.snapshots }()
+	case <-ctx.Done():
+		return abstract.NewErrorResult(abstract.CodeUnavailable, "cancelled while waiting for the other snapshots to finish"), nil
+	}
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(bs.config.Timeout)*time.Second)
+	defer cancel()
+
+	output := SnapshotOutput{URL: target, Format: format}
+	actions := []chromedp.Action{chromedp.Navigate(target)}
+	if waitSelector != "" {
+		actions = append(actions, chromedp.WaitVisible(waitSelector, chromedp.ByQuery))
+	}
+	actions = append(actions, chromedp.Location(&output.Location), chromedp.Title(&output.Title))
+	var buf []byte
+	content := &Page{}
+	switch format {
+	case SnapshotScreenshot:
+		if abstract.GetBool(args, "full_page", true) {
+			actions = append(actions, chromedp.FullScreenshot(&buf, 100))
+		} else {
+			actions = append(actions, chromedp.CaptureScreenshot(&buf))
+		}
+	case SnapshotPDF:
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, _, err = page.PrintToPDF().WithPrintBackground(true).Do(ctx)
+			return err
+		}))
+	case SnapshotText:
+		actions = append(actions, chromedp.Evaluate(readPageScript, content))
+	}
+	bs.ReportProgress(ctx, 0, 1, fmt.Sprintf("capturing %s", target))
+	if err := bs.runSnapshot(runCtx, width, height, actions...); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to capture %s: %s", target, err.Error()), nil
+	}
+	bs.ReportProgress(ctx, 1, 1, "captured")
+
+	if format == SnapshotText {
+		output.Text = strings.Join(content.Blocks, "\n\n")
+		output.Size = len(output.Text)
+		return abstract.NewStructuredResult(fmt.Sprintf("# %s\n\n%s", output.Title, output.Text), output), nil
+	}
+	ext := ".png"
+	if format == SnapshotPDF {
+		ext = ".pdf"
+	}
+	output.Path = filepath.Join(bs.config.DataPath, fmt.Sprintf("snapshot_%d%s", rand.Int(), ext))
+	if err := os.WriteFile(output.Path, buf, 0644); err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to save the snapshot: %s", err.Error()), nil
+	}
+	output.MIMEType = http.DetectContentType(buf)
+	output.URI = bs.publishScreenshot(output.Path, output.MIMEType)
+	output.Size = len(buf)
+	return abstract.NewStructuredResult(fmt.Sprintf("Snapshot of %s saved to:%s, resource:%s", target, output.Path, output.URI), output), nil
+}
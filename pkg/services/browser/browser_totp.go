@@ -0,0 +1,100 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totpKey is a TOTP key as described by RFC 6238.
+type totpKey struct {
+	secret []byte
+	digits int
+	period int64
+	hash   func() hash.Hash
+}
+
+// parseTOTPKey parses a base32 TOTP secret, as shown by sites when 2FA is set up, or an otpauth:// URI, as encoded in
+// their QR codes.
+func parseTOTPKey(s string) (*totpKey, error) {
+	key := &totpKey{digits: 6, period: 30, hash: sha1.New}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "otpauth://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+		}
+		if u.Host != "totp" {
+			return nil, fmt.Errorf("only otpauth://totp URIs are supported, not %s", u.Host)
+		}
+		q := u.Query()
+		s = q.Get("secret")
+		if d := q.Get("digits"); d != "" {
+			if key.digits, err = strconv.Atoi(d); err != nil || key.digits < 6 || key.digits > 8 {
+				return nil, fmt.Errorf("invalid digits %q in otpauth URI", d)
+			}
+		}
+		if p := q.Get("period"); p != "" {
+			if key.period, err = strconv.ParseInt(p, 10, 64); err != nil || key.period <= 0 {
+				return nil, fmt.Errorf("invalid period %q in otpauth URI", p)
+			}
+		}
+		switch strings.ToUpper(q.Get("algorithm")) {
+		case "", "SHA1":
+		case "SHA256":
+			key.hash = sha256.New
+		case "SHA512":
+			key.hash = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported algorithm %q in otpauth URI", q.Get("algorithm"))
+		}
+	}
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("the TOTP secret is not valid base32")
+	}
+	key.secret = secret
+	return key, nil
+}
+
+// code returns the one-time code of the key at t.
+func (k *totpKey) code(t time.Time) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/k.period))
+	mac := hmac.New(k.hash, k.secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < k.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.digits, value%mod)
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// BaselinesDir is the directory of the data path the baselines of browser_visual_diff are saved to.
+	BaselinesDir = "baselines"
+
+	// visualDiffThresholdDefault is the color distance, from 0 to 1, under which two pixels count as the same.
+	visualDiffThresholdDefault = 0.1
+	// maxYIQDelta is the largest squared YIQ distance between two colors.
+	maxYIQDelta = 35215
+)
+
+// baselineNamePattern matches the names of the baselines, which are file names.
+var baselineNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// VisualDiffOutput is the structured result of browser_visual_diff.
+type VisualDiffOutput struct {
+	Name         string   `json:"name"`
+	Action       string   `json:"action"`
+	Baseline     string   `json:"baseline,omitempty"` // Baseline is the path of the baseline.
+	Created      bool     `json:"created"`            // Created is set when the screenshot became the baseline.
+	DiffPixels   int      `json:"diffPixels"`
+	TotalPixels  int      `json:"totalPixels"`
+	DiffPercent  float64  `json:"diffPercent"`
+	Passed       bool     `json:"passed"`                 // Passed is set when DiffPercent is within the tolerance.
+	SizeMismatch bool     `json:"sizeMismatch,omitempty"` // SizeMismatch is set when the screenshot and the baseline differ in size.
+	Path         string   `json:"path,omitempty"`         // Path is the diff image, the differences in red over the faded baseline.
+	URI          string   `json:"uri,omitempty"`
+	Baselines    []string `json:"baselines,omitempty"` // Baselines are the names of the baselines, for action list.
+}
+
+var visualDiffSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"action": {"type": "string", "enum": ["compare", "update", "delete", "list"]},
+		"baseline": {"type": "string"},
+		"created": {"type": "boolean"},
+		"diffPixels": {"type": "integer"},
+		"totalPixels": {"type": "integer"},
+		"diffPercent": {"type": "number"},
+		"passed": {"type": "boolean"},
+		"sizeMismatch": {"type": "boolean"},
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"baselines": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["name", "action", "created", "diffPixels", "totalPixels", "diffPercent", "passed"]
+}`)
+
+// addVisualDiffTools adds browser_visual_diff.
+func (bs *BrowserServer) addVisualDiffTools() {
+	bs.AddStructuredTool(mcp.NewTool(
+		"browser_visual_diff",
+		mcp.WithDescription("Check the page or an element for visual changes: take a screenshot and compare it pixel by pixel with the baseline of the same name, returning the share of pixels that differ and an image with the differences in red. The first screenshot of a name becomes its baseline"),
+		mcp.WithString("name",
+			mcp.Description("Name of the baseline, such as checkout-page, made of letters, digits, dots, dashes and underscores"),
+		),
+		mcp.WithString("action",
+			mcp.Description("compare (default) with the baseline, update it with a new screenshot, delete it, or list the baselines"),
+			mcp.Enum("compare", "update", "delete", "list"),
+		),
+		mcp.WithString("selector",
+			mcp.Description("CSS selector of the element to take, the whole page if left out"),
+		),
+		mcp.WithNumber("threshold",
+			mcp.Description(fmt.Sprintf("Color distance from 0 to 1 under which two pixels count as the same, higher ignores more (default: %g)", visualDiffThresholdDefault)),
+		),
+		mcp.WithNumber("tolerance",
+			mcp.Description("Percentage of differing pixels the check passes with (default: 0)"),
+		),
+	), visualDiffSchema, bs.handleVisualDiff)
+}
+
+func (bs *BrowserServer) handleVisualDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	action := abstract.GetString(args, "action", "compare")
+	name := abstract.GetString(args, "name", "")
+	dir := filepath.Join(bs.config.DataPath, BaselinesDir)
+	if action == "list" {
+		names, err := listBaselines(dir)
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.CodeInternal, "failed to list the baselines: %s", err.Error()), nil
+		}
+		return abstract.NewStructuredResult(fmt.Sprintf("%d baselines: %s", len(names), strings.Join(names, ", ")),
+			VisualDiffOutput{Action: action, Baselines: names, Passed: true}), nil
+	}
+	if !baselineNamePattern.MatchString(name) {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "name must be made of letters, digits, dots, dashes and underscores, got %q", name), nil
+	}
+	baseline := filepath.Join(dir, name+".png")
+	switch action {
+	case "delete":
+		if err := os.Remove(baseline); err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to delete the baseline %s: %s", name, err.Error()), nil
+		}
+		return abstract.NewStructuredResult(fmt.Sprintf("Deleted the baseline %s", name), VisualDiffOutput{Name: name, Action: action, Passed: true}), nil
+	case "compare", "update":
+	default:
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "action must be compare, update, delete or list, got %s", action), nil
+	}
+	threshold := abstract.GetFloat(args, "threshold", visualDiffThresholdDefault)
+	if threshold < 0 || threshold > 1 {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "threshold must be between 0 and 1, got %g", threshold), nil
+	}
+	tolerance := abstract.GetFloat(args, "tolerance", 0)
+
+	// The screenshots are PNG, compressed screenshots would differ from the baseline by their artifacts
+	selector := abstract.GetString(args, "selector", "")
+	var shot []byte
+	runCtx, cancelFunc := context.WithTimeout(bs.page(ctx), time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	stop := context.AfterFunc(ctx, cancelFunc)
+	defer stop()
+	var err error
+	if selector == "" {
+		err = bs.run(runCtx, chromedp.FullScreenshot(&shot, 100))
+	} else {
+		err = bs.run(runCtx, chromedp.Screenshot(selector, &shot, chromedp.NodeVisible))
+	}
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "failed to take the screenshot: %s", err.Error()), nil
+	}
+	return bs.visualDiff(name, baseline, action == "update", shot, threshold, tolerance), nil
+}
+
+// visualDiff compares a PNG screenshot with a baseline, which it creates if it does not exist or update is set.
+func (bs *BrowserServer) visualDiff(name, baseline string, update bool, shot []byte, threshold, tolerance float64) *mcp.CallToolResult {
+	action := map[bool]string{true: "update", false: "compare"}[update]
+	output := VisualDiffOutput{Name: name, Action: action, Baseline: baseline}
+	img, err := png.Decode(bytes.NewReader(shot))
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeUpstreamFailure, "the screenshot is not a PNG image: %s", err.Error())
+	}
+	output.TotalPixels = img.Bounds().Dx() * img.Bounds().Dy()
+
+	data, err := os.ReadFile(baseline)
+	if errors.Is(err, os.ErrNotExist) || update {
+		if err = os.MkdirAll(filepath.Dir(baseline), 0o755); err == nil {
+			err = os.WriteFile(baseline, shot, 0o644)
+		}
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.CodeInternal, "failed to save the baseline: %s", err.Error())
+		}
+		output.Created, output.Passed = true, true
+		return abstract.NewStructuredResult(fmt.Sprintf("Saved the screenshot as the baseline %s (%dx%d), the next comparisons use it",
+			name, img.Bounds().Dx(), img.Bounds().Dy()), output)
+	}
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to read the baseline: %s", err.Error())
+	}
+	base, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "the baseline %s is not a PNG image, update it: %s", name, err.Error())
+	}
+
+	diff, count := diffImages(base, img, threshold)
+	output.DiffPixels, output.TotalPixels = count, diff.Bounds().Dx()*diff.Bounds().Dy()
+	output.SizeMismatch = base.Bounds().Size() != img.Bounds().Size()
+	output.DiffPercent = float64(count) * 100 / float64(max(output.TotalPixels, 1))
+	output.Passed = output.DiffPercent <= tolerance
+	text := fmt.Sprintf("The screenshot differs from the baseline %s by %.2f%% (%d of %d pixels)", name, output.DiffPercent, count, output.TotalPixels)
+	if output.SizeMismatch {
+		text += fmt.Sprintf(", its size is %dx%d instead of %dx%d", img.Bounds().Dx(), img.Bounds().Dy(), base.Bounds().Dx(), base.Bounds().Dy())
+	}
+	if count == 0 {
+		return abstract.NewStructuredResult(text, output)
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, diff); err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to encode the diff image: %s", err.Error())
+	}
+	output.Path = filepath.Join(bs.config.DataPath, fmt.Sprintf("visual_diff_%s_%d.png", name, rand.Int()))
+	if err = os.WriteFile(output.Path, buf.Bytes(), 0o644); err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to save the diff image: %s", err.Error())
+	}
+	output.URI = bs.publishScreenshot(output.Path, "image/png")
+	text += fmt.Sprintf(", the differences are in red in %s, resource:%s", output.Path, output.URI)
+	return abstract.NewStructuredResult(text, output)
+}
+
+// diffImages compares two images pixel by pixel like pixelmatch, by their YIQ color distance. It returns an image of
+// the size of both, with the differing pixels in red over the faded first image, and the number of them. The pixels
+// outside of one of the images all differ.
+func diffImages(a, b image.Image, threshold float64) (*image.RGBA, int) {
+	ab, bb := a.Bounds(), b.Bounds()
+	width, height := max(ab.Dx(), bb.Dx()), max(ab.Dy(), bb.Dy())
+	diff := image.NewRGBA(image.Rect(0, 0, width, height))
+	maxDelta := maxYIQDelta * threshold * threshold
+	red := color.RGBA{R: 255, A: 255}
+	count := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pa, pb := image.Pt(ab.Min.X+x, ab.Min.Y+y), image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			if !pa.In(ab) || !pb.In(bb) {
+				diff.SetRGBA(x, y, red)
+				count++
+				continue
+			}
+			ca, cb := a.At(pa.X, pa.Y), b.At(pb.X, pb.Y)
+			if colorDelta(ca, cb) > maxDelta {
+				diff.SetRGBA(x, y, red)
+				count++
+				continue
+			}
+			// Fade the unchanged pixels to gray, so the differences stand out
+			r, g, bl, _ := blendWhite(ca)
+			gray := uint8(255 + (0.299*r+0.587*g+0.114*bl-255)*0.1)
+			diff.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	return diff, count
+}
+
+// blendWhite returns the 8-bit color channels of a color drawn over white.
+func blendWhite(c color.Color) (float64, float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	// RGBA returns channels premultiplied by alpha, in 16 bits
+	white := 0xffff - float64(a)
+	return (float64(r) + white) / 257, (float64(g) + white) / 257, (float64(b) + white) / 257, float64(a) / 257
+}
+
+// colorDelta returns the squared YIQ distance of two colors, as pixelmatch measures it.
+func colorDelta(c1, c2 color.Color) float64 {
+	r1, g1, b1, _ := blendWhite(c1)
+	r2, g2, b2, _ := blendWhite(c2)
+	y := (r1-r2)*0.29889531 + (g1-g2)*0.58662247 + (b1-b2)*0.11448223
+	i := (r1-r2)*0.59597799 - (g1-g2)*0.27417610 - (b1-b2)*0.32180189
+	q := (r1-r2)*0.21147017 - (g1-g2)*0.52261711 + (b1-b2)*0.31114694
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+// listBaselines returns the names of the baselines, sorted.
+func listBaselines(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".png") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".png"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,502 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package clock provides the time service: current time in any zone, conversion, date
+// arithmetic, cron expressions and countdown timers for the MoLing application.
+package clock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zone data for systems without it, e.g. Windows
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	TimeServerName comm.MoLingServerType = "Time"
+)
+
+// TimeServer implements the Service interface and provides clock, calendar and timer tools.
+type TimeServer struct {
+	abstract.MLService
+	config *TimeConfig
+
+	timersMu sync.Mutex
+	timers   map[string]*countdown
+	timerSeq int
+}
+
+// NewTimeServer creates a new TimeServer instance.
+func NewTimeServer(ctx context.Context) (abstract.Service, error) {
+	tc := NewTimeConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("TimeServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("TimeServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(TimeServerName))
+	})
+
+	ts := &TimeServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    tc,
+		timers:    make(map[string]*countdown),
+	}
+	err := ts.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Init registers the prompt and tools of the time service.
+func (ts *TimeServer) Init() error {
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "time_prompt",
+			Description: "Get the relevant functions and prompts of the Time MCP Server",
+		},
+		HandlerFunc: ts.handlePrompt,
+	}
+	ts.AddPrompt(pe)
+
+	ts.AddTool(mcp.NewTool(
+		"time_now",
+		mcp.WithDescription("Get the current date and time in one or more time zones"),
+		mcp.WithArray("zones",
+			mcp.Description("IANA zone names such as Asia/Tokyo, or offsets such as +05:30. Default: the default zone"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+	), ts.handleNow)
+
+	ts.AddTool(mcp.NewTool(
+		"time_convert",
+		mcp.WithDescription("Convert a date and time from one time zone to others"),
+		mcp.WithString("time",
+			mcp.Description("Time to convert, e.g. 2025-06-01 09:30, 15:00, 2025-06-01T09:30:00Z or now"),
+			mcp.Required(),
+		),
+		mcp.WithString("from",
+			mcp.Description("Zone of the time when it has no offset. Default: the default zone"),
+		),
+		mcp.WithArray("to",
+			mcp.Description("Zones to convert to"),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+	), ts.handleConvert)
+
+	ts.AddTool(mcp.NewTool(
+		"time_add",
+		mcp.WithDescription("Add or subtract an offset to a date and time, e.g. +2w3d, -90m or +1mo. Adding months clamps to the end of shorter months"),
+		mcp.WithString("offset",
+			mcp.Description("Offset with units y, mo, w, d, h, m and s, e.g. +1y2mo, -3d 4h"),
+			mcp.Required(),
+		),
+		mcp.WithString("time",
+			mcp.Description("Start time, default: now"),
+		),
+		mcp.WithString("zone",
+			mcp.Description("Zone for the start time and the result. Default: the default zone"),
+		),
+	), ts.handleAdd)
+
+	ts.AddTool(mcp.NewTool(
+		"time_diff",
+		mcp.WithDescription("Compute the time between two dates, as a duration and in calendar years, months and days"),
+		mcp.WithString("start",
+			mcp.Description("Start time"),
+			mcp.Required(),
+		),
+		mcp.WithString("end",
+			mcp.Description("End time, default: now"),
+		),
+		mcp.WithString("zone",
+			mcp.Description("Zone for times without an offset. Default: the default zone"),
+		),
+	), ts.handleDiff)
+
+	ts.AddTool(mcp.NewTool(
+		"cron_explain",
+		mcp.WithDescription("Explain a cron expression in plain words and list its next run times. Supports 5 fields, 6 fields with seconds, @daily style descriptors and @every <duration>"),
+		mcp.WithString("expression",
+			mcp.Description("Cron expression, e.g. */15 9-17 * * MON-FRI"),
+			mcp.Required(),
+		),
+		mcp.WithString("zone",
+			mcp.Description("Zone the schedule runs in. Default: the default zone"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("Number of next run times to list, default: 5"),
+		),
+		mcp.WithString("from",
+			mcp.Description("List run times after this time, default: now"),
+		),
+	), ts.handleCron)
+
+	ts.AddTool(mcp.NewTool(
+		"timer_start",
+		mcp.WithDescription("Start a countdown timer. A notification is sent to the client when it finishes"),
+		mcp.WithString("duration",
+			mcp.Description("Countdown length, e.g. 25m or 1h30m"),
+		),
+		mcp.WithString("until",
+			mcp.Description("End time instead of a duration, e.g. 17:00"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name of the timer"),
+		),
+		mcp.WithString("message",
+			mcp.Description("Message included in the notification"),
+		),
+	), ts.handleTimerStart)
+
+	ts.AddTool(mcp.NewTool(
+		"timer_list",
+		mcp.WithDescription("List running timers with their remaining time"),
+	), ts.handleTimerList)
+
+	ts.AddTool(mcp.NewTool(
+		"timer_cancel",
+		mcp.WithDescription("Cancel a running timer"),
+		mcp.WithString("id",
+			mcp.Description("ID of the timer, as returned by timer_start"),
+			mcp.Required(),
+		),
+	), ts.handleTimerCancel)
+	return nil
+}
+
+func (ts *TimeServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ts.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ts.config.location.String())
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// zone resolves an optional zone argument, falling back to the default zone.
+func (ts *TimeServer) zone(args map[string]any, key string) (*time.Location, error) {
+	name, _ := args[key].(string)
+	if strings.TrimSpace(name) == "" {
+		return ts.config.location, nil
+	}
+	return loadZone(name)
+}
+
+// handleNow returns the current time in the requested zones.
+func (ts *TimeServer) handleNow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	zones, err := stringArray(args, "zones")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(zones) == 0 {
+		zones = []string{ts.config.DefaultZone}
+	}
+	now := time.Now()
+	result := make([]zonedTime, 0, len(zones))
+	for _, name := range zones {
+		loc, err := loadZone(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result = append(result, describeTime(loc.String(), now.In(loc)))
+	}
+	return abstract.NewJSONResult(result), nil
+}
+
+// handleConvert converts a time to other zones.
+func (ts *TimeServer) handleConvert(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	from, err := ts.zone(args, "from")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	value := abstract.GetString(args, "time", "")
+	t, err := parseTime(value, from, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	zones, err := stringArray(args, "to")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(zones) == 0 {
+		return mcp.NewToolResultError("to must contain at least one zone"), nil
+	}
+	result := map[string]any{"source": describeTime(t.Location().String(), t)}
+	converted := make([]zonedTime, 0, len(zones))
+	for _, name := range zones {
+		loc, err := loadZone(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		converted = append(converted, describeTime(loc.String(), t.In(loc)))
+	}
+	result["converted"] = converted
+	return abstract.NewJSONResult(result), nil
+}
+
+// handleAdd applies an offset to a time.
+func (ts *TimeServer) handleAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	loc, err := ts.zone(args, "zone")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	value := abstract.GetString(args, "time", "")
+	t, err := parseTime(value, loc, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	offsetText := abstract.GetString(args, "offset", "")
+	o, err := parseOffset(offsetText)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	t = t.In(loc)
+	return abstract.NewJSONResult(map[string]any{
+		"start":  describeTime(loc.String(), t),
+		"offset": offsetText,
+		"result": describeTime(loc.String(), o.apply(t)),
+	}), nil
+}
+
+// handleDiff computes the time between two dates.
+func (ts *TimeServer) handleDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	loc, err := ts.zone(args, "zone")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	now := time.Now()
+	startText := abstract.GetString(args, "start", "")
+	if strings.TrimSpace(startText) == "" {
+		return mcp.NewToolResultError("start must be a non-empty string"), nil
+	}
+	start, err := parseTime(startText, loc, now)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	endText := abstract.GetString(args, "end", "")
+	end, err := parseTime(endText, loc, now)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	d := end.Sub(start)
+	a, b := start.In(loc), end.In(loc)
+	if d < 0 {
+		a, b = b, a
+	}
+	years, months, days := calendarDiff(a, b)
+	calendar := fmt.Sprintf("%dy %dmo %dd", years, months, days)
+	if d < 0 {
+		calendar = "-" + calendar
+	}
+	return abstract.NewJSONResult(map[string]any{
+		"start":    describeTime(loc.String(), start.In(loc)),
+		"end":      describeTime(loc.String(), end.In(loc)),
+		"duration": formatDuration(d),
+		"seconds":  int64(d.Round(time.Second) / time.Second),
+		"days":     math.Round(d.Hours()/24*100) / 100,
+		"calendar": calendar,
+	}), nil
+}
+
+// handleCron explains a cron expression and lists its next run times.
+func (ts *TimeServer) handleCron(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	expr := abstract.GetString(args, "expression", "")
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	loc, err := ts.zone(args, "zone")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	count := 5
+	if c := abstract.GetInt(args, "count", 0); c > 0 {
+		count = min(c, ts.config.MaxCronRuns)
+	}
+	fromText := abstract.GetString(args, "from", "")
+	t, err := parseTime(fromText, loc, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	t = t.In(loc)
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s (%s)\n\nNext runs:\n", schedule.explain(), loc.String()))
+	for i := 0; i < count; i++ {
+		t = schedule.next(t)
+		if t.IsZero() {
+			if i == 0 {
+				result.WriteString("none within the next five years\n")
+			}
+			break
+		}
+		result.WriteString(fmt.Sprintf("%s %s\n", t.Format(time.RFC3339), t.Weekday().String()[:3]))
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleTimerStart starts a countdown timer.
+func (ts *TimeServer) handleTimerStart(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	duration := abstract.GetString(args, "duration", "")
+	until := abstract.GetString(args, "until", "")
+	name := abstract.GetString(args, "name", "")
+	message := abstract.GetString(args, "message", "")
+	now := time.Now()
+	var end time.Time
+	switch {
+	case strings.TrimSpace(duration) != "" && strings.TrimSpace(until) != "":
+		return mcp.NewToolResultError("either duration or until must be specified, not both"), nil
+	case strings.TrimSpace(duration) != "":
+		o, err := parseOffset(duration)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		end = o.apply(now)
+	case strings.TrimSpace(until) != "":
+		t, err := parseTime(until, ts.config.location, now)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		end = t
+	default:
+		return mcp.NewToolResultError("either duration or until must be specified"), nil
+	}
+	if !end.After(now) {
+		return mcp.NewToolResultError(fmt.Sprintf("timer end %s is not in the future", end.Format(time.RFC3339))), nil
+	}
+	if end.Sub(now) > time.Duration(ts.config.MaxTimerDuration)*time.Hour {
+		return mcp.NewToolResultError(fmt.Sprintf("timers can run for at most %d hours", ts.config.MaxTimerDuration)), nil
+	}
+	c, err := ts.startTimer(strings.TrimSpace(name), message, end)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Timer %s started, it ends at %s (in %s)", c.ID, end.In(ts.config.location).Format(time.RFC3339), formatDuration(end.Sub(now)))), nil
+}
+
+// handleTimerList lists the running timers.
+func (ts *TimeServer) handleTimerList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	timers := ts.runningTimers()
+	if len(timers) == 0 {
+		return mcp.NewToolResultText("No running timers"), nil
+	}
+	now := time.Now()
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%d running timers:\n\n", len(timers)))
+	for _, c := range timers {
+		result.WriteString(fmt.Sprintf("%s ends at %s, %s remaining", c.ID, c.End.In(ts.config.location).Format(time.RFC3339), formatDuration(c.End.Sub(now))))
+		if c.Name != "" {
+			result.WriteString(", name: " + c.Name)
+		}
+		result.WriteString("\n")
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleTimerCancel cancels a running timer.
+func (ts *TimeServer) handleTimerCancel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id := abstract.GetString(args, "id", "")
+	if !ts.cancelTimer(strings.TrimSpace(id)) {
+		return mcp.NewToolResultError(fmt.Sprintf("timer %s not found", id)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Timer %s cancelled", id)), nil
+}
+
+// stringArray reads an optional array of strings argument.
+func stringArray(args map[string]any, key string) ([]string, error) {
+	raw, ok := args[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", key)
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of strings", key)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ts *TimeServer) Config() string {
+	cfg, err := json.Marshal(ts.config)
+	if err != nil {
+		ts.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ts *TimeServer) Name() comm.MoLingServerType {
+	return TimeServerName
+}
+
+func (ts *TimeServer) Close() error {
+	ts.stopTimers()
+	ts.Logger.Debug().Msg("TimeServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ts *TimeServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ts.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ts.config.Check()
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package clock
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const TimePromptDefault = `
+You are an assistant with reliable clock, calendar and scheduling utilities. Do not guess the current date or time, use these tools instead. Your capabilities include:
+
+1. **Current time**: Use time_now to get the current time in one or more time zones, e.g. "Asia/Shanghai", "America/New_York" or "UTC+8".
+
+2. **Conversion**: Use time_convert to convert a time from one zone to others, e.g. to find a meeting time for several offices.
+
+3. **Date arithmetic**: Use time_add to add or subtract an offset such as "+2w3d", "-90m" or "+1mo", and time_diff to compute the time between two dates.
+
+4. **Cron**: Use cron_explain to explain a cron expression in plain words and list its next run times.
+
+5. **Timers**: Use timer_start to start a countdown; a notification is sent when it finishes. Use timer_list and timer_cancel to manage running timers.
+
+Times without a zone are interpreted in the default zone %s.
+`
+
+// TimeConfig represents the configuration for the time service.
+type TimeConfig struct {
+	PromptFile       string `json:"prompt_file"` // PromptFile is the prompt file for the time service.
+	prompt           string
+	DefaultZone      string `json:"default_zone"`       // DefaultZone is the zone used for times without a zone, default: the system zone.
+	MaxTimers        int    `json:"max_timers"`         // MaxTimers is the maximum number of running timers.
+	MaxTimerDuration int    `json:"max_timer_duration"` // MaxTimerDuration is the longest timer that can be started. time.Hour
+	MaxCronRuns      int    `json:"max_cron_runs"`      // MaxCronRuns is the maximum number of next run times listed by cron_explain.
+	location         *time.Location
+}
+
+// NewTimeConfig creates a new TimeConfig with default values.
+func NewTimeConfig() *TimeConfig {
+	return &TimeConfig{
+		prompt:           TimePromptDefault,
+		DefaultZone:      "Local",
+		MaxTimers:        100,
+		MaxTimerDuration: 24 * 7,
+		MaxCronRuns:      50,
+		location:         time.Local,
+	}
+}
+
+// Check validates the time configuration.
+func (cfg *TimeConfig) Check() error {
+	cfg.prompt = TimePromptDefault
+	if cfg.DefaultZone == "" {
+		cfg.DefaultZone = "Local"
+	}
+	loc, err := loadZone(cfg.DefaultZone)
+	if err != nil {
+		return fmt.Errorf("invalid default_zone: %w", err)
+	}
+	cfg.location = loc
+	if cfg.MaxTimers <= 0 || cfg.MaxTimerDuration <= 0 || cfg.MaxCronRuns <= 0 {
+		return fmt.Errorf("max_timers, max_timer_duration and max_cron_runs must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
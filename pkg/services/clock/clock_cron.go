@@ -0,0 +1,447 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names indexed from min, e.g. JAN for month 1
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day-of-month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	dowField    = cronField{name: "day-of-week", min: 0, max: 6, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the matching values.
+type cronSchedule struct {
+	fields  []string // the expression fields, seconds first when present
+	seconds bool
+	second  uint64
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+	every   time.Duration // set for @every expressions
+}
+
+// parseCron parses a standard five field cron expression, a six field expression with
+// seconds first, a descriptor such as @daily or "@every 90m".
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid @every duration in %q, use a duration of at least 1s such as 90m", expr)
+		}
+		return &cronSchedule{every: d}, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		spec, ok := cronDescriptors[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported descriptor %s", expr)
+		}
+		expr = spec
+	}
+	fields := strings.Fields(expr)
+	s := &cronSchedule{fields: fields}
+	switch len(fields) {
+	case 5:
+		s.second = 1
+	case 6:
+		s.seconds = true
+		second, _, err := parseCronField(fields[0], secondField)
+		if err != nil {
+			return nil, err
+		}
+		s.second = second
+		fields = fields[1:]
+	default:
+		return nil, fmt.Errorf("cron expression must have 5 fields (minute hour day-of-month month day-of-week) or 6 with seconds, got %d", len(fields))
+	}
+	var err error
+	if s.minute, _, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, _, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, s.domStar, err = parseCronField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, _, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, s.dowStar, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps. The returned flag
+// reports whether the field starts with a wildcard, which matters for the day fields.
+func parseCronField(field string, f cronField) (uint64, bool, error) {
+	var bits uint64
+	star := strings.HasPrefix(field, "*") || strings.HasPrefix(field, "?")
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+		var lo, hi int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			lo, hi = f.min, f.max
+		default:
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			lo, err = cronValue(first, f)
+			if err != nil {
+				return 0, false, err
+			}
+			hi = lo
+			if isRange {
+				hi, err = cronValue(last, f)
+				if err != nil {
+					return 0, false, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+		}
+		if f.name == dowField.name && hi == 7 {
+			// 7 is Sunday as well
+			if (7-lo)%step == 0 {
+				bits |= 1
+			}
+			if lo == 7 {
+				continue
+			}
+			hi = 6
+		}
+		if lo > hi {
+			return 0, false, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, star, nil
+}
+
+func cronValue(s string, f cronField) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	max := f.max
+	if f.name == dowField.name {
+		max = 7
+	}
+	if err != nil || n < f.min || n > max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", s, f.name, f.min, max)
+	}
+	return n, nil
+}
+
+// next returns the first time after t that matches the schedule, or the zero time when there
+// is none within five years.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// a DST change at midnight can move the start of the day
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for s.second&(1<<uint(t.Second())) == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches applies the cron day rule: when both day fields are restricted, a day matches
+// if either of them does.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// explain describes the schedule in plain English.
+func (s *cronSchedule) explain() string {
+	if s.every > 0 {
+		return fmt.Sprintf("Every %s", s.every)
+	}
+	fields := s.fields
+	second := "0"
+	if s.seconds {
+		second, fields = fields[0], fields[1:]
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	var sb strings.Builder
+	switch {
+	case isNumber(minute) && isNumber(hour) && isNumber(second):
+		h, _ := strconv.Atoi(hour)
+		m, _ := strconv.Atoi(minute)
+		sec, _ := strconv.Atoi(second)
+		if sec == 0 {
+			sb.WriteString(fmt.Sprintf("At %02d:%02d", h, m))
+		} else {
+			sb.WriteString(fmt.Sprintf("At %02d:%02d:%02d", h, m, sec))
+		}
+	default:
+		if s.seconds && second != "0" {
+			sb.WriteString(atOrEvery(describeCronField(second, secondField)))
+			if minute != "*" {
+				sb.WriteString(", " + strings.ToLower(atOrEvery(describeCronField(minute, minuteField))))
+			}
+		} else if minute == "*" {
+			sb.WriteString("Every minute")
+		} else if strings.HasPrefix(minute, "*/") {
+			sb.WriteString(capitalize(describeCronField(minute, minuteField)))
+		} else {
+			sb.WriteString("At " + describeCronField(minute, minuteField))
+		}
+		switch {
+		case hour == "*":
+			if minute != "*" && !strings.HasPrefix(minute, "*/") {
+				sb.WriteString(" past every hour")
+			}
+		case minute == "*" || strings.HasPrefix(minute, "*/"):
+			sb.WriteString(", during " + describeCronField(hour, hourField))
+		default:
+			sb.WriteString(" past " + describeCronField(hour, hourField))
+		}
+	}
+	switch {
+	case dom != "*" && dom != "?" && dow != "*" && dow != "?":
+		sb.WriteString(", on " + describeCronField(dom, domField) + " or on " + describeCronField(dow, dowField))
+	case dom != "*" && dom != "?":
+		sb.WriteString(", on " + describeCronField(dom, domField))
+	case dow != "*" && dow != "?":
+		sb.WriteString(", on " + describeCronField(dow, dowField))
+	}
+	if month != "*" && month != "?" {
+		sb.WriteString(", in " + describeCronField(month, monthField))
+	}
+	return sb.String()
+}
+
+// describeCronField describes one field, e.g. "every 5 minutes", "hours 9 through 17" or
+// "Monday, Wednesday and Friday".
+func describeCronField(field string, f cronField) string {
+	unit := f.name
+	if f.name == domField.name {
+		unit = "day"
+	}
+	named := f.name == monthField.name || f.name == dowField.name
+	var parts []string
+	plural := false
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step, hasStep := strings.Cut(item, "/")
+		var text string
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			text = "every " + unit
+			if hasStep {
+				text = fmt.Sprintf("every %s %ss", step, unit)
+				if named {
+					text = fmt.Sprintf("every %s %s", ordinal(step), unit)
+				}
+			}
+		default:
+			first, last, isRange := strings.Cut(rangePart, "-")
+			if isRange {
+				text = cronValueName(first, f) + " through " + cronValueName(last, f)
+			} else {
+				text = cronValueName(first, f)
+				if hasStep {
+					text += " through " + cronValueName(strconv.Itoa(f.max), f)
+				}
+			}
+			if hasStep {
+				text = fmt.Sprintf("every %s %s from %s", ordinal(step), unit, text)
+			} else if isRange {
+				plural = true
+			}
+		}
+		parts = append(parts, text)
+	}
+	text := joinList(parts)
+	if named || strings.HasPrefix(text, "every") {
+		return text
+	}
+	if plural || len(parts) > 1 {
+		unit += "s"
+	}
+	if f.name == domField.name {
+		return unit + " " + text + " of the month"
+	}
+	return unit + " " + text
+}
+
+func cronValueName(s string, f cronField) string {
+	n, err := cronValue(s, f)
+	if err != nil {
+		return s
+	}
+	switch f.name {
+	case monthField.name:
+		return time.Month(n).String()
+	case dowField.name:
+		return time.Weekday(n % 7).String()
+	}
+	return strconv.Itoa(n)
+}
+
+func ordinal(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func joinList(parts []string) string {
+	if len(parts) <= 1 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// atOrEvery prefixes a field description with "At" unless it starts with "every".
+func atOrEvery(text string) string {
+	if strings.HasPrefix(text, "every") {
+		return capitalize(text)
+	}
+	return "At " + text
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
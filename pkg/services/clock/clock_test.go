@@ -0,0 +1,239 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package clock
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestParseOffset(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+	cases := map[string]string{
+		"+1mo":       "2024-02-29T10:00:00Z",
+		"1y1mo":      "2025-02-28T10:00:00Z",
+		"-2w":        "2024-01-17T10:00:00Z",
+		"-1d 2h":     "2024-01-30T08:00:00Z",
+		"90m":        "2024-01-31T11:30:00Z",
+		"+3 days 5s": "2024-02-03T10:00:05Z",
+		"-13mo":      "2022-12-31T10:00:00Z",
+	}
+	for s, expected := range cases {
+		o, err := parseOffset(s)
+		if err != nil {
+			t.Fatalf("Failed to parse offset %q: %s", s, err.Error())
+		}
+		if got := o.apply(base).Format(time.RFC3339); got != expected {
+			t.Errorf("Unexpected result for %q: %s, expected %s", s, got, expected)
+		}
+	}
+	for _, s := range []string{"", "soon", "5x", "1d and 2h"} {
+		if _, err := parseOffset(s); err == nil {
+			t.Errorf("Expected an error for offset %q", s)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	shanghai, err := loadZone("Asia/Shanghai")
+	if err != nil {
+		t.Fatalf("Failed to load zone: %s", err.Error())
+	}
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]string{
+		"2025-03-01 09:30":          "2025-03-01T09:30:00+08:00",
+		"2025-03-01T09:30:00-05:00": "2025-03-01T09:30:00-05:00",
+		"21:15":                     "2025-06-01T21:15:00+08:00",
+		"3pm":                       "2025-06-01T15:00:00+08:00",
+		"1700000000":                "2023-11-15T06:13:20+08:00",
+		"now":                       "2025-06-01T20:00:00+08:00",
+	}
+	for s, expected := range cases {
+		got, err := parseTime(s, shanghai, now)
+		if err != nil {
+			t.Fatalf("Failed to parse time %q: %s", s, err.Error())
+		}
+		if got.Format(time.RFC3339) != expected {
+			t.Errorf("Unexpected time for %q: %s, expected %s", s, got.Format(time.RFC3339), expected)
+		}
+	}
+	loc, err := loadZone("UTC-03:30")
+	if err != nil || loc.String() != "UTC-03:30" {
+		t.Errorf("Unexpected offset zone: %v, %v", loc, err)
+	}
+	if _, err = loadZone("Mars/Olympus"); err == nil {
+		t.Errorf("Expected an error for an unknown zone")
+	}
+}
+
+func TestCron(t *testing.T) {
+	from := time.Date(2025, time.May, 30, 16, 59, 30, 0, time.UTC) // a Friday
+	cases := []struct {
+		expr    string
+		explain string
+		next    []string
+	}{
+		{"*/15 9-17 * * MON-FRI", "Every 15 minutes, during hours 9 through 17, on Monday through Friday",
+			[]string{"2025-05-30T17:00:00Z", "2025-05-30T17:15:00Z", "2025-05-30T17:30:00Z", "2025-05-30T17:45:00Z", "2025-06-02T09:00:00Z"}},
+		{"30 9 1,15 * *", "At 09:30, on days 1 and 15 of the month",
+			[]string{"2025-06-01T09:30:00Z", "2025-06-15T09:30:00Z"}},
+		{"0 0 13 * 5", "At 00:00, on day 13 of the month or on Friday",
+			[]string{"2025-06-06T00:00:00Z", "2025-06-13T00:00:00Z", "2025-06-20T00:00:00Z"}},
+		{"@yearly", "At 00:00, on day 1 of the month, in January",
+			[]string{"2026-01-01T00:00:00Z"}},
+		{"0 0 29 2 *", "At 00:00, on day 29 of the month, in February",
+			[]string{"2028-02-29T00:00:00Z"}},
+		{"5 */2 * * 7", "At minute 5 past every 2 hours, on Sunday",
+			[]string{"2025-06-01T00:05:00Z", "2025-06-01T02:05:00Z"}},
+		{"*/20 * * * * *", "Every 20 seconds",
+			[]string{"2025-05-30T16:59:40Z", "2025-05-30T17:00:00Z"}},
+		{"30 5 8 * * *", "At 08:05:30",
+			[]string{"2025-05-31T08:05:30Z"}},
+		{"@every 90m", "Every 1h30m0s",
+			[]string{"2025-05-30T18:29:30Z"}},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %s", c.expr, err.Error())
+		}
+		if got := s.explain(); got != c.explain {
+			t.Errorf("Unexpected explanation for %q: %q, expected %q", c.expr, got, c.explain)
+		}
+		next := from
+		for _, expected := range c.next {
+			next = s.next(next)
+			if got := next.Format(time.RFC3339); got != expected {
+				t.Errorf("Unexpected next run for %q: %s, expected %s", c.expr, got, expected)
+				break
+			}
+		}
+	}
+	if s, err := parseCron("0 0 30 2 *"); err != nil || !s.next(from).IsZero() {
+		t.Errorf("Expected no run for February 30th")
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * MON-FOO", "*/0 * * * *", "@reboot"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
+
+func TestTimeServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewTimeServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create TimeServer: %s", err.Error())
+	}
+	err = srv.LoadConfig(map[string]any{"default_zone": "Europe/Berlin"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init TimeServer: %s", err.Error())
+	}
+	ts := srv.(*TimeServer)
+	notified := make(chan map[string]any, 1)
+	ts.SetNotifyFunc(func(method string, params map[string]any) {
+		notified <- params
+	})
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	text, isErr := call(ts.handleConvert, map[string]any{"time": "2025-01-15 09:00", "to": []any{"America/New_York", "+05:30"}})
+	if isErr {
+		t.Fatalf("Failed to convert: %s", text)
+	}
+	var converted struct {
+		Converted []zonedTime `json:"converted"`
+	}
+	err = json.Unmarshal([]byte(text), &converted)
+	if err != nil || len(converted.Converted) != 2 || converted.Converted[0].Time != "2025-01-15T03:00:00-05:00" || converted.Converted[1].Time != "2025-01-15T13:30:00+05:30" {
+		t.Errorf("Unexpected conversion: %s", text)
+	}
+
+	text, isErr = call(ts.handleDiff, map[string]any{"start": "2024-02-10", "end": "2025-03-01 12:00"})
+	if isErr || !strings.Contains(text, `"calendar": "1y 0mo 19d"`) || !strings.Contains(text, `"duration": "385d 12h 0m 0s"`) {
+		t.Errorf("Unexpected diff: %s", text)
+	}
+
+	text, isErr = call(ts.handleAdd, map[string]any{"time": "2025-03-29 12:00", "offset": "+1d"})
+	if isErr || !strings.Contains(text, `"time": "2025-03-30T12:00:00+02:00"`) {
+		t.Errorf("Unexpected add across DST: %s", text)
+	}
+
+	text, isErr = call(ts.handleNow, map[string]any{"zones": []any{"Nowhere/City"}})
+	if !isErr || !strings.Contains(text, "unknown time zone") {
+		t.Errorf("Expected an unknown zone error, got: %s", text)
+	}
+
+	text, isErr = call(ts.handleCron, map[string]any{"expression": "0 9 * * 1", "from": "2025-06-01 10:00", "count": float64(2)})
+	if isErr || !strings.Contains(text, "2025-06-02T09:00:00+02:00 Mon\n2025-06-09T09:00:00+02:00 Mon") {
+		t.Errorf("Unexpected cron result: %s", text)
+	}
+
+	text, isErr = call(ts.handleTimerStart, map[string]any{"duration": "1h", "name": "tea"})
+	if isErr {
+		t.Fatalf("Failed to start timer: %s", text)
+	}
+	text, _ = call(ts.handleTimerList, map[string]any{})
+	if !strings.Contains(text, "name: tea") {
+		t.Errorf("Unexpected timer list: %s", text)
+	}
+	text, isErr = call(ts.handleTimerCancel, map[string]any{"id": "t1"})
+	if isErr {
+		t.Errorf("Failed to cancel timer: %s", text)
+	}
+	text, _ = call(ts.handleTimerList, map[string]any{})
+	if text != "No running timers" {
+		t.Errorf("Unexpected timer list after cancel: %s", text)
+	}
+
+	c, err := ts.startTimer("short", "stand up", time.Now().Add(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to start timer: %s", err.Error())
+	}
+	select {
+	case params := <-notified:
+		data := params["data"].(map[string]any)
+		if data["timer_id"] != c.ID || !strings.Contains(data["message"].(string), "stand up") {
+			t.Errorf("Unexpected notification: %v", params)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timer notification was not sent")
+	}
+	_ = srv.Close()
+}
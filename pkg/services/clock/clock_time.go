@@ -0,0 +1,259 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package clock
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	offsetZonePattern = regexp.MustCompile(`^(?i)(?:utc|gmt)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	offsetPattern     = regexp.MustCompile(`(?i)^\s*([+-]?)\s*(\d+)\s*(years?|y|months?|mo|weeks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)`)
+
+	// localLayouts are parsed in the zone of the call.
+	localLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"2006/01/02 15:04:05",
+		"2006/01/02 15:04",
+		"2006/01/02",
+	}
+	// clockLayouts are times of day, on the current date.
+	clockLayouts = []string{"15:04:05", "15:04", "3:04PM", "3:04pm", "3PM", "3pm"}
+)
+
+// loadZone resolves an IANA zone name, "Local", "UTC" or a fixed offset such as "+08:00" or "UTC-5".
+func loadZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc", "gmt", "z":
+		return time.UTC, nil
+	}
+	if m := offsetZonePattern.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid offset %s", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone("UTC"+formatOffset(offset), offset), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %s, use an IANA name such as Europe/Berlin or an offset such as +08:00", name)
+	}
+	return loc, nil
+}
+
+// parseTime parses a time in one of the supported formats. Times without a zone are
+// interpreted in loc.
+func parseTime(s string, loc *time.Location, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "now") {
+		return now.In(loc), nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 9 {
+		if len(s) >= 13 {
+			return time.UnixMilli(n).In(loc), nil
+		}
+		return time.Unix(n, 0).In(loc), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.RFC1123Z, time.RFC1123, "2006-01-02 15:04:05Z07:00", "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	today := now.In(loc)
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return time.Date(today.Year(), today.Month(), today.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time %q, use RFC 3339 (2006-01-02T15:04:05Z07:00), 2006-01-02 15:04, 15:04, a unix timestamp or now", s)
+}
+
+// offset is a calendar offset, months and days are applied before the duration.
+type offset struct {
+	years, months, days int
+	duration            time.Duration
+}
+
+// parseOffset parses offsets such as "+1y2mo", "-3d 4h", "90m" or "2 weeks".
+func parseOffset(s string) (offset, error) {
+	var o offset
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return o, fmt.Errorf("offset must not be empty")
+	}
+	sign := 1
+	for rest != "" {
+		m := offsetPattern.FindStringSubmatch(rest)
+		if m == nil {
+			return o, fmt.Errorf("invalid offset %q, use units y, mo, w, d, h, m and s, e.g. +1w2d or -90m", s)
+		}
+		rest = strings.TrimSpace(rest[len(m[0]):])
+		switch m[1] {
+		case "-":
+			sign = -1
+		case "+":
+			sign = 1
+		}
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return o, fmt.Errorf("invalid offset %q: %w", s, err)
+		}
+		n *= sign
+		unit := strings.ToLower(m[3])
+		switch {
+		case unit == "y" || strings.HasPrefix(unit, "year"):
+			o.years += n
+		case unit == "mo" || strings.HasPrefix(unit, "month"):
+			o.months += n
+		case unit == "w" || strings.HasPrefix(unit, "week"):
+			o.days += 7 * n
+		case unit == "d" || strings.HasPrefix(unit, "day"):
+			o.days += n
+		case strings.HasPrefix(unit, "h"):
+			o.duration += time.Duration(n) * time.Hour
+		case unit == "m" || strings.HasPrefix(unit, "min"):
+			o.duration += time.Duration(n) * time.Minute
+		default:
+			o.duration += time.Duration(n) * time.Second
+		}
+	}
+	return o, nil
+}
+
+// apply adds the offset to t. Adding months keeps the day of month where possible and clamps
+// it to the end of shorter months, so Jan 31 plus one month is the last day of February.
+func (o offset) apply(t time.Time) time.Time {
+	months := o.years*12 + o.months
+	if months != 0 {
+		total := int(t.Month()) - 1 + months
+		year := t.Year() + floorDiv(total, 12)
+		month := time.Month(total - floorDiv(total, 12)*12 + 1)
+		day := min(t.Day(), daysIn(year, month))
+		t = time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	return t.AddDate(0, 0, o.days).Add(o.duration)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// calendarDiff returns the years, months and days from a to b, with a not after b.
+func calendarDiff(a, b time.Time) (int, int, int) {
+	b = b.In(a.Location())
+	years := b.Year() - a.Year()
+	months := int(b.Month()) - int(a.Month())
+	days := b.Day() - a.Day()
+	if b.Hour()*3600+b.Minute()*60+b.Second() < a.Hour()*3600+a.Minute()*60+a.Second() {
+		days--
+	}
+	if days < 0 {
+		months--
+		prev := b.Month() - 1
+		year := b.Year()
+		if prev == 0 {
+			prev, year = 12, year-1
+		}
+		days += daysIn(year, prev)
+	}
+	if months < 0 {
+		years--
+		months += 12
+	}
+	return years, months, days
+}
+
+// formatDuration formats a duration as days, hours, minutes and seconds, e.g. "2d 3h 0m 5s".
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	seconds := (d - minutes*time.Minute) / time.Second
+	if days > 0 {
+		return fmt.Sprintf("%s%dd %dh %dm %ds", sign, days, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%s%dh %dm %ds", sign, hours, minutes, seconds)
+}
+
+// formatOffset formats a UTC offset in seconds as +08:00.
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// zonedTime describes a time in one zone.
+type zonedTime struct {
+	Zone         string `json:"zone"`
+	Time         string `json:"time"`
+	Weekday      string `json:"weekday"`
+	Abbreviation string `json:"abbreviation"`
+	UTCOffset    string `json:"utc_offset"`
+	DST          bool   `json:"dst"`
+	Unix         int64  `json:"unix"`
+}
+
+func describeTime(zone string, t time.Time) zonedTime {
+	abbr, offset := t.Zone()
+	return zonedTime{
+		Zone:         zone,
+		Time:         t.Format(time.RFC3339),
+		Weekday:      t.Weekday().String(),
+		Abbreviation: abbr,
+		UTCOffset:    formatOffset(offset),
+		DST:          t.IsDST(),
+		Unix:         t.Unix(),
+	}
+}
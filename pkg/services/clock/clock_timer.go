@@ -0,0 +1,122 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package clock
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// countdown is a running timer.
+type countdown struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Start   time.Time `json:"started_at"`
+	End     time.Time `json:"ends_at"`
+	timer   *time.Timer
+}
+
+// startTimer starts a countdown that notifies connected clients when it ends.
+func (ts *TimeServer) startTimer(name, message string, end time.Time) (*countdown, error) {
+	ts.timersMu.Lock()
+	defer ts.timersMu.Unlock()
+	if len(ts.timers) >= ts.config.MaxTimers {
+		return nil, fmt.Errorf("too many running timers (%d), cancel some first", ts.config.MaxTimers)
+	}
+	ts.timerSeq++
+	c := &countdown{
+		ID:      "t" + strconv.Itoa(ts.timerSeq),
+		Name:    name,
+		Message: message,
+		Start:   time.Now(),
+		End:     end,
+	}
+	c.timer = time.AfterFunc(time.Until(end), func() { ts.finishTimer(c.ID) })
+	ts.timers[c.ID] = c
+	return c, nil
+}
+
+// finishTimer removes a timer that has ended and sends its notification.
+func (ts *TimeServer) finishTimer(id string) {
+	ts.timersMu.Lock()
+	c, ok := ts.timers[id]
+	delete(ts.timers, id)
+	ts.timersMu.Unlock()
+	if !ok {
+		return
+	}
+	label := c.ID
+	if c.Name != "" {
+		label = fmt.Sprintf("%s (%s)", c.Name, c.ID)
+	}
+	text := fmt.Sprintf("timer %s finished", label)
+	if c.Message != "" {
+		text += ": " + c.Message
+	}
+	ts.Logger.Info().Str("timer", c.ID).Str("name", c.Name).Msg("timer finished")
+	ts.SendNotification("notifications/message", map[string]any{
+		"level":  mcp.LoggingLevelInfo,
+		"logger": "time",
+		"data": map[string]any{
+			"message":    text,
+			"timer_id":   c.ID,
+			"name":       c.Name,
+			"started_at": c.Start.Format(time.RFC3339),
+			"ended_at":   time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// cancelTimer stops a running timer.
+func (ts *TimeServer) cancelTimer(id string) bool {
+	ts.timersMu.Lock()
+	defer ts.timersMu.Unlock()
+	c, ok := ts.timers[id]
+	if !ok {
+		return false
+	}
+	c.timer.Stop()
+	delete(ts.timers, id)
+	return true
+}
+
+// runningTimers returns the running timers, the one ending first first.
+func (ts *TimeServer) runningTimers() []*countdown {
+	ts.timersMu.Lock()
+	defer ts.timersMu.Unlock()
+	result := make([]*countdown, 0, len(ts.timers))
+	for _, c := range ts.timers {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].End.Before(result[j].End) })
+	return result
+}
+
+// stopTimers stops all timers without notifying.
+func (ts *TimeServer) stopTimers() {
+	ts.timersMu.Lock()
+	defer ts.timersMu.Unlock()
+	for id, c := range ts.timers {
+		c.timer.Stop()
+		delete(ts.timers, id)
+	}
+}
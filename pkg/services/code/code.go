@@ -0,0 +1,311 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package code provides QR code generation and QR code and barcode decoding for the MoLing application.
+package code
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	CodeServerName comm.MoLingServerType = "Code"
+)
+
+var fileNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+// CodeServer implements the Service interface and generates and decodes QR codes and barcodes.
+type CodeServer struct {
+	abstract.MLService
+	config *CodeConfig
+}
+
+// NewCodeServer creates a new CodeServer instance.
+func NewCodeServer(ctx context.Context) (abstract.Service, error) {
+	cc := NewCodeConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("CodeServer: invalid config type")
+	}
+	cc.DataPath = filepath.Join(globalConf.BasePath, "data")
+	cc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("CodeServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(CodeServerName))
+	})
+
+	cs := &CodeServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    cc,
+	}
+	err := cs.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Init registers the prompt and tools of the code service.
+func (cs *CodeServer) Init() error {
+	err := utils.CreateDirectory(cs.config.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "code_prompt",
+			Description: "Get the relevant functions and prompts of the Code MCP Server",
+		},
+		HandlerFunc: cs.handlePrompt,
+	}
+	cs.AddPrompt(pe)
+
+	cs.AddTool(mcp.NewTool(
+		"qrcode_generate",
+		mcp.WithDescription("Generate a QR code PNG from text, a URL or a pairing token"),
+		mcp.WithString("content",
+			mcp.Description("Content to encode"),
+			mcp.Required(),
+		),
+		mcp.WithString("level",
+			mcp.Description("Error correction level: L (7%), M (15%), Q (25%) or H (30%). Default: M"),
+		),
+		mcp.WithNumber("size",
+			mcp.Description("Approximate image width in pixels, default: 256"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name for the PNG file, default: qrcode"),
+		),
+		mcp.WithBoolean("inline",
+			mcp.Description("Return the QR code as inline image content instead of saving it"),
+		),
+	), cs.handleGenerate)
+
+	cs.AddTool(mcp.NewTool(
+		"qrcode_decode",
+		mcp.WithDescription("Read the content of a QR code from a PNG, JPEG or GIF image"),
+		mcp.WithString("path",
+			mcp.Description("Path of the image file"),
+			mcp.Required(),
+		),
+	), cs.handleDecodeQR)
+
+	cs.AddTool(mcp.NewTool(
+		"barcode_decode",
+		mcp.WithDescription("Read EAN-13, EAN-8, UPC-A, Code 128 and Code 39 barcodes from a PNG, JPEG or GIF image"),
+		mcp.WithString("path",
+			mcp.Description("Path of the image file"),
+			mcp.Required(),
+		),
+	), cs.handleDecodeBarcode)
+	return nil
+}
+
+func (cs *CodeServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := cs.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, cs.config.AllowedDirs[0])
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// handleGenerate encodes content as a QR code and saves or returns the PNG.
+func (cs *CodeServer) handleGenerate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	content, ok := args["content"].(string)
+	if !ok || content == "" {
+		return mcp.NewToolResultError("content must be a non-empty string"), nil
+	}
+	levelName := abstract.GetString(args, "level", "")
+	if levelName == "" {
+		levelName = cs.config.DefaultLevel
+	}
+	level, ok := levelNames[strings.ToUpper(levelName)]
+	if !ok {
+		return mcp.NewToolResultError("level must be one of L, M, Q, H"), nil
+	}
+	size := cs.config.DefaultSize
+	if s := abstract.GetInt(args, "size", 0); s > 0 {
+		size = s
+	}
+	if size > cs.config.MaxSize {
+		return mcp.NewToolResultError(fmt.Sprintf("size must not be larger than %d", cs.config.MaxSize)), nil
+	}
+	name := abstract.GetString(args, "name", "")
+	inline := abstract.GetBool(args, "inline", false)
+
+	qr, err := encodeQR(content, level)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, qr.image(max(size/(qr.size+2*quietZone), 1)))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode PNG: %s", err.Error())), nil
+	}
+	text := fmt.Sprintf("QR code version %d (%dx%d modules), level %s", qr.version, qr.size, qr.size, strings.ToUpper(levelName))
+	if inline {
+		if buf.Len() > cs.config.MaxInlineSize {
+			return mcp.NewToolResultError(fmt.Sprintf("image is %d bytes, too large to return inline (limit %d bytes)", buf.Len(), cs.config.MaxInlineSize)), nil
+		}
+		return mcp.NewToolResultImage(text, base64.StdEncoding.EncodeToString(buf.Bytes()), "image/png"), nil
+	}
+	output := cs.outputPath(name, "qrcode")
+	err = os.WriteFile(output, buf.Bytes(), 0o644)
+	if err != nil {
+		cs.Logger.Error().Err(err).Str("output", output).Msg("failed to save QR code")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save QR code: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s saved to:%s (%d bytes)", text, output, buf.Len())), nil
+}
+
+// handleDecodeQR reads the content of a QR code image.
+func (cs *CodeServer) handleDecodeQR(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	img, err := cs.loadImage(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text, err := decodeQR(img)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to decode %s: %s", path, err.Error())), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// handleDecodeBarcode reads the linear barcodes of an image.
+func (cs *CodeServer) handleDecodeBarcode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	img, err := cs.loadImage(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	codes, err := decodeBarcodes(img)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to decode %s: %s", path, err.Error())), nil
+	}
+	data, err := json.Marshal(codes)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// loadImage decodes an image inside the allowed directories, checking its dimensions first.
+func (cs *CodeServer) loadImage(path string) (image.Image, error) {
+	abs, err := utils.ResolvePath(path, cs.config.AllowedDirs)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+	if cfg.Width*cfg.Height > cs.config.MaxImagePixels {
+		return nil, fmt.Errorf("image is %dx%d pixels, the limit is %d pixels", cfg.Width, cfg.Height, cs.config.MaxImagePixels)
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// outputPath returns the file path for a new QR code under DataPath.
+func (cs *CodeServer) outputPath(name, defaultName string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".png")
+	name = fileNameReplacer.ReplaceAllString(name, "_")
+	if name == "" || name == "_" {
+		name = defaultName
+	}
+	return filepath.Join(cs.config.DataPath, fmt.Sprintf("%s_%s.png", name, time.Now().Format("20060102150405.000")))
+}
+
+// Config returns the configuration of the service as a string.
+func (cs *CodeServer) Config() string {
+	cfg, err := json.Marshal(cs.config)
+	if err != nil {
+		cs.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (cs *CodeServer) Name() comm.MoLingServerType {
+	return CodeServerName
+}
+
+func (cs *CodeServer) Close() error {
+	cs.Logger.Debug().Msg("CodeServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (cs *CodeServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(cs.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return cs.config.Check()
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package code
+
+import (
+	"errors"
+	"image"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+var errNoBarcode = errors.New("no barcode found")
+
+// barcodeFormats are the names barcode_decode reports for the formats it reads.
+var barcodeFormats = map[gozxing.BarcodeFormat]string{
+	gozxing.BarcodeFormat_EAN_13:   "EAN-13",
+	gozxing.BarcodeFormat_EAN_8:    "EAN-8",
+	gozxing.BarcodeFormat_UPC_A:    "UPC-A",
+	gozxing.BarcodeFormat_CODE_128: "Code 128",
+	gozxing.BarcodeFormat_CODE_39:  "Code 39",
+}
+
+// barcode is a decoded linear barcode.
+type barcode struct {
+	Format string `json:"format"`
+	Text   string `json:"text"`
+}
+
+// decodeBarcodes scans an image, also rotated by 90 degrees, and returns the barcode found for each format.
+func decodeBarcodes(img image.Image) ([]barcode, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+		gozxing.DecodeHintType_POSSIBLE_FORMATS: []gozxing.BarcodeFormat{
+			gozxing.BarcodeFormat_EAN_13, gozxing.BarcodeFormat_EAN_8, gozxing.BarcodeFormat_UPC_A,
+		},
+	}
+	readers := []gozxing.Reader{oned.NewMultiFormatUPCEANReader(hints), oned.NewCode128Reader(), oned.NewCode39Reader()}
+	var codes []barcode
+	for _, r := range readers {
+		result, err := r.Decode(bmp, hints)
+		if err != nil {
+			continue
+		}
+		format, ok := barcodeFormats[result.GetBarcodeFormat()]
+		if !ok {
+			continue
+		}
+		codes = append(codes, barcode{Format: format, Text: result.GetText()})
+	}
+	if len(codes) == 0 {
+		return nil, errNoBarcode
+	}
+	return codes, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package code
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const CodePromptDefault = `
+You are an assistant that can create and read QR codes and barcodes. Your capabilities include:
+
+1. **Generate**: Use qrcode_generate to turn text, a URL, a Wi-Fi configuration or a pairing token into a QR code PNG. The image is saved to the MoLing data directory, or returned inline with inline=true.
+
+2. **Decode QR codes**: Use qrcode_decode to read the content of a QR code from a PNG, JPEG or GIF image, e.g. a photo of a device label or a ticket.
+
+3. **Decode barcodes**: Use barcode_decode to read EAN-13, EAN-8, UPC-A, Code 128 and Code 39 barcodes from an image.
+
+Relative paths are resolved in %s. Images should be reasonably sharp, with the code upright or rotated and not heavily distorted.
+`
+
+// CodeConfig represents the configuration for the QR code and barcode service.
+type CodeConfig struct {
+	PromptFile     string `json:"prompt_file"` // PromptFile is the prompt file for the code service.
+	prompt         string
+	DataPath       string   `json:"data_path"`        // DataPath is the path where generated QR codes are saved.
+	AllowedDirs    []string `json:"allowed_dirs"`     // AllowedDirs are the directories images may be decoded from, relative paths use the first one.
+	DefaultLevel   string   `json:"default_level"`    // DefaultLevel is the error correction level used when none is given: L, M, Q or H.
+	DefaultSize    int      `json:"default_size"`     // DefaultSize is the default width of generated images, in pixels.
+	MaxSize        int      `json:"max_size"`         // MaxSize is the maximum width of generated images, in pixels.
+	MaxImagePixels int      `json:"max_image_pixels"` // MaxImagePixels is the maximum number of pixels of an image that is decoded.
+	MaxInlineSize  int      `json:"max_inline_size"`  // MaxInlineSize is the maximum size of an image returned inline, in bytes.
+}
+
+// NewCodeConfig creates a new CodeConfig with default values.
+func NewCodeConfig() *CodeConfig {
+	return &CodeConfig{
+		prompt:         CodePromptDefault,
+		DataPath:       filepath.Join(os.TempDir(), ".moling", "data"),
+		AllowedDirs:    []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		DefaultLevel:   "M",
+		DefaultSize:    256,
+		MaxSize:        4096,
+		MaxImagePixels: 4096 * 4096,
+		MaxInlineSize:  1024 * 1024 * 1,
+	}
+}
+
+// Check validates the code configuration.
+func (cfg *CodeConfig) Check() error {
+	cfg.prompt = CodePromptDefault
+	if cfg.DataPath == "" {
+		return fmt.Errorf("data_path must not be empty")
+	}
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	cfg.DefaultLevel = strings.ToUpper(cfg.DefaultLevel)
+	if _, ok := levelNames[cfg.DefaultLevel]; !ok {
+		return fmt.Errorf("default_level must be one of L, M, Q, H")
+	}
+	if cfg.DefaultSize <= 0 || cfg.MaxSize < cfg.DefaultSize {
+		return fmt.Errorf("default_size must be greater than 0 and not larger than max_size")
+	}
+	if cfg.MaxImagePixels <= 0 {
+		return fmt.Errorf("max_image_pixels must be greater than 0")
+	}
+	if cfg.MaxInlineSize < 0 {
+		return fmt.Errorf("max_inline_size must not be negative")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
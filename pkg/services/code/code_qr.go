@@ -0,0 +1,111 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package code
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+)
+
+// quietZone is the border around a rendered QR code, in modules.
+const quietZone = 4
+
+var (
+	errNoQRCode = errors.New("no QR code found")
+
+	levelNames = map[string]decoder.ErrorCorrectionLevel{
+		"L": decoder.ErrorCorrectionLevel_L,
+		"M": decoder.ErrorCorrectionLevel_M,
+		"Q": decoder.ErrorCorrectionLevel_Q,
+		"H": decoder.ErrorCorrectionLevel_H,
+	}
+
+	// levelCapacity is the largest byte mode content of a version 40 symbol per level, for error messages.
+	levelCapacity = map[decoder.ErrorCorrectionLevel]int{
+		decoder.ErrorCorrectionLevel_L: 2953,
+		decoder.ErrorCorrectionLevel_M: 2331,
+		decoder.ErrorCorrectionLevel_Q: 1663,
+		decoder.ErrorCorrectionLevel_H: 1273,
+	}
+)
+
+// qrCode is an encoded QR code symbol.
+type qrCode struct {
+	version int
+	size    int
+	matrix  *encoder.ByteMatrix
+}
+
+// encodeQR encodes content as UTF-8 in the smallest symbol for level.
+func encodeQR(content string, level decoder.ErrorCorrectionLevel) (*qrCode, error) {
+	qr, err := encoder.Encoder_encode(content, level, map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_CHARACTER_SET: "UTF-8",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("content is too long for a QR code, the limit at level %s is %d bytes", level, levelCapacity[level])
+	}
+	matrix := qr.GetMatrix()
+	return &qrCode{version: qr.GetVersion().GetVersionNumber(), size: matrix.GetWidth(), matrix: matrix}, nil
+}
+
+// image renders the symbol with scale pixels per module and a quiet zone around it.
+func (q *qrCode) image(scale int) image.Image {
+	width := (q.size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.matrix.Get(x, y) != 1 {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				offset := img.PixOffset((x+quietZone)*scale, (y+quietZone)*scale+dy)
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[offset+dx] = 1
+				}
+			}
+		}
+	}
+	return img
+}
+
+// decodeQR finds and decodes a QR code in an image, with a local and then a global threshold.
+func decodeQR(img image.Image) (string, error) {
+	source := gozxing.NewLuminanceSourceFromImage(img)
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	err := errNoQRCode
+	for _, binarizer := range []gozxing.Binarizer{gozxing.NewHybridBinarizer(source), gozxing.NewGlobalHistgramBinarizer(source)} {
+		bmp, berr := gozxing.NewBinaryBitmap(binarizer)
+		if berr != nil {
+			return "", berr
+		}
+		result, derr := qrcode.NewQRCodeReader().Decode(bmp, hints)
+		if derr == nil {
+			return result.GetText(), nil
+		}
+		if _, notFound := derr.(gozxing.NotFoundException); !notFound {
+			err = derr
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package code
+
+import (
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestQRRoundTrip(t *testing.T) {
+	cases := []struct {
+		content string
+		level   string
+	}{
+		{"01234567890123", "L"},
+		{"HTTPS://MOLING.EXAMPLE/PAIR", "Q"},
+		{"https://github.com/gojue/moling?token=3f2a9c", "M"},
+		{"WIFI:T:WPA;S:moling-lab;P:correct horse battery staple;;", "H"},
+		{"设备配对码：ML-2025-0042", "M"},
+		{strings.Repeat("ticket-0123456789;", 40), "Q"},
+	}
+	for _, c := range cases {
+		qr, err := encodeQR(c.content, levelNames[c.level])
+		if err != nil {
+			t.Fatalf("Failed to encode %q: %s", c.content, err.Error())
+		}
+		img := qr.image(4)
+		for _, rotated := range []image.Image{img, rotate90(img)} {
+			text, err := decodeQR(rotated)
+			if err != nil {
+				t.Fatalf("Failed to decode version %d: %s", qr.version, err.Error())
+			}
+			if text != c.content {
+				t.Errorf("Unexpected content: %q, expected %q", text, c.content)
+			}
+		}
+	}
+	if _, err := encodeQR(strings.Repeat("x", 1300), levelNames["H"]); err == nil || !strings.Contains(err.Error(), "1273 bytes") {
+		t.Errorf("Expected a too long error with the level limit, got %v", err)
+	}
+}
+
+func TestQRErrorCorrection(t *testing.T) {
+	qr, err := encodeQR("https://github.com/gojue/moling", levelNames["H"])
+	if err != nil {
+		t.Fatalf("Failed to encode: %s", err.Error())
+	}
+	// flip a block of data modules away from the finder and format areas
+	img := qr.image(3).(*image.Paletted)
+	for y := (10 + quietZone) * 3; y < (13+quietZone)*3; y++ {
+		for x := (10 + quietZone) * 3; x < (14+quietZone)*3; x++ {
+			img.Pix[img.PixOffset(x, y)] ^= 1
+		}
+	}
+	text, err := decodeQR(img)
+	if err != nil {
+		t.Fatalf("Failed to decode damaged QR code: %s", err.Error())
+	}
+	if text != "https://github.com/gojue/moling" {
+		t.Errorf("Unexpected content: %q", text)
+	}
+}
+
+func TestBarcodeDecode(t *testing.T) {
+	cases := []struct {
+		writer   gozxing.Writer
+		format   gozxing.BarcodeFormat
+		contents string
+		code     barcode
+	}{
+		{oned.NewEAN13Writer(), gozxing.BarcodeFormat_EAN_13, "4006381333931", barcode{Format: "EAN-13", Text: "4006381333931"}},
+		{oned.NewEAN13Writer(), gozxing.BarcodeFormat_EAN_13, "0036000291452", barcode{Format: "UPC-A", Text: "036000291452"}},
+		{oned.NewEAN8Writer(), gozxing.BarcodeFormat_EAN_8, "96385074", barcode{Format: "EAN-8", Text: "96385074"}},
+		{oned.NewCode128Writer(), gozxing.BarcodeFormat_CODE_128, "MoLing-128 ok", barcode{Format: "Code 128", Text: "MoLing-128 ok"}},
+		{oned.NewCode39Writer(), gozxing.BarcodeFormat_CODE_39, "MOLING-39", barcode{Format: "Code 39", Text: "MOLING-39"}},
+	}
+	for _, c := range cases {
+		img := barsImage(t, c.writer, c.format, c.contents)
+		for _, rotated := range []image.Image{img, rotate90(rotate90(img)), rotate90(img)} {
+			codes, err := decodeBarcodes(rotated)
+			if err != nil {
+				t.Fatalf("Failed to decode %s: %s", c.code.Text, err.Error())
+			}
+			if len(codes) != 1 || codes[0] != c.code {
+				t.Errorf("Unexpected barcodes: %+v, expected %+v", codes, c.code)
+			}
+		}
+	}
+	if _, err := decodeBarcodes(image.NewGray(image.Rect(0, 0, 64, 64))); err == nil {
+		t.Errorf("Expected an error for an empty image")
+	}
+}
+
+func TestCodeServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewCodeServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CodeServer: %s", err.Error())
+	}
+	dir := t.TempDir()
+	err = srv.LoadConfig(map[string]any{"data_path": dir, "allowed_dirs": []any{dir}})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init CodeServer: %s", err.Error())
+	}
+	cs := srv.(*CodeServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res
+	}
+	text := func(res *mcp.CallToolResult) string {
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	res := call(cs.handleGenerate, map[string]any{"content": "pair:ML-0042", "name": "device pairing"})
+	if res.IsError {
+		t.Fatalf("Failed to generate QR code: %s", text(res))
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "device_pairing_*.png"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one QR code file, found %v", files)
+	}
+	res = call(cs.handleDecodeQR, map[string]any{"path": filepath.Base(files[0])})
+	if res.IsError || text(res) != "pair:ML-0042" {
+		t.Errorf("Unexpected decode result: %s", text(res))
+	}
+
+	res = call(cs.handleGenerate, map[string]any{"content": "inline", "inline": true, "level": "h", "size": float64(128)})
+	if res.IsError || len(res.Content) != 2 {
+		t.Fatalf("Expected inline image content: %+v", res.Content)
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Content[1].(mcp.ImageContent).Data)
+	if err != nil {
+		t.Fatalf("Failed to decode inline image: %s", err.Error())
+	}
+	if !strings.HasPrefix(string(data), "\x89PNG") {
+		t.Errorf("Inline image is not a PNG")
+	}
+
+	res = call(cs.handleGenerate, map[string]any{"content": "x", "level": "X"})
+	if !res.IsError {
+		t.Errorf("Expected an error for an invalid level")
+	}
+	res = call(cs.handleGenerate, map[string]any{"content": strings.Repeat("x", 3000)})
+	if !res.IsError {
+		t.Errorf("Expected an error for content that is too long")
+	}
+
+	f, err := os.Create(filepath.Join(dir, "ean.png"))
+	if err != nil {
+		t.Fatalf("Failed to create image: %s", err.Error())
+	}
+	err = png.Encode(f, barsImage(t, oned.NewEAN13Writer(), gozxing.BarcodeFormat_EAN_13, "4006381333931"))
+	_ = f.Close()
+	if err != nil {
+		t.Fatalf("Failed to write image: %s", err.Error())
+	}
+	res = call(cs.handleDecodeBarcode, map[string]any{"path": "ean.png"})
+	if res.IsError || text(res) != `[{"format":"EAN-13","text":"4006381333931"}]` {
+		t.Errorf("Unexpected barcode result: %s", text(res))
+	}
+	res = call(cs.handleDecodeQR, map[string]any{"path": "ean.png"})
+	if !res.IsError {
+		t.Errorf("Expected an error for an image without a QR code")
+	}
+	res = call(cs.handleDecodeQR, map[string]any{"path": "../outside.png"})
+	if !res.IsError || !strings.Contains(text(res), "access denied") {
+		t.Errorf("Expected access denied, got: %s", text(res))
+	}
+}
+
+// rotate90 rotates an image clockwise.
+func rotate90(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dy()-1-y, x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// barsImage renders a barcode with a quiet zone, as a grayscale image.
+func barsImage(t *testing.T, w gozxing.Writer, format gozxing.BarcodeFormat, contents string) image.Image {
+	matrix, err := w.Encode(contents, format, 0, 120, nil)
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %s", contents, err.Error())
+	}
+	b := matrix.Bounds()
+	img := image.NewGray(image.Rect(0, 0, (b.Dx()+20)*2, b.Dy()))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if matrix.Get(x, y) {
+				img.Set(20+2*x, y, color.Black)
+				img.Set(21+2*x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
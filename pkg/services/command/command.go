@@ -20,9 +20,12 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/rs/zerolog"
@@ -50,6 +53,15 @@ type CommandServer struct {
 	config    *CommandConfig
 	osName    string
 	osVersion string
+
+	jobsLock sync.Mutex
+	jobs     map[string]*jobOutput // jobs holds the recent command outputs by resource URI.
+	jobOrder []string
+	nextJob  int
+
+	watchesLock sync.Mutex
+	watches     map[string]*commandWatch // watches holds the running command_watch watches by ID.
+	watchSeq    int
 }
 
 // NewCommandServer creates a new CommandServer with the given allowed commands.
@@ -94,14 +106,74 @@ func (cs *CommandServer) Init() error {
 		HandlerFunc: cs.handlePrompt,
 	}
 	cs.AddPrompt(pe)
-	cs.AddTool(mcp.NewTool(
+	cs.AddStructuredTool(mcp.NewTool(
 		"execute_command",
 		mcp.WithDescription("Execute a named command.Only support command execution on macOS and will strictly follow safety guidelines, ensuring that commands are safe and secure"),
 		mcp.WithString("command",
 			mcp.Description("The command to execute"),
 			mcp.Required(),
 		),
-	), cs.handleExecuteCommand)
+		mcp.WithBoolean("background",
+			mcp.Description("Run the command as a background job and return the job at once, for long commands. Poll jobs_status and read the output with jobs_result"),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Directory to run the command in, relative to the working directory (default: the working directory)"),
+		),
+		mcp.WithBoolean("toolchain",
+			mcp.Description("Run the command with the environment of the project toolchains found in dir, such as its virtualenv, nvm node version or Go vendor directory, see detect_toolchains (default: false)"),
+		),
+		abstract.WithDryRunArgument(),
+	), executeCommandSchema, cs.handleExecuteCommand)
+	cs.AddTool(mcp.NewTool(
+		"detect_toolchains",
+		mcp.WithDescription("Detect the toolchains of a project from files such as go.mod, package.json, requirements.txt and Cargo.toml, and snapshot the environment execute_command uses with toolchain set: the PATH entries, variables and the version of each toolchain"),
+		mcp.WithString("dir",
+			mcp.Description("Directory of the project, relative to the working directory (default: the working directory)"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), cs.handleDetectToolchains)
+	cs.AddTool(mcp.NewTool(
+		"command_watch",
+		mcp.WithDescription("Watch the output of a command and get a notification when it matches a regular expression, such as BUILD SUCCESS or error, instead of polling. The command runs every interval seconds, or once with follow for a long-running command such as a build or a log tail. action list shows the running watches and stop ends one"),
+		mcp.WithString("action",
+			mcp.Description("start (default), list or stop"),
+			mcp.Enum("start", "list", "stop"),
+		),
+		mcp.WithString("command",
+			mcp.Description("The command to watch, for start"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Go regular expression matched against each line of the output, for start"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Match pattern regardless of case"),
+		),
+		mcp.WithBoolean("follow",
+			mcp.Description("Run the command once and match its output as it is written, instead of running it every interval"),
+		),
+		mcp.WithNumber("interval",
+			mcp.Description("Seconds between runs of the command (default: 30)"),
+			mcp.Min(1),
+		),
+		mcp.WithBoolean("once",
+			mcp.Description("Stop the watch after the first match (default: true)"),
+		),
+		mcp.WithNumber("duration",
+			mcp.Description("Seconds after which the watch stops (default: 3600)"),
+			mcp.Min(1),
+			mcp.Max(watchDurationMax),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Directory to run the command in, relative to the working directory (default: the working directory)"),
+		),
+		mcp.WithBoolean("toolchain",
+			mcp.Description("Run the command with the environment of the project toolchains found in dir (default: false)"),
+		),
+		mcp.WithString("id",
+			mcp.Description("ID of the watch to stop, for stop"),
+		),
+		abstract.WithDryRunArgument(),
+	), cs.handleWatch)
 	return err
 }
 
@@ -128,19 +200,123 @@ func (cs *CommandServer) handleExecuteCommand(ctx context.Context, request mcp.C
 		return mcp.NewToolResultError(fmt.Errorf("command must be a string").Error()), nil
 	}
 
-	// Check if the command is allowed
-	if !cs.isAllowedCommand(command) {
-		cs.Logger.Err(ErrCommandNotAllowed).Str("command", command).Msgf("If you want to allow this command, add it to %s", filepath.Join(cs.MlConfig().BasePath, "config", cs.MlConfig().ConfigFile))
+	dir := cs.commandDir(ctx, abstract.GetString(args, "dir", ""))
+	var env []string
+	if abstract.GetBool(args, "toolchain", false) {
+		env = ToolchainEnv(os.Environ(), DetectToolchains(dirOrCwd(dir), os.Getenv))
+	}
+
+	if abstract.IsDryRun(ctx, request) {
+		if dir == "" {
+			dir = "the working directory of MoLing"
+		}
+		if !cs.isAllowedCommand(command) {
+			return abstract.NewDryRunResult("would ask the user to allow '%s', which is not in the allowed list, and run it in %s", command, dir), nil
+		}
+		return abstract.NewDryRunResult("would run '%s' in %s", command, dir), nil
+	}
+
+	if !cs.allowCommand(ctx, command) {
 		return mcp.NewToolResultError(fmt.Sprintf("Error: Command '%s' is not allowed", command)), nil
 	}
 
 	// Execute the command
-	output, err := ExecCommand(command)
+	cs.ReportProgress(ctx, 0, 1, fmt.Sprintf("running %s", command))
+	output, err := ExecCommandWithEnv(ctx, dir, env, command)
+	cs.ReportProgress(ctx, 1, 1, "command finished")
+	uri := cs.publishJob(command, output, err)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error executing command: %v", err)), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Error executing command: %v (output: %s)", err, uri)), nil
 	}
 
-	return mcp.NewToolResultText(output), nil
+	return abstract.NewStructuredResult(output, ExecuteCommandOutput{Command: command, Output: output, URI: uri}), nil
+}
+
+// workDir returns the directory commands of the tool call handled in ctx run in: with use_roots, the first workspace
+// root of the client, otherwise the working directory of MoLing.
+func (cs *CommandServer) workDir(ctx context.Context) string {
+	if !cs.config.UseRoots {
+		return ""
+	}
+	roots, err := cs.ClientRoots(ctx)
+	if err != nil {
+		cs.Logger.Warn().Err(err).Msg("failed to get the roots of the client")
+		return ""
+	}
+	if len(roots) == 0 {
+		return ""
+	}
+	return roots[0]
+}
+
+// commandDir returns the directory of a command: dir, relative to the working directory of the tool call.
+func (cs *CommandServer) commandDir(ctx context.Context, dir string) string {
+	if dir == "" {
+		return cs.workDir(ctx)
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(dirOrCwd(cs.workDir(ctx)), dir)
+}
+
+// dirOrCwd returns dir, or the working directory of MoLing if it is empty.
+func dirOrCwd(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return wd
+	}
+	return "."
+}
+
+// ToolchainSnapshot is the result of detect_toolchains.
+type ToolchainSnapshot struct {
+	Dir        string              `json:"dir"`
+	Toolchains []ToolchainVersions `json:"toolchains"`
+}
+
+// ToolchainVersions is a toolchain with the version its command reports in the environment of the project.
+type ToolchainVersions struct {
+	Toolchain
+	Installed string `json:"installed"` // Installed is the output of the version command, or why it could not run.
+}
+
+// handleDetectToolchains detects the toolchains of a project and reports the versions they run with.
+func (cs *CommandServer) handleDetectToolchains(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dir := dirOrCwd(cs.commandDir(ctx, abstract.GetString(request.GetArguments(), "dir", "")))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "%s is not a directory", dir), nil
+	}
+	toolchains := DetectToolchains(dir, os.Getenv)
+	env := ToolchainEnv(os.Environ(), toolchains)
+	snapshot := ToolchainSnapshot{Dir: dir, Toolchains: []ToolchainVersions{}}
+	for _, tc := range toolchains {
+		snapshot.Toolchains = append(snapshot.Toolchains, ToolchainVersions{Toolchain: tc, Installed: toolchainVersion(ctx, dir, env, tc)})
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to encode the toolchains: %s", err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// allowCommand reports whether the command may run: if it is allowed, or if the user allows it once.
+func (cs *CommandServer) allowCommand(ctx context.Context, command string) bool {
+	if cs.isAllowedCommand(command) {
+		return true
+	}
+	allowed, err := cs.Confirm(ctx, fmt.Sprintf("The command '%s' is not in the allowed list. Run it once?", command))
+	if err != nil && !errors.Is(err, abstract.ErrElicitationUnavailable) {
+		cs.Logger.Warn().Err(err).Str("command", command).Msg("failed to ask the user to allow the command")
+	}
+	if !allowed {
+		cs.Logger.Err(ErrCommandNotAllowed).Str("command", command).Msgf("If you want to allow this command, add it to %s", filepath.Join(cs.MlConfig().BasePath, "config", cs.MlConfig().ConfigFile))
+		return false
+	}
+	cs.Logger.Warn().Str("command", command).Msg("the user allowed the command once")
+	return true
 }
 
 // isAllowedCommand checks if the command is allowed based on the configuration.
@@ -195,7 +371,7 @@ func (cs *CommandServer) Name() comm.MoLingServerType {
 }
 
 func (cs *CommandServer) Close() error {
-	// Cancel the context to stop the browser
+	cs.stopWatches()
 	cs.Logger.Debug().Msg("CommandServer closed")
 	return nil
 }
@@ -54,6 +54,13 @@ You are a powerful terminal command assistant capable of executing various comma
     - Terminate specified processes
     - Adjust process priorities
 
+6. **Project Toolchains**:
+    - Detect the Go, Node.js, Python and Rust toolchains of a project with detect_toolchains
+    - Run build and test commands in the project with execute_command, dir and toolchain set, so that its virtualenv, nvm node version and Go vendor directory are used
+
+7. **Watching Output**:
+    - Use command_watch to be notified when the output of a command matches a pattern, such as a build finishing or an error in a log, rather than running the command again and again
+
 Before executing any actions, please provide clear instructions, including:
 - The specific command you want to execute
 - Required parameters (file paths, directory names, etc.)
@@ -70,6 +77,8 @@ type CommandConfig struct {
 	prompt          string
 	AllowedCommand  string `json:"allowed_command"` // AllowedCommand is a list of allowed command. split by comma. e.g. ls,cat,echo
 	allowedCommands []string
+	UseRoots        bool `json:"use_roots"`   // UseRoots runs commands in the first workspace root of the client, if the client declares any.
+	MaxWatches      int  `json:"max_watches"` // MaxWatches is how many command_watch watches can run at once.
 }
 
 var (
@@ -89,6 +98,8 @@ func NewCommandConfig() *CommandConfig {
 	return &CommandConfig{
 		allowedCommands: allowedCmdDefault,
 		AllowedCommand:  strings.Join(allowedCmdDefault, ","),
+		UseRoots:        true,
+		MaxWatches:      5,
 	}
 }
 
@@ -108,6 +119,9 @@ func (cc *CommandConfig) Check() error {
 	if cnt <= 0 {
 		return fmt.Errorf("no allowed commands specified")
 	}
+	if cc.MaxWatches < 1 {
+		return fmt.Errorf("max_watches must be at least 1, got %d", cc.MaxWatches)
+	}
 	if cc.PromptFile != "" {
 		read, err := os.ReadFile(cc.PromptFile)
 		if err != nil {
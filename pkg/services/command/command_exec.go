@@ -27,16 +27,36 @@ import (
 
 // ExecCommand executes a command and returns its output.
 func ExecCommand(command string) (string, error) {
+	return ExecCommandContext(context.Background(), command)
+}
+
+// ExecCommandContext executes a command and returns its output. The command is killed when ctx is cancelled.
+func ExecCommandContext(ctx context.Context, command string) (string, error) {
+	return ExecCommandInDir(ctx, "", command)
+}
+
+// ExecCommandInDir executes a command in dir and returns its output. An empty dir uses the working directory of MoLing.
+func ExecCommandInDir(ctx context.Context, dir, command string) (string, error) {
+	return ExecCommandWithEnv(ctx, dir, nil, command)
+}
+
+// ExecCommandWithEnv executes a command in dir with the environment env, that of MoLing if nil, and returns its output.
+func ExecCommandWithEnv(ctx context.Context, dir string, env []string, command string) (string, error) {
 	var cmd *exec.Cmd
-	ctx, cfunc := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cfunc := context.WithTimeout(ctx, time.Second*10)
 	defer cfunc()
-	cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	cmd = shellCommand(ctx, command)
+	cmd.Dir = dir
+	cmd.Env = env
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		switch {
 		case errors.Is(err, exec.ErrNotFound):
 			// 命令未找到
 			return "", errors.New("command not found")
+		case errors.Is(ctx.Err(), context.Canceled):
+			// 请求被取消
+			return string(output), errors.New("command cancelled")
 		case errors.Is(ctx.Err(), context.DeadlineExceeded):
 			// 超时时仅返回输出，不返回错误
 			return string(output), nil
@@ -47,3 +67,8 @@ func ExecCommand(command string) (string, error) {
 
 	return string(output), nil
 }
+
+// shellCommand returns the command that runs command with the shell. It is killed when ctx is cancelled.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
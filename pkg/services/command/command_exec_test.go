@@ -18,13 +18,22 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+
 	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
 )
 
 // MockCommandServer is a mock implementation of CommandServer for testing purposes.
@@ -91,6 +100,114 @@ func TestAllowCmd(t *testing.T) {
 	t.Log("Command is allowed:", cmd)
 }
 
+func TestCommandJobs(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	srv, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	cs := srv.(*CommandServer)
+
+	first := cs.publishJob("echo first", "first\n", nil)
+	for i := 0; i < maxJobOutputs; i++ {
+		cs.publishJob(fmt.Sprintf("echo %d", i), fmt.Sprintf("%d\n", i), nil)
+	}
+	last := cs.publishJob("false", "", errors.New("exit status 1"))
+	if len(cs.Resources()) != maxJobOutputs {
+		t.Fatalf("Expected %d job outputs, got %d", maxJobOutputs, len(cs.Resources()))
+	}
+
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = first
+	if _, err := cs.handleReadJob(context.Background(), request); err == nil {
+		t.Errorf("Expected the oldest job %s to be dropped", first)
+	}
+	request.Params.URI = last
+	contents, err := cs.handleReadJob(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed to read job output: %v", err)
+	}
+	text := contents[0].(mcp.TextResourceContents).Text
+	if !strings.HasPrefix(text, "$ false\n") || !strings.Contains(text, "[error: exit status 1]") {
+		t.Errorf("Unexpected job output %q", text)
+	}
+}
+
+func TestExecuteCommandStructured(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	srv, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err := srv.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs := srv.(*CommandServer)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"command": "echo structured"}
+	res, _ := cs.handleExecuteCommand(context.Background(), request)
+	if res.IsError || len(res.Content) != 2 {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+	var output ExecuteCommandOutput
+	if err := json.Unmarshal([]byte(res.Content[1].(mcp.TextContent).Text), &output); err != nil {
+		t.Fatalf("Failed to parse structured content: %v", err)
+	}
+	if output.Command != "echo structured" || output.Output != "structured\n" || !strings.HasPrefix(output.URI, JobURIPrefix) {
+		t.Errorf("Unexpected structured content %+v", output)
+	}
+}
+
+// rootsRequester lists a single workspace root.
+type rootsRequester struct {
+	root string
+}
+
+func (r *rootsRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == abstract.RootsCapability
+}
+
+func (r *rootsRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return json.Marshal(map[string]any{"roots": []map[string]string{{"uri": "file://" + r.root}}})
+}
+
+func TestExecuteCommandInRoot(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	srv, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err := srv.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs := srv.(*CommandServer)
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.SetClientRequester(&rootsRequester{root: root})
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"command": "pwd"}
+	res, _ := cs.handleExecuteCommand(context.Background(), request)
+	if res.IsError || strings.TrimSpace(res.Content[0].(mcp.TextContent).Text) != root {
+		t.Fatalf("Expected the command to run in %s, got %+v", root, res.Content[0])
+	}
+
+	cs.config.UseRoots = false
+	if dir := cs.workDir(context.Background()); dir != "" {
+		t.Errorf("Expected the working directory of MoLing without use_roots, got %s", dir)
+	}
+}
+
 // 将 struct 转换为 map
 func StructToMap(obj any) map[string]any {
 	result := make(map[string]any)
@@ -118,3 +235,154 @@ func StructToMap(obj any) map[string]any {
 	}
 	return result
 }
+
+func TestDetectToolchains(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/app\n\ngo 1.22\n\ntoolchain go1.24.1\n")
+	write("vendor/modules.txt", "")
+	write("package.json", "{}")
+	write(".nvmrc", "v20\n")
+	write("node_modules/.bin/eslint", "")
+	write("requirements.txt", "requests\n")
+	write(".venv/pyvenv.cfg", "home = /usr/bin\n")
+	write(".venv/"+binDir()+"/python", "")
+	nodeBin := filepath.Join(home, ".nvm", "versions", "node", "v20.11.1", "bin")
+	if err := os.MkdirAll(nodeBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".nvm", "versions", "node", "v200.0.0", "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	getenv := func(key string) string {
+		if key == "HOME" {
+			return home
+		}
+		return ""
+	}
+	toolchains := DetectToolchains(dir, getenv)
+	byName := make(map[string]Toolchain)
+	for _, tc := range toolchains {
+		byName[tc.Name] = tc
+	}
+	if len(toolchains) != 3 {
+		t.Fatalf("expected go, node and python, got %+v", toolchains)
+	}
+	if tc := byName["go"]; tc.Version != "1.24.1" || tc.Env["GOFLAGS"] != "-mod=vendor" {
+		t.Fatalf("unexpected go toolchain %+v", tc)
+	}
+	if tc := byName["node"]; tc.Version != "20" || len(tc.Path) != 2 || tc.Path[1] != nodeBin {
+		t.Fatalf("node 20 must be taken from nvm, not node 200, got %+v", tc)
+	}
+	if tc := byName["python"]; tc.Env["VIRTUAL_ENV"] != filepath.Join(dir, ".venv") || len(tc.Notes) != 0 {
+		t.Fatalf("the virtualenv must be activated, got %+v", tc)
+	}
+
+	env := ToolchainEnv([]string{"PATH=/usr/bin", "GOFLAGS=-mod=mod", "LANG=C"}, toolchains)
+	var path string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			path = v
+		}
+	}
+	if !strings.HasPrefix(path, filepath.Join(dir, "node_modules", ".bin")) || !strings.HasSuffix(path, string(os.PathListSeparator)+"/usr/bin") {
+		t.Fatalf("the toolchain directories must come first in PATH, got %s", path)
+	}
+	if !slices.Contains(env, "GOFLAGS=-mod=vendor") || slices.Contains(env, "GOFLAGS=-mod=mod") || !slices.Contains(env, "LANG=C") {
+		t.Fatalf("unexpected environment %v", env)
+	}
+
+	if got := DetectToolchains(home, getenv); len(got) != 0 {
+		t.Fatalf("a directory without markers has no toolchain, got %+v", got)
+	}
+}
+
+func TestCommandWatch(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	srv, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err := srv.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs := srv.(*CommandServer)
+	messages := make(chan map[string]any, 10)
+	cs.SetNotifyFunc(func(method string, params map[string]any) {
+		messages <- params["data"].(map[string]any)
+	})
+	next := func() map[string]any {
+		select {
+		case m := <-messages:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a notification")
+			return nil
+		}
+	}
+	call := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		res, _ := cs.handleWatch(context.Background(), request)
+		return res
+	}
+
+	// A followed command is matched line by line and killed after the first match.
+	res := call(map[string]any{"command": "echo starting; sleep 0.2; echo BUILD SUCCESS; sleep 30", "pattern": "BUILD (SUCCESS|FAILURE)", "follow": true})
+	if res.IsError {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+	if m := next(); m["line"] != "BUILD SUCCESS" || m["watch_id"] != "w1" {
+		t.Errorf("Unexpected match notification %v", m)
+	}
+	if m := next(); !strings.Contains(m["message"].(string), "ended: it matched") {
+		t.Errorf("Unexpected end notification %v", m)
+	}
+
+	// A polled command is notified when it starts to match.
+	file := filepath.Join(t.TempDir(), "status")
+	if err := os.WriteFile(file, []byte("running\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res = call(map[string]any{"command": "cat " + file, "pattern": "error", "ignore_case": true, "interval": 1, "once": false})
+	if res.IsError {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := os.WriteFile(file, []byte("running\nERROR: disk full\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m := next(); m["line"] != "ERROR: disk full" || m["watch_id"] != "w2" {
+		t.Errorf("Unexpected match notification %v", m)
+	}
+	if watches := cs.runningWatches(); len(watches) != 1 || watches[0].Matches != 1 {
+		t.Errorf("Expected the watch to keep running, got %+v", watches)
+	}
+	if res = call(map[string]any{"action": "stop", "id": "w2"}); res.IsError {
+		t.Errorf("Unexpected result %+v", res)
+	}
+	select {
+	case m := <-messages:
+		t.Errorf("Expected a stopped watch to end quietly, got %v", m)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	if res = call(map[string]any{"command": "rm -rf /tmp/nothing", "pattern": "x"}); !res.IsError {
+		t.Errorf("Expected a command that is not allowed to be refused, got %+v", res)
+	}
+	if res = call(map[string]any{"command": "echo", "pattern": "("}); !res.IsError {
+		t.Errorf("Expected an invalid pattern to be refused, got %+v", res)
+	}
+}
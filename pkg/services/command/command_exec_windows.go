@@ -20,13 +20,35 @@
 package command
 
 import (
+	"context"
 	"os/exec"
 )
 
 // ExecCommand executes a command and returns its output.
 func ExecCommand(command string) (string, error) {
+	return ExecCommandContext(context.Background(), command)
+}
+
+// ExecCommandContext executes a command and returns its output. The command is killed when ctx is cancelled.
+func ExecCommandContext(ctx context.Context, command string) (string, error) {
+	return ExecCommandInDir(ctx, "", command)
+}
+
+// ExecCommandInDir executes a command in dir and returns its output. An empty dir uses the working directory of MoLing.
+func ExecCommandInDir(ctx context.Context, dir, command string) (string, error) {
+	return ExecCommandWithEnv(ctx, dir, nil, command)
+}
+
+// ExecCommandWithEnv executes a command in dir with the environment env, that of MoLing if nil, and returns its output.
+func ExecCommandWithEnv(ctx context.Context, dir string, env []string, command string) (string, error) {
 	var cmd *exec.Cmd
-	cmd = exec.Command("cmd", "/C", command)
+	cmd = shellCommand(ctx, command)
+	cmd.Dir = dir
+	cmd.Env = env
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
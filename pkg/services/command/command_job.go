@@ -0,0 +1,101 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// JobURIPrefix is the URI prefix of the command outputs published as resources.
+	JobURIPrefix  = "moling://command/jobs/"
+	maxJobOutputs = 20
+)
+
+// jobOutput is the recorded result of one execute_command call.
+type jobOutput struct {
+	command  string
+	output   string
+	err      error
+	finished time.Time
+}
+
+// text renders the job the way a terminal would show it.
+func (j *jobOutput) text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "$ %s\n%s", j.command, j.output)
+	if j.err != nil {
+		fmt.Fprintf(&sb, "\n[error: %s]", j.err.Error())
+	}
+	fmt.Fprintf(&sb, "\n[finished at %s]", j.finished.Format(time.RFC3339))
+	return sb.String()
+}
+
+// publishJob records the output of a command as a resource and returns its URI.
+// Only the latest maxJobOutputs jobs are kept.
+func (cs *CommandServer) publishJob(command, output string, err error) string {
+	cs.jobsLock.Lock()
+	if cs.jobs == nil {
+		cs.jobs = make(map[string]*jobOutput)
+	}
+	cs.nextJob++
+	id := cs.nextJob
+	uri := fmt.Sprintf("%s%d", JobURIPrefix, id)
+	cs.jobs[uri] = &jobOutput{command: command, output: output, err: err, finished: time.Now()}
+	cs.jobOrder = append(cs.jobOrder, uri)
+	var dropped []string
+	if n := len(cs.jobOrder) - maxJobOutputs; n > 0 {
+		dropped = cs.jobOrder[:n]
+		cs.jobOrder = append([]string(nil), cs.jobOrder[n:]...)
+		for _, old := range dropped {
+			delete(cs.jobs, old)
+		}
+	}
+	cs.jobsLock.Unlock()
+
+	cs.AddResource(mcp.NewResource(uri, fmt.Sprintf("job %d", id),
+		mcp.WithResourceDescription(fmt.Sprintf("Output of: %s", command)),
+		mcp.WithMIMEType("text/plain"),
+	), cs.handleReadJob)
+	for _, old := range dropped {
+		cs.RemoveResource(old)
+	}
+	return uri
+}
+
+// handleReadJob returns the output of a recorded command.
+func (cs *CommandServer) handleReadJob(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	cs.jobsLock.Lock()
+	job, ok := cs.jobs[uri]
+	cs.jobsLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("command job %s not found, only the latest %d jobs are kept", uri, maxJobOutputs)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "text/plain",
+			Text:     job.text(),
+		},
+	}, nil
+}
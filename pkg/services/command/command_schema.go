@@ -0,0 +1,36 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import "encoding/json"
+
+// ExecuteCommandOutput is the structured result of execute_command.
+type ExecuteCommandOutput struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	URI     string `json:"uri"` // URI is the job resource that keeps the output.
+}
+
+var executeCommandSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"command": {"type": "string"},
+		"output": {"type": "string"},
+		"uri": {"type": "string"}
+	},
+	"required": ["command", "output", "uri"]
+}`)
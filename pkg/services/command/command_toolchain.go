@@ -0,0 +1,251 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Toolchain is a language toolchain a project uses, found by its marker files, with the environment its commands
+// need.
+type Toolchain struct {
+	Name    string            `json:"name"`              // Name is go, node, python or rust.
+	Markers []string          `json:"markers"`           // Markers are the files that show the toolchain is used.
+	Version string            `json:"version,omitempty"` // Version is the version the project asks for, if it pins one.
+	Path    []string          `json:"path,omitempty"`    // Path are the directories put in front of PATH.
+	Env     map[string]string `json:"env,omitempty"`     // Env are the variables set for the commands.
+	Command string            `json:"command"`           // Command prints the version of the toolchain.
+	Notes   []string          `json:"notes,omitempty"`   // Notes explain what could not be set up, such as a missing virtualenv.
+}
+
+// toolchainMarkers are the files of each toolchain, the first found in a directory are its markers.
+var toolchainMarkers = []struct {
+	name    string
+	files   []string
+	command string
+}{
+	{"go", []string{"go.mod", "go.work"}, "go version"},
+	{"node", []string{"package.json"}, "node --version"},
+	{"python", []string{"pyproject.toml", "requirements.txt", "setup.py", "Pipfile"}, "python --version"},
+	{"rust", []string{"Cargo.toml"}, "cargo --version"},
+}
+
+// binDir is the directory of the programs of a virtualenv.
+func binDir() string {
+	if runtime.GOOS == "windows" {
+		return "Scripts"
+	}
+	return "bin"
+}
+
+// isDir reports whether path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// firstLine returns the first non-empty line of a file, "" if it cannot be read.
+func firstLine(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// DetectToolchains finds the toolchains of the project in dir and the environment their commands need. getenv reads
+// the environment of MoLing, such as HOME, NVM_DIR and PYENV_ROOT.
+func DetectToolchains(dir string, getenv func(string) string) []Toolchain {
+	home := getenv("HOME")
+	if home == "" {
+		home = getenv("USERPROFILE")
+	}
+	var found []Toolchain
+	for _, m := range toolchainMarkers {
+		tc := Toolchain{Name: m.name, Command: m.command, Env: map[string]string{}}
+		for _, f := range m.files {
+			if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+				tc.Markers = append(tc.Markers, f)
+			}
+		}
+		if len(tc.Markers) == 0 {
+			continue
+		}
+		switch m.name {
+		case "go":
+			detectGo(dir, &tc)
+		case "node":
+			detectNode(dir, home, getenv, &tc)
+		case "python":
+			detectPython(dir, home, getenv, &tc)
+		case "rust":
+			if cargo := filepath.Join(home, ".cargo", "bin"); home != "" && isDir(cargo) {
+				tc.Path = append(tc.Path, cargo)
+			}
+		}
+		if len(tc.Env) == 0 {
+			tc.Env = nil
+		}
+		found = append(found, tc)
+	}
+	return found
+}
+
+// detectGo reads the Go version of go.mod and uses the vendor directory if there is one.
+func detectGo(dir string, tc *Toolchain) {
+	if f, err := os.Open(filepath.Join(dir, "go.mod")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && (fields[0] == "go" && tc.Version == "" || fields[0] == "toolchain") {
+				tc.Version = strings.TrimPrefix(fields[1], "go")
+			}
+		}
+		_ = f.Close()
+	}
+	if isDir(filepath.Join(dir, "vendor")) {
+		tc.Env["GOFLAGS"] = "-mod=vendor"
+	}
+}
+
+// detectNode uses the node version of .nvmrc or .node-version from nvm, and the programs of node_modules.
+func detectNode(dir, home string, getenv func(string) string, tc *Toolchain) {
+	for _, f := range []string{".nvmrc", ".node-version"} {
+		if v := firstLine(filepath.Join(dir, f)); v != "" {
+			tc.Version = strings.TrimPrefix(v, "v")
+			break
+		}
+	}
+	if bin := filepath.Join(dir, "node_modules", ".bin"); isDir(bin) {
+		tc.Path = append(tc.Path, bin)
+	}
+	if tc.Version == "" {
+		return
+	}
+	nvm := getenv("NVM_DIR")
+	if nvm == "" && home != "" {
+		nvm = filepath.Join(home, ".nvm")
+	}
+	// nvm installs versions as v20.11.1, .nvmrc may name only v20 or 20.11
+	matches, _ := filepath.Glob(filepath.Join(nvm, "versions", "node", "v"+tc.Version+"*"))
+	sort.Strings(matches)
+	for i := len(matches) - 1; i >= 0; i-- {
+		name := filepath.Base(matches[i])
+		if name == "v"+tc.Version || strings.HasPrefix(name, "v"+tc.Version+".") {
+			tc.Path = append(tc.Path, filepath.Join(matches[i], "bin"))
+			return
+		}
+	}
+	tc.Notes = append(tc.Notes, "node "+tc.Version+" is not installed with nvm, the node on PATH is used")
+}
+
+// detectPython activates the virtualenv of the project, or the pyenv version of .python-version.
+func detectPython(dir, home string, getenv func(string) string, tc *Toolchain) {
+	if v := firstLine(filepath.Join(dir, ".python-version")); v != "" {
+		tc.Version = v
+	}
+	for _, venv := range []string{".venv", "venv", "env"} {
+		path := filepath.Join(dir, venv)
+		if isDir(filepath.Join(path, binDir())) && fileExists(filepath.Join(path, "pyvenv.cfg")) {
+			tc.Path = append(tc.Path, filepath.Join(path, binDir()))
+			tc.Env["VIRTUAL_ENV"] = path
+			return
+		}
+	}
+	if tc.Version != "" {
+		root := getenv("PYENV_ROOT")
+		if root == "" && home != "" {
+			root = filepath.Join(home, ".pyenv")
+		}
+		if bin := filepath.Join(root, "versions", tc.Version, "bin"); isDir(bin) {
+			tc.Path = append(tc.Path, bin)
+			return
+		}
+		tc.Notes = append(tc.Notes, "python "+tc.Version+" is not installed with pyenv")
+	}
+	tc.Notes = append(tc.Notes, "no virtualenv found in .venv, venv or env, packages are installed for the python on PATH")
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ToolchainEnv returns the environment base with the variables and PATH entries of the toolchains.
+func ToolchainEnv(base []string, toolchains []Toolchain) []string {
+	vars := make(map[string]string)
+	var path []string
+	for _, tc := range toolchains {
+		for k, v := range tc.Env {
+			vars[k] = v
+		}
+		path = append(path, tc.Path...)
+	}
+	env := make([]string, 0, len(base)+len(vars)+1)
+	pathSet := false
+	for _, kv := range base {
+		k, v, _ := strings.Cut(kv, "=")
+		if _, ok := vars[k]; ok {
+			continue
+		}
+		if strings.EqualFold(k, "PATH") && len(path) > 0 {
+			kv = k + "=" + strings.Join(append(path, v), string(os.PathListSeparator))
+			pathSet = true
+		}
+		env = append(env, kv)
+	}
+	if !pathSet && len(path) > 0 {
+		env = append(env, "PATH="+strings.Join(path, string(os.PathListSeparator)))
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+vars[k])
+	}
+	return env
+}
+
+// toolchainVersion runs the version command of a toolchain in its environment, for the snapshot of detect_toolchains.
+func toolchainVersion(ctx context.Context, dir string, env []string, tc Toolchain) string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	output, err := ExecCommandWithEnv(ctx, dir, env, tc.Command)
+	output = strings.TrimSpace(output)
+	if err != nil && output == "" {
+		return "unavailable: " + err.Error()
+	}
+	if line, _, ok := strings.Cut(output, "\n"); ok {
+		return line
+	}
+	return output
+}
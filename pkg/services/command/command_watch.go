@@ -0,0 +1,358 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// watchIntervalDefault is how often command_watch runs a command, in seconds.
+	watchIntervalDefault = 30
+	// watchDurationDefault and watchDurationMax bound how long a watch runs, in seconds.
+	watchDurationDefault = 3600
+	watchDurationMax     = 24 * 3600
+	// watchLineMax is the length to which matched lines are cut in notifications.
+	watchLineMax = 500
+)
+
+// commandWatch runs a command on an interval, or follows a long-running one, and notifies the clients when its
+// output matches a pattern.
+type commandWatch struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	Pattern   string    `json:"pattern"`
+	Dir       string    `json:"dir,omitempty"`
+	Follow    bool      `json:"follow"`
+	Interval  int       `json:"interval,omitempty"` // Interval is the seconds between runs, unless the command is followed.
+	Once      bool      `json:"once"`
+	Started   time.Time `json:"started_at"`
+	Ends      time.Time `json:"ends_at"`
+	Runs      int       `json:"runs"`
+	Matches   int       `json:"matches"`
+	LastMatch string    `json:"last_match,omitempty"`
+	re        *regexp.Regexp
+	env       []string
+	cancel    context.CancelFunc
+}
+
+// startWatch starts a watch that runs until its command exits, it matched with once, it expires or it is stopped.
+// It returns a copy of the watch as it started.
+func (cs *CommandServer) startWatch(w *commandWatch, duration time.Duration) (commandWatch, error) {
+	cs.watchesLock.Lock()
+	defer cs.watchesLock.Unlock()
+	if cs.watches == nil {
+		cs.watches = make(map[string]*commandWatch)
+	}
+	if len(cs.watches) >= cs.config.MaxWatches {
+		return commandWatch{}, fmt.Errorf("too many running watches (%d), stop some first", cs.config.MaxWatches)
+	}
+	cs.watchSeq++
+	w.ID = "w" + strconv.Itoa(cs.watchSeq)
+	w.Started = time.Now()
+	w.Ends = w.Started.Add(duration)
+	ctx, cancel := context.WithDeadline(cs.Ctx(), w.Ends)
+	w.cancel = cancel
+	cs.watches[w.ID] = w
+	started := *w
+	go func() {
+		defer cancel()
+		var reason string
+		if w.Follow {
+			reason = cs.followWatch(ctx, w)
+		} else {
+			reason = cs.pollWatch(ctx, w)
+		}
+		cs.watchesLock.Lock()
+		_, running := cs.watches[w.ID]
+		delete(cs.watches, w.ID)
+		cs.watchesLock.Unlock()
+		// A watch removed by stopWatch was stopped on purpose and ends quietly.
+		if running {
+			cs.notifyWatch(w, fmt.Sprintf("watch %s of '%s' ended: %s", w.ID, w.Command, reason), "")
+		}
+	}()
+	return started, nil
+}
+
+// pollWatch runs the command of w every interval. A match is notified when the previous run did not match, so a
+// condition that stays true is reported once.
+func (cs *CommandServer) pollWatch(ctx context.Context, w *commandWatch) string {
+	ticker := time.NewTicker(time.Duration(w.Interval) * time.Second)
+	defer ticker.Stop()
+	matched := false
+	for {
+		output, err := ExecCommandWithEnv(ctx, w.Dir, w.env, w.Command)
+		if ctx.Err() != nil {
+			return watchEndReason(ctx)
+		}
+		line, found := firstMatch(w.re, output)
+		cs.watchesLock.Lock()
+		w.Runs++
+		if found && !matched {
+			w.Matches++
+			w.LastMatch = line
+		}
+		cs.watchesLock.Unlock()
+		if err != nil {
+			cs.Logger.Warn().Err(err).Str("watch", w.ID).Msg("watched command failed")
+		}
+		if found && !matched {
+			cs.notifyWatch(w, fmt.Sprintf("watch %s: the output of '%s' matches %q", w.ID, w.Command, w.Pattern), line)
+			if w.Once {
+				return "it matched"
+			}
+		}
+		matched = found
+		select {
+		case <-ctx.Done():
+			return watchEndReason(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// followWatch runs the command of w once and notifies every line of its output that matches.
+func (cs *CommandServer) followWatch(ctx context.Context, w *commandWatch) string {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := shellCommand(ctx, w.Command)
+	cmd.Dir = w.Dir
+	cmd.Env = w.env
+	// Without a delay, the children of the shell can keep the output open after the shell was killed.
+	cmd.WaitDelay = time.Second
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return fmt.Sprintf("it could not start: %v", err)
+	}
+	untrack := cs.TrackProcess(cmd)
+	defer untrack()
+	waited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		_ = pw.Close()
+		waited <- err
+	}()
+	cs.watchesLock.Lock()
+	w.Runs = 1
+	cs.watchesLock.Unlock()
+
+	reason := ""
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !w.re.MatchString(line) {
+			continue
+		}
+		line = cutLine(line)
+		cs.watchesLock.Lock()
+		w.Matches++
+		w.LastMatch = line
+		cs.watchesLock.Unlock()
+		cs.notifyWatch(w, fmt.Sprintf("watch %s: the output of '%s' matches %q", w.ID, w.Command, w.Pattern), line)
+		if w.Once {
+			reason = "it matched"
+			cancel()
+			break
+		}
+	}
+	// The rest of the output is drained so the command does not block on a full pipe while it is killed.
+	go func() { _, _ = io.Copy(io.Discard, pr) }()
+	err := <-waited
+	switch {
+	case reason != "":
+		return reason
+	case ctx.Err() != nil:
+		return watchEndReason(ctx)
+	case err != nil:
+		return fmt.Sprintf("the command failed: %v", err)
+	default:
+		return "the command exited"
+	}
+}
+
+// watchEndReason tells why the context of a watch ended.
+func watchEndReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "its duration elapsed"
+	}
+	return "it was stopped"
+}
+
+// firstMatch returns the first line of output that matches re.
+func firstMatch(re *regexp.Regexp, output string) (string, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		if re.MatchString(line) {
+			return cutLine(strings.TrimRight(line, "\r")), true
+		}
+	}
+	return "", false
+}
+
+// cutLine shortens a line for a notification.
+func cutLine(line string) string {
+	if len(line) > watchLineMax {
+		return line[:watchLineMax] + "…"
+	}
+	return line
+}
+
+// notifyWatch tells the connected clients about a watch, with the matched line if any.
+func (cs *CommandServer) notifyWatch(w *commandWatch, message, line string) {
+	cs.Logger.Info().Str("watch", w.ID).Str("command", w.Command).Msg(message)
+	data := map[string]any{
+		"message":  message,
+		"watch_id": w.ID,
+		"command":  w.Command,
+		"pattern":  w.Pattern,
+	}
+	if line != "" {
+		data["line"] = line
+	}
+	cs.SendNotification("notifications/message", map[string]any{
+		"level":  mcp.LoggingLevelInfo,
+		"logger": "command",
+		"data":   data,
+	})
+}
+
+// stopWatch stops a running watch without notifying.
+func (cs *CommandServer) stopWatch(id string) bool {
+	cs.watchesLock.Lock()
+	defer cs.watchesLock.Unlock()
+	w, ok := cs.watches[id]
+	if !ok {
+		return false
+	}
+	delete(cs.watches, id)
+	w.cancel()
+	return true
+}
+
+// stopWatches stops all watches without notifying.
+func (cs *CommandServer) stopWatches() {
+	cs.watchesLock.Lock()
+	defer cs.watchesLock.Unlock()
+	for id, w := range cs.watches {
+		delete(cs.watches, id)
+		w.cancel()
+	}
+}
+
+// runningWatches returns copies of the running watches, the oldest first.
+func (cs *CommandServer) runningWatches() []commandWatch {
+	cs.watchesLock.Lock()
+	defer cs.watchesLock.Unlock()
+	result := make([]commandWatch, 0, len(cs.watches))
+	for _, w := range cs.watches {
+		result = append(result, *w)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Started.Before(result[j].Started) })
+	return result
+}
+
+// handleWatch starts, lists or stops watches of command output.
+func (cs *CommandServer) handleWatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	switch action := abstract.GetString(args, "action", "start"); action {
+	case "list":
+		data, err := json.MarshalIndent(cs.runningWatches(), "", "  ")
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.CodeInternal, "failed to encode the watches: %s", err.Error()), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	case "stop":
+		id := abstract.GetString(args, "id", "")
+		if !cs.stopWatch(id) {
+			return abstract.NewErrorResultf(abstract.CodeNotFound, "no running watch %q", id), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("watch %s stopped", id)), nil
+	case "start":
+	default:
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "unknown action %q, use start, list or stop", action), nil
+	}
+
+	w := &commandWatch{
+		Command:  abstract.GetString(args, "command", ""),
+		Pattern:  abstract.GetString(args, "pattern", ""),
+		Follow:   abstract.GetBool(args, "follow", false),
+		Interval: abstract.GetInt(args, "interval", watchIntervalDefault),
+		Once:     abstract.GetBool(args, "once", true),
+	}
+	if w.Command == "" || w.Pattern == "" {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "command and pattern are required to start a watch"), nil
+	}
+	expr := w.Pattern
+	if abstract.GetBool(args, "ignore_case", false) {
+		expr = "(?i)" + expr
+	}
+	var err error
+	if w.re, err = regexp.Compile(expr); err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "invalid pattern: %v", err), nil
+	}
+	if w.Follow {
+		w.Interval = 0
+	} else if w.Interval < 1 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "interval must be at least 1 second"), nil
+	}
+	duration := abstract.GetInt(args, "duration", watchDurationDefault)
+	if duration < 1 || duration > watchDurationMax {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "duration must be between 1 and %d seconds", watchDurationMax), nil
+	}
+	w.Dir = cs.commandDir(ctx, abstract.GetString(args, "dir", ""))
+	if abstract.GetBool(args, "toolchain", false) {
+		w.env = ToolchainEnv(os.Environ(), DetectToolchains(dirOrCwd(w.Dir), os.Getenv))
+	}
+
+	if abstract.IsDryRun(ctx, request) {
+		how := fmt.Sprintf("every %d seconds", w.Interval)
+		if w.Follow {
+			how = "once, following its output"
+		}
+		return abstract.NewDryRunResult("would run '%s' %s for up to %d seconds and notify when its output matches %q", w.Command, how, duration, w.Pattern), nil
+	}
+	if !cs.allowCommand(ctx, w.Command) {
+		return mcp.NewToolResultError(fmt.Sprintf("Error: Command '%s' is not allowed", w.Command)), nil
+	}
+	started, err := cs.startWatch(w, time.Duration(duration)*time.Second)
+	if err != nil {
+		return abstract.NewErrorResult(abstract.CodeUnavailable, err.Error()), nil
+	}
+	data, err := json.MarshalIndent(started, "", "  ")
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.CodeInternal, "failed to encode the watch: %s", err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package docconvert converts documents between Markdown, HTML, DOCX and PDF for the MoLing application.
+package docconvert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	DocConvertServerName comm.MoLingServerType = "DocConvert"
+)
+
+// DocConvertServer implements the Service interface and converts documents between formats.
+type DocConvertServer struct {
+	abstract.MLService
+	config *DocConvertConfig
+}
+
+// NewDocConvertServer creates a new DocConvertServer instance.
+func NewDocConvertServer(ctx context.Context) (abstract.Service, error) {
+	dc := NewDocConvertConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("DocConvertServer: invalid config type")
+	}
+	dc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+	dc.WorkPath = filepath.Join(globalConf.BasePath, "cache", "docconvert")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("DocConvertServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(DocConvertServerName))
+	})
+
+	ds := &DocConvertServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    dc,
+	}
+	err := ds.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Init registers the prompt and tools of the document conversion service.
+func (ds *DocConvertServer) Init() error {
+	for _, dir := range []string{ds.config.AllowedDirs[0], ds.config.WorkPath} {
+		err := utils.CreateDirectory(dir)
+		if err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "docconvert_prompt",
+			Description: "Get the relevant functions and prompts of the DocConvert MCP Server",
+		},
+		HandlerFunc: ds.handlePrompt,
+	}
+	ds.AddPrompt(pe)
+
+	ds.AddTool(mcp.NewTool(
+		"doc_convert",
+		mcp.WithDescription("Convert a document between Markdown, HTML, DOCX and PDF. The formats are taken from the file extensions unless given"),
+		mcp.WithString("input",
+			mcp.Description("Path of the document to convert"),
+			mcp.Required(),
+		),
+		mcp.WithString("output",
+			mcp.Description("Path of the converted document"),
+			mcp.Required(),
+		),
+		mcp.WithString("from",
+			mcp.Description("Input format, default: from the input extension"),
+			mcp.Enum(formatMarkdown, formatHTML, formatDOCX),
+		),
+		mcp.WithString("to",
+			mcp.Description("Output format, default: from the output extension"),
+			mcp.Enum(formatMarkdown, formatHTML, formatDOCX, formatPDF),
+		),
+		mcp.WithString("engine",
+			mcp.Description("Converter to use, default: auto, which prefers pandoc, and LibreOffice for PDF, over the built-in converter"),
+			mcp.Enum(engineAuto, enginePandoc, engineLibreOffice, engineNative),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace the output file if it exists, default: false"),
+		),
+	), ds.handleConvert)
+	return nil
+}
+
+func (ds *DocConvertServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ds.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ds.config.AllowedDirs[0])
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+func (ds *DocConvertServer) handleConvert(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	inputArg := abstract.GetString(args, "input", "")
+	outputArg := abstract.GetString(args, "output", "")
+	input, err := utils.ResolvePath(inputArg, ds.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output, err := utils.ResolvePath(outputArg, ds.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if input == output {
+		return mcp.NewToolResultError("input and output must be different files"), nil
+	}
+
+	from := abstract.GetString(args, "from", "")
+	if from == "" {
+		from = filepath.Ext(input)
+	}
+	to := abstract.GetString(args, "to", "")
+	if to == "" {
+		to = filepath.Ext(output)
+	}
+	from, to = formatOf(from), formatOf(to)
+	switch {
+	case from == "" || to == "":
+		return mcp.NewToolResultError("unknown format, use .md, .html, .docx or .pdf files or pass from and to"), nil
+	case from == formatPDF:
+		return mcp.NewToolResultError("PDF files can not be converted to other formats"), nil
+	case from == to:
+		return mcp.NewToolResultError(fmt.Sprintf("input and output are both %s", from)), nil
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read input: %s", err.Error())), nil
+	}
+	if info.Size() > ds.config.MaxFileSize {
+		return mcp.NewToolResultError(fmt.Sprintf("input is %d bytes, the limit is %d", info.Size(), ds.config.MaxFileSize)), nil
+	}
+	if overwrite := abstract.GetBool(args, "overwrite", false); !overwrite {
+		if _, err := os.Stat(output); err == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s already exists, pass overwrite=true to replace it", output)), nil
+		}
+	}
+	err = os.MkdirAll(filepath.Dir(output), 0o755)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create output directory: %s", err.Error())), nil
+	}
+
+	engine := abstract.GetString(args, "engine", "")
+	if engine == "" {
+		engine = engineAuto
+	}
+	used, err := ds.convert(ctx, input, output, from, to, engine)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to convert %s to %s: %s", from, to, err.Error())), nil
+	}
+	ds.Logger.Info().Str("input", input).Str("output", output).Str("engine", used).Msg("document converted")
+	result := fmt.Sprintf("Converted %s (%s) to %s (%s) with %s", input, from, output, to, used)
+	if info, err := os.Stat(output); err == nil {
+		result += fmt.Sprintf(", %d bytes", info.Size())
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// convert picks the converter and returns the name of the one that was used.
+func (ds *DocConvertServer) convert(ctx context.Context, input, output, from, to, engine string) (string, error) {
+	pandoc, soffice := ds.pandoc(), ds.libreOffice()
+	switch engine {
+	case engineAuto:
+		switch {
+		case to == formatPDF && soffice != "":
+			engine = engineLibreOffice
+		case pandoc != "":
+			engine = enginePandoc
+		case to == formatPDF:
+			return "", errors.New("PDF output requires LibreOffice or pandoc with a PDF engine, neither was found")
+		default:
+			engine = engineNative
+		}
+	case enginePandoc:
+		if pandoc == "" {
+			return "", fmt.Errorf("pandoc was not found, set pandoc_path or install pandoc")
+		}
+	case engineLibreOffice:
+		if soffice == "" {
+			return "", fmt.Errorf("LibreOffice was not found, set libreoffice_path or install LibreOffice")
+		}
+		if to == formatMarkdown {
+			return "", fmt.Errorf("LibreOffice can not write Markdown")
+		}
+	case engineNative:
+	default:
+		return "", fmt.Errorf("unknown engine %q", engine)
+	}
+
+	switch engine {
+	case enginePandoc:
+		return engine, ds.runPandoc(ctx, pandoc, input, output, from, to)
+	case engineLibreOffice:
+		if from == formatMarkdown {
+			// LibreOffice does not read Markdown, render it as HTML first
+			html, err := os.CreateTemp(ds.config.WorkPath, "*.html")
+			if err != nil {
+				return "", fmt.Errorf("failed to create temporary file: %w", err)
+			}
+			_ = html.Close()
+			defer func() { _ = os.Remove(html.Name()) }()
+			if pandoc != "" {
+				err = ds.runPandoc(ctx, pandoc, input, html.Name(), from, formatHTML)
+			} else {
+				err = ds.convertNative(input, html.Name(), from, formatHTML)
+			}
+			if err != nil {
+				return "", err
+			}
+			input, from = html.Name(), formatHTML
+		}
+		return engine, ds.runLibreOffice(ctx, soffice, input, output, from, to)
+	default:
+		return engine, ds.convertNative(input, output, from, to)
+	}
+}
+
+func (ds *DocConvertServer) convertNative(input, output, from, to string) error {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	converted, err := convertNative(data, from, to, title, ds.config.MaxFileSize)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, converted, 0o644)
+}
+
+func (ds *DocConvertServer) Config() string {
+	cfg, err := json.Marshal(ds.config)
+	if err != nil {
+		ds.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ds *DocConvertServer) Name() comm.MoLingServerType {
+	return DocConvertServerName
+}
+
+func (ds *DocConvertServer) Close() error {
+	ds.Logger.Debug().Msg("DocConvertServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ds *DocConvertServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ds.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ds.config.Check()
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const DocConvertPromptDefault = `
+You are an assistant that can convert documents between Markdown, HTML, DOCX and PDF. Your capabilities include:
+
+1. **Convert**: Use doc_convert with an input and an output path. The formats are taken from the file extensions (.md, .html, .docx, .pdf) unless from and to are given.
+
+2. **Engines**: pandoc and LibreOffice are used when they are installed. Without them, a built-in converter handles Markdown, HTML and DOCX with headings, emphasis, links, lists, code blocks and tables. PDF output requires LibreOffice or pandoc with a PDF engine.
+
+Relative paths are resolved in %s. PDF files can only be written, not converted to other formats. Existing output files are only replaced when overwrite is true.
+`
+
+// DocConvertConfig represents the configuration for the document conversion service.
+type DocConvertConfig struct {
+	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the document conversion service.
+	prompt          string
+	AllowedDirs     []string `json:"allowed_dirs"`     // AllowedDirs are the directories documents may be read from and written to, relative paths use the first one.
+	WorkPath        string   `json:"work_path"`        // WorkPath holds intermediate files and the LibreOffice profile.
+	PandocPath      string   `json:"pandoc_path"`      // PandocPath is the pandoc executable, empty disables pandoc.
+	LibreOfficePath string   `json:"libreoffice_path"` // LibreOfficePath is the soffice executable, empty searches the PATH and the default install locations.
+	Timeout         int      `json:"timeout"`          // Timeout is the time limit of an external conversion, in seconds.
+	MaxFileSize     int64    `json:"max_file_size"`    // MaxFileSize is the maximum size of an input document, in bytes.
+}
+
+// NewDocConvertConfig creates a new DocConvertConfig with default values.
+func NewDocConvertConfig() *DocConvertConfig {
+	return &DocConvertConfig{
+		prompt:      DocConvertPromptDefault,
+		AllowedDirs: []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		WorkPath:    filepath.Join(os.TempDir(), ".moling", "cache", "docconvert"),
+		PandocPath:  "pandoc",
+		Timeout:     120,
+		MaxFileSize: 1024 * 1024 * 50,
+	}
+}
+
+// Check validates the document conversion configuration.
+func (cfg *DocConvertConfig) Check() error {
+	cfg.prompt = DocConvertPromptDefault
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	if cfg.WorkPath == "" {
+		return fmt.Errorf("work_path must not be empty")
+	}
+	if cfg.Timeout <= 0 || cfg.MaxFileSize <= 0 {
+		return fmt.Errorf("timeout and max_file_size must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
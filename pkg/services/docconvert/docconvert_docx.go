@@ -0,0 +1,433 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var headingStylePattern = regexp.MustCompile(`(?i)^heading\s*([1-6])$`)
+
+// xmlNode is a generic element of an Office Open XML part.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *xmlNode) child(local string) *xmlNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == local {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// flag reports whether a toggle property such as <w:b/> is set.
+func (n *xmlNode) flag(local string) bool {
+	c := n.child(local)
+	if c == nil {
+		return false
+	}
+	v := c.attr("val")
+	return v != "0" && v != "false" && v != "none"
+}
+
+// docxReader converts the main document part of a DOCX package to HTML.
+type docxReader struct {
+	styles    map[string]string // style id -> lower case style name
+	numFormat map[string]string // num id + "/" + level -> number format
+	numStart  map[string]string // num id + "/" + level -> start value
+	links     map[string]string // relationship id -> target
+}
+
+// segment is a run of text with uniform formatting.
+type segment struct {
+	text                       string
+	bold, italic, strike, code bool
+	href                       string
+}
+
+// docxToHTML converts a DOCX document to an HTML fragment. Headings, emphasis, hyperlinks,
+// lists and tables are kept, images and page layout are dropped.
+func docxToHTML(data []byte, maxSize int64) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a DOCX document: %w", err)
+	}
+	parts := make(map[string]*xmlNode)
+	for _, f := range zr.File {
+		switch f.Name {
+		case "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels":
+		default:
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		raw, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+		_ = rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		if int64(len(raw)) > maxSize {
+			return "", fmt.Errorf("%s is larger than %d bytes", f.Name, maxSize)
+		}
+		var node xmlNode
+		err = xml.Unmarshal(raw, &node)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", f.Name, err)
+		}
+		parts[f.Name] = &node
+	}
+	document := parts["word/document.xml"]
+	if document == nil || document.child("body") == nil {
+		return "", fmt.Errorf("not a DOCX document: word/document.xml is missing")
+	}
+
+	r := &docxReader{
+		styles:    make(map[string]string),
+		numFormat: make(map[string]string),
+		numStart:  make(map[string]string),
+		links:     make(map[string]string),
+	}
+	if styles := parts["word/styles.xml"]; styles != nil {
+		for _, s := range styles.Nodes {
+			if s.XMLName.Local == "style" {
+				if name := s.child("name"); name != nil {
+					r.styles[s.attr("styleId")] = strings.ToLower(name.attr("val"))
+				}
+			}
+		}
+	}
+	if numbering := parts["word/numbering.xml"]; numbering != nil {
+		abstract := make(map[string]map[string]string)
+		for _, n := range numbering.Nodes {
+			if n.XMLName.Local != "abstractNum" {
+				continue
+			}
+			levels := make(map[string]string)
+			for _, lvl := range n.Nodes {
+				if lvl.XMLName.Local == "lvl" {
+					if f := lvl.child("numFmt"); f != nil {
+						levels[lvl.attr("ilvl")] = f.attr("val")
+					}
+				}
+			}
+			abstract[n.attr("abstractNumId")] = levels
+		}
+		for _, n := range numbering.Nodes {
+			if n.XMLName.Local != "num" || n.child("abstractNumId") == nil {
+				continue
+			}
+			for lvl, format := range abstract[n.child("abstractNumId").attr("val")] {
+				r.numFormat[n.attr("numId")+"/"+lvl] = format
+			}
+			for _, override := range n.Nodes {
+				if start := override.child("startOverride"); override.XMLName.Local == "lvlOverride" && start != nil {
+					r.numStart[n.attr("numId")+"/"+override.attr("ilvl")] = start.attr("val")
+				}
+			}
+		}
+	}
+	if rels := parts["word/_rels/document.xml.rels"]; rels != nil {
+		for _, rel := range rels.Nodes {
+			r.links[rel.attr("Id")] = rel.attr("Target")
+		}
+	}
+
+	var b strings.Builder
+	r.blocks(&b, document.child("body").Nodes)
+	return b.String(), nil
+}
+
+// blocks converts body level elements, grouping numbered paragraphs into nested lists.
+func (r *docxReader) blocks(b *strings.Builder, nodes []xmlNode) {
+	var lists []string // the opening tags of the open lists, one per level
+	closeLists := func(depth int) {
+		for len(lists) > depth {
+			tag, _, _ := strings.Cut(lists[len(lists)-1], " ")
+			b.WriteString("</li></" + tag + ">\n")
+			lists = lists[:len(lists)-1]
+		}
+	}
+	// consecutive code paragraphs form one preformatted block
+	var code strings.Builder
+	flushCode := func() {
+		if code.Len() > 0 {
+			b.WriteString("<pre><code>" + html.EscapeString(code.String()) + "</code></pre>\n")
+			code.Reset()
+		}
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.XMLName.Local {
+		case "p":
+			tag, level, listTag, segments := r.paragraph(n)
+			if tag != "pre" {
+				flushCode()
+			}
+			text := renderSegments(segments)
+			switch {
+			case tag == "pre":
+				closeLists(0)
+				for _, s := range segments {
+					code.WriteString(s.text)
+				}
+				code.WriteString("\n")
+				continue
+			case level < 0:
+				closeLists(0)
+				if text != "" {
+					b.WriteString("<" + tag + ">" + text + "</" + tag + ">\n")
+				}
+				continue
+			}
+			closeLists(level + 1)
+			if len(lists) == level+1 {
+				if lists[level] == listTag {
+					b.WriteString("</li>\n")
+				} else {
+					closeLists(level)
+				}
+			}
+			for len(lists) < level+1 {
+				if len(lists) < level {
+					// a level was skipped, open an empty item to keep the nesting valid
+					b.WriteString("<" + listTag + "><li>")
+				} else {
+					b.WriteString("<" + listTag + ">\n")
+				}
+				lists = append(lists, listTag)
+			}
+			b.WriteString("<li>" + text)
+		case "tbl":
+			flushCode()
+			closeLists(0)
+			r.table(b, n)
+		case "sdt":
+			flushCode()
+			closeLists(0)
+			if content := n.child("sdtContent"); content != nil {
+				r.blocks(b, content.Nodes)
+			}
+		}
+	}
+	flushCode()
+	closeLists(0)
+}
+
+// paragraph returns the HTML tag, the list level (-1 outside of lists), the opening tag of the
+// list and the text runs of a paragraph.
+func (r *docxReader) paragraph(p *xmlNode) (string, int, string, []segment) {
+	tag, level, list := "p", -1, ""
+	if ppr := p.child("pPr"); ppr != nil {
+		if style := ppr.child("pStyle"); style != nil {
+			name := r.styles[style.attr("val")]
+			if name == "" {
+				name = style.attr("val")
+			}
+			if m := headingStylePattern.FindStringSubmatch(name); m != nil {
+				tag = "h" + m[1]
+			} else if name == "title" {
+				tag = "h1"
+			} else if strings.Contains(name, "quote") {
+				tag = "blockquote"
+			} else if name == "code" || name == "source code" || name == "html preformatted" {
+				tag = "pre"
+			}
+		}
+		if num := ppr.child("numPr"); num != nil && num.child("numId") != nil {
+			numID := num.child("numId").attr("val")
+			lvl := "0"
+			if il := num.child("ilvl"); il != nil {
+				lvl = il.attr("val")
+			}
+			if numID != "0" {
+				level, _ = strconv.Atoi(lvl)
+				level = min(max(level, 0), 8)
+				list = "ul"
+				if format := r.numFormat[numID+"/"+lvl]; format != "" && format != "bullet" && format != "none" {
+					list = "ol"
+					if start := r.numStart[numID+"/"+lvl]; start != "" && start != "1" {
+						list = `ol start="` + html.EscapeString(start) + `"`
+					}
+				}
+			}
+		}
+	}
+	var segments []segment
+	r.runs(&segments, p.Nodes, "")
+	return tag, level, list, segments
+}
+
+// runs collects the text runs of paragraph content, including runs inside hyperlinks and insertions.
+func (r *docxReader) runs(segments *[]segment, nodes []xmlNode, href string) {
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.XMLName.Local {
+		case "r":
+			var s segment
+			s.href = href
+			if rpr := n.child("rPr"); rpr != nil {
+				s.bold = rpr.flag("b")
+				s.italic = rpr.flag("i")
+				s.strike = rpr.flag("strike") || rpr.flag("dstrike")
+				if style := rpr.child("rStyle"); style != nil {
+					name := strings.ToLower(r.styles[style.attr("val")] + " " + style.attr("val"))
+					s.code = strings.Contains(name, "code") || strings.Contains(name, "verbatim")
+				}
+				if font := rpr.child("rFonts"); font != nil && isMonospace(font.attr("ascii")) {
+					s.code = true
+				}
+			}
+			var text strings.Builder
+			for _, c := range n.Nodes {
+				switch c.XMLName.Local {
+				case "t":
+					text.WriteString(c.Content)
+				case "tab":
+					text.WriteString("\t")
+				case "br", "cr":
+					if c.attr("type") != "page" {
+						text.WriteString("\n")
+					}
+				case "noBreakHyphen":
+					text.WriteString("-")
+				}
+			}
+			s.text = text.String()
+			*segments = append(*segments, s)
+		case "hyperlink":
+			link := r.links[n.attr("id")]
+			if anchor := n.attr("anchor"); link == "" && anchor != "" {
+				link = "#" + anchor
+			}
+			r.runs(segments, n.Nodes, link)
+		case "ins", "smartTag", "fldSimple", "customXml":
+			r.runs(segments, n.Nodes, href)
+		case "sdt":
+			if content := n.child("sdtContent"); content != nil {
+				r.runs(segments, content.Nodes, href)
+			}
+		}
+	}
+}
+
+func isMonospace(font string) bool {
+	font = strings.ToLower(font)
+	for _, mono := range []string{"courier", "consolas", "menlo", "monaco", "mono"} {
+		if strings.Contains(font, mono) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSegments merges runs with the same formatting and renders them as HTML.
+func renderSegments(segments []segment) string {
+	var merged []segment
+	for _, s := range segments {
+		if s.text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.bold == s.bold && last.italic == s.italic && last.strike == s.strike && last.code == s.code && last.href == s.href {
+				last.text += s.text
+				continue
+			}
+		}
+		merged = append(merged, s)
+	}
+	var b strings.Builder
+	for i := 0; i < len(merged); {
+		// consecutive segments of one hyperlink share the anchor element
+		href := merged[i].href
+		if href != "" {
+			b.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+		}
+		for ; i < len(merged) && merged[i].href == href; i++ {
+			s := merged[i]
+			text := strings.ReplaceAll(html.EscapeString(s.text), "\n", "<br>")
+			if s.code {
+				text = "<code>" + text + "</code>"
+			}
+			if s.strike {
+				text = "<del>" + text + "</del>"
+			}
+			if s.italic {
+				text = "<em>" + text + "</em>"
+			}
+			if s.bold {
+				text = "<strong>" + text + "</strong>"
+			}
+			b.WriteString(text)
+		}
+		if href != "" {
+			b.WriteString("</a>")
+		}
+	}
+	return b.String()
+}
+
+func (r *docxReader) table(b *strings.Builder, tbl *xmlNode) {
+	b.WriteString("<table>\n")
+	for _, tr := range tbl.Nodes {
+		if tr.XMLName.Local != "tr" {
+			continue
+		}
+		b.WriteString("<tr>")
+		for _, tc := range tr.Nodes {
+			if tc.XMLName.Local != "tc" {
+				continue
+			}
+			var cell []string
+			for i := range tc.Nodes {
+				if tc.Nodes[i].XMLName.Local == "p" {
+					_, _, _, segments := r.paragraph(&tc.Nodes[i])
+					if text := renderSegments(segments); text != "" {
+						cell = append(cell, text)
+					}
+				}
+			}
+			b.WriteString("<td>" + strings.Join(cell, "<br>") + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+}
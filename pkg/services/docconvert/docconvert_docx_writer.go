@@ -0,0 +1,409 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	bulletNumID  = 1
+	docxMaxLevel = 8
+	docxNS       = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+)
+
+// runFormat is the character formatting applied to text runs.
+type runFormat struct {
+	bold, italic, strike, code, link bool
+}
+
+// listContext places the paragraphs of a list item.
+type listContext struct {
+	numID, level int
+	numbered     bool // numbered is set once the first paragraph of the item carries the number
+}
+
+// docxWriter builds a DOCX package from HTML.
+type docxWriter struct {
+	body    strings.Builder
+	links   []string // hyperlink targets, relationship ids start at rIdLink1
+	ordered []int    // start values of the ordered lists, numbering ids start at 2
+
+	runs      strings.Builder // runs of the pending paragraph
+	lastSpace bool
+}
+
+// htmlToDocx converts an HTML document to a DOCX package.
+func htmlToDocx(src string) ([]byte, error) {
+	body, err := parseHTML(src)
+	if err != nil {
+		return nil, err
+	}
+	w := &docxWriter{}
+	w.blocks(body, "", nil)
+	w.flush("", nil)
+	if w.body.Len() == 0 {
+		w.body.WriteString("<w:p/>")
+	}
+	return w.pack(htmlTitle(src))
+}
+
+// blocks converts the children of n. Inline content is collected into runs and written as a
+// paragraph with the given style whenever a block element starts.
+func (w *docxWriter) blocks(n *html.Node, style string, list *listContext) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedAtoms[c.DataAtom] {
+			continue
+		}
+		if c.Type != html.ElementNode || !blockAtoms[c.DataAtom] {
+			w.inline(c, runFormat{})
+			continue
+		}
+		w.flush(style, list)
+		w.block(c, style, list)
+	}
+}
+
+func (w *docxWriter) block(n *html.Node, style string, list *listContext) {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.blocks(n, "Heading"+n.Data[1:], nil)
+		w.flush("Heading"+n.Data[1:], nil)
+	case atom.Pre:
+		for _, line := range strings.Split(strings.TrimSuffix(textContent(n), "\n"), "\n") {
+			w.body.WriteString(`<w:p><w:pPr><w:pStyle w:val="Code"/></w:pPr>`)
+			w.body.WriteString(textRun(expandTabs(line), runFormat{}, true))
+			w.body.WriteString("</w:p>")
+		}
+	case atom.Blockquote:
+		w.blocks(n, "Quote", list)
+		w.flush("Quote", list)
+	case atom.Ul, atom.Ol:
+		level := 0
+		if list != nil {
+			level = min(list.level+1, docxMaxLevel)
+		}
+		numID := bulletNumID
+		if n.DataAtom == atom.Ol {
+			start, err := strconv.Atoi(attr(n, "start"))
+			if err != nil {
+				start = 1
+			}
+			w.ordered = append(w.ordered, start)
+			numID = len(w.ordered) + 1
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+				item := &listContext{numID: numID, level: level}
+				w.blocks(c, "", item)
+				w.flush("", item)
+			}
+		}
+	case atom.Table:
+		w.table(n)
+	case atom.Hr:
+		w.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+	case atom.Dt:
+		w.inline(n, runFormat{bold: true})
+		w.flush(style, list)
+	default:
+		w.blocks(n, style, list)
+		w.flush(style, list)
+	}
+}
+
+// inline converts inline content into runs of the pending paragraph.
+func (w *docxWriter) inline(n *html.Node, f runFormat) {
+	switch n.Type {
+	case html.TextNode:
+		text := whitespacePattern.ReplaceAllString(n.Data, " ")
+		if w.lastSpace || w.runs.Len() == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text == "" {
+			return
+		}
+		w.lastSpace = strings.HasSuffix(text, " ")
+		w.runs.WriteString(textRun(text, f, false))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if skippedAtoms[n.DataAtom] {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		w.runs.WriteString("<w:r><w:br/></w:r>")
+		w.lastSpace = true
+		return
+	case atom.Img:
+		if alt := attr(n, "alt"); alt != "" {
+			w.runs.WriteString(textRun("["+alt+"]", runFormat{italic: true}, false))
+		}
+		return
+	case atom.Strong, atom.B:
+		f.bold = true
+	case atom.Em, atom.I, atom.Cite, atom.Var:
+		f.italic = true
+	case atom.Del, atom.S, atom.Strike:
+		f.strike = true
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		f.code = true
+	case atom.A:
+		href := attr(n, "href")
+		if href != "" && !f.link && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			w.links = append(w.links, href)
+			fmt.Fprintf(&w.runs, `<w:hyperlink r:id="rIdLink%d">`, len(w.links))
+			f.link = true
+			w.children(n, f)
+			w.runs.WriteString("</w:hyperlink>")
+			return
+		}
+	}
+	w.children(n, f)
+}
+
+func (w *docxWriter) children(n *html.Node, f runFormat) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.inline(c, f)
+	}
+}
+
+// flush writes the pending runs as a paragraph.
+func (w *docxWriter) flush(style string, list *listContext) {
+	if w.runs.Len() == 0 {
+		return
+	}
+	w.body.WriteString("<w:p><w:pPr>")
+	switch {
+	case list != nil && !list.numbered:
+		list.numbered = true
+		fmt.Fprintf(&w.body, `<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, list.level, list.numID)
+	case list != nil:
+		fmt.Fprintf(&w.body, `<w:pStyle w:val="ListParagraph"/><w:ind w:left="%d"/>`, 720*(list.level+1))
+	case style != "":
+		w.body.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+	}
+	w.body.WriteString("</w:pPr>")
+	w.body.WriteString(w.runs.String())
+	w.body.WriteString("</w:p>")
+	w.runs.Reset()
+	w.lastSpace = false
+}
+
+func (w *docxWriter) table(n *html.Node) {
+	var rows []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom == atom.Table {
+				continue
+			}
+			if c.DataAtom == atom.Tr {
+				rows = append(rows, c)
+			} else {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	width := 0
+	for _, row := range rows {
+		cells := 0
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+				cells++
+			}
+		}
+		width = max(width, cells)
+	}
+	if width == 0 {
+		return
+	}
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr><w:tblGrid>`)
+	w.body.WriteString(strings.Repeat(`<w:gridCol/>`, width))
+	w.body.WriteString("</w:tblGrid>")
+	for _, row := range rows {
+		w.body.WriteString("<w:tr>")
+		cells := 0
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			cells++
+			w.body.WriteString("<w:tc>")
+			mark := w.body.Len()
+			if c.DataAtom == atom.Th {
+				w.children(c, runFormat{bold: true})
+				w.flush("", nil)
+			} else {
+				w.blocks(c, "", nil)
+				w.flush("", nil)
+			}
+			if w.body.Len() == mark {
+				// every cell needs a paragraph
+				w.body.WriteString("<w:p/>")
+			}
+			w.body.WriteString("</w:tc>")
+		}
+		w.body.WriteString(strings.Repeat("<w:tc><w:p/></w:tc>", width-cells))
+		w.body.WriteString("</w:tr>")
+	}
+	w.body.WriteString("</w:tbl><w:p/>")
+}
+
+// textRun renders a run of text. Tabs are kept as tab characters in preformatted text.
+func textRun(text string, f runFormat, preformatted bool) string {
+	var b strings.Builder
+	b.WriteString("<w:r>")
+	var props strings.Builder
+	if f.link {
+		props.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	} else if f.code {
+		props.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+	}
+	if f.bold {
+		props.WriteString("<w:b/>")
+	}
+	if f.italic {
+		props.WriteString("<w:i/>")
+	}
+	if f.strike {
+		props.WriteString("<w:strike/>")
+	}
+	if props.Len() > 0 {
+		b.WriteString("<w:rPr>" + props.String() + "</w:rPr>")
+	}
+	if !preformatted || text != "" {
+		b.WriteString(`<w:t xml:space="preserve">`)
+		_ = xml.EscapeText(&b, []byte(text))
+		b.WriteString("</w:t>")
+	}
+	b.WriteString("</w:r>")
+	return b.String()
+}
+
+// pack assembles the parts of the package.
+func (w *docxWriter) pack(title string) ([]byte, error) {
+	var rels strings.Builder
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for i, link := range w.links {
+		fmt.Fprintf(&rels, `<Relationship Id="rIdLink%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`,
+			i+1, xmlAttr(link))
+	}
+	rels.WriteString(`</Relationships>`)
+
+	var numbering strings.Builder
+	numbering.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:numbering ` + docxNS + `>`)
+	numbering.WriteString(`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	bullets := []string{"•", "◦", "▪"}
+	for l := 0; l <= docxMaxLevel; l++ {
+		fmt.Fprintf(&numbering, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			l, bullets[l%len(bullets)], 720*(l+1))
+	}
+	numbering.WriteString(`</w:abstractNum><w:abstractNum w:abstractNumId="1"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	for l := 0; l <= docxMaxLevel; l++ {
+		fmt.Fprintf(&numbering, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="decimal"/><w:lvlText w:val="%%%d."/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+			l, l+1, 720*(l+1))
+	}
+	numbering.WriteString(`</w:abstractNum>`)
+	fmt.Fprintf(&numbering, `<w:num w:numId="%d"><w:abstractNumId w:val="0"/></w:num>`, bulletNumID)
+	for i, start := range w.ordered {
+		// every ordered list gets its own numbering so that it restarts at its start value
+		fmt.Fprintf(&numbering, `<w:num w:numId="%d"><w:abstractNumId w:val="1"/>`, i+2)
+		for l := 0; l <= docxMaxLevel; l++ {
+			fmt.Fprintf(&numbering, `<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, l, start)
+		}
+		numbering.WriteString(`</w:num>`)
+	}
+	numbering.WriteString(`</w:numbering>`)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCoreProps, xmlAttr(title))},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:document ` + docxNS + `><w:body>` +
+			w.body.String() + `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr></w:body></w:document>`},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", numbering.String()},
+		{"word/_rels/document.xml.rels", rels.String()},
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fw.Write([]byte(f.content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func xmlAttr(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/><Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/><Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/><Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/></Types>`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/></Relationships>`
+
+const docxCoreProps = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>%s</dc:title><dc:creator>MoLing</dc:creator></cp:coreProperties>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="259" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:rPr><w:sz w:val="56"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="80"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="40"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="160" w:after="80"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="160" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="80" w:after="40"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="80" w:after="40"/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="40" w:after="0"/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:i/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720" w:right="720"/></w:pPr><w:rPr><w:i/><w:color w:val="404040"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="40"/><w:ind w:left="720"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
@@ -0,0 +1,210 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatDOCX     = "docx"
+	formatPDF      = "pdf"
+
+	engineAuto        = "auto"
+	engineNative      = "native"
+	enginePandoc      = "pandoc"
+	engineLibreOffice = "libreoffice"
+)
+
+// formatAliases maps file extensions and format names to formats.
+var formatAliases = map[string]string{
+	"md": formatMarkdown, "markdown": formatMarkdown, "html": formatHTML, "htm": formatHTML,
+	"xhtml": formatHTML, "docx": formatDOCX, "pdf": formatPDF,
+}
+
+// pandocFormats maps formats to pandoc format names.
+var pandocFormats = map[string]string{formatMarkdown: "gfm", formatHTML: "html", formatDOCX: "docx"}
+
+// libreOfficeFilters maps output formats to LibreOffice export filters.
+var libreOfficeFilters = map[string]string{
+	formatHTML: "html:XHTML Writer File:UTF8",
+	formatDOCX: "docx:MS Word 2007 XML",
+	formatPDF:  "pdf",
+}
+
+// libreOfficeCandidates are searched when libreoffice_path is empty.
+var libreOfficeCandidates = []string{
+	"soffice",
+	"libreoffice",
+	"/Applications/LibreOffice.app/Contents/MacOS/soffice",
+	`C:\Program Files\LibreOffice\program\soffice.exe`,
+}
+
+// formatOf returns the format of a file extension or format name.
+func formatOf(name string) string {
+	return formatAliases[strings.ToLower(strings.TrimPrefix(name, "."))]
+}
+
+// pandoc returns the pandoc executable, or an empty string if it is not available.
+func (ds *DocConvertServer) pandoc() string {
+	if ds.config.PandocPath == "" {
+		return ""
+	}
+	path, err := exec.LookPath(ds.config.PandocPath)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// libreOffice returns the soffice executable, or an empty string if it is not available.
+func (ds *DocConvertServer) libreOffice() string {
+	candidates := libreOfficeCandidates
+	if ds.config.LibreOfficePath != "" {
+		candidates = []string{ds.config.LibreOfficePath}
+	}
+	for _, candidate := range candidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// run runs an external converter within the configured time limit.
+func (ds *DocConvertServer) run(ctx context.Context, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ds.config.Timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = time.Second
+	ds.Logger.Debug().Str("command", name).Strs("args", args).Msg("running converter")
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s did not finish within %d seconds", filepath.Base(name), ds.config.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(name), err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// runPandoc converts a file with pandoc. PDF output needs a PDF engine such as LaTeX.
+func (ds *DocConvertServer) runPandoc(ctx context.Context, pandoc, input, output, from, to string) error {
+	args := []string{"-f", pandocFormats[from], "-o", output}
+	switch to {
+	case formatPDF:
+	case formatHTML:
+		title := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+		args = append(args, "-t", pandocFormats[to], "--standalone", "--metadata", "pagetitle="+title)
+	default:
+		args = append(args, "-t", pandocFormats[to])
+	}
+	return ds.run(ctx, pandoc, append(args, input)...)
+}
+
+// runLibreOffice converts an HTML or DOCX file with LibreOffice in headless mode. LibreOffice
+// names the result after the input, so it converts into a temporary directory first.
+func (ds *DocConvertServer) runLibreOffice(ctx context.Context, soffice, input, output, from, to string) error {
+	dir, err := os.MkdirTemp(ds.config.WorkPath, "convert-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filter := libreOfficeFilters[to]
+	if from == formatHTML && to == formatPDF {
+		filter = "pdf:writer_web_pdf_Export"
+	}
+	// a separate profile keeps the conversion from attaching to a running LibreOffice
+	profile := filepath.ToSlash(filepath.Join(ds.config.WorkPath, "libreoffice"))
+	if !strings.HasPrefix(profile, "/") {
+		profile = "/" + profile
+	}
+	profileURL := url.URL{Scheme: "file", Path: profile}
+	err = ds.run(ctx, soffice, "--headless", "--norestore", "--nolockcheck", "-env:UserInstallation="+profileURL.String(),
+		"--convert-to", filter, "--outdir", dir, input)
+	if err != nil {
+		return err
+	}
+	result := filepath.Join(dir, strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))+"."+to)
+	data, err := os.ReadFile(result)
+	if err != nil {
+		return fmt.Errorf("LibreOffice did not produce a %s file: %w", to, err)
+	}
+	return os.WriteFile(output, data, 0o644)
+}
+
+// convertNative converts between Markdown, HTML and DOCX with the built-in converters, using
+// HTML as the intermediate format.
+func convertNative(data []byte, from, to, title string, maxSize int64) ([]byte, error) {
+	var fragment string
+	switch from {
+	case formatMarkdown:
+		fragment = markdownToHTML(string(data))
+	case formatHTML:
+		fragment = string(data)
+	case formatDOCX:
+		var err error
+		fragment, err = docxToHTML(data, maxSize)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the built-in converter cannot read %s", from)
+	}
+	switch to {
+	case formatMarkdown:
+		md, err := htmlToMarkdown(fragment)
+		return []byte(md), err
+	case formatHTML:
+		if from == formatHTML {
+			return data, nil
+		}
+		if t := htmlTitle(fragment); t != "" {
+			title = t
+		}
+		return []byte(fmt.Sprintf(htmlDocument, xmlAttr(title), fragment)), nil
+	case formatDOCX:
+		return htmlToDocx(fragment)
+	default:
+		return nil, fmt.Errorf("the built-in converter cannot write %s, install LibreOffice or pandoc", to)
+	}
+}
+
+const htmlDocument = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+</head>
+<body>
+%s</body>
+</html>
+`
@@ -0,0 +1,396 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+	mdEscaper         = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+	blockStartPattern = regexp.MustCompile(`^(#{1,6}\s|[-+*]\s|\d+[.)]\s|>|=+\s*$)`)
+)
+
+// blockAtoms are the elements that start a new block in Markdown.
+var blockAtoms = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Pre: true, atom.Blockquote: true, atom.Table: true, atom.Hr: true,
+	atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true, atom.Main: true, atom.Nav: true,
+	atom.Aside: true, atom.Figure: true, atom.Figcaption: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Address: true, atom.Details: true, atom.Summary: true, atom.Form: true, atom.Fieldset: true,
+}
+
+// skippedAtoms are the elements whose content is never converted.
+var skippedAtoms = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+}
+
+// parseHTML parses a document and returns its body.
+func parseHTML(src string) (*html.Node, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	if body := findElement(doc, atom.Body); body != nil {
+		return body, nil
+	}
+	return doc, nil
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent returns the text of a node without any formatting.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// htmlTitle returns the document title, or the first heading.
+func htmlTitle(src string) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return ""
+	}
+	for _, a := range []atom.Atom{atom.Title, atom.H1} {
+		if n := findElement(doc, a); n != nil {
+			return strings.TrimSpace(whitespacePattern.ReplaceAllString(textContent(n), " "))
+		}
+	}
+	return ""
+}
+
+// htmlToMarkdown converts an HTML document to GitHub flavored Markdown.
+func htmlToMarkdown(src string) (string, error) {
+	body, err := parseHTML(src)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(markdownBlocks(body, false)) + "\n", nil
+}
+
+// markdownBlocks converts the children of n. Inline content between blocks becomes a paragraph.
+// Blocks are separated by a blank line, or by a line break in tight list items.
+func markdownBlocks(n *html.Node, tight bool) string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := cleanInline(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedAtoms[c.DataAtom] {
+			continue
+		}
+		if c.Type != html.ElementNode || !blockAtoms[c.DataAtom] {
+			inline.WriteString(markdownInline(c))
+			continue
+		}
+		flush()
+		if block := markdownBlock(c); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+	sep := "\n\n"
+	if tight {
+		sep = "\n"
+	}
+	return strings.Join(blocks, sep)
+}
+
+// markdownBlock converts a block element.
+func markdownBlock(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(cleanInline(markdownInline(n)), "\\\n", " ")
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + text
+	case atom.P, atom.Dd, atom.Figcaption, atom.Summary:
+		return cleanInline(markdownInline(n))
+	case atom.Dt:
+		if text := cleanInline(markdownInline(n)); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	case atom.Hr:
+		return "---"
+	case atom.Pre:
+		code := strings.TrimSuffix(textContent(n), "\n")
+		lang := ""
+		if c := findElement(n, atom.Code); c != nil {
+			for _, class := range strings.Fields(attr(c, "class")) {
+				if l, ok := strings.CutPrefix(class, "language-"); ok {
+					lang = l
+				} else if l, ok := strings.CutPrefix(class, "lang-"); ok {
+					lang = l
+				}
+			}
+		}
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + lang + "\n" + code + "\n" + fence
+	case atom.Blockquote:
+		inner := markdownBlocks(n, false)
+		if inner == "" {
+			return ""
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case atom.Ul, atom.Ol:
+		return markdownList(n)
+	case atom.Table:
+		return markdownTable(n)
+	default:
+		return markdownBlocks(n, false)
+	}
+}
+
+func markdownList(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		number = start
+	}
+	var items []string
+	loose := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		tight := findElement(c, atom.P) == nil
+		loose = loose || !tight
+		content := markdownBlocks(c, tight)
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	if loose {
+		return strings.Join(items, "\n\n")
+	}
+	return strings.Join(items, "\n")
+}
+
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom == atom.Table {
+				continue
+			}
+			if c.DataAtom != atom.Tr {
+				walk(c)
+				continue
+			}
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					text := cleanInline(markdownInline(cell))
+					text = strings.ReplaceAll(strings.ReplaceAll(text, "\\\n", " "), "|", `\|`)
+					row = append(row, text)
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// markdownInline converts inline content. Whitespace is collapsed by cleanInline.
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscaper.Replace(n.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedAtoms[n.DataAtom] {
+		return ""
+	}
+	children := func() string {
+		var b strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && blockAtoms[c.DataAtom] {
+				// blocks nested in inline content are flattened
+				b.WriteString(" " + markdownInline(c) + " ")
+				continue
+			}
+			b.WriteString(markdownInline(c))
+		}
+		return b.String()
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		return "\\\n"
+	case atom.Strong, atom.B:
+		return wrapInline(children(), "**")
+	case atom.Em, atom.I, atom.Cite, atom.Var:
+		return wrapInline(children(), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(children(), "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		code := whitespacePattern.ReplaceAllString(textContent(n), " ")
+		if code == "" {
+			return ""
+		}
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+		return fence + code + fence
+	case atom.A:
+		text := children()
+		href := attr(n, "href")
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if strings.TrimSpace(text) == "" {
+			text = mdEscaper.Replace(href)
+		}
+		return "[" + strings.TrimSpace(text) + "](" + markdownURL(href) + markdownTitle(n) + ")"
+	case atom.Img:
+		src := attr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + mdEscaper.Replace(attr(n, "alt")) + "](" + markdownURL(src) + markdownTitle(n) + ")"
+	default:
+		return children()
+	}
+}
+
+// wrapInline wraps text in emphasis markers, keeping surrounding whitespace outside of them.
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:len(text)-len(strings.TrimLeft(text, " \t\r\n"))]
+	trail := text[len(strings.TrimRight(text, " \t\r\n")):]
+	return lead + marker + trimmed + marker + trail
+}
+
+func markdownURL(url string) string {
+	url = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(url)
+	return url
+}
+
+func markdownTitle(n *html.Node) string {
+	if title := attr(n, "title"); title != "" {
+		return ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return ""
+}
+
+// cleanInline collapses whitespace, keeps hard line breaks and escapes text that would
+// otherwise start a block.
+func cleanInline(s string) string {
+	parts := strings.Split(s, "\\\n")
+	for i, part := range parts {
+		part = strings.TrimSpace(whitespacePattern.ReplaceAllString(part, " "))
+		if blockStartPattern.MatchString(part) {
+			if digits := len(part) - len(strings.TrimLeft(part, "0123456789")); digits > 0 {
+				part = part[:digits] + `\` + part[digits:]
+			} else {
+				part = `\` + part
+			}
+		}
+		parts[i] = part
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, "\\\n")
+}
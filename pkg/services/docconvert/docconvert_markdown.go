@@ -0,0 +1,434 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	fencePattern     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	listPattern      = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	setextPattern    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	separatorPattern = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+
+	escapePattern   = regexp.MustCompile("\\\\([!\"#$%&'()*+,\\-./:;<=>?@\\[\\]^_`{|}~\\\\])")
+	autolinkPattern = regexp.MustCompile(`<((?:https?|mailto|ftp):[^<>\s]+)>`)
+	imagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]*)(?:\s+&#34;(.*?)&#34;)?\)`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]*)(?:\s+&#34;(.*?)&#34;)?\)`)
+	strongPattern   = regexp.MustCompile(`\*\*([^*\s](?:.*?[^*\s])?)\*\*|__([^_\s](?:.*?[^_\s])?)__`)
+	emPattern       = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*|(^|[^\w])_([^_\s](?:[^_]*[^_\s])?)_(\W|$)`)
+	strikePattern   = regexp.MustCompile(`~~([^~\s](?:.*?[^~\s])?)~~`)
+	breakPattern    = regexp.MustCompile(`(?: {2,}|\\)\n`)
+	tokenPattern    = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// markdownToHTML renders the common subset of GitHub flavored Markdown as HTML: headings,
+// paragraphs, emphasis, links, images, code, lists, block quotes, tables and rules.
+// Raw HTML in the source is escaped.
+func markdownToHTML(src string) string {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\x00", "").Replace(src)
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+	var b strings.Builder
+	renderBlocks(&b, lines, false)
+	return b.String()
+}
+
+// expandTabs replaces the tabs of the leading indentation with spaces.
+func expandTabs(line string) string {
+	var b strings.Builder
+	for i, r := range line {
+		switch r {
+		case ' ':
+			b.WriteByte(' ')
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 4-b.Len()%4))
+		default:
+			return b.String() + line[i:]
+		}
+	}
+	return b.String()
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// startsBlock reports whether a line interrupts a paragraph.
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return headingPattern.MatchString(line) || fencePattern.MatchString(line) || rulePattern.MatchString(line) ||
+		strings.HasPrefix(trimmed, ">") || (listPattern.MatchString(line) && !isBlank(listPattern.FindStringSubmatch(line)[3]))
+}
+
+// renderBlocks renders block level Markdown. In tight lists, paragraphs are not wrapped in <p>.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fencePattern.MatchString(line):
+			m := fencePattern.FindStringSubmatch(line)
+			indent := indentOf(line)
+			i++
+			var code []string
+			for ; i < len(lines); i++ {
+				trimmed := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(trimmed, m[1]) && strings.Trim(trimmed, m[1][:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], strings.Repeat(" ", min(indent, indentOf(lines[i])))))
+			}
+			writeCode(b, code, m[2])
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", len(m[1]), renderInline(m[2]), len(m[1]))
+			i++
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(strings.TrimSpace(line), ">"):
+			var quote []string
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				trimmed := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(trimmed, ">") {
+					if startsBlock(lines[i]) {
+						break
+					}
+					// lazy continuation of a quoted paragraph
+					quote = append(quote, trimmed)
+					continue
+				}
+				trimmed = strings.TrimPrefix(trimmed, ">")
+				quote = append(quote, expandTabs(strings.TrimPrefix(trimmed, " ")))
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quote, false)
+			b.WriteString("</blockquote>\n")
+		case listPattern.MatchString(line):
+			i = renderList(b, lines, i)
+		case i+1 < len(lines) && strings.Contains(line, "|") && separatorPattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = renderTable(b, lines, i)
+		case indentOf(line) >= 4:
+			var code []string
+			for ; i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			writeCode(b, code, "")
+		default:
+			para := []string{strings.TrimLeft(line, " ")}
+			level := 0
+			for i++; i < len(lines) && !isBlank(lines[i]); i++ {
+				if m := setextPattern.FindStringSubmatch(lines[i]); m != nil {
+					level = 2
+					if m[1][0] == '=' {
+						level = 1
+					}
+					i++
+					break
+				}
+				if startsBlock(lines[i]) {
+					break
+				}
+				para = append(para, strings.TrimLeft(lines[i], " "))
+			}
+			text := renderInline(strings.TrimRight(strings.Join(para, "\n"), " "))
+			switch {
+			case level > 0:
+				fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, text, level)
+			case tight:
+				b.WriteString(text + "\n")
+			default:
+				b.WriteString("<p>" + text + "</p>\n")
+			}
+		}
+	}
+}
+
+func writeCode(b *strings.Builder, code []string, lang string) {
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// renderList renders the list starting at lines[i] and returns the index of the first line after it.
+func renderList(b *strings.Builder, lines []string, i int) int {
+	first := listPattern.FindStringSubmatch(lines[i])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	delimiter := first[2][len(first[2])-1]
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		m := listPattern.FindStringSubmatch(lines[i])
+		if m == nil || rulePattern.MatchString(lines[i]) || m[2][len(m[2])-1] != delimiter {
+			break
+		}
+		contentIndent := len(m[1]) + len(m[2]) + 1
+		if m[3] != "" {
+			contentIndent = len(lines[i]) - len(m[3])
+			if spaces := indentOf(lines[i][len(m[1])+len(m[2]):]); spaces > 4 {
+				// the content is indented code, it starts one space after the marker
+				contentIndent = len(m[1]) + len(m[2]) + 1
+			}
+		}
+		item := []string{lines[i][min(contentIndent, len(lines[i])):]}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				j := i
+				for j < len(lines) && isBlank(lines[j]) {
+					j++
+				}
+				if j < len(lines) && indentOf(lines[j]) >= contentIndent {
+					for ; i < j; i++ {
+						item = append(item, "")
+					}
+					loose = true
+					continue
+				}
+				if j < len(lines) {
+					if next := listPattern.FindStringSubmatch(lines[j]); next != nil && !rulePattern.MatchString(lines[j]) &&
+						next[2][len(next[2])-1] == delimiter {
+						loose = true
+						i = j
+					}
+				}
+				break
+			}
+			if indentOf(line) >= contentIndent {
+				item = append(item, line[contentIndent:])
+				i++
+				continue
+			}
+			if startsBlock(line) || listPattern.MatchString(line) {
+				break
+			}
+			// lazy continuation of the item's paragraph
+			item = append(item, strings.TrimSpace(line))
+			i++
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		start, _ := strconv.Atoi(first[2][:len(first[2])-1])
+		if start != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderBlocks(&inner, item, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderTable renders a GitHub flavored Markdown table and returns the index of the first line after it.
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	var aligns []string
+	for _, cell := range splitTableRow(lines[i+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "right")
+		case strings.HasPrefix(cell, ":"):
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>")
+		for j := range header {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			if j < len(aligns) && aligns[j] != "" {
+				fmt.Fprintf(b, "<%s style=\"text-align: %s\">%s</%s>", tag, aligns[j], renderInline(cell), tag)
+			} else {
+				fmt.Fprintf(b, "<%s>%s</%s>", tag, renderInline(cell), tag)
+			}
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	b.WriteString("</thead>\n<tbody>\n")
+	for i += 2; i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]); i++ {
+		writeRow(splitTableRow(lines[i]), "td")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// splitTableRow splits a table row on unescaped pipes.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for j := 0; j < len(line); j++ {
+		switch {
+		case line[j] == '\\' && j+1 < len(line) && line[j+1] == '|':
+			cell.WriteByte('|')
+			j++
+		case line[j] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[j])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderInline renders code spans, links, images, emphasis and line breaks. Code spans, escapes
+// and links are replaced by tokens first so that their content is not formatted again.
+func renderInline(s string) string {
+	var tokens []string
+	token := func(html string) string {
+		tokens = append(tokens, html)
+		return "\x00" + strconv.Itoa(len(tokens)-1) + "\x00"
+	}
+
+	var b strings.Builder
+	for j := 0; j < len(s); {
+		if s[j] != '`' {
+			b.WriteByte(s[j])
+			j++
+			continue
+		}
+		n := 1
+		for j+n < len(s) && s[j+n] == '`' {
+			n++
+		}
+		end := -1
+		for k := j + n; k < len(s); {
+			if s[k] != '`' {
+				k++
+				continue
+			}
+			m := 1
+			for k+m < len(s) && s[k+m] == '`' {
+				m++
+			}
+			if m == n {
+				end = k
+				break
+			}
+			k += m
+		}
+		if end < 0 {
+			b.WriteString(s[j : j+n])
+			j += n
+			continue
+		}
+		code := strings.ReplaceAll(s[j+n:end], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+			code = code[1 : len(code)-1]
+		}
+		b.WriteString(token("<code>" + html.EscapeString(code) + "</code>"))
+		j = end + n
+	}
+	s = b.String()
+
+	s = escapePattern.ReplaceAllStringFunc(s, func(m string) string {
+		return token(html.EscapeString(m[1:]))
+	})
+	s = autolinkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		url := html.EscapeString(m[1 : len(m)-1])
+		return token(`<a href="` + url + `">` + url + `</a>`)
+	})
+	s = html.EscapeString(s)
+	s = imagePattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := imagePattern.FindStringSubmatch(m)
+		img := `<img src="` + sub[2] + `" alt="` + sub[1] + `"`
+		if sub[3] != "" {
+			img += ` title="` + sub[3] + `"`
+		}
+		return token(img + ">")
+	})
+	s = linkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkPattern.FindStringSubmatch(m)
+		a := `<a href="` + sub[2] + `"`
+		if sub[3] != "" {
+			a += ` title="` + sub[3] + `"`
+		}
+		return token(a + ">" + renderEmphasis(sub[1]) + "</a>")
+	})
+	s = renderEmphasis(s)
+	s = breakPattern.ReplaceAllString(s, "<br>\n")
+
+	// tokens may contain other tokens, e.g. a link around a code span
+	for range 8 {
+		if !strings.Contains(s, "\x00") {
+			break
+		}
+		s = tokenPattern.ReplaceAllStringFunc(s, func(m string) string {
+			n, _ := strconv.Atoi(m[1 : len(m)-1])
+			return tokens[n]
+		})
+	}
+	return s
+}
+
+func renderEmphasis(s string) string {
+	s = strongPattern.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = emPattern.ReplaceAllString(s, "${2}<em>$1$3</em>$4")
+	return strikePattern.ReplaceAllString(s, "<del>$1</del>")
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package docconvert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const sampleMarkdown = "# Report\n\nSome *em*, **bold** and `code` with a [link](https://example.com/a_b?x=1&y=2).\n\n" +
+	"- one\n- two\n  - nested\n- three\n\n3. third\n4. fourth\n\n> quoted\n\n```go\nfmt.Println(\"<hi>\")\n```\n\n" +
+	"| Name | Total |\n|---|--:|\n| a \\| b | **2** |\n\nline one  \nline two\n"
+
+func TestMarkdownToHTML(t *testing.T) {
+	cases := map[string]string{
+		"# Title #":                      "<h1>Title</h1>\n",
+		"Setext\n---":                    "<h2>Setext</h2>\n",
+		"a <b> & c":                      "<p>a &lt;b&gt; &amp; c</p>\n",
+		"snake_case and _em_":            "<p>snake_case and <em>em</em></p>\n",
+		"\\*not em\\*":                   "<p>*not em*</p>\n",
+		"`a **b**` <https://x.y>":        "<p><code>a **b**</code> <a href=\"https://x.y\">https://x.y</a></p>\n",
+		"![logo](l.png \"Logo\")":        "<p><img src=\"l.png\" alt=\"logo\" title=\"Logo\"></p>\n",
+		"~~gone~~":                       "<p><del>gone</del></p>\n",
+		"***":                            "<hr>\n",
+		"    indented\n    code":         "<pre><code>indented\ncode\n</code></pre>\n",
+		"- a\n\n- b":                     "<ul>\n<li><p>a</p></li>\n<li><p>b</p></li>\n</ul>\n",
+		"1. a\n1. b\n- c":                "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n<ul>\n<li>c</li>\n</ul>\n",
+		"para\n- item":                   "<p>para</p>\n<ul>\n<li>item</li>\n</ul>\n",
+		"> a\ncontinued\n\nafter":        "<blockquote>\n<p>a\ncontinued</p>\n</blockquote>\n<p>after</p>\n",
+		"~~~\n```\n~~~":                  "<pre><code>```\n</code></pre>\n",
+		"| a |\n| :-: |\n| 1 |":          "<table>\n<thead>\n<tr><th style=\"text-align: center\">a</th></tr>\n</thead>\n<tbody>\n<tr><td style=\"text-align: center\">1</td></tr>\n</tbody>\n</table>\n",
+		"[**x** `y`](u \"t\")":           "<p><a href=\"u\" title=\"t\"><strong>x</strong> <code>y</code></a></p>\n",
+		"break\\\nhere":                  "<p>break<br>\nhere</p>\n",
+		"#hashtag":                       "<p>#hashtag</p>\n",
+		"1) a\n2) b\n\n3. c":             "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n<ol start=\"3\">\n<li>c</li>\n</ol>\n",
+		"- [link](http://a.b/c_d_e) end": "<ul>\n<li><a href=\"http://a.b/c_d_e\">link</a> end</li>\n</ul>\n",
+	}
+	for md, expected := range cases {
+		if got := markdownToHTML(md); got != expected {
+			t.Errorf("Unexpected HTML for %q:\n%q\nexpected:\n%q", md, got, expected)
+		}
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	cases := map[string]string{
+		"<h2>Title <small>x</small></h2><p>a  <b>bold </b>text</p>":                                "## Title x\n\na **bold** text\n",
+		"<p>1. not a list * or _em_</p>":                                                           "1\\. not a list \\* or \\_em\\_\n",
+		"<ul><li>a<ul><li>b</li></ul></li><li><p>c</p></li></ul>":                                  "- a\n  - b\n\n- c\n",
+		"<ol start=\"2\"><li>a</li><li>b</li></ol>":                                                "2. a\n3. b\n",
+		"<pre><code class=\"language-sh\">echo ```\n</code></pre>":                                 "````sh\necho ```\n````\n",
+		"<blockquote><p>a</p><p>b</p></blockquote>":                                                "> a\n>\n> b\n",
+		"<table><tr><th>a</th></tr><tr><td>x|y</td><td>z</td></tr></table>":                        "| a |  |\n| --- | --- |\n| x\\|y | z |\n",
+		"<p>a<br>b <a href=\"u v\">link</a> <img src=\"i.png\" alt=\"i\"></p><script>x()</script>": "a\\\nb [link](u%20v) ![i](i.png)\n",
+		"text <code>a`b</code>":                                                                    "text ``a`b``\n",
+	}
+	for src, expected := range cases {
+		got, err := htmlToMarkdown(src)
+		if err != nil {
+			t.Fatalf("Failed to convert %q: %s", src, err.Error())
+		}
+		if got != expected {
+			t.Errorf("Unexpected Markdown for %q:\n%q\nexpected:\n%q", src, got, expected)
+		}
+	}
+}
+
+func TestDocxRoundTrip(t *testing.T) {
+	docx, err := htmlToDocx(markdownToHTML(sampleMarkdown))
+	if err != nil {
+		t.Fatalf("Failed to write DOCX: %s", err.Error())
+	}
+	fragment, err := docxToHTML(docx, 1024*1024)
+	if err != nil {
+		t.Fatalf("Failed to read DOCX: %s", err.Error())
+	}
+	md, err := htmlToMarkdown(fragment)
+	if err != nil {
+		t.Fatalf("Failed to convert to Markdown: %s", err.Error())
+	}
+	for _, expected := range []string{
+		"# Report\n",
+		"Some *em*, **bold** and `code` with a [link](https://example.com/a_b?x=1&y=2).",
+		"- one\n- two\n  - nested\n- three\n",
+		"3. third\n4. fourth\n",
+		"> quoted\n",
+		"```\nfmt.Println(\"<hi>\")\n```\n",
+		"| a \\| b | **2** |",
+		"line one\\\nline two\n",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("Round trip lost %q:\n%s", expected, md)
+		}
+	}
+	if _, err := docxToHTML([]byte("not a zip"), 1024); err == nil {
+		t.Errorf("Expected an error for invalid DOCX data")
+	}
+}
+
+func TestDocConvertServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewDocConvertServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create DocConvertServer: %s", err.Error())
+	}
+	dir := t.TempDir()
+	err = srv.LoadConfig(map[string]any{"allowed_dirs": []any{dir}, "work_path": filepath.Join(dir, ".work")})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init DocConvertServer: %s", err.Error())
+	}
+	ds := srv.(*DocConvertServer)
+	err = os.WriteFile(filepath.Join(dir, "report.md"), []byte(sampleMarkdown), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write input: %s", err.Error())
+	}
+
+	call := func(args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := ds.handleConvert(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	steps := []map[string]any{
+		{"input": "report.md", "output": "out/report.docx", "engine": "native"},
+		{"input": "out/report.docx", "output": "report.html", "engine": "native"},
+		{"input": "report.html", "output": "copy.txt", "to": "markdown", "engine": "native"},
+	}
+	for _, args := range steps {
+		if out, isErr := call(args); isErr || !strings.Contains(out, "with native") {
+			t.Fatalf("Failed to convert %v: %s", args, out)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	if err != nil || !strings.Contains(string(data), "<title>Report</title>") || !strings.Contains(string(data), "<h1>Report</h1>") {
+		t.Errorf("Unexpected HTML: %s", data)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "copy.txt"))
+	if err != nil || !strings.HasPrefix(string(data), "# Report\n\nSome *em*") {
+		t.Errorf("Unexpected Markdown: %s", data)
+	}
+
+	failures := []map[string]any{
+		{"input": "report.md", "output": "report.html"},
+		{"input": "report.md", "output": "../report.html"},
+		{"input": "report.md", "output": "report.odt"},
+		{"input": "report.pdf", "output": "report.md"},
+		{"input": "report.md", "output": "report.pdf", "engine": "native"},
+		{"input": "missing.md", "output": "missing.html"},
+	}
+	for _, args := range failures {
+		if out, isErr := call(args); !isErr {
+			t.Errorf("Expected an error for %v, got: %s", args, out)
+		}
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -42,6 +43,8 @@ const (
 	MaxInlineSize = 1024 * 1024 * 5
 	// MaxBase64Size Maximum size for base64 encoding (1MB)
 	MaxBase64Size = 1024 * 1024 * 1
+	// searchProgressEvery is how many entries search_files walks between progress notifications
+	searchProgressEvery = 500
 )
 const (
 	FilesystemServerName comm.MoLingServerType = "FileSystem"
@@ -59,7 +62,11 @@ type FileInfo struct {
 
 type FilesystemServer struct {
 	abstract.MLService
-	config *FileSystemConfig
+	config  *FileSystemConfig
+	gitLock sync.Mutex // gitLock serializes checkpoints and reverts.
+	// checkpointed is when a checkpoint was last saved for each repository root.
+	checkpointed map[string]time.Time
+	versions     *versionStore // versions is nil unless the previous versions of files are kept.
 }
 
 func NewFilesystemServer(ctx context.Context) (abstract.Service, error) {
@@ -69,6 +76,7 @@ func NewFilesystemServer(ctx context.Context) (abstract.Service, error) {
 	userDataDir := filepath.Join(globalConf.BasePath, "data")
 
 	fc := NewFileSystemConfig(userDataDir)
+	fc.VersionsPath = filepath.Join(globalConf.BasePath, "data", "versions")
 
 	lger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
 	if !ok {
@@ -97,6 +105,15 @@ func (fs *FilesystemServer) Init() error {
 	fs.AddResource(mcp.NewResource("file://", "File System",
 		mcp.WithResourceDescription("Access to files and directories on the local file system"),
 	), fs.handleReadResource)
+	fs.AddResourceTemplate(mcp.NewResourceTemplate("file://{+path}", "File",
+		mcp.WithTemplateDescription("A file or directory inside one of the allowed directories"),
+	), fs.handleReadResource)
+	for _, dir := range fs.config.allowedDirs {
+		fs.AddResource(mcp.NewResource(utils.PathToResourceURI(dir), filepath.Base(dir),
+			mcp.WithResourceDescription(fmt.Sprintf("Allowed directory %s", dir)),
+			mcp.WithMIMEType("text/plain"),
+		), fs.handleReadResource)
+	}
 
 	pe := abstract.PromptEntry{
 		PromptVar: mcp.Prompt{
@@ -110,13 +127,14 @@ func (fs *FilesystemServer) Init() error {
 	// Register tool handlers
 	fs.AddTool(mcp.NewTool("read_file",
 		mcp.WithDescription("Read the complete contents of a file from the file system."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
 			mcp.Description("Relative path to the file to read"),
 			mcp.Required(),
 		),
 	), fs.handleReadFile)
 
-	fs.AddTool(mcp.NewTool(
+	fs.AddStructuredTool(mcp.NewTool(
 		"write_file",
 		mcp.WithDescription("Create a new file or overwrite an existing file with new content."),
 		mcp.WithString("path",
@@ -127,16 +145,18 @@ func (fs *FilesystemServer) Init() error {
 			mcp.Description("Content to write to the file"),
 			mcp.Required(),
 		),
-	), fs.handleWriteFile)
+		abstract.WithDryRunArgument(),
+	), writeFileSchema, fs.handleWriteFile)
 
-	fs.AddTool(mcp.NewTool(
+	fs.AddStructuredTool(mcp.NewTool(
 		"list_directory",
 		mcp.WithDescription("Get a detailed listing of all files and directories in a specified path."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
 			mcp.Description("Relative Path of the directory to list"),
 			mcp.Required(),
 		),
-	), fs.handleListDirectory)
+	), listDirectorySchema, fs.handleListDirectory)
 
 	fs.AddTool(mcp.NewTool(
 		"create_directory",
@@ -145,6 +165,7 @@ func (fs *FilesystemServer) Init() error {
 			mcp.Description("Relative Path of the directory to create"),
 			mcp.Required(),
 		),
+		abstract.WithDryRunArgument(),
 	), fs.handleCreateDirectory)
 
 	fs.AddTool(mcp.NewTool(
@@ -158,11 +179,77 @@ func (fs *FilesystemServer) Init() error {
 			mcp.Description("Relative Destination path"),
 			mcp.Required(),
 		),
+		abstract.WithDryRunArgument(),
 	), fs.handleMoveFile)
 
 	fs.AddTool(mcp.NewTool(
+		"fs_tree",
+		mcp.WithDescription("Show the tree of a directory with the size of each file and the number of files and size of each directory, within a token budget. The top levels are always shown and deeper directories are summarized when the budget runs out. Version control directories, node_modules and the entries of .gitignore are skipped."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of the directory"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description("How many levels to list, 3 by default"),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate size of the tree in tokens, 2000 by default"),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("max_bytes",
+			mcp.Description("Size of the tree in bytes, instead of max_tokens"),
+			mcp.Min(1),
+		),
+		mcp.WithArray("ignore",
+			mcp.Description("Further .gitignore style patterns to skip, such as *.log or build/"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithBoolean("all",
+			mcp.Description("Also list the version control directories, node_modules and the entries of .gitignore"),
+		),
+	), fs.handleTree)
+
+	fs.AddTool(mcp.NewTool(
+		"fs_replace",
+		mcp.WithDescription("Search and replace text across the files of a directory. Call it with dry_run true first to get the diff of every file and a preview token, then with the same arguments and the preview to apply it. Binary files, version control directories, node_modules and the entries of .gitignore are skipped."),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of the directory or file"),
+			mcp.Required(),
+		),
+		mcp.WithString("find",
+			mcp.Description("Text to find, or a Go regular expression with regex"),
+			mcp.Required(),
+		),
+		mcp.WithString("replace",
+			mcp.Description("Replacement text. With regex, $1 or ${name} insert the groups of the match"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("regex",
+			mcp.Description("Treat find as a regular expression"),
+		),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Match find regardless of case"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Globs of the files to change, such as *.go or src/**/*.ts, all files by default"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Globs of the files to leave alone"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("preview",
+			mcp.Description("The preview token returned by the dry run, required to apply the replacement"),
+		),
+		abstract.WithDryRunArgument(),
+	), fs.handleReplace)
+
+	fs.AddStructuredTool(mcp.NewTool(
 		"search_files",
 		mcp.WithDescription("Recursively search for files and directories matching a pattern."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
 			mcp.Description("Relative Starting path for the search"),
 			mcp.Required(),
@@ -171,21 +258,70 @@ func (fs *FilesystemServer) Init() error {
 			mcp.Description("Relative Search pattern to match against file names"),
 			mcp.Required(),
 		),
-	), fs.handleSearchFiles)
+	), searchFilesSchema, fs.handleSearchFiles)
 
-	fs.AddTool(mcp.NewTool(
+	fs.AddStructuredTool(mcp.NewTool(
 		"get_file_info",
 		mcp.WithDescription("Retrieve detailed metadata about a file or directory."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("path",
 			mcp.Description("Relative Path to the file or directory"),
 			mcp.Required(),
 		),
-	), fs.handleGetFileInfo)
+	), fileInfoSchema, fs.handleGetFileInfo)
 
-	fs.AddTool(mcp.NewTool(
+	fs.AddStructuredTool(mcp.NewTool(
 		"list_allowed_directories",
 		mcp.WithDescription("Returns the list of directories that this server is allowed to access."),
-	), fs.handleListAllowedDirectories)
+		mcp.WithReadOnlyHintAnnotation(true),
+	), allowedDirectoriesSchema, fs.handleListAllowedDirectories)
+
+	fs.AddStructuredTool(mcp.NewTool(
+		"fs_history",
+		mcp.WithDescription("List the previous versions of a file kept before the FileSystem tools changed it, the newest first. Versions are kept if FileSystem.versions is set."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of the file"),
+			mcp.Required(),
+		),
+	), historySchema, fs.handleHistory)
+
+	fs.AddTool(mcp.NewTool(
+		"fs_restore_version",
+		mcp.WithDescription("Restore a previous version of a file from fs_history. The current content is kept as a version first, so the restore can be undone."),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of the file"),
+			mcp.Required(),
+		),
+		mcp.WithString("version",
+			mcp.Description("The version from fs_history"),
+			mcp.Required(),
+		),
+		abstract.WithDryRunArgument(),
+	), fs.handleRestoreVersion)
+
+	fs.AddStructuredTool(mcp.NewTool(
+		"fs_list_checkpoints",
+		mcp.WithDescription("List the checkpoints of the git repository that contains a path, newest first. A checkpoint is saved before a file of the repository is changed if git_checkpoint is enabled."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of a file or directory inside the repository"),
+			mcp.Required(),
+		),
+	), listCheckpointsSchema, fs.handleListCheckpoints)
+
+	fs.AddStructuredTool(mcp.NewTool(
+		"fs_revert_to_checkpoint",
+		mcp.WithDescription("Undo the changes made to a file or directory of a git repository since a checkpoint: changed and deleted files get their content back and files created since are removed. The current state is saved as a new checkpoint first."),
+		mcp.WithString("path",
+			mcp.Description("Relative Path of the file or directory to revert, the repository root reverts everything"),
+			mcp.Required(),
+		),
+		mcp.WithString("checkpoint",
+			mcp.Description("ID of the checkpoint from fs_list_checkpoints, defaults to the newest one"),
+		),
+		abstract.WithDryRunArgument(),
+	), revertCheckpointSchema, fs.handleRevertToCheckpoint)
 	return nil
 }
 
@@ -205,8 +341,32 @@ func (fs *FilesystemServer) handlePrompt(ctx context.Context, request mcp.GetPro
 	}, nil
 }
 
+// allowedDirs returns the allowed directories for the tool call handled in ctx. With use_roots, they are narrowed to
+// the workspace roots of the client, if it declares any. Access is denied when the roots cannot be listed.
+func (fs *FilesystemServer) allowedDirs(ctx context.Context) ([]string, error) {
+	if !fs.config.UseRoots {
+		return fs.config.allowedDirs, nil
+	}
+	roots, err := fs.ClientRoots(ctx)
+	if err != nil {
+		fs.Logger.Warn().Err(err).Msg("failed to get the roots of the client")
+		return nil, fmt.Errorf("access denied - failed to get the roots of the client: %w", err)
+	}
+	if len(roots) == 0 {
+		return fs.config.allowedDirs, nil
+	}
+	narrowed := abstract.NarrowToRoots(fs.config.allowedDirs, roots)
+	if len(narrowed) == 0 {
+		return nil, fmt.Errorf("access denied - none of the client's roots %s is inside the allowed directories", strings.Join(roots, ", "))
+	}
+	for i, dir := range narrowed {
+		narrowed[i] = dir + string(filepath.Separator)
+	}
+	return narrowed, nil
+}
+
 // isPathInAllowedDirs checks if a path is within any of the allowed directories
-func (fs *FilesystemServer) isPathInAllowedDirs(path string) bool {
+func isPathInAllowedDirs(path string, allowedDirs []string) bool {
 	// Ensure path is absolute and clean
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -225,7 +385,7 @@ func (fs *FilesystemServer) isPathInAllowedDirs(path string) bool {
 	}
 
 	// Check if the path is within any of the allowed directories
-	for _, dir := range fs.config.allowedDirs {
+	for _, dir := range allowedDirs {
 		if strings.HasPrefix(absPath, dir) {
 			return true
 		}
@@ -233,11 +393,21 @@ func (fs *FilesystemServer) isPathInAllowedDirs(path string) bool {
 	return false
 }
 
-func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
+// validatePath resolves a path against the allowed directories of the tool call handled in ctx.
+func (fs *FilesystemServer) validatePath(ctx context.Context, requestedPath string) (string, error) {
+	allowedDirs, err := fs.allowedDirs(ctx)
+	if err != nil {
+		return "", err
+	}
+	return validatePathIn(requestedPath, allowedDirs)
+}
+
+// validatePathIn resolves a path against allowedDirs, following symlinks. Relative paths are joined to the first directory.
+func validatePathIn(requestedPath string, allowedDirs []string) (string, error) {
 	// Always convert to absolute path first
 	var hasPrefix bool
 	var firstDir string
-	for _, dir := range fs.config.allowedDirs {
+	for _, dir := range allowedDirs {
 		if firstDir == "" {
 			firstDir = dir
 		}
@@ -251,12 +421,12 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 	}
 	abs, err := filepath.Abs(requestedPath)
 	if err != nil {
-		return "", fmt.Errorf("invalid path: %w", err)
+		return "", abstract.Errorf(abstract.CodeInvalidArgument, "invalid path: %w", err)
 	}
 
 	// Check if path is within allowed directories
-	if !fs.isPathInAllowedDirs(abs) {
-		return "", fmt.Errorf("access denied - path outside allowed directories: %s", abs)
+	if !isPathInAllowedDirs(abs, allowedDirs) {
+		return "", abstract.Errorf(abstract.CodePolicyDenied, "access denied - path outside allowed directories: %s", abs)
 	}
 
 	// Handle symlinks
@@ -269,11 +439,11 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 		parent := filepath.Dir(abs)
 		realParent, err := filepath.EvalSymlinks(parent)
 		if err != nil {
-			return "", fmt.Errorf("parent directory does not exist: %s", parent)
+			return "", abstract.Errorf(abstract.CodeNotFound, "parent directory does not exist: %s", parent)
 		}
 
-		if !fs.isPathInAllowedDirs(realParent) {
-			return "", fmt.Errorf(
+		if !isPathInAllowedDirs(realParent, allowedDirs) {
+			return "", abstract.Errorf(abstract.CodePolicyDenied,
 				"access denied - parent directory outside allowed directories",
 			)
 		}
@@ -281,10 +451,8 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 	}
 
 	// Check if the real path (after resolving symlinks) is still within allowed directories
-	if !fs.isPathInAllowedDirs(realPath) {
-		return "", fmt.Errorf(
-			"access denied - symlink target outside allowed directories",
-		)
+	if !isPathInAllowedDirs(realPath, allowedDirs) {
+		return "", abstract.Errorf(abstract.CodePolicyDenied, "access denied - symlink target outside allowed directories")
 	}
 
 	return realPath, nil
@@ -307,19 +475,32 @@ func (fs *FilesystemServer) getFileStats(path string) (FileInfo, error) {
 	}, nil
 }
 
-func (fs *FilesystemServer) searchFiles(rootPath, pattern string) ([]string, error) {
+// searchFiles walks rootPath for names containing pattern. It stops when ctx is cancelled and reports progress every searchProgressEvery entries.
+func (fs *FilesystemServer) searchFiles(ctx context.Context, rootPath, pattern string) ([]string, error) {
 	var results []string
+	var walked int
 	pattern = strings.ToLower(pattern)
+	allowedDirs, err := fs.allowedDirs(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(
+	err = filepath.Walk(
 		rootPath,
 		func(path string, info os.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			walked++
+			if walked%searchProgressEvery == 0 {
+				fs.ReportProgress(ctx, float64(walked), 0, fmt.Sprintf("searched %d entries, %d matches", walked, len(results)))
+			}
 			if err != nil {
 				return nil // Skip errors and continue
 			}
 
 			// Try to validate path
-			if _, err := fs.validatePath(path); err != nil {
+			if _, err := validatePathIn(path, allowedDirs); err != nil {
 				return nil // Skip invalid paths
 			}
 
@@ -349,7 +530,7 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 	path := strings.TrimPrefix(uri, "file://")
 
 	// Validate the path
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -416,6 +597,19 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 	if err != nil {
 		return nil, err
 	}
+	if content, found := fs.redact(validPath, content); found != nil {
+		// Redacted content is text, also for files that would otherwise be returned as a blob, such as key.pem.
+		if !utils.IsTextFile(mimeType) {
+			mimeType = "text/plain"
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      uri,
+				MIMEType: mimeType,
+				Text:     string(content),
+			},
+		}, nil
+	}
 
 	// Handle based on content type
 	if utils.IsTextFile(mimeType) {
@@ -457,20 +651,20 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
 	}
 
 	// 判断 前缀是不是已经包含了
 	//path = filepath.Join(fss.config.CachePath, path)
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("validate Path Error: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "validate Path Error: %v", err), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("check directory error: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "check directory error: %v", err), nil
 	}
 
 	if info.IsDir() {
@@ -522,7 +716,15 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 	// Read file content
 	content, err := os.ReadFile(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error reading file: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error reading file: %v", err), nil
+	}
+	if content, found := fs.redact(validPath, content); found != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(string(content)),
+				mcp.NewTextContent(fmt.Sprintf("Redacted %s from %s.", strings.Join(found, ", "), path)),
+			},
+		}, nil
 	}
 
 	// Handle based on content type
@@ -613,16 +815,16 @@ func (fs *FilesystemServer) handleWriteFile(ctx context.Context, request mcp.Cal
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
 	}
 	content, ok := args["content"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Content must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "content must be a string"), nil
 	}
 
 	//path = filepath.Join(fss.config.CachePath, path)
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -636,29 +838,48 @@ func (fs *FilesystemServer) handleWriteFile(ctx context.Context, request mcp.Cal
 	}
 
 	// Check if it'fss a directory
-	if info, err := os.Stat(validPath); err == nil && info.IsDir() {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Cannot write to a directory:%s", validPath)), nil
+	info, statErr := os.Stat(validPath)
+	if statErr == nil && info.IsDir() {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "Error: Cannot write to a directory:%s", validPath), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		if statErr == nil {
+			return abstract.NewDryRunResult("would overwrite %s (%d bytes) with %d bytes", validPath, info.Size(), len(content)), nil
+		}
+		return abstract.NewDryRunResult("would create %s with %d bytes", validPath, len(content)), nil
 	}
+	if statErr == nil && fs.config.ConfirmOverwrite && fs.CanElicit(ctx) {
+		ok, err := fs.Confirm(ctx, fmt.Sprintf("Overwrite %s (%d bytes)?", validPath, info.Size()))
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: could not confirm overwriting %s: %v", path, err), nil
+		}
+		if !ok {
+			return abstract.NewErrorResultf(abstract.CodePolicyDenied, "Error: the user declined to overwrite %s", path), nil
+		}
+	}
+
+	fs.beforeChange(ctx, "write_file", validPath)
 
 	// Create parent directories if they don't exist
 	parentDir := filepath.Dir(validPath)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating parent directories: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error creating parent directories: %v", err), nil
 	}
 
 	if err := os.WriteFile(validPath, []byte(content), 0644); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error writing file: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error writing file: %v", err), nil
 	}
+	fs.NotifyResourceUpdated(utils.PathToResourceURI(validPath))
 
 	// Get file info for the response
-	info, err := os.Stat(validPath)
+	info, err = os.Stat(validPath)
 	if err != nil {
 		// File was written but we couldn't get info
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully wrote to %s", path)), nil
+		return abstract.NewStructuredResult(fmt.Sprintf("Successfully wrote to %s", path), WriteFileOutput{Path: validPath, URI: utils.PathToResourceURI(validPath)}), nil
 	}
 
 	resourceURI := utils.PathToResourceURI(validPath)
-	return &mcp.CallToolResult{
+	return abstract.WithStructuredContent(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -673,59 +894,64 @@ func (fs *FilesystemServer) handleWriteFile(ctx context.Context, request mcp.Cal
 				},
 			},
 		},
-	}, nil
+	}, WriteFileOutput{Path: validPath, URI: resourceURI, Size: info.Size()}), nil
 }
 
 func (fs *FilesystemServer) handleListDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("validate path error: %v, path:%s", err, validPath)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "validate path error: %v, path:%s", err, validPath), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Check directory %s Error: %v", validPath, err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Check directory %s Error: %v", validPath, err), nil
 	}
 
 	if !info.IsDir() {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Path is not a directory:%s", validPath)), nil
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "Error: Path is not a directory:%s", validPath), nil
 	}
 
 	entries, err := os.ReadDir(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error reading directory: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error reading directory: %v", err), nil
 	}
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", validPath))
+	output := ListDirectoryOutput{Path: validPath, Entries: make([]Entry, 0, len(entries))}
 
 	for _, entry := range entries {
 		entryPath := filepath.Join(validPath, entry.Name())
 		resourceURI := utils.PathToResourceURI(entryPath)
+		item := Entry{Name: entry.Name(), Path: entryPath, URI: resourceURI, Type: "file"}
 
 		if entry.IsDir() {
+			item.Type = "dir"
 			result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", entry.Name(), resourceURI))
 		} else {
 			info, err := entry.Info()
 			if err == nil {
+				item.Size = info.Size()
 				result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n",
 					entry.Name(), resourceURI, info.Size()))
 			} else {
 				result.WriteString(fmt.Sprintf("[FILE] %s (%s)\n", entry.Name(), resourceURI))
 			}
 		}
+		output.Entries = append(output.Entries, item)
 	}
 
 	// Return both text content and embedded resource
 	resourceURI := utils.PathToResourceURI(validPath)
-	return &mcp.CallToolResult{
+	return abstract.WithStructuredContent(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -740,19 +966,19 @@ func (fs *FilesystemServer) handleListDirectory(ctx context.Context, request mcp
 				},
 			},
 		},
-	}, nil
+	}, output), nil
 }
 
 func (fs *FilesystemServer) handleCreateDirectory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("path must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
 	}
 
 	// Check if path already exists
@@ -776,11 +1002,14 @@ func (fs *FilesystemServer) handleCreateDirectory(ctx context.Context, request m
 				},
 			}, nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Path exists but is not a directory: %s", path)), nil
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "Error: Path exists but is not a directory: %s", path), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		return abstract.NewDryRunResult("would create the directory %s", validPath), nil
 	}
 
 	if err := os.MkdirAll(validPath, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating directory: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error creating directory: %v", err), nil
 	}
 
 	resourceURI := utils.PathToResourceURI(validPath)
@@ -806,37 +1035,47 @@ func (fs *FilesystemServer) handleMoveFile(ctx context.Context, request mcp.Call
 	args := request.GetArguments()
 	source, ok := args["source"].(string)
 	if !ok {
-		return mcp.NewToolResultError("source must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "source must be a string"), nil
 	}
 	destination, ok := args["destination"].(string)
 	if !ok {
-		return mcp.NewToolResultError("destination must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "destination must be a string"), nil
 	}
 
-	validSource, err := fs.validatePath(source)
+	validSource, err := fs.validatePath(ctx, source)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error with source path: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error with source path: %v", err), nil
 	}
 
 	// Check if source exists
 	if _, err := os.Stat(validSource); os.IsNotExist(err) {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Source does not exist: %s", source)), nil
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "Error: Source does not exist: %s", source), nil
 	}
 
-	validDest, err := fs.validatePath(destination)
+	validDest, err := fs.validatePath(ctx, destination)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error with destination path: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error with destination path: %v", err), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		if _, err := os.Stat(validDest); err == nil {
+			return abstract.NewDryRunResult("would move %s to %s, replacing it", validSource, validDest), nil
+		}
+		return abstract.NewDryRunResult("would move %s to %s", validSource, validDest), nil
 	}
 
+	fs.beforeChange(ctx, "move_file", validSource, validDest)
+
 	// Create parent directory for destination if it doesn't exist
 	destDir := filepath.Dir(validDest)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating destination directory: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error creating destination directory: %v", err), nil
 	}
 
 	if err := os.Rename(validSource, validDest); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error moving file: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error moving file: %v", err), nil
 	}
+	fs.NotifyResourceUpdated(utils.PathToResourceURI(validSource))
+	fs.NotifyResourceUpdated(utils.PathToResourceURI(validDest))
 
 	resourceURI := utils.PathToResourceURI(validDest)
 	return &mcp.CallToolResult{
@@ -865,35 +1104,36 @@ func (fs *FilesystemServer) handleSearchFiles(ctx context.Context, request mcp.C
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("path must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
 	}
 	pattern, ok := args["pattern"].(string)
 	if !ok {
-		return mcp.NewToolResultError("pattern must be a string"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "pattern must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
 	}
 
 	if !info.IsDir() {
-		return mcp.NewToolResultError("Error: Search path must be a directory"), nil
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "Error: Search path must be a directory"), nil
 	}
 
-	results, err := fs.searchFiles(validPath, pattern)
+	results, err := fs.searchFiles(ctx, validPath, pattern)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error searching files: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error searching files: %v", err), nil
 	}
 
+	output := SearchFilesOutput{Path: validPath, Pattern: pattern, Matches: make([]Entry, 0, len(results))}
 	if len(results) == 0 {
-		return mcp.NewToolResultText(fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path)), nil
+		return abstract.NewStructuredResult(fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path), output), nil
 	}
 
 	// Format results with resource URIs
@@ -902,30 +1142,34 @@ func (fs *FilesystemServer) handleSearchFiles(ctx context.Context, request mcp.C
 
 	for _, result := range results {
 		resourceURI := utils.PathToResourceURI(result)
+		item := Entry{Name: filepath.Base(result), Path: result, URI: resourceURI, Type: "file"}
 		info, err := os.Stat(result)
 		if err == nil {
 			if info.IsDir() {
+				item.Type = "dir"
 				formattedResults.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", result, resourceURI))
 			} else {
+				item.Size = info.Size()
 				formattedResults.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n",
 					result, resourceURI, info.Size()))
 			}
 		} else {
 			formattedResults.WriteString(fmt.Sprintf("%s (%s)\n", result, resourceURI))
 		}
+		output.Matches = append(output.Matches, item)
 	}
 
-	return mcp.NewToolResultText(formattedResults.String()), nil
+	return abstract.NewStructuredResult(formattedResults.String(), output), nil
 }
 
 func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError(fmt.Errorf("path %v must be a string", args["path"]).Error()), nil
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "path %v must be a string", args["path"]), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -940,7 +1184,7 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 
 	info, err := fs.getFileStats(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error getting file info: %v", err)), nil
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error getting file info: %v", err), nil
 	}
 
 	// Get MIME type for files
@@ -959,7 +1203,7 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 		fileTypeText = "File"
 	}
 
-	return &mcp.CallToolResult{
+	return abstract.WithStructuredContent(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -990,13 +1234,17 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 				},
 			},
 		},
-	}, nil
+	}, FileInfoOutput{FileInfo: info, Path: validPath, URI: resourceURI, MIMEType: mimeType}), nil
 }
 
 func (fs *FilesystemServer) handleListAllowedDirectories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	allowedDirs, err := fs.allowedDirs(ctx)
+	if err != nil {
+		return abstract.NewErrorResult(abstract.ClassifyError(err), err.Error()), nil
+	}
 	// Remove the trailing separator for display purposes
-	displayDirs := make([]string, len(fs.config.allowedDirs))
-	for i, dir := range fs.config.allowedDirs {
+	displayDirs := make([]string, len(allowedDirs))
+	for i, dir := range allowedDirs {
 		displayDirs[i] = strings.TrimSuffix(dir, string(filepath.Separator))
 	}
 
@@ -1008,7 +1256,7 @@ func (fs *FilesystemServer) handleListAllowedDirectories(ctx context.Context, re
 		result.WriteString(fmt.Sprintf("%s (%s)\n", dir, resourceURI))
 	}
 
-	return mcp.NewToolResultText(result.String()), nil
+	return abstract.NewStructuredResult(result.String(), AllowedDirectoriesOutput{Directories: displayDirs}), nil
 }
 
 // Config returns the configuration of the service as a string.
@@ -1039,5 +1287,12 @@ func (fs *FilesystemServer) LoadConfig(jsonData map[string]any) error {
 		return err
 	}
 	fs.config.allowedDirs = strings.Split(fs.config.AllowedDir, ",")
-	return fs.config.Check()
+	if err := fs.config.Check(); err != nil {
+		return err
+	}
+	fs.versions = nil
+	if fs.config.Versions > 0 {
+		fs.versions = &versionStore{dir: fs.config.VersionsPath, keep: fs.config.Versions}
+	}
+	return nil
 }
@@ -28,7 +28,7 @@ const (
 	FileSystemPromptDefault = `
 You are a powerful local filesystem management assistant capable of performing various file operations and management tasks. Your capabilities include:
 
-1. **File Browsing**: Navigate to specified directories to load lists of files and folders.
+1. **File Browsing**: Navigate to specified directories to load lists of files and folders. Use fs_tree to get an overview of a project before listing single directories.
 
 2. **File Operations**:
    - Create new files or folders
@@ -40,6 +40,7 @@ You are a powerful local filesystem management assistant capable of performing v
    - Read the contents of text files and return them
    - Write text to specified files
    - Append content to existing files
+   - Search and replace across many files with fs_replace: preview the diff with dry_run first, then apply it with the preview token
 
 4. **File Information Retrieval**:
    - Retrieve properties of files or folders (e.g., size, creation date, modification date)
@@ -49,6 +50,17 @@ You are a powerful local filesystem management assistant capable of performing v
    - Search for files in specified directories, supporting wildcard matching
    - Filter search results by file type or modification date
 
+6. **Checkpoints**:
+   - With git_checkpoint enabled, the git work tree of a file is saved as a checkpoint before the file is changed
+   - List the checkpoints of a repository with fs_list_checkpoints
+   - Undo the changes made to a file or directory since a checkpoint with fs_revert_to_checkpoint
+
+7. **Versions**:
+   - With versions enabled, the previous versions of the files you change are kept
+   - List them with fs_history and bring one back with fs_restore_version
+
+Private keys, the secrets of .env files and access tokens in the files read are replaced by [REDACTED ...] markers. Do not try to recover the redacted values; ask the user if one is needed.
+
 For all actions, please provide clear instructions, including:
 - The specific action you want to perform
 - Required parameters (directory paths, filenames, content, etc.)
@@ -70,6 +82,28 @@ type FileSystemConfig struct {
 	AllowedDir  string `json:"allowed_dir"` // AllowedDirs is a list of allowed directories. split by comma. e.g. /tmp,/var/tmp
 	allowedDirs []string
 	CachePath   string `json:"cache_path"` // CachePath is the root path for the file system.
+	// ConfirmOverwrite asks the user before write_file replaces an existing file, if the client supports elicitation.
+	ConfirmOverwrite bool `json:"confirm_overwrite"`
+	// UseRoots narrows the allowed directories to the workspace roots of the client, if the client declares any.
+	UseRoots bool `json:"use_roots"`
+	// GitCheckpoint snapshots the git work tree of a file under refs/moling/checkpoints before the file is changed.
+	GitCheckpoint bool `json:"git_checkpoint"`
+	// CheckpointInterval is the number of seconds after a checkpoint in which further changes to the same repository
+	// make no new one, so that a series of edits can be undone as a whole. 0 saves a checkpoint before every change.
+	CheckpointInterval int `json:"checkpoint_interval"`
+	// CheckpointKeep is how many checkpoints are kept per repository, 0 keeps all of them.
+	CheckpointKeep int `json:"checkpoint_keep"`
+	// Redact masks private keys, the secrets of .env files and access tokens in the files read.
+	Redact bool `json:"redact"`
+	// RedactPatterns are regular expressions of further secrets to redact.
+	RedactPatterns []string `json:"redact_patterns"`
+	// RedactExempt are the paths, file names or glob patterns of files read without redaction.
+	RedactExempt []string `json:"redact_exempt"`
+	redactions   []redactionRule
+	// Versions is how many previous versions of each file changed by the tools are kept, 0 keeps none.
+	Versions int `json:"versions"`
+	// VersionsPath is the directory of the version store.
+	VersionsPath string `json:"versions_path"`
 }
 
 // NewFileSystemConfig creates a new FileSystemConfig with the given allowed directories.
@@ -82,9 +116,14 @@ func NewFileSystemConfig(path string) *FileSystemConfig {
 	}
 
 	return &FileSystemConfig{
-		AllowedDir:  path,
-		CachePath:   path,
-		allowedDirs: paths,
+		AllowedDir:         path,
+		CachePath:          path,
+		allowedDirs:        paths,
+		ConfirmOverwrite:   true,
+		UseRoots:           true,
+		CheckpointInterval: 300,
+		CheckpointKeep:     20,
+		Redact:             true,
 	}
 }
 
@@ -108,6 +147,23 @@ func (fc *FileSystemConfig) Check() error {
 		normalized = append(normalized, filepath.Clean(abs)+string(filepath.Separator))
 	}
 	fc.allowedDirs = normalized
+	redactions, err := compileRedactions(fc.RedactPatterns)
+	if err != nil {
+		return err
+	}
+	fc.redactions = redactions
+	if fc.CheckpointInterval < 0 {
+		return fmt.Errorf("checkpoint_interval must not be negative, got %d", fc.CheckpointInterval)
+	}
+	if fc.Versions < 0 {
+		return fmt.Errorf("versions must not be negative, got %d", fc.Versions)
+	}
+	if fc.Versions > 0 && fc.VersionsPath == "" {
+		return fmt.Errorf("versions_path must be set to keep file versions")
+	}
+	if fc.CheckpointKeep < 0 {
+		return fmt.Errorf("checkpoint_keep must not be negative, got %d", fc.CheckpointKeep)
+	}
 
 	if fc.PromptFile != "" {
 		read, err := os.ReadFile(fc.PromptFile)
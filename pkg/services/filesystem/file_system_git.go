@@ -0,0 +1,409 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// checkpointRefs is the namespace of the checkpoint refs kept in a repository.
+const checkpointRefs = "refs/moling/checkpoints/"
+
+// errNotRepository is returned by gitRoot for a path outside any git work tree.
+var errNotRepository = errors.New("not inside a git work tree")
+
+// Checkpoint is a snapshot of a git work tree taken before the agent changed it.
+type Checkpoint struct {
+	ID      string    `json:"id"`
+	Commit  string    `json:"commit"`
+	Created time.Time `json:"created"`
+	Reason  string    `json:"reason"`
+}
+
+// CheckpointChange is a file that differs between a checkpoint and the work tree.
+type CheckpointChange struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // Status is "restored" for a changed or deleted file and "removed" for a file created since.
+}
+
+// git runs git in dir with the extra environment and returns its trimmed output.
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitRoot returns the top directory of the work tree that contains path, which need not exist yet.
+func gitRoot(ctx context.Context, path string) (string, error) {
+	dir := path
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errNotRepository
+		}
+		dir = parent
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", errNotRepository
+	}
+	root, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return "", errNotRepository
+	}
+	return filepath.Clean(root), nil
+}
+
+// snapshotTree writes the whole work tree of root, untracked files included, to a tree object.
+// It works on a copy of the index, so the staged changes of the user are left alone.
+func snapshotTree(ctx context.Context, root string) (string, error) {
+	tmp, err := os.MkdirTemp("", "moling-checkpoint")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+	if gitIndex, err := git(ctx, root, nil, "rev-parse", "--git-path", "index"); err == nil {
+		if !filepath.IsAbs(gitIndex) {
+			gitIndex = filepath.Join(root, gitIndex)
+		}
+		// Starting from the real index keeps its stat cache, so unchanged files are not hashed again. The copy keeps
+		// the modification time of the index too, which git needs to tell files changed within the same second.
+		if info, err := os.Stat(gitIndex); err == nil {
+			data, err := os.ReadFile(gitIndex)
+			if err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(index, data, 0600); err != nil {
+				return "", err
+			}
+			if err := os.Chtimes(index, info.ModTime(), info.ModTime()); err != nil {
+				return "", err
+			}
+		}
+	}
+	env := []string{"GIT_INDEX_FILE=" + index}
+	if _, err := git(ctx, root, env, "add", "--all", "--", "."); err != nil {
+		return "", err
+	}
+	return git(ctx, root, env, "write-tree")
+}
+
+// listCheckpoints returns the checkpoints of the repository at root, newest first.
+func listCheckpoints(ctx context.Context, root string) ([]Checkpoint, error) {
+	out, err := git(ctx, root, nil, "for-each-ref", "--sort=-refname",
+		"--format=%(refname)%00%(objectname)%00%(creatordate:unix)%00%(contents:subject)", checkpointRefs)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []Checkpoint
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		created, _ := strconv.ParseInt(fields[2], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			ID:      strings.TrimPrefix(fields[0], checkpointRefs),
+			Commit:  fields[1],
+			Created: time.Unix(created, 0),
+			Reason:  fields[3],
+		})
+	}
+	return checkpoints, nil
+}
+
+// createCheckpoint snapshots the work tree at root under a new checkpoint ref and keeps the newest keep of them.
+// No checkpoint is made if the work tree has not changed since the last one, which is returned instead.
+func createCheckpoint(ctx context.Context, root, reason string, keep int) (Checkpoint, bool, error) {
+	tree, err := snapshotTree(ctx, root)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	checkpoints, err := listCheckpoints(ctx, root)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	if len(checkpoints) > 0 {
+		if last, err := git(ctx, root, nil, "rev-parse", checkpoints[0].Commit+"^{tree}"); err == nil && last == tree {
+			return checkpoints[0], false, nil
+		}
+	}
+
+	args := []string{"commit-tree", tree, "-m", reason}
+	if head, err := git(ctx, root, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil && head != "" {
+		args = append(args, "-p", head)
+	}
+	// commit-tree needs an identity, which a fresh machine may not have configured.
+	env := []string{
+		"GIT_AUTHOR_NAME=MoLing", "GIT_AUTHOR_EMAIL=moling@localhost",
+		"GIT_COMMITTER_NAME=MoLing", "GIT_COMMITTER_EMAIL=moling@localhost",
+	}
+	commit, err := git(ctx, root, env, args...)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	now := time.Now()
+	id := now.UTC().Format("20060102-150405.000000")
+	if _, err := git(ctx, root, nil, "update-ref", checkpointRefs+id, commit); err != nil {
+		return Checkpoint{}, false, err
+	}
+	checkpoints = append([]Checkpoint{{ID: id, Commit: commit, Created: now, Reason: reason}}, checkpoints...)
+	if keep > 0 {
+		for _, old := range checkpoints[min(keep, len(checkpoints)):] {
+			if _, err := git(ctx, root, nil, "update-ref", "-d", checkpointRefs+old.ID); err != nil {
+				return Checkpoint{}, false, err
+			}
+		}
+	}
+	return checkpoints[0], true, nil
+}
+
+// checkpointChanges lists the files under path that differ between the checkpoint commit and the work tree at root.
+func checkpointChanges(ctx context.Context, root, commit, path string) ([]CheckpointChange, error) {
+	tree, err := snapshotTree(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, root, nil, "diff-tree", "-r", "--no-renames", "--name-status", "-z", commit, tree, "--", filepath.ToSlash(rel))
+	if err != nil {
+		return nil, err
+	}
+	var changes []CheckpointChange
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := "restored"
+		if fields[i] == "A" {
+			status = "removed"
+		}
+		changes = append(changes, CheckpointChange{Path: fields[i+1], Status: status})
+	}
+	return changes, nil
+}
+
+// restoreCheckpoint brings the changed files back to their content in the checkpoint commit and removes the files
+// created since. Neither the index nor the files outside changes are touched.
+func restoreCheckpoint(ctx context.Context, root, commit string, changes []CheckpointChange) error {
+	var restore []string
+	for _, c := range changes {
+		if c.Status == "removed" {
+			if err := os.Remove(filepath.Join(root, filepath.FromSlash(c.Path))); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		restore = append(restore, c.Path)
+	}
+	if len(restore) == 0 {
+		return nil
+	}
+	tmp, err := os.MkdirTemp("", "moling-checkpoint")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	if _, err := git(ctx, root, env, "read-tree", commit); err != nil {
+		return err
+	}
+	_, err = git(ctx, root, env, append([]string{"checkout-index", "--force", "--"}, restore...)...)
+	return err
+}
+
+// checkpoint snapshots the git work trees that contain paths before a tool changes them, if git_checkpoint is set.
+// A failed checkpoint is logged and does not stop the change.
+func (fs *FilesystemServer) checkpoint(ctx context.Context, tool string, paths ...string) {
+	if !fs.config.GitCheckpoint {
+		return
+	}
+	fs.gitLock.Lock()
+	defer fs.gitLock.Unlock()
+	if fs.checkpointed == nil {
+		fs.checkpointed = make(map[string]time.Time)
+	}
+	interval := time.Duration(fs.config.CheckpointInterval) * time.Second
+	for _, path := range paths {
+		root, err := gitRoot(ctx, path)
+		if err != nil {
+			continue
+		}
+		if last, ok := fs.checkpointed[root]; ok && time.Since(last) < interval {
+			continue
+		}
+		cp, created, err := createCheckpoint(ctx, root, fmt.Sprintf("before %s %s", tool, path), fs.config.CheckpointKeep)
+		if err != nil {
+			fs.Logger.Warn().Err(err).Str("repository", root).Msg("failed to create git checkpoint")
+			continue
+		}
+		fs.checkpointed[root] = time.Now()
+		if created {
+			fs.Logger.Info().Str("repository", root).Str("checkpoint", cp.ID).Msg("created git checkpoint")
+		}
+	}
+}
+
+// checkpointRepository returns the validated path argument and the work tree that contains it.
+func (fs *FilesystemServer) checkpointRepository(ctx context.Context, request mcp.CallToolRequest) (string, string, *mcp.CallToolResult) {
+	path, ok := request.GetArguments()["path"].(string)
+	if !ok {
+		return "", "", abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string")
+	}
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return "", "", abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err)
+	}
+	root, err := gitRoot(ctx, validPath)
+	if err != nil {
+		return "", "", abstract.NewErrorResultf(abstract.CodeNotFound, "Error: %s is %v", path, err)
+	}
+	return validPath, root, nil
+}
+
+func (fs *FilesystemServer) handleListCheckpoints(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	_, root, errResult := fs.checkpointRepository(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	checkpoints, err := listCheckpoints(ctx, root)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error listing checkpoints: %v", err), nil
+	}
+	if checkpoints == nil {
+		checkpoints = []Checkpoint{}
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "%d checkpoints of %s", len(checkpoints), root)
+	for _, cp := range checkpoints {
+		fmt.Fprintf(&result, "\n%s  %s  %s", cp.ID, cp.Created.Format(time.RFC3339), cp.Reason)
+	}
+	return abstract.NewStructuredResult(result.String(), ListCheckpointsOutput{Repository: root, Checkpoints: checkpoints}), nil
+}
+
+func (fs *FilesystemServer) handleRevertToCheckpoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	validPath, root, errResult := fs.checkpointRepository(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	id := abstract.GetString(request.GetArguments(), "checkpoint", "")
+
+	fs.gitLock.Lock()
+	defer fs.gitLock.Unlock()
+	checkpoints, err := listCheckpoints(ctx, root)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error listing checkpoints: %v", err), nil
+	}
+	var target *Checkpoint
+	for i := range checkpoints {
+		if id == "" || checkpoints[i].ID == id {
+			target = &checkpoints[i]
+			break
+		}
+	}
+	if target == nil {
+		if id == "" {
+			return abstract.NewErrorResultf(abstract.CodeNotFound, "Error: %s has no checkpoints", root), nil
+		}
+		return abstract.NewErrorResultf(abstract.CodeNotFound, "Error: %s has no checkpoint %q, see fs_list_checkpoints", root, id), nil
+	}
+
+	changes, err := checkpointChanges(ctx, root, target.Commit, validPath)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error comparing with checkpoint %s: %v", target.ID, err), nil
+	}
+	if changes == nil {
+		changes = []CheckpointChange{}
+	}
+	if len(changes) == 0 {
+		return abstract.NewStructuredResult(fmt.Sprintf("%s already matches checkpoint %s", validPath, target.ID),
+			RevertCheckpointOutput{Repository: root, Checkpoint: target.ID, Changes: changes}), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		return abstract.NewDryRunResult("would restore %d files of %s to checkpoint %s (%s):\n%s",
+			len(changes), validPath, target.ID, target.Reason, describeChanges(changes)), nil
+	}
+	if fs.config.ConfirmOverwrite && fs.CanElicit(ctx) {
+		ok, err := fs.Confirm(ctx, fmt.Sprintf("Restore %d files of %s to checkpoint %s (%s)?", len(changes), validPath, target.ID, target.Reason))
+		if err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: could not confirm the revert: %v", err), nil
+		}
+		if !ok {
+			return abstract.NewErrorResult(abstract.CodePolicyDenied, "Error: the user declined to revert to the checkpoint"), nil
+		}
+	}
+
+	// The current state is kept as a checkpoint too, so that the revert can be undone.
+	backup, _, err := createCheckpoint(ctx, root, fmt.Sprintf("before fs_revert_to_checkpoint %s", target.ID), fs.config.CheckpointKeep)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error saving the current state before the revert: %v", err), nil
+	}
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = filepath.Join(root, filepath.FromSlash(c.Path))
+	}
+	fs.snapshot("fs_revert_to_checkpoint", paths...)
+	if err := restoreCheckpoint(ctx, root, target.Commit, changes); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error restoring checkpoint %s: %v", target.ID, err), nil
+	}
+	// The next change starts a new checkpoint rather than counting on the one saved before the revert.
+	delete(fs.checkpointed, root)
+	for _, c := range changes {
+		fs.NotifyResourceUpdated(utils.PathToResourceURI(filepath.Join(root, filepath.FromSlash(c.Path))))
+	}
+	return abstract.NewStructuredResult(
+		fmt.Sprintf("Restored %d files of %s to checkpoint %s, the previous state is checkpoint %s:\n%s",
+			len(changes), validPath, target.ID, backup.ID, describeChanges(changes)),
+		RevertCheckpointOutput{Repository: root, Checkpoint: target.ID, Backup: backup.ID, Changes: changes}), nil
+}
+
+// describeChanges lists changes one per line.
+func describeChanges(changes []CheckpointChange) string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.Status + " " + c.Path
+	}
+	return strings.Join(lines, "\n")
+}
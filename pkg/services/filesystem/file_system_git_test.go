@@ -0,0 +1,128 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestGitCheckpoints(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	run("init", "-q")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.txt", "one")
+	run("add", "a.txt")
+	run("commit", "-q", "-m", "initial")
+	write("untracked.txt", "keep")
+
+	srv, err := NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create FilesystemServer: %v", err)
+	}
+	if err := srv.LoadConfig(map[string]any{"allowed_dir": repo, "git_checkpoint": true}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	fs := srv.(*FilesystemServer)
+	fs.config.CheckpointInterval = 0
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		res, err := handler(context.Background(), request)
+		if err != nil || res.IsError {
+			t.Fatalf("Unexpected result %+v, %v", res, err)
+		}
+		return res
+	}
+	call(fs.handleWriteFile, map[string]any{"path": "a.txt", "content": "two"})
+	call(fs.handleWriteFile, map[string]any{"path": "new.txt", "content": "new"})
+
+	var listed ListCheckpointsOutput
+	res := call(fs.handleListCheckpoints, map[string]any{"path": "."})
+	if err := json.Unmarshal([]byte(res.Content[1].(mcp.TextContent).Text), &listed); err != nil {
+		t.Fatalf("Failed to parse structured content: %v", err)
+	}
+	if len(listed.Checkpoints) != 2 || listed.Checkpoints[1].Reason != "before write_file "+filepath.Join(repo, "a.txt") {
+		t.Fatalf("Expected a checkpoint before each write, got %+v", listed.Checkpoints)
+	}
+	first := listed.Checkpoints[1].ID
+
+	res = call(fs.handleRevertToCheckpoint, map[string]any{"path": ".", "checkpoint": first, "dry_run": true})
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "restored a.txt") || !strings.Contains(text, "removed new.txt") {
+		t.Errorf("Unexpected dry run %q", text)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "a.txt")); string(data) != "two" {
+		t.Fatalf("Expected the dry run to change nothing, a.txt is %q", data)
+	}
+
+	var reverted RevertCheckpointOutput
+	res = call(fs.handleRevertToCheckpoint, map[string]any{"path": ".", "checkpoint": first})
+	if err := json.Unmarshal([]byte(res.Content[1].(mcp.TextContent).Text), &reverted); err != nil {
+		t.Fatalf("Failed to parse structured content: %v", err)
+	}
+	if len(reverted.Changes) != 2 || reverted.Backup == "" {
+		t.Errorf("Unexpected revert %+v", reverted)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, "a.txt")); string(data) != "one" {
+		t.Errorf("Expected a.txt to be restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected new.txt to be removed, got %v", err)
+	}
+	if status := run("status", "--porcelain"); status != "?? untracked.txt\n" {
+		t.Errorf("Expected only the untracked file to remain, got %q", status)
+	}
+
+	// The revert itself can be undone from the checkpoint saved before it.
+	call(fs.handleRevertToCheckpoint, map[string]any{"path": "a.txt", "checkpoint": reverted.Backup})
+	if data, _ := os.ReadFile(filepath.Join(repo, "a.txt")); string(data) != "two" {
+		t.Errorf("Expected a.txt to be back to the edited content, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected reverting a.txt to leave new.txt alone, got %v", err)
+	}
+}
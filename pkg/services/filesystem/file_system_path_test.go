@@ -0,0 +1,52 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestValidatePathSymlinkEscape(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := filepath.Join(base, "allowed")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	allowedDirs := []string{allowed + string(filepath.Separator)}
+
+	for _, path := range []string{"escape", filepath.Join("escape", "a.txt")} {
+		_, err := validatePathIn(path, allowedDirs)
+		var toolErr *abstract.ToolError
+		if !errors.As(err, &toolErr) || toolErr.Code != abstract.CodePolicyDenied {
+			t.Errorf("%s: expected a %s error, got %v", path, abstract.CodePolicyDenied, err)
+		}
+	}
+}
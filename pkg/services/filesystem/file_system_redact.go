@@ -0,0 +1,127 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactionRule replaces the matches of a pattern in the content of a file.
+type redactionRule struct {
+	name    string
+	pattern *regexp.Regexp
+	// replace is the regexp template of the replacement, which can keep parts of the match such as the key of an
+	// assignment. It defaults to the mask alone.
+	replace string
+	// envOnly limits the rule to .env files, where a secret-looking key is a credential rather than a variable name.
+	envOnly bool
+}
+
+// redactionRules are the secrets redacted from every file read unless redact is turned off.
+var redactionRules = []redactionRule{
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?(-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----|$)`)},
+	{name: "env secret", envOnly: true, replace: "${1}[REDACTED env secret]",
+		pattern: regexp.MustCompile(`(?im)^(\s*(?:export\s+)?[A-Z0-9_.-]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_.-]*\s*[=:]\s*)\S.*?\s*$`)},
+	{name: "url password", replace: "${1}[REDACTED url password]${2}", pattern: regexp.MustCompile(`([a-z][a-z0-9+.-]*://[^\s:/@]+:)[^\s@/]+(@)`)},
+	{name: "aws access key", pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github token", pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "gitlab token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{name: "slack token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "api key", pattern: regexp.MustCompile(`\bsk-(ant-|proj-)?[A-Za-z0-9_-]{20,}\b`)},
+	{name: "stripe key", pattern: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{name: "google api key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`)},
+}
+
+// compileRedactions returns the built-in rules followed by the custom patterns.
+func compileRedactions(patterns []string) ([]redactionRule, error) {
+	rules := append([]redactionRule(nil), redactionRules...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		rules = append(rules, redactionRule{name: "custom pattern", pattern: re})
+	}
+	return rules, nil
+}
+
+// isEnvFile reports whether path names a dotenv file, such as .env, .env.local or prod.env.
+func isEnvFile(path string) bool {
+	base := filepath.Base(path)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// redactExempt reports whether the file at path is returned as is. A pattern matches the whole path, the file
+// name, or a directory that contains the file.
+func (fc *FileSystemConfig) redactExempt(path string) bool {
+	for _, p := range fc.RedactExempt {
+		p = filepath.Clean(p)
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, filepath.Base(path)); ok {
+			return true
+		}
+		if filepath.IsAbs(p) && strings.HasPrefix(path, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact masks the secrets in the content of the file at path and returns it with the names of the kinds of
+// secrets found. Binary content is returned unchanged.
+func (fc *FileSystemConfig) redact(path string, content []byte) ([]byte, []string) {
+	if !fc.Redact || fc.redactExempt(path) || !utf8.Valid(content) {
+		return content, nil
+	}
+	text := string(content)
+	var found []string
+	for _, rule := range fc.redactions {
+		if rule.envOnly && !isEnvFile(path) {
+			continue
+		}
+		count := len(rule.pattern.FindAllStringIndex(text, -1))
+		if count == 0 {
+			continue
+		}
+		replace := rule.replace
+		if replace == "" {
+			replace = "[REDACTED " + rule.name + "]"
+		}
+		text = rule.pattern.ReplaceAllString(text, replace)
+		found = append(found, fmt.Sprintf("%d %s", count, rule.name))
+	}
+	if found == nil {
+		return content, nil
+	}
+	return []byte(text), found
+}
+
+// redact redacts the content of the file at path, logging what was found.
+func (fs *FilesystemServer) redact(path string, content []byte) ([]byte, []string) {
+	content, found := fs.config.redact(path, content)
+	if found != nil {
+		fs.Logger.Info().Str("path", path).Strs("redacted", found).Msg("redacted secrets from file")
+	}
+	return content, found
+}
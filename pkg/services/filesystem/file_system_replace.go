@@ -0,0 +1,306 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+	"github.com/gojue/moling/pkg/utils/diff"
+)
+
+const (
+	// replaceMaxFiles is how many files one fs_replace call may change.
+	replaceMaxFiles = 500
+	// replaceMaxDiff bounds the size of the diffs shown by a preview of fs_replace.
+	replaceMaxDiff = 64 * 1024
+	// replaceDiffEdits bounds the work of the diff of a single file.
+	replaceDiffEdits = 2000
+)
+
+// replacement is a file that fs_replace changes.
+type replacement struct {
+	path    string
+	rel     string
+	old     string
+	new     string
+	count   int
+	mode    os.FileMode
+	oldHash [sha256.Size]byte
+}
+
+// replaceArgs are the arguments of fs_replace that decide what it changes.
+type replaceArgs struct {
+	find       string
+	replace    string
+	regex      bool
+	ignoreCase bool
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+}
+
+// globToRegexp compiles a glob where * and ? stay within a path segment and ** matches any number of them.
+// A glob without a slash matches the file name, one with a slash the path relative to the searched directory.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	glob = filepath.ToSlash(strings.TrimPrefix(glob, "./"))
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		sb.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				sb.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// compileGlobs compiles the globs of an argument.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(globs))
+	for _, g := range globs {
+		re, err := globToRegexp(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchAny reports whether rel matches one of res.
+func matchAny(res []*regexp.Regexp, rel string) bool {
+	for _, re := range res {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// pattern returns the expression that finds the text to replace.
+func (ra *replaceArgs) pattern() (*regexp.Regexp, error) {
+	expr := ra.find
+	if !ra.regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if ra.ignoreCase {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// planReplace finds the files under root that the replacement changes, with their new content. The files skipped
+// by fs_tree, binary files and files too large to read inline are left alone.
+func planReplace(ctx context.Context, root string, ra *replaceArgs) ([]replacement, error) {
+	re, err := ra.pattern()
+	if err != nil {
+		return nil, fmt.Errorf("invalid find expression: %w", err)
+	}
+	rules := make([]ignoreRule, 0, len(treeIgnoreDefault))
+	for _, p := range treeIgnoreDefault {
+		rules = append(rules, ignoreRule{pattern: p})
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	base := root
+	if info.IsDir() {
+		rules = append(rules, readGitignore(root)...)
+	} else {
+		base = filepath.Dir(root)
+	}
+
+	var plan []replacement
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(base, path)
+		if path != root && ignored(rules, rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Symbolic links may lead out of the allowed directories, so they are not followed.
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		slashed := filepath.ToSlash(rel)
+		if len(ra.include) > 0 && !matchAny(ra.include, slashed) || matchAny(ra.exclude, slashed) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > MaxInlineSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+			return nil
+		}
+		old := string(data)
+		count := len(re.FindAllStringIndex(old, -1))
+		if count == 0 {
+			return nil
+		}
+		var updated string
+		if ra.regex {
+			updated = re.ReplaceAllString(old, ra.replace)
+		} else {
+			updated = re.ReplaceAllLiteralString(old, ra.replace)
+		}
+		if updated == old {
+			return nil
+		}
+		if len(plan) == replaceMaxFiles {
+			return fmt.Errorf("the replacement changes more than %d files, narrow it with include or path", replaceMaxFiles)
+		}
+		plan = append(plan, replacement{path: path, rel: slashed, old: old, new: updated, count: count,
+			mode: info.Mode().Perm(), oldHash: sha256.Sum256(data)})
+		return nil
+	})
+	return plan, err
+}
+
+// previewToken identifies a replacement and the content of the files it changes, so that applying it can check
+// that it was previewed and that the files did not change since.
+func previewToken(ra *replaceArgs, plan []replacement) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %t %t\n", ra.find, ra.replace, ra.regex, ra.ignoreCase)
+	for _, r := range plan {
+		fmt.Fprintf(h, "%s %x\n", r.path, r.oldHash)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func (fs *FilesystemServer) handleReplace(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path, ok := args["path"].(string)
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
+	}
+	ra := &replaceArgs{
+		regex:      abstract.GetBool(args, "regex", false),
+		ignoreCase: abstract.GetBool(args, "ignore_case", false),
+	}
+	if ra.find, ok = args["find"].(string); !ok || ra.find == "" {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "find must be a non-empty string"), nil
+	}
+	if ra.replace, ok = args["replace"].(string); !ok {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "replace must be a string"), nil
+	}
+	var err error
+	if ra.include, err = compileGlobs(abstract.GetStringSlice(args, "include", nil)); err != nil {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, err.Error()), nil
+	}
+	if ra.exclude, err = compileGlobs(abstract.GetStringSlice(args, "exclude", nil)); err != nil {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, err.Error()), nil
+	}
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+
+	plan, err := planReplace(ctx, validPath, ra)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	if len(plan) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No matches of %q in %s, nothing to replace", ra.find, path)), nil
+	}
+	token := previewToken(ra, plan)
+	total := 0
+	for _, r := range plan {
+		total += r.count
+	}
+
+	if abstract.IsDryRun(ctx, request) {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "would replace %s in %s. To apply it, call fs_replace again with the same arguments and preview %q.\n\n",
+			plural(total, "match"), plural(len(plan), "file"), token)
+		for i, r := range plan {
+			unified := diff.Unified("a/"+r.rel, "b/"+r.rel, diff.Lines(diff.SplitLines(r.old), diff.SplitLines(r.new), replaceDiffEdits), 2)
+			redacted, _ := fs.redact(r.path, []byte(unified))
+			if sb.Len()+len(redacted) > replaceMaxDiff {
+				fmt.Fprintf(&sb, "… the diffs of %s more are left out\n", plural(len(plan)-i, "file"))
+				break
+			}
+			sb.Write(redacted)
+		}
+		return abstract.NewDryRunResult("%s", sb.String()), nil
+	}
+
+	preview := abstract.GetString(args, "preview", "")
+	if preview == "" {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument,
+			"fs_replace needs a preview: call it with dry_run true first, check the diff, then pass the preview it returns"), nil
+	}
+	if preview != token {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument,
+			"the arguments or the files changed since the preview, call fs_replace with dry_run true again"), nil
+	}
+
+	paths := make([]string, len(plan))
+	for i, r := range plan {
+		paths[i] = r.path
+	}
+	fs.beforeChange(ctx, "fs_replace", paths...)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Replaced %s in %s:", plural(total, "match"), plural(len(plan), "file"))
+	for i, r := range plan {
+		if err := os.WriteFile(r.path, []byte(r.new), r.mode); err != nil {
+			return abstract.NewErrorResultf(abstract.ClassifyError(err),
+				"Error writing %s after changing %d of %d files: %v", r.rel, i, len(plan), err), nil
+		}
+		fs.NotifyResourceUpdated(utils.PathToResourceURI(r.path))
+		fmt.Fprintf(&sb, "\n%s (%d)", r.rel, r.count)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/a/a.go", true},
+		{"*.go", "main.go.txt", false},
+		{"pkg/*.go", "pkg/a.go", true},
+		{"pkg/*.go", "pkg/a/a.go", false},
+		{"pkg/**/*.go", "pkg/a.go", true},
+		{"pkg/**/*.go", "pkg/a/b/c.go", true},
+		{"src/**", "src/x/y.ts", true},
+		{"?.md", "a.md", true},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q): %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("glob %q on %q = %t, want %t", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		".gitignore":     "gen/\n",
+		"a.go":           "package a\n\nfunc OldName() {}\n",
+		"sub/b.go":       "package sub\n\nvar x = a.OldName()\nvar y = a.OldName()\n",
+		"notes.txt":      "OldName is documented here\n",
+		"gen/ignored.go": "a.OldName()\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv, err := NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create FilesystemServer: %v", err)
+	}
+	if err := srv.LoadConfig(map[string]any{"allowed_dir": dir}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	fs := srv.(*FilesystemServer)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		res, err := fs.handleReplace(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	args := func(extra map[string]any) map[string]any {
+		a := map[string]any{"path": ".", "find": `OldName\(\)`, "replace": "NewName()", "regex": true, "include": []any{"*.go"}}
+		for k, v := range extra {
+			a[k] = v
+		}
+		return a
+	}
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		return string(data)
+	}
+
+	res := call(args(map[string]any{"dry_run": true}))
+	text := res.Content[0].(mcp.TextContent).Text
+	if res.IsError || res.Meta[abstract.DryRunMetaKey] != true || !strings.Contains(text, "would replace 3 matches in 2 files") ||
+		!strings.Contains(text, "-var y = a.OldName()\n+var x = a.NewName()\n+var y = a.NewName()\n") || strings.Contains(text, "ignored.go") {
+		t.Fatalf("Unexpected preview %+v", res)
+	}
+	token := regexp.MustCompile(`preview "([0-9a-f]+)"`).FindStringSubmatch(text)
+	if token == nil {
+		t.Fatalf("Expected a preview token in %q", text)
+	}
+	if read("a.go") != files["a.go"] {
+		t.Fatal("Expected the preview to change nothing")
+	}
+
+	if res = call(args(nil)); !res.IsError {
+		t.Errorf("Expected applying without a preview to fail, got %+v", res)
+	}
+	if res = call(args(map[string]any{"preview": token[1], "replace": "Other()"})); !res.IsError {
+		t.Errorf("Expected applying other arguments than previewed to fail, got %+v", res)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc OldName() { }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if res = call(args(map[string]any{"preview": token[1]})); !res.IsError {
+		t.Errorf("Expected applying after the files changed to fail, got %+v", res)
+	}
+
+	res = call(args(map[string]any{"dry_run": true}))
+	token = regexp.MustCompile(`preview "([0-9a-f]+)"`).FindStringSubmatch(res.Content[0].(mcp.TextContent).Text)
+	if res = call(args(map[string]any{"preview": token[1]})); res.IsError {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+	if read("a.go") != "package a\n\nfunc NewName() { }\n" || read("sub/b.go") != "package sub\n\nvar x = a.NewName()\nvar y = a.NewName()\n" ||
+		read("notes.txt") != files["notes.txt"] || read("gen/ignored.go") != files["gen/ignored.go"] {
+		t.Errorf("Unexpected files after the replacement: %q, %q", read("a.go"), read("sub/b.go"))
+	}
+
+	// Literal replacements keep $ as it is.
+	res = call(map[string]any{"path": "notes.txt", "find": "here", "replace": "$1 there", "dry_run": true})
+	token = regexp.MustCompile(`preview "([0-9a-f]+)"`).FindStringSubmatch(res.Content[0].(mcp.TextContent).Text)
+	call(map[string]any{"path": "notes.txt", "find": "here", "replace": "$1 there", "preview": token[1]})
+	if read("notes.txt") != "OldName is documented $1 there\n" {
+		t.Errorf("Unexpected literal replacement %q", read("notes.txt"))
+	}
+}
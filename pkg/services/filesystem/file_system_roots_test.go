@@ -0,0 +1,82 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+// rootsRequester answers roots/list with roots, or fails with err.
+type rootsRequester struct {
+	roots []string
+	err   error
+}
+
+func (r *rootsRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == abstract.RootsCapability
+}
+
+func (r *rootsRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	roots := make([]map[string]string, 0, len(r.roots))
+	for _, root := range r.roots {
+		roots = append(roots, map[string]string{"uri": "file://" + filepath.ToSlash(root)})
+	}
+	return json.Marshal(map[string]any{"roots": roots})
+}
+
+func TestAllowedDirsRoots(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fs := testkit.NewServiceAs[*FilesystemServer](t, NewFilesystemServer, map[string]any{"allowed_dir": dir})
+
+	fs.SetClientRequester(&rootsRequester{roots: []string{project}})
+	if _, err := fs.validatePath(context.Background(), filepath.Join(project, "a.txt")); err != nil {
+		t.Fatalf("a path inside the root must be allowed: %v", err)
+	}
+	if _, err := fs.validatePath(context.Background(), filepath.Join(dir, "a.txt")); err == nil {
+		t.Fatal("a path outside the roots must be denied")
+	}
+
+	fs.SetClientRequester(&rootsRequester{err: errors.New("client went away")})
+	_, err = fs.validatePath(context.Background(), filepath.Join(project, "a.txt"))
+	if err == nil || !strings.HasPrefix(err.Error(), "access denied") {
+		t.Fatalf("a failure to list the roots must deny access, got %v", err)
+	}
+
+	fs.SetClientRequester(&rootsRequester{})
+	if _, err := fs.validatePath(context.Background(), filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatalf("without roots the allowed directories apply: %v", err)
+	}
+}
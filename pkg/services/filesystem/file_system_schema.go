@@ -0,0 +1,197 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import "encoding/json"
+
+// Entry is a file or directory in a structured tool result.
+type Entry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	URI  string `json:"uri"`
+	Type string `json:"type"` // Type is "file" or "dir".
+	Size int64  `json:"size,omitempty"`
+}
+
+// entrySchema is the JSON Schema of Entry.
+const entrySchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"type": {"type": "string", "enum": ["file", "dir"]},
+		"size": {"type": "integer"}
+	},
+	"required": ["name", "path", "uri", "type"]
+}`
+
+// WriteFileOutput is the structured result of write_file.
+type WriteFileOutput struct {
+	Path string `json:"path"`
+	URI  string `json:"uri"`
+	Size int64  `json:"size"`
+}
+
+// ListDirectoryOutput is the structured result of list_directory.
+type ListDirectoryOutput struct {
+	Path    string  `json:"path"`
+	Entries []Entry `json:"entries"`
+}
+
+// SearchFilesOutput is the structured result of search_files.
+type SearchFilesOutput struct {
+	Path    string  `json:"path"`
+	Pattern string  `json:"pattern"`
+	Matches []Entry `json:"matches"`
+}
+
+// FileInfoOutput is the structured result of get_file_info.
+type FileInfoOutput struct {
+	FileInfo
+	Path     string `json:"path"`
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType"`
+}
+
+// AllowedDirectoriesOutput is the structured result of list_allowed_directories.
+type AllowedDirectoriesOutput struct {
+	Directories []string `json:"directories"`
+}
+
+// ListCheckpointsOutput is the structured result of fs_list_checkpoints.
+type ListCheckpointsOutput struct {
+	Repository  string       `json:"repository"`
+	Checkpoints []Checkpoint `json:"checkpoints"`
+}
+
+// RevertCheckpointOutput is the structured result of fs_revert_to_checkpoint.
+type RevertCheckpointOutput struct {
+	Repository string             `json:"repository"`
+	Checkpoint string             `json:"checkpoint"`
+	Backup     string             `json:"backup,omitempty"` // Backup is the checkpoint of the state before the revert.
+	Changes    []CheckpointChange `json:"changes"`
+}
+
+// HistoryOutput is the structured result of fs_history.
+type HistoryOutput struct {
+	Path     string        `json:"path"`
+	Versions []FileVersion `json:"versions"`
+}
+
+var (
+	writeFileSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"size": {"type": "integer"}
+	},
+	"required": ["path", "uri", "size"]
+}`)
+	listDirectorySchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"entries": {"type": "array", "items": ` + entrySchema + `}
+	},
+	"required": ["path", "entries"]
+}`)
+	searchFilesSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"pattern": {"type": "string"},
+		"matches": {"type": "array", "items": ` + entrySchema + `}
+	},
+	"required": ["path", "pattern", "matches"]
+}`)
+	fileInfoSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"uri": {"type": "string"},
+		"mimeType": {"type": "string"},
+		"size": {"type": "integer"},
+		"created": {"type": "string", "format": "date-time"},
+		"modified": {"type": "string", "format": "date-time"},
+		"accessed": {"type": "string", "format": "date-time"},
+		"isDirectory": {"type": "boolean"},
+		"isFile": {"type": "boolean"},
+		"permissions": {"type": "string"}
+	},
+	"required": ["path", "uri", "mimeType", "size", "isDirectory", "isFile", "permissions"]
+}`)
+	allowedDirectoriesSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"directories": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["directories"]
+}`)
+	listCheckpointsSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"repository": {"type": "string"},
+		"checkpoints": {"type": "array", "items": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "string"},
+				"commit": {"type": "string"},
+				"created": {"type": "string", "format": "date-time"},
+				"reason": {"type": "string"}
+			},
+			"required": ["id", "commit", "created", "reason"]
+		}}
+	},
+	"required": ["repository", "checkpoints"]
+}`)
+	revertCheckpointSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"repository": {"type": "string"},
+		"checkpoint": {"type": "string"},
+		"backup": {"type": "string"},
+		"changes": {"type": "array", "items": {
+			"type": "object",
+			"properties": {
+				"path": {"type": "string"},
+				"status": {"type": "string", "enum": ["restored", "removed"]}
+			},
+			"required": ["path", "status"]
+		}}
+	},
+	"required": ["repository", "checkpoint", "changes"]
+}`)
+	historySchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string"},
+		"versions": {"type": "array", "items": {
+			"type": "object",
+			"properties": {
+				"version": {"type": "string"},
+				"size": {"type": "integer"},
+				"saved": {"type": "string", "format": "date-time"},
+				"tool": {"type": "string"}
+			},
+			"required": ["version", "size", "saved", "tool"]
+		}}
+	},
+	"required": ["path", "versions"]
+}`)
+)
@@ -0,0 +1,364 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// treeDepthDefault is how deep fs_tree lists entries by default.
+	treeDepthDefault = 3
+	// treeTokensDefault is the default budget of fs_tree, at about 4 bytes per token.
+	treeTokensDefault = 2000
+	// treeMaxChildren is how many entries of a directory fs_tree lists before summarizing the rest.
+	treeMaxChildren = 40
+	// treeMaxEntries bounds the walk of fs_tree, beyond which the counts are incomplete.
+	treeMaxEntries = 50000
+)
+
+// treeIgnoreDefault are skipped by fs_tree unless all is set.
+var treeIgnoreDefault = []string{".git", ".hg", ".svn", "node_modules", "__pycache__", ".venv", ".idea", ".DS_Store"}
+
+// treeNode is a file or directory in the tree of fs_tree.
+type treeNode struct {
+	name     string
+	dir      bool
+	link     string // link is the target of a symbolic link.
+	size     int64  // size of a file, or of the files under a directory.
+	files    int    // files under a directory, at any depth.
+	dirs     int    // dirs under a directory, at any depth.
+	children []*treeNode
+	depth    int
+	expanded bool // expanded directories list their children.
+}
+
+// ignoreRule is a pattern of .gitignore or of the ignore argument.
+type ignoreRule struct {
+	pattern  string
+	anchored bool // anchored patterns match the path relative to the root, others the name at any depth.
+	dirOnly  bool
+}
+
+// parseIgnoreRule parses a .gitignore style pattern. Negations are not supported and are skipped.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	line = strings.TrimPrefix(line, "**/")
+	rule.pattern = line
+	return rule, line != ""
+}
+
+// readGitignore returns the rules of the .gitignore file in dir, if any.
+func readGitignore(dir string) []ignoreRule {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ignored reports whether the entry at rel, relative to the root of the tree, matches one of rules.
+func ignored(rules []ignoreRule, rel string, dir bool) bool {
+	rel = filepath.ToSlash(rel)
+	name := rel[strings.LastIndex(rel, "/")+1:]
+	for _, r := range rules {
+		if r.dirOnly && !dir {
+			continue
+		}
+		target := name
+		if r.anchored {
+			target = rel
+		}
+		if ok, _ := filepath.Match(r.pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// treeBuilder walks a directory into treeNodes.
+type treeBuilder struct {
+	root     string
+	depth    int
+	rules    []ignoreRule
+	entries  int
+	complete bool
+}
+
+// build reads the directory at path, keeping the entries down to the depth of the builder and counting the rest.
+func (b *treeBuilder) build(ctx context.Context, node *treeNode, path string) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil || b.entries >= treeMaxEntries {
+			b.complete = false
+			return
+		}
+		childPath := filepath.Join(path, entry.Name())
+		rel, _ := filepath.Rel(b.root, childPath)
+		isDir := entry.IsDir()
+		if ignored(b.rules, rel, isDir) {
+			continue
+		}
+		b.entries++
+		child := &treeNode{name: entry.Name(), dir: isDir, depth: node.depth + 1}
+		if entry.Type()&os.ModeSymlink != 0 {
+			child.link, _ = os.Readlink(childPath)
+		} else if isDir {
+			b.build(ctx, child, childPath)
+			node.files += child.files
+			node.dirs += child.dirs
+		} else if info, err := entry.Info(); err == nil {
+			child.size = info.Size()
+		}
+		if isDir {
+			node.dirs++
+		} else {
+			node.files++
+		}
+		node.size += child.size
+		if child.depth <= b.depth {
+			node.children = append(node.children, child)
+		}
+	}
+	sort.Slice(node.children, func(i, j int) bool {
+		a, c := node.children[i], node.children[j]
+		if a.dir != c.dir {
+			return a.dir
+		}
+		return a.name < c.name
+	})
+}
+
+// line renders the entry itself, indented by its depth.
+func (n *treeNode) line() string {
+	indent := strings.Repeat("  ", n.depth)
+	switch {
+	case n.link != "":
+		return fmt.Sprintf("%s%s -> %s\n", indent, n.name, n.link)
+	case !n.dir:
+		return fmt.Sprintf("%s%s  %s\n", indent, n.name, sizeString(n.size))
+	case n.files+n.dirs == 0:
+		return fmt.Sprintf("%s%s/ (empty)\n", indent, n.name)
+	default:
+		return fmt.Sprintf("%s%s/ (%s)\n", indent, n.name, n.summary())
+	}
+}
+
+// summary counts the contents of a directory.
+func (n *treeNode) summary() string {
+	parts := make([]string, 0, 3)
+	if n.files > 0 {
+		parts = append(parts, plural(n.files, "file"))
+	}
+	if n.dirs > 0 {
+		parts = append(parts, plural(n.dirs, "dir"))
+	}
+	return strings.Join(append(parts, sizeString(n.size)), ", ")
+}
+
+// shown returns the children of a directory that are listed and a line for the rest, if any.
+func (n *treeNode) shown(limit int) ([]*treeNode, string) {
+	if len(n.children) <= limit {
+		return n.children, ""
+	}
+	rest := n.children[limit:]
+	var size int64
+	for _, c := range rest {
+		size += c.size
+	}
+	indent := strings.Repeat("  ", n.depth+1)
+	return n.children[:limit], fmt.Sprintf("%s… %d more entries, %s\n", indent, len(rest), sizeString(size))
+}
+
+// cost is the number of bytes that expanding the directory adds to the tree.
+func (n *treeNode) cost(limit int) int {
+	children, more := n.shown(limit)
+	total := len(more)
+	for _, c := range children {
+		total += len(c.line())
+	}
+	return total
+}
+
+// renderTree lays out the tree of root within budget bytes. Directories are expanded breadth first, so the top
+// levels are always shown and the deeper directories that do not fit are summarized by their counts and size.
+func renderTree(root *treeNode, budget int) (string, int) {
+	used := len(root.line())
+	limit := treeMaxChildren
+	// The listing of the root itself is cut to the budget rather than left out.
+	for limit > 1 && used+root.cost(limit) > budget {
+		limit /= 2
+	}
+	used += root.cost(limit)
+	root.expanded = true
+
+	collapsed := 0
+	queue := []*treeNode{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		children, _ := n.shown(limit)
+		for _, c := range children {
+			if !c.dir || len(c.children) == 0 {
+				if c.dir && c.files+c.dirs > 0 {
+					collapsed++
+				}
+				continue
+			}
+			if extra := c.cost(limit); used+extra <= budget {
+				c.expanded = true
+				used += extra
+				queue = append(queue, c)
+				continue
+			}
+			collapsed++
+		}
+	}
+
+	var out strings.Builder
+	var write func(n *treeNode)
+	write = func(n *treeNode) {
+		out.WriteString(n.line())
+		if !n.expanded {
+			return
+		}
+		children, more := n.shown(limit)
+		for _, c := range children {
+			write(c)
+		}
+		out.WriteString(more)
+	}
+	write(root)
+	return out.String(), collapsed
+}
+
+// sizeString formats a size in bytes for people.
+func sizeString(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// plural formats a count of things.
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	if strings.HasSuffix(thing, "ch") {
+		return fmt.Sprintf("%d %ses", n, thing)
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+func (fs *FilesystemServer) handleTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path, ok := args["path"].(string)
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
+	}
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	info, err := os.Stat(validPath)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	if !info.IsDir() {
+		return abstract.NewErrorResultf(abstract.CodeInvalidArgument, "Error: %s is not a directory", path), nil
+	}
+
+	budget := abstract.GetInt(args, "max_tokens", treeTokensDefault) * 4
+	if abstract.Has(args, "max_bytes") {
+		budget = abstract.GetInt(args, "max_bytes", 0)
+	}
+	if budget < 1 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "max_tokens and max_bytes must be positive"), nil
+	}
+	b := &treeBuilder{root: validPath, depth: abstract.GetInt(args, "depth", treeDepthDefault), complete: true}
+	if b.depth < 1 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "depth must be at least 1"), nil
+	}
+	if !abstract.GetBool(args, "all", false) {
+		for _, p := range treeIgnoreDefault {
+			b.rules = append(b.rules, ignoreRule{pattern: p})
+		}
+		b.rules = append(b.rules, readGitignore(validPath)...)
+	}
+	for _, p := range abstract.GetStringSlice(args, "ignore", nil) {
+		if rule, ok := parseIgnoreRule(p); ok {
+			b.rules = append(b.rules, rule)
+		}
+	}
+
+	root := &treeNode{name: strings.TrimSuffix(validPath, string(filepath.Separator)), dir: true}
+	b.build(ctx, root, validPath)
+	if err := ctx.Err(); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	tree, collapsed := renderTree(root, budget)
+
+	var notes []string
+	notes = append(notes, fmt.Sprintf("%s, %s, %s", plural(root.files, "file"), plural(root.dirs, "dir"), sizeString(root.size)))
+	if collapsed > 0 {
+		notes = append(notes, fmt.Sprintf("%s summarized to fit the budget or depth, list them with a deeper path", plural(collapsed, "dir")))
+	}
+	if !b.complete {
+		notes = append(notes, fmt.Sprintf("stopped after %d entries, the counts are incomplete", treeMaxEntries))
+	}
+	return mcp.NewToolResultText(tree + "\n" + strings.Join(notes, "; ")), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestTree(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		".gitignore":              "*.log\n/build/\n",
+		"go.mod":                  "module example\n",
+		"app.log":                 "ignored",
+		"build/out":               "ignored",
+		"node_modules/x/index.js": "ignored",
+		"cmd/tool/main.go":        "package main\n",
+		"pkg/a/a.go":              "package a\n",
+		"pkg/a/deep/b.go":         "package deep\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv, err := NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create FilesystemServer: %v", err)
+	}
+	if err := srv.LoadConfig(map[string]any{"allowed_dir": dir}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	fs := srv.(*FilesystemServer)
+	tree := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		res, err := fs.handleTree(context.Background(), request)
+		if err != nil || res.IsError {
+			t.Fatalf("Unexpected result %+v, %v", res, err)
+		}
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	got := tree(map[string]any{"path": ".", "depth": 4})
+	for _, want := range []string{"  cmd/ (1 file, 1 dir, 13 B)\n", "      main.go  13 B\n", "        b.go  13 B\n", "  go.mod  15 B\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the tree:\n%s", want, got)
+		}
+	}
+	for _, skipped := range []string{"app.log", "build", "node_modules"} {
+		if strings.Contains(got, skipped) {
+			t.Errorf("Expected %s to be skipped:\n%s", skipped, got)
+		}
+	}
+
+	got = tree(map[string]any{"path": ".", "depth": 1, "ignore": []any{"go.mod"}})
+	if !strings.Contains(got, "  pkg/ (2 files, 2 dirs, 23 B)\n") || strings.Contains(got, "a.go") || strings.Contains(got, "go.mod") {
+		t.Errorf("Expected the directories below depth 1 to be summarized:\n%s", got)
+	}
+
+	// With a small budget the top level is kept and the deeper directories are summarized.
+	got = tree(map[string]any{"path": ".", "max_bytes": 150})
+	if !strings.Contains(got, "  pkg/ (") || strings.Contains(got, "b.go") || !strings.Contains(got, "summarized to fit") {
+		t.Errorf("Expected the tree to be cut to the budget:\n%s", got)
+	}
+
+	if got = tree(map[string]any{"path": ".", "all": true}); !strings.Contains(got, "app.log") || !strings.Contains(got, "node_modules/") {
+		t.Errorf("Expected all entries to be listed with all:\n%s", got)
+	}
+}
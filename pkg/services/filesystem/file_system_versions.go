@@ -0,0 +1,327 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+	"github.com/gojue/moling/pkg/utils/diff"
+)
+
+const (
+	// versionMaxSize is the size above which files are not kept in the version store.
+	versionMaxSize = 20 * 1024 * 1024
+	// versionIDLength is how many hex digits of the content hash identify a version.
+	versionIDLength = 12
+)
+
+// FileVersion is a previous content of a file, kept in the version store before the file was changed.
+type FileVersion struct {
+	Version string    `json:"version"` // Version is the start of the SHA-256 of the content.
+	Size    int64     `json:"size"`
+	Saved   time.Time `json:"saved"`
+	Tool    string    `json:"tool"` // Tool is the tool that changed the file afterwards.
+	hash    string
+}
+
+// versionIndex lists the versions of a file, the newest first.
+type versionIndex struct {
+	Path     string         `json:"path"`
+	Versions []versionEntry `json:"versions"`
+}
+
+type versionEntry struct {
+	Hash  string    `json:"hash"`
+	Size  int64     `json:"size"`
+	Saved time.Time `json:"saved"`
+	Tool  string    `json:"tool"`
+}
+
+// versionStore keeps the previous versions of files. The contents are stored once per SHA-256 under objects, and
+// each file has an index of its versions under index, named after the hash of its path.
+type versionStore struct {
+	dir  string
+	keep int
+	lock sync.Mutex
+}
+
+func (vs *versionStore) indexPath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(vs.dir, "index", hex.EncodeToString(sum[:])+".json")
+}
+
+func (vs *versionStore) objectPath(hash string) string {
+	return filepath.Join(vs.dir, "objects", hash[:2], hash)
+}
+
+func (vs *versionStore) readIndex(path string) (versionIndex, error) {
+	idx := versionIndex{Path: path}
+	data, err := os.ReadFile(vs.indexPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, err
+	}
+	return idx, json.Unmarshal(data, &idx)
+}
+
+// writeFileAtomic replaces the file at path with data, so that readers never see it half written.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// save keeps the current content of the file at path as a version, unless it is already the newest one. Missing
+// files, directories and files larger than versionMaxSize are not kept.
+func (vs *versionStore) save(path, tool string) error {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > versionMaxSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	idx, err := vs.readIndex(path)
+	if err != nil {
+		return err
+	}
+	if len(idx.Versions) > 0 && idx.Versions[0].Hash == hash {
+		return nil
+	}
+	if _, err := os.Stat(vs.objectPath(hash)); errors.Is(err, os.ErrNotExist) {
+		if err := writeFileAtomic(vs.objectPath(hash), data, 0o600); err != nil {
+			return err
+		}
+	}
+	idx.Versions = append([]versionEntry{{Hash: hash, Size: info.Size(), Saved: time.Now(), Tool: tool}}, idx.Versions...)
+	var dropped []versionEntry
+	if len(idx.Versions) > vs.keep {
+		dropped = idx.Versions[vs.keep:]
+		idx.Versions = idx.Versions[:vs.keep]
+	}
+	out, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(vs.indexPath(path), out, 0o600); err != nil {
+		return err
+	}
+	if len(dropped) > 0 {
+		return vs.collect(dropped)
+	}
+	return nil
+}
+
+// collect removes the contents of dropped versions that no index refers to any more.
+func (vs *versionStore) collect(dropped []versionEntry) error {
+	unused := make(map[string]bool, len(dropped))
+	for _, v := range dropped {
+		unused[v.Hash] = true
+	}
+	entries, err := os.ReadDir(filepath.Join(vs.dir, "index"))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(vs.dir, "index", entry.Name()))
+		if err != nil {
+			continue
+		}
+		var idx versionIndex
+		if json.Unmarshal(data, &idx) != nil {
+			continue
+		}
+		for _, v := range idx.Versions {
+			delete(unused, v.Hash)
+		}
+	}
+	for hash := range unused {
+		if err := os.Remove(vs.objectPath(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// history returns the versions kept of the file at path, the newest first.
+func (vs *versionStore) history(path string) ([]FileVersion, error) {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	idx, err := vs.readIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]FileVersion, len(idx.Versions))
+	for i, v := range idx.Versions {
+		versions[i] = FileVersion{Version: v.Hash[:versionIDLength], Size: v.Size, Saved: v.Saved, Tool: v.Tool, hash: v.Hash}
+	}
+	return versions, nil
+}
+
+// content returns the content of the version of the file at path whose hash starts with id.
+func (vs *versionStore) content(path, id string) (FileVersion, []byte, error) {
+	versions, err := vs.history(path)
+	if err != nil {
+		return FileVersion{}, nil, err
+	}
+	for _, v := range versions {
+		if id != "" && strings.HasPrefix(v.hash, strings.ToLower(id)) {
+			data, err := os.ReadFile(vs.objectPath(v.hash))
+			return v, data, err
+		}
+	}
+	return FileVersion{}, nil, fmt.Errorf("%s has no version %q, see fs_history: %w", path, id, os.ErrNotExist)
+}
+
+// snapshot keeps the current content of the files at paths in the version store, if versions is set. A failure is
+// logged and does not stop the change.
+func (fs *FilesystemServer) snapshot(tool string, paths ...string) {
+	if fs.versions == nil {
+		return
+	}
+	for _, path := range paths {
+		if err := fs.versions.save(path, tool); err != nil {
+			fs.Logger.Warn().Err(err).Str("path", path).Msg("failed to keep the previous version of the file")
+		}
+	}
+}
+
+// beforeChange saves what the git checkpoints and the version store keep of the files at paths before tool
+// changes them.
+func (fs *FilesystemServer) beforeChange(ctx context.Context, tool string, paths ...string) {
+	fs.checkpoint(ctx, tool, paths...)
+	fs.snapshot(tool, paths...)
+}
+
+// versionStoreError is the result of a version tool called while the version store is off.
+func (fs *FilesystemServer) versionStoreError() *mcp.CallToolResult {
+	return abstract.NewErrorResult(abstract.CodeUnavailable,
+		"file versions are not kept, enable them with `moling config set FileSystem.versions 10`")
+}
+
+func (fs *FilesystemServer) handleHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if fs.versions == nil {
+		return fs.versionStoreError(), nil
+	}
+	path, ok := request.GetArguments()["path"].(string)
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
+	}
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	versions, err := fs.versions.history(validPath)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error reading the versions of %s: %v", path, err), nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s of %s, the newest first", plural(len(versions), "version"), validPath)
+	for _, v := range versions {
+		fmt.Fprintf(&sb, "\n%s  %s  %d bytes  before %s", v.Version, v.Saved.Format(time.RFC3339), v.Size, v.Tool)
+	}
+	return abstract.NewStructuredResult(sb.String(), HistoryOutput{Path: validPath, Versions: versions}), nil
+}
+
+func (fs *FilesystemServer) handleRestoreVersion(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if fs.versions == nil {
+		return fs.versionStoreError(), nil
+	}
+	args := request.GetArguments()
+	path, ok := args["path"].(string)
+	if !ok {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "path must be a string"), nil
+	}
+	id, ok := args["version"].(string)
+	if !ok || len(id) < 4 {
+		return abstract.NewErrorResult(abstract.CodeInvalidArgument, "version must be a version from fs_history"), nil
+	}
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	version, data, err := fs.versions.content(validPath, id)
+	if err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error: %v", err), nil
+	}
+	current, err := os.ReadFile(validPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error reading %s: %v", path, err), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		if errors.Is(err, os.ErrNotExist) {
+			return abstract.NewDryRunResult("would create %s with version %s (%d bytes)", validPath, version.Version, len(data)), nil
+		}
+		unified := diff.Unified("current", version.Version, diff.Lines(diff.SplitLines(string(current)), diff.SplitLines(string(data)), replaceDiffEdits), 2)
+		redacted, _ := fs.redact(validPath, []byte(unified))
+		return abstract.NewDryRunResult("would restore version %s of %s:\n%s", version.Version, validPath, redacted), nil
+	}
+
+	// The content being replaced is kept too, so that the restore can be undone.
+	fs.beforeChange(ctx, "fs_restore_version", validPath)
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(validPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(validPath), 0755); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error creating parent directories: %v", err), nil
+	}
+	if err := os.WriteFile(validPath, data, mode); err != nil {
+		return abstract.NewErrorResultf(abstract.ClassifyError(err), "Error writing file: %v", err), nil
+	}
+	fs.NotifyResourceUpdated(utils.PathToResourceURI(validPath))
+	return mcp.NewToolResultText(fmt.Sprintf("Restored version %s of %s (%d bytes), saved %s before %s",
+		version.Version, validPath, len(data), version.Saved.Format(time.RFC3339), version.Tool)), nil
+}
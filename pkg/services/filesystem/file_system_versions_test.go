@@ -0,0 +1,103 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestFileVersions(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := t.TempDir()
+	srv, err := NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create FilesystemServer: %v", err)
+	}
+	if err := srv.LoadConfig(map[string]any{"allowed_dir": dir, "versions": 2, "versions_path": store}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	fs := srv.(*FilesystemServer)
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		res, err := handler(context.Background(), request)
+		if err != nil || res.IsError {
+			t.Fatalf("Unexpected result %+v, %v", res, err)
+		}
+		return res
+	}
+	history := func() []FileVersion {
+		var out HistoryOutput
+		res := call(fs.handleHistory, map[string]any{"path": "notes.txt"})
+		if err := json.Unmarshal([]byte(res.Content[1].(mcp.TextContent).Text), &out); err != nil {
+			t.Fatalf("Failed to parse structured content: %v", err)
+		}
+		return out.Versions
+	}
+
+	for _, content := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		call(fs.handleWriteFile, map[string]any{"path": "notes.txt", "content": content})
+	}
+	versions := history()
+	if len(versions) != 2 || versions[0].Size != 6 || versions[0].Tool != "write_file" || versions[1].Size != 4 {
+		t.Fatalf("Expected the two versions before the last write, got %+v", versions)
+	}
+	objects := 0
+	_ = filepath.Walk(filepath.Join(store, "objects"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			objects++
+		}
+		return nil
+	})
+	if objects != 2 {
+		t.Errorf("Expected the contents of dropped versions to be removed, %d are kept", objects)
+	}
+
+	res := call(fs.handleRestoreVersion, map[string]any{"path": "notes.txt", "version": versions[1].Version, "dry_run": true})
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "-four\n+two\n") {
+		t.Errorf("Unexpected dry run %q", text)
+	}
+	call(fs.handleRestoreVersion, map[string]any{"path": "notes.txt", "version": versions[1].Version})
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.txt")); string(data) != "two\n" {
+		t.Errorf("Expected the version to be restored, got %q", data)
+	}
+	if versions = history(); len(versions) != 2 || versions[0].Tool != "fs_restore_version" || versions[0].Size != 5 {
+		t.Errorf("Expected the content before the restore to be kept, got %+v", versions)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"path": "notes.txt", "version": "ffffffff"}
+	if res, _ := fs.handleRestoreVersion(context.Background(), request); !res.IsError {
+		t.Errorf("Expected an unknown version to fail, got %+v", res)
+	}
+}
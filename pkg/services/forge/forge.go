@@ -0,0 +1,480 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package forge provides GitHub and GitLab issue, pull request and CI tools for the MoLing application.
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+	"github.com/gojue/moling/pkg/utils/httpapi"
+)
+
+const (
+	ForgeServerName comm.MoLingServerType = "Forge"
+)
+
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)+$`)
+
+// ForgeServer implements the Service interface and works with GitHub or GitLab repositories.
+type ForgeServer struct {
+	abstract.MLService
+	config *ForgeConfig
+	forge  Forge
+}
+
+// NewForgeServer creates a new ForgeServer instance.
+func NewForgeServer(ctx context.Context) (abstract.Service, error) {
+	fc := NewForgeConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("ForgeServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("ForgeServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(ForgeServerName))
+	})
+
+	fs := &ForgeServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    fc,
+	}
+	err := fs.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Init creates the API client and registers the prompt and tools of the forge service.
+func (fs *ForgeServer) Init() error {
+	fs.forge = NewForge(fs.config, &http.Client{Timeout: time.Duration(fs.config.Timeout) * time.Second})
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "forge_prompt",
+			Description: "Get the relevant functions and prompts of the Forge MCP Server",
+		},
+		HandlerFunc: fs.handlePrompt,
+	}
+	fs.AddPrompt(pe)
+
+	repoDesc := "Repository as owner/name, or group/subgroup/project on GitLab"
+	if fs.config.DefaultRepo != "" {
+		repoDesc += ". Default: " + fs.config.DefaultRepo
+	}
+	repoOpt := []mcp.PropertyOption{mcp.Description(repoDesc)}
+	if fs.config.DefaultRepo == "" {
+		repoOpt = append(repoOpt, mcp.Required())
+	}
+
+	fs.AddTool(mcp.NewTool(
+		"forge_list_issues",
+		mcp.WithDescription("List the issues of a repository, most recently created first"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithString("state",
+			mcp.Description("Issue state, default: open"),
+			mcp.Enum("open", "closed", "all"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Only issues with all of these labels"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of issues, 1-100, default: 20"),
+		),
+	), fs.handleListIssues)
+
+	fs.AddTool(mcp.NewTool(
+		"forge_list_pulls",
+		mcp.WithDescription("List the pull requests (merge requests on GitLab) of a repository, most recently updated first"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithString("state",
+			mcp.Description("Pull request state, default: open"),
+			mcp.Enum("open", "closed", "merged", "all"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of pull requests, 1-100, default: 20"),
+		),
+	), fs.handleListPulls)
+
+	fs.AddTool(mcp.NewTool(
+		"forge_pull_diff",
+		mcp.WithDescription("Get the unified diff of a pull request together with its title, branches and description"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithNumber("number",
+			mcp.Description("Pull request number, the iid on GitLab"),
+			mcp.Required(),
+		),
+	), fs.handlePullDiff)
+
+	fs.AddTool(mcp.NewTool(
+		"forge_pull_comments",
+		mcp.WithDescription("Get the reviews and review comments of a pull request, with the file and line of line comments"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithNumber("number",
+			mcp.Description("Pull request number, the iid on GitLab"),
+			mcp.Required(),
+		),
+	), fs.handlePullComments)
+
+	fs.AddTool(mcp.NewTool(
+		"forge_ci_status",
+		mcp.WithDescription("Get the CI status of a pull request, branch, tag or commit, with the state of every check or job"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithNumber("number",
+			mcp.Description("Pull request number, checks the head commit of the pull request"),
+		),
+		mcp.WithString("ref",
+			mcp.Description("Branch, tag or commit SHA, used when number is not given"),
+		),
+	), fs.handleCIStatus)
+
+	if fs.config.ReadOnly {
+		return nil
+	}
+
+	fs.AddTool(mcp.NewTool(
+		"forge_create_issue",
+		mcp.WithDescription("Open a new issue in a repository"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithString("title",
+			mcp.Description("Issue title"),
+			mcp.Required(),
+		),
+		mcp.WithString("body",
+			mcp.Description("Issue description in Markdown"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Labels to add"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+	), fs.handleCreateIssue)
+
+	fs.AddTool(mcp.NewTool(
+		"forge_create_pull",
+		mcp.WithDescription("Open a new pull request (merge request on GitLab) from a pushed branch"),
+		mcp.WithString("repo", repoOpt...),
+		mcp.WithString("title",
+			mcp.Description("Pull request title"),
+			mcp.Required(),
+		),
+		mcp.WithString("head",
+			mcp.Description("Branch with the changes, owner:branch for a fork on GitHub"),
+			mcp.Required(),
+		),
+		mcp.WithString("base",
+			mcp.Description("Branch to merge into"),
+			mcp.Required(),
+		),
+		mcp.WithString("body",
+			mcp.Description("Pull request description in Markdown"),
+		),
+		mcp.WithBoolean("draft",
+			mcp.Description("Open the pull request as a draft, default: false"),
+		),
+	), fs.handleCreatePull)
+	return nil
+}
+
+func (fs *ForgeServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: fs.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// repo reads the repository argument and checks it against allowed_repos.
+func (fs *ForgeServer) repo(args map[string]any) (string, error) {
+	repo := abstract.GetString(args, "repo", "")
+	repo = strings.Trim(strings.TrimSpace(repo), "/")
+	if repo == "" {
+		repo = fs.config.DefaultRepo
+	}
+	if repo == "" {
+		return "", fmt.Errorf("repo must be specified")
+	}
+	if !repoPattern.MatchString(repo) || (fs.config.Provider == ProviderGitHub && strings.Count(repo, "/") != 1) {
+		return "", fmt.Errorf("invalid repository: %s", repo)
+	}
+	if !fs.config.repoAllowed(repo) {
+		return "", fmt.Errorf("access denied - repository %s is not in allowed_repos", repo)
+	}
+	return repo, nil
+}
+
+func number(args map[string]any) (int, error) {
+	n := abstract.GetFloat(args, "number", 0)
+	if n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("number must be a positive integer")
+	}
+	return int(n), nil
+}
+
+func listOptions(args map[string]any) (ListOptions, error) {
+	opts := ListOptions{State: "open", Limit: 20}
+	if state := abstract.GetString(args, "state", ""); state != "" {
+		opts.State = state
+	}
+	opts.Limit = abstract.GetInt(args, "limit", opts.Limit)
+	if opts.Limit < 1 || opts.Limit > 100 {
+		return opts, fmt.Errorf("limit must be between 1 and 100")
+	}
+	labels, err := stringArray(args["labels"])
+	if err != nil {
+		return opts, err
+	}
+	opts.Labels = labels
+	return opts, nil
+}
+
+func stringArray(v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	items, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("labels must be an array of strings")
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("labels must be an array of strings")
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (fs *ForgeServer) handleListIssues(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts, err := listOptions(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issues, err := fs.forge.ListIssues(ctx, repo, opts)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "listing issues", "repo", repo, err), nil
+	}
+	return abstract.NewJSONResult(issues), nil
+}
+
+func (fs *ForgeServer) handleListPulls(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts, err := listOptions(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pulls, err := fs.forge.ListPulls(ctx, repo, opts)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "listing pull requests", "repo", repo, err), nil
+	}
+	return abstract.NewJSONResult(pulls), nil
+}
+
+// handlePullDiff returns the pull request summary followed by its diff, cut at max_diff_size.
+func (fs *ForgeServer) handlePullDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	n, err := number(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pull, err := fs.forge.GetPull(ctx, repo, n)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "reading the pull request", "repo", repo, err), nil
+	}
+	diff, err := fs.forge.PullDiff(ctx, repo, n, fs.config.MaxDiffSize)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "reading the diff", "repo", repo, err), nil
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("#%d %s\nState: %s, author: %s, %s -> %s\nURL: %s\n", pull.Number, pull.Title, pull.State, pull.Author, pull.Head, pull.Base, pull.URL))
+	if pull.Body != "" {
+		b.WriteString("\n" + pull.Body + "\n")
+	}
+	b.WriteString("\n")
+	if len(diff) > fs.config.MaxDiffSize {
+		cut := strings.LastIndexByte(diff[:fs.config.MaxDiffSize], '\n') + 1
+		b.WriteString(diff[:cut])
+		b.WriteString(fmt.Sprintf("\n[diff truncated at %d of %d bytes]\n", cut, len(diff)))
+	} else {
+		b.WriteString(diff)
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+func (fs *ForgeServer) handlePullComments(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	n, err := number(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	comments, err := fs.forge.PullComments(ctx, repo, n)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "reading review comments", "repo", repo, err), nil
+	}
+	if len(comments) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Pull request #%d has no reviews or review comments", n)), nil
+	}
+	return abstract.NewJSONResult(comments), nil
+}
+
+// handleCIStatus checks a ref, or the head commit of a pull request.
+func (fs *ForgeServer) handleCIStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ref := abstract.GetString(args, "ref", "")
+	if _, ok := args["number"]; ok {
+		n, err := number(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		pull, err := fs.forge.GetPull(ctx, repo, n)
+		if err != nil {
+			return httpapi.ErrorResult(fs.Logger, "reading the pull request", "repo", repo, err), nil
+		}
+		ref = pull.HeadSHA
+	}
+	if ref == "" {
+		return mcp.NewToolResultError("either number or ref must be specified"), nil
+	}
+	status, err := fs.forge.CIStatus(ctx, repo, ref)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "reading the CI status", "repo", repo, err), nil
+	}
+	status.Ref = ref
+	return abstract.NewJSONResult(status), nil
+}
+
+func (fs *ForgeServer) handleCreateIssue(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	title := abstract.GetString(args, "title", "")
+	body := abstract.GetString(args, "body", "")
+	if strings.TrimSpace(title) == "" {
+		return mcp.NewToolResultError("title must be a non-empty string"), nil
+	}
+	labels, err := stringArray(args["labels"])
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := fs.forge.CreateIssue(ctx, repo, title, body, labels)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "creating the issue", "repo", repo, err), nil
+	}
+	fs.Logger.Info().Str("repo", repo).Int("number", issue.Number).Msg("issue created")
+	return mcp.NewToolResultText(fmt.Sprintf("Created issue #%d: %s", issue.Number, issue.URL)), nil
+}
+
+func (fs *ForgeServer) handleCreatePull(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	repo, err := fs.repo(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	title := abstract.GetString(args, "title", "")
+	head := abstract.GetString(args, "head", "")
+	base := abstract.GetString(args, "base", "")
+	body := abstract.GetString(args, "body", "")
+	draft := abstract.GetBool(args, "draft", false)
+	if strings.TrimSpace(title) == "" || head == "" || base == "" {
+		return mcp.NewToolResultError("title, head and base must be non-empty strings"), nil
+	}
+	pull, err := fs.forge.CreatePull(ctx, repo, title, body, head, base, draft)
+	if err != nil {
+		return httpapi.ErrorResult(fs.Logger, "creating the pull request", "repo", repo, err), nil
+	}
+	fs.Logger.Info().Str("repo", repo).Int("number", pull.Number).Msg("pull request created")
+	return mcp.NewToolResultText(fmt.Sprintf("Created pull request #%d: %s", pull.Number, pull.URL)), nil
+}
+
+// Config returns the configuration of the service as a string. The token is left out.
+func (fs *ForgeServer) Config() string {
+	cfg := *fs.config
+	cfg.Token = ""
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		fs.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (fs *ForgeServer) Name() comm.MoLingServerType {
+	return ForgeServerName
+}
+
+func (fs *ForgeServer) Close() error {
+	fs.Logger.Debug().Msg("ForgeServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (fs *ForgeServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(fs.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return fs.config.Check()
+}
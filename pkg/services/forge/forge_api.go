@@ -0,0 +1,250 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gojue/moling/pkg/utils/httpapi"
+)
+
+// Normalized CI states.
+const (
+	ciSuccess = "success"
+	ciFailure = "failure"
+	ciPending = "pending"
+	ciUnknown = "unknown"
+)
+
+// Issue is an issue of a repository.
+type Issue struct {
+	Number    int      `json:"number"`
+	Title     string   `json:"title"`
+	State     string   `json:"state"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels,omitempty"`
+	Comments  int      `json:"comments"`
+	URL       string   `json:"url"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	Body      string   `json:"body,omitempty"`
+}
+
+// Pull is a pull request, or a merge request on GitLab.
+type Pull struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Author    string `json:"author"`
+	Head      string `json:"head"`
+	Base      string `json:"base"`
+	HeadSHA   string `json:"head_sha,omitempty"`
+	Draft     bool   `json:"draft"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Body      string `json:"body,omitempty"`
+}
+
+// Comment is a review or a review comment of a pull request. Path and Line are set for
+// comments on a line of the diff.
+type Comment struct {
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	State     string `json:"state,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Resolved  *bool  `json:"resolved,omitempty"`
+	URL       string `json:"url,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Check is a single CI job or status.
+type Check struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// CIStatus is the combined CI status of a commit.
+type CIStatus struct {
+	Ref    string  `json:"ref"`
+	SHA    string  `json:"sha"`
+	State  string  `json:"state"`
+	Checks []Check `json:"checks"`
+}
+
+// ListOptions filters issue and pull request lists.
+type ListOptions struct {
+	State  string // open, closed, merged or all
+	Labels []string
+	Limit  int
+}
+
+// Forge is the API of a code hosting platform.
+type Forge interface {
+	ListIssues(ctx context.Context, repo string, opts ListOptions) ([]Issue, error)
+	CreateIssue(ctx context.Context, repo, title, body string, labels []string) (*Issue, error)
+	ListPulls(ctx context.Context, repo string, opts ListOptions) ([]Pull, error)
+	CreatePull(ctx context.Context, repo, title, body, head, base string, draft bool) (*Pull, error)
+	GetPull(ctx context.Context, repo string, number int) (*Pull, error)
+	// PullDiff returns the unified diff of a pull request, stopping after about limit bytes.
+	PullDiff(ctx context.Context, repo string, number int, limit int) (string, error)
+	PullComments(ctx context.Context, repo string, number int) ([]Comment, error)
+	// CIStatus returns the CI status of a branch, tag or commit.
+	CIStatus(ctx context.Context, repo, ref string) (*CIStatus, error)
+}
+
+// NewForge creates the forge selected by the configuration.
+func NewForge(cfg *ForgeConfig, client *http.Client) Forge {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Provider == ProviderGitLab {
+		if baseURL == "" {
+			baseURL = "https://gitlab.com/api/v4"
+		}
+		header := http.Header{}
+		if cfg.Token != "" {
+			header.Set("PRIVATE-TOKEN", cfg.Token)
+		}
+		return &gitLab{api: &apiClient{client: client, baseURL: baseURL, header: header}}
+	}
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+	header := http.Header{}
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if cfg.Token != "" {
+		header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	return &gitHub{api: &apiClient{client: client, baseURL: baseURL, header: header}}
+}
+
+// combineStates reduces check states to one: any failure fails, else anything pending is pending.
+func combineStates(checks []Check) string {
+	if len(checks) == 0 {
+		return ciUnknown
+	}
+	state := ciSuccess
+	for _, c := range checks {
+		switch c.State {
+		case ciFailure:
+			return ciFailure
+		case ciPending:
+			state = ciPending
+		}
+	}
+	return state
+}
+
+// apiClient sends authenticated requests to a REST API.
+type apiClient struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+// do sends a request and returns the response body. A JSON body is encoded when body is not nil.
+func (c *apiClient) do(ctx context.Context, method, path string, query url.Values, body any, accept string) ([]byte, error) {
+	rawURL := c.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if accept == "" {
+		accept = "application/json"
+	}
+	req.Header.Set("Accept", accept)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, data, err := httpapi.Do(c.client, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: HTTP %s: %s", method, path, resp.Status, errorMessage(data))
+	}
+	return data, nil
+}
+
+func (c *apiClient) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	return c.sendJSON(ctx, http.MethodGet, path, query, nil, out)
+}
+
+func (c *apiClient) sendJSON(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	data, err := c.do(ctx, method, path, query, body, "")
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("invalid response to %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message of a GitHub or GitLab error response. GitLab returns
+// validation errors as an object or array in message.
+func errorMessage(data []byte) string {
+	var apiErr struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+	msg := ""
+	if json.Unmarshal(data, &apiErr) == nil {
+		if len(apiErr.Message) > 0 && json.Unmarshal(apiErr.Message, &msg) != nil {
+			msg = string(apiErr.Message)
+		}
+		if msg == "" {
+			msg = apiErr.Error
+		}
+		if len(apiErr.Errors) > 0 {
+			msg += " " + string(apiErr.Errors)
+		}
+	}
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		msg = strings.TrimSpace(string(data))
+	}
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	return msg
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package forge
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const ForgePromptDefault = `
+You are an assistant that works with GitHub or GitLab repositories through their API. Your capabilities include:
+
+1. **Issues**: List issues with forge_list_issues and open new ones with forge_create_issue.
+
+2. **Pull requests**: List pull requests (merge requests on GitLab) with forge_list_pulls and open new ones with forge_create_pull.
+
+3. **Code review**: Read the diff of a pull request with forge_pull_diff and the review comments with forge_pull_comments, including the file and line they refer to.
+
+4. **CI**: Check the CI status of a pull request, branch or commit with forge_ci_status.
+
+Repositories are given as owner/name (group/subgroup/project on GitLab). Only create issues and pull requests when the user asked for it.
+`
+
+// Forge providers.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// ForgeConfig represents the configuration for the forge service.
+type ForgeConfig struct {
+	PromptFile   string `json:"prompt_file"` // PromptFile is the prompt file for the forge service.
+	prompt       string
+	Provider     string   `json:"provider"`      // Provider is github or gitlab.
+	BaseURL      string   `json:"base_url"`      // BaseURL is the API URL, empty uses github.com or gitlab.com. Set it for GitHub Enterprise or self-managed GitLab.
+	Token        string   `json:"token"`         // Token is the access token, empty reads GITHUB_TOKEN or GITLAB_TOKEN.
+	DefaultRepo  string   `json:"default_repo"`  // DefaultRepo is used when a tool call names no repository.
+	AllowedRepos []string `json:"allowed_repos"` // AllowedRepos are repository patterns such as owner/*, empty allows every repository.
+	ReadOnly     bool     `json:"read_only"`     // ReadOnly disables the tools that create issues and pull requests.
+	MaxDiffSize  int      `json:"max_diff_size"` // MaxDiffSize is the maximum size of a returned diff, in bytes.
+	Timeout      int      `json:"timeout"`       // Timeout is the timeout of a single API request. time.Second
+}
+
+// NewForgeConfig creates a new ForgeConfig with default values.
+func NewForgeConfig() *ForgeConfig {
+	return &ForgeConfig{
+		prompt:       ForgePromptDefault,
+		Provider:     ProviderGitHub,
+		AllowedRepos: []string{},
+		MaxDiffSize:  256 * 1024,
+		Timeout:      30,
+	}
+}
+
+// Check validates the forge configuration.
+func (cfg *ForgeConfig) Check() error {
+	cfg.prompt = ForgePromptDefault
+	cfg.Provider = strings.ToLower(cfg.Provider)
+	switch cfg.Provider {
+	case ProviderGitHub, ProviderGitLab:
+	default:
+		return fmt.Errorf("provider must be %s or %s", ProviderGitHub, ProviderGitLab)
+	}
+	if cfg.Token == "" {
+		if cfg.Provider == ProviderGitHub {
+			cfg.Token = os.Getenv("GITHUB_TOKEN")
+		} else {
+			cfg.Token = os.Getenv("GITLAB_TOKEN")
+		}
+	}
+	for _, pattern := range cfg.AllowedRepos {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid allowed_repos pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.DefaultRepo != "" && !cfg.repoAllowed(cfg.DefaultRepo) {
+		return fmt.Errorf("default_repo %s is not in allowed_repos", cfg.DefaultRepo)
+	}
+	if cfg.MaxDiffSize <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_diff_size and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// repoAllowed reports whether a repository matches allowed_repos, ignoring case.
+func (cfg *ForgeConfig) repoAllowed(repo string) bool {
+	if len(cfg.AllowedRepos) == 0 {
+		return true
+	}
+	repo = strings.ToLower(repo)
+	for _, pattern := range cfg.AllowedRepos {
+		if ok, _ := path.Match(strings.ToLower(pattern), repo); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gitHub uses the GitHub REST API.
+type gitHub struct {
+	api *apiClient
+}
+
+type ghUser struct {
+	Login string `json:"login"`
+}
+
+type ghIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	User   ghUser `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Comments    int    `json:"comments"`
+	HTMLURL     string `json:"html_url"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	Body        string `json:"body"`
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request"`
+}
+
+func (i ghIssue) issue() Issue {
+	issue := Issue{
+		Number:    i.Number,
+		Title:     i.Title,
+		State:     i.State,
+		Author:    i.User.Login,
+		Comments:  i.Comments,
+		URL:       i.HTMLURL,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		Body:      i.Body,
+	}
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	return issue
+}
+
+type ghPull struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	User   ghUser `json:"user"`
+	Head   struct {
+		Ref   string `json:"ref"`
+		SHA   string `json:"sha"`
+		Label string `json:"label"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Draft     bool    `json:"draft"`
+	MergedAt  *string `json:"merged_at"`
+	HTMLURL   string  `json:"html_url"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	Body      string  `json:"body"`
+}
+
+func (p ghPull) pull() Pull {
+	state := p.State
+	if p.MergedAt != nil {
+		state = "merged"
+	}
+	return Pull{
+		Number:    p.Number,
+		Title:     p.Title,
+		State:     state,
+		Author:    p.User.Login,
+		Head:      p.Head.Ref,
+		Base:      p.Base.Ref,
+		HeadSHA:   p.Head.SHA,
+		Draft:     p.Draft,
+		URL:       p.HTMLURL,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		Body:      p.Body,
+	}
+}
+
+func repoPath(repo string) string {
+	return "/repos/" + repo
+}
+
+// ListIssues lists issues. The issues API also returns pull requests, which are left out.
+func (g *gitHub) ListIssues(ctx context.Context, repo string, opts ListOptions) ([]Issue, error) {
+	q := url.Values{}
+	q.Set("state", opts.State)
+	q.Set("per_page", strconv.Itoa(min(opts.Limit*2, 100)))
+	if len(opts.Labels) > 0 {
+		q.Set("labels", strings.Join(opts.Labels, ","))
+	}
+	var resp []ghIssue
+	err := g.api.getJSON(ctx, repoPath(repo)+"/issues", q, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(resp))
+	for _, i := range resp {
+		if i.PullRequest != nil {
+			continue
+		}
+		issue := i.issue()
+		issue.Body = ""
+		issues = append(issues, issue)
+		if len(issues) == opts.Limit {
+			break
+		}
+	}
+	return issues, nil
+}
+
+func (g *gitHub) CreateIssue(ctx context.Context, repo, title, body string, labels []string) (*Issue, error) {
+	req := map[string]any{"title": title, "body": body}
+	if len(labels) > 0 {
+		req["labels"] = labels
+	}
+	var resp ghIssue
+	err := g.api.sendJSON(ctx, http.MethodPost, repoPath(repo)+"/issues", nil, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issue := resp.issue()
+	return &issue, nil
+}
+
+// ListPulls lists pull requests. GitHub has no merged state filter, so merged pull requests
+// are taken from the closed ones.
+func (g *gitHub) ListPulls(ctx context.Context, repo string, opts ListOptions) ([]Pull, error) {
+	q := url.Values{}
+	state := opts.State
+	if state == "merged" {
+		q.Set("state", "closed")
+		q.Set("per_page", "100")
+	} else {
+		q.Set("state", state)
+		q.Set("per_page", strconv.Itoa(opts.Limit))
+	}
+	q.Set("sort", "updated")
+	q.Set("direction", "desc")
+	var resp []ghPull
+	err := g.api.getJSON(ctx, repoPath(repo)+"/pulls", q, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pulls := make([]Pull, 0, len(resp))
+	for _, p := range resp {
+		pull := p.pull()
+		if state == "merged" && pull.State != "merged" {
+			continue
+		}
+		pull.Body = ""
+		pulls = append(pulls, pull)
+		if len(pulls) == opts.Limit {
+			break
+		}
+	}
+	return pulls, nil
+}
+
+func (g *gitHub) CreatePull(ctx context.Context, repo, title, body, head, base string, draft bool) (*Pull, error) {
+	req := map[string]any{"title": title, "body": body, "head": head, "base": base, "draft": draft}
+	var resp ghPull
+	err := g.api.sendJSON(ctx, http.MethodPost, repoPath(repo)+"/pulls", nil, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pull := resp.pull()
+	return &pull, nil
+}
+
+func (g *gitHub) GetPull(ctx context.Context, repo string, number int) (*Pull, error) {
+	var resp ghPull
+	err := g.api.getJSON(ctx, fmt.Sprintf("%s/pulls/%d", repoPath(repo), number), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pull := resp.pull()
+	return &pull, nil
+}
+
+// PullDiff requests the pull request in the diff media type.
+func (g *gitHub) PullDiff(ctx context.Context, repo string, number int, limit int) (string, error) {
+	data, err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", repoPath(repo), number), nil, nil, "application/vnd.github.diff")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// PullComments returns the reviews with a summary or a verdict, followed by the comments on
+// lines of the diff.
+func (g *gitHub) PullComments(ctx context.Context, repo string, number int) ([]Comment, error) {
+	q := url.Values{}
+	q.Set("per_page", "100")
+	var reviews []struct {
+		User        ghUser `json:"user"`
+		Body        string `json:"body"`
+		State       string `json:"state"`
+		HTMLURL     string `json:"html_url"`
+		SubmittedAt string `json:"submitted_at"`
+	}
+	err := g.api.getJSON(ctx, fmt.Sprintf("%s/pulls/%d/reviews", repoPath(repo), number), q, &reviews)
+	if err != nil {
+		return nil, err
+	}
+	var lineComments []struct {
+		User         ghUser `json:"user"`
+		Body         string `json:"body"`
+		Path         string `json:"path"`
+		Line         *int   `json:"line"`
+		OriginalLine *int   `json:"original_line"`
+		HTMLURL      string `json:"html_url"`
+		CreatedAt    string `json:"created_at"`
+	}
+	err = g.api.getJSON(ctx, fmt.Sprintf("%s/pulls/%d/comments", repoPath(repo), number), q, &lineComments)
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, r := range reviews {
+		if r.Body == "" && r.State == "COMMENTED" {
+			continue
+		}
+		comments = append(comments, Comment{Author: r.User.Login, Body: r.Body, State: strings.ToLower(r.State), URL: r.HTMLURL, CreatedAt: r.SubmittedAt})
+	}
+	for _, c := range lineComments {
+		comment := Comment{Author: c.User.Login, Body: c.Body, Path: c.Path, URL: c.HTMLURL, CreatedAt: c.CreatedAt}
+		// Comments on lines that changed since have no line, only the original one.
+		if c.Line != nil {
+			comment.Line = *c.Line
+		} else if c.OriginalLine != nil {
+			comment.Line = *c.OriginalLine
+			comment.State = "outdated"
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// CIStatus combines the check runs, e.g. GitHub Actions, with the commit statuses set by other
+// CI systems.
+func (g *gitHub) CIStatus(ctx context.Context, repo, ref string) (*CIStatus, error) {
+	q := url.Values{}
+	q.Set("per_page", "100")
+	ref = url.PathEscape(ref)
+	var runs struct {
+		CheckRuns []struct {
+			Name       string  `json:"name"`
+			HeadSHA    string  `json:"head_sha"`
+			Status     string  `json:"status"`
+			Conclusion *string `json:"conclusion"`
+			HTMLURL    string  `json:"html_url"`
+		} `json:"check_runs"`
+	}
+	err := g.api.getJSON(ctx, fmt.Sprintf("%s/commits/%s/check-runs", repoPath(repo), ref), q, &runs)
+	if err != nil {
+		return nil, err
+	}
+	var combined struct {
+		SHA      string `json:"sha"`
+		Statuses []struct {
+			Context     string `json:"context"`
+			State       string `json:"state"`
+			Description string `json:"description"`
+			TargetURL   string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	err = g.api.getJSON(ctx, fmt.Sprintf("%s/commits/%s/status", repoPath(repo), ref), q, &combined)
+	if err != nil {
+		return nil, err
+	}
+	status := &CIStatus{SHA: combined.SHA}
+	for _, r := range runs.CheckRuns {
+		check := Check{Name: r.Name, State: ciPending, Detail: r.Status, URL: r.HTMLURL}
+		if r.Status == "completed" && r.Conclusion != nil {
+			check.Detail = *r.Conclusion
+			switch *r.Conclusion {
+			case "success", "neutral", "skipped":
+				check.State = ciSuccess
+			default:
+				check.State = ciFailure
+			}
+		}
+		if status.SHA == "" {
+			status.SHA = r.HeadSHA
+		}
+		status.Checks = append(status.Checks, check)
+	}
+	for _, s := range combined.Statuses {
+		check := Check{Name: s.Context, State: ciPending, Detail: s.Description, URL: s.TargetURL}
+		switch s.State {
+		case "success":
+			check.State = ciSuccess
+		case "failure", "error":
+			check.State = ciFailure
+		}
+		status.Checks = append(status.Checks, check)
+	}
+	status.State = combineStates(status.Checks)
+	return status, nil
+}
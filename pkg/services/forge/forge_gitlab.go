@@ -0,0 +1,363 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gitLab uses the GitLab REST API v4. Pull requests are merge requests there, and their
+// number is the project-scoped iid.
+type gitLab struct {
+	api *apiClient
+}
+
+type glUser struct {
+	Username string `json:"username"`
+}
+
+type glIssue struct {
+	IID            int      `json:"iid"`
+	Title          string   `json:"title"`
+	State          string   `json:"state"`
+	Author         glUser   `json:"author"`
+	Labels         []string `json:"labels"`
+	UserNotesCount int      `json:"user_notes_count"`
+	WebURL         string   `json:"web_url"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+	Description    string   `json:"description"`
+}
+
+func (i glIssue) issue() Issue {
+	return Issue{
+		Number:    i.IID,
+		Title:     i.Title,
+		State:     glState(i.State),
+		Author:    i.Author.Username,
+		Labels:    i.Labels,
+		Comments:  i.UserNotesCount,
+		URL:       i.WebURL,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		Body:      i.Description,
+	}
+}
+
+type glMergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	Author       glUser `json:"author"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+	Draft        bool   `json:"draft"`
+	WebURL       string `json:"web_url"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	Description  string `json:"description"`
+}
+
+func (m glMergeRequest) pull() Pull {
+	return Pull{
+		Number:    m.IID,
+		Title:     m.Title,
+		State:     glState(m.State),
+		Author:    m.Author.Username,
+		Head:      m.SourceBranch,
+		Base:      m.TargetBranch,
+		HeadSHA:   m.SHA,
+		Draft:     m.Draft,
+		URL:       m.WebURL,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+		Body:      m.Description,
+	}
+}
+
+// glState maps GitLab states to the GitHub names.
+func glState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return state
+}
+
+// listState maps a list filter to GitLab, which has no "all" issue state.
+func listState(state string) string {
+	switch state {
+	case "open":
+		return "opened"
+	case "all":
+		return ""
+	}
+	return state
+}
+
+// projectPath addresses a project by its URL-encoded full path.
+func projectPath(repo string) string {
+	return "/projects/" + url.PathEscape(repo)
+}
+
+func (g *gitLab) ListIssues(ctx context.Context, repo string, opts ListOptions) ([]Issue, error) {
+	q := url.Values{}
+	if state := listState(opts.State); state != "" {
+		q.Set("state", state)
+	}
+	q.Set("per_page", strconv.Itoa(opts.Limit))
+	if len(opts.Labels) > 0 {
+		q.Set("labels", strings.Join(opts.Labels, ","))
+	}
+	var resp []glIssue
+	err := g.api.getJSON(ctx, projectPath(repo)+"/issues", q, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(resp))
+	for _, i := range resp {
+		issue := i.issue()
+		issue.Body = ""
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func (g *gitLab) CreateIssue(ctx context.Context, repo, title, body string, labels []string) (*Issue, error) {
+	req := map[string]any{"title": title, "description": body}
+	if len(labels) > 0 {
+		req["labels"] = strings.Join(labels, ",")
+	}
+	var resp glIssue
+	err := g.api.sendJSON(ctx, http.MethodPost, projectPath(repo)+"/issues", nil, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issue := resp.issue()
+	return &issue, nil
+}
+
+func (g *gitLab) ListPulls(ctx context.Context, repo string, opts ListOptions) ([]Pull, error) {
+	q := url.Values{}
+	if state := listState(opts.State); state != "" {
+		q.Set("state", state)
+	}
+	q.Set("per_page", strconv.Itoa(opts.Limit))
+	q.Set("order_by", "updated_at")
+	var resp []glMergeRequest
+	err := g.api.getJSON(ctx, projectPath(repo)+"/merge_requests", q, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pulls := make([]Pull, 0, len(resp))
+	for _, m := range resp {
+		pull := m.pull()
+		pull.Body = ""
+		pulls = append(pulls, pull)
+	}
+	return pulls, nil
+}
+
+// CreatePull opens a merge request. Drafts are marked by the title prefix.
+func (g *gitLab) CreatePull(ctx context.Context, repo, title, body, head, base string, draft bool) (*Pull, error) {
+	if draft && !strings.HasPrefix(strings.ToLower(title), "draft:") {
+		title = "Draft: " + title
+	}
+	req := map[string]any{"title": title, "description": body, "source_branch": head, "target_branch": base}
+	var resp glMergeRequest
+	err := g.api.sendJSON(ctx, http.MethodPost, projectPath(repo)+"/merge_requests", nil, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pull := resp.pull()
+	return &pull, nil
+}
+
+func (g *gitLab) GetPull(ctx context.Context, repo string, number int) (*Pull, error) {
+	var resp glMergeRequest
+	err := g.api.getJSON(ctx, fmt.Sprintf("%s/merge_requests/%d", projectPath(repo), number), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	pull := resp.pull()
+	return &pull, nil
+}
+
+// PullDiff builds a unified diff from the per-file diffs, which GitLab returns without headers.
+func (g *gitLab) PullDiff(ctx context.Context, repo string, number int, limit int) (string, error) {
+	var b strings.Builder
+	for page := 1; b.Len() <= limit; page++ {
+		q := url.Values{}
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", "50")
+		var files []struct {
+			OldPath     string `json:"old_path"`
+			NewPath     string `json:"new_path"`
+			Diff        string `json:"diff"`
+			NewFile     bool   `json:"new_file"`
+			DeletedFile bool   `json:"deleted_file"`
+		}
+		err := g.api.getJSON(ctx, fmt.Sprintf("%s/merge_requests/%d/diffs", projectPath(repo), number), q, &files)
+		if err != nil {
+			return "", err
+		}
+		for _, f := range files {
+			oldName, newName := "a/"+f.OldPath, "b/"+f.NewPath
+			b.WriteString(fmt.Sprintf("diff --git %s %s\n", oldName, newName))
+			if f.NewFile {
+				oldName = "/dev/null"
+			}
+			if f.DeletedFile {
+				newName = "/dev/null"
+			}
+			b.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+			b.WriteString(f.Diff)
+			if f.Diff != "" && !strings.HasSuffix(f.Diff, "\n") {
+				b.WriteString("\n")
+			}
+		}
+		if len(files) < 50 {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+// PullComments returns the notes of the merge request discussions, without system notes.
+func (g *gitLab) PullComments(ctx context.Context, repo string, number int) ([]Comment, error) {
+	q := url.Values{}
+	q.Set("per_page", "100")
+	var discussions []struct {
+		Notes []struct {
+			Author     glUser `json:"author"`
+			Body       string `json:"body"`
+			System     bool   `json:"system"`
+			Resolvable bool   `json:"resolvable"`
+			Resolved   bool   `json:"resolved"`
+			CreatedAt  string `json:"created_at"`
+			Position   *struct {
+				NewPath string `json:"new_path"`
+				OldPath string `json:"old_path"`
+				NewLine *int   `json:"new_line"`
+				OldLine *int   `json:"old_line"`
+			} `json:"position"`
+		} `json:"notes"`
+	}
+	err := g.api.getJSON(ctx, fmt.Sprintf("%s/merge_requests/%d/discussions", projectPath(repo), number), q, &discussions)
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, d := range discussions {
+		for _, n := range d.Notes {
+			if n.System {
+				continue
+			}
+			comment := Comment{Author: n.Author.Username, Body: n.Body, CreatedAt: n.CreatedAt}
+			if n.Resolvable {
+				resolved := n.Resolved
+				comment.Resolved = &resolved
+			}
+			if p := n.Position; p != nil {
+				comment.Path = p.NewPath
+				if p.NewLine != nil {
+					comment.Line = *p.NewLine
+				} else if p.OldLine != nil {
+					comment.Path, comment.Line = p.OldPath, *p.OldLine
+				}
+			}
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// CIStatus returns the jobs of the latest pipeline of a commit or branch.
+func (g *gitLab) CIStatus(ctx context.Context, repo, ref string) (*CIStatus, error) {
+	q := url.Values{}
+	if isSHA(ref) {
+		q.Set("sha", ref)
+	} else {
+		q.Set("ref", ref)
+	}
+	q.Set("per_page", "1")
+	var pipelines []struct {
+		ID     int    `json:"id"`
+		SHA    string `json:"sha"`
+		Ref    string `json:"ref"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	err := g.api.getJSON(ctx, projectPath(repo)+"/pipelines", q, &pipelines)
+	if err != nil {
+		return nil, err
+	}
+	status := &CIStatus{Ref: ref, State: ciUnknown}
+	if len(pipelines) == 0 {
+		return status, nil
+	}
+	p := pipelines[0]
+	status.SHA = p.SHA
+	var jobs []struct {
+		Name   string `json:"name"`
+		Stage  string `json:"stage"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	q = url.Values{}
+	q.Set("per_page", "100")
+	err = g.api.getJSON(ctx, fmt.Sprintf("%s/pipelines/%d/jobs", projectPath(repo), p.ID), q, &jobs)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		status.Checks = append(status.Checks, Check{Name: j.Stage + "/" + j.Name, State: glJobState(j.Status), Detail: j.Status, URL: j.WebURL})
+	}
+	status.State = glJobState(p.Status)
+	return status, nil
+}
+
+// glJobState maps pipeline and job statuses to the normalized CI states.
+func glJobState(status string) string {
+	switch status {
+	case "success", "skipped", "manual":
+		return ciSuccess
+	case "failed", "canceled":
+		return ciFailure
+	case "":
+		return ciUnknown
+	}
+	return ciPending
+}
+
+// isSHA reports whether ref looks like a full commit hash.
+func isSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package forge
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func TestForgeGitHub(t *testing.T) {
+	var created map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/gojue/moling/issues":
+			if r.URL.Query().Get("labels") != "bug,ui" {
+				t.Errorf("unexpected labels %q", r.URL.Query().Get("labels"))
+			}
+			_, _ = w.Write([]byte(`[{"number":2,"title":"A PR","pull_request":{"url":"x"}},
+{"number":1,"title":"Crash","state":"open","user":{"login":"alice"},"labels":[{"name":"bug"}],"comments":3,"html_url":"https://github.com/gojue/moling/issues/1"}]`))
+		case "POST /repos/gojue/moling/issues":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"number":3,"html_url":"https://github.com/gojue/moling/issues/3"}`))
+		case "GET /repos/gojue/moling/pulls/7":
+			if r.Header.Get("Accept") == "application/vnd.github.diff" {
+				_, _ = w.Write([]byte("diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-old\n+new\n"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"number":7,"title":"Fix","state":"open","user":{"login":"bob"},"head":{"ref":"fix","sha":"abc123"},"base":{"ref":"main"},"body":"Fixes #1"}`))
+		case "GET /repos/gojue/moling/pulls/7/reviews":
+			_, _ = w.Write([]byte(`[{"user":{"login":"carol"},"body":"","state":"COMMENTED"},{"user":{"login":"carol"},"body":"Looks good","state":"APPROVED"}]`))
+		case "GET /repos/gojue/moling/pulls/7/comments":
+			_, _ = w.Write([]byte(`[{"user":{"login":"carol"},"body":"Typo","path":"a.go","line":1},{"user":{"login":"dave"},"body":"Old","path":"b.go","line":null,"original_line":9}]`))
+		case "GET /repos/gojue/moling/commits/abc123/check-runs":
+			_, _ = w.Write([]byte(`{"check_runs":[{"name":"build","status":"completed","conclusion":"success"},{"name":"test","status":"in_progress","conclusion":null}]}`))
+		case "GET /repos/gojue/moling/commits/abc123/status":
+			_, _ = w.Write([]byte(`{"sha":"abc123","statuses":[{"context":"ci/lint","state":"failure","description":"2 issues"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	srv := testkit.NewServiceAs[*ForgeServer](t, NewForgeServer, map[string]any{"base_url": ts.URL, "token": "gh-token", "default_repo": "gojue/moling", "allowed_repos": []any{"gojue/*"}})
+
+	text, isErr := testkit.CallHandler(t, srv.handleListIssues, map[string]any{"labels": []any{"bug", "ui"}})
+	if isErr || !strings.Contains(text, `"title": "Crash"`) || strings.Contains(text, "A PR") || !strings.Contains(text, `"author": "alice"`) {
+		t.Errorf("unexpected issues: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleCreateIssue, map[string]any{"title": "New bug", "body": "Details", "labels": []any{"bug"}})
+	if isErr || !strings.Contains(text, "Created issue #3") || created["title"] != "New bug" {
+		t.Errorf("unexpected create result: %s %v", text, created)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handlePullDiff, map[string]any{"number": float64(7)})
+	if isErr || !strings.Contains(text, "#7 Fix") || !strings.Contains(text, "fix -> main") || !strings.Contains(text, "+new") {
+		t.Errorf("unexpected diff: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handlePullComments, map[string]any{"number": float64(7)})
+	var comments []Comment
+	if isErr || json.Unmarshal([]byte(text), &comments) != nil || len(comments) != 3 {
+		t.Fatalf("unexpected comments: %s", text)
+	}
+	if comments[0].State != "approved" || comments[1].Path != "a.go" || comments[1].Line != 1 || comments[2].State != "outdated" || comments[2].Line != 9 {
+		t.Errorf("unexpected comments: %+v", comments)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleCIStatus, map[string]any{"number": float64(7)})
+	var status CIStatus
+	if isErr || json.Unmarshal([]byte(text), &status) != nil {
+		t.Fatalf("unexpected CI status: %s", text)
+	}
+	if status.State != ciFailure || status.SHA != "abc123" || len(status.Checks) != 3 || status.Checks[1].State != ciPending {
+		t.Errorf("unexpected CI status: %+v", status)
+	}
+
+	for _, args := range []map[string]any{
+		{"repo": "other/repo"},
+		{"repo": "a/b/c"},
+		{"repo": "gojue/moling", "limit": float64(500)},
+	} {
+		if text, isErr := testkit.CallHandler(t, srv.handleListIssues, args); !isErr {
+			t.Errorf("expected error for %v, got %s", args, text)
+		}
+	}
+
+	srv = testkit.NewServiceAs[*ForgeServer](t, NewForgeServer, map[string]any{"base_url": ts.URL, "token": "wrong", "read_only": true})
+	text, isErr = testkit.CallHandler(t, srv.handleListPulls, map[string]any{"repo": "gojue/moling"})
+	if !isErr || !strings.Contains(text, "Bad credentials") {
+		t.Errorf("expected an API error, got %s", text)
+	}
+	for _, tool := range srv.Tools() {
+		if strings.HasPrefix(tool.Tool.Name, "forge_create") {
+			t.Errorf("read_only server registered %s", tool.Tool.Name)
+		}
+	}
+}
+
+func TestForgeGitLab(t *testing.T) {
+	var created map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gl-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(r.URL.RawPath, "/projects/group%2Fsub%2Fproject/") {
+			t.Errorf("project path is not encoded: %s", r.URL.RawPath)
+		}
+		switch r.Method + " " + strings.TrimPrefix(r.URL.Path, "/projects/group/sub/project") {
+		case "GET /merge_requests":
+			if r.URL.Query().Get("state") != "opened" {
+				t.Errorf("unexpected state %q", r.URL.Query().Get("state"))
+			}
+			_, _ = w.Write([]byte(`[{"iid":4,"title":"Feature","state":"opened","author":{"username":"erin"},"source_branch":"feat","target_branch":"main","draft":true}]`))
+		case "POST /merge_requests":
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &created)
+			_, _ = w.Write([]byte(`{"iid":5,"web_url":"https://gitlab.com/group/sub/project/-/merge_requests/5"}`))
+		case "GET /merge_requests/4":
+			_, _ = w.Write([]byte(`{"iid":4,"title":"Feature","state":"opened","source_branch":"feat","target_branch":"main","sha":"0123456789abcdef0123456789abcdef01234567"}`))
+		case "GET /merge_requests/4/diffs":
+			_, _ = w.Write([]byte(`[{"old_path":"new.txt","new_path":"new.txt","new_file":true,"diff":"@@ -0,0 +1 @@\n+hello\n"}]`))
+		case "GET /merge_requests/4/discussions":
+			_, _ = w.Write([]byte(`[{"notes":[{"author":{"username":"bot"},"body":"added 1 commit","system":true}]},
+{"notes":[{"author":{"username":"frank"},"body":"Rename this","resolvable":true,"resolved":false,"position":{"new_path":"new.txt","new_line":1}}]}]`))
+		case "GET /pipelines":
+			if r.URL.Query().Get("sha") != "0123456789abcdef0123456789abcdef01234567" {
+				t.Errorf("unexpected pipeline query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[{"id":99,"sha":"0123456789abcdef0123456789abcdef01234567","status":"running"}]`))
+		case "GET /pipelines/99/jobs":
+			_, _ = w.Write([]byte(`[{"name":"unit","stage":"test","status":"success"},{"name":"e2e","stage":"test","status":"running"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	srv := testkit.NewServiceAs[*ForgeServer](t, NewForgeServer, map[string]any{"provider": "gitlab", "base_url": ts.URL, "token": "gl-token"})
+	repo := "group/sub/project"
+
+	text, isErr := testkit.CallHandler(t, srv.handleListPulls, map[string]any{"repo": repo})
+	if isErr || !strings.Contains(text, `"state": "open"`) || !strings.Contains(text, `"head": "feat"`) {
+		t.Errorf("unexpected merge requests: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleCreatePull, map[string]any{"repo": repo, "title": "WIP", "head": "feat", "base": "main", "draft": true})
+	if isErr || !strings.Contains(text, "#5") || created["title"] != "Draft: WIP" || created["source_branch"] != "feat" {
+		t.Errorf("unexpected create result: %s %v", text, created)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handlePullDiff, map[string]any{"repo": repo, "number": float64(4)})
+	if isErr || !strings.Contains(text, "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n") {
+		t.Errorf("unexpected diff: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handlePullComments, map[string]any{"repo": repo, "number": float64(4)})
+	if isErr || strings.Contains(text, "added 1 commit") || !strings.Contains(text, `"path": "new.txt"`) || !strings.Contains(text, `"resolved": false`) {
+		t.Errorf("unexpected comments: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleCIStatus, map[string]any{"repo": repo, "number": float64(4)})
+	if isErr || !strings.Contains(text, `"state": "pending"`) || !strings.Contains(text, `"name": "test/unit"`) {
+		t.Errorf("unexpected CI status: %s", text)
+	}
+}
+
+func TestForgeConfigCheck(t *testing.T) {
+	cfg := NewForgeConfig()
+	cfg.Provider = "bitbucket"
+	if err := cfg.Check(); err == nil {
+		t.Errorf("expected an invalid provider error")
+	}
+	cfg = NewForgeConfig()
+	cfg.AllowedRepos = []string{"gojue/*"}
+	cfg.DefaultRepo = "other/repo"
+	if err := cfg.Check(); err == nil {
+		t.Errorf("expected a default_repo error")
+	}
+	if !cfg.repoAllowed("GoJue/MoLing") || cfg.repoAllowed("gojue/moling/sub") {
+		t.Errorf("unexpected allowed_repos matching")
+	}
+}
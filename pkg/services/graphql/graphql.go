@@ -0,0 +1,383 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package graphql provides a GraphQL client for the MoLing application.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	GraphQLServerName comm.MoLingServerType = "GraphQL"
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,64}$`)
+
+// GraphQLServer implements the Service interface and queries configured GraphQL endpoints.
+type GraphQLServer struct {
+	abstract.MLService
+	config    *GraphQLConfig
+	endpoints map[string]EndpointConfig
+	client    *http.Client
+
+	mu      sync.Mutex
+	schemas map[string]*cachedSchema
+}
+
+// NewGraphQLServer creates a new GraphQLServer instance.
+func NewGraphQLServer(ctx context.Context) (abstract.Service, error) {
+	gc := NewGraphQLConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("GraphQLServer: invalid config type")
+	}
+	gc.CachePath = filepath.Join(globalConf.BasePath, "cache", "graphql")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("GraphQLServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(GraphQLServerName))
+	})
+
+	gs := &GraphQLServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    gc,
+		endpoints: make(map[string]EndpointConfig),
+		schemas:   make(map[string]*cachedSchema),
+	}
+	err := gs.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// Init registers the prompt and tools of the GraphQL service.
+func (gs *GraphQLServer) Init() error {
+	for _, ep := range gs.config.Endpoints {
+		gs.endpoints[ep.Name] = ep
+	}
+	gs.client = &http.Client{Timeout: time.Duration(gs.config.Timeout) * time.Second}
+	err := utils.CreateDirectory(gs.config.CachePath)
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory %s: %w", gs.config.CachePath, err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "graphql_prompt",
+			Description: "Get the relevant functions and prompts of the GraphQL MCP Server",
+		},
+		HandlerFunc: gs.handlePrompt,
+	}
+	gs.AddPrompt(pe)
+
+	gs.AddTool(mcp.NewTool(
+		"graphql_schema",
+		mcp.WithDescription("Describe the schema of a GraphQL endpoint: the root query and mutation fields and an index of all types, or the definition of a single type. Omit endpoint to list the configured endpoints"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+		),
+		mcp.WithString("type",
+			mcp.Description("Name of a type to describe in detail"),
+		),
+		mcp.WithBoolean("refresh",
+			mcp.Description("Ignore the cached schema and run introspection again, default: false"),
+		),
+	), gs.handleSchema)
+
+	gs.AddTool(mcp.NewTool(
+		"graphql_query",
+		mcp.WithDescription("Run a GraphQL query against a configured endpoint and return the JSON response"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("query",
+			mcp.Description("GraphQL document"),
+			mcp.Required(),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Values of the variables used in the document"),
+		),
+		mcp.WithString("operation_name",
+			mcp.Description("Operation to run when the document contains several"),
+		),
+	), gs.handleQuery)
+	return nil
+}
+
+func (gs *GraphQLServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: gs.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// listEndpoints describes the configured endpoints without their credentials.
+func (gs *GraphQLServer) listEndpoints() string {
+	if len(gs.config.Endpoints) == 0 {
+		return "No GraphQL endpoints are configured."
+	}
+	var sb strings.Builder
+	for _, ep := range gs.config.Endpoints {
+		mode := "queries only"
+		if ep.AllowMutations {
+			mode = "queries and mutations"
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s (%s)\n", ep.Name, ep.URL, mode))
+	}
+	return sb.String()
+}
+
+func (gs *GraphQLServer) handleSchema(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "endpoint", "")
+	if name == "" {
+		return mcp.NewToolResultText(gs.listEndpoints()), nil
+	}
+	ep, ok := gs.endpoints[name]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("endpoint %q is not configured", name)), nil
+	}
+	refresh := abstract.GetBool(args, "refresh", false)
+	s, err := gs.schema(ctx, ep, refresh)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load the schema of %s: %s", name, err.Error())), nil
+	}
+	typeName := abstract.GetString(args, "type", "")
+	if typeName == "" {
+		return mcp.NewToolResultText(s.summary()), nil
+	}
+	t := s.lookup(typeName)
+	if t == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("type %s not found in the schema of %s", typeName, name)), nil
+	}
+	return mcp.NewToolResultText(t.sdl()), nil
+}
+
+func (gs *GraphQLServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "endpoint", "")
+	ep, ok := gs.endpoints[name]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("endpoint %q is not configured", name)), nil
+	}
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+	variables := abstract.GetMap(args, "variables")
+	opName := abstract.GetString(args, "operation_name", "")
+	err := gs.checkQuery(ep, query, opName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	data, err := gs.execute(ctx, ep, query, variables, opName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("query failed: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// checkQuery enforces the size, depth and operation type guards on a query document.
+func (gs *GraphQLServer) checkQuery(ep EndpointConfig, query, opName string) error {
+	if len(query) > gs.config.MaxQuerySize {
+		return fmt.Errorf("query is %d bytes, the limit is %d", len(query), gs.config.MaxQuerySize)
+	}
+	doc, err := parseDocument(query)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	op, err := doc.operation(opName)
+	if err != nil {
+		return err
+	}
+	switch op.Type {
+	case "subscription":
+		return fmt.Errorf("subscriptions are not supported")
+	case "mutation":
+		if !ep.AllowMutations {
+			return fmt.Errorf("mutations are not allowed on endpoint %s", ep.Name)
+		}
+	}
+	depth, err := doc.depth(op.sel, make(map[string]bool))
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	if depth > gs.config.MaxDepth {
+		return fmt.Errorf("query depth is %d, the limit is %d", depth, gs.config.MaxDepth)
+	}
+	return nil
+}
+
+// execute posts a GraphQL request and returns the raw response body.
+func (gs *GraphQLServer) execute(ctx context.Context, ep EndpointConfig, query string, variables map[string]any, opName string) ([]byte, error) {
+	payload := map[string]any{"query": query}
+	if len(variables) > 0 {
+		payload["variables"] = variables
+	}
+	if opName != "" {
+		payload["operationName"] = opName
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	if ep.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.BearerToken)
+	}
+	resp, err := gs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, gs.config.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > gs.config.MaxResponseSize {
+		return nil, fmt.Errorf("response exceeds %d bytes, select fewer fields or paginate", gs.config.MaxResponseSize)
+	}
+	// GraphQL servers may report errors with a non-2xx status and a regular JSON body.
+	if resp.StatusCode >= 300 && !json.Valid(data) {
+		return nil, fmt.Errorf("HTTP %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// schema returns the introspection result of an endpoint from memory, disk or the endpoint itself.
+func (gs *GraphQLServer) schema(ctx context.Context, ep EndpointConfig, refresh bool) (*schema, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	ttl := time.Duration(gs.config.SchemaCacheTTL) * time.Second
+	file := filepath.Join(gs.config.CachePath, ep.Name+".json")
+	if !refresh {
+		cached, ok := gs.schemas[ep.Name]
+		if !ok {
+			if data, err := os.ReadFile(file); err == nil {
+				cached = &cachedSchema{}
+				if json.Unmarshal(data, cached) != nil {
+					cached = nil
+				}
+			}
+		}
+		if cached != nil && time.Since(cached.FetchedAt) < ttl {
+			gs.schemas[ep.Name] = cached
+			return &cached.Schema, nil
+		}
+	}
+
+	data, err := gs.execute(ctx, ep, introspectionQuery, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if resp.Data.Schema == nil {
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("introspection failed: %s", resp.Errors[0].Message)
+		}
+		return nil, errors.New("introspection returned no schema, it may be disabled on this endpoint")
+	}
+	cached := &cachedSchema{FetchedAt: time.Now(), Schema: *resp.Data.Schema}
+	gs.schemas[ep.Name] = cached
+	data, err = json.Marshal(cached)
+	if err == nil {
+		err = os.WriteFile(file, data, 0o600)
+	}
+	if err != nil {
+		gs.Logger.Warn().Err(err).Str("endpoint", ep.Name).Msg("failed to cache schema")
+	}
+	return &cached.Schema, nil
+}
+
+func (gs *GraphQLServer) Config() string {
+	cfg, err := json.Marshal(gs.config)
+	if err != nil {
+		gs.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (gs *GraphQLServer) Name() comm.MoLingServerType {
+	return GraphQLServerName
+}
+
+func (gs *GraphQLServer) Close() error {
+	gs.Logger.Debug().Msg("GraphQLServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (gs *GraphQLServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(gs.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return gs.config.Check()
+}
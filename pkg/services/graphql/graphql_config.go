@@ -0,0 +1,112 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package graphql
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+const GraphQLPromptDefault = `
+You are an assistant that can query GraphQL APIs. Your capabilities include:
+
+1. **Explore the schema**: Use graphql_schema to see the root query and mutation fields of an endpoint, then call it again with a type name to see the fields of that type. The schema is cached, pass refresh=true after the API has changed.
+
+2. **Query**: Use graphql_query to run a query with variables. Only select the fields you need, and pass values through variables instead of string interpolation.
+
+Queries are limited in nesting depth and size. Mutations are only allowed on endpoints that enable them; confirm with the user before running a mutation.
+`
+
+// EndpointConfig describes a GraphQL API.
+type EndpointConfig struct {
+	Name           string            `json:"name"`            // Name is the alias used in tool calls.
+	URL            string            `json:"url"`             // URL is the GraphQL HTTP endpoint.
+	Headers        map[string]string `json:"headers"`         // Headers are sent with every request, e.g. an API key header.
+	BearerToken    string            `json:"bearer_token"`    // BearerToken is sent in the Authorization header.
+	AllowMutations bool              `json:"allow_mutations"` // AllowMutations permits mutation operations.
+}
+
+// GraphQLConfig represents the configuration for the GraphQL service.
+type GraphQLConfig struct {
+	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the GraphQL service.
+	prompt          string
+	Endpoints       []EndpointConfig `json:"endpoints"`         // Endpoints are the GraphQL APIs that can be queried.
+	CachePath       string           `json:"cache_path"`        // CachePath is the directory holding cached introspection results.
+	SchemaCacheTTL  int              `json:"schema_cache_ttl"`  // SchemaCacheTTL is how long an introspection result is reused, in seconds.
+	MaxDepth        int              `json:"max_depth"`         // MaxDepth is the maximum selection set nesting of a query.
+	MaxQuerySize    int              `json:"max_query_size"`    // MaxQuerySize is the maximum size of a query document, in bytes.
+	MaxResponseSize int64            `json:"max_response_size"` // MaxResponseSize is the maximum size of a response, in bytes.
+	Timeout         int              `json:"timeout"`           // Timeout is the request timeout, in seconds.
+}
+
+// NewGraphQLConfig creates a new GraphQLConfig with default values.
+func NewGraphQLConfig() *GraphQLConfig {
+	return &GraphQLConfig{
+		prompt:          GraphQLPromptDefault,
+		Endpoints:       []EndpointConfig{},
+		CachePath:       filepath.Join(os.TempDir(), ".moling", "cache", "graphql"),
+		SchemaCacheTTL:  3600 * 24,
+		MaxDepth:        10,
+		MaxQuerySize:    1024 * 16,
+		MaxResponseSize: 1024 * 1024,
+		Timeout:         30,
+	}
+}
+
+// Check validates the GraphQL configuration.
+func (cfg *GraphQLConfig) Check() error {
+	cfg.prompt = GraphQLPromptDefault
+	names := make(map[string]bool)
+	for _, ep := range cfg.Endpoints {
+		if ep.Name == "" {
+			return fmt.Errorf("endpoint name must not be empty")
+		}
+		if !namePattern.MatchString(ep.Name) {
+			return fmt.Errorf("endpoint name %q may only contain letters, digits, '_' and '-'", ep.Name)
+		}
+		if names[ep.Name] {
+			return fmt.Errorf("duplicate endpoint name %s", ep.Name)
+		}
+		names[ep.Name] = true
+		u, err := url.Parse(ep.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("endpoint %s: url must be an http(s) URL", ep.Name)
+		}
+	}
+	if cfg.CachePath == "" {
+		return fmt.Errorf("cache_path must not be empty")
+	}
+	if cfg.SchemaCacheTTL < 0 {
+		return fmt.Errorf("schema_cache_ttl must not be negative")
+	}
+	if cfg.MaxDepth <= 0 || cfg.MaxQuerySize <= 0 || cfg.MaxResponseSize <= 0 {
+		return fmt.Errorf("max_depth, max_query_size and max_response_size must be greater than 0")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
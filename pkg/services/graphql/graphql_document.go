@@ -0,0 +1,370 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A lightweight GraphQL document analyzer. It does not validate documents against a schema, it only
+// extracts what the guards need: the operations with their types, and the selection set depth.
+
+// operation is an operation definition of a document.
+type operation struct {
+	Type string // query, mutation or subscription
+	Name string
+	sel  *selectionSet
+}
+
+type selection struct {
+	field  bool
+	spread string // name of a fragment spread
+	sel    *selectionSet
+}
+
+type selectionSet struct {
+	items []selection
+}
+
+// document is a parsed GraphQL document.
+type document struct {
+	operations []operation
+	fragments  map[string]*selectionSet
+}
+
+type docParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseDocument parses the structure of a GraphQL document.
+func parseDocument(src string) (*document, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &docParser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*selectionSet)}
+	for p.pos < len(p.tokens) {
+		switch tok := p.peek(); tok {
+		case "{":
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation{Type: "query", sel: sel})
+		case "query", "mutation", "subscription":
+			p.pos++
+			op := operation{Type: tok}
+			if isName(p.peek()) {
+				op.Name = p.next()
+			}
+			err = p.skipUntilSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.sel, err = p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case "fragment":
+			p.pos++
+			name := p.next()
+			if !isName(name) {
+				return nil, fmt.Errorf("expected fragment name, got %q", name)
+			}
+			err = p.skipUntilSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[name] = sel
+		default:
+			return nil, fmt.Errorf("unexpected %q at the top level of the document", tok)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the document contains no operation")
+	}
+	return doc, nil
+}
+
+func (p *docParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *docParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// skipBalanced skips a bracketed group, such as arguments or variable definitions.
+func (p *docParser) skipBalanced(open, closing string) error {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		switch p.next() {
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unbalanced %s", open)
+}
+
+// skipUntilSelectionSet skips variable definitions, type conditions and directives.
+func (p *docParser) skipUntilSelectionSet() error {
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "{":
+			return nil
+		case "(":
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	return fmt.Errorf("expected a selection set")
+}
+
+func (p *docParser) selectionSet() (*selectionSet, error) {
+	if p.next() != "{" {
+		return nil, fmt.Errorf("expected '{'")
+	}
+	set := &selectionSet{}
+	for {
+		tok := p.peek()
+		switch {
+		case tok == "":
+			return nil, fmt.Errorf("unterminated selection set")
+		case tok == "}":
+			p.pos++
+			if len(set.items) == 0 {
+				return nil, fmt.Errorf("empty selection set")
+			}
+			return set, nil
+		case tok == "...":
+			p.pos++
+			if isName(p.peek()) && p.peek() != "on" {
+				set.items = append(set.items, selection{spread: p.next()})
+				p.skipDirectives()
+				continue
+			}
+			// inline fragment
+			err := p.skipUntilSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			set.items = append(set.items, selection{sel: sel})
+		case isName(tok):
+			p.pos++
+			if p.peek() == ":" { // alias
+				p.pos++
+				if !isName(p.next()) {
+					return nil, fmt.Errorf("expected field name after alias %s", tok)
+				}
+			}
+			if p.peek() == "(" {
+				if err := p.skipBalanced("(", ")"); err != nil {
+					return nil, err
+				}
+			}
+			p.skipDirectives()
+			item := selection{field: true}
+			if p.peek() == "{" {
+				sel, err := p.selectionSet()
+				if err != nil {
+					return nil, err
+				}
+				item.sel = sel
+			}
+			set.items = append(set.items, item)
+		default:
+			return nil, fmt.Errorf("unexpected %q in selection set", tok)
+		}
+	}
+}
+
+func (p *docParser) skipDirectives() {
+	for p.peek() == "@" {
+		p.pos += 2
+		if p.peek() == "(" {
+			_ = p.skipBalanced("(", ")")
+		}
+	}
+}
+
+// depth returns the field nesting depth of a selection set, expanding fragment spreads.
+func (d *document) depth(set *selectionSet, visiting map[string]bool) (int, error) {
+	maxDepth := 0
+	for _, item := range set.items {
+		var depth int
+		switch {
+		case item.spread != "":
+			frag, ok := d.fragments[item.spread]
+			if !ok {
+				return 0, fmt.Errorf("unknown fragment %s", item.spread)
+			}
+			if visiting[item.spread] {
+				return 0, fmt.Errorf("fragment %s spreads itself", item.spread)
+			}
+			visiting[item.spread] = true
+			n, err := d.depth(frag, visiting)
+			delete(visiting, item.spread)
+			if err != nil {
+				return 0, err
+			}
+			depth = n
+		case item.sel != nil:
+			n, err := d.depth(item.sel, visiting)
+			if err != nil {
+				return 0, err
+			}
+			depth = n
+		}
+		if item.field {
+			depth++
+		}
+		maxDepth = max(maxDepth, depth)
+	}
+	return maxDepth, nil
+}
+
+// operation selects the operation to run, by name when the document has several.
+func (d *document) operation(name string) (operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return operation{}, fmt.Errorf("the document contains %d operations, operation_name is required", len(d.operations))
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return operation{}, fmt.Errorf("operation %s not found in the document", name)
+}
+
+func isName(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	for i, r := range tok {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize splits a GraphQL document into names, punctuators and literal values, dropping
+// whitespace, commas and comments.
+func tokenize(src string) ([]string, error) {
+	var tokens []string
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '.':
+			if !strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), "...") {
+				return nil, fmt.Errorf("unexpected '.'")
+			}
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.ContainsRune("!$&()[]{}:=@|", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			end, err := stringEnd(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case r == '-' || unicode.IsDigit(r):
+			// numbers are only used as values, so a loose match is enough
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// stringEnd returns the index after the string or block string starting at start.
+func stringEnd(runes []rune, start int) (int, error) {
+	if strings.HasPrefix(string(runes[start:min(start+3, len(runes))]), `"""`) {
+		for i := start + 3; i+2 < len(runes); i++ {
+			if runes[i] == '\\' && strings.HasPrefix(string(runes[i+1:min(i+4, len(runes))]), `"""`) {
+				i += 3
+				continue
+			}
+			if runes[i] == '"' && runes[i+1] == '"' && runes[i+2] == '"' {
+				return i + 3, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated block string")
+	}
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n':
+			return 0, fmt.Errorf("unterminated string")
+		}
+	}
+	return 0, fmt.Errorf("unterminated string")
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: false) { name description args { name type { ...TypeRef } defaultValue } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: false) { name }
+      interfaces { name }
+      possibleTypes { name }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String renders a type reference in SDL notation, e.g. [String!]!.
+func (t *typeRef) String() string {
+	if t == nil {
+		return "?"
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+type namedRef struct {
+	Name string `json:"name"`
+}
+
+type schemaInput struct {
+	Name         string  `json:"name"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type schemaField struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Args        []schemaInput `json:"args"`
+	Type        typeRef       `json:"type"`
+}
+
+type schemaType struct {
+	Kind          string        `json:"kind"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Fields        []schemaField `json:"fields"`
+	InputFields   []schemaInput `json:"inputFields"`
+	EnumValues    []namedRef    `json:"enumValues"`
+	Interfaces    []namedRef    `json:"interfaces"`
+	PossibleTypes []namedRef    `json:"possibleTypes"`
+}
+
+type schema struct {
+	QueryType        *namedRef    `json:"queryType"`
+	MutationType     *namedRef    `json:"mutationType"`
+	SubscriptionType *namedRef    `json:"subscriptionType"`
+	Types            []schemaType `json:"types"`
+}
+
+// cachedSchema is an introspection result stored on disk.
+type cachedSchema struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Schema    schema    `json:"schema"`
+}
+
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+func (s *schema) lookup(name string) *schemaType {
+	for i := range s.Types {
+		if strings.EqualFold(s.Types[i].Name, name) {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// summary renders the root operation types and an index of the other types.
+func (s *schema) summary() string {
+	var sb strings.Builder
+	for _, root := range []*namedRef{s.QueryType, s.MutationType} {
+		if root == nil {
+			continue
+		}
+		if t := s.lookup(root.Name); t != nil {
+			sb.WriteString(t.sdl())
+			sb.WriteString("\n")
+		}
+	}
+	byKind := make(map[string][]string)
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		if (s.QueryType != nil && t.Name == s.QueryType.Name) || (s.MutationType != nil && t.Name == s.MutationType.Name) {
+			continue
+		}
+		byKind[t.Kind] = append(byKind[t.Kind], t.Name)
+	}
+	kinds := make([]string, 0, len(byKind))
+	for k := range byKind {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	sb.WriteString("# Other types, use graphql_schema with type=<name> for details\n")
+	for _, k := range kinds {
+		names := byKind[k]
+		sort.Strings(names)
+		sb.WriteString(fmt.Sprintf("# %s: %s\n", k, strings.Join(names, ", ")))
+	}
+	return sb.String()
+}
+
+// sdl renders a type in schema definition language, with descriptions as comments.
+func (t *schemaType) sdl() string {
+	var sb strings.Builder
+	if t.Description != "" {
+		sb.WriteString("# " + firstLine(t.Description) + "\n")
+	}
+	switch t.Kind {
+	case "SCALAR":
+		sb.WriteString("scalar " + t.Name + "\n")
+	case "UNION":
+		names := make([]string, 0, len(t.PossibleTypes))
+		for _, p := range t.PossibleTypes {
+			names = append(names, p.Name)
+		}
+		sb.WriteString(fmt.Sprintf("union %s = %s\n", t.Name, strings.Join(names, " | ")))
+	case "ENUM":
+		sb.WriteString("enum " + t.Name + " {\n")
+		for _, v := range t.EnumValues {
+			sb.WriteString("  " + v.Name + "\n")
+		}
+		sb.WriteString("}\n")
+	case "INPUT_OBJECT":
+		sb.WriteString("input " + t.Name + " {\n")
+		for _, f := range t.InputFields {
+			sb.WriteString("  " + f.sdl() + "\n")
+		}
+		sb.WriteString("}\n")
+	default:
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+		sb.WriteString(keyword + " " + t.Name)
+		if len(t.Interfaces) > 0 {
+			names := make([]string, 0, len(t.Interfaces))
+			for _, i := range t.Interfaces {
+				names = append(names, i.Name)
+			}
+			sb.WriteString(" implements " + strings.Join(names, " & "))
+		}
+		sb.WriteString(" {\n")
+		for _, f := range t.Fields {
+			sb.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				args := make([]string, 0, len(f.Args))
+				for _, a := range f.Args {
+					args = append(args, a.sdl())
+				}
+				sb.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			sb.WriteString(": " + f.Type.String())
+			if f.Description != "" {
+				sb.WriteString("  # " + firstLine(f.Description))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+func (i schemaInput) sdl() string {
+	s := i.Name + ": " + i.Type.String()
+	if i.DefaultValue != nil {
+		s += " = " + *i.DefaultValue
+	}
+	return s
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if len(s) > 120 {
+		s = s[:120] + "..."
+	}
+	return s
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestCheckQuery(t *testing.T) {
+	gs := &GraphQLServer{config: NewGraphQLConfig()}
+	gs.config.MaxDepth = 3
+	ep := EndpointConfig{Name: "api"}
+	cases := map[string]bool{
+		`{ viewer { login } }`: true,
+		`query Q($id: ID!) { node(id: $id) { ... on User { name } } }`: true,
+		`{ a { b { c { d } } } }`:                                      false,
+		`query { a { ...F } } fragment F on A { b { c } }`:             true,
+		`query { a { ...F } } fragment F on A { b { c { d } } }`:       false,
+		`query { a { ...F } } fragment F on A { ...F }`:                false,
+		`mutation { addStar(id: "1") { ok } }`:                         false,
+		`subscription { events { id } }`:                               false,
+		`query { a(s: "} { # not a comment") { b } } # comment { {`:    true,
+		`{ a { b }`:                   false,
+		`query A { a } query B { b }`: false,
+		`query Q($v: Int = 1) @cached(ttl: 60) { items(first: $v) @include(if: true) { id } }`: true,
+	}
+	for query, expected := range cases {
+		err := gs.checkQuery(ep, query, "")
+		if (err == nil) != expected {
+			t.Errorf("checkQuery(%q) error = %v, expected success %v", query, err, expected)
+		}
+	}
+	err := gs.checkQuery(ep, `query A { a } query B { b }`, "B")
+	if err != nil {
+		t.Errorf("Expected operation B to be selected: %s", err.Error())
+	}
+	ep.AllowMutations = true
+	err = gs.checkQuery(ep, `mutation { addStar(id: "1") { ok } }`, "")
+	if err != nil {
+		t.Errorf("Expected mutation to be allowed: %s", err.Error())
+	}
+	gs.config.MaxQuerySize = 8
+	err = gs.checkQuery(ep, `{ viewer { login } }`, "")
+	if err == nil {
+		t.Errorf("Expected query size limit to be enforced")
+	}
+}
+
+func TestGraphQLServer(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests++
+		if strings.Contains(body.Query, "__schema") {
+			_, _ = w.Write([]byte(`{"data":{"__schema":{"queryType":{"name":"Query"},"types":[
+				{"kind":"OBJECT","name":"Query","fields":[{"name":"user","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"OBJECT","name":"User"}}]},
+				{"kind":"OBJECT","name":"User","description":"A user","fields":[{"name":"tags","args":[],"type":{"kind":"LIST","ofType":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"String"}}}}]},
+				{"kind":"ENUM","name":"Role","enumValues":[{"name":"ADMIN"}]}]}}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"user": map[string]any{"id": body.Variables["id"]}}})
+	}))
+	defer ts.Close()
+
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewGraphQLServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create GraphQLServer: %s", err.Error())
+	}
+	err = srv.LoadConfig(map[string]any{
+		"cache_path": t.TempDir(),
+		"endpoints":  []any{map[string]any{"name": "api", "url": ts.URL, "bearer_token": "secret", "headers": map[string]any{"X-Api-Key": "key"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init GraphQLServer: %s", err.Error())
+	}
+	gs := srv.(*GraphQLServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		if res.IsError {
+			t.Fatalf("Tool returned an error: %v", res.Content)
+		}
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	out := call(gs.handleQuery, map[string]any{"endpoint": "api", "query": "query U($id: ID!) { user(id: $id) { id } }", "variables": map[string]any{"id": "42"}})
+	if !strings.Contains(out, `"id":"42"`) {
+		t.Errorf("Unexpected query response: %s", out)
+	}
+	out = call(gs.handleSchema, map[string]any{"endpoint": "api"})
+	if !strings.Contains(out, "user(id: ID!): User") || !strings.Contains(out, "# ENUM: Role") {
+		t.Errorf("Unexpected schema summary: %s", out)
+	}
+	out = call(gs.handleSchema, map[string]any{"endpoint": "api", "type": "User"})
+	if !strings.Contains(out, "tags: [String!]") {
+		t.Errorf("Unexpected type description: %s", out)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the schema to be cached, got %d requests", requests)
+	}
+
+	// a new server instance reads the schema from the disk cache
+	gs.schemas = make(map[string]*cachedSchema)
+	call(gs.handleSchema, map[string]any{"endpoint": "api", "type": "Role"})
+	if requests != 2 {
+		t.Errorf("Expected the schema to be read from disk, got %d requests", requests)
+	}
+}
@@ -0,0 +1,404 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package grpc provides a gRPC client for the MoLing application.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	GRPCServerName comm.MoLingServerType = "GRPC"
+)
+
+// endpoint is a configured server with its lazily created connection and descriptor source.
+type endpoint struct {
+	EndpointConfig
+	conn   *grpc.ClientConn
+	source descriptorSource
+}
+
+// GRPCServer implements the Service interface and calls methods of configured gRPC servers.
+type GRPCServer struct {
+	abstract.MLService
+	config *GRPCConfig
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+}
+
+// NewGRPCServer creates a new GRPCServer instance.
+func NewGRPCServer(ctx context.Context) (abstract.Service, error) {
+	gc := NewGRPCConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("GRPCServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("GRPCServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(GRPCServerName))
+	})
+
+	gs := &GRPCServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    gc,
+		endpoints: make(map[string]*endpoint),
+	}
+	err := gs.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// Init registers the prompt and tools of the gRPC service. Connections are created on first use.
+func (gs *GRPCServer) Init() error {
+	for _, ep := range gs.config.Endpoints {
+		gs.endpoints[ep.Name] = &endpoint{EndpointConfig: ep}
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "grpc_prompt",
+			Description: "Get the relevant functions and prompts of the GRPC MCP Server",
+		},
+		HandlerFunc: gs.handlePrompt,
+	}
+	gs.AddPrompt(pe)
+
+	gs.AddTool(mcp.NewTool(
+		"grpc_list",
+		mcp.WithDescription("List the configured gRPC endpoints, the services of an endpoint, or the methods of a service"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name, omit to list the endpoints"),
+		),
+		mcp.WithString("service",
+			mcp.Description("Fully qualified service name, e.g. helloworld.Greeter, to list its methods"),
+		),
+	), gs.handleList)
+
+	gs.AddTool(mcp.NewTool(
+		"grpc_describe",
+		mcp.WithDescription("Describe a service, method, message or enum in proto syntax, including the JSON names of message fields"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("symbol",
+			mcp.Description("Fully qualified name, e.g. helloworld.Greeter, helloworld.Greeter.SayHello or helloworld.HelloRequest"),
+			mcp.Required(),
+		),
+	), gs.handleDescribe)
+
+	gs.AddTool(mcp.NewTool(
+		"grpc_invoke",
+		mcp.WithDescription("Invoke a unary gRPC method with a JSON request and return the JSON response"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("method",
+			mcp.Description("Method as service/method, e.g. helloworld.Greeter/SayHello"),
+			mcp.Required(),
+		),
+		mcp.WithObject("request",
+			mcp.Description("Request message in the protobuf JSON mapping, default: {}"),
+		),
+		mcp.WithObject("metadata",
+			mcp.Description("Additional request metadata (headers) as string values"),
+		),
+	), gs.handleInvoke)
+	return nil
+}
+
+func (gs *GRPCServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: gs.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// connect returns the endpoint with its connection and descriptor source, creating them on first use.
+func (gs *GRPCServer) connect(ctx context.Context, name string) (*endpoint, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	ep, ok := gs.endpoints[name]
+	if !ok {
+		return nil, fmt.Errorf("endpoint %q is not configured", name)
+	}
+	if ep.conn != nil {
+		return ep, nil
+	}
+	creds := insecure.NewCredentials()
+	if !ep.Plaintext {
+		tlsConf := &tls.Config{InsecureSkipVerify: ep.InsecureSkipVerify, ServerName: ep.ServerName}
+		if ep.CACert != "" {
+			pem, err := os.ReadFile(ep.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+			}
+			tlsConf.RootCAs = x509.NewCertPool()
+			if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", ep.CACert)
+			}
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	conn, err := grpc.NewClient(ep.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(gs.config.MaxMessageSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", ep.Address, err)
+	}
+	var source descriptorSource
+	if len(ep.ProtoFiles) > 0 {
+		source, err = newFileSource(ctx, ep.ProtoFiles, ep.ImportPaths)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	} else {
+		source = newReflectionSource(conn)
+	}
+	ep.conn = conn
+	ep.source = source
+	return ep, nil
+}
+
+// callContext applies the call timeout and the configured and per-call metadata.
+func (gs *GRPCServer) callContext(ctx context.Context, ep *endpoint, extra map[string]any) (context.Context, context.CancelFunc) {
+	md := metadata.MD{}
+	for k, v := range ep.Metadata {
+		md.Append(k, v)
+	}
+	for k, v := range extra {
+		md.Append(k, fmt.Sprint(v))
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	return context.WithTimeout(ctx, time.Duration(gs.config.Timeout)*time.Second)
+}
+
+func (gs *GRPCServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "endpoint", "")
+	if name == "" {
+		if len(gs.config.Endpoints) == 0 {
+			return mcp.NewToolResultText("No gRPC endpoints are configured."), nil
+		}
+		var sb strings.Builder
+		for _, ep := range gs.config.Endpoints {
+			source := "server reflection"
+			if len(ep.ProtoFiles) > 0 {
+				source = "proto files"
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s (%s)\n", ep.Name, ep.Address, source))
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	ep, err := gs.connect(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ctx, cancel := gs.callContext(ctx, ep, nil)
+	defer cancel()
+	service := abstract.GetString(args, "service", "")
+	if service == "" {
+		services, err := ep.source.listServices(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list services: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(strings.Join(services, "\n")), nil
+	}
+	d, err := ep.source.findDescriptor(ctx, service)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("service %s not found: %s", service, err.Error())), nil
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not a service", service)), nil
+	}
+	return mcp.NewToolResultText(describe(sd)), nil
+}
+
+func (gs *GRPCServer) handleDescribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "endpoint", "")
+	symbol := abstract.GetString(args, "symbol", "")
+	if symbol == "" {
+		return mcp.NewToolResultError("symbol must be a non-empty string"), nil
+	}
+	ep, err := gs.connect(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ctx, cancel := gs.callContext(ctx, ep, nil)
+	defer cancel()
+	d, err := ep.source.findDescriptor(ctx, strings.ReplaceAll(strings.TrimPrefix(symbol, "."), "/", "."))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("symbol %s not found: %s", symbol, err.Error())), nil
+	}
+	return mcp.NewToolResultText(describe(d)), nil
+}
+
+func (gs *GRPCServer) handleInvoke(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "endpoint", "")
+	method := abstract.GetString(args, "method", "")
+	ep, err := gs.connect(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	extra := abstract.GetMap(args, "metadata")
+	ctx, cancel := gs.callContext(ctx, ep, extra)
+	defer cancel()
+
+	md, err := findMethod(ctx, ep.source, method)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is a streaming method, only unary methods can be invoked", method)), nil
+	}
+	body := []byte("{}")
+	switch req := args["request"].(type) {
+	case map[string]any:
+		body, err = json.Marshal(req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid request: %s", err.Error())), nil
+		}
+	case string:
+		if strings.TrimSpace(req) != "" {
+			body = []byte(req)
+		}
+	}
+	in := dynamicpb.NewMessage(md.Input())
+	err = protojson.Unmarshal(body, in)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("request does not match %s: %s", md.Input().FullName(), err.Error())), nil
+	}
+	out := dynamicpb.NewMessage(md.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	err = ep.conn.Invoke(ctx, fullMethod, in, out)
+	if err != nil {
+		st := status.Convert(err)
+		return mcp.NewToolResultError(fmt.Sprintf("call failed: code = %s, message = %s", st.Code(), st.Message())), nil
+	}
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(out)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode the response: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// findMethod resolves a method given as service/method or service.method.
+func findMethod(ctx context.Context, source descriptorSource, method string) (protoreflect.MethodDescriptor, error) {
+	method = strings.TrimPrefix(method, "/")
+	service, name, found := strings.Cut(method, "/")
+	if !found {
+		i := strings.LastIndex(method, ".")
+		if i < 0 {
+			return nil, fmt.Errorf("method must be given as service/method, got %q", method)
+		}
+		service, name = method[:i], method[i+1:]
+	}
+	d, err := source.findDescriptor(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("service %s has no method %s", service, name)
+	}
+	return md, nil
+}
+
+func (gs *GRPCServer) Config() string {
+	cfg, err := json.Marshal(gs.config)
+	if err != nil {
+		gs.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (gs *GRPCServer) Name() comm.MoLingServerType {
+	return GRPCServerName
+}
+
+// Close closes the connections to all endpoints.
+func (gs *GRPCServer) Close() error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	for _, ep := range gs.endpoints {
+		if ep.conn != nil {
+			_ = ep.conn.Close()
+			ep.conn = nil
+		}
+	}
+	gs.Logger.Debug().Msg("GRPCServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (gs *GRPCServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(gs.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return gs.config.Check()
+}
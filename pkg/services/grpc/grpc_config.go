@@ -0,0 +1,104 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package grpc
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+const GRPCPromptDefault = `
+You are an assistant that can call gRPC services. Your capabilities include:
+
+1. **Discover**: Use grpc_list to see the configured endpoints, the services of an endpoint, or the methods of a service.
+
+2. **Describe**: Use grpc_describe to see the definition of a service, method or message, including the fields of request and response messages.
+
+3. **Invoke**: Use grpc_invoke to call a unary method with a request written as JSON, using the protobuf JSON mapping (lowerCamelCase field names, enums as strings, 64-bit integers as strings).
+
+Describe the request message before invoking a method you have not used before. Streaming methods cannot be invoked. Confirm with the user before calling methods that change data.
+`
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,64}$`)
+
+// EndpointConfig describes a gRPC server.
+type EndpointConfig struct {
+	Name               string            `json:"name"`                 // Name is the alias used in tool calls.
+	Address            string            `json:"address"`              // Address is the host:port of the server.
+	Plaintext          bool              `json:"plaintext"`            // Plaintext disables TLS.
+	InsecureSkipVerify bool              `json:"insecure_skip_verify"` // InsecureSkipVerify disables server certificate verification.
+	CACert             string            `json:"ca_cert"`              // CACert is a PEM file with the CA certificates to trust.
+	ServerName         string            `json:"server_name"`          // ServerName overrides the name used to verify the server certificate.
+	Metadata           map[string]string `json:"metadata"`             // Metadata is sent with every call, e.g. an authorization header.
+	ProtoFiles         []string          `json:"proto_files"`          // ProtoFiles are used instead of server reflection when set.
+	ImportPaths        []string          `json:"import_paths"`         // ImportPaths are searched for proto_files and their imports.
+}
+
+// GRPCConfig represents the configuration for the gRPC service.
+type GRPCConfig struct {
+	PromptFile     string `json:"prompt_file"` // PromptFile is the prompt file for the gRPC service.
+	prompt         string
+	Endpoints      []EndpointConfig `json:"endpoints"`        // Endpoints are the gRPC servers that can be called.
+	Timeout        int              `json:"timeout"`          // Timeout is the deadline of a call, in seconds.
+	MaxMessageSize int              `json:"max_message_size"` // MaxMessageSize is the maximum size of a response message, in bytes.
+}
+
+// NewGRPCConfig creates a new GRPCConfig with default values.
+func NewGRPCConfig() *GRPCConfig {
+	return &GRPCConfig{
+		prompt:         GRPCPromptDefault,
+		Endpoints:      []EndpointConfig{},
+		Timeout:        30,
+		MaxMessageSize: 1024 * 1024 * 4,
+	}
+}
+
+// Check validates the gRPC configuration.
+func (cfg *GRPCConfig) Check() error {
+	cfg.prompt = GRPCPromptDefault
+	names := make(map[string]bool)
+	for _, ep := range cfg.Endpoints {
+		if !namePattern.MatchString(ep.Name) {
+			return fmt.Errorf("endpoint name %q may only contain letters, digits, '_' and '-'", ep.Name)
+		}
+		if names[ep.Name] {
+			return fmt.Errorf("duplicate endpoint name %s", ep.Name)
+		}
+		names[ep.Name] = true
+		if ep.Address == "" {
+			return fmt.Errorf("endpoint %s: address must not be empty", ep.Name)
+		}
+		if ep.Plaintext && (ep.CACert != "" || ep.InsecureSkipVerify) {
+			return fmt.Errorf("endpoint %s: plaintext cannot be combined with TLS settings", ep.Name)
+		}
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.MaxMessageSize <= 0 {
+		return fmt.Errorf("max_message_size must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
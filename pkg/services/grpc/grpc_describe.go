@@ -0,0 +1,135 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package grpc
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// describe renders a descriptor in proto syntax.
+func describe(d protoreflect.Descriptor) string {
+	switch d := d.(type) {
+	case protoreflect.ServiceDescriptor:
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("service %s {\n", d.FullName()))
+		methods := d.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			sb.WriteString("  " + methodSignature(methods.Get(i)) + "\n")
+		}
+		sb.WriteString("}\n")
+		return sb.String()
+	case protoreflect.MethodDescriptor:
+		return methodSignature(d) + "\n\n" + describe(d.Input()) + "\n" + describe(d.Output())
+	case protoreflect.MessageDescriptor:
+		return describeMessage(d, "")
+	case protoreflect.EnumDescriptor:
+		return describeEnum(d, "")
+	case protoreflect.FieldDescriptor:
+		return fieldLine(d) + "\n"
+	}
+	return fmt.Sprintf("%s (%T)\n", d.FullName(), d)
+}
+
+func methodSignature(m protoreflect.MethodDescriptor) string {
+	in, out := string(m.Input().FullName()), string(m.Output().FullName())
+	if m.IsStreamingClient() {
+		in = "stream " + in
+	}
+	if m.IsStreamingServer() {
+		out = "stream " + out
+	}
+	return fmt.Sprintf("rpc %s(%s) returns (%s);", m.Name(), in, out)
+}
+
+func describeMessage(m protoreflect.MessageDescriptor, indent string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%smessage %s {\n", indent, m.FullName()))
+	fields := m.Fields()
+	var oneof protoreflect.OneofDescriptor
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		o := f.ContainingOneof()
+		if o != nil && o.IsSynthetic() {
+			o = nil
+		}
+		if o != oneof {
+			if oneof != nil {
+				sb.WriteString(indent + "  }\n")
+			}
+			if o != nil {
+				sb.WriteString(fmt.Sprintf("%s  oneof %s {\n", indent, o.Name()))
+			}
+			oneof = o
+		}
+		prefix := indent + "  "
+		if oneof != nil {
+			prefix += "  "
+		}
+		sb.WriteString(prefix + fieldLine(f) + "\n")
+	}
+	if oneof != nil {
+		sb.WriteString(indent + "  }\n")
+	}
+	enums := m.Enums()
+	for i := 0; i < enums.Len(); i++ {
+		sb.WriteString(describeEnum(enums.Get(i), indent+"  "))
+	}
+	sb.WriteString(indent + "}\n")
+	return sb.String()
+}
+
+func describeEnum(e protoreflect.EnumDescriptor, indent string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%senum %s {\n", indent, e.FullName()))
+	values := e.Values()
+	for i := 0; i < values.Len(); i++ {
+		v := values.Get(i)
+		sb.WriteString(fmt.Sprintf("%s  %s = %d;\n", indent, v.Name(), v.Number()))
+	}
+	sb.WriteString(indent + "}\n")
+	return sb.String()
+}
+
+// fieldLine renders a field with its JSON name, which is what requests must use.
+func fieldLine(f protoreflect.FieldDescriptor) string {
+	var label string
+	switch {
+	case f.IsMap():
+	case f.IsList():
+		label = "repeated "
+	case f.HasOptionalKeyword():
+		label = "optional "
+	}
+	typ := fieldType(f)
+	if f.IsMap() {
+		typ = fmt.Sprintf("map<%s, %s>", fieldType(f.MapKey()), fieldType(f.MapValue()))
+	}
+	return fmt.Sprintf("%s%s %s = %d; // json: %s", label, typ, f.Name(), f.Number(), f.JSONName())
+}
+
+func fieldType(f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(f.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorSource resolves the services and messages of an endpoint.
+type descriptorSource interface {
+	listServices(ctx context.Context) ([]string, error)
+	findDescriptor(ctx context.Context, name string) (protoreflect.Descriptor, error)
+}
+
+// The v1alpha reflection service uses the same messages as v1, only the method name differs.
+var reflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// reflectionSource queries the server reflection service and caches the received file descriptors.
+type reflectionSource struct {
+	conn *grpc.ClientConn
+
+	mu     sync.Mutex
+	method string
+	protos map[string]*descriptorpb.FileDescriptorProto
+}
+
+func newReflectionSource(conn *grpc.ClientConn) *reflectionSource {
+	return &reflectionSource{conn: conn, protos: make(map[string]*descriptorpb.FileDescriptorProto)}
+}
+
+// roundTrip sends a single reflection request on a new stream and returns the response.
+func (rs *reflectionSource) roundTrip(ctx context.Context, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	methods := reflectionMethods
+	if rs.method != "" {
+		methods = []string{rs.method}
+	}
+	var lastErr error
+	for _, method := range methods {
+		resp, err := rs.call(ctx, method, req)
+		if status.Code(err) == codes.Unimplemented {
+			lastErr = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rs.method = method
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("server reflection is not available, configure proto_files for this endpoint: %w", lastErr)
+}
+
+func (rs *reflectionSource) call(ctx context.Context, method string, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	desc := &grpc.StreamDesc{StreamName: "ServerReflectionInfo", ServerStreams: true, ClientStreams: true}
+	stream, err := rs.conn.NewStream(ctx, desc, method)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.SendMsg(req)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.CloseSend()
+	if err != nil {
+		return nil, err
+	}
+	resp := &rpb.ServerReflectionResponse{}
+	err = stream.RecvMsg(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (rs *reflectionSource) listServices(ctx context.Context) ([]string, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	resp, err := rs.roundTrip(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (rs *reflectionSource) findDescriptor(ctx context.Context, name string) (protoreflect.Descriptor, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if files, err := rs.files(); err == nil {
+		if d, err := files.FindDescriptorByName(protoreflect.FullName(name)); err == nil {
+			return d, nil
+		}
+	}
+	resp, err := rs.roundTrip(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = rs.addFiles(resp)
+	if err != nil {
+		return nil, err
+	}
+	err = rs.fetchDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := rs.files()
+	if err != nil {
+		return nil, err
+	}
+	return files.FindDescriptorByName(protoreflect.FullName(name))
+}
+
+func (rs *reflectionSource) addFiles(resp *rpb.ServerReflectionResponse) error {
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		err := proto.Unmarshal(raw, fd)
+		if err != nil {
+			return fmt.Errorf("invalid file descriptor from server: %w", err)
+		}
+		rs.protos[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// fetchDependencies requests the imports that the server did not send along, falling back to the
+// well-known types compiled into this binary.
+func (rs *reflectionSource) fetchDependencies(ctx context.Context) error {
+	for {
+		var missing []string
+		for _, fd := range rs.protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := rs.protos[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		for _, dep := range missing {
+			if _, ok := rs.protos[dep]; ok {
+				continue
+			}
+			resp, err := rs.roundTrip(ctx, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err == nil {
+				err = rs.addFiles(resp)
+			}
+			if _, ok := rs.protos[dep]; ok {
+				continue
+			}
+			if fd, lookupErr := protoregistry.GlobalFiles.FindFileByPath(dep); lookupErr == nil {
+				rs.protos[dep] = protodesc.ToFileDescriptorProto(fd)
+				continue
+			}
+			if err == nil {
+				err = errors.New("not returned by the server")
+			}
+			return fmt.Errorf("failed to resolve import %s: %w", dep, err)
+		}
+	}
+}
+
+func (rs *reflectionSource) files() (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range rs.protos {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+// fileSource resolves descriptors from local proto files.
+type fileSource struct {
+	files *protoregistry.Files
+}
+
+func newFileSource(ctx context.Context, protoFiles, importPaths []string) (*fileSource, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+	}
+	compiled, err := compiler.Compile(ctx, protoFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto files: %w", err)
+	}
+	files := &protoregistry.Files{}
+	for _, fd := range compiled {
+		err = registerFile(files, fd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &fileSource{files: files}, nil
+}
+
+// registerFile registers a file after its imports.
+func registerFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		err := registerFile(files, imports.Get(i).FileDescriptor)
+		if err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
+
+func (fs *fileSource) listServices(ctx context.Context) ([]string, error) {
+	var names []string
+	fs.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			names = append(names, string(services.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names, nil
+}
+
+func (fs *fileSource) findDescriptor(ctx context.Context, name string) (protoreflect.Descriptor, error) {
+	return fs.files.FindDescriptorByName(protoreflect.FullName(name))
+}
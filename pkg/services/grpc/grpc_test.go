@@ -0,0 +1,141 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const healthProto = `syntax = "proto3";
+package grpc.health.v1;
+message HealthCheckRequest { string service = 1; }
+message HealthCheckResponse {
+  enum ServingStatus { UNKNOWN = 0; SERVING = 1; NOT_SERVING = 2; SERVICE_UNKNOWN = 3; }
+  ServingStatus status = 1;
+}
+service Health {
+  rpc Check(HealthCheckRequest) returns (HealthCheckResponse);
+  rpc Watch(HealthCheckRequest) returns (stream HealthCheckResponse);
+}
+`
+
+func startServer(t *testing.T, withReflection bool) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err.Error())
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("moling", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	if withReflection {
+		reflection.Register(srv)
+	}
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestGRPCServer(t *testing.T) {
+	reflectionAddr := startServer(t, true)
+	plainAddr := startServer(t, false)
+	protoDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(protoDir, "health.proto"), []byte(healthProto), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write proto file: %s", err.Error())
+	}
+
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewGRPCServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create GRPCServer: %s", err.Error())
+	}
+	err = srv.LoadConfig(map[string]any{
+		"endpoints": []any{
+			map[string]any{"name": "reflect", "address": reflectionAddr, "plaintext": true},
+			map[string]any{"name": "files", "address": plainAddr, "plaintext": true, "proto_files": []any{"health.proto"}, "import_paths": []any{protoDir}},
+			map[string]any{"name": "none", "address": plainAddr, "plaintext": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init GRPCServer: %s", err.Error())
+	}
+	defer func() { _ = srv.Close() }()
+	gs := srv.(*GRPCServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	for _, name := range []string{"reflect", "files"} {
+		out, isErr := call(gs.handleList, map[string]any{"endpoint": name})
+		if isErr || !strings.Contains(out, "grpc.health.v1.Health") {
+			t.Errorf("%s: unexpected service list: %s", name, out)
+		}
+		out, isErr = call(gs.handleDescribe, map[string]any{"endpoint": name, "symbol": "grpc.health.v1.Health/Check"})
+		if isErr || !strings.Contains(out, "string service = 1; // json: service") || !strings.Contains(out, "NOT_SERVING = 2;") {
+			t.Errorf("%s: unexpected method description: %s", name, out)
+		}
+		out, isErr = call(gs.handleInvoke, map[string]any{"endpoint": name, "method": "grpc.health.v1.Health/Check", "request": map[string]any{"service": "moling"}})
+		if isErr || !strings.Contains(out, `"NOT_SERVING"`) {
+			t.Errorf("%s: unexpected response: %s", name, out)
+		}
+		out, isErr = call(gs.handleInvoke, map[string]any{"endpoint": name, "method": "grpc.health.v1.Health.Check", "request": map[string]any{"service": "unknown"}})
+		if !isErr || !strings.Contains(out, "NotFound") {
+			t.Errorf("%s: expected a NotFound status, got: %s", name, out)
+		}
+		out, isErr = call(gs.handleInvoke, map[string]any{"endpoint": name, "method": "grpc.health.v1.Health/Watch"})
+		if !isErr || !strings.Contains(out, "streaming") {
+			t.Errorf("%s: expected streaming methods to be rejected, got: %s", name, out)
+		}
+		out, isErr = call(gs.handleInvoke, map[string]any{"endpoint": name, "method": "grpc.health.v1.Health/Check", "request": map[string]any{"nope": 1}})
+		if !isErr {
+			t.Errorf("%s: expected an invalid request to be rejected, got: %s", name, out)
+		}
+	}
+
+	out, isErr := call(gs.handleList, map[string]any{"endpoint": "none"})
+	if !isErr || !strings.Contains(out, "proto_files") {
+		t.Errorf("Expected a hint to configure proto files, got: %s", out)
+	}
+}
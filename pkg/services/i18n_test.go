@@ -0,0 +1,80 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package services
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/gojue/moling/pkg/i18n"
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+// TestCatalogCoverage lists the tools, parameters and prompts of the registered services that a catalog does not
+// translate, in the golden file testdata/i18n_<language>_missing.golden. A new tool changes the list, so it is either
+// translated or its gap is tracked in the diff; run the test with MOLING_UPDATE_GOLDEN=1 to update the lists.
+func TestCatalogCoverage(t *testing.T) {
+	ctx, _ := testkit.NewContext(t)
+	var tools []string
+	params := make(map[string][]string)
+	var prompts []string
+	for name, factory := range ServiceList() {
+		srv := testkit.NewService(t, ctx, factory, nil)
+		if len(srv.Tools()) == 0 {
+			t.Errorf("service %s registers no tools", name)
+		}
+		for _, st := range srv.Tools() {
+			tools = append(tools, st.Tool.Name)
+			for param := range st.Tool.InputSchema.Properties {
+				params[st.Tool.Name] = append(params[st.Tool.Name], param)
+			}
+		}
+		for _, pe := range srv.Prompts() {
+			prompts = append(prompts, pe.PromptVar.Name)
+		}
+	}
+
+	for _, lang := range i18n.Languages() {
+		if lang == i18n.DefaultLanguage {
+			continue
+		}
+		l, err := i18n.Load(lang, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		missing := []string{}
+		for _, name := range tools {
+			text, ok := l.Tool(name)
+			if !ok || text.Description == "" {
+				missing = append(missing, "tool "+name)
+				continue
+			}
+			for _, param := range params[name] {
+				if _, ok := text.Parameters[param]; !ok {
+					missing = append(missing, "parameter "+name+"."+param)
+				}
+			}
+		}
+		for _, name := range prompts {
+			if _, ok := l.Prompt(name); !ok {
+				missing = append(missing, "prompt "+name)
+			}
+		}
+		sort.Strings(missing)
+		testkit.AssertGolden(t, "i18n_"+lang+"_missing", missing)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package issuetracker provides Jira and Linear issue tools for the MoLing application.
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+	"github.com/gojue/moling/pkg/utils/httpapi"
+)
+
+const (
+	IssueTrackerServerName comm.MoLingServerType = "IssueTracker"
+)
+
+var issueKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-[0-9]+$`)
+
+// IssueTrackerServer implements the Service interface and works with a Jira or Linear issue tracker.
+type IssueTrackerServer struct {
+	abstract.MLService
+	config  *IssueTrackerConfig
+	tracker Tracker
+}
+
+// NewIssueTrackerServer creates a new IssueTrackerServer instance.
+func NewIssueTrackerServer(ctx context.Context) (abstract.Service, error) {
+	ic := NewIssueTrackerConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("IssueTrackerServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("IssueTrackerServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(IssueTrackerServerName))
+	})
+
+	is := &IssueTrackerServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    ic,
+	}
+	err := is.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return is, nil
+}
+
+// Init creates the API client and registers the prompt and tools of the issue tracker service.
+func (is *IssueTrackerServer) Init() error {
+	is.tracker = NewTracker(is.config, &http.Client{Timeout: time.Duration(is.config.Timeout) * time.Second})
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "issuetracker_prompt",
+			Description: "Get the relevant functions and prompts of the IssueTracker MCP Server",
+		},
+		HandlerFunc: is.handlePrompt,
+	}
+	is.AddPrompt(pe)
+
+	queryDesc := "JQL query, e.g. project = OPS AND status != Done ORDER BY updated DESC"
+	if is.config.Backend == BackendLinear {
+		queryDesc = "Search term, empty lists the recently updated issues"
+	}
+	if len(is.config.AllowedProjects) > 0 {
+		queryDesc += ". Results are limited to the projects " + strings.Join(is.config.AllowedProjects, ", ")
+	}
+	keyOpt := mcp.Description("Issue key, e.g. OPS-123")
+
+	is.AddTool(mcp.NewTool(
+		"tracker_search",
+		mcp.WithDescription("Search issues in the issue tracker"),
+		mcp.WithString("query",
+			mcp.Description(queryDesc),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of issues, default: 20, maximum: %d", is.config.MaxResults)),
+		),
+	), is.handleSearch)
+
+	is.AddTool(mcp.NewTool(
+		"tracker_get",
+		mcp.WithDescription("Get an issue with its description, comments and the statuses it can be transitioned to"),
+		mcp.WithString("key", keyOpt, mcp.Required()),
+	), is.handleGet)
+
+	if is.config.ReadOnly {
+		return nil
+	}
+
+	projectDesc := "Project key, the team key on Linear"
+	if is.config.DefaultProject != "" {
+		projectDesc += ". Default: " + is.config.DefaultProject
+	}
+	is.AddTool(mcp.NewTool(
+		"tracker_create",
+		mcp.WithDescription("Create an issue"),
+		mcp.WithString("project",
+			mcp.Description(projectDesc),
+		),
+		mcp.WithString("summary",
+			mcp.Description("Issue summary, the title"),
+			mcp.Required(),
+		),
+		mcp.WithString("description",
+			mcp.Description("Issue description"),
+		),
+		mcp.WithString("type",
+			mcp.Description("Jira issue type such as Bug, Task or Story. Default: "+is.config.DefaultType),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("Labels to add"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+	), is.handleCreate)
+
+	is.AddTool(mcp.NewTool(
+		"tracker_comment",
+		mcp.WithDescription("Add a comment to an issue"),
+		mcp.WithString("key", keyOpt, mcp.Required()),
+		mcp.WithString("body",
+			mcp.Description("Comment text"),
+			mcp.Required(),
+		),
+	), is.handleComment)
+
+	is.AddTool(mcp.NewTool(
+		"tracker_transition",
+		mcp.WithDescription("Move an issue to another status. tracker_get lists the available transitions"),
+		mcp.WithString("key", keyOpt, mcp.Required()),
+		mcp.WithString("to",
+			mcp.Description("Target status or transition name, e.g. In Progress or Done"),
+			mcp.Required(),
+		),
+	), is.handleTransition)
+	return nil
+}
+
+func (is *IssueTrackerServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := is.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, is.config.Backend)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// issueKey reads the key argument and checks its project against allowed_projects.
+func (is *IssueTrackerServer) issueKey(args map[string]any) (string, error) {
+	key := abstract.GetString(args, "key", "")
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid issue key: %q", key)
+	}
+	if !is.config.projectAllowed(projectOf(key)) {
+		return "", fmt.Errorf("%w: %s", ErrProjectNotAllowed, key)
+	}
+	return key, nil
+}
+
+// handleSearch searches issues. Results outside allowed_projects are dropped, even when the
+// query escapes the project scope.
+func (is *IssueTrackerServer) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	query := abstract.GetString(args, "query", "")
+	limit := abstract.GetInt(args, "limit", min(20, is.config.MaxResults))
+	if limit < 1 || limit > is.config.MaxResults {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", is.config.MaxResults)), nil
+	}
+	issues, err := is.tracker.Search(ctx, query, is.config.AllowedProjects, limit)
+	if err != nil {
+		return httpapi.ErrorResult(is.Logger, "search", "key", "", err), nil
+	}
+	allowed := issues[:0]
+	for _, issue := range issues {
+		if is.config.projectAllowed(projectOf(issue.Key)) {
+			allowed = append(allowed, issue)
+		}
+	}
+	if len(allowed) == 0 {
+		return mcp.NewToolResultText("No issues found"), nil
+	}
+	return abstract.NewJSONResult(allowed), nil
+}
+
+func (is *IssueTrackerServer) handleGet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := is.issueKey(request.GetArguments())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := is.tracker.Get(ctx, key)
+	if err != nil {
+		return httpapi.ErrorResult(is.Logger, "reading the issue", "key", key, err), nil
+	}
+	return abstract.NewJSONResult(issue), nil
+}
+
+func (is *IssueTrackerServer) handleCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	n := NewIssue{Type: is.config.DefaultType}
+	n.Project = abstract.GetString(args, "project", "")
+	n.Summary = abstract.GetString(args, "summary", "")
+	n.Description = abstract.GetString(args, "description", "")
+	if t := abstract.GetString(args, "type", ""); t != "" {
+		n.Type = t
+	}
+	if n.Project == "" {
+		n.Project = is.config.DefaultProject
+	}
+	n.Project = strings.ToUpper(strings.TrimSpace(n.Project))
+	if !projectKeyPattern.MatchString(n.Project) {
+		return mcp.NewToolResultError("project must be a project key"), nil
+	}
+	if !is.config.projectAllowed(n.Project) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s: %s", ErrProjectNotAllowed.Error(), n.Project)), nil
+	}
+	if strings.TrimSpace(n.Summary) == "" {
+		return mcp.NewToolResultError("summary must be a non-empty string"), nil
+	}
+	if labels, ok := args["labels"].([]any); ok {
+		for _, l := range labels {
+			label, ok := l.(string)
+			if !ok || label == "" {
+				return mcp.NewToolResultError("labels must be an array of strings"), nil
+			}
+			n.Labels = append(n.Labels, label)
+		}
+	}
+	issue, err := is.tracker.Create(ctx, n)
+	if err != nil {
+		return httpapi.ErrorResult(is.Logger, "creating the issue", "key", n.Project, err), nil
+	}
+	is.Logger.Info().Str("key", issue.Key).Msg("issue created")
+	return mcp.NewToolResultText(fmt.Sprintf("Created %s: %s", issue.Key, issue.URL)), nil
+}
+
+func (is *IssueTrackerServer) handleComment(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	key, err := is.issueKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	body := abstract.GetString(args, "body", "")
+	if strings.TrimSpace(body) == "" {
+		return mcp.NewToolResultError("body must be a non-empty string"), nil
+	}
+	err = is.tracker.Comment(ctx, key, body)
+	if err != nil {
+		return httpapi.ErrorResult(is.Logger, "adding the comment", "key", key, err), nil
+	}
+	is.Logger.Info().Str("key", key).Msg("comment added")
+	return mcp.NewToolResultText(fmt.Sprintf("Added a comment to %s", key)), nil
+}
+
+func (is *IssueTrackerServer) handleTransition(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	key, err := is.issueKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	to := abstract.GetString(args, "to", "")
+	if strings.TrimSpace(to) == "" {
+		return mcp.NewToolResultError("to must be a non-empty string"), nil
+	}
+	status, err := is.tracker.Transition(ctx, key, strings.TrimSpace(to))
+	if err != nil {
+		return httpapi.ErrorResult(is.Logger, "transitioning the issue", "key", key, err), nil
+	}
+	is.Logger.Info().Str("key", key).Str("status", status).Msg("issue transitioned")
+	return mcp.NewToolResultText(fmt.Sprintf("Moved %s to %s", key, status)), nil
+}
+
+// Config returns the configuration of the service as a string. The token is left out.
+func (is *IssueTrackerServer) Config() string {
+	cfg := *is.config
+	cfg.Token = ""
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		is.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (is *IssueTrackerServer) Name() comm.MoLingServerType {
+	return IssueTrackerServerName
+}
+
+func (is *IssueTrackerServer) Close() error {
+	is.Logger.Debug().Msg("IssueTrackerServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (is *IssueTrackerServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(is.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return is.config.Check()
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package issuetracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gojue/moling/pkg/utils/httpapi"
+)
+
+// ErrProjectNotAllowed is returned for issues and projects outside allowed_projects.
+var ErrProjectNotAllowed = errors.New("access denied - project is not in allowed_projects")
+
+// Issue is an issue of the tracker. Description, Comments and Transitions are only set by Get.
+type Issue struct {
+	Key         string       `json:"key"`
+	Summary     string       `json:"summary"`
+	Status      string       `json:"status"`
+	Type        string       `json:"type,omitempty"`
+	Priority    string       `json:"priority,omitempty"`
+	Assignee    string       `json:"assignee,omitempty"`
+	Reporter    string       `json:"reporter,omitempty"`
+	Labels      []string     `json:"labels,omitempty"`
+	URL         string       `json:"url"`
+	Created     string       `json:"created"`
+	Updated     string       `json:"updated"`
+	Description string       `json:"description,omitempty"`
+	Comments    []Comment    `json:"comments,omitempty"`
+	Transitions []Transition `json:"transitions,omitempty"`
+	id          string
+}
+
+// Comment is a comment on an issue.
+type Comment struct {
+	Author  string `json:"author"`
+	Body    string `json:"body"`
+	Created string `json:"created"`
+}
+
+// Transition is a status an issue can move to.
+type Transition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   string `json:"to"`
+}
+
+// NewIssue holds the fields of an issue to create.
+type NewIssue struct {
+	Project     string
+	Summary     string
+	Description string
+	Type        string
+	Labels      []string
+}
+
+// Tracker is the API of an issue tracker.
+type Tracker interface {
+	// Search finds issues. The query is JQL for Jira and a search term for Linear. A non-empty
+	// projects list limits the search to these projects.
+	Search(ctx context.Context, query string, projects []string, limit int) ([]Issue, error)
+	Get(ctx context.Context, key string) (*Issue, error)
+	Create(ctx context.Context, issue NewIssue) (*Issue, error)
+	Comment(ctx context.Context, key, body string) error
+	// Transition moves an issue along the transition, or to the status, with the given name or
+	// ID and returns the new status.
+	Transition(ctx context.Context, key, to string) (string, error)
+}
+
+// NewTracker creates the tracker selected by the configuration.
+func NewTracker(cfg *IssueTrackerConfig, client *http.Client) Tracker {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	header := http.Header{}
+	if cfg.Backend == BackendLinear {
+		if baseURL == "" {
+			baseURL = "https://api.linear.app/graphql"
+		}
+		if cfg.Token != "" {
+			header.Set("Authorization", cfg.Token)
+		}
+		return &linear{api: &apiClient{client: client, baseURL: baseURL, header: header}}
+	}
+	if cfg.Email != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cfg.Email+":"+cfg.Token)))
+	} else if cfg.Token != "" {
+		header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	return &jira{api: &apiClient{client: client, baseURL: baseURL, header: header}, baseURL: baseURL, cloud: cfg.Email != ""}
+}
+
+// projectOf returns the project key of an issue key such as OPS-123.
+func projectOf(key string) string {
+	i := strings.LastIndexByte(key, '-')
+	if i <= 0 {
+		return ""
+	}
+	return key[:i]
+}
+
+// matchTransition finds a transition by ID, name or target status, ignoring case.
+func matchTransition(transitions []Transition, to string) (Transition, error) {
+	for _, t := range transitions {
+		if t.ID == to || strings.EqualFold(t.Name, to) || strings.EqualFold(t.To, to) {
+			return t, nil
+		}
+	}
+	names := make([]string, 0, len(transitions))
+	for _, t := range transitions {
+		names = append(names, t.To)
+	}
+	sort.Strings(names)
+	return Transition{}, fmt.Errorf("no transition to %q, available: %s", to, strings.Join(names, ", "))
+}
+
+// apiClient sends authenticated JSON requests to a REST or GraphQL API.
+type apiClient struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+// sendJSON sends a request with an optional JSON body and decodes the JSON response into out,
+// unless out is nil.
+func (c *apiClient) sendJSON(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	if c.baseURL == "" {
+		return fmt.Errorf("base_url is not configured")
+	}
+	rawURL := c.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, data, err := httpapi.Do(c.client, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s from %s: %s", resp.Status, req.URL.Host, errorMessage(data))
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+// errorMessage extracts the messages of a Jira or GraphQL error response.
+func errorMessage(data []byte) string {
+	var apiErr struct {
+		ErrorMessages []string        `json:"errorMessages"`
+		Errors        json.RawMessage `json:"errors"`
+		Message       string          `json:"message"`
+	}
+	var msgs []string
+	if json.Unmarshal(data, &apiErr) == nil {
+		msgs = append(msgs, apiErr.ErrorMessages...)
+		if apiErr.Message != "" {
+			msgs = append(msgs, apiErr.Message)
+		}
+		var fields map[string]string
+		var list []struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(apiErr.Errors, &fields) == nil {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				msgs = append(msgs, k+": "+fields[k])
+			}
+		} else if json.Unmarshal(apiErr.Errors, &list) == nil {
+			for _, e := range list {
+				msgs = append(msgs, e.Message)
+			}
+		}
+	}
+	msg := strings.Join(msgs, "; ")
+	if msg == "" {
+		msg = strings.TrimSpace(string(data))
+	}
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	return msg
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package issuetracker
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const IssueTrackerPromptDefault = `
+You are an assistant that works with the issue tracker of the team (%s). Your capabilities include:
+
+1. **Search**: Use tracker_search to find issues. With Jira the query is JQL, e.g. project = OPS AND status = "In Progress" ORDER BY priority DESC. With Linear it is a search term.
+
+2. **Read**: Use tracker_get with an issue key such as OPS-123 to read the description, comments and the statuses the issue can move to.
+
+3. **Update**: Create issues with tracker_create, add comments with tracker_comment, and move issues to another status with tracker_transition.
+
+Only create, comment on or transition issues when the user asked for it, and quote issue keys and links in your answers.
+`
+
+// Issue tracker backends.
+const (
+	BackendJira   = "jira"
+	BackendLinear = "linear"
+)
+
+var projectKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// IssueTrackerConfig represents the configuration for the issue tracker service.
+type IssueTrackerConfig struct {
+	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the issue tracker service.
+	prompt          string
+	Backend         string   `json:"backend"`          // Backend is jira or linear.
+	BaseURL         string   `json:"base_url"`         // BaseURL is the Jira site, e.g. https://example.atlassian.net, which Jira requires, or the Linear GraphQL endpoint.
+	Email           string   `json:"email"`            // Email is the Jira Cloud account of the API token. Without it the token is sent as a Jira Data Center personal access token.
+	Token           string   `json:"token"`            // Token is the Jira API token or Linear API key, empty reads JIRA_API_TOKEN or LINEAR_API_KEY.
+	AllowedProjects []string `json:"allowed_projects"` // AllowedProjects are the Jira project keys or Linear team keys the tools may access, empty allows all.
+	DefaultProject  string   `json:"default_project"`  // DefaultProject is used by tracker_create when no project is given.
+	DefaultType     string   `json:"default_type"`     // DefaultType is the Jira issue type of new issues.
+	MaxResults      int      `json:"max_results"`      // MaxResults is the maximum number of search results.
+	ReadOnly        bool     `json:"read_only"`        // ReadOnly disables the tools that create, comment on or transition issues.
+	Timeout         int      `json:"timeout"`          // Timeout is the timeout of a single API request. time.Second
+}
+
+// NewIssueTrackerConfig creates a new IssueTrackerConfig with default values.
+func NewIssueTrackerConfig() *IssueTrackerConfig {
+	return &IssueTrackerConfig{
+		prompt:          IssueTrackerPromptDefault,
+		Backend:         BackendJira,
+		AllowedProjects: []string{},
+		DefaultType:     "Task",
+		MaxResults:      50,
+		Timeout:         30,
+	}
+}
+
+// Check validates the issue tracker configuration.
+func (cfg *IssueTrackerConfig) Check() error {
+	cfg.prompt = IssueTrackerPromptDefault
+	cfg.Backend = strings.ToLower(cfg.Backend)
+	switch cfg.Backend {
+	case BackendJira:
+		if cfg.Token == "" {
+			cfg.Token = os.Getenv("JIRA_API_TOKEN")
+		}
+	case BackendLinear:
+		if cfg.Token == "" {
+			cfg.Token = os.Getenv("LINEAR_API_KEY")
+		}
+	default:
+		return fmt.Errorf("backend must be %s or %s", BackendJira, BackendLinear)
+	}
+	for i, key := range cfg.AllowedProjects {
+		if !projectKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid project key in allowed_projects: %q", key)
+		}
+		cfg.AllowedProjects[i] = strings.ToUpper(key)
+	}
+	if cfg.DefaultProject != "" && !cfg.projectAllowed(cfg.DefaultProject) {
+		return fmt.Errorf("default_project %s is not in allowed_projects", cfg.DefaultProject)
+	}
+	if cfg.MaxResults <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_results and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// projectAllowed reports whether a project key is in allowed_projects, ignoring case.
+func (cfg *IssueTrackerConfig) projectAllowed(key string) bool {
+	if len(cfg.AllowedProjects) == 0 {
+		return true
+	}
+	for _, allowed := range cfg.AllowedProjects {
+		if strings.EqualFold(allowed, key) {
+			return true
+		}
+	}
+	return false
+}
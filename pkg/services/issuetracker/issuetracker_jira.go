@@ -0,0 +1,226 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package issuetracker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// jiraFields are the issue fields requested for search results.
+var jiraFields = []string{"summary", "status", "issuetype", "priority", "assignee", "reporter", "labels", "created", "updated"}
+
+var orderByPattern = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// jira uses the Jira REST API v2, which takes plain text descriptions on both Jira Cloud and
+// Jira Data Center.
+type jira struct {
+	api     *apiClient
+	baseURL string
+	cloud   bool
+}
+
+type jiraUser struct {
+	DisplayName string `json:"displayName"`
+}
+
+type jiraName struct {
+	Name string `json:"name"`
+}
+
+type jiraTransition struct {
+	ID   string   `json:"id"`
+	Name string   `json:"name"`
+	To   jiraName `json:"to"`
+}
+
+type jiraIssue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string    `json:"summary"`
+		Status      *jiraName `json:"status"`
+		IssueType   *jiraName `json:"issuetype"`
+		Priority    *jiraName `json:"priority"`
+		Assignee    *jiraUser `json:"assignee"`
+		Reporter    *jiraUser `json:"reporter"`
+		Labels      []string  `json:"labels"`
+		Created     string    `json:"created"`
+		Updated     string    `json:"updated"`
+		Description string    `json:"description"`
+		Comment     *struct {
+			Comments []struct {
+				Author  jiraUser `json:"author"`
+				Body    string   `json:"body"`
+				Created string   `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+	Transitions []jiraTransition `json:"transitions"`
+}
+
+func (j *jira) issue(i jiraIssue) Issue {
+	f := i.Fields
+	issue := Issue{
+		Key:         i.Key,
+		Summary:     f.Summary,
+		Labels:      f.Labels,
+		URL:         j.baseURL + "/browse/" + i.Key,
+		Created:     f.Created,
+		Updated:     f.Updated,
+		Description: f.Description,
+		id:          i.ID,
+	}
+	if f.Status != nil {
+		issue.Status = f.Status.Name
+	}
+	if f.IssueType != nil {
+		issue.Type = f.IssueType.Name
+	}
+	if f.Priority != nil {
+		issue.Priority = f.Priority.Name
+	}
+	if f.Assignee != nil {
+		issue.Assignee = f.Assignee.DisplayName
+	}
+	if f.Reporter != nil {
+		issue.Reporter = f.Reporter.DisplayName
+	}
+	if f.Comment != nil {
+		for _, c := range f.Comment.Comments {
+			issue.Comments = append(issue.Comments, Comment{Author: c.Author.DisplayName, Body: c.Body, Created: c.Created})
+		}
+	}
+	for _, t := range i.Transitions {
+		issue.Transitions = append(issue.Transitions, Transition{ID: t.ID, Name: t.Name, To: t.To.Name})
+	}
+	return issue
+}
+
+// scopeJQL limits a JQL query to the projects, keeping its ORDER BY clause at the end.
+func scopeJQL(query string, projects []string) string {
+	query = strings.TrimSpace(query)
+	if len(projects) == 0 {
+		return query
+	}
+	where, order := query, ""
+	if loc := orderByPattern.FindAllStringIndex(query, -1); len(loc) > 0 {
+		last := loc[len(loc)-1]
+		where, order = strings.TrimSpace(query[:last[0]]), query[last[0]:]
+	}
+	quoted := make([]string, len(projects))
+	for i, p := range projects {
+		quoted[i] = `"` + p + `"`
+	}
+	scoped := "project in (" + strings.Join(quoted, ", ") + ")"
+	if where != "" {
+		scoped += " AND (" + where + ")"
+	}
+	if order != "" {
+		scoped += " " + order
+	}
+	return scoped
+}
+
+// Search runs a JQL query. Jira Cloud has replaced the search endpoint with search/jql.
+func (j *jira) Search(ctx context.Context, query string, projects []string, limit int) ([]Issue, error) {
+	path := "/rest/api/2/search"
+	if j.cloud {
+		path = "/rest/api/2/search/jql"
+	}
+	body := map[string]any{"jql": scopeJQL(query, projects), "maxResults": limit, "fields": jiraFields}
+	var resp struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	err := j.api.sendJSON(ctx, http.MethodPost, path, nil, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(resp.Issues))
+	for _, i := range resp.Issues {
+		issues = append(issues, j.issue(i))
+	}
+	return issues, nil
+}
+
+// Get reads an issue with its description, comments and available transitions.
+func (j *jira) Get(ctx context.Context, key string) (*Issue, error) {
+	q := url.Values{}
+	q.Set("fields", strings.Join(append(jiraFields, "description", "comment"), ","))
+	q.Set("expand", "transitions")
+	var resp jiraIssue
+	err := j.api.sendJSON(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key), q, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	issue := j.issue(resp)
+	return &issue, nil
+}
+
+func (j *jira) Create(ctx context.Context, n NewIssue) (*Issue, error) {
+	fields := map[string]any{
+		"project":   map[string]string{"key": n.Project},
+		"summary":   n.Summary,
+		"issuetype": map[string]string{"name": n.Type},
+	}
+	if n.Description != "" {
+		fields["description"] = n.Description
+	}
+	if len(n.Labels) > 0 {
+		fields["labels"] = n.Labels
+	}
+	var resp struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	err := j.api.sendJSON(ctx, http.MethodPost, "/rest/api/2/issue", nil, map[string]any{"fields": fields}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{Key: resp.Key, Summary: n.Summary, Type: n.Type, URL: j.baseURL + "/browse/" + resp.Key, id: resp.ID}, nil
+}
+
+func (j *jira) Comment(ctx context.Context, key, body string) error {
+	return j.api.sendJSON(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", nil, map[string]string{"body": body}, nil)
+}
+
+func (j *jira) Transition(ctx context.Context, key, to string) (string, error) {
+	path := "/rest/api/2/issue/" + url.PathEscape(key) + "/transitions"
+	var resp struct {
+		Transitions []jiraTransition `json:"transitions"`
+	}
+	err := j.api.sendJSON(ctx, http.MethodGet, path, nil, nil, &resp)
+	if err != nil {
+		return "", err
+	}
+	transitions := make([]Transition, 0, len(resp.Transitions))
+	for _, t := range resp.Transitions {
+		transitions = append(transitions, Transition{ID: t.ID, Name: t.Name, To: t.To.Name})
+	}
+	t, err := matchTransition(transitions, to)
+	if err != nil {
+		return "", err
+	}
+	err = j.api.sendJSON(ctx, http.MethodPost, path, nil, map[string]any{"transition": map[string]string{"id": t.ID}}, nil)
+	if err != nil {
+		return "", err
+	}
+	return t.To, nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package issuetracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// linearIssueFields are the fields selected for every issue.
+const linearIssueFields = `id identifier title url priorityLabel createdAt updatedAt
+state { name } assignee { name } creator { name } labels { nodes { name } } team { key }`
+
+// linear uses the Linear GraphQL API. Projects are Linear teams, identified by their key.
+type linear struct {
+	api *apiClient
+}
+
+type linearName struct {
+	Name string `json:"name"`
+}
+
+type linearIssue struct {
+	ID            string      `json:"id"`
+	Identifier    string      `json:"identifier"`
+	Title         string      `json:"title"`
+	URL           string      `json:"url"`
+	PriorityLabel string      `json:"priorityLabel"`
+	CreatedAt     string      `json:"createdAt"`
+	UpdatedAt     string      `json:"updatedAt"`
+	Description   string      `json:"description"`
+	State         *linearName `json:"state"`
+	Assignee      *linearName `json:"assignee"`
+	Creator       *linearName `json:"creator"`
+	Labels        struct {
+		Nodes []linearName `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		Nodes []struct {
+			Body      string      `json:"body"`
+			CreatedAt string      `json:"createdAt"`
+			User      *linearName `json:"user"`
+		} `json:"nodes"`
+	} `json:"comments"`
+	Team struct {
+		Key    string `json:"key"`
+		States struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"states"`
+	} `json:"team"`
+}
+
+func (i linearIssue) issue() Issue {
+	issue := Issue{
+		Key:         i.Identifier,
+		Summary:     i.Title,
+		Priority:    i.PriorityLabel,
+		URL:         i.URL,
+		Created:     i.CreatedAt,
+		Updated:     i.UpdatedAt,
+		Description: i.Description,
+		id:          i.ID,
+	}
+	if i.State != nil {
+		issue.Status = i.State.Name
+	}
+	if i.Assignee != nil {
+		issue.Assignee = i.Assignee.Name
+	}
+	if i.Creator != nil {
+		issue.Reporter = i.Creator.Name
+	}
+	for _, l := range i.Labels.Nodes {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	for _, c := range i.Comments.Nodes {
+		comment := Comment{Body: c.Body, Created: c.CreatedAt}
+		if c.User != nil {
+			comment.Author = c.User.Name
+		}
+		issue.Comments = append(issue.Comments, comment)
+	}
+	for _, s := range i.Team.States.Nodes {
+		if s.Name != issue.Status {
+			issue.Transitions = append(issue.Transitions, Transition{ID: s.ID, Name: s.Name, To: s.Name})
+		}
+	}
+	return issue
+}
+
+// query runs a GraphQL operation and decodes its data into out.
+func (l *linear) query(ctx context.Context, query string, variables map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := l.api.sendJSON(ctx, http.MethodPost, "", nil, map[string]any{"query": query, "variables": variables}, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("linear: %s", strings.Join(msgs, "; "))
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// Search runs a full text search, or lists the recently updated issues for an empty query.
+func (l *linear) Search(ctx context.Context, query string, projects []string, limit int) ([]Issue, error) {
+	variables := map[string]any{"first": limit}
+	if len(projects) > 0 {
+		variables["filter"] = map[string]any{"team": map[string]any{"key": map[string]any{"in": projects}}}
+	}
+	var resp struct {
+		SearchIssues struct {
+			Nodes []linearIssue `json:"nodes"`
+		} `json:"searchIssues"`
+		Issues struct {
+			Nodes []linearIssue `json:"nodes"`
+		} `json:"issues"`
+	}
+	var err error
+	query = strings.TrimSpace(query)
+	if query == "" {
+		err = l.query(ctx, `query($first: Int, $filter: IssueFilter) {
+  issues(first: $first, filter: $filter, orderBy: updatedAt) { nodes { `+linearIssueFields+` } }
+}`, variables, &resp)
+	} else {
+		variables["term"] = query
+		err = l.query(ctx, `query($term: String!, $first: Int, $filter: IssueFilter) {
+  searchIssues(term: $term, first: $first, filter: $filter) { nodes { `+linearIssueFields+` } }
+}`, variables, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	nodes := append(resp.SearchIssues.Nodes, resp.Issues.Nodes...)
+	issues := make([]Issue, 0, len(nodes))
+	for _, n := range nodes {
+		issues = append(issues, n.issue())
+	}
+	return issues, nil
+}
+
+// Get reads an issue. The other workflow states of its team are returned as transitions.
+func (l *linear) Get(ctx context.Context, key string) (*Issue, error) {
+	var resp struct {
+		Issue *linearIssue `json:"issue"`
+	}
+	err := l.query(ctx, `query($id: String!) {
+  issue(id: $id) {
+    `+linearIssueFields+` description
+    comments { nodes { body createdAt user { name } } }
+    team { key states { nodes { id name } } }
+  }
+}`, map[string]any{"id": key}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Issue == nil {
+		return nil, fmt.Errorf("issue %s not found", key)
+	}
+	issue := resp.Issue.issue()
+	return &issue, nil
+}
+
+// Create looks up the team, and the labels by name, before creating the issue. The issue type
+// does not exist in Linear and is ignored.
+func (l *linear) Create(ctx context.Context, n NewIssue) (*Issue, error) {
+	var teams struct {
+		Teams struct {
+			Nodes []struct {
+				ID string `json:"id"`
+			} `json:"nodes"`
+		} `json:"teams"`
+		IssueLabels struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Team *struct {
+					Key string `json:"key"`
+				} `json:"team"`
+			} `json:"nodes"`
+		} `json:"issueLabels"`
+	}
+	labels := n.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	err := l.query(ctx, `query($key: String!, $labels: [String!]) {
+  teams(filter: { key: { eq: $key } }) { nodes { id } }
+  issueLabels(filter: { name: { in: $labels } }) { nodes { id name team { key } } }
+}`, map[string]any{"key": n.Project, "labels": labels}, &teams)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams.Teams.Nodes) == 0 {
+		return nil, fmt.Errorf("team %s not found", n.Project)
+	}
+	input := map[string]any{"teamId": teams.Teams.Nodes[0].ID, "title": n.Summary}
+	if n.Description != "" {
+		input["description"] = n.Description
+	}
+	if len(n.Labels) > 0 {
+		var ids []string
+		for _, name := range n.Labels {
+			found := false
+			for _, label := range teams.IssueLabels.Nodes {
+				if strings.EqualFold(label.Name, name) && (label.Team == nil || strings.EqualFold(label.Team.Key, n.Project)) {
+					ids = append(ids, label.ID)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("label %s not found in team %s", name, n.Project)
+			}
+		}
+		input["labelIds"] = ids
+	}
+	var resp struct {
+		IssueCreate struct {
+			Success bool         `json:"success"`
+			Issue   *linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	err = l.query(ctx, `mutation($input: IssueCreateInput!) {
+  issueCreate(input: $input) { success issue { `+linearIssueFields+` } }
+}`, map[string]any{"input": input}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IssueCreate.Success || resp.IssueCreate.Issue == nil {
+		return nil, fmt.Errorf("linear did not create the issue")
+	}
+	issue := resp.IssueCreate.Issue.issue()
+	return &issue, nil
+}
+
+func (l *linear) Comment(ctx context.Context, key, body string) error {
+	issue, err := l.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+	err = l.query(ctx, `mutation($issueId: String!, $body: String!) {
+  commentCreate(input: { issueId: $issueId, body: $body }) { success }
+}`, map[string]any{"issueId": issue.id, "body": body}, &resp)
+	if err != nil {
+		return err
+	}
+	if !resp.CommentCreate.Success {
+		return fmt.Errorf("linear did not create the comment")
+	}
+	return nil
+}
+
+// Transition moves the issue to another workflow state of its team.
+func (l *linear) Transition(ctx context.Context, key, to string) (string, error) {
+	issue, err := l.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	t, err := matchTransition(issue.Transitions, to)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		IssueUpdate struct {
+			Success bool `json:"success"`
+		} `json:"issueUpdate"`
+	}
+	err = l.query(ctx, `mutation($id: String!, $stateId: String!) {
+  issueUpdate(id: $id, input: { stateId: $stateId }) { success }
+}`, map[string]any{"id": issue.id, "stateId": t.ID}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if !resp.IssueUpdate.Success {
+		return "", fmt.Errorf("linear did not update the issue")
+	}
+	return t.To, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package issuetracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func TestScopeJQL(t *testing.T) {
+	for _, c := range []struct {
+		query, want string
+	}{
+		{"", `project in ("OPS", "WEB")`},
+		{"status = Done", `project in ("OPS", "WEB") AND (status = Done)`},
+		{"assignee = currentUser() order by updated DESC", `project in ("OPS", "WEB") AND (assignee = currentUser()) order by updated DESC`},
+		{"ORDER BY created", `project in ("OPS", "WEB") ORDER BY created`},
+	} {
+		if got := scopeJQL(c.query, []string{"OPS", "WEB"}); got != c.want {
+			t.Errorf("scopeJQL(%q) = %q, want %q", c.query, got, c.want)
+		}
+	}
+}
+
+func TestIssueTrackerJira(t *testing.T) {
+	var jql string
+	var posted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "jira-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/search/jql":
+			jql, _ = body["jql"].(string)
+			_, _ = w.Write([]byte(`{"issues":[
+{"key":"OPS-1","fields":{"summary":"Disk full","status":{"name":"To Do"},"assignee":{"displayName":"Alice"},"labels":["infra"]}},
+{"key":"SEC-9","fields":{"summary":"Secret"}}]}`))
+		case "GET /rest/api/2/issue/OPS-1":
+			if r.URL.Query().Get("expand") != "transitions" {
+				t.Errorf("transitions are not expanded")
+			}
+			_, _ = w.Write([]byte(`{"key":"OPS-1","fields":{"summary":"Disk full","description":"The disk is full","status":{"name":"To Do"},
+"comment":{"comments":[{"author":{"displayName":"Bob"},"body":"On it"}]}},
+"transitions":[{"id":"21","name":"Start","to":{"name":"In Progress"}}]}`))
+		case "POST /rest/api/2/issue":
+			fields, _ := body["fields"].(map[string]any)
+			posted = append(posted, fields["summary"].(string))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"10002","key":"OPS-2"}`))
+		case "POST /rest/api/2/issue/OPS-1/comment":
+			posted = append(posted, body["body"].(string))
+			w.WriteHeader(http.StatusCreated)
+		case "GET /rest/api/2/issue/OPS-1/transitions":
+			_, _ = w.Write([]byte(`{"transitions":[{"id":"21","name":"Start","to":{"name":"In Progress"}},{"id":"31","name":"Finish","to":{"name":"Done"}}]}`))
+		case "POST /rest/api/2/issue/OPS-1/transitions":
+			transition, _ := body["transition"].(map[string]any)
+			posted = append(posted, transition["id"].(string))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errorMessages":[],"errors":{"project":"project is required"}}`))
+		}
+	}))
+	defer ts.Close()
+	srv := testkit.NewServiceAs[*IssueTrackerServer](t, NewIssueTrackerServer, map[string]any{
+		"base_url": ts.URL, "email": "me@example.com", "token": "jira-token",
+		"allowed_projects": []any{"ops"}, "default_project": "OPS",
+	})
+
+	text, isErr := testkit.CallHandler(t, srv.handleSearch, map[string]any{"query": "status = \"To Do\" ORDER BY priority"})
+	if isErr || jql != `project in ("OPS") AND (status = "To Do") ORDER BY priority` {
+		t.Fatalf("unexpected search: %s, jql %s", text, jql)
+	}
+	if !strings.Contains(text, `"key": "OPS-1"`) || strings.Contains(text, "SEC-9") || !strings.Contains(text, ts.URL+"/browse/OPS-1") {
+		t.Errorf("unexpected search result: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleGet, map[string]any{"key": "ops-1"})
+	if isErr || !strings.Contains(text, "The disk is full") || !strings.Contains(text, `"author": "Bob"`) || !strings.Contains(text, `"to": "In Progress"`) {
+		t.Errorf("unexpected issue: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleCreate, map[string]any{"summary": "New task"})
+	if isErr || !strings.Contains(text, "Created OPS-2") {
+		t.Errorf("unexpected create result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleComment, map[string]any{"key": "OPS-1", "body": "Fixed"})
+	if isErr || text != "Added a comment to OPS-1" {
+		t.Errorf("unexpected comment result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleTransition, map[string]any{"key": "OPS-1", "to": "done"})
+	if isErr || text != "Moved OPS-1 to Done" {
+		t.Errorf("unexpected transition result: %s", text)
+	}
+	if strings.Join(posted, ",") != "New task,Fixed,31" {
+		t.Errorf("unexpected requests: %v", posted)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleTransition, map[string]any{"key": "OPS-1", "to": "Closed"})
+	if !isErr || !strings.Contains(text, "available: Done, In Progress") {
+		t.Errorf("expected an unknown transition error, got %s", text)
+	}
+
+	for _, c := range []struct {
+		handler testkit.ToolHandler
+		args    map[string]any
+	}{
+		{srv.handleGet, map[string]any{"key": "SEC-9"}},
+		{srv.handleGet, map[string]any{"key": "not a key"}},
+		{srv.handleCreate, map[string]any{"project": "SEC", "summary": "x"}},
+		{srv.handleComment, map[string]any{"key": "SEC-9", "body": "x"}},
+	} {
+		if text, isErr := testkit.CallHandler(t, c.handler, c.args); !isErr {
+			t.Errorf("expected error for %v, got %s", c.args, text)
+		}
+	}
+}
+
+func TestIssueTrackerLinear(t *testing.T) {
+	var mutations []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "lin_api_key" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Authentication required"}]}`))
+			return
+		}
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case strings.Contains(req.Query, "searchIssues"):
+			_, _ = w.Write([]byte(`{"data":{"searchIssues":{"nodes":[{"id":"u1","identifier":"ENG-7","title":"Flaky test","state":{"name":"Todo"},"team":{"key":"ENG"}}]}}}`))
+		case strings.Contains(req.Query, "issue(id: $id)"):
+			_, _ = w.Write([]byte(`{"data":{"issue":{"id":"u1","identifier":"ENG-7","title":"Flaky test","description":"Fails on CI","state":{"name":"Todo"},
+"comments":{"nodes":[{"body":"Seen again","user":{"name":"Eve"}}]},
+"team":{"key":"ENG","states":{"nodes":[{"id":"s1","name":"Todo"},{"id":"s2","name":"In Progress"},{"id":"s3","name":"Done"}]}}}}}`))
+		case strings.Contains(req.Query, "teams("):
+			_, _ = w.Write([]byte(`{"data":{"teams":{"nodes":[{"id":"t1"}]},"issueLabels":{"nodes":[{"id":"l1","name":"Bug","team":null}]}}}`))
+		case strings.Contains(req.Query, "issueCreate"), strings.Contains(req.Query, "commentCreate"), strings.Contains(req.Query, "issueUpdate"):
+			mutations = append(mutations, req.Variables)
+			_, _ = w.Write([]byte(`{"data":{"issueCreate":{"success":true,"issue":{"identifier":"ENG-8","url":"https://linear.app/x/issue/ENG-8"}},"commentCreate":{"success":true},"issueUpdate":{"success":true}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unexpected query"}]}`))
+		}
+	}))
+	defer ts.Close()
+	srv := testkit.NewServiceAs[*IssueTrackerServer](t, NewIssueTrackerServer, map[string]any{"backend": "linear", "base_url": ts.URL, "token": "lin_api_key"})
+
+	text, isErr := testkit.CallHandler(t, srv.handleSearch, map[string]any{"query": "flaky"})
+	if isErr || !strings.Contains(text, `"key": "ENG-7"`) {
+		t.Errorf("unexpected search result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleGet, map[string]any{"key": "ENG-7"})
+	if isErr || !strings.Contains(text, "Fails on CI") || !strings.Contains(text, `"to": "Done"`) || strings.Contains(text, `"to": "Todo"`) {
+		t.Errorf("unexpected issue: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleCreate, map[string]any{"project": "eng", "summary": "New", "labels": []any{"bug"}})
+	if isErr || !strings.Contains(text, "Created ENG-8") {
+		t.Errorf("unexpected create result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleComment, map[string]any{"key": "ENG-7", "body": "Fixed"})
+	if isErr {
+		t.Errorf("unexpected comment result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleTransition, map[string]any{"key": "ENG-7", "to": "in progress"})
+	if isErr || text != "Moved ENG-7 to In Progress" {
+		t.Errorf("unexpected transition result: %s", text)
+	}
+	if len(mutations) != 3 {
+		t.Fatalf("expected 3 mutations, got %d", len(mutations))
+	}
+	input, _ := mutations[0]["input"].(map[string]any)
+	if input["teamId"] != "t1" || mutations[1]["issueId"] != "u1" || mutations[2]["stateId"] != "s2" {
+		t.Errorf("unexpected mutations: %v", mutations)
+	}
+
+	srv = testkit.NewServiceAs[*IssueTrackerServer](t, NewIssueTrackerServer, map[string]any{"backend": "linear", "base_url": ts.URL, "token": "wrong", "read_only": true})
+	text, isErr = testkit.CallHandler(t, srv.handleSearch, map[string]any{"query": "flaky"})
+	if !isErr || !strings.Contains(text, "Authentication required") {
+		t.Errorf("expected an authentication error, got %s", text)
+	}
+	if len(srv.Tools()) != 2 {
+		t.Errorf("read_only server registered %d tools", len(srv.Tools()))
+	}
+}
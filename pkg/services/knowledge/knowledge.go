@@ -0,0 +1,416 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package knowledge provides a local retrieval (RAG) knowledge base for the MoLing application.
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	KnowledgeServerName comm.MoLingServerType = "Knowledge"
+	DefaultCollection                         = "default"
+)
+
+// KnowledgeServer implements the Service interface and provides ingestion and semantic search over local documents.
+type KnowledgeServer struct {
+	abstract.MLService
+	config      *KnowledgeConfig
+	client      *http.Client
+	fetchClient *http.Client // fetchClient downloads web pages, checking every redirect against allowed_urls.
+	embedder    Embedder
+	index       *Index
+}
+
+// NewKnowledgeServer creates a new KnowledgeServer instance.
+func NewKnowledgeServer(ctx context.Context) (abstract.Service, error) {
+	kc := NewKnowledgeConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("KnowledgeServer: invalid config type")
+	}
+	kc.StorePath = filepath.Join(globalConf.BasePath, "data", "knowledge")
+	kc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("KnowledgeServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(KnowledgeServerName))
+	})
+
+	ks := &KnowledgeServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    kc,
+	}
+	err := ks.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Init opens the index, creates the embedding client and registers the prompt and tools.
+func (ks *KnowledgeServer) Init() error {
+	var err error
+	ks.index, err = NewIndex(ks.config.StorePath)
+	if err != nil {
+		return err
+	}
+	ks.client = &http.Client{Timeout: time.Duration(ks.config.Timeout) * time.Second}
+	ks.embedder = NewEmbedder(ks.config, ks.client)
+	ks.fetchClient = &http.Client{
+		Timeout: ks.client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			_, err := ks.checkURL(req.URL.String())
+			return err
+		},
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "knowledge_prompt",
+			Description: "Get the relevant functions and prompts of the Knowledge MCP Server",
+		},
+		HandlerFunc: ks.handlePrompt,
+	}
+	ks.AddPrompt(pe)
+
+	ks.AddTool(mcp.NewTool(
+		"knowledge_ingest",
+		mcp.WithDescription("Ingest a local text file, a directory of text files, or a web page into the knowledge base. Re-ingesting a source replaces its previous content."),
+		mcp.WithString("source",
+			mcp.Description("File or directory path inside allowed_dirs, or an http(s) URL matching allowed_urls"),
+			mcp.Required(),
+		),
+		mcp.WithString("collection",
+			mcp.Description("Collection to ingest into, default: default"),
+		),
+	), ks.handleIngest)
+
+	ks.AddTool(mcp.NewTool(
+		"knowledge_query",
+		mcp.WithDescription("Retrieve the chunks of the knowledge base most relevant to a question"),
+		mcp.WithString("query",
+			mcp.Description("The question or text to search for"),
+			mcp.Required(),
+		),
+		mcp.WithString("collection",
+			mcp.Description("Collection to search, default: default"),
+		),
+		mcp.WithNumber("top_k",
+			mcp.Description(fmt.Sprintf("Number of chunks to return, default: %d", ks.config.DefaultTopK)),
+		),
+	), ks.handleQuery)
+	return nil
+}
+
+func (ks *KnowledgeServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ks.config.prompt
+	if strings.Count(text, "%s") == 2 {
+		allowed := "none, ask the user to configure allowed_urls"
+		if len(ks.config.AllowedURLs) > 0 {
+			allowed = strings.Join(ks.config.AllowedURLs, ", ")
+		}
+		text = fmt.Sprintf(text, strings.Join(ks.config.AllowedDirs, ", "), allowed)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// handleIngest handles ingesting a file, directory or URL.
+func (ks *KnowledgeServer) handleIngest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	source, ok := args["source"].(string)
+	if !ok || source == "" {
+		return mcp.NewToolResultError("source must be a non-empty string"), nil
+	}
+	collection := collectionArg(args)
+
+	var documents map[string]string
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		var text string
+		text, err = ks.fetchURL(ctx, source)
+		documents = map[string]string{source: text}
+	} else {
+		documents, err = ks.readPath(source)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", source, err.Error())), nil
+	}
+	if len(documents) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no text content found in %s", source)), nil
+	}
+
+	names := make([]string, 0, len(documents))
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	total := 0
+	for _, name := range names {
+		n, err := ks.ingest(ctx, collection, name, documents[name])
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to ingest %s: %s", name, err.Error())), nil
+		}
+		ks.Logger.Debug().Str("collection", collection).Str("source", name).Int("chunks", n).Msg("source ingested")
+		total += n
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Ingested %d chunks from %d source(s) into collection %s", total, len(names), collection)), nil
+}
+
+// ingest chunks, embeds and stores a single document.
+func (ks *KnowledgeServer) ingest(ctx context.Context, collection, source, text string) (int, error) {
+	texts := chunkText(text, ks.config.ChunkSize, ks.config.ChunkOverlap)
+	chunks := make([]Chunk, 0, len(texts))
+	for start := 0; start < len(texts); start += ks.config.BatchSize {
+		end := min(start+ks.config.BatchSize, len(texts))
+		vectors, err := ks.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return 0, err
+		}
+		for i, v := range vectors {
+			chunks = append(chunks, Chunk{Source: source, Index: start + i, Text: texts[start+i], Vector: v})
+		}
+	}
+	err := ks.index.Replace(collection, ks.config.EmbeddingModel, source, chunks)
+	if err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// handleQuery handles retrieving the chunks most relevant to a query.
+func (ks *KnowledgeServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+	collection := collectionArg(args)
+	topK := ks.config.DefaultTopK
+	if k := abstract.GetInt(args, "top_k", 0); k > 0 {
+		topK = k
+	}
+
+	vectors, err := ks.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to embed query: %s", err.Error())), nil
+	}
+	matches, err := ks.index.Query(collection, vectors[0], topK)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to query collection %s: %s", collection, err.Error())), nil
+	}
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Collection %s is empty, ingest some documents first", collection)), nil
+	}
+	var sb strings.Builder
+	for i, m := range matches {
+		sb.WriteString(fmt.Sprintf("[%d] source: %s (chunk %d, score %.4f)\n%s\n\n", i+1, m.Source, m.Index, m.Score, m.Text))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// readPath reads a text file, or every text file below a directory. Symlinks below a directory are skipped.
+func (ks *KnowledgeServer) readPath(path string) (map[string]string, error) {
+	absPath, err := utils.ResolvePath(path, ks.config.AllowedDirs)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+	documents := make(map[string]string)
+	if !info.IsDir() {
+		if !utils.IsTextFile(utils.DetectMimeType(absPath)) {
+			return nil, fmt.Errorf("not a text file")
+		}
+		text, err := ks.readFile(absPath, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		documents[absPath] = text
+		return documents, nil
+	}
+	err = filepath.WalkDir(absPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != absPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !utils.IsTextFile(utils.DetectMimeType(p)) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		text, err := ks.readFile(p, fi.Size())
+		if err != nil {
+			ks.Logger.Warn().Err(err).Str("path", p).Msg("skip file")
+			return nil
+		}
+		documents[p] = text
+		return nil
+	})
+	return documents, err
+}
+
+func (ks *KnowledgeServer) readFile(path string, size int64) (string, error) {
+	if size > ks.config.MaxSourceSize {
+		return "", fmt.Errorf("file is too large (%d bytes), limit is %d bytes", size, ks.config.MaxSourceSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+		text = htmlToText(text)
+	}
+	return text, nil
+}
+
+// checkURL parses a URL and checks it against allowed_urls.
+func (ks *KnowledgeServer) checkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an http or https URL")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("url must not contain credentials")
+	}
+	if !utils.URLAllowed(u, ks.config.AllowedURLs) {
+		return nil, fmt.Errorf("access denied - %s is not in allowed_urls", u.Host)
+	}
+	return u, nil
+}
+
+// fetchURL downloads a web page and extracts its text.
+func (ks *KnowledgeServer) fetchURL(ctx context.Context, raw string) (string, error) {
+	u, err := ks.checkURL(raw)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := ks.fetchClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, ks.config.MaxSourceSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > ks.config.MaxSourceSize {
+		return "", fmt.Errorf("page is larger than %d bytes", ks.config.MaxSourceSize)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if strings.Contains(contentType, "html") {
+		return htmlToText(string(data)), nil
+	}
+	if !utils.IsTextFile(strings.TrimSpace(strings.Split(contentType, ";")[0])) {
+		return "", fmt.Errorf("unsupported content type %s", contentType)
+	}
+	return string(data), nil
+}
+
+func collectionArg(args map[string]any) string {
+	if c := abstract.GetString(args, "collection", ""); c != "" {
+		return c
+	}
+	return DefaultCollection
+}
+
+// Config returns the configuration of the service as a string.
+func (ks *KnowledgeServer) Config() string {
+	cfg, err := json.Marshal(ks.config)
+	if err != nil {
+		ks.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ks *KnowledgeServer) Name() comm.MoLingServerType {
+	return KnowledgeServerName
+}
+
+func (ks *KnowledgeServer) Close() error {
+	ks.Logger.Debug().Msg("KnowledgeServer closed")
+	if ks.index == nil {
+		return nil
+	}
+	return ks.index.Close()
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ks *KnowledgeServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ks.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ks.config.Check()
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const KnowledgePromptDefault = `
+You are a retrieval assistant with access to a local knowledge base. Your capabilities include:
+
+1. **Ingest**: Add local files or web pages to a collection with knowledge_ingest. The content is split into chunks and embedded; ingesting the same source again replaces its previous chunks. Only files below the allowed directories (%s) and web pages matching the allowed URLs (%s) can be ingested.
+
+2. **Query**: Retrieve the chunks most relevant to a question with knowledge_query, then answer using them. Always cite the source of the chunks you used.
+
+If knowledge_query returns nothing relevant, say so instead of guessing, and suggest which documents could be ingested.
+`
+
+const (
+	EmbeddingAPIOllama = "ollama" // POST {endpoint}/api/embed
+	EmbeddingAPIOpenAI = "openai" // POST {endpoint}/v1/embeddings
+)
+
+// KnowledgeConfig represents the configuration for the knowledge service.
+type KnowledgeConfig struct {
+	PromptFile        string `json:"prompt_file"` // PromptFile is the prompt file for the knowledge service.
+	prompt            string
+	StorePath         string   `json:"store_path"`         // StorePath is the directory of the vector index database.
+	AllowedDirs       []string `json:"allowed_dirs"`       // AllowedDirs are the directories files may be ingested from, relative paths use the first one.
+	AllowedURLs       []string `json:"allowed_urls"`       // AllowedURLs are the sites that may be ingested: a host, which covers its subdomains, a URL prefix, or * for any.
+	EmbeddingAPI      string   `json:"embedding_api"`      // EmbeddingAPI is the embedding backend protocol, "ollama" or "openai".
+	EmbeddingEndpoint string   `json:"embedding_endpoint"` // EmbeddingEndpoint is the base URL of the embedding backend.
+	EmbeddingModel    string   `json:"embedding_model"`    // EmbeddingModel is the model used to embed chunks and queries.
+	APIKey            string   `json:"api_key"`            // APIKey is sent as a bearer token to OpenAI-compatible endpoints.
+	ChunkSize         int      `json:"chunk_size"`         // ChunkSize is the maximum number of characters per chunk.
+	ChunkOverlap      int      `json:"chunk_overlap"`      // ChunkOverlap is the number of characters shared by adjacent chunks.
+	BatchSize         int      `json:"batch_size"`         // BatchSize is the number of chunks sent per embedding request.
+	MaxSourceSize     int64    `json:"max_source_size"`    // MaxSourceSize is the maximum size of a file or web page to ingest, in bytes.
+	Timeout           int      `json:"timeout"`            // Timeout is the timeout of a single HTTP request, in seconds.
+	DefaultTopK       int      `json:"default_top_k"`      // DefaultTopK is the number of chunks returned by knowledge_query by default.
+}
+
+// NewKnowledgeConfig creates a new KnowledgeConfig with default values.
+func NewKnowledgeConfig() *KnowledgeConfig {
+	return &KnowledgeConfig{
+		prompt:            KnowledgePromptDefault,
+		StorePath:         filepath.Join(os.TempDir(), ".moling", "data", "knowledge"),
+		AllowedDirs:       []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		AllowedURLs:       []string{},
+		EmbeddingAPI:      EmbeddingAPIOllama,
+		EmbeddingEndpoint: "http://127.0.0.1:11434",
+		EmbeddingModel:    "nomic-embed-text",
+		ChunkSize:         1000,
+		ChunkOverlap:      150,
+		BatchSize:         32,
+		MaxSourceSize:     1024 * 1024 * 10,
+		Timeout:           120,
+		DefaultTopK:       5,
+	}
+}
+
+// Check validates the knowledge configuration.
+func (cfg *KnowledgeConfig) Check() error {
+	cfg.prompt = KnowledgePromptDefault
+	if cfg.StorePath == "" {
+		return fmt.Errorf("store_path must not be empty")
+	}
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	for i, entry := range cfg.AllowedURLs {
+		pattern, err := utils.NormalizeURLPattern(entry)
+		if err != nil {
+			return err
+		}
+		cfg.AllowedURLs[i] = pattern
+	}
+	if cfg.EmbeddingAPI != EmbeddingAPIOllama && cfg.EmbeddingAPI != EmbeddingAPIOpenAI {
+		return fmt.Errorf("embedding_api must be %q or %q, got %q", EmbeddingAPIOllama, EmbeddingAPIOpenAI, cfg.EmbeddingAPI)
+	}
+	if cfg.EmbeddingEndpoint == "" || cfg.EmbeddingModel == "" {
+		return fmt.Errorf("embedding_endpoint and embedding_model must not be empty")
+	}
+	if cfg.ChunkSize <= 0 {
+		return fmt.Errorf("chunk_size must be greater than 0")
+	}
+	if cfg.ChunkOverlap < 0 || cfg.ChunkOverlap >= cfg.ChunkSize {
+		return fmt.Errorf("chunk_overlap must be between 0 and chunk_size")
+	}
+	if cfg.BatchSize <= 0 {
+		return fmt.Errorf("batch_size must be greater than 0")
+	}
+	if cfg.MaxSourceSize <= 0 {
+		return fmt.Errorf("max_source_size must be greater than 0")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.DefaultTopK <= 0 {
+		return fmt.Errorf("default_top_k must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Embedder turns texts into vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder creates the embedder selected by the configuration.
+func NewEmbedder(cfg *KnowledgeConfig, client *http.Client) Embedder {
+	endpoint := strings.TrimRight(cfg.EmbeddingEndpoint, "/")
+	if cfg.EmbeddingAPI == EmbeddingAPIOpenAI {
+		return &openAIEmbedder{client: client, endpoint: endpoint, model: cfg.EmbeddingModel, apiKey: cfg.APIKey}
+	}
+	return &ollamaEmbedder{client: client, endpoint: endpoint, model: cfg.EmbeddingModel}
+}
+
+// ollamaEmbedder calls the Ollama /api/embed endpoint.
+type ollamaEmbedder struct {
+	client   *http.Client
+	endpoint string
+	model    string
+}
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	err := postJSON(ctx, e.client, e.endpoint+"/api/embed", "", map[string]any{"model": e.model, "input": texts}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding backend returned %d vectors for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
+
+// openAIEmbedder calls an OpenAI-compatible /v1/embeddings endpoint.
+type openAIEmbedder struct {
+	client   *http.Client
+	endpoint string
+	model    string
+	apiKey   string
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	url := e.endpoint + "/v1/embeddings"
+	if strings.HasSuffix(e.endpoint, "/v1") {
+		url = e.endpoint + "/embeddings"
+	}
+	err := postJSON(ctx, e.client, url, e.apiKey, map[string]any{"model": e.model, "input": texts}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding backend returned %d vectors for %d texts", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding backend returned an invalid index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("embedding backend returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gojue/moling/pkg/utils/sqlitedb"
+)
+
+var (
+	// ErrInvalidCollection is returned when a collection name contains unsupported characters.
+	ErrInvalidCollection = errors.New("invalid collection")
+	// ErrModelMismatch is returned when vectors from different embedding models are mixed in one collection.
+	ErrModelMismatch = errors.New("embedding model mismatch")
+
+	collectionPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-.]{1,64}$`)
+)
+
+// IndexFile is the name of the database in the store directory.
+const IndexFile = "knowledge.db"
+
+const indexSchema = `
+CREATE TABLE IF NOT EXISTS collections (
+	name       TEXT PRIMARY KEY,
+	model      TEXT NOT NULL,
+	dimension  INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS chunks (
+	collection TEXT NOT NULL,
+	source     TEXT NOT NULL,
+	idx        INTEGER NOT NULL,
+	text       TEXT NOT NULL,
+	vector     BLOB NOT NULL,
+	PRIMARY KEY (collection, source, idx)
+);
+`
+
+// Chunk is an embedded piece of a source document.
+type Chunk struct {
+	Source string    `json:"source"`
+	Index  int       `json:"index"`
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// Match is a chunk returned by a query together with its cosine similarity.
+type Match struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// Index is a flat (brute-force) vector index, persisted in a SQLite database. Vectors are kept as little-endian
+// float32 blobs and compared one by one on each query.
+type Index struct {
+	db *sql.DB
+}
+
+// NewIndex opens the index in the directory path. The directory and the database are created if they do not exist.
+func NewIndex(path string) (*Index, error) {
+	db, err := sqlitedb.Open(filepath.Join(path, IndexFile), indexSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the knowledge index: %w", err)
+	}
+	return &Index{db: db}, nil
+}
+
+// Close closes the database of the index.
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// Replace stores the chunks of a source, removing any chunks previously ingested from it.
+func (idx *Index) Replace(name, model, source string, chunks []Chunk) error {
+	if err := checkCollection(name); err != nil {
+		return err
+	}
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err = tx.Exec(`DELETE FROM chunks WHERE collection = ? AND source = ?`, name, source); err != nil {
+		return fmt.Errorf("failed to write collection %s: %w", name, err)
+	}
+	var kept int
+	if err = tx.QueryRow(`SELECT COUNT(*) FROM chunks WHERE collection = ?`, name).Scan(&kept); err != nil {
+		return fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+	dimension := 0
+	if kept > 0 {
+		var current string
+		if err = tx.QueryRow(`SELECT model, dimension FROM collections WHERE name = ?`, name).Scan(&current, &dimension); err != nil {
+			return fmt.Errorf("failed to read collection %s: %w", name, err)
+		}
+		if current != model {
+			return fmt.Errorf("%w: collection %s was built with %s, not %s", ErrModelMismatch, name, current, model)
+		}
+	}
+	for _, ch := range chunks {
+		if dimension == 0 {
+			dimension = len(ch.Vector)
+		}
+		if len(ch.Vector) != dimension {
+			return fmt.Errorf("%w: vector dimension %d, collection %s uses %d", ErrModelMismatch, len(ch.Vector), name, dimension)
+		}
+		_, err = tx.Exec(`INSERT INTO chunks (collection, source, idx, text, vector) VALUES (?, ?, ?, ?, ?)`,
+			name, source, ch.Index, ch.Text, encodeVector(ch.Vector))
+		if err != nil {
+			return fmt.Errorf("failed to write collection %s: %w", name, err)
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO collections (name, model, dimension, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET model = excluded.model, dimension = excluded.dimension, updated_at = excluded.updated_at`,
+		name, model, dimension, time.Now().UnixNano())
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write collection %s: %w", name, err)
+	}
+	return nil
+}
+
+// Query returns the k chunks most similar to vector.
+func (idx *Index) Query(name string, vector []float32, k int) ([]Match, error) {
+	if err := checkCollection(name); err != nil {
+		return nil, err
+	}
+	var dimension int
+	err := idx.db.QueryRow(`SELECT dimension FROM collections WHERE name = ?`, name).Scan(&dimension)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+	// The dimension is 0 for collections without chunks
+	if dimension == 0 {
+		return nil, nil
+	}
+	if len(vector) != dimension {
+		return nil, fmt.Errorf("%w: query dimension %d, collection %s uses %d", ErrModelMismatch, len(vector), name, dimension)
+	}
+	rows, err := idx.db.Query(`SELECT source, idx, text, vector FROM chunks WHERE collection = ?`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+	defer rows.Close()
+	var matches []Match
+	for rows.Next() {
+		var m Match
+		var blob []byte
+		if err = rows.Scan(&m.Source, &m.Index, &m.Text, &blob); err != nil {
+			return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+		}
+		m.Vector = decodeVector(blob)
+		m.Score = cosine(vector, m.Vector)
+		matches = append(matches, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// Sources returns the number of chunks per source in a collection.
+func (idx *Index) Sources(name string) (map[string]int, error) {
+	if err := checkCollection(name); err != nil {
+		return nil, err
+	}
+	rows, err := idx.db.Query(`SELECT source, COUNT(*) FROM chunks WHERE collection = ? GROUP BY source`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+	defer rows.Close()
+	sources := make(map[string]int)
+	for rows.Next() {
+		var source string
+		var count int
+		if err = rows.Scan(&source, &count); err != nil {
+			return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+		}
+		sources[source] = count
+	}
+	return sources, rows.Err()
+}
+
+func checkCollection(name string) error {
+	if !collectionPattern.MatchString(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidCollection, name)
+	}
+	return nil
+}
+
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+func TestReadPathAllowedDirs(t *testing.T) {
+	allowed, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(allowed, "notes.txt"), []byte("allowed notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(allowed, "link.txt")); err != nil {
+		t.Skipf("symlinks are not supported: %s", err.Error())
+	}
+	ks := testkit.NewServiceAs[*KnowledgeServer](t, NewKnowledgeServer, map[string]any{"allowed_dirs": []any{allowed}})
+
+	docs, err := ks.readPath("notes.txt")
+	if err != nil || docs[filepath.Join(allowed, "notes.txt")] != "allowed notes" {
+		t.Fatalf("Expected to read notes.txt, got %v, %v", docs, err)
+	}
+	for _, path := range []string{secret, outside, filepath.Join(allowed, "link.txt"), filepath.Join(allowed, "..")} {
+		if _, err := ks.readPath(path); !errors.Is(err, utils.ErrOutsideAllowedDirs) {
+			t.Errorf("Expected %s to be rejected, got %v", path, err)
+		}
+	}
+	docs, err = ks.readPath(allowed)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", allowed, err.Error())
+	}
+	for name := range docs {
+		if name != filepath.Join(allowed, "notes.txt") {
+			t.Errorf("Unexpected document %s read from the directory", name)
+		}
+	}
+}
+
+func TestFetchURLAllowedURLs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs/page":
+			_, _ = w.Write([]byte("public page"))
+		case "/docs/redirect":
+			http.Redirect(w, r, "/private", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("private page"))
+		}
+	}))
+	defer ts.Close()
+	ks := testkit.NewServiceAs[*KnowledgeServer](t, NewKnowledgeServer, map[string]any{"allowed_urls": []any{ts.URL + "/docs"}})
+
+	text, err := ks.fetchURL(context.Background(), ts.URL+"/docs/page")
+	if err != nil || text != "public page" {
+		t.Fatalf("Expected the public page, got %q, %v", text, err)
+	}
+	for _, raw := range []string{ts.URL + "/private", ts.URL + "/docs/redirect", "http://169.254.169.254/latest/meta-data/"} {
+		if _, err := ks.fetchURL(context.Background(), raw); err == nil || !strings.Contains(err.Error(), "access denied") {
+			t.Errorf("Expected %s to be rejected, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head)[^>]*>.*?</(script|style|noscript|svg|head)>|<!--.*?-->`)
+	htmlBlockPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6]|/section|/article|/pre|/blockquote)[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n\s*\n\s*(\n\s*)+`)
+	spacePattern     = regexp.MustCompile(`[ \t\r\f\v]+`)
+)
+
+// htmlToText extracts readable text from an HTML document.
+func htmlToText(doc string) string {
+	doc = htmlDropPattern.ReplaceAllString(doc, " ")
+	doc = htmlBlockPattern.ReplaceAllString(doc, "\n")
+	doc = htmlTagPattern.ReplaceAllString(doc, " ")
+	doc = html.UnescapeString(doc)
+	doc = spacePattern.ReplaceAllString(doc, " ")
+	lines := strings.Split(doc, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	doc = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinePattern.ReplaceAllString(doc, "\n\n"))
+}
+
+// chunkText splits text into chunks of at most size runes, with overlap runes shared by
+// adjacent chunks. Chunks end at a paragraph, line, sentence or word boundary when possible.
+func chunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			end = len(runes)
+		} else {
+			end = breakPoint(runes, start, end)
+		}
+		chunk := strings.TrimSpace(string(runes[start:end]))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		// do not start a chunk in the middle of a word
+		for next < end && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint finds the best place to end a chunk within the second half of runes[start:end].
+func breakPoint(runes []rune, start, end int) int {
+	lower := start + (end-start)/2
+	for _, sep := range []string{"\n\n", "\n", ". ", "。", " "} {
+		sepRunes := []rune(sep)
+		for i := end - len(sepRunes); i >= lower; i-- {
+			if string(runes[i:i+len(sepRunes)]) == sep {
+				return i + len(sepRunes)
+			}
+		}
+	}
+	return end
+}
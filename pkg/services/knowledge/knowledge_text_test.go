@@ -0,0 +1,104 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package knowledge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50)
+	chunks := chunkText(text, 200, 40)
+	if len(chunks) < 10 {
+		t.Fatalf("Expected at least 10 chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if len([]rune(c)) > 200 {
+			t.Errorf("Chunk %d is too long: %d", i, len([]rune(c)))
+		}
+		if !strings.HasPrefix(text, c[:5]) && !strings.Contains(text, " "+c[:5]) {
+			t.Errorf("Chunk %d starts in the middle of a word: %q", i, c[:10])
+		}
+	}
+	if chunks := chunkText("short", 200, 40); len(chunks) != 1 || chunks[0] != "short" {
+		t.Errorf("Expected a single chunk, got %v", chunks)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	doc := `<html><head><title>x</title><style>p{}</style></head><body><h1>Title</h1><p>Hello &amp; <b>welcome</b></p><script>alert(1)</script></body></html>`
+	text := htmlToText(doc)
+	if text != "Title\nHello & welcome" {
+		t.Errorf("Unexpected text: %q", text)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewIndex(dir)
+	if err != nil {
+		t.Fatalf("Failed to create index: %s", err.Error())
+	}
+	err = idx.Replace("docs", "m", "a.md", []Chunk{
+		{Source: "a.md", Index: 0, Text: "x", Vector: []float32{1, 0}},
+		{Source: "a.md", Index: 1, Text: "y", Vector: []float32{0, 1}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add chunks: %s", err.Error())
+	}
+	// reopen to make sure the chunks are persisted
+	if err = idx.Close(); err != nil {
+		t.Fatalf("Failed to close index: %s", err.Error())
+	}
+	idx, err = NewIndex(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen index: %s", err.Error())
+	}
+	defer idx.Close()
+	matches, err := idx.Query("docs", []float32{0.9, 0.1}, 1)
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err.Error())
+	}
+	if len(matches) != 1 || matches[0].Text != "x" || matches[0].Vector[0] != 1 {
+		t.Errorf("Expected chunk x, got %v", matches)
+	}
+	// re-ingesting a source replaces its chunks
+	err = idx.Replace("docs", "m", "a.md", []Chunk{{Source: "a.md", Text: "z", Vector: []float32{1, 1}}})
+	if err != nil {
+		t.Fatalf("Failed to replace chunks: %s", err.Error())
+	}
+	sources, _ := idx.Sources("docs")
+	if sources["a.md"] != 1 {
+		t.Errorf("Expected 1 chunk for a.md, got %d", sources["a.md"])
+	}
+	err = idx.Replace("docs", "m", "b.md", []Chunk{{Source: "b.md", Text: "w", Vector: []float32{1, 1, 1}}})
+	if !errors.Is(err, ErrModelMismatch) {
+		t.Errorf("Expected a dimension mismatch error, got %v", err)
+	}
+	err = idx.Replace("docs", "other", "b.md", []Chunk{{Source: "b.md", Text: "w", Vector: []float32{1, 1}}})
+	if !errors.Is(err, ErrModelMismatch) {
+		t.Errorf("Expected a model mismatch error, got %v", err)
+	}
+	if _, err = idx.Query("docs", []float32{1}, 1); !errors.Is(err, ErrModelMismatch) {
+		t.Errorf("Expected a query dimension mismatch error, got %v", err)
+	}
+	if matches, err = idx.Query("empty", []float32{1}, 1); err != nil || len(matches) != 0 {
+		t.Errorf("Expected no match in an unknown collection, got %v, %v", matches, err)
+	}
+}
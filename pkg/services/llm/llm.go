@@ -0,0 +1,352 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package llm provides access to local language models for the MoLing application.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	LLMServerName comm.MoLingServerType = "LLM"
+)
+
+// thinking matches the reasoning that models such as deepseek-r1 and qwen3 put before the answer.
+var thinking = regexp.MustCompile(`(?s)^\s*<think>.*?</think>\s*`)
+
+// LLMServer implements the Service interface and bridges to a local model server.
+type LLMServer struct {
+	abstract.MLService
+	config  *LLMConfig
+	backend Backend
+}
+
+// NewLLMServer creates a new LLMServer instance.
+func NewLLMServer(ctx context.Context) (abstract.Service, error) {
+	lc := NewLLMConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("LLMServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("LLMServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(LLMServerName))
+	})
+
+	ls := &LLMServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    lc,
+	}
+	err := ls.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+// Init creates the backend client and registers the prompt and tools.
+func (ls *LLMServer) Init() error {
+	client := &http.Client{Timeout: time.Duration(ls.config.Timeout) * time.Second}
+	ls.backend = NewBackend(ls.config, client)
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "llm_prompt",
+			Description: "Get the relevant functions and prompts of the LLM MCP Server",
+		},
+		HandlerFunc: ls.handlePrompt,
+	}
+	ls.AddPrompt(pe)
+
+	ls.AddTool(mcp.NewTool(
+		"llm_generate",
+		mcp.WithDescription("Generate text with a local language model"),
+		mcp.WithString("prompt",
+			mcp.Description("The instructions and the input text"),
+			mcp.Required(),
+		),
+		mcp.WithString("system",
+			mcp.Description("System message that sets the role and rules of the model"),
+		),
+		mcp.WithString("model",
+			mcp.Description("Model name, default: "+ls.config.DefaultModel),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description(fmt.Sprintf("Maximum number of tokens to generate, default: %d, maximum: %d", min(1024, ls.config.MaxTokens), ls.config.MaxTokens)),
+		),
+		mcp.WithNumber("temperature",
+			mcp.Description("Sampling temperature between 0 and 2, lower is more deterministic, default: the model default"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Answer format, default: text"),
+			mcp.Enum("text", "json"),
+		),
+	), ls.handleGenerate)
+
+	ls.AddTool(mcp.NewTool(
+		"llm_embed",
+		mcp.WithDescription("Compute embedding vectors of texts with a local embedding model"),
+		mcp.WithArray("texts",
+			mcp.Description(fmt.Sprintf("Texts to embed, at most %d", ls.config.MaxEmbedInputs)),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("model",
+			mcp.Description("Embedding model name, default: "+ls.config.EmbeddingModel),
+		),
+	), ls.handleEmbed)
+
+	ls.AddTool(mcp.NewTool(
+		"llm_models",
+		mcp.WithDescription("List the models of the local model server"),
+	), ls.handleModels)
+	return nil
+}
+
+func (ls *LLMServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ls.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ls.config.DefaultModel)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// model reads the model argument and checks it against allowed_models.
+func (ls *LLMServer) model(args map[string]any, fallback string) (string, error) {
+	model := abstract.GetString(args, "model", "")
+	return ls.allowedModel(model, fallback)
+}
+
+// allowedModel returns model, or fallback if model is empty, after checking it against allowed_models.
+func (ls *LLMServer) allowedModel(model, fallback string) (string, error) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = fallback
+	}
+	if !ls.config.modelAllowed(model) {
+		return "", fmt.Errorf("model %s is not allowed, use one of %s", model, strings.Join(ls.config.AllowedModels, ", "))
+	}
+	return model, nil
+}
+
+// Generate runs a completion with the configured backend and limits. An empty model selects the default model.
+func (ls *LLMServer) Generate(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	var err error
+	req.Model, err = ls.allowedModel(req.Model, ls.config.DefaultModel)
+	if err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, m := range req.Messages {
+		size += utf8.RuneCountInString(m.Content)
+	}
+	if size > ls.config.MaxInputSize {
+		return nil, fmt.Errorf("the input has %d characters, the limit is %d", size, ls.config.MaxInputSize)
+	}
+	start := time.Now()
+	gen, err := ls.backend.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	gen.Text = thinking.ReplaceAllString(gen.Text, "")
+	if gen.Model == "" {
+		gen.Model = req.Model
+	}
+	ls.Logger.Info().Str("model", gen.Model).Int("prompt_tokens", gen.PromptTokens).Int("completion_tokens", gen.CompletionTokens).
+		Dur("duration", time.Since(start)).Msg("text generated")
+	return gen, nil
+}
+
+func (ls *LLMServer) handleGenerate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	prompt := abstract.GetString(args, "prompt", "")
+	if strings.TrimSpace(prompt) == "" {
+		return mcp.NewToolResultError("prompt must be a non-empty string"), nil
+	}
+	model, err := ls.model(args, ls.config.DefaultModel)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	req := GenerateRequest{Model: model, MaxTokens: min(1024, ls.config.MaxTokens)}
+	if abstract.Has(args, "max_tokens") {
+		n := abstract.GetInt(args, "max_tokens", 0)
+		if n < 1 || n > ls.config.MaxTokens {
+			return mcp.NewToolResultError(fmt.Sprintf("max_tokens must be between 1 and %d", ls.config.MaxTokens)), nil
+		}
+		req.MaxTokens = n
+	}
+	if abstract.Has(args, "temperature") {
+		t := abstract.GetFloat(args, "temperature", -1)
+		if t < 0 || t > 2 {
+			return mcp.NewToolResultError("temperature must be between 0 and 2"), nil
+		}
+		req.Temperature = &t
+	}
+	switch format := abstract.GetString(args, "format", ""); format {
+	case "", "text":
+	case "json":
+		req.JSON = true
+	default:
+		return mcp.NewToolResultError("format must be text or json"), nil
+	}
+	if system := abstract.GetString(args, "system", ""); system != "" {
+		req.Messages = append(req.Messages, Message{Role: "system", Content: system})
+	}
+	req.Messages = append(req.Messages, Message{Role: "user", Content: prompt})
+
+	gen, err := ls.Generate(ctx, req)
+	if err != nil {
+		ls.Logger.Error().Err(err).Str("model", model).Msg("generation failed")
+		return mcp.NewToolResultError(fmt.Sprintf("generation failed: %s", err.Error())), nil
+	}
+	text := gen.Text
+	if gen.FinishReason == "length" {
+		text += fmt.Sprintf("\n\n[The answer was cut off after %d tokens, raise max_tokens for a longer answer]", req.MaxTokens)
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// Embed computes embedding vectors with the configured backend and limits. An empty model selects the embedding model.
+func (ls *LLMServer) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	model, err := ls.allowedModel(model, ls.config.EmbeddingModel)
+	if err != nil {
+		return nil, err
+	}
+	if len(texts) > ls.config.MaxEmbedInputs {
+		return nil, fmt.Errorf("%d texts given, the limit is %d", len(texts), ls.config.MaxEmbedInputs)
+	}
+	size := 0
+	for _, t := range texts {
+		size += utf8.RuneCountInString(t)
+	}
+	if size > ls.config.MaxInputSize {
+		return nil, fmt.Errorf("the texts have %d characters, the limit is %d", size, ls.config.MaxInputSize)
+	}
+	return ls.backend.Embed(ctx, model, texts)
+}
+
+func (ls *LLMServer) handleEmbed(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	items, _ := args["texts"].([]any)
+	if len(items) == 0 {
+		return mcp.NewToolResultError("texts must be a non-empty array of strings"), nil
+	}
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		text, ok := item.(string)
+		if !ok || strings.TrimSpace(text) == "" {
+			return mcp.NewToolResultError("texts must only contain non-empty strings"), nil
+		}
+		texts = append(texts, text)
+	}
+	model, err := ls.model(args, ls.config.EmbeddingModel)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	vectors, err := ls.Embed(ctx, model, texts)
+	if err != nil {
+		ls.Logger.Error().Err(err).Str("model", model).Msg("embedding failed")
+		return mcp.NewToolResultError(fmt.Sprintf("embedding failed: %s", err.Error())), nil
+	}
+	data, err := json.Marshal(map[string]any{"model": model, "dimensions": len(vectors[0]), "embeddings": vectors})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (ls *LLMServer) handleModels(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	models, err := ls.backend.Models(ctx)
+	if err != nil {
+		ls.Logger.Error().Err(err).Msg("failed to list models")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list models: %s", err.Error())), nil
+	}
+	allowed := make([]Model, 0, len(models))
+	for _, m := range models {
+		if ls.config.modelAllowed(m.Name) {
+			allowed = append(allowed, m)
+		}
+	}
+	if len(allowed) == 0 {
+		return mcp.NewToolResultText("No models available"), nil
+	}
+	data, err := json.MarshalIndent(allowed, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// Config returns the configuration of the service as a string. The API key is left out.
+func (ls *LLMServer) Config() string {
+	cfg := *ls.config
+	cfg.APIKey = ""
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		ls.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (ls *LLMServer) Name() comm.MoLingServerType {
+	return LLMServerName
+}
+
+func (ls *LLMServer) Close() error {
+	ls.Logger.Debug().Msg("LLMServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ls *LLMServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ls.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ls.config.Check()
+}
@@ -0,0 +1,311 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxResponseSize is the maximum size of a backend response.
+const maxResponseSize = 64 * 1024 * 1024
+
+// Message is a chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// GenerateRequest is a request for a completion.
+type GenerateRequest struct {
+	Model       string
+	Messages    []Message
+	MaxTokens   int
+	Temperature *float64
+	JSON        bool // ask for a JSON object
+}
+
+// Generation is the answer of a model.
+type Generation struct {
+	Model            string `json:"model"`
+	Text             string `json:"text"`
+	FinishReason     string `json:"finish_reason,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+}
+
+// Model is a model provided by the backend.
+type Model struct {
+	Name          string `json:"name"`
+	Size          int64  `json:"size,omitempty"`
+	Family        string `json:"family,omitempty"`
+	ParameterSize string `json:"parameter_size,omitempty"`
+	Quantization  string `json:"quantization,omitempty"`
+}
+
+// Backend is a language model server.
+type Backend interface {
+	Generate(ctx context.Context, req GenerateRequest) (*Generation, error)
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+	Models(ctx context.Context) ([]Model, error)
+}
+
+// NewBackend creates the backend selected by the configuration.
+func NewBackend(cfg *LLMConfig, client *http.Client) Backend {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.API == APIOpenAI {
+		return &openAI{client: client, endpoint: strings.TrimSuffix(endpoint, "/v1") + "/v1", apiKey: cfg.APIKey}
+	}
+	return &ollama{client: client, endpoint: endpoint}
+}
+
+// ollama uses the native Ollama API.
+type ollama struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (o *ollama) Generate(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	options := map[string]any{"num_predict": req.MaxTokens}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	body := map[string]any{"model": req.Model, "messages": req.Messages, "stream": false, "options": options}
+	if req.JSON {
+		body["format"] = "json"
+	}
+	var resp struct {
+		Model   string `json:"model"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		DoneReason      string `json:"done_reason"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+	err := doJSON(ctx, o.client, http.MethodPost, o.endpoint+"/api/chat", "", body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Generation{
+		Model:            resp.Model,
+		Text:             resp.Message.Content,
+		FinishReason:     resp.DoneReason,
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+	}, nil
+}
+
+func (o *ollama) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	err := doJSON(ctx, o.client, http.MethodPost, o.endpoint+"/api/embed", "", map[string]any{"model": model, "input": texts}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("backend returned %d vectors for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
+
+func (o *ollama) Models(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Models []struct {
+			Name    string `json:"name"`
+			Size    int64  `json:"size"`
+			Details struct {
+				Family            string `json:"family"`
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	err := doJSON(ctx, o.client, http.MethodGet, o.endpoint+"/api/tags", "", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(resp.Models))
+	for _, m := range resp.Models {
+		models = append(models, Model{
+			Name:          m.Name,
+			Size:          m.Size,
+			Family:        m.Details.Family,
+			ParameterSize: m.Details.ParameterSize,
+			Quantization:  m.Details.QuantizationLevel,
+		})
+	}
+	return models, nil
+}
+
+// openAI uses an OpenAI-compatible API.
+type openAI struct {
+	client   *http.Client
+	endpoint string // ends with /v1
+	apiKey   string
+}
+
+func (o *openAI) Generate(ctx context.Context, req GenerateRequest) (*Generation, error) {
+	body := map[string]any{"model": req.Model, "messages": req.Messages, "max_tokens": req.MaxTokens}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.JSON {
+		body["response_format"] = map[string]any{"type": "json_object"}
+	}
+	var resp struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	err := doJSON(ctx, o.client, http.MethodPost, o.endpoint+"/chat/completions", o.apiKey, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("backend returned no choices")
+	}
+	return &Generation{
+		Model:            resp.Model,
+		Text:             resp.Choices[0].Message.Content,
+		FinishReason:     resp.Choices[0].FinishReason,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
+
+func (o *openAI) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	err := doJSON(ctx, o.client, http.MethodPost, o.endpoint+"/embeddings", o.apiKey, map[string]any{"model": model, "input": texts}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("backend returned %d vectors for %d texts", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("backend returned an invalid index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+func (o *openAI) Models(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := doJSON(ctx, o.client, http.MethodGet, o.endpoint+"/models", o.apiKey, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]Model, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, Model{Name: m.ID})
+	}
+	return models, nil
+}
+
+// doJSON sends a request with an optional JSON body and decodes the JSON response into out.
+func doJSON(ctx context.Context, client *http.Client, method, rawURL, apiKey string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backend returned %s: %s", resp.Status, errorMessage(data))
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage extracts the error of an Ollama or OpenAI error response.
+func errorMessage(data []byte) string {
+	var e struct {
+		Error any `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil {
+		switch v := e.Error.(type) {
+		case string:
+			return v
+		case map[string]any:
+			if msg, ok := v["message"].(string); ok {
+				return msg
+			}
+		}
+	}
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 500 {
+		msg = msg[:500] + "..."
+	}
+	return msg
+}
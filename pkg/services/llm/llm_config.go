@@ -0,0 +1,110 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const LLMPromptDefault = `
+You are an assistant with access to local language models. Your capabilities include:
+
+1. **Generate**: Use llm_generate to have a local model summarize, classify, extract or rewrite text, e.g. to condense a long document before you work with it. Set format to json to get a JSON object back.
+
+2. **Embed**: Use llm_embed to turn texts into embedding vectors, e.g. to compare them or to store them.
+
+3. **Models**: Use llm_models to list the models the local server provides.
+
+Local models are smaller than you; give them short, explicit instructions and check their answers. The default model is %s.
+`
+
+// LLM backend protocols.
+const (
+	APIOllama = "ollama" // {endpoint}/api/chat, /api/embed and /api/tags
+	APIOpenAI = "openai" // {endpoint}/v1/chat/completions, /v1/embeddings and /v1/models
+)
+
+// LLMConfig represents the configuration for the LLM service.
+type LLMConfig struct {
+	PromptFile     string `json:"prompt_file"` // PromptFile is the prompt file for the LLM service.
+	prompt         string
+	API            string   `json:"api"`              // API is the backend protocol, "ollama" or "openai", which covers llama.cpp, vLLM, LM Studio and similar servers.
+	Endpoint       string   `json:"endpoint"`         // Endpoint is the base URL of the backend.
+	APIKey         string   `json:"api_key"`          // APIKey is sent as a bearer token to OpenAI-compatible endpoints.
+	DefaultModel   string   `json:"default_model"`    // DefaultModel is the model llm_generate uses when none is given.
+	EmbeddingModel string   `json:"embedding_model"`  // EmbeddingModel is the model llm_embed uses when none is given.
+	AllowedModels  []string `json:"allowed_models"`   // AllowedModels are the models that may be used, empty allows all. A name without a tag covers all its tags.
+	MaxTokens      int      `json:"max_tokens"`       // MaxTokens is the maximum number of tokens llm_generate may ask for.
+	MaxInputSize   int      `json:"max_input_size"`   // MaxInputSize is the maximum number of characters sent per call.
+	MaxEmbedInputs int      `json:"max_embed_inputs"` // MaxEmbedInputs is the maximum number of texts per llm_embed call.
+	Timeout        int      `json:"timeout"`          // Timeout is the timeout of a single request. time.Second
+}
+
+// NewLLMConfig creates a new LLMConfig with default values.
+func NewLLMConfig() *LLMConfig {
+	return &LLMConfig{
+		prompt:         LLMPromptDefault,
+		API:            APIOllama,
+		Endpoint:       "http://127.0.0.1:11434",
+		DefaultModel:   "llama3.2",
+		EmbeddingModel: "nomic-embed-text",
+		AllowedModels:  []string{},
+		MaxTokens:      4096,
+		MaxInputSize:   200000,
+		MaxEmbedInputs: 64,
+		Timeout:        300,
+	}
+}
+
+// Check validates the LLM configuration.
+func (cfg *LLMConfig) Check() error {
+	cfg.prompt = LLMPromptDefault
+	if cfg.API != APIOllama && cfg.API != APIOpenAI {
+		return fmt.Errorf("api must be %q or %q, got %q", APIOllama, APIOpenAI, cfg.API)
+	}
+	if cfg.Endpoint == "" || cfg.DefaultModel == "" || cfg.EmbeddingModel == "" {
+		return fmt.Errorf("endpoint, default_model and embedding_model must not be empty")
+	}
+	if cfg.MaxTokens <= 0 || cfg.MaxInputSize <= 0 || cfg.MaxEmbedInputs <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_tokens, max_input_size, max_embed_inputs and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// modelAllowed reports whether model may be used. A name without a tag, such as llama3.2,
+// covers all its tags, such as llama3.2:latest and llama3.2:1b.
+func (cfg *LLMConfig) modelAllowed(model string) bool {
+	if len(cfg.AllowedModels) == 0 {
+		return true
+	}
+	name, _, _ := strings.Cut(model, ":")
+	for _, allowed := range cfg.AllowedModels {
+		if allowed == model || (!strings.Contains(allowed, ":") && allowed == name) {
+			return true
+		}
+	}
+	return false
+}
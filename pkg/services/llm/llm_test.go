@@ -0,0 +1,170 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func TestLLMOllama(t *testing.T) {
+	var chat map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			_ = json.NewDecoder(r.Body).Decode(&chat)
+			_, _ = w.Write([]byte(`{"model":"qwen3:8b","message":{"role":"assistant","content":"<think>hmm</think>\n\nA short summary."},"done_reason":"length","prompt_eval_count":12,"eval_count":5}`))
+		case "/api/embed":
+			var body struct {
+				Input []string `json:"input"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			vectors := make([][]float32, len(body.Input))
+			for i := range vectors {
+				vectors[i] = []float32{float32(i), 0.5}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"embeddings": vectors})
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"qwen3:8b","size":5000,"details":{"family":"qwen3","parameter_size":"8.2B","quantization_level":"Q4_K_M"}},{"name":"mistral:7b"},{"name":"nomic-embed-text:latest"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+		}
+	}))
+	defer ts.Close()
+
+	ls := testkit.NewServiceAs[*LLMServer](t, NewLLMServer, map[string]any{
+		"endpoint":       ts.URL + "/",
+		"default_model":  "qwen3:8b",
+		"allowed_models": []any{"qwen3", "nomic-embed-text"},
+	})
+
+	text, isErr := testkit.CallHandler(t, ls.handleGenerate, map[string]any{"prompt": "Summarize: ...", "system": "Be brief", "max_tokens": float64(5), "temperature": float64(0), "format": "json"})
+	if isErr || !strings.HasPrefix(text, "A short summary.") || !strings.Contains(text, "cut off after 5 tokens") {
+		t.Fatalf("llm_generate failed: %s", text)
+	}
+	messages, _ := chat["messages"].([]any)
+	options, _ := chat["options"].(map[string]any)
+	if len(messages) != 2 || chat["stream"] != false || chat["format"] != "json" || options["num_predict"] != float64(5) || options["temperature"] != float64(0) {
+		t.Fatalf("unexpected chat request: %v", chat)
+	}
+
+	text, isErr = testkit.CallHandler(t, ls.handleGenerate, map[string]any{"prompt": "hi", "model": "mistral:7b"})
+	if !isErr || !strings.Contains(text, "not allowed") {
+		t.Fatalf("expected a model error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleGenerate, map[string]any{"prompt": "hi", "max_tokens": float64(100000)})
+	if !isErr {
+		t.Fatalf("expected a max_tokens error, got: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ls.handleEmbed, map[string]any{"texts": []any{"a", "b", "c"}})
+	if isErr {
+		t.Fatalf("llm_embed failed: %s", text)
+	}
+	var res struct {
+		Model      string      `json:"model"`
+		Dimensions int         `json:"dimensions"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if res.Model != "nomic-embed-text" || res.Dimensions != 2 || len(res.Embeddings) != 3 || res.Embeddings[2][0] != 2 {
+		t.Fatalf("unexpected embeddings: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ls.handleModels, map[string]any{})
+	if isErr || !strings.Contains(text, "Q4_K_M") || strings.Contains(text, "mistral") {
+		t.Fatalf("llm_models failed: %s", text)
+	}
+}
+
+func TestLLMOpenAI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-local" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["max_tokens"] != float64(1024) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"model":"local","choices":[{"message":{"content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`))
+		case "/v1/embeddings":
+			_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[1,1]},{"index":0,"embedding":[0,0]}]}`))
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"local"}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	ls := testkit.NewServiceAs[*LLMServer](t, NewLLMServer, map[string]any{"api": APIOpenAI, "endpoint": ts.URL + "/v1", "api_key": "sk-local"})
+	text, isErr := testkit.CallHandler(t, ls.handleGenerate, map[string]any{"prompt": "hi"})
+	if isErr || text != "hello" {
+		t.Fatalf("llm_generate failed: %s", text)
+	}
+	vectors, err := ls.Embed(context.Background(), "e5", []string{"a", "b"})
+	if err != nil || vectors[0][0] != 0 || vectors[1][0] != 1 {
+		t.Fatalf("Embed returned %v, %v", vectors, err)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleModels, map[string]any{})
+	if isErr || !strings.Contains(text, `"local"`) {
+		t.Fatalf("llm_models failed: %s", text)
+	}
+	if strings.Contains(ls.Config(), "sk-local") {
+		t.Fatalf("config leaks the API key: %s", ls.Config())
+	}
+
+	bad := testkit.NewServiceAs[*LLMServer](t, NewLLMServer, map[string]any{"api": APIOpenAI, "endpoint": ts.URL, "api_key": "wrong"})
+	text, isErr = testkit.CallHandler(t, bad.handleGenerate, map[string]any{"prompt": "hi"})
+	if !isErr || !strings.Contains(text, "invalid api key") {
+		t.Fatalf("expected the backend error, got: %s", text)
+	}
+}
+
+func TestLLMLimits(t *testing.T) {
+	ls := testkit.NewServiceAs[*LLMServer](t, NewLLMServer, map[string]any{"endpoint": "http://127.0.0.1:1", "max_input_size": float64(10), "max_embed_inputs": float64(2)})
+	text, isErr := testkit.CallHandler(t, ls.handleGenerate, map[string]any{"prompt": strings.Repeat("x", 11)})
+	if !isErr || !strings.Contains(text, "limit is 10") {
+		t.Fatalf("expected an input size error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleEmbed, map[string]any{"texts": []any{"a", "b", "c"}})
+	if !isErr || !strings.Contains(text, "limit is 2") {
+		t.Fatalf("expected an input count error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleEmbed, map[string]any{"texts": []any{"a", 1}})
+	if !isErr {
+		t.Fatalf("expected an invalid texts error, got: %s", text)
+	}
+	cfg := NewLLMConfig()
+	cfg.API = "llamafile"
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected an error for an unknown api")
+	}
+}
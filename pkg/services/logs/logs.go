@@ -0,0 +1,682 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package logs provides log parsing, filtering and triage tools for the MoLing application.
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	LogServerName comm.MoLingServerType = "Log"
+)
+
+const (
+	// sampleSize is the number of bytes read to detect the format of a file.
+	sampleSize = 64 * 1024
+	// maxFollowBytes is the maximum number of bytes log_follow reads per call.
+	maxFollowBytes = 1024 * 1024
+	// followTail is the number of bytes from the end of the file the first log_follow call reads.
+	followTail = 64 * 1024
+)
+
+// LogServer implements the Service interface and provides log triage tools.
+type LogServer struct {
+	abstract.MLService
+	config *LogConfig
+}
+
+// NewLogServer creates a new LogServer instance.
+func NewLogServer(ctx context.Context) (abstract.Service, error) {
+	lc := NewLogConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("LogServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("LogServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(LogServerName))
+	})
+
+	ls := &LogServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    lc,
+	}
+	err := ls.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+// Init registers the prompt and tools of the log service.
+func (ls *LogServer) Init() error {
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "log_prompt",
+			Description: "Get the relevant functions and prompts of the Log MCP Server",
+		},
+		HandlerFunc: ls.handlePrompt,
+	}
+	ls.AddPrompt(pe)
+
+	filterOpts := []mcp.ToolOption{
+		mcp.WithString("path",
+			mcp.Description("Path of the log file, .gz files are decompressed"),
+			mcp.Required(),
+		),
+		mcp.WithString("format",
+			mcp.Description("Log format, default: auto. access covers the nginx and apache common and combined formats"),
+			mcp.Enum(FormatAuto, FormatJSON, FormatSyslog, FormatAccess, FormatNginxError, FormatApacheError, FormatPlain),
+		),
+		mcp.WithString("level",
+			mcp.Description("Minimum level of the entries"),
+			mcp.Enum(levels...),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Regular expression (RE2 syntax) the entry must match, use (?i) to ignore case"),
+		),
+	}
+	timeOpts := []mcp.ToolOption{
+		mcp.WithString("since",
+			mcp.Description("Start of the time range: an RFC 3339 timestamp, a date and time such as 2024-05-01 12:00, or a duration before now such as 15m, 2h or 7d"),
+		),
+		mcp.WithString("until",
+			mcp.Description("End of the time range, in the same forms as since"),
+		),
+	}
+
+	ls.AddTool(mcp.NewTool(
+		"log_query",
+		append(append([]mcp.ToolOption{
+			mcp.WithDescription("Read the entries of a log file that match a time range, minimum level and pattern. Multi-line entries such as stack traces are kept together"),
+		}, append(filterOpts, timeOpts...)...),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Maximum number of entries, default: 50, maximum: %d", ls.config.MaxEntries)),
+			),
+			mcp.WithString("from",
+				mcp.Description("Return the first or the last matching entries, default: end"),
+				mcp.Enum("start", "end"),
+			),
+		)...,
+	), ls.handleQuery)
+
+	ls.AddTool(mcp.NewTool(
+		"log_stats",
+		append(append([]mcp.ToolOption{
+			mcp.WithDescription("Count the entries of a log file per level and over time, and group errors by signature, where IDs, numbers and addresses are masked"),
+		}, append(filterOpts, timeOpts...)...),
+			mcp.WithString("interval",
+				mcp.Description("Histogram interval such as 1m, 5m or 1h, default: picked for about 60 buckets"),
+			),
+			mcp.WithNumber("top",
+				mcp.Description("Number of signatures, default: 10, maximum: 100. Signatures are built from error and fatal entries, or from the entries of the minimum level if one is given"),
+			),
+		)...,
+	), ls.handleStats)
+
+	ls.AddTool(mcp.NewTool(
+		"log_follow",
+		append(append([]mcp.ToolOption{
+			mcp.WithDescription("Follow a log file. Without a cursor the latest entries are returned; with the cursor of the previous call, the entries written since, waiting for new ones if there are none yet"),
+		}, filterOpts...),
+			mcp.WithNumber("cursor",
+				mcp.Description("Cursor returned by the previous call"),
+			),
+			mcp.WithNumber("wait",
+				mcp.Description(fmt.Sprintf("Seconds to wait for new entries, default: 5, maximum: %d", ls.config.MaxFollowWait)),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Maximum number of entries, default: 50, maximum: %d", ls.config.MaxEntries)),
+			),
+		)...,
+	), ls.handleFollow)
+
+	ls.AddTool(mcp.NewTool(
+		"log_summarize",
+		append(append([]mcp.ToolOption{
+			mcp.WithDescription("Summarize a log file with the model of the client: the statistics and latest matching entries are sent to the client, which must support sampling"),
+		}, append(filterOpts, timeOpts...)...),
+			mcp.WithString("focus",
+				mcp.Description("Question the summary should answer, such as why requests to /api failed"),
+			),
+		)...,
+	), ls.handleSummarize)
+	return nil
+}
+
+func (ls *LogServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ls.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ls.config.AllowedDirs[0])
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// filter selects entries by time range, level and pattern.
+type filter struct {
+	format  string
+	since   time.Time
+	until   time.Time
+	minRank int // -1 for any level
+	pattern *regexp.Regexp
+}
+
+func (f *filter) match(e *Entry) bool {
+	if (!f.since.IsZero() || !f.until.IsZero()) && e.Time.IsZero() {
+		return false
+	}
+	if !f.since.IsZero() && e.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && e.Time.After(f.until) {
+		return false
+	}
+	if f.minRank >= 0 && levelRank(e.Level) < f.minRank {
+		return false
+	}
+	return f.pattern == nil || f.pattern.MatchString(e.raw)
+}
+
+// parseWhen parses a point in time: a timestamp, or a duration before now such as 15m or 7d.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if s == "now" {
+		return now, nil
+	}
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if len(s) == len("2006-01-02") {
+		if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if len(s) == len("2006-01-02 15:04") {
+		if t, err := time.ParseInLocation("2006-01-02 15:04", strings.Replace(s, "T", " ", 1), time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, ok := parseTime(s); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use an RFC 3339 timestamp or a duration such as 15m", s)
+}
+
+// filter reads the filter arguments.
+func (ls *LogServer) filter(args map[string]any, withTime bool) (*filter, error) {
+	f := &filter{minRank: -1}
+	f.format = abstract.GetString(args, "format", "")
+	if f.format == "" {
+		f.format = FormatAuto
+	}
+	if _, ok := parsers[f.format]; !ok && f.format != FormatAuto {
+		return nil, fmt.Errorf("unknown format %q", f.format)
+	}
+	if level := abstract.GetString(args, "level", ""); level != "" {
+		l := normalizeLevel(level)
+		if l == "" {
+			return nil, fmt.Errorf("unknown level %q, use one of %s", level, strings.Join(levels, ", "))
+		}
+		f.minRank = levelRank(l)
+	}
+	if pattern := abstract.GetString(args, "pattern", ""); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		f.pattern = re
+	}
+	if !withTime {
+		return f, nil
+	}
+	now := time.Now()
+	var err error
+	since := abstract.GetString(args, "since", "")
+	if f.since, err = parseWhen(since, now); err != nil {
+		return nil, err
+	}
+	until := abstract.GetString(args, "until", "")
+	if f.until, err = parseWhen(until, now); err != nil {
+		return nil, err
+	}
+	if !f.since.IsZero() && !f.until.IsZero() && f.until.Before(f.since) {
+		return nil, fmt.Errorf("until must not be before since")
+	}
+	return f, nil
+}
+
+// scanResult describes what a scan read.
+type scanResult struct {
+	File         string `json:"file"`
+	Format       string `json:"format"`
+	Entries      int    `json:"scanned_entries"`
+	Matched      int    `json:"matched"`
+	SkippedBytes int64  `json:"skipped_bytes,omitempty"` // bytes at the start of the file that were not read
+	Truncated    bool   `json:"truncated,omitempty"`     // the end of a compressed file was not read
+}
+
+// scan calls fn for every entry of the file that matches the filter. Files larger than
+// max_scan_bytes are read from the end, compressed files up to max_scan_bytes of content.
+func (ls *LogServer) scan(ctx context.Context, path string, f *filter, fn func(*Entry)) (*scanResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	res := &scanResult{File: path}
+	var r io.Reader = file
+	skipFirst := false
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = io.LimitReader(gz, ls.config.MaxScanBytes)
+	} else if info.Size() > ls.config.MaxScanBytes {
+		res.SkippedBytes = info.Size() - ls.config.MaxScanBytes
+		if _, err := file.Seek(res.SkippedBytes, io.SeekStart); err != nil {
+			return nil, err
+		}
+		skipFirst = true
+	}
+	counter := &countingReader{r: r}
+	br := bufio.NewReaderSize(counter, sampleSize)
+	if skipFirst {
+		// Start at a line boundary.
+		if _, _, err := readLine(br, 0); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	}
+	res.Format = f.format
+	if res.Format == FormatAuto {
+		sample, _ := br.Peek(sampleSize)
+		res.Format = detectFormat(sample)
+	}
+	er := newEntryReader(br, res.Format, ls.config.MaxLineLength)
+	for {
+		e, err := er.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Entries++
+		if res.Entries%10000 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if f.match(e) {
+			res.Matched++
+			fn(e)
+		}
+	}
+	if strings.HasSuffix(path, ".gz") && counter.n >= ls.config.MaxScanBytes {
+		res.Truncated = true
+	}
+	return res, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (ls *LogServer) limit(args map[string]any) (int, error) {
+	limit := abstract.GetInt(args, "limit", min(50, ls.config.MaxEntries))
+	if limit < 1 || limit > ls.config.MaxEntries {
+		return 0, fmt.Errorf("limit must be between 1 and %d", ls.config.MaxEntries)
+	}
+	return limit, nil
+}
+
+func (ls *LogServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	path, err := utils.ResolvePath(p, ls.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	f, err := ls.filter(args, true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	limit, err := ls.limit(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fromStart := false
+	switch from := abstract.GetString(args, "from", ""); from {
+	case "", "end":
+	case "start":
+		fromStart = true
+	default:
+		return mcp.NewToolResultError("from must be start or end"), nil
+	}
+
+	// From the end, the last entries are kept in a ring.
+	entries := make([]*Entry, 0, limit)
+	next := 0
+	res, err := ls.scan(ctx, path, f, func(e *Entry) {
+		switch {
+		case len(entries) < limit:
+			entries = append(entries, e)
+		case !fromStart:
+			entries[next] = e
+			next = (next + 1) % limit
+		}
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", p, err.Error())), nil
+	}
+	entries = append(entries[next:], entries[:next]...)
+	return abstract.NewJSONResult(struct {
+		*scanResult
+		Returned int      `json:"returned"`
+		Entries  []*Entry `json:"entries"`
+	}{res, len(entries), entries}), nil
+}
+
+func (ls *LogServer) handleStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	path, err := utils.ResolvePath(p, ls.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	f, err := ls.filter(args, true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var interval time.Duration
+	if i := abstract.GetString(args, "interval", ""); i != "" {
+		interval, err = time.ParseDuration(i)
+		if err != nil || interval < time.Second {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid interval %q, use a duration of at least 1s such as 5m", i)), nil
+		}
+	}
+	top := abstract.GetInt(args, "top", 10)
+	if top < 0 || top > 100 {
+		return mcp.NewToolResultError("top must be between 0 and 100"), nil
+	}
+
+	minRank := levelRank("error")
+	if f.minRank >= 0 {
+		minRank = f.minRank
+	}
+	st := newStats(minRank)
+	res, err := ls.scan(ctx, path, f, st.add)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", p, err.Error())), nil
+	}
+	interval, buckets, err := st.histogram(interval)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	out := struct {
+		*scanResult
+		First      string         `json:"first,omitempty"`
+		Last       string         `json:"last,omitempty"`
+		Levels     map[string]int `json:"levels"`
+		Untimed    int            `json:"untimed_entries,omitempty"`
+		Interval   string         `json:"interval,omitempty"`
+		Histogram  []Bucket       `json:"histogram,omitempty"`
+		Signatures []*Signature   `json:"top_signatures"`
+	}{scanResult: res, Levels: st.levels, Untimed: st.untimed, Histogram: buckets, Signatures: st.top(top)}
+	if len(buckets) > 0 {
+		out.First = st.first.Format(time.RFC3339Nano)
+		out.Last = st.last.Format(time.RFC3339Nano)
+		out.Interval = interval.String()
+	}
+	return abstract.NewJSONResult(out), nil
+}
+
+func (ls *LogServer) handleFollow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	path, err := utils.ResolvePath(p, ls.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if strings.HasSuffix(path, ".gz") {
+		return mcp.NewToolResultError("compressed files cannot be followed"), nil
+	}
+	f, err := ls.filter(args, false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	limit, err := ls.limit(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	wait := min(max(abstract.GetFloat(args, "wait", 5), 0), float64(ls.config.MaxFollowWait))
+	cursor := int64(-1)
+	if abstract.Has(args, "cursor") {
+		c := abstract.GetFloat(args, "cursor", -1)
+		if c < 0 {
+			return mcp.NewToolResultError("cursor must not be negative"), nil
+		}
+		cursor = int64(c)
+	}
+
+	res, err := ls.follow(ctx, path, f, cursor, limit, time.Duration(wait*float64(time.Second)))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to follow %s: %s", p, err.Error())), nil
+	}
+	return abstract.NewJSONResult(res), nil
+}
+
+// followResult is the result of log_follow.
+type followResult struct {
+	File    string   `json:"file"`
+	Format  string   `json:"format"`
+	Cursor  int64    `json:"cursor"`
+	Rotated bool     `json:"rotated,omitempty"` // the file is shorter than the cursor, it was truncated or rotated
+	More    bool     `json:"more,omitempty"`    // there is more to read, call again right away
+	Entries []*Entry `json:"entries"`
+}
+
+// follow reads the complete lines written after cursor, waiting up to wait for matching
+// entries. A cursor of -1 reads the tail of the file.
+func (ls *LogServer) follow(ctx context.Context, path string, f *filter, cursor int64, limit int, wait time.Duration) (*followResult, error) {
+	res := &followResult{File: path, Format: f.format, Entries: []*Entry{}}
+	deadline := time.Now().Add(wait)
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		size := info.Size()
+		if cursor >= 0 && size < cursor {
+			res.Rotated = true
+			cursor = 0
+		}
+		if cursor < 0 || size > cursor {
+			tail := cursor < 0
+			start := cursor
+			if tail {
+				start = max(0, size-followTail)
+			}
+			err = ls.readFrom(ctx, res, path, f, start, tail, size, limit)
+			if err != nil {
+				return nil, err
+			}
+			if tail || len(res.Entries) > 0 || res.More {
+				return res, nil
+			}
+			cursor = res.Cursor
+		}
+		res.Cursor = cursor
+		if !time.Now().Before(deadline) {
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(250*time.Millisecond, time.Until(deadline))):
+		}
+	}
+}
+
+// readFrom reads the complete lines from start, up to maxFollowBytes. For the tail of the file,
+// the last entries are returned, otherwise the first ones and the cursor is put after them.
+func (ls *LogServer) readFrom(ctx context.Context, res *followResult, path string, f *filter, start int64, tail bool, size int64, limit int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data := make([]byte, min(size-start, maxFollowBytes))
+	n, err := file.ReadAt(data, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	data = data[:n]
+	if tail && start > 0 {
+		// Start at a line boundary.
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data, start = data[i+1:], start+int64(i+1)
+		} else {
+			data, start = nil, start+int64(len(data))
+		}
+	}
+	// A line that is still being written is left for the next call, unless it fills the buffer.
+	if last := bytes.LastIndexByte(data, '\n'); last >= 0 {
+		data = data[:last+1]
+	} else if n < maxFollowBytes {
+		data = nil
+	}
+	res.Cursor = start + int64(len(data))
+	res.More = n == maxFollowBytes && res.Cursor < size
+
+	res.Format = f.format
+	if res.Format == FormatAuto {
+		res.Format = detectFormat(data[:min(len(data), sampleSize)])
+	}
+	er := newEntryReader(bufio.NewReader(bytes.NewReader(data)), res.Format, ls.config.MaxLineLength)
+	for {
+		e, err := er.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !f.match(e) {
+			continue
+		}
+		if !tail && len(res.Entries) == limit {
+			// Continue with this entry on the next call.
+			res.Cursor = start + e.offset
+			res.More = true
+			break
+		}
+		res.Entries = append(res.Entries, e)
+	}
+	if len(res.Entries) > limit {
+		res.Entries = res.Entries[len(res.Entries)-limit:]
+	}
+	return nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ls *LogServer) Config() string {
+	data, err := json.Marshal(ls.config)
+	if err != nil {
+		ls.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (ls *LogServer) Name() comm.MoLingServerType {
+	return LogServerName
+}
+
+func (ls *LogServer) Close() error {
+	ls.Logger.Debug().Msg("LogServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ls *LogServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ls.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ls.config.Check()
+}
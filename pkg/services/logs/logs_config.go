@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const LogPromptDefault = `
+You are an assistant that can triage log files. Your capabilities include:
+
+1. **Query**: Use log_query to read the entries of a log file, filtered by time range (since, until), minimum level and a regular expression. JSON lines, syslog, nginx and apache access and error logs and plain timestamped logs are detected automatically.
+
+2. **Statistics**: Use log_stats to get the number of entries per level, a histogram over time and the most frequent error signatures, where IDs, numbers and addresses are masked so that the same error is counted once.
+
+3. **Follow**: Use log_follow to watch a file for new entries. The first call returns the latest entries and a cursor; pass the cursor to the next call to get only what was written since.
+
+4. **Summarize**: Use log_summarize to have your client's model summarize the statistics and latest entries of a file, optionally focused on a question. It needs a client that supports sampling.
+
+Start with log_stats to find when and what went wrong, then use log_query on the time range of interest. Relative paths are resolved in %s.
+`
+
+// LogConfig represents the configuration for the log service.
+type LogConfig struct {
+	PromptFile    string `json:"prompt_file"` // PromptFile is the prompt file for the log service.
+	prompt        string
+	AllowedDirs   []string `json:"allowed_dirs"`    // AllowedDirs are the directories log files may be read from, relative paths use the first one.
+	MaxScanBytes  int64    `json:"max_scan_bytes"`  // MaxScanBytes is the maximum number of bytes read per call, larger files are read from the end.
+	MaxEntries    int      `json:"max_entries"`     // MaxEntries is the maximum number of entries a call returns.
+	MaxLineLength int      `json:"max_line_length"` // MaxLineLength is the length after which lines are cut, in bytes.
+	MaxFollowWait int      `json:"max_follow_wait"` // MaxFollowWait is the maximum time log_follow waits for new entries. time.Second
+}
+
+// NewLogConfig creates a new LogConfig with default values.
+func NewLogConfig() *LogConfig {
+	return &LogConfig{
+		prompt:        LogPromptDefault,
+		AllowedDirs:   []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		MaxScanBytes:  64 * 1024 * 1024,
+		MaxEntries:    500,
+		MaxLineLength: 4096,
+		MaxFollowWait: 30,
+	}
+}
+
+// Check validates the log configuration.
+func (cfg *LogConfig) Check() error {
+	cfg.prompt = LogPromptDefault
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	if cfg.MaxScanBytes <= 0 || cfg.MaxEntries <= 0 || cfg.MaxLineLength <= 0 || cfg.MaxFollowWait <= 0 {
+		return fmt.Errorf("max_scan_bytes, max_entries, max_line_length and max_follow_wait must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
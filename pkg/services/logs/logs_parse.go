@@ -0,0 +1,523 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log formats.
+const (
+	FormatAuto        = "auto"
+	FormatJSON        = "json"
+	FormatSyslog      = "syslog"
+	FormatAccess      = "access"
+	FormatNginxError  = "nginx_error"
+	FormatApacheError = "apache_error"
+	FormatPlain       = "plain"
+)
+
+// Levels, from the lowest to the highest. Entries without a known level rank as info.
+var levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// Entry is a parsed log entry. Continuation lines, such as stack traces, are part of the entry
+// they follow.
+type Entry struct {
+	Line      int            `json:"line"`
+	Time      time.Time      `json:"-"`
+	Timestamp string         `json:"time,omitempty"`
+	Level     string         `json:"level,omitempty"`
+	Source    string         `json:"source,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+
+	raw    string // the lines of the entry, which patterns are matched against
+	key    string // the signature of the entry, empty uses the message
+	offset int64  // the position of the entry in the input
+}
+
+type parseFunc func(line string) (*Entry, bool)
+
+var parsers = map[string]parseFunc{
+	FormatJSON:        parseJSON,
+	FormatSyslog:      parseSyslog,
+	FormatAccess:      parseAccess,
+	FormatNginxError:  parseNginxError,
+	FormatApacheError: parseApacheError,
+	FormatPlain:       parsePlain,
+}
+
+// detectOrder is the order in which formats are tried, the more specific ones first.
+var detectOrder = []string{FormatJSON, FormatAccess, FormatNginxError, FormatApacheError, FormatSyslog}
+
+// detectFormat returns the format that parses most of the sample lines, or plain.
+func detectFormat(sample []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(sample), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" && line[0] != ' ' && line[0] != '\t' {
+			lines = append(lines, line)
+		}
+		if len(lines) == 20 {
+			break
+		}
+	}
+	best, bestCount := FormatPlain, 0
+	for _, format := range detectOrder {
+		count := 0
+		for _, line := range lines {
+			if _, ok := parsers[format](line); ok {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = format, count
+		}
+	}
+	if bestCount*2 < len(lines) {
+		return FormatPlain
+	}
+	return best
+}
+
+func levelRank(level string) int {
+	for i, l := range levels {
+		if l == level {
+			return i
+		}
+	}
+	return 2
+}
+
+// normalizeLevel maps the level names of common loggers to one of levels, or "" if unknown.
+func normalizeLevel(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "trace", "finest", "finer":
+		return "trace"
+	case "debug", "dbug", "dbg", "fine", "verbose", "d":
+		return "debug"
+	case "info", "information", "informational", "notice", "inf", "i":
+		return "info"
+	case "warn", "warning", "wrn", "w":
+		return "warn"
+	case "error", "err", "eror", "e", "severe":
+		return "error"
+	case "fatal", "crit", "critical", "alert", "emerg", "emergency", "panic", "dpanic", "f":
+		return "fatal"
+	}
+	if strings.HasPrefix(s, "trace") {
+		// apache trace1 to trace8
+		return "trace"
+	}
+	return ""
+}
+
+// syslogLevel maps a syslog severity to a level.
+func syslogLevel(severity int) string {
+	switch {
+	case severity <= 2:
+		return "fatal"
+	case severity == 3:
+		return "error"
+	case severity == 4:
+		return "warn"
+	case severity <= 6:
+		return "info"
+	}
+	return "debug"
+}
+
+var (
+	plainTime  = regexp.MustCompile(`^\[?(\d{4}[-/]\d\d[-/]\d\d[T ]\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:?\d\d)?)\]?\s*(.*)$`)
+	plainLevel = regexp.MustCompile(`(?i)^[\[(<]?(TRACE|DEBUG|DBG|INFO|NOTICE|WARN|WARNING|ERROR|ERR|FATAL|CRIT|CRITICAL|PANIC|SEVERE)[\])>]?:?(?:\s+|$)`)
+	anyLevel   = regexp.MustCompile(`(?i)\b(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|CRITICAL|PANIC|SEVERE)\b`)
+)
+
+// parseTime parses the timestamps of common loggers. Timestamps without a zone are local time.
+func parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if len(s) < 19 {
+		return time.Time{}, false
+	}
+	b := []byte(s)
+	b[4], b[7], b[10] = '-', '-', ' '
+	s = strings.Replace(string(b), ",", ".", 1)
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999Z0700", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parsePlain parses a line that starts with a timestamp, optionally followed by a level. It
+// returns false for lines without a timestamp, with the whole line as the message.
+func parsePlain(line string) (*Entry, bool) {
+	m := plainTime.FindStringSubmatch(line)
+	if m == nil {
+		e := &Entry{Message: line}
+		if l := anyLevel.FindString(line[:min(len(line), 64)]); l != "" {
+			e.Level = normalizeLevel(l)
+		}
+		return e, false
+	}
+	t, ok := parseTime(m[1])
+	if !ok {
+		return &Entry{Message: line}, false
+	}
+	e := &Entry{Time: t, Message: m[2]}
+	if lm := plainLevel.FindStringSubmatch(m[2]); lm != nil {
+		e.Level = normalizeLevel(lm[1])
+		e.Message = m[2][len(lm[0]):]
+	} else if l := anyLevel.FindString(m[2][:min(len(m[2]), 64)]); l != "" {
+		e.Level = normalizeLevel(l)
+	}
+	return e, true
+}
+
+var (
+	jsonTimeKeys    = []string{"time", "timestamp", "@timestamp", "ts", "t", "date", "datetime"}
+	jsonLevelKeys   = []string{"level", "lvl", "severity", "log.level", "levelname", "loglevel"}
+	jsonMessageKeys = []string{"msg", "message", "@message", "log", "text", "event"}
+)
+
+// parseJSON parses a JSON object as written by zerolog, zap, logrus, bunyan, pino, slog and
+// similar loggers. The remaining keys are kept as fields.
+func parseJSON(line string) (*Entry, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	var m map[string]any
+	if json.Unmarshal([]byte(line), &m) != nil {
+		return nil, false
+	}
+	e := &Entry{}
+	for _, k := range jsonTimeKeys {
+		if t, ok := jsonTime(m[k]); ok {
+			e.Time = t
+			delete(m, k)
+			break
+		}
+	}
+	for _, k := range jsonLevelKeys {
+		if l := jsonLevel(m[k]); l != "" {
+			e.Level = l
+			delete(m, k)
+			break
+		}
+	}
+	for _, k := range jsonMessageKeys {
+		if msg, ok := m[k].(string); ok {
+			e.Message = msg
+			delete(m, k)
+			break
+		}
+	}
+	if e.Message == "" {
+		for _, k := range []string{"error", "err"} {
+			if msg, ok := m[k].(string); ok {
+				e.Message = msg
+				break
+			}
+		}
+	}
+	if len(m) > 0 {
+		e.Fields = m
+	}
+	return e, true
+}
+
+func jsonTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		return parseTime(t)
+	case float64:
+		// Unix time in seconds, milliseconds or nanoseconds.
+		switch {
+		case t > 1e17:
+			return time.Unix(0, int64(t)), true
+		case t > 1e11:
+			return time.UnixMilli(int64(t)), true
+		case t > 0:
+			sec := int64(t)
+			return time.Unix(sec, int64((t-float64(sec))*1e9)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func jsonLevel(v any) string {
+	switch l := v.(type) {
+	case string:
+		return normalizeLevel(l)
+	case float64:
+		// bunyan and pino
+		switch {
+		case l >= 60:
+			return "fatal"
+		case l >= 50:
+			return "error"
+		case l >= 40:
+			return "warn"
+		case l >= 30:
+			return "info"
+		case l >= 20:
+			return "debug"
+		case l >= 10:
+			return "trace"
+		}
+	}
+	return ""
+}
+
+var (
+	// RFC 3164, optionally without the priority as written to /var/log/syslog, and the ISO
+	// timestamp variant of rsyslog and journalctl -o short-iso.
+	syslog3164 = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d|\d{4}-\d\d-\d\dT\S+) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?(.*)$`)
+	syslog5424 = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+) ?(.*)$`)
+)
+
+func parseSyslog(line string) (*Entry, bool) {
+	if m := syslog5424.FindStringSubmatch(line); m != nil {
+		e := &Entry{Source: m[3] + " " + m[4], Message: strings.TrimPrefix(m[8], "\ufeff")}
+		if t, ok := parseTime(m[2]); ok {
+			e.Time = t
+		}
+		pri, _ := strconv.Atoi(m[1])
+		e.Level = syslogLevel(pri % 8)
+		if m[5] != "-" {
+			e.Fields = map[string]any{"pid": m[5]}
+		}
+		return e, true
+	}
+	m := syslog3164.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	e := &Entry{Source: m[3] + " " + m[4], Message: m[6]}
+	if m[2][0] >= '0' && m[2][0] <= '9' {
+		t, ok := parseTime(m[2])
+		if !ok {
+			return nil, false
+		}
+		e.Time = t
+	} else {
+		t, err := time.ParseInLocation("Jan _2 15:04:05", m[2], time.Local)
+		if err != nil {
+			return nil, false
+		}
+		// The timestamp has no year, take the one that is not in the future.
+		now := time.Now()
+		e.Time = t.AddDate(now.Year(), 0, 0)
+		if e.Time.After(now.Add(24 * time.Hour)) {
+			e.Time = e.Time.AddDate(-1, 0, 0)
+		}
+	}
+	if m[1] != "" {
+		pri, _ := strconv.Atoi(m[1])
+		e.Level = syslogLevel(pri % 8)
+	} else if l := anyLevel.FindString(m[6][:min(len(m[6]), 64)]); l != "" {
+		e.Level = normalizeLevel(l)
+	}
+	if m[5] != "" {
+		e.Fields = map[string]any{"pid": m[5]}
+	}
+	return e, true
+}
+
+// accessLine matches the common and combined log formats of nginx and apache.
+var accessLine = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\S+)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+func parseAccess(line string) (*Entry, bool) {
+	m := accessLine.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[3])
+	if err != nil {
+		return nil, false
+	}
+	status, _ := strconv.Atoi(m[5])
+	e := &Entry{Time: t, Source: m[1], Message: m[4] + " " + m[5], Level: "info"}
+	switch {
+	case status >= 500:
+		e.Level = "error"
+	case status >= 400:
+		e.Level = "warn"
+	}
+	e.Fields = map[string]any{"status": status}
+	if m[2] != "-" {
+		e.Fields["user"] = m[2]
+	}
+	if n, err := strconv.Atoi(m[6]); err == nil {
+		e.Fields["bytes"] = n
+	}
+	if m[7] != "" && m[7] != "-" {
+		e.Fields["referer"] = m[7]
+	}
+	if m[8] != "" && m[8] != "-" {
+		e.Fields["user_agent"] = m[8]
+	}
+	// Requests are grouped by status, method and path, without the query.
+	method, target, _ := strings.Cut(m[4], " ")
+	target, _, _ = strings.Cut(target, " ")
+	target, _, _ = strings.Cut(target, "?")
+	e.key = m[5] + " " + method + " " + maskValues(target)
+	return e, true
+}
+
+var nginxError = regexp.MustCompile(`^(\d{4}/\d\d/\d\d \d\d:\d\d:\d\d) \[(\w+)\] (\d+)#\d+: (?:\*\d+ )?(.*)$`)
+
+func parseNginxError(line string) (*Entry, bool) {
+	m := nginxError.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	t, err := time.ParseInLocation("2006/01/02 15:04:05", m[1], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &Entry{Time: t, Level: normalizeLevel(m[2]), Message: m[4], Fields: map[string]any{"pid": m[3]}}, true
+}
+
+var apacheError = regexp.MustCompile(`^\[([A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d(?:\.\d+)? \d{4})\] \[(?:([\w-]+):)?(\w+)\] (?:\[pid (\d+)[^\]]*\] )?(?:\[client ([^\]]+)\] )?(.*)$`)
+
+func parseApacheError(line string) (*Entry, bool) {
+	m := apacheError.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	t, err := time.ParseInLocation("Mon Jan _2 15:04:05.999999999 2006", m[1], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	e := &Entry{Time: t, Level: normalizeLevel(m[3]), Source: m[5], Message: m[6]}
+	if m[2] != "" || m[4] != "" {
+		e.Fields = map[string]any{}
+		if m[2] != "" {
+			e.Fields["module"] = m[2]
+		}
+		if m[4] != "" {
+			e.Fields["pid"] = m[4]
+		}
+	}
+	return e, true
+}
+
+// entryReader reads the entries of a log, joining continuation lines to the entry before them.
+type entryReader struct {
+	r       *bufio.Reader
+	parse   parseFunc
+	maxLine int
+	line    int
+	pos     int64
+	pending *Entry
+}
+
+func newEntryReader(r *bufio.Reader, format string, maxLine int) *entryReader {
+	return &entryReader{r: r, parse: parsers[format], maxLine: maxLine}
+}
+
+// Next returns the next entry, or io.EOF.
+func (er *entryReader) Next() (*Entry, error) {
+	for {
+		text, n, err := readLine(er.r, er.maxLine)
+		if err != nil {
+			if e := er.pending; e != nil && errors.Is(err, io.EOF) {
+				er.pending = nil
+				return finish(e), nil
+			}
+			return nil, err
+		}
+		offset := er.pos
+		er.line++
+		er.pos += int64(n)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		e := er.parseLine(text)
+		if e == nil {
+			p := er.pending
+			if len(p.raw) < 8*er.maxLine {
+				p.raw += "\n" + text
+				p.Message += "\n" + text
+			}
+			continue
+		}
+		e.Line, e.raw, e.offset = er.line, text, offset
+		prev := er.pending
+		er.pending = e
+		if prev != nil {
+			return finish(prev), nil
+		}
+	}
+}
+
+// parseLine parses a line, or returns nil for a continuation of the pending entry: an indented
+// line, or a line without a timestamp after one with a timestamp.
+func (er *entryReader) parseLine(text string) *Entry {
+	if er.pending != nil && (text[0] == ' ' || text[0] == '\t') {
+		return nil
+	}
+	if e, ok := er.parse(text); ok {
+		return e
+	}
+	e, ok := parsePlain(text)
+	if !ok && er.pending != nil && !er.pending.Time.IsZero() {
+		return nil
+	}
+	return e
+}
+
+func finish(e *Entry) *Entry {
+	if !e.Time.IsZero() {
+		e.Timestamp = e.Time.Format(time.RFC3339Nano)
+	}
+	e.Message = strings.ToValidUTF8(e.Message, "\uFFFD")
+	return e
+}
+
+// readLine reads a line without its line break, cutting it after max bytes, and returns the
+// number of bytes it consumed. It returns io.EOF only when there is nothing left to read.
+func readLine(r *bufio.Reader, max int) (string, int, error) {
+	var buf []byte
+	read := 0
+	for {
+		chunk, err := r.ReadSlice('\n')
+		read += len(chunk)
+		if len(buf) < max {
+			buf = append(buf, chunk[:min(len(chunk), max-len(buf))]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && (!errors.Is(err, io.EOF) || read == 0) {
+			return "", read, err
+		}
+		return strings.TrimRight(string(buf), "\r\n"), read, nil
+	}
+}
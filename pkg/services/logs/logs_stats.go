@@ -0,0 +1,203 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxBuckets is the maximum number of histogram buckets.
+const maxBuckets = 1000
+
+// intervals are the histogram intervals that are picked from when none is given.
+var intervals = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+var masks = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]*\d[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b|\b[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\d[0-9a-fA-F]*\b`), "<hex>"},
+	{regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`), "<str>"},
+	{regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ms|s|µs|ns|m|h|b|kb|mb|gb|%)?\b`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// maskValues replaces the parts of a message that differ between occurrences of the same
+// event, such as IDs, numbers, addresses and quoted values.
+func maskValues(s string) string {
+	for _, m := range masks {
+		s = m.re.ReplaceAllString(s, m.repl)
+	}
+	return strings.TrimSpace(s)
+}
+
+// signature returns the key under which an entry is counted, built from the first line of its
+// message.
+func signature(e *Entry) string {
+	if e.key != "" {
+		return e.key
+	}
+	msg, _, _ := strings.Cut(e.Message, "\n")
+	if strings.TrimSpace(msg) == "" {
+		msg, _, _ = strings.Cut(e.raw, "\n")
+	}
+	sig := maskValues(msg)
+	if len(sig) > 200 {
+		sig = strings.ToValidUTF8(sig[:200], "") + "..."
+	}
+	return sig
+}
+
+// Signature is a group of entries with the same signature.
+type Signature struct {
+	Signature string `json:"signature"`
+	Count     int    `json:"count"`
+	Level     string `json:"level,omitempty"`
+	First     string `json:"first,omitempty"`
+	Last      string `json:"last,omitempty"`
+	Example   string `json:"example"`
+}
+
+// Bucket is a histogram bucket.
+type Bucket struct {
+	Start  string         `json:"start"`
+	Total  int            `json:"total"`
+	Levels map[string]int `json:"levels,omitempty"`
+}
+
+// stats collects the statistics of log_stats.
+type stats struct {
+	minRank    int // the minimum level of the entries counted in signatures
+	levels     map[string]int
+	signatures map[string]*Signature
+	times      []time.Time
+	timeLevels []string
+	first      time.Time
+	last       time.Time
+	untimed    int
+}
+
+func newStats(minRank int) *stats {
+	return &stats{minRank: minRank, levels: make(map[string]int), signatures: make(map[string]*Signature)}
+}
+
+func (s *stats) add(e *Entry) {
+	level := e.Level
+	if level == "" {
+		level = "unknown"
+	}
+	s.levels[level]++
+	if e.Time.IsZero() {
+		s.untimed++
+	} else {
+		s.times = append(s.times, e.Time)
+		s.timeLevels = append(s.timeLevels, level)
+		if s.first.IsZero() || e.Time.Before(s.first) {
+			s.first = e.Time
+		}
+		if e.Time.After(s.last) {
+			s.last = e.Time
+		}
+	}
+	if levelRank(e.Level) < s.minRank {
+		return
+	}
+	key := signature(e)
+	sig, ok := s.signatures[key]
+	if !ok {
+		example, _, _ := strings.Cut(e.Message, "\n")
+		if len(example) > 500 {
+			example = strings.ToValidUTF8(example[:500], "") + "..."
+		}
+		sig = &Signature{Signature: key, Example: example}
+		s.signatures[key] = sig
+	}
+	sig.Count++
+	if levelRank(e.Level) > levelRank(sig.Level) || sig.Level == "" {
+		sig.Level = e.Level
+	}
+	if e.Timestamp != "" {
+		if sig.First == "" {
+			sig.First = e.Timestamp
+		}
+		sig.Last = e.Timestamp
+	}
+}
+
+// top returns the n most frequent signatures.
+func (s *stats) top(n int) []*Signature {
+	sigs := make([]*Signature, 0, len(s.signatures))
+	for _, sig := range s.signatures {
+		sigs = append(sigs, sig)
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		if sigs[i].Count != sigs[j].Count {
+			return sigs[i].Count > sigs[j].Count
+		}
+		return sigs[i].Signature < sigs[j].Signature
+	})
+	if len(sigs) > n {
+		sigs = sigs[:n]
+	}
+	return sigs
+}
+
+// histogram counts the entries per interval, picking an interval for about 60 buckets when
+// interval is 0.
+func (s *stats) histogram(interval time.Duration) (time.Duration, []Bucket, error) {
+	if len(s.times) == 0 {
+		return interval, nil, nil
+	}
+	span := s.last.Sub(s.first)
+	if interval == 0 {
+		interval = intervals[len(intervals)-1]
+		for _, i := range intervals {
+			if span/i < 60 {
+				interval = i
+				break
+			}
+		}
+	}
+	start := s.first.Truncate(interval)
+	n := int(s.last.Sub(start)/interval) + 1
+	if n > maxBuckets {
+		return interval, nil, fmt.Errorf("interval %s gives %d buckets for %s, the limit is %d", interval, n, span, maxBuckets)
+	}
+	buckets := make([]Bucket, n)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * interval).In(s.first.Location()).Format(time.RFC3339)
+	}
+	for i, t := range s.times {
+		b := &buckets[int(t.Sub(start)/interval)]
+		b.Total++
+		if b.Levels == nil {
+			b.Levels = make(map[string]int)
+		}
+		b.Levels[s.timeLevels[i]]++
+	}
+	return interval, buckets, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	// summaryEntries is the number of latest matching entries sent to the client's model.
+	summaryEntries = 30
+	// summaryInputSize is the maximum size of the data sent to the client's model, in bytes.
+	summaryInputSize = 32 * 1024
+	// errNoSampling is returned when the client's model cannot be asked.
+	errNoSampling = "the client does not support sampling or sampling is disabled, use log_stats and log_query instead"
+	// summarySystemPrompt tells the client's model what to do with the data.
+	summarySystemPrompt = "You triage log files. You get statistics of a log file and its latest matching entries as JSON. " +
+		"Summarize what happened in a few sentences: the main errors, when they started and whether they are still going on. " +
+		"Quote signatures exactly and do not guess causes the data does not show."
+)
+
+// handleSummarize collects the statistics and latest entries of a log file and asks the model of the client to summarize them.
+func (ls *LogServer) handleSummarize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !ls.CanSample(ctx) {
+		return mcp.NewToolResultError(errNoSampling), nil
+	}
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	path, err := utils.ResolvePath(p, ls.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	f, err := ls.filter(args, true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	focus := abstract.GetString(args, "focus", "")
+
+	minRank := levelRank("error")
+	if f.minRank >= 0 {
+		minRank = f.minRank
+	}
+	st := newStats(minRank)
+	entries := make([]*Entry, 0, summaryEntries)
+	next := 0
+	res, err := ls.scan(ctx, path, f, func(e *Entry) {
+		st.add(e)
+		if len(entries) < summaryEntries {
+			entries = append(entries, e)
+			return
+		}
+		entries[next] = e
+		next = (next + 1) % summaryEntries
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", p, err.Error())), nil
+	}
+	if res.Matched == 0 {
+		return mcp.NewToolResultError("no entries match, nothing to summarize"), nil
+	}
+	entries = append(entries[next:], entries[:next]...)
+
+	data := struct {
+		*scanResult
+		Levels     map[string]int `json:"levels"`
+		Signatures []*Signature   `json:"top_signatures"`
+		Latest     []*Entry       `json:"latest_entries"`
+	}{res, st.levels, st.top(10), entries}
+	input, err := marshalPlain(data)
+	// Older entries are dropped until the data fits.
+	for err == nil && len(input) > summaryInputSize && len(data.Latest) > 1 {
+		data.Latest = data.Latest[len(data.Latest)/2:]
+		input, err = marshalPlain(data)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal log data: %s", err.Error())), nil
+	}
+	prompt := string(input)
+	if focus != "" {
+		prompt = fmt.Sprintf("Focus on this question: %s\n\n%s", focus, prompt)
+	}
+
+	summary, err := ls.SampleText(ctx, summarySystemPrompt, prompt, 0)
+	if err != nil {
+		if errors.Is(err, abstract.ErrSamplingUnavailable) {
+			return mcp.NewToolResultError(errNoSampling), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to summarize %s: %s", p, err.Error())), nil
+	}
+	return abstract.NewJSONResult(struct {
+		*scanResult
+		Summary string `json:"summary"`
+	}{res, strings.TrimSpace(summary)}), nil
+}
+
+// marshalPlain marshals v without escaping <, > and &, which are common in log messages and masked signatures.
+func marshalPlain(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func writeLog(t *testing.T, path string, lines ...string) {
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write log: %s", err.Error())
+	}
+}
+
+func TestParsers(t *testing.T) {
+	for _, c := range []struct {
+		format, line           string
+		level, message, source string
+		time                   string
+	}{
+		{FormatJSON, `{"level":"error","time":"2024-05-01T10:00:00Z","message":"db down","attempt":3}`, "error", "db down", "", "2024-05-01T10:00:00Z"},
+		{FormatJSON, `{"level":50,"time":1714557600000,"msg":"bunyan"}`, "error", "bunyan", "", "2024-05-01T10:00:00Z"},
+		{FormatJSON, `{"level":"warn","ts":1714557600.5,"msg":"zap"}`, "warn", "zap", "", "2024-05-01T10:00:00.5Z"},
+		{FormatSyslog, `<11>1 2024-05-01T10:00:00Z web01 nginx 42 - - worker crashed`, "error", "worker crashed", "web01 nginx", "2024-05-01T10:00:00Z"},
+		{FormatSyslog, `2024-05-01T10:00:00+00:00 web01 sshd[99]: Accepted publickey`, "", "Accepted publickey", "web01 sshd", "2024-05-01T10:00:00Z"},
+		{FormatAccess, `10.0.0.1 - bob [01/May/2024:10:00:00 +0000] "GET /api/users/42?x=1 HTTP/1.1" 502 157 "-" "curl/8.0"`, "error", "GET /api/users/42?x=1 HTTP/1.1 502", "10.0.0.1", "2024-05-01T10:00:00Z"},
+		{FormatPlain, `2024-05-01T10:00:00Z [WARN] disk almost full`, "warn", "disk almost full", "", "2024-05-01T10:00:00Z"},
+		{FormatPlain, `2024-05-01 10:00:00,123+0000 ERROR: failed`, "error", "failed", "", "2024-05-01T10:00:00.123Z"},
+	} {
+		e, ok := parsers[c.format](c.line)
+		if !ok {
+			t.Errorf("%s: failed to parse %q", c.format, c.line)
+			continue
+		}
+		e = finish(e)
+		if e.Level != c.level || e.Message != c.message || e.Source != c.source || !e.Time.Equal(mustTime(t, c.time)) {
+			t.Errorf("%s: unexpected entry for %q: %+v", c.format, c.line, e)
+		}
+	}
+
+	e, ok := parseNginxError(`2024/05/01 10:00:00 [crit] 123#0: *5 connect() failed (111: Connection refused)`)
+	if !ok || e.Level != "fatal" || e.Message != "connect() failed (111: Connection refused)" {
+		t.Errorf("unexpected nginx error entry: %+v", e)
+	}
+	e, ok = parseApacheError(`[Wed May 01 10:00:00.123456 2024] [proxy:error] [pid 42:tid 7] [client 10.0.0.1:5000] AH00957: backend failed`)
+	if !ok || e.Level != "error" || e.Source != "10.0.0.1:5000" || e.Fields["module"] != "proxy" {
+		t.Errorf("unexpected apache error entry: %+v", e)
+	}
+}
+
+func mustTime(t *testing.T, s string) time.Time {
+	tm, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatalf("Failed to parse time: %s", err.Error())
+	}
+	return tm
+}
+
+func TestDetectFormatAndSignature(t *testing.T) {
+	sample := `{"level":"info","msg":"a"}
+{"level":"info","msg":"b"}
+not json
+`
+	if got := detectFormat([]byte(sample)); got != FormatJSON {
+		t.Errorf("detectFormat = %s, want json", got)
+	}
+	if got := detectFormat([]byte("hello\nworld\n")); got != FormatPlain {
+		t.Errorf("detectFormat = %s, want plain", got)
+	}
+	a := signature(&Entry{Message: `user 1234 not found in "db-7" from 10.1.2.3:8080 id=550e8400-e29b-41d4-a716-446655440000 after 35ms`})
+	b := signature(&Entry{Message: `user 99 not found in "db-2" from 10.9.9.9:1 id=6ba7b810-9dad-11d1-80b4-00c04fd430c8 after 2s`})
+	if a != b || a != `user <n> not found in <str> from <ip> id=<uuid> after <n>` {
+		t.Errorf("signatures differ: %q, %q", a, b)
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	dir := t.TempDir()
+	ls := testkit.NewServiceAs[*LogServer](t, NewLogServer, map[string]any{"allowed_dirs": []any{dir}})
+	writeLog(t, filepath.Join(dir, "app.log"),
+		"2024-05-01T10:00:00Z INFO starting",
+		"2024-05-01T10:01:00Z ERROR request failed",
+		"java.lang.IllegalStateException: boom",
+		"\tat com.example.Main.run(Main.java:42)",
+		"2024-05-01T10:02:00Z WARN slow query",
+		"2024-05-01T10:03:00Z ERROR request failed again",
+		"2024-05-01T10:04:00Z INFO done",
+	)
+
+	text, isErr := testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "level": "error"})
+	if isErr {
+		t.Fatalf("log_query failed: %s", text)
+	}
+	var res struct {
+		Format  string  `json:"format"`
+		Entries int     `json:"scanned_entries"`
+		Matched int     `json:"matched"`
+		List    []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if res.Format != FormatPlain || res.Entries != 5 || res.Matched != 2 || len(res.List) != 2 {
+		t.Fatalf("unexpected result: %s", text)
+	}
+	if !strings.Contains(res.List[0].Message, "Main.java:42") || res.List[0].Line != 2 || res.List[1].Line != 6 {
+		t.Fatalf("stack trace not joined to its entry: %s", text)
+	}
+
+	text, _ = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "since": "2024-05-01T10:01:30Z", "until": "2024-05-01T10:03:00Z"})
+	if !strings.Contains(text, "slow query") || !strings.Contains(text, "failed again") || strings.Contains(text, "done") || strings.Contains(text, "boom") {
+		t.Fatalf("unexpected time range result: %s", text)
+	}
+	text, _ = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "pattern": "(?i)illegalstate"})
+	if !strings.Contains(text, `"matched": 1`) {
+		t.Fatalf("pattern should match the continuation lines: %s", text)
+	}
+	text, _ = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "limit": float64(1)})
+	if !strings.Contains(text, "done") || strings.Contains(text, "starting") {
+		t.Fatalf("expected the last entry: %s", text)
+	}
+	text, _ = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "limit": float64(1), "from": "start"})
+	if !strings.Contains(text, "starting") || strings.Contains(text, "done") {
+		t.Fatalf("expected the first entry: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "../outside.log"})
+	if !isErr || !strings.Contains(text, "access denied") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleQuery, map[string]any{"path": "app.log", "since": "yesterday-ish"})
+	if !isErr {
+		t.Fatalf("expected invalid time error, got: %s", text)
+	}
+}
+
+func TestLogStats(t *testing.T) {
+	dir := t.TempDir()
+	ls := testkit.NewServiceAs[*LogServer](t, NewLogServer, map[string]any{"allowed_dirs": []any{dir}})
+	writeLog(t, filepath.Join(dir, "access.log"),
+		`10.0.0.1 - - [01/May/2024:10:00:05 +0000] "GET /api/users/1 HTTP/1.1" 200 10 "-" "curl"`,
+		`10.0.0.2 - - [01/May/2024:10:00:30 +0000] "GET /api/users/2 HTTP/1.1" 502 10 "-" "curl"`,
+		`10.0.0.3 - - [01/May/2024:10:01:10 +0000] "GET /api/users/3?full=1 HTTP/1.1" 502 10 "-" "curl"`,
+		`10.0.0.4 - - [01/May/2024:10:02:50 +0000] "POST /login HTTP/1.1" 500 10 "-" "curl"`,
+		`10.0.0.5 - - [01/May/2024:10:02:55 +0000] "GET /missing HTTP/1.1" 404 10 "-" "curl"`,
+	)
+
+	text, isErr := testkit.CallHandler(t, ls.handleStats, map[string]any{"path": "access.log", "interval": "1m"})
+	if isErr {
+		t.Fatalf("log_stats failed: %s", text)
+	}
+	var res struct {
+		Format     string         `json:"format"`
+		Levels     map[string]int `json:"levels"`
+		Interval   string         `json:"interval"`
+		Histogram  []Bucket       `json:"histogram"`
+		Signatures []Signature    `json:"top_signatures"`
+	}
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if res.Format != FormatAccess || res.Levels["error"] != 3 || res.Levels["warn"] != 1 || res.Levels["info"] != 1 {
+		t.Fatalf("unexpected levels: %s", text)
+	}
+	if res.Interval != "1m0s" || len(res.Histogram) != 3 || res.Histogram[0].Total != 2 || res.Histogram[1].Total != 1 ||
+		res.Histogram[2].Levels["warn"] != 1 || res.Histogram[0].Start != "2024-05-01T10:00:00Z" {
+		t.Fatalf("unexpected histogram: %+v", res.Histogram)
+	}
+	if len(res.Signatures) != 2 || res.Signatures[0].Signature != "502 GET /api/users/<n>" || res.Signatures[0].Count != 2 ||
+		res.Signatures[0].First != "2024-05-01T10:00:30Z" || res.Signatures[0].Last != "2024-05-01T10:01:10Z" {
+		t.Fatalf("unexpected signatures: %+v", res.Signatures)
+	}
+
+	text, _ = testkit.CallHandler(t, ls.handleStats, map[string]any{"path": "access.log", "level": "warn", "top": float64(1)})
+	if !strings.Contains(text, `"interval": "1s"`) && !strings.Contains(text, `"interval": "5s"`) {
+		t.Fatalf("expected an automatic interval: %s", text)
+	}
+	if !strings.Contains(text, `"matched": 4`) || strings.Count(text, `"signature"`) != 1 {
+		t.Fatalf("unexpected filtered stats: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ls.handleStats, map[string]any{"path": "access.log", "since": "2024-05-01T10:00:00Z", "interval": "1s", "until": "2024-05-02T10:00:00Z"})
+	if isErr {
+		t.Fatalf("log_stats failed: %s", text)
+	}
+}
+
+func TestLogFollow(t *testing.T) {
+	dir := t.TempDir()
+	ls := testkit.NewServiceAs[*LogServer](t, NewLogServer, map[string]any{"allowed_dirs": []any{dir}})
+	path := filepath.Join(dir, "app.jsonl")
+	writeLog(t, path,
+		`{"level":"info","time":"2024-05-01T10:00:00Z","msg":"one"}`,
+		`{"level":"error","time":"2024-05-01T10:00:01Z","msg":"two"}`,
+	)
+
+	var res followResult
+	text, isErr := testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "limit": float64(1)})
+	if isErr {
+		t.Fatalf("log_follow failed: %s", text)
+	}
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	info, _ := os.Stat(path)
+	if res.Format != FormatJSON || res.Cursor != info.Size() || len(res.Entries) != 1 || res.Entries[0].Message != "two" {
+		t.Fatalf("unexpected tail: %s", text)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %s", err.Error())
+	}
+	_, _ = file.WriteString(`{"level":"debug","msg":"three"}` + "\n" + `{"level":"error","msg":"four"}` + "\n" + `{"level":"error","msg":"fi`)
+	_ = file.Close()
+
+	cursor := res.Cursor
+	text, _ = testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "cursor": float64(cursor), "level": "warn", "wait": float64(0)})
+	res = followResult{}
+	_ = json.Unmarshal([]byte(text), &res)
+	if len(res.Entries) != 1 || res.Entries[0].Message != "four" || res.More {
+		t.Fatalf("unexpected follow result: %s", text)
+	}
+	info, _ = os.Stat(path)
+	if res.Cursor != info.Size()-int64(len(`{"level":"error","msg":"fi`)) {
+		t.Fatalf("the partial line should be left for the next call: %s", text)
+	}
+
+	// Nothing new: the call waits and returns the same cursor.
+	start := time.Now()
+	text, _ = testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "cursor": float64(res.Cursor), "wait": 0.3})
+	if !strings.Contains(text, `"entries": []`) || time.Since(start) < 250*time.Millisecond {
+		t.Fatalf("expected an empty result after waiting: %s", text)
+	}
+
+	// A limit puts the cursor after the returned entries.
+	text, _ = testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "cursor": float64(cursor), "limit": float64(1)})
+	res = followResult{}
+	_ = json.Unmarshal([]byte(text), &res)
+	if len(res.Entries) != 1 || res.Entries[0].Message != "three" || !res.More {
+		t.Fatalf("unexpected limited result: %s", text)
+	}
+	text, _ = testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "cursor": float64(res.Cursor), "limit": float64(1)})
+	if !strings.Contains(text, `"four"`) {
+		t.Fatalf("expected the next entry: %s", text)
+	}
+
+	writeLog(t, path, `{"level":"info","msg":"rotated"}`)
+	text, _ = testkit.CallHandler(t, ls.handleFollow, map[string]any{"path": "app.jsonl", "cursor": float64(cursor)})
+	if !strings.Contains(text, `"rotated": true`) || !strings.Contains(text, `"message": "rotated"`) {
+		t.Fatalf("expected rotation to be detected: %s", text)
+	}
+}
+
+// summarizingRequester answers sampling requests with a fixed summary and keeps the prompt.
+type summarizingRequester struct {
+	prompt string
+}
+
+func (r *summarizingRequester) ClientSupports(ctx context.Context, capability string) bool {
+	return capability == abstract.SamplingCapability
+}
+
+func (r *summarizingRequester) RequestClient(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	r.prompt = params.(abstract.SampleParams).Messages[0].Content.(mcp.TextContent).Text
+	return json.RawMessage(`{"role":"assistant","content":{"type":"text","text":" Logins fail since 10:02. "},"model":"test-model"}`), nil
+}
+
+func TestLogSummarize(t *testing.T) {
+	dir := t.TempDir()
+	ls := testkit.NewServiceAs[*LogServer](t, NewLogServer, map[string]any{"allowed_dirs": []any{dir}})
+	writeLog(t, filepath.Join(dir, "app.log"),
+		`{"level":"info","time":"2024-05-01T10:00:00Z","msg":"started"}`,
+		`{"level":"error","time":"2024-05-01T10:02:00Z","msg":"login failed for user 42"}`,
+	)
+
+	text, isErr := testkit.CallHandler(t, ls.handleSummarize, map[string]any{"path": "app.log"})
+	if !isErr || !strings.Contains(text, "log_stats") {
+		t.Fatalf("expected an error without sampling: %s", text)
+	}
+
+	ls.MlConfig().Sampling = config.NewSamplingConfig()
+	requester := &summarizingRequester{}
+	ls.SetClientRequester(requester)
+	text, isErr = testkit.CallHandler(t, ls.handleSummarize, map[string]any{"path": "app.log", "focus": "why do logins fail?"})
+	if isErr {
+		t.Fatalf("log_summarize failed: %s", text)
+	}
+	if !strings.Contains(text, `"summary": "Logins fail since 10:02."`) {
+		t.Fatalf("unexpected summary: %s", text)
+	}
+	if !strings.HasPrefix(requester.prompt, "Focus on this question: why do logins fail?") ||
+		!strings.Contains(requester.prompt, "login failed for user <n>") {
+		t.Fatalf("unexpected prompt: %s", requester.prompt)
+	}
+}
@@ -0,0 +1,615 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package media provides audio and video download, conversion and probing for the MoLing application.
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	MediaServerName comm.MoLingServerType = "Media"
+)
+
+const (
+	// Markers of the lines yt-dlp prints after a download.
+	fileMarker = "[moling-file] "
+	infoMarker = "[moling-info] "
+)
+
+var (
+	downloadProgress = regexp.MustCompile(`^\[download\]\s+([\d.]+)%`)
+	qualityPattern   = regexp.MustCompile(`^(best|\d{3,4})p?$`)
+)
+
+// audioCodecs maps the audio formats to the ffmpeg encoder arguments.
+var audioCodecs = map[string][]string{
+	"mp3":  {"-c:a", "libmp3lame", "-q:a", "2"},
+	"m4a":  {"-c:a", "aac", "-b:a", "192k"},
+	"opus": {"-c:a", "libopus", "-b:a", "128k"},
+	"wav":  {"-c:a", "pcm_s16le"},
+	"flac": {"-c:a", "flac"},
+}
+
+// MediaServer implements the Service interface and wraps yt-dlp, ffmpeg and ffprobe.
+type MediaServer struct {
+	abstract.MLService
+	config *MediaConfig
+}
+
+// NewMediaServer creates a new MediaServer instance.
+func NewMediaServer(ctx context.Context) (abstract.Service, error) {
+	mc := NewMediaConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("MediaServer: invalid config type")
+	}
+	mc.DataPath = filepath.Join(globalConf.BasePath, "data")
+	mc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("MediaServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(MediaServerName))
+	})
+
+	ms := &MediaServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    mc,
+	}
+	err := ms.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Init registers the prompt and tools of the media service.
+func (ms *MediaServer) Init() error {
+	err := utils.CreateDirectory(ms.config.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "media_prompt",
+			Description: "Get the relevant functions and prompts of the Media MCP Server",
+		},
+		HandlerFunc: ms.handlePrompt,
+	}
+	ms.AddPrompt(pe)
+
+	audioFormats := []string{"mp3", "m4a", "opus", "wav", "flac"}
+	ms.AddTool(mcp.NewTool(
+		"media_download",
+		mcp.WithDescription(fmt.Sprintf("Download a video or its audio with yt-dlp into the data directory. Playlists and live streams are not downloaded, nor media longer than %d minutes", ms.config.MaxDuration/60)),
+		mcp.WithString("url",
+			mcp.Description("URL of the video or track page"),
+			mcp.Required(),
+		),
+		mcp.WithString("type",
+			mcp.Description("What to download, default: video"),
+			mcp.Enum("video", "audio"),
+		),
+		mcp.WithString("quality",
+			mcp.Description("Maximum video height such as 1080, 720 or 480, or best, default: best"),
+		),
+		mcp.WithString("audio_format",
+			mcp.Description("Audio format for type audio, default: mp3"),
+			mcp.Enum(audioFormats...),
+		),
+		mcp.WithBoolean("background",
+			mcp.Description("Download as a background job and return the job at once. Poll jobs_status and read the outcome with jobs_result"),
+		),
+	), ms.handleDownload)
+
+	ms.AddTool(mcp.NewTool(
+		"media_extract_audio",
+		mcp.WithDescription("Extract the audio of a video or audio file with ffmpeg into the data directory"),
+		mcp.WithString("path",
+			mcp.Description("Path of the input file"),
+			mcp.Required(),
+		),
+		mcp.WithString("audio_format",
+			mcp.Description("Audio format, default: mp3"),
+			mcp.Enum(audioFormats...),
+		),
+		mcp.WithString("output",
+			mcp.Description("Name of the output file in the data directory, default: the input name with the new extension"),
+		),
+		mcp.WithNumber("start",
+			mcp.Description("Start of the part to extract, in seconds"),
+		),
+		mcp.WithNumber("duration",
+			mcp.Description("Length of the part to extract, in seconds, default: up to the end"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Overwrite an existing output file, default: false"),
+		),
+	), ms.handleExtractAudio)
+
+	ms.AddTool(mcp.NewTool(
+		"media_probe",
+		mcp.WithDescription("Read the metadata of a media file with ffprobe, or of a URL with yt-dlp without downloading it"),
+		mcp.WithString("path",
+			mcp.Description("Path of a local file"),
+		),
+		mcp.WithString("url",
+			mcp.Description("URL of a video or track page"),
+		),
+	), ms.handleProbe)
+	return nil
+}
+
+func (ms *MediaServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ms.config.prompt
+	if strings.Count(text, "%s") == 2 {
+		allowed := "none, ask the user to configure allowed_urls"
+		if len(ms.config.AllowedURLs) > 0 {
+			allowed = strings.Join(ms.config.AllowedURLs, ", ")
+		}
+		text = fmt.Sprintf(text, allowed, ms.config.DataPath)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// resolvePath resolves a path against the allowed directories and the data directory.
+func (ms *MediaServer) resolvePath(path string) (string, error) {
+	// Relative paths are in the first allowed directory, not in the data directory
+	if path != "" && !filepath.IsAbs(path) && len(ms.config.AllowedDirs) > 0 {
+		path = filepath.Join(ms.config.AllowedDirs[0], path)
+	}
+	return utils.ResolvePath(path, append([]string{ms.config.DataPath}, ms.config.AllowedDirs...))
+}
+
+// checkURL parses a URL and checks it against allowed_urls.
+func (ms *MediaServer) checkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an http or https URL")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("url must not contain credentials")
+	}
+	if !ms.config.urlAllowed(u) {
+		return nil, fmt.Errorf("access denied - %s is not in allowed_urls", u.Host)
+	}
+	return u, nil
+}
+
+// downloadResult is the result of media_download.
+type downloadResult struct {
+	File       string  `json:"file"`
+	Size       int64   `json:"size"`
+	ID         string  `json:"id,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Uploader   string  `json:"uploader,omitempty"`
+	Duration   float64 `json:"duration,omitempty"` // in seconds
+	WebpageURL string  `json:"webpage_url,omitempty"`
+	Extractor  string  `json:"extractor,omitempty"`
+}
+
+func (ms *MediaServer) handleDownload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	raw := abstract.GetString(args, "url", "")
+	u, err := ms.checkURL(raw)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	kind := abstract.GetString(args, "type", "")
+	if kind == "" {
+		kind = "video"
+	}
+	if kind != "video" && kind != "audio" {
+		return mcp.NewToolResultError("type must be video or audio"), nil
+	}
+	quality := abstract.GetString(args, "quality", "")
+	quality = strings.ToLower(strings.TrimSpace(quality))
+	if quality == "" {
+		quality = "best"
+	}
+	m := qualityPattern.FindStringSubmatch(quality)
+	if m == nil {
+		return mcp.NewToolResultError("quality must be best or a video height such as 720"), nil
+	}
+	audioFormat := abstract.GetString(args, "audio_format", "")
+	if audioFormat == "" {
+		audioFormat = "mp3"
+	}
+	if _, ok := audioCodecs[audioFormat]; !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported audio_format %q", audioFormat)), nil
+	}
+	ytdlp, err := tool(ms.config.YtDlpPath, "ytdlp_path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ffmpeg, ffmpegErr := tool(ms.config.FfmpegPath, "ffmpeg_path")
+	if kind == "audio" && ffmpegErr != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("extracting audio needs ffmpeg: %s", ffmpegErr.Error())), nil
+	}
+
+	cmdArgs := []string{
+		"--ignore-config", "--no-playlist", "--newline", "--progress", "--restrict-filenames",
+		"--paths", ms.config.DataPath, "--output", "%(title).80B [%(id)s].%(ext)s",
+		"--max-filesize", strconv.FormatInt(ms.config.MaxFileSize, 10),
+		"--match-filter", fmt.Sprintf("!is_live & duration <=? %d", ms.config.MaxDuration),
+		"--print", "after_move:" + fileMarker + "%(filepath)s",
+		"--print", "after_move:" + infoMarker + "%(.{id,title,uploader,duration,webpage_url,extractor})j",
+	}
+	if ffmpegErr == nil {
+		cmdArgs = append(cmdArgs, "--ffmpeg-location", ffmpeg)
+	}
+	height := ""
+	if m[1] != "best" {
+		height = "[height<=" + m[1] + "]"
+	}
+	switch {
+	case kind == "audio":
+		cmdArgs = append(cmdArgs, "--format", "ba/b", "--extract-audio", "--audio-format", audioFormat, "--audio-quality", "0")
+	case ffmpegErr == nil:
+		cmdArgs = append(cmdArgs, "--format", "bv*"+height+"+ba/b"+height+"/b", "--merge-output-format", "mp4")
+	default:
+		// Without ffmpeg, only formats with both video and audio can be downloaded.
+		cmdArgs = append(cmdArgs, "--format", "b"+height+"/b")
+	}
+	// The URL follows -- so that it is never read as an option.
+	cmdArgs = append(cmdArgs, "--", u.String())
+
+	res := &downloadResult{}
+	p := ms.newProgress(ctx, request, "downloading "+u.String())
+	err = ms.run(ctx, ytdlp, cmdArgs, func(line string) {
+		switch {
+		case strings.HasPrefix(line, fileMarker):
+			res.File = strings.TrimPrefix(line, fileMarker)
+		case strings.HasPrefix(line, infoMarker):
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(line, infoMarker)), res)
+		default:
+			if pm := downloadProgress.FindStringSubmatch(line); pm != nil {
+				if percent, err := strconv.ParseFloat(pm[1], 64); err == nil {
+					p.report(percent, strings.TrimSpace(strings.TrimPrefix(line, "[download]")))
+				}
+			}
+		}
+	})
+	if err != nil {
+		ms.Logger.Error().Err(err).Str("url", u.String()).Msg("download failed")
+		return mcp.NewToolResultError(fmt.Sprintf("download failed: %s", err.Error())), nil
+	}
+	if res.File == "" {
+		return mcp.NewToolResultError("nothing was downloaded: the media is a live stream, longer than max_duration or larger than max_file_size"), nil
+	}
+	info, err := os.Stat(res.File)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("yt-dlp reported %s, but it cannot be read: %s", res.File, err.Error())), nil
+	}
+	res.Size = info.Size()
+	ms.Logger.Info().Str("url", u.String()).Str("file", res.File).Int64("size", res.Size).Msg("media downloaded")
+	return abstract.NewJSONResult(res), nil
+}
+
+func (ms *MediaServer) handleExtractAudio(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	input, err := ms.resolvePath(p)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, err := os.Stat(input); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", p, err.Error())), nil
+	}
+	audioFormat := abstract.GetString(args, "audio_format", "")
+	if audioFormat == "" {
+		audioFormat = "mp3"
+	}
+	codec, ok := audioCodecs[audioFormat]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported audio_format %q", audioFormat)), nil
+	}
+	start := abstract.GetFloat(args, "start", 0)
+	duration := abstract.GetFloat(args, "duration", 0)
+	if start < 0 || duration < 0 {
+		return mcp.NewToolResultError("start and duration must not be negative"), nil
+	}
+
+	name := abstract.GetString(args, "output", "")
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + "." + audioFormat
+	}
+	output := filepath.Clean(filepath.Join(ms.config.DataPath, name))
+	if !utils.Within(ms.config.DataPath, output) || output == filepath.Clean(ms.config.DataPath) {
+		return mcp.NewToolResultError(fmt.Sprintf("access denied - output outside the data directory: %s", name)), nil
+	}
+	if output == input {
+		output = strings.TrimSuffix(output, filepath.Ext(output)) + "_audio." + audioFormat
+	}
+	overwrite := abstract.GetBool(args, "overwrite", false)
+	if _, err := os.Stat(output); err == nil && !overwrite {
+		return mcp.NewToolResultError(fmt.Sprintf("%s already exists, set overwrite to replace it", output)), nil
+	}
+	ffmpeg, err := tool(ms.config.FfmpegPath, "ffmpeg_path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// The length of the result is needed for the progress.
+	total := duration
+	if total == 0 {
+		if info, err := ms.probeFile(ctx, input); err == nil && info.Duration > start {
+			total = info.Duration - start
+		}
+	}
+	cmdArgs := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-nostats", "-progress", "pipe:1", "-y"}
+	if start > 0 {
+		cmdArgs = append(cmdArgs, "-ss", strconv.FormatFloat(start, 'f', -1, 64))
+	}
+	cmdArgs = append(cmdArgs, "-i", input)
+	if duration > 0 {
+		cmdArgs = append(cmdArgs, "-t", strconv.FormatFloat(duration, 'f', -1, 64))
+	}
+	cmdArgs = append(cmdArgs, "-vn", "-map", "0:a:0")
+	cmdArgs = append(append(cmdArgs, codec...), output)
+
+	pr := ms.newProgress(ctx, request, "extracting audio of "+filepath.Base(input))
+	err = ms.run(ctx, ffmpeg, cmdArgs, func(line string) {
+		key, value, _ := strings.Cut(line, "=")
+		if total <= 0 || (key != "out_time_us" && key != "out_time_ms") {
+			return
+		}
+		// Both keys are in microseconds.
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+			pr.report(min(float64(us)/1e6/total*100, 100), fmt.Sprintf("%.0f of %.0f seconds", float64(us)/1e6, total))
+		}
+	})
+	if err != nil {
+		ms.Logger.Error().Err(err).Str("input", input).Msg("audio extraction failed")
+		return mcp.NewToolResultError(fmt.Sprintf("audio extraction failed: %s", err.Error())), nil
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("ffmpeg did not write %s: %s", output, err.Error())), nil
+	}
+	ms.Logger.Info().Str("input", input).Str("output", output).Msg("audio extracted")
+	return mcp.NewToolResultText(fmt.Sprintf("Audio extracted to %s (%d bytes)", output, info.Size())), nil
+}
+
+// fileInfo is the metadata of a media file.
+type fileInfo struct {
+	Path     string            `json:"path"`
+	Format   string            `json:"format"`
+	Duration float64           `json:"duration,omitempty"` // in seconds
+	Size     int64             `json:"size,omitempty"`
+	BitRate  int64             `json:"bit_rate,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Streams  []streamInfo      `json:"streams"`
+}
+
+type streamInfo struct {
+	Index      int    `json:"index"`
+	Type       string `json:"type"`
+	Codec      string `json:"codec"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	FrameRate  string `json:"frame_rate,omitempty"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	Channels   int    `json:"channels,omitempty"`
+	BitRate    int64  `json:"bit_rate,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+// probeFile reads the metadata of a file with ffprobe.
+func (ms *MediaServer) probeFile(ctx context.Context, path string) (*fileInfo, error) {
+	ffprobe, err := tool(ms.config.FfprobePath, "ffprobe_path")
+	if err != nil {
+		return nil, err
+	}
+	var out strings.Builder
+	err = ms.run(ctx, ffprobe, []string{"-v", "error", "-print_format", "json", "-show_format", "-show_streams", path}, func(line string) {
+		out.WriteString(line)
+		out.WriteByte('\n')
+	})
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Format struct {
+			FormatName string            `json:"format_name"`
+			Duration   string            `json:"duration"`
+			Size       string            `json:"size"`
+			BitRate    string            `json:"bit_rate"`
+			Tags       map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			Index        int               `json:"index"`
+			CodecType    string            `json:"codec_type"`
+			CodecName    string            `json:"codec_name"`
+			Width        int               `json:"width"`
+			Height       int               `json:"height"`
+			AvgFrameRate string            `json:"avg_frame_rate"`
+			SampleRate   string            `json:"sample_rate"`
+			Channels     int               `json:"channels"`
+			BitRate      string            `json:"bit_rate"`
+			Tags         map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	err = json.Unmarshal([]byte(out.String()), &probe)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+	info := &fileInfo{Path: path, Format: probe.Format.FormatName, Tags: probe.Format.Tags, Streams: []streamInfo{}}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	for _, s := range probe.Streams {
+		si := streamInfo{Index: s.Index, Type: s.CodecType, Codec: s.CodecName, Width: s.Width, Height: s.Height, Channels: s.Channels, Language: s.Tags["language"]}
+		if s.CodecType == "video" && s.AvgFrameRate != "0/0" {
+			si.FrameRate = s.AvgFrameRate
+		}
+		si.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		si.BitRate, _ = strconv.ParseInt(s.BitRate, 10, 64)
+		info.Streams = append(info.Streams, si)
+	}
+	return info, nil
+}
+
+// urlInfo is the metadata of a URL.
+type urlInfo struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Uploader    string  `json:"uploader,omitempty"`
+	UploadDate  string  `json:"upload_date,omitempty"`
+	Duration    float64 `json:"duration,omitempty"` // in seconds
+	ViewCount   int64   `json:"view_count,omitempty"`
+	IsLive      bool    `json:"is_live,omitempty"`
+	WebpageURL  string  `json:"webpage_url,omitempty"`
+	Extractor   string  `json:"extractor,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Heights     []int   `json:"video_heights,omitempty"`
+	AudioOnly   bool    `json:"audio_only_formats,omitempty"`
+}
+
+// probeURL reads the metadata of a URL with yt-dlp.
+func (ms *MediaServer) probeURL(ctx context.Context, u *url.URL) (*urlInfo, error) {
+	ytdlp, err := tool(ms.config.YtDlpPath, "ytdlp_path")
+	if err != nil {
+		return nil, err
+	}
+	var out string
+	err = ms.run(ctx, ytdlp, []string{"--ignore-config", "--no-playlist", "--dump-single-json", "--skip-download", "--", u.String()}, func(line string) {
+		if strings.HasPrefix(line, "{") {
+			out = line
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	var meta struct {
+		urlInfo
+		Formats []struct {
+			VCodec string `json:"vcodec"`
+			Height int    `json:"height"`
+		} `json:"formats"`
+	}
+	err = json.Unmarshal([]byte(out), &meta)
+	if err != nil {
+		return nil, fmt.Errorf("invalid yt-dlp output: %w", err)
+	}
+	info := meta.urlInfo
+	if len([]rune(info.Description)) > 500 {
+		info.Description = string([]rune(info.Description)[:500]) + "..."
+	}
+	for _, f := range meta.Formats {
+		switch {
+		case f.VCodec == "none":
+			info.AudioOnly = true
+		case f.Height > 0 && !slices.Contains(info.Heights, f.Height):
+			info.Heights = append(info.Heights, f.Height)
+		}
+	}
+	slices.Sort(info.Heights)
+	return &info, nil
+}
+
+func (ms *MediaServer) handleProbe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	p := abstract.GetString(args, "path", "")
+	raw := abstract.GetString(args, "url", "")
+	if (p == "") == (raw == "") {
+		return mcp.NewToolResultError("either path or url must be specified"), nil
+	}
+	if raw != "" {
+		u, err := ms.checkURL(raw)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		info, err := ms.probeURL(ctx, u)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to probe %s: %s", u.String(), err.Error())), nil
+		}
+		return abstract.NewJSONResult(info), nil
+	}
+	path, err := ms.resolvePath(p)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %s", p, err.Error())), nil
+	}
+	info, err := ms.probeFile(ctx, path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to probe %s: %s", p, err.Error())), nil
+	}
+	return abstract.NewJSONResult(info), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ms *MediaServer) Config() string {
+	data, err := json.Marshal(ms.config)
+	if err != nil {
+		ms.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (ms *MediaServer) Name() comm.MoLingServerType {
+	return MediaServerName
+}
+
+func (ms *MediaServer) Close() error {
+	ms.Logger.Debug().Msg("MediaServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ms *MediaServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ms.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ms.config.Check()
+}
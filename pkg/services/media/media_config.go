@@ -0,0 +1,103 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package media
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const MediaPromptDefault = `
+You are an assistant that can download and inspect audio and video. Your capabilities include:
+
+1. **Download**: Use media_download to download a video or only its audio from a supported site, such as a talk, a podcast episode or a tutorial. Only URLs in the allowlist can be downloaded: %s.
+
+2. **Extract audio**: Use media_extract_audio to convert a downloaded or local video file to MP3, M4A, Opus, WAV or FLAC, optionally only a part of it, e.g. to transcribe it.
+
+3. **Probe**: Use media_probe to read the duration, format and streams of a file, or the title, duration and available resolutions of a URL without downloading it.
+
+Files are saved to %s. Probe a URL first if you are unsure about its length, long downloads report their progress.
+`
+
+// MediaConfig represents the configuration for the media service.
+type MediaConfig struct {
+	PromptFile  string `json:"prompt_file"` // PromptFile is the prompt file for the media service.
+	prompt      string
+	YtDlpPath   string   `json:"ytdlp_path"`    // YtDlpPath is the yt-dlp executable.
+	FfmpegPath  string   `json:"ffmpeg_path"`   // FfmpegPath is the ffmpeg executable, used to merge streams and convert audio.
+	FfprobePath string   `json:"ffprobe_path"`  // FfprobePath is the ffprobe executable.
+	DataPath    string   `json:"data_path"`     // DataPath is the path where downloads and converted files are saved.
+	AllowedDirs []string `json:"allowed_dirs"`  // AllowedDirs are the directories local files may be read from, relative paths use the first one.
+	AllowedURLs []string `json:"allowed_urls"`  // AllowedURLs are the sites that may be downloaded from: a host, which covers its subdomains, a URL prefix, or * for any.
+	MaxFileSize int64    `json:"max_file_size"` // MaxFileSize is the maximum size of a download, in bytes.
+	MaxDuration int      `json:"max_duration"`  // MaxDuration is the maximum duration of a download. time.Second
+	Timeout     int      `json:"timeout"`       // Timeout is the maximum run time of a download or conversion. time.Second
+}
+
+// NewMediaConfig creates a new MediaConfig with default values.
+func NewMediaConfig() *MediaConfig {
+	return &MediaConfig{
+		prompt:      MediaPromptDefault,
+		YtDlpPath:   "yt-dlp",
+		FfmpegPath:  "ffmpeg",
+		FfprobePath: "ffprobe",
+		DataPath:    filepath.Join(os.TempDir(), ".moling", "data"),
+		AllowedDirs: []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		AllowedURLs: []string{"youtube.com", "youtu.be", "vimeo.com", "soundcloud.com"},
+		MaxFileSize: 2 * 1024 * 1024 * 1024,
+		MaxDuration: 4 * 60 * 60,
+		Timeout:     30 * 60,
+	}
+}
+
+// Check validates the media configuration.
+func (cfg *MediaConfig) Check() error {
+	cfg.prompt = MediaPromptDefault
+	if cfg.DataPath == "" {
+		return fmt.Errorf("data_path must not be empty")
+	}
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	for i, entry := range cfg.AllowedURLs {
+		pattern, err := utils.NormalizeURLPattern(entry)
+		if err != nil {
+			return err
+		}
+		cfg.AllowedURLs[i] = pattern
+	}
+	if cfg.MaxFileSize <= 0 || cfg.MaxDuration <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_file_size, max_duration and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// urlAllowed reports whether u matches allowed_urls.
+func (cfg *MediaConfig) urlAllowed(u *url.URL) bool {
+	return utils.URLAllowed(u, cfg.AllowedURLs)
+}
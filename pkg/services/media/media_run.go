@@ -0,0 +1,167 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// tailLines is the number of output lines kept for error messages.
+	tailLines = 10
+	// progressInterval is the interval of the progress messages sent to clients without a progress token.
+	progressInterval = 10 * time.Second
+)
+
+// tool returns the path of an executable, or an error that explains how to configure it.
+func tool(name, key string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%s is not set", key)
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed, install it or set %s", filepath.Base(name), key)
+	}
+	return path, nil
+}
+
+// run runs an external tool within the configured time limit and calls onLine for every line it
+// writes to stdout or stderr. Errors include the last lines of the output.
+func (ms *MediaServer) run(ctx context.Context, name string, args []string, onLine func(string)) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ms.config.Timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	cmd.WaitDelay = time.Second
+	ms.Logger.Debug().Str("command", name).Strs("args", args).Msg("running media tool")
+	err := cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %w", filepath.Base(name), err)
+	}
+	untrack := ms.TrackProcess(cmd)
+	defer untrack()
+
+	var tail []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(pr)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		sc.Split(scanLines)
+		for sc.Scan() {
+			line := sc.Text()
+			if onLine != nil {
+				onLine(line)
+			}
+			if strings.TrimSpace(line) != "" {
+				tail = append(tail, line)
+				if len(tail) > tailLines {
+					tail = tail[1:]
+				}
+			}
+		}
+		// Keep the pipe drained if the scanner gave up.
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	err = cmd.Wait()
+	_ = pw.Close()
+	<-done
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s did not finish within %d seconds", filepath.Base(name), ms.config.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(name), err, strings.Join(tail, "\n"))
+	}
+	return nil
+}
+
+// scanLines splits at \n and \r, as progress output overwrites lines with \r.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// progress reports the progress of a tool call. A client that sent a progress token gets
+// notifications/progress, the others get a log message every progressInterval.
+type progress struct {
+	ms      *MediaServer
+	ctx     context.Context
+	token   mcp.ProgressToken
+	label   string
+	last    time.Time
+	percent float64
+}
+
+func (ms *MediaServer) newProgress(ctx context.Context, request mcp.CallToolRequest, label string) *progress {
+	p := &progress{ms: ms, ctx: ctx, label: label, last: time.Now(), percent: -1}
+	if request.Params.Meta != nil {
+		p.token = request.Params.Meta.ProgressToken
+	}
+	return p
+}
+
+// report sends the progress in percent. Progress notifications must increase, so a lower
+// percentage, such as for the second stream of a download, is not sent.
+func (p *progress) report(percent float64, message string) {
+	now := time.Now()
+	if p.token != nil {
+		if percent <= p.percent || (percent-p.percent < 1 && now.Sub(p.last) < time.Second) {
+			return
+		}
+		srv := server.ServerFromContext(p.ctx)
+		if srv == nil {
+			return
+		}
+		_ = srv.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+			"progressToken": p.token,
+			"progress":      percent,
+			"total":         100,
+			"message":       message,
+		})
+	} else {
+		if now.Sub(p.last) < progressInterval {
+			return
+		}
+		p.ms.SendNotification("notifications/message", map[string]any{
+			"level":  mcp.LoggingLevelInfo,
+			"logger": "media",
+			"data": map[string]any{
+				"message":  fmt.Sprintf("%s: %.0f%%", p.label, percent),
+				"progress": percent,
+			},
+		})
+	}
+	p.last, p.percent = now, percent
+}
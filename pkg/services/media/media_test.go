@@ -0,0 +1,229 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package media
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+// fakeYtDlp records its arguments and either prints metadata or "downloads" a file into --paths.
+const fakeYtDlp = `#!/bin/sh
+printf '%s\n' "$@" > "$0.args"
+for a in "$@"; do
+	if [ "$a" = "--dump-single-json" ]; then
+		echo '{"id":"abc123","title":"Test Video","duration":12,"description":"about","formats":[{"vcodec":"none"},{"vcodec":"avc1","height":720},{"vcodec":"vp9","height":360},{"vcodec":"avc1","height":720}]}'
+		exit 0
+	fi
+done
+while [ $# -gt 0 ]; do
+	case "$1" in --paths) dir="$2"; shift ;; esac
+	shift
+done
+echo "[download]  42.0% of 1.00MiB at 1.00MiB/s ETA 00:01"
+echo "[download] 100% of 1.00MiB"
+echo data > "$dir/Test_Video [abc123].mp4"
+echo "[moling-file] $dir/Test_Video [abc123].mp4"
+echo '[moling-info] {"id":"abc123","title":"Test Video","duration":12,"extractor":"youtube"}'
+`
+
+const fakeFfprobe = `#!/bin/sh
+echo '{"format":{"format_name":"mov,mp4","duration":"10.000000","size":"1000","bit_rate":"800","tags":{"title":"Test"}},'
+echo '"streams":[{"index":0,"codec_type":"video","codec_name":"h264","width":1280,"height":720,"avg_frame_rate":"30/1"},'
+echo '{"index":1,"codec_type":"audio","codec_name":"aac","sample_rate":"44100","channels":2,"bit_rate":"128000","tags":{"language":"eng"}}]}'
+`
+
+// fakeFfmpeg writes its last argument, the output file, and reports progress.
+const fakeFfmpeg = `#!/bin/sh
+printf '%s\n' "$@" > "$0.args"
+for a in "$@"; do out="$a"; done
+echo "out_time_us=5000000"
+echo "progress=continue"
+echo audio > "$out"
+echo "out_time_us=10000000"
+echo "progress=end"
+`
+
+func writeScript(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(content), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to write %s: %s", name, err.Error())
+	}
+	return path
+}
+
+func newFakeMediaServer(t *testing.T) (*MediaServer, string, string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tools are shell scripts")
+	}
+	bin, data := t.TempDir(), t.TempDir()
+	ms := testkit.NewServiceAs[*MediaServer](t, NewMediaServer, map[string]any{
+		"ytdlp_path":   writeScript(t, bin, "yt-dlp", fakeYtDlp),
+		"ffmpeg_path":  writeScript(t, bin, "ffmpeg", fakeFfmpeg),
+		"ffprobe_path": writeScript(t, bin, "ffprobe", fakeFfprobe),
+		"data_path":    data,
+		"allowed_dirs": []any{data},
+		"allowed_urls": []any{"youtube.com", "https://example.com/media/"},
+	})
+	return ms, bin, data
+}
+
+func TestMediaDownload(t *testing.T) {
+	ms, bin, data := newFakeMediaServer(t)
+
+	text, isErr := testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": "https://www.youtube.com/watch?v=abc123", "quality": "720p"})
+	if isErr {
+		t.Fatalf("media_download failed: %s", text)
+	}
+	var res downloadResult
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if res.File != filepath.Join(data, "Test_Video [abc123].mp4") || res.Size != 5 || res.Title != "Test Video" || res.Duration != 12 {
+		t.Fatalf("unexpected result: %s", text)
+	}
+	args, err := os.ReadFile(filepath.Join(bin, "yt-dlp.args"))
+	if err != nil {
+		t.Fatalf("Failed to read arguments: %s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(string(args)), "\n")
+	if lines[len(lines)-2] != "--" || lines[len(lines)-1] != "https://www.youtube.com/watch?v=abc123" {
+		t.Fatalf("the URL must follow --: %v", lines)
+	}
+	if !strings.Contains(string(args), "bv*[height<=720]+ba/b[height<=720]/b") || !strings.Contains(string(args), "--ignore-config") {
+		t.Fatalf("unexpected arguments: %v", lines)
+	}
+
+	_, isErr = testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": "https://example.com/media/talk.mp4", "type": "audio", "audio_format": "opus"})
+	if isErr {
+		t.Fatalf("media_download of an allowed prefix failed")
+	}
+	args, _ = os.ReadFile(filepath.Join(bin, "yt-dlp.args"))
+	if !strings.Contains(string(args), "--extract-audio\n--audio-format\nopus") {
+		t.Fatalf("unexpected arguments: %s", args)
+	}
+
+	for _, u := range []string{"https://vimeo.com/1", "https://example.com/mediaX/a.mp4", "https://example.com.evil.test/media/a", "file:///etc/passwd", "https://u:p@youtube.com/x"} {
+		text, isErr = testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": u})
+		if !isErr {
+			t.Fatalf("expected %s to be rejected, got: %s", u, text)
+		}
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": "https://youtu.be/x"})
+	if !isErr || !strings.Contains(text, "allowed_urls") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": "https://youtube.com/x", "quality": "high"})
+	if !isErr {
+		t.Fatalf("expected quality error, got: %s", text)
+	}
+}
+
+func TestMediaExtractAndProbe(t *testing.T) {
+	ms, bin, data := newFakeMediaServer(t)
+	video := filepath.Join(data, "talk.mp4")
+	if err := os.WriteFile(video, []byte("video"), 0o644); err != nil {
+		t.Fatalf("Failed to write video: %s", err.Error())
+	}
+
+	text, isErr := testkit.CallHandler(t, ms.handleProbe, map[string]any{"path": "talk.mp4"})
+	if isErr {
+		t.Fatalf("media_probe failed: %s", text)
+	}
+	var info fileInfo
+	if err := json.Unmarshal([]byte(text), &info); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if info.Duration != 10 || len(info.Streams) != 2 || info.Streams[0].Height != 720 || info.Streams[1].SampleRate != 44100 || info.Streams[1].Language != "eng" {
+		t.Fatalf("unexpected probe result: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleProbe, map[string]any{"url": "https://youtube.com/watch?v=abc123"})
+	if isErr || !strings.Contains(text, `"video_heights": [`) || !strings.Contains(text, `"audio_only_formats": true`) {
+		t.Fatalf("media_probe of a URL failed: %s", text)
+	}
+	var ui urlInfo
+	_ = json.Unmarshal([]byte(text), &ui)
+	if len(ui.Heights) != 2 || ui.Heights[0] != 360 || ui.Title != "Test Video" {
+		t.Fatalf("unexpected URL probe result: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleExtractAudio, map[string]any{"path": "talk.mp4", "start": float64(5), "duration": float64(30)})
+	if isErr || !strings.Contains(text, filepath.Join(data, "talk.mp3")) {
+		t.Fatalf("media_extract_audio failed: %s", text)
+	}
+	args, _ := os.ReadFile(filepath.Join(bin, "ffmpeg.args"))
+	if !strings.Contains(string(args), "-ss\n5\n-i\n"+video+"\n-t\n30\n-vn") || !strings.Contains(string(args), "libmp3lame") {
+		t.Fatalf("unexpected arguments: %s", args)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleExtractAudio, map[string]any{"path": "talk.mp4"})
+	if !isErr || !strings.Contains(text, "already exists") {
+		t.Fatalf("expected an existing output error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleExtractAudio, map[string]any{"path": "talk.mp4", "output": "../x.mp3"})
+	if !isErr || !strings.Contains(text, "access denied") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleProbe, map[string]any{"path": "/etc/hosts"})
+	if !isErr || !strings.Contains(text, "access denied") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+}
+
+func TestMediaMissingTool(t *testing.T) {
+	ms := testkit.NewServiceAs[*MediaServer](t, NewMediaServer, map[string]any{"ytdlp_path": "moling-no-such-yt-dlp", "data_path": t.TempDir()})
+	text, isErr := testkit.CallHandler(t, ms.handleDownload, map[string]any{"url": "https://youtube.com/watch?v=x"})
+	if !isErr || !strings.Contains(text, "ytdlp_path") {
+		t.Fatalf("expected a missing tool error, got: %s", text)
+	}
+}
+
+func TestMediaConfigCheck(t *testing.T) {
+	cfg := NewMediaConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("default config should be valid: %s", err.Error())
+	}
+	cfg.AllowedURLs = []string{"YouTube.com", "HTTPS://Example.com/Media"}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	for raw, want := range map[string]bool{
+		"https://m.youtube.com/watch":     true,
+		"https://notyoutube.com/watch":    false,
+		"https://example.com/Media":       true,
+		"https://example.com/Media/a.mp4": true,
+		"https://example.com/media/a.mp4": false,
+		"http://example.com/Media/a.mp4":  false,
+	} {
+		u, _ := url.Parse(raw)
+		if got := cfg.urlAllowed(u); got != want {
+			t.Errorf("urlAllowed(%s) = %v, want %v", raw, got, want)
+		}
+	}
+	cfg.AllowedURLs = []string{"ftp://example.com"}
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected error for an ftp URL")
+	}
+}
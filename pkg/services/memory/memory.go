@@ -0,0 +1,335 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package memory provides a persistent memory and notes store for the MoLing application.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	MemoryServerName comm.MoLingServerType = "Memory"
+	MemoryDataPath                         = "memory" // Path under the data directory to store memories
+)
+
+var clientNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_\-.]+`)
+
+// MemoryServer implements the Service interface and persists facts and preferences across sessions.
+type MemoryServer struct {
+	abstract.MLService
+	config *MemoryConfig
+	store  *Store
+}
+
+// NewMemoryServer creates a new MemoryServer instance.
+func NewMemoryServer(ctx context.Context) (abstract.Service, error) {
+	mc := NewMemoryConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("MemoryServer: invalid config type")
+	}
+	mc.StorePath = filepath.Join(globalConf.BasePath, "data", MemoryDataPath)
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("MemoryServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(MemoryServerName))
+	})
+
+	ms := &MemoryServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    mc,
+	}
+	err := ms.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Init opens the store and registers the prompt and tools.
+func (ms *MemoryServer) Init() error {
+	var err error
+	ms.store, err = NewStore(ms.config.StorePath, ms.config.MaxEntries, ms.config.FullTextSearch)
+	if err != nil {
+		return err
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "memory_prompt",
+			Description: "Get the relevant functions and prompts of the Memory MCP Server",
+		},
+		HandlerFunc: ms.handlePrompt,
+	}
+	ms.AddPrompt(pe)
+
+	ms.AddTool(mcp.NewTool(
+		"memory_save",
+		mcp.WithDescription("Save a fact, note or preference to persistent memory. Saving with an existing key updates that memory."),
+		mcp.WithString("content",
+			mcp.Description("The content to remember"),
+			mcp.Required(),
+		),
+		mcp.WithString("key",
+			mcp.Description("Optional unique key, e.g. preferred_language"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional tags to group related memories"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to save into, default: the connected client's namespace"),
+		),
+	), ms.handleSave)
+
+	ms.AddTool(mcp.NewTool(
+		"memory_search",
+		mcp.WithDescription("Search persistent memory by keywords, most relevant first"),
+		mcp.WithString("query",
+			mcp.Description("Keywords to search for"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results, default: 10"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to search, default: the connected client's namespace"),
+		),
+	), ms.handleSearch)
+
+	ms.AddTool(mcp.NewTool(
+		"memory_list",
+		mcp.WithDescription("List memories, newest first, optionally filtered by tag. Use namespace '*' to list the available namespaces."),
+		mcp.WithString("tag",
+			mcp.Description("Only list memories with this tag"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results, default: 50"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to list, default: the connected client's namespace"),
+		),
+	), ms.handleList)
+
+	ms.AddTool(mcp.NewTool(
+		"memory_delete",
+		mcp.WithDescription("Delete a memory by id or key"),
+		mcp.WithString("id",
+			mcp.Description("ID or key of the memory to delete"),
+			mcp.Required(),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to delete from, default: the connected client's namespace"),
+		),
+	), ms.handleDelete)
+	return nil
+}
+
+func (ms *MemoryServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ms.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// namespace resolves the namespace for a call: explicit argument, then client name, then the configured default.
+func (ms *MemoryServer) namespace(ctx context.Context, args map[string]any) string {
+	if ns := strings.TrimSpace(abstract.GetString(args, "namespace", "")); ns != "" {
+		return ns
+	}
+	if ms.config.ClientNamespace {
+		session := server.ClientSessionFromContext(ctx)
+		if ci, ok := session.(interface{ GetClientInfo() mcp.Implementation }); ok {
+			name := strings.Trim(clientNameReplacer.ReplaceAllString(ci.GetClientInfo().Name, "_"), "_")
+			if name != "" && namespacePattern.MatchString(name) {
+				return name
+			}
+		}
+	}
+	return ms.config.DefaultNamespace
+}
+
+// handleSave handles saving a memory.
+func (ms *MemoryServer) handleSave(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	content, ok := args["content"].(string)
+	if !ok || strings.TrimSpace(content) == "" {
+		return mcp.NewToolResultError("content must be a non-empty string"), nil
+	}
+	if len(content) > ms.config.MaxContentSize {
+		return mcp.NewToolResultError(fmt.Sprintf("content is too large (%d bytes), limit is %d bytes", len(content), ms.config.MaxContentSize)), nil
+	}
+	key := abstract.GetString(args, "key", "")
+	var tags []string
+	for _, s := range abstract.GetStringSlice(args, "tags", nil) {
+		if s != "" {
+			tags = append(tags, s)
+		}
+	}
+	ns := ms.namespace(ctx, args)
+	m, err := ms.store.Save(ns, Memory{Key: strings.TrimSpace(key), Content: content, Tags: tags})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save memory: %s", err.Error())), nil
+	}
+	ms.Logger.Debug().Str("namespace", ns).Str("id", m.ID).Msg("memory saved")
+	return mcp.NewToolResultText(fmt.Sprintf("Memory saved, id: %s, namespace: %s", m.ID, ns)), nil
+}
+
+// handleSearch handles searching memories.
+func (ms *MemoryServer) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+	limit := abstract.GetInt(args, "limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	ns := ms.namespace(ctx, args)
+	results, err := ms.store.Search(ns, query, limit)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to search memory: %s", err.Error())), nil
+	}
+	if len(results) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No memories found matching '%s' in namespace %s", query, ns)), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d memories in namespace %s:\n\n", len(results), ns))
+	for _, r := range results {
+		writeMemory(&sb, r.Memory)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleList handles listing memories or namespaces.
+func (ms *MemoryServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	if ns := abstract.GetString(args, "namespace", ""); ns == "*" {
+		names, err := ms.store.Namespaces()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list namespaces: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Namespaces: %s", strings.Join(names, ", "))), nil
+	}
+	tag := abstract.GetString(args, "tag", "")
+	limit := abstract.GetInt(args, "limit", 0)
+	if limit <= 0 {
+		limit = 50
+	}
+	ns := ms.namespace(ctx, args)
+	memories, err := ms.store.List(ns, tag, limit)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list memory: %s", err.Error())), nil
+	}
+	if len(memories) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No memories in namespace %s", ns)), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d memories in namespace %s:\n\n", len(memories), ns))
+	for _, m := range memories {
+		writeMemory(&sb, m)
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleDelete handles deleting a memory.
+func (ms *MemoryServer) handleDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return mcp.NewToolResultError("id must be a non-empty string"), nil
+	}
+	ns := ms.namespace(ctx, args)
+	err := ms.store.Delete(ns, id)
+	if errors.Is(err, ErrMemoryNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("memory %s not found in namespace %s", id, ns)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete memory: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Memory %s deleted from namespace %s", id, ns)), nil
+}
+
+func writeMemory(sb *strings.Builder, m Memory) {
+	sb.WriteString(fmt.Sprintf("- id: %s", m.ID))
+	if m.Key != "" {
+		sb.WriteString(fmt.Sprintf(", key: %s", m.Key))
+	}
+	if len(m.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf(", tags: %s", strings.Join(m.Tags, ",")))
+	}
+	sb.WriteString(fmt.Sprintf(", updated: %s\n  %s\n", m.UpdatedAt.Format(time.RFC3339), m.Content))
+}
+
+// Config returns the configuration of the service as a string.
+func (ms *MemoryServer) Config() string {
+	cfg, err := json.Marshal(ms.config)
+	if err != nil {
+		ms.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ms *MemoryServer) Name() comm.MoLingServerType {
+	return MemoryServerName
+}
+
+func (ms *MemoryServer) Close() error {
+	ms.Logger.Debug().Msg("MemoryServer closed")
+	if ms.store == nil {
+		return nil
+	}
+	return ms.store.Close()
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ms *MemoryServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ms.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ms.config.Check()
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const MemoryPromptDefault = `
+You are an assistant with a persistent memory that survives across sessions. Your capabilities include:
+
+1. **Save**: Store facts, notes and user preferences with memory_save. Give important entries a stable key (e.g. "preferred_language") so that saving again updates them instead of creating duplicates, and add tags to group related entries.
+
+2. **Search**: Find relevant memories with memory_search using keywords. Search before asking the user for information they may have already told you.
+
+3. **List**: Browse recent memories with memory_list, optionally filtered by tag.
+
+4. **Delete**: Remove outdated or wrong memories with memory_delete by id or key.
+
+Memories are stored per namespace. Unless a namespace is given explicitly, the namespace of the connected client is used, so different MCP clients keep separate memories.
+
+Only store information the user would expect you to remember, and never store passwords, tokens or other secrets.
+`
+
+// MemoryConfig represents the configuration for the memory service.
+type MemoryConfig struct {
+	PromptFile       string `json:"prompt_file"` // PromptFile is the prompt file for the memory service.
+	prompt           string
+	StorePath        string `json:"store_path"`        // StorePath is the directory of the memory database.
+	DefaultNamespace string `json:"default_namespace"` // DefaultNamespace is used when neither the call nor the client provides one.
+	ClientNamespace  bool   `json:"client_namespace"`  // ClientNamespace uses the connected MCP client's name as the default namespace.
+	FullTextSearch   bool   `json:"full_text_search"`  // FullTextSearch enables ranked full-text search, otherwise substring matching is used.
+	MaxEntries       int    `json:"max_entries"`       // MaxEntries is the maximum number of memories per namespace, 0 means unlimited.
+	MaxContentSize   int    `json:"max_content_size"`  // MaxContentSize is the maximum size of a single memory, in bytes.
+}
+
+// NewMemoryConfig creates a new MemoryConfig with default values.
+func NewMemoryConfig() *MemoryConfig {
+	return &MemoryConfig{
+		prompt:           MemoryPromptDefault,
+		StorePath:        filepath.Join(os.TempDir(), ".moling", "data", "memory"),
+		DefaultNamespace: "default",
+		ClientNamespace:  true,
+		FullTextSearch:   true,
+		MaxEntries:       10000,
+		MaxContentSize:   1024 * 64,
+	}
+}
+
+// Check validates the memory configuration.
+func (cfg *MemoryConfig) Check() error {
+	cfg.prompt = MemoryPromptDefault
+	if cfg.StorePath == "" {
+		return fmt.Errorf("store_path must not be empty")
+	}
+	if !namespacePattern.MatchString(cfg.DefaultNamespace) {
+		return fmt.Errorf("%w: default_namespace %q", ErrInvalidNamespace, cfg.DefaultNamespace)
+	}
+	if cfg.MaxEntries < 0 {
+		return fmt.Errorf("max_entries must not be negative")
+	}
+	if cfg.MaxContentSize <= 0 {
+		return fmt.Errorf("max_content_size must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
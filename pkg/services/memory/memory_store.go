@@ -0,0 +1,365 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package memory
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gojue/moling/pkg/utils/sqlitedb"
+)
+
+var (
+	// ErrMemoryNotFound is returned when a memory entry does not exist.
+	ErrMemoryNotFound = errors.New("memory not found")
+	// ErrInvalidNamespace is returned when a namespace contains unsupported characters.
+	ErrInvalidNamespace = errors.New("invalid namespace")
+
+	namespacePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-.]{1,64}$`)
+)
+
+// StoreFile is the name of the database in the store directory.
+const StoreFile = "memory.db"
+
+// The FTS5 table holds the terms of tokenize rather than the text, so that CJK text is searched one rune per term.
+const storeSchema = `
+CREATE TABLE IF NOT EXISTS memories (
+	id         TEXT PRIMARY KEY,
+	namespace  TEXT NOT NULL,
+	key        TEXT NOT NULL DEFAULT '',
+	content    TEXT NOT NULL,
+	tags       TEXT NOT NULL DEFAULT '[]',
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS memories_key ON memories (namespace, key) WHERE key != '';
+CREATE INDEX IF NOT EXISTS memories_updated ON memories (namespace, updated_at);
+CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(id UNINDEXED, terms);
+`
+
+const memoryColumns = `id, key, content, tags, created_at, updated_at`
+
+// Memory is a single persisted fact, note or preference.
+type Memory struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key,omitempty"` // Key is an optional unique name, saving with an existing key replaces the entry.
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SearchResult is a memory entry together with its relevance score.
+type SearchResult struct {
+	Memory
+	Score float64 `json:"score"`
+}
+
+// Store persists memories in a SQLite database, with an FTS5 index for full-text search.
+type Store struct {
+	db         *sql.DB
+	maxEntries int
+	fullText   bool
+}
+
+// NewStore opens the store in the directory path. The directory and the database are created if they do not exist.
+func NewStore(path string, maxEntries int, fullText bool) (*Store, error) {
+	db, err := sqlitedb.Open(filepath.Join(path, StoreFile), storeSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the memory store: %w", err)
+	}
+	return &Store{db: db, maxEntries: maxEntries, fullText: fullText}, nil
+}
+
+// Close closes the database of the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save inserts a new memory, or replaces the existing one with the same key.
+func (s *Store) Save(namespace string, m Memory) (Memory, error) {
+	if err := checkNamespace(namespace); err != nil {
+		return Memory{}, err
+	}
+	tags, err := json.Marshal(m.Tags)
+	if err != nil {
+		return Memory{}, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Memory{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	m.UpdatedAt = now
+	var created int64
+	err = sql.ErrNoRows
+	if m.Key != "" {
+		err = tx.QueryRow(`SELECT id, created_at FROM memories WHERE namespace = ? AND key = ?`, namespace, m.Key).Scan(&m.ID, &created)
+	}
+	switch {
+	case err == nil:
+		m.CreatedAt = time.Unix(0, created)
+		_, err = tx.Exec(`UPDATE memories SET content = ?, tags = ?, updated_at = ? WHERE id = ?`, m.Content, string(tags), now.UnixNano(), m.ID)
+	case errors.Is(err, sql.ErrNoRows):
+		if s.maxEntries > 0 {
+			var count int
+			if err = tx.QueryRow(`SELECT COUNT(*) FROM memories WHERE namespace = ?`, namespace).Scan(&count); err != nil {
+				return Memory{}, fmt.Errorf("failed to count the memories of namespace %s: %w", namespace, err)
+			}
+			if count >= s.maxEntries {
+				return Memory{}, fmt.Errorf("namespace %s is full (%d entries), delete some memories first", namespace, s.maxEntries)
+			}
+		}
+		m.ID = newID()
+		m.CreatedAt = now
+		_, err = tx.Exec(`INSERT INTO memories (`+memoryColumns+`, namespace) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			m.ID, m.Key, m.Content, string(tags), now.UnixNano(), now.UnixNano(), namespace)
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM memories_fts WHERE id = ?`, m.ID)
+	}
+	if err == nil {
+		terms := tokenize(m.Key + " " + m.Content + " " + strings.Join(m.Tags, " "))
+		_, err = tx.Exec(`INSERT INTO memories_fts (id, terms) VALUES (?, ?)`, m.ID, strings.Join(terms, " "))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return Memory{}, fmt.Errorf("failed to save to namespace %s: %w", namespace, err)
+	}
+	return m, nil
+}
+
+// Delete removes a memory by id or key.
+func (s *Store) Delete(namespace, idOrKey string) error {
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var id string
+	err = tx.QueryRow(`SELECT id FROM memories WHERE namespace = ? AND (id = ? OR (key != '' AND key = ?))`, namespace, idOrKey, idOrKey).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMemoryNotFound
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM memories WHERE id = ?`, id)
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM memories_fts WHERE id = ?`, id)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete from namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// List returns the memories of a namespace, newest first, optionally filtered by tag.
+func (s *Store) List(namespace, tag string, limit int) ([]Memory, error) {
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+	entries, err := s.query(`SELECT `+memoryColumns+` FROM memories WHERE namespace = ? ORDER BY updated_at DESC`, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace %s: %w", namespace, err)
+	}
+	var result []Memory
+	for _, m := range entries {
+		if tag != "" && !hasTag(m.Tags, tag) {
+			continue
+		}
+		result = append(result, m)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Search finds memories matching query. With full-text search enabled, every term of the query must match and
+// results are ranked by BM25 over content, key and tags; otherwise a case-insensitive substring match is used.
+func (s *Store) Search(namespace, query string, limit int) ([]SearchResult, error) {
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+	var result []SearchResult
+	if s.fullText {
+		terms := tokenize(query)
+		if len(terms) == 0 {
+			return nil, nil
+		}
+		// Quoted terms are matched as they are, not as FTS5 query syntax
+		for i, term := range terms {
+			terms[i] = `"` + term + `"`
+		}
+		rows, err := s.db.Query(`SELECT m.id, m.key, m.content, m.tags, m.created_at, m.updated_at, -f.rank
+FROM memories_fts f JOIN memories m ON m.id = f.id
+WHERE memories_fts MATCH ? AND m.namespace = ?`, strings.Join(terms, " AND "), namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search namespace %s: %w", namespace, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var r SearchResult
+			if err = scanMemory(rows, &r.Memory, &r.Score); err != nil {
+				return nil, err
+			}
+			result = append(result, r)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to search namespace %s: %w", namespace, err)
+		}
+	} else {
+		entries, err := s.query(`SELECT `+memoryColumns+` FROM memories WHERE namespace = ?`, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search namespace %s: %w", namespace, err)
+		}
+		q := strings.ToLower(query)
+		for _, m := range entries {
+			if strings.Contains(strings.ToLower(m.Content), q) || strings.Contains(strings.ToLower(m.Key), q) {
+				result = append(result, SearchResult{Memory: m, Score: 1})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score == result[j].Score {
+			return result[i].UpdatedAt.After(result[j].UpdatedAt)
+		}
+		return result[i].Score > result[j].Score
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Namespaces returns the names of all namespaces that hold memories.
+func (s *Store) Namespaces() ([]string, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT namespace FROM memories ORDER BY namespace`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// query returns the memories selected by a statement on memoryColumns.
+func (s *Store) query(query string, args ...any) ([]Memory, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []Memory
+	for rows.Next() {
+		var m Memory
+		if err = scanMemory(rows, &m); err != nil {
+			return nil, err
+		}
+		entries = append(entries, m)
+	}
+	return entries, rows.Err()
+}
+
+// scanMemory reads the memoryColumns of a row into m, followed by extra columns.
+func scanMemory(rows *sql.Rows, m *Memory, extra ...any) error {
+	var tags string
+	var created, updated int64
+	err := rows.Scan(append([]any{&m.ID, &m.Key, &m.Content, &tags, &created, &updated}, extra...)...)
+	if err != nil {
+		return err
+	}
+	m.CreatedAt, m.UpdatedAt = time.Unix(0, created), time.Unix(0, updated)
+	return json.Unmarshal([]byte(tags), &m.Tags)
+}
+
+func checkNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("%w: %s", ErrInvalidNamespace, namespace)
+	}
+	return nil
+}
+
+// tokenize splits text into lower-case terms. CJK characters are indexed one rune per term.
+func tokenize(text string) []string {
+	var terms []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			terms = append(terms, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			flush()
+			terms = append(terms, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
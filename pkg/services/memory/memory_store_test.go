@@ -0,0 +1,131 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package memory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, 0, true)
+	if err != nil {
+		t.Fatalf("Failed to create store: %s", err.Error())
+	}
+	_, err = s.Save("default", Memory{Key: "lang", Content: "User prefers Go over Rust", Tags: []string{"pref"}})
+	if err != nil {
+		t.Fatalf("Failed to save memory: %s", err.Error())
+	}
+	_, err = s.Save("default", Memory{Content: "The office is in Shanghai"})
+	if err != nil {
+		t.Fatalf("Failed to save memory: %s", err.Error())
+	}
+	// saving with the same key replaces the entry
+	_, err = s.Save("default", Memory{Key: "lang", Content: "User prefers Go", Tags: []string{"pref"}})
+	if err != nil {
+		t.Fatalf("Failed to save memory: %s", err.Error())
+	}
+
+	// reopen to make sure the entries are persisted
+	if err = s.Close(); err != nil {
+		t.Fatalf("Failed to close store: %s", err.Error())
+	}
+	s, err = NewStore(dir, 0, true)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %s", err.Error())
+	}
+	list, err := s.List("default", "", 0)
+	if err != nil {
+		t.Fatalf("Failed to list memories: %s", err.Error())
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 memories, got %d", len(list))
+	}
+
+	results, err := s.Search("default", "prefers go", 10)
+	if err != nil {
+		t.Fatalf("Failed to search memories: %s", err.Error())
+	}
+	if len(results) != 1 || results[0].Key != "lang" {
+		t.Fatalf("Expected to find the lang memory, got %v", results)
+	}
+
+	list, _ = s.List("default", "pref", 0)
+	if len(list) != 1 {
+		t.Errorf("Expected 1 memory tagged pref, got %d", len(list))
+	}
+
+	err = s.Delete("default", "lang")
+	if err != nil {
+		t.Fatalf("Failed to delete memory: %s", err.Error())
+	}
+	err = s.Delete("default", "lang")
+	if !errors.Is(err, ErrMemoryNotFound) {
+		t.Errorf("Expected ErrMemoryNotFound, got %v", err)
+	}
+
+	_, err = s.List("../etc", "", 0)
+	if !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("Expected ErrInvalidNamespace, got %v", err)
+	}
+	_ = s.Close()
+}
+
+func TestStoreSearch(t *testing.T) {
+	s, err := NewStore(t.TempDir(), 2, true)
+	if err != nil {
+		t.Fatalf("Failed to create store: %s", err.Error())
+	}
+	defer s.Close()
+	for _, content := range []string{"办公室在上海", "Deploy with \"make release\" AND tag it"} {
+		if _, err = s.Save("team", Memory{Content: content}); err != nil {
+			t.Fatalf("Failed to save memory: %s", err.Error())
+		}
+	}
+	if _, err = s.Save("team", Memory{Content: "one too many"}); err == nil {
+		t.Error("Expected an error for a full namespace")
+	}
+	if _, err = s.Save("other", Memory{Content: "上海 weather"}); err != nil {
+		t.Fatalf("Failed to save memory: %s", err.Error())
+	}
+
+	// CJK text is matched rune by rune, FTS5 operators in the query are plain terms
+	for query, want := range map[string]int{"上海": 1, "海上": 1, "北京": 0, `"make" AND`: 1, "release OR nothing": 0} {
+		results, err := s.Search("team", query, 10)
+		if err != nil || len(results) != want {
+			t.Errorf("Search %q: expected %d results, got %v, %v", query, want, results, err)
+		}
+	}
+	names, err := s.Namespaces()
+	if err != nil || len(names) != 2 || names[0] != "other" {
+		t.Errorf("Expected the namespaces [other team], got %v, %v", names, err)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	terms := tokenize("Hello, World! 你好 v1.2")
+	expected := []string{"hello", "world", "你", "好", "v1", "2"}
+	if len(terms) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, terms)
+	}
+	for i := range terms {
+		if terms[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, terms)
+		}
+	}
+}
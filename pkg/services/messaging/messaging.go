@@ -0,0 +1,330 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package messaging provides Slack and Telegram messaging tools for the MoLing application.
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	MessagingServerName comm.MoLingServerType = "Messaging"
+)
+
+// MessagingServer implements the Service interface and sends and reads chat messages.
+type MessagingServer struct {
+	abstract.MLService
+	config     *MessagingConfig
+	messengers map[string]Messenger
+	platforms  []string // configured platforms, the first one is the default
+}
+
+// NewMessagingServer creates a new MessagingServer instance.
+func NewMessagingServer(ctx context.Context) (abstract.Service, error) {
+	mc := NewMessagingConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("MessagingServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("MessagingServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(MessagingServerName))
+	})
+
+	ms := &MessagingServer{
+		MLService:  abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:     mc,
+		messengers: make(map[string]Messenger),
+	}
+	err := ms.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Init creates a client for every platform with a token and registers the prompt and tools.
+func (ms *MessagingServer) Init() error {
+	client := &http.Client{Timeout: time.Duration(ms.config.Timeout) * time.Second}
+	if ms.config.SlackToken != "" {
+		ms.messengers[PlatformSlack] = newSlack(ms.config.SlackURL, ms.config.SlackToken, client)
+		ms.platforms = append(ms.platforms, PlatformSlack)
+	}
+	if ms.config.TelegramToken != "" {
+		ms.messengers[PlatformTelegram] = newTelegram(ms.config.TelegramURL, ms.config.TelegramToken, client)
+		ms.platforms = append(ms.platforms, PlatformTelegram)
+	}
+	if len(ms.platforms) == 0 {
+		ms.Logger.Warn().Msg("no messaging platform is configured, set slack_token or telegram_token")
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "messaging_prompt",
+			Description: "Get the relevant functions and prompts of the Messaging MCP Server",
+		},
+		HandlerFunc: ms.handlePrompt,
+	}
+	ms.AddPrompt(pe)
+
+	channelDesc := "Slack #channel, channel ID or user ID, or Telegram chat ID or @username. Prefix with telegram: or slack: to pick the platform"
+	if ms.config.DefaultChannel != "" {
+		channelDesc += ". Default: " + ms.config.DefaultChannel
+	}
+	platformOpt := mcp.WithString("platform",
+		mcp.Description("Messaging platform, default: the channel prefix or the first configured platform"),
+		mcp.Enum(PlatformSlack, PlatformTelegram),
+	)
+
+	ms.AddTool(mcp.NewTool(
+		"message_send",
+		mcp.WithDescription("Post a message to an allowed channel or user"),
+		mcp.WithString("channel",
+			mcp.Description(channelDesc),
+		),
+		mcp.WithString("text",
+			mcp.Description("Message text. Slack mrkdwn is supported on Slack"),
+			mcp.Required(),
+		),
+		mcp.WithString("thread",
+			mcp.Description("Message ID to reply to: the Slack thread ts or the Telegram message ID"),
+		),
+		platformOpt,
+	), ms.handleSend)
+
+	ms.AddTool(mcp.NewTool(
+		"channel_history",
+		mcp.WithDescription("Read the recent messages of an allowed channel, oldest first. On Telegram only the messages received by the bot in the last 24 hours are available"),
+		mcp.WithString("channel",
+			mcp.Description(channelDesc),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of messages, default: 20, maximum: %d", ms.config.MaxHistory)),
+		),
+		platformOpt,
+	), ms.handleHistory)
+
+	ms.AddTool(mcp.NewTool(
+		"user_lookup",
+		mcp.WithDescription("Find users by name, email or ID. On Telegram only a user ID or @username can be looked up"),
+		mcp.WithString("query",
+			mcp.Description("Name, display name, email or user ID"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of users, default: 10"),
+		),
+		platformOpt,
+	), ms.handleUserLookup)
+	return nil
+}
+
+func (ms *MessagingServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ms.config.prompt
+	if strings.Contains(text, "%s") {
+		allowed := "none, ask the user to configure allowed_recipients"
+		if len(ms.config.AllowedRecipients) > 0 {
+			allowed = strings.Join(ms.config.AllowedRecipients, ", ")
+		}
+		text = fmt.Sprintf(text, allowed)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// messenger picks the platform from the platform argument, a platform prefix of ref, or the
+// configured platforms. It returns the platform, its messenger and ref without the prefix.
+func (ms *MessagingServer) messenger(args map[string]any, ref string) (string, Messenger, string, error) {
+	platform := abstract.GetString(args, "platform", "")
+	if p, rest, ok := strings.Cut(ref, ":"); ok && (p == PlatformSlack || p == PlatformTelegram) {
+		if platform != "" && platform != p {
+			return "", nil, "", fmt.Errorf("channel %s does not belong to platform %s", ref, platform)
+		}
+		platform, ref = p, rest
+	}
+	if platform == "" {
+		if len(ms.platforms) == 0 {
+			return "", nil, "", fmt.Errorf("no messaging platform is configured, set slack_token or telegram_token")
+		}
+		platform = ms.platforms[0]
+	}
+	m, ok := ms.messengers[platform]
+	if !ok {
+		return "", nil, "", fmt.Errorf("platform %s is not configured", platform)
+	}
+	return platform, m, ref, nil
+}
+
+// channel resolves the channel argument and checks it against allowed_recipients, by the
+// given name as well as the resolved ID and name.
+func (ms *MessagingServer) channel(ctx context.Context, args map[string]any) (string, Messenger, *Channel, error) {
+	ref := abstract.GetString(args, "channel", "")
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		ref = ms.config.DefaultChannel
+	}
+	if ref == "" {
+		return "", nil, nil, fmt.Errorf("channel must be specified")
+	}
+	platform, m, ref, err := ms.messenger(args, ref)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(ms.config.AllowedRecipients) == 0 {
+		return "", nil, nil, fmt.Errorf("access denied - allowed_recipients is empty, no channel may be used")
+	}
+	ch, err := m.Channel(ctx, ref)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if !ms.config.recipientAllowed(platform, ref, ch.ID, ch.Name) {
+		return "", nil, nil, fmt.Errorf("access denied - %s:%s is not in allowed_recipients", platform, ref)
+	}
+	return platform, m, ch, nil
+}
+
+func (ms *MessagingServer) handleSend(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text := abstract.GetString(args, "text", "")
+	if strings.TrimSpace(text) == "" {
+		return mcp.NewToolResultError("text must be a non-empty string"), nil
+	}
+	if n := utf8.RuneCountInString(text); n > ms.config.MaxMessageSize {
+		return mcp.NewToolResultError(fmt.Sprintf("text has %d characters, the limit is %d", n, ms.config.MaxMessageSize)), nil
+	}
+	platform, m, ch, err := ms.channel(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	thread := abstract.GetString(args, "thread", "")
+	id, err := m.Send(ctx, ch.ID, text, thread)
+	if err != nil {
+		ms.Logger.Error().Err(err).Str("platform", platform).Str("channel", ch.ID).Msg("failed to send message")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send message: %s", err.Error())), nil
+	}
+	ms.Logger.Info().Str("platform", platform).Str("channel", ch.ID).Str("id", id).Msg("message sent")
+	name := ch.Name
+	if name == "" {
+		name = ch.ID
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Message sent to %s on %s, message ID: %s", name, platform, id)), nil
+}
+
+func (ms *MessagingServer) handleHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	limit := abstract.GetInt(args, "limit", min(20, ms.config.MaxHistory))
+	if limit < 1 || limit > ms.config.MaxHistory {
+		return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", ms.config.MaxHistory)), nil
+	}
+	platform, m, ch, err := ms.channel(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	messages, err := m.History(ctx, ch.ID, limit)
+	if err != nil {
+		ms.Logger.Error().Err(err).Str("platform", platform).Str("channel", ch.ID).Msg("failed to read history")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read history: %s", err.Error())), nil
+	}
+	if len(messages) == 0 {
+		return mcp.NewToolResultText("No messages found"), nil
+	}
+	return abstract.NewJSONResult(messages), nil
+}
+
+func (ms *MessagingServer) handleUserLookup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	query := abstract.GetString(args, "query", "")
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return mcp.NewToolResultError("query must be a non-empty string"), nil
+	}
+	limit := 10
+	if l := abstract.GetInt(args, "limit", 0); l >= 1 {
+		limit = min(l, 100)
+	}
+	platform, m, query, err := ms.messenger(args, query)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	users, err := m.LookupUsers(ctx, query, limit)
+	if err != nil {
+		ms.Logger.Error().Err(err).Str("platform", platform).Msg("failed to look up users")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to look up users: %s", err.Error())), nil
+	}
+	if len(users) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No users found for %s", query)), nil
+	}
+	return abstract.NewJSONResult(users), nil
+}
+
+// Config returns the configuration of the service as a string. The tokens are left out.
+func (ms *MessagingServer) Config() string {
+	cfg := *ms.config
+	cfg.SlackToken, cfg.TelegramToken = "", ""
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		ms.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (ms *MessagingServer) Name() comm.MoLingServerType {
+	return MessagingServerName
+}
+
+func (ms *MessagingServer) Close() error {
+	ms.Logger.Debug().Msg("MessagingServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ms *MessagingServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ms.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ms.config.Check()
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxResponseSize caps the size of an API response.
+const maxResponseSize = 8 * 1024 * 1024
+
+// Channel is a channel, group or direct conversation.
+type Channel struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Message is a message of a channel.
+type Message struct {
+	ID      string `json:"id"`
+	User    string `json:"user,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+	Text    string `json:"text"`
+	Time    string `json:"time"`
+	Thread  string `json:"thread,omitempty"`
+	Replies int    `json:"replies,omitempty"`
+}
+
+// User is a member of the workspace, or a Telegram user.
+type User struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	RealName    string `json:"real_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	IsBot       bool   `json:"is_bot,omitempty"`
+}
+
+// Messenger is the bot API of a messaging platform.
+type Messenger interface {
+	// Channel resolves a channel name, username or ID.
+	Channel(ctx context.Context, ref string) (*Channel, error)
+	// Send posts a message and returns its ID. thread is the message to reply to, if any.
+	Send(ctx context.Context, channelID, text, thread string) (string, error)
+	// History returns up to limit recent messages, oldest first.
+	History(ctx context.Context, channelID string, limit int) ([]Message, error)
+	LookupUsers(ctx context.Context, query string, limit int) ([]User, error)
+}
+
+// postForm posts a form and returns the response body. Errors do not include the URL, which
+// contains the bot token for Telegram.
+func postForm(ctx context.Context, client *http.Client, rawURL string, header http.Header, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("response from %s exceeds %d bytes", req.URL.Host, maxResponseSize)
+	}
+	// Both APIs describe errors in the JSON body, also for HTTP errors.
+	if resp.StatusCode >= 500 || (resp.StatusCode >= 300 && !strings.HasPrefix(strings.TrimSpace(string(data)), "{")) {
+		return nil, fmt.Errorf("HTTP %s from %s", resp.Status, req.URL.Host)
+	}
+	return data, nil
+}
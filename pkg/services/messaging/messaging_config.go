@@ -0,0 +1,117 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package messaging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const MessagingPromptDefault = `
+You are an assistant that can post to and read team chat channels. Your capabilities include:
+
+1. **Send**: Use message_send to post a message to a channel, such as #releases on Slack or a chat on Telegram, optionally as a reply in a thread.
+
+2. **Read**: Use channel_history to read the recent messages of a channel, e.g. to pick up context before you answer or to check that a message arrived.
+
+3. **People**: Use user_lookup to find a user by name, email or ID before you mention them.
+
+Only channels in the allowlist can be used: %s. Keep messages short and to the point, and only post when the user asked for it.
+`
+
+// Messaging platforms.
+const (
+	PlatformSlack    = "slack"
+	PlatformTelegram = "telegram"
+)
+
+// MessagingConfig represents the configuration for the messaging service.
+type MessagingConfig struct {
+	PromptFile        string `json:"prompt_file"` // PromptFile is the prompt file for the messaging service.
+	prompt            string
+	SlackToken        string   `json:"slack_token"`        // SlackToken is the Slack bot token, empty reads SLACK_BOT_TOKEN.
+	SlackURL          string   `json:"slack_url"`          // SlackURL is the Slack Web API URL.
+	TelegramToken     string   `json:"telegram_token"`     // TelegramToken is the Telegram bot token, empty reads TELEGRAM_BOT_TOKEN.
+	TelegramURL       string   `json:"telegram_url"`       // TelegramURL is the Telegram Bot API URL.
+	AllowedRecipients []string `json:"allowed_recipients"` // AllowedRecipients are the channels the tools may use, as platform:channel, e.g. slack:#general, slack:C0123ABC, telegram:@news or telegram:*.
+	DefaultChannel    string   `json:"default_channel"`    // DefaultChannel is used when a tool call names no channel.
+	MaxHistory        int      `json:"max_history"`        // MaxHistory is the maximum number of messages channel_history returns.
+	MaxMessageSize    int      `json:"max_message_size"`   // MaxMessageSize is the maximum length of a message, in characters.
+	Timeout           int      `json:"timeout"`            // Timeout is the timeout of a single API request. time.Second
+}
+
+// NewMessagingConfig creates a new MessagingConfig with default values.
+func NewMessagingConfig() *MessagingConfig {
+	return &MessagingConfig{
+		prompt:            MessagingPromptDefault,
+		SlackURL:          "https://slack.com/api",
+		TelegramURL:       "https://api.telegram.org",
+		AllowedRecipients: []string{},
+		MaxHistory:        100,
+		MaxMessageSize:    4000,
+		Timeout:           30,
+	}
+}
+
+// Check validates the messaging configuration.
+func (cfg *MessagingConfig) Check() error {
+	cfg.prompt = MessagingPromptDefault
+	if cfg.SlackToken == "" {
+		cfg.SlackToken = os.Getenv("SLACK_BOT_TOKEN")
+	}
+	if cfg.TelegramToken == "" {
+		cfg.TelegramToken = os.Getenv("TELEGRAM_BOT_TOKEN")
+	}
+	for _, r := range cfg.AllowedRecipients {
+		platform, channel, ok := strings.Cut(r, ":")
+		if !ok || channel == "" || (platform != PlatformSlack && platform != PlatformTelegram) {
+			return fmt.Errorf("allowed_recipients entries must look like slack:#channel or telegram:@chat, got %q", r)
+		}
+	}
+	if cfg.MaxHistory <= 0 || cfg.MaxMessageSize <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_history, max_message_size and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// recipientAllowed reports whether any of the names of a channel is in allowed_recipients.
+// Channel names and usernames are compared without case.
+func (cfg *MessagingConfig) recipientAllowed(platform string, names ...string) bool {
+	for _, r := range cfg.AllowedRecipients {
+		p, channel, _ := strings.Cut(r, ":")
+		if p != platform {
+			continue
+		}
+		if channel == "*" {
+			return true
+		}
+		for _, name := range names {
+			if name != "" && strings.EqualFold(channel, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,321 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	slackChannelID = regexp.MustCompile(`^[CGD][A-Z0-9]{6,}$`)
+	slackUserID    = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+)
+
+// slack uses the Slack Web API with a bot token.
+type slack struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+
+	mu       sync.Mutex
+	channels map[string]Channel // by lower case name
+	users    map[string]string  // user ID to name
+}
+
+func newSlack(baseURL, token string, client *http.Client) *slack {
+	return &slack{
+		client:   client,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		header:   http.Header{"Authorization": {"Bearer " + token}},
+		channels: make(map[string]Channel),
+		users:    make(map[string]string),
+	}
+}
+
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Deleted  bool   `json:"deleted"`
+	IsBot    bool   `json:"is_bot"`
+	RealName string `json:"real_name"`
+	TZ       string `json:"tz"`
+	Profile  struct {
+		DisplayName string `json:"display_name"`
+		RealName    string `json:"real_name"`
+		Email       string `json:"email"`
+		Title       string `json:"title"`
+	} `json:"profile"`
+}
+
+func (u slackUser) user() User {
+	realName := u.RealName
+	if realName == "" {
+		realName = u.Profile.RealName
+	}
+	return User{
+		ID:          u.ID,
+		Name:        u.Name,
+		RealName:    realName,
+		DisplayName: u.Profile.DisplayName,
+		Email:       u.Profile.Email,
+		Title:       u.Profile.Title,
+		Timezone:    u.TZ,
+		IsBot:       u.IsBot,
+	}
+}
+
+// call invokes a Web API method and decodes the response into out.
+func (s *slack) call(ctx context.Context, method string, form url.Values, out any) error {
+	data, err := postForm(ctx, s.client, s.baseURL+"/"+method, s.header, form)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(data, &envelope)
+	if err != nil {
+		return fmt.Errorf("invalid response to %s: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("slack %s: %s", method, envelope.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Channel resolves #name through the channel list, which is cached, and a channel ID through
+// conversations.info. User IDs are used as they are.
+func (s *slack) Channel(ctx context.Context, ref string) (*Channel, error) {
+	switch {
+	case strings.HasPrefix(ref, "#"):
+		return s.channelByName(ctx, ref)
+	case slackChannelID.MatchString(ref):
+		var resp struct {
+			Channel struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channel"`
+		}
+		err := s.call(ctx, "conversations.info", url.Values{"channel": {ref}}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		ch := &Channel{ID: resp.Channel.ID}
+		if resp.Channel.Name != "" {
+			ch.Name = "#" + resp.Channel.Name
+		}
+		return ch, nil
+	case slackUserID.MatchString(ref):
+		// chat.postMessage delivers messages for a user ID to the direct conversation with the bot.
+		return &Channel{ID: ref, Name: ref}, nil
+	}
+	return nil, fmt.Errorf("channel must be #name, a channel ID or a user ID, got %q", ref)
+}
+
+func (s *slack) channelByName(ctx context.Context, name string) (*Channel, error) {
+	key := strings.ToLower(name)
+	s.mu.Lock()
+	ch, ok := s.channels[key]
+	s.mu.Unlock()
+	if ok {
+		return &ch, nil
+	}
+	cursor := ""
+	for page := 0; page < 20; page++ {
+		form := url.Values{"types": {"public_channel,private_channel"}, "exclude_archived": {"true"}, "limit": {"1000"}}
+		if cursor != "" {
+			form.Set("cursor", cursor)
+		}
+		var resp struct {
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		err := s.call(ctx, "conversations.list", form, &resp)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for _, c := range resp.Channels {
+			s.channels["#"+strings.ToLower(c.Name)] = Channel{ID: c.ID, Name: "#" + c.Name}
+		}
+		ch, ok = s.channels[key]
+		s.mu.Unlock()
+		if ok {
+			return &ch, nil
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	return nil, fmt.Errorf("channel %s not found, the bot may not have access to it", name)
+}
+
+func (s *slack) Send(ctx context.Context, channelID, text, thread string) (string, error) {
+	form := url.Values{"channel": {channelID}, "text": {text}}
+	if thread != "" {
+		form.Set("thread_ts", thread)
+	}
+	var resp struct {
+		TS string `json:"ts"`
+	}
+	err := s.call(ctx, "chat.postMessage", form, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+func (s *slack) History(ctx context.Context, channelID string, limit int) ([]Message, error) {
+	var resp struct {
+		Messages []struct {
+			TS         string `json:"ts"`
+			User       string `json:"user"`
+			BotID      string `json:"bot_id"`
+			Username   string `json:"username"`
+			Text       string `json:"text"`
+			ThreadTS   string `json:"thread_ts"`
+			ReplyCount int    `json:"reply_count"`
+		} `json:"messages"`
+	}
+	err := s.call(ctx, "conversations.history", url.Values{"channel": {channelID}, "limit": {strconv.Itoa(limit)}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]Message, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		msg := Message{ID: m.TS, UserID: m.User, User: m.Username, Text: m.Text, Time: slackTime(m.TS), Replies: m.ReplyCount}
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			msg.Thread = m.ThreadTS
+		}
+		if m.User != "" {
+			msg.User = s.userName(ctx, m.User)
+		}
+		messages = append(messages, msg)
+	}
+	// Slack returns the newest message first.
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// userName returns the name of a user, caching it. The ID is returned when the lookup fails.
+func (s *slack) userName(ctx context.Context, id string) string {
+	s.mu.Lock()
+	name, ok := s.users[id]
+	s.mu.Unlock()
+	if ok {
+		return name
+	}
+	var resp struct {
+		User slackUser `json:"user"`
+	}
+	if err := s.call(ctx, "users.info", url.Values{"user": {id}}, &resp); err != nil {
+		return id
+	}
+	name = resp.User.Name
+	s.mu.Lock()
+	s.users[id] = name
+	s.mu.Unlock()
+	return name
+}
+
+// LookupUsers finds users by email, by ID, or by a part of their names.
+func (s *slack) LookupUsers(ctx context.Context, query string, limit int) ([]User, error) {
+	var single struct {
+		User slackUser `json:"user"`
+	}
+	switch {
+	case strings.Contains(query, "@") && strings.Contains(query, "."):
+		err := s.call(ctx, "users.lookupByEmail", url.Values{"email": {query}}, &single)
+		if err != nil {
+			return nil, err
+		}
+		return []User{single.User.user()}, nil
+	case slackUserID.MatchString(query):
+		err := s.call(ctx, "users.info", url.Values{"user": {query}}, &single)
+		if err != nil {
+			return nil, err
+		}
+		return []User{single.User.user()}, nil
+	}
+	needle := strings.ToLower(strings.TrimPrefix(query, "@"))
+	var users []User
+	cursor := ""
+	for page := 0; page < 20 && len(users) < limit; page++ {
+		form := url.Values{"limit": {"200"}}
+		if cursor != "" {
+			form.Set("cursor", cursor)
+		}
+		var resp struct {
+			Members          []slackUser `json:"members"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		err := s.call(ctx, "users.list", form, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range resp.Members {
+			if m.Deleted {
+				continue
+			}
+			u := m.user()
+			if strings.Contains(strings.ToLower(u.Name), needle) || strings.Contains(strings.ToLower(u.RealName), needle) ||
+				strings.Contains(strings.ToLower(u.DisplayName), needle) {
+				users = append(users, u)
+				if len(users) == limit {
+					break
+				}
+			}
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	return users, nil
+}
+
+// slackTime converts a message timestamp such as 1712345678.123456 to RFC 3339.
+func slackTime(ts string) string {
+	sec, _, _ := strings.Cut(ts, ".")
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.Unix(n, 0).UTC().Format(time.RFC3339)
+}
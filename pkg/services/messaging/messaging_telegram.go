@@ -0,0 +1,198 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegram uses the Telegram Bot API. Chats are addressed by their numeric ID or @username.
+type telegram struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func newTelegram(baseURL, token string, client *http.Client) *telegram {
+	return &telegram{client: client, baseURL: strings.TrimRight(baseURL, "/"), token: token}
+}
+
+type telegramChat struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type telegramUser struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+func (u telegramUser) name() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// call invokes a Bot API method and decodes its result into out.
+func (t *telegram) call(ctx context.Context, method string, form url.Values, out any) error {
+	data, err := postForm(ctx, t.client, t.baseURL+"/bot"+t.token+"/"+method, nil, form)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	err = json.Unmarshal(data, &envelope)
+	if err != nil {
+		return fmt.Errorf("invalid response to %s: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s: %s", method, envelope.Description)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+func (t *telegram) getChat(ctx context.Context, ref string) (*telegramChat, error) {
+	var chat telegramChat
+	err := t.call(ctx, "getChat", url.Values{"chat_id": {ref}}, &chat)
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (t *telegram) Channel(ctx context.Context, ref string) (*Channel, error) {
+	chat, err := t.getChat(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	ch := &Channel{ID: strconv.FormatInt(chat.ID, 10)}
+	if chat.Username != "" {
+		ch.Name = "@" + chat.Username
+	} else if chat.Title != "" {
+		ch.Name = chat.Title
+	}
+	return ch, nil
+}
+
+func (t *telegram) Send(ctx context.Context, channelID, text, thread string) (string, error) {
+	form := url.Values{"chat_id": {channelID}, "text": {text}}
+	if thread != "" {
+		id, err := strconv.Atoi(thread)
+		if err != nil {
+			return "", fmt.Errorf("thread must be the ID of the message to reply to")
+		}
+		form.Set("reply_parameters", fmt.Sprintf(`{"message_id":%d}`, id))
+	}
+	var msg struct {
+		MessageID int64 `json:"message_id"`
+	}
+	err := t.call(ctx, "sendMessage", form, &msg)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(msg.MessageID, 10), nil
+}
+
+// History returns the pending updates of the chat. Bots cannot read the chat history, so these
+// are only the messages the bot received in the last 24 hours and has not confirmed. It does
+// not work while a webhook is set for the bot.
+func (t *telegram) History(ctx context.Context, channelID string, limit int) ([]Message, error) {
+	type telegramMessage struct {
+		MessageID      int64         `json:"message_id"`
+		Date           int64         `json:"date"`
+		Chat           telegramChat  `json:"chat"`
+		From           *telegramUser `json:"from"`
+		Text           string        `json:"text"`
+		Caption        string        `json:"caption"`
+		ReplyToMessage *struct {
+			MessageID int64 `json:"message_id"`
+		} `json:"reply_to_message"`
+	}
+	var updates []struct {
+		Message     *telegramMessage `json:"message"`
+		ChannelPost *telegramMessage `json:"channel_post"`
+	}
+	form := url.Values{"limit": {"100"}, "allowed_updates": {`["message","channel_post"]`}}
+	err := t.call(ctx, "getUpdates", form, &updates)
+	if err != nil {
+		return nil, err
+	}
+	var messages []Message
+	for _, u := range updates {
+		m := u.Message
+		if m == nil {
+			m = u.ChannelPost
+		}
+		if m == nil || strconv.FormatInt(m.Chat.ID, 10) != channelID {
+			continue
+		}
+		msg := Message{ID: strconv.FormatInt(m.MessageID, 10), Text: m.Text, Time: time.Unix(m.Date, 0).UTC().Format(time.RFC3339)}
+		if msg.Text == "" {
+			msg.Text = m.Caption
+		}
+		if m.From != nil {
+			msg.User, msg.UserID = m.From.name(), strconv.FormatInt(m.From.ID, 10)
+		}
+		if m.ReplyToMessage != nil {
+			msg.Thread = strconv.FormatInt(m.ReplyToMessage.MessageID, 10)
+		}
+		messages = append(messages, msg)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Time < messages[j].Time })
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// LookupUsers reads a user by ID or @username. The Bot API cannot search users by name.
+func (t *telegram) LookupUsers(ctx context.Context, query string, limit int) ([]User, error) {
+	chat, err := t.getChat(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if chat.Type != "private" {
+		return nil, fmt.Errorf("%s is a %s chat, not a user", query, chat.Type)
+	}
+	return []User{{
+		ID:       strconv.FormatInt(chat.ID, 10),
+		Name:     chat.Username,
+		RealName: strings.TrimSpace(chat.FirstName + " " + chat.LastName),
+	}}, nil
+}
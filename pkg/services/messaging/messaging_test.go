@@ -0,0 +1,252 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package messaging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestMessagingSlack(t *testing.T) {
+	var posted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			writeJSON(w, map[string]any{"ok": false, "error": "invalid_auth"})
+			return
+		}
+		_ = r.ParseForm()
+		switch r.URL.Path {
+		case "/conversations.list":
+			writeJSON(w, map[string]any{"ok": true, "channels": []map[string]any{
+				{"id": "C0000GENERAL", "name": "general"},
+				{"id": "C00RELEASES", "name": "releases"},
+			}})
+		case "/conversations.info":
+			writeJSON(w, map[string]any{"ok": true, "channel": map[string]any{"id": r.Form.Get("channel"), "name": "random"}})
+		case "/chat.postMessage":
+			posted = append(posted, r.Form.Get("channel")+"|"+r.Form.Get("text")+"|"+r.Form.Get("thread_ts"))
+			writeJSON(w, map[string]any{"ok": true, "ts": "1712345678.000200"})
+		case "/conversations.history":
+			writeJSON(w, map[string]any{"ok": true, "messages": []map[string]any{
+				{"ts": "1712345679.000100", "user": "U0000ALICE", "text": "second", "thread_ts": "1712345678.000100"},
+				{"ts": "1712345678.000100", "user": "U0000ALICE", "text": "first", "reply_count": 1},
+			}})
+		case "/users.info":
+			writeJSON(w, map[string]any{"ok": true, "user": map[string]any{"id": r.Form.Get("user"), "name": "alice"}})
+		case "/users.lookupByEmail":
+			writeJSON(w, map[string]any{"ok": true, "user": map[string]any{"id": "U0000ALICE", "name": "alice",
+				"profile": map[string]any{"email": r.Form.Get("email"), "real_name": "Alice Doe"}}})
+		case "/users.list":
+			writeJSON(w, map[string]any{"ok": true, "members": []map[string]any{
+				{"id": "U0000ALICE", "name": "alice", "real_name": "Alice Doe"},
+				{"id": "U00000BOB", "name": "bob", "real_name": "Bob Roe"},
+				{"id": "U000CAROL", "name": "carol", "real_name": "Carol Doe", "deleted": true},
+			}})
+		default:
+			writeJSON(w, map[string]any{"ok": false, "error": "unknown_method"})
+		}
+	}))
+	defer ts.Close()
+
+	ms := testkit.NewServiceAs[*MessagingServer](t, NewMessagingServer, map[string]any{
+		"slack_token":        "xoxb-test",
+		"slack_url":          ts.URL,
+		"allowed_recipients": []any{"slack:#releases"},
+		"default_channel":    "#releases",
+	})
+
+	text, isErr := testkit.CallHandler(t, ms.handleSend, map[string]any{"text": "v1.2.0 is out", "thread": "1712345678.000100"})
+	if isErr || !strings.Contains(text, "1712345678.000200") {
+		t.Fatalf("message_send failed: %s", text)
+	}
+	if len(posted) != 1 || posted[0] != "C00RELEASES|v1.2.0 is out|1712345678.000100" {
+		t.Fatalf("unexpected posts: %v", posted)
+	}
+
+	// The channel ID of an allowed channel resolves to its name but #random is not allowed.
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "C0000RANDOM", "text": "hi"})
+	if !isErr || !strings.Contains(text, "allowed_recipients") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "#General", "text": "hi"})
+	if !isErr || !strings.Contains(text, "allowed_recipients") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "telegram:@news", "text": "hi"})
+	if !isErr || !strings.Contains(text, "not configured") {
+		t.Fatalf("expected unconfigured platform error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"text": strings.Repeat("x", 4001)})
+	if !isErr || !strings.Contains(text, "limit is 4000") {
+		t.Fatalf("expected size error, got: %s", text)
+	}
+	if len(posted) != 1 {
+		t.Fatalf("denied messages were posted: %v", posted)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleHistory, map[string]any{"channel": "slack:#releases", "limit": float64(10)})
+	if isErr {
+		t.Fatalf("channel_history failed: %s", text)
+	}
+	var messages []Message
+	if err := json.Unmarshal([]byte(text), &messages); err != nil {
+		t.Fatalf("Failed to parse history: %s", err.Error())
+	}
+	if len(messages) != 2 || messages[0].Text != "first" || messages[0].User != "alice" || messages[0].Replies != 1 ||
+		messages[1].Thread != "1712345678.000100" || messages[0].Time != "2024-04-05T19:34:38Z" {
+		t.Fatalf("unexpected history: %+v", messages)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleHistory, map[string]any{"limit": float64(101)})
+	if !isErr {
+		t.Fatalf("expected limit error, got: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleUserLookup, map[string]any{"query": "alice@example.com"})
+	if isErr || !strings.Contains(text, "Alice Doe") || !strings.Contains(text, "alice@example.com") {
+		t.Fatalf("user_lookup by email failed: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleUserLookup, map[string]any{"query": "doe"})
+	if isErr || !strings.Contains(text, "U0000ALICE") || strings.Contains(text, "U000CAROL") || strings.Contains(text, "U00000BOB") {
+		t.Fatalf("user_lookup by name failed: %s", text)
+	}
+
+	if strings.Contains(ms.Config(), "xoxb-test") {
+		t.Fatalf("config leaks the token: %s", ms.Config())
+	}
+}
+
+func TestMessagingTelegram(t *testing.T) {
+	var sent []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := strings.CutPrefix(r.URL.Path, "/bot123:abc/")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(w, map[string]any{"ok": false, "description": "Unauthorized"})
+			return
+		}
+		_ = r.ParseForm()
+		switch method {
+		case "getChat":
+			switch r.Form.Get("chat_id") {
+			case "@news", "-1001":
+				writeJSON(w, map[string]any{"ok": true, "result": map[string]any{"id": -1001, "type": "channel", "title": "News", "username": "news"}})
+			case "@ops":
+				writeJSON(w, map[string]any{"ok": true, "result": map[string]any{"id": -1002, "type": "supergroup", "title": "Ops", "username": "ops"}})
+			case "@alice":
+				writeJSON(w, map[string]any{"ok": true, "result": map[string]any{"id": 42, "type": "private", "username": "alice", "first_name": "Alice", "last_name": "Doe"}})
+			default:
+				writeJSON(w, map[string]any{"ok": false, "description": "Bad Request: chat not found"})
+			}
+		case "sendMessage":
+			sent = append(sent, r.Form.Get("chat_id")+"|"+r.Form.Get("text")+"|"+r.Form.Get("reply_parameters"))
+			writeJSON(w, map[string]any{"ok": true, "result": map[string]any{"message_id": 7}})
+		case "getUpdates":
+			writeJSON(w, map[string]any{"ok": true, "result": []map[string]any{
+				{"update_id": 1, "channel_post": map[string]any{"message_id": 5, "date": 1712345600, "chat": map[string]any{"id": -1001}, "text": "hello"}},
+				{"update_id": 2, "message": map[string]any{"message_id": 9, "date": 1712345500, "chat": map[string]any{"id": -1002}, "text": "other chat"}},
+				{"update_id": 3, "message": map[string]any{"message_id": 6, "date": 1712345700, "chat": map[string]any{"id": -1001},
+					"from": map[string]any{"id": 42, "username": "alice"}, "text": "reply", "reply_to_message": map[string]any{"message_id": 5}}},
+			}})
+		default:
+			writeJSON(w, map[string]any{"ok": false, "description": "Not Found"})
+		}
+	}))
+	defer ts.Close()
+
+	ms := testkit.NewServiceAs[*MessagingServer](t, NewMessagingServer, map[string]any{
+		"telegram_token":     "123:abc",
+		"telegram_url":       ts.URL,
+		"allowed_recipients": []any{"telegram:@news"},
+	})
+
+	text, isErr := testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "@news", "text": "hello", "thread": "5"})
+	if isErr || !strings.Contains(text, "message ID: 7") {
+		t.Fatalf("message_send failed: %s", text)
+	}
+	if len(sent) != 1 || sent[0] != `-1001|hello|{"message_id":5}` {
+		t.Fatalf("unexpected messages: %v", sent)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "@ops", "text": "hello"})
+	if !isErr || !strings.Contains(text, "allowed_recipients") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleSend, map[string]any{"text": "hello"})
+	if !isErr || !strings.Contains(text, "channel must be specified") {
+		t.Fatalf("expected missing channel error, got: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleHistory, map[string]any{"channel": "telegram:@news"})
+	if isErr {
+		t.Fatalf("channel_history failed: %s", text)
+	}
+	var messages []Message
+	if err := json.Unmarshal([]byte(text), &messages); err != nil {
+		t.Fatalf("Failed to parse history: %s", err.Error())
+	}
+	if len(messages) != 2 || messages[0].Text != "hello" || messages[1].User != "@alice" || messages[1].Thread != "5" {
+		t.Fatalf("unexpected history: %+v", messages)
+	}
+
+	text, isErr = testkit.CallHandler(t, ms.handleUserLookup, map[string]any{"query": "@alice"})
+	if isErr || !strings.Contains(text, "Alice Doe") {
+		t.Fatalf("user_lookup failed: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ms.handleUserLookup, map[string]any{"query": "@ops"})
+	if !isErr || !strings.Contains(text, "not a user") {
+		t.Fatalf("expected not a user error, got: %s", text)
+	}
+	if strings.Contains(ms.Config(), "123:abc") {
+		t.Fatalf("config leaks the token: %s", ms.Config())
+	}
+}
+
+func TestMessagingEmptyAllowlist(t *testing.T) {
+	ms := testkit.NewServiceAs[*MessagingServer](t, NewMessagingServer, map[string]any{"slack_token": "xoxb-test", "slack_url": "http://127.0.0.1:1"})
+	text, isErr := testkit.CallHandler(t, ms.handleSend, map[string]any{"channel": "#general", "text": "hi"})
+	if !isErr || !strings.Contains(text, "allowed_recipients is empty") {
+		t.Fatalf("expected access denied, got: %s", text)
+	}
+}
+
+func TestMessagingConfigCheck(t *testing.T) {
+	cfg := NewMessagingConfig()
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("default config should be valid: %s", err.Error())
+	}
+	cfg.AllowedRecipients = []string{"#general"}
+	if err := cfg.Check(); err == nil {
+		t.Fatalf("expected error for a recipient without platform")
+	}
+	cfg.AllowedRecipients = []string{"slack:#General", "telegram:*"}
+	if err := cfg.Check(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !cfg.recipientAllowed(PlatformSlack, "C123", "#general") || cfg.recipientAllowed(PlatformSlack, "#random") ||
+		!cfg.recipientAllowed(PlatformTelegram, "@anything") {
+		t.Fatalf("unexpected recipientAllowed results")
+	}
+}
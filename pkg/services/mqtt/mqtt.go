@@ -0,0 +1,469 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package mqtt provides an MQTT client for the MoLing application.
+package mqtt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	MQTTServerName comm.MoLingServerType = "MQTT"
+)
+
+// messageView is the JSON representation of a received message.
+type messageView struct {
+	Topic      string    `json:"topic"`
+	Payload    string    `json:"payload"`
+	Encoding   string    `json:"encoding,omitempty"` // base64 for binary payloads
+	Size       int       `json:"size"`
+	Truncated  bool      `json:"truncated,omitempty"`
+	QoS        byte      `json:"qos"`
+	Retain     bool      `json:"retain"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// MQTTServer implements the Service interface and publishes and collects messages on MQTT brokers.
+type MQTTServer struct {
+	abstract.MLService
+	config  *MQTTConfig
+	brokers map[string]BrokerConfig
+}
+
+// NewMQTTServer creates a new MQTTServer instance.
+func NewMQTTServer(ctx context.Context) (abstract.Service, error) {
+	mc := NewMQTTConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("MQTTServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("MQTTServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(MQTTServerName))
+	})
+
+	ms := &MQTTServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    mc,
+		brokers:   make(map[string]BrokerConfig),
+	}
+	err := ms.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Init registers the prompt and tools of the MQTT service.
+func (ms *MQTTServer) Init() error {
+	for _, b := range ms.config.Brokers {
+		ms.brokers[b.Name] = b
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "mqtt_prompt",
+			Description: "Get the relevant functions and prompts of the MQTT MCP Server",
+		},
+		HandlerFunc: ms.handlePrompt,
+	}
+	ms.AddPrompt(pe)
+
+	ms.AddTool(mcp.NewTool(
+		"mqtt_list_brokers",
+		mcp.WithDescription("List the configured MQTT brokers and the topic filters each of them allows"),
+	), ms.handleListBrokers)
+
+	ms.AddTool(mcp.NewTool(
+		"mqtt_publish",
+		mcp.WithDescription("Publish a message to a topic. Publish an empty retained message to clear the retained message of a topic"),
+		mcp.WithString("broker",
+			mcp.Description("Configured broker name"),
+			mcp.Required(),
+		),
+		mcp.WithString("topic",
+			mcp.Description("Topic name, without wildcards"),
+			mcp.Required(),
+		),
+		mcp.WithString("payload",
+			mcp.Description("Message payload"),
+		),
+		mcp.WithBoolean("base64",
+			mcp.Description("The payload is base64 encoded binary data, default: false"),
+		),
+		mcp.WithNumber("qos",
+			mcp.Description("Quality of service 0, 1 or 2, default: 0"),
+		),
+		mcp.WithBoolean("retain",
+			mcp.Description("Ask the broker to retain the message for future subscribers, default: false"),
+		),
+	), ms.handlePublish)
+
+	ms.AddTool(mcp.NewTool(
+		"mqtt_subscribe_collect",
+		mcp.WithDescription("Subscribe to topic filters and collect the messages that arrive within a number of seconds"),
+		mcp.WithString("broker",
+			mcp.Description("Configured broker name"),
+			mcp.Required(),
+		),
+		mcp.WithArray("topics",
+			mcp.Description("Topic filters, wildcards + and # are supported"),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("seconds",
+			mcp.Description("How long to collect messages, default: 10"),
+		),
+		mcp.WithNumber("max_messages",
+			mcp.Description("Stop after this many messages, default: 50"),
+		),
+		mcp.WithNumber("qos",
+			mcp.Description("Maximum quality of service of the subscription 0, 1 or 2, default: 0"),
+		),
+		mcp.WithBoolean("include_retained",
+			mcp.Description("Include the retained messages delivered right after subscribing, default: true"),
+		),
+	), ms.handleCollect)
+
+	ms.AddTool(mcp.NewTool(
+		"mqtt_retained",
+		mcp.WithDescription("Read the retained messages of the topics matching a filter, which usually hold the current state of devices"),
+		mcp.WithString("broker",
+			mcp.Description("Configured broker name"),
+			mcp.Required(),
+		),
+		mcp.WithString("topic",
+			mcp.Description("Topic filter, e.g. home/+/state or zigbee2mqtt/#"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("wait",
+			mcp.Description("Seconds to wait for retained messages, default: 2"),
+		),
+	), ms.handleRetained)
+	return nil
+}
+
+func (ms *MQTTServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ms.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+func (ms *MQTTServer) broker(args map[string]any) (BrokerConfig, error) {
+	name := abstract.GetString(args, "broker", "")
+	b, ok := ms.brokers[name]
+	if !ok {
+		return b, fmt.Errorf("broker %q is not configured", name)
+	}
+	return b, nil
+}
+
+func (ms *MQTTServer) dial(ctx context.Context, b BrokerConfig) (*client, error) {
+	c, err := dial(ctx, b, time.Duration(ms.config.Timeout)*time.Second, time.Duration(ms.config.KeepAlive)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker %s: %w", b.Name, err)
+	}
+	return c, nil
+}
+
+// allowed reports whether a topic or filter is covered by the topic allowlist of a broker.
+func allowed(b BrokerConfig, topic string) bool {
+	if len(b.Topics) == 0 {
+		return true
+	}
+	for _, f := range b.Topics {
+		if topicMatch(f, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func qosArg(args map[string]any) (byte, error) {
+	qos := abstract.GetFloat(args, "qos", 0)
+	if qos != 0 && qos != 1 && qos != 2 {
+		return 0, fmt.Errorf("qos must be 0, 1 or 2")
+	}
+	return byte(qos), nil
+}
+
+func (ms *MQTTServer) handleListBrokers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if len(ms.config.Brokers) == 0 {
+		return mcp.NewToolResultText("No MQTT brokers are configured."), nil
+	}
+	var sb strings.Builder
+	for _, b := range ms.config.Brokers {
+		topics := "all topics"
+		if len(b.Topics) > 0 {
+			topics = strings.Join(b.Topics, ", ")
+		}
+		mode := ""
+		if b.ReadOnly {
+			mode = ", read only"
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s (%s%s)\n", b.Name, b.URL, topics, mode))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+func (ms *MQTTServer) handlePublish(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, err := ms.broker(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if b.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("broker %s is read only", b.Name)), nil
+	}
+	topic := abstract.GetString(args, "topic", "")
+	err = validTopic(topic)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !allowed(b, topic) {
+		return mcp.NewToolResultError(fmt.Sprintf("topic %s is not allowed on broker %s", topic, b.Name)), nil
+	}
+	qos, err := qosArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	payload := abstract.GetString(args, "payload", "")
+	data := []byte(payload)
+	if isBase64 := abstract.GetBool(args, "base64", false); isBase64 {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid base64 payload: %s", err.Error())), nil
+		}
+	}
+	retain := abstract.GetBool(args, "retain", false)
+
+	c, err := ms.dial(ctx, b)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer c.close()
+	err = c.publish(ctx, Message{Topic: topic, Payload: data, QoS: qos, Retain: retain})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to publish to %s: %s", topic, err.Error())), nil
+	}
+	ms.Logger.Info().Str("broker", b.Name).Str("topic", topic).Int("size", len(data)).Bool("retain", retain).Msg("message published")
+	return mcp.NewToolResultText(fmt.Sprintf("Published %d bytes to %s with qos %d", len(data), topic, qos)), nil
+}
+
+func (ms *MQTTServer) handleCollect(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, err := ms.broker(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	rawTopics, _ := args["topics"].([]any)
+	var filters []string
+	for _, t := range rawTopics {
+		filter, ok := t.(string)
+		if !ok {
+			return mcp.NewToolResultError("topics must be an array of strings"), nil
+		}
+		err = validFilter(filter)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if !allowed(b, filter) {
+			return mcp.NewToolResultError(fmt.Sprintf("topic filter %s is not allowed on broker %s", filter, b.Name)), nil
+		}
+		filters = append(filters, filter)
+	}
+	if len(filters) == 0 {
+		return mcp.NewToolResultError("at least one topic filter is required"), nil
+	}
+	qos, err := qosArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	seconds := 10
+	if s := abstract.GetInt(args, "seconds", 0); s > 0 {
+		seconds = min(s, ms.config.MaxCollect)
+	}
+	limit := 50
+	if l := abstract.GetInt(args, "max_messages", 0); l > 0 {
+		limit = l
+	}
+	limit = min(limit, ms.config.MaxMessages)
+	includeRetained := abstract.GetBool(args, "include_retained", true)
+
+	c, err := ms.dial(ctx, b)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer c.close()
+	err = c.subscribe(ctx, filters, qos)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to subscribe: %s", err.Error())), nil
+	}
+	messages, err := ms.collect(ctx, c, time.Now().Add(time.Duration(seconds)*time.Second), limit, func(m Message) bool {
+		return includeRetained || !m.Retain
+	}, 0)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to receive messages: %s", err.Error())), nil
+	}
+	return ms.result(messages, fmt.Sprintf("No messages received within %d seconds.", seconds))
+}
+
+func (ms *MQTTServer) handleRetained(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, err := ms.broker(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	filter := abstract.GetString(args, "topic", "")
+	err = validFilter(filter)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !allowed(b, filter) {
+		return mcp.NewToolResultError(fmt.Sprintf("topic filter %s is not allowed on broker %s", filter, b.Name)), nil
+	}
+	wait := 2.0
+	if w := abstract.GetFloat(args, "wait", 0); w > 0 {
+		wait = min(w, float64(ms.config.MaxCollect))
+	}
+
+	c, err := ms.dial(ctx, b)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer c.close()
+	err = c.subscribe(ctx, []string{filter}, 0)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to subscribe: %s", err.Error())), nil
+	}
+	// retained messages are delivered in a burst right after subscribing, stop once it is over
+	deadline := time.Now().Add(time.Duration(wait * float64(time.Second)))
+	messages, err := ms.collect(ctx, c, deadline, ms.config.MaxMessages, func(m Message) bool {
+		return m.Retain
+	}, 500*time.Millisecond)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to receive messages: %s", err.Error())), nil
+	}
+	return ms.result(messages, fmt.Sprintf("No retained messages match %s.", filter))
+}
+
+// collect receives messages until deadline or limit. With a positive quiet period, it stops early
+// once messages have arrived and none followed for that long.
+func (ms *MQTTServer) collect(ctx context.Context, c *client, deadline time.Time, limit int, keep func(Message) bool, quiet time.Duration) ([]Message, error) {
+	var messages []Message
+	for len(messages) < limit && ctx.Err() == nil {
+		until := deadline
+		if quiet > 0 && len(messages) > 0 {
+			if q := time.Now().Add(quiet); q.Before(until) {
+				until = q
+			}
+		}
+		m, err := c.receive(ctx, until)
+		if errors.Is(err, errDeadline) || ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			return messages, err
+		}
+		if keep(m) {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+func (ms *MQTTServer) result(messages []Message, empty string) (*mcp.CallToolResult, error) {
+	if len(messages) == 0 {
+		return mcp.NewToolResultText(empty), nil
+	}
+	views := make([]messageView, 0, len(messages))
+	for _, m := range messages {
+		v := messageView{Topic: m.Topic, Size: len(m.Payload), QoS: m.QoS, Retain: m.Retain, ReceivedAt: m.ReceivedAt}
+		payload := m.Payload
+		if len(payload) > ms.config.MaxPayload {
+			payload = payload[:ms.config.MaxPayload]
+			v.Truncated = true
+		}
+		if utf8.Valid(payload) {
+			v.Payload = string(payload)
+		} else {
+			v.Payload = base64.StdEncoding.EncodeToString(payload)
+			v.Encoding = "base64"
+		}
+		views = append(views, v)
+	}
+	data, err := json.MarshalIndent(views, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode messages: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (ms *MQTTServer) Config() string {
+	cfg, err := json.Marshal(ms.config)
+	if err != nil {
+		ms.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ms *MQTTServer) Name() comm.MoLingServerType {
+	return MQTTServerName
+}
+
+func (ms *MQTTServer) Close() error {
+	ms.Logger.Debug().Msg("MQTTServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ms *MQTTServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ms.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ms.config.Check()
+}
@@ -0,0 +1,257 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Every tool call uses its own short-lived clean session, so reconnecting and persistent state are disabled.
+
+const (
+	subscribeFailure    = 0x80
+	defaultPort         = "1883"
+	defaultTLSPort      = "8883"
+	clientIDPrefix      = "moling-"
+	clientIDRandomBytes = 6
+	receiveQueueSize    = 1024
+	disconnectQuiesce   = 250 // milliseconds
+)
+
+var errDeadline = errors.New("deadline reached")
+
+// Message is an application message received from or sent to a broker.
+type Message struct {
+	Topic      string
+	Payload    []byte
+	QoS        byte
+	Retain     bool
+	ReceivedAt time.Time
+}
+
+// client is a connected MQTT session. Received messages are queued until receive reads them.
+type client struct {
+	conn     paho.Client
+	timeout  time.Duration
+	messages chan Message
+	lost     chan struct{}
+	lostErr  error
+	lostOnce sync.Once
+}
+
+// dial connects to a broker and completes the MQTT handshake.
+func dial(ctx context.Context, b BrokerConfig, timeout, keepAlive time.Duration) (*client, error) {
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return nil, err
+	}
+	secure := u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts"
+	if u.Port() == "" {
+		port := defaultPort
+		if secure {
+			port = defaultTLSPort
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	clientID := b.ClientID
+	if clientID == "" {
+		clientID = randomClientID()
+	}
+	c := &client{timeout: timeout, messages: make(chan Message, receiveQueueSize), lost: make(chan struct{})}
+	opts := paho.NewClientOptions().
+		AddBroker(u.Scheme + "://" + u.Host).
+		SetClientID(clientID).
+		SetUsername(b.Username).
+		SetPassword(b.Password).
+		SetCleanSession(true).
+		SetKeepAlive(keepAlive).
+		SetConnectTimeout(timeout).
+		SetWriteTimeout(timeout).
+		SetAutoReconnect(false).
+		SetDefaultPublishHandler(c.onMessage).
+		SetConnectionLostHandler(c.onConnectionLost)
+	if secure {
+		tlsConf := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: b.InsecureSkipVerify}
+		if b.CACert != "" {
+			pem, err := os.ReadFile(b.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+			}
+			tlsConf.RootCAs = x509.NewCertPool()
+			if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", b.CACert)
+			}
+		}
+		opts.SetTLSConfig(tlsConf)
+	}
+	c.conn = paho.NewClient(opts)
+	err = c.wait(ctx, c.conn.Connect())
+	if err != nil {
+		c.conn.Disconnect(0)
+		return nil, err
+	}
+	return c, nil
+}
+
+func randomClientID() string {
+	b := make([]byte, clientIDRandomBytes)
+	_, _ = rand.Read(b)
+	return clientIDPrefix + hex.EncodeToString(b)
+}
+
+// onMessage queues an incoming message, dropping it if nobody has been reading the queue.
+func (c *client) onMessage(_ paho.Client, m paho.Message) {
+	select {
+	case c.messages <- Message{Topic: m.Topic(), Payload: m.Payload(), QoS: m.Qos(), Retain: m.Retained(), ReceivedAt: time.Now()}:
+	default:
+	}
+}
+
+func (c *client) onConnectionLost(_ paho.Client, err error) {
+	c.lostOnce.Do(func() {
+		c.lostErr = err
+		close(c.lost)
+	})
+}
+
+// wait waits for the broker to acknowledge the operation of token.
+func (c *client) wait(ctx context.Context, token paho.Token) error {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return fmt.Errorf("timed out waiting for the broker to acknowledge")
+	}
+}
+
+// publish sends a message and waits for the acknowledgements its QoS level requires.
+func (c *client) publish(ctx context.Context, m Message) error {
+	return c.wait(ctx, c.conn.Publish(m.Topic, m.QoS, m.Retain, m.Payload))
+}
+
+// subscribe subscribes to topic filters and fails if the broker rejects any of them.
+func (c *client) subscribe(ctx context.Context, filters []string, qos byte) error {
+	subs := make(map[string]byte, len(filters))
+	for _, f := range filters {
+		subs[f] = qos
+	}
+	token := c.conn.SubscribeMultiple(subs, nil)
+	err := c.wait(ctx, token)
+	if err != nil {
+		return err
+	}
+	result := token.(*paho.SubscribeToken).Result()
+	for _, f := range filters {
+		if result[f] == subscribeFailure {
+			return fmt.Errorf("the broker rejected the subscription to %s", f)
+		}
+	}
+	return nil
+}
+
+// receive returns the next message before deadline, or errDeadline.
+func (c *client) receive(ctx context.Context, deadline time.Time) (Message, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case m := <-c.messages:
+		return m, nil
+	case <-c.lost:
+		return Message{}, fmt.Errorf("connection lost: %w", c.lostErr)
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-timer.C:
+		return Message{}, errDeadline
+	}
+}
+
+// close disconnects gracefully.
+func (c *client) close() {
+	c.conn.Disconnect(disconnectQuiesce)
+}
+
+// validTopic checks a topic name used for publishing.
+func validTopic(topic string) error {
+	if topic == "" || len(topic) > 65535 {
+		return fmt.Errorf("topic must be between 1 and 65535 bytes")
+	}
+	if strings.ContainsAny(topic, "+#\x00") {
+		return fmt.Errorf("topic %q must not contain wildcards", topic)
+	}
+	return nil
+}
+
+// validFilter checks a topic filter used for subscribing.
+func validFilter(filter string) error {
+	if filter == "" || len(filter) > 65535 || strings.ContainsRune(filter, 0) {
+		return fmt.Errorf("invalid topic filter %q", filter)
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("invalid topic filter %q: + must occupy a whole level", filter)
+		}
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("invalid topic filter %q: # must be the last level", filter)
+		}
+	}
+	return nil
+}
+
+// topicMatch reports whether a topic matches a filter. Wildcards in topic are compared literally,
+// which makes it usable to check that one filter is covered by another.
+func topicMatch(filter, topic string) bool {
+	fl := strings.Split(filter, "/")
+	tl := strings.Split(topic, "/")
+	for i, f := range fl {
+		if f == "#" {
+			// topics starting with $ are not matched by wildcards at the first level
+			return i > 0 || !strings.HasPrefix(topic, "$")
+		}
+		if i >= len(tl) {
+			return false
+		}
+		if f == "+" {
+			if i == 0 && strings.HasPrefix(tl[0], "$") {
+				return false
+			}
+			continue
+		}
+		if f != tl[i] {
+			return false
+		}
+	}
+	return len(fl) == len(tl)
+}
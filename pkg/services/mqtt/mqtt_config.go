@@ -0,0 +1,120 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package mqtt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+const MQTTPromptDefault = `
+You are an assistant that can talk to MQTT brokers, e.g. to read sensors and drive home-automation devices. Your capabilities include:
+
+1. **Publish**: Use mqtt_publish to send a message to a topic. Set retain=true for state that new subscribers should receive, and publish an empty retained message to clear it.
+
+2. **Collect**: Use mqtt_subscribe_collect to subscribe to one or more topic filters (wildcards + and # are supported) and collect the messages that arrive within a number of seconds.
+
+3. **Retained messages**: Use mqtt_retained to read the retained messages below a topic filter, which usually describe the current state of devices.
+
+Use mqtt_list_brokers to see the configured brokers and the topics you may use. Inspect the current state before publishing commands, and confirm with the user before switching devices.
+`
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,64}$`)
+
+// BrokerConfig describes an MQTT broker.
+type BrokerConfig struct {
+	Name               string   `json:"name"`                 // Name is the alias used in tool calls.
+	URL                string   `json:"url"`                  // URL is the broker address, e.g. tcp://localhost:1883 or ssl://broker:8883.
+	Username           string   `json:"username"`             // Username is used for authentication.
+	Password           string   `json:"password"`             // Password is used for authentication.
+	ClientID           string   `json:"client_id"`            // ClientID is the MQTT client identifier, generated when empty.
+	CACert             string   `json:"ca_cert"`              // CACert is a PEM file with the CA certificates to trust.
+	InsecureSkipVerify bool     `json:"insecure_skip_verify"` // InsecureSkipVerify disables broker certificate verification.
+	Topics             []string `json:"topics"`               // Topics are the topic filters that may be used, empty allows all topics.
+	ReadOnly           bool     `json:"read_only"`            // ReadOnly disables publishing.
+}
+
+// MQTTConfig represents the configuration for the MQTT service.
+type MQTTConfig struct {
+	PromptFile  string `json:"prompt_file"` // PromptFile is the prompt file for the MQTT service.
+	prompt      string
+	Brokers     []BrokerConfig `json:"brokers"`      // Brokers are the MQTT brokers that can be used.
+	Timeout     int            `json:"timeout"`      // Timeout is the connect and acknowledgement timeout, in seconds.
+	KeepAlive   int            `json:"keep_alive"`   // KeepAlive is the MQTT keep alive interval, in seconds.
+	MaxCollect  int            `json:"max_collect"`  // MaxCollect is the maximum collection time of mqtt_subscribe_collect, in seconds.
+	MaxMessages int            `json:"max_messages"` // MaxMessages is the maximum number of messages returned by one call.
+	MaxPayload  int            `json:"max_payload"`  // MaxPayload is the maximum payload size shown per message, in bytes.
+}
+
+// NewMQTTConfig creates a new MQTTConfig with default values.
+func NewMQTTConfig() *MQTTConfig {
+	return &MQTTConfig{
+		prompt:      MQTTPromptDefault,
+		Brokers:     []BrokerConfig{},
+		Timeout:     10,
+		KeepAlive:   30,
+		MaxCollect:  120,
+		MaxMessages: 200,
+		MaxPayload:  1024 * 16,
+	}
+}
+
+// Check validates the MQTT configuration.
+func (cfg *MQTTConfig) Check() error {
+	cfg.prompt = MQTTPromptDefault
+	names := make(map[string]bool)
+	for _, b := range cfg.Brokers {
+		if !namePattern.MatchString(b.Name) {
+			return fmt.Errorf("broker name %q may only contain letters, digits, '_' and '-'", b.Name)
+		}
+		if names[b.Name] {
+			return fmt.Errorf("duplicate broker name %s", b.Name)
+		}
+		names[b.Name] = true
+		u, err := url.Parse(b.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("broker %s: invalid url %q", b.Name, b.URL)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt", "ssl", "tls", "mqtts":
+		default:
+			return fmt.Errorf("broker %s: unsupported url scheme %q, use tcp:// or ssl://", b.Name, u.Scheme)
+		}
+		for _, t := range b.Topics {
+			err = validFilter(t)
+			if err != nil {
+				return fmt.Errorf("broker %s: %w", b.Name, err)
+			}
+		}
+	}
+	if cfg.Timeout <= 0 || cfg.KeepAlive <= 0 {
+		return fmt.Errorf("timeout and keep_alive must be greater than 0")
+	}
+	if cfg.MaxCollect <= 0 || cfg.MaxMessages <= 0 || cfg.MaxPayload <= 0 {
+		return fmt.Errorf("max_collect, max_messages and max_payload must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package mqtt
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestTopicMatch(t *testing.T) {
+	cases := []struct {
+		filter, topic string
+		match         bool
+	}{
+		{"home/#", "home/lamp/state", true},
+		{"home/#", "home", true},
+		{"home/+/state", "home/lamp/state", true},
+		{"home/+/state", "home/lamp/set", false},
+		{"home/+/state", "home/+/state", true},
+		{"home/+/state", "home/#", false},
+		{"#", "$SYS/uptime", false},
+		{"+/uptime", "$SYS/uptime", false},
+		{"home/lamp", "home/lamp/state", false},
+	}
+	for _, c := range cases {
+		if topicMatch(c.filter, c.topic) != c.match {
+			t.Errorf("topicMatch(%q, %q) != %v", c.filter, c.topic, c.match)
+		}
+	}
+	for _, f := range []string{"home/#/state", "home/lamp+", "", "home/#x"} {
+		if validFilter(f) == nil {
+			t.Errorf("Expected filter %q to be invalid", f)
+		}
+	}
+}
+
+// fakeBroker is a tiny MQTT broker supporting retained messages and QoS acknowledgements.
+type fakeBroker struct {
+	mu       sync.Mutex
+	retained map[string]*packets.PublishPacket
+	subs     map[net.Conn][]string
+}
+
+// forward sends a message to a subscriber with QoS 0.
+func forward(conn net.Conn, m *packets.PublishPacket, retain bool) {
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName, p.Payload, p.Retain = m.TopicName, m.Payload, retain
+	_ = p.Write(conn)
+}
+
+func (fb *fakeBroker) serve(conn net.Conn) {
+	defer func() {
+		fb.mu.Lock()
+		delete(fb.subs, conn)
+		fb.mu.Unlock()
+		_ = conn.Close()
+	}()
+	for {
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		fb.mu.Lock()
+		switch p := cp.(type) {
+		case *packets.ConnectPacket:
+			_ = packets.NewControlPacket(packets.Connack).Write(conn)
+		case *packets.PublishPacket:
+			if p.Retain {
+				if len(p.Payload) == 0 {
+					delete(fb.retained, p.TopicName)
+				} else {
+					fb.retained[p.TopicName] = p
+				}
+			}
+			for sc, filters := range fb.subs {
+				for _, f := range filters {
+					if topicMatch(f, p.TopicName) {
+						forward(sc, p, false)
+						break
+					}
+				}
+			}
+			switch p.Qos {
+			case 1:
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				_ = ack.Write(conn)
+			case 2:
+				ack := packets.NewControlPacket(packets.Pubrec).(*packets.PubrecPacket)
+				ack.MessageID = p.MessageID
+				_ = ack.Write(conn)
+			}
+		case *packets.PubrelPacket:
+			ack := packets.NewControlPacket(packets.Pubcomp).(*packets.PubcompPacket)
+			ack.MessageID = p.MessageID
+			_ = ack.Write(conn)
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID, ack.ReturnCodes = p.MessageID, p.Qoss
+			fb.subs[conn] = append(fb.subs[conn], p.Topics...)
+			_ = ack.Write(conn)
+			for _, m := range fb.retained {
+				for _, f := range p.Topics {
+					if topicMatch(f, m.TopicName) {
+						forward(conn, m, true)
+						break
+					}
+				}
+			}
+		case *packets.PingreqPacket:
+			_ = packets.NewControlPacket(packets.Pingresp).Write(conn)
+		case *packets.DisconnectPacket:
+			fb.mu.Unlock()
+			return
+		}
+		fb.mu.Unlock()
+	}
+}
+
+func TestMQTTServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err.Error())
+	}
+	defer lis.Close()
+	fb := &fakeBroker{retained: make(map[string]*packets.PublishPacket), subs: make(map[net.Conn][]string)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go fb.serve(conn)
+		}
+	}()
+
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewMQTTServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create MQTTServer: %s", err.Error())
+	}
+	url := "tcp://" + lis.Addr().String()
+	err = srv.LoadConfig(map[string]any{
+		"brokers": []any{
+			map[string]any{"name": "home", "url": url, "username": "user", "password": "pass", "topics": []any{"home/#"}},
+			map[string]any{"name": "ro", "url": url, "read_only": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init MQTTServer: %s", err.Error())
+	}
+	ms := srv.(*MQTTServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	out, isErr := call(ms.handlePublish, map[string]any{"broker": "home", "topic": "home/lamp/state", "payload": "on", "qos": float64(1), "retain": true})
+	if isErr {
+		t.Fatalf("Failed to publish: %s", out)
+	}
+	out, isErr = call(ms.handleRetained, map[string]any{"broker": "home", "topic": "home/+/state"})
+	if isErr || !strings.Contains(out, `"payload": "on"`) || !strings.Contains(out, `"retain": true`) {
+		t.Errorf("Unexpected retained messages: %s", out)
+	}
+
+	done := make(chan string)
+	go func() {
+		out, _ := call(ms.handleCollect, map[string]any{"broker": "home", "topics": []any{"home/#"}, "seconds": float64(5), "max_messages": float64(2)})
+		done <- out
+	}()
+	time.Sleep(300 * time.Millisecond)
+	out, isErr = call(ms.handlePublish, map[string]any{"broker": "home", "topic": "home/lamp/set", "payload": "off", "qos": float64(2)})
+	if isErr {
+		t.Fatalf("Failed to publish with qos 2: %s", out)
+	}
+	select {
+	case out = <-done:
+		if !strings.Contains(out, `"payload": "on"`) || !strings.Contains(out, `"payload": "off"`) {
+			t.Errorf("Unexpected collected messages: %s", out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("mqtt_subscribe_collect did not return after max_messages")
+	}
+
+	_, isErr = call(ms.handlePublish, map[string]any{"broker": "home", "topic": "office/lamp/set", "payload": "on"})
+	if !isErr {
+		t.Errorf("Expected topics outside the allowlist to be rejected")
+	}
+	_, isErr = call(ms.handlePublish, map[string]any{"broker": "ro", "topic": "home/lamp/set", "payload": "on"})
+	if !isErr {
+		t.Errorf("Expected publishing on a read only broker to be rejected")
+	}
+	_, isErr = call(ms.handleCollect, map[string]any{"broker": "home", "topics": []any{"#"}})
+	if !isErr {
+		t.Errorf("Expected filters wider than the allowlist to be rejected")
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package pipeline provides tools that combine several services for the MoLing application.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	PipelineServerName comm.MoLingServerType = "Pipeline"
+)
+
+// PipelineServer implements the Service interface and runs pipelines across the other loaded services.
+type PipelineServer struct {
+	abstract.MLService
+	config *PipelineConfig
+	client *http.Client
+
+	runsLock sync.Mutex
+	runs     []*workflowRun // runs are the last max_runs workflow runs, oldest first.
+	runCount int
+}
+
+// NewPipelineServer creates a new PipelineServer instance.
+func NewPipelineServer(ctx context.Context) (abstract.Service, error) {
+	pc := NewPipelineConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("PipelineServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("PipelineServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(PipelineServerName))
+	})
+
+	ps := &PipelineServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    pc,
+	}
+	err := ps.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Init creates the HTTP client and registers the prompt and tools.
+func (ps *PipelineServer) Init() error {
+	ps.client = &http.Client{Timeout: time.Duration(ps.config.Timeout) * time.Second}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "pipeline_prompt",
+			Description: "Get the relevant functions and prompts of the Pipeline MCP Server",
+		},
+		HandlerFunc: ps.handlePrompt,
+	}
+	ps.AddPrompt(pe)
+
+	ps.AddTool(mcp.NewTool(
+		"web_summarize",
+		mcp.WithDescription("Read a web page and summarize it, with citations of the passages each statement is based on"),
+		mcp.WithString("url",
+			mcp.Description("The http or https URL of the page"),
+			mcp.Required(),
+		),
+		mcp.WithString("focus",
+			mcp.Description("What the summary should concentrate on, e.g. a question about the page"),
+		),
+		mcp.WithNumber("max_words",
+			mcp.Description("Maximum length of the summary in words, between 20 and 1000, default: 200"),
+		),
+	), ps.handleWebSummarize)
+
+	if len(ps.config.Workflows) == 0 {
+		return nil
+	}
+	for i := range ps.config.Workflows {
+		wf := &ps.config.Workflows[i]
+		ps.AddTool(workflowTool(wf), ps.workflowHandler(wf))
+	}
+	ps.AddTool(mcp.NewTool(
+		"workflow_run",
+		mcp.WithDescription("Show the status, the steps and the output of a workflow run, or list the recent runs"),
+		mcp.WithString("run_id",
+			mcp.Description("The id of the run, omit to list the recent runs"),
+		),
+		mcp.WithString("workflow",
+			mcp.Description("Only list the runs of this workflow"),
+		),
+	), ps.handleWorkflowRun)
+	return nil
+}
+
+func (ps *PipelineServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ps.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, strings.Join(ps.config.SummarizeSteps, ", "))
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+func (ps *PipelineServer) handleWebSummarize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	rawURL := abstract.GetString(args, "url", "")
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return mcp.NewToolResultError("url must be an http or https URL"), nil
+	}
+	run := &summarizeRun{url: u.String(), maxWords: 200}
+	run.focus = strings.TrimSpace(abstract.GetString(args, "focus", ""))
+	if abstract.Has(args, "max_words") {
+		n := abstract.GetInt(args, "max_words", 0)
+		if n < 20 || n > 1000 {
+			return mcp.NewToolResultError("max_words must be between 20 and 1000"), nil
+		}
+		run.maxWords = n
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ps.config.Timeout)*time.Second)
+	defer cancel()
+	steps := ps.summarizeSteps()
+	result := summaryResult{}
+	for _, name := range ps.config.SummarizeSteps {
+		start := time.Now()
+		err = steps[name](ctx, run)
+		result.Steps = append(result.Steps, stepTiming{Name: name, Duration: time.Since(start).Milliseconds()})
+		if err != nil {
+			ps.Logger.Error().Err(err).Str("step", name).Str("url", run.url).Msg("web_summarize failed")
+			return mcp.NewToolResultError(fmt.Sprintf("step %s failed: %s", name, err.Error())), nil
+		}
+	}
+
+	result.URL = run.url
+	result.Title = run.title
+	result.Model = run.model
+	result.Summary = run.summary
+	result.Truncated = run.truncated
+	if run.summary != "" {
+		result.Citations = citations(run.summary, run.passages[:run.sent], run.url)
+	} else {
+		result.Passages = run.passages
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ps *PipelineServer) Config() string {
+	cfg, err := json.Marshal(ps.config)
+	if err != nil {
+		ps.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ps *PipelineServer) Name() comm.MoLingServerType {
+	return PipelineServerName
+}
+
+// Dependencies returns none, workflows may call the tools of any loaded service.
+func (ps *PipelineServer) Dependencies() []comm.MoLingServerType {
+	return nil
+}
+
+func (ps *PipelineServer) Close() error {
+	ps.Logger.Debug().Msg("PipelineServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ps *PipelineServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ps.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ps.config.Check()
+}
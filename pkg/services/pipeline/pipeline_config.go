@@ -0,0 +1,133 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const PipelinePromptDefault = `
+You are an assistant with pipeline tools that combine several MoLing services in a single call. Your capabilities include:
+
+1. **Web Summary**: Use web_summarize to read a web page and get a short summary of it. Statements in the summary cite the numbered passages of the page, and every citation comes with the passage text and a link to it. The pipeline runs these steps: %s.
+
+2. **Workflows**: Every workflow configured by the user is a tool of its own, which runs the tools of other services one after another. Use workflow_run to see the status, the steps and the output of workflow runs.
+
+Check the cited passages before you rely on a statement, and read the page with the browser tools when the summary is not enough.
+`
+
+const SummaryPromptDefault = `You summarize web pages. You get the title, the address and the numbered passages of a page.
+Write a faithful summary in the language of the page, using only facts from the passages.
+After every sentence, cite the passages it is based on by their numbers in square brackets, such as [2] or [3, 5].
+Do not add an introduction, a title or a conclusion of your own.`
+
+// Steps of web_summarize.
+const (
+	StepBrowse    = "browse"    // read the page with the Browser service
+	StepFetch     = "fetch"     // download the page over HTTP, without running JavaScript
+	StepClean     = "clean"     // drop duplicate and short passages
+	StepSummarize = "summarize" // summarize the passages with the LLM service
+)
+
+// PipelineConfig represents the configuration for the pipeline service.
+type PipelineConfig struct {
+	PromptFile       string `json:"prompt_file"` // PromptFile is the prompt file for the pipeline service.
+	prompt           string
+	SummarizeSteps   []string   `json:"summarize_steps"`    // SummarizeSteps are the steps of web_summarize, in order. The first one must be browse or fetch.
+	SummaryPrompt    string     `json:"summary_prompt"`     // SummaryPrompt is the system message of the summarize step.
+	Model            string     `json:"model"`              // Model is the model of the summarize step, empty uses the default model of the LLM service.
+	MaxTokens        int        `json:"max_tokens"`         // MaxTokens is the maximum length of a summary in tokens.
+	MaxContentSize   int        `json:"max_content_size"`   // MaxContentSize is the maximum number of characters of page content sent to the model.
+	MinPassageLength int        `json:"min_passage_length"` // MinPassageLength is the number of characters below which the clean step drops a passage.
+	MaxFetchSize     int64      `json:"max_fetch_size"`     // MaxFetchSize is the maximum number of bytes the fetch step downloads.
+	UserAgent        string     `json:"user_agent"`         // UserAgent is sent by the fetch step.
+	Timeout          int        `json:"timeout"`            // Timeout is the timeout of a whole pipeline run. time.Second
+	Workflows        []Workflow `json:"workflows"`          // Workflows are registered as tools of their own.
+	MaxRuns          int        `json:"max_runs"`           // MaxRuns is the number of workflow runs that workflow_run keeps.
+}
+
+// NewPipelineConfig creates a new PipelineConfig with default values.
+func NewPipelineConfig() *PipelineConfig {
+	return &PipelineConfig{
+		prompt:           PipelinePromptDefault,
+		SummarizeSteps:   []string{StepBrowse, StepClean, StepSummarize},
+		SummaryPrompt:    SummaryPromptDefault,
+		MaxTokens:        512,
+		MaxContentSize:   24000,
+		MinPassageLength: 20,
+		MaxFetchSize:     10 * 1024 * 1024,
+		UserAgent:        "MoLing (+https://github.com/gojue/moling)",
+		Timeout:          180,
+		Workflows:        []Workflow{},
+		MaxRuns:          100,
+	}
+}
+
+// Check validates the pipeline configuration.
+func (cfg *PipelineConfig) Check() error {
+	cfg.prompt = PipelinePromptDefault
+	if len(cfg.SummarizeSteps) == 0 || (cfg.SummarizeSteps[0] != StepBrowse && cfg.SummarizeSteps[0] != StepFetch) {
+		return fmt.Errorf("summarize_steps must start with %s or %s", StepBrowse, StepFetch)
+	}
+	for i, step := range cfg.SummarizeSteps {
+		switch step {
+		case StepBrowse, StepFetch:
+			if i > 0 {
+				return fmt.Errorf("summarize_steps may only start with %s or %s", StepBrowse, StepFetch)
+			}
+		case StepClean, StepSummarize:
+		default:
+			return fmt.Errorf("unknown summarize step %q, use %s", step, strings.Join([]string{StepBrowse, StepFetch, StepClean, StepSummarize}, ", "))
+		}
+	}
+	if strings.TrimSpace(cfg.SummaryPrompt) == "" {
+		return fmt.Errorf("summary_prompt must not be empty")
+	}
+	if cfg.MaxTokens <= 0 || cfg.MaxContentSize <= 0 || cfg.MaxFetchSize <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("max_tokens, max_content_size, max_fetch_size and timeout must be greater than 0")
+	}
+	if cfg.MaxRuns <= 0 {
+		return fmt.Errorf("max_runs must be greater than 0")
+	}
+	names := map[string]bool{"web_summarize": true, "workflow_run": true}
+	for i := range cfg.Workflows {
+		wf := &cfg.Workflows[i]
+		if names[wf.Name] {
+			return fmt.Errorf("workflow name %q is used twice or by a pipeline tool", wf.Name)
+		}
+		names[wf.Name] = true
+	}
+	for i := range cfg.Workflows {
+		err := cfg.Workflows[i].check(names)
+		if err != nil {
+			return fmt.Errorf("workflow %q: %w", cfg.Workflows[i].Name, err)
+		}
+	}
+	if cfg.MinPassageLength < 0 {
+		return fmt.Errorf("min_passage_length must not be negative")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/llm"
+)
+
+var (
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	paragraphPattern  = regexp.MustCompile(`\n\s*\n`)
+	citationPattern   = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+)
+
+// pageReader is implemented by the Browser service.
+type pageReader interface {
+	ReadPage(ctx context.Context, url string) (*browser.Page, error)
+}
+
+// generator is implemented by the LLM service.
+type generator interface {
+	Generate(ctx context.Context, req llm.GenerateRequest) (*llm.Generation, error)
+}
+
+// summarizeRun is the state that the steps of web_summarize pass on to each other.
+type summarizeRun struct {
+	url       string
+	focus     string
+	maxWords  int
+	title     string
+	passages  []string
+	sent      int // sent is the number of passages given to the model.
+	truncated bool
+	model     string
+	summary   string
+}
+
+type stepFunc func(ctx context.Context, run *summarizeRun) error
+
+// citation is a passage that the summary refers to.
+type citation struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	URL  string `json:"url"` // URL links to the passage with a text fragment.
+}
+
+// stepTiming is the duration of a step in milliseconds.
+type stepTiming struct {
+	Name     string `json:"name"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// summaryResult is the result of web_summarize.
+type summaryResult struct {
+	URL       string       `json:"url"`
+	Title     string       `json:"title"`
+	Model     string       `json:"model,omitempty"`
+	Summary   string       `json:"summary,omitempty"`
+	Citations []citation   `json:"citations,omitempty"`
+	Passages  []string     `json:"passages,omitempty"` // Passages are returned instead of a summary when there is no summarize step.
+	Truncated bool         `json:"truncated,omitempty"`
+	Steps     []stepTiming `json:"steps"`
+}
+
+// summarizeSteps maps the step names of summarize_steps to their implementations.
+func (ps *PipelineServer) summarizeSteps() map[string]stepFunc {
+	return map[string]stepFunc{
+		StepBrowse:    ps.stepBrowse,
+		StepFetch:     ps.stepFetch,
+		StepClean:     ps.stepClean,
+		StepSummarize: ps.stepSummarize,
+	}
+}
+
+// stepBrowse reads the main content of the page with the Browser service.
+func (ps *PipelineServer) stepBrowse(ctx context.Context, run *summarizeRun) error {
+	srv, ok := ps.LookupService(browser.BrowserServerName)
+	reader, isReader := srv.(pageReader)
+	if !ok || !isReader {
+		return fmt.Errorf("the %s service is not loaded, enable it or use the %s step", browser.BrowserServerName, StepFetch)
+	}
+	page, err := reader.ReadPage(ctx, run.url)
+	if err != nil {
+		return err
+	}
+	if page.URL != "" {
+		run.url = page.URL
+	}
+	run.title = strings.TrimSpace(page.Title)
+	run.passages = page.Blocks
+	return nil
+}
+
+// stepFetch downloads the page and extracts its main content without running JavaScript.
+func (ps *PipelineServer) stepFetch(ctx context.Context, run *summarizeRun) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, run.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", ps.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9")
+	resp, err := ps.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, ps.config.MaxFetchSize))
+	if err != nil {
+		return err
+	}
+	run.url = resp.Request.URL.String()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "":
+		doc, err := html.Parse(strings.NewReader(string(data)))
+		if err != nil {
+			return fmt.Errorf("failed to parse the page: %w", err)
+		}
+		run.title, run.passages = extractPage(doc)
+	case "text/plain", "text/markdown":
+		for _, p := range paragraphPattern.Split(string(data), -1) {
+			if p = normalizeSpace(p); p != "" {
+				run.passages = append(run.passages, p)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot summarize %s content, only HTML and plain text", mediaType)
+	}
+	return nil
+}
+
+// stepClean drops duplicate passages and passages shorter than min_passage_length, which are mostly menus,
+// buttons and captions.
+func (ps *PipelineServer) stepClean(ctx context.Context, run *summarizeRun) error {
+	seen := make(map[string]bool, len(run.passages))
+	passages := run.passages[:0]
+	for _, p := range run.passages {
+		if seen[p] || utf8.RuneCountInString(p) < ps.config.MinPassageLength {
+			continue
+		}
+		seen[p] = true
+		passages = append(passages, p)
+	}
+	run.passages = passages
+	return nil
+}
+
+// stepSummarize has the LLM service summarize the numbered passages.
+func (ps *PipelineServer) stepSummarize(ctx context.Context, run *summarizeRun) error {
+	srv, ok := ps.LookupService(llm.LLMServerName)
+	gen, isGen := srv.(generator)
+	if !ok || !isGen {
+		return fmt.Errorf("the %s service is not loaded", llm.LLMServerName)
+	}
+	if len(run.passages) == 0 {
+		return fmt.Errorf("the page has no readable content")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\nURL: %s\n\nPassages:\n", run.title, run.url)
+	size := 0
+	run.sent = 0
+	for i, p := range run.passages {
+		line := fmt.Sprintf("[%d] %s\n", i+1, p)
+		size += utf8.RuneCountInString(line)
+		if size > ps.config.MaxContentSize {
+			run.truncated = true
+			break
+		}
+		b.WriteString(line)
+		run.sent++
+	}
+	if run.sent == 0 {
+		return fmt.Errorf("the first passage is longer than max_content_size %d", ps.config.MaxContentSize)
+	}
+	fmt.Fprintf(&b, "\nSummarize the page in at most %d words.", run.maxWords)
+	if run.focus != "" {
+		fmt.Fprintf(&b, " Focus on: %s", run.focus)
+	}
+
+	g, err := gen.Generate(ctx, llm.GenerateRequest{
+		Model:     ps.config.Model,
+		MaxTokens: ps.config.MaxTokens,
+		Messages: []llm.Message{
+			{Role: "system", Content: ps.config.SummaryPrompt},
+			{Role: "user", Content: b.String()},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	run.model = g.Model
+	run.summary = strings.TrimSpace(g.Text)
+	if run.summary == "" {
+		return fmt.Errorf("the model returned an empty summary")
+	}
+	return nil
+}
+
+// citations returns the passages the summary cites, in the order of their first citation.
+func citations(summary string, passages []string, pageURL string) []citation {
+	var cited []citation
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(summary, -1) {
+		for _, s := range strings.Split(m[1], ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || id < 1 || id > len(passages) || seen[id] {
+				continue
+			}
+			seen[id] = true
+			cited = append(cited, citation{ID: id, Text: passages[id-1], URL: fragmentURL(pageURL, passages[id-1])})
+		}
+	}
+	return cited
+}
+
+// fragmentURL links to the start of a passage with a text fragment, which browsers scroll to and highlight.
+func fragmentURL(pageURL, passage string) string {
+	base, _, _ := strings.Cut(pageURL, "#")
+	words := strings.Fields(passage)
+	if len(words) > 8 {
+		words = words[:8]
+	}
+	text := strings.Join(words, " ")
+	if runes := []rune(text); len(runes) > 80 {
+		text = string(runes[:80])
+	}
+	// Dashes, commas and ampersands are delimiters in text fragments.
+	text = strings.NewReplacer("+", "%20", "-", "%2D").Replace(url.QueryEscape(text))
+	return base + "#:~:text=" + text
+}
+
+// skippedAtoms are the elements that never hold main content.
+var skippedAtoms = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Svg: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Form: true, atom.Button: true, atom.Iframe: true,
+}
+
+// passageAtoms are the elements whose text becomes a passage.
+var passageAtoms = map[atom.Atom]bool{
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.P: true, atom.Li: true, atom.Pre: true, atom.Blockquote: true, atom.Td: true, atom.Dd: true,
+}
+
+// extractPage returns the title and the passages of the main content of a document, like Page.Blocks of the
+// Browser service.
+func extractPage(doc *html.Node) (string, []string) {
+	title := ""
+	if n := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Title }); n != nil {
+		title = normalizeSpace(textContent(n))
+	}
+	root := findElement(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Article || n.DataAtom == atom.Main || attr(n, "role") == "main"
+	})
+	skipHeader := false
+	if root == nil {
+		root = findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+		skipHeader = true
+	}
+	if root == nil {
+		return title, nil
+	}
+
+	var passages []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if skippedAtoms[c.DataAtom] || (skipHeader && c.DataAtom == atom.Header) ||
+				attr(c, "role") == "navigation" || attr(c, "aria-hidden") == "true" {
+				continue
+			}
+			if passageAtoms[c.DataAtom] {
+				if text := normalizeSpace(textContent(c)); text != "" {
+					passages = append(passages, text)
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return title, passages
+}
+
+func findElement(n *html.Node, match func(n *html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent returns the text of a node, leaving out scripts and styles.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+		return ""
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			b.WriteString(" ")
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func normalizeSpace(s string) string {
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
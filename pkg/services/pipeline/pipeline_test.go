@@ -0,0 +1,310 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/llm"
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+// fakeBrowser stands in for the Browser service.
+type fakeBrowser struct {
+	abstract.Service
+	page *browser.Page
+}
+
+func (fb *fakeBrowser) ReadPage(ctx context.Context, url string) (*browser.Page, error) {
+	return fb.page, nil
+}
+
+// fakeLLM stands in for the LLM service and records the last request.
+type fakeLLM struct {
+	abstract.Service
+	answer string
+	req    llm.GenerateRequest
+}
+
+func (fl *fakeLLM) Generate(ctx context.Context, req llm.GenerateRequest) (*llm.Generation, error) {
+	fl.req = req
+	return &llm.Generation{Model: "llama3.2", Text: fl.answer}, nil
+}
+
+func newTestPipelineServer(t *testing.T, cfg map[string]any, services map[comm.MoLingServerType]abstract.Service) *PipelineServer {
+	ps := testkit.NewServiceAs[*PipelineServer](t, NewPipelineServer, cfg)
+	ps.SetServiceLookup(func(name comm.MoLingServerType) (abstract.Service, bool) {
+		s, ok := services[name]
+		return s, ok
+	})
+	return ps
+}
+
+func TestWebSummarizeBrowse(t *testing.T) {
+	fb := &fakeBrowser{page: &browser.Page{
+		URL:   "https://example.com/post#top",
+		Title: "Release notes",
+		Blocks: []string{
+			"Home",
+			"Version 2.0 adds a plugin system for third-party services.",
+			"Home",
+			"Startup is now twice as fast, thanks to lazy service loading.",
+			"The configuration format did not change.",
+		},
+	}}
+	fl := &fakeLLM{answer: "Version 2.0 adds plugins [1] and starts faster [2, 9]. Configs stay compatible [3][1]."}
+	ps := newTestPipelineServer(t, map[string]any{"max_tokens": float64(300)}, map[comm.MoLingServerType]abstract.Service{
+		browser.BrowserServerName: fb,
+		llm.LLMServerName:         fl,
+	})
+
+	text, isErr := testkit.CallHandler(t, ps.handleWebSummarize, map[string]any{"url": "https://example.com/post", "focus": "performance", "max_words": float64(50)})
+	if isErr {
+		t.Fatalf("web_summarize failed: %s", text)
+	}
+	var res summaryResult
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if res.Title != "Release notes" || res.Model != "llama3.2" || len(res.Steps) != 3 || res.Passages != nil {
+		t.Fatalf("unexpected result: %s", text)
+	}
+	if len(res.Citations) != 3 || res.Citations[0].ID != 1 || res.Citations[1].ID != 2 || res.Citations[2].ID != 3 {
+		t.Fatalf("unexpected citations: %+v", res.Citations)
+	}
+	if res.Citations[1].URL != "https://example.com/post#:~:text=Startup%20is%20now%20twice%20as%20fast%2C%20thanks%20to" {
+		t.Fatalf("unexpected citation URL: %s", res.Citations[1].URL)
+	}
+
+	user := fl.req.Messages[1].Content
+	if fl.req.MaxTokens != 300 || fl.req.Messages[0].Content != SummaryPromptDefault ||
+		strings.Contains(user, "Home") || !strings.Contains(user, "[2] Startup is now") ||
+		!strings.Contains(user, "at most 50 words") || !strings.Contains(user, "Focus on: performance") {
+		t.Fatalf("unexpected generate request: %+v", fl.req)
+	}
+}
+
+func TestWebSummarizeFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Docs</title><script>var x = 1;</script></head><body>
+<header><p>Site header with a long tagline text</p></header>
+<nav><ul><li>Navigation entry one</li></ul></nav>
+<main><h1>Getting started</h1><p>Install the binary and run <code>moling</code> once.</p>
+<ul><li>It creates the configuration directory.</li></ul></main>
+<footer><p>Copyright footer text that is long</p></footer></body></html>`))
+	}))
+	defer ts.Close()
+
+	ps := newTestPipelineServer(t, map[string]any{"summarize_steps": []any{"fetch"}}, nil)
+	text, isErr := testkit.CallHandler(t, ps.handleWebSummarize, map[string]any{"url": ts.URL})
+	if isErr {
+		t.Fatalf("web_summarize failed: %s", text)
+	}
+	var res summaryResult
+	if err := json.Unmarshal([]byte(text), &res); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	want := []string{"Getting started", "Install the binary and run moling once.", "It creates the configuration directory."}
+	if res.Title != "Docs" || res.Summary != "" || strings.Join(res.Passages, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected result: %s", text)
+	}
+}
+
+func TestWebSummarizeErrors(t *testing.T) {
+	ps := newTestPipelineServer(t, map[string]any{}, nil)
+	text, isErr := testkit.CallHandler(t, ps.handleWebSummarize, map[string]any{"url": "file:///etc/passwd"})
+	if !isErr {
+		t.Fatalf("expected a url error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ps.handleWebSummarize, map[string]any{"url": "https://example.com"})
+	if !isErr || !strings.Contains(text, "step browse failed") || !strings.Contains(text, "not loaded") {
+		t.Fatalf("expected a missing service error, got: %s", text)
+	}
+
+	ps = newTestPipelineServer(t, map[string]any{"max_content_size": float64(30)}, map[comm.MoLingServerType]abstract.Service{
+		browser.BrowserServerName: &fakeBrowser{page: &browser.Page{Blocks: []string{strings.Repeat("long passage ", 5)}}},
+		llm.LLMServerName:         &fakeLLM{answer: "unused"},
+	})
+	text, isErr = testkit.CallHandler(t, ps.handleWebSummarize, map[string]any{"url": "https://example.com"})
+	if !isErr || !strings.Contains(text, "step summarize failed") {
+		t.Fatalf("expected a content size error, got: %s", text)
+	}
+
+	for _, steps := range [][]any{{}, {"clean", "summarize"}, {"browse", "fetch"}, {"browse", "translate"}} {
+		cfg := NewPipelineConfig()
+		cfg.SummarizeSteps = make([]string, len(steps))
+		for i, s := range steps {
+			cfg.SummarizeSteps[i] = s.(string)
+		}
+		if err := cfg.Check(); err == nil {
+			t.Fatalf("expected an error for steps %v", steps)
+		}
+	}
+}
+
+// fakeService stands in for a service with tools.
+type fakeService struct {
+	abstract.Service
+	tools []server.ServerTool
+}
+
+func (fs *fakeService) Tools() []server.ServerTool {
+	return fs.tools
+}
+
+func newFakeService() *fakeService {
+	fetches := 0
+	return &fakeService{tools: []server.ServerTool{
+		{
+			Tool: mcp.NewTool("fake_fetch", mcp.WithString("url"), mcp.WithNumber("limit")),
+			Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				fetches++
+				if fetches == 1 {
+					return mcp.NewToolResultError("temporary failure"), nil
+				}
+				args := request.GetArguments()
+				limit, ok := args["limit"].(float64)
+				if !ok {
+					return mcp.NewToolResultError("limit must be a number"), nil
+				}
+				data, _ := json.Marshal(map[string]any{"title": "Page of " + args["url"].(string), "links": []any{"a", "b"}, "limit": limit})
+				return mcp.NewToolResultText(string(data)), nil
+			},
+		},
+		{
+			Tool: mcp.NewTool("fake_echo", mcp.WithString("text")),
+			Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("echo: " + request.GetArguments()["text"].(string)), nil
+			},
+		},
+		{
+			Tool: mcp.NewTool("fake_fail"),
+			Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultError("always fails"), nil
+			},
+		},
+	}}
+}
+
+func TestWorkflow(t *testing.T) {
+	workflows := []any{
+		map[string]any{
+			"name":        "research",
+			"description": "Fetch a page and echo its title",
+			"inputs": []any{
+				map[string]any{"name": "url", "required": true},
+				map[string]any{"name": "topic"},
+			},
+			"steps": []any{
+				map[string]any{"id": "fetch", "service": "Fake", "tool": "fake_fetch", "retries": 1,
+					"args":   map[string]any{"url": "{{.inputs.url}}", "limit": "{{len .inputs.url}}"},
+					"output": map[string]any{"title": "title", "second": "links.1"}},
+				map[string]any{"id": "note", "service": "Fake", "tool": "fake_echo", "if": "{{.inputs.topic}}",
+					"args": map[string]any{"text": "{{.inputs.topic}}"}},
+				map[string]any{"service": "Fake", "tool": "fake_fail", "continue_on_error": true},
+				map[string]any{"id": "echo", "service": "Fake", "tool": "fake_echo",
+					"args": map[string]any{"text": "{{.vars.title}} {{.vars.second}}"}},
+			},
+			"output": "{{.steps.echo.text}}|{{.steps.note.text}}|{{.steps.step3.error}}",
+		},
+		map[string]any{
+			"name":  "broken",
+			"steps": []any{map[string]any{"service": "Fake", "tool": "fake_fail"}},
+		},
+	}
+	ps := newTestPipelineServer(t, map[string]any{"workflows": workflows}, map[comm.MoLingServerType]abstract.Service{
+		"Fake": newFakeService(),
+	})
+	handlers := map[string]server.ToolHandlerFunc{}
+	for _, tool := range ps.Tools() {
+		handlers[tool.Tool.Name] = tool.Handler
+	}
+	if handlers["research"] == nil || handlers["broken"] == nil || handlers["workflow_run"] == nil {
+		t.Fatalf("workflow tools are not registered")
+	}
+
+	text, isErr := testkit.CallHandler(t, handlers["research"], map[string]any{})
+	if !isErr || !strings.Contains(text, "url is required") {
+		t.Fatalf("expected a missing input error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, handlers["research"], map[string]any{"url": "x.io"})
+	if isErr || text != "echo: Page of x.io b||always fails" {
+		t.Fatalf("research failed: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, handlers["workflow_run"], map[string]any{"run_id": "research-1"})
+	if isErr {
+		t.Fatalf("workflow_run failed: %s", text)
+	}
+	var run workflowRun
+	if err := json.Unmarshal([]byte(text), &run); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if run.Status != statusSucceeded || len(run.Steps) != 4 || run.Steps[0].Attempts != 2 ||
+		run.Steps[1].Status != statusSkipped || run.Steps[2].Status != statusFailed || run.Steps[3].Status != statusSucceeded {
+		t.Fatalf("unexpected run: %s", text)
+	}
+
+	text, isErr = testkit.CallHandler(t, handlers["broken"], map[string]any{})
+	if !isErr || !strings.Contains(text, "see workflow_run broken-2") || !strings.Contains(text, "always fails") {
+		t.Fatalf("expected a step error, got: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, handlers["workflow_run"], map[string]any{})
+	if isErr || strings.Index(text, "broken-2") > strings.Index(text, "research-1") || !strings.Contains(text, statusFailed) {
+		t.Fatalf("unexpected run list: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, handlers["workflow_run"], map[string]any{"run_id": "research-9"})
+	if !isErr {
+		t.Fatalf("expected a missing run error, got: %s", text)
+	}
+}
+
+func TestWorkflowCheck(t *testing.T) {
+	step := map[string]any{"service": "Fake", "tool": "fake_echo"}
+	for _, workflows := range [][]any{
+		{map[string]any{"name": "web_summarize", "steps": []any{step}}},
+		{map[string]any{"name": "a", "steps": []any{step}}, map[string]any{"name": "a", "steps": []any{step}}},
+		{map[string]any{"name": "a", "steps": []any{}}},
+		{map[string]any{"name": "a", "steps": []any{map[string]any{"service": "Pipeline", "tool": "a"}}}},
+		{map[string]any{"name": "a", "steps": []any{map[string]any{"service": "Fake", "tool": "fake_echo", "args": map[string]any{"text": "{{.inputs"}}}}},
+		{map[string]any{"name": "a", "inputs": []any{map[string]any{"name": "n", "type": "number", "default": "1"}}, "steps": []any{step}}},
+	} {
+		_, ctx, err := comm.InitTestEnv()
+		if err != nil {
+			t.Fatalf("Failed to initialize test environment: %s", err.Error())
+		}
+		srv, err := NewPipelineServer(ctx)
+		if err != nil {
+			t.Fatalf("Failed to create PipelineServer: %s", err.Error())
+		}
+		if err = srv.LoadConfig(map[string]any{"workflows": workflows}); err == nil {
+			t.Fatalf("expected a config error for %v", workflows)
+		}
+	}
+}
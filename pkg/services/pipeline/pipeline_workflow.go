@@ -0,0 +1,608 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+var (
+	workflowNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+	identifierPattern   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// templateFuncs are the functions available in workflow templates besides the built-in ones.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// inputTypes are the types of workflow inputs.
+var inputTypes = map[string]bool{"string": true, "number": true, "boolean": true, "array": true, "object": true}
+
+// Statuses of workflow runs and their steps.
+const (
+	statusPending   = "pending"
+	statusRunning   = "running"
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+	statusSkipped   = "skipped"
+)
+
+// Workflow is a named sequence of tool calls that is registered as a tool of its own.
+//
+// Step arguments, conditions and the output are Go templates over .inputs (the workflow arguments),
+// .steps (the results of the steps by id, with .text, .json and .error) and .vars (the output mappings
+// of the steps), e.g. {{.inputs.url}} or {{.vars.title}}.
+type Workflow struct {
+	Name        string          `json:"name"`        // Name is the name of the tool.
+	Description string          `json:"description"` // Description is the description of the tool.
+	Inputs      []WorkflowInput `json:"inputs"`      // Inputs are the arguments of the tool.
+	Steps       []WorkflowStep  `json:"steps"`       // Steps run in order.
+	Output      string          `json:"output"`      // Output is the template of the result, empty returns the result of the last step that ran.
+}
+
+// WorkflowInput is an argument of a workflow tool.
+type WorkflowInput struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // Type is string, number, boolean, array or object, default: string.
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Default     any    `json:"default"` // Default is used when the argument is not given.
+}
+
+// WorkflowStep calls a tool of a loaded service.
+type WorkflowStep struct {
+	ID              string            `json:"id"`                // ID names the result of the step in templates, default: step1, step2, ...
+	Service         string            `json:"service"`           // Service is the name of the service, e.g. Browser.
+	Tool            string            `json:"tool"`              // Tool is the name of the tool, e.g. browser_navigate.
+	Args            map[string]any    `json:"args"`              // Args are the tool arguments. Strings are templates, converted to the type the tool expects.
+	If              string            `json:"if"`                // If is a condition template, the step is skipped when it renders to "", "false" or "0".
+	Retries         int               `json:"retries"`           // Retries is the number of times a failed call is repeated.
+	RetryDelay      int               `json:"retry_delay"`       // RetryDelay is the wait before a retry. time.Second
+	ContinueOnError bool              `json:"continue_on_error"` // ContinueOnError runs the next steps after the call failed for good.
+	Output          map[string]string `json:"output"`            // Output maps variable names to dotted paths in the JSON result, such as citations.0.url. An empty path maps the whole result text.
+}
+
+// workflowRun is the status of a workflow run, as returned by workflow_run.
+type workflowRun struct {
+	ID       string        `json:"id"`
+	Workflow string        `json:"workflow"`
+	Status   string        `json:"status"`
+	Started  time.Time     `json:"started"`
+	Duration int64         `json:"duration_ms"`
+	Error    string        `json:"error,omitempty"`
+	Output   string        `json:"output,omitempty"`
+	Steps    []*stepStatus `json:"steps,omitempty"`
+}
+
+type stepStatus struct {
+	ID       string `json:"id"`
+	Tool     string `json:"tool"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts,omitempty"`
+	Duration int64  `json:"duration_ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+// check validates the workflow and sets the default step ids. workflows are the names of all workflow tools.
+func (wf *Workflow) check(workflows map[string]bool) error {
+	if !workflowNamePattern.MatchString(wf.Name) {
+		return fmt.Errorf("name must start with a letter and contain only letters, digits, _ and -")
+	}
+	if len(wf.Steps) == 0 {
+		return fmt.Errorf("steps must not be empty")
+	}
+	inputs := make(map[string]bool, len(wf.Inputs))
+	for i := range wf.Inputs {
+		in := &wf.Inputs[i]
+		if !identifierPattern.MatchString(in.Name) || inputs[in.Name] {
+			return fmt.Errorf("input name %q is invalid or used twice", in.Name)
+		}
+		inputs[in.Name] = true
+		if in.Type == "" {
+			in.Type = "string"
+		}
+		if !inputTypes[in.Type] {
+			return fmt.Errorf("input %s: unknown type %q, use string, number, boolean, array or object", in.Name, in.Type)
+		}
+		if in.Default != nil {
+			if err := checkType(in.Default, in.Type); err != nil {
+				return fmt.Errorf("default of input %s %w", in.Name, err)
+			}
+		}
+	}
+	ids := make(map[string]bool, len(wf.Steps))
+	for i := range wf.Steps {
+		step := &wf.Steps[i]
+		if step.ID == "" {
+			step.ID = fmt.Sprintf("step%d", i+1)
+		}
+		if !identifierPattern.MatchString(step.ID) || ids[step.ID] {
+			return fmt.Errorf("step id %q is invalid or used twice", step.ID)
+		}
+		ids[step.ID] = true
+		if step.Service == "" || step.Tool == "" {
+			return fmt.Errorf("step %s: service and tool must not be empty", step.ID)
+		}
+		if step.Service == string(PipelineServerName) && workflows[step.Tool] {
+			return fmt.Errorf("step %s: workflows cannot call workflows", step.ID)
+		}
+		if step.Retries < 0 || step.Retries > 10 || step.RetryDelay < 0 {
+			return fmt.Errorf("step %s: retries must be between 0 and 10 and retry_delay must not be negative", step.ID)
+		}
+		templates := []string{step.If}
+		templates = appendTemplates(templates, step.Args)
+		for _, text := range templates {
+			if _, err := parseTemplate(text); err != nil {
+				return fmt.Errorf("step %s: %w", step.ID, err)
+			}
+		}
+		for name := range step.Output {
+			if !identifierPattern.MatchString(name) {
+				return fmt.Errorf("step %s: output variable name %q is invalid", step.ID, name)
+			}
+		}
+	}
+	if _, err := parseTemplate(wf.Output); err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	return nil
+}
+
+// checkType reports whether v, as decoded from JSON, has the type of a workflow input.
+func checkType(v any, typ string) error {
+	ok := false
+	switch typ {
+	case "string":
+		_, ok = v.(string)
+	case "number":
+		_, ok = v.(float64)
+	case "boolean":
+		_, ok = v.(bool)
+	case "array":
+		_, ok = v.([]any)
+	case "object":
+		_, ok = v.(map[string]any)
+	}
+	if !ok {
+		return fmt.Errorf("must be of type %s", typ)
+	}
+	return nil
+}
+
+// appendTemplates appends the strings in an argument value to templates.
+func appendTemplates(templates []string, v any) []string {
+	switch v := v.(type) {
+	case string:
+		return append(templates, v)
+	case map[string]any:
+		for _, item := range v {
+			templates = appendTemplates(templates, item)
+		}
+	case []any:
+		for _, item := range v {
+			templates = appendTemplates(templates, item)
+		}
+	}
+	return templates
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+}
+
+func render(text string, data map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderValue renders the strings in an argument value.
+func renderValue(v any, data map[string]any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return render(v, data)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			rendered, err := renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// convertArg converts a rendered string argument to the type in the input schema of the tool.
+func convertArg(s, typ string) (any, error) {
+	switch typ {
+	case "number", "integer":
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case "boolean":
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case "array", "object":
+		var v any
+		err := json.Unmarshal([]byte(s), &v)
+		return v, err
+	}
+	return s, nil
+}
+
+// lookupPath returns the value at a dotted path, such as items.0.name, in a JSON value.
+func lookupPath(v any, path string) (any, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			item, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: no key %q", path, key)
+			}
+			v = item
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%s: no index %q", path, key)
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%s: cannot look up %q in a %T", path, key, v)
+		}
+	}
+	return v, nil
+}
+
+func truthy(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && s != "false" && s != "0"
+}
+
+// workflowTool builds the tool definition of a workflow.
+func workflowTool(wf *Workflow) mcp.Tool {
+	description := wf.Description
+	if description == "" {
+		description = fmt.Sprintf("Run the %s workflow", wf.Name)
+	}
+	opts := []mcp.ToolOption{mcp.WithDescription(description)}
+	for _, in := range wf.Inputs {
+		props := []mcp.PropertyOption{mcp.Description(in.Description)}
+		if in.Required {
+			props = append(props, mcp.Required())
+		}
+		switch in.Type {
+		case "number":
+			opts = append(opts, mcp.WithNumber(in.Name, props...))
+		case "boolean":
+			opts = append(opts, mcp.WithBoolean(in.Name, props...))
+		case "array":
+			opts = append(opts, mcp.WithArray(in.Name, props...))
+		case "object":
+			opts = append(opts, mcp.WithObject(in.Name, props...))
+		default:
+			opts = append(opts, mcp.WithString(in.Name, props...))
+		}
+	}
+	return mcp.NewTool(wf.Name, opts...)
+}
+
+// workflowInputs checks the arguments of a workflow call and fills in the defaults. Optional inputs
+// without a default are empty strings, so that templates can test them with if.
+func workflowInputs(wf *Workflow, args map[string]any) (map[string]any, error) {
+	inputs := make(map[string]any, len(wf.Inputs))
+	for _, in := range wf.Inputs {
+		v, ok := args[in.Name]
+		if !ok || v == nil {
+			switch {
+			case in.Default != nil:
+				v = in.Default
+			case in.Required:
+				return nil, fmt.Errorf("%s is required", in.Name)
+			default:
+				inputs[in.Name] = ""
+				continue
+			}
+		}
+		if err := checkType(v, in.Type); err != nil {
+			return nil, fmt.Errorf("%s %w", in.Name, err)
+		}
+		inputs[in.Name] = v
+	}
+	return inputs, nil
+}
+
+// workflowHandler returns the tool handler of a workflow.
+func (ps *PipelineServer) workflowHandler(wf *Workflow) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		inputs, err := workflowInputs(wf, request.GetArguments())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		run := ps.startRun(wf)
+		output, err := ps.runWorkflow(ctx, wf, run, inputs)
+		ps.finishRun(run, output, err)
+		if err != nil {
+			ps.Logger.Error().Err(err).Str("workflow", wf.Name).Str("run", run.ID).Msg("workflow failed")
+			return mcp.NewToolResultError(fmt.Sprintf("workflow %s failed, see workflow_run %s: %s", wf.Name, run.ID, err.Error())), nil
+		}
+		return mcp.NewToolResultText(output), nil
+	}
+}
+
+// runWorkflow runs the steps of a workflow and renders its output.
+func (ps *PipelineServer) runWorkflow(ctx context.Context, wf *Workflow, run *workflowRun, inputs map[string]any) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ps.config.Timeout)*time.Second)
+	defer cancel()
+
+	steps := make(map[string]any, len(wf.Steps))
+	for _, step := range wf.Steps {
+		steps[step.ID] = map[string]any{"text": "", "json": nil, "error": ""}
+	}
+	vars := make(map[string]any)
+	data := map[string]any{"inputs": inputs, "steps": steps, "vars": vars}
+	last := ""
+	for i := range wf.Steps {
+		step := &wf.Steps[i]
+		status := run.Steps[i]
+		start := time.Now()
+		ps.setStep(status, statusRunning, 0, "", start)
+		if step.If != "" {
+			cond, err := render(step.If, data)
+			if err != nil {
+				ps.setStep(status, statusFailed, 0, err.Error(), start)
+				return "", fmt.Errorf("step %s: condition: %w", step.ID, err)
+			}
+			if !truthy(cond) {
+				ps.setStep(status, statusSkipped, 0, "", start)
+				continue
+			}
+		}
+
+		text, attempts, err := ps.runStep(ctx, step, data, func(attempts int) {
+			ps.setStep(status, statusRunning, attempts, "", start)
+		})
+		if err != nil {
+			ps.setStep(status, statusFailed, attempts, err.Error(), start)
+			steps[step.ID] = map[string]any{"text": text, "json": nil, "error": err.Error()}
+			if step.ContinueOnError && ctx.Err() == nil {
+				continue
+			}
+			return "", fmt.Errorf("step %s: %w", step.ID, err)
+		}
+		var parsed any
+		if json.Unmarshal([]byte(text), &parsed) != nil {
+			parsed = nil
+		}
+		steps[step.ID] = map[string]any{"text": text, "json": parsed, "error": ""}
+		for name, path := range step.Output {
+			if path == "" {
+				vars[name] = text
+				continue
+			}
+			v, err := lookupPath(parsed, path)
+			if err != nil {
+				ps.setStep(status, statusFailed, attempts, err.Error(), start)
+				return "", fmt.Errorf("step %s: output %s: %w", step.ID, name, err)
+			}
+			vars[name] = v
+		}
+		ps.setStep(status, statusSucceeded, attempts, "", start)
+		last = text
+	}
+	if wf.Output == "" {
+		return last, nil
+	}
+	return render(wf.Output, data)
+}
+
+// runStep calls the tool of a step, with retries, and returns the result text and the number of attempts.
+func (ps *PipelineServer) runStep(ctx context.Context, step *WorkflowStep, data map[string]any, onAttempt func(attempts int)) (string, int, error) {
+	tool, handler, err := ps.findTool(step.Service, step.Tool)
+	if err != nil {
+		return "", 0, err
+	}
+	args := make(map[string]any, len(step.Args))
+	for name, v := range step.Args {
+		rendered, err := renderValue(v, data)
+		if err != nil {
+			return "", 0, fmt.Errorf("argument %s: %w", name, err)
+		}
+		if s, ok := rendered.(string); ok {
+			prop, _ := tool.InputSchema.Properties[name].(map[string]any)
+			typ, _ := prop["type"].(string)
+			rendered, err = convertArg(s, typ)
+			if err != nil {
+				return "", 0, fmt.Errorf("argument %s must be of type %s: %w", name, typ, err)
+			}
+		}
+		args[name] = rendered
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = step.Tool
+	request.Params.Arguments = args
+	var text string
+	attempts := 0
+	for {
+		attempts++
+		onAttempt(attempts)
+		text, err = invokeTool(ctx, handler, request)
+		if err == nil || attempts > step.Retries {
+			return text, attempts, err
+		}
+		ps.Logger.Warn().Err(err).Str("step", step.ID).Int("attempt", attempts).Msg("workflow step failed, retrying")
+		select {
+		case <-ctx.Done():
+			return text, attempts, ctx.Err()
+		case <-time.After(time.Duration(step.RetryDelay) * time.Second):
+		}
+	}
+}
+
+// findTool returns a tool of a loaded service.
+func (ps *PipelineServer) findTool(service, name string) (mcp.Tool, server.ToolHandlerFunc, error) {
+	srv, ok := ps.LookupService(comm.MoLingServerType(service))
+	if !ok {
+		return mcp.Tool{}, nil, fmt.Errorf("the %s service is not loaded", service)
+	}
+	for _, tool := range srv.Tools() {
+		if tool.Tool.Name == name {
+			return tool.Tool, tool.Handler, nil
+		}
+	}
+	return mcp.Tool{}, nil, fmt.Errorf("the %s service has no tool %s", service, name)
+}
+
+// invokeTool calls a tool handler and returns the text of the result. Error results are returned as errors.
+func invokeTool(ctx context.Context, handler server.ToolHandlerFunc, request mcp.CallToolRequest) (string, error) {
+	res, err := handler(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, content := range res.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if res.IsError {
+		return text, errors.New(text)
+	}
+	return text, nil
+}
+
+// startRun records a new run of a workflow and drops the oldest runs beyond max_runs.
+func (ps *PipelineServer) startRun(wf *Workflow) *workflowRun {
+	ps.runsLock.Lock()
+	defer ps.runsLock.Unlock()
+	ps.runCount++
+	run := &workflowRun{
+		ID:       fmt.Sprintf("%s-%d", wf.Name, ps.runCount),
+		Workflow: wf.Name,
+		Status:   statusRunning,
+		Started:  time.Now(),
+	}
+	for _, step := range wf.Steps {
+		run.Steps = append(run.Steps, &stepStatus{ID: step.ID, Tool: step.Tool, Status: statusPending})
+	}
+	ps.runs = append(ps.runs, run)
+	if len(ps.runs) > ps.config.MaxRuns {
+		ps.runs = ps.runs[len(ps.runs)-ps.config.MaxRuns:]
+	}
+	return run
+}
+
+func (ps *PipelineServer) setStep(status *stepStatus, state string, attempts int, errText string, start time.Time) {
+	ps.runsLock.Lock()
+	defer ps.runsLock.Unlock()
+	status.Status = state
+	status.Attempts = attempts
+	status.Error = errText
+	status.Duration = time.Since(start).Milliseconds()
+}
+
+func (ps *PipelineServer) finishRun(run *workflowRun, output string, err error) {
+	ps.runsLock.Lock()
+	defer ps.runsLock.Unlock()
+	run.Duration = time.Since(run.Started).Milliseconds()
+	if err != nil {
+		run.Status = statusFailed
+		run.Error = err.Error()
+		return
+	}
+	run.Status = statusSucceeded
+	run.Output = output
+}
+
+func (ps *PipelineServer) handleWorkflowRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id := abstract.GetString(args, "run_id", "")
+	workflow := abstract.GetString(args, "workflow", "")
+
+	ps.runsLock.Lock()
+	defer ps.runsLock.Unlock()
+	var result any
+	if id != "" {
+		for _, run := range ps.runs {
+			if run.ID == id {
+				result = run
+			}
+		}
+		if result == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("run %s not found, only the last %d runs are kept", id, ps.config.MaxRuns)), nil
+		}
+	} else {
+		runs := make([]workflowRun, 0, len(ps.runs))
+		for i := len(ps.runs) - 1; i >= 0; i-- {
+			if workflow == "" || ps.runs[i].Workflow == workflow {
+				run := *ps.runs[i]
+				run.Output = ""
+				run.Steps = nil
+				runs = append(runs, run)
+			}
+		}
+		if len(runs) == 0 {
+			return mcp.NewToolResultText("No workflow runs"), nil
+		}
+		result = runs
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
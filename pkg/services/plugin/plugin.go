@@ -0,0 +1,260 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package plugin runs third-party services as executable plugins for the MoLing application.
+//
+// A plugin is a program described by a manifest in the plugins directory. MoLing starts it and speaks JSON-RPC 2.0
+// with it, one message per line on its stdin and stdout; anything it writes to stderr is logged:
+//
+//   - moling.handshake, params {"protocol_version", "moling_version", "data_path", "config"}, where config is the
+//     plugin section of the configuration file. The result is {"protocol_version", "version", "prompt", "tools"},
+//     where tools are MCP tool definitions with name, description and inputSchema.
+//   - moling.call_tool, params {"name", "arguments"}. The result is an MCP tool result,
+//     {"content": [{"type": "text", "text"} or {"type": "image", "data", "mimeType"}], "isError"}.
+//   - moling.shutdown, a notification before stdin is closed.
+//
+// Go services do not need this: they register a factory with services.RegisterServ from an init function.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+const (
+	// ProtocolVersion is the version of the plugin protocol.
+	ProtocolVersion = 1
+
+	MethodHandshake = "moling.handshake"
+	MethodCallTool  = "moling.call_tool"
+	MethodShutdown  = "moling.shutdown"
+
+	// handshakeTimeout bounds starting a plugin and the handshake.
+	handshakeTimeout = 10 * time.Second
+)
+
+type handshakeParams struct {
+	ProtocolVersion int            `json:"protocol_version"`
+	MolingVersion   string         `json:"moling_version"`
+	DataPath        string         `json:"data_path"`
+	Config          map[string]any `json:"config"`
+}
+
+type handshakeResult struct {
+	ProtocolVersion int        `json:"protocol_version"`
+	Version         string     `json:"version"`
+	Prompt          string     `json:"prompt"`
+	Tools           []mcp.Tool `json:"tools"`
+}
+
+type callParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type callResult struct {
+	Content []struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Data     string `json:"data"`
+		MimeType string `json:"mimeType"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+// PluginServer implements the Service interface for an executable plugin.
+type PluginServer struct {
+	abstract.MLService
+	manifest Manifest
+	config   map[string]any
+	client   *rpcClient
+	info     handshakeResult
+}
+
+// NewFactory returns the service factory of a plugin, for services.RegisterServ.
+func NewFactory(m Manifest) abstract.ServiceFactory {
+	return func(ctx context.Context) (abstract.Service, error) {
+		globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+		if !ok {
+			return nil, fmt.Errorf("PluginServer: invalid config type")
+		}
+		logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+		if !ok {
+			return nil, fmt.Errorf("PluginServer: invalid logger type")
+		}
+		loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+			e.Str("Service", m.Name)
+		})
+		ps := &PluginServer{
+			MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+			manifest:  m,
+			config:    map[string]any{},
+		}
+		err := ps.InitResources()
+		if err != nil {
+			return nil, err
+		}
+		return ps, nil
+	}
+}
+
+// Init starts the plugin, hands it its configuration and registers the tools it reports.
+func (ps *PluginServer) Init() error {
+	client, err := startPlugin(ps.Context, ps.manifest, ps.Logger)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ps.Context, handshakeTimeout)
+	defer cancel()
+	params := handshakeParams{
+		ProtocolVersion: ProtocolVersion,
+		MolingVersion:   ps.MlConfig().Version,
+		DataPath:        filepath.Join(ps.MlConfig().BasePath, "data", "plugins", ps.manifest.Name),
+		Config:          ps.config,
+	}
+	if err = client.call(ctx, MethodHandshake, params, &ps.info); err != nil {
+		_ = client.close()
+		return fmt.Errorf("plugin %s: handshake failed: %w", ps.manifest.Name, err)
+	}
+	if ps.info.ProtocolVersion != ProtocolVersion {
+		_ = client.close()
+		return fmt.Errorf("plugin %s: protocol version %d is not supported, MoLing speaks version %d", ps.manifest.Name, ps.info.ProtocolVersion, ProtocolVersion)
+	}
+	ps.client = client
+
+	if ps.info.Prompt != "" {
+		ps.AddPrompt(abstract.PromptEntry{
+			PromptVar: mcp.Prompt{
+				Name:        strings.ToLower(ps.manifest.Name) + "_prompt",
+				Description: fmt.Sprintf("Get the relevant functions and prompts of the %s MCP Server", ps.manifest.Name),
+			},
+			HandlerFunc: ps.handlePrompt,
+		})
+	}
+	for _, tool := range ps.info.Tools {
+		ps.AddTool(tool, ps.forward(tool.Name))
+	}
+	ps.Logger.Info().Str("version", ps.info.Version).Int("tools", len(ps.info.Tools)).Msg("plugin started")
+	return nil
+}
+
+func (ps *PluginServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ps.info.Prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// forward returns the handler that passes calls of a tool on to the plugin.
+func (ps *PluginServer) forward(name string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(ps.manifest.Timeout)*time.Second)
+		defer cancel()
+		var res callResult
+		err := ps.client.call(ctx, MethodCallTool, callParams{Name: name, Arguments: request.GetArguments()}, &res)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("plugin %s: %s", ps.manifest.Name, err.Error())), nil
+		}
+		result := &mcp.CallToolResult{IsError: res.IsError}
+		for _, c := range res.Content {
+			switch c.Type {
+			case "text":
+				result.Content = append(result.Content, mcp.NewTextContent(c.Text))
+			case "image":
+				result.Content = append(result.Content, mcp.NewImageContent(c.Data, c.MimeType))
+			default:
+				ps.Logger.Warn().Str("type", c.Type).Str("tool", name).Msg("unsupported content type from plugin")
+			}
+		}
+		if len(result.Content) == 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(""))
+		}
+		return result, nil
+	}
+}
+
+// Health reports whether the plugin process is still running.
+func (ps *PluginServer) Health(ctx context.Context) error {
+	if ps.client == nil {
+		return fmt.Errorf("the plugin is not running")
+	}
+	select {
+	case <-ps.client.done:
+		return fmt.Errorf("the plugin exited: %w", ps.client.err)
+	default:
+		return nil
+	}
+}
+
+// Config returns the plugin configuration as a string, without the values of the secrets of the manifest.
+func (ps *PluginServer) Config() string {
+	cfg := make(map[string]any, len(ps.config))
+	for k, v := range ps.config {
+		cfg[k] = v
+	}
+	for _, key := range ps.manifest.Secrets {
+		if _, ok := cfg[key]; ok {
+			cfg[key] = ""
+		}
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		ps.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+func (ps *PluginServer) Name() comm.MoLingServerType {
+	return comm.MoLingServerType(ps.manifest.Name)
+}
+
+// Close stops the plugin.
+func (ps *PluginServer) Close() error {
+	var err error
+	if ps.client != nil {
+		err = ps.client.close()
+	}
+	ps.Logger.Debug().Msg("PluginServer closed")
+	return err
+}
+
+// LoadConfig keeps the plugin section of the configuration file for the handshake. The plugin validates it.
+func (ps *PluginServer) LoadConfig(jsonData map[string]any) error {
+	for k, v := range jsonData {
+		ps.config[k] = v
+	}
+	return nil
+}
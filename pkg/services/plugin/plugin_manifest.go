@@ -0,0 +1,101 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// validName matches plugin names, which become service names.
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Manifest describes an executable plugin. Manifests are the *.json files in the plugins directory and the
+// plugin.json files in its subdirectories.
+type Manifest struct {
+	Name        string            `json:"name"`        // Name is the service name, e.g. Jira, and the key of the plugin configuration in the configuration file.
+	Description string            `json:"description"` // Description tells users what the plugin does.
+	Command     string            `json:"command"`     // Command starts the plugin. Paths starting with . are relative to the manifest, other names are looked up in PATH.
+	Args        []string          `json:"args"`        // Args are the arguments of Command.
+	Env         map[string]string `json:"env"`         // Env is added to the environment of Command.
+	Secrets     []string          `json:"secrets"`     // Secrets are configuration keys whose values are left out of the printed configuration.
+	Timeout     int               `json:"timeout"`     // Timeout bounds a single tool call, default 60. time.Second
+	Dir         string            `json:"-"`           // Dir is the directory of the manifest and the working directory of the plugin.
+}
+
+// Discover reads the manifests in dir. A missing directory has no plugins. Invalid manifests are skipped and
+// reported in the error, so that one broken plugin does not stop the others.
+func Discover(dir string) ([]Manifest, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	nested, err := filepath.Glob(filepath.Join(dir, "*", "plugin.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []Manifest
+	var errs []error
+	names := make(map[string]string)
+	for _, file := range append(files, nested...) {
+		m, err := readManifest(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if other, ok := names[strings.ToLower(m.Name)]; ok {
+			errs = append(errs, fmt.Errorf("%s: the plugin name %s is already used by %s", file, m.Name, other))
+			continue
+		}
+		names[strings.ToLower(m.Name)] = file
+		manifests = append(manifests, m)
+	}
+	return manifests, errors.Join(errs...)
+}
+
+func readManifest(file string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return m, err
+	}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", file, err)
+	}
+	if !validName.MatchString(m.Name) {
+		return m, fmt.Errorf("%s: the name %q must start with a letter and only contain letters, digits, _ and -", file, m.Name)
+	}
+	if m.Command == "" {
+		return m, fmt.Errorf("%s: command must not be empty", file)
+	}
+	if m.Timeout < 0 {
+		return m, fmt.Errorf("%s: timeout must not be negative", file)
+	}
+	if m.Timeout == 0 {
+		m.Timeout = 60
+	}
+	m.Dir = filepath.Dir(file)
+	if strings.HasPrefix(m.Command, ".") {
+		m.Command = filepath.Join(m.Dir, m.Command)
+	}
+	return m, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxMessageSize is the maximum size of a message from a plugin.
+	maxMessageSize = 16 << 20
+	// shutdownGrace is how long a plugin may take to exit after it was asked to.
+	shutdownGrace = 5 * time.Second
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// rpcClient speaks line-delimited JSON-RPC 2.0 with a plugin process on its stdin and stdout.
+type rpcClient struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	logger zerolog.Logger
+
+	writeLock sync.Mutex
+	lock      sync.Mutex
+	nextID    int64
+	pending   map[int64]chan rpcResponse
+	err       error         // err is why the connection ended.
+	done      chan struct{} // done is closed when the plugin exits or closes stdout.
+}
+
+// startPlugin starts the plugin process. It is stopped when ctx is done.
+func startPlugin(ctx context.Context, m Manifest, logger zerolog.Logger) (*rpcClient, error) {
+	cmd := exec.CommandContext(ctx, m.Command, m.Args...)
+	cmd.Dir = m.Dir
+	cmd.Env = os.Environ()
+	for k, v := range m.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", m.Command, err)
+	}
+	c := &rpcClient{
+		cmd:     cmd,
+		stdin:   stdin,
+		logger:  logger,
+		pending: make(map[int64]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logger.Debug().Str("plugin", m.Name).Msg(scanner.Text())
+		}
+	}()
+	go c.read(stdout)
+	return c, nil
+}
+
+// read dispatches responses to the waiting calls until the plugin closes stdout.
+func (c *rpcClient) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			c.logger.Warn().Err(err).Msg("invalid message from plugin")
+			continue
+		}
+		c.lock.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.lock.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = errors.New("the plugin exited")
+	}
+	c.lock.Lock()
+	c.err = err
+	c.pending = nil
+	c.lock.Unlock()
+	close(c.done)
+}
+
+// call sends a request and decodes the result into result.
+func (c *rpcClient) call(ctx context.Context, method string, params any, result any) error {
+	ch := make(chan rpcResponse, 1)
+	c.lock.Lock()
+	if c.pending == nil {
+		err := c.err
+		c.lock.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		if c.pending != nil {
+			delete(c.pending, id)
+		}
+		c.lock.Unlock()
+	}()
+
+	if err := c.send(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification, which has no response.
+func (c *rpcClient) notify(method string, params any) error {
+	return c.send(rpcRequest{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *rpcClient) send(req rpcRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// close asks the plugin to shut down, closes its stdin and waits for it to exit. Plugins that do not exit within
+// the grace period are killed.
+func (c *rpcClient) close() error {
+	_ = c.notify(MethodShutdown, nil)
+	_ = c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(shutdownGrace):
+		_ = c.cmd.Process.Kill()
+		<-c.done
+	}
+	return c.cmd.Wait()
+}
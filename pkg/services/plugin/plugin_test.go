@@ -0,0 +1,178 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestMain runs the test binary as a plugin when the tests start it as one.
+func TestMain(m *testing.M) {
+	if os.Getenv("MOLING_TEST_PLUGIN") == "1" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin is a plugin with a greet tool that uses the greeting of its configuration.
+func runTestPlugin() {
+	greeting := "hello"
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		_ = json.Unmarshal(scanner.Bytes(), &req)
+		var result any
+		switch req.Method {
+		case MethodHandshake:
+			var p handshakeParams
+			_ = json.Unmarshal(req.Params, &p)
+			if g, ok := p.Config["greeting"].(string); ok {
+				greeting = g
+			}
+			fmt.Fprintln(os.Stderr, "handshake from", p.MolingVersion)
+			result = map[string]any{
+				"protocol_version": ProtocolVersion,
+				"version":          "0.1.0",
+				"prompt":           "Use greet to greet people.",
+				"tools": []any{map[string]any{
+					"name":        "greet",
+					"description": "Greet a person",
+					"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+				}},
+			}
+		case MethodCallTool:
+			var p callParams
+			_ = json.Unmarshal(req.Params, &p)
+			name, _ := p.Arguments["name"].(string)
+			result = map[string]any{
+				"content": []any{map[string]any{"type": "text", "text": greeting + ", " + name}},
+				"isError": name == "",
+			}
+		case MethodShutdown:
+			return
+		}
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		fmt.Println(string(data))
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"greeter.json":         `{"name": "Greeter", "command": "./bin/greeter", "secrets": ["token"]}`,
+		"broken.json":          `{"name": "Broken"`,
+		"nameless.json":        `{"command": "x"}`,
+		"jira/plugin.json":     `{"name": "Jira", "command": "python3", "args": ["jira.py"], "timeout": 30}`,
+		"greeter2/plugin.json": `{"name": "greeter", "command": "x"}`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifests, err := Discover(dir)
+	if err == nil || !strings.Contains(err.Error(), "broken.json") || !strings.Contains(err.Error(), "already used") {
+		t.Fatalf("expected errors for the broken manifests, got %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 manifests, got %+v", manifests)
+	}
+	if manifests[0].Command != filepath.Join(dir, "bin", "greeter") || manifests[0].Timeout != 60 {
+		t.Fatalf("unexpected manifest %+v", manifests[0])
+	}
+	if manifests[1].Command != "python3" || manifests[1].Dir != filepath.Join(dir, "jira") || manifests[1].Timeout != 30 {
+		t.Fatalf("unexpected manifest %+v", manifests[1])
+	}
+	if manifests, err = Discover(filepath.Join(dir, "missing")); err != nil || len(manifests) != 0 {
+		t.Fatalf("a missing directory must have no plugins, got %v %v", manifests, err)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	m := Manifest{
+		Name:    "Greeter",
+		Command: os.Args[0],
+		Env:     map[string]string{"MOLING_TEST_PLUGIN": "1"},
+		Secrets: []string{"token"},
+		Timeout: 10,
+	}
+	srv, err := NewFactory(m)(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create PluginServer: %s", err.Error())
+	}
+	if err = srv.LoadConfig(map[string]any{"greeting": "hi", "token": "secret"}); err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	if err = srv.Init(); err != nil {
+		t.Fatalf("Failed to init PluginServer: %s", err.Error())
+	}
+	ps := srv.(*PluginServer)
+	if srv.Name() != "Greeter" || len(ps.Tools()) != 1 || len(ps.Prompts()) != 1 {
+		t.Fatalf("unexpected plugin service %s with %d tools", srv.Name(), len(ps.Tools()))
+	}
+	if strings.Contains(ps.Config(), "secret") || !strings.Contains(ps.Config(), `"greeting":"hi"`) {
+		t.Fatalf("unexpected config %s", ps.Config())
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "greet"
+	req.Params.Arguments = map[string]any{"name": "Ada"}
+	res, _ := ps.Tools()[0].Handler(context.Background(), req)
+	if res.IsError || res.Content[0].(mcp.TextContent).Text != "hi, Ada" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	req.Params.Arguments = map[string]any{}
+	if res, _ = ps.Tools()[0].Handler(context.Background(), req); !res.IsError {
+		t.Fatalf("the plugin error must be passed on")
+	}
+	if err = ps.Health(context.Background()); err != nil {
+		t.Fatalf("the plugin must be healthy: %s", err.Error())
+	}
+
+	if err = srv.Close(); err != nil {
+		t.Fatalf("Failed to close the plugin: %s", err.Error())
+	}
+	if err = ps.Health(context.Background()); err == nil {
+		t.Fatalf("a stopped plugin must not be healthy")
+	}
+	if res, _ = ps.Tools()[0].Handler(context.Background(), req); !res.IsError {
+		t.Fatalf("calls to a stopped plugin must fail")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package proxy mounts other MCP servers and re-exports their tools for the MoLing application.
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	ProxyServerName comm.MoLingServerType = "Proxy"
+)
+
+// ProxyServer implements the Service interface and forwards tool calls to mounted MCP servers.
+type ProxyServer struct {
+	abstract.MLService
+	config    *ProxyConfig
+	upstreams []*upstream
+}
+
+// NewProxyServer creates a new ProxyServer instance.
+func NewProxyServer(ctx context.Context) (abstract.Service, error) {
+	pc := NewProxyConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("ProxyServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("ProxyServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(ProxyServerName))
+	})
+
+	ps := &ProxyServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    pc,
+	}
+	err := ps.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Init connects to the mounted servers and registers their tools under the server name. Servers that cannot be
+// reached are logged and skipped, since their tools are unknown.
+func (ps *ProxyServer) Init() error {
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "proxy_prompt",
+			Description: "Get the relevant functions and prompts of the Proxy MCP Server",
+		},
+		HandlerFunc: ps.handlePrompt,
+	}
+	ps.AddPrompt(pe)
+
+	ps.AddTool(mcp.NewTool(
+		"proxy_servers",
+		mcp.WithDescription("List the mounted MCP servers, whether they are connected and the tools they provide"),
+	), ps.handleServers)
+
+	for _, sc := range ps.config.Servers {
+		if sc.Disabled {
+			continue
+		}
+		ps.upstreams = append(ps.upstreams, &upstream{
+			config:  sc,
+			ctx:     ps.Context,
+			logger:  ps.Logger,
+			timeout: time.Duration(ps.config.ConnectTimeout) * time.Second,
+			version: ps.MlConfig().Version,
+		})
+	}
+	var wg sync.WaitGroup
+	for _, u := range ps.upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := u.connect(); err != nil {
+				ps.Logger.Warn().Str("server", u.config.Name).Err(err).Msg("failed to mount MCP server")
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, u := range ps.upstreams {
+		for _, tool := range u.tools {
+			ps.AddTool(ps.exportTool(u, tool), ps.forward(u, tool.Name))
+		}
+		ps.Logger.Info().Str("server", u.config.Name).Int("tools", len(u.tools)).Msg("mounted MCP server")
+	}
+	return nil
+}
+
+// exportTool returns the tool of a mounted server under its re-exported name.
+func (ps *ProxyServer) exportTool(u *upstream, tool mcp.Tool) mcp.Tool {
+	tool.Name = u.config.Name + "_" + tool.Name
+	tool.Description = fmt.Sprintf("[%s] %s", u.config.Name, tool.Description)
+	return tool
+}
+
+// forward returns the handler that passes calls on to the tool of a mounted server.
+func (ps *ProxyServer) forward(u *upstream, name string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(ps.config.CallTimeout)*time.Second)
+		defer cancel()
+		res, err := u.call(ctx, name, request.GetArguments())
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("MCP server %s: %s", u.config.Name, err.Error())), nil
+		}
+		return res, nil
+	}
+}
+
+func (ps *ProxyServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ps.config.prompt
+	if strings.Contains(text, "%s") {
+		var servers strings.Builder
+		for _, u := range ps.upstreams {
+			servers.WriteString(fmt.Sprintf("- %s (%d tools)\n", u.config.Name, len(u.tools)))
+		}
+		if servers.Len() == 0 {
+			servers.WriteString("- none\n")
+		}
+		text = fmt.Sprintf(text, servers.String())
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// serverStatus is the state of a mounted server as reported by proxy_servers.
+type serverStatus struct {
+	Name      string   `json:"name"`
+	Transport string   `json:"transport"`
+	Connected bool     `json:"connected"`
+	Server    string   `json:"server,omitempty"`
+	Tools     []string `json:"tools"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (ps *ProxyServer) handleServers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	statuses := make([]serverStatus, 0, len(ps.upstreams))
+	for _, u := range ps.upstreams {
+		connected, server, err := u.status()
+		s := serverStatus{Name: u.config.Name, Transport: u.config.Transport, Connected: connected, Server: server, Tools: []string{}}
+		for _, tool := range u.tools {
+			s.Tools = append(s.Tools, u.config.Name+"_"+tool.Name)
+		}
+		if err != nil {
+			s.Error = err.Error()
+		}
+		statuses = append(statuses, s)
+	}
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode the servers: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// Config returns the configuration of the service as a string, without environment and header values.
+func (ps *ProxyServer) Config() string {
+	cfg := *ps.config
+	cfg.Servers = make([]UpstreamConfig, len(ps.config.Servers))
+	for i, s := range ps.config.Servers {
+		s.Env = blankValues(s.Env)
+		s.Headers = blankValues(s.Headers)
+		cfg.Servers[i] = s
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		ps.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(data)
+}
+
+// blankValues returns a copy of m with empty values, since they may hold secrets.
+func blankValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	blank := make(map[string]string, len(m))
+	for k := range m {
+		blank[k] = ""
+	}
+	return blank
+}
+
+func (ps *ProxyServer) Name() comm.MoLingServerType {
+	return ProxyServerName
+}
+
+// Close disconnects from the mounted servers and stops child processes.
+func (ps *ProxyServer) Close() error {
+	var err error
+	for _, u := range ps.upstreams {
+		if cErr := u.close(); cErr != nil {
+			ps.Logger.Warn().Str("server", u.config.Name).Err(cErr).Msg("failed to close MCP server")
+			err = cErr
+		}
+	}
+	ps.Logger.Debug().Msg("ProxyServer closed")
+	return err
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ps *ProxyServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ps.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ps.config.Check()
+}
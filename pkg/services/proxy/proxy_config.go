@@ -0,0 +1,136 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const ProxyPromptDefault = `
+You are an assistant connected to MoLing, which also forwards tools of other MCP servers. Tools of a mounted server are named after it, e.g. github_create_issue is the create_issue tool of the server mounted as github.
+
+Mounted servers:
+%s
+Use proxy_servers to see whether a mounted server is connected and which of its tools are available.
+`
+
+// Transports of upstream servers.
+const (
+	TransportStdio = "stdio" // a child process speaking MCP on stdin and stdout
+	TransportSSE   = "sse"   // a remote server with the HTTP+SSE transport
+	TransportHTTP  = "http"  // a remote server with the streamable HTTP transport
+)
+
+// validName matches server names, which become prefixes of tool names.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ProxyConfig represents the configuration for the proxy service.
+type ProxyConfig struct {
+	PromptFile     string `json:"prompt_file"` // PromptFile is the prompt file for the proxy service.
+	prompt         string
+	Servers        []UpstreamConfig `json:"servers"`         // Servers are the MCP servers to mount.
+	ConnectTimeout int              `json:"connect_timeout"` // ConnectTimeout bounds starting, initializing and listing the tools of a server. time.Second
+	CallTimeout    int              `json:"call_timeout"`    // CallTimeout bounds a single forwarded tool call. time.Second
+}
+
+// UpstreamConfig is an MCP server whose tools are re-exported.
+type UpstreamConfig struct {
+	Name      string            `json:"name"`      // Name identifies the server and prefixes its tools, e.g. github for github_create_issue.
+	Transport string            `json:"transport"` // Transport is stdio, sse or http.
+	Command   string            `json:"command"`   // Command starts a stdio server.
+	Args      []string          `json:"args"`      // Args are the arguments of Command.
+	Env       map[string]string `json:"env"`       // Env is added to the environment of Command.
+	URL       string            `json:"url"`       // URL is the endpoint of an sse or http server, e.g. https://host/sse or https://host/mcp.
+	Headers   map[string]string `json:"headers"`   // Headers are sent with every request to an sse or http server, e.g. Authorization.
+	Tools     []string          `json:"tools"`     // Tools are the tools to re-export, empty exports all. Names may end with *.
+	Disabled  bool              `json:"disabled"`  // Disabled keeps the server configured without mounting it.
+}
+
+// NewProxyConfig creates a new ProxyConfig with default values.
+func NewProxyConfig() *ProxyConfig {
+	return &ProxyConfig{
+		prompt:         ProxyPromptDefault,
+		Servers:        []UpstreamConfig{},
+		ConnectTimeout: 30,
+		CallTimeout:    300,
+	}
+}
+
+// Check validates the proxy configuration.
+func (cfg *ProxyConfig) Check() error {
+	cfg.prompt = ProxyPromptDefault
+	if cfg.ConnectTimeout <= 0 || cfg.CallTimeout <= 0 {
+		return fmt.Errorf("connect_timeout and call_timeout must be greater than 0")
+	}
+	names := make(map[string]bool, len(cfg.Servers))
+	for _, s := range cfg.Servers {
+		if !validName.MatchString(s.Name) {
+			return fmt.Errorf("server name %q must only contain letters, digits, _ and -", s.Name)
+		}
+		if names[strings.ToLower(s.Name)] {
+			return fmt.Errorf("server name %q is used twice", s.Name)
+		}
+		names[strings.ToLower(s.Name)] = true
+		switch s.Transport {
+		case TransportStdio:
+			if s.Command == "" {
+				return fmt.Errorf("server %s: command must not be empty", s.Name)
+			}
+		case TransportSSE, TransportHTTP:
+			u, err := url.Parse(s.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("server %s: url must be an http or https URL, got %q", s.Name, s.URL)
+			}
+		default:
+			return fmt.Errorf("server %s: transport must be %q, %q or %q, got %q", s.Name, TransportStdio, TransportSSE, TransportHTTP, s.Transport)
+		}
+		for _, tool := range s.Tools {
+			if strings.Contains(strings.TrimSuffix(tool, "*"), "*") {
+				return fmt.Errorf("server %s: tool pattern %q may only end with *", s.Name, tool)
+			}
+		}
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
+
+// exported reports whether a tool of the server is re-exported.
+func (s *UpstreamConfig) exported(tool string) bool {
+	if len(s.Tools) == 0 {
+		return true
+	}
+	for _, pattern := range s.Tools {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(tool, prefix) {
+				return true
+			}
+		} else if pattern == tool {
+			return true
+		}
+	}
+	return false
+}
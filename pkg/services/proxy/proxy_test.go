@@ -0,0 +1,149 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func newTestProxyServer(t *testing.T, cfg map[string]any) *ProxyServer {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewProxyServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create ProxyServer: %s", err.Error())
+	}
+	err = srv.LoadConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init ProxyServer: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	return srv.(*ProxyServer)
+}
+
+func callTool(t *testing.T, ps *ProxyServer, name string, args map[string]any) (string, bool) {
+	for _, tool := range ps.Tools() {
+		if tool.Tool.Name == name {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = name
+			req.Params.Arguments = args
+			res, err := tool.Handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Failed to call tool: %s", err.Error())
+			}
+			return res.Content[0].(mcp.TextContent).Text, res.IsError
+		}
+	}
+	t.Fatalf("tool %s not found", name)
+	return "", false
+}
+
+// newUpstream returns an MCP server with an echo tool and a secret tool.
+func newUpstream() *server.MCPServer {
+	s := server.NewMCPServer("upstream", "1.2.3")
+	s.AddTool(mcp.NewTool("echo",
+		mcp.WithDescription("Echo the text"),
+		mcp.WithString("text", mcp.Required()),
+	), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text, _ := request.GetArguments()["text"].(string)
+		return mcp.NewToolResultText("echo: " + text), nil
+	})
+	s.AddTool(mcp.NewTool("secret"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("no secrets here"), nil
+	})
+	return s
+}
+
+func TestProxy(t *testing.T) {
+	sse := server.NewTestServer(newUpstream())
+	t.Cleanup(sse.Close)
+	streamable := server.NewTestStreamableHTTPServer(newUpstream())
+	t.Cleanup(streamable.Close)
+
+	ps := newTestProxyServer(t, map[string]any{
+		"connect_timeout": 5,
+		"servers": []any{
+			map[string]any{"name": "remote", "transport": "sse", "url": sse.URL + "/sse", "tools": []any{"ec*"}, "headers": map[string]any{"Authorization": "Bearer secret"}},
+			map[string]any{"name": "web", "transport": "http", "url": streamable.URL + "/mcp"},
+			map[string]any{"name": "down", "transport": "sse", "url": "http://127.0.0.1:1/sse"},
+			map[string]any{"name": "off", "transport": "stdio", "command": "missing-mcp-server", "disabled": true},
+		},
+	})
+
+	var names []string
+	for _, tool := range ps.Tools() {
+		names = append(names, tool.Tool.Name)
+	}
+	if strings.Join(names, ",") != "proxy_servers,remote_echo,web_echo,web_secret" {
+		t.Fatalf("unexpected tools %v", names)
+	}
+	if ps.Tools()[1].Tool.Description != "[remote] Echo the text" || len(ps.Tools()[1].Tool.InputSchema.Required) != 1 {
+		t.Fatalf("the tool must keep its schema, got %+v", ps.Tools()[1].Tool)
+	}
+
+	text, isErr := callTool(t, ps, "remote_echo", map[string]any{"text": "hi"})
+	if isErr || text != "echo: hi" {
+		t.Fatalf("unexpected result %q", text)
+	}
+	text, isErr = callTool(t, ps, "web_secret", nil)
+	if !isErr || text != "no secrets here" {
+		t.Fatalf("tool errors must be passed on, got %q", text)
+	}
+
+	text, _ = callTool(t, ps, "proxy_servers", nil)
+	var statuses []serverStatus
+	if err := json.Unmarshal([]byte(text), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 3 || !statuses[0].Connected || statuses[0].Server != "upstream 1.2.3" || statuses[2].Connected || statuses[2].Error == "" {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+	if strings.Contains(ps.Config(), "secret") {
+		t.Fatalf("the config must not contain header values: %s", ps.Config())
+	}
+}
+
+func TestProxyConfigCheck(t *testing.T) {
+	for _, servers := range [][]UpstreamConfig{
+		{{Name: "bad name", Transport: TransportStdio, Command: "x"}},
+		{{Name: "a", Transport: TransportStdio}},
+		{{Name: "a", Transport: TransportSSE, URL: "ftp://host"}},
+		{{Name: "a", Transport: "grpc"}},
+		{{Name: "a", Transport: TransportStdio, Command: "x"}, {Name: "A", Transport: TransportStdio, Command: "y"}},
+		{{Name: "a", Transport: TransportStdio, Command: "x", Tools: []string{"*_x"}}},
+	} {
+		cfg := NewProxyConfig()
+		cfg.Servers = servers
+		if err := cfg.Check(); err == nil {
+			t.Fatalf("expected an error for %+v", servers)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+)
+
+// upstream is the connection to a mounted MCP server. After the connection fails it reconnects on the next call.
+type upstream struct {
+	config  UpstreamConfig
+	ctx     context.Context // ctx bounds the lifetime of connections and child processes.
+	logger  zerolog.Logger
+	timeout time.Duration // timeout bounds connecting.
+	version string        // version is sent to the server as the client version.
+
+	lock   sync.Mutex
+	client *client.Client
+	server string     // server is the name and version the server reported.
+	tools  []mcp.Tool // tools are the exported tools, under their upstream names.
+	err    error      // err is the last connection error.
+}
+
+// connect returns the client of the server, connecting first if needed.
+func (u *upstream) connect() (*client.Client, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.client != nil {
+		return u.client, nil
+	}
+	c, err := u.dial()
+	u.err = err
+	if err != nil {
+		return nil, err
+	}
+	u.client = c
+	return c, nil
+}
+
+// dial starts a client, initializes the session and lists the tools of the server.
+func (u *upstream) dial() (*client.Client, error) {
+	var c *client.Client
+	var err error
+	switch u.config.Transport {
+	case TransportStdio:
+		env := make([]string, 0, len(u.config.Env))
+		for k, v := range u.config.Env {
+			env = append(env, k+"="+v)
+		}
+		c = client.NewClient(transport.NewStdio(u.config.Command, env, u.config.Args...))
+	case TransportSSE:
+		c, err = client.NewSSEMCPClient(u.config.URL, transport.WithHeaders(u.config.Headers))
+	case TransportHTTP:
+		c, err = client.NewStreamableHttpClient(u.config.URL, transport.WithHTTPHeaders(u.config.Headers))
+	default:
+		err = fmt.Errorf("unknown transport %q", u.config.Transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Child processes and SSE streams live as long as the service, only the handshake is bounded.
+	if err = c.Start(u.ctx); err != nil {
+		return nil, fmt.Errorf("failed to start: %w", err)
+	}
+	if stderr, ok := client.GetStderr(c); ok {
+		go func() {
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				u.logger.Debug().Str("server", u.config.Name).Msg(scanner.Text())
+			}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(u.ctx, u.timeout)
+	defer cancel()
+	req := mcp.InitializeRequest{}
+	req.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	req.Params.ClientInfo = mcp.Implementation{Name: "MoLing", Version: u.version}
+	res, err := c.Initialize(ctx, req)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	u.server = res.ServerInfo.Name + " " + res.ServerInfo.Version
+
+	var tools []mcp.Tool
+	list := mcp.ListToolsRequest{}
+	for {
+		page, err := c.ListToolsByPage(ctx, list)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		for _, tool := range page.Tools {
+			if u.config.exported(tool.Name) {
+				tools = append(tools, tool)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		list.Params.Cursor = page.NextCursor
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	u.tools = tools
+	return c, nil
+}
+
+// call forwards a tool call. If it fails and the server does not answer a ping, the connection is dropped,
+// so that the next call reconnects.
+func (u *upstream) call(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	c, err := u.connect()
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := c.CallTool(ctx, req)
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return res, err
+	}
+	pingCtx, cancel := context.WithTimeout(u.ctx, 5*time.Second)
+	defer cancel()
+	if c.Ping(pingCtx) != nil {
+		u.logger.Warn().Str("server", u.config.Name).Err(err).Msg("connection lost, reconnecting on the next call")
+		u.drop(c)
+	}
+	return nil, err
+}
+
+// drop closes the client if it is still the current one.
+func (u *upstream) drop(c *client.Client) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.client == c {
+		u.client = nil
+		_ = c.Close()
+	}
+}
+
+// status returns whether the server is connected, what it reported about itself and the last connection error.
+func (u *upstream) status() (bool, string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	return u.client != nil, u.server, u.err
+}
+
+func (u *upstream) close() error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.client == nil {
+		return nil
+	}
+	err := u.client.Close()
+	u.client = nil
+	return err
+}
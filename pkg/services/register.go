@@ -20,8 +20,34 @@ import (
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/services/abstract"
 	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/clock"
+	"github.com/gojue/moling/pkg/services/code"
 	"github.com/gojue/moling/pkg/services/command"
+	"github.com/gojue/moling/pkg/services/docconvert"
 	"github.com/gojue/moling/pkg/services/filesystem"
+	"github.com/gojue/moling/pkg/services/forge"
+	"github.com/gojue/moling/pkg/services/graphql"
+	"github.com/gojue/moling/pkg/services/grpc"
+	"github.com/gojue/moling/pkg/services/issuetracker"
+	"github.com/gojue/moling/pkg/services/knowledge"
+	"github.com/gojue/moling/pkg/services/llm"
+	"github.com/gojue/moling/pkg/services/logs"
+	"github.com/gojue/moling/pkg/services/media"
+	"github.com/gojue/moling/pkg/services/memory"
+	"github.com/gojue/moling/pkg/services/messaging"
+	"github.com/gojue/moling/pkg/services/mqtt"
+	"github.com/gojue/moling/pkg/services/pipeline"
+	"github.com/gojue/moling/pkg/services/proxy"
+	"github.com/gojue/moling/pkg/services/sandbox"
+	"github.com/gojue/moling/pkg/services/screen"
+	"github.com/gojue/moling/pkg/services/secrets"
+	"github.com/gojue/moling/pkg/services/spreadsheet"
+	"github.com/gojue/moling/pkg/services/storage"
+	"github.com/gojue/moling/pkg/services/text"
+	"github.com/gojue/moling/pkg/services/transfer"
+	"github.com/gojue/moling/pkg/services/translate"
+	"github.com/gojue/moling/pkg/services/weather"
+	"github.com/gojue/moling/pkg/services/webhook"
 )
 
 var serviceLists = make(map[comm.MoLingServerType]abstract.ServiceFactory)
@@ -43,4 +69,56 @@ func init() {
 	RegisterServ(browser.BrowserServerName, browser.NewBrowserServer)
 	// Register the command service
 	RegisterServ(command.CommandServerName, command.NewCommandServer)
+	// Register the screen service
+	RegisterServ(screen.ScreenServerName, screen.NewScreenServer)
+	// Register the memory service
+	RegisterServ(memory.MemoryServerName, memory.NewMemoryServer)
+	// Register the knowledge service
+	RegisterServ(knowledge.KnowledgeServerName, knowledge.NewKnowledgeServer)
+	// Register the webhook service
+	RegisterServ(webhook.WebhookServerName, webhook.NewWebhookServer)
+	// Register the storage service
+	RegisterServ(storage.StorageServerName, storage.NewStorageServer)
+	// Register the transfer service
+	RegisterServ(transfer.TransferServerName, transfer.NewTransferServer)
+	// Register the graphql service
+	RegisterServ(graphql.GraphQLServerName, graphql.NewGraphQLServer)
+	// Register the grpc service
+	RegisterServ(grpc.GRPCServerName, grpc.NewGRPCServer)
+	// Register the mqtt service
+	RegisterServ(mqtt.MQTTServerName, mqtt.NewMQTTServer)
+	// Register the sandbox service
+	RegisterServ(sandbox.SandboxServerName, sandbox.NewSandboxServer)
+	// Register the text service
+	RegisterServ(text.TextServerName, text.NewTextServer)
+	// Register the spreadsheet service
+	RegisterServ(spreadsheet.SpreadsheetServerName, spreadsheet.NewSpreadsheetServer)
+	// Register the docconvert service
+	RegisterServ(docconvert.DocConvertServerName, docconvert.NewDocConvertServer)
+	// Register the code service
+	RegisterServ(code.CodeServerName, code.NewCodeServer)
+	// Register the secrets service
+	RegisterServ(secrets.SecretsServerName, secrets.NewSecretsServer)
+	// Register the time service
+	RegisterServ(clock.TimeServerName, clock.NewTimeServer)
+	// Register the weather service
+	RegisterServ(weather.WeatherServerName, weather.NewWeatherServer)
+	// Register the translate service
+	RegisterServ(translate.TranslateServerName, translate.NewTranslateServer)
+	// Register the forge service
+	RegisterServ(forge.ForgeServerName, forge.NewForgeServer)
+	// Register the issue tracker service
+	RegisterServ(issuetracker.IssueTrackerServerName, issuetracker.NewIssueTrackerServer)
+	// Register the messaging service
+	RegisterServ(messaging.MessagingServerName, messaging.NewMessagingServer)
+	// Register the log service
+	RegisterServ(logs.LogServerName, logs.NewLogServer)
+	// Register the media service
+	RegisterServ(media.MediaServerName, media.NewMediaServer)
+	// Register the llm service
+	RegisterServ(llm.LLMServerName, llm.NewLLMServer)
+	// Register the pipeline service
+	RegisterServ(pipeline.PipelineServerName, pipeline.NewPipelineServer)
+	// Register the proxy service
+	RegisterServ(proxy.ProxyServerName, proxy.NewProxyServer)
 }
@@ -0,0 +1,233 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package sandbox runs short Python and JavaScript programs for the MoLing application.
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	SandboxServerName comm.MoLingServerType = "Sandbox"
+)
+
+// SandboxServer implements the Service interface and runs code snippets with limited resources.
+type SandboxServer struct {
+	abstract.MLService
+	config *SandboxConfig
+	slots  chan struct{}
+
+	envMu    sync.Mutex
+	nodeFlag *string
+}
+
+// NewSandboxServer creates a new SandboxServer instance.
+func NewSandboxServer(ctx context.Context) (abstract.Service, error) {
+	sc := NewSandboxConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("SandboxServer: invalid config type")
+	}
+	sc.WorkPath = filepath.Join(globalConf.BasePath, "cache", "sandbox")
+	sc.OutputPath = filepath.Join(globalConf.BasePath, "data", "sandbox")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("SandboxServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(SandboxServerName))
+	})
+
+	ss := &SandboxServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    sc,
+	}
+	err := ss.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Init creates the working directories and registers the prompt and tools.
+func (ss *SandboxServer) Init() error {
+	for _, dir := range []string{ss.config.WorkPath, ss.config.OutputPath} {
+		err := utils.CreateDirectory(dir)
+		if err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	ss.slots = make(chan struct{}, ss.config.MaxConcurrent)
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "sandbox_prompt",
+			Description: "Get the relevant functions and prompts of the Sandbox MCP Server",
+		},
+		HandlerFunc: ss.handlePrompt,
+	}
+	ss.AddPrompt(pe)
+
+	packages := "Packages to install before running, pip requirement specifiers for Python or npm package names for JavaScript"
+	if !ss.config.AllowPackages {
+		packages = "Not available, installing packages is disabled in the configuration"
+	}
+	ss.AddTool(mcp.NewTool(
+		"sandbox_run",
+		mcp.WithDescription(fmt.Sprintf("Run a short %s program in an empty temporary directory with time and memory limits, and return its exit code, stdout, stderr and generated files", strings.Join(ss.config.Languages, " or "))),
+		mcp.WithString("language",
+			mcp.Description("Language of the program"),
+			mcp.Enum(ss.config.Languages...),
+			mcp.Required(),
+		),
+		mcp.WithString("code",
+			mcp.Description("Program source code"),
+			mcp.Required(),
+		),
+		mcp.WithObject("files",
+			mcp.Description("Input files to create in the working directory, as relative path to text content"),
+		),
+		mcp.WithString("stdin",
+			mcp.Description("Standard input of the program"),
+		),
+		mcp.WithArray("packages",
+			mcp.Description(packages),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(fmt.Sprintf("Time limit in seconds, default: %d, maximum: %d", ss.config.Timeout, ss.config.MaxTimeout)),
+		),
+	), ss.handleRun)
+	return nil
+}
+
+func (ss *SandboxServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ss.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ss.config.OutputPath)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+func (ss *SandboxServer) handleRun(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	req := runRequest{Files: make(map[string]string), Timeout: time.Duration(ss.config.Timeout) * time.Second}
+	req.Language = abstract.GetString(args, "language", "")
+	if !slices.Contains(ss.config.Languages, req.Language) {
+		return mcp.NewToolResultError(fmt.Sprintf("language must be one of: %s", strings.Join(ss.config.Languages, ", "))), nil
+	}
+	req.Code = abstract.GetString(args, "code", "")
+	if strings.TrimSpace(req.Code) == "" {
+		return mcp.NewToolResultError("code must be a non-empty string"), nil
+	}
+	req.Stdin = abstract.GetString(args, "stdin", "")
+	if files := abstract.GetMap(args, "files"); files != nil {
+		for name, content := range files {
+			text, ok := content.(string)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("content of file %s must be a string", name)), nil
+			}
+			req.Files[name] = text
+		}
+	}
+	if packages, ok := args["packages"].([]any); ok && len(packages) > 0 {
+		if !ss.config.AllowPackages {
+			return mcp.NewToolResultError("installing packages is disabled in the configuration"), nil
+		}
+		for _, p := range packages {
+			name, ok := p.(string)
+			if !ok || !packagePattern.MatchString(name) {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid package name %v", p)), nil
+			}
+			req.Packages = append(req.Packages, name)
+		}
+	}
+	if t := abstract.GetFloat(args, "timeout", 0); t > 0 {
+		req.Timeout = time.Duration(min(t, float64(ss.config.MaxTimeout)) * float64(time.Second))
+	}
+
+	select {
+	case ss.slots <- struct{}{}:
+		defer func() { <-ss.slots }()
+	case <-ctx.Done():
+		return mcp.NewToolResultError("cancelled while waiting for a free sandbox slot"), nil
+	}
+	result, err := ss.run(ctx, req)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ss.Logger.Info().Str("language", req.Language).Int("exit_code", result.ExitCode).Bool("timed_out", result.TimedOut).Str("duration", result.Duration).Msg("sandbox run finished")
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode the result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (ss *SandboxServer) Config() string {
+	cfg, err := json.Marshal(ss.config)
+	if err != nil {
+		ss.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ss *SandboxServer) Name() comm.MoLingServerType {
+	return SandboxServerName
+}
+
+func (ss *SandboxServer) Close() error {
+	ss.Logger.Debug().Msg("SandboxServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ss *SandboxServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ss.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ss.config.Check()
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const SandboxPromptDefault = `
+You are an assistant that can run short Python and JavaScript programs in a sandbox. Your capabilities include:
+
+1. **Run code**: Use sandbox_run to execute a snippet for calculations, data processing, parsing or quick experiments. Print the results you need, stdout and stderr are returned.
+
+2. **Input files**: Pass small input files with the files argument, they are placed next to the program in its working directory.
+
+3. **Output files**: Files the program writes to its working directory are kept in %s and listed in the result, small text files are returned inline.
+
+Each run starts in an empty directory and nothing is shared between runs. Runs are limited in time, memory and output size. Writing outside the working directory, starting processes and network access are blocked unless the configuration allows them. Prefer the sandbox over shell commands for running code.
+`
+
+// SandboxConfig represents the configuration for the sandbox service.
+type SandboxConfig struct {
+	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the sandbox service.
+	prompt          string
+	WorkPath        string   `json:"work_path"`        // WorkPath holds the temporary run directories and cached virtual environments.
+	OutputPath      string   `json:"output_path"`      // OutputPath is where generated files are kept.
+	Languages       []string `json:"languages"`        // Languages are the enabled languages: python and javascript.
+	PythonPath      string   `json:"python_path"`      // PythonPath is the Python 3 interpreter.
+	NodePath        string   `json:"node_path"`        // NodePath is the Node.js executable.
+	Timeout         int      `json:"timeout"`          // Timeout is the default run time limit, in seconds.
+	MaxTimeout      int      `json:"max_timeout"`      // MaxTimeout is the largest time limit a call may request, in seconds.
+	MemoryLimit     int      `json:"memory_limit"`     // MemoryLimit is the memory limit of a run, in MB.
+	MaxOutputSize   int      `json:"max_output_size"`  // MaxOutputSize is the maximum size of stdout and stderr each, in bytes.
+	MaxInlineSize   int      `json:"max_inline_size"`  // MaxInlineSize is the maximum size of a generated text file returned inline, in bytes.
+	MaxConcurrent   int      `json:"max_concurrent"`   // MaxConcurrent is the maximum number of simultaneous runs.
+	AllowNetwork    bool     `json:"allow_network"`    // AllowNetwork permits network access from Python code.
+	AllowSubprocess bool     `json:"allow_subprocess"` // AllowSubprocess permits starting other processes.
+	AllowPackages   bool     `json:"allow_packages"`   // AllowPackages permits installing pip and npm packages per run.
+}
+
+// NewSandboxConfig creates a new SandboxConfig with default values.
+func NewSandboxConfig() *SandboxConfig {
+	python := "python3"
+	if runtime.GOOS == "windows" {
+		python = "python"
+	}
+	return &SandboxConfig{
+		prompt:        SandboxPromptDefault,
+		WorkPath:      filepath.Join(os.TempDir(), ".moling", "cache", "sandbox"),
+		OutputPath:    filepath.Join(os.TempDir(), ".moling", "data", "sandbox"),
+		Languages:     []string{languagePython, languageJavaScript},
+		PythonPath:    python,
+		NodePath:      "node",
+		Timeout:       30,
+		MaxTimeout:    300,
+		MemoryLimit:   512,
+		MaxOutputSize: 1024 * 64,
+		MaxInlineSize: 1024 * 16,
+		MaxConcurrent: 2,
+	}
+}
+
+// Check validates the sandbox configuration.
+func (cfg *SandboxConfig) Check() error {
+	cfg.prompt = SandboxPromptDefault
+	if cfg.WorkPath == "" || cfg.OutputPath == "" {
+		return fmt.Errorf("work_path and output_path must not be empty")
+	}
+	for _, l := range cfg.Languages {
+		if l != languagePython && l != languageJavaScript {
+			return fmt.Errorf("unsupported language %q, supported: %s, %s", l, languagePython, languageJavaScript)
+		}
+	}
+	if cfg.Timeout <= 0 || cfg.MaxTimeout < cfg.Timeout {
+		return fmt.Errorf("timeout must be greater than 0 and not exceed max_timeout")
+	}
+	if cfg.MemoryLimit < 0 {
+		return fmt.Errorf("memory_limit must not be negative")
+	}
+	if cfg.MaxOutputSize <= 0 || cfg.MaxInlineSize < 0 || cfg.MaxConcurrent <= 0 {
+		return fmt.Errorf("max_output_size and max_concurrent must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	languagePython     = "python"
+	languageJavaScript = "javascript"
+)
+
+// pythonBootstrap runs main.py after installing an audit hook that blocks writes outside the
+// working directory, process creation and network access, and after applying the memory limit.
+// Audit hooks guard against accidents, they are not a security boundary against hostile code.
+const pythonBootstrap = `import os, sys, runpy
+
+def _sandbox(work, memory, network, subprocess):
+    work = os.path.realpath(work)
+    try:
+        import resource
+        if memory > 0:
+            resource.setrlimit(resource.RLIMIT_AS, (memory, memory))
+    except (ImportError, ValueError, OSError):
+        pass
+
+    def inside(path):
+        if isinstance(path, int):
+            return True
+        try:
+            p = os.path.realpath(os.fsdecode(path))
+        except (TypeError, ValueError):
+            return False
+        return p == work or p.startswith(work + os.sep)
+
+    write_flags = os.O_WRONLY | os.O_RDWR | os.O_CREAT | os.O_APPEND | os.O_TRUNC
+    path_events = {"os.remove", "os.rename", "os.rmdir", "os.mkdir", "os.chmod", "os.chown", "os.symlink", "os.link", "os.truncate", "os.utime", "shutil.rmtree", "shutil.move", "shutil.copyfile"}
+    process_events = {"subprocess.Popen", "os.system", "os.exec", "os.posix_spawn", "os.spawn", "os.fork", "os.forkpty", "pty.spawn", "os.startfile"}
+    network_events = {"socket.connect", "socket.bind", "socket.getaddrinfo", "socket.sendto", "urllib.Request"}
+
+    def hook(event, args):
+        if event == "open":
+            path, mode, flags = args
+            writing = (isinstance(flags, int) and flags & write_flags) or (isinstance(mode, str) and any(c in mode for c in "wax+"))
+            if path is not None and writing and not inside(path):
+                raise PermissionError("sandbox: writing outside the working directory is not allowed: %s" % (path,))
+        elif event in path_events:
+            for p in args[:2]:
+                if isinstance(p, (str, bytes, os.PathLike)) and not inside(p):
+                    raise PermissionError("sandbox: %s outside the working directory is not allowed" % event)
+        elif event in process_events and not subprocess:
+            raise PermissionError("sandbox: starting processes is not allowed")
+        elif event in network_events and not network:
+            raise PermissionError("sandbox: network access is not allowed")
+
+    sys.addaudithook(hook)
+    return work
+
+_work = _sandbox(sys.argv[1], int(sys.argv[2]), sys.argv[3] == "1", sys.argv[4] == "1")
+sys.argv = sys.argv[5:]
+sys.path.insert(0, _work)
+del _sandbox, _work
+runpy.run_path(sys.argv[0], run_name="__main__")
+`
+
+var (
+	esModulePattern = regexp.MustCompile(`(?m)^\s*(import\s.+\sfrom\s|import\s+['"]|export\s)`)
+	packagePattern  = regexp.MustCompile(`^[a-zA-Z0-9@][a-zA-Z0-9@/._\-\[\],=<>~!^]*$`)
+	nodeVersion     = regexp.MustCompile(`v(\d+)\.(\d+)`)
+)
+
+// runRequest is a program to run in the sandbox.
+type runRequest struct {
+	Language string
+	Code     string
+	Files    map[string]string
+	Stdin    string
+	Packages []string
+	Timeout  time.Duration
+}
+
+// outputFile is a file generated by a run.
+type outputFile struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+}
+
+// runResult is the outcome of a run.
+type runResult struct {
+	ExitCode  int          `json:"exit_code"`
+	TimedOut  bool         `json:"timed_out,omitempty"`
+	Duration  string       `json:"duration"`
+	Stdout    string       `json:"stdout"`
+	Stderr    string       `json:"stderr"`
+	Truncated bool         `json:"truncated,omitempty"`
+	Files     []outputFile `json:"files,omitempty"`
+	Notes     []string     `json:"notes,omitempty"`
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := b.limit - b.buf.Len()
+	if room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	s := b.buf.String()
+	if b.truncated {
+		s = strings.ToValidUTF8(s, "") + "\n... output truncated"
+	}
+	return s
+}
+
+// run executes a program in a new temporary directory and collects its output and generated files.
+func (ss *SandboxServer) run(ctx context.Context, req runRequest) (*runResult, error) {
+	dir, err := os.MkdirTemp(ss.config.WorkPath, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	// resolve symlinks such as /tmp -> /private/tmp, so path checks compare real paths
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+
+	inputs := make(map[string]bool)
+	for name, content := range req.Files {
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("invalid file name %q, use a relative path inside the working directory", name)
+		}
+		path := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(content), 0o644)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write input file %s: %w", name, err)
+		}
+		inputs[filepath.ToSlash(name)] = true
+	}
+
+	result := &runResult{}
+	var name string
+	var args []string
+	switch req.Language {
+	case languagePython:
+		name, args, err = ss.pythonCommand(ctx, dir, req, result)
+	case languageJavaScript:
+		name, args, err = ss.nodeCommand(ctx, dir, req, result)
+	default:
+		err = fmt.Errorf("unsupported language %q", req.Language)
+	}
+	if err != nil {
+		return nil, err
+	}
+	inputs[args[len(args)-1]] = true
+
+	runCtx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv(dir)
+	cmd.Stdin = strings.NewReader(req.Stdin)
+	stdout := &limitedBuffer{limit: ss.config.MaxOutputSize}
+	stderr := &limitedBuffer{limit: ss.config.MaxOutputSize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Start()
+	if err == nil {
+		untrack := ss.TrackProcess(cmd)
+		err = cmd.Wait()
+		untrack()
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+
+	result.Files, err = ss.collectFiles(dir, inputs)
+	if err != nil {
+		result.Notes = append(result.Notes, err.Error())
+	}
+	return result, nil
+}
+
+// pythonCommand returns the interpreter and arguments of a Python run, preparing a virtual
+// environment when packages are requested.
+func (ss *SandboxServer) pythonCommand(ctx context.Context, dir string, req runRequest, result *runResult) (string, []string, error) {
+	err := os.WriteFile(filepath.Join(dir, "main.py"), []byte(req.Code), 0o644)
+	if err != nil {
+		return "", nil, err
+	}
+	python := ss.config.PythonPath
+	if len(req.Packages) > 0 {
+		python, err = ss.pythonEnv(ctx, req.Packages)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if runtime.GOOS != "linux" && ss.config.MemoryLimit > 0 {
+		result.Notes = append(result.Notes, "the memory limit may not be enforced on "+runtime.GOOS)
+	}
+	memory := int64(ss.config.MemoryLimit) * 1024 * 1024
+	return python, []string{"-I", "-c", pythonBootstrap, dir, strconv.FormatInt(memory, 10),
+		boolFlag(ss.config.AllowNetwork), boolFlag(ss.config.AllowSubprocess), "main.py"}, nil
+}
+
+// pythonEnv returns the interpreter of a cached virtual environment with the given packages.
+func (ss *SandboxServer) pythonEnv(ctx context.Context, packages []string) (string, error) {
+	sorted := append([]string(nil), packages...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	venv := filepath.Join(ss.config.WorkPath, "venv-"+hex.EncodeToString(sum[:8]))
+	python := filepath.Join(venv, "bin", "python")
+	if runtime.GOOS == "windows" {
+		python = filepath.Join(venv, "Scripts", "python.exe")
+	}
+	ss.envMu.Lock()
+	defer ss.envMu.Unlock()
+	if _, err := os.Stat(filepath.Join(venv, ".complete")); err == nil {
+		return python, nil
+	}
+	_ = os.RemoveAll(venv)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ss.config.MaxTimeout)*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, ss.config.PythonPath, "-m", "venv", venv).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to create virtual environment: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	args := append([]string{"-m", "pip", "install", "--disable-pip-version-check", "--no-input", "--"}, sorted...)
+	output, err = exec.CommandContext(ctx, python, args...).CombinedOutput()
+	if err != nil {
+		_ = os.RemoveAll(venv)
+		return "", fmt.Errorf("failed to install packages: %w, output: %s", err, tail(string(output), 2000))
+	}
+	err = os.WriteFile(filepath.Join(venv, ".complete"), nil, 0o644)
+	if err != nil {
+		return "", err
+	}
+	return python, nil
+}
+
+// nodeCommand returns the executable and arguments of a JavaScript run, installing npm packages
+// into the run directory when requested.
+func (ss *SandboxServer) nodeCommand(ctx context.Context, dir string, req runRequest, result *runResult) (string, []string, error) {
+	main := "main.js"
+	if esModulePattern.MatchString(req.Code) {
+		main = "main.mjs"
+	}
+	err := os.WriteFile(filepath.Join(dir, main), []byte(req.Code), 0o644)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(req.Packages) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(ss.config.MaxTimeout)*time.Second)
+		defer cancel()
+		npm := filepath.Join(filepath.Dir(ss.nodeExecutable()), "npm")
+		if _, err := exec.LookPath(npm); err != nil {
+			npm = "npm"
+		}
+		args := append([]string{"install", "--no-audit", "--no-fund", "--no-save", "--"}, req.Packages...)
+		cmd := exec.CommandContext(ctx, npm, args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to install packages: %w, output: %s", err, tail(string(output), 2000))
+		}
+	}
+	args := []string{}
+	if ss.config.MemoryLimit > 0 {
+		args = append(args, fmt.Sprintf("--max-old-space-size=%d", ss.config.MemoryLimit))
+	}
+	flag, err := ss.nodePermissionFlag(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if flag == "" {
+		result.Notes = append(result.Notes, "this Node.js version has no permission model, file system and process access are not restricted")
+	} else {
+		args = append(args, flag, "--allow-fs-read="+dir, "--allow-fs-write="+dir)
+		if flag == "--experimental-permission" {
+			args = append(args, "--no-warnings")
+		}
+		if ss.config.AllowSubprocess {
+			args = append(args, "--allow-child-process")
+		}
+	}
+	if !ss.config.AllowNetwork {
+		result.Notes = append(result.Notes, "network access is not restricted for JavaScript")
+	}
+	return ss.config.NodePath, append(args, main), nil
+}
+
+func (ss *SandboxServer) nodeExecutable() string {
+	path, err := exec.LookPath(ss.config.NodePath)
+	if err != nil {
+		return ss.config.NodePath
+	}
+	return path
+}
+
+// nodePermissionFlag returns the flag enabling the Node.js permission model, which is stable
+// since Node.js 22.13 and 23.5 and experimental since 20.
+func (ss *SandboxServer) nodePermissionFlag(ctx context.Context) (string, error) {
+	ss.envMu.Lock()
+	defer ss.envMu.Unlock()
+	if ss.nodeFlag != nil {
+		return *ss.nodeFlag, nil
+	}
+	output, err := exec.CommandContext(ctx, ss.config.NodePath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("node is not available: %w", err)
+	}
+	flag := ""
+	if m := nodeVersion.FindStringSubmatch(string(output)); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		switch {
+		case major > 23 || (major == 23 && minor >= 5) || (major == 22 && minor >= 13):
+			flag = "--permission"
+		case major >= 20:
+			flag = "--experimental-permission"
+		}
+	}
+	ss.nodeFlag = &flag
+	return flag, nil
+}
+
+// collectFiles moves the files created or modified by a run to the output directory.
+func (ss *SandboxServer) collectFiles(dir string, inputs map[string]bool) ([]outputFile, error) {
+	var files []outputFile
+	var outDir string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case "node_modules", "__pycache__":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if !d.Type().IsRegular() || inputs[rel] || rel == "package.json" || rel == "package-lock.json" {
+			return nil
+		}
+		if outDir == "" {
+			outDir = filepath.Join(ss.config.OutputPath, time.Now().Format("20060102-150405")+"-"+filepath.Base(dir)[len("run-"):])
+		}
+		target := filepath.Join(outDir, filepath.FromSlash(rel))
+		err = copyFile(path, target)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f := outputFile{Name: rel, Size: info.Size(), Path: target}
+		if info.Size() <= int64(ss.config.MaxInlineSize) {
+			data, err := os.ReadFile(path)
+			if err == nil && utf8.Valid(data) {
+				f.Content = string(data)
+			}
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return files, fmt.Errorf("failed to collect generated files: %w", err)
+	}
+	return files, nil
+}
+
+func copyFile(src, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0o755)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// sandboxEnv returns a minimal environment, so that credentials in the environment of MoLing do
+// not leak into the program.
+func sandboxEnv(dir string) []string {
+	env := []string{
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"TEMP=" + dir,
+		"TMP=" + dir,
+		"LANG=C.UTF-8",
+		"PYTHONIOENCODING=utf-8",
+		"PYTHONDONTWRITEBYTECODE=1",
+		"NODE_OPTIONS=",
+	}
+	for _, key := range []string{"PATH", "SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT"} {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
+
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) > n {
+		return "..." + s[len(s)-n:]
+	}
+	return s
+}
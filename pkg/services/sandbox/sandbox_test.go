@@ -0,0 +1,156 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func newTestServer(t *testing.T) *SandboxServer {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewSandboxServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create SandboxServer: %s", err.Error())
+	}
+	err = srv.LoadConfig(map[string]any{
+		"work_path":       t.TempDir(),
+		"output_path":     t.TempDir(),
+		"max_output_size": float64(1024),
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init SandboxServer: %s", err.Error())
+	}
+	return srv.(*SandboxServer)
+}
+
+func runCode(t *testing.T, ss *SandboxServer, args map[string]any) runResult {
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = args
+	res, err := ss.handleRun(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Tool call failed: %s", err.Error())
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("sandbox_run returned an error: %s", text)
+	}
+	var result runResult
+	err = json.Unmarshal([]byte(text), &result)
+	if err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	return result
+}
+
+func TestSandboxPython(t *testing.T) {
+	ss := newTestServer(t)
+	if _, err := exec.LookPath(ss.config.PythonPath); err != nil {
+		t.Skipf("%s is not installed", ss.config.PythonPath)
+	}
+	code := `
+import os, sys
+data = open("input.txt").read()
+print(data.upper(), sys.stdin.read())
+os.makedirs("out", exist_ok=True)
+with open("out/result.txt", "w") as f:
+    f.write("done")
+for attempt in [lambda: open(os.path.join(os.path.dirname(os.getcwd()), "escape.txt"), "w"), lambda: os.system("echo hi")]:
+    try:
+        attempt()
+        print("allowed")
+    except PermissionError as e:
+        print("blocked")
+sys.exit(3)
+`
+	result := runCode(t, ss, map[string]any{
+		"language": "python",
+		"code":     code,
+		"files":    map[string]any{"input.txt": "hello"},
+		"stdin":    "from stdin",
+	})
+	if result.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d, stderr: %s", result.ExitCode, result.Stderr)
+	}
+	if !strings.Contains(result.Stdout, "HELLO from stdin") || strings.Count(result.Stdout, "blocked") != 2 {
+		t.Errorf("Unexpected stdout: %s", result.Stdout)
+	}
+	if len(result.Files) != 1 || result.Files[0].Name != "out/result.txt" || result.Files[0].Content != "done" {
+		t.Fatalf("Unexpected generated files: %+v", result.Files)
+	}
+	if _, err := os.Stat(result.Files[0].Path); err != nil {
+		t.Errorf("Generated file was not kept: %s", err.Error())
+	}
+
+	result = runCode(t, ss, map[string]any{"language": "python", "code": "while True: print('x' * 100)", "timeout": float64(1)})
+	if !result.TimedOut || !result.Truncated || len(result.Stdout) > 1100 {
+		t.Errorf("Expected a truncated, timed out run, got timed_out=%v truncated=%v size=%d", result.TimedOut, result.Truncated, len(result.Stdout))
+	}
+}
+
+func TestSandboxJavaScript(t *testing.T) {
+	ss := newTestServer(t)
+	if _, err := exec.LookPath(ss.config.NodePath); err != nil {
+		t.Skipf("%s is not installed", ss.config.NodePath)
+	}
+	result := runCode(t, ss, map[string]any{
+		"language": "javascript",
+		"code":     `const fs = require("fs"); fs.writeFileSync("chart.json", JSON.stringify({ok: true})); console.log([1, 2, 3].map(x => x * 2).join(","));`,
+	})
+	if result.ExitCode != 0 || strings.TrimSpace(result.Stdout) != "2,4,6" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Files) != 1 || result.Files[0].Content != `{"ok":true}` {
+		t.Errorf("Unexpected generated files: %+v", result.Files)
+	}
+
+	result = runCode(t, ss, map[string]any{"language": "javascript", "code": `import os from "node:os"; console.log(typeof os.cpus);`})
+	if strings.TrimSpace(result.Stdout) != "function" {
+		t.Errorf("Expected ES module code to run, got: %+v", result)
+	}
+}
+
+func TestSandboxInvalidInput(t *testing.T) {
+	ss := newTestServer(t)
+	for _, args := range []map[string]any{
+		{"language": "ruby", "code": "puts 1"},
+		{"language": "python", "code": "print(1)", "files": map[string]any{"../x.txt": "x"}},
+		{"language": "python", "code": "print(1)", "packages": []any{"requests"}},
+	} {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := ss.handleRun(context.Background(), req)
+		if err != nil || !res.IsError {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package screen provides desktop and native window screenshots for the MoLing application.
+package screen
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	ScreenServerName comm.MoLingServerType = "Screen"
+)
+
+var fileNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+// ScreenServer implements the Service interface and captures the desktop or native application windows.
+type ScreenServer struct {
+	abstract.MLService
+	config *ScreenConfig
+}
+
+// NewScreenServer creates a new ScreenServer instance.
+func NewScreenServer(ctx context.Context) (abstract.Service, error) {
+	sc := NewScreenConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("ScreenServer: invalid config type")
+	}
+	sc.DataPath = filepath.Join(globalConf.BasePath, "data")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("ScreenServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(ScreenServerName))
+	})
+
+	ss := &ScreenServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    sc,
+	}
+
+	err := ss.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Init initializes the screen server, registering its prompt and tools.
+func (ss *ScreenServer) Init() error {
+	err := utils.CreateDirectory(ss.config.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "screen_prompt",
+			Description: "Get the relevant functions and prompts of the Screen MCP Server",
+		},
+		HandlerFunc: ss.handlePrompt,
+	}
+	ss.AddPrompt(pe)
+
+	ss.AddTool(mcp.NewTool(
+		"screen_capture",
+		mcp.WithDescription("Take a screenshot of the full desktop (all displays, or a single display)"),
+		mcp.WithString("name",
+			mcp.Description("Name for the screenshot file, default: screen"),
+		),
+		mcp.WithNumber("display",
+			mcp.Description("Display index to capture, starting from 1. Default: all displays (main display on macOS)"),
+		),
+		mcp.WithBoolean("inline",
+			mcp.Description("Return the screenshot as inline image content in addition to saving it"),
+		),
+	), ss.handleCaptureScreen)
+
+	ss.AddTool(mcp.NewTool(
+		"screen_capture_window",
+		mcp.WithDescription("Take a screenshot of a native application window, matched by window title or application name"),
+		mcp.WithString("title",
+			mcp.Description("Part of the window title to match (case sensitive)"),
+		),
+		mcp.WithString("app",
+			mcp.Description("Application or process name to match, e.g. Finder, code, notepad"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name for the screenshot file, default: window"),
+		),
+		mcp.WithBoolean("inline",
+			mcp.Description("Return the screenshot as inline image content in addition to saving it"),
+		),
+	), ss.handleCaptureWindow)
+
+	ss.AddTool(mcp.NewTool(
+		"screen_list_windows",
+		mcp.WithDescription("List visible application windows with their application names and titles"),
+	), ss.handleListWindows)
+	return nil
+}
+
+func (ss *ScreenServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ss.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, runtime.GOOS)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// handleCaptureScreen handles the full desktop screenshot action.
+func (ss *ScreenServer) handleCaptureScreen(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "name", "")
+	display := abstract.GetInt(args, "display", 0)
+	inline := abstract.GetBool(args, "inline", false)
+	if display < 0 {
+		return mcp.NewToolResultError("display must be greater than 0"), nil
+	}
+
+	output := ss.outputPath(name, "screen")
+	runCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(ss.config.Timeout)*time.Second)
+	defer cancelFunc()
+	err := captureScreen(runCtx, display, output)
+	if err != nil {
+		ss.Logger.Error().Err(err).Msg("failed to capture screen")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to capture screen: %s", err.Error())), nil
+	}
+	return ss.captureResult(output, inline)
+}
+
+// handleCaptureWindow handles the application window screenshot action.
+func (ss *ScreenServer) handleCaptureWindow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	title := abstract.GetString(args, "title", "")
+	app := abstract.GetString(args, "app", "")
+	name := abstract.GetString(args, "name", "")
+	inline := abstract.GetBool(args, "inline", false)
+	if title == "" && app == "" {
+		return mcp.NewToolResultError("either title or app must be specified"), nil
+	}
+
+	output := ss.outputPath(name, "window")
+	runCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(ss.config.Timeout)*time.Second)
+	defer cancelFunc()
+	err := captureWindow(runCtx, windowMatcher{Title: title, App: app}, output)
+	if err != nil {
+		ss.Logger.Error().Err(err).Str("title", title).Str("app", app).Msg("failed to capture window")
+		return mcp.NewToolResultError(fmt.Sprintf("failed to capture window: %s", err.Error())), nil
+	}
+	return ss.captureResult(output, inline)
+}
+
+// handleListWindows handles listing the visible application windows.
+func (ss *ScreenServer) handleListWindows(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(ss.config.Timeout)*time.Second)
+	defer cancelFunc()
+	windows, err := listWindows(runCtx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list windows: %s", err.Error())), nil
+	}
+	if len(windows) == 0 {
+		return mcp.NewToolResultText("No visible windows found"), nil
+	}
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d windows:\n\n", len(windows)))
+	for _, w := range windows {
+		result.WriteString(fmt.Sprintf("[%s] %s\n", w.App, w.Title))
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// outputPath returns the file path for a new screenshot under DataPath.
+func (ss *ScreenServer) outputPath(name, defaultName string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".png")
+	name = fileNameReplacer.ReplaceAllString(name, "_")
+	if name == "" || name == "_" {
+		name = defaultName
+	}
+	return filepath.Join(ss.config.DataPath, fmt.Sprintf("%s_%s.png", name, time.Now().Format("20060102150405.000")))
+}
+
+// captureResult builds the tool result for a saved screenshot, optionally embedding the image.
+func (ss *ScreenServer) captureResult(output string, inline bool) (*mcp.CallToolResult, error) {
+	info, err := os.Stat(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("screenshot was not written: %s", err.Error())), nil
+	}
+	text := fmt.Sprintf("Screenshot saved to:%s (%d bytes)", output, info.Size())
+	if !inline {
+		return mcp.NewToolResultText(text), nil
+	}
+	if info.Size() > int64(ss.config.MaxInlineSize) {
+		return mcp.NewToolResultText(fmt.Sprintf("%s, too large to return inline (limit %d bytes)", text, ss.config.MaxInlineSize)), nil
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read screenshot: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultImage(text, base64.StdEncoding.EncodeToString(data), "image/png"), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ss *ScreenServer) Config() string {
+	cfg, err := json.Marshal(ss.config)
+	if err != nil {
+		ss.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ss *ScreenServer) Name() comm.MoLingServerType {
+	return ScreenServerName
+}
+
+func (ss *ScreenServer) Close() error {
+	ss.Logger.Debug().Msg("ScreenServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ss *ScreenServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ss.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ss.config.Check()
+}
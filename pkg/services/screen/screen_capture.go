@@ -0,0 +1,63 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoCaptureTool is returned when no screenshot utility is available on the system.
+var ErrNoCaptureTool = errors.New("no screenshot utility found")
+
+// windowMatcher selects a window by title and/or application name.
+type windowMatcher struct {
+	Title string
+	App   string
+}
+
+// windowInfo describes a visible application window.
+type windowInfo struct {
+	App   string
+	Title string
+}
+
+// runTool executes an external capture utility and returns its trimmed output.
+func runTool(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s timed out", name)
+		}
+		return "", fmt.Errorf("%s failed: %w, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// firstAvailable returns the first tool in names that can be found in PATH.
+func firstAvailable(names ...string) (string, error) {
+	for _, name := range names {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w, please install one of: %s", ErrNoCaptureTool, strings.Join(names, ", "))
+}
@@ -0,0 +1,98 @@
+//go:build darwin
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// captureScreen captures the desktop with the macOS screencapture utility.
+func captureScreen(ctx context.Context, display int, output string) error {
+	args := []string{"-x", "-t", "png"}
+	if display > 0 {
+		args = append(args, "-D", strconv.Itoa(display))
+	}
+	args = append(args, output)
+	_, err := runTool(ctx, "screencapture", args...)
+	return err
+}
+
+// captureWindow brings the matching window to the front and captures its bounds.
+func captureWindow(ctx context.Context, m windowMatcher, output string) error {
+	script := fmt.Sprintf(`
+tell application "System Events"
+	repeat with p in (every process whose background only is false)
+		if "%s" is "" or name of p contains "%s" then
+			repeat with w in (every window of p)
+				if "%s" is "" or name of w contains "%s" then
+					set frontmost of p to true
+					set {x, y} to position of w
+					set {wd, ht} to size of w
+					return (x as text) & "," & (y as text) & "," & (wd as text) & "," & (ht as text)
+				end if
+			end repeat
+		end if
+	end repeat
+end tell
+return ""`, appleScriptQuote(m.App), appleScriptQuote(m.App), appleScriptQuote(m.Title), appleScriptQuote(m.Title))
+	bounds, err := runTool(ctx, "osascript", "-e", script)
+	if err != nil {
+		return err
+	}
+	if bounds == "" {
+		return fmt.Errorf("no window matches title %q app %q", m.Title, m.App)
+	}
+	_, err = runTool(ctx, "screencapture", "-x", "-t", "png", "-R", strings.ReplaceAll(bounds, " ", ""), output)
+	return err
+}
+
+// listWindows lists the windows of all foreground processes via System Events.
+func listWindows(ctx context.Context) ([]windowInfo, error) {
+	script := `
+set out to ""
+tell application "System Events"
+	repeat with p in (every process whose background only is false)
+		repeat with w in (every window of p)
+			set out to out & (name of p) & tab & (name of w) & linefeed
+		end repeat
+	end repeat
+end tell
+return out`
+	output, err := runTool(ctx, "osascript", "-e", script)
+	if err != nil {
+		return nil, err
+	}
+	var windows []windowInfo
+	for _, line := range strings.Split(output, "\n") {
+		app, title, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		windows = append(windows, windowInfo{App: app, Title: title})
+	}
+	return windows, nil
+}
+
+// appleScriptQuote escapes a value for use inside a double quoted AppleScript string.
+func appleScriptQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
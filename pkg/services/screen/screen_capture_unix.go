@@ -0,0 +1,104 @@
+//go:build !darwin && !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// captureScreen captures the desktop with the first available X11/Wayland screenshot utility.
+func captureScreen(ctx context.Context, display int, output string) error {
+	if display > 0 {
+		return fmt.Errorf("selecting a single display is not supported on this platform")
+	}
+	tool, err := firstAvailable("grim", "gnome-screenshot", "import", "scrot")
+	if err != nil {
+		return err
+	}
+	switch tool {
+	case "gnome-screenshot":
+		_, err = runTool(ctx, tool, "-f", output)
+	case "import":
+		_, err = runTool(ctx, tool, "-window", "root", output)
+	case "scrot":
+		_, err = runTool(ctx, tool, "--overwrite", output)
+	default:
+		_, err = runTool(ctx, tool, output)
+	}
+	return err
+}
+
+// captureWindow finds the window with xdotool and captures it with ImageMagick import.
+func captureWindow(ctx context.Context, m windowMatcher, output string) error {
+	if _, err := firstAvailable("xdotool"); err != nil {
+		return err
+	}
+	if _, err := firstAvailable("import"); err != nil {
+		return err
+	}
+	args := []string{"search", "--onlyvisible"}
+	if m.Title != "" {
+		args = append(args, "--name", regexp.QuoteMeta(m.Title))
+	} else {
+		args = append(args, "--class", regexp.QuoteMeta(m.App))
+	}
+	ids, err := runTool(ctx, "xdotool", args...)
+	if err != nil || ids == "" {
+		return fmt.Errorf("no window matches title %q app %q", m.Title, m.App)
+	}
+	for _, id := range strings.Fields(ids) {
+		if m.Title != "" && m.App != "" {
+			class, err := runTool(ctx, "xdotool", "getwindowclassname", id)
+			if err != nil || !strings.Contains(strings.ToLower(class), strings.ToLower(m.App)) {
+				continue
+			}
+		}
+		_, err = runTool(ctx, "import", "-window", id, output)
+		return err
+	}
+	return fmt.Errorf("no window matches title %q app %q", m.Title, m.App)
+}
+
+// listWindows lists the managed windows with wmctrl.
+func listWindows(ctx context.Context) ([]windowInfo, error) {
+	if _, err := firstAvailable("wmctrl"); err != nil {
+		return nil, err
+	}
+	output, err := runTool(ctx, "wmctrl", "-lx")
+	if err != nil {
+		return nil, err
+	}
+	var windows []windowInfo
+	for _, line := range strings.Split(output, "\n") {
+		// 0x04400003  0 code.Code  hostname  main.go - moling - Visual Studio Code
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		title := ""
+		if len(fields) > 4 {
+			title = strings.Join(fields[4:], " ")
+		}
+		windows = append(windows, windowInfo{App: fields[2], Title: title})
+	}
+	return windows, nil
+}
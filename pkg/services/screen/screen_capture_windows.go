@@ -0,0 +1,100 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const psCaptureHeader = `
+Add-Type -AssemblyName System.Windows.Forms,System.Drawing
+Add-Type @"
+using System;
+using System.Runtime.InteropServices;
+public struct RECT { public int Left; public int Top; public int Right; public int Bottom; }
+public class Win32 {
+	[DllImport("user32.dll")] public static extern bool GetWindowRect(IntPtr hWnd, out RECT rect);
+	[DllImport("user32.dll")] public static extern bool SetForegroundWindow(IntPtr hWnd);
+	[DllImport("user32.dll")] public static extern bool SetProcessDPIAware();
+}
+"@
+[Win32]::SetProcessDPIAware() | Out-Null
+function Save-Region($x, $y, $w, $h, $path) {
+	$bmp = New-Object System.Drawing.Bitmap $w, $h
+	$g = [System.Drawing.Graphics]::FromImage($bmp)
+	$g.CopyFromScreen($x, $y, 0, 0, $bmp.Size)
+	$bmp.Save($path, [System.Drawing.Imaging.ImageFormat]::Png)
+	$g.Dispose(); $bmp.Dispose()
+}
+`
+
+// captureScreen captures the desktop with System.Drawing via PowerShell.
+func captureScreen(ctx context.Context, display int, output string) error {
+	script := psCaptureHeader
+	if display > 0 {
+		script += fmt.Sprintf(`
+$screens = [System.Windows.Forms.Screen]::AllScreens
+if (%d -gt $screens.Count) { throw "display %d not found" }
+$b = $screens[%d].Bounds
+Save-Region $b.X $b.Y $b.Width $b.Height '%s'`, display, display, display-1, utils.PSQuote(output))
+	} else {
+		script += fmt.Sprintf(`
+$b = [System.Windows.Forms.SystemInformation]::VirtualScreen
+Save-Region $b.X $b.Y $b.Width $b.Height '%s'`, utils.PSQuote(output))
+	}
+	_, err := runTool(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	return err
+}
+
+// captureWindow brings the matching window to the front and captures its rectangle.
+func captureWindow(ctx context.Context, m windowMatcher, output string) error {
+	script := psCaptureHeader + fmt.Sprintf(`
+$p = Get-Process | Where-Object { $_.MainWindowHandle -ne 0 -and ('%s' -eq '' -or $_.MainWindowTitle -like '*%s*') -and ('%s' -eq '' -or $_.ProcessName -like '*%s*') } | Select-Object -First 1
+if ($p -eq $null) { throw "no window matches" }
+[Win32]::SetForegroundWindow($p.MainWindowHandle) | Out-Null
+Start-Sleep -Milliseconds 300
+$r = New-Object RECT
+[Win32]::GetWindowRect($p.MainWindowHandle, [ref]$r) | Out-Null
+Save-Region $r.Left $r.Top ($r.Right - $r.Left) ($r.Bottom - $r.Top) '%s'`,
+		utils.PSQuote(m.Title), utils.PSQuote(m.Title), utils.PSQuote(m.App), utils.PSQuote(m.App), utils.PSQuote(output))
+	_, err := runTool(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	return err
+}
+
+// listWindows lists processes that own a main window.
+func listWindows(ctx context.Context) ([]windowInfo, error) {
+	script := `Get-Process | Where-Object { $_.MainWindowHandle -ne 0 } | ForEach-Object { $_.ProcessName + "` + "`t" + `" + $_.MainWindowTitle }`
+	output, err := runTool(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err != nil {
+		return nil, err
+	}
+	var windows []windowInfo
+	for _, line := range strings.Split(output, "\n") {
+		app, title, found := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !found {
+			continue
+		}
+		windows = append(windows, windowInfo{App: app, Title: title})
+	}
+	return windows, nil
+}
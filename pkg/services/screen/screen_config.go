@@ -0,0 +1,78 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const ScreenPromptDefault = `
+You are a desktop screen capture assistant running on %s. You can see native applications that are not inside the browser. Your capabilities include:
+
+1. **Desktop Capture**: Take a screenshot of the full desktop, or of a single display when several monitors are attached.
+
+2. **Window Capture**: Take a screenshot of one application window, matched by its window title or by the application (process) name.
+
+3. **Window Listing**: List the visible windows with their application names and titles, so you can pick the right capture target.
+
+Screenshots are saved to the MoLing data directory and can optionally be returned inline as image content. Inline images are limited in size; for large screens, read the saved file path instead.
+
+Only capture the screen when the user asks for it, since the desktop may contain private information.
+`
+
+// ScreenConfig represents the configuration for the screen capture service.
+type ScreenConfig struct {
+	PromptFile    string `json:"prompt_file"` // PromptFile is the prompt file for the screen service.
+	prompt        string
+	DataPath      string `json:"data_path"`       // DataPath is the path where screenshots are saved.
+	Timeout       int    `json:"timeout"`         // Timeout is the timeout for a single capture. time.Second
+	MaxInlineSize int    `json:"max_inline_size"` // MaxInlineSize is the maximum size of a screenshot returned inline, in bytes.
+}
+
+// NewScreenConfig creates a new ScreenConfig with default values.
+func NewScreenConfig() *ScreenConfig {
+	return &ScreenConfig{
+		prompt:        ScreenPromptDefault,
+		DataPath:      filepath.Join(os.TempDir(), ".moling", "data"),
+		Timeout:       15,
+		MaxInlineSize: 1024 * 1024 * 1,
+	}
+}
+
+// Check validates the screen configuration.
+func (cfg *ScreenConfig) Check() error {
+	cfg.prompt = ScreenPromptDefault
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.MaxInlineSize < 0 {
+		return fmt.Errorf("max_inline_size must not be negative")
+	}
+	if cfg.DataPath == "" {
+		return fmt.Errorf("data_path must not be empty")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
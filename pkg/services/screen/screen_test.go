@@ -0,0 +1,61 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package screen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestScreenServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewScreenServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create ScreenServer: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init ScreenServer: %s", err.Error())
+	}
+	if len(srv.Tools()) != 3 {
+		t.Errorf("Expected 3 tools, got %d", len(srv.Tools()))
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	ss := &ScreenServer{config: NewScreenConfig()}
+	cases := map[string]string{
+		"":              "screen_",
+		"my shot.png":   "my_shot_",
+		"../../etc/foo": "_etc_foo_",
+	}
+	for name, prefix := range cases {
+		p := ss.outputPath(name, "screen")
+		if filepath.Dir(p) != ss.config.DataPath {
+			t.Errorf("outputPath(%q) escaped data path: %s", name, p)
+		}
+		if !strings.HasPrefix(filepath.Base(p), prefix) {
+			t.Errorf("outputPath(%q) = %s, expected prefix %s", name, filepath.Base(p), prefix)
+		}
+	}
+}
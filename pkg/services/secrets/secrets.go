@@ -0,0 +1,267 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package secrets provides allowlisted access to the OS keychain and password manager CLIs for the MoLing application.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	SecretsServerName comm.MoLingServerType = "Secrets"
+)
+
+// active is the loaded secrets service used by Lookup.
+var active atomic.Pointer[SecretsServer]
+
+// SecretsServer implements the Service interface and reads allowlisted secrets from the OS keychain and password managers.
+type SecretsServer struct {
+	abstract.MLService
+	config  *SecretsConfig
+	auditMu sync.Mutex
+}
+
+// auditEntry is one line of the audit log. It never contains the secret value.
+type auditEntry struct {
+	Time    string `json:"time"`
+	Name    string `json:"name"`
+	Backend string `json:"backend,omitempty"`
+	Caller  string `json:"caller"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewSecretsServer creates a new SecretsServer instance.
+func NewSecretsServer(ctx context.Context) (abstract.Service, error) {
+	sc := NewSecretsConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("SecretsServer: invalid config type")
+	}
+	sc.AuditFile = filepath.Join(globalConf.BasePath, "logs", "secrets_audit.log")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("SecretsServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(SecretsServerName))
+	})
+
+	ss := &SecretsServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    sc,
+	}
+	err := ss.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Init registers the prompt and tools of the secrets service.
+func (ss *SecretsServer) Init() error {
+	err := utils.CreateDirectory(filepath.Dir(ss.config.AuditFile))
+	if err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "secrets_prompt",
+			Description: "Get the relevant functions and prompts of the Secrets MCP Server",
+		},
+		HandlerFunc: ss.handlePrompt,
+	}
+	ss.AddPrompt(pe)
+
+	ss.AddTool(mcp.NewTool(
+		"secret_list",
+		mcp.WithDescription("List the names and descriptions of the secrets that may be read, without their values"),
+	), ss.handleList)
+
+	ss.AddTool(mcp.NewTool(
+		"secret_get",
+		mcp.WithDescription("Read the value of an allowlisted secret by name. Every access is audited"),
+		mcp.WithString("name",
+			mcp.Description("Name of the secret, as listed by secret_list"),
+			mcp.Required(),
+		),
+	), ss.handleGet)
+
+	active.Store(ss)
+	return nil
+}
+
+func (ss *SecretsServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ss.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// handleList lists the allowlisted secrets.
+func (ss *SecretsServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if len(ss.config.Secrets) == 0 {
+		return mcp.NewToolResultText("No secrets are configured, add them to the secrets allowlist of the Secrets service"), nil
+	}
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%d secrets may be read:\n\n", len(ss.config.Secrets)))
+	for _, entry := range ss.config.Secrets {
+		result.WriteString(fmt.Sprintf("- %s (%s)", entry.Name, entry.Backend))
+		if entry.Description != "" {
+			result.WriteString(": " + entry.Description)
+		}
+		result.WriteString("\n")
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleGet reads one secret for the connected client.
+func (ss *SecretsServer) handleGet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name, ok := args["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return mcp.NewToolResultError("name must be a non-empty string"), nil
+	}
+	value, err := ss.get(ctx, clientCaller(ctx), strings.TrimSpace(name))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(value), nil
+}
+
+// get reads an allowlisted secret and records the access in the audit log.
+func (ss *SecretsServer) get(ctx context.Context, caller, name string) (string, error) {
+	var entry *SecretEntry
+	for i := range ss.config.Secrets {
+		if ss.config.Secrets[i].Name == name {
+			entry = &ss.config.Secrets[i]
+			break
+		}
+	}
+	if entry == nil {
+		ss.audit(auditEntry{Name: name, Caller: caller, Result: "denied"})
+		return "", fmt.Errorf("%w: %s", ErrSecretNotAllowed, name)
+	}
+	runCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(ss.config.Timeout)*time.Second)
+	defer cancelFunc()
+	value, err := ss.fetch(runCtx, *entry)
+	if err != nil {
+		ss.audit(auditEntry{Name: name, Backend: entry.Backend, Caller: caller, Result: "error", Error: err.Error()})
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	ss.audit(auditEntry{Name: name, Backend: entry.Backend, Caller: caller, Result: "ok"})
+	return value, nil
+}
+
+// audit appends an entry to the audit log and the service log.
+func (ss *SecretsServer) audit(entry auditEntry) {
+	entry.Time = time.Now().Format(time.RFC3339)
+	ss.Logger.Info().Str("secret", entry.Name).Str("caller", entry.Caller).Str("result", entry.Result).Str("error", entry.Error).Msg("secret access")
+	line, err := json.Marshal(entry)
+	if err != nil {
+		ss.Logger.Error().Err(err).Msg("failed to marshal audit entry")
+		return
+	}
+	ss.auditMu.Lock()
+	defer ss.auditMu.Unlock()
+	f, err := os.OpenFile(ss.config.AuditFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		ss.Logger.Error().Err(err).Str("file", ss.config.AuditFile).Msg("failed to open audit log")
+		return
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		ss.Logger.Error().Err(err).Str("file", ss.config.AuditFile).Msg("failed to write audit log")
+	}
+}
+
+// clientCaller names the MCP client of a call for the audit log.
+func clientCaller(ctx context.Context) string {
+	session := server.ClientSessionFromContext(ctx)
+	if ci, ok := session.(interface{ GetClientInfo() mcp.Implementation }); ok && ci.GetClientInfo().Name != "" {
+		return "client:" + ci.GetClientInfo().Name
+	}
+	return "client"
+}
+
+// Lookup reads an allowlisted secret on behalf of another service, so that services can
+// reference secrets by name instead of storing them in their configuration. The access is
+// audited with the calling service as caller.
+func Lookup(ctx context.Context, caller comm.MoLingServerType, name string) (string, error) {
+	ss := active.Load()
+	if ss == nil {
+		return "", ErrSecretsUnavailable
+	}
+	return ss.get(ctx, "service:"+string(caller), name)
+}
+
+// Config returns the configuration of the service as a string.
+func (ss *SecretsServer) Config() string {
+	cfg, err := json.Marshal(ss.config)
+	if err != nil {
+		ss.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ss *SecretsServer) Name() comm.MoLingServerType {
+	return SecretsServerName
+}
+
+func (ss *SecretsServer) Close() error {
+	active.CompareAndSwap(ss, nil)
+	ss.Logger.Debug().Msg("SecretsServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ss *SecretsServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ss.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ss.config.Check()
+}
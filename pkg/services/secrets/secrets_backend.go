@@ -0,0 +1,110 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+var (
+	// ErrSecretNotAllowed is returned for secrets that are not on the allowlist.
+	ErrSecretNotAllowed = errors.New("secret is not on the allowlist")
+	// ErrSecretsUnavailable is returned by Lookup when the secrets service is not loaded.
+	ErrSecretsUnavailable = errors.New("secrets service is not loaded")
+)
+
+func init() {
+	// ${keychain:service} and ${keychain:service/account} in the configuration file read the OS keychain.
+	config.RegisterSecretResolver("keychain", keychainSecret)
+}
+
+// keychainSecret resolves a keychain reference of the configuration file, with the default timeout of the service.
+func keychainSecret(ref string) (string, error) {
+	service, account, _ := strings.Cut(ref, "/")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(NewSecretsConfig().Timeout)*time.Second)
+	defer cancel()
+	return keychainGet(ctx, service, account)
+}
+
+// runTool executes a password manager CLI and returns its standard output. The output is
+// never part of the returned error, since it may contain the secret.
+func runTool(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s timed out", name)
+		}
+		return "", fmt.Errorf("%s failed: %w, output: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// fetch reads the value of a secret from its backend.
+func (ss *SecretsServer) fetch(ctx context.Context, entry SecretEntry) (string, error) {
+	switch entry.Backend {
+	case BackendKeychain:
+		return keychainGet(ctx, entry.Service, entry.Account)
+	case Backend1Password:
+		return runTool(ctx, ss.config.OpPath, "read", "--no-newline", entry.Ref)
+	case BackendBitwarden:
+		field := entry.Field
+		if field == "" || field == "password" || field == "username" || field == "totp" || field == "notes" {
+			if field == "" {
+				field = "password"
+			}
+			return runTool(ctx, ss.config.BwPath, "get", field, entry.Ref)
+		}
+		output, err := runTool(ctx, ss.config.BwPath, "get", "item", entry.Ref)
+		if err != nil {
+			return "", err
+		}
+		return bitwardenField(output, field)
+	}
+	return "", fmt.Errorf("unsupported backend %s", entry.Backend)
+}
+
+// bitwardenField returns a custom field of a Bitwarden item.
+func bitwardenField(item, field string) (string, error) {
+	var parsed struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	err := json.Unmarshal([]byte(item), &parsed)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse bitwarden item: %w", err)
+	}
+	for _, f := range parsed.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("bitwarden item has no field %s", field)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const SecretsPromptDefault = `
+You are an assistant that can read secrets such as API tokens and passwords from the user's password managers. Your capabilities include:
+
+1. **List**: Use secret_list to see the names and descriptions of the secrets you are allowed to read. Values are never listed.
+
+2. **Get**: Use secret_get to read the value of one allowed secret by name.
+
+Only secrets on the allowlist configured by the user can be read, and every access is recorded in an audit log. Read a secret only when a task needs it, never repeat its value in your answers, and never write it to files, commits or messages unless the user explicitly asks you to.
+`
+
+// Secret backends.
+const (
+	BackendKeychain  = "keychain"
+	Backend1Password = "1password"
+	BackendBitwarden = "bitwarden"
+)
+
+// SecretEntry describes one secret that may be read and where it is stored.
+type SecretEntry struct {
+	Name        string `json:"name"`        // Name is the name the secret is requested by.
+	Description string `json:"description"` // Description tells the model what the secret is for.
+	Backend     string `json:"backend"`     // Backend is keychain, 1password or bitwarden.
+	Service     string `json:"service"`     // Service is the keychain service (macOS, libsecret) or the target name (Windows Credential Manager).
+	Account     string `json:"account"`     // Account is the keychain account, optional.
+	Ref         string `json:"ref"`         // Ref is the 1Password secret reference (op://vault/item/field) or the Bitwarden item id or name.
+	Field       string `json:"field"`       // Field is the Bitwarden field to read, default: password.
+}
+
+// SecretsConfig represents the configuration for the secrets service.
+type SecretsConfig struct {
+	PromptFile string `json:"prompt_file"` // PromptFile is the prompt file for the secrets service.
+	prompt     string
+	Secrets    []SecretEntry `json:"secrets"`    // Secrets is the allowlist, only these secrets can be read.
+	AuditFile  string        `json:"audit_file"` // AuditFile receives one JSON line per secret access.
+	OpPath     string        `json:"op_path"`    // OpPath is the path of the 1Password CLI.
+	BwPath     string        `json:"bw_path"`    // BwPath is the path of the Bitwarden CLI, BW_SESSION must be set for it.
+	Timeout    int           `json:"timeout"`    // Timeout is the timeout for reading a single secret. time.Second
+}
+
+// NewSecretsConfig creates a new SecretsConfig with default values.
+func NewSecretsConfig() *SecretsConfig {
+	return &SecretsConfig{
+		prompt:    SecretsPromptDefault,
+		Secrets:   []SecretEntry{},
+		AuditFile: filepath.Join(os.TempDir(), ".moling", "logs", "secrets_audit.log"),
+		OpPath:    "op",
+		BwPath:    "bw",
+		Timeout:   30,
+	}
+}
+
+// Check validates the secrets configuration.
+func (cfg *SecretsConfig) Check() error {
+	cfg.prompt = SecretsPromptDefault
+	if cfg.AuditFile == "" {
+		return fmt.Errorf("audit_file must not be empty")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	names := make(map[string]bool, len(cfg.Secrets))
+	for i := range cfg.Secrets {
+		entry := &cfg.Secrets[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			return fmt.Errorf("secrets[%d]: name must not be empty", i)
+		}
+		if names[entry.Name] {
+			return fmt.Errorf("secrets[%d]: duplicate name %s", i, entry.Name)
+		}
+		names[entry.Name] = true
+		entry.Backend = strings.ToLower(entry.Backend)
+		switch entry.Backend {
+		case BackendKeychain:
+			if entry.Service == "" {
+				return fmt.Errorf("secret %s: service must not be empty for the keychain backend", entry.Name)
+			}
+		case Backend1Password:
+			if !strings.HasPrefix(entry.Ref, "op://") {
+				return fmt.Errorf("secret %s: ref must be a 1Password secret reference (op://vault/item/field)", entry.Name)
+			}
+		case BackendBitwarden:
+			if entry.Ref == "" {
+				return fmt.Errorf("secret %s: ref must not be empty for the bitwarden backend", entry.Name)
+			}
+		default:
+			return fmt.Errorf("secret %s: backend must be one of %s, %s, %s", entry.Name, BackendKeychain, Backend1Password, BackendBitwarden)
+		}
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build !darwin && !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+)
+
+// keychainGet reads a secret from the Secret Service (GNOME Keyring, KWallet) through libsecret.
+// Secrets are looked up by their service and account attributes.
+func keychainGet(ctx context.Context, service, account string) (string, error) {
+	args := []string{"lookup", "service", service}
+	if account != "" {
+		args = append(args, "account", account)
+	}
+	value, err := runTool(ctx, "secret-tool", args...)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("no secret found for service %s", service)
+	}
+	return value, nil
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const psCredRead = `
+Add-Type -Namespace MoLing -Name Cred -MemberDefinition @"
+[DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+public static extern bool CredRead(string target, int type, int flags, out IntPtr credential);
+[DllImport("advapi32.dll")]
+public static extern void CredFree(IntPtr credential);
+[StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+public struct CREDENTIAL {
+	public int Flags; public int Type; public string TargetName; public string Comment;
+	public System.Runtime.InteropServices.ComTypes.FILETIME LastWritten;
+	public int CredentialBlobSize; public IntPtr CredentialBlob; public int Persist;
+	public int AttributeCount; public IntPtr Attributes; public string TargetAlias; public string UserName;
+}
+"@
+$p = [IntPtr]::Zero
+if (-not [MoLing.Cred]::CredRead('%s', 1, 0, [ref]$p)) { throw "credential not found" }
+$c = [System.Runtime.InteropServices.Marshal]::PtrToStructure($p, [type][MoLing.Cred+CREDENTIAL])
+if ('%s' -ne '' -and $c.UserName -ne '%s') { [MoLing.Cred]::CredFree($p); throw "credential user does not match" }
+$s = [System.Runtime.InteropServices.Marshal]::PtrToStringUni($c.CredentialBlob, $c.CredentialBlobSize / 2)
+[MoLing.Cred]::CredFree($p)
+[Console]::Out.Write($s)
+`
+
+// keychainGet reads a generic credential from the Windows Credential Manager, with service as
+// the target name.
+func keychainGet(ctx context.Context, service, account string) (string, error) {
+	script := fmt.Sprintf(psCredRead, utils.PSQuote(service), utils.PSQuote(account), utils.PSQuote(account))
+	return runTool(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+}
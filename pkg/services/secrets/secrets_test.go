@@ -0,0 +1,151 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestSecretsConfigCheck(t *testing.T) {
+	cases := []SecretEntry{
+		{Name: "", Backend: BackendKeychain, Service: "x"},
+		{Name: "a", Backend: BackendKeychain},
+		{Name: "a", Backend: Backend1Password, Ref: "vault/item"},
+		{Name: "a", Backend: BackendBitwarden},
+		{Name: "a", Backend: "vault"},
+	}
+	for _, entry := range cases {
+		cfg := NewSecretsConfig()
+		cfg.Secrets = []SecretEntry{entry}
+		if err := cfg.Check(); err == nil {
+			t.Errorf("Expected an error for %+v", entry)
+		}
+	}
+	cfg := NewSecretsConfig()
+	cfg.Secrets = []SecretEntry{{Name: "a", Backend: "Keychain", Service: "x"}, {Name: "a", Backend: BackendKeychain, Service: "y"}}
+	if err := cfg.Check(); err == nil {
+		t.Errorf("Expected an error for duplicate names")
+	}
+}
+
+func TestSecretsServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake password manager is a shell script")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewSecretsServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create SecretsServer: %s", err.Error())
+	}
+	dir := t.TempDir()
+	op := filepath.Join(dir, "op")
+	err = os.WriteFile(op, []byte("#!/bin/sh\nif [ \"$3\" = \"op://dev/api/token\" ]; then printf 'tok-123'; else echo \"item not found\" >&2; exit 1; fi\n"), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to write fake op: %s", err.Error())
+	}
+	audit := filepath.Join(dir, "audit.log")
+	err = srv.LoadConfig(map[string]any{
+		"audit_file": audit,
+		"op_path":    op,
+		"secrets": []any{
+			map[string]any{"name": "api_token", "backend": "1password", "ref": "op://dev/api/token", "description": "API token"},
+			map[string]any{"name": "missing", "backend": "1password", "ref": "op://dev/missing/token"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init SecretsServer: %s", err.Error())
+	}
+	ss := srv.(*SecretsServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	text, isErr := call(ss.handleList, map[string]any{})
+	if isErr || !strings.Contains(text, "api_token (1password): API token") || strings.Contains(text, "tok-123") {
+		t.Errorf("Unexpected list result: %s", text)
+	}
+	text, isErr = call(ss.handleGet, map[string]any{"name": "api_token"})
+	if isErr || text != "tok-123" {
+		t.Errorf("Unexpected secret: %s", text)
+	}
+	text, isErr = call(ss.handleGet, map[string]any{"name": "db_password"})
+	if !isErr || !strings.Contains(text, "allowlist") {
+		t.Errorf("Expected an allowlist error, got: %s", text)
+	}
+	text, isErr = call(ss.handleGet, map[string]any{"name": "missing"})
+	if !isErr || !strings.Contains(text, "item not found") {
+		t.Errorf("Expected a backend error, got: %s", text)
+	}
+	value, err := Lookup(context.Background(), "GraphQL", "api_token")
+	if err != nil || value != "tok-123" {
+		t.Errorf("Unexpected lookup result: %q, %v", value, err)
+	}
+	_, err = Lookup(context.Background(), "GraphQL", "db_password")
+	if !errors.Is(err, ErrSecretNotAllowed) {
+		t.Errorf("Expected ErrSecretNotAllowed, got: %v", err)
+	}
+
+	data, err := os.ReadFile(audit)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %s", err.Error())
+	}
+	if strings.Contains(string(data), "tok-123") {
+		t.Errorf("Audit log contains the secret value")
+	}
+	var results []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid audit line %q: %s", line, err.Error())
+		}
+		results = append(results, entry.Name+"/"+entry.Caller+"/"+entry.Result)
+	}
+	expected := "api_token/client/ok db_password/client/denied missing/client/error api_token/service:GraphQL/ok db_password/service:GraphQL/denied"
+	if strings.Join(results, " ") != expected {
+		t.Errorf("Unexpected audit log: %v", results)
+	}
+
+	_ = srv.Close()
+	if _, err = Lookup(context.Background(), "GraphQL", "api_token"); !errors.Is(err, ErrSecretsUnavailable) {
+		t.Errorf("Expected ErrSecretsUnavailable after close, got: %v", err)
+	}
+}
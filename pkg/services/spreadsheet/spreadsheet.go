@@ -0,0 +1,640 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package spreadsheet reads and writes Excel workbooks for the MoLing application.
+package spreadsheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	SpreadsheetServerName comm.MoLingServerType = "Spreadsheet"
+)
+
+var workbookExtensions = map[string]bool{".xlsx": true, ".xlsm": true, ".xltx": true, ".xltm": true}
+
+// sheetInfo describes a sheet of a workbook.
+type sheetInfo struct {
+	Name      string `json:"name"`
+	Dimension string `json:"dimension,omitempty"`
+	Visible   bool   `json:"visible"`
+}
+
+// cellRange is a rectangular range, a zero bound is open.
+type cellRange struct {
+	col1, row1, col2, row2 int
+}
+
+// SpreadsheetServer implements the Service interface and reads and writes Excel workbooks.
+type SpreadsheetServer struct {
+	abstract.MLService
+	config *SpreadsheetConfig
+}
+
+// NewSpreadsheetServer creates a new SpreadsheetServer instance.
+func NewSpreadsheetServer(ctx context.Context) (abstract.Service, error) {
+	sc := NewSpreadsheetConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("SpreadsheetServer: invalid config type")
+	}
+	sc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("SpreadsheetServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(SpreadsheetServerName))
+	})
+
+	ss := &SpreadsheetServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    sc,
+	}
+	err := ss.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Init registers the prompt and tools of the spreadsheet service.
+func (ss *SpreadsheetServer) Init() error {
+	err := utils.CreateDirectory(ss.config.AllowedDirs[0])
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", ss.config.AllowedDirs[0], err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "spreadsheet_prompt",
+			Description: "Get the relevant functions and prompts of the Spreadsheet MCP Server",
+		},
+		HandlerFunc: ss.handlePrompt,
+	}
+	ss.AddPrompt(pe)
+
+	ss.AddTool(mcp.NewTool(
+		"spreadsheet_list_sheets",
+		mcp.WithDescription("List the sheets of a workbook with the range of cells each sheet uses"),
+		mcp.WithString("path",
+			mcp.Description("Path of the workbook"),
+			mcp.Required(),
+		),
+	), ss.handleListSheets)
+
+	ss.AddTool(mcp.NewTool(
+		"spreadsheet_read",
+		mcp.WithDescription("Read a range of a sheet as JSON, either as rows of values or, with header=true, as objects keyed by the first row"),
+		mcp.WithString("path",
+			mcp.Description("Path of the workbook"),
+			mcp.Required(),
+		),
+		mcp.WithString("sheet",
+			mcp.Description("Sheet name, default: the first sheet"),
+		),
+		mcp.WithString("range",
+			mcp.Description("Range to read, e.g. A1:F50, B:D or C3, default: the whole sheet"),
+		),
+		mcp.WithBoolean("header",
+			mcp.Description("Use the first row of the range as keys, default: false"),
+		),
+		mcp.WithBoolean("raw",
+			mcp.Description("Return raw values instead of values formatted with the number format of the cell, default: false"),
+		),
+	), ss.handleRead)
+
+	ss.AddTool(mcp.NewTool(
+		"spreadsheet_write",
+		mcp.WithDescription("Write a block of values to a sheet starting at a cell, creating the workbook and sheet when missing. Strings starting with = are written as formulas"),
+		mcp.WithString("path",
+			mcp.Description("Path of the workbook"),
+			mcp.Required(),
+		),
+		mcp.WithString("sheet",
+			mcp.Description("Sheet name, default: the first sheet"),
+		),
+		mcp.WithString("cell",
+			mcp.Description("Top left cell of the block, default: A1"),
+		),
+		mcp.WithArray("values",
+			mcp.Description("Rows of cell values, e.g. [[\"Name\", \"Total\"], [\"A\", 1.5], [\"Sum\", \"=SUM(B2:B2)\"]], null leaves a cell empty"),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "array"}),
+		),
+	), ss.handleWrite)
+
+	ss.AddTool(mcp.NewTool(
+		"spreadsheet_export_csv",
+		mcp.WithDescription("Export a sheet to CSV, either to a file or returned inline"),
+		mcp.WithString("path",
+			mcp.Description("Path of the workbook"),
+			mcp.Required(),
+		),
+		mcp.WithString("sheet",
+			mcp.Description("Sheet name, default: the first sheet"),
+		),
+		mcp.WithString("output",
+			mcp.Description("Path of the CSV file to write, omit to return the CSV inline"),
+		),
+	), ss.handleExportCSV)
+	return nil
+}
+
+func (ss *SpreadsheetServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ss.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ss.config.AllowedDirs[0])
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// open opens an existing workbook.
+func (ss *SpreadsheetServer) open(path string, opts ...excelize.Options) (*excelize.File, string, error) {
+	abs, err := utils.ResolvePath(path, ss.config.AllowedDirs)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open workbook: %w", err)
+	}
+	if info.Size() > ss.config.MaxFileSize {
+		return nil, "", fmt.Errorf("workbook is %d bytes, the limit is %d", info.Size(), ss.config.MaxFileSize)
+	}
+	f, err := excelize.OpenFile(abs, opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open workbook: %w", err)
+	}
+	return f, abs, nil
+}
+
+// sheetName returns the requested sheet, or the first sheet when none is given.
+func sheetName(f *excelize.File, args map[string]any) (string, error) {
+	sheet := abstract.GetString(args, "sheet", "")
+	if sheet == "" {
+		return f.GetSheetName(0), nil
+	}
+	index, err := f.GetSheetIndex(sheet)
+	if err != nil || index < 0 {
+		return "", fmt.Errorf("sheet %q not found, available sheets: %s", sheet, strings.Join(f.GetSheetList(), ", "))
+	}
+	return sheet, nil
+}
+
+// parseRange parses A1:C10, B:D, C3 or an empty string.
+func parseRange(s string) (cellRange, error) {
+	var r cellRange
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "$", ""))
+	if s == "" {
+		return r, nil
+	}
+	from, to, found := strings.Cut(s, ":")
+	if !found {
+		to = from
+	}
+	var err error
+	r.col1, r.row1, err = parseBound(from)
+	if err == nil {
+		r.col2, r.row2, err = parseBound(to)
+	}
+	if err != nil {
+		return r, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+	if (r.col2 > 0 && r.col1 > r.col2) || (r.row2 > 0 && r.row1 > r.row2) {
+		return r, fmt.Errorf("invalid range %q: start is after end", s)
+	}
+	return r, nil
+}
+
+// parseBound parses a cell name or a column name.
+func parseBound(s string) (int, int, error) {
+	if strings.IndexFunc(s, func(r rune) bool { return r >= '0' && r <= '9' }) < 0 {
+		col, err := excelize.ColumnNameToNumber(s)
+		return col, 0, err
+	}
+	return excelize.CellNameToCoordinates(s)
+}
+
+func (r cellRange) containsRow(row int) bool {
+	return row >= r.row1 && (r.row2 == 0 || row <= r.row2)
+}
+
+// columns slices a row to the columns of the range.
+func (r cellRange) columns(cells []string) []string {
+	start := max(r.col1, 1) - 1
+	end := len(cells)
+	if r.col2 > 0 {
+		end = min(end, r.col2)
+	}
+	if start >= end {
+		return []string{}
+	}
+	return cells[start:end]
+}
+
+func (ss *SpreadsheetServer) handleListSheets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	f, _, err := ss.open(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer f.Close()
+	var sheets []sheetInfo
+	for _, name := range f.GetSheetList() {
+		dim, err := usedRange(f, name)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read sheet %s: %s", name, err.Error())), nil
+		}
+		visible, _ := f.GetSheetVisible(name)
+		sheets = append(sheets, sheetInfo{Name: name, Dimension: dim, Visible: visible})
+	}
+	data, err := json.MarshalIndent(sheets, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode sheets: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// usedRange returns the range of cells a sheet uses. The dimension stored in the workbook is
+// only a hint and is not updated by every writer, so the rows are scanned.
+func usedRange(f *excelize.File, sheet string) (string, error) {
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	lastRow, lastCol := 0, 0
+	for row := 1; rows.Next(); row++ {
+		cols, err := rows.Columns()
+		if err != nil {
+			return "", err
+		}
+		if len(cols) > 0 {
+			lastRow = row
+			lastCol = max(lastCol, len(cols))
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return "", err
+	}
+	if lastRow == 0 {
+		return "", nil
+	}
+	end, err := excelize.CoordinatesToCellName(lastCol, lastRow)
+	if err != nil {
+		return "", err
+	}
+	return "A1:" + end, nil
+}
+
+// readRows reads the rows of a range, stopping after maxCells cells.
+func readRows(f *excelize.File, sheet string, r cellRange, maxCells int, opts ...excelize.Options) ([][]string, bool, error) {
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	var result [][]string
+	cells := 0
+	for row := 1; rows.Next(); row++ {
+		if !r.containsRow(row) {
+			if r.row2 > 0 && row > r.row2 {
+				break
+			}
+			continue
+		}
+		cols, err := rows.Columns(opts...)
+		if err != nil {
+			return nil, false, err
+		}
+		cols = r.columns(cols)
+		if cells+len(cols) > maxCells {
+			return result, true, nil
+		}
+		cells += len(cols)
+		result = append(result, cols)
+	}
+	// drop trailing empty rows
+	for len(result) > 0 && len(result[len(result)-1]) == 0 {
+		result = result[:len(result)-1]
+	}
+	return result, false, rows.Error()
+}
+
+func (ss *SpreadsheetServer) handleRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	raw := abstract.GetBool(args, "raw", false)
+	f, _, err := ss.open(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer f.Close()
+	sheet, err := sheetName(f, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	rangeArg := abstract.GetString(args, "range", "")
+	r, err := parseRange(rangeArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	rows, truncated, err := readRows(f, sheet, r, ss.config.MaxCells, excelize.Options{RawCellValue: raw})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read sheet %s: %s", sheet, err.Error())), nil
+	}
+
+	result := map[string]any{"sheet": sheet}
+	if truncated {
+		result["truncated"] = fmt.Sprintf("only the first %d cells were read, read a smaller range", ss.config.MaxCells)
+	}
+	if header := abstract.GetBool(args, "header", false); header && len(rows) > 0 {
+		keys := headerKeys(rows[0], max(r.col1, 1))
+		records := make([]map[string]string, 0, len(rows)-1)
+		for _, row := range rows[1:] {
+			record := make(map[string]string, len(keys))
+			for i, key := range keys {
+				if i < len(row) {
+					record[key] = row[i]
+				} else {
+					record[key] = ""
+				}
+			}
+			records = append(records, record)
+		}
+		result["columns"] = keys
+		result["records"] = records
+	} else {
+		result["rows"] = rows
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode rows: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// headerKeys turns a header row into unique keys, naming empty headers after their column.
+func headerKeys(header []string, firstCol int) []string {
+	keys := make([]string, len(header))
+	seen := make(map[string]int)
+	for i, h := range header {
+		key := strings.TrimSpace(h)
+		if key == "" {
+			key, _ = excelize.ColumnNumberToName(firstCol + i)
+		}
+		seen[key]++
+		if seen[key] > 1 {
+			key = fmt.Sprintf("%s_%d", key, seen[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func (ss *SpreadsheetServer) handleWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if ss.config.ReadOnly {
+		return mcp.NewToolResultError("the spreadsheet service is read only"), nil
+	}
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	abs, err := utils.ResolvePath(path, ss.config.AllowedDirs)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !workbookExtensions[strings.ToLower(filepath.Ext(abs))] {
+		return mcp.NewToolResultError("path must end with .xlsx, .xlsm, .xltx or .xltm"), nil
+	}
+	rows, ok := args["values"].([]any)
+	if !ok || len(rows) == 0 {
+		return mcp.NewToolResultError("values must be a non-empty array of rows"), nil
+	}
+	cell := abstract.GetString(args, "cell", "")
+	if cell == "" {
+		cell = "A1"
+	}
+	col, row, err := excelize.CellNameToCoordinates(strings.ToUpper(cell))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid cell %q: %s", cell, err.Error())), nil
+	}
+
+	var f *excelize.File
+	created := false
+	if _, err := os.Stat(abs); errors.Is(err, os.ErrNotExist) {
+		f = excelize.NewFile()
+		created = true
+	} else {
+		f, _, err = ss.open(path)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	defer f.Close()
+	sheet := abstract.GetString(args, "sheet", "")
+	switch {
+	case sheet == "":
+		sheet = f.GetSheetName(0)
+	case created:
+		err = f.SetSheetName(f.GetSheetName(0), sheet)
+	default:
+		if index, _ := f.GetSheetIndex(sheet); index < 0 {
+			_, err = f.NewSheet(sheet)
+		}
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create sheet %s: %s", sheet, err.Error())), nil
+	}
+
+	cells := 0
+	for i, rawRow := range rows {
+		values, ok := rawRow.([]any)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("row %d must be an array", i)), nil
+		}
+		cells += len(values)
+		if cells > ss.config.MaxCells {
+			return mcp.NewToolResultError(fmt.Sprintf("too many cells, the limit is %d", ss.config.MaxCells)), nil
+		}
+		for j, value := range values {
+			name, err := excelize.CoordinatesToCellName(col+j, row+i)
+			if err == nil {
+				err = setCell(f, sheet, name, value)
+			}
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to write row %d column %d: %s", i, j, err.Error())), nil
+			}
+		}
+	}
+	err = os.MkdirAll(filepath.Dir(abs), 0o755)
+	if err == nil {
+		err = f.SaveAs(abs)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save workbook: %s", err.Error())), nil
+	}
+	ss.Logger.Info().Str("path", abs).Str("sheet", sheet).Int("cells", cells).Msg("workbook written")
+	return mcp.NewToolResultText(fmt.Sprintf("Wrote %d cells to %s!%s of %s", cells, sheet, cell, abs)), nil
+}
+
+// setCell writes a JSON value to a cell.
+func setCell(f *excelize.File, sheet, cell string, value any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if len(v) > 1 && strings.HasPrefix(v, "=") {
+			return f.SetCellFormula(sheet, cell, v[1:])
+		}
+		return f.SetCellValue(sheet, cell, v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return f.SetCellValue(sheet, cell, int64(v))
+		}
+		return f.SetCellValue(sheet, cell, v)
+	case bool:
+		return f.SetCellValue(sheet, cell, v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return f.SetCellValue(sheet, cell, string(data))
+	}
+}
+
+func (ss *SpreadsheetServer) handleExportCSV(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := abstract.GetString(args, "path", "")
+	output := abstract.GetString(args, "output", "")
+	var outPath string
+	if output != "" {
+		if ss.config.ReadOnly {
+			return mcp.NewToolResultError("the spreadsheet service is read only, omit output to return the CSV inline"), nil
+		}
+		var err error
+		outPath, err = utils.ResolvePath(output, ss.config.AllowedDirs)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	f, _, err := ss.open(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer f.Close()
+	sheet, err := sheetName(f, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	rows, truncated, err := readRows(f, sheet, cellRange{}, ss.config.MaxCells*10)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read sheet %s: %s", sheet, err.Error())), nil
+	}
+	if truncated {
+		return mcp.NewToolResultError(fmt.Sprintf("sheet %s has more than %d cells", sheet, ss.config.MaxCells*10)), nil
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range rows {
+		// pad rows so that every line has the same number of fields
+		padded := make([]string, width)
+		copy(padded, row)
+		err = w.Write(padded)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	w.Flush()
+
+	if outPath == "" {
+		if len(rows) > 0 && buf.Len() > ss.config.MaxCells*10 {
+			return mcp.NewToolResultError(fmt.Sprintf("the CSV is %d bytes, pass output to write it to a file", buf.Len())), nil
+		}
+		return mcp.NewToolResultText(buf.String()), nil
+	}
+	err = os.MkdirAll(filepath.Dir(outPath), 0o755)
+	if err == nil {
+		err = os.WriteFile(outPath, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to write CSV: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Exported %d rows of %s to %s", len(rows), sheet, outPath)), nil
+}
+
+func (ss *SpreadsheetServer) Config() string {
+	cfg, err := json.Marshal(ss.config)
+	if err != nil {
+		ss.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ss *SpreadsheetServer) Name() comm.MoLingServerType {
+	return SpreadsheetServerName
+}
+
+func (ss *SpreadsheetServer) Close() error {
+	ss.Logger.Debug().Msg("SpreadsheetServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ss *SpreadsheetServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ss.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ss.config.Check()
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package spreadsheet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const SpreadsheetPromptDefault = `
+You are an assistant that can read and write Excel workbooks (.xlsx, .xlsm). Your capabilities include:
+
+1. **List sheets**: Use spreadsheet_list_sheets to see the sheets of a workbook and the range each of them uses.
+
+2. **Read**: Use spreadsheet_read to read a range such as A1:F50 as JSON. With header=true, the first row is used as keys and every other row becomes an object.
+
+3. **Write**: Use spreadsheet_write to write a block of values starting at a cell. Strings starting with "=" are written as formulas. Missing workbooks and sheets are created.
+
+4. **Export**: Use spreadsheet_export_csv to convert a sheet to CSV.
+
+Relative paths are resolved in %s. Read the header row and a few lines before reading large ranges, and do not overwrite existing data without the user's consent.
+`
+
+// SpreadsheetConfig represents the configuration for the spreadsheet service.
+type SpreadsheetConfig struct {
+	PromptFile  string `json:"prompt_file"` // PromptFile is the prompt file for the spreadsheet service.
+	prompt      string
+	AllowedDirs []string `json:"allowed_dirs"`  // AllowedDirs are the directories workbooks may be read from and written to, relative paths use the first one.
+	MaxCells    int      `json:"max_cells"`     // MaxCells is the maximum number of cells read or written by one call.
+	MaxFileSize int64    `json:"max_file_size"` // MaxFileSize is the maximum size of a workbook that is opened, in bytes.
+	ReadOnly    bool     `json:"read_only"`     // ReadOnly disables writing workbooks and exporting CSV files.
+}
+
+// NewSpreadsheetConfig creates a new SpreadsheetConfig with default values.
+func NewSpreadsheetConfig() *SpreadsheetConfig {
+	return &SpreadsheetConfig{
+		prompt:      SpreadsheetPromptDefault,
+		AllowedDirs: []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		MaxCells:    20000,
+		MaxFileSize: 1024 * 1024 * 50,
+	}
+}
+
+// Check validates the spreadsheet configuration.
+func (cfg *SpreadsheetConfig) Check() error {
+	cfg.prompt = SpreadsheetPromptDefault
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	if cfg.MaxCells <= 0 || cfg.MaxFileSize <= 0 {
+		return fmt.Errorf("max_cells and max_file_size must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
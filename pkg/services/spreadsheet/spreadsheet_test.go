@@ -0,0 +1,147 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package spreadsheet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := map[string]cellRange{
+		"":       {},
+		"A1:C10": {1, 1, 3, 10},
+		"$b$2":   {2, 2, 2, 2},
+		"B:D":    {2, 0, 4, 0},
+	}
+	for s, expected := range cases {
+		r, err := parseRange(s)
+		if err != nil {
+			t.Fatalf("Failed to parse range %q: %s", s, err.Error())
+		}
+		if r != expected {
+			t.Errorf("Unexpected range for %q: %+v, expected %+v", s, r, expected)
+		}
+	}
+	for _, s := range []string{"C1:A1", "A0", "1A:B2"} {
+		if _, err := parseRange(s); err == nil {
+			t.Errorf("Expected an error for range %q", s)
+		}
+	}
+}
+
+func TestSpreadsheetServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewSpreadsheetServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create SpreadsheetServer: %s", err.Error())
+	}
+	dir := t.TempDir()
+	err = srv.LoadConfig(map[string]any{"allowed_dirs": []any{dir}})
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init SpreadsheetServer: %s", err.Error())
+	}
+	ss := srv.(*SpreadsheetServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		return res.Content[0].(mcp.TextContent).Text, res.IsError
+	}
+
+	out, isErr := call(ss.handleWrite, map[string]any{
+		"path":  "report.xlsx",
+		"sheet": "Sales",
+		"values": []any{
+			[]any{"Name", "Total", nil},
+			[]any{"A, Inc.", float64(1.5), true},
+			[]any{"B", float64(2), nil},
+			[]any{"Sum", "=SUM(B2:B3)"},
+		},
+	})
+	if isErr {
+		t.Fatalf("Failed to write workbook: %s", out)
+	}
+	out, isErr = call(ss.handleWrite, map[string]any{"path": "report.xlsx", "sheet": "Notes", "cell": "B2", "values": []any{[]any{"note"}}})
+	if isErr {
+		t.Fatalf("Failed to add sheet: %s", out)
+	}
+
+	out, _ = call(ss.handleListSheets, map[string]any{"path": "report.xlsx"})
+	if !strings.Contains(out, `"name": "Sales"`) || !strings.Contains(out, `"dimension": "A1:C4"`) || !strings.Contains(out, `"name": "Notes"`) {
+		t.Errorf("Unexpected sheets: %s", out)
+	}
+
+	out, _ = call(ss.handleRead, map[string]any{"path": "report.xlsx", "range": "A1:B3", "header": true})
+	if !strings.Contains(out, `"Name": "A, Inc."`) || !strings.Contains(out, `"Total": "2"`) || strings.Contains(out, "Sum") {
+		t.Errorf("Unexpected records: %s", out)
+	}
+	out, _ = call(ss.handleRead, map[string]any{"path": "report.xlsx", "sheet": "Notes"})
+	if !strings.Contains(out, `"note"`) {
+		t.Errorf("Unexpected rows: %s", out)
+	}
+
+	out, _ = call(ss.handleExportCSV, map[string]any{"path": "report.xlsx", "sheet": "Sales", "output": "report.csv"})
+	if !strings.Contains(out, "Exported 4 rows") {
+		t.Errorf("Unexpected export result: %s", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %s", err.Error())
+	}
+	if !strings.HasPrefix(string(data), "Name,Total,\n\"A, Inc.\",1.5,TRUE\nB,2,\n") {
+		t.Errorf("Unexpected CSV: %q", data)
+	}
+
+	if out, isErr = call(ss.handleRead, map[string]any{"path": "../outside.xlsx"}); !isErr {
+		t.Errorf("Expected paths outside the allowed directories to be rejected, got: %s", out)
+	}
+	// A symlink inside the allowed directory must not lead to a file outside of it
+	outside := filepath.Join(t.TempDir(), "outside.xlsx")
+	if err = os.Rename(filepath.Join(dir, "report.xlsx"), outside); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink(outside, filepath.Join(dir, "link.xlsx")); err == nil {
+		if out, isErr = call(ss.handleRead, map[string]any{"path": "link.xlsx"}); !isErr || !strings.Contains(out, "access denied") {
+			t.Errorf("Expected a symlink to a file outside the allowed directories to be rejected, got: %s", out)
+		}
+	}
+	if err = os.Rename(outside, filepath.Join(dir, "report.xlsx")); err != nil {
+		t.Fatal(err)
+	}
+	if out, isErr = call(ss.handleRead, map[string]any{"path": "report.xlsx", "sheet": "Missing"}); !isErr || !strings.Contains(out, "Sales") {
+		t.Errorf("Expected an error listing the sheets, got: %s", out)
+	}
+}
@@ -0,0 +1,529 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package storage provides access to S3-compatible object storage for the MoLing application.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	StorageServerName comm.MoLingServerType = "Storage"
+)
+
+// bucket is a configured bucket with its client.
+type bucket struct {
+	BucketConfig
+	client *minio.Client
+}
+
+// StorageServer implements the Service interface and moves objects between the local data directory and S3-compatible storage.
+type StorageServer struct {
+	abstract.MLService
+	config  *StorageConfig
+	buckets map[string]*bucket
+}
+
+// NewStorageServer creates a new StorageServer instance.
+func NewStorageServer(ctx context.Context) (abstract.Service, error) {
+	sc := NewStorageConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("StorageServer: invalid config type")
+	}
+	sc.DataPath = filepath.Join(globalConf.BasePath, "data")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("StorageServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(StorageServerName))
+	})
+
+	ss := &StorageServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    sc,
+		buckets:   make(map[string]*bucket),
+	}
+	err := ss.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Init creates the bucket clients and registers the prompt and tools.
+func (ss *StorageServer) Init() error {
+	for _, bc := range ss.config.Buckets {
+		creds := credentials.NewStaticV4(bc.AccessKey, bc.SecretKey, "")
+		if bc.AccessKey == "" {
+			creds = credentials.NewChainCredentials([]credentials.Provider{&credentials.EnvAWS{}, &credentials.EnvMinio{}})
+		}
+		client, err := minio.New(bc.Endpoint, &minio.Options{Creds: creds, Secure: bc.UseSSL, Region: bc.Region})
+		if err != nil {
+			return fmt.Errorf("failed to create client for bucket %s: %w", bc.Name, err)
+		}
+		ss.buckets[bc.Name] = &bucket{BucketConfig: bc, client: client}
+	}
+	err := utils.CreateDirectory(ss.config.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory %s: %w", ss.config.DataPath, err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "storage_prompt",
+			Description: "Get the relevant functions and prompts of the Storage MCP Server",
+		},
+		HandlerFunc: ss.handlePrompt,
+	}
+	ss.AddPrompt(pe)
+
+	ss.AddTool(mcp.NewTool(
+		"storage_list",
+		mcp.WithDescription("List the configured buckets, or the objects of a bucket under a prefix"),
+		mcp.WithString("bucket",
+			mcp.Description("Configured bucket name, omit to list the buckets"),
+		),
+		mcp.WithString("prefix",
+			mcp.Description("Only list objects whose key starts with this prefix"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("List all objects below the prefix instead of one level, default: false"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of objects, default: 100"),
+		),
+	), ss.handleList)
+
+	ss.AddTool(mcp.NewTool(
+		"storage_get",
+		mcp.WithDescription("Download an object into the local data directory, or return a small text object inline when local_path is omitted"),
+		mcp.WithString("bucket",
+			mcp.Description("Configured bucket name"),
+			mcp.Required(),
+		),
+		mcp.WithString("key",
+			mcp.Description("Object key"),
+			mcp.Required(),
+		),
+		mcp.WithString("local_path",
+			mcp.Description("Destination path, relative to the data directory"),
+		),
+	), ss.handleGet)
+
+	ss.AddTool(mcp.NewTool(
+		"storage_put",
+		mcp.WithDescription("Upload a local file from the data directory, or inline text content, as an object"),
+		mcp.WithString("bucket",
+			mcp.Description("Configured bucket name"),
+			mcp.Required(),
+		),
+		mcp.WithString("key",
+			mcp.Description("Object key"),
+			mcp.Required(),
+		),
+		mcp.WithString("local_path",
+			mcp.Description("Source path, relative to the data directory"),
+		),
+		mcp.WithString("content",
+			mcp.Description("Text content to upload when local_path is omitted"),
+		),
+		mcp.WithString("content_type",
+			mcp.Description("Content type of the object, detected from the file by default"),
+		),
+	), ss.handlePut)
+
+	ss.AddTool(mcp.NewTool(
+		"storage_delete",
+		mcp.WithDescription("Delete an object"),
+		mcp.WithString("bucket",
+			mcp.Description("Configured bucket name"),
+			mcp.Required(),
+		),
+		mcp.WithString("key",
+			mcp.Description("Object key"),
+			mcp.Required(),
+		),
+		abstract.WithDryRunArgument(),
+	), ss.handleDelete)
+
+	ss.AddTool(mcp.NewTool(
+		"storage_presign",
+		mcp.WithDescription("Create a temporary URL to download (GET) or upload (PUT) an object"),
+		mcp.WithString("bucket",
+			mcp.Description("Configured bucket name"),
+			mcp.Required(),
+		),
+		mcp.WithString("key",
+			mcp.Description("Object key"),
+			mcp.Required(),
+		),
+		mcp.WithString("method",
+			mcp.Description("GET or PUT, default: GET"),
+			mcp.Enum("GET", "PUT"),
+		),
+		mcp.WithNumber("expiry",
+			mcp.Description(fmt.Sprintf("Lifetime of the URL in seconds, default: %d", ss.config.PresignExpiry)),
+		),
+	), ss.handlePresign)
+	return nil
+}
+
+func (ss *StorageServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ss.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ss.config.DataPath)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// bucketAndKey resolves the bucket and object key arguments and checks the prefix allowlist.
+func (ss *StorageServer) bucketAndKey(args map[string]any) (*bucket, string, error) {
+	name := abstract.GetString(args, "bucket", "")
+	b, ok := ss.buckets[name]
+	if !ok {
+		return nil, "", fmt.Errorf("bucket %q is not configured", name)
+	}
+	key := abstract.GetString(args, "key", "")
+	if key == "" {
+		return nil, "", fmt.Errorf("key must be a non-empty string")
+	}
+	if !keyAllowed(key, b.Prefixes) {
+		return nil, "", fmt.Errorf("access denied - key %s is outside the allowed prefixes %v", key, b.Prefixes)
+	}
+	return b, key, nil
+}
+
+// keyAllowed reports whether key is within one of the allowed prefixes. Keys with ".." segments are rejected.
+func keyAllowed(key string, prefixes []string) bool {
+	for _, seg := range strings.Split(key, "/") {
+		if seg == ".." {
+			return false
+		}
+	}
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// localPath resolves a path relative to the data directory and makes sure it stays inside it.
+func (ss *StorageServer) localPath(path string) (string, error) {
+	return utils.ResolvePath(path, []string{ss.config.DataPath})
+}
+
+func (ss *StorageServer) timeoutCtx(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, time.Duration(ss.config.Timeout)*time.Second)
+}
+
+// handleList handles listing buckets or objects.
+func (ss *StorageServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "bucket", "")
+	if name == "" {
+		if len(ss.config.Buckets) == 0 {
+			return mcp.NewToolResultText("No buckets configured, add them to the Storage section of the config file"), nil
+		}
+		var sb strings.Builder
+		for _, b := range ss.config.Buckets {
+			sb.WriteString(fmt.Sprintf("- %s: %s/%s", b.Name, b.Endpoint, b.Bucket))
+			if len(b.Prefixes) > 0 {
+				sb.WriteString(fmt.Sprintf(", prefixes: %s", strings.Join(b.Prefixes, ",")))
+			}
+			if b.ReadOnly {
+				sb.WriteString(", read-only")
+			}
+			sb.WriteString("\n")
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	b, ok := ss.buckets[name]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("bucket %q is not configured", name)), nil
+	}
+	prefix := abstract.GetString(args, "prefix", "")
+	recursive := abstract.GetBool(args, "recursive", false)
+	limit := abstract.GetInt(args, "limit", 0)
+	if limit <= 0 {
+		limit = 100
+	}
+	prefixes := []string{prefix}
+	if prefix == "" && len(b.Prefixes) > 0 {
+		prefixes = b.Prefixes
+	} else if !keyAllowed(prefix, b.Prefixes) {
+		return mcp.NewToolResultError(fmt.Sprintf("access denied - prefix %s is outside the allowed prefixes %v", prefix, b.Prefixes)), nil
+	}
+
+	tctx, cancel := ss.timeoutCtx(ctx)
+	defer cancel()
+	var sb strings.Builder
+	count := 0
+	for _, p := range prefixes {
+		for obj := range b.client.ListObjects(tctx, b.Bucket, minio.ListObjectsOptions{Prefix: p, Recursive: recursive}) {
+			if obj.Err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to list objects: %s", obj.Err.Error())), nil
+			}
+			if count >= limit {
+				sb.WriteString(fmt.Sprintf("... truncated at %d objects\n", count))
+				return mcp.NewToolResultText(sb.String()), nil
+			}
+			if strings.HasSuffix(obj.Key, "/") {
+				sb.WriteString(fmt.Sprintf("[DIR]  %s\n", obj.Key))
+			} else {
+				sb.WriteString(fmt.Sprintf("[FILE] %s (%d bytes, %s)\n", obj.Key, obj.Size, obj.LastModified.Format(time.RFC3339)))
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No objects found in %s under prefix %q", b.Name, prefix)), nil
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleGet handles downloading an object.
+func (ss *StorageServer) handleGet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, key, err := ss.bucketAndKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	tctx, cancel := ss.timeoutCtx(ctx)
+	defer cancel()
+	info, err := b.client.StatObject(tctx, b.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to stat object %s: %s", key, err.Error())), nil
+	}
+	if info.Size > ss.config.MaxObjectSize {
+		return mcp.NewToolResultError(fmt.Sprintf("object is too large (%d bytes), limit is %d bytes", info.Size, ss.config.MaxObjectSize)), nil
+	}
+
+	local := abstract.GetString(args, "local_path", "")
+	if local == "" {
+		if info.Size > ss.config.MaxInlineSize || !utils.IsTextFile(strings.TrimSpace(strings.Split(info.ContentType, ";")[0])) {
+			return mcp.NewToolResultError(fmt.Sprintf("object %s (%s, %d bytes) cannot be returned inline, provide local_path to download it", key, info.ContentType, info.Size)), nil
+		}
+		obj, err := b.client.GetObject(tctx, b.Bucket, key, minio.GetObjectOptions{})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get object %s: %s", key, err.Error())), nil
+		}
+		defer obj.Close()
+		data, err := io.ReadAll(io.LimitReader(obj, ss.config.MaxInlineSize))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read object %s: %s", key, err.Error())), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+
+	dest, err := ss.localPath(local)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	err = b.client.FGetObject(tctx, b.Bucket, key, dest, minio.GetObjectOptions{})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to download object %s: %s", key, err.Error())), nil
+	}
+	ss.Logger.Info().Str("bucket", b.Name).Str("key", key).Str("path", dest).Msg("object downloaded")
+	return mcp.NewToolResultText(fmt.Sprintf("Downloaded %s/%s (%d bytes) to %s", b.Name, key, info.Size, dest)), nil
+}
+
+// handlePut handles uploading an object.
+func (ss *StorageServer) handlePut(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, key, err := ss.bucketAndKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if b.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("bucket %s is read-only", b.Name)), nil
+	}
+	contentType := abstract.GetString(args, "content_type", "")
+	tctx, cancel := ss.timeoutCtx(ctx)
+	defer cancel()
+
+	local := abstract.GetString(args, "local_path", "")
+	var uploaded minio.UploadInfo
+	if local != "" {
+		src, err := ss.localPath(local)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fi, err := os.Stat(src)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to stat %s: %s", src, err.Error())), nil
+		}
+		if fi.IsDir() {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is a directory", src)), nil
+		}
+		if fi.Size() > ss.config.MaxObjectSize {
+			return mcp.NewToolResultError(fmt.Sprintf("file is too large (%d bytes), limit is %d bytes", fi.Size(), ss.config.MaxObjectSize)), nil
+		}
+		if contentType == "" {
+			contentType = utils.DetectMimeType(src)
+		}
+		uploaded, err = b.client.FPutObject(tctx, b.Bucket, key, src, minio.PutObjectOptions{ContentType: contentType})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to upload %s: %s", src, err.Error())), nil
+		}
+	} else {
+		content, ok := args["content"].(string)
+		if !ok {
+			return mcp.NewToolResultError("either local_path or content must be provided"), nil
+		}
+		if int64(len(content)) > ss.config.MaxObjectSize {
+			return mcp.NewToolResultError(fmt.Sprintf("content is too large (%d bytes), limit is %d bytes", len(content), ss.config.MaxObjectSize)), nil
+		}
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		uploaded, err = b.client.PutObject(tctx, b.Bucket, key, strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{ContentType: contentType})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to upload content: %s", err.Error())), nil
+		}
+	}
+	ss.Logger.Info().Str("bucket", b.Name).Str("key", key).Int64("size", uploaded.Size).Msg("object uploaded")
+	return mcp.NewToolResultText(fmt.Sprintf("Uploaded %s/%s (%d bytes, etag %s)", b.Name, key, uploaded.Size, uploaded.ETag)), nil
+}
+
+// handleDelete handles deleting an object.
+func (ss *StorageServer) handleDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, key, err := ss.bucketAndKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if b.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("bucket %s is read-only", b.Name)), nil
+	}
+	tctx, cancel := ss.timeoutCtx(ctx)
+	defer cancel()
+	if abstract.IsDryRun(ctx, request) {
+		info, err := b.client.StatObject(tctx, b.Bucket, key, minio.StatObjectOptions{})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to stat object %s: %s", key, err.Error())), nil
+		}
+		return abstract.NewDryRunResult("would delete %s/%s (%d bytes)", b.Name, key, info.Size), nil
+	}
+	err = b.client.RemoveObject(tctx, b.Bucket, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete object %s: %s", key, err.Error())), nil
+	}
+	ss.Logger.Info().Str("bucket", b.Name).Str("key", key).Msg("object deleted")
+	return mcp.NewToolResultText(fmt.Sprintf("Deleted %s/%s", b.Name, key)), nil
+}
+
+// handlePresign handles creating a presigned URL.
+func (ss *StorageServer) handlePresign(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	b, key, err := ss.bucketAndKey(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	expiry := ss.config.PresignExpiry
+	if e := abstract.GetInt(args, "expiry", 0); e > 0 {
+		expiry = e
+	}
+	if expiry > maxPresignExpiry {
+		return mcp.NewToolResultError(fmt.Sprintf("expiry must not exceed %d seconds", maxPresignExpiry)), nil
+	}
+	method := abstract.GetString(args, "method", "")
+	tctx, cancel := ss.timeoutCtx(ctx)
+	defer cancel()
+	lifetime := time.Duration(expiry) * time.Second
+	switch strings.ToUpper(method) {
+	case "", "GET":
+		u, err := b.client.PresignedGetObject(tctx, b.Bucket, key, lifetime, nil)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to presign: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("GET URL, valid for %s:\n%s", lifetime, u.String())), nil
+	case "PUT":
+		if b.ReadOnly {
+			return mcp.NewToolResultError(fmt.Sprintf("bucket %s is read-only", b.Name)), nil
+		}
+		u, err := b.client.PresignedPutObject(tctx, b.Bucket, key, lifetime)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to presign: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("PUT URL, valid for %s:\n%s", lifetime, u.String())), nil
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported method %s, use GET or PUT", method)), nil
+	}
+}
+
+// Config returns the configuration of the service as a string.
+func (ss *StorageServer) Config() string {
+	cfg, err := json.Marshal(ss.config)
+	if err != nil {
+		ss.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ss *StorageServer) Name() comm.MoLingServerType {
+	return StorageServerName
+}
+
+func (ss *StorageServer) Close() error {
+	ss.Logger.Debug().Msg("StorageServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ss *StorageServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ss.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ss.config.Check()
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const StoragePromptDefault = `
+You are an assistant that can move files between the local data directory (%s) and S3-compatible object storage. Your capabilities include:
+
+1. **List**: Use storage_list to list the configured buckets, or the objects under a prefix of a bucket.
+
+2. **Download**: Use storage_get to download an object into the local data directory, or to read a small text object inline.
+
+3. **Upload**: Use storage_put to upload a local file or inline text content.
+
+4. **Delete**: Use storage_delete to delete an object. Always confirm with the user before deleting.
+
+5. **Share**: Use storage_presign to create a temporary URL to download or upload an object without credentials.
+
+Buckets are referred to by their configured name. Some buckets only allow access to certain prefixes or are read-only; respect these restrictions.
+`
+
+// BucketConfig describes an S3-compatible bucket that the service may access.
+type BucketConfig struct {
+	Name      string   `json:"name"`       // Name is the alias used in tool calls.
+	Endpoint  string   `json:"endpoint"`   // Endpoint is the S3 endpoint without scheme, e.g. s3.amazonaws.com or 127.0.0.1:9000.
+	Bucket    string   `json:"bucket"`     // Bucket is the bucket name on the server.
+	Region    string   `json:"region"`     // Region is the bucket region, optional.
+	AccessKey string   `json:"access_key"` // AccessKey is the access key, if empty the AWS_* or MINIO_* environment variables are used.
+	SecretKey string   `json:"secret_key"` // SecretKey is the secret key.
+	UseSSL    bool     `json:"use_ssl"`    // UseSSL enables HTTPS.
+	Prefixes  []string `json:"prefixes"`   // Prefixes is the allowlist of key prefixes, empty allows all keys.
+	ReadOnly  bool     `json:"read_only"`  // ReadOnly disables put and delete.
+}
+
+// StorageConfig represents the configuration for the storage service.
+type StorageConfig struct {
+	PromptFile    string `json:"prompt_file"` // PromptFile is the prompt file for the storage service.
+	prompt        string
+	DataPath      string         `json:"data_path"`       // DataPath is the local directory that objects are downloaded to and uploaded from.
+	Buckets       []BucketConfig `json:"buckets"`         // Buckets are the buckets that can be accessed.
+	MaxObjectSize int64          `json:"max_object_size"` // MaxObjectSize is the maximum size of an object to upload or download, in bytes.
+	MaxInlineSize int64          `json:"max_inline_size"` // MaxInlineSize is the maximum size of a text object returned inline, in bytes.
+	PresignExpiry int            `json:"presign_expiry"`  // PresignExpiry is the default lifetime of presigned URLs, in seconds.
+	Timeout       int            `json:"timeout"`         // Timeout is the timeout of a single operation, in seconds.
+}
+
+// maxPresignExpiry is the longest lifetime S3 accepts for presigned URLs, 7 days.
+const maxPresignExpiry = 7 * 24 * 3600
+
+// NewStorageConfig creates a new StorageConfig with default values.
+func NewStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		prompt:        StoragePromptDefault,
+		DataPath:      filepath.Join(os.TempDir(), ".moling", "data"),
+		Buckets:       []BucketConfig{},
+		MaxObjectSize: 1024 * 1024 * 100,
+		MaxInlineSize: 1024 * 1024,
+		PresignExpiry: 3600,
+		Timeout:       300,
+	}
+}
+
+// Check validates the storage configuration.
+func (cfg *StorageConfig) Check() error {
+	cfg.prompt = StoragePromptDefault
+	if cfg.DataPath == "" {
+		return fmt.Errorf("data_path must not be empty")
+	}
+	names := make(map[string]bool)
+	for _, b := range cfg.Buckets {
+		if b.Name == "" || b.Endpoint == "" || b.Bucket == "" {
+			return fmt.Errorf("bucket name, endpoint and bucket must not be empty")
+		}
+		if strings.Contains(b.Endpoint, "://") {
+			return fmt.Errorf("bucket %s: endpoint must not contain a scheme, use use_ssl instead", b.Name)
+		}
+		if names[b.Name] {
+			return fmt.Errorf("duplicate bucket name %s", b.Name)
+		}
+		names[b.Name] = true
+	}
+	if cfg.MaxObjectSize <= 0 || cfg.MaxInlineSize <= 0 {
+		return fmt.Errorf("max_object_size and max_inline_size must be greater than 0")
+	}
+	if cfg.PresignExpiry <= 0 || cfg.PresignExpiry > maxPresignExpiry {
+		return fmt.Errorf("presign_expiry must be between 1 and %d seconds", maxPresignExpiry)
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
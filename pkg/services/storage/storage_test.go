@@ -0,0 +1,102 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestStorageServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewStorageServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create StorageServer: %s", err.Error())
+	}
+	var cfg map[string]any
+	err = json.Unmarshal([]byte(`{"buckets":[{"name":"artifacts","endpoint":"127.0.0.1:9000","bucket":"ci","prefixes":["builds/"],"read_only":true}]}`), &cfg)
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err.Error())
+	}
+	err = srv.LoadConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init StorageServer: %s", err.Error())
+	}
+	ss := srv.(*StorageServer)
+	b, ok := ss.buckets["artifacts"]
+	if !ok || !b.ReadOnly || len(b.Prefixes) != 1 {
+		t.Errorf("Bucket config not loaded: %+v", ss.config.Buckets)
+	}
+	if len(srv.Tools()) != 5 {
+		t.Errorf("Expected 5 tools, got %d", len(srv.Tools()))
+	}
+}
+
+func TestKeyAllowed(t *testing.T) {
+	prefixes := []string{"builds/", "reports/"}
+	cases := map[string]bool{
+		"builds/1/app.tar.gz":  true,
+		"reports/today.html":   true,
+		"secrets/key.pem":      false,
+		"builds/../secrets/a":  false,
+		"buildsx/app.tar.gz":   false,
+		"reports/a/../../b.md": false,
+	}
+	for key, expected := range cases {
+		if keyAllowed(key, prefixes) != expected {
+			t.Errorf("keyAllowed(%q) = %v, expected %v", key, !expected, expected)
+		}
+	}
+	if !keyAllowed("anything", nil) {
+		t.Errorf("Expected all keys to be allowed without prefixes")
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	ss := &StorageServer{config: NewStorageConfig()}
+	ss.config.DataPath = t.TempDir()
+	p, err := ss.localPath("out/report.html")
+	if err != nil {
+		t.Fatalf("Failed to resolve path: %s", err.Error())
+	}
+	if p != filepath.Join(ss.config.DataPath, "out", "report.html") {
+		t.Errorf("Unexpected path %s", p)
+	}
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err = os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink(secret, filepath.Join(ss.config.DataPath, "link")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	for _, bad := range []string{"../escape", "/etc/passwd", "link"} {
+		if _, err := ss.localPath(bad); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+}
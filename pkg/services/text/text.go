@@ -0,0 +1,481 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package text provides text utilities for the MoLing application.
+package text
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+	"github.com/gojue/moling/pkg/utils/diff"
+)
+
+const (
+	TextServerName comm.MoLingServerType = "Text"
+)
+
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+// regexMatch is a regex match with its capture groups.
+type regexMatch struct {
+	Match  string            `json:"match"`
+	Index  int               `json:"index"`
+	Groups []string          `json:"groups,omitempty"`
+	Named  map[string]string `json:"named,omitempty"`
+}
+
+// TextServer implements the Service interface and provides diff, regex, hash, encoding and conversion tools.
+type TextServer struct {
+	abstract.MLService
+	config *TextConfig
+}
+
+// NewTextServer creates a new TextServer instance.
+func NewTextServer(ctx context.Context) (abstract.Service, error) {
+	tc := NewTextConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("TextServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("TextServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(TextServerName))
+	})
+
+	ts := &TextServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    tc,
+	}
+	err := ts.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Init registers the prompt and tools of the text service.
+func (ts *TextServer) Init() error {
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "text_prompt",
+			Description: "Get the relevant functions and prompts of the Text MCP Server",
+		},
+		HandlerFunc: ts.handlePrompt,
+	}
+	ts.AddPrompt(pe)
+
+	ts.AddTool(mcp.NewTool(
+		"text_diff",
+		mcp.WithDescription("Compare two texts line by line and return a unified diff"),
+		mcp.WithString("a",
+			mcp.Description("Original text"),
+			mcp.Required(),
+		),
+		mcp.WithString("b",
+			mcp.Description("Changed text"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("context",
+			mcp.Description("Number of unchanged lines around each change, default: 3"),
+		),
+		mcp.WithString("a_name",
+			mcp.Description("Name of the original text in the diff header, default: a"),
+		),
+		mcp.WithString("b_name",
+			mcp.Description("Name of the changed text in the diff header, default: b"),
+		),
+	), ts.handleDiff)
+
+	ts.AddTool(mcp.NewTool(
+		"text_regex",
+		mcp.WithDescription("Extract the matches of a regular expression (RE2 syntax) with their capture groups, or replace them"),
+		mcp.WithString("text",
+			mcp.Description("Text to search"),
+			mcp.Required(),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Regular expression in RE2 syntax"),
+			mcp.Required(),
+		),
+		mcp.WithString("replacement",
+			mcp.Description("Replace the matches with this template instead of extracting them. Use $1 or ${name} for groups"),
+		),
+		mcp.WithString("flags",
+			mcp.Description("Any of i (case insensitive), m (^ and $ match at line breaks), s (. matches newlines)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of matches to extract or replace, default: all"),
+		),
+	), ts.handleRegex)
+
+	ts.AddTool(mcp.NewTool(
+		"text_hash",
+		mcp.WithDescription("Compute a checksum of a text, or an HMAC when a key is given"),
+		mcp.WithString("text",
+			mcp.Description("Text to hash, as UTF-8"),
+			mcp.Required(),
+		),
+		mcp.WithString("algorithm",
+			mcp.Description("Hash algorithm, default: sha256"),
+			mcp.Enum("md5", "sha1", "sha256", "sha512", "crc32"),
+		),
+		mcp.WithString("key",
+			mcp.Description("HMAC key"),
+		),
+		mcp.WithString("encoding",
+			mcp.Description("Output encoding, default: hex"),
+			mcp.Enum("hex", "base64"),
+		),
+	), ts.handleHash)
+
+	ts.AddTool(mcp.NewTool(
+		"text_encode",
+		mcp.WithDescription("Encode or decode a text with base64, base64url, URL, hex or HTML encoding"),
+		mcp.WithString("text",
+			mcp.Description("Text to encode or decode"),
+			mcp.Required(),
+		),
+		mcp.WithString("codec",
+			mcp.Description("Encoding: base64, base64url, url (query component), url_path (path segment), hex or html"),
+			mcp.Enum("base64", "base64url", "url", "url_path", "hex", "html"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("decode",
+			mcp.Description("Decode instead of encode, default: false"),
+		),
+	), ts.handleEncode)
+
+	ts.AddTool(mcp.NewTool(
+		"text_convert",
+		mcp.WithDescription("Convert structured data between JSON, YAML and TOML"),
+		mcp.WithString("text",
+			mcp.Description("Input document"),
+			mcp.Required(),
+		),
+		mcp.WithString("from",
+			mcp.Description("Input format"),
+			mcp.Enum(formatJSON, formatYAML, formatTOML),
+			mcp.Required(),
+		),
+		mcp.WithString("to",
+			mcp.Description("Output format"),
+			mcp.Enum(formatJSON, formatYAML, formatTOML),
+			mcp.Required(),
+		),
+		mcp.WithNumber("indent",
+			mcp.Description("Indentation width, default: 2"),
+		),
+	), ts.handleConvert)
+	return nil
+}
+
+func (ts *TextServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ts.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// text returns a string argument and checks it against the input size limit.
+func (ts *TextServer) text(args map[string]any, name string, required bool) (string, error) {
+	s, ok := args[name].(string)
+	if !ok && required {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	if len(s) > ts.config.MaxInputSize {
+		return "", fmt.Errorf("%s is %d bytes, the limit is %d", name, len(s), ts.config.MaxInputSize)
+	}
+	return s, nil
+}
+
+func (ts *TextServer) handleDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	a, err := ts.text(args, "a", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	b, err := ts.text(args, "b", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	context := 3
+	if c := abstract.GetInt(args, "context", -1); c >= 0 {
+		context = c
+	}
+	nameA := abstract.GetString(args, "a_name", "")
+	if nameA == "" {
+		nameA = "a"
+	}
+	nameB := abstract.GetString(args, "b_name", "")
+	if nameB == "" {
+		nameB = "b"
+	}
+	unified := diff.Unified(nameA, nameB, diff.Lines(diff.SplitLines(a), diff.SplitLines(b), ts.config.MaxDiffEdits), context)
+	if unified == "" {
+		return mcp.NewToolResultText("The texts are identical."), nil
+	}
+	return mcp.NewToolResultText(unified), nil
+}
+
+func (ts *TextServer) handleRegex(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text, err := ts.text(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pattern := abstract.GetString(args, "pattern", "")
+	flags := abstract.GetString(args, "flags", "")
+	if flags != "" {
+		if strings.Trim(flags, "ims") != "" {
+			return mcp.NewToolResultError("flags may only contain i, m and s"), nil
+		}
+		pattern = "(?" + flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid pattern: %s", err.Error())), nil
+	}
+	limit := -1
+	if l := abstract.GetInt(args, "limit", 0); l > 0 {
+		limit = l
+	}
+
+	if abstract.Has(args, "replacement") {
+		replacement := abstract.GetString(args, "replacement", "")
+		var sb strings.Builder
+		last, count := 0, 0
+		for _, loc := range re.FindAllStringSubmatchIndex(text, limit) {
+			sb.WriteString(text[last:loc[0]])
+			sb.Write(re.ExpandString(nil, replacement, text, loc))
+			last = loc[1]
+			count++
+		}
+		sb.WriteString(text[last:])
+		result := sb.String()
+		return mcp.NewToolResultText(fmt.Sprintf("%d replacements\n%s", count, result)), nil
+	}
+
+	if limit < 0 || limit > ts.config.MaxMatches {
+		limit = ts.config.MaxMatches
+	}
+	names := re.SubexpNames()
+	var matches []regexMatch
+	for _, loc := range re.FindAllStringSubmatchIndex(text, limit) {
+		m := regexMatch{Match: text[loc[0]:loc[1]], Index: loc[0]}
+		for g := 1; g < len(names); g++ {
+			var value string
+			if loc[2*g] >= 0 {
+				value = text[loc[2*g]:loc[2*g+1]]
+			}
+			m.Groups = append(m.Groups, value)
+			if names[g] != "" {
+				if m.Named == nil {
+					m.Named = make(map[string]string)
+				}
+				m.Named[names[g]] = value
+			}
+		}
+		matches = append(matches, m)
+	}
+	if len(matches) == 0 {
+		return mcp.NewToolResultText("No matches."), nil
+	}
+	data, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to encode matches: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (ts *TextServer) handleHash(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text, err := ts.text(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	algorithm := abstract.GetString(args, "algorithm", "")
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := hashes[strings.ToLower(algorithm)]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported algorithm %q", algorithm)), nil
+	}
+	h := newHash()
+	if key := abstract.GetString(args, "key", ""); key != "" {
+		if algorithm == "crc32" {
+			return mcp.NewToolResultError("crc32 cannot be used for an HMAC"), nil
+		}
+		h = hmac.New(newHash, []byte(key))
+	}
+	h.Write([]byte(text))
+	sum := h.Sum(nil)
+	if encoding := abstract.GetString(args, "encoding", ""); encoding == "base64" {
+		return mcp.NewToolResultText(base64.StdEncoding.EncodeToString(sum)), nil
+	}
+	return mcp.NewToolResultText(hex.EncodeToString(sum)), nil
+}
+
+func (ts *TextServer) handleEncode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text, err := ts.text(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	codec := abstract.GetString(args, "codec", "")
+	decode := abstract.GetBool(args, "decode", false)
+	result, err := transcode(text, codec, decode)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// transcode encodes or decodes text with a codec.
+func transcode(text, codec string, decode bool) (string, error) {
+	var out []byte
+	var err error
+	switch codec {
+	case "base64", "base64url":
+		enc := base64.StdEncoding
+		if codec == "base64url" {
+			enc = base64.URLEncoding
+		}
+		if !decode {
+			return enc.EncodeToString([]byte(text)), nil
+		}
+		// accept input with or without padding
+		out, err = enc.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(strings.TrimSpace(text), "="))
+	case "url":
+		if !decode {
+			return url.QueryEscape(text), nil
+		}
+		s, err := url.QueryUnescape(text)
+		return s, err
+	case "url_path":
+		if !decode {
+			return url.PathEscape(text), nil
+		}
+		s, err := url.PathUnescape(text)
+		return s, err
+	case "hex":
+		if !decode {
+			return hex.EncodeToString([]byte(text)), nil
+		}
+		out, err = hex.DecodeString(strings.TrimSpace(text))
+	case "html":
+		if !decode {
+			return html.EscapeString(text), nil
+		}
+		return html.UnescapeString(text), nil
+	default:
+		return "", fmt.Errorf("unsupported codec %q", codec)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid %s input: %w", codec, err)
+	}
+	if !utf8.Valid(out) {
+		return "", fmt.Errorf("the decoded data is binary, not text. Hex: %s", hex.EncodeToString(out[:min(len(out), 256)]))
+	}
+	return string(out), nil
+}
+
+func (ts *TextServer) handleConvert(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text, err := ts.text(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	from := abstract.GetString(args, "from", "")
+	to := abstract.GetString(args, "to", "")
+	indent := 2
+	if i := abstract.GetInt(args, "indent", 0); i > 0 && i <= 8 {
+		indent = i
+	}
+	result, err := convert(text, strings.ToLower(from), strings.ToLower(to), indent)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+func (ts *TextServer) Config() string {
+	cfg, err := json.Marshal(ts.config)
+	if err != nil {
+		ts.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ts *TextServer) Name() comm.MoLingServerType {
+	return TextServerName
+}
+
+func (ts *TextServer) Close() error {
+	ts.Logger.Debug().Msg("TextServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ts *TextServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ts.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ts.config.Check()
+}
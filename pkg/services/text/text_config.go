@@ -0,0 +1,73 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package text
+
+import (
+	"fmt"
+	"os"
+)
+
+const TextPromptDefault = `
+You are an assistant with text utilities that save you from shelling out for everyday transformations. Your capabilities include:
+
+1. **Diff**: Use text_diff to compare two texts and get a unified diff.
+
+2. **Regular expressions**: Use text_regex to extract matches with their capture groups, or to replace matches. The syntax is RE2, which has no lookaround or backreferences.
+
+3. **Hash**: Use text_hash to compute MD5, SHA-1, SHA-256, SHA-512 or CRC32 checksums, or an HMAC when a key is given.
+
+4. **Encode and decode**: Use text_encode for base64, base64url, URL, hex and HTML encoding and decoding.
+
+5. **Convert**: Use text_convert to convert structured data between JSON, YAML and TOML.
+
+Use these tools instead of computing hashes, encodings or diffs yourself, they are exact.
+`
+
+// TextConfig represents the configuration for the text service.
+type TextConfig struct {
+	PromptFile   string `json:"prompt_file"` // PromptFile is the prompt file for the text service.
+	prompt       string
+	MaxInputSize int `json:"max_input_size"` // MaxInputSize is the maximum size of each text argument, in bytes.
+	MaxMatches   int `json:"max_matches"`    // MaxMatches is the maximum number of regex matches returned.
+	MaxDiffEdits int `json:"max_diff_edits"` // MaxDiffEdits bounds the diff search, larger differences are shown as one replaced block.
+}
+
+// NewTextConfig creates a new TextConfig with default values.
+func NewTextConfig() *TextConfig {
+	return &TextConfig{
+		prompt:       TextPromptDefault,
+		MaxInputSize: 1024 * 1024,
+		MaxMatches:   1000,
+		MaxDiffEdits: 4000,
+	}
+}
+
+// Check validates the text configuration.
+func (cfg *TextConfig) Check() error {
+	cfg.prompt = TextPromptDefault
+	if cfg.MaxInputSize <= 0 || cfg.MaxMatches <= 0 || cfg.MaxDiffEdits <= 0 {
+		return fmt.Errorf("max_input_size, max_matches and max_diff_edits must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
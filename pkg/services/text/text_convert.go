@@ -0,0 +1,121 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package text
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// convert parses structured data in one format and renders it in another.
+func convert(input, from, to string, indent int) (string, error) {
+	var data any
+	var err error
+	switch from {
+	case formatJSON:
+		dec := json.NewDecoder(strings.NewReader(input))
+		dec.UseNumber()
+		err = dec.Decode(&data)
+		data = normalize(data)
+	case formatYAML:
+		err = yaml.Unmarshal([]byte(input), &data)
+		data = normalize(data)
+	case formatTOML:
+		var table map[string]any
+		err = toml.Unmarshal([]byte(input), &table)
+		data = table
+	default:
+		return "", fmt.Errorf("unsupported input format %q", from)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", from, err)
+	}
+
+	switch to {
+	case formatJSON:
+		out, err := json.MarshalIndent(data, "", strings.Repeat(" ", indent))
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	case formatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(indent)
+		err = enc.Encode(data)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case formatTOML:
+		if _, ok := data.(map[string]any); !ok {
+			return "", fmt.Errorf("toml requires a table at the top level, the input is a %T", data)
+		}
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.SetIndentTables(true)
+		err = enc.Encode(data)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return "", fmt.Errorf("unsupported output format %q", to)
+}
+
+// normalize converts decoded values into types every encoder supports: YAML maps with
+// non-string keys become string keyed maps and JSON numbers become integers or floats.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package text
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+func TestTextServer(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %s", err.Error())
+	}
+	srv, err := NewTextServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create TextServer: %s", err.Error())
+	}
+	err = srv.Init()
+	if err != nil {
+		t.Fatalf("Failed to init TextServer: %s", err.Error())
+	}
+	ts := srv.(*TextServer)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Tool call failed: %s", err.Error())
+		}
+		text := res.Content[0].(mcp.TextContent).Text
+		if res.IsError {
+			t.Fatalf("Tool returned an error for %v: %s", args, text)
+		}
+		return text
+	}
+
+	cases := []struct {
+		handler  func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args     map[string]any
+		expected string
+	}{
+		{ts.handleHash, map[string]any{"text": "hello"}, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{ts.handleHash, map[string]any{"text": "hello", "algorithm": "md5"}, "5d41402abc4b2a76b9719d911017c592"},
+		{ts.handleHash, map[string]any{"text": "hello", "algorithm": "crc32"}, "3610a686"},
+		{ts.handleHash, map[string]any{"text": "hello", "key": "secret"}, "88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b"},
+		{ts.handleEncode, map[string]any{"text": "a b&c/ü", "codec": "url"}, "a+b%26c%2F%C3%BC"},
+		{ts.handleEncode, map[string]any{"text": "aGk", "codec": "base64", "decode": true}, "hi"},
+		{ts.handleEncode, map[string]any{"text": "<a href=\"x\">", "codec": "html"}, "&lt;a href=&#34;x&#34;&gt;"},
+		{ts.handleEncode, map[string]any{"text": "6869", "codec": "hex", "decode": true}, "hi"},
+		{ts.handleRegex, map[string]any{"text": "v1.2 and v3.4", "pattern": `v(?P<major>\d+)\.(\d+)`, "replacement": "${major}.x"}, "2 replacements\n1.x and 3.x"},
+		{ts.handleRegex, map[string]any{"text": "a\nb", "pattern": "^b", "flags": "m", "replacement": "B", "limit": float64(1)}, "1 replacements\na\nB"},
+		{ts.handleConvert, map[string]any{"text": `{"name":"moling","ports":[80,443],"big":12345678901234567}`, "from": "json", "to": "yaml"}, "big: 12345678901234567\nname: moling\nports:\n  - 80\n  - 443\n"},
+		{ts.handleConvert, map[string]any{"text": "title = \"x\"\n[server]\nport = 8080\n", "from": "toml", "to": "json", "indent": float64(1)}, "{\n \"server\": {\n  \"port\": 8080\n },\n \"title\": \"x\"\n}\n"},
+		{ts.handleConvert, map[string]any{"text": "a: 1\nb:\n  c: true\n", "from": "yaml", "to": "toml"}, "a = 1\n\n[b]\n  c = true\n"},
+	}
+	for _, c := range cases {
+		if got := call(c.handler, c.args); got != c.expected {
+			t.Errorf("Unexpected result for %v: %q, expected %q", c.args, got, c.expected)
+		}
+	}
+
+	out := call(ts.handleRegex, map[string]any{"text": "id=7 id=42", "pattern": `id=(?P<id>\d+)`})
+	if !strings.Contains(out, `"id": "42"`) || !strings.Contains(out, `"index": 5`) {
+		t.Errorf("Unexpected matches: %s", out)
+	}
+}
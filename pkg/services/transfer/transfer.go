@@ -0,0 +1,433 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package transfer provides SFTP and FTP file transfers for the MoLing application.
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	TransferServerName comm.MoLingServerType = "Transfer"
+)
+
+// TransferServer implements the Service interface and transfers files to and from SFTP/FTP servers.
+type TransferServer struct {
+	abstract.MLService
+	config    *TransferConfig
+	endpoints map[string]EndpointConfig
+	dial      func(ep EndpointConfig) (remoteFS, error)
+}
+
+// NewTransferServer creates a new TransferServer instance.
+func NewTransferServer(ctx context.Context) (abstract.Service, error) {
+	tc := NewTransferConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("TransferServer: invalid config type")
+	}
+	tc.DataPath = filepath.Join(globalConf.BasePath, "data")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("TransferServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(TransferServerName))
+	})
+
+	ts := &TransferServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    tc,
+		endpoints: make(map[string]EndpointConfig),
+	}
+	ts.dial = func(ep EndpointConfig) (remoteFS, error) {
+		return dialRemote(ep, ts.config.KnownHostsFile, time.Duration(ts.config.Timeout)*time.Second)
+	}
+	err := ts.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Init registers the prompt and tools.
+func (ts *TransferServer) Init() error {
+	for _, ep := range ts.config.Endpoints {
+		ts.endpoints[ep.Name] = ep
+	}
+	err := utils.CreateDirectory(ts.config.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to create data directory %s: %w", ts.config.DataPath, err)
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "transfer_prompt",
+			Description: "Get the relevant functions and prompts of the Transfer MCP Server",
+		},
+		HandlerFunc: ts.handlePrompt,
+	}
+	ts.AddPrompt(pe)
+
+	ts.AddTool(mcp.NewTool(
+		"transfer_list",
+		mcp.WithDescription("List the configured endpoints, or a directory on an endpoint"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name, omit to list the endpoints"),
+		),
+		mcp.WithString("path",
+			mcp.Description("Remote directory, relative to the endpoint root, default: the root"),
+		),
+	), ts.handleList)
+
+	ts.AddTool(mcp.NewTool(
+		"transfer_upload",
+		mcp.WithDescription("Upload a file from the local data directory to an endpoint. Interrupted uploads are resumed."),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("local_path",
+			mcp.Description("Source path, relative to the data directory"),
+			mcp.Required(),
+		),
+		mcp.WithString("remote_path",
+			mcp.Description("Destination path, relative to the endpoint root"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("resume",
+			mcp.Description("Resume a previous partial upload, default: true"),
+		),
+		mcp.WithBoolean("verify",
+			mcp.Description("Verify the SHA-256 checksum after the upload, default: true"),
+		),
+	), ts.handleUpload)
+
+	ts.AddTool(mcp.NewTool(
+		"transfer_download",
+		mcp.WithDescription("Download a file from an endpoint into the local data directory. Interrupted downloads are resumed."),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("remote_path",
+			mcp.Description("Source path, relative to the endpoint root"),
+			mcp.Required(),
+		),
+		mcp.WithString("local_path",
+			mcp.Description("Destination path, relative to the data directory, default: the remote file name"),
+		),
+		mcp.WithBoolean("resume",
+			mcp.Description("Resume a previous partial download, default: true"),
+		),
+		mcp.WithBoolean("verify",
+			mcp.Description("Verify the download, default: true"),
+		),
+		mcp.WithString("expected_sha256",
+			mcp.Description("Published SHA-256 checksum the download must match"),
+		),
+	), ts.handleDownload)
+
+	ts.AddTool(mcp.NewTool(
+		"transfer_delete",
+		mcp.WithDescription("Delete a file on an endpoint"),
+		mcp.WithString("endpoint",
+			mcp.Description("Configured endpoint name"),
+			mcp.Required(),
+		),
+		mcp.WithString("remote_path",
+			mcp.Description("Path of the file, relative to the endpoint root"),
+			mcp.Required(),
+		),
+		abstract.WithDryRunArgument(),
+	), ts.handleDelete)
+	return nil
+}
+
+func (ts *TransferServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ts.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ts.config.DataPath)
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// connect resolves the endpoint argument and connects to it.
+func (ts *TransferServer) connect(args map[string]any) (EndpointConfig, remoteFS, error) {
+	name := abstract.GetString(args, "endpoint", "")
+	ep, ok := ts.endpoints[name]
+	if !ok {
+		return ep, nil, fmt.Errorf("endpoint %q is not configured", name)
+	}
+	rfs, err := ts.dial(ep)
+	if err != nil {
+		return ep, nil, err
+	}
+	return ep, rfs, nil
+}
+
+// remotePath resolves a path relative to the endpoint root and makes sure it stays inside it.
+func remotePath(ep EndpointConfig, p string) (string, error) {
+	root := path.Clean("/" + ep.RootDir)
+	resolved := path.Clean(path.Join(root, p))
+	if root != "/" && resolved != root && !strings.HasPrefix(resolved, root+"/") {
+		return "", fmt.Errorf("access denied - path outside the endpoint root %s: %s", root, p)
+	}
+	return resolved, nil
+}
+
+// localPath resolves a path relative to the data directory and makes sure it stays inside it.
+func (ts *TransferServer) localPath(p string) (string, error) {
+	return utils.ResolvePath(p, []string{ts.config.DataPath})
+}
+
+func boolArg(args map[string]any, name string, def bool) bool {
+	if v, ok := args[name].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// handleList handles listing endpoints or a remote directory.
+func (ts *TransferServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	if name := abstract.GetString(args, "endpoint", ""); name == "" {
+		if len(ts.config.Endpoints) == 0 {
+			return mcp.NewToolResultText("No endpoints configured, add them to the Transfer section of the config file"), nil
+		}
+		var sb strings.Builder
+		for _, ep := range ts.config.Endpoints {
+			sb.WriteString(fmt.Sprintf("- %s: %s://%s@%s%s", ep.Name, ep.Protocol, ep.Username, ep.address(), path.Clean("/"+ep.RootDir)))
+			if ep.ReadOnly {
+				sb.WriteString(", read-only")
+			}
+			sb.WriteString("\n")
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	ep, rfs, err := ts.connect(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer rfs.Close()
+	p := abstract.GetString(args, "path", "")
+	dir, err := remotePath(ep, p)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	entries, err := rfs.List(dir)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list %s: %s", dir, err.Error())), nil
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("%s is empty", dir)), nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	var sb strings.Builder
+	for _, e := range entries {
+		if e.IsDir {
+			sb.WriteString(fmt.Sprintf("[DIR]  %s\n", e.Name))
+		} else {
+			sb.WriteString(fmt.Sprintf("[FILE] %s (%d bytes, %s)\n", e.Name, e.Size, e.ModTime.Format(time.RFC3339)))
+		}
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleUpload handles uploading a file.
+func (ts *TransferServer) handleUpload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	localArg := abstract.GetString(args, "local_path", "")
+	remoteArg := abstract.GetString(args, "remote_path", "")
+	if localArg == "" || remoteArg == "" {
+		return mcp.NewToolResultError("local_path and remote_path must be non-empty strings"), nil
+	}
+	local, err := ts.localPath(localArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fi, err := os.Stat(local)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to stat %s: %s", local, err.Error())), nil
+	}
+	if fi.IsDir() {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is a directory", local)), nil
+	}
+	if fi.Size() > ts.config.MaxFileSize {
+		return mcp.NewToolResultError(fmt.Sprintf("file is too large (%d bytes), limit is %d bytes", fi.Size(), ts.config.MaxFileSize)), nil
+	}
+	ep, rfs, err := ts.connect(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer rfs.Close()
+	if ep.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("endpoint %s is read-only", ep.Name)), nil
+	}
+	remote, err := remotePath(ep, remoteArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	res, err := upload(ctx, rfs, local, remote, boolArg(args, "resume", true), boolArg(args, "verify", true))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to upload %s: %s", local, err.Error())), nil
+	}
+	ts.Logger.Info().Str("endpoint", ep.Name).Str("local", local).Str("remote", remote).Int64("size", res.Size).Msg("file uploaded")
+	return mcp.NewToolResultText(formatResult("Uploaded", local, ep.Name+":"+remote, res)), nil
+}
+
+// handleDownload handles downloading a file.
+func (ts *TransferServer) handleDownload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	remoteArg := abstract.GetString(args, "remote_path", "")
+	if remoteArg == "" {
+		return mcp.NewToolResultError("remote_path must be a non-empty string"), nil
+	}
+	localArg := abstract.GetString(args, "local_path", "")
+	if localArg == "" {
+		localArg = path.Base(remoteArg)
+	}
+	local, err := ts.localPath(localArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	err = utils.CreateDirectory(filepath.Dir(local))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create %s: %s", filepath.Dir(local), err.Error())), nil
+	}
+	ep, rfs, err := ts.connect(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer rfs.Close()
+	remote, err := remotePath(ep, remoteArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	st, err := rfs.Stat(remote)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to stat %s: %s", remote, err.Error())), nil
+	}
+	if st.Size > ts.config.MaxFileSize {
+		return mcp.NewToolResultError(fmt.Sprintf("file is too large (%d bytes), limit is %d bytes", st.Size, ts.config.MaxFileSize)), nil
+	}
+	expected := abstract.GetString(args, "expected_sha256", "")
+	res, err := download(ctx, rfs, remote, local, boolArg(args, "resume", true), boolArg(args, "verify", true), expected)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to download %s: %s", remote, err.Error())), nil
+	}
+	ts.Logger.Info().Str("endpoint", ep.Name).Str("local", local).Str("remote", remote).Int64("size", res.Size).Msg("file downloaded")
+	return mcp.NewToolResultText(formatResult("Downloaded", ep.Name+":"+remote, local, res)), nil
+}
+
+// handleDelete handles deleting a remote file.
+func (ts *TransferServer) handleDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	remoteArg := abstract.GetString(args, "remote_path", "")
+	if remoteArg == "" {
+		return mcp.NewToolResultError("remote_path must be a non-empty string"), nil
+	}
+	ep, rfs, err := ts.connect(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer rfs.Close()
+	if ep.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("endpoint %s is read-only", ep.Name)), nil
+	}
+	remote, err := remotePath(ep, remoteArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if abstract.IsDryRun(ctx, request) {
+		st, err := rfs.Stat(remote)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to stat %s: %s", remote, err.Error())), nil
+		}
+		return abstract.NewDryRunResult("would delete %s:%s (%d bytes)", ep.Name, remote, st.Size), nil
+	}
+	err = rfs.Remove(remote)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete %s: %s", remote, err.Error())), nil
+	}
+	ts.Logger.Info().Str("endpoint", ep.Name).Str("remote", remote).Msg("file deleted")
+	return mcp.NewToolResultText(fmt.Sprintf("Deleted %s:%s", ep.Name, remote)), nil
+}
+
+func formatResult(action, from, to string, res transferResult) string {
+	msg := fmt.Sprintf("%s %s to %s (%d bytes", action, from, to, res.Size)
+	if res.Resumed > 0 {
+		msg += fmt.Sprintf(", resumed at %d bytes", res.Resumed)
+	}
+	return msg + fmt.Sprintf(")\nsha256: %s\nverified: %s", res.SHA256, res.Verified)
+}
+
+// Config returns the configuration of the service as a string.
+func (ts *TransferServer) Config() string {
+	cfg, err := json.Marshal(ts.config)
+	if err != nil {
+		ts.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ts *TransferServer) Name() comm.MoLingServerType {
+	return TransferServerName
+}
+
+func (ts *TransferServer) Close() error {
+	ts.Logger.Debug().Msg("TransferServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ts *TransferServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ts.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ts.config.Check()
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package transfer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const TransferPromptDefault = `
+You are an assistant that transfers files between the local data directory (%s) and remote SFTP/FTP servers. Your capabilities include:
+
+1. **List**: Use transfer_list to list the configured endpoints, or a remote directory.
+
+2. **Upload**: Use transfer_upload to push a local file to an endpoint. Interrupted uploads are resumed when the same upload is started again.
+
+3. **Download**: Use transfer_download to fetch a remote file. Interrupted downloads are resumed as well.
+
+4. **Delete**: Use transfer_delete to delete a remote file. Always confirm with the user before deleting.
+
+Transfers are verified with SHA-256 checksums by default. If the user has a published checksum for a file, pass it as expected_sha256.
+`
+
+const (
+	ProtocolSFTP = "sftp"
+	ProtocolFTP  = "ftp"
+	ProtocolFTPS = "ftps" // FTP with explicit TLS (AUTH TLS)
+)
+
+// EndpointConfig describes a remote server that files can be transferred to.
+type EndpointConfig struct {
+	Name                  string `json:"name"`                    // Name is the alias used in tool calls.
+	Protocol              string `json:"protocol"`                // Protocol is "sftp", "ftp" or "ftps".
+	Host                  string `json:"host"`                    // Host is the server host name or IP.
+	Port                  int    `json:"port"`                    // Port is the server port, default: 22 for sftp, 21 for ftp.
+	Username              string `json:"username"`                // Username is the login user.
+	Password              string `json:"password"`                // Password is the login password, optional for sftp with a private key.
+	PrivateKey            string `json:"private_key"`             // PrivateKey is the path of an SSH private key, sftp only.
+	Passphrase            string `json:"passphrase"`              // Passphrase decrypts the private key.
+	HostKey               string `json:"host_key"`                // HostKey is the expected SHA256 host key fingerprint, e.g. SHA256:..., sftp only.
+	InsecureSkipVerify    bool   `json:"insecure_skip_verify"`    // InsecureSkipVerify disables host key (sftp) or certificate (ftps) verification.
+	RootDir               string `json:"root_dir"`                // RootDir confines all remote paths, default: /.
+	ReadOnly              bool   `json:"read_only"`               // ReadOnly disables uploads and deletes.
+	DisableRemoteChecksum bool   `json:"disable_remote_checksum"` // DisableRemoteChecksum skips running sha256sum over SSH and reads the file back instead.
+}
+
+func (ep EndpointConfig) address() string {
+	port := ep.Port
+	if port == 0 {
+		port = 21
+		if ep.Protocol == ProtocolSFTP {
+			port = 22
+		}
+	}
+	return net.JoinHostPort(ep.Host, strconv.Itoa(port))
+}
+
+// TransferConfig represents the configuration for the transfer service.
+type TransferConfig struct {
+	PromptFile     string `json:"prompt_file"` // PromptFile is the prompt file for the transfer service.
+	prompt         string
+	DataPath       string           `json:"data_path"`        // DataPath is the local directory that files are uploaded from and downloaded to.
+	Endpoints      []EndpointConfig `json:"endpoints"`        // Endpoints are the remote servers that can be accessed.
+	KnownHostsFile string           `json:"known_hosts_file"` // KnownHostsFile verifies SFTP host keys when an endpoint has no host_key, default: ~/.ssh/known_hosts.
+	MaxFileSize    int64            `json:"max_file_size"`    // MaxFileSize is the maximum size of a transferred file, in bytes.
+	Timeout        int              `json:"timeout"`          // Timeout is the connection timeout, in seconds.
+}
+
+// NewTransferConfig creates a new TransferConfig with default values.
+func NewTransferConfig() *TransferConfig {
+	knownHosts := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return &TransferConfig{
+		prompt:         TransferPromptDefault,
+		DataPath:       filepath.Join(os.TempDir(), ".moling", "data"),
+		Endpoints:      []EndpointConfig{},
+		KnownHostsFile: knownHosts,
+		MaxFileSize:    1024 * 1024 * 1024 * 2,
+		Timeout:        30,
+	}
+}
+
+// Check validates the transfer configuration.
+func (cfg *TransferConfig) Check() error {
+	cfg.prompt = TransferPromptDefault
+	if cfg.DataPath == "" {
+		return fmt.Errorf("data_path must not be empty")
+	}
+	names := make(map[string]bool)
+	for _, ep := range cfg.Endpoints {
+		if ep.Name == "" || ep.Host == "" {
+			return fmt.Errorf("endpoint name and host must not be empty")
+		}
+		if names[ep.Name] {
+			return fmt.Errorf("duplicate endpoint name %s", ep.Name)
+		}
+		names[ep.Name] = true
+		switch ep.Protocol {
+		case ProtocolSFTP, ProtocolFTP, ProtocolFTPS:
+		default:
+			return fmt.Errorf("endpoint %s: protocol must be sftp, ftp or ftps, got %q", ep.Name, ep.Protocol)
+		}
+	}
+	if cfg.MaxFileSize <= 0 {
+		return fmt.Errorf("max_file_size must be greater than 0")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// partSuffix marks incomplete transfers, which are resumed and renamed once complete.
+const partSuffix = ".part"
+
+// remoteEntry is a file or directory on a remote server.
+type remoteEntry struct {
+	Name    string
+	Size    int64
+	IsDir   bool
+	ModTime time.Time
+}
+
+// remoteFS is the set of file operations shared by the SFTP and FTP clients.
+type remoteFS interface {
+	List(dir string) ([]remoteEntry, error)
+	Stat(p string) (remoteEntry, error)
+	Open(p string, offset int64) (io.ReadCloser, error)
+	Create(p string, offset int64) (io.WriteCloser, error)
+	Rename(from, to string) error
+	Remove(p string) error
+	MkdirAll(dir string) error
+	Close() error
+}
+
+// checksummer is implemented by clients that can compute a SHA-256 checksum on the server.
+type checksummer interface {
+	Checksum(p string) (string, error)
+}
+
+// transferResult describes a completed transfer.
+type transferResult struct {
+	Size     int64
+	Resumed  int64 // Resumed is the number of bytes that were already transferred.
+	SHA256   string
+	Verified string // Verified describes how the transfer was verified.
+}
+
+// dialRemote connects to an endpoint.
+func dialRemote(ep EndpointConfig, knownHostsFile string, timeout time.Duration) (remoteFS, error) {
+	if ep.Protocol == ProtocolSFTP {
+		c, err := dialSFTP(ep, knownHostsFile, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if ep.DisableRemoteChecksum {
+			// hide Checksum so that files are verified by reading them back
+			return struct{ remoteFS }{c}, nil
+		}
+		return c, nil
+	}
+	options := []ftp.DialOption{ftp.DialWithTimeout(timeout)}
+	if ep.Protocol == ProtocolFTPS {
+		options = append(options, ftp.DialWithExplicitTLS(&tls.Config{ServerName: ep.Host, InsecureSkipVerify: ep.InsecureSkipVerify}))
+	}
+	conn, err := ftp.Dial(ep.address(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", ep.address(), err)
+	}
+	user := ep.Username
+	if user == "" {
+		user = "anonymous"
+	}
+	err = conn.Login(user, ep.Password)
+	if err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("failed to login to %s: %w", ep.address(), err)
+	}
+	return &ftpFS{conn: conn}, nil
+}
+
+func hostKeyCallback(ep EndpointConfig, knownHostsFile string) (ssh.HostKeyCallback, error) {
+	if ep.InsecureSkipVerify {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if ep.HostKey != "" {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fp := ssh.FingerprintSHA256(key); fp != ep.HostKey {
+				return fmt.Errorf("host key mismatch for %s: got %s, expected %s", hostname, fp, ep.HostKey)
+			}
+			return nil
+		}, nil
+	}
+	cb, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts %s, set host_key for endpoint %s: %w", knownHostsFile, ep.Name, err)
+	}
+	return cb, nil
+}
+
+// upload copies a local file to remote, resuming a previous partial upload when possible.
+func upload(ctx context.Context, rfs remoteFS, local, remote string, resume, verify bool) (transferResult, error) {
+	var res transferResult
+	f, err := os.Open(local)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return res, err
+	}
+	res.Size = fi.Size()
+	err = rfs.MkdirAll(path.Dir(remote))
+	if err != nil {
+		return res, fmt.Errorf("failed to create remote directory: %w", err)
+	}
+
+	part := remote + partSuffix
+	if resume {
+		if st, err := rfs.Stat(part); err == nil && !st.IsDir && st.Size <= res.Size {
+			res.Resumed = st.Size
+		}
+	}
+	_, err = f.Seek(res.Resumed, io.SeekStart)
+	if err != nil {
+		return res, err
+	}
+	w, err := rfs.Create(part, res.Resumed)
+	if err != nil {
+		return res, fmt.Errorf("failed to open %s: %w", part, err)
+	}
+	_, err = io.Copy(w, &ctxReader{ctx: ctx, r: f})
+	closeErr := w.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return res, fmt.Errorf("upload interrupted, run it again to resume: %w", err)
+	}
+	err = rfs.Rename(part, remote)
+	if err != nil {
+		return res, fmt.Errorf("failed to rename %s: %w", part, err)
+	}
+
+	res.SHA256, err = fileSHA256(local)
+	if err != nil {
+		return res, err
+	}
+	if !verify {
+		res.Verified = "not verified"
+		return res, nil
+	}
+	remoteSum, how, err := remoteSHA256(ctx, rfs, remote)
+	if err != nil {
+		return res, fmt.Errorf("uploaded, but failed to verify the checksum: %w", err)
+	}
+	if remoteSum != res.SHA256 {
+		return res, fmt.Errorf("checksum mismatch after upload: local %s, remote %s", res.SHA256, remoteSum)
+	}
+	res.Verified = "sha256 " + how
+	return res, nil
+}
+
+// download copies a remote file to local, resuming a previous partial download when possible.
+func download(ctx context.Context, rfs remoteFS, remote, local string, resume, verify bool, expectedSHA256 string) (transferResult, error) {
+	var res transferResult
+	st, err := rfs.Stat(remote)
+	if err != nil {
+		return res, err
+	}
+	if st.IsDir {
+		return res, fmt.Errorf("%s is a directory", remote)
+	}
+	res.Size = st.Size
+	part := local + partSuffix
+	if resume {
+		if fi, err := os.Stat(part); err == nil && fi.Size() <= st.Size {
+			res.Resumed = fi.Size()
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if res.Resumed > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return res, err
+	}
+	r, err := rfs.Open(remote, res.Resumed)
+	if err != nil {
+		_ = f.Close()
+		return res, fmt.Errorf("failed to open %s: %w", remote, err)
+	}
+	_, err = io.Copy(f, &ctxReader{ctx: ctx, r: r})
+	_ = r.Close()
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return res, fmt.Errorf("download interrupted, run it again to resume: %w", err)
+	}
+
+	res.SHA256, err = fileSHA256(part)
+	if err != nil {
+		return res, err
+	}
+	res.Verified = "not verified"
+	if expectedSHA256 != "" {
+		if !strings.EqualFold(expectedSHA256, res.SHA256) {
+			_ = os.Remove(part)
+			return res, fmt.Errorf("checksum mismatch: expected %s, got %s", expectedSHA256, res.SHA256)
+		}
+		res.Verified = "sha256 against the expected checksum"
+	} else if verify {
+		res.Verified = "size only"
+		if cs, ok := rfs.(checksummer); ok {
+			if remoteSum, err := cs.Checksum(remote); err == nil {
+				if remoteSum != res.SHA256 {
+					_ = os.Remove(part)
+					return res, fmt.Errorf("checksum mismatch: remote %s, local %s", remoteSum, res.SHA256)
+				}
+				res.Verified = "sha256 computed on the server"
+			}
+		}
+		if fi, err := os.Stat(part); err != nil || fi.Size() != st.Size {
+			return res, fmt.Errorf("size mismatch after download, expected %d bytes", st.Size)
+		}
+	}
+	return res, os.Rename(part, local)
+}
+
+// remoteSHA256 computes the checksum of a remote file on the server, or by reading it back.
+func remoteSHA256(ctx context.Context, rfs remoteFS, p string) (string, string, error) {
+	if cs, ok := rfs.(checksummer); ok {
+		if sum, err := cs.Checksum(p); err == nil {
+			return sum, "computed on the server", nil
+		}
+	}
+	r, err := rfs.Open(p, 0)
+	if err != nil {
+		return "", "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, &ctxReader{ctx: ctx, r: r})
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), "of the file read back", nil
+}
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ctxReader stops a copy when the context is canceled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// ftpFS implements remoteFS over FTP.
+type ftpFS struct {
+	conn *ftp.ServerConn
+}
+
+func (f *ftpFS) List(dir string) ([]remoteEntry, error) {
+	list, err := f.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]remoteEntry, 0, len(list))
+	for _, e := range list {
+		if e.Name == "." || e.Name == ".." {
+			continue
+		}
+		entries = append(entries, remoteEntry{Name: e.Name, Size: int64(e.Size), IsDir: e.Type == ftp.EntryTypeFolder, ModTime: e.Time})
+	}
+	return entries, nil
+}
+
+func (f *ftpFS) Stat(p string) (remoteEntry, error) {
+	if e, err := f.conn.GetEntry(p); err == nil {
+		return remoteEntry{Name: path.Base(p), Size: int64(e.Size), IsDir: e.Type == ftp.EntryTypeFolder, ModTime: e.Time}, nil
+	}
+	// servers without MLST
+	size, err := f.conn.FileSize(p)
+	if err != nil {
+		return remoteEntry{}, fmt.Errorf("%w: %s", fs.ErrNotExist, err.Error())
+	}
+	return remoteEntry{Name: path.Base(p), Size: size}, nil
+}
+
+func (f *ftpFS) Open(p string, offset int64) (io.ReadCloser, error) {
+	return f.conn.RetrFrom(p, uint64(offset))
+}
+
+func (f *ftpFS) Create(p string, offset int64) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if offset > 0 {
+			err = f.conn.StorFrom(p, pr, uint64(offset))
+		} else {
+			err = f.conn.Stor(p, pr)
+		}
+		_ = pr.CloseWithError(err)
+		done <- err
+	}()
+	return &ftpWriter{pw: pw, done: done}, nil
+}
+
+func (f *ftpFS) Rename(from, to string) error {
+	err := f.conn.Rename(from, to)
+	if err != nil {
+		// some servers refuse to replace an existing file
+		_ = f.conn.Delete(to)
+		err = f.conn.Rename(from, to)
+	}
+	return err
+}
+
+func (f *ftpFS) Remove(p string) error {
+	return f.conn.Delete(p)
+}
+
+func (f *ftpFS) MkdirAll(dir string) error {
+	if dir == "" || dir == "/" || dir == "." {
+		return nil
+	}
+	current := ""
+	if strings.HasPrefix(dir, "/") {
+		current = "/"
+	}
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		current = path.Join(current, part)
+		// errors are expected for existing directories
+		_ = f.conn.MakeDir(current)
+	}
+	_, err := f.conn.List(dir)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return nil
+}
+
+func (f *ftpFS) Close() error {
+	return f.conn.Quit()
+}
+
+// ftpWriter feeds a STOR command running in the background.
+type ftpWriter struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *ftpWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *ftpWriter) Close() error {
+	_ = w.pw.Close()
+	err := <-w.done
+	if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return err
+	}
+	return nil
+}
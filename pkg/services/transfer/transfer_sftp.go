@@ -0,0 +1,216 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package transfer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpReadBuffer is the read size of downloads, large enough for the client to keep several requests in flight.
+const sftpReadBuffer = 512 * 1024
+
+// sftpFS is a remoteFS backed by an SFTP session.
+type sftpFS struct {
+	conn   *ssh.Client // conn runs Checksum, nil when the client does not own an SSH connection.
+	client *sftp.Client
+}
+
+// newSFTPFS starts the sftp subsystem on conn. The client takes ownership of conn.
+func newSFTPFS(conn *ssh.Client) (*sftpFS, error) {
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the sftp subsystem: %w", err)
+	}
+	return &sftpFS{conn: conn, client: client}, nil
+}
+
+func toRemoteEntry(fi os.FileInfo) remoteEntry {
+	return remoteEntry{Name: fi.Name(), Size: fi.Size(), IsDir: fi.IsDir(), ModTime: fi.ModTime()}
+}
+
+// Stat returns the attributes of a path, following symlinks.
+func (c *sftpFS) Stat(p string) (remoteEntry, error) {
+	fi, err := c.client.Stat(p)
+	if err != nil {
+		return remoteEntry{}, err
+	}
+	return toRemoteEntry(fi), nil
+}
+
+// List returns the entries of a directory.
+func (c *sftpFS) List(dir string) ([]remoteEntry, error) {
+	infos, err := c.client.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]remoteEntry, 0, len(infos))
+	for _, fi := range infos {
+		if fi.Name() == "." || fi.Name() == ".." {
+			continue
+		}
+		entries = append(entries, toRemoteEntry(fi))
+	}
+	return entries, nil
+}
+
+// Open opens a file for reading, starting at offset.
+func (c *sftpFS) Open(p string, offset int64) (io.ReadCloser, error) {
+	f, err := c.client.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{bufio.NewReaderSize(f, sftpReadBuffer), f}, nil
+}
+
+// Create opens a file for writing at offset, truncating it when offset is 0.
+func (c *sftpFS) Create(p string, offset int64) (io.WriteCloser, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := c.client.OpenFile(p, flags)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Rename renames a file, replacing the target when the server supports posix-rename.
+func (c *sftpFS) Rename(from, to string) error {
+	if _, ok := c.client.HasExtension("posix-rename@openssh.com"); ok {
+		return c.client.PosixRename(from, to)
+	}
+	_ = c.client.Remove(to)
+	return c.client.Rename(from, to)
+}
+
+// Remove deletes a file.
+func (c *sftpFS) Remove(p string) error {
+	return c.client.Remove(p)
+}
+
+// MkdirAll creates a directory and its missing parents.
+func (c *sftpFS) MkdirAll(dir string) error {
+	if dir == "" || dir == "/" || dir == "." {
+		return nil
+	}
+	return c.client.MkdirAll(dir)
+}
+
+// Checksum runs sha256sum on the server. It fails on servers that only allow SFTP.
+func (c *sftpFS) Checksum(p string) (string, error) {
+	if c.conn == nil {
+		return "", fmt.Errorf("no ssh connection")
+	}
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	out, err := session.Output("sha256sum -- '" + strings.ReplaceAll(p, "'", `'\''`) + "'")
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || len(fields[0]) != 64 {
+		return "", fmt.Errorf("unexpected sha256sum output")
+	}
+	return fields[0], nil
+}
+
+func (c *sftpFS) Close() error {
+	err := c.client.Close()
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return err
+}
+
+// dialSFTP connects to an SFTP endpoint.
+func dialSFTP(ep EndpointConfig, knownHostsFile string, timeout time.Duration) (*sftpFS, error) {
+	auth, err := sshAuth(ep)
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := hostKeyCallback(ep, knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ssh.Dial("tcp", ep.address(), &ssh.ClientConfig{
+		User:            ep.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", ep.address(), err)
+	}
+	c, err := newSFTPFS(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func sshAuth(ep EndpointConfig) ([]ssh.AuthMethod, error) {
+	var auth []ssh.AuthMethod
+	if ep.PrivateKey != "" {
+		key, err := os.ReadFile(ep.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		var signer ssh.Signer
+		if ep.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(ep.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if ep.Password != "" {
+		auth = append(auth, ssh.Password(ep.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("endpoint %s has neither a password nor a private key", ep.Name)
+	}
+	return auth, nil
+}
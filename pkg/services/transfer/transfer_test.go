@@ -0,0 +1,151 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newMemSFTP connects an sftpFS to an in-memory SFTP server.
+func newMemSFTP(t *testing.T) *sftpFS {
+	cc, sc := net.Pipe()
+	srv := sftp.NewRequestServer(sc, sftp.InMemHandler())
+	go func() { _ = srv.Serve() }()
+	client, err := sftp.NewClientPipe(cc, cc)
+	if err != nil {
+		t.Fatalf("Failed to start sftp client: %s", err.Error())
+	}
+	c := &sftpFS{client: client}
+	t.Cleanup(func() {
+		_ = c.Close()
+		_ = srv.Close()
+	})
+	return c
+}
+
+func readRemote(t *testing.T, c *sftpFS, p string) []byte {
+	r, err := c.Open(p, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %s", p, err.Error())
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %s", p, err.Error())
+	}
+	return data
+}
+
+func TestSFTPUploadDownload(t *testing.T) {
+	c := newMemSFTP(t)
+	dir := t.TempDir()
+	data := make([]byte, 300*1024+17)
+	rand.New(rand.NewSource(1)).Read(data)
+	local := filepath.Join(dir, "build.bin")
+	if err := os.WriteFile(local, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a previous upload stopped after 100000 bytes
+	if err := c.MkdirAll("/releases"); err != nil {
+		t.Fatal(err)
+	}
+	w, err := c.Create("/releases/build.bin.part", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data[:100000]); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+	res, err := upload(context.Background(), c, local, "/releases/build.bin", true, true)
+	if err != nil {
+		t.Fatalf("Failed to upload: %s", err.Error())
+	}
+	if res.Resumed != 100000 {
+		t.Errorf("Expected upload to resume at 100000, got %d", res.Resumed)
+	}
+	if !bytes.Equal(readRemote(t, c, "/releases/build.bin"), data) {
+		t.Fatalf("Uploaded content differs")
+	}
+	if _, err := c.Stat("/releases/build.bin.part"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Part file was not renamed")
+	}
+	if !strings.HasPrefix(res.Verified, "sha256") {
+		t.Errorf("Expected a sha256 verification, got %s", res.Verified)
+	}
+
+	// a previous download stopped after 70000 bytes
+	dest := filepath.Join(dir, "copy.bin")
+	if err := os.WriteFile(dest+partSuffix, data[:70000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = download(context.Background(), c, "/releases/build.bin", dest, true, true, "")
+	if err != nil {
+		t.Fatalf("Failed to download: %s", err.Error())
+	}
+	if res.Resumed != 70000 {
+		t.Errorf("Expected download to resume at 70000, got %d", res.Resumed)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Downloaded content differs")
+	}
+
+	_, err = download(context.Background(), c, "/releases/build.bin", dest, false, true, strings.Repeat("0", 64))
+	if err == nil {
+		t.Errorf("Expected a checksum mismatch")
+	}
+
+	entries, err := c.List("/releases")
+	if err != nil || len(entries) != 1 || entries[0].Size != int64(len(data)) {
+		t.Errorf("Unexpected listing %v, %v", entries, err)
+	}
+	if _, err := c.Stat("/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
+
+func TestRemotePath(t *testing.T) {
+	ep := EndpointConfig{RootDir: "/srv/ftp"}
+	cases := map[string]string{
+		"builds/app.zip":  "/srv/ftp/builds/app.zip",
+		"/builds/app.zip": "/srv/ftp/builds/app.zip",
+		"":                "/srv/ftp",
+	}
+	for in, expected := range cases {
+		got, err := remotePath(ep, in)
+		if err != nil || got != expected {
+			t.Errorf("remotePath(%q) = %s, %v, expected %s", in, got, err, expected)
+		}
+	}
+	if _, err := remotePath(ep, "../../etc/passwd"); err == nil {
+		t.Errorf("Expected a path outside the root to be rejected")
+	}
+}
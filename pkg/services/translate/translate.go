@@ -0,0 +1,487 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package translate translates text and files with pluggable translation backends for the MoLing application.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	TranslateServerName comm.MoLingServerType = "Translate"
+)
+
+// TranslateServer implements the Service interface and translates text and files.
+type TranslateServer struct {
+	abstract.MLService
+	config  *TranslateConfig
+	backend Backend
+}
+
+// NewTranslateServer creates a new TranslateServer instance.
+func NewTranslateServer(ctx context.Context) (abstract.Service, error) {
+	tc := NewTranslateConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("TranslateServer: invalid config type")
+	}
+	tc.AllowedDirs = []string{filepath.Join(globalConf.BasePath, "data")}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("TranslateServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(TranslateServerName))
+	})
+
+	ts := &TranslateServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    tc,
+	}
+	err := ts.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Init creates the backend and registers the prompt and tools of the translation service.
+func (ts *TranslateServer) Init() error {
+	err := utils.CreateDirectory(ts.config.AllowedDirs[0])
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", ts.config.AllowedDirs[0], err)
+	}
+	ts.backend = NewBackend(ts.config, &http.Client{Timeout: time.Duration(ts.config.Timeout) * time.Second})
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "translate_prompt",
+			Description: "Get the relevant functions and prompts of the Translate MCP Server",
+		},
+		HandlerFunc: ts.handlePrompt,
+	}
+	ts.AddPrompt(pe)
+
+	glossaries := make([]string, 0, len(ts.config.Glossaries))
+	for name := range ts.config.Glossaries {
+		glossaries = append(glossaries, name)
+	}
+	sort.Strings(glossaries)
+	glossaryDesc := "Name of a configured glossary"
+	if len(glossaries) > 0 {
+		glossaryDesc += ": " + strings.Join(glossaries, ", ")
+	}
+
+	ts.AddTool(mcp.NewTool(
+		"translate_text",
+		mcp.WithDescription("Translate a text into another language, detecting the source language unless given"),
+		mcp.WithString("text",
+			mcp.Description("Text to translate"),
+			mcp.Required(),
+		),
+		mcp.WithString("target",
+			mcp.Description("Target language code, e.g. de, fr, zh or pt-BR. Default: "+ts.config.DefaultTarget),
+		),
+		mcp.WithString("source",
+			mcp.Description("Source language code, default: auto-detect"),
+		),
+		mcp.WithString("glossary",
+			mcp.Description(glossaryDesc),
+		),
+		mcp.WithObject("terms",
+			mcp.Description("Additional glossary terms, as source term to required translation"),
+		),
+	), ts.handleTranslateText)
+
+	ts.AddTool(mcp.NewTool(
+		"translate_files",
+		mcp.WithDescription("Translate text files, such as Markdown or plain text, and write the translations next to them, e.g. README.md to README.de.md"),
+		mcp.WithArray("paths",
+			mcp.Description("Files or directories to translate. Directories are searched recursively for text files"),
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Required(),
+		),
+		mcp.WithString("target",
+			mcp.Description("Target language code. Default: "+ts.config.DefaultTarget),
+		),
+		mcp.WithString("source",
+			mcp.Description("Source language code, default: auto-detect"),
+		),
+		mcp.WithString("glossary",
+			mcp.Description(glossaryDesc),
+		),
+		mcp.WithObject("terms",
+			mcp.Description("Additional glossary terms, as source term to required translation"),
+		),
+		mcp.WithString("output_dir",
+			mcp.Description("Directory to write the translations to, default: next to each file"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace existing translations, default: false"),
+		),
+	), ts.handleTranslateFiles)
+	return nil
+}
+
+func (ts *TranslateServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ts.config.prompt
+	if strings.Contains(text, "%s") {
+		text = fmt.Sprintf(text, ts.config.AllowedDirs[0])
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// languages reads the target and source arguments. An empty or "auto" source is detected.
+func (ts *TranslateServer) languages(args map[string]any) (string, string, error) {
+	target := abstract.GetString(args, "target", "")
+	if target == "" {
+		target = ts.config.DefaultTarget
+	}
+	if !validLanguage(target) {
+		return "", "", fmt.Errorf("invalid target language: %q", target)
+	}
+	source := abstract.GetString(args, "source", "")
+	if strings.EqualFold(source, "auto") {
+		source = ""
+	}
+	if source != "" && !validLanguage(source) {
+		return "", "", fmt.Errorf("invalid source language: %q", source)
+	}
+	return target, source, nil
+}
+
+// glossary merges the named glossary with the terms argument, which takes precedence.
+func (ts *TranslateServer) glossary(args map[string]any) (map[string]string, error) {
+	glossary := make(map[string]string)
+	if name := abstract.GetString(args, "glossary", ""); name != "" {
+		named, ok := ts.config.Glossaries[name]
+		if !ok {
+			return nil, fmt.Errorf("glossary %s is not configured", name)
+		}
+		for term, translation := range named {
+			glossary[term] = translation
+		}
+	}
+	if terms := abstract.GetMap(args, "terms"); terms != nil {
+		for term, v := range terms {
+			translation, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("the translation of term %q must be a string", term)
+			}
+			glossary[term] = translation
+		}
+	}
+	return glossary, nil
+}
+
+// translate splits a text into chunks and translates them in requests of at most chunk_size
+// characters. It returns the translation and the detected source language.
+func (ts *TranslateServer) translate(ctx context.Context, text, source, target string, glossary map[string]string) (string, string, error) {
+	chunks := splitChunks(text, ts.config.ChunkSize)
+	translated := make([]string, len(chunks))
+	detected := ""
+	var batch []int
+	size := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		texts := make([]string, len(batch))
+		for i, idx := range batch {
+			texts[i] = chunks[idx].text
+		}
+		result, err := ts.backend.Translate(ctx, texts, source, target, glossary)
+		if err != nil {
+			return err
+		}
+		for i, idx := range batch {
+			translated[idx] = result[i].Text
+			if detected == "" {
+				detected = result[i].DetectedSource
+			}
+		}
+		batch, size = batch[:0], 0
+		return nil
+	}
+	for i, c := range chunks {
+		if c.text == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(c.text)
+		if size+n > ts.config.ChunkSize {
+			if err := flush(); err != nil {
+				return "", "", err
+			}
+		}
+		batch = append(batch, i)
+		size += n
+	}
+	if err := flush(); err != nil {
+		return "", "", err
+	}
+	var b strings.Builder
+	for i, c := range chunks {
+		b.WriteString(c.lead)
+		b.WriteString(translated[i])
+		b.WriteString(c.trail)
+	}
+	return b.String(), detected, nil
+}
+
+// handleTranslateText translates a single text.
+func (ts *TranslateServer) handleTranslateText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	text := abstract.GetString(args, "text", "")
+	if strings.TrimSpace(text) == "" {
+		return mcp.NewToolResultError("text must be a non-empty string"), nil
+	}
+	if n := utf8.RuneCountInString(text); n > ts.config.MaxTextSize {
+		return mcp.NewToolResultError(fmt.Sprintf("text has %d characters, the limit is %d, use translate_files for long documents", n, ts.config.MaxTextSize)), nil
+	}
+	target, source, err := ts.languages(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	glossary, err := ts.glossary(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	translated, detected, err := ts.translate(ctx, text, source, target, glossary)
+	if err != nil {
+		ts.Logger.Error().Err(err).Str("backend", ts.backend.Name()).Msg("translation failed")
+		return mcp.NewToolResultError(fmt.Sprintf("translation failed: %s", err.Error())), nil
+	}
+	result := map[string]string{"translation": translated, "target": target}
+	if source != "" {
+		result["source"] = source
+	} else if detected != "" {
+		result["detected_source_language"] = detected
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// collectFiles expands the paths into the text files to translate. Hidden entries, symbolic
+// links and existing translations into the target language are skipped inside directories.
+func (ts *TranslateServer) collectFiles(paths []any, target string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) error {
+		if !seen[path] {
+			if len(files) == ts.config.MaxFiles {
+				return fmt.Errorf("more than %d files to translate", ts.config.MaxFiles)
+			}
+			seen[path] = true
+			files = append(files, path)
+		}
+		return nil
+	}
+	for _, p := range paths {
+		arg, _ := p.(string)
+		path, err := utils.ResolvePath(arg, ts.config.AllowedDirs)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access %s: %w", arg, err)
+		}
+		if !info.IsDir() {
+			if err := add(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || isTranslation(p, target) || !utils.IsTextFile(utils.DetectMimeType(p)) {
+				return nil
+			}
+			return add(p)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no text files to translate")
+	}
+	return files, nil
+}
+
+// translationPath inserts the language code before the extension: README.md -> README.de.md.
+func translationPath(path, target, outputDir string) string {
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext) + "." + target + ext
+	if outputDir != "" {
+		return filepath.Join(outputDir, name)
+	}
+	return filepath.Join(filepath.Dir(path), name)
+}
+
+func isTranslation(path, target string) bool {
+	ext := filepath.Ext(path)
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(path, ext)), "."+strings.ToLower(target))
+}
+
+// translateFile translates one file and returns the path of the translation.
+func (ts *TranslateServer) translateFile(ctx context.Context, path, source, target, outputDir string, glossary map[string]string, overwrite bool) (string, error) {
+	out := translationPath(path, target, outputDir)
+	if _, err := os.Stat(out); err == nil && !overwrite {
+		return out, fmt.Errorf("%s already exists, set overwrite to replace it", out)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return out, err
+	}
+	if info.Size() > ts.config.MaxFileSize {
+		return out, fmt.Errorf("file size %d exceeds the limit of %d bytes", info.Size(), ts.config.MaxFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return out, err
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return out, fmt.Errorf("not a UTF-8 text file")
+	}
+	translated, _, err := ts.translate(ctx, string(data), source, target, glossary)
+	if err != nil {
+		return out, err
+	}
+	return out, os.WriteFile(out, []byte(translated), 0o644)
+}
+
+// handleTranslateFiles translates files one by one and reports the result of each.
+func (ts *TranslateServer) handleTranslateFiles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	paths, _ := args["paths"].([]any)
+	if len(paths) == 0 {
+		return mcp.NewToolResultError("paths must be a non-empty array"), nil
+	}
+	target, source, err := ts.languages(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	glossary, err := ts.glossary(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	outputDir := ""
+	if dir := abstract.GetString(args, "output_dir", ""); dir != "" {
+		outputDir, err = utils.ResolvePath(dir, ts.config.AllowedDirs)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		err = utils.CreateDirectory(outputDir)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create directory %s: %s", dir, err.Error())), nil
+		}
+	}
+	overwrite := abstract.GetBool(args, "overwrite", false)
+	files, err := ts.collectFiles(paths, target)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var result strings.Builder
+	failed := 0
+	for _, file := range files {
+		out, err := ts.translateFile(ctx, file, source, target, outputDir, glossary, overwrite)
+		if err != nil {
+			failed++
+			ts.Logger.Warn().Err(err).Str("file", file).Msg("failed to translate file")
+			result.WriteString(fmt.Sprintf("- %s: failed: %s\n", file, err.Error()))
+			continue
+		}
+		result.WriteString(fmt.Sprintf("- %s -> %s\n", file, out))
+	}
+	summary := fmt.Sprintf("Translated %d of %d files into %s:\n\n", len(files)-failed, len(files), target)
+	if failed == len(files) {
+		return mcp.NewToolResultError(summary + result.String()), nil
+	}
+	return mcp.NewToolResultText(summary + result.String()), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ts *TranslateServer) Config() string {
+	cfg, err := json.Marshal(ts.config)
+	if err != nil {
+		ts.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ts *TranslateServer) Name() comm.MoLingServerType {
+	return TranslateServerName
+}
+
+func (ts *TranslateServer) Close() error {
+	ts.Logger.Debug().Msg("TranslateServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ts *TranslateServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ts.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ts.config.Check()
+}
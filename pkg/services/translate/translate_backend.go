@@ -0,0 +1,266 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxResponseSize caps the size of a backend response.
+const maxResponseSize = 16 * 1024 * 1024
+
+// Translation is the translation of one text.
+type Translation struct {
+	Text           string `json:"text"`
+	DetectedSource string `json:"detected_source_language,omitempty"`
+}
+
+// Backend translates texts.
+type Backend interface {
+	Name() string
+	// Translate translates texts from source to target. An empty source detects the language.
+	// Glossary maps source terms to the translations the result must use.
+	Translate(ctx context.Context, texts []string, source, target string, glossary map[string]string) ([]Translation, error)
+}
+
+// NewBackend creates the backend selected by the configuration.
+func NewBackend(cfg *TranslateConfig, client *http.Client) Backend {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	switch cfg.Backend {
+	case BackendDeepL:
+		if endpoint == "" {
+			endpoint = "https://api.deepl.com"
+			if strings.HasSuffix(cfg.APIKey, ":fx") {
+				endpoint = "https://api-free.deepl.com"
+			}
+		}
+		return &deepL{client: client, endpoint: endpoint, apiKey: cfg.APIKey}
+	case BackendOpenAI:
+		if endpoint == "" {
+			endpoint = "https://api.openai.com"
+		}
+		return &openAI{client: client, endpoint: endpoint, apiKey: cfg.APIKey, model: cfg.Model}
+	default:
+		if endpoint == "" {
+			endpoint = "http://127.0.0.1:5000"
+		}
+		return &libreTranslate{client: client, endpoint: endpoint, apiKey: cfg.APIKey}
+	}
+}
+
+var languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,4})?$`)
+
+// validLanguage reports whether code looks like a language code such as en, pt-BR or zh-Hans.
+func validLanguage(code string) bool {
+	return languagePattern.MatchString(code)
+}
+
+// postJSON sends a JSON request and decodes the JSON response. Errors do not include the
+// response of a failed request beyond a short excerpt.
+func postJSON(ctx context.Context, client *http.Client, rawURL string, header http.Header, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if len(data) > maxResponseSize {
+		return fmt.Errorf("response from %s exceeds %d bytes", req.URL.Host, maxResponseSize)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error   json.RawMessage `json:"error"`
+			Message string          `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		msg := apiErr.Message
+		if len(apiErr.Error) > 0 {
+			var nested struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(apiErr.Error, &msg) != nil && json.Unmarshal(apiErr.Error, &nested) == nil {
+				msg = nested.Message
+			}
+		}
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+			if len(msg) > 200 {
+				msg = msg[:200]
+			}
+		}
+		return fmt.Errorf("HTTP %s from %s: %s", resp.Status, req.URL.Host, msg)
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+var termTagPattern = regexp.MustCompile(`<x\s+i\s*=\s*"(\d+)"\s*/>`)
+
+// glossaryPattern matches the glossary terms, longest first and case-insensitive. Terms that
+// start or end with a letter or digit only match whole words.
+func glossaryPattern(glossary map[string]string) (*regexp.Regexp, map[string]string) {
+	if len(glossary) == 0 {
+		return nil, nil
+	}
+	terms := make([]string, 0, len(glossary))
+	targets := make(map[string]string, len(glossary))
+	for term, target := range glossary {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		terms = append(terms, term)
+		targets[strings.ToLower(term)] = target
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		p := regexp.QuoteMeta(term)
+		if r, _ := utf8.DecodeRuneInString(term); isWordRune(r) {
+			p = `\b` + p
+		}
+		if r, _ := utf8.DecodeLastRuneInString(term); isWordRune(r) {
+			p += `\b`
+		}
+		parts[i] = p
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(parts, "|")), targets
+}
+
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// protectTerms prepares a text for a markup-aware machine translation: the text is escaped and
+// every glossary term is replaced by a numbered empty tag, which the backend keeps in place.
+// It returns the marked-up text and the translations of the replaced terms in tag order.
+func protectTerms(text string, pattern *regexp.Regexp, targets map[string]string) (string, []string) {
+	var b strings.Builder
+	var replaced []string
+	last := 0
+	for _, m := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		b.WriteString(`<x i="` + strconv.Itoa(len(replaced)) + `"/>`)
+		replaced = append(replaced, targets[strings.ToLower(text[m[0]:m[1]])])
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String(), replaced
+}
+
+// restoreTerms undoes protectTerms on a translated text.
+func restoreTerms(text string, replaced []string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range termTagPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.UnescapeString(text[last:m[0]]))
+		i, _ := strconv.Atoi(text[m[2]:m[3]])
+		if i < len(replaced) {
+			b.WriteString(replaced[i])
+		}
+		last = m[1]
+	}
+	b.WriteString(html.UnescapeString(text[last:]))
+	return b.String()
+}
+
+// chunk is a piece of a longer text. Only text is translated, the surrounding whitespace is
+// kept as it is.
+type chunk struct {
+	lead, text, trail string
+}
+
+// splitChunks splits a text into chunks of at most size characters, preferring paragraph
+// breaks, then line breaks, then spaces.
+func splitChunks(text string, size int) []chunk {
+	var chunks []chunk
+	for text != "" {
+		piece := text
+		if utf8.RuneCountInString(text) > size {
+			cut := len(text)
+			n := 0
+			for i := range text {
+				if n == size {
+					cut = i
+					break
+				}
+				n++
+			}
+			window := text[:cut]
+			end := cut
+			for _, sep := range []string{"\n\n", "\n", " "} {
+				// A break early in the window would make a needlessly short chunk.
+				if i := strings.LastIndex(window, sep); i > 0 && i >= len(window)/2 {
+					end = i + len(sep)
+					break
+				}
+			}
+			piece = text[:end]
+		}
+		text = text[len(piece):]
+		trimmed := strings.TrimLeftFunc(piece, unicode.IsSpace)
+		lead := piece[:len(piece)-len(trimmed)]
+		core := strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		chunks = append(chunks, chunk{lead: lead, text: core, trail: trimmed[len(core):]})
+	}
+	return chunks
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const TranslatePromptDefault = `
+You are an assistant that can translate text and files. Your capabilities include:
+
+1. **Translate text**: Use translate_text with the text and a target language code such as "de", "fr" or "zh". Leave source empty to detect the source language.
+
+2. **Glossaries**: Pass a configured glossary by name, or terms as an object mapping source terms to their required translations, to keep product names and terminology consistent.
+
+3. **Translate files**: Use translate_files with files or directories to translate text files such as Markdown or plain text. Each file is written next to the original with the language code before the extension, e.g. README.de.md, or into output_dir.
+
+Relative paths are resolved in %s. Formatting, Markdown and code blocks are kept where the backend allows it.
+`
+
+// Translation backends.
+const (
+	BackendLibreTranslate = "libretranslate"
+	BackendDeepL          = "deepl"
+	BackendOpenAI         = "openai"
+)
+
+// TranslateConfig represents the configuration for the translation service.
+type TranslateConfig struct {
+	PromptFile    string `json:"prompt_file"` // PromptFile is the prompt file for the translation service.
+	prompt        string
+	Backend       string                       `json:"backend"`        // Backend is libretranslate, deepl or openai, which covers any OpenAI-compatible API.
+	Endpoint      string                       `json:"endpoint"`       // Endpoint is the base URL of the backend, empty uses the default of the backend.
+	APIKey        string                       `json:"api_key"`        // APIKey is the API key of the backend, if it needs one.
+	Model         string                       `json:"model"`          // Model is the chat model of the openai backend.
+	DefaultTarget string                       `json:"default_target"` // DefaultTarget is the target language when none is given.
+	Glossaries    map[string]map[string]string `json:"glossaries"`     // Glossaries are named term lists, mapping a source term to its translation.
+	AllowedDirs   []string                     `json:"allowed_dirs"`   // AllowedDirs are the directories files may be read from and written to, relative paths use the first one.
+	ChunkSize     int                          `json:"chunk_size"`     // ChunkSize is the maximum number of characters sent per request.
+	MaxTextSize   int                          `json:"max_text_size"`  // MaxTextSize is the maximum number of characters of translate_text.
+	MaxFileSize   int64                        `json:"max_file_size"`  // MaxFileSize is the maximum size of a file to translate, in bytes.
+	MaxFiles      int                          `json:"max_files"`      // MaxFiles is the maximum number of files per translate_files call.
+	Timeout       int                          `json:"timeout"`        // Timeout is the timeout of a single backend request. time.Second
+}
+
+// NewTranslateConfig creates a new TranslateConfig with default values.
+func NewTranslateConfig() *TranslateConfig {
+	return &TranslateConfig{
+		prompt:        TranslatePromptDefault,
+		Backend:       BackendLibreTranslate,
+		Model:         "gpt-4o-mini",
+		DefaultTarget: "en",
+		Glossaries:    map[string]map[string]string{},
+		AllowedDirs:   []string{filepath.Join(os.TempDir(), ".moling", "data")},
+		ChunkSize:     4000,
+		MaxTextSize:   100000,
+		MaxFileSize:   1024 * 1024,
+		MaxFiles:      50,
+		Timeout:       60,
+	}
+}
+
+// Check validates the translation configuration.
+func (cfg *TranslateConfig) Check() error {
+	cfg.prompt = TranslatePromptDefault
+	switch cfg.Backend {
+	case BackendLibreTranslate, BackendOpenAI:
+	case BackendDeepL:
+		if cfg.APIKey == "" {
+			return fmt.Errorf("api_key must be set for the %s backend", cfg.Backend)
+		}
+	default:
+		return fmt.Errorf("backend must be one of %s, %s, %s", BackendLibreTranslate, BackendDeepL, BackendOpenAI)
+	}
+	if cfg.Backend == BackendOpenAI && cfg.Model == "" {
+		return fmt.Errorf("model must be set for the %s backend", cfg.Backend)
+	}
+	if !validLanguage(cfg.DefaultTarget) {
+		return fmt.Errorf("invalid default_target language: %q", cfg.DefaultTarget)
+	}
+	if err := utils.CheckAllowedDirs(cfg.AllowedDirs); err != nil {
+		return err
+	}
+	if cfg.ChunkSize <= 0 || cfg.MaxTextSize <= 0 || cfg.MaxFileSize <= 0 || cfg.MaxFiles <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("chunk_size, max_text_size, max_file_size, max_files and timeout must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// deepL calls the DeepL v2 translate API.
+type deepL struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+func (b *deepL) Name() string {
+	return BackendDeepL
+}
+
+// Translate sends all texts in one request. Glossary terms are protected as XML tags, so
+// ad-hoc glossaries do not have to be created on the DeepL account first.
+func (b *deepL) Translate(ctx context.Context, texts []string, source, target string, glossary map[string]string) ([]Translation, error) {
+	body := map[string]any{
+		"text":        texts,
+		"target_lang": strings.ToUpper(target),
+	}
+	if source != "" {
+		// DeepL only accepts the base language as source, e.g. EN rather than EN-US.
+		base, _, _ := strings.Cut(source, "-")
+		body["source_lang"] = strings.ToUpper(base)
+	}
+	pattern, targets := glossaryPattern(glossary)
+	var replaced [][]string
+	if pattern != nil {
+		marked := make([]string, len(texts))
+		replaced = make([][]string, len(texts))
+		for i, text := range texts {
+			marked[i], replaced[i] = protectTerms(text, pattern, targets)
+		}
+		body["text"] = marked
+		body["tag_handling"] = "xml"
+	}
+	var resp struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	}
+	header := http.Header{"Authorization": {"DeepL-Auth-Key " + b.apiKey}}
+	err := postJSON(ctx, b.client, b.endpoint+"/v2/translate", header, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Translations) != len(texts) {
+		return nil, fmt.Errorf("backend returned %d translations for %d texts", len(resp.Translations), len(texts))
+	}
+	result := make([]Translation, len(texts))
+	for i, t := range resp.Translations {
+		text := t.Text
+		if pattern != nil {
+			text = restoreTerms(text, replaced[i])
+		}
+		result[i].Text = text
+		if source == "" {
+			result[i].DetectedSource = strings.ToLower(t.DetectedSourceLanguage)
+		}
+	}
+	return result, nil
+}
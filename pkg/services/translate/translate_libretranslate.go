@@ -0,0 +1,86 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// libreTranslate calls the /translate endpoint of a LibreTranslate server.
+type libreTranslate struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+func (b *libreTranslate) Name() string {
+	return BackendLibreTranslate
+}
+
+// Translate sends all texts in one request. Glossary terms are protected as HTML tags.
+func (b *libreTranslate) Translate(ctx context.Context, texts []string, source, target string, glossary map[string]string) ([]Translation, error) {
+	body := map[string]any{
+		"q":      texts,
+		"source": "auto",
+		"target": strings.ToLower(target),
+		"format": "text",
+	}
+	if source != "" {
+		body["source"] = strings.ToLower(source)
+	}
+	if b.apiKey != "" {
+		body["api_key"] = b.apiKey
+	}
+	pattern, targets := glossaryPattern(glossary)
+	var replaced [][]string
+	if pattern != nil {
+		marked := make([]string, len(texts))
+		replaced = make([][]string, len(texts))
+		for i, text := range texts {
+			marked[i], replaced[i] = protectTerms(text, pattern, targets)
+		}
+		body["q"] = marked
+		body["format"] = "html"
+	}
+	var resp struct {
+		TranslatedText   []string `json:"translatedText"`
+		DetectedLanguage []struct {
+			Language string `json:"language"`
+		} `json:"detectedLanguage"`
+	}
+	err := postJSON(ctx, b.client, b.endpoint+"/translate", nil, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("backend returned %d translations for %d texts", len(resp.TranslatedText), len(texts))
+	}
+	result := make([]Translation, len(texts))
+	for i, text := range resp.TranslatedText {
+		if pattern != nil {
+			text = restoreTerms(text, replaced[i])
+		}
+		result[i].Text = text
+		if source == "" && i < len(resp.DetectedLanguage) {
+			result[i].DetectedSource = resp.DetectedLanguage[i].Language
+		}
+	}
+	return result, nil
+}
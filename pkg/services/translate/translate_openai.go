@@ -0,0 +1,124 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// openAI translates with the chat completions API of OpenAI or a compatible server.
+type openAI struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+	model    string
+}
+
+func (b *openAI) Name() string {
+	return BackendOpenAI
+}
+
+// instructions builds the system message. The glossary is given to the model as a term list.
+func (b *openAI) instructions(source, target string, glossary map[string]string) string {
+	var sb strings.Builder
+	from := "the language they are written in"
+	if source != "" {
+		from = fmt.Sprintf("the language with the code %q", source)
+	}
+	sb.WriteString(fmt.Sprintf("You are a translation engine. Translate each string of the JSON array \"texts\" from %s to the language with the code %q. ", from, target))
+	sb.WriteString("Keep the meaning, tone and formatting: line breaks, Markdown, HTML tags, placeholders, URLs and code stay unchanged. Do not add explanations.\n")
+	if len(glossary) > 0 {
+		terms := make([]string, 0, len(glossary))
+		for term := range glossary {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		sb.WriteString("Always translate these terms exactly as given:\n")
+		for _, term := range terms {
+			sb.WriteString(fmt.Sprintf("- %s => %s\n", term, glossary[term]))
+		}
+	}
+	sb.WriteString(`Reply with a JSON object {"translations": [...], "detected_source_language": "..."} where translations has one string per input text, in the same order, and detected_source_language is the ISO 639-1 code of the source language.`)
+	return sb.String()
+}
+
+func (b *openAI) Translate(ctx context.Context, texts []string, source, target string, glossary map[string]string) ([]Translation, error) {
+	input, err := json.Marshal(map[string]any{"texts": texts})
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]any{
+		"model":           b.model,
+		"temperature":     0,
+		"response_format": map[string]any{"type": "json_object"},
+		"messages": []map[string]string{
+			{"role": "system", "content": b.instructions(source, target, glossary)},
+			{"role": "user", "content": string(input)},
+		},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	url := b.endpoint + "/v1/chat/completions"
+	if strings.HasSuffix(b.endpoint, "/v1") {
+		url = b.endpoint + "/chat/completions"
+	}
+	var header http.Header
+	if b.apiKey != "" {
+		header = http.Header{"Authorization": {"Bearer " + b.apiKey}}
+	}
+	err = postJSON(ctx, b.client, url, header, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("backend returned no choices")
+	}
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	// Some compatible servers ignore response_format and wrap the JSON in a code fence.
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	var out struct {
+		Translations           []string `json:"translations"`
+		DetectedSourceLanguage string   `json:"detected_source_language"`
+	}
+	err = json.Unmarshal([]byte(content), &out)
+	if err != nil {
+		return nil, fmt.Errorf("model did not reply with the expected JSON: %w", err)
+	}
+	if len(out.Translations) != len(texts) {
+		return nil, fmt.Errorf("model returned %d translations for %d texts", len(out.Translations), len(texts))
+	}
+	result := make([]Translation, len(texts))
+	for i, text := range out.Translations {
+		result[i].Text = text
+		if source == "" {
+			result[i].DetectedSource = strings.ToLower(out.DetectedSourceLanguage)
+		}
+	}
+	return result, nil
+}
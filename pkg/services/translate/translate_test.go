@@ -0,0 +1,244 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package translate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+// fakeLibreTranslate prefixes every text with the target language.
+func fakeLibreTranslate(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Q      []string `json:"q"`
+			Source string   `json:"source"`
+			Target string   `json:"target"`
+			Format string   `json:"format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/translate" {
+			t.Errorf("unexpected request %s: %v", r.URL.Path, err)
+		}
+		resp := map[string]any{}
+		var out []string
+		var detected []map[string]any
+		for _, q := range req.Q {
+			if req.Format == "html" && strings.Contains(q, "&") && !strings.Contains(q, "&amp;") {
+				t.Errorf("text is not escaped: %s", q)
+			}
+			out = append(out, strings.ToUpper(req.Target)+": "+q)
+			detected = append(detected, map[string]any{"language": "en", "confidence": 90})
+		}
+		resp["translatedText"] = out
+		if req.Source == "auto" {
+			resp["detectedLanguage"] = detected
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestTranslateTextLibreTranslate(t *testing.T) {
+	ts := fakeLibreTranslate(t)
+	defer ts.Close()
+	srv := testkit.NewServiceAs[*TranslateServer](t, NewTranslateServer, map[string]any{
+		"endpoint":   ts.URL,
+		"glossaries": map[string]any{"product": map[string]any{"MoLing": "MoLing-Pro"}},
+	})
+
+	text, isErr := testkit.CallHandler(t, srv.handleTranslateText, map[string]any{
+		"text":     "Use moling & Go tools",
+		"target":   "de",
+		"glossary": "product",
+		"terms":    map[string]any{"Go": "Golang"},
+	})
+	if isErr {
+		t.Fatalf("translate_text failed: %s", text)
+	}
+	var result map[string]string
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("Failed to parse result: %s", err.Error())
+	}
+	if result["translation"] != "DE: Use MoLing-Pro & Golang tools" || result["detected_source_language"] != "en" {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	// Terms only match whole words.
+	text, _ = testkit.CallHandler(t, srv.handleTranslateText, map[string]any{"text": "Going", "source": "en", "terms": map[string]any{"Go": "Golang"}})
+	if !strings.Contains(text, "EN: Going") || strings.Contains(text, "detected") {
+		t.Errorf("unexpected result: %s", text)
+	}
+
+	for _, args := range []map[string]any{
+		{"text": ""},
+		{"text": "hi", "target": "not a language"},
+		{"text": "hi", "glossary": "missing"},
+		{"text": "hi", "terms": map[string]any{"a": 1}},
+	} {
+		if text, isErr := testkit.CallHandler(t, srv.handleTranslateText, args); !isErr {
+			t.Errorf("expected error for %v, got %s", args, text)
+		}
+	}
+}
+
+func TestTranslateDeepLAndOpenAI(t *testing.T) {
+	deepl := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "DeepL-Auth-Key key:fx" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Wrong key"}`))
+			return
+		}
+		var req struct {
+			Text        []string `json:"text"`
+			TargetLang  string   `json:"target_lang"`
+			TagHandling string   `json:"tag_handling"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.TargetLang != "FR" || req.TagHandling != "xml" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		_, _ = w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Bonjour <x i=\"0\"/>"}]}`))
+	}))
+	defer deepl.Close()
+	srv := testkit.NewServiceAs[*TranslateServer](t, NewTranslateServer, map[string]any{"backend": "deepl", "api_key": "key:fx", "endpoint": deepl.URL})
+	text, isErr := testkit.CallHandler(t, srv.handleTranslateText, map[string]any{"text": "Hello World", "target": "fr", "terms": map[string]any{"world": "Monde"}})
+	if isErr || !strings.Contains(text, `"translation": "Bonjour Monde"`) || !strings.Contains(text, `"detected_source_language": "en"`) {
+		t.Errorf("unexpected deepl result: %s", text)
+	}
+
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/chat/completions" || req.Model != "test-model" || !strings.Contains(req.Messages[0].Content, "- world => Monde") {
+			t.Errorf("unexpected request %s %+v", r.URL.Path, req)
+		}
+		content := "```json\n{\"translations\":[\"Bonjour Monde\"],\"detected_source_language\":\"EN\"}\n```"
+		_ = json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": map[string]any{"content": content}}}})
+	}))
+	defer openai.Close()
+	srv = testkit.NewServiceAs[*TranslateServer](t, NewTranslateServer, map[string]any{"backend": "openai", "model": "test-model", "endpoint": openai.URL + "/v1"})
+	text, isErr = testkit.CallHandler(t, srv.handleTranslateText, map[string]any{"text": "Hello world", "target": "fr", "terms": map[string]any{"world": "Monde"}})
+	if isErr || !strings.Contains(text, `"translation": "Bonjour Monde"`) || !strings.Contains(text, `"detected_source_language": "en"`) {
+		t.Errorf("unexpected openai result: %s", text)
+	}
+}
+
+func TestTranslateFiles(t *testing.T) {
+	ts := fakeLibreTranslate(t)
+	defer ts.Close()
+	dir := t.TempDir()
+	srv := testkit.NewServiceAs[*TranslateServer](t, NewTranslateServer, map[string]any{"endpoint": ts.URL, "allowed_dirs": []any{dir}})
+
+	docs := filepath.Join(dir, "docs")
+	for name, content := range map[string]string{
+		"README.md":       "# Title\n\nFirst paragraph.\n",
+		"notes.txt":       "Some notes",
+		".hidden.md":      "hidden",
+		"sub/guide.md":    "Guide",
+		"image.png":       "\x89PNG\r\n\x1a\n\x00\x00",
+		"README.other.md": "Other",
+	} {
+		path := filepath.Join(docs, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %s", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %s", err.Error())
+		}
+	}
+
+	text, isErr := testkit.CallHandler(t, srv.handleTranslateFiles, map[string]any{"paths": []any{"docs"}, "target": "de"})
+	if isErr || !strings.Contains(text, "Translated 4 of 4 files") {
+		t.Fatalf("unexpected result: %s", text)
+	}
+	data, err := os.ReadFile(filepath.Join(docs, "README.de.md"))
+	if err != nil {
+		t.Fatalf("Failed to read translation: %s", err.Error())
+	}
+	if string(data) != "DE: # Title\n\nFirst paragraph.\n" {
+		t.Errorf("unexpected translation: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(docs, ".hidden.de.md")); err == nil {
+		t.Errorf("hidden file was translated")
+	}
+
+	// Existing translations are skipped when walking and only replaced with overwrite.
+	text, isErr = testkit.CallHandler(t, srv.handleTranslateFiles, map[string]any{"paths": []any{"docs/README.md"}, "target": "de"})
+	if !isErr || !strings.Contains(text, "already exists") {
+		t.Errorf("expected an existing file error, got %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, srv.handleTranslateFiles, map[string]any{"paths": []any{"docs"}, "target": "de", "overwrite": true, "output_dir": "out"})
+	if isErr || !strings.Contains(text, "Translated 4 of 4 files") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "guide.de.md")); err != nil {
+		t.Errorf("translation not written to output_dir: %s", err.Error())
+	}
+
+	text, isErr = testkit.CallHandler(t, srv.handleTranslateFiles, map[string]any{"paths": []any{"/etc/hosts"}})
+	if !isErr || !strings.Contains(text, "access denied") {
+		t.Errorf("expected access denied, got %s", text)
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	text := strings.Repeat("Lorem ipsum dolor sit amet.\n", 40) + "\n\n" + strings.Repeat("中文句子。", 300) + "\n"
+	chunks := splitChunks(text, 200)
+	var b strings.Builder
+	for _, c := range chunks {
+		if n := utf8.RuneCountInString(c.text); n > 200 {
+			t.Errorf("chunk has %d characters", n)
+		}
+		if strings.TrimSpace(c.text) != c.text {
+			t.Errorf("chunk text is not trimmed: %q", c.text)
+		}
+		b.WriteString(c.lead + c.text + c.trail)
+	}
+	if b.String() != text {
+		t.Errorf("chunks do not add up to the text")
+	}
+}
+
+func TestTranslateConfigCheck(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		cfg  func(*TranslateConfig)
+	}{
+		{"backend", func(c *TranslateConfig) { c.Backend = "nope" }},
+		{"deepl key", func(c *TranslateConfig) { c.Backend = BackendDeepL }},
+		{"target", func(c *TranslateConfig) { c.DefaultTarget = "english" }},
+		{"chunk size", func(c *TranslateConfig) { c.ChunkSize = 0 }},
+	} {
+		cfg := NewTranslateConfig()
+		c.cfg(cfg)
+		if err := cfg.Check(); err == nil {
+			t.Errorf("%s: expected check to fail", c.name)
+		}
+	}
+}
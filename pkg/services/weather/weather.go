@@ -0,0 +1,359 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package weather provides current conditions and forecasts from pluggable weather providers for the MoLing application.
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	WeatherServerName comm.MoLingServerType = "Weather"
+)
+
+// placeCacheTTL is how long geocoding results are cached, places rarely move.
+const placeCacheTTL = 24 * time.Hour
+
+// WeatherServer implements the Service interface and answers weather queries from a weather provider.
+type WeatherServer struct {
+	abstract.MLService
+	config   *WeatherConfig
+	provider Provider
+	cache    *resultCache
+}
+
+// NewWeatherServer creates a new WeatherServer instance.
+func NewWeatherServer(ctx context.Context) (abstract.Service, error) {
+	wc := NewWeatherConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("WeatherServer: invalid config type")
+	}
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("WeatherServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(WeatherServerName))
+	})
+
+	ws := &WeatherServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    wc,
+		cache:     newResultCache(),
+	}
+	err := ws.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// Init creates the provider and registers the prompt and tools of the weather service.
+func (ws *WeatherServer) Init() error {
+	client := &http.Client{Timeout: time.Duration(ws.config.Timeout) * time.Second}
+	ws.provider = providerFactories[ws.config.Provider](ws.config, client)
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "weather_prompt",
+			Description: "Get the relevant functions and prompts of the Weather MCP Server",
+		},
+		HandlerFunc: ws.handlePrompt,
+	}
+	ws.AddPrompt(pe)
+
+	ws.AddTool(mcp.NewTool(
+		"weather_current",
+		mcp.WithDescription("Get the current weather conditions for a place or coordinates"),
+		mcp.WithString("place",
+			mcp.Description("Place name, optionally with region or country, e.g. \"Springfield, Illinois\". Ignored when coordinates are given"),
+		),
+		mcp.WithNumber("latitude",
+			mcp.Description("Latitude in decimal degrees"),
+		),
+		mcp.WithNumber("longitude",
+			mcp.Description("Longitude in decimal degrees"),
+		),
+		mcp.WithString("units",
+			mcp.Description("metric or imperial. Default: "+ws.config.Units),
+		),
+	), ws.handleCurrent)
+
+	ws.AddTool(mcp.NewTool(
+		"weather_forecast",
+		mcp.WithDescription("Get the daily weather forecast for a place or coordinates, together with the current conditions"),
+		mcp.WithString("place",
+			mcp.Description("Place name, optionally with region or country. Ignored when coordinates are given"),
+		),
+		mcp.WithNumber("latitude",
+			mcp.Description("Latitude in decimal degrees"),
+		),
+		mcp.WithNumber("longitude",
+			mcp.Description("Longitude in decimal degrees"),
+		),
+		mcp.WithNumber("days",
+			mcp.Description(fmt.Sprintf("Number of days including today, 1-%d. Default: 3", ws.maxDays())),
+		),
+		mcp.WithString("units",
+			mcp.Description("metric or imperial. Default: "+ws.config.Units),
+		),
+	), ws.handleForecast)
+
+	ws.AddTool(mcp.NewTool(
+		"weather_find_place",
+		mcp.WithDescription("Search places by name to disambiguate them, returning region, country and coordinates"),
+		mcp.WithString("name",
+			mcp.Description("Place name, optionally with region or country"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("Maximum number of places, default: 5"),
+		),
+	), ws.handleFindPlace)
+	return nil
+}
+
+func (ws *WeatherServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	text := ws.config.prompt
+	if strings.Contains(text, "%d") {
+		text = fmt.Sprintf(text, ws.maxDays())
+	}
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		},
+	}, nil
+}
+
+// maxDays is the longest forecast of the configured provider and limit.
+func (ws *WeatherServer) maxDays() int {
+	if ws.provider == nil {
+		return ws.config.MaxForecastDays
+	}
+	return min(ws.config.MaxForecastDays, ws.provider.MaxForecastDays())
+}
+
+// findPlaces searches places by name. A qualifier after the first comma, such as a region or
+// country, filters the results.
+func (ws *WeatherServer) findPlaces(ctx context.Context, name string, count int) ([]Place, error) {
+	name = strings.TrimSpace(name)
+	base, qualifier, _ := strings.Cut(name, ",")
+	base, qualifier = strings.TrimSpace(base), strings.ToLower(strings.TrimSpace(qualifier))
+	if base == "" {
+		return nil, fmt.Errorf("place must be a non-empty string")
+	}
+	key := fmt.Sprintf("place|%s|%s|%s", ws.provider.Name(), ws.config.Language, strings.ToLower(base))
+	var places []Place
+	if cached, ok := ws.cache.get(key); ok {
+		places = cached.([]Place)
+	} else {
+		var err error
+		places, err = ws.provider.SearchPlaces(ctx, base, 20)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search place %s: %w", base, err)
+		}
+		ws.cache.put(key, places, placeCacheTTL)
+	}
+	var result []Place
+	for _, p := range places {
+		if qualifier != "" && !strings.Contains(strings.ToLower(p.Admin), qualifier) &&
+			!strings.Contains(strings.ToLower(p.Country), qualifier) && !strings.EqualFold(p.CountryCode, qualifier) {
+			continue
+		}
+		result = append(result, p)
+		if len(result) == count {
+			break
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no place found for %s", name)
+	}
+	return result, nil
+}
+
+// location reads coordinates, or geocodes the place argument.
+func (ws *WeatherServer) location(ctx context.Context, args map[string]any) (float64, float64, *Place, error) {
+	if abstract.Has(args, "latitude") && abstract.Has(args, "longitude") {
+		lat, lon := abstract.GetFloat(args, "latitude", 0), abstract.GetFloat(args, "longitude", 0)
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return 0, 0, nil, fmt.Errorf("latitude must be within -90..90 and longitude within -180..180")
+		}
+		return lat, lon, nil, nil
+	}
+	name := abstract.GetString(args, "place", "")
+	if strings.TrimSpace(name) == "" {
+		return 0, 0, nil, fmt.Errorf("either place or latitude and longitude must be specified")
+	}
+	places, err := ws.findPlaces(ctx, name, 1)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return places[0].Latitude, places[0].Longitude, &places[0], nil
+}
+
+func (ws *WeatherServer) units(args map[string]any) (string, error) {
+	units := abstract.GetString(args, "units", "")
+	if units == "" {
+		return ws.config.Units, nil
+	}
+	units = strings.ToLower(units)
+	if units != UnitsMetric && units != UnitsImperial {
+		return "", fmt.Errorf("units must be %s or %s", UnitsMetric, UnitsImperial)
+	}
+	return units, nil
+}
+
+// report returns a cached report or requests a new one. Coordinates are rounded to about a
+// kilometer for the cache key.
+func (ws *WeatherServer) report(ctx context.Context, lat, lon float64, days int, units string) (*Report, error) {
+	key := fmt.Sprintf("weather|%s|%.2f|%.2f|%d|%s", ws.provider.Name(), lat, lon, days, units)
+	if cached, ok := ws.cache.get(key); ok {
+		r := *cached.(*Report)
+		return &r, nil
+	}
+	var report *Report
+	var err error
+	if days > 0 {
+		report, err = ws.provider.Forecast(ctx, lat, lon, days, units)
+	} else {
+		report, err = ws.provider.Current(ctx, lat, lon, units)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ws.cache.put(key, report, time.Duration(ws.config.CacheTTL)*time.Second)
+	r := *report
+	return &r, nil
+}
+
+// handleCurrent returns the current conditions.
+func (ws *WeatherServer) handleCurrent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return ws.handleReport(ctx, request, 0)
+}
+
+// handleForecast returns the daily forecast.
+func (ws *WeatherServer) handleForecast(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	days := abstract.GetInt(request.GetArguments(), "days", 3)
+	if days < 1 || days > ws.maxDays() {
+		return mcp.NewToolResultError(fmt.Sprintf("days must be between 1 and %d", ws.maxDays())), nil
+	}
+	return ws.handleReport(ctx, request, days)
+}
+
+func (ws *WeatherServer) handleReport(ctx context.Context, request mcp.CallToolRequest, days int) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	units, err := ws.units(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lat, lon, place, err := ws.location(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	report, err := ws.report(ctx, lat, lon, days, units)
+	if err != nil {
+		ws.Logger.Error().Err(err).Float64("latitude", lat).Float64("longitude", lon).Msg("weather request failed")
+		return mcp.NewToolResultError(fmt.Sprintf("weather request failed: %s", err.Error())), nil
+	}
+	report.Place = place
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %s", err.Error())), nil
+	}
+	text := string(data)
+	if place != nil {
+		text = fmt.Sprintf("Weather for %s:\n%s", place.Label(), text)
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// handleFindPlace lists the places matching a name.
+func (ws *WeatherServer) handleFindPlace(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := abstract.GetString(args, "name", "")
+	count := 5
+	if c := abstract.GetInt(args, "count", 0); c > 0 {
+		count = min(c, 20)
+	}
+	places, err := ws.findPlaces(ctx, name, count)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d places:\n\n", len(places)))
+	for _, p := range places {
+		result.WriteString(fmt.Sprintf("- %s (latitude %.4f, longitude %.4f", p.Label(), p.Latitude, p.Longitude))
+		if p.Timezone != "" {
+			result.WriteString(", " + p.Timezone)
+		}
+		if p.Population > 0 {
+			result.WriteString(fmt.Sprintf(", population %d", p.Population))
+		}
+		result.WriteString(")\n")
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ws *WeatherServer) Config() string {
+	cfg, err := json.Marshal(ws.config)
+	if err != nil {
+		ws.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ws *WeatherServer) Name() comm.MoLingServerType {
+	return WeatherServerName
+}
+
+func (ws *WeatherServer) Close() error {
+	ws.Logger.Debug().Msg("WeatherServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ws *WeatherServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ws.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ws.config.Check()
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package weather
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const WeatherPromptDefault = `
+You are an assistant that can look up the weather. Your capabilities include:
+
+1. **Current conditions**: Use weather_current with a place name (e.g. "Hangzhou" or "Paris, France") or coordinates to get the temperature, wind, humidity and precipitation right now.
+
+2. **Forecast**: Use weather_forecast to get a daily forecast of up to %d days with temperature range, precipitation, wind and sunrise and sunset times.
+
+3. **Places**: Use weather_find_place when a place name is ambiguous, then query the weather with the coordinates of the right place.
+
+Always tell the user which place the weather is for, and include the units. Results are cached for a few minutes, so repeated questions are cheap.
+`
+
+// Weather providers.
+const (
+	ProviderOpenMeteo      = "open-meteo"
+	ProviderOpenWeatherMap = "openweathermap"
+)
+
+// Measurement systems.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// WeatherConfig represents the configuration for the weather service.
+type WeatherConfig struct {
+	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the weather service.
+	prompt          string
+	Provider        string `json:"provider"`          // Provider is open-meteo (no API key needed) or openweathermap.
+	APIKey          string `json:"api_key"`           // APIKey is the API key of the provider, if it needs one.
+	BaseURL         string `json:"base_url"`          // BaseURL overrides the forecast API URL, e.g. for a self-hosted Open-Meteo.
+	GeocodingURL    string `json:"geocoding_url"`     // GeocodingURL overrides the geocoding API URL.
+	Units           string `json:"units"`             // Units is the default measurement system, metric or imperial.
+	Language        string `json:"language"`          // Language is used for place names and descriptions where supported.
+	CacheTTL        int    `json:"cache_ttl"`         // CacheTTL is how long weather results are cached. time.Second
+	Timeout         int    `json:"timeout"`           // Timeout is the timeout for a single provider request. time.Second
+	MaxForecastDays int    `json:"max_forecast_days"` // MaxForecastDays is the maximum number of forecast days.
+}
+
+// NewWeatherConfig creates a new WeatherConfig with default values.
+func NewWeatherConfig() *WeatherConfig {
+	return &WeatherConfig{
+		prompt:          WeatherPromptDefault,
+		Provider:        ProviderOpenMeteo,
+		Units:           UnitsMetric,
+		Language:        "en",
+		CacheTTL:        600,
+		Timeout:         15,
+		MaxForecastDays: 16,
+	}
+}
+
+// Check validates the weather configuration.
+func (cfg *WeatherConfig) Check() error {
+	cfg.prompt = WeatherPromptDefault
+	cfg.Provider = strings.ToLower(cfg.Provider)
+	if _, ok := providerFactories[cfg.Provider]; !ok {
+		return fmt.Errorf("provider must be one of %s, %s", ProviderOpenMeteo, ProviderOpenWeatherMap)
+	}
+	if cfg.Provider == ProviderOpenWeatherMap && cfg.APIKey == "" {
+		return fmt.Errorf("api_key must be set for the %s provider", cfg.Provider)
+	}
+	cfg.Units = strings.ToLower(cfg.Units)
+	if cfg.Units != UnitsMetric && cfg.Units != UnitsImperial {
+		return fmt.Errorf("units must be %s or %s", UnitsMetric, UnitsImperial)
+	}
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative")
+	}
+	if cfg.Timeout <= 0 || cfg.MaxForecastDays <= 0 {
+		return fmt.Errorf("timeout and max_forecast_days must be greater than 0")
+	}
+	if cfg.PromptFile != "" {
+		read, err := os.ReadFile(cfg.PromptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file:%s, error: %w", cfg.PromptFile, err)
+		}
+		cfg.prompt = string(read)
+	}
+	return nil
+}
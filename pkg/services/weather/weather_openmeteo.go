@@ -0,0 +1,219 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	openMeteoURL          = "https://api.open-meteo.com"
+	openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com"
+)
+
+// openMeteo reads forecasts from Open-Meteo, which needs no API key.
+type openMeteo struct {
+	client       *http.Client
+	baseURL      string
+	geocodingURL string
+	apiKey       string
+	language     string
+}
+
+func newOpenMeteo(cfg *WeatherConfig, client *http.Client) Provider {
+	p := &openMeteo{client: client, baseURL: openMeteoURL, geocodingURL: openMeteoGeocodingURL, apiKey: cfg.APIKey, language: cfg.Language}
+	if cfg.BaseURL != "" {
+		p.baseURL = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	if cfg.GeocodingURL != "" {
+		p.geocodingURL = strings.TrimRight(cfg.GeocodingURL, "/")
+	}
+	return p
+}
+
+func (p *openMeteo) Name() string {
+	return ProviderOpenMeteo
+}
+
+func (p *openMeteo) MaxForecastDays() int {
+	return 16
+}
+
+// SearchPlaces uses the Open-Meteo geocoding API.
+func (p *openMeteo) SearchPlaces(ctx context.Context, name string, count int) ([]Place, error) {
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("count", strconv.Itoa(count))
+	q.Set("language", p.language)
+	q.Set("format", "json")
+	p.setKey(q)
+	var resp struct {
+		Results []struct {
+			Name        string  `json:"name"`
+			Latitude    float64 `json:"latitude"`
+			Longitude   float64 `json:"longitude"`
+			Country     string  `json:"country"`
+			CountryCode string  `json:"country_code"`
+			Admin1      string  `json:"admin1"`
+			Timezone    string  `json:"timezone"`
+			Population  int     `json:"population"`
+		} `json:"results"`
+	}
+	err := getJSON(ctx, p.client, p.geocodingURL+"/v1/search?"+q.Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	places := make([]Place, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		places = append(places, Place{
+			Name:        r.Name,
+			Admin:       r.Admin1,
+			Country:     r.Country,
+			CountryCode: r.CountryCode,
+			Latitude:    r.Latitude,
+			Longitude:   r.Longitude,
+			Timezone:    r.Timezone,
+			Population:  r.Population,
+		})
+	}
+	return places, nil
+}
+
+func (p *openMeteo) Current(ctx context.Context, lat, lon float64, units string) (*Report, error) {
+	return p.Forecast(ctx, lat, lon, 0, units)
+}
+
+// Forecast requests the current conditions and, when days is positive, the daily forecast.
+func (p *openMeteo) Forecast(ctx context.Context, lat, lon float64, days int, units string) (*Report, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	q.Set("timezone", "auto")
+	q.Set("current", "temperature_2m,relative_humidity_2m,apparent_temperature,is_day,precipitation,weather_code,cloud_cover,pressure_msl,wind_speed_10m,wind_direction_10m,uv_index")
+	if days > 0 {
+		q.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,wind_speed_10m_max,sunrise,sunset,uv_index_max")
+		q.Set("forecast_days", strconv.Itoa(days))
+	}
+	if units == UnitsImperial {
+		q.Set("temperature_unit", "fahrenheit")
+		q.Set("wind_speed_unit", "mph")
+		q.Set("precipitation_unit", "inch")
+	}
+	p.setKey(q)
+	var resp struct {
+		Latitude     float64           `json:"latitude"`
+		Longitude    float64           `json:"longitude"`
+		Timezone     string            `json:"timezone"`
+		CurrentUnits map[string]string `json:"current_units"`
+		Current      struct {
+			Time                string   `json:"time"`
+			Temperature         float64  `json:"temperature_2m"`
+			Humidity            int      `json:"relative_humidity_2m"`
+			ApparentTemperature float64  `json:"apparent_temperature"`
+			IsDay               int      `json:"is_day"`
+			Precipitation       float64  `json:"precipitation"`
+			WeatherCode         int      `json:"weather_code"`
+			CloudCover          int      `json:"cloud_cover"`
+			Pressure            float64  `json:"pressure_msl"`
+			WindSpeed           float64  `json:"wind_speed_10m"`
+			WindDirection       float64  `json:"wind_direction_10m"`
+			UVIndex             *float64 `json:"uv_index"`
+		} `json:"current"`
+		Daily struct {
+			Time                     []string   `json:"time"`
+			WeatherCode              []int      `json:"weather_code"`
+			TemperatureMax           []float64  `json:"temperature_2m_max"`
+			TemperatureMin           []float64  `json:"temperature_2m_min"`
+			Precipitation            []float64  `json:"precipitation_sum"`
+			PrecipitationProbability []*int     `json:"precipitation_probability_max"`
+			WindSpeedMax             []float64  `json:"wind_speed_10m_max"`
+			Sunrise                  []string   `json:"sunrise"`
+			Sunset                   []string   `json:"sunset"`
+			UVIndexMax               []*float64 `json:"uv_index_max"`
+		} `json:"daily"`
+	}
+	err := getJSON(ctx, p.client, p.baseURL+"/v1/forecast?"+q.Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	c := resp.Current
+	isDay := c.IsDay == 1
+	report := &Report{
+		Latitude:  resp.Latitude,
+		Longitude: resp.Longitude,
+		Timezone:  resp.Timezone,
+		Provider:  ProviderOpenMeteo,
+		Units: Units{
+			Temperature:   resp.CurrentUnits["temperature_2m"],
+			WindSpeed:     resp.CurrentUnits["wind_speed_10m"],
+			Precipitation: resp.CurrentUnits["precipitation"],
+			Pressure:      resp.CurrentUnits["pressure_msl"],
+		},
+		Current: &Conditions{
+			Time:                c.Time,
+			Description:         wmoDescription(c.WeatherCode),
+			Temperature:         c.Temperature,
+			ApparentTemperature: c.ApparentTemperature,
+			Humidity:            c.Humidity,
+			Precipitation:       c.Precipitation,
+			CloudCover:          c.CloudCover,
+			Pressure:            c.Pressure,
+			WindSpeed:           c.WindSpeed,
+			WindDirection:       compassPoint(c.WindDirection),
+			IsDay:               &isDay,
+			UVIndex:             c.UVIndex,
+		},
+	}
+	d := resp.Daily
+	for i, date := range d.Time {
+		day := DailyForecast{Date: date}
+		if i < len(d.WeatherCode) {
+			day.Description = wmoDescription(d.WeatherCode[i])
+		}
+		if i < len(d.TemperatureMax) && i < len(d.TemperatureMin) {
+			day.TemperatureMax, day.TemperatureMin = d.TemperatureMax[i], d.TemperatureMin[i]
+		}
+		if i < len(d.Precipitation) {
+			day.Precipitation = d.Precipitation[i]
+		}
+		if i < len(d.PrecipitationProbability) {
+			day.PrecipitationProbability = d.PrecipitationProbability[i]
+		}
+		if i < len(d.WindSpeedMax) {
+			day.WindSpeedMax = d.WindSpeedMax[i]
+		}
+		if i < len(d.Sunrise) && i < len(d.Sunset) {
+			day.Sunrise, day.Sunset = d.Sunrise[i], d.Sunset[i]
+		}
+		if i < len(d.UVIndexMax) {
+			day.UVIndexMax = d.UVIndexMax[i]
+		}
+		report.Daily = append(report.Daily, day)
+	}
+	return report, nil
+}
+
+// setKey adds the API key of the commercial Open-Meteo API.
+func (p *openMeteo) setKey(q url.Values) {
+	if p.apiKey != "" {
+		q.Set("apikey", p.apiKey)
+	}
+}
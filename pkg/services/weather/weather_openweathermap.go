@@ -0,0 +1,261 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package weather
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const openWeatherMapURL = "https://api.openweathermap.org"
+
+// openWeatherMap reads the free OpenWeatherMap APIs, which need an API key. Daily forecasts
+// are aggregated from the 3 hourly forecast and cover five days.
+type openWeatherMap struct {
+	client       *http.Client
+	baseURL      string
+	geocodingURL string
+	apiKey       string
+	language     string
+}
+
+func newOpenWeatherMap(cfg *WeatherConfig, client *http.Client) Provider {
+	p := &openWeatherMap{client: client, baseURL: openWeatherMapURL, geocodingURL: openWeatherMapURL, apiKey: cfg.APIKey, language: cfg.Language}
+	if cfg.BaseURL != "" {
+		p.baseURL = strings.TrimRight(cfg.BaseURL, "/")
+		p.geocodingURL = p.baseURL
+	}
+	if cfg.GeocodingURL != "" {
+		p.geocodingURL = strings.TrimRight(cfg.GeocodingURL, "/")
+	}
+	return p
+}
+
+func (p *openWeatherMap) Name() string {
+	return ProviderOpenWeatherMap
+}
+
+func (p *openWeatherMap) MaxForecastDays() int {
+	return 5
+}
+
+// owmWeather is the weather part shared by current and forecast responses.
+type owmWeather struct {
+	Weather []struct {
+		Description string `json:"description"`
+	} `json:"weather"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		TempMin   float64 `json:"temp_min"`
+		TempMax   float64 `json:"temp_max"`
+		Pressure  float64 `json:"pressure"`
+		Humidity  int     `json:"humidity"`
+	} `json:"main"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+		Deg   float64 `json:"deg"`
+	} `json:"wind"`
+	Clouds struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Rain map[string]float64 `json:"rain"`
+	Snow map[string]float64 `json:"snow"`
+	Dt   int64              `json:"dt"`
+	Pop  float64            `json:"pop"`
+}
+
+func (w owmWeather) description() string {
+	if len(w.Weather) == 0 {
+		return ""
+	}
+	d := w.Weather[0].Description
+	if d == "" {
+		return d
+	}
+	return strings.ToUpper(d[:1]) + d[1:]
+}
+
+// precipitation sums rain and snow for the given period key, "1h" or "3h".
+func (w owmWeather) precipitation(period string) float64 {
+	return w.Rain[period] + w.Snow[period]
+}
+
+// SearchPlaces uses the OpenWeatherMap direct geocoding API.
+func (p *openWeatherMap) SearchPlaces(ctx context.Context, name string, count int) ([]Place, error) {
+	q := url.Values{}
+	q.Set("q", name)
+	q.Set("limit", strconv.Itoa(min(count, 5)))
+	q.Set("appid", p.apiKey)
+	var resp []struct {
+		Name       string            `json:"name"`
+		LocalNames map[string]string `json:"local_names"`
+		Lat        float64           `json:"lat"`
+		Lon        float64           `json:"lon"`
+		Country    string            `json:"country"`
+		State      string            `json:"state"`
+	}
+	err := getJSON(ctx, p.client, p.geocodingURL+"/geo/1.0/direct?"+q.Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	places := make([]Place, 0, len(resp))
+	for _, r := range resp {
+		name := r.Name
+		if local, ok := r.LocalNames[p.language]; ok && local != "" {
+			name = local
+		}
+		places = append(places, Place{Name: name, Admin: r.State, CountryCode: r.Country, Latitude: r.Lat, Longitude: r.Lon})
+	}
+	return places, nil
+}
+
+func (p *openWeatherMap) query(lat, lon float64, units string) url.Values {
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(lat, 'f', 4, 64))
+	q.Set("lon", strconv.FormatFloat(lon, 'f', 4, 64))
+	q.Set("units", units)
+	q.Set("lang", p.language)
+	q.Set("appid", p.apiKey)
+	return q
+}
+
+func (p *openWeatherMap) units(units string) Units {
+	if units == UnitsImperial {
+		return Units{Temperature: "°F", WindSpeed: "mph", Precipitation: "mm", Pressure: "hPa"}
+	}
+	return Units{Temperature: "°C", WindSpeed: "m/s", Precipitation: "mm", Pressure: "hPa"}
+}
+
+// Current uses the current weather API.
+func (p *openWeatherMap) Current(ctx context.Context, lat, lon float64, units string) (*Report, error) {
+	var resp struct {
+		owmWeather
+		Timezone int `json:"timezone"`
+		Sys      struct {
+			Sunrise int64 `json:"sunrise"`
+			Sunset  int64 `json:"sunset"`
+		} `json:"sys"`
+	}
+	err := getJSON(ctx, p.client, p.baseURL+"/data/2.5/weather?"+p.query(lat, lon, units).Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	zone := time.FixedZone("", resp.Timezone)
+	isDay := resp.Dt >= resp.Sys.Sunrise && resp.Dt < resp.Sys.Sunset
+	return &Report{
+		Latitude:  lat,
+		Longitude: lon,
+		Timezone:  "UTC" + offsetString(resp.Timezone),
+		Provider:  ProviderOpenWeatherMap,
+		Units:     p.units(units),
+		Current: &Conditions{
+			Time:                time.Unix(resp.Dt, 0).In(zone).Format("2006-01-02T15:04"),
+			Description:         resp.description(),
+			Temperature:         resp.Main.Temp,
+			ApparentTemperature: resp.Main.FeelsLike,
+			Humidity:            resp.Main.Humidity,
+			Precipitation:       resp.precipitation("1h"),
+			CloudCover:          resp.Clouds.All,
+			Pressure:            resp.Main.Pressure,
+			WindSpeed:           resp.Wind.Speed,
+			WindDirection:       compassPoint(resp.Wind.Deg),
+			IsDay:               &isDay,
+		},
+	}, nil
+}
+
+// Forecast combines the current weather with a daily summary of the 3 hourly forecast.
+func (p *openWeatherMap) Forecast(ctx context.Context, lat, lon float64, days int, units string) (*Report, error) {
+	report, err := p.Current(ctx, lat, lon, units)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		List []owmWeather `json:"list"`
+		City struct {
+			Timezone int   `json:"timezone"`
+			Sunrise  int64 `json:"sunrise"`
+			Sunset   int64 `json:"sunset"`
+		} `json:"city"`
+	}
+	err = getJSON(ctx, p.client, p.baseURL+"/data/2.5/forecast?"+p.query(lat, lon, units).Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	zone := time.FixedZone("", resp.City.Timezone)
+	byDate := make(map[string][]owmWeather)
+	for _, w := range resp.List {
+		date := time.Unix(w.Dt, 0).In(zone).Format("2006-01-02")
+		byDate[date] = append(byDate[date], w)
+	}
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for _, date := range dates[:min(days, len(dates))] {
+		report.Daily = append(report.Daily, summarizeDay(date, byDate[date], resp.City.Timezone))
+	}
+	return report, nil
+}
+
+// summarizeDay aggregates the 3 hourly entries of one day. The description is the one closest
+// to local noon.
+func summarizeDay(date string, entries []owmWeather, offset int) DailyForecast {
+	day := DailyForecast{Date: date, TemperatureMax: math.Inf(-1), TemperatureMin: math.Inf(1)}
+	pop := 0.0
+	bestNoon := int64(math.MaxInt64)
+	for _, w := range entries {
+		day.TemperatureMax = math.Max(day.TemperatureMax, w.Main.TempMax)
+		day.TemperatureMin = math.Min(day.TemperatureMin, w.Main.TempMin)
+		day.Precipitation += w.precipitation("3h")
+		day.WindSpeedMax = math.Max(day.WindSpeedMax, w.Wind.Speed)
+		pop = math.Max(pop, w.Pop)
+		noon := (w.Dt + int64(offset)) % 86400
+		if d := abs64(noon - 12*3600); d < bestNoon {
+			bestNoon = d
+			day.Description = w.description()
+		}
+	}
+	day.Precipitation = math.Round(day.Precipitation*100) / 100
+	probability := int(math.Round(pop * 100))
+	day.PrecipitationProbability = &probability
+	return day
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func offsetString(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
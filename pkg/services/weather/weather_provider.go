@@ -0,0 +1,204 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxResponseSize limits provider responses, forecasts are a few kilobytes.
+const maxResponseSize = 1024 * 1024 * 4
+
+// Provider is a source of geocoding, current conditions and forecasts.
+type Provider interface {
+	// Name returns the provider name shown in reports.
+	Name() string
+	// MaxForecastDays returns the longest forecast the provider offers.
+	MaxForecastDays() int
+	// SearchPlaces finds places by name.
+	SearchPlaces(ctx context.Context, name string, count int) ([]Place, error)
+	// Current returns the current conditions at a location.
+	Current(ctx context.Context, lat, lon float64, units string) (*Report, error)
+	// Forecast returns the current conditions and a daily forecast at a location.
+	Forecast(ctx context.Context, lat, lon float64, days int, units string) (*Report, error)
+}
+
+// providerFactories creates the providers by name.
+var providerFactories = map[string]func(cfg *WeatherConfig, client *http.Client) Provider{
+	ProviderOpenMeteo:      newOpenMeteo,
+	ProviderOpenWeatherMap: newOpenWeatherMap,
+}
+
+// Place is a geocoded location.
+type Place struct {
+	Name        string  `json:"name"`
+	Admin       string  `json:"admin,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	CountryCode string  `json:"country_code,omitempty"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Timezone    string  `json:"timezone,omitempty"`
+	Population  int     `json:"population,omitempty"`
+}
+
+// Label returns a readable name such as "Paris, Île-de-France, France".
+func (p Place) Label() string {
+	parts := []string{p.Name}
+	if p.Admin != "" && p.Admin != p.Name {
+		parts = append(parts, p.Admin)
+	}
+	if p.Country != "" {
+		parts = append(parts, p.Country)
+	} else if p.CountryCode != "" {
+		parts = append(parts, p.CountryCode)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Units names the units of the values in a report.
+type Units struct {
+	Temperature   string `json:"temperature"`
+	WindSpeed     string `json:"wind_speed"`
+	Precipitation string `json:"precipitation"`
+	Pressure      string `json:"pressure"`
+}
+
+// Conditions are the weather conditions at one point in time.
+type Conditions struct {
+	Time                string   `json:"time"`
+	Description         string   `json:"description"`
+	Temperature         float64  `json:"temperature"`
+	ApparentTemperature float64  `json:"apparent_temperature"`
+	Humidity            int      `json:"humidity"`
+	Precipitation       float64  `json:"precipitation"`
+	CloudCover          int      `json:"cloud_cover"`
+	Pressure            float64  `json:"pressure"`
+	WindSpeed           float64  `json:"wind_speed"`
+	WindDirection       string   `json:"wind_direction"`
+	IsDay               *bool    `json:"is_day,omitempty"`
+	UVIndex             *float64 `json:"uv_index,omitempty"`
+}
+
+// DailyForecast is the forecast for one day.
+type DailyForecast struct {
+	Date                     string   `json:"date"`
+	Description              string   `json:"description"`
+	TemperatureMax           float64  `json:"temperature_max"`
+	TemperatureMin           float64  `json:"temperature_min"`
+	Precipitation            float64  `json:"precipitation"`
+	PrecipitationProbability *int     `json:"precipitation_probability,omitempty"`
+	WindSpeedMax             float64  `json:"wind_speed_max"`
+	Sunrise                  string   `json:"sunrise,omitempty"`
+	Sunset                   string   `json:"sunset,omitempty"`
+	UVIndexMax               *float64 `json:"uv_index_max,omitempty"`
+}
+
+// Report is the result of a weather query.
+type Report struct {
+	Place     *Place          `json:"place,omitempty"`
+	Latitude  float64         `json:"latitude"`
+	Longitude float64         `json:"longitude"`
+	Timezone  string          `json:"timezone,omitempty"`
+	Provider  string          `json:"provider"`
+	Units     Units           `json:"units"`
+	Current   *Conditions     `json:"current,omitempty"`
+	Daily     []DailyForecast `json:"daily,omitempty"`
+}
+
+// wmoDescriptions describes the WMO weather interpretation codes used by Open-Meteo.
+var wmoDescriptions = map[int]string{
+	0: "Clear sky", 1: "Mainly clear", 2: "Partly cloudy", 3: "Overcast",
+	45: "Fog", 48: "Depositing rime fog",
+	51: "Light drizzle", 53: "Moderate drizzle", 55: "Dense drizzle",
+	56: "Light freezing drizzle", 57: "Dense freezing drizzle",
+	61: "Slight rain", 63: "Moderate rain", 65: "Heavy rain",
+	66: "Light freezing rain", 67: "Heavy freezing rain",
+	71: "Slight snow fall", 73: "Moderate snow fall", 75: "Heavy snow fall", 77: "Snow grains",
+	80: "Slight rain showers", 81: "Moderate rain showers", 82: "Violent rain showers",
+	85: "Slight snow showers", 86: "Heavy snow showers",
+	95: "Thunderstorm", 96: "Thunderstorm with slight hail", 99: "Thunderstorm with heavy hail",
+}
+
+func wmoDescription(code int) string {
+	if d, ok := wmoDescriptions[code]; ok {
+		return d
+	}
+	return fmt.Sprintf("Weather code %d", code)
+}
+
+// compassPoint converts a wind direction in degrees to one of 16 compass points.
+func compassPoint(degrees float64) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	i := int((degrees+11.25)/22.5) % 16
+	if i < 0 {
+		i += 16
+	}
+	return fmt.Sprintf("%s (%.0f°)", points[i], degrees)
+}
+
+// getJSON requests a URL and decodes the JSON response. Errors do not include the URL, since
+// the query may contain an API key.
+func getJSON(ctx context.Context, client *http.Client, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if len(data) > maxResponseSize {
+		return fmt.Errorf("response from %s exceeds %d bytes", req.URL.Host, maxResponseSize)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		msg := apiErr.Reason + apiErr.Message
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+			if len(msg) > 200 {
+				msg = msg[:200]
+			}
+		}
+		return fmt.Errorf("HTTP %s from %s: %s", resp.Status, req.URL.Host, msg)
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package weather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+const openMeteoSearch = `{"results":[
+{"name":"Springfield","latitude":39.8017,"longitude":-89.6437,"country":"United States","country_code":"US","admin1":"Illinois","timezone":"America/Chicago","population":116565},
+{"name":"Springfield","latitude":37.2153,"longitude":-93.2982,"country":"United States","country_code":"US","admin1":"Missouri","timezone":"America/Chicago","population":169176}]}`
+
+const openMeteoForecast = `{"latitude":37.21,"longitude":-93.3,"timezone":"America/Chicago",
+"current_units":{"temperature_2m":"°C","wind_speed_10m":"km/h","precipitation":"mm","pressure_msl":"hPa"},
+"current":{"time":"2025-06-01T12:00","temperature_2m":24.5,"relative_humidity_2m":60,"apparent_temperature":25.1,"is_day":1,"precipitation":0,"weather_code":2,"cloud_cover":40,"pressure_msl":1013.2,"wind_speed_10m":12.3,"wind_direction_10m":225,"uv_index":6.5},
+"daily":{"time":["2025-06-01","2025-06-02"],"weather_code":[2,61],"temperature_2m_max":[28.1,22.4],"temperature_2m_min":[17.2,15.9],"precipitation_sum":[0,5.2],"precipitation_probability_max":[10,80],"wind_speed_10m_max":[20.1,25.3],"sunrise":["2025-06-01T05:58","2025-06-02T05:58"],"sunset":["2025-06-01T20:30","2025-06-02T20:31"],"uv_index_max":[8.1,4.2]}}`
+
+func TestWeatherOpenMeteo(t *testing.T) {
+	var searches, forecasts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/search":
+			searches.Add(1)
+			if r.URL.Query().Get("name") != "Springfield" {
+				t.Errorf("unexpected search name %q", r.URL.Query().Get("name"))
+			}
+			_, _ = w.Write([]byte(openMeteoSearch))
+		case "/v1/forecast":
+			forecasts.Add(1)
+			q := r.URL.Query()
+			if q.Get("latitude") != "37.2153" || q.Get("forecast_days") != "2" {
+				t.Errorf("unexpected forecast query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(openMeteoForecast))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	ws := testkit.NewServiceAs[*WeatherServer](t, NewWeatherServer, map[string]any{"base_url": ts.URL, "geocoding_url": ts.URL})
+
+	text, isErr := testkit.CallHandler(t, ws.handleForecast, map[string]any{"place": "Springfield, missouri", "days": float64(2)})
+	if isErr {
+		t.Fatalf("weather_forecast failed: %s", text)
+	}
+	for _, want := range []string{"Springfield, Missouri, United States", "\"temperature\": 24.5", "Partly cloudy", "Slight rain", "\"wind_direction\": \"SW (225°)\"", "\"precipitation_probability\": 80"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("forecast missing %q:\n%s", want, text)
+		}
+	}
+
+	// A repeated question is answered from the cache.
+	_, isErr = testkit.CallHandler(t, ws.handleForecast, map[string]any{"place": "Springfield, Missouri", "days": float64(2)})
+	if isErr || searches.Load() != 1 || forecasts.Load() != 1 {
+		t.Errorf("expected cached results, got %d searches and %d forecasts", searches.Load(), forecasts.Load())
+	}
+
+	text, isErr = testkit.CallHandler(t, ws.handleFindPlace, map[string]any{"name": "Springfield"})
+	if isErr || !strings.Contains(text, "Found 2 places") || !strings.Contains(text, "Illinois") {
+		t.Errorf("unexpected find place result: %s", text)
+	}
+	text, isErr = testkit.CallHandler(t, ws.handleFindPlace, map[string]any{"name": "Springfield, Texas"})
+	if !isErr || !strings.Contains(text, "no place found") {
+		t.Errorf("expected no place found, got %s", text)
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"latitude": float64(91), "longitude": float64(0)},
+		{"place": "Springfield", "units": "kelvin"},
+		{"place": "Springfield", "days": float64(17)},
+	} {
+		if text, isErr := testkit.CallHandler(t, ws.handleForecast, args); !isErr {
+			t.Errorf("expected error for %v, got %s", args, text)
+		}
+	}
+}
+
+func TestWeatherOpenWeatherMap(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("appid") != "secret-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"cod":401,"message":"Invalid API key"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			_, _ = w.Write([]byte(`{"weather":[{"description":"light rain"}],"main":{"temp":12.3,"feels_like":11.1,"pressure":1008,"humidity":81},
+"wind":{"speed":5.2,"deg":90},"clouds":{"all":75},"rain":{"1h":0.4},"dt":1748779200,"timezone":3600,"sys":{"sunrise":1748750000,"sunset":1748810000}}`))
+		case "/data/2.5/forecast":
+			_, _ = w.Write([]byte(`{"city":{"timezone":3600},"list":[
+{"dt":1748779200,"main":{"temp_min":10,"temp_max":13},"weather":[{"description":"light rain"}],"wind":{"speed":5},"rain":{"3h":1.2},"pop":0.6},
+{"dt":1748790000,"main":{"temp_min":12,"temp_max":16},"weather":[{"description":"overcast clouds"}],"wind":{"speed":7},"pop":0.2},
+{"dt":1748865600,"main":{"temp_min":9,"temp_max":18},"weather":[{"description":"clear sky"}],"wind":{"speed":3},"pop":0}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	ws := testkit.NewServiceAs[*WeatherServer](t, NewWeatherServer, map[string]any{"provider": "openweathermap", "api_key": "secret-key", "base_url": ts.URL, "geocoding_url": ts.URL})
+
+	text, isErr := testkit.CallHandler(t, ws.handleForecast, map[string]any{"latitude": float64(51.5), "longitude": float64(-0.12), "days": float64(2)})
+	if isErr {
+		t.Fatalf("weather_forecast failed: %s", text)
+	}
+	for _, want := range []string{"\"timezone\": \"UTC+01:00\"", "Light rain", "\"temperature_max\": 16", "\"precipitation\": 1.2", "\"precipitation_probability\": 60", "\"date\": \"2025-06-02\"", "Clear sky"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("forecast missing %q:\n%s", want, text)
+		}
+	}
+
+	// Errors never expose the API key in the request URL.
+	ws = testkit.NewServiceAs[*WeatherServer](t, NewWeatherServer, map[string]any{"provider": "openweathermap", "api_key": "wrong-key", "base_url": ts.URL, "geocoding_url": ts.URL})
+	text, isErr = testkit.CallHandler(t, ws.handleCurrent, map[string]any{"latitude": float64(51.5), "longitude": float64(-0.12)})
+	if !isErr || !strings.Contains(text, "Invalid API key") || strings.Contains(text, "wrong-key") {
+		t.Errorf("unexpected error result: %s", text)
+	}
+}
+
+func TestWeatherConfigCheck(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		cfg  func(*WeatherConfig)
+	}{
+		{"provider", func(c *WeatherConfig) { c.Provider = "nope" }},
+		{"api key", func(c *WeatherConfig) { c.Provider = ProviderOpenWeatherMap }},
+		{"units", func(c *WeatherConfig) { c.Units = "kelvin" }},
+		{"timeout", func(c *WeatherConfig) { c.Timeout = 0 }},
+	} {
+		cfg := NewWeatherConfig()
+		c.cfg(cfg)
+		if err := cfg.Check(); err == nil {
+			t.Errorf("%s: expected check to fail", c.name)
+		}
+	}
+	if err := NewWeatherConfig().Check(); err != nil {
+		t.Errorf("default config: %s", err.Error())
+	}
+}
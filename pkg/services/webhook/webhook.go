@@ -0,0 +1,317 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package webhook provides an HTTP inbox that lets external systems push events into MoLing sessions.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	WebhookServerName comm.MoLingServerType = "Webhook"
+	// maxPreviewSize is the number of payload bytes included in a notification.
+	maxPreviewSize = 512
+)
+
+// WebhookServer implements the Service interface and receives webhook deliveries over HTTP.
+type WebhookServer struct {
+	abstract.MLService
+	config     *WebhookConfig
+	store      *Store
+	httpServer *http.Server
+}
+
+// NewWebhookServer creates a new WebhookServer instance.
+func NewWebhookServer(ctx context.Context) (abstract.Service, error) {
+	wc := NewWebhookConfig()
+	globalConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("WebhookServer: invalid config type")
+	}
+	wc.StorePath = filepath.Join(globalConf.BasePath, "data", "webhook")
+
+	logger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("WebhookServer: invalid logger type")
+	}
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(WebhookServerName))
+	})
+
+	ws := &WebhookServer{
+		MLService: abstract.NewMLService(ctx, logger.Hook(loggerNameHook), globalConf),
+		config:    wc,
+	}
+	err := ws.InitResources()
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// Init opens the store, starts the HTTP listener and registers the prompt and tools.
+func (ws *WebhookServer) Init() error {
+	var err error
+	ws.store, err = NewStore(ws.config.StorePath, ws.config.MaxEvents)
+	if err != nil {
+		return err
+	}
+	// a busy port must not prevent the other services from loading, so only log the error
+	listener, err := net.Listen("tcp", ws.config.ListenAddr)
+	if err != nil {
+		ws.Logger.Error().Err(err).Str("listenAddr", ws.config.ListenAddr).Msg("failed to start webhook listener")
+	} else {
+		rc := &receiver{store: ws.store, maxPayloadSize: ws.config.MaxPayloadSize, onEvent: ws.onEvent}
+		ws.httpServer = &http.Server{Handler: rc.routes(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			err := ws.httpServer.Serve(listener)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				ws.Logger.Error().Err(err).Msg("webhook listener stopped")
+			}
+		}()
+		ws.Logger.Info().Str("listenAddr", ws.config.ListenAddr).Msg("webhook listener started")
+	}
+
+	pe := abstract.PromptEntry{
+		PromptVar: mcp.Prompt{
+			Name:        "webhook_prompt",
+			Description: "Get the relevant functions and prompts of the Webhook MCP Server",
+		},
+		HandlerFunc: ws.handlePrompt,
+	}
+	ws.AddPrompt(pe)
+
+	ws.AddTool(mcp.NewTool(
+		"webhook_create",
+		mcp.WithDescription("Create a webhook inbox and return its URL and secret token"),
+		mcp.WithString("name",
+			mcp.Description("Unique name of the hook, e.g. github-ci"),
+			mcp.Required(),
+		),
+	), ws.handleCreate)
+
+	ws.AddTool(mcp.NewTool(
+		"webhook_list_events",
+		mcp.WithDescription("List the events received by a hook, newest first. Without a hook, list all hooks."),
+		mcp.WithString("hook",
+			mcp.Description("Name or ID of the hook"),
+		),
+		mcp.WithString("since",
+			mcp.Description("Only return events received after this RFC3339 time"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of events, default: 10"),
+		),
+	), ws.handleListEvents)
+
+	ws.AddTool(mcp.NewTool(
+		"webhook_delete",
+		mcp.WithDescription("Delete a hook and its stored events"),
+		mcp.WithString("hook",
+			mcp.Description("Name or ID of the hook"),
+			mcp.Required(),
+		),
+	), ws.handleDelete)
+	return nil
+}
+
+func (ws *WebhookServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return &mcp.GetPromptResult{
+		Description: "",
+		Messages: []mcp.PromptMessage{
+			{
+				Role: mcp.RoleUser,
+				Content: mcp.TextContent{
+					Type: "text",
+					Text: ws.config.prompt,
+				},
+			},
+		},
+	}, nil
+}
+
+// onEvent logs a delivery and notifies connected clients.
+func (ws *WebhookServer) onEvent(h Hook, e Event) {
+	ws.Logger.Info().Str("hook", h.Name).Str("event", e.ID).Int("size", len(e.Body)).Msg("webhook event received")
+	if !ws.config.Notify {
+		return
+	}
+	preview := e.Body
+	if len(preview) > maxPreviewSize {
+		preview = preview[:maxPreviewSize] + "..."
+	}
+	ws.SendNotification("notifications/message", map[string]any{
+		"level":  mcp.LoggingLevelInfo,
+		"logger": "webhook",
+		"data": map[string]any{
+			"message":      fmt.Sprintf("webhook %s received event %s, use webhook_list_events to read it", h.Name, e.ID),
+			"hook":         h.Name,
+			"event_id":     e.ID,
+			"content_type": e.ContentType,
+			"preview":      preview,
+		},
+	})
+}
+
+// hookURL returns the delivery URL of a hook.
+func (ws *WebhookServer) hookURL(h Hook) string {
+	base := ws.config.PublicURL
+	if base == "" {
+		base = "http://" + ws.config.ListenAddr
+	}
+	return fmt.Sprintf("%s/hooks/%s", strings.TrimRight(base, "/"), h.ID)
+}
+
+// handleCreate handles creating a hook.
+func (ws *WebhookServer) handleCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name, ok := args["name"].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return mcp.NewToolResultError("name must be a non-empty string"), nil
+	}
+	name = strings.TrimSpace(name)
+	if _, err := ws.store.Hook(name); err == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("hook %s already exists", name)), nil
+	}
+	h, err := ws.store.CreateHook(name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create hook: %s", err.Error())), nil
+	}
+	url := ws.hookURL(h)
+	if ws.httpServer == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("hook %s created, but the webhook listener is not running, check that %s is free and restart MoLing", h.Name, ws.config.ListenAddr)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Hook %s created.\nURL: %s?token=%s\nToken: %s\n"+
+		"The token can also be sent in the %s header, as a bearer token, or used as the GitHub webhook secret (URL: %s).",
+		h.Name, url, h.Token, h.Token, tokenHeader, url)), nil
+}
+
+// handleListEvents handles listing the events of a hook, or all hooks.
+func (ws *WebhookServer) handleListEvents(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	hookName := abstract.GetString(args, "hook", "")
+	if hookName == "" {
+		hooks := ws.store.Hooks()
+		if len(hooks) == 0 {
+			return mcp.NewToolResultText("No hooks, use webhook_create to create one"), nil
+		}
+		var sb strings.Builder
+		for _, h := range hooks {
+			events, err := ws.store.Events(h.ID, time.Time{}, 0)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to read events of %s: %s", h.Name, err.Error())), nil
+			}
+			sb.WriteString(fmt.Sprintf("- %s (id: %s, url: %s, events: %d)\n", h.Name, h.ID, ws.hookURL(h), len(events)))
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+
+	h, err := ws.store.Hook(hookName)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("hook %s not found", hookName)), nil
+	}
+	var since time.Time
+	if s := abstract.GetString(args, "since", ""); s != "" {
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid since time %s: %s", s, err.Error())), nil
+		}
+	}
+	limit := abstract.GetInt(args, "limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	events, err := ws.store.Events(h.ID, since, limit)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read events: %s", err.Error())), nil
+	}
+	if len(events) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No events received by hook %s", h.Name)), nil
+	}
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal events: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleDelete handles deleting a hook.
+func (ws *WebhookServer) handleDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	hookName, ok := args["hook"].(string)
+	if !ok || hookName == "" {
+		return mcp.NewToolResultError("hook must be a non-empty string"), nil
+	}
+	h, err := ws.store.DeleteHook(hookName)
+	if errors.Is(err, ErrHookNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("hook %s not found", hookName)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete hook: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Hook %s deleted", h.Name)), nil
+}
+
+// Config returns the configuration of the service as a string.
+func (ws *WebhookServer) Config() string {
+	cfg, err := json.Marshal(ws.config)
+	if err != nil {
+		ws.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (ws *WebhookServer) Name() comm.MoLingServerType {
+	return WebhookServerName
+}
+
+// Close stops the HTTP listener.
+func (ws *WebhookServer) Close() error {
+	ws.Logger.Debug().Msg("WebhookServer closed")
+	if ws.httpServer == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return ws.httpServer.Shutdown(ctx)
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (ws *WebhookServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(ws.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return ws.config.Check()
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tokenHeader is the request header that can carry the hook token instead of the query string.
+const tokenHeader = "X-Webhook-Token"
+
+// redactedHeaders are not stored with events.
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	tokenHeader:     true,
+}
+
+// receiver accepts webhook deliveries at /hooks/{id} and stores them.
+type receiver struct {
+	store          *Store
+	maxPayloadSize int64
+	onEvent        func(h Hook, e Event)
+}
+
+func (rc *receiver) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks/{id}", rc.handleDelivery)
+	return mux
+}
+
+func (rc *receiver) handleDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h, err := rc.store.Hook(r.PathValue("id"))
+	if err != nil || h.ID != r.PathValue("id") {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rc.maxPayloadSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !authorized(r, h.Token, body) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	query.Del("token")
+	e := Event{
+		HookID:      h.ID,
+		ReceivedAt:  time.Now(),
+		Method:      r.Method,
+		RemoteAddr:  r.RemoteAddr,
+		ContentType: r.Header.Get("Content-Type"),
+		Headers:     make(map[string]string),
+		Query:       query.Encode(),
+	}
+	for k, v := range r.Header {
+		if !redactedHeaders[k] {
+			e.Headers[k] = strings.Join(v, ", ")
+		}
+	}
+	if utf8.Valid(body) {
+		e.Body = string(body)
+	} else {
+		e.Body = base64.StdEncoding.EncodeToString(body)
+		e.Encoding = "base64"
+	}
+	e, err = rc.store.AddEvent(e)
+	if err != nil {
+		http.Error(w, "failed to store event", http.StatusInternalServerError)
+		return
+	}
+	if rc.onEvent != nil {
+		rc.onEvent(h, e)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": e.ID})
+}
+
+// authorized checks the hook token passed as ?token=, X-Webhook-Token or a bearer token,
+// or a GitHub style X-Hub-Signature-256 HMAC of the body keyed with the token.
+func authorized(r *http.Request, token string, body []byte) bool {
+	candidates := []string{
+		r.URL.Query().Get("token"),
+		r.Header.Get(tokenHeader),
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+	}
+	for _, c := range candidates {
+		if c != "" && subtle.ConstantTimeCompare([]byte(c), []byte(token)) == 1 {
+			return true
+		}
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(token))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig), []byte(expected))
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrHookNotFound is returned when a hook does not exist.
+var ErrHookNotFound = errors.New("hook not found")
+
+// Hook is an inbox that external systems post events to.
+type Hook struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event is a payload received by a hook.
+type Event struct {
+	ID          string            `json:"id"`
+	HookID      string            `json:"hook_id"`
+	ReceivedAt  time.Time         `json:"received_at"`
+	Method      string            `json:"method"`
+	RemoteAddr  string            `json:"remote_addr"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Query       string            `json:"query,omitempty"`
+	Body        string            `json:"body"`
+	Encoding    string            `json:"encoding,omitempty"` // Encoding is "base64" when the body is not valid UTF-8.
+}
+
+// Store persists hooks in hooks.json and the events of each hook in events/{hook id}.json.
+type Store struct {
+	path      string
+	maxEvents int
+
+	mu     sync.Mutex
+	hooks  []Hook
+	events map[string][]Event
+}
+
+// NewStore creates a store rooted at path and loads the existing hooks.
+func NewStore(path string, maxEvents int) (*Store, error) {
+	err := os.MkdirAll(filepath.Join(path, "events"), 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook directory: %w", err)
+	}
+	s := &Store{path: path, maxEvents: maxEvents, events: make(map[string][]Event)}
+	data, err := os.ReadFile(filepath.Join(path, "hooks.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read hooks: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &s.hooks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse hooks: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// CreateHook creates a hook with a random id and token.
+func (s *Store) CreateHook(name string) (Hook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := Hook{ID: randomHex(8), Name: name, Token: randomHex(24), CreatedAt: time.Now()}
+	hooks := append(append([]Hook{}, s.hooks...), h)
+	err := writeJSON(filepath.Join(s.path, "hooks.json"), hooks)
+	if err != nil {
+		return Hook{}, err
+	}
+	s.hooks = hooks
+	return h, nil
+}
+
+// DeleteHook removes a hook, by id or name, together with its events.
+func (s *Store) DeleteHook(idOrName string) (Hook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, h := range s.hooks {
+		if h.ID != idOrName && h.Name != idOrName {
+			continue
+		}
+		hooks := append(append([]Hook{}, s.hooks[:i]...), s.hooks[i+1:]...)
+		err := writeJSON(filepath.Join(s.path, "hooks.json"), hooks)
+		if err != nil {
+			return Hook{}, err
+		}
+		s.hooks = hooks
+		delete(s.events, h.ID)
+		_ = os.Remove(s.eventFile(h.ID))
+		return h, nil
+	}
+	return Hook{}, ErrHookNotFound
+}
+
+// Hook finds a hook by id or name.
+func (s *Store) Hook(idOrName string) (Hook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.hooks {
+		if h.ID == idOrName || h.Name == idOrName {
+			return h, nil
+		}
+	}
+	return Hook{}, ErrHookNotFound
+}
+
+// Hooks returns all hooks, oldest first.
+func (s *Store) Hooks() []Hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Hook{}, s.hooks...)
+}
+
+// AddEvent stores an event, dropping the oldest events beyond the limit.
+func (s *Store) AddEvent(e Event) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, err := s.loadEvents(e.HookID)
+	if err != nil {
+		return Event{}, err
+	}
+	e.ID = randomHex(8)
+	events = append(append([]Event{}, events...), e)
+	if len(events) > s.maxEvents {
+		events = events[len(events)-s.maxEvents:]
+	}
+	err = writeJSON(s.eventFile(e.HookID), events)
+	if err != nil {
+		return Event{}, err
+	}
+	s.events[e.HookID] = events
+	return e, nil
+}
+
+// Events returns the events of a hook received after since, newest first.
+func (s *Store) Events(hookID string, since time.Time, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, err := s.loadEvents(hookID)
+	if err != nil {
+		return nil, err
+	}
+	var result []Event
+	for _, e := range events {
+		if e.ReceivedAt.After(since) {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ReceivedAt.After(result[j].ReceivedAt)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *Store) loadEvents(hookID string) ([]Event, error) {
+	if events, ok := s.events[hookID]; ok {
+		return events, nil
+	}
+	var events []Event
+	data, err := os.ReadFile(s.eventFile(hookID))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &events)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse events: %w", err)
+		}
+	}
+	s.events[hookID] = events
+	return events, nil
+}
+
+func (s *Store) eventFile(hookID string) string {
+	return filepath.Join(s.path, "events", hookID+".json")
+}
+
+// writeJSON atomically writes v to path, readable by the owner only.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
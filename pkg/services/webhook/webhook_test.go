@@ -0,0 +1,99 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReceiver(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("Failed to create store: %s", err.Error())
+	}
+	h, err := store.CreateHook("ci")
+	if err != nil {
+		t.Fatalf("Failed to create hook: %s", err.Error())
+	}
+	var received []Event
+	rc := &receiver{store: store, maxPayloadSize: 64, onEvent: func(h Hook, e Event) {
+		received = append(received, e)
+	}}
+	ts := httptest.NewServer(rc.routes())
+	defer ts.Close()
+
+	post := func(url, body string, header map[string]string) int {
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %s", err.Error())
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	hookURL := ts.URL + "/hooks/" + h.ID
+	if code := post(hookURL, `{"a":1}`, nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", code)
+	}
+	if code := post(hookURL+"?token="+h.Token, `{"a":1}`, nil); code != http.StatusAccepted {
+		t.Errorf("Expected 202 with query token, got %d", code)
+	}
+	if code := post(hookURL, `{"a":2}`, map[string]string{tokenHeader: h.Token}); code != http.StatusAccepted {
+		t.Errorf("Expected 202 with header token, got %d", code)
+	}
+	mac := hmac.New(sha256.New, []byte(h.Token))
+	mac.Write([]byte(`{"a":3}`))
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if code := post(hookURL, `{"a":3}`, map[string]string{"X-Hub-Signature-256": sig}); code != http.StatusAccepted {
+		t.Errorf("Expected 202 with a valid signature, got %d", code)
+	}
+	if code := post(hookURL, `{"a":4}`, map[string]string{"X-Hub-Signature-256": sig}); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a wrong signature, got %d", code)
+	}
+	if code := post(hookURL+"?token="+h.Token, strings.Repeat("x", 100), nil); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large payload, got %d", code)
+	}
+	if code := post(ts.URL+"/hooks/unknown?token="+h.Token, `{}`, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown hook, got %d", code)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(received))
+	}
+	// only the newest max_events events are kept
+	events, err := store.Events(h.ID, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Failed to read events: %s", err.Error())
+	}
+	if len(events) != 2 || events[0].Body != `{"a":3}` {
+		t.Errorf("Expected the 2 newest events, got %v", events)
+	}
+	if _, ok := events[1].Headers[tokenHeader]; ok {
+		t.Errorf("Token header must not be stored")
+	}
+}
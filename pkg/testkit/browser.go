@@ -0,0 +1,55 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// FakeBrowser is a browser backend that runs no browser, for the SetBackend method of the browser service. It
+// records the actions of every run and answers them with Handle if set, else with Err.
+type FakeBrowser struct {
+	// Handle answers a run, for example by filling the values that the actions read from the page.
+	Handle func(ctx context.Context, actions ...chromedp.Action) error
+	// Err is returned by the runs when Handle is not set.
+	Err error
+
+	lock sync.Mutex
+	runs [][]chromedp.Action
+}
+
+// Run records the actions and answers them.
+func (fb *FakeBrowser) Run(ctx context.Context, actions ...chromedp.Action) error {
+	fb.lock.Lock()
+	fb.runs = append(fb.runs, actions)
+	handle, err := fb.Handle, fb.Err
+	fb.lock.Unlock()
+	if handle != nil {
+		return handle(ctx, actions...)
+	}
+	return err
+}
+
+// Runs returns the actions of the runs so far, in order.
+func (fb *FakeBrowser) Runs() [][]chromedp.Action {
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	return append([][]chromedp.Action(nil), fb.runs...)
+}
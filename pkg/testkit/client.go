@@ -0,0 +1,85 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package testkit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// Client calls tools through a MoLing server in the same process, with the requests an MCP client sends. The calls
+// pass through the middleware of the server, such as auth, the cache and the background jobs.
+type Client struct {
+	t      testing.TB
+	ctx    context.Context
+	server *server.MoLingServer
+}
+
+// NewClient starts a MoLing server with the services, with the configuration in ctx, and returns a client of it.
+// The server stops when the test ends. It does not close the services, create them with NewService.
+func NewClient(t testing.TB, ctx context.Context, srvs ...abstract.Service) *Client {
+	t.Helper()
+	cfg, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		t.Fatal("the context holds no MoLingConfig, create it with NewContext")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	srv, err := server.NewMoLingServer(ctx, srvs, *cfg)
+	if err != nil {
+		t.Fatalf("failed to create the server: %v", err)
+	}
+	return &Client{t: t, ctx: ctx, server: srv}
+}
+
+// Server returns the server of the client, for example to add middleware with Use.
+func (c *Client) Server() *server.MoLingServer {
+	return c.server
+}
+
+// CallTool calls a tool. It returns an error if the call fails at the protocol level, such as for an unknown tool,
+// and the result of the tool otherwise, which may be an error result.
+func (c *Client) CallTool(name string, args map[string]any) (*mcp.CallToolResult, error) {
+	return c.server.CallTool(c.ctx, name, args)
+}
+
+// MustCallTool calls a tool and fails the test if the call fails at the protocol level.
+func (c *Client) MustCallTool(name string, args map[string]any) *mcp.CallToolResult {
+	c.t.Helper()
+	result, err := c.CallTool(name, args)
+	if err != nil {
+		c.t.Fatalf("failed to call %s: %v", name, err)
+	}
+	return result
+}
+
+// ListTools returns the tools the server lists to clients, sorted by name.
+func (c *Client) ListTools() []mcp.Tool {
+	c.t.Helper()
+	tools, err := c.server.ListTools(c.ctx)
+	if err != nil {
+		c.t.Fatalf("failed to list the tools: %v", err)
+	}
+	return tools
+}
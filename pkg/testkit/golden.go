@@ -0,0 +1,74 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package testkit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the golden files instead of comparing
+// with them, e.g. MOLING_UPDATE_GOLDEN=1 go test ./pkg/services/filesystem/.
+const UpdateGoldenEnv = "MOLING_UPDATE_GOLDEN"
+
+// AssertGolden compares got, as indented JSON, with the golden file testdata/<name>.golden of the package under
+// test. Replacements are pairs of old and new strings replaced in the JSON first, to keep values that change from
+// run to run, such as temporary directories, out of the golden file.
+func AssertGolden(t testing.TB, name string, got any, replacements ...string) {
+	t.Helper()
+	if len(replacements)%2 != 0 {
+		t.Fatal("replacements must be pairs of old and new strings")
+	}
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode %s: %v", name, err)
+	}
+	actual := strings.NewReplacer(replacements...).Replace(string(data)) + "\n"
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateGoldenEnv) == "1" {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, []byte(actual), 0o644)
+		}
+		if err != nil {
+			t.Fatalf("failed to update %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s, run the test with %s=1 to create it: %v", path, UpdateGoldenEnv, err)
+	}
+	if actual != string(want) {
+		t.Fatalf("%s does not match %s, run the test with %s=1 to update it\n--- got\n%s--- want\n%s", name, path, UpdateGoldenEnv, actual, want)
+	}
+}
+
+// ResultText returns the text contents of a tool result, one per line.
+func ResultText(result *mcp.CallToolResult) string {
+	texts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package testkit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// ToolHandler is the handler of a tool, as services register it with AddTool.
+type ToolHandler = server.ToolHandlerFunc
+
+// NewServiceAs creates a service as NewService does, in a context from NewContext, and returns it as its concrete
+// type, so that tests can call its handlers and read its fields.
+func NewServiceAs[S abstract.Service](t testing.TB, factory abstract.ServiceFactory, cfg map[string]any) S {
+	t.Helper()
+	ctx, _ := NewContext(t)
+	srv, ok := NewService(t, ctx, factory, cfg).(S)
+	if !ok {
+		t.Fatalf("the factory does not create a %T", srv)
+	}
+	return srv
+}
+
+// CallHandler calls a tool handler directly, without a server, and returns the text of the result and whether it
+// is an error result. The test fails if the handler returns an error.
+func CallHandler(t testing.TB, handler ToolHandler, args map[string]any) (string, bool) {
+	t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = args
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("failed to call the tool: %v", err)
+	}
+	return ResultText(result), result.IsError
+}
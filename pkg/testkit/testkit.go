@@ -0,0 +1,128 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package testkit helps service authors write integration tests of their tools. It builds the context services
+// are created with, on a temporary base path, calls tools through an in-process MoLing server the way MCP clients
+// do, replaces Chrome with a fake browser backend and compares results with golden files.
+//
+//	ctx, _ := testkit.NewContext(t)
+//	srv := testkit.NewService(t, ctx, filesystem.NewFilesystemServer, nil)
+//	client := testkit.NewClient(t, ctx, srv)
+//	result := client.MustCallTool("read_file", map[string]any{"path": "notes.txt"})
+//	testkit.AssertGolden(t, "read_file", result)
+package testkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// baseDirectories are the directories MoLing creates in its base path.
+var baseDirectories = []string{"logs", "config", "browser", "data", "cache"}
+
+// NewConfig returns a MoLingConfig whose base path is a temporary directory of the test, with the directories
+// MoLing creates in it.
+func NewConfig(t testing.TB) *config.MoLingConfig {
+	t.Helper()
+	cfg := &config.MoLingConfig{
+		Version:    "test",
+		ServerName: "MoLing",
+		ConfigFile: filepath.Join("config", "config.json"),
+		BasePath:   t.TempDir(),
+		Module:     "all",
+	}
+	for _, dir := range baseDirectories {
+		if err := os.MkdirAll(filepath.Join(cfg.BasePath, dir), 0o700); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	cfg.SetLogger(NewLogger(t))
+	return cfg
+}
+
+// NewLogger returns a logger that writes to the log of the test, shown with go test -v or when the test fails.
+// What goroutines of the services or the server log after the test ended is dropped.
+func NewLogger(t testing.TB) zerolog.Logger {
+	w := &testWriter{t: t}
+	t.Cleanup(w.close)
+	return zerolog.New(w).With().Timestamp().Logger()
+}
+
+// testWriter writes to the log of a test until the test ends, which testing does not allow after.
+type testWriter struct {
+	lock  sync.Mutex
+	t     testing.TB
+	ended bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.ended {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w *testWriter) close() {
+	w.lock.Lock()
+	w.ended = true
+	w.lock.Unlock()
+}
+
+// NewContext returns the context services are created with, holding a configuration from NewConfig and a logger
+// from NewLogger. Change the returned configuration before creating services to test other settings.
+func NewContext(t testing.TB) (context.Context, *config.MoLingConfig) {
+	t.Helper()
+	cfg := NewConfig(t)
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, cfg)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, NewLogger(t))
+	return ctx, cfg
+}
+
+// NewService creates a service with its factory, loads cfg as the configuration of the service if it is not nil
+// and initializes it, as MoLing does on start. The service is closed when the test ends.
+func NewService(t testing.TB, ctx context.Context, factory abstract.ServiceFactory, cfg map[string]any) abstract.Service {
+	t.Helper()
+	srv, err := factory(ctx)
+	if err != nil {
+		t.Fatalf("failed to create the service: %v", err)
+	}
+	if cfg != nil {
+		if err = srv.LoadConfig(cfg); err != nil {
+			t.Fatalf("failed to load the configuration of %s: %v", srv.Name(), err)
+		}
+	}
+	if err = srv.Init(); err != nil {
+		t.Fatalf("failed to initialize %s: %v", srv.Name(), err)
+	}
+	t.Cleanup(func() {
+		if err := srv.Close(); err != nil {
+			t.Errorf("failed to close %s: %v", srv.Name(), err)
+		}
+	})
+	return srv
+}
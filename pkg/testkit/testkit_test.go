@@ -0,0 +1,72 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package testkit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/filesystem"
+	"github.com/gojue/moling/pkg/testkit"
+)
+
+func TestClient(t *testing.T) {
+	ctx, cfg := testkit.NewContext(t)
+	if err := os.WriteFile(filepath.Join(cfg.BasePath, "data", "notes.txt"), []byte("buy milk\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv := testkit.NewService(t, ctx, filesystem.NewFilesystemServer, map[string]any{"allowed_dir": filepath.Join(cfg.BasePath, "data")})
+	client := testkit.NewClient(t, ctx, srv)
+
+	if len(client.ListTools()) == 0 {
+		t.Fatal("expected the tools of the filesystem service")
+	}
+	result := client.MustCallTool("read_file", map[string]any{"path": "notes.txt"})
+	if result.IsError {
+		t.Fatalf("unexpected error %s", testkit.ResultText(result))
+	}
+	testkit.AssertGolden(t, "read_file", result, cfg.BasePath, "$BASE")
+	if _, err := client.CallTool("nope", nil); err == nil {
+		t.Fatal("expected an error for an unknown tool")
+	}
+}
+
+func TestFakeBrowser(t *testing.T) {
+	ctx, _ := testkit.NewContext(t)
+	srv := testkit.NewService(t, ctx, browser.NewBrowserServer, nil)
+	fake := &testkit.FakeBrowser{}
+	srv.(*browser.BrowserServer).SetBackend(fake)
+	client := testkit.NewClient(t, ctx, srv)
+
+	result := client.MustCallTool("browser_navigate", map[string]any{"url": "https://example.com"})
+	if result.IsError || len(fake.Runs()) != 1 {
+		t.Fatalf("expected one run without a browser, got %s", testkit.ResultText(result))
+	}
+	fake.Handle = func(ctx context.Context, actions ...chromedp.Action) error {
+		return errors.New("net::ERR_NAME_NOT_RESOLVED")
+	}
+	result = client.MustCallTool("browser_navigate", map[string]any{"url": "https://nowhere.invalid"})
+	if !result.IsError {
+		t.Fatal("the error of the backend must fail the tool call")
+	}
+}
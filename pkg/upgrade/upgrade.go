@@ -0,0 +1,437 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package upgrade replaces the MoLing binary with a release from GitHub. The archive of a release is only installed
+// once its SHA-256 checksum matches, and once the Ed25519 signature of the checksum file is valid if the binary
+// was built with the public key of the releases. The previous binary is kept for a rollback.
+package upgrade
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRepository is the GitHub repository of the releases.
+	DefaultRepository = "gojue/moling"
+	// DefaultAPI is the GitHub API.
+	DefaultAPI = "https://api.github.com"
+	// maxArchiveSize bounds the downloaded archives.
+	maxArchiveSize = 512 << 20
+)
+
+// PublicKey is the base64 Ed25519 public key, raw or in PKIX DER form, that signs the checksum files of the releases.
+// It is set at build time with -ldflags "-X github.com/gojue/moling/pkg/upgrade.PublicKey=...". Without it, only
+// the checksums of the archives are verified.
+var PublicKey string
+
+var (
+	// ErrNoAsset is returned for releases without an archive for the operating system and architecture.
+	ErrNoAsset = errors.New("the release has no archive for this system")
+	// ErrNoBackup is returned by Rollback if there is no previous binary.
+	ErrNoBackup = errors.New("there is no previous binary to roll back to")
+
+	versionPattern = regexp.MustCompile(`v(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.-]+)?`)
+)
+
+// Asset is a file of a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a GitHub release.
+type Release struct {
+	Tag       string    `json:"tag_name"`
+	Name      string    `json:"name"`
+	Published time.Time `json:"published_at"`
+	URL       string    `json:"html_url"`
+	Assets    []Asset   `json:"assets"`
+}
+
+// asset returns the asset with the given name.
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Updater finds, downloads and verifies releases.
+type Updater struct {
+	API        string // API is the base URL of the GitHub API.
+	Repository string // Repository is the owner/name of the repository of the releases.
+	Token      string // Token authenticates the requests to the API, for higher rate limits, empty for none.
+	Client     *http.Client
+	PublicKey  ed25519.PublicKey // PublicKey verifies the signatures of the checksum files, nil to verify the checksums only.
+}
+
+// New returns an Updater of the MoLing releases with the given base64 public key, empty for none.
+func New(publicKey string) (*Updater, error) {
+	u := &Updater{
+		API:        DefaultAPI,
+		Repository: DefaultRepository,
+		Token:      os.Getenv("GITHUB_TOKEN"),
+		Client:     &http.Client{Timeout: 10 * time.Minute},
+	}
+	if publicKey == "" {
+		return u, nil
+	}
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	u.PublicKey = key
+	return u, nil
+}
+
+// ParsePublicKey parses a base64 Ed25519 public key, raw or in PKIX DER form.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key: %T is not an Ed25519 key", key)
+	}
+	return pub, nil
+}
+
+// Latest returns the latest release, prereleases excluded.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	return u.release(ctx, "latest")
+}
+
+// Release returns the release with the given tag, such as v0.2.0.
+func (u *Updater) Release(ctx context.Context, tag string) (*Release, error) {
+	return u.release(ctx, "tags/"+tag)
+}
+
+func (u *Updater) release(ctx context.Context, which string) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/%s", strings.TrimSuffix(u.API, "/"), u.Repository, which)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if u.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+u.Token)
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to look up the release: %s %s", url, resp.Status)
+	}
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid release: %w", err)
+	}
+	return &r, nil
+}
+
+// ArchiveName returns the name of the archive of a release for an operating system and architecture, as the
+// release workflow names it.
+func ArchiveName(tag, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("moling-%s-%s-%s%s", tag, goos, goarch, ext)
+}
+
+// BinaryName returns the name of the MoLing binary on an operating system.
+func BinaryName(goos string) string {
+	if goos == "windows" {
+		return "moling.exe"
+	}
+	return "moling"
+}
+
+// Download downloads the archive of the release for this system with its checksum file, and its signature if the
+// Updater has a public key, verifies them and extracts the binary to a new file in dir. It returns the path of the
+// file, which the caller installs or removes.
+func (u *Updater) Download(ctx context.Context, r *Release, dir string) (string, error) {
+	name := ArchiveName(r.Tag, runtime.GOOS, runtime.GOARCH)
+	archive, ok := r.asset(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s is missing from %s", ErrNoAsset, name, r.Tag)
+	}
+	checksum, ok := r.asset(name + ".sha256")
+	if !ok {
+		return "", fmt.Errorf("the release %s has no checksum of %s and cannot be verified", r.Tag, name)
+	}
+	sums, err := u.fetch(ctx, checksum.URL)
+	if err != nil {
+		return "", err
+	}
+	var sig []byte
+	if u.PublicKey != nil {
+		signature, ok := r.asset(name + ".sha256.sig")
+		if !ok {
+			return "", fmt.Errorf("the release %s has no signature of %s and cannot be verified", r.Tag, name)
+		}
+		if sig, err = u.fetch(ctx, signature.URL); err != nil {
+			return "", err
+		}
+	}
+	data, err := u.fetch(ctx, archive.URL)
+	if err != nil {
+		return "", err
+	}
+	if err := Verify(data, sums, sig, u.PublicKey); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	binary, err := Extract(name, data, BinaryName(runtime.GOOS))
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, ".moling-upgrade-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(binary); err == nil {
+		err = f.Chmod(0o755)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// fetch downloads a file of a release.
+func (u *Updater) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxArchiveSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", url, maxArchiveSize>>20)
+	}
+	return data, nil
+}
+
+// Verify checks the archive against the first SHA-256 checksum of the checksum file, in the format of sha256sum.
+// With a public key, the checksum file must carry a valid signature, raw or base64.
+func Verify(archive, sums, sig []byte, key ed25519.PublicKey) error {
+	if key != nil {
+		if len(sig) != ed25519.SignatureSize {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+			if err != nil || len(decoded) != ed25519.SignatureSize {
+				return errors.New("invalid signature of the checksum file")
+			}
+			sig = decoded
+		}
+		if !ed25519.Verify(key, sums, sig) {
+			return errors.New("the signature of the checksum file does not match the public key of the releases")
+		}
+	}
+	fields := strings.Fields(string(sums))
+	if len(fields) == 0 {
+		return errors.New("the checksum file is empty")
+	}
+	want, err := hex.DecodeString(fields[0])
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("invalid checksum %q", fields[0])
+	}
+	got := sha256.Sum256(archive)
+	if !bytes.Equal(got[:], want) {
+		return fmt.Errorf("checksum mismatch: got %x, want %x", got, want)
+	}
+	return nil
+}
+
+// Extract returns the file with the given base name from a .tar.gz or .zip archive.
+func Extract(archiveName string, data []byte, binary string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", archiveName, err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || path.Base(f.Name) != binary {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxArchiveSize))
+		}
+		return nil, fmt.Errorf("%s is missing from %s", binary, archiveName)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive %s: %w", archiveName, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s is missing from %s", binary, archiveName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive %s: %w", archiveName, err)
+		}
+		if h.Typeflag == tar.TypeReg && path.Base(h.Name) == binary {
+			return io.ReadAll(io.LimitReader(tr, maxArchiveSize))
+		}
+	}
+}
+
+// BackupPath returns the path the previous binary is kept at.
+func BackupPath(target string) string {
+	return target + ".old"
+}
+
+// Install replaces the binary at target with the new binary, keeping the previous one at BackupPath. The new binary
+// must be on the same file system, such as in the directory of target. The binary at target is replaced by a
+// rename, so a running MoLing keeps running the previous binary until it restarts.
+func Install(newBinary, target string) (string, error) {
+	backup := BackupPath(target)
+	if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to remove the previous backup: %w", err)
+	}
+	// A hard link keeps target in place until the rename replaces it. Windows cannot replace a running binary,
+	// but it can rename it.
+	if runtime.GOOS == "windows" || os.Link(target, backup) != nil {
+		if err := os.Rename(target, backup); err != nil {
+			return "", fmt.Errorf("failed to keep the previous binary: %w", err)
+		}
+	}
+	if err := os.Rename(newBinary, target); err != nil {
+		if _, serr := os.Stat(target); errors.Is(serr, os.ErrNotExist) {
+			_ = os.Rename(backup, target)
+		}
+		return "", fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	return backup, nil
+}
+
+// Rollback swaps the binary at target with the one kept at BackupPath, so that a second rollback undoes the first.
+func Rollback(target string) error {
+	backup := BackupPath(target)
+	if _, err := os.Stat(backup); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNoBackup
+		}
+		return err
+	}
+	swap := target + ".swap"
+	if err := os.Rename(target, swap); err != nil {
+		return fmt.Errorf("failed to move the current binary: %w", err)
+	}
+	if err := os.Rename(backup, target); err != nil {
+		_ = os.Rename(swap, target)
+		return fmt.Errorf("failed to restore the previous binary: %w", err)
+	}
+	return os.Rename(swap, backup)
+}
+
+// Executable returns the path of the running binary, with symbolic links resolved.
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// Version returns the release version, such as v0.2.0, in a version string of a build, such as
+// linux_amd64_v0.2.0_2025-05-01 10:00. It reports false for builds that are not of a release.
+func Version(s string) (string, bool) {
+	v := versionPattern.FindString(s)
+	return v, v != ""
+}
+
+// Newer reports whether the release version a is newer than b. Prereleases are older than their release.
+func Newer(a, b string) bool {
+	ma, mb := versionPattern.FindStringSubmatch(a), versionPattern.FindStringSubmatch(b)
+	if ma == nil {
+		return false
+	}
+	if mb == nil {
+		return true
+	}
+	for i := 1; i <= 3; i++ {
+		na, _ := strconv.Atoi(ma[i])
+		nb, _ := strconv.Atoi(mb[i])
+		if na != nb {
+			return na > nb
+		}
+	}
+	switch {
+	case ma[4] == mb[4]:
+		return false
+	case ma[4] == "":
+		return true
+	case mb[4] == "":
+		return false
+	}
+	return ma[4] > mb[4]
+}
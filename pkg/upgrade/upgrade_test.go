@@ -0,0 +1,175 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// testArchive returns a .tar.gz or .zip archive with the binary, as the release workflow builds it.
+func testArchive(t *testing.T, name string, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if filepath.Ext(name) == ".zip" {
+		t.Skip("zip archives are covered on the other systems")
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "./" + BinaryName(runtime.GOOS), Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownload(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := ArchiveName("v1.2.0", runtime.GOOS, runtime.GOARCH)
+	archive := testArchive(t, name, []byte("new binary"))
+	sums := []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(archive), name))
+	files := map[string][]byte{
+		name:                 archive,
+		name + ".sha256":     sums,
+		name + ".sha256.sig": ed25519.Sign(priv, sums),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/gojue/moling/releases/latest" {
+			rel := Release{Tag: "v1.2.0"}
+			for _, n := range []string{name, name + ".sha256", name + ".sha256.sig"} {
+				rel.Assets = append(rel.Assets, Asset{Name: n, URL: srv.URL + "/download/" + n})
+			}
+			_ = json.NewEncoder(w).Encode(rel)
+			return
+		}
+		data, ok := files[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	u := &Updater{API: srv.URL, Repository: DefaultRepository, Client: srv.Client(), PublicKey: pub}
+	rel, err := u.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path, err := u.Download(context.Background(), rel, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new binary" {
+		t.Fatalf("unexpected binary %q", data)
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	u.PublicKey = other
+	if _, err = u.Download(context.Background(), rel, dir); err == nil {
+		t.Fatal("a signature of another key must be rejected")
+	}
+	if err = Verify(archive, []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("other")), name)), nil, nil); err == nil {
+		t.Fatal("a checksum mismatch must be rejected")
+	}
+	rel.Assets = rel.Assets[1:]
+	if _, err = u.Download(context.Background(), rel, dir); err == nil {
+		t.Fatal("a release without an archive for the system must fail")
+	}
+}
+
+func TestInstallAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "moling")
+	if err := os.WriteFile(target, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rollback(target); err != ErrNoBackup {
+		t.Fatalf("a rollback without a backup must fail with ErrNoBackup, got %v", err)
+	}
+	next := filepath.Join(dir, ".moling-upgrade-1")
+	if err := os.WriteFile(next, []byte("new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := Install(next, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(p string) string {
+		data, _ := os.ReadFile(p)
+		return string(data)
+	}
+	if read(target) != "new" || read(backup) != "old" {
+		t.Fatalf("unexpected binaries after the install: %q, backup %q", read(target), read(backup))
+	}
+	if err = Rollback(target); err != nil {
+		t.Fatal(err)
+	}
+	if read(target) != "old" || read(backup) != "new" {
+		t.Fatalf("unexpected binaries after the rollback: %q, backup %q", read(target), read(backup))
+	}
+}
+
+func TestVersion(t *testing.T) {
+	if v, ok := Version("linux_amd64_v0.2.1_2025-05-01 10:00"); !ok || v != "v0.2.1" {
+		t.Fatalf("unexpected version %q", v)
+	}
+	if _, ok := Version("unknown_arm64_2025-03-22"); ok {
+		t.Fatal("a build without a version must not have one")
+	}
+	for _, c := range []struct {
+		a, b  string
+		newer bool
+	}{
+		{"v0.2.0", "v0.1.9", true},
+		{"v0.10.0", "v0.9.0", true},
+		{"v0.2.0", "v0.2.0", false},
+		{"v0.2.0", "v0.2.0-rc.1", true},
+		{"v0.2.0-rc.1", "v0.2.0", false},
+		{"v0.1.0", "dev", true},
+	} {
+		if got := Newer(c.a, c.b); got != c.newer {
+			t.Errorf("Newer(%s, %s) = %v", c.a, c.b, got)
+		}
+	}
+}
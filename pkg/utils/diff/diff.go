@@ -0,0 +1,206 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package diff computes line diffs with the Myers algorithm and renders them in unified format.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edit operations, written as the first character of the lines of a unified diff.
+const (
+	Equal  = ' '
+	Delete = '-'
+	Insert = '+'
+)
+
+// Edit is one line of a diff. A and B are the indexes of the next line in each input.
+type Edit struct {
+	Op   byte
+	Line string
+	A, B int
+}
+
+// SplitLines splits text into lines, keeping the line endings.
+func SplitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines computes a line diff with the Myers algorithm. When more than maxEdits edits are
+// needed, the differing middle part is reported as deleted and inserted as a whole.
+func Lines(a, b []string, maxEdits int) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var edits []Edit
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, Edit{Op: Equal, Line: a[i], A: i, B: i})
+	}
+	middle, ok := shortestEdit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], maxEdits)
+	if !ok {
+		middle = nil
+		for i, line := range a[prefix : len(a)-suffix] {
+			middle = append(middle, Edit{Op: Delete, Line: line, A: i, B: 0})
+		}
+		for i, line := range b[prefix : len(b)-suffix] {
+			middle = append(middle, Edit{Op: Insert, Line: line, A: len(a) - suffix - prefix, B: i})
+		}
+	}
+	for _, e := range middle {
+		e.A += prefix
+		e.B += prefix
+		edits = append(edits, e)
+	}
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, Edit{Op: Equal, Line: a[len(a)-i], A: len(a) - i, B: len(b) - i})
+	}
+	return edits
+}
+
+func shortestEdit(a, b []string, maxEdits int) ([]Edit, bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func backtrack(a, b []string, trace [][]int) []Edit {
+	x, y := len(a), len(b)
+	var reversed []Edit
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Edit{Op: Equal, Line: a[x], A: x, B: y})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, Edit{Op: Insert, Line: b[y], A: x, B: y})
+		} else {
+			x--
+			reversed = append(reversed, Edit{Op: Delete, Line: a[x], A: x, B: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, Edit{Op: Equal, Line: a[x], A: x, B: y})
+	}
+	edits := make([]Edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// Unified renders edits in unified diff format with the given number of context lines.
+func Unified(nameA, nameB string, edits []Edit, context int) string {
+	var sb strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", nameA, nameB))
+		}
+		start := max(0, i-context)
+		last := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].Op != Equal {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+		end := min(len(edits), last+context+1)
+		hunk := edits[start:end]
+		aCount, bCount := 0, 0
+		for _, e := range hunk {
+			if e.Op != Insert {
+				aCount++
+			}
+			if e.Op != Delete {
+				bCount++
+			}
+		}
+		aStart, bStart := hunk[0].A, hunk[0].B
+		if aCount > 0 {
+			aStart++
+		}
+		if bCount > 0 {
+			bStart++
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount)))
+		for _, e := range hunk {
+			sb.WriteByte(e.Op)
+			sb.WriteString(e.Line)
+			if !strings.HasSuffix(e.Line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven"
+	expected := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+\ No newline at end of file
+`
+	got := Unified("old", "new", Lines(SplitLines(a), SplitLines(b), 100), 3)
+	if got != expected {
+		t.Errorf("Unexpected diff:\n%s\nexpected:\n%s", got, expected)
+	}
+	if d := Unified("a", "b", Lines(SplitLines(a), SplitLines(a), 100), 3); d != "" {
+		t.Errorf("Expected no diff for identical texts, got:\n%s", d)
+	}
+	got = Unified("a", "b", Lines(SplitLines(""), SplitLines("x\n"), 100), 3)
+	if !strings.Contains(got, "@@ -0,0 +1 @@\n+x\n") {
+		t.Errorf("Unexpected diff against empty text:\n%s", got)
+	}
+
+	// the fallback for large differences must still describe b completely
+	edits := Lines(SplitLines("a\nb\nc\nd\n"), SplitLines("a\nx\ny\nd\n"), 1)
+	var rebuilt strings.Builder
+	for _, e := range edits {
+		if e.Op != Delete {
+			rebuilt.WriteString(e.Line)
+		}
+	}
+	if rebuilt.String() != "a\nx\ny\nd\n" {
+		t.Errorf("Fallback diff does not rebuild b: %q", rebuilt.String())
+	}
+}
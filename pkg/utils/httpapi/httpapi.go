@@ -0,0 +1,61 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package httpapi holds what the services that call HTTP APIs share: sending a request with a bounded response and
+// reporting a failed call as a tool error.
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+)
+
+// MaxResponseSize caps the size of an API response.
+const MaxResponseSize = 16 * 1024 * 1024
+
+// Do sends a request and reads the response body, failing when it exceeds MaxResponseSize. The status is left to the
+// caller, since APIs report errors in their own format.
+func Do(client *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if len(data) > MaxResponseSize {
+		return nil, nil, fmt.Errorf("response from %s exceeds %d bytes", req.URL.Host, MaxResponseSize)
+	}
+	return resp, data, nil
+}
+
+// ErrorResult logs a failed API call with the field that identifies its target, and turns it into a tool error.
+func ErrorResult(logger zerolog.Logger, op, field, value string, err error) *mcp.CallToolResult {
+	logger.Error().Err(err).Str(field, value).Msg(op + " failed")
+	return mcp.NewToolResultError(fmt.Sprintf("%s failed: %s", op, err.Error()))
+}
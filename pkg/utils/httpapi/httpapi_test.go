@@ -0,0 +1,51 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package httpapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big" {
+			_, _ = w.Write(bytes.Repeat([]byte("x"), MaxResponseSize+1))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/missing", nil)
+	resp, data, err := Do(ts.Client(), req)
+	if err != nil || resp.StatusCode != http.StatusNotFound || string(data) != `{"message":"Not Found"}` {
+		t.Fatalf("expected the error body with its status, got %v %q", err, data)
+	}
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"/big", nil)
+	if _, _, err = Do(ts.Client(), req); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("expected an error for an oversized response, got %v", err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "http://127.0.0.1:1/", nil)
+	if _, _, err = Do(ts.Client(), req); err == nil || !strings.HasPrefix(err.Error(), "request to 127.0.0.1:1 failed: ") || strings.Contains(err.Error(), "Get ") {
+		t.Fatalf("expected the unwrapped transport error, got %v", err)
+	}
+}
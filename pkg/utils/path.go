@@ -0,0 +1,106 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideAllowedDirs is the error of a path that is not inside the allowed directories, after following symlinks.
+var ErrOutsideAllowedDirs = errors.New("access denied - path outside the allowed directories")
+
+// Within reports whether path is dir or inside it, comparing the cleaned paths without following symlinks.
+func Within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// CheckAllowedDirs makes the allowed_dirs of a service configuration absolute, in place. At least one directory is
+// required, ResolvePath joins relative paths to the first one.
+func CheckAllowedDirs(dirs []string) error {
+	if len(dirs) == 0 {
+		return fmt.Errorf("allowed_dirs must contain at least one directory")
+	}
+	for i, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid allowed directory %s: %w", dir, err)
+		}
+		dirs[i] = abs
+	}
+	return nil
+}
+
+// ResolvePath returns the absolute path of path if it is inside one of the allowed directories, joining relative
+// paths to the first one. Symlinks are followed: the whole path if it exists, else the nearest existing parent, for a
+// file about to be created. A symlink that points to nothing is refused, writing to it would create its target.
+func ResolvePath(path string, allowedDirs []string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path must be a non-empty string")
+	}
+	if len(allowedDirs) == 0 {
+		return "", fmt.Errorf("%w: no directory is allowed", ErrOutsideAllowedDirs)
+	}
+	abs := path
+	if !filepath.IsAbs(path) {
+		abs = filepath.Join(allowedDirs[0], path)
+	}
+	abs, err := filepath.Abs(abs)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %w", path, err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		if _, lerr := os.Lstat(abs); lerr == nil {
+			return "", fmt.Errorf("%w: %s is a symlink to a missing file", ErrOutsideAllowedDirs, path)
+		}
+		// A new file: what exists of its directory must be inside
+		parent := filepath.Dir(abs)
+		for {
+			if resolved, err = filepath.EvalSymlinks(parent); err == nil {
+				break
+			}
+			next := filepath.Dir(parent)
+			if next == parent {
+				return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+			}
+			parent = next
+		}
+	}
+	for _, dir := range allowedDirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil || !Within(dir, abs) {
+			continue
+		}
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			realDir = dir
+		}
+		if Within(realDir, resolved) {
+			return abs, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrOutsideAllowedDirs, path)
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	root := t.TempDir()
+	allowed, outside := filepath.Join(root, "allowed"), filepath.Join(root, "outside")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range map[string]string{"leaf": secret, "dir": outside, "dangling": filepath.Join(outside, "new.txt")} {
+		if err := os.Symlink(target, filepath.Join(allowed, name)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	for _, path := range []string{"leaf", "dir/secret.txt", "dir/new.txt", "dangling", "../outside/secret.txt", secret} {
+		if _, err := ResolvePath(path, []string{allowed}); !errors.Is(err, ErrOutsideAllowedDirs) {
+			t.Errorf("%s must be refused, got %v", path, err)
+		}
+	}
+	for path, want := range map[string]string{
+		"report.xlsx":          filepath.Join(allowed, "report.xlsx"),
+		"new/sub/report.xlsx":  filepath.Join(allowed, "new", "sub", "report.xlsx"),
+		filepath.Join(allowed): allowed,
+	} {
+		if got, err := ResolvePath(path, []string{allowed}); err != nil || got != want {
+			t.Errorf("%s: got %s %v, want %s", path, got, err, want)
+		}
+	}
+	if _, err := ResolvePath("", []string{allowed}); err == nil {
+		t.Error("an empty path must be refused")
+	}
+}
+
+func TestCheckAllowedDirs(t *testing.T) {
+	if err := CheckAllowedDirs(nil); err == nil {
+		t.Fatal("expected an error for no allowed directory")
+	}
+	dirs := []string{"data", t.TempDir()}
+	want0, _ := filepath.Abs("data")
+	want1 := dirs[1]
+	if err := CheckAllowedDirs(dirs); err != nil {
+		t.Fatal(err)
+	}
+	if dirs[0] != want0 || dirs[1] != want1 {
+		t.Fatalf("allowed dirs = %v, want [%s %s]", dirs, want0, want1)
+	}
+}
@@ -19,14 +19,86 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var pidFile *os.File
 
-// CreatePIDFile creates and locks a PID file to prevent multiple instances.
-func CreatePIDFile(pidFilePath string) error {
+// ErrInstanceRunning is returned by CreatePIDFile if another instance holds the PID file.
+var ErrInstanceRunning = errors.New("another instance is already running")
+
+// InstanceOwner describes the instance that holds a PID file. It is saved next to the PID file, since the PID file
+// cannot be read while it is locked on Windows.
+type InstanceOwner struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	Started    time.Time `json:"started"`
+	Version    string    `json:"version,omitempty"`
+	ListenAddr string    `json:"listen_addr,omitempty"` // ListenAddr is the SSE listen address, empty in STDIO mode.
+	Module     string    `json:"module,omitempty"`
+	Profile    string    `json:"profile,omitempty"`
+}
+
+func (o *InstanceOwner) String() string {
+	s := fmt.Sprintf("pid %d on %s, started %s", o.PID, o.Host, o.Started.Local().Format(time.DateTime))
+	if o.Version != "" {
+		s += ", version " + o.Version
+	}
+	if o.ListenAddr != "" {
+		s += ", listening on " + o.ListenAddr
+	} else {
+		s += ", STDIO mode"
+	}
+	if o.Profile != "" {
+		s += ", profile " + o.Profile
+	}
+	return s
+}
+
+// InstanceRunningError is the error of CreatePIDFile if another instance holds the PID file.
+type InstanceRunningError struct {
+	Path  string
+	Owner *InstanceOwner // Owner is the instance holding the file, nil if it did not save its description.
+}
+
+func (e *InstanceRunningError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("%s: %s", ErrInstanceRunning, e.Path)
+	}
+	return fmt.Sprintf("%s: %s, %s", ErrInstanceRunning, e.Path, e.Owner)
+}
+
+func (e *InstanceRunningError) Unwrap() error { return ErrInstanceRunning }
+
+// ownerFilePath returns the path of the description of the instance holding a PID file.
+func ownerFilePath(pidFilePath string) string {
+	return pidFilePath + ".json"
+}
+
+// ReadInstanceOwner returns the description of the instance that holds or held a PID file.
+func ReadInstanceOwner(pidFilePath string) (*InstanceOwner, error) {
+	data, err := os.ReadFile(ownerFilePath(pidFilePath))
+	if err != nil {
+		return nil, err
+	}
+	owner := &InstanceOwner{}
+	if err = json.Unmarshal(data, owner); err != nil {
+		return nil, fmt.Errorf("invalid instance description %s: %w", ownerFilePath(pidFilePath), err)
+	}
+	return owner, nil
+}
+
+// CreatePIDFile creates and locks a PID file to prevent multiple instances, and saves the description of the
+// instance next to it. The PID, host and start time of owner are filled in. If another instance holds the file,
+// the error is an *InstanceRunningError.
+func CreatePIDFile(pidFilePath string, owner InstanceOwner) error {
 	// Open or create the PID file
 	file, err := os.OpenFile(pidFilePath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -41,7 +113,9 @@ func CreatePIDFile(pidFilePath string) error {
 	}
 	if !locked {
 		_ = file.Close()
-		return fmt.Errorf("another instance is already running: %s", pidFilePath)
+		running := &InstanceRunningError{Path: pidFilePath}
+		running.Owner, _ = ReadInstanceOwner(pidFilePath)
+		return running
 	}
 
 	// Write the current PID to the file
@@ -57,25 +131,66 @@ func CreatePIDFile(pidFilePath string) error {
 		_ = file.Close()
 		return fmt.Errorf("failed to write PID to file: %w", err)
 	}
+	owner.PID = os.Getpid()
+	owner.Host, _ = os.Hostname()
+	owner.Started = time.Now()
+	data, _ := json.MarshalIndent(owner, "", "  ")
+	if err = os.WriteFile(ownerFilePath(pidFilePath), data, 0644); err != nil {
+		_ = unlockFile(file)
+		_ = file.Close()
+		return fmt.Errorf("failed to write the instance description: %w", err)
+	}
 
 	// Keep the file open to maintain the lock
 	pidFile = file
 	return nil
 }
 
-// RemovePIDFile releases the lock and removes the PID file.
+// RemovePIDFile removes the PID file and releases its lock. The files are removed while the lock is held where the
+// system allows it, so that they are not removed from under an instance that takes over.
 func RemovePIDFile(pidFilePath string) error {
-	if pidFile != nil {
-		err := unlockFile(pidFile)
-		if err != nil {
-			return fmt.Errorf("failed to unlock PID file: %w", err)
-		}
-		_ = pidFile.Close()
-		pidFile = nil
-		err = os.Remove(pidFilePath)
-		if err != nil {
+	if pidFile == nil {
+		return nil
+	}
+	_ = os.Remove(ownerFilePath(pidFilePath))
+	removed := os.Remove(pidFilePath) == nil
+	err := unlockFile(pidFile)
+	if err != nil {
+		return fmt.Errorf("failed to unlock PID file: %w", err)
+	}
+	_ = pidFile.Close()
+	pidFile = nil
+	if !removed {
+		// Windows does not remove open files.
+		if err = os.Remove(pidFilePath); err != nil {
 			return fmt.Errorf("failed to remove PID file: %w", err)
 		}
 	}
 	return nil
 }
+
+// ReadPIDFile returns the PID in a PID file and whether the instance that wrote it is still running, that is,
+// still holds the lock of the file. A file left behind by an instance that crashed is not locked. If the file cannot be
+// read while it is locked, as on Windows, the PID is taken from the instance description, 0 if there is none.
+func ReadPIDFile(pidFilePath string) (int, bool, error) {
+	file, err := os.OpenFile(pidFilePath, os.O_RDWR, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	locked, err := lockFile(file)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock PID file: %w", err)
+	}
+	if locked {
+		defer func() { _ = unlockFile(file) }()
+	}
+	data, _ := io.ReadAll(file)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	if pid == 0 && !locked {
+		if owner, err := ReadInstanceOwner(pidFilePath); err == nil {
+			pid = owner.PID
+		}
+	}
+	return pid, !locked, nil
+}
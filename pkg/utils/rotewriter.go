@@ -19,51 +19,53 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"sync"
+	"time"
 )
 
-// RotateWriter 是一个简单的日志轮转写入器
+// RotateWriter 是一个简单的日志轮转写入器，按大小或时长将日志文件轮转为 file.1、file.2 ……，file.1 为最新
 type RotateWriter struct {
-	filePaths    [2]string // 两个日志文件的路径
-	currentIndex int       // 当前写入的文件索引（0或1）
-	maxSize      int64     // 文件大小阈值（字节）
-	count        uint16    // 当前文件的写入次数
-	mu           sync.Mutex
-	file         *os.File // 当前打开的文件句柄
+	filePath   string        // 当前日志文件的路径
+	maxSize    int64         // 文件大小阈值（字节），0 表示不限制
+	maxAge     time.Duration // 文件时长阈值，自打开文件起计算，0 表示不限制
+	maxBackups int           // 保留的轮转文件个数
+	openedAt   time.Time     // 当前文件的打开时间
+	count      uint16        // 当前文件的写入次数
+	mu         sync.Mutex
+	file       *os.File // 当前打开的文件句柄
 }
 
-// NewRotateWriter 创建一个新的 RotateWriter 实例
+// NewRotateWriter 创建一个新的 RotateWriter 实例，超过 maxSize 时轮转，保留一个轮转文件
 func NewRotateWriter(filePath string, maxSize int64) (*RotateWriter, error) {
-	rw := &RotateWriter{
-		filePaths:    [2]string{filePath + ".1", filePath + ".2"},
-		currentIndex: 0,
-		maxSize:      maxSize,
-		count:        0,
-	}
-
-	// 初始化文件，确保两个文件都存在
-	for _, path := range rw.filePaths {
-		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-		if err != nil {
-			return nil, err
-		}
-		file.Close()
-	}
-
-	// 打开当前文件
-	file, err := os.OpenFile(rw.filePaths[rw.currentIndex], os.O_WRONLY|os.O_APPEND, 0644)
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		return nil, err
 	}
-	rw.file = file
+	return &RotateWriter{
+		filePath:   filePath,
+		maxSize:    maxSize,
+		maxBackups: 1,
+		openedAt:   time.Now(),
+		file:       file,
+	}, nil
+}
 
-	return rw, nil
+// SetRotation 设置轮转的大小阈值、时长阈值与保留的轮转文件个数
+func (rw *RotateWriter) SetRotation(maxSize int64, maxAge time.Duration, maxBackups int) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.maxSize = maxSize
+	rw.maxAge = maxAge
+	rw.maxBackups = max(maxBackups, 1)
 }
 
 // Write 实现 io.Writer 接口
 func (rw *RotateWriter) Write(p []byte) (n int, err error) {
-	err = rw.CheckFileSize()
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	err = rw.checkRotate()
 	if err != nil {
 		return 0, err
 	}
@@ -71,39 +73,60 @@ func (rw *RotateWriter) Write(p []byte) (n int, err error) {
 	return rw.file.Write(p)
 }
 
-// CheckFileSize 检查当前文件大小
-func (rw *RotateWriter) CheckFileSize() error {
-	rw.mu.Lock()
-	defer rw.mu.Unlock()
-	if rw.count >= 10 {
-		// 检查当前文件大小
-		fileInfo, err := rw.file.Stat()
-		if err != nil {
-			return err
-		}
-
-		// 如果文件大小超过阈值，切换到另一个文件并清空
-		if fileInfo.Size() >= rw.maxSize {
-			// 关闭当前文件
-			rw.file.Close()
-
-			// 切换到另一个文件
-			rw.currentIndex = (rw.currentIndex + 1) % 2
-
-			// 清空目标文件
-			file, err := os.OpenFile(rw.filePaths[rw.currentIndex], os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-			if err != nil {
-				return err
-			}
-			rw.file = file
-		}
-		rw.count = 0
+// checkRotate 检查当前文件的时长与大小，超过阈值时轮转
+func (rw *RotateWriter) checkRotate() error {
+	if rw.maxAge > 0 && time.Since(rw.openedAt) >= rw.maxAge {
+		return rw.rotate()
 	}
-
 	rw.count++
+	if rw.count < 10 || rw.maxSize <= 0 {
+		return nil
+	}
+	rw.count = 0
+	// 检查当前文件大小
+	fileInfo, err := rw.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fileInfo.Size() >= rw.maxSize {
+		return rw.rotate()
+	}
 	return nil
 }
 
+// rotate 将 file.N-1 依次重命名为 file.N，当前文件重命名为 file.1，并打开新的文件
+func (rw *RotateWriter) rotate() error {
+	// 关闭当前文件
+	rw.file.Close()
+	_ = os.Remove(rw.backupPath(rw.maxBackups))
+	for i := rw.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(rw.backupPath(i), rw.backupPath(i+1))
+	}
+	err := os.Rename(rw.filePath, rw.backupPath(1))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, err := os.OpenFile(rw.filePath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	rw.file = file
+	rw.openedAt = time.Now()
+	rw.count = 0
+	return nil
+}
+
+func (rw *RotateWriter) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", rw.filePath, i)
+}
+
+// Sync 将日志写入磁盘
+func (rw *RotateWriter) Sync() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.file.Sync()
+}
+
 // Close 关闭 RotateWriter
 func (rw *RotateWriter) Close() error {
 	rw.mu.Lock()
@@ -0,0 +1,51 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package sqlitedb opens the SQLite databases that MoLing keeps its data in. The driver is written in Go, so that
+// MoLing still builds without cgo.
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// BusyTimeout is how long a statement waits for another process, such as a CLI command reading the database of a
+// running server, to release its lock, in milliseconds.
+const BusyTimeout = 5000
+
+// Open opens the database in file, creating it and its directory if they do not exist, and runs schema on it. The
+// schema must only create what does not exist yet. The database uses a single connection, which serializes the
+// statements of a process: rows must be closed before the next statement.
+func Open(file, schema string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of %s: %w", file, err)
+	}
+	db, err := sql.Open("sqlite", fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)", file, BusyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to prepare %s: %w", file, err)
+	}
+	return db, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package sqlitedb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data", "test.db")
+	schema := `CREATE TABLE IF NOT EXISTS notes (id INTEGER PRIMARY KEY, text TEXT NOT NULL);
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(text);`
+	db, err := Open(file, schema)
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+	if _, err = db.Exec(`INSERT INTO notes (text) VALUES ('kept')`); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening again keeps the data
+	db, err = Open(file, schema)
+	if err != nil {
+		t.Fatalf("failed to reopen the database: %v", err)
+	}
+	defer db.Close()
+	var text string
+	if err = db.QueryRow(`SELECT text FROM notes`).Scan(&text); err != nil || text != "kept" {
+		t.Fatalf("expected the saved row, got %q, %v", text, err)
+	}
+	if _, err = Open(file, "CREATE TABLE broken ("); err == nil {
+		t.Fatal("expected an error for an invalid schema")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeURLPattern validates an allowed_urls entry and returns it in the form URLAllowed expects: a host, which
+// covers its subdomains, an http(s) URL prefix, or * for any.
+func NormalizeURLPattern(entry string) (string, error) {
+	pattern := strings.TrimSpace(entry)
+	if strings.Contains(pattern, "://") {
+		u, err := url.Parse(pattern)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("allowed_urls entry %q must be a host or an http(s) URL prefix", entry)
+		}
+		u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return "", fmt.Errorf("allowed_urls entry %q must be a host or an http(s) URL prefix", entry)
+		}
+		return u.String(), nil
+	}
+	if pattern = strings.ToLower(pattern); pattern == "" || strings.ContainsAny(pattern, "/?#") {
+		return "", fmt.Errorf("allowed_urls entry %q must be a host or an http(s) URL prefix", entry)
+	}
+	return pattern, nil
+}
+
+// URLAllowed reports whether u matches one of the patterns normalized by NormalizeURLPattern.
+func URLAllowed(u *url.URL, patterns []string) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*":
+			return true
+		case strings.Contains(pattern, "://"):
+			// A prefix matches whole path segments on the same scheme and host.
+			p, err := url.Parse(pattern)
+			if err != nil || p.Scheme != strings.ToLower(u.Scheme) || p.Host != strings.ToLower(u.Host) {
+				continue
+			}
+			dir := strings.TrimSuffix(p.Path, "/")
+			if u.Path == p.Path || dir == "" || strings.HasPrefix(u.Path, dir+"/") {
+				return true
+			}
+		case host == pattern || strings.HasSuffix(host, "."+pattern):
+			return true
+		}
+	}
+	return false
+}
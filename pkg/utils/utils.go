@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"mime"
 	"net/http"
@@ -71,9 +72,12 @@ func MergeJSONToStruct(target any, jsonMap map[string]any) error {
 				if fieldVal.CanSet() {
 					// 将JSON值转换为结构体字段的类型
 					jsonVal := reflect.ValueOf(jsonValue)
+					if !jsonVal.IsValid() {
+						continue
+					}
 					if jsonVal.Type().ConvertibleTo(fieldVal.Type()) {
 						fieldVal.Set(jsonVal.Convert(fieldVal.Type()))
-					} else {
+					} else if err := remarshal(jsonValue, fieldVal.Addr().Interface()); err != nil {
 						return fmt.Errorf("type mismatch for field %s, value:%v", jsonKey, jsonValue)
 					}
 				}
@@ -83,6 +87,15 @@ func MergeJSONToStruct(target any, jsonMap map[string]any) error {
 	return nil
 }
 
+// remarshal converts composite JSON values, such as arrays of objects, by a JSON round trip.
+func remarshal(src any, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
 // DetectMimeType tries to determine the MIME type of a file
 func DetectMimeType(path string) string {
 	// First try by extension
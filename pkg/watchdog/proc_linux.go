@@ -0,0 +1,87 @@
+//go:build linux
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package watchdog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// clockTicks is the unit of the CPU times in /proc, USER_HZ, which is 100 on all Linux architectures.
+const clockTicks = 100
+
+// listProcesses reads the processes from /proc.
+func listProcesses() ([]process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+	pageSize := uint64(os.Getpagesize())
+	var all []process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		// Processes that exit while /proc is read are skipped.
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		p, ok := parseStat(pid, data, pageSize)
+		if ok {
+			all = append(all, p)
+		}
+	}
+	return all, nil
+}
+
+// parseStat parses /proc/<pid>/stat. The command name in parentheses may contain spaces, so the fields are counted
+// from its closing parenthesis.
+func parseStat(pid int, data []byte, pageSize uint64) (process, bool) {
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return process{}, false
+	}
+	// The fields after the name start with the state, field 3 of proc(5).
+	fields := bytes.Fields(data[end+1:])
+	if len(fields) < 22 {
+		return process{}, false
+	}
+	ppid, err1 := strconv.Atoi(string(fields[1]))
+	utime, err2 := strconv.ParseUint(string(fields[11]), 10, 64)
+	stime, err3 := strconv.ParseUint(string(fields[12]), 10, 64)
+	rss, err4 := strconv.ParseInt(string(fields[21]), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return process{}, false
+	}
+	if rss < 0 {
+		rss = 0
+	}
+	return process{
+		pid:  pid,
+		ppid: ppid,
+		rss:  uint64(rss) * pageSize,
+		cpu:  time.Duration(utime+stime) * time.Second / clockTicks,
+	}, true
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package watchdog measures the resources that services use: the memory and CPU of their child processes, such as
+// Chrome or yt-dlp, with the processes these start, and the disk space of their data directories.
+package watchdog
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported is returned when the processes cannot be measured on this operating system.
+var ErrUnsupported = errors.New("measuring processes is not supported on this system")
+
+// Usage is the resources a service uses.
+type Usage struct {
+	Processes   int     `json:"processes"`    // Processes is the number of measured processes, children included.
+	MemoryBytes uint64  `json:"memory_bytes"` // MemoryBytes is the resident memory of the processes.
+	CPUPercent  float64 `json:"cpu_percent"`  // CPUPercent is the CPU the processes used since the last measurement, in percent of one core.
+	DiskBytes   int64   `json:"disk_bytes"`   // DiskBytes is the size of the files in the data directory.
+}
+
+// process is a measured process.
+type process struct {
+	pid  int
+	ppid int
+	rss  uint64        // rss is the resident memory in bytes.
+	cpu  time.Duration // cpu is the CPU time the process used since it started.
+}
+
+// cpuSample is the CPU time of the processes of a key at some point.
+type cpuSample struct {
+	at  time.Time
+	cpu time.Duration
+}
+
+// Meter measures usage. It remembers the CPU time of every key, so that the CPU of the next measurement is the
+// share of the time in between.
+type Meter struct {
+	lock sync.Mutex
+	last map[string]cpuSample
+	list func() ([]process, error) // list returns all processes of the system.
+}
+
+// NewMeter creates a Meter.
+func NewMeter() *Meter {
+	return &Meter{last: make(map[string]cpuSample), list: listProcesses}
+}
+
+// Processes measures the processes with the given IDs and all their descendants. The first measurement of a key
+// reports no CPU. It returns ErrUnsupported on systems where processes cannot be measured.
+func (m *Meter) Processes(key string, pids []int) (Usage, error) {
+	var u Usage
+	if len(pids) == 0 {
+		m.lock.Lock()
+		delete(m.last, key)
+		m.lock.Unlock()
+		return u, nil
+	}
+	all, err := m.list()
+	if err != nil {
+		return u, err
+	}
+	var cpu time.Duration
+	for _, p := range tree(all, pids) {
+		u.Processes++
+		u.MemoryBytes += p.rss
+		cpu += p.cpu
+	}
+	now := time.Now()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if last, ok := m.last[key]; ok && now.After(last.at) && cpu >= last.cpu {
+		u.CPUPercent = float64(cpu-last.cpu) / float64(now.Sub(last.at)) * 100
+	}
+	m.last[key] = cpuSample{at: now, cpu: cpu}
+	return u, nil
+}
+
+// tree returns the processes with the given IDs and their descendants.
+func tree(all []process, pids []int) []process {
+	children := make(map[int][]int)
+	byPID := make(map[int]process, len(all))
+	for _, p := range all {
+		byPID[p.pid] = p
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+	var result []process
+	seen := make(map[int]bool)
+	queue := append([]int(nil), pids...)
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		p, ok := byPID[pid]
+		if !ok || seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		result = append(result, p)
+		queue = append(queue, children[pid]...)
+	}
+	return result
+}
+
+// DirSize returns the size of the regular files in dir and its subdirectories, 0 if dir does not exist.
+// Files that disappear while it walks are skipped.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package watchdog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMeterProcesses(t *testing.T) {
+	// Chrome (10) starts two renderers (11, 12), 12 starts a helper (13). Process 20 is not ours.
+	all := []process{
+		{pid: 1, ppid: 0, rss: 1 << 20},
+		{pid: 10, ppid: 1, rss: 100 << 20, cpu: time.Second},
+		{pid: 11, ppid: 10, rss: 50 << 20, cpu: time.Second},
+		{pid: 12, ppid: 10, rss: 50 << 20},
+		{pid: 13, ppid: 12, rss: 10 << 20},
+		{pid: 20, ppid: 1, rss: 500 << 20, cpu: time.Hour},
+	}
+	m := NewMeter()
+	m.list = func() ([]process, error) { return all, nil }
+	u, err := m.Processes("Browser", []int{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Processes != 4 || u.MemoryBytes != 210<<20 || u.CPUPercent != 0 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+
+	m.last["Browser"] = cpuSample{at: time.Now().Add(-10 * time.Second), cpu: time.Second}
+	u, err = m.Processes("Browser", []int{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.CPUPercent < 9 || u.CPUPercent > 10.1 {
+		t.Fatalf("expected about 10%% CPU, got %+v", u)
+	}
+
+	if u, _ = m.Processes("Browser", nil); u.Processes != 0 {
+		t.Fatalf("unexpected usage without processes %+v", u)
+	}
+	if _, ok := m.last["Browser"]; ok {
+		t.Fatal("the CPU sample must be forgotten once the processes are gone")
+	}
+}
+
+func TestMeterOwnProcess(t *testing.T) {
+	u, err := NewMeter().Processes("self", []int{os.Getpid()})
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("expected ErrUnsupported, got %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Processes < 1 || u.MemoryBytes == 0 {
+		t.Fatalf("unexpected usage of the test process %+v", u)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "one"), make([]byte, 100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "b", "two"), make([]byte, 50), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := DirSize(dir); err != nil || size != 150 {
+		t.Fatalf("expected 150 bytes, got %d %v", size, err)
+	}
+	if size, err := DirSize(filepath.Join(dir, "missing")); err != nil || size != 0 {
+		t.Fatalf("a missing directory must be empty, got %d %v", size, err)
+	}
+}